@@ -0,0 +1,103 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/hashicorp/vault/helper/consts"
+)
+
+const (
+	ErrOutputPolicyRequest = "output a policy, please"
+)
+
+var (
+	LastOutputPolicyError *OutputPolicyError
+)
+
+// OutputPolicyError is returned instead of sending a request when the client
+// outputs policies. It gives the ACL policy allowing the request.
+type OutputPolicyError struct {
+	method          string
+	path            string
+	parsingError    error
+	parsedHCLString string
+}
+
+func (d *OutputPolicyError) Error() string {
+	if d.parsedHCLString == "" {
+		d.parseRequest()
+		if d.parsingError != nil {
+			return d.parsingError.Error()
+		}
+	}
+
+	return ErrOutputPolicyRequest
+}
+
+func (d *OutputPolicyError) parseRequest() {
+	capabilities, err := requestCapabilities(d.method)
+	if err != nil {
+		d.parsingError = err
+		return
+	}
+
+	d.parsedHCLString = fmt.Sprintf("path %q {\n  capabilities = [\"%s\"]\n}", d.path, strings.Join(capabilities, `", "`))
+}
+
+// HCLString returns the ACL policy allowing the request, in HCL
+func (d *OutputPolicyError) HCLString() string {
+	if d.parsedHCLString == "" {
+		d.parseRequest()
+	}
+	return d.parsedHCLString
+}
+
+// newOutputPolicyError returns the error output instead of sending the
+// request. The policy path is relative to the root namespace, as policies are
+// usually written there.
+func newOutputPolicyError(r *Request) *OutputPolicyError {
+	method := r.Method
+	if method == "GET" && r.Params.Get("list") == "true" {
+		method = "LIST"
+	}
+
+	requestPath := r.URL.Path
+	if idx := strings.Index(requestPath, "/v1/"); idx != -1 {
+		requestPath = requestPath[idx+len("/v1/"):]
+	}
+	// Lists are authorized against the paths with a trailing slash
+	if method == "LIST" && !strings.HasSuffix(requestPath, "/") {
+		requestPath += "/"
+	}
+	if r.Headers != nil {
+		if ns := strings.Trim(r.Headers.Get(consts.NamespaceHeaderName), "/"); ns != "" {
+			requestPath = ns + "/" + requestPath
+		}
+	}
+
+	return &OutputPolicyError{
+		method: method,
+		path:   requestPath,
+	}
+}
+
+// requestCapabilities returns the capabilities needed for the requests with
+// the method
+func requestCapabilities(method string) ([]string, error) {
+	switch method {
+	case http.MethodGet, http.MethodHead:
+		return []string{"read"}, nil
+	case "LIST":
+		return []string{"list"}, nil
+	case http.MethodPost, http.MethodPut:
+		return []string{"create", "update"}, nil
+	case http.MethodPatch:
+		return []string{"update"}, nil
+	case http.MethodDelete:
+		return []string{"delete"}, nil
+	default:
+		return nil, fmt.Errorf("unsupported request method %q", method)
+	}
+}
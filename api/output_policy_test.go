@@ -0,0 +1,42 @@
+package api
+
+import (
+	"testing"
+)
+
+func TestOutputPolicy(t *testing.T) {
+	config := DefaultConfig()
+	config.Address = "http://127.0.0.1:8200"
+	config.OutputPolicy = true
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectPolicy := func(err error, expected string) {
+		t.Helper()
+		policyErr, ok := err.(*OutputPolicyError)
+		if !ok {
+			t.Fatalf("expected an output policy error, got: %v", err)
+		}
+		if policyErr.Error() != ErrOutputPolicyRequest {
+			t.Fatalf("unexpected error: %v", policyErr)
+		}
+		if policy := policyErr.HCLString(); policy != expected {
+			t.Fatalf("expected policy:\n%s\ngot:\n%s", expected, policy)
+		}
+	}
+
+	_, err = client.Logical().Read("secret/foo")
+	expectPolicy(err, "path \"secret/foo\" {\n  capabilities = [\"read\"]\n}")
+
+	_, err = client.Logical().List("secret/")
+	expectPolicy(err, "path \"secret/\" {\n  capabilities = [\"list\"]\n}")
+
+	_, err = client.Logical().Write("secret/foo", map[string]interface{}{"foo": "bar"})
+	expectPolicy(err, "path \"secret/foo\" {\n  capabilities = [\"create\", \"update\"]\n}")
+
+	client.SetNamespace("ns1/")
+	_, err = client.Logical().Delete("secret/foo")
+	expectPolicy(err, "path \"ns1/secret/foo\" {\n  capabilities = [\"delete\"]\n}")
+}
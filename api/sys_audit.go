@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/hashicorp/errwrap"
 	"github.com/mitchellh/mapstructure"
 )
 
@@ -46,6 +47,50 @@ func (c *Sys) AuditHash(path string, input string) (string, error) {
 	return hashStr, nil
 }
 
+// AuditHashes returns the hashes of the inputs with the audit device at path,
+// in the same order, in one request.
+func (c *Sys) AuditHashes(path string, inputs []string) ([]string, error) {
+	body := map[string]interface{}{
+		"inputs": inputs,
+	}
+
+	r := c.c.NewRequest("PUT", fmt.Sprintf("/v1/sys/audit-hash/%s", path))
+	if err := r.SetJSONBody(body); err != nil {
+		return nil, err
+	}
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	secret, err := ParseSecret(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, errors.New("data from server response is empty")
+	}
+
+	hashesRaw, ok := secret.Data["hashes"]
+	if !ok {
+		return nil, errors.New("hashes not found in response data")
+	}
+
+	var hashes []string
+	if err := mapstructure.Decode(hashesRaw, &hashes); err != nil {
+		return nil, errwrap.Wrapf("could not parse hashes in response data: {{err}}", err)
+	}
+	if len(hashes) != len(inputs) {
+		return nil, fmt.Errorf("expected %d hashes in response data, got %d", len(inputs), len(hashes))
+	}
+
+	return hashes, nil
+}
+
 func (c *Sys) ListAudit() (map[string]*Audit, error) {
 	r := c.c.NewRequest("GET", "/v1/sys/audit")
 
@@ -38,4 +38,13 @@ type HealthResponse struct {
 	ClusterName                string `json:"cluster_name,omitempty"`
 	ClusterID                  string `json:"cluster_id,omitempty"`
 	LastWAL                    uint64 `json:"last_wal,omitempty"`
+
+	Storage *StorageProbeResponse `json:"storage,omitempty"`
+}
+
+type StorageProbeResponse struct {
+	Healthy        bool    `json:"healthy"`
+	ReadLatencyMs  float64 `json:"read_latency_ms"`
+	WriteLatencyMs float64 `json:"write_latency_ms,omitempty"`
+	Error          string  `json:"error,omitempty"`
 }
@@ -0,0 +1,38 @@
+package api
+
+import (
+	"context"
+	"errors"
+)
+
+// LicenseStatus returns the feature modules compiled into the build of the
+// server
+func (c *Sys) LicenseStatus() (*LicenseStatusResponse, error) {
+	r := c.c.NewRequest("GET", "/v1/sys/license/status")
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Data *LicenseStatusResponse `json:"data"`
+	}
+	if err := resp.DecodeJSON(&result); err != nil {
+		return nil, err
+	}
+	if result.Data == nil {
+		return nil, errors.New("data from server response is empty")
+	}
+	return result.Data, nil
+}
+
+type LicenseStatusResponse struct {
+	Version         string          `json:"version"`
+	VersionMetadata string          `json:"version_metadata"`
+	Features        []string        `json:"features"`
+	Modules         map[string]bool `json:"modules"`
+}
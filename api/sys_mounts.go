@@ -85,6 +85,30 @@ func (c *Sys) Remount(from, to string) error {
 	return err
 }
 
+// MigrateToDatabase converts the cassandra, mongodb or mssql secrets engine
+// mounted at the given path into a database secrets engine. The connection
+// name defaults to the type of the mount if empty.
+func (c *Sys) MigrateToDatabase(path, connName string) (*Secret, error) {
+	body := map[string]interface{}{
+		"connection_name": connName,
+	}
+
+	r := c.c.NewRequest("POST", fmt.Sprintf("/v1/sys/mounts/%s/migrate-to-database", path))
+	if err := r.SetJSONBody(body); err != nil {
+		return nil, err
+	}
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return ParseSecret(resp.Body)
+}
+
 func (c *Sys) TuneMount(path string, config MountConfigInput) error {
 	r := c.c.NewRequest("POST", fmt.Sprintf("/v1/sys/mounts/%s/tune", path))
 	if err := r.SetJSONBody(config); err != nil {
@@ -153,6 +177,13 @@ type MountConfigInput struct {
 	PassthroughRequestHeaders []string          `json:"passthrough_request_headers,omitempty" mapstructure:"passthrough_request_headers"`
 	AllowedResponseHeaders    []string          `json:"allowed_response_headers,omitempty" mapstructure:"allowed_response_headers"`
 	TokenType                 string            `json:"token_type,omitempty" mapstructure:"token_type"`
+	LoginRateLimit            *int              `json:"login_rate_limit,omitempty" mapstructure:"login_rate_limit"`
+	LoginRateLimitPeriod      string            `json:"login_rate_limit_period,omitempty" mapstructure:"login_rate_limit_period"`
+	UserLockoutThreshold      *int              `json:"user_lockout_threshold,omitempty" mapstructure:"user_lockout_threshold"`
+	UserLockoutDuration       string            `json:"user_lockout_duration,omitempty" mapstructure:"user_lockout_duration"`
+	UserLockoutCounterReset   string            `json:"user_lockout_counter_reset,omitempty" mapstructure:"user_lockout_counter_reset"`
+	AllowedAliasMetadataKeys  []string          `json:"allowed_alias_metadata_keys,omitempty" mapstructure:"allowed_alias_metadata_keys"`
+	RequestConcurrencyLimit   *int              `json:"request_concurrency_limit,omitempty" mapstructure:"request_concurrency_limit"`
 
 	// Deprecated: This field will always be blank for newer server responses.
 	PluginName string `json:"plugin_name,omitempty" mapstructure:"plugin_name"`
@@ -166,6 +197,10 @@ type MountOutput struct {
 	Options     map[string]string `json:"options"`
 	Local       bool              `json:"local"`
 	SealWrap    bool              `json:"seal_wrap" mapstructure:"seal_wrap"`
+
+	// DeprecationStatus is the deprecation status of the builtin plugin of
+	// the mount, and empty if it isn't a builtin plugin.
+	DeprecationStatus string `json:"deprecation_status,omitempty" mapstructure:"deprecation_status"`
 }
 
 type MountConfigOutput struct {
@@ -178,6 +213,13 @@ type MountConfigOutput struct {
 	PassthroughRequestHeaders []string `json:"passthrough_request_headers,omitempty" mapstructure:"passthrough_request_headers"`
 	AllowedResponseHeaders    []string `json:"allowed_response_headers,omitempty" mapstructure:"allowed_response_headers"`
 	TokenType                 string   `json:"token_type,omitempty" mapstructure:"token_type"`
+	LoginRateLimit            int      `json:"login_rate_limit,omitempty" mapstructure:"login_rate_limit"`
+	LoginRateLimitPeriod      int      `json:"login_rate_limit_period,omitempty" mapstructure:"login_rate_limit_period"`
+	UserLockoutThreshold      int      `json:"user_lockout_threshold,omitempty" mapstructure:"user_lockout_threshold"`
+	UserLockoutDuration       int      `json:"user_lockout_duration,omitempty" mapstructure:"user_lockout_duration"`
+	UserLockoutCounterReset   int      `json:"user_lockout_counter_reset,omitempty" mapstructure:"user_lockout_counter_reset"`
+	AllowedAliasMetadataKeys  []string `json:"allowed_alias_metadata_keys,omitempty" mapstructure:"allowed_alias_metadata_keys"`
+	RequestConcurrencyLimit   int      `json:"request_concurrency_limit,omitempty" mapstructure:"request_concurrency_limit"`
 
 	// Deprecated: This field will always be blank for newer server responses.
 	PluginName string `json:"plugin_name,omitempty" mapstructure:"plugin_name"`
@@ -0,0 +1,33 @@
+package api
+
+import "context"
+
+// OpenAPI returns the OpenAPI document generated from the path schemas of
+// all mounted backends the client's token is allowed to see. If operationCtx
+// is non-empty it is appended to every generated operationId.
+func (c *Sys) OpenAPI(operationCtx string) (*OpenAPIDocument, error) {
+	r := c.c.NewRequest("GET", "/v1/sys/internal/specs/openapi")
+	if operationCtx != "" {
+		r.Params.Set("context", operationCtx)
+	}
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result OpenAPIDocument
+	err = resp.DecodeJSON(&result)
+	return &result, err
+}
+
+// OpenAPIDocument is an OpenAPI 3 document. Paths are left undecoded so that
+// callers can hand them to the OpenAPI tooling of their choice.
+type OpenAPIDocument struct {
+	Version string                            `json:"openapi"`
+	Info    map[string]interface{}            `json:"info"`
+	Paths   map[string]map[string]interface{} `json:"paths"`
+}
@@ -77,6 +77,8 @@ type SealStatusResponse struct {
 	ClusterName  string `json:"cluster_name,omitempty"`
 	ClusterID    string `json:"cluster_id,omitempty"`
 	RecoverySeal bool   `json:"recovery_seal"`
+
+	Storage *StorageProbeResponse `json:"storage,omitempty"`
 }
 
 type UnsealOpts struct {
@@ -94,10 +94,24 @@ func Backend(conf *logical.BackendConfig) (*backend, error) {
 
 	// Attach the paths and secrets that are to be handled by the backend
 	b.Backend = &framework.Backend{
-		// Register a periodic function that deletes the expired SecretID entries
-		PeriodicFunc: b.periodicFunc,
-		Help:         backendHelp,
-		AuthRenew:    b.pathLoginRenew,
+		// Register the periodic functions that delete the expired SecretID
+		// entries and rotate the current SecretIDs
+		ScheduledFuncs: []*framework.ScheduledFunc{
+			{
+				Name:            "tidy_secret_id",
+				Description:     "Deletes the expired SecretID entries.",
+				DefaultSchedule: "1m",
+				Func:            b.periodicTidySecretID,
+			},
+			{
+				Name:            "rotate_secret_id",
+				Description:     "Rotates the current SecretIDs of the roles with a rotation period.",
+				DefaultSchedule: "1m",
+				Func:            b.periodicRotateSecretIDs,
+			},
+		},
+		Help:      backendHelp,
+		AuthRenew: b.pathLoginRenew,
 		PathsSpecial: &logical.Paths{
 			Unauthenticated: []string{
 				"login",
@@ -105,11 +119,13 @@ func Backend(conf *logical.BackendConfig) (*backend, error) {
 			LocalStorage: []string{
 				secretIDLocalPrefix,
 				secretIDAccessorLocalPrefix,
+				secretIDRotationLocalPrefix,
 			},
 		},
 		Paths: framework.PathAppend(
 			rolePaths(b),
 			[]*framework.Path{
+				pathRoleSecretIDCurrent(b),
 				pathLogin(b),
 				pathTidySecretID(b),
 			},
@@ -152,19 +168,26 @@ func (b *backend) invalidate(_ context.Context, key string) {
 	}
 }
 
-// periodicFunc of the backend will be invoked once a minute by the RollbackManager.
-// RoleRole backend utilizes this function to delete expired SecretID entries.
-// This could mean that the SecretID may live in the backend upto 1 min after its
-// expiration. The deletion of SecretIDs are not security sensitive and it is okay
-// to delay the removal of SecretIDs by a minute.
-func (b *backend) periodicFunc(ctx context.Context, req *logical.Request) error {
-	// Initiate clean-up of expired SecretID entries
+// periodicTidySecretID deletes the expired SecretID entries. It runs once a
+// minute by default, which could mean that the SecretID may live in the
+// backend upto 1 min after its expiration. The deletion of SecretIDs are not
+// security sensitive and it is okay to delay the removal of SecretIDs.
+func (b *backend) periodicTidySecretID(ctx context.Context, req *logical.Request) error {
 	if b.System().LocalMount() || !b.System().ReplicationState().HasState(consts.ReplicationPerformanceSecondary|consts.ReplicationPerformanceStandby) {
 		b.tidySecretID(ctx, req)
 	}
 	return nil
 }
 
+// periodicRotateSecretIDs rotates the current SecretIDs of the roles with a
+// rotation period which are due. It runs once a minute by default.
+func (b *backend) periodicRotateSecretIDs(ctx context.Context, req *logical.Request) error {
+	if b.System().LocalMount() || !b.System().ReplicationState().HasState(consts.ReplicationPerformanceSecondary|consts.ReplicationPerformanceStandby) {
+		return b.rotateSecretIDs(ctx, req.Storage)
+	}
+	return nil
+}
+
 const backendHelp = `
 Any registered Role can authenticate itself with Vault. The credentials
 depends on the constraints that are set on the Role. One common required
@@ -30,6 +30,7 @@ func pathLogin(b *backend) *framework.Path {
 		Callbacks: map[logical.Operation]framework.OperationFunc{
 			logical.UpdateOperation:         b.pathLoginUpdate,
 			logical.AliasLookaheadOperation: b.pathLoginUpdateAliasLookahead,
+			logical.ResolveRoleOperation:    b.pathLoginResolveRole,
 		},
 		HelpSynopsis:    pathLoginHelpSys,
 		HelpDescription: pathLoginHelpDesc,
@@ -51,6 +52,24 @@ func (b *backend) pathLoginUpdateAliasLookahead(ctx context.Context, req *logica
 	}, nil
 }
 
+// pathLoginResolveRole returns the name of the role the role ID belongs to
+func (b *backend) pathLoginResolveRole(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	roleID := strings.TrimSpace(data.Get("role_id").(string))
+	if roleID == "" {
+		return logical.ErrorResponse("missing role_id"), nil
+	}
+
+	roleIDIndex, err := b.roleIDEntry(ctx, req.Storage, roleID)
+	if err != nil {
+		return nil, err
+	}
+	if roleIDIndex == nil {
+		return logical.ErrorResponse("invalid role ID"), nil
+	}
+
+	return logical.ResolveRoleResponse(roleIDIndex.Name)
+}
+
 // Returns the Auth object indicating the authentication and authorization information
 // if the credentials provided are validated by the backend.
 func (b *backend) pathLoginUpdate(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
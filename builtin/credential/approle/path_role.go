@@ -87,6 +87,19 @@ type roleStorageEntry struct {
 
 	// TokenType is the type of token to generate
 	TokenType string `json:"token_type" mapstructure:"token_type"`
+
+	// SecretIDMetadataSchema, if set, maps the metadata keys allowed on the
+	// SecretIDs to the regular expressions their values must match. An empty
+	// expression allows any value.
+	SecretIDMetadataSchema map[string]string `json:"secret_id_metadata_schema" mapstructure:"secret_id_metadata_schema"`
+
+	// SecretIDRequiredMetadata is the list of metadata keys that every
+	// SecretID must have
+	SecretIDRequiredMetadata []string `json:"secret_id_required_metadata" mapstructure:"secret_id_required_metadata"`
+
+	// SecretIDRotationPeriod, if set, is the duration after which the
+	// current SecretID of the role is rotated by the backend
+	SecretIDRotationPeriod time.Duration `json:"secret_id_rotation_period" mapstructure:"secret_id_rotation_period"`
 }
 
 // roleIDStorageEntry represents the reverse mapping from RoleID to Role
@@ -111,6 +124,7 @@ type roleIDStorageEntry struct {
 // role/<role_name>/role-id - For fetching the role_id of an role
 // role/<role_name>/secret-id - For issuing a secret_id against an role, also to list the secret_id_accessors
 // role/<role_name>/custom-secret-id - For assigning a custom SecretID against an role
+// role/<role_name>/secret-id/current - For reading the current rotated secret_id of an role
 // role/<role_name>/secret-id/lookup - For reading the properties of a secret_id
 // role/<role_name>/secret-id/destroy - For deleting a secret_id
 // role/<role_name>/secret-id-accessor/lookup - For reading secret_id using accessor
@@ -204,6 +218,24 @@ can only be set during role creation and once set, it can't be reset later.`,
 					Default:     "default",
 					Description: `The type of token to generate ("service" or "batch"), or "default" to use the default`,
 				},
+				"secret_id_metadata_schema": &framework.FieldSchema{
+					Type: framework.TypeMap,
+					Description: `Map of the metadata keys allowed on the SecretIDs to the regular
+expressions their whole values must match. An empty expression allows any
+value. If set, SecretIDs with other metadata keys are refused.`,
+				},
+				"secret_id_required_metadata": &framework.FieldSchema{
+					Type: framework.TypeCommaStringSlice,
+					Description: `Comma separated string or list of the metadata keys that every
+SecretID must have.`,
+				},
+				"secret_id_rotation_period": &framework.FieldSchema{
+					Type: framework.TypeDurationSecond,
+					Description: `Duration in seconds after which the current SecretID of the role,
+read from 'role/<role_name>/secret-id/current', is rotated. The SecretID it
+replaces remains valid until the next rotation. Defaults to 0, in which case
+SecretIDs aren't rotated.`,
+				},
 			},
 			ExistenceCheck: b.pathRoleExistenceCheck,
 			Callbacks: map[logical.Operation]framework.OperationFunc{
@@ -1039,6 +1071,37 @@ func (b *backend) pathRoleCreateUpdate(ctx context.Context, req *logical.Request
 		}
 	}
 
+	if schemaRaw, ok := data.GetOk("secret_id_metadata_schema"); ok {
+		schema, err := parseSecretIDMetadataSchema(schemaRaw.(map[string]interface{}))
+		if err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+		role.SecretIDMetadataSchema = schema
+	}
+
+	if requiredRaw, ok := data.GetOk("secret_id_required_metadata"); ok {
+		role.SecretIDRequiredMetadata = strutil.RemoveDuplicates(requiredRaw.([]string), false)
+	}
+
+	if rotationPeriodRaw, ok := data.GetOk("secret_id_rotation_period"); ok {
+		role.SecretIDRotationPeriod = time.Second * time.Duration(rotationPeriodRaw.(int))
+	}
+	if role.SecretIDRotationPeriod < 0 {
+		return logical.ErrorResponse("secret_id_rotation_period cannot be negative"), nil
+	}
+	// The SecretID replaced by a rotation must remain valid until the next one
+	if role.SecretIDRotationPeriod != 0 && role.SecretIDTTL != 0 && role.SecretIDTTL < 2*role.SecretIDRotationPeriod {
+		return logical.ErrorResponse("secret_id_ttl must be at least twice secret_id_rotation_period"), nil
+	}
+
+	if len(role.SecretIDMetadataSchema) != 0 {
+		for _, key := range role.SecretIDRequiredMetadata {
+			if _, ok := role.SecretIDMetadataSchema[key]; !ok {
+				return logical.ErrorResponse(fmt.Sprintf("required metadata key %q is not in secret_id_metadata_schema", key)), nil
+			}
+		}
+	}
+
 	// Check that the TokenTTL value provided is less than the TokenMaxTTL.
 	// Sanitizing the TTL and MaxTTL is not required now and can be performed
 	// at credential issue time.
@@ -1082,18 +1145,21 @@ func (b *backend) pathRoleRead(ctx context.Context, req *logical.Request, data *
 		"bind_secret_id": role.BindSecretID,
 		// TODO - remove this deprecated field in future versions,
 		// and its associated warning below.
-		"bound_cidr_list":       role.SecretIDBoundCIDRs,
-		"secret_id_bound_cidrs": role.SecretIDBoundCIDRs,
-		"token_bound_cidrs":     role.TokenBoundCIDRs,
-		"period":                role.Period / time.Second,
-		"policies":              role.Policies,
-		"secret_id_num_uses":    role.SecretIDNumUses,
-		"secret_id_ttl":         role.SecretIDTTL / time.Second,
-		"token_max_ttl":         role.TokenMaxTTL / time.Second,
-		"token_num_uses":        role.TokenNumUses,
-		"token_ttl":             role.TokenTTL / time.Second,
-		"local_secret_ids":      false,
-		"token_type":            role.TokenType,
+		"bound_cidr_list":             role.SecretIDBoundCIDRs,
+		"secret_id_bound_cidrs":       role.SecretIDBoundCIDRs,
+		"token_bound_cidrs":           role.TokenBoundCIDRs,
+		"period":                      role.Period / time.Second,
+		"policies":                    role.Policies,
+		"secret_id_num_uses":          role.SecretIDNumUses,
+		"secret_id_ttl":               role.SecretIDTTL / time.Second,
+		"token_max_ttl":               role.TokenMaxTTL / time.Second,
+		"token_num_uses":              role.TokenNumUses,
+		"token_ttl":                   role.TokenTTL / time.Second,
+		"local_secret_ids":            false,
+		"token_type":                  role.TokenType,
+		"secret_id_metadata_schema":   role.SecretIDMetadataSchema,
+		"secret_id_required_metadata": role.SecretIDRequiredMetadata,
+		"secret_id_rotation_period":   role.SecretIDRotationPeriod / time.Second,
 	}
 
 	if role.SecretIDPrefix == secretIDLocalPrefix {
@@ -1172,6 +1238,11 @@ func (b *backend) pathRoleDelete(ctx context.Context, req *logical.Request, data
 		return nil, errwrap.Wrapf(fmt.Sprintf("failed to invalidate the secrets belonging to role %q: {{err}}", role.name), err)
 	}
 
+	// Delete the current SecretID of the role, destroyed above
+	if err = req.Storage.Delete(ctx, secretIDRotationEntryIndex(role)); err != nil {
+		return nil, err
+	}
+
 	// Delete the reverse mapping from RoleID to the role
 	if err = b.roleIDEntryDelete(ctx, req.Storage, role.RoleID); err != nil {
 		return nil, errwrap.Wrapf(fmt.Sprintf("failed to delete the mapping from RoleID to role %q: {{err}}", role.name), err)
@@ -2354,6 +2425,12 @@ func (b *backend) handleRoleSecretIDCommon(ctx context.Context, req *logical.Req
 		return logical.ErrorResponse(fmt.Sprintf("failed to parse metadata: %v", err)), nil
 	}
 
+	// Ensure that the metadata on the secret ID complies with the schema of
+	// the role
+	if err := verifySecretIDMetadata(role, secretIDStorage.Metadata); err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
 	if secretIDStorage, err = b.registerSecretIDEntry(ctx, req.Storage, role.name, secretID, role.HMACKey, role.SecretIDPrefix, secretIDStorage); err != nil {
 		return nil, errwrap.Wrapf("failed to store secret_id: {{err}}", err)
 	}
@@ -2366,6 +2443,33 @@ func (b *backend) handleRoleSecretIDCommon(ctx context.Context, req *logical.Req
 	}, nil
 }
 
+// parseSecretIDMetadataSchema parses the metadata schema of a role, validating
+// the regular expressions of the metadata keys
+func parseSecretIDMetadataSchema(raw map[string]interface{}) (map[string]string, error) {
+	schema := make(map[string]string, len(raw))
+	for key, patternRaw := range raw {
+		if key == "" {
+			return nil, fmt.Errorf("metadata keys cannot be empty")
+		}
+		// The role name is always added to the token metadata on login
+		if key == "role_name" {
+			return nil, fmt.Errorf("metadata key %q is reserved", key)
+		}
+
+		pattern, ok := patternRaw.(string)
+		if !ok {
+			return nil, fmt.Errorf("pattern of metadata key %q is not a string", key)
+		}
+		if _, err := metadataValueRegexp(pattern); err != nil {
+			return nil, fmt.Errorf("invalid pattern for metadata key %q: %v", key, err)
+		}
+
+		schema[key] = pattern
+	}
+
+	return schema, nil
+}
+
 func (b *backend) roleIDLock(roleID string) *locksutil.LockEntry {
 	return locksutil.LockForKey(b.roleIDLocks, roleID)
 }
@@ -2568,6 +2672,14 @@ just this role and none else. The properties of this SecretID will be
 based on the options set on the role. It will expire after a period
 defined by the 'secret_id_ttl' option on the role and/or the backend
 mount's maximum TTL value.`,
+	},
+	"role-secret-id-current": {
+		"Read the current SecretID of the role, rotated by the backend.",
+		`If the 'secret_id_rotation_period' option is set on the role, the
+backend generates a SecretID for the role on the first read of this endpoint,
+and rotates it every period. The response is always wrapped. The SecretID
+replaced by a rotation remains valid until the next rotation, so that clients
+have time to read the current SecretID.`,
 	},
 	"role-custom-secret-id": {
 		"Assign a SecretID of choice against the role.",
@@ -611,6 +611,122 @@ func TestAppRole_CIDRSubset(t *testing.T) {
 	}
 }
 
+func TestAppRole_SecretIDMetadataSchema(t *testing.T) {
+	var resp *logical.Response
+	var err error
+
+	b, storage := createBackendWithStorage(t)
+
+	roleReq := &logical.Request{
+		Operation: logical.CreateOperation,
+		Path:      "role/testrole1",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"policies": "a,b",
+			"secret_id_metadata_schema": map[string]interface{}{
+				"role_name": "",
+			},
+		},
+	}
+
+	// The role name is reserved
+	resp, err = b.HandleRequest(context.Background(), roleReq)
+	if err != nil || resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error: err: %v resp: %#v", err, resp)
+	}
+
+	roleReq.Data["secret_id_metadata_schema"] = map[string]interface{}{
+		"build_id": "[0-9]+",
+		"pipeline": "",
+	}
+	roleReq.Data["secret_id_required_metadata"] = "build_id,commit"
+	resp, err = b.HandleRequest(context.Background(), roleReq)
+	if err != nil || resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error: err: %v resp: %#v", err, resp)
+	}
+
+	roleReq.Data["secret_id_required_metadata"] = "build_id"
+	resp, err = b.HandleRequest(context.Background(), roleReq)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err: %v resp: %#v", err, resp)
+	}
+
+	roleReq.Operation = logical.ReadOperation
+	resp, err = b.HandleRequest(context.Background(), roleReq)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err: %v resp: %#v", err, resp)
+	}
+	if !reflect.DeepEqual(resp.Data["secret_id_metadata_schema"], map[string]string{"build_id": "[0-9]+", "pipeline": ""}) {
+		t.Fatalf("bad: %#v", resp.Data["secret_id_metadata_schema"])
+	}
+	if !reflect.DeepEqual(resp.Data["secret_id_required_metadata"], []string{"build_id"}) {
+		t.Fatalf("bad: %#v", resp.Data["secret_id_required_metadata"])
+	}
+
+	secretIDReq := &logical.Request{
+		Operation: logical.UpdateOperation,
+		Storage:   storage,
+		Path:      "role/testrole1/secret-id",
+	}
+
+	for _, metadata := range []string{
+		`{"pipeline": "release"}`,
+		`{"build_id": "12a"}`,
+		`{"build_id": "12", "commit": "abc"}`,
+	} {
+		secretIDReq.Data = map[string]interface{}{
+			"metadata": metadata,
+		}
+		resp, err = b.HandleRequest(context.Background(), secretIDReq)
+		if err != nil || resp == nil || !resp.IsError() {
+			t.Fatalf("expected an error for metadata %s: err: %v resp: %#v", metadata, err, resp)
+		}
+	}
+
+	secretIDReq.Data = map[string]interface{}{
+		"metadata":  `{"build_id": "12", "pipeline": "release"}`,
+		"cidr_list": "127.0.0.1/32",
+	}
+	resp, err = b.HandleRequest(context.Background(), secretIDReq)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err: %v resp: %#v", err, resp)
+	}
+	secretID := resp.Data["secret_id"].(string)
+
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.ReadOperation,
+		Storage:   storage,
+		Path:      "role/testrole1/role-id",
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err: %v resp: %#v", err, resp)
+	}
+	roleID := resp.Data["role_id"].(string)
+
+	// The metadata of the secret ID is attached to the token
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Storage:   storage,
+		Path:      "login",
+		Data: map[string]interface{}{
+			"role_id":   roleID,
+			"secret_id": secretID,
+		},
+		Connection: &logical.Connection{RemoteAddr: "127.0.0.1"},
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err: %v resp: %#v", err, resp)
+	}
+	expected := map[string]string{
+		"build_id":  "12",
+		"pipeline":  "release",
+		"role_name": "testrole1",
+	}
+	if !reflect.DeepEqual(resp.Auth.Metadata, expected) {
+		t.Fatalf("bad: %#v", resp.Auth.Metadata)
+	}
+}
+
 func TestAppRole_RoleConstraints(t *testing.T) {
 	var resp *logical.Response
 	var err error
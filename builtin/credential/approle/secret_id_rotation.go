@@ -0,0 +1,251 @@
+package approle
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	uuid "github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/helper/wrapping"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+const (
+	secretIDRotationPrefix      = "secret_id_rotation/"
+	secretIDRotationLocalPrefix = "secret_id_rotation_local/"
+
+	// currentSecretIDWrapTTL is the TTL of the response wrapping the current
+	// SecretID of a role. Clients can request a shorter one.
+	currentSecretIDWrapTTL = 5 * time.Minute
+)
+
+// secretIDRotationStorageEntry tracks the SecretIDs rotated by the backend
+// for a role
+type secretIDRotationStorageEntry struct {
+	// The current SecretID, handed out by the 'secret-id/current' endpoint
+	SecretID         string `json:"secret_id"`
+	SecretIDAccessor string `json:"secret_id_accessor"`
+
+	// The time when the current SecretID was generated
+	RotationTime time.Time `json:"rotation_time"`
+
+	// Accessor of the SecretID replaced by the current one. It remains valid
+	// until the next rotation so that clients have time to pick up the
+	// current SecretID.
+	PreviousSecretIDAccessor string `json:"previous_secret_id_accessor"`
+}
+
+func pathRoleSecretIDCurrent(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "role/" + framework.GenericNameRegex("role_name") + "/secret-id/current$",
+		Fields: map[string]*framework.FieldSchema{
+			"role_name": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Name of the role.",
+			},
+		},
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation: b.pathRoleSecretIDCurrentRead,
+		},
+		HelpSynopsis:    strings.TrimSpace(roleHelp["role-secret-id-current"][0]),
+		HelpDescription: strings.TrimSpace(roleHelp["role-secret-id-current"][1]),
+	}
+}
+
+// secretIDRotationEntryIndex returns the storage index of the rotation entry
+// of the role, which is cluster local if the SecretIDs of the role are local
+func secretIDRotationEntryIndex(role *roleStorageEntry) string {
+	if role.SecretIDPrefix == secretIDLocalPrefix {
+		return secretIDRotationLocalPrefix + strings.ToLower(role.name)
+	}
+	return secretIDRotationPrefix + strings.ToLower(role.name)
+}
+
+func (b *backend) secretIDRotationEntry(ctx context.Context, s logical.Storage, role *roleStorageEntry) (*secretIDRotationStorageEntry, error) {
+	entry, err := s.Get(ctx, secretIDRotationEntryIndex(role))
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var result secretIDRotationStorageEntry
+	if err := entry.DecodeJSON(&result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// rotateSecretID generates a new current SecretID for the role and destroys
+// the SecretID it replaced before. The role lock must be held for writing.
+func (b *backend) rotateSecretID(ctx context.Context, s logical.Storage, role *roleStorageEntry, rotation *secretIDRotationStorageEntry) (*secretIDRotationStorageEntry, error) {
+	secretID, err := uuid.GenerateUUID()
+	if err != nil {
+		return nil, errwrap.Wrapf("failed to generate secret_id: {{err}}", err)
+	}
+
+	secretIDStorage, err := b.registerSecretIDEntry(ctx, s, role.name, secretID, role.HMACKey, role.SecretIDPrefix, &secretIDStorageEntry{
+		SecretIDNumUses: role.SecretIDNumUses,
+		SecretIDTTL:     role.SecretIDTTL,
+		Metadata:        make(map[string]string),
+	})
+	if err != nil {
+		return nil, errwrap.Wrapf("failed to store secret_id: {{err}}", err)
+	}
+
+	newRotation := &secretIDRotationStorageEntry{
+		SecretID:         secretID,
+		SecretIDAccessor: secretIDStorage.SecretIDAccessor,
+		RotationTime:     time.Now(),
+	}
+
+	if rotation != nil {
+		if rotation.PreviousSecretIDAccessor != "" {
+			if err := b.destroySecretIDByAccessor(ctx, s, role, rotation.PreviousSecretIDAccessor); err != nil {
+				return nil, err
+			}
+		}
+		newRotation.PreviousSecretIDAccessor = rotation.SecretIDAccessor
+	}
+
+	entry, err := logical.StorageEntryJSON(secretIDRotationEntryIndex(role), newRotation)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	return newRotation, nil
+}
+
+// destroySecretIDByAccessor deletes the SecretID of the role with the given
+// accessor, if it still exists
+func (b *backend) destroySecretIDByAccessor(ctx context.Context, s logical.Storage, role *roleStorageEntry, secretIDAccessor string) error {
+	accessorEntry, err := b.secretIDAccessorEntry(ctx, s, secretIDAccessor, role.SecretIDPrefix)
+	if err != nil {
+		return err
+	}
+	// The SecretID has already expired or been destroyed
+	if accessorEntry == nil {
+		return nil
+	}
+
+	roleNameHMAC, err := createHMAC(role.HMACKey, role.name)
+	if err != nil {
+		return errwrap.Wrapf("failed to create HMAC of role_name: {{err}}", err)
+	}
+
+	lock := b.secretIDLock(accessorEntry.SecretIDHMAC)
+	lock.Lock()
+	defer lock.Unlock()
+
+	if err := b.deleteSecretIDAccessorEntry(ctx, s, secretIDAccessor, role.SecretIDPrefix); err != nil {
+		return err
+	}
+
+	entryIndex := fmt.Sprintf("%s%s/%s", role.SecretIDPrefix, roleNameHMAC, accessorEntry.SecretIDHMAC)
+	if err := s.Delete(ctx, entryIndex); err != nil {
+		return errwrap.Wrapf("failed to delete secret_id: {{err}}", err)
+	}
+
+	return nil
+}
+
+// pathRoleSecretIDCurrentRead returns the current SecretID of a role with a
+// rotation period, wrapped in a response wrapping token. The SecretID is
+// rotated first if it's due.
+func (b *backend) pathRoleSecretIDCurrentRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	roleName := data.Get("role_name").(string)
+	if roleName == "" {
+		return logical.ErrorResponse("missing role_name"), nil
+	}
+
+	lock := b.roleLock(roleName)
+	lock.Lock()
+	defer lock.Unlock()
+
+	role, err := b.roleEntry(ctx, req.Storage, roleName)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return logical.ErrorResponse(fmt.Sprintf("role %q does not exist", roleName)), logical.ErrUnsupportedPath
+	}
+
+	if !role.BindSecretID {
+		return logical.ErrorResponse("bind_secret_id is not set on the role"), nil
+	}
+	if role.SecretIDRotationPeriod == 0 {
+		return logical.ErrorResponse("secret_id_rotation_period is not set on the role"), nil
+	}
+
+	rotation, err := b.secretIDRotationEntry(ctx, req.Storage, role)
+	if err != nil {
+		return nil, err
+	}
+	if rotation == nil || !time.Now().Before(rotation.RotationTime.Add(role.SecretIDRotationPeriod)) {
+		rotation, err = b.rotateSecretID(ctx, req.Storage, role, rotation)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"secret_id":          rotation.SecretID,
+			"secret_id_accessor": rotation.SecretIDAccessor,
+			"rotation_time":      rotation.RotationTime,
+			"next_rotation_time": rotation.RotationTime.Add(role.SecretIDRotationPeriod),
+		},
+		WrapInfo: &wrapping.ResponseWrapInfo{
+			TTL: currentSecretIDWrapTTL,
+		},
+	}, nil
+}
+
+// rotateSecretIDs rotates the current SecretIDs of the roles that are due.
+// The SecretIDs of roles that were never read aren't generated.
+func (b *backend) rotateSecretIDs(ctx context.Context, s logical.Storage) error {
+	roleNames, err := s.List(ctx, "role/")
+	if err != nil {
+		return err
+	}
+
+	for _, roleName := range roleNames {
+		if err := b.rotateRoleSecretID(ctx, s, roleName); err != nil {
+			return errwrap.Wrapf(fmt.Sprintf("failed to rotate the secret_id of role %q: {{err}}", roleName), err)
+		}
+	}
+
+	return nil
+}
+
+func (b *backend) rotateRoleSecretID(ctx context.Context, s logical.Storage, roleName string) error {
+	lock := b.roleLock(roleName)
+	lock.Lock()
+	defer lock.Unlock()
+
+	role, err := b.roleEntry(ctx, s, roleName)
+	if err != nil {
+		return err
+	}
+	if role == nil || !role.BindSecretID || role.SecretIDRotationPeriod == 0 {
+		return nil
+	}
+
+	rotation, err := b.secretIDRotationEntry(ctx, s, role)
+	if err != nil {
+		return err
+	}
+	if rotation == nil || time.Now().Before(rotation.RotationTime.Add(role.SecretIDRotationPeriod)) {
+		return nil
+	}
+
+	_, err = b.rotateSecretID(ctx, s, role, rotation)
+	return err
+}
@@ -0,0 +1,167 @@
+package approle
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/logical"
+)
+
+func TestAppRole_SecretIDRotation(t *testing.T) {
+	var resp *logical.Response
+	var err error
+
+	b, storage := createBackendWithStorage(t)
+
+	roleReq := &logical.Request{
+		Operation: logical.CreateOperation,
+		Path:      "role/testrole1",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"policies":                  "a,b",
+			"secret_id_ttl":             "1h",
+			"secret_id_rotation_period": "1h",
+		},
+	}
+
+	// The replaced SecretID must remain valid for a whole period
+	resp, err = b.HandleRequest(context.Background(), roleReq)
+	if err != nil || resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error: err: %v resp: %#v", err, resp)
+	}
+
+	roleReq.Data["secret_id_ttl"] = "2h"
+	resp, err = b.HandleRequest(context.Background(), roleReq)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err: %v resp: %#v", err, resp)
+	}
+
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "role/testrole1/role-id",
+		Storage:   storage,
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err: %v resp: %#v", err, resp)
+	}
+	roleID := resp.Data["role_id"].(string)
+
+	readCurrent := func() string {
+		t.Helper()
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation: logical.ReadOperation,
+			Path:      "role/testrole1/secret-id/current",
+			Storage:   storage,
+		})
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("err: %v resp: %#v", err, resp)
+		}
+		if resp.WrapInfo == nil || resp.WrapInfo.TTL != currentSecretIDWrapTTL {
+			t.Fatalf("expected the response to be wrapped: %#v", resp.WrapInfo)
+		}
+		return resp.Data["secret_id"].(string)
+	}
+	login := func(secretID string) bool {
+		t.Helper()
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      "login",
+			Storage:   storage,
+			Data: map[string]interface{}{
+				"role_id":   roleID,
+				"secret_id": secretID,
+			},
+			Connection: &logical.Connection{RemoteAddr: "127.0.0.1"},
+		})
+		if err != nil && err != logical.ErrInvalidRequest {
+			t.Fatal(err)
+		}
+		return resp != nil && !resp.IsError() && resp.Auth != nil
+	}
+	// expire moves the rotation time of the current SecretID a period back
+	expire := func() {
+		t.Helper()
+		role, err := b.roleEntry(context.Background(), storage, "testrole1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		rotation, err := b.secretIDRotationEntry(context.Background(), storage, role)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rotation.RotationTime = rotation.RotationTime.Add(-time.Hour)
+		entry, err := logical.StorageEntryJSON(secretIDRotationEntryIndex(role), rotation)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := storage.Put(context.Background(), entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Roles that were never read have no SecretID to rotate
+	if err := b.rotateSecretIDs(context.Background(), storage); err != nil {
+		t.Fatal(err)
+	}
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.ListOperation,
+		Path:      "role/testrole1/secret-id",
+		Storage:   storage,
+	})
+	if err != nil || resp == nil || len(resp.Data) != 0 {
+		t.Fatalf("expected no secret IDs: err: %v resp: %#v", err, resp)
+	}
+
+	first := readCurrent()
+	if readCurrent() != first {
+		t.Fatal("expected the current secret ID to be returned again")
+	}
+	if !login(first) {
+		t.Fatal("expected login to succeed")
+	}
+
+	// Rotating on read keeps the replaced SecretID valid
+	expire()
+	second := readCurrent()
+	if second == first {
+		t.Fatal("expected the secret ID to be rotated")
+	}
+	if !login(first) || !login(second) {
+		t.Fatal("expected login to succeed")
+	}
+
+	// The next rotation destroys it
+	expire()
+	if err := b.rotateSecretIDs(context.Background(), storage); err != nil {
+		t.Fatal(err)
+	}
+	third := readCurrent()
+	if third == second {
+		t.Fatal("expected the secret ID to be rotated")
+	}
+	if login(first) {
+		t.Fatal("expected login to fail")
+	}
+	if !login(second) || !login(third) {
+		t.Fatal("expected login to succeed")
+	}
+
+	// Roles without a rotation period have no current SecretID
+	roleReq.Operation = logical.UpdateOperation
+	roleReq.Data = map[string]interface{}{
+		"secret_id_rotation_period": 0,
+	}
+	resp, err = b.HandleRequest(context.Background(), roleReq)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err: %v resp: %#v", err, resp)
+	}
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "role/testrole1/secret-id/current",
+		Storage:   storage,
+	})
+	if err != nil || resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error: err: %v resp: %#v", err, resp)
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"regexp"
+	"sort"
 	"time"
 
 	"github.com/hashicorp/errwrap"
@@ -87,6 +89,53 @@ func verifyCIDRRoleSecretIDSubset(secretIDCIDRs []string, roleBoundCIDRList []st
 	return nil
 }
 
+// metadataValueRegexp compiles the regular expression of the metadata schema
+// of the role that the whole value of a metadata key must match
+func metadataValueRegexp(pattern string) (*regexp.Regexp, error) {
+	return regexp.Compile("^(?:" + pattern + ")$")
+}
+
+// verifySecretIDMetadata checks the metadata of a secret ID against the
+// metadata schema of the role. If the role has a schema, only the keys it
+// defines are allowed, and their values must match the key's pattern.
+func verifySecretIDMetadata(role *roleStorageEntry, metadata map[string]string) error {
+	for _, key := range role.SecretIDRequiredMetadata {
+		if _, ok := metadata[key]; !ok {
+			return fmt.Errorf("missing required metadata key %q", key)
+		}
+	}
+
+	if len(role.SecretIDMetadataSchema) == 0 {
+		return nil
+	}
+
+	// Sort the keys so that the reported error is stable
+	keys := make([]string, 0, len(metadata))
+	for key := range metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		pattern, ok := role.SecretIDMetadataSchema[key]
+		if !ok {
+			return fmt.Errorf("metadata key %q is not allowed by the role", key)
+		}
+		if pattern == "" {
+			continue
+		}
+		re, err := metadataValueRegexp(pattern)
+		if err != nil {
+			return errwrap.Wrapf(fmt.Sprintf("invalid pattern for metadata key %q: {{err}}", key), err)
+		}
+		if !re.MatchString(metadata[key]) {
+			return fmt.Errorf("value of metadata key %q does not match the pattern %q", key, pattern)
+		}
+	}
+
+	return nil
+}
+
 // Creates a SHA256 HMAC of the given 'value' using the given 'key' and returns
 // a hex encoded string.
 func createHMAC(key, value string) (string, error) {
@@ -13,6 +13,7 @@ import (
 	"github.com/hashicorp/errwrap"
 	cleanhttp "github.com/hashicorp/go-cleanhttp"
 	"github.com/hashicorp/vault/helper/awsutil"
+	"github.com/hashicorp/vault/helper/proxyutil"
 	"github.com/hashicorp/vault/logical"
 )
 
@@ -36,6 +37,7 @@ func (b *backend) getRawClientConfig(ctx context.Context, s logical.Storage, reg
 
 	endpoint := aws.String("")
 	var maxRetries int = aws.UseServiceDefaultRetries
+	var proxyURL string
 	if config != nil {
 		// Override the default endpoint with the configured endpoint.
 		switch {
@@ -46,14 +48,23 @@ func (b *backend) getRawClientConfig(ctx context.Context, s logical.Storage, reg
 		case clientType == "sts" && config.STSEndpoint != "":
 			endpoint = aws.String(config.STSEndpoint)
 		}
+		if clientType == "sts" && config.STSRegion != "" {
+			region = config.STSRegion
+		}
 
 		credsConfig.AccessKey = config.AccessKey
 		credsConfig.SecretKey = config.SecretKey
 		maxRetries = config.MaxRetries
+		proxyURL = config.ProxyURL
 	}
 
 	credsConfig.HTTPClient = cleanhttp.DefaultClient()
 
+	httpClient, err := proxyutil.NewHTTPClient(proxyURL)
+	if err != nil {
+		return nil, err
+	}
+
 	creds, err := credsConfig.GenerateCredentialChain()
 	if err != nil {
 		return nil, err
@@ -66,7 +77,7 @@ func (b *backend) getRawClientConfig(ctx context.Context, s logical.Storage, reg
 	return &aws.Config{
 		Credentials: creds,
 		Region:      aws.String(region),
-		HTTPClient:  cleanhttp.DefaultClient(),
+		HTTPClient:  httpClient,
 		Endpoint:    endpoint,
 		MaxRetries:  aws.Int(maxRetries),
 	}, nil
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/hashicorp/vault/helper/proxyutil"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
 )
@@ -42,6 +43,18 @@ func pathConfigClient(b *backend) *framework.Path {
 				Description: "URL to override the default generated endpoint for making AWS STS API calls.",
 			},
 
+			"sts_region": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Default:     "",
+				Description: "Region to sign AWS STS API calls for, e.g. when sts_endpoint is a regional or VPC endpoint.",
+			},
+
+			"proxy_url": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Default:     "",
+				Description: "URL of the HTTP(S) proxy to send AWS API calls through. If not set, the proxy is taken from the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables.",
+			},
+
 			"iam_server_id_header_value": &framework.FieldSchema{
 				Type:        framework.TypeString,
 				Default:     "",
@@ -119,6 +132,8 @@ func (b *backend) pathConfigClientRead(ctx context.Context, req *logical.Request
 			"endpoint":                   clientConfig.Endpoint,
 			"iam_endpoint":               clientConfig.IAMEndpoint,
 			"sts_endpoint":               clientConfig.STSEndpoint,
+			"sts_region":                 clientConfig.STSRegion,
+			"proxy_url":                  clientConfig.ProxyURL,
 			"iam_server_id_header_value": clientConfig.IAMServerIdHeaderValue,
 			"max_retries":                clientConfig.MaxRetries,
 		},
@@ -221,6 +236,31 @@ func (b *backend) pathConfigClientCreateUpdate(ctx context.Context, req *logical
 		configEntry.STSEndpoint = data.Get("sts_endpoint").(string)
 	}
 
+	stsRegionStr, ok := data.GetOk("sts_region")
+	if ok {
+		if configEntry.STSRegion != stsRegionStr.(string) {
+			// Like the STS endpoint, the region affects the credentials of
+			// cached clients
+			changedCreds = true
+			configEntry.STSRegion = stsRegionStr.(string)
+		}
+	} else if req.Operation == logical.CreateOperation {
+		configEntry.STSRegion = data.Get("sts_region").(string)
+	}
+
+	proxyURLStr, ok := data.GetOk("proxy_url")
+	if ok {
+		if _, err := proxyutil.ParseProxyURL(proxyURLStr.(string)); err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+		if configEntry.ProxyURL != proxyURLStr.(string) {
+			changedCreds = true
+			configEntry.ProxyURL = proxyURLStr.(string)
+		}
+	} else if req.Operation == logical.CreateOperation {
+		configEntry.ProxyURL = data.Get("proxy_url").(string)
+	}
+
 	headerValStr, ok := data.GetOk("iam_server_id_header_value")
 	if ok {
 		if configEntry.IAMServerIdHeaderValue != headerValStr.(string) {
@@ -273,6 +313,8 @@ type clientConfig struct {
 	Endpoint               string `json:"endpoint"`
 	IAMEndpoint            string `json:"iam_endpoint"`
 	STSEndpoint            string `json:"sts_endpoint"`
+	STSRegion              string `json:"sts_region"`
+	ProxyURL               string `json:"proxy_url"`
 	IAMServerIdHeaderValue string `json:"iam_server_id_header_value"`
 	MaxRetries             int    `json:"max_retries"`
 }
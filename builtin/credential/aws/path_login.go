@@ -20,10 +20,10 @@ import (
 	"github.com/aws/aws-sdk-go/service/iam"
 	"github.com/fullsailor/pkcs7"
 	"github.com/hashicorp/errwrap"
-	cleanhttp "github.com/hashicorp/go-cleanhttp"
 	uuid "github.com/hashicorp/go-uuid"
 	"github.com/hashicorp/vault/helper/awsutil"
 	"github.com/hashicorp/vault/helper/jsonutil"
+	"github.com/hashicorp/vault/helper/proxyutil"
 	"github.com/hashicorp/vault/helper/strutil"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
@@ -111,6 +111,7 @@ needs to be supplied along with 'identity' parameter.`,
 		Callbacks: map[logical.Operation]framework.OperationFunc{
 			logical.UpdateOperation:         b.pathLoginUpdate,
 			logical.AliasLookaheadOperation: b.pathLoginUpdate,
+			logical.ResolveRoleOperation:    b.pathLoginUpdate,
 		},
 
 		HelpSynopsis:    pathLoginSyn,
@@ -609,6 +610,10 @@ func (b *backend) pathLoginUpdateEc2(ctx context.Context, req *logical.Request,
 		return logical.ErrorResponse(fmt.Sprintf("auth method ec2 not allowed for role %s", roleName)), nil
 	}
 
+	if req.Operation == logical.ResolveRoleOperation {
+		return logical.ResolveRoleResponse(roleName)
+	}
+
 	identityConfigEntry, err := identityConfigEntry(ctx, req.Storage)
 	if err != nil {
 		return nil, err
@@ -1177,6 +1182,7 @@ func (b *backend) pathLoginUpdateIam(ctx context.Context, req *logical.Request,
 	}
 
 	endpoint := "https://sts.amazonaws.com"
+	var proxyURL string
 
 	if config != nil {
 		if config.IAMServerIdHeaderValue != "" {
@@ -1188,9 +1194,10 @@ func (b *backend) pathLoginUpdateIam(ctx context.Context, req *logical.Request,
 		if config.STSEndpoint != "" {
 			endpoint = config.STSEndpoint
 		}
+		proxyURL = config.ProxyURL
 	}
 
-	callerID, err := submitCallerIdentityRequest(method, endpoint, parsedUrl, body, headers)
+	callerID, err := submitCallerIdentityRequest(method, endpoint, proxyURL, parsedUrl, body, headers)
 	if err != nil {
 		return logical.ErrorResponse(fmt.Sprintf("error making upstream request: %v", err)), nil
 	}
@@ -1217,6 +1224,10 @@ func (b *backend) pathLoginUpdateIam(ctx context.Context, req *logical.Request,
 		return logical.ErrorResponse(fmt.Sprintf("auth method iam not allowed for role %s", roleName)), nil
 	}
 
+	if req.Operation == logical.ResolveRoleOperation {
+		return logical.ResolveRoleResponse(roleName)
+	}
+
 	identityConfigEntry, err := identityConfigEntry(ctx, req.Storage)
 	if err != nil {
 		return nil, err
@@ -1518,13 +1529,16 @@ func parseGetCallerIdentityResponse(response string) (GetCallerIdentityResponse,
 	return result, err
 }
 
-func submitCallerIdentityRequest(method, endpoint string, parsedUrl *url.URL, body string, headers http.Header) (*GetCallerIdentityResult, error) {
+func submitCallerIdentityRequest(method, endpoint, proxyURL string, parsedUrl *url.URL, body string, headers http.Header) (*GetCallerIdentityResult, error) {
 	// NOTE: We need to ensure we're calling STS, instead of acting as an unintended network proxy
 	// The protection against this is that this method will only call the endpoint specified in the
 	// client config (defaulting to sts.amazonaws.com), so it would require a Vault admin to override
 	// the endpoint to talk to alternate web addresses
 	request := buildHttpRequest(method, endpoint, parsedUrl, body, headers)
-	client := cleanhttp.DefaultClient()
+	client, err := proxyutil.NewHTTPClient(proxyURL)
+	if err != nil {
+		return nil, err
+	}
 	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
 		return http.ErrUseLastResponse
 	}
@@ -343,6 +343,41 @@ func defaultLoginData() (map[string]interface{}, error) {
 	return buildCallerIdentityLoginData(stsRequestValid.HTTPRequest, testValidRoleName)
 }
 
+func TestBackend_pathLogin_submitCallerIdentityRequestProxy(t *testing.T) {
+	var proxiedHost string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxiedHost = r.URL.Host
+		fmt.Fprintln(w, `<GetCallerIdentityResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <GetCallerIdentityResult>
+    <Arn>arn:aws:iam::123456789012:user/valid-role</Arn>
+    <UserId>ASOMETHINGSOMETHINGSOMETHING</UserId>
+    <Account>123456789012</Account>
+  </GetCallerIdentityResult>
+</GetCallerIdentityResponse>`)
+	}))
+	defer proxy.Close()
+
+	parsedUrl, err := url.Parse("/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := "Action=GetCallerIdentity&Version=2011-06-15"
+	callerID, err := submitCallerIdentityRequest("POST", "http://sts.us-west-2.amazonaws.com", proxy.URL, parsedUrl, body, http.Header{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if callerID.Arn != "arn:aws:iam::123456789012:user/valid-role" {
+		t.Fatalf("bad caller identity: %#v", callerID)
+	}
+	if proxiedHost != "sts.us-west-2.amazonaws.com" {
+		t.Fatalf("expected the request to STS to go through the proxy, got host %q", proxiedHost)
+	}
+
+	if _, err := submitCallerIdentityRequest("POST", "http://sts.us-west-2.amazonaws.com", "::invalid", parsedUrl, body, http.Header{}); err == nil {
+		t.Fatal("expected an error with an invalid proxy URL")
+	}
+}
+
 // setupIAMTestServer configures httptest server to intercept and respond to the
 // IAM login path's invocation of submitCallerIdentityRequest (which does not
 // use the AWS SDK), which receieves the mocked response responseFromUser
@@ -2,11 +2,15 @@ package cert
 
 import (
 	"context"
+	"net/http"
 	"strings"
 	"sync"
+	"time"
 
+	cleanhttp "github.com/hashicorp/go-cleanhttp"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
+	cache "github.com/patrickmn/go-cache"
 )
 
 func Factory(ctx context.Context, conf *logical.BackendConfig) (logical.Backend, error) {
@@ -40,6 +44,10 @@ func Backend() *backend {
 
 	b.crlUpdateMutex = &sync.RWMutex{}
 
+	b.ocspClient = cleanhttp.DefaultPooledClient()
+	b.ocspClient.Timeout = ocspRequestTimeout
+	b.ocspCache = cache.New(0, 10*time.Minute)
+
 	return &b
 }
 
@@ -49,6 +57,9 @@ type backend struct {
 
 	crls           map[string]CRLInfo
 	crlUpdateMutex *sync.RWMutex
+
+	ocspClient *http.Client
+	ocspCache  *cache.Cache
 }
 
 func (b *backend) invalidate(_ context.Context, key string) {
@@ -0,0 +1,166 @@
+package cert
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	multierror "github.com/hashicorp/go-multierror"
+	"golang.org/x/crypto/ocsp"
+)
+
+const (
+	// ocspRequestTimeout is the timeout of the requests to OCSP responders
+	ocspRequestTimeout = 10 * time.Second
+
+	// ocspMaxResponseSize is the maximum size of the responses read from OCSP
+	// responders
+	ocspMaxResponseSize = 1024 * 1024
+
+	// ocspClockSkew is the clock skew tolerated in the validity period of the
+	// OCSP responses
+	ocspClockSkew = 5 * time.Minute
+)
+
+// matchesOCSP verifies that the certificate isn't revoked according to an
+// OCSP responder, if the configuration enables OCSP checking. When its status
+// can't be determined, the certificate is rejected unless the configuration
+// fails open.
+func (b *backend) matchesOCSP(clientCert *x509.Certificate, trustedChain []*x509.Certificate, config *ParsedCert) bool {
+	if !config.Entry.OCSPEnabled {
+		return true
+	}
+
+	resp, err := b.ocspStatus(clientCert, trustedChain, config.Entry.OCSPServersOverride)
+	if err != nil {
+		if config.Entry.OCSPFailOpen {
+			b.Logger().Warn("unable to check the OCSP status of the client certificate, failing open", "cert_name", config.Entry.Name, "serial_number", clientCert.SerialNumber.String(), "error", err)
+			return true
+		}
+		b.Logger().Error("unable to check the OCSP status of the client certificate", "cert_name", config.Entry.Name, "serial_number", clientCert.SerialNumber.String(), "error", err)
+		return false
+	}
+
+	if resp.Status != ocsp.Good {
+		b.Logger().Warn("client certificate revoked according to OCSP", "cert_name", config.Entry.Name, "serial_number", clientCert.SerialNumber.String(), "revoked_at", resp.RevokedAt)
+		return false
+	}
+	return true
+}
+
+// ocspStatus returns an OCSP response with the status of the certificate,
+// from the cache or from the first of the servers giving a definitive one. The
+// servers default to those of the certificate.
+func (b *backend) ocspStatus(cert *x509.Certificate, chain []*x509.Certificate, servers []string) (*ocsp.Response, error) {
+	issuer := findIssuer(cert, chain)
+	if issuer == nil {
+		return nil, errors.New("issuer of the certificate not found")
+	}
+
+	key := ocspCacheKey(cert, issuer)
+	if cached, ok := b.ocspCache.Get(key); ok {
+		return cached.(*ocsp.Response), nil
+	}
+
+	if len(servers) == 0 {
+		servers = cert.OCSPServer
+	}
+	if len(servers) == 0 {
+		return nil, errors.New("no OCSP server configured or given by the certificate")
+	}
+
+	req, err := ocsp.CreateRequest(cert, issuer, nil)
+	if err != nil {
+		return nil, errwrap.Wrapf("failed to create the OCSP request: {{err}}", err)
+	}
+
+	var errs *multierror.Error
+	for _, server := range servers {
+		resp, err := b.queryOCSP(server, req, cert, issuer)
+		if err != nil {
+			errs = multierror.Append(errs, errwrap.Wrapf(fmt.Sprintf("OCSP request to %q failed: {{err}}", server), err))
+			continue
+		}
+
+		// Responses are cached until they're updated
+		if !resp.NextUpdate.IsZero() {
+			b.ocspCache.Set(key, resp, time.Until(resp.NextUpdate))
+		}
+		return resp, nil
+	}
+
+	return nil, errs.ErrorOrNil()
+}
+
+// queryOCSP sends the OCSP request to the server, and returns its response
+// once verified to be a current and definitive one for the certificate
+func (b *backend) queryOCSP(server string, req []byte, cert, issuer *x509.Certificate) (*ocsp.Response, error) {
+	httpReq, err := http.NewRequest("POST", server, bytes.NewReader(req))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/ocsp-request")
+	httpReq.Header.Set("Accept", "application/ocsp-response")
+
+	httpResp, err := b.ocspClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d", httpResp.StatusCode)
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(httpResp.Body, ocspMaxResponseSize))
+	if err != nil {
+		return nil, err
+	}
+
+	// The response must be signed by the issuer or a responder it delegated
+	resp, err := ocsp.ParseResponseForCert(body, cert, issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if resp.ThisUpdate.After(now.Add(ocspClockSkew)) {
+		return nil, fmt.Errorf("response is not valid before %s", resp.ThisUpdate)
+	}
+	if !resp.NextUpdate.IsZero() && resp.NextUpdate.Before(now.Add(-ocspClockSkew)) {
+		return nil, fmt.Errorf("response expired at %s", resp.NextUpdate)
+	}
+	if resp.Status == ocsp.Unknown {
+		return nil, errors.New("status of the certificate is unknown")
+	}
+
+	return resp, nil
+}
+
+// findIssuer returns the certificate of the chain which issued the
+// certificate
+func findIssuer(cert *x509.Certificate, chain []*x509.Certificate) *x509.Certificate {
+	for _, candidate := range chain {
+		if candidate.Equal(cert) {
+			continue
+		}
+		if cert.CheckSignatureFrom(candidate) == nil {
+			return candidate
+		}
+	}
+	return nil
+}
+
+// ocspCacheKey returns the key of the cached OCSP responses for the
+// certificate
+func ocspCacheKey(cert, issuer *x509.Certificate) string {
+	issuerKeyHash := sha256.Sum256(issuer.RawSubjectPublicKeyInfo)
+	return hex.EncodeToString(issuerKeyHash[:]) + "/" + cert.SerialNumber.String()
+}
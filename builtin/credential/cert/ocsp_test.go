@@ -0,0 +1,259 @@
+package cert
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/logical"
+	"golang.org/x/crypto/ocsp"
+)
+
+// testOCSPResponder is an OCSP responder answering with a configurable status
+type testOCSPResponder struct {
+	sync.Mutex
+
+	issuer     *x509.Certificate
+	key        *ecdsa.PrivateKey
+	status     int
+	nextUpdate time.Duration
+	fail       bool
+
+	requests int
+	lastPath string
+}
+
+func (r *testOCSPResponder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.Lock()
+	defer r.Unlock()
+
+	r.requests++
+	r.lastPath = req.URL.Path
+	if r.fail {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	ocspReq, err := ocsp.ParseRequest(body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	template := ocsp.Response{
+		Status:       r.status,
+		SerialNumber: ocspReq.SerialNumber,
+		ThisUpdate:   time.Now().Add(-time.Minute),
+	}
+	if r.nextUpdate > 0 {
+		template.NextUpdate = time.Now().Add(r.nextUpdate)
+	}
+	if r.status == ocsp.Revoked {
+		template.RevokedAt = time.Now().Add(-time.Hour)
+		template.RevocationReason = ocsp.KeyCompromise
+	}
+	resp, err := ocsp.CreateResponse(r.issuer, r.issuer, template, r.key)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/ocsp-response")
+	w.Write(resp)
+}
+
+func (r *testOCSPResponder) set(status int, nextUpdate time.Duration, fail bool) {
+	r.Lock()
+	defer r.Unlock()
+	r.status = status
+	r.nextUpdate = nextUpdate
+	r.fail = fail
+}
+
+func (r *testOCSPResponder) stats() (int, string) {
+	r.Lock()
+	defer r.Unlock()
+	return r.requests, r.lastPath
+}
+
+func testOCSPCert(t *testing.T, template, parent *x509.Certificate, key, parentKey *ecdsa.PrivateKey) *x509.Certificate {
+	t.Helper()
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func TestBackend_OCSP(t *testing.T) {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "OCSP Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caCert := testOCSPCert(t, caTemplate, caTemplate, caKey, caKey)
+
+	responder := &testOCSPResponder{
+		issuer: caCert,
+		key:    caKey,
+	}
+	server := httptest.NewServer(responder)
+	defer server.Close()
+
+	clientKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientCert := testOCSPCert(t, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		OCSPServer:   []string{server.URL + "/ocsp"},
+	}, caCert, clientKey, caKey)
+
+	storage := &logical.InmemStorage{}
+	lb, err := Factory(context.Background(), &logical.BackendConfig{
+		System: &logical.StaticSystemView{
+			DefaultLeaseTTLVal: 300 * time.Second,
+			MaxLeaseTTLVal:     1800 * time.Second,
+		},
+		StorageView: storage,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := lb.(*backend)
+
+	writeCert := func(data map[string]interface{}) *logical.Response {
+		t.Helper()
+		data["certificate"] = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caCert.Raw}))
+		data["policies"] = "foo"
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      "certs/ocsp",
+			Storage:   storage,
+			Data:      data,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+	login := func() bool {
+		t.Helper()
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      "login",
+			Storage:   storage,
+			Connection: &logical.Connection{
+				ConnState: &tls.ConnectionState{
+					PeerCertificates: []*x509.Certificate{clientCert},
+				},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp != nil && !resp.IsError() && resp.Auth != nil
+	}
+	expectRequests := func(expected int) {
+		t.Helper()
+		if requests, _ := responder.stats(); requests != expected {
+			t.Fatalf("expected %d OCSP requests, got %d", expected, requests)
+		}
+	}
+
+	// OCSP isn't checked unless enabled
+	writeCert(map[string]interface{}{})
+	if !login() {
+		t.Fatal("expected the login to succeed")
+	}
+	expectRequests(0)
+
+	// Good certificates are accepted, and responses without a next update
+	// aren't cached
+	writeCert(map[string]interface{}{"ocsp_enabled": true})
+	responder.set(ocsp.Good, 0, false)
+	if !login() || !login() {
+		t.Fatal("expected the logins to succeed")
+	}
+	expectRequests(2)
+	if _, path := responder.stats(); path != "/ocsp" {
+		t.Fatalf("expected the OCSP server of the certificate to be used, got %q", path)
+	}
+
+	// Revoked certificates are rejected, and the responses are cached until
+	// their next update
+	b.ocspCache.Flush()
+	responder.set(ocsp.Revoked, time.Hour, false)
+	if login() || login() {
+		t.Fatal("expected the logins to fail")
+	}
+	expectRequests(3)
+
+	// Unknown statuses and failing responders fail closed by default
+	b.ocspCache.Flush()
+	responder.set(ocsp.Unknown, 0, false)
+	if login() {
+		t.Fatal("expected the login to fail")
+	}
+	responder.set(ocsp.Good, 0, true)
+	if login() {
+		t.Fatal("expected the login to fail")
+	}
+
+	// They can fail open, but revoked certificates are still rejected
+	writeCert(map[string]interface{}{"ocsp_enabled": true, "ocsp_fail_open": true})
+	if !login() {
+		t.Fatal("expected the login to succeed")
+	}
+	responder.set(ocsp.Revoked, 0, false)
+	if login() {
+		t.Fatal("expected the login to fail")
+	}
+
+	// The OCSP servers can be overridden
+	resp := writeCert(map[string]interface{}{"ocsp_enabled": true, "ocsp_servers_override": "ldap://ocsp"})
+	if resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error for an invalid OCSP server, got: %#v", resp)
+	}
+	writeCert(map[string]interface{}{"ocsp_enabled": true, "ocsp_servers_override": server.URL + "/override"})
+	responder.set(ocsp.Good, 0, false)
+	if !login() {
+		t.Fatal("expected the login to succeed")
+	}
+	if _, path := responder.stats(); path != "/override" {
+		t.Fatalf("expected the overriding OCSP server to be used, got %q", path)
+	}
+}
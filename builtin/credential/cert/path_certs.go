@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/x509"
 	"fmt"
+	"net/url"
 	"strings"
 	"time"
 
@@ -129,6 +130,25 @@ TTL will be set to the value of this parameter.`,
 				Description: `Comma separated string or list of CIDR blocks. If set, specifies the blocks of
 IP addresses which can perform the login operation.`,
 			},
+
+			"ocsp_enabled": &framework.FieldSchema{
+				Type: framework.TypeBool,
+				Description: `If set, the revocation status of the client certificates
+is checked with an OCSP responder at login and renewal.`,
+			},
+
+			"ocsp_servers_override": &framework.FieldSchema{
+				Type: framework.TypeCommaStringSlice,
+				Description: `A comma-separated list of OCSP responder URLs, used
+instead of the OCSP servers given by the client certificates. They are
+tried in order until one gives the status of the certificate.`,
+			},
+
+			"ocsp_fail_open": &framework.FieldSchema{
+				Type: framework.TypeBool,
+				Description: `If set, logins are allowed when the OCSP status of the
+client certificate can't be determined. Defaults to false, rejecting them.`,
+			},
 		},
 
 		Callbacks: map[logical.Operation]framework.OperationFunc{
@@ -199,6 +219,9 @@ func (b *backend) pathCertRead(ctx context.Context, req *logical.Request, d *fra
 			"allowed_organizational_units": cert.AllowedOrganizationalUnits,
 			"required_extensions":          cert.RequiredExtensions,
 			"bound_cidrs":                  cert.BoundCIDRs,
+			"ocsp_enabled":                 cert.OCSPEnabled,
+			"ocsp_servers_override":        cert.OCSPServersOverride,
+			"ocsp_fail_open":               cert.OCSPFailOpen,
 		},
 	}, nil
 }
@@ -286,6 +309,13 @@ func (b *backend) pathCertWrite(ctx context.Context, req *logical.Request, d *fr
 		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
 	}
 
+	ocspServersOverride := d.Get("ocsp_servers_override").([]string)
+	for _, server := range ocspServersOverride {
+		if u, err := url.Parse(server); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return logical.ErrorResponse(fmt.Sprintf("invalid OCSP server URL %q", server)), nil
+		}
+	}
+
 	certEntry := &CertEntry{
 		Name:                       name,
 		Certificate:                certificate,
@@ -302,6 +332,9 @@ func (b *backend) pathCertWrite(ctx context.Context, req *logical.Request, d *fr
 		MaxTTL:                     maxTTL,
 		Period:                     period,
 		BoundCIDRs:                 parsedCIDRs,
+		OCSPEnabled:                d.Get("ocsp_enabled").(bool),
+		OCSPServersOverride:        ocspServersOverride,
+		OCSPFailOpen:               d.Get("ocsp_fail_open").(bool),
 	}
 
 	// Store it
@@ -336,6 +369,9 @@ type CertEntry struct {
 	AllowedOrganizationalUnits []string
 	RequiredExtensions         []string
 	BoundCIDRs                 []*sockaddr.SockAddrMarshaler
+	OCSPEnabled                bool
+	OCSPServersOverride        []string
+	OCSPFailOpen               bool
 }
 
 const pathCertHelpSyn = `
@@ -39,6 +39,7 @@ func pathLogin(b *backend) *framework.Path {
 		Callbacks: map[logical.Operation]framework.OperationFunc{
 			logical.UpdateOperation:         b.pathLogin,
 			logical.AliasLookaheadOperation: b.pathLoginAliasLookahead,
+			logical.ResolveRoleOperation:    b.pathLoginResolveRole,
 		},
 	}
 }
@@ -58,6 +59,20 @@ func (b *backend) pathLoginAliasLookahead(ctx context.Context, req *logical.Requ
 	}, nil
 }
 
+// pathLoginResolveRole returns the name of the certificate role the client
+// certificate matches
+func (b *backend) pathLoginResolveRole(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	matched, resp, err := b.verifyCredentials(ctx, req, data)
+	if err != nil || resp != nil {
+		return resp, err
+	}
+	if matched == nil {
+		return logical.ErrorResponse("no certificate role matched"), nil
+	}
+
+	return logical.ResolveRoleResponse(matched.Entry.Name)
+}
+
 func (b *backend) pathLogin(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
 	var matched *ParsedCert
 	if verifyResp, resp, err := b.verifyCredentials(ctx, req, data); err != nil {
@@ -253,7 +268,8 @@ func (b *backend) matchesConstraints(clientCert *x509.Certificate, trustedChain
 		b.matchesEmailSANs(clientCert, config) &&
 		b.matchesURISANs(clientCert, config) &&
 		b.matchesOrganizationalUnits(clientCert, config) &&
-		b.matchesCertificateExtensions(clientCert, config)
+		b.matchesCertificateExtensions(clientCert, config) &&
+		b.matchesOCSP(clientCert, trustedChain, config)
 }
 
 // matchesNames verifies that the certificate matches at least one configured
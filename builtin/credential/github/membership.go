@@ -0,0 +1,263 @@
+package github
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/google/go-github/github"
+	"github.com/hashicorp/vault/logical"
+)
+
+const (
+	// fineGrainedTokenPrefix is the prefix of the fine-grained personal
+	// access tokens
+	fineGrainedTokenPrefix = "github_pat_"
+
+	// appUserTokenPrefix is the prefix of the tokens of GitHub Apps acting
+	// on behalf of users
+	appUserTokenPrefix = "ghu_"
+
+	// appInstallationTokenPrefix is the prefix of the installation tokens of
+	// GitHub Apps
+	appInstallationTokenPrefix = "ghs_"
+
+	// appInstallationAliasPrefix is the prefix of the alias names of the
+	// logins with installation tokens
+	appInstallationAliasPrefix = "installation/"
+)
+
+// usesMembershipAPI returns whether the token must be verified with the
+// membership APIs, as it can't list the organizations and teams of the user
+func usesMembershipAPI(token string) bool {
+	return strings.HasPrefix(token, fineGrainedTokenPrefix) || strings.HasPrefix(token, appUserTokenPrefix)
+}
+
+// orgTeam is a team along with the organization it belongs to
+type orgTeam struct {
+	Org  *github.Organization
+	Team *github.Team
+}
+
+// userOrgs returns the allowed organizations the user is part of, in the
+// order of the configuration
+func (b *backend) userOrgs(ctx context.Context, client *github.Client, config *config, membershipAPI bool) ([]*github.Organization, error) {
+	var orgs []*github.Organization
+
+	if membershipAPI {
+		for _, name := range config.organizations() {
+			membership, resp, err := client.Organizations.GetOrgMembership(ctx, "", name)
+			if err != nil {
+				if isNotMember(resp) {
+					continue
+				}
+				return nil, err
+			}
+			if membership.GetState() == "active" && membership.Organization != nil {
+				orgs = append(orgs, membership.Organization)
+			}
+		}
+		return orgs, nil
+	}
+
+	orgOpt := &github.ListOptions{
+		PerPage: 100,
+	}
+
+	var allOrgs []*github.Organization
+	for {
+		page, resp, err := client.Organizations.List(ctx, "", orgOpt)
+		if err != nil {
+			return nil, err
+		}
+		allOrgs = append(allOrgs, page...)
+		if resp.NextPage == 0 {
+			break
+		}
+		orgOpt.Page = resp.NextPage
+	}
+
+	for _, name := range config.organizations() {
+		for _, o := range allOrgs {
+			if strings.EqualFold(o.GetLogin(), name) {
+				orgs = append(orgs, o)
+				break
+			}
+		}
+	}
+	return orgs, nil
+}
+
+// userTeams returns the teams of the organizations the user is part of,
+// including their parents if the configuration inherits them
+func (b *backend) userTeams(ctx context.Context, client *github.Client, config *config, user *github.User, orgs []*github.Organization, membershipAPI bool) ([]*orgTeam, error) {
+	// Teams are cached by ID to look up their parents
+	cache := make(map[int64]*github.Team)
+
+	var teams []*orgTeam
+	if membershipAPI {
+		for _, org := range orgs {
+			orgTeams, err := listOrgTeams(ctx, client, org.GetLogin())
+			if err != nil {
+				return nil, err
+			}
+
+			for _, t := range orgTeams {
+				cache[t.GetID()] = t
+
+				membership, resp, err := client.Teams.GetTeamMembership(ctx, t.GetID(), user.GetLogin())
+				if err != nil {
+					if isNotMember(resp) {
+						continue
+					}
+					return nil, err
+				}
+				if membership.GetState() == "active" {
+					teams = append(teams, &orgTeam{Org: org, Team: t})
+				}
+			}
+		}
+	} else {
+		teamOpt := &github.ListOptions{
+			PerPage: 100,
+		}
+
+		var allTeams []*github.Team
+		for {
+			userTeams, resp, err := client.Teams.ListUserTeams(ctx, teamOpt)
+			if err != nil {
+				return nil, err
+			}
+			allTeams = append(allTeams, userTeams...)
+			if resp.NextPage == 0 {
+				break
+			}
+			teamOpt.Page = resp.NextPage
+		}
+
+		for _, t := range allTeams {
+			cache[t.GetID()] = t
+
+			// We only care about teams that are part of the organizations we
+			// use
+			for _, org := range orgs {
+				if t.Organization != nil && t.Organization.GetID() == org.GetID() {
+					teams = append(teams, &orgTeam{Org: org, Team: t})
+					break
+				}
+			}
+		}
+	}
+
+	if !config.InheritParentTeams {
+		return teams, nil
+	}
+
+	// Members of a team are members of its parents as well
+	seen := make(map[int64]bool)
+	for _, t := range teams {
+		seen[t.Team.GetID()] = true
+	}
+	for _, t := range teams {
+		parent := t.Team.Parent
+		for parent != nil && !seen[parent.GetID()] {
+			seen[parent.GetID()] = true
+
+			// The parents embedded in teams don't have parents themselves
+			full, ok := cache[parent.GetID()]
+			if !ok {
+				var err error
+				full, _, err = client.Teams.GetTeam(ctx, parent.GetID())
+				if err != nil {
+					return nil, err
+				}
+				cache[parent.GetID()] = full
+			}
+
+			teams = append(teams, &orgTeam{Org: t.Org, Team: full})
+			parent = full.Parent
+		}
+	}
+
+	return teams, nil
+}
+
+// listOrgTeams returns all the teams of the organization
+func listOrgTeams(ctx context.Context, client *github.Client, org string) ([]*github.Team, error) {
+	teamOpt := &github.ListOptions{
+		PerPage: 100,
+	}
+
+	var allTeams []*github.Team
+	for {
+		teams, resp, err := client.Teams.ListTeams(ctx, org, teamOpt)
+		if err != nil {
+			return nil, err
+		}
+		allTeams = append(allTeams, teams...)
+		if resp.NextPage == 0 {
+			break
+		}
+		teamOpt.Page = resp.NextPage
+	}
+	return allTeams, nil
+}
+
+// teamNames returns the names the teams are mapped to policies by. The teams
+// of the main organization are mapped by name and slug, and the others by
+// slug prefixed by the name of their organization and an underscore, which
+// organization names can't contain.
+func teamNames(config *config, teams []*orgTeam) []string {
+	var names []string
+	for _, t := range teams {
+		if !strings.EqualFold(t.Org.GetLogin(), config.Organization) {
+			names = append(names, t.Org.GetLogin()+"_"+t.Team.GetSlug())
+			continue
+		}
+
+		names = append(names, t.Team.GetName())
+		if t.Team.GetName() != t.Team.GetSlug() {
+			names = append(names, t.Team.GetSlug())
+		}
+	}
+	return names
+}
+
+// verifyInstallation verifies the installation token is one of a GitHub App
+// installed in one of the allowed organizations, which is determined from the
+// repositories it can access
+func (b *backend) verifyInstallation(ctx context.Context, client *github.Client, config *config) (*verifyCredentialsResp, *logical.Response, error) {
+	if len(config.AppInstallationPolicies) == 0 {
+		return nil, logical.ErrorResponse("app installation tokens are not allowed"), nil
+	}
+
+	repos, _, err := client.Apps.ListRepos(ctx, &github.ListOptions{PerPage: 1})
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(repos) == 0 || repos[0].Owner == nil {
+		return nil, logical.ErrorResponse("app installation has no repository to determine its organization from"), nil
+	}
+	owner := repos[0].Owner
+
+	for _, name := range config.organizations() {
+		if strings.EqualFold(owner.GetLogin(), name) {
+			return &verifyCredentialsResp{
+				Login: appInstallationAliasPrefix + owner.GetLogin(),
+				Org: &github.Organization{
+					ID:    owner.ID,
+					Login: owner.Login,
+				},
+				Policies: config.AppInstallationPolicies,
+			}, nil, nil
+		}
+	}
+
+	return nil, logical.ErrorResponse("app is not installed in required org"), nil
+}
+
+// isNotMember returns whether the response of a membership API denotes that
+// the user isn't a member
+func isNotMember(resp *github.Response) bool {
+	return resp != nil && resp.StatusCode == http.StatusNotFound
+}
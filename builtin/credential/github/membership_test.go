@@ -0,0 +1,159 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/hashicorp/vault/logical"
+)
+
+// testGitHubAPI returns a fake GitHub API with the user "alice", member of the
+// team "Site Reliability" of the organization "acme", child of "ops" itself
+// child of "eng", and of the team "devs" of the organization "other"
+func testGitHubAPI(t *testing.T) *httptest.Server {
+	acme := map[string]interface{}{"id": 1, "login": "acme"}
+	other := map[string]interface{}{"id": 2, "login": "other"}
+	eng := map[string]interface{}{"id": 10, "name": "eng", "slug": "eng", "organization": acme}
+	ops := map[string]interface{}{"id": 11, "name": "ops", "slug": "ops", "organization": acme, "parent": map[string]interface{}{"id": 10, "name": "eng", "slug": "eng"}}
+	sre := map[string]interface{}{"id": 12, "name": "Site Reliability", "slug": "site-reliability", "organization": acme, "parent": map[string]interface{}{"id": 11, "name": "ops", "slug": "ops"}}
+	devs := map[string]interface{}{"id": 20, "name": "devs", "slug": "devs", "organization": other}
+
+	responses := map[string]interface{}{
+		"/user":                        map[string]interface{}{"id": 100, "login": "alice"},
+		"/user/orgs":                   []interface{}{acme, other},
+		"/user/teams":                  []interface{}{sre, devs},
+		"/teams/10":                    eng,
+		"/teams/11":                    ops,
+		"/user/memberships/orgs/acme":  map[string]interface{}{"state": "active", "organization": acme},
+		"/user/memberships/orgs/other": map[string]interface{}{"state": "active", "organization": other},
+		"/orgs/acme/teams":             []interface{}{eng, ops, sre},
+		"/orgs/other/teams":            []interface{}{devs},
+		"/teams/12/memberships/alice":  map[string]interface{}{"state": "active"},
+		"/teams/20/memberships/alice":  map[string]interface{}{"state": "pending"},
+		"/installation/repositories":   map[string]interface{}{"repositories": []interface{}{map[string]interface{}{"name": "repo", "owner": acme}}},
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp, ok := responses[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message": "Not Found"}`))
+			return
+		}
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			t.Error(err)
+		}
+	}))
+}
+
+func TestBackend_LoginMemberships(t *testing.T) {
+	server := testGitHubAPI(t)
+	defer server.Close()
+
+	storage := &logical.InmemStorage{}
+	b, err := Factory(context.Background(), &logical.BackendConfig{
+		System:      &logical.StaticSystemView{},
+		StorageView: storage,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request := func(path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      path,
+			Storage:   storage,
+			Data:      data,
+		})
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		return resp
+	}
+	login := func(token string) *logical.Response {
+		t.Helper()
+		return request("login", map[string]interface{}{"token": token})
+	}
+	expectPolicies := func(resp *logical.Response, expected ...string) {
+		t.Helper()
+		if resp == nil || resp.IsError() || resp.Auth == nil {
+			t.Fatalf("expected the login to succeed, got: %#v", resp)
+		}
+		policies := append([]string{}, resp.Auth.Policies...)
+		sort.Strings(policies)
+		if !reflect.DeepEqual(policies, expected) {
+			t.Fatalf("expected policies %v, got %v", expected, policies)
+		}
+	}
+
+	for team, policy := range map[string]string{
+		"site-reliability": "sre",
+		"ops":              "ops",
+		"eng":              "eng",
+		"other_devs":       "devs",
+	} {
+		request("map/teams/"+team, map[string]interface{}{"value": policy})
+	}
+
+	// Users must be part of the main organization by default
+	request("config", map[string]interface{}{
+		"organization": "acme",
+		"base_url":     server.URL + "/",
+	})
+	expectPolicies(login("ghp_classic"), "sre")
+	expectPolicies(login("github_pat_finegrained"), "sre")
+
+	// Parent teams are inherited, and the teams of the other allowed
+	// organizations are mapped by slug
+	request("config", map[string]interface{}{
+		"organization":          "acme",
+		"allowed_organizations": "other",
+		"inherit_parent_teams":  true,
+		"base_url":              server.URL + "/",
+	})
+	resp := login("ghp_classic")
+	expectPolicies(resp, "devs", "eng", "ops", "sre")
+	if resp.Auth.Alias.Name != "alice" || resp.Auth.Metadata["org"] != "acme" {
+		t.Fatalf("unexpected auth: %#v", resp.Auth)
+	}
+
+	// Pending team memberships aren't taken into account
+	expectPolicies(login("github_pat_finegrained"), "eng", "ops", "sre")
+
+	// Users not part of any allowed organization are rejected
+	request("config", map[string]interface{}{
+		"organization": "unknown",
+		"base_url":     server.URL + "/",
+	})
+	for _, token := range []string{"ghp_classic", "github_pat_finegrained"} {
+		if resp := login(token); resp == nil || !resp.IsError() {
+			t.Fatalf("expected an error, got: %#v", resp)
+		}
+	}
+
+	// Installation tokens are only allowed with policies to give them
+	request("config", map[string]interface{}{
+		"organization": "acme",
+		"base_url":     server.URL + "/",
+	})
+	if resp := login("ghs_installation"); resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error, got: %#v", resp)
+	}
+	request("config", map[string]interface{}{
+		"organization":              "acme",
+		"app_installation_policies": "ci",
+		"base_url":                  server.URL + "/",
+	})
+	resp = login("ghs_installation")
+	expectPolicies(resp, "ci")
+	if resp.Auth.Alias.Name != "installation/acme" {
+		t.Fatalf("unexpected alias: %#v", resp.Auth.Alias)
+	}
+}
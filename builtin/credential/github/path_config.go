@@ -4,9 +4,12 @@ import (
 	"context"
 	"fmt"
 	"net/url"
+	"strings"
 	"time"
 
 	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/helper/policyutil"
+	"github.com/hashicorp/vault/helper/strutil"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
 )
@@ -20,6 +23,27 @@ func pathConfig(b *backend) *framework.Path {
 				Description: "The organization users must be part of",
 			},
 
+			"allowed_organizations": &framework.FieldSchema{
+				Type: framework.TypeCommaStringSlice,
+				Description: `Additional organizations users may be part of
+instead of the main one. Their teams are mapped
+by slug as "<organization>_<team>".`,
+			},
+
+			"inherit_parent_teams": &framework.FieldSchema{
+				Type: framework.TypeBool,
+				Description: `If set, users are considered members of the
+parents of their teams when mapping teams to
+policies.`,
+			},
+
+			"app_installation_policies": &framework.FieldSchema{
+				Type: framework.TypeCommaStringSlice,
+				Description: `Policies given to logins with GitHub App
+installation tokens. If not set, these tokens
+are rejected.`,
+			},
+
 			"base_url": &framework.FieldSchema{
 				Type: framework.TypeString,
 				Description: `The API endpoint to use. Useful if you
@@ -76,11 +100,28 @@ func (b *backend) pathConfigWrite(ctx context.Context, req *logical.Request, dat
 		}
 	}
 
+	appInstallationPolicies := policyutil.ParsePolicies(data.Get("app_installation_policies"))
+	if strutil.StrListContains(appInstallationPolicies, "root") {
+		return logical.ErrorResponse("root policy cannot be given to app installation tokens"), nil
+	}
+
+	var allowedOrganizations []string
+	for _, org := range data.Get("allowed_organizations").([]string) {
+		if strings.EqualFold(org, organization) {
+			continue
+		}
+		allowedOrganizations = append(allowedOrganizations, org)
+	}
+	allowedOrganizations = strutil.RemoveDuplicates(allowedOrganizations, true)
+
 	entry, err := logical.StorageEntryJSON("config", config{
-		Organization: organization,
-		BaseURL:      baseURL,
-		TTL:          ttl,
-		MaxTTL:       maxTTL,
+		Organization:            organization,
+		AllowedOrganizations:    allowedOrganizations,
+		InheritParentTeams:      data.Get("inherit_parent_teams").(bool),
+		AppInstallationPolicies: appInstallationPolicies,
+		BaseURL:                 baseURL,
+		TTL:                     ttl,
+		MaxTTL:                  maxTTL,
 	})
 
 	if err != nil {
@@ -109,10 +150,13 @@ func (b *backend) pathConfigRead(ctx context.Context, req *logical.Request, data
 
 	resp := &logical.Response{
 		Data: map[string]interface{}{
-			"organization": config.Organization,
-			"base_url":     config.BaseURL,
-			"ttl":          config.TTL,
-			"max_ttl":      config.MaxTTL,
+			"organization":              config.Organization,
+			"allowed_organizations":     config.AllowedOrganizations,
+			"inherit_parent_teams":      config.InheritParentTeams,
+			"app_installation_policies": config.AppInstallationPolicies,
+			"base_url":                  config.BaseURL,
+			"ttl":                       config.TTL,
+			"max_ttl":                   config.MaxTTL,
 		},
 	}
 	return resp, nil
@@ -135,9 +179,18 @@ func (b *backend) Config(ctx context.Context, s logical.Storage) (*config, error
 	return &result, nil
 }
 
+// organizations returns the organizations users may be part of, starting
+// with the main one
+func (c *config) organizations() []string {
+	return append([]string{c.Organization}, c.AllowedOrganizations...)
+}
+
 type config struct {
-	Organization string        `json:"organization" structs:"organization" mapstructure:"organization"`
-	BaseURL      string        `json:"base_url" structs:"base_url" mapstructure:"base_url"`
-	TTL          time.Duration `json:"ttl" structs:"ttl" mapstructure:"ttl"`
-	MaxTTL       time.Duration `json:"max_ttl" structs:"max_ttl" mapstructure:"max_ttl"`
+	Organization            string        `json:"organization" structs:"organization" mapstructure:"organization"`
+	AllowedOrganizations    []string      `json:"allowed_organizations" structs:"allowed_organizations" mapstructure:"allowed_organizations"`
+	InheritParentTeams      bool          `json:"inherit_parent_teams" structs:"inherit_parent_teams" mapstructure:"inherit_parent_teams"`
+	AppInstallationPolicies []string      `json:"app_installation_policies" structs:"app_installation_policies" mapstructure:"app_installation_policies"`
+	BaseURL                 string        `json:"base_url" structs:"base_url" mapstructure:"base_url"`
+	TTL                     time.Duration `json:"ttl" structs:"ttl" mapstructure:"ttl"`
+	MaxTTL                  time.Duration `json:"max_ttl" structs:"max_ttl" mapstructure:"max_ttl"`
 }
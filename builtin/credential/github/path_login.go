@@ -45,7 +45,7 @@ func (b *backend) pathLoginAliasLookahead(ctx context.Context, req *logical.Requ
 	return &logical.Response{
 		Auth: &logical.Auth{
 			Alias: &logical.Alias{
-				Name: *verifyResp.User.Login,
+				Name: verifyResp.Login,
 			},
 		},
 	}, nil
@@ -75,17 +75,17 @@ func (b *backend) pathLogin(ctx context.Context, req *logical.Request, data *fra
 			},
 			Policies: verifyResp.Policies,
 			Metadata: map[string]string{
-				"username": *verifyResp.User.Login,
+				"username": verifyResp.Login,
 				"org":      *verifyResp.Org.Login,
 			},
-			DisplayName: *verifyResp.User.Login,
+			DisplayName: verifyResp.Login,
 			LeaseOptions: logical.LeaseOptions{
 				TTL:       config.TTL,
 				MaxTTL:    config.MaxTTL,
 				Renewable: true,
 			},
 			Alias: &logical.Alias{
-				Name: *verifyResp.User.Login,
+				Name: verifyResp.Login,
 			},
 		},
 	}
@@ -169,74 +169,36 @@ func (b *backend) verifyCredentials(ctx context.Context, req *logical.Request, t
 		client.BaseURL = parsedURL
 	}
 
+	// Installation tokens aren't tied to users
+	if strings.HasPrefix(token, appInstallationTokenPrefix) {
+		return b.verifyInstallation(ctx, client, config)
+	}
+
 	// Get the user
 	user, _, err := client.Users.Get(ctx, "")
 	if err != nil {
 		return nil, nil, err
 	}
 
-	// Verify that the user is part of the organization
-	var org *github.Organization
-
-	orgOpt := &github.ListOptions{
-		PerPage: 100,
-	}
+	// Fine-grained tokens can't list the organizations and teams of the user,
+	// whose memberships are checked instead
+	membershipAPI := usesMembershipAPI(token)
 
-	var allOrgs []*github.Organization
-	for {
-		orgs, resp, err := client.Organizations.List(ctx, "", orgOpt)
-		if err != nil {
-			return nil, nil, err
-		}
-		allOrgs = append(allOrgs, orgs...)
-		if resp.NextPage == 0 {
-			break
-		}
-		orgOpt.Page = resp.NextPage
-	}
-
-	for _, o := range allOrgs {
-		if strings.EqualFold(*o.Login, config.Organization) {
-			org = o
-			break
-		}
+	// Verify that the user is part of one of the organizations
+	orgs, err := b.userOrgs(ctx, client, config, membershipAPI)
+	if err != nil {
+		return nil, nil, err
 	}
-	if org == nil {
+	if len(orgs) == 0 {
 		return nil, logical.ErrorResponse("user is not part of required org"), nil
 	}
 
 	// Get the teams that this user is part of to determine the policies
-	var teamNames []string
-
-	teamOpt := &github.ListOptions{
-		PerPage: 100,
-	}
-
-	var allTeams []*github.Team
-	for {
-		teams, resp, err := client.Teams.ListUserTeams(ctx, teamOpt)
-		if err != nil {
-			return nil, nil, err
-		}
-		allTeams = append(allTeams, teams...)
-		if resp.NextPage == 0 {
-			break
-		}
-		teamOpt.Page = resp.NextPage
-	}
-
-	for _, t := range allTeams {
-		// We only care about teams that are part of the organization we use
-		if *t.Organization.ID != *org.ID {
-			continue
-		}
-
-		// Append the names so we can get the policies
-		teamNames = append(teamNames, *t.Name)
-		if *t.Name != *t.Slug {
-			teamNames = append(teamNames, *t.Slug)
-		}
+	teams, err := b.userTeams(ctx, client, config, user, orgs, membershipAPI)
+	if err != nil {
+		return nil, nil, err
 	}
+	teamNames := teamNames(config, teams)
 
 	groupPoliciesList, err := b.TeamMap.Policies(ctx, req.Storage, teamNames...)
 
@@ -251,15 +213,15 @@ func (b *backend) verifyCredentials(ctx context.Context, req *logical.Request, t
 	}
 
 	return &verifyCredentialsResp{
-		User:      user,
-		Org:       org,
+		Login:     *user.Login,
+		Org:       orgs[0],
 		Policies:  append(groupPoliciesList, userPoliciesList...),
 		TeamNames: teamNames,
 	}, nil, nil
 }
 
 type verifyCredentialsResp struct {
-	User      *github.User
+	Login     string
 	Org       *github.Organization
 	Policies  []string
 	TeamNames []string
@@ -46,6 +46,8 @@ func backend(c *logical.BackendConfig) *jwtAuthBackend {
 		PathsSpecial: &logical.Paths{
 			Unauthenticated: []string{
 				"login",
+				"device/start",
+				"device/poll",
 			},
 			SealWrapStorage: []string{
 				"config",
@@ -54,6 +56,8 @@ func backend(c *logical.BackendConfig) *jwtAuthBackend {
 		Paths: framework.PathAppend(
 			[]*framework.Path{
 				pathLogin(b),
+				pathOIDCDeviceStart(b),
+				pathOIDCDevicePoll(b),
 				pathRoleList(b),
 				pathRole(b),
 				pathConfig(b),
@@ -0,0 +1,96 @@
+package jwtauth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/logical"
+	jose "gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+func getBackend(t *testing.T) (logical.Backend, logical.Storage) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+
+	b, err := Factory(context.Background(), config)
+	if err != nil {
+		t.Fatalf("unable to create backend: %v", err)
+	}
+	return b, config.StorageView
+}
+
+// testRequest makes a request against the backend, failing the test on
+// errors other than error responses
+func testRequest(t *testing.T, b logical.Backend, s logical.Storage, op logical.Operation, path string, data map[string]interface{}) *logical.Response {
+	t.Helper()
+
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation:  op,
+		Path:       path,
+		Storage:    s,
+		Data:       data,
+		Connection: &logical.Connection{RemoteAddr: "127.0.0.1"},
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	return resp
+}
+
+// testKey returns a signing key, along with its public key in PEM format
+func testKey(t *testing.T) (*ecdsa.PrivateKey, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key, string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+// signClaims returns a JWT with the claims, valid for a minute and signed by
+// the key
+func signClaims(t *testing.T, key *ecdsa.PrivateKey, keyID string, claims map[string]interface{}) string {
+	signingKey := jose.SigningKey{Algorithm: jose.ES256, Key: key}
+	if keyID != "" {
+		signingKey.Key = jose.JSONWebKey{Key: key, KeyID: keyID}
+	}
+	signer, err := jose.NewSigner(signingKey, (&jose.SignerOptions{}).WithType("JWT"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	token, err := jwt.Signed(signer).Claims(jwt.Claims{
+		IssuedAt: jwt.NewNumericDate(now),
+		Expiry:   jwt.NewNumericDate(now.Add(time.Minute)),
+	}).Claims(claims).CompactSerialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+// setupPubKeyBackend returns a backend validating tokens against the public
+// key of the returned signing key
+func setupPubKeyBackend(t *testing.T) (logical.Backend, logical.Storage, *ecdsa.PrivateKey) {
+	b, s := getBackend(t)
+	key, pubKey := testKey(t)
+
+	resp := testRequest(t, b, s, logical.UpdateOperation, configPath, map[string]interface{}{
+		"jwt_validation_pubkeys": pubKey,
+	})
+	if resp != nil && resp.IsError() {
+		t.Fatalf("bad: %#v", resp)
+	}
+	return b, s, key
+}
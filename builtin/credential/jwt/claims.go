@@ -0,0 +1,126 @@
+package jwtauth
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/vault/helper/policyutil"
+	"github.com/hashicorp/vault/helper/strutil"
+)
+
+// claimTemplate is replaced by the value of the claim in the templates of
+// claim_policies
+const claimTemplate = "{{claim}}"
+
+// parseClaimValueMap parses a map of claims to a comma-separated string or a
+// list of strings, as accepted by bound_claims and claim_policies
+func parseClaimValueMap(raw map[string]interface{}) (map[string][]string, error) {
+	ret := make(map[string][]string, len(raw))
+	for claim, valuesRaw := range raw {
+		if claim == "" {
+			return nil, errors.New("claim names cannot be empty")
+		}
+
+		var values []string
+		switch v := valuesRaw.(type) {
+		case string:
+			values = strutil.ParseDedupAndSortStrings(v, ",")
+		case []interface{}:
+			for _, valueRaw := range v {
+				value, ok := valueRaw.(string)
+				if !ok {
+					return nil, fmt.Errorf("value %v of claim %q is not a string", valueRaw, claim)
+				}
+				values = append(values, value)
+			}
+			values = strutil.RemoveDuplicates(strutil.RemoveEmpty(strutil.TrimStrings(values)), false)
+		default:
+			return nil, fmt.Errorf("values of claim %q must be a string or a list of strings", claim)
+		}
+		if len(values) == 0 {
+			return nil, fmt.Errorf("no values given for claim %q", claim)
+		}
+
+		ret[claim] = values
+	}
+
+	return ret, nil
+}
+
+// claimValues returns the values of a claim of the token, which can be a
+// string or a list of strings. Values of other types are ignored.
+func claimValues(allClaims map[string]interface{}, claim string) []string {
+	switch v := allClaims[claim].(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		var values []string
+		for _, valueRaw := range v {
+			if value, ok := valueRaw.(string); ok {
+				values = append(values, value)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// boundClaimValues returns the values of a claim of the token matching the
+// patterns bound to the claim by the role. No values are returned if the claim
+// isn't bound.
+func boundClaimValues(role *jwtRole, allClaims map[string]interface{}, claim string) []string {
+	values := claimValues(allClaims, claim)
+	patterns := role.BoundClaims[claim]
+
+	var matched []string
+	for _, value := range values {
+		for _, pattern := range patterns {
+			if strutil.GlobbedStringsMatch(pattern, value) {
+				matched = append(matched, value)
+				break
+			}
+		}
+	}
+	return matched
+}
+
+// validateRoleBoundClaims checks that every claim bound by the role has at
+// least one value matching its patterns
+func validateRoleBoundClaims(role *jwtRole, allClaims map[string]interface{}) error {
+	for claim := range role.BoundClaims {
+		if len(boundClaimValues(role, allClaims, claim)) == 0 {
+			return fmt.Errorf("claim %q does not match any associated bound claim values", claim)
+		}
+	}
+	return nil
+}
+
+// templatedPolicies returns the policies of the role, along with the policies
+// computed from the templates of its claim_policies for the bound values of
+// the claims. Templated policies are never allowed to grant the root policy.
+func templatedPolicies(role *jwtRole, allClaims map[string]interface{}) []string {
+	policies := append([]string{}, role.Policies...)
+
+	// Sort the claims so that the policies are in a stable order
+	claims := make([]string, 0, len(role.ClaimPolicies))
+	for claim := range role.ClaimPolicies {
+		claims = append(claims, claim)
+	}
+	sort.Strings(claims)
+
+	for _, claim := range claims {
+		for _, value := range boundClaimValues(role, allClaims, claim) {
+			for _, template := range role.ClaimPolicies[claim] {
+				policy := strings.ToLower(strings.TrimSpace(strings.Replace(template, claimTemplate, value, -1)))
+				if policy == "" || policy == "root" || strings.Contains(policy, ",") {
+					continue
+				}
+				policies = append(policies, policy)
+			}
+		}
+	}
+
+	return policyutil.SanitizePolicies(policies, false)
+}
@@ -0,0 +1,122 @@
+package jwtauth
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/vault/logical"
+)
+
+func TestClaimPolicies_UnboundClaim(t *testing.T) {
+	b, s := getBackend(t)
+
+	// Any value of an unbound claim would become a policy name
+	resp := testRequest(t, b, s, logical.CreateOperation, "role/teams", map[string]interface{}{
+		"user_claim":      "sub",
+		"bound_audiences": "vault",
+		"claim_policies": map[string]interface{}{
+			"groups": "vault-{{claim}}",
+		},
+	})
+	if resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error, got: %#v", resp)
+	}
+
+	resp = testRequest(t, b, s, logical.CreateOperation, "role/teams", map[string]interface{}{
+		"user_claim":      "sub",
+		"bound_audiences": "vault",
+		"bound_claims": map[string]interface{}{
+			"groups": "team-*",
+		},
+		"claim_policies": map[string]interface{}{
+			"groups": "vault-{{claim}}",
+		},
+	})
+	if resp != nil && resp.IsError() {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	// Unbinding the claim later is rejected as well
+	resp = testRequest(t, b, s, logical.UpdateOperation, "role/teams", map[string]interface{}{
+		"bound_claims": map[string]interface{}{
+			"project": "vault",
+		},
+	})
+	if resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error, got: %#v", resp)
+	}
+}
+
+func TestClaimPolicies_Template(t *testing.T) {
+	b, s := getBackend(t)
+
+	resp := testRequest(t, b, s, logical.CreateOperation, "role/teams", map[string]interface{}{
+		"user_claim":      "sub",
+		"bound_audiences": "vault",
+		"claim_policies": map[string]interface{}{
+			"groups": "vault-team",
+		},
+		"bound_claims": map[string]interface{}{
+			"groups": "team-*",
+		},
+	})
+	if resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error for a template without %q, got: %#v", claimTemplate, resp)
+	}
+}
+
+func TestClaimPolicies_Login(t *testing.T) {
+	b, s, key := setupPubKeyBackend(t)
+
+	resp := testRequest(t, b, s, logical.CreateOperation, "role/teams", map[string]interface{}{
+		"role_type":       "jwt",
+		"user_claim":      "sub",
+		"bound_audiences": "vault",
+		"policies":        "default",
+		"bound_claims": map[string]interface{}{
+			"groups": []interface{}{"team-*", "root"},
+		},
+		"claim_policies": map[string]interface{}{
+			"groups": []interface{}{"vault-{{claim}}", "{{claim}}"},
+		},
+	})
+	if resp != nil && resp.IsError() {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	resp = testRequest(t, b, s, logical.UpdateOperation, "login", map[string]interface{}{
+		"role": "teams",
+		"jwt": signClaims(t, key, "", map[string]interface{}{
+			"sub":    "user",
+			"aud":    "vault",
+			"groups": []interface{}{"team-payments", "admins", "root", "Team-Ops"},
+		}),
+	})
+	if resp == nil || resp.IsError() || resp.Auth == nil {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	// Only the values matching bound_claims are used, and root is never
+	// granted through templates
+	expected := []string{"default", "team-payments", "vault-root", "vault-team-payments"}
+	if !reflect.DeepEqual(resp.Auth.Policies, expected) {
+		t.Fatalf("bad: %#v", resp.Auth.Policies)
+	}
+}
+
+func TestTemplatedPolicies_UnboundClaim(t *testing.T) {
+	// Roles stored before claims were required to be bound never template
+	// policies from unbound claims
+	role := &jwtRole{
+		Policies: []string{"default"},
+		ClaimPolicies: map[string][]string{
+			"groups": []string{"vault-{{claim}}"},
+		},
+	}
+	policies := templatedPolicies(role, map[string]interface{}{
+		"groups": []interface{}{"admins"},
+	})
+	if !reflect.DeepEqual(policies, []string{"default"}) {
+		t.Fatalf("bad: %#v", policies)
+	}
+}
@@ -0,0 +1,104 @@
+package jwtauth
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/helper/parseutil"
+)
+
+// CLIHandler logs in with the OIDC device authorization flow, for machines
+// without a browser
+type CLIHandler struct{}
+
+func (h *CLIHandler) Auth(c *api.Client, m map[string]string) (*api.Secret, error) {
+	mount, ok := m["mount"]
+	if !ok {
+		mount = "oidc"
+	}
+
+	role := m["role"]
+	if role == "" {
+		return nil, errors.New("'role' not supplied")
+	}
+
+	start, err := c.Logical().Write(fmt.Sprintf("auth/%s/device/start", mount), map[string]interface{}{
+		"role": role,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if start == nil || start.Data == nil {
+		return nil, errors.New("empty response from credential provider")
+	}
+
+	deviceCode, _ := start.Data["device_code"].(string)
+	userCode, _ := start.Data["user_code"].(string)
+	verificationURI, _ := start.Data["verification_uri"].(string)
+	verificationURIComplete, _ := start.Data["verification_uri_complete"].(string)
+	interval, err := parseutil.ParseDurationSecond(start.Data["interval"])
+	if err != nil || interval <= 0 {
+		interval = defaultDevicePollInterval * time.Second
+	}
+
+	// The instructions are printed to stderr, so that they are shown even if
+	// the output is redirected
+	fmt.Fprintf(os.Stderr, "To complete the login, open %s and enter the code %s\n", verificationURI, userCode)
+	if verificationURIComplete != "" {
+		fmt.Fprintf(os.Stderr, "or open %s\n", verificationURIComplete)
+	}
+	fmt.Fprintf(os.Stderr, "\nWaiting for the authorization...\n")
+
+	pollPath := fmt.Sprintf("auth/%s/device/poll", mount)
+	for {
+		time.Sleep(interval)
+
+		secret, err := c.Logical().Write(pollPath, map[string]interface{}{
+			"role":        role,
+			"device_code": deviceCode,
+		})
+		switch {
+		case err == nil:
+			if secret == nil {
+				return nil, errors.New("empty response from credential provider")
+			}
+			return secret, nil
+		case strings.Contains(err.Error(), deviceErrAuthorizationPending):
+		case strings.Contains(err.Error(), deviceErrSlowDown):
+			interval += 5 * time.Second
+		default:
+			return nil, err
+		}
+	}
+}
+
+func (h *CLIHandler) Help() string {
+	help := `
+Usage: vault login -method=oidc [CONFIG K=V...]
+
+  The OIDC auth method allows users to authenticate using an OIDC provider,
+  with the device authorization flow. The CLI prints a URL and a code, to be
+  entered in a browser on any device, and waits for the authorization.
+
+  Authenticate using the "engineering" role:
+
+      $ vault login -method=oidc role=engineering
+
+Configuration:
+
+  mount=<string>
+      Path where the JWT/OIDC credential method is mounted. This is usually
+      provided via the -path flag in the "vault login" command, but it can be
+      specified here as well. If specified here, it takes precedence over the
+      value for -path. The default value is "oidc".
+
+  role=<string>
+      Name of the role to log in against. This is required.
+`
+
+	return strings.TrimSpace(help)
+}
@@ -42,6 +42,14 @@ func pathConfig(b *jwtAuthBackend) *framework.Path {
 				Type:        framework.TypeString,
 				Description: "The value against which to match the 'iss' claim in a JWT. Optional.",
 			},
+			"oidc_client_id": {
+				Type:        framework.TypeString,
+				Description: `The OAuth client ID registered with the OIDC provider, used by the device authorization flow. Requires "oidc_discovery_url".`,
+			},
+			"oidc_client_secret": {
+				Type:        framework.TypeString,
+				Description: "The OAuth client secret, if the client is not a public client. It is never returned.",
+			},
 		},
 
 		Callbacks: map[logical.Operation]framework.OperationFunc{
@@ -106,6 +114,7 @@ func (b *jwtAuthBackend) pathConfigRead(ctx context.Context, req *logical.Reques
 			"jwt_validation_pubkeys": config.JWTValidationPubKeys,
 			"jwt_supported_algs":     config.JWTSupportedAlgs,
 			"bound_issuer":           config.BoundIssuer,
+			"oidc_client_id":         config.OIDCClientID,
 		},
 	}
 
@@ -119,6 +128,15 @@ func (b *jwtAuthBackend) pathConfigWrite(ctx context.Context, req *logical.Reque
 		JWTValidationPubKeys: d.Get("jwt_validation_pubkeys").([]string),
 		JWTSupportedAlgs:     d.Get("jwt_supported_algs").([]string),
 		BoundIssuer:          d.Get("bound_issuer").(string),
+		OIDCClientID:         d.Get("oidc_client_id").(string),
+		OIDCClientSecret:     d.Get("oidc_client_secret").(string),
+	}
+
+	switch {
+	case config.OIDCClientID != "" && config.OIDCDiscoveryURL == "":
+		return logical.ErrorResponse("'oidc_client_id' requires 'oidc_discovery_url' to be set"), nil
+	case config.OIDCClientSecret != "" && config.OIDCClientID == "":
+		return logical.ErrorResponse("'oidc_client_secret' requires 'oidc_client_id' to be set"), nil
 	}
 
 	// Run checks on values
@@ -167,6 +185,23 @@ func (b *jwtAuthBackend) pathConfigWrite(ctx context.Context, req *logical.Reque
 }
 
 func (b *jwtAuthBackend) createProvider(config *jwtConfig) (*oidc.Provider, error) {
+	tc, err := createHTTPClient(config)
+	if err != nil {
+		return nil, err
+	}
+	oidcCtx := context.WithValue(b.providerCtx, oauth2.HTTPClient, tc)
+
+	provider, err := oidc.NewProvider(oidcCtx, config.OIDCDiscoveryURL)
+	if err != nil {
+		return nil, errwrap.Wrapf("error creating provider with given values: {{err}}", err)
+	}
+
+	return provider, nil
+}
+
+// createHTTPClient returns a client for the requests to the OIDC provider,
+// trusting the configured CA certificates if any
+func createHTTPClient(config *jwtConfig) (*http.Client, error) {
 	var certPool *x509.CertPool
 	if config.OIDCDiscoveryCAPEM != "" {
 		certPool = x509.NewCertPool()
@@ -181,17 +216,9 @@ func (b *jwtAuthBackend) createProvider(config *jwtConfig) (*oidc.Provider, erro
 			RootCAs: certPool,
 		}
 	}
-	tc := &http.Client{
+	return &http.Client{
 		Transport: tr,
-	}
-	oidcCtx := context.WithValue(b.providerCtx, oauth2.HTTPClient, tc)
-
-	provider, err := oidc.NewProvider(oidcCtx, config.OIDCDiscoveryURL)
-	if err != nil {
-		return nil, errwrap.Wrapf("error creating provider with given values: {{err}}", err)
-	}
-
-	return provider, nil
+	}, nil
 }
 
 type jwtConfig struct {
@@ -200,6 +227,8 @@ type jwtConfig struct {
 	JWTValidationPubKeys []string `json:"jwt_validation_pubkeys"`
 	JWTSupportedAlgs     []string `json:"jwt_supported_algs"`
 	BoundIssuer          string   `json:"bound_issuer"`
+	OIDCClientID         string   `json:"oidc_client_id"`
+	OIDCClientSecret     string   `json:"oidc_client_secret"`
 
 	ParsedJWTPubKeys []interface{} `json:"-"`
 }
@@ -212,6 +241,8 @@ Configures the JWT authentication backend.
 The JWT authentication backend validates JWTs (or OIDC) using the configured
 credentials. If using OIDC Discovery, the URL must be provided, along
 with (optionally) the CA cert to use for the connection. If performing JWT
-validation locally, a set of public keys must be provided.
+validation locally, a set of public keys must be provided. Logging in with
+the OIDC device authorization flow additionally requires the OAuth client
+credentials registered with the provider.
 `
 )
@@ -32,6 +32,7 @@ func pathLogin(b *jwtAuthBackend) *framework.Path {
 		Callbacks: map[logical.Operation]framework.OperationFunc{
 			logical.UpdateOperation:         b.pathLogin,
 			logical.AliasLookaheadOperation: b.pathLogin,
+			logical.ResolveRoleOperation:    b.pathLoginResolveRole,
 		},
 
 		HelpSynopsis:    pathLoginHelpSyn,
@@ -39,6 +40,16 @@ func pathLogin(b *jwtAuthBackend) *framework.Path {
 	}
 }
 
+// pathLoginResolveRole returns the name of the role of the login
+func (b *jwtAuthBackend) pathLoginResolveRole(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	roleName := d.Get("role").(string)
+	if len(roleName) == 0 {
+		return logical.ErrorResponse("missing role"), nil
+	}
+
+	return logical.ResolveRoleResponse(roleName)
+}
+
 func (b *jwtAuthBackend) pathLogin(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
 	token := d.Get("jwt").(string)
 	if len(token) == 0 {
@@ -149,26 +160,50 @@ func (b *jwtAuthBackend) pathLogin(ctx context.Context, req *logical.Request, d
 			return logical.ErrorResponse(errwrap.Wrapf("unable to successfully parse all claims from token: {{err}}", err).Error()), nil
 		}
 
-		if role.BoundSubject != "" && role.BoundSubject != idToken.Subject {
-			return logical.ErrorResponse("sub claim does not match bound subject"), nil
-		}
-		if len(role.BoundAudiences) != 0 {
-			var found bool
-			for _, v := range role.BoundAudiences {
-				if strutil.StrListContains(idToken.Audience, v) {
-					found = true
-					break
-				}
-			}
-			if !found {
-				return logical.ErrorResponse("aud claim does not match any bound audience"), nil
-			}
+		if err := validateBoundClaims(role, idToken); err != nil {
+			return logical.ErrorResponse(err.Error()), nil
 		}
 
 	default:
 		return nil, errors.New("unhandled case during login")
 	}
 
+	return b.createAuthResponse(roleName, role, allClaims)
+}
+
+// validateBoundClaims checks the subject and audiences of an OIDC ID token
+// against the ones bound to the role
+func validateBoundClaims(role *jwtRole, idToken *oidc.IDToken) error {
+	if role.BoundSubject != "" && role.BoundSubject != idToken.Subject {
+		return errors.New("sub claim does not match bound subject")
+	}
+	if len(role.BoundAudiences) != 0 {
+		var found bool
+		for _, v := range role.BoundAudiences {
+			if strutil.StrListContains(idToken.Audience, v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return errors.New("aud claim does not match any bound audience")
+		}
+	}
+	return nil
+}
+
+// createAuthResponse returns the response of a login against the role, with
+// the claims of the validated token
+func (b *jwtAuthBackend) createAuthResponse(roleName string, role *jwtRole, allClaims map[string]interface{}) (*logical.Response, error) {
+	if err := validateRoleBoundClaims(role, allClaims); err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	workloadMetadata, err := validateWorkloadClaims(role, allClaims)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
 	userClaimRaw, ok := allClaims[role.UserClaim]
 	if !ok {
 		return logical.ErrorResponse(fmt.Sprintf("%q claim not found in token", role.UserClaim)), nil
@@ -227,9 +262,16 @@ func (b *jwtAuthBackend) pathLogin(ctx context.Context, req *logical.Request, d
 		}
 	}
 
+	metadata := map[string]string{
+		"role": roleName,
+	}
+	for key, value := range workloadMetadata {
+		metadata[key] = value
+	}
+
 	resp := &logical.Response{
 		Auth: &logical.Auth{
-			Policies:    role.Policies,
+			Policies:    templatedPolicies(role, allClaims),
 			DisplayName: userName,
 			Period:      role.Period,
 			NumUses:     role.NumUses,
@@ -240,9 +282,7 @@ func (b *jwtAuthBackend) pathLogin(ctx context.Context, req *logical.Request, d
 			InternalData: map[string]interface{}{
 				"role": roleName,
 			},
-			Metadata: map[string]string{
-				"role": roleName,
-			},
+			Metadata: metadata,
 			LeaseOptions: logical.LeaseOptions{
 				Renewable: true,
 				TTL:       role.TTL,
@@ -0,0 +1,312 @@
+package jwtauth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	oidc "github.com/coreos/go-oidc"
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/helper/cidrutil"
+	"github.com/hashicorp/vault/helper/strutil"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+const (
+	// deviceCodeGrantType is the grant type of the device access token
+	// requests, as defined by RFC 8628
+	deviceCodeGrantType = "urn:ietf:params:oauth:grant-type:device_code"
+
+	// defaultDevicePollInterval is the interval, in seconds, clients poll at
+	// if the provider doesn't specify it
+	defaultDevicePollInterval = 5
+
+	// The errors returned while the user has not completed the authorization
+	deviceErrAuthorizationPending = "authorization_pending"
+	deviceErrSlowDown             = "slow_down"
+)
+
+func pathOIDCDeviceStart(b *jwtAuthBackend) *framework.Path {
+	return &framework.Path{
+		Pattern: `device/start$`,
+		Fields: map[string]*framework.FieldSchema{
+			"role": {
+				Type:        framework.TypeLowerCaseString,
+				Description: "The role to log in against.",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathOIDCDeviceStart,
+		},
+
+		HelpSynopsis:    pathOIDCDeviceStartHelpSyn,
+		HelpDescription: pathOIDCDeviceStartHelpDesc,
+	}
+}
+
+func pathOIDCDevicePoll(b *jwtAuthBackend) *framework.Path {
+	return &framework.Path{
+		Pattern: `device/poll$`,
+		Fields: map[string]*framework.FieldSchema{
+			"role": {
+				Type:        framework.TypeLowerCaseString,
+				Description: "The role to log in against.",
+			},
+			"device_code": {
+				Type:        framework.TypeString,
+				Description: "The device code returned by device/start.",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathOIDCDevicePoll,
+		},
+
+		HelpSynopsis:    pathOIDCDevicePollHelpSyn,
+		HelpDescription: pathOIDCDevicePollHelpDesc,
+	}
+}
+
+// deviceFlowRole returns the role and the configuration of a device flow
+// request, or an error response if the flow can't be used
+func (b *jwtAuthBackend) deviceFlowRole(ctx context.Context, req *logical.Request, roleName string) (*jwtRole, *jwtConfig, *logical.Response, error) {
+	if roleName == "" {
+		return nil, nil, logical.ErrorResponse("missing role"), nil
+	}
+
+	role, err := b.role(ctx, req.Storage, roleName)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if role == nil {
+		return nil, nil, logical.ErrorResponse("role could not be found"), nil
+	}
+
+	if req.Connection != nil && !cidrutil.RemoteAddrIsOk(req.Connection.RemoteAddr, role.BoundCIDRs) {
+		return nil, nil, logical.ErrorResponse("request originated from invalid CIDR"), nil
+	}
+
+	config, err := b.config(ctx, req.Storage)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if config == nil {
+		return nil, nil, logical.ErrorResponse("could not load configuration"), nil
+	}
+	if config.OIDCClientID == "" {
+		return nil, nil, logical.ErrorResponse("the device authorization flow requires 'oidc_client_id' to be configured"), nil
+	}
+
+	return role, config, nil, nil
+}
+
+func (b *jwtAuthBackend) pathOIDCDeviceStart(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	role, config, errResp, err := b.deviceFlowRole(ctx, req, d.Get("role").(string))
+	if errResp != nil || err != nil {
+		return errResp, err
+	}
+
+	provider, err := b.getProvider(ctx, config)
+	if err != nil {
+		return nil, errwrap.Wrapf("error getting provider for login operation: {{err}}", err)
+	}
+
+	var discovery struct {
+		DeviceAuthorizationEndpoint string `json:"device_authorization_endpoint"`
+	}
+	if err := provider.Claims(&discovery); err != nil {
+		return nil, errwrap.Wrapf("error reading provider metadata: {{err}}", err)
+	}
+	if discovery.DeviceAuthorizationEndpoint == "" {
+		return logical.ErrorResponse("the OIDC provider does not support the device authorization flow"), nil
+	}
+
+	scopes := []string{oidc.ScopeOpenID}
+	for _, s := range role.OIDCScopes {
+		if !strutil.StrListContains(scopes, s) {
+			scopes = append(scopes, s)
+		}
+	}
+	form := url.Values{
+		"scope": {strings.Join(scopes, " ")},
+	}
+
+	var auth struct {
+		DeviceCode              string `json:"device_code"`
+		UserCode                string `json:"user_code"`
+		VerificationURI         string `json:"verification_uri"`
+		VerificationURL         string `json:"verification_url"`
+		VerificationURIComplete string `json:"verification_uri_complete"`
+		ExpiresIn               int    `json:"expires_in"`
+		Interval                int    `json:"interval"`
+	}
+	if err := postDeviceFlowForm(ctx, config, discovery.DeviceAuthorizationEndpoint, form, &auth); err != nil {
+		return logical.ErrorResponse(errwrap.Wrapf("error starting device authorization: {{err}}", err).Error()), nil
+	}
+	if auth.DeviceCode == "" || auth.UserCode == "" {
+		return logical.ErrorResponse("the OIDC provider returned no device or user code"), nil
+	}
+
+	// Some providers predate the RFC and use verification_url
+	if auth.VerificationURI == "" {
+		auth.VerificationURI = auth.VerificationURL
+	}
+	if auth.Interval <= 0 {
+		auth.Interval = defaultDevicePollInterval
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"device_code":               auth.DeviceCode,
+			"user_code":                 auth.UserCode,
+			"verification_uri":          auth.VerificationURI,
+			"verification_uri_complete": auth.VerificationURIComplete,
+			"expires_in":                auth.ExpiresIn,
+			"interval":                  auth.Interval,
+		},
+	}, nil
+}
+
+func (b *jwtAuthBackend) pathOIDCDevicePoll(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	roleName := d.Get("role").(string)
+	role, config, errResp, err := b.deviceFlowRole(ctx, req, roleName)
+	if errResp != nil || err != nil {
+		return errResp, err
+	}
+
+	deviceCode := d.Get("device_code").(string)
+	if deviceCode == "" {
+		return logical.ErrorResponse("missing device_code"), nil
+	}
+
+	provider, err := b.getProvider(ctx, config)
+	if err != nil {
+		return nil, errwrap.Wrapf("error getting provider for login operation: {{err}}", err)
+	}
+
+	form := url.Values{
+		"grant_type":  {deviceCodeGrantType},
+		"device_code": {deviceCode},
+	}
+
+	var token struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := postDeviceFlowForm(ctx, config, provider.Endpoint().TokenURL, form, &token); err != nil {
+		// Pending authorizations are reported with their bare error code, for
+		// clients to keep polling
+		if flowErr, ok := err.(*deviceFlowError); ok && (flowErr.Code == deviceErrAuthorizationPending || flowErr.Code == deviceErrSlowDown) {
+			return logical.ErrorResponse(flowErr.Code), nil
+		}
+		return logical.ErrorResponse(errwrap.Wrapf("error completing device authorization: {{err}}", err).Error()), nil
+	}
+	if token.IDToken == "" {
+		return logical.ErrorResponse("the OIDC provider returned no ID token"), nil
+	}
+
+	// The ID token was issued to Vault's client, so its audience is checked
+	verifier := provider.Verifier(&oidc.Config{
+		ClientID:             config.OIDCClientID,
+		SupportedSigningAlgs: config.JWTSupportedAlgs,
+	})
+
+	idToken, err := verifier.Verify(ctx, token.IDToken)
+	if err != nil {
+		return logical.ErrorResponse(errwrap.Wrapf("error validating signature: {{err}}", err).Error()), nil
+	}
+
+	allClaims := map[string]interface{}{}
+	if err := idToken.Claims(&allClaims); err != nil {
+		return logical.ErrorResponse(errwrap.Wrapf("unable to successfully parse all claims from token: {{err}}", err).Error()), nil
+	}
+
+	if err := validateBoundClaims(role, idToken); err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	return b.createAuthResponse(roleName, role, allClaims)
+}
+
+// deviceFlowError is an OAuth error returned by the provider
+type deviceFlowError struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description"`
+}
+
+func (e *deviceFlowError) Error() string {
+	if e.Description == "" {
+		return e.Code
+	}
+	return fmt.Sprintf("%s: %s", e.Code, e.Description)
+}
+
+// postDeviceFlowForm posts form to the endpoint of the provider, with the
+// client credentials, and decodes the JSON response into out
+func postDeviceFlowForm(ctx context.Context, config *jwtConfig, endpoint string, form url.Values, out interface{}) error {
+	client, err := createHTTPClient(config)
+	if err != nil {
+		return err
+	}
+
+	form.Set("client_id", config.OIDCClientID)
+	if config.OIDCClientSecret != "" {
+		form.Set("client_secret", config.OIDCClientSecret)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		flowErr := &deviceFlowError{}
+		if err := json.Unmarshal(body, flowErr); err != nil || flowErr.Code == "" {
+			return fmt.Errorf("unexpected response status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+		}
+		return flowErr
+	}
+
+	return json.Unmarshal(body, out)
+}
+
+const (
+	pathOIDCDeviceStartHelpSyn = `
+	Starts a login with the OIDC device authorization flow.
+	`
+	pathOIDCDeviceStartHelpDesc = `
+Starts an OIDC device authorization flow (RFC 8628) for the role, for clients
+without a browser. The user must visit the returned verification URI and enter
+the user code, while the client polls device/poll with the device code at the
+returned interval.
+`
+
+	pathOIDCDevicePollHelpSyn = `
+	Completes a login with the OIDC device authorization flow.
+	`
+	pathOIDCDevicePollHelpDesc = `
+Polls the OIDC provider for the authorization of a device code returned by
+device/start. While the user has not completed the authorization, the error
+"authorization_pending" is returned, or "slow_down" if the client must
+increase its polling interval by 5 seconds. Once authorized, the ID token is
+validated against the role and a Vault token is returned.
+`
+)
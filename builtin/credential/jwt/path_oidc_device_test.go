@@ -0,0 +1,218 @@
+package jwtauth
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+
+	"github.com/hashicorp/vault/logical"
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+// testDeviceProvider is an OIDC provider supporting the device authorization
+// flow, which authorizes device codes after the first poll
+type testDeviceProvider struct {
+	t      *testing.T
+	server *httptest.Server
+	key    *ecdsa.PrivateKey
+
+	scope string
+	polls int32
+}
+
+func newTestDeviceProvider(t *testing.T) *testDeviceProvider {
+	key, _ := testKey(t)
+	p := &testDeviceProvider{
+		t:   t,
+		key: key,
+	}
+	p.server = httptest.NewServer(http.HandlerFunc(p.serveHTTP))
+	return p
+}
+
+func (p *testDeviceProvider) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+
+	switch r.URL.Path {
+	case "/.well-known/openid-configuration":
+		enc.Encode(map[string]interface{}{
+			"issuer":                                p.server.URL,
+			"authorization_endpoint":                p.server.URL + "/auth",
+			"token_endpoint":                        p.server.URL + "/token",
+			"jwks_uri":                              p.server.URL + "/keys",
+			"device_authorization_endpoint":         p.server.URL + "/device",
+			"id_token_signing_alg_values_supported": []string{"ES256"},
+		})
+
+	case "/keys":
+		enc.Encode(jose.JSONWebKeySet{
+			Keys: []jose.JSONWebKey{
+				{Key: &p.key.PublicKey, KeyID: "test", Algorithm: "ES256", Use: "sig"},
+			},
+		})
+
+	case "/device":
+		if r.FormValue("client_id") != "vault" || r.FormValue("client_secret") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			enc.Encode(map[string]string{"error": "invalid_client"})
+			return
+		}
+		p.scope = r.FormValue("scope")
+		enc.Encode(map[string]interface{}{
+			"device_code":      "device-code",
+			"user_code":        "ABCD-EFGH",
+			"verification_uri": p.server.URL + "/activate",
+			"expires_in":       600,
+		})
+
+	case "/token":
+		if r.FormValue("grant_type") != deviceCodeGrantType || r.FormValue("device_code") != "device-code" {
+			w.WriteHeader(http.StatusBadRequest)
+			enc.Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		if atomic.AddInt32(&p.polls, 1) == 1 {
+			w.WriteHeader(http.StatusBadRequest)
+			enc.Encode(map[string]string{"error": deviceErrAuthorizationPending})
+			return
+		}
+		enc.Encode(map[string]interface{}{
+			"access_token": "access-token",
+			"token_type":   "Bearer",
+			"id_token": signClaims(p.t, p.key, "test", map[string]interface{}{
+				"iss":   p.server.URL,
+				"aud":   "vault",
+				"sub":   "1234",
+				"email": "user@example.com",
+			}),
+		})
+
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestOIDCDevice_Login(t *testing.T) {
+	b, s := getBackend(t)
+	provider := newTestDeviceProvider(t)
+	defer provider.server.Close()
+
+	// The device flow needs a client registered with the provider
+	resp := testRequest(t, b, s, logical.UpdateOperation, configPath, map[string]interface{}{
+		"oidc_discovery_url": provider.server.URL,
+		"jwt_supported_algs": "ES256",
+	})
+	if resp != nil && resp.IsError() {
+		t.Fatalf("bad: %#v", resp)
+	}
+	resp = testRequest(t, b, s, logical.CreateOperation, "role/dev", map[string]interface{}{
+		"user_claim":      "email",
+		"bound_audiences": "vault",
+		"oidc_scopes":     "email,profile",
+		"policies":        "dev",
+	})
+	if resp != nil && resp.IsError() {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	resp = testRequest(t, b, s, logical.UpdateOperation, "device/start", map[string]interface{}{
+		"role": "dev",
+	})
+	if resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error without a client ID, got: %#v", resp)
+	}
+
+	resp = testRequest(t, b, s, logical.UpdateOperation, configPath, map[string]interface{}{
+		"oidc_discovery_url": provider.server.URL,
+		"jwt_supported_algs": "ES256",
+		"oidc_client_id":     "vault",
+		"oidc_client_secret": "secret",
+	})
+	if resp != nil && resp.IsError() {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	resp = testRequest(t, b, s, logical.UpdateOperation, "device/start", map[string]interface{}{
+		"role": "dev",
+	})
+	if resp == nil || resp.IsError() {
+		t.Fatalf("bad: %#v", resp)
+	}
+	expected := map[string]interface{}{
+		"device_code":               "device-code",
+		"user_code":                 "ABCD-EFGH",
+		"verification_uri":          provider.server.URL + "/activate",
+		"verification_uri_complete": "",
+		"expires_in":                600,
+		"interval":                  defaultDevicePollInterval,
+	}
+	if !reflect.DeepEqual(resp.Data, expected) {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+	if provider.scope != "openid email profile" {
+		t.Fatalf("bad scope: %q", provider.scope)
+	}
+
+	// Polling before the user authorized the device is reported as pending
+	poll := map[string]interface{}{
+		"role":        "dev",
+		"device_code": "device-code",
+	}
+	resp = testRequest(t, b, s, logical.UpdateOperation, "device/poll", poll)
+	if resp == nil || resp.Data["error"] != deviceErrAuthorizationPending {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	resp = testRequest(t, b, s, logical.UpdateOperation, "device/poll", poll)
+	if resp == nil || resp.IsError() || resp.Auth == nil {
+		t.Fatalf("bad: %#v", resp)
+	}
+	if resp.Auth.Alias.Name != "user@example.com" || !reflect.DeepEqual(resp.Auth.Policies, []string{"dev"}) {
+		t.Fatalf("bad: %#v", resp.Auth)
+	}
+
+	// Unknown device codes are rejected by the provider
+	poll["device_code"] = "bogus"
+	resp = testRequest(t, b, s, logical.UpdateOperation, "device/poll", poll)
+	if resp == nil || !resp.IsError() || resp.Data["error"] == deviceErrAuthorizationPending {
+		t.Fatalf("bad: %#v", resp)
+	}
+}
+
+func TestOIDCDevice_Audience(t *testing.T) {
+	b, s := getBackend(t)
+	provider := newTestDeviceProvider(t)
+	defer provider.server.Close()
+
+	// The ID token is issued to the client of the flow, so it is rejected if
+	// it names another client
+	resp := testRequest(t, b, s, logical.UpdateOperation, configPath, map[string]interface{}{
+		"oidc_discovery_url": provider.server.URL,
+		"jwt_supported_algs": "ES256",
+		"oidc_client_id":     "other",
+	})
+	if resp != nil && resp.IsError() {
+		t.Fatalf("bad: %#v", resp)
+	}
+	resp = testRequest(t, b, s, logical.CreateOperation, "role/dev", map[string]interface{}{
+		"user_claim":  "email",
+		"bound_cidrs": "127.0.0.1/32",
+	})
+	if resp != nil && resp.IsError() {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	atomic.StoreInt32(&provider.polls, 1)
+	resp = testRequest(t, b, s, logical.UpdateOperation, "device/poll", map[string]interface{}{
+		"role":        "dev",
+		"device_code": "device-code",
+	})
+	if resp == nil || !resp.IsError() || resp.Auth != nil {
+		t.Fatalf("expected the ID token to be rejected, got: %#v", resp)
+	}
+}
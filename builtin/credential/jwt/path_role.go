@@ -68,6 +68,18 @@ TTL will be set to the value of this parameter.`,
 				Type:        framework.TypeCommaStringSlice,
 				Description: `Comma-separated list of 'aud' claims that are valid for login; any match is sufficient`,
 			},
+			"bound_claims": {
+				Type: framework.TypeMap,
+				Description: `Map of claims to the values, or lists of values, that are valid for
+login. Values may start or end with the '*' glob. Every claim must have at least
+one matching value.`,
+			},
+			"claim_policies": {
+				Type: framework.TypeMap,
+				Description: `Map of claims to templates of policies, or lists of templates, granted
+for each value of the claim, with "{{claim}}" replaced by the value. Every
+claim must be in bound_claims, and only the values matching it are used.`,
+			},
 			"user_claim": {
 				Type:        framework.TypeString,
 				Description: `The claim to use for the Identity entity alias name`,
@@ -80,6 +92,17 @@ TTL will be set to the value of this parameter.`,
 				Type:        framework.TypeString,
 				Description: `A pattern of delimiters used to allow the groups_claim to live outside of the top-level JWT structure. For instance, a "groups_claim" of "meta/user.name/groups" with this field set to "//" will expect nested structures named "meta", "user.name", and "groups". If this field was set to "/./" the groups information would expect to be via nested structures of "meta", "user", "name", and "groups".`,
 			},
+			"workload_provider": {
+				Type: framework.TypeString,
+				Description: `The provider of workload identities issuing the tokens of the role:
+"github_actions", "gitlab_ci" or "azure_ad". Its issuer is checked, the role must
+bind audiences and a claim identifying the tenants of the provider, and
+user_claim defaults to a claim of the provider.`,
+			},
+			"oidc_scopes": {
+				Type:        framework.TypeCommaStringSlice,
+				Description: `Comma-separated list of OIDC scopes requested in addition to "openid" when logging in with the device authorization flow`,
+			},
 			"bound_cidrs": {
 				Type: framework.TypeCommaStringSlice,
 				Description: `Comma-separated list of IP CIDRS that are allowed to 
@@ -122,9 +145,21 @@ type jwtRole struct {
 	BoundAudiences              []string                      `json:"bound_audiences"`
 	BoundSubject                string                        `json:"bound_subject"`
 	BoundCIDRs                  []*sockaddr.SockAddrMarshaler `json:"bound_cidrs"`
+	BoundClaims                 map[string][]string           `json:"bound_claims"`
 	UserClaim                   string                        `json:"user_claim"`
 	GroupsClaim                 string                        `json:"groups_claim"`
 	GroupsClaimDelimiterPattern string                        `json:"groups_claim_delimiter_pattern"`
+
+	// ClaimPolicies maps claims to the templates of the policies granted for
+	// each of their values
+	ClaimPolicies map[string][]string `json:"claim_policies"`
+
+	// OIDCScopes are the scopes requested by the device authorization flow
+	OIDCScopes []string `json:"oidc_scopes"`
+
+	// WorkloadProvider is the provider of workload identities issuing the
+	// tokens of the role, whose preset validates them
+	WorkloadProvider string `json:"workload_provider"`
 }
 
 // role takes a storage backend and the name and returns the role's storage
@@ -190,9 +225,13 @@ func (b *jwtAuthBackend) pathRoleRead(ctx context.Context, req *logical.Request,
 			"bound_audiences":                role.BoundAudiences,
 			"bound_subject":                  role.BoundSubject,
 			"bound_cidrs":                    role.BoundCIDRs,
+			"bound_claims":                   role.BoundClaims,
+			"claim_policies":                 role.ClaimPolicies,
 			"user_claim":                     role.UserClaim,
 			"groups_claim":                   role.GroupsClaim,
 			"groups_claim_delimiter_pattern": role.GroupsClaimDelimiterPattern,
+			"oidc_scopes":                    role.OIDCScopes,
+			"workload_provider":              role.WorkloadProvider,
 		},
 	}
 
@@ -287,9 +326,42 @@ func (b *jwtAuthBackend) pathRoleCreateUpdate(ctx context.Context, req *logical.
 		role.BoundCIDRs = parsedCIDRs
 	}
 
+	if boundClaimsRaw, ok := data.GetOk("bound_claims"); ok {
+		boundClaims, err := parseClaimValueMap(boundClaimsRaw.(map[string]interface{}))
+		if err != nil {
+			return logical.ErrorResponse(errwrap.Wrapf("error parsing bound_claims: {{err}}", err).Error()), nil
+		}
+		role.BoundClaims = boundClaims
+	}
+
+	if claimPoliciesRaw, ok := data.GetOk("claim_policies"); ok {
+		claimPolicies, err := parseClaimValueMap(claimPoliciesRaw.(map[string]interface{}))
+		if err != nil {
+			return logical.ErrorResponse(errwrap.Wrapf("error parsing claim_policies: {{err}}", err).Error()), nil
+		}
+		for claim, templates := range claimPolicies {
+			for _, template := range templates {
+				if !strings.Contains(template, claimTemplate) {
+					return logical.ErrorResponse(fmt.Sprintf("policy template %q of claim %q does not contain %q", template, claim, claimTemplate)), nil
+				}
+			}
+		}
+		role.ClaimPolicies = claimPolicies
+	}
+
+	if workloadProvider, ok := data.GetOk("workload_provider"); ok {
+		role.WorkloadProvider = workloadProvider.(string)
+		if _, ok := workloadProviders[role.WorkloadProvider]; role.WorkloadProvider != "" && !ok {
+			return logical.ErrorResponse(fmt.Sprintf("unknown workload provider %q, must be one of %s", role.WorkloadProvider, strings.Join(workloadProviderNames(), ", "))), nil
+		}
+	}
+
 	if userClaim, ok := data.GetOk("user_claim"); ok {
 		role.UserClaim = userClaim.(string)
 	}
+	if provider, ok := workloadProviders[role.WorkloadProvider]; ok && role.UserClaim == "" {
+		role.UserClaim = provider.userClaim
+	}
 	if role.UserClaim == "" {
 		return logical.ErrorResponse("a user claim must be defined on the role"), nil
 	}
@@ -302,6 +374,10 @@ func (b *jwtAuthBackend) pathRoleCreateUpdate(ctx context.Context, req *logical.
 		role.GroupsClaimDelimiterPattern = groupsClaimDelimiterPattern.(string)
 	}
 
+	if oidcScopes, ok := data.GetOk("oidc_scopes"); ok {
+		role.OIDCScopes = oidcScopes.([]string)
+	}
+
 	// Validate claim/delims
 	if role.GroupsClaim != "" {
 		if _, err := parseClaimWithDelimiters(role.GroupsClaim, role.GroupsClaimDelimiterPattern); err != nil {
@@ -311,10 +387,25 @@ func (b *jwtAuthBackend) pathRoleCreateUpdate(ctx context.Context, req *logical.
 
 	if len(role.BoundAudiences) == 0 &&
 		len(role.BoundCIDRs) == 0 &&
+		len(role.BoundClaims) == 0 &&
 		role.BoundSubject == "" {
 		return logical.ErrorResponse("must have at least one bound constraint when creating/updating a role"), nil
 	}
 
+	// Policy names are taken from the claims, so they are limited to the values
+	// bound by the role
+	for claim := range role.ClaimPolicies {
+		if _, ok := role.BoundClaims[claim]; !ok {
+			return logical.ErrorResponse(fmt.Sprintf("claim %q of claim_policies must be in bound_claims", claim)), nil
+		}
+	}
+
+	if role.WorkloadProvider != "" {
+		if err := validateWorkloadRole(role); err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+	}
+
 	// Check that the TTL value provided is less than the MaxTTL.
 	// Sanitizing the TTL and MaxTTL is not required now and can be performed
 	// at credential issue time.
@@ -0,0 +1,125 @@
+package jwtauth
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// workloadProvider is a preset validating the tokens a provider of workload
+// identities issues to the CI jobs or services running on it
+type workloadProvider struct {
+	// userClaim is the default claim of the roles used as the name of the
+	// alias
+	userClaim string
+
+	// tenantClaims are the claims identifying the tenants of the provider.
+	// Roles must bind at least one of them, as the provider issues tokens to
+	// all its tenants.
+	tenantClaims []string
+
+	// issuers returns the issuers allowed for the claims of a token, or nil
+	// if any issuer is allowed
+	issuers func(allClaims map[string]interface{}) []string
+
+	// metadataClaims are the claims copied to the metadata of the tokens
+	metadataClaims []string
+}
+
+var workloadProviders = map[string]*workloadProvider{
+	"github_actions": {
+		userClaim:    "repository",
+		tenantClaims: []string{"repository", "repository_id", "repository_owner", "repository_owner_id", "sub"},
+		issuers: func(map[string]interface{}) []string {
+			return []string{"https://token.actions.githubusercontent.com"}
+		},
+		metadataClaims: []string{"repository", "ref", "environment", "workflow", "actor", "run_id"},
+	},
+	// GitLab instances may be self-managed, so the issuer can't be checked
+	"gitlab_ci": {
+		userClaim:      "project_path",
+		tenantClaims:   []string{"project_path", "project_id", "namespace_path", "namespace_id", "sub"},
+		metadataClaims: []string{"project_path", "ref", "environment", "pipeline_id", "job_id", "user_login"},
+	},
+	"azure_ad": {
+		userClaim:    "oid",
+		tenantClaims: []string{"tid"},
+		issuers: func(allClaims map[string]interface{}) []string {
+			tenant, _ := allClaims["tid"].(string)
+			return []string{
+				"https://login.microsoftonline.com/" + tenant + "/v2.0",
+				"https://sts.windows.net/" + tenant + "/",
+			}
+		},
+		metadataClaims: []string{"tid", "oid", "appid", "azp"},
+	},
+}
+
+// workloadProviderNames returns the names of the workload providers
+func workloadProviderNames() []string {
+	names := make([]string, 0, len(workloadProviders))
+	for name := range workloadProviders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// validateWorkloadRole checks that the role binds its tokens to a tenant of
+// its workload provider, and to audiences
+func validateWorkloadRole(role *jwtRole) error {
+	provider, ok := workloadProviders[role.WorkloadProvider]
+	if !ok {
+		return fmt.Errorf("unknown workload provider %q", role.WorkloadProvider)
+	}
+
+	if len(role.BoundAudiences) == 0 {
+		return fmt.Errorf("roles of the %q workload provider must have bound audiences", role.WorkloadProvider)
+	}
+
+	for _, claim := range provider.tenantClaims {
+		if _, ok := role.BoundClaims[claim]; ok {
+			return nil
+		}
+		if claim == "sub" && role.BoundSubject != "" {
+			return nil
+		}
+	}
+	return fmt.Errorf("roles of the %q workload provider must bind at least one of the claims %s", role.WorkloadProvider, strings.Join(provider.tenantClaims, ", "))
+}
+
+// validateWorkloadClaims checks that the token was issued by the workload
+// provider of the role, if any, and returns the metadata taken from its
+// claims
+func validateWorkloadClaims(role *jwtRole, allClaims map[string]interface{}) (map[string]string, error) {
+	if role.WorkloadProvider == "" {
+		return nil, nil
+	}
+
+	provider, ok := workloadProviders[role.WorkloadProvider]
+	if !ok {
+		return nil, fmt.Errorf("unknown workload provider %q", role.WorkloadProvider)
+	}
+
+	if provider.issuers != nil {
+		issuer, _ := allClaims["iss"].(string)
+		var found bool
+		for _, allowed := range provider.issuers(allClaims) {
+			if issuer == allowed {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("iss claim does not match the issuers of the %q workload provider", role.WorkloadProvider)
+		}
+	}
+
+	metadata := make(map[string]string)
+	for _, claim := range provider.metadataClaims {
+		if value, ok := allClaims[claim].(string); ok && value != "" {
+			metadata[claim] = value
+		}
+	}
+	return metadata, nil
+}
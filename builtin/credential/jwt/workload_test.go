@@ -0,0 +1,268 @@
+package jwtauth
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/vault/logical"
+)
+
+// workloadLogin logs in with a token of the claims against a role of the
+// workload provider
+func workloadLogin(t *testing.T, provider string, role map[string]interface{}, claims map[string]interface{}) (*logical.Response, *logical.Response) {
+	t.Helper()
+	b, s, key := setupPubKeyBackend(t)
+
+	data := map[string]interface{}{
+		"role_type":         "jwt",
+		"workload_provider": provider,
+		"bound_audiences":   "vault",
+		"policies":          "ci",
+	}
+	for k, v := range role {
+		data[k] = v
+	}
+	resp := testRequest(t, b, s, logical.CreateOperation, "role/ci", data)
+	if resp != nil && resp.IsError() {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	resp = testRequest(t, b, s, logical.ReadOperation, "role/ci", nil)
+	if resp == nil || resp.IsError() {
+		t.Fatalf("bad: %#v", resp)
+	}
+	roleResp := resp
+
+	resp = testRequest(t, b, s, logical.UpdateOperation, "login", map[string]interface{}{
+		"role": "ci",
+		"jwt":  signClaims(t, key, "", claims),
+	})
+	return roleResp, resp
+}
+
+func TestWorkload_Role(t *testing.T) {
+	tests := map[string]struct {
+		data map[string]interface{}
+		ok   bool
+	}{
+		"unknown provider": {
+			data: map[string]interface{}{
+				"workload_provider": "travis_ci",
+				"bound_audiences":   "vault",
+				"bound_subject":     "repo:org/repo:ref:refs/heads/master",
+			},
+		},
+		"github without audiences": {
+			data: map[string]interface{}{
+				"workload_provider": "github_actions",
+				"bound_claims":      map[string]interface{}{"repository": "org/repo"},
+			},
+		},
+		"github without tenant": {
+			data: map[string]interface{}{
+				"workload_provider": "github_actions",
+				"bound_audiences":   "vault",
+				"bound_claims":      map[string]interface{}{"ref": "refs/heads/master"},
+			},
+		},
+		"github with subject": {
+			data: map[string]interface{}{
+				"workload_provider": "github_actions",
+				"bound_audiences":   "vault",
+				"bound_subject":     "repo:org/repo:ref:refs/heads/master",
+			},
+			ok: true,
+		},
+		"gitlab without tenant": {
+			data: map[string]interface{}{
+				"workload_provider": "gitlab_ci",
+				"bound_audiences":   "vault",
+				"bound_claims":      map[string]interface{}{"ref": "master"},
+			},
+		},
+		"gitlab with namespace": {
+			data: map[string]interface{}{
+				"workload_provider": "gitlab_ci",
+				"bound_audiences":   "vault",
+				"bound_claims":      map[string]interface{}{"namespace_path": "org"},
+			},
+			ok: true,
+		},
+		"azure with subject": {
+			data: map[string]interface{}{
+				"workload_provider": "azure_ad",
+				"bound_audiences":   "vault",
+				"bound_subject":     "app",
+			},
+		},
+		"azure with tenant": {
+			data: map[string]interface{}{
+				"workload_provider": "azure_ad",
+				"bound_audiences":   "vault",
+				"bound_claims":      map[string]interface{}{"tid": "tenant"},
+			},
+			ok: true,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			b, s := getBackend(t)
+			resp := testRequest(t, b, s, logical.CreateOperation, "role/ci", tt.data)
+			if tt.ok && resp != nil && resp.IsError() {
+				t.Fatalf("bad: %#v", resp)
+			}
+			if !tt.ok && (resp == nil || !resp.IsError()) {
+				t.Fatalf("expected an error, got: %#v", resp)
+			}
+		})
+	}
+}
+
+func TestWorkload_GitHubActions(t *testing.T) {
+	role := map[string]interface{}{
+		"bound_claims": map[string]interface{}{"repository": "org/repo"},
+	}
+	claims := map[string]interface{}{
+		"iss":         "https://token.actions.githubusercontent.com",
+		"aud":         "vault",
+		"sub":         "repo:org/repo:ref:refs/heads/master",
+		"repository":  "org/repo",
+		"ref":         "refs/heads/master",
+		"workflow":    "release",
+		"actor":       "octocat",
+		"run_id":      "42",
+		"environment": "",
+	}
+
+	roleResp, resp := workloadLogin(t, "github_actions", role, claims)
+	if roleResp.Data["user_claim"] != "repository" {
+		t.Fatalf("bad user claim: %#v", roleResp.Data["user_claim"])
+	}
+	if resp == nil || resp.IsError() || resp.Auth == nil {
+		t.Fatalf("bad: %#v", resp)
+	}
+	if resp.Auth.Alias.Name != "org/repo" {
+		t.Fatalf("bad alias: %q", resp.Auth.Alias.Name)
+	}
+	expected := map[string]string{
+		"role":       "ci",
+		"repository": "org/repo",
+		"ref":        "refs/heads/master",
+		"workflow":   "release",
+		"actor":      "octocat",
+		"run_id":     "42",
+	}
+	if !reflect.DeepEqual(resp.Auth.Metadata, expected) {
+		t.Fatalf("bad metadata: %#v", resp.Auth.Metadata)
+	}
+
+	// Tokens of another issuer are rejected, even if signed by a trusted key
+	claims["iss"] = "https://gitlab.com"
+	_, resp = workloadLogin(t, "github_actions", role, claims)
+	if resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error, got: %#v", resp)
+	}
+
+	// Tokens of other repositories are rejected
+	claims["iss"] = "https://token.actions.githubusercontent.com"
+	claims["repository"] = "evil/repo"
+	_, resp = workloadLogin(t, "github_actions", role, claims)
+	if resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error, got: %#v", resp)
+	}
+}
+
+func TestWorkload_GitLabCI(t *testing.T) {
+	role := map[string]interface{}{
+		"bound_claims": map[string]interface{}{"project_path": "org/project"},
+	}
+	claims := map[string]interface{}{
+		"iss":          "https://gitlab.example.com",
+		"aud":          "vault",
+		"sub":          "project_path:org/project:ref_type:branch:ref:master",
+		"project_path": "org/project",
+		"ref":          "master",
+		"pipeline_id":  "7",
+		"job_id":       "8",
+		"user_login":   "dev",
+	}
+
+	// Self-managed instances have their own issuer
+	roleResp, resp := workloadLogin(t, "gitlab_ci", role, claims)
+	if roleResp.Data["user_claim"] != "project_path" {
+		t.Fatalf("bad user claim: %#v", roleResp.Data["user_claim"])
+	}
+	if resp == nil || resp.IsError() || resp.Auth == nil {
+		t.Fatalf("bad: %#v", resp)
+	}
+	if resp.Auth.Alias.Name != "org/project" {
+		t.Fatalf("bad alias: %q", resp.Auth.Alias.Name)
+	}
+	expected := map[string]string{
+		"role":         "ci",
+		"project_path": "org/project",
+		"ref":          "master",
+		"pipeline_id":  "7",
+		"job_id":       "8",
+		"user_login":   "dev",
+	}
+	if !reflect.DeepEqual(resp.Auth.Metadata, expected) {
+		t.Fatalf("bad metadata: %#v", resp.Auth.Metadata)
+	}
+
+	// Tokens issued for another audience are rejected
+	claims["aud"] = "other"
+	_, resp = workloadLogin(t, "gitlab_ci", role, claims)
+	if resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error, got: %#v", resp)
+	}
+}
+
+func TestWorkload_AzureAD(t *testing.T) {
+	role := map[string]interface{}{
+		"bound_claims": map[string]interface{}{"tid": "tenant"},
+	}
+	claims := map[string]interface{}{
+		"iss":   "https://login.microsoftonline.com/tenant/v2.0",
+		"aud":   "vault",
+		"sub":   "object",
+		"tid":   "tenant",
+		"oid":   "object",
+		"appid": "app",
+	}
+
+	roleResp, resp := workloadLogin(t, "azure_ad", role, claims)
+	if roleResp.Data["user_claim"] != "oid" {
+		t.Fatalf("bad user claim: %#v", roleResp.Data["user_claim"])
+	}
+	if resp == nil || resp.IsError() || resp.Auth == nil {
+		t.Fatalf("bad: %#v", resp)
+	}
+	if resp.Auth.Alias.Name != "object" {
+		t.Fatalf("bad alias: %q", resp.Auth.Alias.Name)
+	}
+	expected := map[string]string{
+		"role":  "ci",
+		"tid":   "tenant",
+		"oid":   "object",
+		"appid": "app",
+	}
+	if !reflect.DeepEqual(resp.Auth.Metadata, expected) {
+		t.Fatalf("bad metadata: %#v", resp.Auth.Metadata)
+	}
+
+	// v1 tokens are issued by the security token service of the tenant
+	claims["iss"] = "https://sts.windows.net/tenant/"
+	_, resp = workloadLogin(t, "azure_ad", role, claims)
+	if resp == nil || resp.IsError() {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	// The issuer must be the one of the tenant of the token
+	claims["iss"] = "https://login.microsoftonline.com/other/v2.0"
+	_, resp = workloadLogin(t, "azure_ad", role, claims)
+	if resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error, got: %#v", resp)
+	}
+}
@@ -82,13 +82,9 @@ func (b *kubeAuthBackend) config(ctx context.Context, s logical.Storage) (*kubeC
 		return nil, err
 	}
 
-	// Parse the public keys from the CertificatesBytes
-	conf.PublicKeys = make([]interface{}, len(conf.PEMKeys))
-	for i, cert := range conf.PEMKeys {
-		conf.PublicKeys[i], err = parsePublicKeyPEM([]byte(cert))
-		if err != nil {
-			return nil, err
-		}
+	// Parse the public keys from the CertificatesBytes and the JWKS
+	if err := conf.parsePublicKeys(); err != nil {
+		return nil, err
 	}
 
 	return conf, nil
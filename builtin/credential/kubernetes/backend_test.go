@@ -0,0 +1,83 @@
+package kubeauth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/briankassouf/jose/crypto"
+	"github.com/briankassouf/jose/jws"
+	"github.com/hashicorp/vault/logical"
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+const testIssuer = "https://kubernetes.default.svc"
+
+func getBackend(t *testing.T) (*kubeAuthBackend, logical.Storage) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+
+	b := Backend()
+	if err := b.Setup(context.Background(), config); err != nil {
+		t.Fatalf("unable to create backend: %v", err)
+	}
+	return b, config.StorageView
+}
+
+// testRequest makes a request against the backend from a local address
+func testRequest(t *testing.T, b logical.Backend, s logical.Storage, op logical.Operation, path string, data map[string]interface{}) (*logical.Response, error) {
+	t.Helper()
+
+	return b.HandleRequest(context.Background(), &logical.Request{
+		Operation:  op,
+		Path:       path,
+		Storage:    s,
+		Data:       data,
+		Connection: &logical.Connection{RemoteAddr: "127.0.0.1"},
+	})
+}
+
+// testKey returns a signing key, along with a JWKS of its public key
+func testKey(t *testing.T) (*ecdsa.PrivateKey, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwks, err := json.Marshal(jose.JSONWebKeySet{
+		Keys: []jose.JSONWebKey{
+			{Key: &key.PublicKey, KeyID: "test", Algorithm: "ES256", Use: "sig"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key, string(jwks)
+}
+
+// projectedToken returns a projected service account JWT of the service
+// account "vault-auth" in the namespace "default", signed by the key
+func projectedToken(t *testing.T, key *ecdsa.PrivateKey, issuer, audience string, expiry time.Time) string {
+	claims := jws.Claims{
+		"iss": issuer,
+		"aud": []string{audience},
+		"sub": "system:serviceaccount:default:vault-auth",
+		"iat": time.Now().Unix(),
+		"exp": expiry.Unix(),
+		"kubernetes.io": map[string]interface{}{
+			"namespace": "default",
+			"serviceaccount": map[string]interface{}{
+				"name": "vault-auth",
+				"uid":  "d77f89bc-9055-11e7-a068-0800276d99bf",
+			},
+		},
+	}
+	token, err := jws.NewJWT(claims, crypto.SigningMethodES256).Serialize(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(token)
+}
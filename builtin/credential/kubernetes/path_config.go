@@ -5,12 +5,16 @@ import (
 	"crypto/ecdsa"
 	"crypto/rsa"
 	"crypto/x509"
+	"encoding/json"
 	"encoding/pem"
 	"errors"
+	"fmt"
 
 	"github.com/briankassouf/jose/jws"
+	"github.com/hashicorp/errwrap"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
+	jose "gopkg.in/square/go-jose.v2"
 )
 
 // pathConfig returns the path configuration for CRUD operations on the backend
@@ -45,6 +49,28 @@ JWTs. If a certificate is given, its public key will be
 extracted. Not every installation of Kuberentes exposes these keys.`,
 				DisplayName: "Service account verification keys",
 			},
+			"jwks": {
+				Type: framework.TypeString,
+				Description: `Optional JSON Web Key Set, as served by the service account
+issuer discovery endpoint of Kubernetes, with the public keys used to verify
+the signatures of service account JWTs in addition to pem_keys.`,
+				DisplayName: "Service account verification JWKS",
+			},
+			"issuer": {
+				Type: framework.TypeString,
+				Description: `Optional JWT issuer, the "iss" claim service account JWTs must
+have. Defaults to "kubernetes/serviceaccount", the issuer of legacy service
+account tokens.`,
+				DisplayName: "Service account issuer",
+			},
+			"disable_token_review": {
+				Type: framework.TypeBool,
+				Description: `If set, service account JWTs are only validated with pem_keys
+and jwks, without calling the TokenReview API. Logins are then faster and
+don't load the API server, but tokens of deleted service accounts remain valid
+until they expire.`,
+				DisplayName: "Disable TokenReview",
+			},
 		},
 		Callbacks: map[logical.Operation]framework.OperationFunc{
 			logical.UpdateOperation: b.pathConfigWrite(),
@@ -68,9 +94,12 @@ func (b *kubeAuthBackend) pathConfigRead() framework.OperationFunc {
 			// Create a map of data to be returned
 			resp := &logical.Response{
 				Data: map[string]interface{}{
-					"kubernetes_host":    config.Host,
-					"kubernetes_ca_cert": config.CACert,
-					"pem_keys":           config.PEMKeys,
+					"kubernetes_host":      config.Host,
+					"kubernetes_ca_cert":   config.CACert,
+					"pem_keys":             config.PEMKeys,
+					"jwks":                 config.JWKS,
+					"issuer":               config.Issuer,
+					"disable_token_review": config.DisableTokenReview,
 				},
 			}
 
@@ -88,9 +117,15 @@ func (b *kubeAuthBackend) pathConfigWrite() framework.OperationFunc {
 		}
 
 		pemList := data.Get("pem_keys").([]string)
+		jwks := data.Get("jwks").(string)
 		caCert := data.Get("kubernetes_ca_cert").(string)
-		if len(pemList) == 0 && len(caCert) == 0 {
-			return logical.ErrorResponse("one of pem_keys or kubernetes_ca_cert must be set"), nil
+		if len(pemList) == 0 && len(jwks) == 0 && len(caCert) == 0 {
+			return logical.ErrorResponse("one of pem_keys, jwks or kubernetes_ca_cert must be set"), nil
+		}
+
+		disableTokenReview := data.Get("disable_token_review").(bool)
+		if disableTokenReview && len(pemList) == 0 && len(jwks) == 0 {
+			return logical.ErrorResponse("one of pem_keys or jwks must be set to disable the TokenReview API"), nil
 		}
 
 		tokenReviewer := data.Get("token_reviewer_jwt").(string)
@@ -103,19 +138,17 @@ func (b *kubeAuthBackend) pathConfigWrite() framework.OperationFunc {
 		}
 
 		config := &kubeConfig{
-			PublicKeys:       make([]interface{}, len(pemList)),
-			PEMKeys:          pemList,
-			Host:             host,
-			CACert:           caCert,
-			TokenReviewerJWT: tokenReviewer,
+			PEMKeys:            pemList,
+			JWKS:               jwks,
+			Host:               host,
+			CACert:             caCert,
+			TokenReviewerJWT:   tokenReviewer,
+			Issuer:             data.Get("issuer").(string),
+			DisableTokenReview: disableTokenReview,
 		}
 
-		var err error
-		for i, pem := range pemList {
-			config.PublicKeys[i], err = parsePublicKeyPEM([]byte(pem))
-			if err != nil {
-				return logical.ErrorResponse(err.Error()), nil
-			}
+		if err := config.parsePublicKeys(); err != nil {
+			return logical.ErrorResponse(err.Error()), nil
 		}
 
 		entry, err := logical.StorageEntryJSON(configPath, config)
@@ -144,6 +177,72 @@ type kubeConfig struct {
 	CACert string `json:"ca_cert"`
 	// TokenReviewJWT is the bearer to use during the TokenReview API call
 	TokenReviewerJWT string `json:"token_reviewer_jwt"`
+	// JWKS is the JSON Web Key Set with additional public keys used to verify
+	// JWTs
+	JWKS string `json:"jwks"`
+	// Issuer is the expected issuer of the JWTs, if not the legacy one
+	Issuer string `json:"issuer"`
+	// DisableTokenReview, if set, validates JWTs with the public keys only,
+	// without calling the TokenReview API
+	DisableTokenReview bool `json:"disable_token_review"`
+}
+
+// issuer returns the expected "iss" claim of the JWTs
+func (c *kubeConfig) issuer() string {
+	if c.Issuer != "" {
+		return c.Issuer
+	}
+	return expectedJWTIssuer
+}
+
+// parsePublicKeys parses the public key objects from the PEM keys and the
+// JWKS
+func (c *kubeConfig) parsePublicKeys() error {
+	c.PublicKeys = make([]interface{}, 0, len(c.PEMKeys))
+	for _, pem := range c.PEMKeys {
+		key, err := parsePublicKeyPEM([]byte(pem))
+		if err != nil {
+			return err
+		}
+		c.PublicKeys = append(c.PublicKeys, key)
+	}
+
+	if c.JWKS != "" {
+		keys, err := parsePublicKeysJWKS([]byte(c.JWKS))
+		if err != nil {
+			return errwrap.Wrapf("error parsing jwks: {{err}}", err)
+		}
+		c.PublicKeys = append(c.PublicKeys, keys...)
+	}
+
+	return nil
+}
+
+// parsePublicKeysJWKS is used to parse the RSA and ECDSA public keys of a
+// JSON Web Key Set
+func parsePublicKeysJWKS(data []byte) ([]interface{}, error) {
+	var jwks jose.JSONWebKeySet
+	if err := json.Unmarshal(data, &jwks); err != nil {
+		return nil, err
+	}
+	if len(jwks.Keys) == 0 {
+		return nil, errors.New("the key set does not contain any key")
+	}
+
+	keys := make([]interface{}, 0, len(jwks.Keys))
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		switch key := jwk.Public().Key.(type) {
+		case *rsa.PublicKey, *ecdsa.PublicKey:
+			keys = append(keys, key)
+		default:
+			return nil, fmt.Errorf("key %q is not a RSA or ECDSA key", jwk.KeyID)
+		}
+	}
+
+	return keys, nil
 }
 
 // PasrsePublicKeyPEM is used to parse RSA and ECDSA public keys from PEMs
@@ -48,6 +48,7 @@ func pathLogin(b *kubeAuthBackend) *framework.Path {
 		Callbacks: map[logical.Operation]framework.OperationFunc{
 			logical.UpdateOperation:         b.pathLogin(),
 			logical.AliasLookaheadOperation: b.aliasLookahead(),
+			logical.ResolveRoleOperation:    b.resolveRole(),
 		},
 
 		HelpSynopsis:    pathLoginHelpSyn,
@@ -97,10 +98,13 @@ func (b *kubeAuthBackend) pathLogin() framework.OperationFunc {
 			return nil, err
 		}
 
-		// look up the JWT token in the kubernetes API
-		err = serviceAccount.lookup(jwtStr, b.reviewFactory(config))
-		if err != nil {
-			return nil, err
+		// look up the JWT token in the kubernetes API, unless it's only
+		// validated with the configured public keys
+		if !config.DisableTokenReview {
+			err = serviceAccount.lookup(jwtStr, role.Audience, b.reviewFactory(config))
+			if err != nil {
+				return nil, err
+			}
 		}
 
 		resp := &logical.Response{
@@ -141,6 +145,18 @@ func (b *kubeAuthBackend) pathLogin() framework.OperationFunc {
 	}
 }
 
+// resolveRole returns the name of the role of the login
+func (b *kubeAuthBackend) resolveRole() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+		roleName := data.Get("role").(string)
+		if len(roleName) == 0 {
+			return logical.ErrorResponse("missing role"), nil
+		}
+
+		return logical.ResolveRoleResponse(roleName)
+	}
+}
+
 // aliasLookahead returns the alias object with the SA UID from the JWT
 // Claims.
 func (b *kubeAuthBackend) aliasLookahead() framework.OperationFunc {
@@ -182,7 +198,7 @@ func (b *kubeAuthBackend) parseAndValidateJWT(jwtStr string, role *roleStorageEn
 	sa := &serviceAccount{}
 	validator := &jwt.Validator{
 		Expected: jwt.Claims{
-			"iss": expectedJWTIssuer,
+			"iss": config.issuer(),
 		},
 		Fn: func(c jwt.Claims) error {
 			// Decode claims into a service account object
@@ -205,6 +221,14 @@ func (b *kubeAuthBackend) parseAndValidateJWT(jwtStr string, role *roleStorageEn
 				}
 			}
 
+			// verify the JWT was issued for the audience of the role
+			if role.Audience != "" {
+				aud, _ := c.Audience()
+				if !strutil.StrListContains(aud, role.Audience) {
+					return errors.New("audience not authorized")
+				}
+			}
+
 			return nil
 		},
 	}
@@ -214,7 +238,8 @@ func (b *kubeAuthBackend) parseAndValidateJWT(jwtStr string, role *roleStorageEn
 	}
 
 	// If we don't have any public keys to verify, return the sa and end early.
-	if len(config.PublicKeys) == 0 {
+	// The JWT is then verified by the TokenReview API.
+	if len(config.PublicKeys) == 0 && !config.DisableTokenReview {
 		return sa, nil
 	}
 
@@ -340,9 +365,14 @@ type projectedServiceAccountPod struct {
 }
 
 // lookup calls the TokenReview API in kubernetes to verify the token and secret
-// still exist.
-func (s *serviceAccount) lookup(jwtStr string, tr tokenReviewer) error {
-	r, err := tr.Review(jwtStr)
+// still exist. If an audience is given, the token must be valid for it.
+func (s *serviceAccount) lookup(jwtStr, audience string, tr tokenReviewer) error {
+	var audiences []string
+	if audience != "" {
+		audiences = []string{audience}
+	}
+
+	r, err := tr.Review(jwtStr, audiences)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,110 @@
+package kubeauth
+
+import (
+	"crypto/ecdsa"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/logical"
+)
+
+// setupOfflineBackend returns a backend validating JWTs with the public key
+// of the returned signing key only, and a role bound to the audience "vault"
+func setupOfflineBackend(t *testing.T) (*kubeAuthBackend, logical.Storage, *ecdsa.PrivateKey) {
+	b, s := getBackend(t)
+	key, jwks := testKey(t)
+
+	resp, err := testRequest(t, b, s, logical.UpdateOperation, configPath, map[string]interface{}{
+		"kubernetes_host":      "https://kubernetes.default.svc",
+		"jwks":                 jwks,
+		"issuer":               testIssuer,
+		"disable_token_review": true,
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err: %v, resp: %#v", err, resp)
+	}
+
+	resp, err = testRequest(t, b, s, logical.CreateOperation, "role/app", map[string]interface{}{
+		"bound_service_account_names":      "vault-auth",
+		"bound_service_account_namespaces": "default",
+		"audience":                         "vault",
+		"policies":                         "app",
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err: %v, resp: %#v", err, resp)
+	}
+
+	return b, s, key
+}
+
+func TestLogin_Offline(t *testing.T) {
+	b, s, key := setupOfflineBackend(t)
+
+	// The review factory must not be called when the TokenReview API is
+	// disabled
+	b.reviewFactory = func(*kubeConfig) tokenReviewer {
+		t.Fatal("unexpected token review")
+		return nil
+	}
+
+	resp, err := testRequest(t, b, s, logical.UpdateOperation, "login", map[string]interface{}{
+		"role": "app",
+		"jwt":  projectedToken(t, key, testIssuer, "vault", time.Now().Add(time.Minute)),
+	})
+	if err != nil || resp == nil || resp.IsError() || resp.Auth == nil {
+		t.Fatalf("err: %v, resp: %#v", err, resp)
+	}
+	if resp.Auth.Alias.Name != "d77f89bc-9055-11e7-a068-0800276d99bf" {
+		t.Fatalf("bad alias: %q", resp.Auth.Alias.Name)
+	}
+	if resp.Auth.Metadata["service_account_name"] != "vault-auth" || resp.Auth.Metadata["service_account_namespace"] != "default" {
+		t.Fatalf("bad metadata: %#v", resp.Auth.Metadata)
+	}
+}
+
+func TestLogin_Offline_Invalid(t *testing.T) {
+	b, s, key := setupOfflineBackend(t)
+	otherKey, _ := testKey(t)
+
+	tests := map[string]string{
+		"audience mismatch": projectedToken(t, key, testIssuer, "other", time.Now().Add(time.Minute)),
+		"expired":           projectedToken(t, key, testIssuer, "vault", time.Now().Add(-time.Minute)),
+		"wrong issuer":      projectedToken(t, key, "https://other.svc", "vault", time.Now().Add(time.Minute)),
+		"legacy issuer":     projectedToken(t, key, expectedJWTIssuer, "vault", time.Now().Add(time.Minute)),
+		"unknown key":       projectedToken(t, otherKey, testIssuer, "vault", time.Now().Add(time.Minute)),
+	}
+
+	for name, jwt := range tests {
+		t.Run(name, func(t *testing.T) {
+			resp, err := testRequest(t, b, s, logical.UpdateOperation, "login", map[string]interface{}{
+				"role": "app",
+				"jwt":  jwt,
+			})
+			if err == nil && (resp == nil || !resp.IsError()) {
+				t.Fatalf("expected an error, got: %#v", resp)
+			}
+		})
+	}
+}
+
+func TestConfig_DisableTokenReview(t *testing.T) {
+	b, s := getBackend(t)
+
+	// JWTs can't be validated offline without public keys
+	resp, err := testRequest(t, b, s, logical.UpdateOperation, configPath, map[string]interface{}{
+		"kubernetes_host":      "https://kubernetes.default.svc",
+		"kubernetes_ca_cert":   "ca",
+		"disable_token_review": true,
+	})
+	if err != nil || resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error, got err: %v, resp: %#v", err, resp)
+	}
+
+	resp, err = testRequest(t, b, s, logical.UpdateOperation, configPath, map[string]interface{}{
+		"kubernetes_host": "https://kubernetes.default.svc",
+		"jwks":            `{"keys": []}`,
+	})
+	if err != nil || resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error for an empty key set, got err: %v, resp: %#v", err, resp)
+	}
+}
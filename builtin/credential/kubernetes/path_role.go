@@ -73,6 +73,11 @@ TTL will be set to the value of this parameter.`,
 					Description: `Comma separated string or list of CIDR blocks. If set, specifies the blocks of
 IP addresses which can perform the login operation.`,
 				},
+				"audience": &framework.FieldSchema{
+					Type: framework.TypeString,
+					Description: `If set, the audience the service account JWTs must be issued for, as
+projected service account tokens. It is also requested from the TokenReview API.`,
+				},
 			},
 			ExistenceCheck: b.pathRoleExistenceCheck(),
 			Callbacks: map[logical.Operation]framework.OperationFunc{
@@ -150,6 +155,7 @@ func (b *kubeAuthBackend) pathRoleRead() framework.OperationFunc {
 				"period":                           role.Period,
 				"ttl":                              role.TTL,
 				"bound_cidrs":                      role.BoundCIDRs,
+				"audience":                         role.Audience,
 			},
 		}
 
@@ -291,6 +297,10 @@ func (b *kubeAuthBackend) pathRoleCreateUpdate() framework.OperationFunc {
 		}
 		role.BoundCIDRs = boundCIDRs
 
+		if audience, ok := data.GetOk("audience"); ok {
+			role.Audience = audience.(string)
+		}
+
 		// Store the entry.
 		entry, err := logical.StorageEntryJSON("role/"+strings.ToLower(roleName), role)
 		if err != nil {
@@ -337,6 +347,9 @@ type roleStorageEntry struct {
 	ServiceAccountNamespaces []string `json:"bound_service_account_namespaces" mapstructure:"bound_service_account_namespaces" structs:"bound_service_account_namespaces"`
 
 	BoundCIDRs []*sockaddr.SockAddrMarshaler
+
+	// Audience is the audience the service account JWTs must be issued for
+	Audience string `json:"audience" mapstructure:"audience" structs:"audience"`
 }
 
 var roleHelp = map[string][2]string{
@@ -12,6 +12,7 @@ import (
 	"strings"
 
 	cleanhttp "github.com/hashicorp/go-cleanhttp"
+	"github.com/hashicorp/vault/helper/strutil"
 	authv1 "k8s.io/api/authentication/v1"
 	kubeerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -28,11 +29,35 @@ type tokenReviewResult struct {
 
 // This exists so we can use a mock TokenReview when running tests
 type tokenReviewer interface {
-	Review(string) (*tokenReviewResult, error)
+	Review(jwt string, audiences []string) (*tokenReviewResult, error)
 }
 
 type tokenReviewFactory func(*kubeConfig) tokenReviewer
 
+// tokenReview is the TokenReview object of the kubernetes API, along with the
+// audiences fields which the vendored API types predate
+type tokenReview struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   tokenReviewSpec   `json:"spec"`
+	Status tokenReviewStatus `json:"status,omitempty"`
+}
+
+type tokenReviewSpec struct {
+	authv1.TokenReviewSpec `json:",inline"`
+
+	// Audiences are the identifiers the token must be valid for
+	Audiences []string `json:"audiences,omitempty"`
+}
+
+type tokenReviewStatus struct {
+	authv1.TokenReviewStatus `json:",inline"`
+
+	// Audiences are the identifiers the token was found valid for
+	Audiences []string `json:"audiences,omitempty"`
+}
+
 // This is the real implementation that calls the kubernetes API
 type tokenReviewAPI struct {
 	config *kubeConfig
@@ -44,7 +69,7 @@ func tokenReviewAPIFactory(config *kubeConfig) tokenReviewer {
 	}
 }
 
-func (t *tokenReviewAPI) Review(jwt string) (*tokenReviewResult, error) {
+func (t *tokenReviewAPI) Review(jwt string, audiences []string) (*tokenReviewResult, error) {
 
 	client := cleanhttp.DefaultClient()
 
@@ -62,9 +87,12 @@ func (t *tokenReviewAPI) Review(jwt string) (*tokenReviewResult, error) {
 	}
 
 	// Create the TokenReview Object and marshal it into json
-	trReq := &authv1.TokenReview{
-		Spec: authv1.TokenReviewSpec{
-			Token: jwt,
+	trReq := &tokenReview{
+		Spec: tokenReviewSpec{
+			TokenReviewSpec: authv1.TokenReviewSpec{
+				Token: jwt,
+			},
+			Audiences: audiences,
 		},
 	}
 	trJSON, err := json.Marshal(trReq)
@@ -117,6 +145,14 @@ func (t *tokenReviewAPI) Review(jwt string) (*tokenReviewResult, error) {
 		return nil, errors.New("lookup failed: service account jwt not valid")
 	}
 
+	// The API server returns the audiences the token is valid for, which must
+	// include the requested ones
+	for _, aud := range audiences {
+		if !strutil.StrListContains(r.Status.Audiences, aud) {
+			return nil, fmt.Errorf("lookup failed: service account jwt not valid for audience %q", aud)
+		}
+	}
+
 	// The username is of format: system:serviceaccount:(NAMESPACE):(SERVICEACCOUNT)
 	parts := strings.Split(r.Status.User.Username, ":")
 	if len(parts) != 4 {
@@ -137,7 +173,7 @@ func (t *tokenReviewAPI) Review(jwt string) (*tokenReviewResult, error) {
 
 // parseResponse takes the API response and either returns the appropriate error
 // or the TokenReview Object.
-func parseResponse(resp *http.Response) (*authv1.TokenReview, error) {
+func parseResponse(resp *http.Response) (*tokenReview, error) {
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		return nil, err
@@ -158,7 +194,7 @@ func parseResponse(resp *http.Response) (*authv1.TokenReview, error) {
 	}
 
 	// Unmarshal the resp body into a TokenReview Object
-	trResp := &authv1.TokenReview{}
+	trResp := &tokenReview{}
 	err = json.Unmarshal(body, trResp)
 	if err != nil {
 		return nil, err
@@ -184,7 +220,7 @@ func mockTokenReviewFactory(name, namespace, UID string) tokenReviewFactory {
 	}
 }
 
-func (t *mockTokenReview) Review(jwt string) (*tokenReviewResult, error) {
+func (t *mockTokenReview) Review(jwt string, audiences []string) (*tokenReviewResult, error) {
 	return &tokenReviewResult{
 		Name:      t.saName,
 		Namespace: t.saNamespace,
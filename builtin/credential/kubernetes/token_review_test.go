@@ -0,0 +1,67 @@
+package kubeauth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// testTokenReviewServer returns a kubernetes API authenticating the token of
+// the service account "vault-auth" for the audiences
+func testTokenReviewServer(t *testing.T, audiences []string) (*httptest.Server, *tokenReview) {
+	received := &tokenReview{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/apis/authentication.k8s.io/v1/tokenreviews" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(received); err != nil {
+			t.Fatal(err)
+		}
+
+		resp := &tokenReview{}
+		resp.Status.Authenticated = true
+		resp.Status.User.Username = "system:serviceaccount:default:vault-auth"
+		resp.Status.User.UID = "d77f89bc-9055-11e7-a068-0800276d99bf"
+		resp.Status.Audiences = audiences
+		json.NewEncoder(w).Encode(resp)
+	}))
+	return server, received
+}
+
+func TestTokenReview_Audience(t *testing.T) {
+	server, received := testTokenReviewServer(t, []string{"vault"})
+	defer server.Close()
+
+	reviewer := tokenReviewAPIFactory(&kubeConfig{Host: server.URL})
+	result, err := reviewer.Review("token", []string{"vault"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(received.Spec.Audiences, []string{"vault"}) || received.Spec.Token != "token" {
+		t.Fatalf("bad review request: %#v", received.Spec)
+	}
+	expected := &tokenReviewResult{
+		Name:      "vault-auth",
+		Namespace: "default",
+		UID:       "d77f89bc-9055-11e7-a068-0800276d99bf",
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Fatalf("bad: %#v", result)
+	}
+}
+
+func TestTokenReview_AudienceMismatch(t *testing.T) {
+	// API servers predating audiences ignore the requested ones
+	for _, audiences := range [][]string{nil, {"other"}} {
+		server, _ := testTokenReviewServer(t, audiences)
+
+		reviewer := tokenReviewAPIFactory(&kubeConfig{Host: server.URL})
+		if _, err := reviewer.Review("token", []string{"vault"}); err == nil {
+			t.Fatalf("expected an error for the audiences %v", audiences)
+		}
+		server.Close()
+	}
+}
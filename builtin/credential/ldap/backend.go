@@ -4,12 +4,15 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/hashicorp/vault/helper/ldaputil"
 	"github.com/hashicorp/vault/helper/mfa"
 	"github.com/hashicorp/vault/helper/strutil"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
+	cache "github.com/patrickmn/go-cache"
 )
 
 func Factory(ctx context.Context, conf *logical.BackendConfig) (logical.Backend, error) {
@@ -22,6 +25,7 @@ func Factory(ctx context.Context, conf *logical.BackendConfig) (logical.Backend,
 
 func Backend() *backend {
 	var b backend
+	b.groupCache = cache.New(0, time.Minute)
 	b.Backend = &framework.Backend{
 		Help: backendHelp,
 
@@ -47,7 +51,17 @@ func Backend() *backend {
 			mfa.MFAPaths(b.Backend, pathLogin(&b))...,
 		),
 
-		AuthRenew:   b.pathLoginRenew,
+		AuthRenew:  b.pathLoginRenew,
+		Invalidate: b.invalidate,
+		Clean:      b.cleanup,
+		ScheduledFuncs: []*framework.ScheduledFunc{
+			{
+				Name:            "health_check",
+				Description:     "Checks the health of the LDAP servers, and of the idle connections to them.",
+				DefaultSchedule: "1m",
+				Func:            b.periodicHealthCheck,
+			},
+		},
 		BackendType: logical.TypeCredential,
 	}
 
@@ -56,44 +70,54 @@ func Backend() *backend {
 
 type backend struct {
 	*framework.Backend
+
+	pool     *ldaputil.Pool
+	poolOnce sync.Once
+
+	// groupCache caches the parent groups of the groups when resolving
+	// nested groups
+	groupCache *cache.Cache
 }
 
-func (b *backend) Login(ctx context.Context, req *logical.Request, username string, password string) ([]string, *logical.Response, []string, error) {
+// connPool returns the pool of connections to the LDAP servers. It is created
+// on first use, once the logger of the backend is set up.
+func (b *backend) connPool() *ldaputil.Pool {
+	b.poolOnce.Do(func() {
+		b.pool = ldaputil.NewPool(&ldaputil.Client{
+			Logger: b.Logger(),
+			LDAP:   ldaputil.NewLDAP(),
+		})
+	})
+	return b.pool
+}
 
-	cfg, err := b.Config(ctx, req)
-	if err != nil {
-		return nil, nil, nil, err
-	}
-	if cfg == nil {
-		return nil, logical.ErrorResponse("ldap backend not configured"), nil, nil
-	}
+// loginError is an error of the LDAP operations of a login. Its message is
+// returned to the client, while the error it wraps tells whether the server
+// failed.
+type loginError struct {
+	message string
+	err     error
+}
 
-	if cfg.DenyNullBind && len(password) == 0 {
-		return nil, logical.ErrorResponse("password cannot be of zero length when passwordless binds are being denied"), nil, nil
-	}
+func (e *loginError) Error() string {
+	return e.message
+}
 
+// ldapLogin authenticates the user against the LDAP server and returns their
+// LDAP groups and alias metadata
+func (b *backend) ldapLogin(cfg *ldaputil.ConfigEntry, c ldaputil.Connection, username, password string) ([]string, map[string]string, *loginError) {
 	ldapClient := ldaputil.Client{
-		Logger: b.Logger(),
-		LDAP:   ldaputil.NewLDAP(),
+		Logger:     b.Logger(),
+		LDAP:       ldaputil.NewLDAP(),
+		GroupCache: b.groupCache,
 	}
 
-	c, err := ldapClient.DialLDAP(cfg)
-	if err != nil {
-		return nil, logical.ErrorResponse(err.Error()), nil, nil
-	}
-	if c == nil {
-		return nil, logical.ErrorResponse("invalid connection returned from LDAP dial"), nil, nil
-	}
-
-	// Clean connection
-	defer c.Close()
-
 	userBindDN, err := ldapClient.GetUserBindDN(cfg, c, username)
 	if err != nil {
 		if b.Logger().IsDebug() {
 			b.Logger().Debug("error getting user bind DN", "error", err)
 		}
-		return nil, logical.ErrorResponse("ldap operation failed"), nil, nil
+		return nil, nil, &loginError{"ldap operation failed", err}
 	}
 
 	if b.Logger().IsDebug() {
@@ -110,7 +134,7 @@ func (b *backend) Login(ctx context.Context, req *logical.Request, username stri
 		if b.Logger().IsDebug() {
 			b.Logger().Debug("ldap bind failed", "error", err)
 		}
-		return nil, logical.ErrorResponse("ldap operation failed"), nil, nil
+		return nil, nil, &loginError{"ldap operation failed", err}
 	}
 
 	// We re-bind to the BindDN if it's defined because we assume
@@ -120,7 +144,7 @@ func (b *backend) Login(ctx context.Context, req *logical.Request, username stri
 			if b.Logger().IsDebug() {
 				b.Logger().Debug("error while attempting to re-bind with the BindDN User", "error", err)
 			}
-			return nil, logical.ErrorResponse("ldap operation failed"), nil, nil
+			return nil, nil, &loginError{"ldap operation failed", err}
 		}
 		if b.Logger().IsDebug() {
 			b.Logger().Debug("re-bound to original binddn")
@@ -129,17 +153,101 @@ func (b *backend) Login(ctx context.Context, req *logical.Request, username stri
 
 	userDN, err := ldapClient.GetUserDN(cfg, c, userBindDN)
 	if err != nil {
-		return nil, logical.ErrorResponse(err.Error()), nil, nil
+		return nil, nil, &loginError{err.Error(), err}
 	}
 
 	ldapGroups, err := ldapClient.GetLdapGroups(cfg, c, userDN, username)
 	if err != nil {
-		return nil, logical.ErrorResponse(err.Error()), nil, nil
+		return nil, nil, &loginError{err.Error(), err}
 	}
 	if b.Logger().IsDebug() {
 		b.Logger().Debug("groups fetched from server", "num_server_groups", len(ldapGroups), "server_groups", ldapGroups)
 	}
 
+	aliasMetadata, err := ldapClient.GetUserAliasMetadata(cfg, c, userDN)
+	if err != nil {
+		return nil, nil, &loginError{err.Error(), err}
+	}
+
+	return ldapGroups, aliasMetadata, nil
+}
+
+func (b *backend) invalidate(_ context.Context, key string) {
+	switch key {
+	case "config":
+		b.connPool().Reset()
+		b.groupCache.Flush()
+	}
+}
+
+func (b *backend) cleanup(_ context.Context) {
+	b.connPool().Reset()
+	b.groupCache.Flush()
+}
+
+// periodicHealthCheck checks the health of the LDAP servers, and of the idle
+// connections to them
+func (b *backend) periodicHealthCheck(ctx context.Context, req *logical.Request) error {
+	entry, err := req.Storage.Get(ctx, "config")
+	if err != nil {
+		return err
+	}
+	if entry == nil {
+		return nil
+	}
+
+	cfg, err := b.Config(ctx, req)
+	if err != nil {
+		return err
+	}
+	b.connPool().HealthCheck(cfg)
+
+	return nil
+}
+
+func (b *backend) Login(ctx context.Context, req *logical.Request, username string, password string) ([]string, *logical.Response, []string, map[string]string, error) {
+
+	cfg, err := b.Config(ctx, req)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	if cfg == nil {
+		return nil, logical.ErrorResponse("ldap backend not configured"), nil, nil, nil
+	}
+
+	if cfg.DenyNullBind && len(password) == 0 {
+		return nil, logical.ErrorResponse("password cannot be of zero length when passwordless binds are being denied"), nil, nil, nil
+	}
+
+	// Retry the login on another server when the server fails mid-login, so
+	// that logins survive a server outage
+	var ldapGroups []string
+	var aliasMetadata map[string]string
+	for attempt := 0; ; attempt++ {
+		c, err := b.connPool().Get(cfg)
+		if err != nil {
+			return nil, logical.ErrorResponse(err.Error()), nil, nil, nil
+		}
+
+		var lerr *loginError
+		ldapGroups, aliasMetadata, lerr = b.ldapLogin(cfg, c, username, password)
+		if lerr == nil {
+			b.connPool().Put(cfg, c)
+			break
+		}
+
+		if !ldaputil.IsNetworkError(lerr.err) {
+			b.connPool().Put(cfg, c)
+			return nil, logical.ErrorResponse(lerr.message), nil, nil, nil
+		}
+		b.connPool().Fail(c, lerr.err)
+		if attempt+1 >= len(cfg.URLs()) {
+			return nil, logical.ErrorResponse(lerr.message), nil, nil, nil
+		}
+
+		b.Logger().Warn("LDAP server failed during login, retrying", "url", c.URL, "error", lerr.err)
+	}
+
 	ldapResponse := &logical.Response{
 		Data: map[string]interface{}{},
 	}
@@ -190,7 +298,7 @@ func (b *backend) Login(ctx context.Context, req *logical.Request, username stri
 	// Policies from each group may overlap
 	policies = strutil.RemoveDuplicates(policies, true)
 
-	return policies, ldapResponse, allGroups, nil
+	return policies, ldapResponse, allGroups, aliasMetadata, nil
 }
 
 const backendHelp = `
@@ -117,6 +117,11 @@ func (b *backend) pathConfigWrite(ctx context.Context, req *logical.Request, d *
 		return nil, err
 	}
 
+	// Connections and groups resolved with the previous configuration can't
+	// be reused
+	b.connPool().Reset()
+	b.groupCache.Flush()
+
 	return nil, nil
 }
 
@@ -153,6 +158,12 @@ case, an unencrypted connection will be made with a default port of 389, unless
 the "starttls" parameter is set to true, in which case TLS will be used. In the
 latter case, a SSL connection will be established with a default port of 636.
 
+Multiple URLs can be given, separated by commas. Logins use the first server
+that isn't failing, and are retried on the next one when a server fails
+mid-login. Failing servers are checked again every "health_check_interval",
+and used again once they recover. Setting "max_idle_connections" keeps
+connections open for reuse by later logins.
+
 ## A NOTE ON ESCAPING
 
 It is up to the administrator to provide properly escaped DNs. This includes
@@ -9,6 +9,7 @@ import (
 	"github.com/hashicorp/vault/helper/mfa"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
+	cache "github.com/patrickmn/go-cache"
 )
 
 func Factory(ctx context.Context, conf *logical.BackendConfig) (logical.Backend, error) {
@@ -20,7 +21,9 @@ func Factory(ctx context.Context, conf *logical.BackendConfig) (logical.Backend,
 }
 
 func Backend() *backend {
-	var b backend
+	b := backend{
+		verifyCache: cache.New(5*time.Minute, time.Minute),
+	}
 	b.Backend = &framework.Backend{
 		Help: backendHelp,
 
@@ -29,6 +32,7 @@ func Backend() *backend {
 
 			Unauthenticated: []string{
 				"login/*",
+				"verify/*",
 			},
 			SealWrapStorage: []string{
 				"config",
@@ -41,6 +45,7 @@ func Backend() *backend {
 			pathGroups(&b),
 			pathUsersList(&b),
 			pathGroupsList(&b),
+			pathVerify(&b),
 		},
 			mfa.MFAPaths(b.Backend, pathLogin(&b))...,
 		),
@@ -54,9 +59,22 @@ func Backend() *backend {
 
 type backend struct {
 	*framework.Backend
+
+	// verifyCache holds the numbers to select in Okta Verify by the nonce of
+	// the pending login requests
+	verifyCache *cache.Cache
 }
 
-func (b *backend) Login(ctx context.Context, req *logical.Request, username string, password string) ([]string, *logical.Response, []string, error) {
+// mfaFactor is a factor the user enrolled
+type mfaFactor struct {
+	Id       string `json:"id"`
+	Type     string `json:"factorType"`
+	Provider string `json:"provider"`
+}
+
+// Login authenticates the user, verifying the given MFA factor if Okta
+// requires it. A nil factor verifies push, as renewals do.
+func (b *backend) Login(ctx context.Context, req *logical.Request, username string, password string, factor *loginFactor) ([]string, *logical.Response, []string, error) {
 	cfg, err := b.Config(ctx, req.Storage)
 	if err != nil {
 		return nil, nil, nil, err
@@ -64,18 +82,29 @@ func (b *backend) Login(ctx context.Context, req *logical.Request, username stri
 	if cfg == nil {
 		return nil, logical.ErrorResponse("Okta auth method not configured"), nil, nil
 	}
+	if factor == nil {
+		factor = &loginFactor{
+			Type:     factorPush,
+			Provider: providerOkta,
+		}
+	}
 
 	client := cfg.OktaClient()
 
-	type mfaFactor struct {
-		Id       string `json:"id"`
-		Type     string `json:"factorType"`
-		Provider string `json:"provider"`
+	// The challenge of a push requiring number matching holds the number
+	// to select in Okta Verify
+	type embeddedFactor struct {
+		Embedded struct {
+			Challenge struct {
+				CorrectAnswer *int `json:"correctAnswer"`
+			} `json:"challenge"`
+		} `json:"_embedded"`
 	}
 
 	type embeddedResult struct {
-		User    okta.User   `json:"user"`
-		Factors []mfaFactor `json:"factors"`
+		User    okta.User      `json:"user"`
+		Factors []mfaFactor    `json:"factors"`
+		Factor  embeddedFactor `json:"factor"`
 	}
 
 	type authResult struct {
@@ -147,25 +176,18 @@ func (b *backend) Login(ctx context.Context, req *logical.Request, username stri
 			break
 		}
 
-		factorAvailable := false
-
-		var selectedFactor mfaFactor
-		// only okta push is currently supported
-		for _, v := range result.Embedded.Factors {
-			if v.Type == "push" && v.Provider == "OKTA" {
-				factorAvailable = true
-				selectedFactor = v
-			}
-		}
-
-		if !factorAvailable {
-			return nil, logical.ErrorResponse("Okta Verify Push factor is required in order to perform MFA"), nil, nil
+		factorID, err := factor.selectFactor(result.Embedded.Factors)
+		if err != nil {
+			return nil, logical.ErrorResponse(err.Error()), nil, nil
 		}
 
-		requestPath := fmt.Sprintf("authn/factors/%s/verify", selectedFactor.Id)
+		requestPath := fmt.Sprintf("authn/factors/%s/verify", factorID)
 		payload := map[string]interface{}{
 			"stateToken": result.StateToken,
 		}
+		if factor.Code != "" {
+			payload["passCode"] = factor.Code
+		}
 		verifyReq, err := client.NewRequest("POST", requestPath, payload)
 		if err != nil {
 			return nil, nil, nil, err
@@ -178,7 +200,26 @@ func (b *backend) Login(ctx context.Context, req *logical.Request, username stri
 		if rsp == nil {
 			return nil, logical.ErrorResponse("okta auth backend unexpected failure"), nil, nil
 		}
+
+		// Verifying SMS without a code sends it
+		if factor.Type == factorSMS && factor.Code == "" {
+			if result.Status == "MFA_CHALLENGE" {
+				return nil, logical.ErrorResponse("an SMS code was sent, log in again providing it as the code"), nil, nil
+			}
+			return nil, logical.ErrorResponse("failed to send the SMS code"), nil, nil
+		}
+
+		if factor.Nonce != "" {
+			defer b.verifyCache.Delete(factor.Nonce)
+		}
 		for result.Status == "MFA_CHALLENGE" {
+			if answer := result.Embedded.Factor.Embedded.Challenge.CorrectAnswer; answer != nil {
+				if factor.Nonce == "" {
+					return nil, logical.ErrorResponse("Okta Verify Push requires number matching, log in again providing a nonce to read the number to select from verify/<nonce>"), nil, nil
+				}
+				b.verifyCache.SetDefault(factor.Nonce, *answer)
+			}
+
 			switch result.FactorResult {
 			case "WAITING":
 				verifyReq, err := client.NewRequest("POST", requestPath, payload)
@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
+	uuid "github.com/hashicorp/go-uuid"
 	"github.com/hashicorp/vault/api"
 	pwd "github.com/hashicorp/vault/helper/password"
 )
@@ -46,6 +48,26 @@ func (h *CLIHandler) Auth(c *api.Client, m map[string]string) (*api.Secret, erro
 	if ok {
 		data["passcode"] = mfa_passcode
 	}
+	for _, k := range []string{"factor", "provider", "code"} {
+		if v, ok := m[k]; ok {
+			data[k] = v
+		}
+	}
+
+	// Logins verifying push show the number to select in Okta Verify when
+	// it requires number matching
+	var doneCh chan struct{}
+	if data["code"] == nil && (data["factor"] == nil || data["factor"] == factorPush) {
+		nonce, err := uuid.GenerateUUID()
+		if err != nil {
+			return nil, err
+		}
+		data["nonce"] = nonce
+
+		doneCh = make(chan struct{})
+		defer close(doneCh)
+		go showCorrectAnswer(c, fmt.Sprintf("auth/%s/verify/%s", mount, nonce), doneCh)
+	}
 
 	path := fmt.Sprintf("auth/%s/login/%s", mount, username)
 	secret, err := c.Logical().Write(path, data)
@@ -59,6 +81,25 @@ func (h *CLIHandler) Auth(c *api.Client, m map[string]string) (*api.Secret, erro
 	return secret, nil
 }
 
+// showCorrectAnswer prints the number to select in Okta Verify once the
+// pending login request makes it available
+func showCorrectAnswer(c *api.Client, path string, doneCh chan struct{}) {
+	for {
+		select {
+		case <-doneCh:
+			return
+		case <-time.After(time.Second):
+		}
+
+		secret, err := c.Logical().Read(path)
+		if err != nil || secret == nil || secret.Data["correct_answer"] == nil {
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "Select the number %v in Okta Verify\n", secret.Data["correct_answer"])
+		return
+	}
+}
+
 // Help method for okta cli
 func (h *CLIHandler) Help() string {
 	help := `
@@ -75,12 +116,30 @@ Usage: vault login -method=okta [CONFIG K=V...]
 
       $ vault login -method=okta username=bob password=password
 
+  Authenticate as "bob" with a TOTP code of Google Authenticator:
+
+      $ vault login -method=okta username=bob factor=totp provider=GOOGLE code=123456
+
 Configuration:
 
+  code=<string>
+      Passcode of the "totp" or "sms" MFA factor. Logging in with the "sms"
+      factor without a code sends it.
+
+  factor=<string>
+      MFA factor to verify if Okta requires it: "push", "totp" or "sms".
+      Defaults to "totp" if a code is given, and to "push" otherwise. The
+      number to select in Okta Verify is shown if push requires number
+      matching.
+
   password=<string>
       Okta password to use for authentication. If not provided, the CLI will
       prompt for this on stdin.
 
+  provider=<string>
+      Provider of the MFA factor: "OKTA", or "GOOGLE" for the "totp" factor.
+      Defaults to "OKTA".
+
   username=<string>
       Okta username to use for authentication.
 `
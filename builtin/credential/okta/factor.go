@@ -0,0 +1,95 @@
+package okta
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	factorPush = "push"
+	factorTOTP = "totp"
+	factorSMS  = "sms"
+
+	providerOkta   = "OKTA"
+	providerGoogle = "GOOGLE"
+)
+
+// oktaFactorTypes maps the factors login requests select to the types Okta
+// gives them
+var oktaFactorTypes = map[string]string{
+	factorPush: "push",
+	factorTOTP: "token:software:totp",
+	factorSMS:  "sms",
+}
+
+// loginFactor is the MFA factor a login request verifies
+type loginFactor struct {
+	// Type is one of the factorPush, factorTOTP or factorSMS
+	Type string
+
+	// Provider is the provider of the factor, OKTA or GOOGLE
+	Provider string
+
+	// Code is the passcode of the TOTP and SMS factors
+	Code string
+
+	// Nonce identifies the login request to read the number to select in
+	// Okta Verify from, when push requires number matching
+	Nonce string
+}
+
+// newLoginFactor validates the factor of a login request. The factor defaults
+// to TOTP if a code is given, and to push otherwise.
+func newLoginFactor(factorType, provider, code, nonce string) (*loginFactor, error) {
+	factorType = strings.ToLower(factorType)
+	if factorType == "" {
+		factorType = factorPush
+		if code != "" {
+			factorType = factorTOTP
+		}
+	}
+	if _, ok := oktaFactorTypes[factorType]; !ok {
+		return nil, fmt.Errorf("factor must be one of %q, %q or %q", factorPush, factorTOTP, factorSMS)
+	}
+
+	provider = strings.ToUpper(provider)
+	switch {
+	case provider == "":
+		provider = providerOkta
+	case provider == providerGoogle && factorType != factorTOTP:
+		return nil, fmt.Errorf("provider %q only supports the %q factor", providerGoogle, factorTOTP)
+	case provider != providerOkta && provider != providerGoogle:
+		return nil, fmt.Errorf("provider must be %q or %q", providerOkta, providerGoogle)
+	}
+
+	switch {
+	case factorType == factorTOTP && code == "":
+		return nil, fmt.Errorf("code is required for the %q factor", factorTOTP)
+	case factorType == factorPush && code != "":
+		return nil, fmt.Errorf("code is not supported by the %q factor", factorPush)
+	}
+
+	return &loginFactor{
+		Type:     factorType,
+		Provider: provider,
+		Code:     code,
+		Nonce:    nonce,
+	}, nil
+}
+
+// selectFactor returns the ID of the factor of the user matching the factor
+// of the login request
+func (f *loginFactor) selectFactor(factors []mfaFactor) (string, error) {
+	for _, v := range factors {
+		if v.Type == oktaFactorTypes[f.Type] && v.Provider == f.Provider {
+			return v.Id, nil
+		}
+	}
+
+	switch f.Type {
+	case factorPush:
+		return "", fmt.Errorf("Okta Verify Push factor is required in order to perform MFA")
+	default:
+		return "", fmt.Errorf("%s %s factor is not enrolled", f.Provider, f.Type)
+	}
+}
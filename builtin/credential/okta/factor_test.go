@@ -0,0 +1,93 @@
+package okta
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/vault/logical"
+)
+
+func TestNewLoginFactor(t *testing.T) {
+	cases := []struct {
+		factor, provider, code string
+		expected               *loginFactor
+	}{
+		{"", "", "", &loginFactor{Type: factorPush, Provider: providerOkta}},
+		{"", "", "123456", &loginFactor{Type: factorTOTP, Provider: providerOkta, Code: "123456"}},
+		{"TOTP", "google", "123456", &loginFactor{Type: factorTOTP, Provider: providerGoogle, Code: "123456"}},
+		{"sms", "", "", &loginFactor{Type: factorSMS, Provider: providerOkta}},
+		{"sms", "", "123456", &loginFactor{Type: factorSMS, Provider: providerOkta, Code: "123456"}},
+		{"totp", "", "", nil},
+		{"push", "", "123456", nil},
+		{"push", "GOOGLE", "", nil},
+		{"push", "DUO", "", nil},
+		{"email", "", "", nil},
+	}
+	for _, c := range cases {
+		factor, err := newLoginFactor(c.factor, c.provider, c.code, "")
+		switch {
+		case c.expected == nil && err == nil:
+			t.Fatalf("expected an error for %#v", c)
+		case c.expected != nil && err != nil:
+			t.Fatalf("unexpected error for %#v: %v", c, err)
+		case c.expected != nil && *factor != *c.expected:
+			t.Fatalf("bad: expected %#v, got %#v", c.expected, factor)
+		}
+	}
+}
+
+func TestLoginFactor_selectFactor(t *testing.T) {
+	factors := []mfaFactor{
+		{Id: "push", Type: "push", Provider: "OKTA"},
+		{Id: "okta-totp", Type: "token:software:totp", Provider: "OKTA"},
+		{Id: "google-totp", Type: "token:software:totp", Provider: "GOOGLE"},
+	}
+
+	for factor, expected := range map[loginFactor]string{
+		{Type: factorPush, Provider: providerOkta}:   "push",
+		{Type: factorTOTP, Provider: providerOkta}:   "okta-totp",
+		{Type: factorTOTP, Provider: providerGoogle}: "google-totp",
+	} {
+		id, err := factor.selectFactor(factors)
+		if err != nil || id != expected {
+			t.Fatalf("bad: %#v: expected %q, got %q, %v", factor, expected, id, err)
+		}
+	}
+
+	sms := &loginFactor{Type: factorSMS, Provider: providerOkta}
+	if _, err := sms.selectFactor(factors); err == nil {
+		t.Fatal("expected an error for a factor which isn't enrolled")
+	}
+}
+
+func TestBackend_verify(t *testing.T) {
+	b, err := Factory(context.Background(), &logical.BackendConfig{
+		System: &logical.StaticSystemView{},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	read := func(nonce string) *logical.Response {
+		t.Helper()
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation: logical.ReadOperation,
+			Path:      "verify/" + nonce,
+			Storage:   &logical.InmemStorage{},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	if resp := read("unknown"); resp != nil {
+		t.Fatalf("expected no response, got: %#v", resp)
+	}
+
+	b.(*backend).verifyCache.SetDefault("nonce", 42)
+	resp := read("nonce")
+	if resp == nil || resp.Data["correct_answer"] != 42 {
+		t.Fatalf("bad: %#v", resp)
+	}
+}
@@ -25,6 +25,30 @@ func pathLogin(b *backend) *framework.Path {
 				Type:        framework.TypeString,
 				Description: "Password for this user.",
 			},
+
+			"factor": &framework.FieldSchema{
+				Type: framework.TypeString,
+				Description: `MFA factor to verify if Okta requires it: "push", "totp" or "sms".
+Defaults to "totp" if a code is given, and to "push" otherwise.`,
+			},
+
+			"provider": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Default:     providerOkta,
+				Description: `Provider of the MFA factor: "OKTA", or "GOOGLE" for the "totp" factor.`,
+			},
+
+			"code": &framework.FieldSchema{
+				Type: framework.TypeString,
+				Description: `Passcode of the "totp" or "sms" factor. Logging in with the "sms" factor
+without a code sends it.`,
+			},
+
+			"nonce": &framework.FieldSchema{
+				Type: framework.TypeString,
+				Description: `Random value identifying the login request, to read the number to select in
+Okta Verify from "verify/<nonce>" when push requires number matching.`,
+			},
 		},
 
 		Callbacks: map[logical.Operation]framework.OperationFunc{
@@ -56,7 +80,12 @@ func (b *backend) pathLogin(ctx context.Context, req *logical.Request, d *framew
 	username := d.Get("username").(string)
 	password := d.Get("password").(string)
 
-	policies, resp, groupNames, err := b.Login(ctx, req, username, password)
+	factor, err := newLoginFactor(d.Get("factor").(string), d.Get("provider").(string), d.Get("code").(string), d.Get("nonce").(string))
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	policies, resp, groupNames, err := b.Login(ctx, req, username, password, factor)
 	// Handle an internal error
 	if err != nil {
 		return nil, err
@@ -113,7 +142,7 @@ func (b *backend) pathLoginRenew(ctx context.Context, req *logical.Request, d *f
 	username := req.Auth.Metadata["username"]
 	password := req.Auth.InternalData["password"].(string)
 
-	loginPolicies, resp, groupNames, err := b.Login(ctx, req, username, password)
+	loginPolicies, resp, groupNames, err := b.Login(ctx, req, username, password, nil)
 	if len(loginPolicies) == 0 {
 		return resp, err
 	}
@@ -163,4 +192,9 @@ Log in with a username and password.
 
 const pathLoginDesc = `
 This endpoint authenticates using a username and password.
+
+If Okta requires MFA, the factor to verify is selected with "factor". Okta
+Verify push is verified by default; when it requires number matching, the
+number to select is read from "verify/<nonce>" while the login request with
+that nonce is pending.
 `
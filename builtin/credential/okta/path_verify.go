@@ -0,0 +1,50 @@
+package okta
+
+import (
+	"context"
+
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+func pathVerify(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: `verify/(?P<nonce>.+)`,
+		Fields: map[string]*framework.FieldSchema{
+			"nonce": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Nonce of the pending login request.",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation: b.pathVerify,
+		},
+
+		HelpSynopsis:    pathVerifySyn,
+		HelpDescription: pathVerifyDesc,
+	}
+}
+
+func (b *backend) pathVerify(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	answer, ok := b.verifyCache.Get(d.Get("nonce").(string))
+	if !ok {
+		return nil, nil
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"correct_answer": answer.(int),
+		},
+	}, nil
+}
+
+const pathVerifySyn = `
+Read the number to select in Okta Verify.
+`
+
+const pathVerifyDesc = `
+This endpoint returns the number to select in Okta Verify to complete the
+pending login request with the given nonce, when its push requires number
+matching.
+`
@@ -0,0 +1,116 @@
+package saml
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+	cache "github.com/patrickmn/go-cache"
+)
+
+const (
+	configPath string = "config"
+	rolePrefix string = "role/"
+
+	// loginRequestTTL is the time a user has to log in with the IdP, and the
+	// client to retrieve the token, after requesting the SSO service URL
+	loginRequestTTL = 10 * time.Minute
+)
+
+// Factory is used by framework
+func Factory(ctx context.Context, c *logical.BackendConfig) (logical.Backend, error) {
+	b := backend()
+	if err := b.Setup(ctx, c); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+type samlAuthBackend struct {
+	*framework.Backend
+
+	l            sync.RWMutex
+	cachedConfig *samlConfig
+
+	// loginRequests holds the pending logins, keyed by their poll ID
+	loginRequests *cache.Cache
+	requestsLock  sync.Mutex
+}
+
+// loginRequest is a pending login, from the request of the SSO service URL
+// to the retrieval of the token. Pending logins are keyed by their poll ID,
+// which is also sent to the IdP as the relay state.
+type loginRequest struct {
+	RequestID       string
+	Role            string
+	ACSURL          string
+	ClientChallenge string
+
+	// Assertion is set once the response of the IdP has been validated
+	Assertion *assertionInfo
+}
+
+func backend() *samlAuthBackend {
+	b := &samlAuthBackend{
+		loginRequests: cache.New(loginRequestTTL, time.Minute),
+	}
+
+	b.Backend = &framework.Backend{
+		AuthRenew:   b.pathLoginRenew,
+		BackendType: logical.TypeCredential,
+		Invalidate:  b.invalidate,
+		Help:        backendHelp,
+		PathsSpecial: &logical.Paths{
+			Unauthenticated: []string{
+				"sso_service_url",
+				"callback",
+				"token",
+			},
+			SealWrapStorage: []string{
+				"config",
+			},
+		},
+		Paths: []*framework.Path{
+			pathSSOServiceURL(b),
+			pathCallback(b),
+			pathToken(b),
+			pathRoleList(b),
+			pathRole(b),
+			pathConfig(b),
+		},
+		Clean: b.cleanup,
+	}
+
+	return b
+}
+
+func (b *samlAuthBackend) cleanup(_ context.Context) {
+	b.loginRequests.Flush()
+}
+
+func (b *samlAuthBackend) invalidate(ctx context.Context, key string) {
+	switch key {
+	case configPath:
+		b.reset()
+	}
+}
+
+func (b *samlAuthBackend) reset() {
+	b.l.Lock()
+	b.cachedConfig = nil
+	b.l.Unlock()
+}
+
+const (
+	backendHelp = `
+The SAML auth method allows users to log in with a SAML 2.0 identity provider.
+
+A login starts by requesting the SSO service URL of a role, where the user is
+sent to authenticate with the identity provider. The identity provider then
+posts its response to the callback endpoint, and once the assertion it holds
+has been validated, the client retrieves its Vault token from the token
+endpoint.
+`
+)
@@ -0,0 +1,436 @@
+package saml
+
+import (
+	"bytes"
+	"compress/flate"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/beevik/etree"
+	"github.com/hashicorp/vault/logical"
+	dsig "github.com/russellhaering/goxmldsig"
+	"github.com/russellhaering/goxmldsig/etreeutils"
+)
+
+const (
+	testIDPEntityID = "https://idp.example.com/metadata"
+	testEntityID    = "https://vault.example.com/saml"
+	testACSURL      = "https://vault.example.com/v1/auth/saml/callback"
+	testVerifier    = "test-verifier"
+)
+
+const testResponseTemplate = `<samlp:Response xmlns:samlp="urn:oasis:names:tc:SAML:2.0:protocol" xmlns:saml="urn:oasis:names:tc:SAML:2.0:assertion" ID="_response" Version="2.0" IssueInstant="{{now}}" Destination="{{acs}}" InResponseTo="{{request}}">
+<saml:Issuer>{{idp}}</saml:Issuer>
+<samlp:Status><samlp:StatusCode Value="urn:oasis:names:tc:SAML:2.0:status:Success"/></samlp:Status>
+<saml:Assertion ID="_assertion" Version="2.0" IssueInstant="{{now}}">
+<saml:Issuer>{{idp}}</saml:Issuer>
+<saml:Subject>
+<saml:NameID>jane@example.com</saml:NameID>
+<saml:SubjectConfirmation Method="urn:oasis:names:tc:SAML:2.0:cm:bearer">
+<saml:SubjectConfirmationData NotOnOrAfter="{{expiry}}" Recipient="{{acs}}" InResponseTo="{{request}}"/>
+</saml:SubjectConfirmation>
+</saml:Subject>
+<saml:Conditions NotBefore="{{now}}" NotOnOrAfter="{{expiry}}">
+<saml:AudienceRestriction><saml:Audience>{{audience}}</saml:Audience></saml:AudienceRestriction>
+</saml:Conditions>
+<saml:AttributeStatement>
+<saml:Attribute Name="uid"><saml:AttributeValue>jane</saml:AttributeValue></saml:Attribute>
+<saml:Attribute Name="groups"><saml:AttributeValue>admins</saml:AttributeValue><saml:AttributeValue>developers</saml:AttributeValue></saml:Attribute>
+<saml:Attribute Name="department"><saml:AttributeValue>engineering</saml:AttributeValue></saml:Attribute>
+</saml:AttributeStatement>
+</saml:Assertion>
+</samlp:Response>`
+
+// testIDP signs SAML responses like an identity provider
+type testIDP struct {
+	signer  *dsig.SigningContext
+	certPEM string
+}
+
+func newTestIDP(t *testing.T) *testIDP {
+	ks := dsig.RandomKeyStoreForTest()
+	_, cert, err := ks.GetKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &testIDP{
+		signer:  dsig.NewDefaultSigningContext(ks),
+		certPEM: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert})),
+	}
+}
+
+type testResponseOptions struct {
+	requestID    string
+	audience     string
+	now          time.Time
+	signResponse bool
+	tamper       bool
+}
+
+// response returns a base64 encoded SAML response with a signed assertion,
+// or a signed response if opts.signResponse is set
+func (idp *testIDP) response(t *testing.T, opts testResponseOptions) string {
+	if opts.audience == "" {
+		opts.audience = testEntityID
+	}
+	if opts.now.IsZero() {
+		opts.now = time.Now()
+	}
+
+	raw := strings.NewReplacer(
+		"{{now}}", opts.now.UTC().Format(time.RFC3339),
+		"{{expiry}}", opts.now.Add(5*time.Minute).UTC().Format(time.RFC3339),
+		"{{acs}}", testACSURL,
+		"{{request}}", opts.requestID,
+		"{{idp}}", testIDPEntityID,
+		"{{audience}}", opts.audience,
+	).Replace(testResponseTemplate)
+
+	doc := etree.NewDocument()
+	if err := doc.ReadFromString(raw); err != nil {
+		t.Fatal(err)
+	}
+
+	root := doc.Root()
+	if opts.signResponse {
+		signed, err := idp.signer.SignEnveloped(root)
+		if err != nil {
+			t.Fatal(err)
+		}
+		doc.SetRoot(signed)
+	} else {
+		// The assertion is signed with the namespaces it inherits, like IdPs do
+		assertionEl := root.FindElement("./Assertion")
+		ctx, err := etreeutils.NSBuildParentContext(assertionEl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		detached, err := etreeutils.NSDetatch(ctx, assertionEl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		signed, err := idp.signer.SignEnveloped(detached)
+		if err != nil {
+			t.Fatal(err)
+		}
+		root.RemoveChild(assertionEl)
+		root.AddChild(signed)
+	}
+
+	if opts.tamper {
+		doc.FindElement("//NameID").SetText("mallory@example.com")
+	}
+
+	out, err := doc.WriteToBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(out)
+}
+
+func createBackendWithStorage(t *testing.T) (*samlAuthBackend, logical.Storage) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+
+	b, err := Factory(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return b.(*samlAuthBackend), config.StorageView
+}
+
+func setupBackend(t *testing.T, idp *testIDP, roleData map[string]interface{}) (*samlAuthBackend, logical.Storage) {
+	b, storage := createBackendWithStorage(t)
+
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      configPath,
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"idp_sso_url":   "https://idp.example.com/sso",
+			"idp_entity_id": testIDPEntityID,
+			"idp_cert":      idp.certPEM,
+			"entity_id":     testEntityID,
+			"acs_urls":      testACSURL,
+			"default_role":  "test",
+		},
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: resp: %#v\nerr: %v", resp, err)
+	}
+
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.CreateOperation,
+		Path:      rolePrefix + "test",
+		Storage:   storage,
+		Data:      roleData,
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: resp: %#v\nerr: %v", resp, err)
+	}
+
+	return b, storage
+}
+
+// startLogin requests the SSO service URL, returning the poll ID and the ID
+// of the authentication request
+func startLogin(t *testing.T, b *samlAuthBackend, storage logical.Storage) (string, string) {
+	sum := sha256.Sum256([]byte(testVerifier))
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "sso_service_url",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"client_challenge": base64.RawURLEncoding.EncodeToString(sum[:]),
+		},
+	})
+	if err != nil || resp == nil || resp.IsError() {
+		t.Fatalf("bad: resp: %#v\nerr: %v", resp, err)
+	}
+
+	ssoURL, err := url.Parse(resp.Data["sso_service_url"].(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pollID := resp.Data["token_poll_id"].(string)
+	if relayState := ssoURL.Query().Get("RelayState"); relayState != pollID {
+		t.Fatalf("expected the relay state %q, got %q", pollID, relayState)
+	}
+
+	deflated, err := base64.StdEncoding.DecodeString(ssoURL.Query().Get("SAMLRequest"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := ioutil.ReadAll(flate.NewReader(bytes.NewReader(deflated)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := etree.NewDocument()
+	if err := doc.ReadFromBytes(raw); err != nil {
+		t.Fatal(err)
+	}
+	if acs := doc.Root().SelectAttrValue("AssertionConsumerServiceURL", ""); acs != testACSURL {
+		t.Fatalf("expected the ACS URL %q, got %q", testACSURL, acs)
+	}
+
+	return pollID, doc.Root().SelectAttrValue("ID", "")
+}
+
+func postCallback(t *testing.T, b *samlAuthBackend, storage logical.Storage, pollID, samlResponse string) int {
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "callback",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"SAMLResponse": samlResponse,
+			"RelayState":   pollID,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.Data[logical.HTTPStatusCode].(int)
+}
+
+func pollToken(t *testing.T, b *samlAuthBackend, storage logical.Storage, pollID, verifier string) *logical.Response {
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "token",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"token_poll_id":   pollID,
+			"client_verifier": verifier,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func TestSAML_Login(t *testing.T) {
+	for _, signResponse := range []bool{false, true} {
+		t.Run(fmt.Sprintf("signResponse=%t", signResponse), func(t *testing.T) {
+			idp := newTestIDP(t)
+			b, storage := setupBackend(t, idp, map[string]interface{}{
+				"policies":         "dev",
+				"bound_subjects":   "*@example.com",
+				"bound_attributes": map[string]interface{}{"groups": "admins"},
+				"user_attribute":   "uid",
+				"groups_attribute": "groups",
+				"attribute_metadata": map[string]interface{}{
+					"department": "dept",
+				},
+			})
+
+			pollID, requestID := startLogin(t, b, storage)
+
+			resp := pollToken(t, b, storage, pollID, testVerifier)
+			if resp == nil || !resp.IsError() || resp.Data["error"] != errAuthorizationPending {
+				t.Fatalf("expected a pending authorization, got: %#v", resp)
+			}
+
+			samlResponse := idp.response(t, testResponseOptions{requestID: requestID, signResponse: signResponse})
+			if status := postCallback(t, b, storage, pollID, samlResponse); status != 200 {
+				t.Fatalf("expected status 200, got %d", status)
+			}
+
+			resp = pollToken(t, b, storage, pollID, "wrong-verifier")
+			if resp == nil || !resp.IsError() {
+				t.Fatalf("expected an error for the wrong verifier, got: %#v", resp)
+			}
+
+			resp = pollToken(t, b, storage, pollID, testVerifier)
+			if resp == nil || resp.IsError() || resp.Auth == nil {
+				t.Fatalf("bad: resp: %#v", resp)
+			}
+			auth := resp.Auth
+			if auth.Alias.Name != "jane" || auth.DisplayName != "jane" {
+				t.Fatalf("unexpected alias: %#v", auth.Alias)
+			}
+			var groups []string
+			for _, alias := range auth.GroupAliases {
+				groups = append(groups, alias.Name)
+			}
+			sort.Strings(groups)
+			if !reflect.DeepEqual(groups, []string{"admins", "developers"}) {
+				t.Fatalf("unexpected group aliases: %v", groups)
+			}
+			expectedMetadata := map[string]string{"role": "test", "dept": "engineering"}
+			if !reflect.DeepEqual(auth.Alias.Metadata, expectedMetadata) {
+				t.Fatalf("unexpected alias metadata: %v", auth.Alias.Metadata)
+			}
+			if !reflect.DeepEqual(auth.Policies, []string{"dev"}) {
+				t.Fatalf("unexpected policies: %v", auth.Policies)
+			}
+
+			// The token can only be retrieved once
+			resp = pollToken(t, b, storage, pollID, testVerifier)
+			if resp == nil || !resp.IsError() {
+				t.Fatalf("expected an error for a completed login, got: %#v", resp)
+			}
+		})
+	}
+}
+
+func TestSAML_InvalidResponses(t *testing.T) {
+	idp := newTestIDP(t)
+	otherIDP := newTestIDP(t)
+
+	cases := map[string]func(requestID string) string{
+		"tampered": func(requestID string) string {
+			return idp.response(t, testResponseOptions{requestID: requestID, tamper: true})
+		},
+		"tampered response": func(requestID string) string {
+			return idp.response(t, testResponseOptions{requestID: requestID, signResponse: true, tamper: true})
+		},
+		"unknown signer": func(requestID string) string {
+			return otherIDP.response(t, testResponseOptions{requestID: requestID})
+		},
+		"wrong audience": func(requestID string) string {
+			return idp.response(t, testResponseOptions{requestID: requestID, audience: "https://other.example.com"})
+		},
+		"expired": func(requestID string) string {
+			return idp.response(t, testResponseOptions{requestID: requestID, now: time.Now().Add(-time.Hour)})
+		},
+		"not yet valid": func(requestID string) string {
+			return idp.response(t, testResponseOptions{requestID: requestID, now: time.Now().Add(time.Hour)})
+		},
+		"other request": func(requestID string) string {
+			return idp.response(t, testResponseOptions{requestID: "_other"})
+		},
+		"not base64": func(requestID string) string {
+			return "<Response/>"
+		},
+	}
+
+	for name, response := range cases {
+		t.Run(name, func(t *testing.T) {
+			b, storage := setupBackend(t, idp, map[string]interface{}{
+				"policies": "dev",
+			})
+			pollID, requestID := startLogin(t, b, storage)
+
+			if status := postCallback(t, b, storage, pollID, response(requestID)); status != 403 {
+				t.Fatalf("expected status 403, got %d", status)
+			}
+
+			resp := pollToken(t, b, storage, pollID, testVerifier)
+			if resp == nil || !resp.IsError() || resp.Data["error"] == errAuthorizationPending {
+				t.Fatalf("expected the login to fail, got: %#v", resp)
+			}
+		})
+	}
+}
+
+func TestSAML_BoundAttributes(t *testing.T) {
+	idp := newTestIDP(t)
+	b, storage := setupBackend(t, idp, map[string]interface{}{
+		"policies":         "dev",
+		"bound_attributes": map[string]interface{}{"groups": []interface{}{"operators", "sre-*"}},
+	})
+
+	pollID, requestID := startLogin(t, b, storage)
+	if status := postCallback(t, b, storage, pollID, idp.response(t, testResponseOptions{requestID: requestID})); status != 200 {
+		t.Fatalf("expected status 200, got %d", status)
+	}
+
+	resp := pollToken(t, b, storage, pollID, testVerifier)
+	if resp == nil || !resp.IsError() || !strings.Contains(resp.Data["error"].(string), "bound value") {
+		t.Fatalf("expected the bound attributes to be enforced, got: %#v", resp)
+	}
+}
+
+func TestSAML_Config(t *testing.T) {
+	b, storage := createBackendWithStorage(t)
+
+	cases := map[string]map[string]interface{}{
+		"missing cert": {
+			"idp_sso_url":   "https://idp.example.com/sso",
+			"idp_entity_id": testIDPEntityID,
+			"entity_id":     testEntityID,
+			"acs_urls":      testACSURL,
+		},
+		"invalid cert": {
+			"idp_sso_url":   "https://idp.example.com/sso",
+			"idp_entity_id": testIDPEntityID,
+			"idp_cert":      "not a certificate",
+			"entity_id":     testEntityID,
+			"acs_urls":      testACSURL,
+		},
+		"relative ACS URL": {
+			"idp_sso_url":   "https://idp.example.com/sso",
+			"idp_entity_id": testIDPEntityID,
+			"idp_cert":      newTestIDP(t).certPEM,
+			"entity_id":     testEntityID,
+			"acs_urls":      "/v1/auth/saml/callback",
+		},
+	}
+
+	for name, data := range cases {
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      configPath,
+			Storage:   storage,
+			Data:      data,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp == nil || !resp.IsError() {
+			t.Fatalf("%s: expected an error, got: %#v", name, resp)
+		}
+	}
+}
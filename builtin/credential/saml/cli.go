@@ -0,0 +1,119 @@
+package saml
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+)
+
+// defaultPollInterval is the interval at which the CLI polls for the token
+const defaultPollInterval = 2 * time.Second
+
+// CLIHandler logs in with the SAML identity provider, printing the URL the
+// user must open in a browser and polling for the token
+type CLIHandler struct{}
+
+func (h *CLIHandler) Auth(c *api.Client, m map[string]string) (*api.Secret, error) {
+	mount, ok := m["mount"]
+	if !ok {
+		mount = "saml"
+	}
+
+	// The verifier never leaves the client, so that only it can retrieve
+	// the token
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+	verifier := base64.RawURLEncoding.EncodeToString(buf)
+	sum := sha256.Sum256([]byte(verifier))
+
+	data := map[string]interface{}{
+		"client_challenge": base64.RawURLEncoding.EncodeToString(sum[:]),
+	}
+	if role, ok := m["role"]; ok {
+		data["role"] = role
+	}
+	if acsURL, ok := m["acs_url"]; ok {
+		data["acs_url"] = acsURL
+	}
+
+	start, err := c.Logical().Write(fmt.Sprintf("auth/%s/sso_service_url", mount), data)
+	if err != nil {
+		return nil, err
+	}
+	if start == nil || start.Data == nil {
+		return nil, errors.New("empty response from credential provider")
+	}
+
+	ssoURL, _ := start.Data["sso_service_url"].(string)
+	pollID, _ := start.Data["token_poll_id"].(string)
+	if ssoURL == "" || pollID == "" {
+		return nil, errors.New("no SSO service URL returned by the credential provider")
+	}
+
+	// The instructions are printed to stderr, so that they are shown even if
+	// the output is redirected
+	fmt.Fprintf(os.Stderr, "To complete the login, open the following URL in a browser:\n\n    %s\n\n", ssoURL)
+	fmt.Fprintf(os.Stderr, "Waiting for the identity provider...\n")
+
+	tokenPath := fmt.Sprintf("auth/%s/token", mount)
+	for {
+		time.Sleep(defaultPollInterval)
+
+		secret, err := c.Logical().Write(tokenPath, map[string]interface{}{
+			"token_poll_id":   pollID,
+			"client_verifier": verifier,
+		})
+		switch {
+		case err == nil:
+			if secret == nil {
+				return nil, errors.New("empty response from credential provider")
+			}
+			return secret, nil
+		case strings.Contains(err.Error(), errAuthorizationPending):
+		default:
+			return nil, err
+		}
+	}
+}
+
+func (h *CLIHandler) Help() string {
+	help := `
+Usage: vault login -method=saml [CONFIG K=V...]
+
+  The SAML auth method allows users to authenticate using a SAML 2.0 identity
+  provider. The CLI prints a URL to open in a browser, where the user logs in
+  with the identity provider, and waits for the login to complete.
+
+  Authenticate using the "engineering" role:
+
+      $ vault login -method=saml role=engineering
+
+Configuration:
+
+  acs_url=<string>
+      Assertion consumer service URL the identity provider is to post its
+      response to. It must be one of the URLs configured on the mount, and
+      defaults to the first one.
+
+  mount=<string>
+      Path where the SAML credential method is mounted. This is usually
+      provided via the -path flag in the "vault login" command, but it can be
+      specified here as well. If specified here, it takes precedence over the
+      value for -path. The default value is "saml".
+
+  role=<string>
+      Name of the role to log in against. Defaults to the default role of the
+      mount.
+`
+
+	return strings.TrimSpace(help)
+}
@@ -0,0 +1,107 @@
+package saml
+
+import (
+	"context"
+	"html"
+	"net/http"
+	"time"
+
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+	cache "github.com/patrickmn/go-cache"
+)
+
+const (
+	callbackSuccessMessage = "Vault login successful. You can close this window and return to the CLI."
+	callbackFailureMessage = "Vault login failed. Check the Vault server logs for details."
+)
+
+func pathCallback(b *samlAuthBackend) *framework.Path {
+	return &framework.Path{
+		Pattern: `callback$`,
+		Fields: map[string]*framework.FieldSchema{
+			"SAMLResponse": {
+				Type:        framework.TypeString,
+				Description: "The base64 encoded SAML response of the IdP.",
+			},
+			"RelayState": {
+				Type:        framework.TypeString,
+				Description: "The relay state sent with the authentication request.",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathCallback,
+		},
+
+		HelpSynopsis:    pathCallbackHelpSyn,
+		HelpDescription: pathCallbackHelpDesc,
+	}
+}
+
+// pathCallback is the assertion consumer service, which the browser of the
+// user posts the response of the IdP to with the HTTP-POST binding. Since it
+// is read by a person, it answers with an HTML page rather than JSON.
+func (b *samlAuthBackend) pathCallback(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	pollID := d.Get("RelayState").(string)
+	samlResponse := d.Get("SAMLResponse").(string)
+	if pollID == "" || samlResponse == "" {
+		return callbackResponse(http.StatusBadRequest, "Missing SAMLResponse or RelayState."), nil
+	}
+
+	b.requestsLock.Lock()
+	defer b.requestsLock.Unlock()
+
+	raw, ok := b.loginRequests.Get(pollID)
+	if !ok {
+		return callbackResponse(http.StatusBadRequest, "The login request has expired or is unknown."), nil
+	}
+	loginReq := raw.(*loginRequest)
+	if loginReq.Assertion != nil {
+		return callbackResponse(http.StatusBadRequest, "The login request has already been completed."), nil
+	}
+
+	config, err := b.config(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if config == nil {
+		return callbackResponse(http.StatusInternalServerError, callbackFailureMessage), nil
+	}
+
+	info, err := validateResponse(config, samlResponse, loginReq.RequestID, loginReq.ACSURL, time.Now())
+	if err != nil {
+		// A response of the IdP can't be replayed, so the request is dropped
+		b.loginRequests.Delete(pollID)
+		b.Logger().Warn("invalid SAML response", "error", err)
+		return callbackResponse(http.StatusForbidden, callbackFailureMessage), nil
+	}
+
+	loginReq.Assertion = info
+	b.loginRequests.Set(pollID, loginReq, cache.DefaultExpiration)
+
+	return callbackResponse(http.StatusOK, callbackSuccessMessage), nil
+}
+
+func callbackResponse(status int, message string) *logical.Response {
+	body := "<!DOCTYPE html><html><head><title>Vault</title></head><body><p>" + html.EscapeString(message) + "</p></body></html>"
+	return &logical.Response{
+		Data: map[string]interface{}{
+			logical.HTTPContentType: "text/html; charset=utf-8",
+			logical.HTTPRawBody:     []byte(body),
+			logical.HTTPStatusCode:  status,
+		},
+	}
+}
+
+const (
+	pathCallbackHelpSyn = `
+Receives the responses of the SAML identity provider.
+`
+	pathCallbackHelpDesc = `
+The assertion consumer service of the mount, to which the identity provider
+posts its responses with the HTTP-POST binding, as the SAMLResponse and
+RelayState form fields. Once the response and its assertion are validated, the
+token of the login can be retrieved from the token endpoint.
+`
+)
@@ -0,0 +1,178 @@
+package saml
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"net/url"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+func pathConfig(b *samlAuthBackend) *framework.Path {
+	return &framework.Path{
+		Pattern: `config`,
+		Fields: map[string]*framework.FieldSchema{
+			"idp_sso_url": {
+				Type:        framework.TypeString,
+				Description: "The URL of the single sign-on service of the IdP, to which authentication requests are sent with the HTTP-Redirect binding.",
+			},
+			"idp_entity_id": {
+				Type:        framework.TypeString,
+				Description: "The entity ID of the IdP, which must be the issuer of the responses and assertions.",
+			},
+			"idp_cert": {
+				Type:        framework.TypeString,
+				Description: "The PEM encoded certificates of the IdP, one of which must sign the responses or assertions.",
+			},
+			"entity_id": {
+				Type:        framework.TypeString,
+				Description: "The entity ID of Vault as a service provider, which must be in the audience of the assertions.",
+			},
+			"acs_urls": {
+				Type:        framework.TypeCommaStringSlice,
+				Description: `The assertion consumer service URLs the IdP may post its responses to. They are the URLs of the "callback" endpoint of the mount, as reached by the users' browsers.`,
+			},
+			"default_role": {
+				Type:        framework.TypeLowerCaseString,
+				Description: "The role used when none is given at login.",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation:   b.pathConfigRead,
+			logical.UpdateOperation: b.pathConfigWrite,
+		},
+
+		HelpSynopsis:    confHelpSyn,
+		HelpDescription: confHelpDesc,
+	}
+}
+
+func (b *samlAuthBackend) config(ctx context.Context, s logical.Storage) (*samlConfig, error) {
+	b.l.RLock()
+	defer b.l.RUnlock()
+
+	if b.cachedConfig != nil {
+		return b.cachedConfig, nil
+	}
+
+	entry, err := s.Get(ctx, configPath)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	result := &samlConfig{}
+	if err := entry.DecodeJSON(result); err != nil {
+		return nil, err
+	}
+
+	result.ParsedIDPCerts, err = parseCertificates(result.IDPCert)
+	if err != nil {
+		return nil, errwrap.Wrapf("error parsing the IdP certificates: {{err}}", err)
+	}
+
+	b.cachedConfig = result
+
+	return result, nil
+}
+
+func (b *samlAuthBackend) pathConfigRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	config, err := b.config(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if config == nil {
+		return nil, nil
+	}
+
+	resp := &logical.Response{
+		Data: map[string]interface{}{
+			"idp_sso_url":   config.IDPSSOURL,
+			"idp_entity_id": config.IDPEntityID,
+			"idp_cert":      config.IDPCert,
+			"entity_id":     config.EntityID,
+			"acs_urls":      config.ACSURLs,
+			"default_role":  config.DefaultRole,
+		},
+	}
+
+	return resp, nil
+}
+
+func (b *samlAuthBackend) pathConfigWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	config := &samlConfig{
+		IDPSSOURL:   d.Get("idp_sso_url").(string),
+		IDPEntityID: d.Get("idp_entity_id").(string),
+		IDPCert:     d.Get("idp_cert").(string),
+		EntityID:    d.Get("entity_id").(string),
+		ACSURLs:     d.Get("acs_urls").([]string),
+		DefaultRole: d.Get("default_role").(string),
+	}
+
+	switch {
+	case config.IDPSSOURL == "":
+		return logical.ErrorResponse("'idp_sso_url' must be set"), nil
+	case config.IDPEntityID == "":
+		return logical.ErrorResponse("'idp_entity_id' must be set"), nil
+	case config.IDPCert == "":
+		return logical.ErrorResponse("'idp_cert' must be set"), nil
+	case config.EntityID == "":
+		return logical.ErrorResponse("'entity_id' must be set"), nil
+	case len(config.ACSURLs) == 0:
+		return logical.ErrorResponse("'acs_urls' must be set"), nil
+	}
+
+	for _, u := range append([]string{config.IDPSSOURL}, config.ACSURLs...) {
+		parsed, err := url.Parse(u)
+		if err != nil || !parsed.IsAbs() {
+			return logical.ErrorResponse(fmt.Sprintf("invalid URL %q", u)), nil
+		}
+	}
+
+	if _, err := parseCertificates(config.IDPCert); err != nil {
+		return logical.ErrorResponse(errwrap.Wrapf("error parsing 'idp_cert': {{err}}", err).Error()), nil
+	}
+
+	entry, err := logical.StorageEntryJSON(configPath, config)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	b.reset()
+
+	return nil, nil
+}
+
+type samlConfig struct {
+	IDPSSOURL   string   `json:"idp_sso_url"`
+	IDPEntityID string   `json:"idp_entity_id"`
+	IDPCert     string   `json:"idp_cert"`
+	EntityID    string   `json:"entity_id"`
+	ACSURLs     []string `json:"acs_urls"`
+	DefaultRole string   `json:"default_role"`
+
+	ParsedIDPCerts []*x509.Certificate `json:"-"`
+}
+
+const (
+	confHelpSyn = `
+Configures the SAML identity provider and service provider.
+`
+	confHelpDesc = `
+The SAML auth method sends authentication requests to the single sign-on
+service of the identity provider, and accepts the responses it posts to the
+assertion consumer service URLs, which are the URLs of the "callback" endpoint
+of the mount. The responses or their assertions must be signed with one of the
+certificates of the identity provider, and the assertions must be issued for
+the entity ID of Vault.
+`
+)
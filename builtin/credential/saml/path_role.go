@@ -0,0 +1,393 @@
+package saml
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	sockaddr "github.com/hashicorp/go-sockaddr"
+	"github.com/hashicorp/vault/helper/parseutil"
+	"github.com/hashicorp/vault/helper/policyutil"
+	"github.com/hashicorp/vault/helper/strutil"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+func pathRoleList(b *samlAuthBackend) *framework.Path {
+	return &framework.Path{
+		Pattern: "role/?",
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ListOperation: b.pathRoleList,
+		},
+		HelpSynopsis:    strings.TrimSpace(roleHelp["role-list"][0]),
+		HelpDescription: strings.TrimSpace(roleHelp["role-list"][1]),
+	}
+}
+
+// pathRole returns the path configurations for the CRUD operations on roles
+func pathRole(b *samlAuthBackend) *framework.Path {
+	return &framework.Path{
+		Pattern: "role/" + framework.GenericNameRegex("name"),
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeLowerCaseString,
+				Description: "Name of the role.",
+			},
+			"policies": {
+				Type:        framework.TypeCommaStringSlice,
+				Description: "List of policies on the role.",
+			},
+			"num_uses": {
+				Type:        framework.TypeInt,
+				Description: `Number of times issued tokens can be used`,
+			},
+			"ttl": {
+				Type: framework.TypeDurationSecond,
+				Description: `Duration in seconds after which the issued token should expire. Defaults
+to 0, in which case the value will fall back to the system/mount defaults.`,
+			},
+			"max_ttl": {
+				Type: framework.TypeDurationSecond,
+				Description: `Duration in seconds after which the issued token should not be allowed to
+be renewed. Defaults to 0, in which case the value will fall back to the system/mount defaults.`,
+			},
+			"period": {
+				Type: framework.TypeDurationSecond,
+				Description: `If set, indicates that the token generated using this role
+should never expire. The token should be renewed within the
+duration specified by this value. At each renewal, the token's
+TTL will be set to the value of this parameter.`,
+			},
+			"bound_cidrs": {
+				Type: framework.TypeCommaStringSlice,
+				Description: `Comma-separated list of IP CIDRS that are allowed to
+authenticate against this role`,
+			},
+			"bound_subjects": {
+				Type:        framework.TypeCommaStringSlice,
+				Description: `Comma-separated list of the subject NameIDs that are valid for login. Values may start or end with the '*' glob. Optional.`,
+			},
+			"bound_attributes": {
+				Type: framework.TypeMap,
+				Description: `Map of attributes to the values, or lists of values, that are valid for
+login. Values may start or end with the '*' glob. Every attribute must have at
+least one matching value.`,
+			},
+			"user_attribute": {
+				Type:        framework.TypeString,
+				Description: `The attribute to use for the Identity entity alias name. Defaults to the subject NameID.`,
+			},
+			"groups_attribute": {
+				Type:        framework.TypeString,
+				Description: `The attribute to use for the Identity group alias names`,
+			},
+			"attribute_metadata": {
+				Type:        framework.TypeKVPairs,
+				Description: `Map of attributes to the keys of the alias metadata their values are copied to`,
+			},
+		},
+		ExistenceCheck: b.pathRoleExistenceCheck,
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.CreateOperation: b.pathRoleCreateUpdate,
+			logical.UpdateOperation: b.pathRoleCreateUpdate,
+			logical.ReadOperation:   b.pathRoleRead,
+			logical.DeleteOperation: b.pathRoleDelete,
+		},
+		HelpSynopsis:    strings.TrimSpace(roleHelp["role"][0]),
+		HelpDescription: strings.TrimSpace(roleHelp["role"][1]),
+	}
+}
+
+type samlRole struct {
+	// Policies that are to be required by the token to access this role
+	Policies []string `json:"policies"`
+
+	// TokenNumUses defines the number of allowed uses of the token issued
+	NumUses int `json:"num_uses"`
+
+	// Duration before which an issued token must be renewed
+	TTL time.Duration `json:"ttl"`
+
+	// Duration after which an issued token should not be allowed to be renewed
+	MaxTTL time.Duration `json:"max_ttl"`
+
+	// Period, if set, indicates that the token generated using this role
+	// should never expire. The token should be renewed within the duration
+	// specified by this value.
+	Period time.Duration `json:"period"`
+
+	// Role binding properties
+	BoundCIDRs      []*sockaddr.SockAddrMarshaler `json:"bound_cidrs"`
+	BoundSubjects   []string                      `json:"bound_subjects"`
+	BoundAttributes map[string][]string           `json:"bound_attributes"`
+
+	// Mapping of the attributes to the Identity aliases
+	UserAttribute     string            `json:"user_attribute"`
+	GroupsAttribute   string            `json:"groups_attribute"`
+	AttributeMetadata map[string]string `json:"attribute_metadata"`
+}
+
+// role takes a storage backend and the name and returns the role's storage
+// entry
+func (b *samlAuthBackend) role(ctx context.Context, s logical.Storage, name string) (*samlRole, error) {
+	raw, err := s.Get(ctx, rolePrefix+name)
+	if err != nil {
+		return nil, err
+	}
+	if raw == nil {
+		return nil, nil
+	}
+
+	role := new(samlRole)
+	if err := raw.DecodeJSON(role); err != nil {
+		return nil, err
+	}
+
+	return role, nil
+}
+
+// pathRoleExistenceCheck returns whether the role with the given name exists or not.
+func (b *samlAuthBackend) pathRoleExistenceCheck(ctx context.Context, req *logical.Request, data *framework.FieldData) (bool, error) {
+	role, err := b.role(ctx, req.Storage, data.Get("name").(string))
+	if err != nil {
+		return false, err
+	}
+	return role != nil, nil
+}
+
+// pathRoleList is used to list all the Roles registered with the backend.
+func (b *samlAuthBackend) pathRoleList(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	roles, err := req.Storage.List(ctx, rolePrefix)
+	if err != nil {
+		return nil, err
+	}
+	return logical.ListResponse(roles), nil
+}
+
+// pathRoleRead grabs a read lock and reads the options set on the role from the storage
+func (b *samlAuthBackend) pathRoleRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	roleName := data.Get("name").(string)
+	if roleName == "" {
+		return logical.ErrorResponse("missing name"), nil
+	}
+
+	role, err := b.role(ctx, req.Storage, roleName)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return nil, nil
+	}
+
+	// Create a map of data to be returned
+	resp := &logical.Response{
+		Data: map[string]interface{}{
+			"policies":           role.Policies,
+			"num_uses":           role.NumUses,
+			"period":             int64(role.Period.Seconds()),
+			"ttl":                int64(role.TTL.Seconds()),
+			"max_ttl":            int64(role.MaxTTL.Seconds()),
+			"bound_cidrs":        role.BoundCIDRs,
+			"bound_subjects":     role.BoundSubjects,
+			"bound_attributes":   role.BoundAttributes,
+			"user_attribute":     role.UserAttribute,
+			"groups_attribute":   role.GroupsAttribute,
+			"attribute_metadata": role.AttributeMetadata,
+		},
+	}
+
+	return resp, nil
+}
+
+// pathRoleDelete removes the role from storage
+func (b *samlAuthBackend) pathRoleDelete(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	roleName := data.Get("name").(string)
+	if roleName == "" {
+		return logical.ErrorResponse("role name required"), nil
+	}
+
+	// Delete the role itself
+	if err := req.Storage.Delete(ctx, rolePrefix+roleName); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+// pathRoleCreateUpdate registers a new role with the backend or updates the options
+// of an existing role
+func (b *samlAuthBackend) pathRoleCreateUpdate(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	roleName := data.Get("name").(string)
+	if roleName == "" {
+		return logical.ErrorResponse("missing role name"), nil
+	}
+
+	// Check if the role already exists
+	role, err := b.role(ctx, req.Storage, roleName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create a new entry object if this is a CreateOperation
+	if role == nil {
+		if req.Operation == logical.UpdateOperation {
+			return nil, errors.New("role entry not found during update operation")
+		}
+		role = new(samlRole)
+	}
+
+	if policiesRaw, ok := data.GetOk("policies"); ok {
+		role.Policies = policyutil.ParsePolicies(policiesRaw)
+	}
+
+	periodRaw, ok := data.GetOk("period")
+	if ok {
+		role.Period = time.Duration(periodRaw.(int)) * time.Second
+	} else if req.Operation == logical.CreateOperation {
+		role.Period = time.Duration(data.Get("period").(int)) * time.Second
+	}
+	if role.Period > b.System().MaxLeaseTTL() {
+		return logical.ErrorResponse(fmt.Sprintf("'period' of '%q' is greater than the backend's maximum lease TTL of '%q'", role.Period.String(), b.System().MaxLeaseTTL().String())), nil
+	}
+
+	if tokenNumUsesRaw, ok := data.GetOk("num_uses"); ok {
+		role.NumUses = tokenNumUsesRaw.(int)
+	} else if req.Operation == logical.CreateOperation {
+		role.NumUses = data.Get("num_uses").(int)
+	}
+	if role.NumUses < 0 {
+		return logical.ErrorResponse("num_uses cannot be negative"), nil
+	}
+
+	if tokenTTLRaw, ok := data.GetOk("ttl"); ok {
+		role.TTL = time.Duration(tokenTTLRaw.(int)) * time.Second
+	} else if req.Operation == logical.CreateOperation {
+		role.TTL = time.Duration(data.Get("ttl").(int)) * time.Second
+	}
+
+	if tokenMaxTTLRaw, ok := data.GetOk("max_ttl"); ok {
+		role.MaxTTL = time.Duration(tokenMaxTTLRaw.(int)) * time.Second
+	} else if req.Operation == logical.CreateOperation {
+		role.MaxTTL = time.Duration(data.Get("max_ttl").(int)) * time.Second
+	}
+
+	if boundCIDRs, ok := data.GetOk("bound_cidrs"); ok {
+		parsedCIDRs, err := parseutil.ParseAddrs(boundCIDRs)
+		if err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+		role.BoundCIDRs = parsedCIDRs
+	}
+
+	if boundSubjects, ok := data.GetOk("bound_subjects"); ok {
+		role.BoundSubjects = boundSubjects.([]string)
+	}
+
+	if boundAttributesRaw, ok := data.GetOk("bound_attributes"); ok {
+		boundAttributes, err := parseAttributeValueMap(boundAttributesRaw.(map[string]interface{}))
+		if err != nil {
+			return logical.ErrorResponse(errwrap.Wrapf("error parsing bound_attributes: {{err}}", err).Error()), nil
+		}
+		role.BoundAttributes = boundAttributes
+	}
+
+	if userAttribute, ok := data.GetOk("user_attribute"); ok {
+		role.UserAttribute = userAttribute.(string)
+	}
+
+	if groupsAttribute, ok := data.GetOk("groups_attribute"); ok {
+		role.GroupsAttribute = groupsAttribute.(string)
+	}
+
+	if attributeMetadata, ok := data.GetOk("attribute_metadata"); ok {
+		role.AttributeMetadata = attributeMetadata.(map[string]string)
+		for attribute, key := range role.AttributeMetadata {
+			if key == "" || key == "role" {
+				return logical.ErrorResponse(fmt.Sprintf("invalid metadata key %q for attribute %q", key, attribute)), nil
+			}
+		}
+	}
+
+	// Check that the TTL value provided is less than the MaxTTL.
+	// Sanitizing the TTL and MaxTTL is not required now and can be performed
+	// at credential issue time.
+	if role.MaxTTL > 0 && role.TTL > role.MaxTTL {
+		return logical.ErrorResponse("ttl should not be greater than max_ttl"), nil
+	}
+
+	var resp *logical.Response
+	if role.MaxTTL > b.System().MaxLeaseTTL() {
+		resp = &logical.Response{}
+		resp.AddWarning("max_ttl is greater than the system or backend mount's maximum TTL value; issued tokens' max TTL value will be truncated")
+	}
+
+	// Store the entry.
+	entry, err := logical.StorageEntryJSON(rolePrefix+roleName, role)
+	if err != nil {
+		return nil, err
+	}
+	if err = req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// parseAttributeValueMap parses a map of attributes to a value or a list of
+// values, where a string value may hold a comma-separated list
+func parseAttributeValueMap(raw map[string]interface{}) (map[string][]string, error) {
+	ret := make(map[string][]string, len(raw))
+	for attribute, valuesRaw := range raw {
+		if attribute == "" {
+			return nil, errors.New("attribute names cannot be empty")
+		}
+
+		var values []string
+		switch v := valuesRaw.(type) {
+		case string:
+			values = strutil.ParseDedupAndSortStrings(v, ",")
+		case []interface{}:
+			for _, valueRaw := range v {
+				value, ok := valueRaw.(string)
+				if !ok {
+					return nil, fmt.Errorf("value %v of attribute %q is not a string", valueRaw, attribute)
+				}
+				values = append(values, value)
+			}
+			values = strutil.RemoveDuplicates(strutil.RemoveEmpty(strutil.TrimStrings(values)), false)
+		default:
+			return nil, fmt.Errorf("values of attribute %q must be a string or a list of strings", attribute)
+		}
+		if len(values) == 0 {
+			return nil, fmt.Errorf("no values given for attribute %q", attribute)
+		}
+
+		ret[attribute] = values
+	}
+
+	return ret, nil
+}
+
+// roleHelp maps the role paths to their help synopsis and description
+var roleHelp = map[string][2]string{
+	"role-list": {
+		"Lists all the roles registered with the backend.",
+		"The list will contain the names of the roles.",
+	},
+	"role": {
+		"Register a role with the backend.",
+		`
+A role is required to log in with the SAML auth method. It holds the policies
+of the issued tokens, and optionally binds the subjects and attributes of the
+assertions that are valid for login.
+
+The Identity entity alias is named after the subject NameID of the assertion,
+or after the value of "user_attribute" if it is set. The values of
+"groups_attribute" are used as group alias names, and "attribute_metadata"
+copies the values of attributes to the alias metadata.
+`,
+	},
+}
@@ -0,0 +1,121 @@
+package saml
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/errwrap"
+	uuid "github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/helper/cidrutil"
+	"github.com/hashicorp/vault/helper/strutil"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+	cache "github.com/patrickmn/go-cache"
+)
+
+func pathSSOServiceURL(b *samlAuthBackend) *framework.Path {
+	return &framework.Path{
+		Pattern: `sso_service_url$`,
+		Fields: map[string]*framework.FieldSchema{
+			"role": {
+				Type:        framework.TypeLowerCaseString,
+				Description: "The role to log in against. Defaults to the configured default role.",
+			},
+			"acs_url": {
+				Type:        framework.TypeString,
+				Description: "The assertion consumer service URL the IdP is to post its response to. It must be one of the configured ACS URLs, and defaults to the first one.",
+			},
+			"client_challenge": {
+				Type:        framework.TypeString,
+				Description: "The base64url encoded SHA-256 hash of a random verifier, which must be given to retrieve the token.",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathSSOServiceURL,
+		},
+
+		HelpSynopsis:    pathSSOServiceURLHelpSyn,
+		HelpDescription: pathSSOServiceURLHelpDesc,
+	}
+}
+
+func (b *samlAuthBackend) pathSSOServiceURL(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	config, err := b.config(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if config == nil {
+		return logical.ErrorResponse("could not load configuration"), nil
+	}
+
+	roleName := d.Get("role").(string)
+	if roleName == "" {
+		roleName = config.DefaultRole
+	}
+	if roleName == "" {
+		return logical.ErrorResponse("missing role"), nil
+	}
+
+	role, err := b.role(ctx, req.Storage, roleName)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return logical.ErrorResponse(fmt.Sprintf("role %q could not be found", roleName)), nil
+	}
+
+	if req.Connection != nil && !cidrutil.RemoteAddrIsOk(req.Connection.RemoteAddr, role.BoundCIDRs) {
+		return logical.ErrorResponse("request originated from invalid CIDR"), nil
+	}
+
+	acsURL := d.Get("acs_url").(string)
+	switch {
+	case acsURL == "":
+		acsURL = config.ACSURLs[0]
+	case !strutil.StrListContains(config.ACSURLs, acsURL):
+		return logical.ErrorResponse(fmt.Sprintf("unauthorized acs_url %q", acsURL)), nil
+	}
+
+	clientChallenge := d.Get("client_challenge").(string)
+	if clientChallenge == "" {
+		return logical.ErrorResponse("missing client_challenge"), nil
+	}
+
+	pollID, err := uuid.GenerateUUID()
+	if err != nil {
+		return nil, err
+	}
+
+	requestID, ssoURL, err := newAuthnRequest(config, acsURL, pollID)
+	if err != nil {
+		return nil, errwrap.Wrapf("error creating the authentication request: {{err}}", err)
+	}
+
+	b.loginRequests.Set(pollID, &loginRequest{
+		RequestID:       requestID,
+		Role:            roleName,
+		ACSURL:          acsURL,
+		ClientChallenge: clientChallenge,
+	}, cache.DefaultExpiration)
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"sso_service_url": ssoURL,
+			"token_poll_id":   pollID,
+		},
+	}, nil
+}
+
+const (
+	pathSSOServiceURLHelpSyn = `
+Starts a login with the SAML identity provider.
+`
+	pathSSOServiceURLHelpDesc = `
+Creates an authentication request for the role, and returns the URL of the
+single sign-on service of the identity provider the user must open in a
+browser to authenticate. The identity provider then posts its response to the
+callback endpoint, while the client polls the token endpoint with the returned
+token_poll_id and the verifier of its challenge.
+`
+)
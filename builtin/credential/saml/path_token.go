@@ -0,0 +1,228 @@
+package saml
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/helper/cidrutil"
+	"github.com/hashicorp/vault/helper/strutil"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+const (
+	// errAuthorizationPending is returned while the IdP has not posted its
+	// response, for clients to keep polling
+	errAuthorizationPending = "authorization_pending"
+)
+
+func pathToken(b *samlAuthBackend) *framework.Path {
+	return &framework.Path{
+		Pattern: `token$`,
+		Fields: map[string]*framework.FieldSchema{
+			"token_poll_id": {
+				Type:        framework.TypeString,
+				Description: "The token poll ID returned by sso_service_url.",
+			},
+			"client_verifier": {
+				Type:        framework.TypeString,
+				Description: "The verifier of the client challenge given to sso_service_url.",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathToken,
+		},
+
+		HelpSynopsis:    pathTokenHelpSyn,
+		HelpDescription: pathTokenHelpDesc,
+	}
+}
+
+func (b *samlAuthBackend) pathToken(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	pollID := d.Get("token_poll_id").(string)
+	if pollID == "" {
+		return logical.ErrorResponse("missing token_poll_id"), nil
+	}
+	verifier := d.Get("client_verifier").(string)
+	if verifier == "" {
+		return logical.ErrorResponse("missing client_verifier"), nil
+	}
+
+	loginReq, errResp := b.completedLoginRequest(pollID, verifier)
+	if errResp != nil {
+		return errResp, nil
+	}
+
+	role, err := b.role(ctx, req.Storage, loginReq.Role)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return logical.ErrorResponse(fmt.Sprintf("role %q could not be found", loginReq.Role)), nil
+	}
+
+	if req.Connection != nil && !cidrutil.RemoteAddrIsOk(req.Connection.RemoteAddr, role.BoundCIDRs) {
+		return logical.ErrorResponse("request originated from invalid CIDR"), nil
+	}
+
+	return b.createAuthResponse(loginReq.Role, role, loginReq.Assertion)
+}
+
+// completedLoginRequest returns the pending login once the IdP has posted
+// its response, removing it so that the token is only issued once
+func (b *samlAuthBackend) completedLoginRequest(pollID, verifier string) (*loginRequest, *logical.Response) {
+	b.requestsLock.Lock()
+	defer b.requestsLock.Unlock()
+
+	raw, ok := b.loginRequests.Get(pollID)
+	if !ok {
+		return nil, logical.ErrorResponse("the login request has expired or is unknown")
+	}
+	loginReq := raw.(*loginRequest)
+
+	sum := sha256.Sum256([]byte(verifier))
+	challenge := base64.RawURLEncoding.EncodeToString(sum[:])
+	if subtle.ConstantTimeCompare([]byte(challenge), []byte(loginReq.ClientChallenge)) != 1 {
+		return nil, logical.ErrorResponse("invalid client_verifier")
+	}
+
+	if loginReq.Assertion == nil {
+		return nil, logical.ErrorResponse(errAuthorizationPending)
+	}
+
+	b.loginRequests.Delete(pollID)
+	return loginReq, nil
+}
+
+// createAuthResponse returns the response of a login against the role, with
+// the validated assertion
+func (b *samlAuthBackend) createAuthResponse(roleName string, role *samlRole, info *assertionInfo) (*logical.Response, error) {
+	if err := validateBoundAssertion(role, info); err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	userName := info.NameID
+	if role.UserAttribute != "" {
+		values := info.Attributes[role.UserAttribute]
+		if len(values) != 1 || values[0] == "" {
+			return logical.ErrorResponse(fmt.Sprintf("%q attribute must have a single value", role.UserAttribute)), nil
+		}
+		userName = values[0]
+	}
+
+	var groupAliases []*logical.Alias
+	if role.GroupsAttribute != "" {
+		for _, group := range info.Attributes[role.GroupsAttribute] {
+			if group == "" {
+				continue
+			}
+			groupAliases = append(groupAliases, &logical.Alias{
+				Name: group,
+			})
+		}
+	}
+
+	metadata := map[string]string{
+		"role": roleName,
+	}
+	for attribute, key := range role.AttributeMetadata {
+		if values, ok := info.Attributes[attribute]; ok {
+			metadata[key] = strings.Join(values, ",")
+		}
+	}
+
+	resp := &logical.Response{
+		Auth: &logical.Auth{
+			Policies:    role.Policies,
+			DisplayName: userName,
+			Period:      role.Period,
+			NumUses:     role.NumUses,
+			Alias: &logical.Alias{
+				Name:     userName,
+				Metadata: metadata,
+			},
+			GroupAliases: groupAliases,
+			InternalData: map[string]interface{}{
+				"role": roleName,
+			},
+			Metadata: metadata,
+			LeaseOptions: logical.LeaseOptions{
+				Renewable: true,
+				TTL:       role.TTL,
+				MaxTTL:    role.MaxTTL,
+			},
+			BoundCIDRs: role.BoundCIDRs,
+		},
+	}
+
+	return resp, nil
+}
+
+// validateBoundAssertion checks the subject and attributes of the assertion
+// against the ones bound to the role
+func validateBoundAssertion(role *samlRole, info *assertionInfo) error {
+	if len(role.BoundSubjects) != 0 && !strutil.StrListContainsGlob(role.BoundSubjects, info.NameID) {
+		return errors.New("subject does not match any bound subject")
+	}
+
+	for attribute, expected := range role.BoundAttributes {
+		values, ok := info.Attributes[attribute]
+		if !ok {
+			return fmt.Errorf("%q attribute not found in assertion", attribute)
+		}
+
+		found := false
+		for _, value := range values {
+			if strutil.StrListContainsGlob(expected, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%q attribute does not match any bound value", attribute)
+		}
+	}
+
+	return nil
+}
+
+func (b *samlAuthBackend) pathLoginRenew(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	roleName := req.Auth.InternalData["role"].(string)
+	if roleName == "" {
+		return nil, errors.New("failed to fetch role_name during renewal")
+	}
+
+	// Ensure that the Role still exists.
+	role, err := b.role(ctx, req.Storage, roleName)
+	if err != nil {
+		return nil, errwrap.Wrapf(fmt.Sprintf("failed to validate role %s during renewal: {{err}}", roleName), err)
+	}
+	if role == nil {
+		return nil, fmt.Errorf("role %s does not exist during renewal", roleName)
+	}
+
+	resp := &logical.Response{Auth: req.Auth}
+	resp.Auth.TTL = role.TTL
+	resp.Auth.MaxTTL = role.MaxTTL
+	resp.Auth.Period = role.Period
+	return resp, nil
+}
+
+const (
+	pathTokenHelpSyn = `
+Retrieves the token of a SAML login.
+`
+	pathTokenHelpDesc = `
+Returns the Vault token of a login started with sso_service_url, given its
+token_poll_id and the verifier of its client_challenge. While the identity
+provider has not posted its response, the error "authorization_pending" is
+returned and the client should keep polling.
+`
+)
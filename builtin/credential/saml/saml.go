@@ -0,0 +1,354 @@
+package saml
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/beevik/etree"
+	"github.com/hashicorp/errwrap"
+	uuid "github.com/hashicorp/go-uuid"
+	dsig "github.com/russellhaering/goxmldsig"
+	"github.com/russellhaering/goxmldsig/etreeutils"
+)
+
+const (
+	assertionNamespace = "urn:oasis:names:tc:SAML:2.0:assertion"
+
+	bindingHTTPPost           = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-POST"
+	statusSuccess             = "urn:oasis:names:tc:SAML:2.0:status:Success"
+	subjectConfirmationBearer = "urn:oasis:names:tc:SAML:2.0:cm:bearer"
+
+	// allowedClockSkew is the leeway given when checking the validity period
+	// of assertions, for the clock differences between Vault and the IdP
+	allowedClockSkew = 90 * time.Second
+)
+
+type authnRequest struct {
+	XMLName                     xml.Name     `xml:"urn:oasis:names:tc:SAML:2.0:protocol AuthnRequest"`
+	ID                          string       `xml:"ID,attr"`
+	Version                     string       `xml:"Version,attr"`
+	IssueInstant                string       `xml:"IssueInstant,attr"`
+	Destination                 string       `xml:"Destination,attr"`
+	ProtocolBinding             string       `xml:"ProtocolBinding,attr"`
+	AssertionConsumerServiceURL string       `xml:"AssertionConsumerServiceURL,attr"`
+	Issuer                      issuer       `xml:"urn:oasis:names:tc:SAML:2.0:assertion Issuer"`
+	NameIDPolicy                nameIDPolicy `xml:"urn:oasis:names:tc:SAML:2.0:protocol NameIDPolicy"`
+}
+
+type nameIDPolicy struct {
+	AllowCreate bool `xml:"AllowCreate,attr"`
+}
+
+type issuer struct {
+	Value string `xml:",chardata"`
+}
+
+type response struct {
+	XMLName      xml.Name `xml:"urn:oasis:names:tc:SAML:2.0:protocol Response"`
+	InResponseTo string   `xml:"InResponseTo,attr"`
+	Destination  string   `xml:"Destination,attr"`
+	Issuer       *issuer  `xml:"urn:oasis:names:tc:SAML:2.0:assertion Issuer"`
+	Status       struct {
+		StatusCode struct {
+			Value string `xml:"Value,attr"`
+		} `xml:"urn:oasis:names:tc:SAML:2.0:protocol StatusCode"`
+		StatusMessage string `xml:"urn:oasis:names:tc:SAML:2.0:protocol StatusMessage"`
+	} `xml:"urn:oasis:names:tc:SAML:2.0:protocol Status"`
+}
+
+type assertion struct {
+	XMLName xml.Name `xml:"urn:oasis:names:tc:SAML:2.0:assertion Assertion"`
+	Issuer  issuer   `xml:"urn:oasis:names:tc:SAML:2.0:assertion Issuer"`
+	Subject struct {
+		NameID               string `xml:"urn:oasis:names:tc:SAML:2.0:assertion NameID"`
+		SubjectConfirmations []struct {
+			Method string `xml:"Method,attr"`
+			Data   struct {
+				NotOnOrAfter time.Time `xml:"NotOnOrAfter,attr"`
+				Recipient    string    `xml:"Recipient,attr"`
+				InResponseTo string    `xml:"InResponseTo,attr"`
+			} `xml:"urn:oasis:names:tc:SAML:2.0:assertion SubjectConfirmationData"`
+		} `xml:"urn:oasis:names:tc:SAML:2.0:assertion SubjectConfirmation"`
+	} `xml:"urn:oasis:names:tc:SAML:2.0:assertion Subject"`
+	Conditions *struct {
+		NotBefore            time.Time `xml:"NotBefore,attr"`
+		NotOnOrAfter         time.Time `xml:"NotOnOrAfter,attr"`
+		AudienceRestrictions []struct {
+			Audiences []string `xml:"urn:oasis:names:tc:SAML:2.0:assertion Audience"`
+		} `xml:"urn:oasis:names:tc:SAML:2.0:assertion AudienceRestriction"`
+	} `xml:"urn:oasis:names:tc:SAML:2.0:assertion Conditions"`
+	Attributes []struct {
+		Name   string   `xml:"Name,attr"`
+		Values []string `xml:"urn:oasis:names:tc:SAML:2.0:assertion AttributeValue"`
+	} `xml:"urn:oasis:names:tc:SAML:2.0:assertion AttributeStatement>Attribute"`
+}
+
+// assertionInfo is the information of a validated assertion about the user
+type assertionInfo struct {
+	NameID     string              `json:"name_id"`
+	Attributes map[string][]string `json:"attributes"`
+}
+
+// newAuthnRequest returns the ID of a new authentication request of the
+// service provider, and the URL of the IdP the user is to be redirected to
+// with it, using the HTTP-Redirect binding.
+func newAuthnRequest(config *samlConfig, acsURL, relayState string) (string, string, error) {
+	id, err := uuid.GenerateUUID()
+	if err != nil {
+		return "", "", err
+	}
+	// IDs must not start with a digit
+	id = "_" + id
+
+	request := &authnRequest{
+		ID:                          id,
+		Version:                     "2.0",
+		IssueInstant:                time.Now().UTC().Format(time.RFC3339),
+		Destination:                 config.IDPSSOURL,
+		ProtocolBinding:             bindingHTTPPost,
+		AssertionConsumerServiceURL: acsURL,
+		Issuer:                      issuer{Value: config.EntityID},
+		NameIDPolicy:                nameIDPolicy{AllowCreate: true},
+	}
+	raw, err := xml.Marshal(request)
+	if err != nil {
+		return "", "", err
+	}
+
+	var buf bytes.Buffer
+	w, err := flate.NewWriter(&buf, flate.DefaultCompression)
+	if err != nil {
+		return "", "", err
+	}
+	if _, err := w.Write(raw); err != nil {
+		return "", "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", "", err
+	}
+
+	ssoURL, err := url.Parse(config.IDPSSOURL)
+	if err != nil {
+		return "", "", errwrap.Wrapf("error parsing the IdP SSO URL: {{err}}", err)
+	}
+	query := ssoURL.Query()
+	query.Set("SAMLRequest", base64.StdEncoding.EncodeToString(buf.Bytes()))
+	query.Set("RelayState", relayState)
+	ssoURL.RawQuery = query.Encode()
+
+	return id, ssoURL.String(), nil
+}
+
+// validateResponse verifies the signature of a base64 encoded SAML response
+// to the request, which was posted to the ACS URL, and validates the
+// assertion it holds. Either the response or the assertion must be signed by
+// one of the certificates of the IdP.
+func validateResponse(config *samlConfig, encoded, requestID, acsURL string, now time.Time) (*assertionInfo, error) {
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, errwrap.Wrapf("error decoding the SAML response: {{err}}", err)
+	}
+
+	doc := etree.NewDocument()
+	if err := doc.ReadFromBytes(raw); err != nil {
+		return nil, errwrap.Wrapf("error parsing the SAML response: {{err}}", err)
+	}
+	root := doc.Root()
+	if root == nil {
+		return nil, errors.New("empty SAML response")
+	}
+
+	validator := dsig.NewDefaultValidationContext(&dsig.MemoryX509CertificateStore{
+		Roots: config.ParsedIDPCerts,
+	})
+	validator.Clock = dsig.NewFakeClockAt(now)
+
+	// Only the signed elements are used from here on, so that unsigned
+	// content can't be injected next to them
+	responseSigned := false
+	sig, err := etreeutils.NSFindOneChild(root, dsig.Namespace, dsig.SignatureTag)
+	if err != nil {
+		return nil, err
+	}
+	if sig != nil {
+		root, err = validator.Validate(root)
+		if err != nil {
+			return nil, errwrap.Wrapf("error validating the signature of the SAML response: {{err}}", err)
+		}
+		responseSigned = true
+	}
+
+	ctx, err := etreeutils.NSBuildParentContext(root)
+	if err != nil {
+		return nil, err
+	}
+	ctx, err = ctx.SubContext(root)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp response
+	if err := etreeutils.NSUnmarshalElement(ctx, root, &resp); err != nil {
+		return nil, errwrap.Wrapf("error parsing the SAML response: {{err}}", err)
+	}
+	switch {
+	case resp.Status.StatusCode.Value != statusSuccess:
+		return nil, fmt.Errorf("the IdP returned an unsuccessful status %q: %s", resp.Status.StatusCode.Value, resp.Status.StatusMessage)
+	case resp.InResponseTo != requestID:
+		return nil, errors.New("the SAML response is not a response to the login request")
+	case resp.Destination != "" && resp.Destination != acsURL:
+		return nil, fmt.Errorf("the SAML response was sent to %q rather than %q", resp.Destination, acsURL)
+	case resp.Issuer != nil && resp.Issuer.Value != config.IDPEntityID:
+		return nil, fmt.Errorf("the SAML response was issued by %q rather than %q", resp.Issuer.Value, config.IDPEntityID)
+	}
+
+	encrypted, err := etreeutils.NSFindOneChildCtx(ctx, root, assertionNamespace, "EncryptedAssertion")
+	if err != nil {
+		return nil, err
+	}
+	if encrypted != nil {
+		return nil, errors.New("encrypted assertions are not supported")
+	}
+
+	var assertionEl *etree.Element
+	var assertionCtx etreeutils.NSContext
+	err = etreeutils.NSFindChildrenIterateCtx(ctx, root, assertionNamespace, "Assertion", func(ctx etreeutils.NSContext, el *etree.Element) error {
+		if assertionEl != nil {
+			return errors.New("the SAML response holds more than one assertion")
+		}
+		assertionEl, assertionCtx = el, ctx
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if assertionEl == nil {
+		return nil, errors.New("the SAML response holds no assertion")
+	}
+
+	sig, err = etreeutils.NSFindOneChildCtx(assertionCtx, assertionEl, dsig.Namespace, dsig.SignatureTag)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case sig != nil:
+		detached, err := etreeutils.NSDetatch(assertionCtx, assertionEl)
+		if err != nil {
+			return nil, err
+		}
+		assertionEl, err = validator.Validate(detached)
+		if err != nil {
+			return nil, errwrap.Wrapf("error validating the signature of the assertion: {{err}}", err)
+		}
+	case !responseSigned:
+		return nil, errors.New("neither the SAML response nor its assertion is signed")
+	}
+
+	var a assertion
+	if err := etreeutils.NSUnmarshalElement(assertionCtx, assertionEl, &a); err != nil {
+		return nil, errwrap.Wrapf("error parsing the assertion: {{err}}", err)
+	}
+	if err := validateAssertion(config, &a, requestID, acsURL, now); err != nil {
+		return nil, err
+	}
+
+	info := &assertionInfo{
+		NameID:     a.Subject.NameID,
+		Attributes: make(map[string][]string),
+	}
+	for _, attr := range a.Attributes {
+		info.Attributes[attr.Name] = append(info.Attributes[attr.Name], attr.Values...)
+	}
+	return info, nil
+}
+
+// validateAssertion checks that the assertion was issued by the IdP for the
+// service provider, in response to the request, and that it is currently
+// valid
+func validateAssertion(config *samlConfig, a *assertion, requestID, acsURL string, now time.Time) error {
+	if a.Issuer.Value != config.IDPEntityID {
+		return fmt.Errorf("the assertion was issued by %q rather than %q", a.Issuer.Value, config.IDPEntityID)
+	}
+	if a.Subject.NameID == "" {
+		return errors.New("the assertion has no subject")
+	}
+
+	// A bearer confirmation of the subject is required
+	confirmed := false
+	for _, c := range a.Subject.SubjectConfirmations {
+		if c.Method != subjectConfirmationBearer ||
+			c.Data.Recipient != acsURL ||
+			c.Data.InResponseTo != requestID ||
+			c.Data.NotOnOrAfter.IsZero() ||
+			!now.Before(c.Data.NotOnOrAfter.Add(allowedClockSkew)) {
+			continue
+		}
+		confirmed = true
+		break
+	}
+	if !confirmed {
+		return errors.New("the subject of the assertion could not be confirmed")
+	}
+
+	if a.Conditions == nil {
+		return errors.New("the assertion has no conditions")
+	}
+	if !a.Conditions.NotBefore.IsZero() && now.Add(allowedClockSkew).Before(a.Conditions.NotBefore) {
+		return errors.New("the assertion is not valid yet")
+	}
+	if !a.Conditions.NotOnOrAfter.IsZero() && !now.Before(a.Conditions.NotOnOrAfter.Add(allowedClockSkew)) {
+		return errors.New("the assertion has expired")
+	}
+
+	// Every audience restriction must include Vault
+	if len(a.Conditions.AudienceRestrictions) == 0 {
+		return errors.New("the assertion has no audience restriction")
+	}
+	for _, restriction := range a.Conditions.AudienceRestrictions {
+		found := false
+		for _, audience := range restriction.Audiences {
+			if audience == config.EntityID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("the audience of the assertion does not include %q", config.EntityID)
+		}
+	}
+
+	return nil
+}
+
+// parseCertificates parses the PEM encoded certificates of the IdP
+func parseCertificates(pemCerts string) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	rest := []byte(pemCerts)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, errors.New("no PEM encoded certificate found")
+	}
+	return certs, nil
+}
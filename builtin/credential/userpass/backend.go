@@ -18,6 +18,7 @@ func Factory(ctx context.Context, conf *logical.BackendConfig) (logical.Backend,
 
 func Backend() *backend {
 	var b backend
+	loginPath := pathLogin(&b)
 	b.Backend = &framework.Backend{
 		Help: backendHelp,
 
@@ -34,14 +35,20 @@ func Backend() *backend {
 			pathUsersList(&b),
 			pathUserPolicies(&b),
 			pathUserPassword(&b),
+			pathPasswordPolicies(&b),
+			pathPasswordPoliciesList(&b),
 		},
-			mfa.MFAPaths(b.Backend, pathLogin(&b))...,
+			mfa.MFAPaths(b.Backend, loginPath)...,
 		),
 
 		AuthRenew:   b.pathLoginRenew,
 		BackendType: logical.TypeCredential,
 	}
 
+	// Passwords are checked as users log in, and changed by the login commit
+	// once the login, including its MFA, succeeded
+	loginPath.Callbacks[logical.UpdateOperation] = b.changePasswordOnLogin(loginPath.Callbacks[logical.UpdateOperation])
+
 	return &b
 }
 
@@ -207,6 +207,135 @@ func TestBackend_policiesUpdate(t *testing.T) {
 
 }
 
+func TestBackend_passwordPolicy(t *testing.T) {
+	storage := &logical.InmemStorage{}
+
+	config := logical.TestBackendConfig()
+	config.StorageView = storage
+
+	ctx := context.Background()
+
+	b, err := Factory(ctx, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request := func(operation logical.Operation, path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := b.HandleRequest(ctx, &logical.Request{
+			Path:       path,
+			Operation:  operation,
+			Storage:    storage,
+			Data:       data,
+			Connection: &logical.Connection{RemoteAddr: "127.0.0.1"},
+		})
+		if err != nil && err != logical.ErrInvalidRequest {
+			t.Fatalf("bad: path: %s err: %v", path, err)
+		}
+		return resp
+	}
+	expectSuccess := func(resp *logical.Response) {
+		t.Helper()
+		if resp != nil && resp.IsError() {
+			t.Fatalf("bad: resp: %#v", resp)
+		}
+	}
+	expectError := func(resp *logical.Response, expected string) {
+		t.Helper()
+		if resp == nil || !resp.IsError() || resp.Error().Error() != expected {
+			t.Fatalf("expected error %q, got: %#v", expected, resp)
+		}
+	}
+
+	expectSuccess(request(logical.CreateOperation, "password_policies/strict", map[string]interface{}{
+		"min_length":     10,
+		"min_digits":     1,
+		"history_length": 2,
+	}))
+
+	// Users can't reference unknown policies
+	expectError(request(logical.CreateOperation, "users/web", map[string]interface{}{
+		"password":        "password1234",
+		"password_policy": "unknown",
+	}), `password policy "unknown" does not exist`)
+
+	// Passwords must meet the requirements of the policy
+	expectError(request(logical.CreateOperation, "users/web", map[string]interface{}{
+		"password":        "password",
+		"password_policy": "strict",
+	}), "password must be at least 10 characters long")
+	expectError(request(logical.CreateOperation, "users/web", map[string]interface{}{
+		"password":        "passwordpassword",
+		"password_policy": "strict",
+	}), "password must contain at least 1 digits")
+	expectSuccess(request(logical.CreateOperation, "users/web", map[string]interface{}{
+		"password":        "password1234",
+		"password_policy": "strict",
+	}))
+
+	// The last two passwords can't be reused
+	expectError(request(logical.UpdateOperation, "users/web/password", map[string]interface{}{
+		"password": "password1234",
+	}), "password must not be one of the last 2 passwords")
+	expectSuccess(request(logical.UpdateOperation, "users/web/password", map[string]interface{}{
+		"password": "password5678",
+	}))
+	expectError(request(logical.UpdateOperation, "users/web/password", map[string]interface{}{
+		"password": "password1234",
+	}), "password must not be one of the last 2 passwords")
+	expectSuccess(request(logical.UpdateOperation, "users/web/password", map[string]interface{}{
+		"password": "password9012",
+	}))
+	expectSuccess(request(logical.UpdateOperation, "users/web/password", map[string]interface{}{
+		"password": "password1234",
+	}))
+
+	// Users forced to change their password must provide a new one
+	// complying with the policy as they log in
+	expectSuccess(request(logical.UpdateOperation, "users/web", map[string]interface{}{
+		"force_password_change": true,
+	}))
+	expectError(request(logical.UpdateOperation, "login/web", map[string]interface{}{
+		"password": "password1234",
+	}), "password change required, log in again providing a new_password")
+	expectError(request(logical.UpdateOperation, "login/web", map[string]interface{}{
+		"password":     "password1234",
+		"new_password": "password9012",
+	}), "password must not be one of the last 2 passwords")
+
+	resp := request(logical.UpdateOperation, "login/web", map[string]interface{}{
+		"password":     "password1234",
+		"new_password": "password3456",
+	})
+	if resp == nil || resp.IsError() || resp.Auth == nil || !resp.Auth.LoginCommit {
+		t.Fatalf("expected the login to succeed, got: %#v", resp)
+	}
+
+	// The password is only changed once core commits the login
+	resp = request(logical.ReadOperation, "users/web", nil)
+	if resp.Data["force_password_change"] != true {
+		t.Fatalf("bad: resp: %#v", resp)
+	}
+	expectSuccess(request(logical.LoginCommitOperation, "login/web", map[string]interface{}{
+		"password":     "password1234",
+		"new_password": "password3456",
+	}))
+
+	resp = request(logical.ReadOperation, "users/web", nil)
+	if resp.Data["force_password_change"] != false || resp.Data["password_policy"] != "strict" {
+		t.Fatalf("bad: resp: %#v", resp)
+	}
+	expectError(request(logical.UpdateOperation, "login/web", map[string]interface{}{
+		"password": "password1234",
+	}), "invalid username or password")
+	resp = request(logical.UpdateOperation, "login/web", map[string]interface{}{
+		"password": "password3456",
+	})
+	if resp == nil || resp.IsError() || resp.Auth == nil {
+		t.Fatalf("expected the login to succeed, got: %#v", resp)
+	}
+}
+
 func testUpdatePassword(t *testing.T, user, password string) logicaltest.TestStep {
 	return logicaltest.TestStep{
 		Operation: logical.UpdateOperation,
@@ -26,11 +26,17 @@ func pathLogin(b *backend) *framework.Path {
 				Type:        framework.TypeString,
 				Description: "Password for this user.",
 			},
+
+			"new_password": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "New password for this user, set once the login, including its MFA, succeeds. Required when the user must change their password.",
+			},
 		},
 
 		Callbacks: map[logical.Operation]framework.OperationFunc{
 			logical.UpdateOperation:         b.pathLogin,
 			logical.AliasLookaheadOperation: b.pathLoginAliasLookahead,
+			logical.LoginCommitOperation:    b.pathLoginCommit,
 		},
 
 		HelpSynopsis:    pathLoginSyn,
@@ -108,6 +114,12 @@ func (b *backend) pathLogin(ctx context.Context, req *logical.Request, d *framew
 		return logical.ErrorResponse("login request originated from invalid CIDR"), nil
 	}
 
+	// The new password is set by changePasswordOnLogin once the login
+	// succeeded
+	if user.ForcePasswordChange && d.Get("new_password").(string) == "" {
+		return logical.ErrorResponse("password change required, log in again providing a new_password"), nil
+	}
+
 	return &logical.Response{
 		Auth: &logical.Auth{
 			Policies: user.Policies,
@@ -128,6 +140,73 @@ func (b *backend) pathLogin(ctx context.Context, req *logical.Request, d *framew
 	}, nil
 }
 
+// changePasswordOnLogin wraps the login handler to check the new password of
+// the user. The password is only changed by pathLoginCommit, once the login,
+// including its MFA verification, succeeded. No token is issued if the new
+// password is refused.
+func (b *backend) changePasswordOnLogin(loginHandler framework.OperationFunc) framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		resp, err := loginHandler(ctx, req, d)
+		if err != nil || resp == nil || resp.IsError() || resp.Auth == nil {
+			return resp, err
+		}
+
+		newPassword := d.Get("new_password").(string)
+		if newPassword == "" {
+			return resp, nil
+		}
+
+		username := strings.ToLower(d.Get("username").(string))
+		user, err := b.user(ctx, req.Storage, username)
+		if err != nil {
+			return nil, err
+		}
+		if user == nil {
+			return logical.ErrorResponse("invalid username or password"), nil
+		}
+
+		_, userErr, intErr := b.validateUserPassword(ctx, req.Storage, newPassword, user)
+		if intErr != nil {
+			return nil, intErr
+		}
+		if userErr != nil {
+			return logical.ErrorResponse(userErr.Error()), nil
+		}
+
+		resp.Auth.LoginCommit = true
+		return resp, nil
+	}
+}
+
+// pathLoginCommit sets the new password of the user once core accepted the
+// login
+func (b *backend) pathLoginCommit(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	newPassword := d.Get("new_password").(string)
+	if newPassword == "" {
+		return nil, nil
+	}
+
+	username := strings.ToLower(d.Get("username").(string))
+	user, err := b.user(ctx, req.Storage, username)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return logical.ErrorResponse("invalid username or password"), nil
+	}
+
+	userErr, intErr := b.updateUserPassword(ctx, req.Storage, newPassword, user)
+	if intErr != nil {
+		return nil, intErr
+	}
+	if userErr != nil {
+		return logical.ErrorResponse(userErr.Error()), nil
+	}
+	user.ForcePasswordChange = false
+
+	return nil, b.setUser(ctx, req.Storage, username, user)
+}
+
 func (b *backend) pathLoginRenew(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
 	// Get the user
 	user, err := b.user(ctx, req.Storage, req.Auth.Metadata["username"])
@@ -155,4 +234,7 @@ Log in with a username and password.
 
 const pathLoginDesc = `
 This endpoint authenticates using a username and password.
+
+Users may change their password as they log in by providing a
+"new_password", which is required when they must change it.
 `
@@ -0,0 +1,261 @@
+package userpass
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func pathPasswordPoliciesList(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "password_policies/?",
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ListOperation: b.pathPasswordPolicyList,
+		},
+
+		HelpSynopsis:    pathPasswordPolicyHelpSyn,
+		HelpDescription: pathPasswordPolicyHelpDesc,
+	}
+}
+
+func pathPasswordPolicies(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "password_policies/" + framework.GenericNameRegex("name"),
+		Fields: map[string]*framework.FieldSchema{
+			"name": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Name of the password policy.",
+			},
+
+			"min_length": &framework.FieldSchema{
+				Type:        framework.TypeInt,
+				Description: "Minimum number of characters of the passwords.",
+			},
+
+			"min_uppercase": &framework.FieldSchema{
+				Type:        framework.TypeInt,
+				Description: "Minimum number of uppercase letters of the passwords.",
+			},
+
+			"min_lowercase": &framework.FieldSchema{
+				Type:        framework.TypeInt,
+				Description: "Minimum number of lowercase letters of the passwords.",
+			},
+
+			"min_digits": &framework.FieldSchema{
+				Type:        framework.TypeInt,
+				Description: "Minimum number of digits of the passwords.",
+			},
+
+			"min_symbols": &framework.FieldSchema{
+				Type:        framework.TypeInt,
+				Description: "Minimum number of punctuation characters and symbols of the passwords.",
+			},
+
+			"history_length": &framework.FieldSchema{
+				Type: framework.TypeInt,
+				Description: `Number of the last passwords of the users, including their current
+one, which can't be reused. Defaults to 0, allowing any password to be reused.`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.DeleteOperation: b.pathPasswordPolicyDelete,
+			logical.ReadOperation:   b.pathPasswordPolicyRead,
+			logical.UpdateOperation: b.pathPasswordPolicyWrite,
+			logical.CreateOperation: b.pathPasswordPolicyWrite,
+		},
+
+		ExistenceCheck: b.passwordPolicyExistenceCheck,
+
+		HelpSynopsis:    pathPasswordPolicyHelpSyn,
+		HelpDescription: pathPasswordPolicyHelpDesc,
+	}
+}
+
+func (b *backend) passwordPolicyExistenceCheck(ctx context.Context, req *logical.Request, data *framework.FieldData) (bool, error) {
+	policy, err := b.passwordPolicy(ctx, req.Storage, data.Get("name").(string))
+	if err != nil {
+		return false, err
+	}
+
+	return policy != nil, nil
+}
+
+func (b *backend) passwordPolicy(ctx context.Context, s logical.Storage, name string) (*PasswordPolicyEntry, error) {
+	if name == "" {
+		return nil, fmt.Errorf("missing password policy name")
+	}
+
+	entry, err := s.Get(ctx, "password_policy/"+strings.ToLower(name))
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var result PasswordPolicyEntry
+	if err := entry.DecodeJSON(&result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (b *backend) pathPasswordPolicyList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	policies, err := req.Storage.List(ctx, "password_policy/")
+	if err != nil {
+		return nil, err
+	}
+	return logical.ListResponse(policies), nil
+}
+
+func (b *backend) pathPasswordPolicyDelete(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	err := req.Storage.Delete(ctx, "password_policy/"+strings.ToLower(d.Get("name").(string)))
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+func (b *backend) pathPasswordPolicyRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	policy, err := b.passwordPolicy(ctx, req.Storage, d.Get("name").(string))
+	if err != nil {
+		return nil, err
+	}
+	if policy == nil {
+		return nil, nil
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"min_length":     policy.MinLength,
+			"min_uppercase":  policy.MinUppercase,
+			"min_lowercase":  policy.MinLowercase,
+			"min_digits":     policy.MinDigits,
+			"min_symbols":    policy.MinSymbols,
+			"history_length": policy.HistoryLength,
+		},
+	}, nil
+}
+
+func (b *backend) pathPasswordPolicyWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := strings.ToLower(d.Get("name").(string))
+	policy, err := b.passwordPolicy(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if policy == nil {
+		policy = &PasswordPolicyEntry{}
+	}
+
+	for field, value := range map[string]*int{
+		"min_length":     &policy.MinLength,
+		"min_uppercase":  &policy.MinUppercase,
+		"min_lowercase":  &policy.MinLowercase,
+		"min_digits":     &policy.MinDigits,
+		"min_symbols":    &policy.MinSymbols,
+		"history_length": &policy.HistoryLength,
+	} {
+		raw, ok := d.GetOk(field)
+		if !ok {
+			continue
+		}
+		if raw.(int) < 0 {
+			return logical.ErrorResponse(fmt.Sprintf("%s must not be negative", field)), logical.ErrInvalidRequest
+		}
+		*value = raw.(int)
+	}
+
+	entry, err := logical.StorageEntryJSON("password_policy/"+name, policy)
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, req.Storage.Put(ctx, entry)
+}
+
+type PasswordPolicyEntry struct {
+	MinLength    int
+	MinUppercase int
+	MinLowercase int
+	MinDigits    int
+	MinSymbols   int
+
+	// HistoryLength is the number of the last passwords of the users,
+	// including their current one, which can't be reused
+	HistoryLength int
+}
+
+// validate returns an error describing the first requirement of the policy
+// the password doesn't meet
+func (p *PasswordPolicyEntry) validate(password string) error {
+	var length, uppercase, lowercase, digits, symbols int
+	for _, r := range password {
+		length++
+		switch {
+		case unicode.IsUpper(r):
+			uppercase++
+		case unicode.IsLower(r):
+			lowercase++
+		case unicode.IsDigit(r):
+			digits++
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			symbols++
+		}
+	}
+
+	switch {
+	case length < p.MinLength:
+		return fmt.Errorf("password must be at least %d characters long", p.MinLength)
+	case uppercase < p.MinUppercase:
+		return fmt.Errorf("password must contain at least %d uppercase letters", p.MinUppercase)
+	case lowercase < p.MinLowercase:
+		return fmt.Errorf("password must contain at least %d lowercase letters", p.MinLowercase)
+	case digits < p.MinDigits:
+		return fmt.Errorf("password must contain at least %d digits", p.MinDigits)
+	case symbols < p.MinSymbols:
+		return fmt.Errorf("password must contain at least %d symbols", p.MinSymbols)
+	}
+	return nil
+}
+
+// reused returns whether the password is one of the last passwords of the
+// user the policy prevents reusing
+func (p *PasswordPolicyEntry) reused(password string, userEntry *UserEntry) bool {
+	if p.HistoryLength == 0 {
+		return false
+	}
+
+	hashes := append([][]byte{userEntry.PasswordHash}, userEntry.PasswordHistory...)
+	if len(hashes) > p.HistoryLength {
+		hashes = hashes[:p.HistoryLength]
+	}
+	for _, hash := range hashes {
+		if hash != nil && bcrypt.CompareHashAndPassword(hash, []byte(password)) == nil {
+			return true
+		}
+	}
+	return false
+}
+
+const pathPasswordPolicyHelpSyn = `
+Manage the policies the passwords of the users must comply with.
+`
+
+const pathPasswordPolicyHelpDesc = `
+This endpoint allows you to create, read, update, and delete password
+policies. Users referencing a policy through their "password_policy" are
+refused new passwords which don't meet its requirements, or which are one
+of their last "history_length" passwords.
+
+Changes to a policy apply to the passwords set afterwards only.
+`
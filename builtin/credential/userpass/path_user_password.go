@@ -45,31 +45,82 @@ func (b *backend) pathUserPasswordUpdate(ctx context.Context, req *logical.Reque
 		return nil, fmt.Errorf("username does not exist")
 	}
 
-	userErr, intErr := b.updateUserPassword(req, d, userEntry)
+	userErr, intErr := b.updateUserPassword(ctx, req.Storage, d.Get("password").(string), userEntry)
 	if intErr != nil {
-		return nil, err
+		return nil, intErr
 	}
 	if userErr != nil {
 		return logical.ErrorResponse(userErr.Error()), logical.ErrInvalidRequest
 	}
 
+	// The user no longer has to change the password set for them
+	userEntry.ForcePasswordChange = false
+
 	return nil, b.setUser(ctx, req.Storage, username, userEntry)
 }
 
-func (b *backend) updateUserPassword(req *logical.Request, d *framework.FieldData, userEntry *UserEntry) (error, error) {
-	password := d.Get("password").(string)
-	if password == "" {
-		return fmt.Errorf("missing password"), nil
+// updateUserPassword sets the password of the user, if it meets the password
+// policy of the user. It returns a user error if it doesn't, and an internal
+// error otherwise.
+func (b *backend) updateUserPassword(ctx context.Context, s logical.Storage, password string, userEntry *UserEntry) (error, error) {
+	historyLength, userErr, intErr := b.validateUserPassword(ctx, s, password, userEntry)
+	if userErr != nil || intErr != nil {
+		return userErr, intErr
 	}
+
 	// Generate a hash of the password
 	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
 		return nil, err
 	}
+
+	// Keep the hashes of the previous passwords which, along with the new
+	// one, are the last passwords the policy prevents reusing, the most
+	// recent first
+	history := userEntry.PasswordHistory
+	if userEntry.PasswordHash != nil {
+		history = append([][]byte{userEntry.PasswordHash}, history...)
+	}
+	switch {
+	case historyLength <= 1:
+		history = nil
+	case len(history) > historyLength-1:
+		history = history[:historyLength-1]
+	}
+	userEntry.PasswordHistory = history
+
 	userEntry.PasswordHash = hash
 	return nil, nil
 }
 
+// validateUserPassword checks the password against the password policy of the
+// user, returning the number of previous passwords the policy prevents
+// reusing. It returns a user error if the password doesn't meet the policy,
+// and an internal error otherwise.
+func (b *backend) validateUserPassword(ctx context.Context, s logical.Storage, password string, userEntry *UserEntry) (int, error, error) {
+	if password == "" {
+		return 0, fmt.Errorf("missing password"), nil
+	}
+	if userEntry.PasswordPolicy == "" {
+		return 0, nil, nil
+	}
+
+	policy, err := b.passwordPolicy(ctx, s, userEntry.PasswordPolicy)
+	if err != nil {
+		return 0, nil, err
+	}
+	if policy == nil {
+		return 0, fmt.Errorf("password policy %q does not exist", userEntry.PasswordPolicy), nil
+	}
+	if err := policy.validate(password); err != nil {
+		return 0, err, nil
+	}
+	if policy.reused(password, userEntry) {
+		return 0, fmt.Errorf("password must not be one of the last %d passwords", policy.HistoryLength), nil
+	}
+	return policy.HistoryLength, nil, nil
+}
+
 const pathUserPasswordHelpSyn = `
 Reset user's password.
 `
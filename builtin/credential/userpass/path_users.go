@@ -60,6 +60,17 @@ func pathUsers(b *backend) *framework.Path {
 				Description: `Comma separated string or list of CIDR blocks. If set, specifies the blocks of
 IP addresses which can perform the login operation.`,
 			},
+
+			"password_policy": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Name of the password policy the passwords of the user must comply with.",
+			},
+
+			"force_password_change": &framework.FieldSchema{
+				Type: framework.TypeBool,
+				Description: `If set, the user must change their password on their next login.
+Defaults to false, and is reset whenever the password changes.`,
+			},
 		},
 
 		Callbacks: map[logical.Operation]framework.OperationFunc{
@@ -143,10 +154,12 @@ func (b *backend) pathUserRead(ctx context.Context, req *logical.Request, d *fra
 
 	return &logical.Response{
 		Data: map[string]interface{}{
-			"policies":    user.Policies,
-			"ttl":         user.TTL.Seconds(),
-			"max_ttl":     user.MaxTTL.Seconds(),
-			"bound_cidrs": user.BoundCIDRs,
+			"policies":              user.Policies,
+			"ttl":                   user.TTL.Seconds(),
+			"max_ttl":               user.MaxTTL.Seconds(),
+			"bound_cidrs":           user.BoundCIDRs,
+			"password_policy":       user.PasswordPolicy,
+			"force_password_change": user.ForcePasswordChange,
 		},
 	}, nil
 }
@@ -162,14 +175,32 @@ func (b *backend) userCreateUpdate(ctx context.Context, req *logical.Request, d
 		userEntry = &UserEntry{}
 	}
 
-	if _, ok := d.GetOk("password"); ok {
-		userErr, intErr := b.updateUserPassword(req, d, userEntry)
+	if passwordPolicyRaw, ok := d.GetOk("password_policy"); ok {
+		userEntry.PasswordPolicy = strings.ToLower(passwordPolicyRaw.(string))
+		if userEntry.PasswordPolicy != "" {
+			policy, err := b.passwordPolicy(ctx, req.Storage, userEntry.PasswordPolicy)
+			if err != nil {
+				return nil, err
+			}
+			if policy == nil {
+				return logical.ErrorResponse(fmt.Sprintf("password policy %q does not exist", userEntry.PasswordPolicy)), logical.ErrInvalidRequest
+			}
+		}
+	}
+
+	if password, ok := d.GetOk("password"); ok {
+		userErr, intErr := b.updateUserPassword(ctx, req.Storage, password.(string), userEntry)
 		if intErr != nil {
-			return nil, err
+			return nil, intErr
 		}
 		if userErr != nil {
 			return logical.ErrorResponse(userErr.Error()), logical.ErrInvalidRequest
 		}
+		userEntry.ForcePasswordChange = false
+	}
+
+	if forcePasswordChange, ok := d.GetOk("force_password_change"); ok {
+		userEntry.ForcePasswordChange = forcePasswordChange.(bool)
 	}
 
 	if policiesRaw, ok := d.GetOk("policies"); ok {
@@ -221,6 +252,18 @@ type UserEntry struct {
 	MaxTTL time.Duration
 
 	BoundCIDRs []*sockaddr.SockAddrMarshaler
+
+	// PasswordPolicy is the name of the password policy the passwords of
+	// the user must comply with
+	PasswordPolicy string
+
+	// PasswordHistory holds the bcrypt hashes of the previous passwords
+	// the password policy prevents reusing, the most recent first
+	PasswordHistory [][]byte
+
+	// ForcePasswordChange requires the user to change their password on
+	// their next login
+	ForcePasswordChange bool
 }
 
 const pathUserHelpSyn = `
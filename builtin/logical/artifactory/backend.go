@@ -0,0 +1,76 @@
+package artifactory
+
+import (
+	"context"
+	"strings"
+
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+// Factory returns an Artifactory backend that satisfies the logical.Backend
+// interface
+func Factory(ctx context.Context, conf *logical.BackendConfig) (logical.Backend, error) {
+	b := Backend()
+	if err := b.Setup(ctx, conf); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Backend returns the Artifactory backend
+func Backend() *backend {
+	var b backend
+	b.Backend = &framework.Backend{
+		Help: strings.TrimSpace(backendHelp),
+
+		PathsSpecial: &logical.Paths{
+			SealWrapStorage: []string{
+				"config",
+			},
+		},
+
+		Paths: []*framework.Path{
+			pathConfig(&b),
+			framework.PathConfigVerify("config", b.verifyConfig),
+			pathListRoles(&b),
+			pathRoles(&b),
+			pathCreds(&b),
+		},
+
+		Secrets: []*framework.Secret{
+			secretAccessToken(&b),
+		},
+		BackendType: logical.TypeLogical,
+	}
+
+	return &b
+}
+
+type backend struct {
+	*framework.Backend
+}
+
+// client returns a client of the Artifactory API using the stored
+// configuration
+func (b *backend) client(ctx context.Context, s logical.Storage) (*client, error) {
+	conf, err := b.readConfig(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+	if conf == nil {
+		return nil, errNotConfigured
+	}
+
+	return clientFromConfig(conf), nil
+}
+
+const backendHelp = `
+The Artifactory backend brokers short-lived Artifactory access tokens.
+
+Using an admin access token configured with the "config" path, it creates
+non-refreshable access tokens with the scope of a role, revoked when their
+lease is revoked, so that CI pipelines don't need long-lived repository
+credentials. Create roles with the "roles/" endpoints and request tokens with
+the "creds/" endpoints.
+`
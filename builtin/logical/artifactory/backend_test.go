@@ -0,0 +1,238 @@
+package artifactory
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/hashicorp/vault/logical"
+)
+
+// testArtifactoryAPI is a fake of the parts of the Artifactory API used by
+// the backend, accepting the admin token "admin"
+type testArtifactoryAPI struct {
+	l      sync.Mutex
+	tokens map[string]url.Values
+	nextID int
+}
+
+func (a *testArtifactoryAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	a.l.Lock()
+	defer a.l.Unlock()
+
+	if r.Header.Get("Authorization") != "Bearer admin" {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"errors": [{"status": 401, "message": "Bad credentials"}]}`))
+		return
+	}
+
+	r.ParseForm()
+	switch r.URL.Path {
+	case "/artifactory/api/system/ping":
+		w.Write([]byte("OK"))
+
+	case "/artifactory/api/security/token":
+		a.nextID++
+		id := fmt.Sprintf("token-%d", a.nextID)
+		a.tokens[id] = r.PostForm
+		payload := base64.RawURLEncoding.EncodeToString([]byte(`{"jti": "` + id + `"}`))
+		json.NewEncoder(w).Encode(&accessToken{
+			AccessToken: "header." + payload + ".signature",
+			ExpiresIn:   1800,
+			Scope:       r.PostForm.Get("scope"),
+			TokenType:   "Bearer",
+		})
+
+	case "/artifactory/api/security/token/revoke":
+		id := r.PostForm.Get("token_id")
+		if _, ok := a.tokens[id]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error": "not_found", "error_description": "Token not found"}`))
+			return
+		}
+		delete(a.tokens, id)
+
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func testBackend(t *testing.T) (*backend, logical.Storage, *testArtifactoryAPI, func()) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+	b := Backend()
+	if err := b.Setup(context.Background(), config); err != nil {
+		t.Fatal(err)
+	}
+
+	api := &testArtifactoryAPI{
+		tokens: map[string]url.Values{},
+	}
+	server := httptest.NewServer(api)
+
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "config",
+		Storage:   config.StorageView,
+		Data: map[string]interface{}{
+			"url":          server.URL + "/artifactory",
+			"access_token": "admin",
+		},
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		server.Close()
+		t.Fatalf("err: %v, resp: %#v", err, resp)
+	}
+
+	return b, config.StorageView, api, server.Close
+}
+
+func TestBackend_Config(t *testing.T) {
+	b, storage, _, cleanup := testBackend(t)
+	defer cleanup()
+
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "config",
+		Storage:   storage,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := resp.Data["access_token"]; ok || !strings.HasSuffix(resp.Data["url"].(string), "/artifactory") {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "config/verify",
+		Storage:   storage,
+	})
+	if err != nil || resp.IsError() {
+		t.Fatalf("err: %v, resp: %#v", err, resp)
+	}
+
+	// A wrong admin token is rejected
+	if _, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "config",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"access_token": "wrong",
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "config/verify",
+		Storage:   storage,
+	})
+	if err != nil || !resp.IsError() {
+		t.Fatalf("expected verification to fail: err: %v, resp: %#v", err, resp)
+	}
+
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "config",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"url": "artifactory",
+		},
+	})
+	if err != nil || resp == nil || !resp.IsError() {
+		t.Fatalf("expected a relative URL to be rejected: err: %v, resp: %#v", err, resp)
+	}
+}
+
+func TestBackend_Roles(t *testing.T) {
+	b, storage, _, cleanup := testBackend(t)
+	defer cleanup()
+
+	for _, data := range []map[string]interface{}{
+		{"username": "user"},
+		{"scope": "member-of-groups:readers", "ttl": "2h", "max_ttl": "1h"},
+	} {
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation: logical.CreateOperation,
+			Path:      "roles/invalid",
+			Storage:   storage,
+			Data:      data,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp == nil || !resp.IsError() {
+			t.Fatalf("expected an error with %v", data)
+		}
+	}
+}
+
+func TestBackend_Creds(t *testing.T) {
+	b, storage, api, cleanup := testBackend(t)
+	defer cleanup()
+
+	if _, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.CreateOperation,
+		Path:      "roles/Readers",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"scope":    "member-of-groups:readers",
+			"audience": "jfrt@*",
+			"ttl":      "1h",
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "creds/Readers",
+		Storage:   storage,
+	})
+	if err != nil || resp.IsError() {
+		t.Fatalf("err: %v, resp: %#v", err, resp)
+	}
+	if resp.Data["token_id"] != "token-1" || resp.Data["scope"] != "member-of-groups:readers" {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+	// The validity returned by Artifactory takes precedence over the TTL
+	if resp.Secret.TTL.Minutes() != 30 || resp.Secret.Renewable {
+		t.Fatalf("bad: %#v", resp.Secret)
+	}
+
+	form := api.tokens["token-1"]
+	if form == nil || form.Get("expires_in") != "3600" || form.Get("audience") != "jfrt@*" || form.Get("refreshable") != "false" {
+		t.Fatalf("bad token request: %v", form)
+	}
+	if username := form.Get("username"); !strings.HasPrefix(username, "vault-readers-") || username != resp.Data["username"] {
+		t.Fatalf("bad username: %q", username)
+	}
+
+	if _, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.RevokeOperation,
+		Storage:   storage,
+		Secret:    resp.Secret,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := api.tokens["token-1"]; ok {
+		t.Fatal("expected the token to be revoked")
+	}
+
+	// Tokens which were already revoked are ignored
+	if _, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.RevokeOperation,
+		Storage:   storage,
+		Secret:    resp.Secret,
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,189 @@
+package artifactory
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	cleanhttp "github.com/hashicorp/go-cleanhttp"
+)
+
+var errNotConfigured = errors.New("the Artifactory backend is not configured")
+
+// accessToken is an access token created by Artifactory
+type accessToken struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int64  `json:"expires_in"`
+	Scope       string `json:"scope"`
+	TokenType   string `json:"token_type"`
+}
+
+// tokenID returns the ID of the token, which is the "jti" claim of the JWT,
+// or an empty string if it can't be decoded
+func (t *accessToken) tokenID() string {
+	parts := strings.Split(t.AccessToken, ".")
+	if len(parts) != 3 {
+		return ""
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return ""
+	}
+
+	var claims struct {
+		ID string `json:"jti"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return ""
+	}
+	return claims.ID
+}
+
+// apiError is an error returned by the Artifactory API
+type apiError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("%d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+func isNotFound(err error) bool {
+	apiErr, ok := err.(*apiError)
+	return ok && apiErr.StatusCode == http.StatusNotFound
+}
+
+// client is a minimal client of the Artifactory API, authenticating with the
+// configured admin access token
+type client struct {
+	url        string
+	token      string
+	httpClient *http.Client
+}
+
+func clientFromConfig(conf *artifactoryConfig) *client {
+	return &client{
+		url:        strings.TrimSuffix(conf.URL, "/"),
+		token:      conf.AccessToken,
+		httpClient: cleanhttp.DefaultClient(),
+	}
+}
+
+// do sends a request to the Artifactory API, with form as the URL encoded
+// body of the request, and decodes the JSON body of the response into out,
+// if not nil
+func (c *client) do(method, path string, form url.Values, out interface{}) error {
+	var body string
+	if form != nil {
+		body = form.Encode()
+	}
+
+	req, err := http.NewRequest(method, c.url+path, strings.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	if form != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &apiError{
+			StatusCode: resp.StatusCode,
+			Message:    errorMessage(respBody),
+		}
+	}
+
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(respBody, out)
+}
+
+// errorMessage extracts the message of an error from the body of a response,
+// in the formats of the REST API and of the token endpoints
+func errorMessage(body []byte) string {
+	var resp struct {
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.Unmarshal(body, &resp); err == nil {
+		switch {
+		case len(resp.Errors) != 0:
+			return resp.Errors[0].Message
+		case resp.ErrorDescription != "":
+			return resp.ErrorDescription
+		case resp.Error != "":
+			return resp.Error
+		}
+	}
+	return strings.TrimSpace(string(body))
+}
+
+// ping checks that Artifactory can be reached and that the configured token
+// is accepted
+func (c *client) ping() error {
+	return c.do(http.MethodGet, "/api/system/ping", nil, nil)
+}
+
+// createToken creates a non-refreshable access token for the user with the
+// given scope
+func (c *client) createToken(username, scope string, audience string, ttl time.Duration) (*accessToken, error) {
+	form := url.Values{
+		"username":    {username},
+		"scope":       {scope},
+		"expires_in":  {strconv.FormatInt(int64(ttl.Seconds()), 10)},
+		"refreshable": {"false"},
+	}
+	if audience != "" {
+		form.Set("audience", audience)
+	}
+
+	var token accessToken
+	if err := c.do(http.MethodPost, "/api/security/token", form, &token); err != nil {
+		return nil, err
+	}
+	if token.AccessToken == "" {
+		return nil, errors.New("no access token returned by Artifactory")
+	}
+	return &token, nil
+}
+
+// revokeToken revokes an access token given its ID, or the token itself if
+// its ID is unknown. Tokens which don't exist anymore are ignored.
+func (c *client) revokeToken(tokenID, token string) error {
+	form := url.Values{}
+	if tokenID != "" {
+		form.Set("token_id", tokenID)
+	} else {
+		form.Set("token", token)
+	}
+
+	err := c.do(http.MethodPost, "/api/security/token/revoke", form, nil)
+	if isNotFound(err) {
+		return nil
+	}
+	return err
+}
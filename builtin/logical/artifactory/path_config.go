@@ -0,0 +1,145 @@
+package artifactory
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+const configPath = "config"
+
+func pathConfig(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "config$",
+		Fields: map[string]*framework.FieldSchema{
+			"url": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "URL of Artifactory, e.g. https://example.jfrog.io/artifactory.",
+			},
+
+			"access_token": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Admin access token used to create and revoke access tokens",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation:   b.pathConfigRead,
+			logical.UpdateOperation: b.pathConfigWrite,
+			logical.DeleteOperation: b.pathConfigDelete,
+		},
+
+		HelpSynopsis:    pathConfigHelpSyn,
+		HelpDescription: pathConfigHelpDesc,
+	}
+}
+
+func (b *backend) readConfig(ctx context.Context, s logical.Storage) (*artifactoryConfig, error) {
+	entry, err := s.Get(ctx, configPath)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	conf := &artifactoryConfig{}
+	if err := entry.DecodeJSON(conf); err != nil {
+		return nil, errwrap.Wrapf("error reading artifactory configuration: {{err}}", err)
+	}
+
+	return conf, nil
+}
+
+// verifyConfig pings Artifactory to check that it can be reached and that the
+// configured token is accepted
+func (b *backend) verifyConfig(ctx context.Context, s logical.Storage) error {
+	c, err := b.client(ctx, s)
+	if err != nil {
+		return err
+	}
+
+	if err := c.ping(); err != nil {
+		return errwrap.Wrapf("error pinging artifactory: {{err}}", err)
+	}
+
+	return nil
+}
+
+func (b *backend) pathConfigRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	conf, err := b.readConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if conf == nil {
+		return nil, nil
+	}
+
+	// The access token is not returned
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"url": conf.URL,
+		},
+	}, nil
+}
+
+func (b *backend) pathConfigWrite(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	conf, err := b.readConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if conf == nil {
+		conf = &artifactoryConfig{}
+	}
+
+	if u, ok := data.GetOk("url"); ok {
+		conf.URL = u.(string)
+	}
+	if u, err := url.Parse(conf.URL); err != nil || u.Scheme == "" || u.Host == "" {
+		return logical.ErrorResponse("url must be an absolute URL"), nil
+	}
+
+	if accessToken, ok := data.GetOk("access_token"); ok {
+		conf.AccessToken = accessToken.(string)
+	}
+	if conf.AccessToken == "" {
+		return logical.ErrorResponse("access_token is required"), nil
+	}
+
+	entry, err := logical.StorageEntryJSON(configPath, conf)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+func (b *backend) pathConfigDelete(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	if err := req.Storage.Delete(ctx, configPath); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+// artifactoryConfig contains the configuration of the connection to
+// Artifactory
+type artifactoryConfig struct {
+	URL         string `json:"url"`
+	AccessToken string `json:"access_token"`
+}
+
+const pathConfigHelpSyn = `
+Configure the connection to Artifactory.
+`
+
+const pathConfigHelpDesc = `
+This path configures the URL of Artifactory and the admin access token used by
+Vault to create and revoke access tokens. The token is never returned once
+written.
+`
@@ -0,0 +1,130 @@
+package artifactory
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	uuid "github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+// invalidUsernameChars matches the characters replaced in the names of the
+// transient users
+var invalidUsernameChars = regexp.MustCompile(`[^a-z0-9_-]+`)
+
+func pathCreds(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "creds/" + framework.GenericNameRegex("name"),
+		Fields: map[string]*framework.FieldSchema{
+			"name": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Name of the role",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation: b.pathCredsRead,
+		},
+
+		HelpSynopsis:    pathCredsHelpSyn,
+		HelpDescription: pathCredsHelpDesc,
+	}
+}
+
+func (b *backend) pathCredsRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	role, err := b.Role(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return logical.ErrorResponse(fmt.Sprintf("role %q not found", name)), nil
+	}
+
+	ttl := role.TTL
+	if ttl == 0 {
+		ttl = b.System().DefaultLeaseTTL()
+	}
+	maxTTL := b.System().MaxLeaseTTL()
+	if role.MaxTTL != 0 && role.MaxTTL < maxTTL {
+		maxTTL = role.MaxTTL
+	}
+	if ttl > maxTTL {
+		ttl = maxTTL
+	}
+
+	username := role.Username
+	if username == "" {
+		username, err = transientUsername(name)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	c, err := b.client(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := c.createToken(username, role.Scope, role.Audience, ttl)
+	if err != nil {
+		return nil, errwrap.Wrapf("error creating access token: {{err}}", err)
+	}
+
+	// Artifactory may cap the validity of the token
+	if token.ExpiresIn > 0 {
+		ttl = time.Duration(token.ExpiresIn) * time.Second
+	}
+
+	tokenID := token.tokenID()
+	internalData := map[string]interface{}{
+		"token_id": tokenID,
+	}
+	if tokenID == "" {
+		// The token itself is needed to revoke it without its ID
+		internalData["access_token"] = token.AccessToken
+	}
+
+	resp := b.Secret(secretAccessTokenType).Response(map[string]interface{}{
+		"access_token": token.AccessToken,
+		"username":     username,
+		"scope":        token.Scope,
+		"token_id":     tokenID,
+	}, internalData)
+	resp.Secret.TTL = ttl
+	resp.Secret.MaxTTL = ttl
+
+	return resp, nil
+}
+
+// transientUsername returns a unique name for the transient user of a token
+// of the role
+func transientUsername(roleName string) (string, error) {
+	id, err := uuid.GenerateUUID()
+	if err != nil {
+		return "", err
+	}
+
+	name := invalidUsernameChars.ReplaceAllString(strings.ToLower(roleName), "-")
+	if len(name) > 32 {
+		name = name[:32]
+	}
+	return fmt.Sprintf("vault-%s-%s", name, id[:8]), nil
+}
+
+const pathCredsHelpSyn = `
+Request an Artifactory access token for a role.
+`
+
+const pathCredsHelpDesc = `
+This path creates a non-refreshable access token with the user, scope and
+audience of the named role. Leases can't be renewed, since the validity of the
+token is set when it is created, and the token is revoked when its lease is
+revoked.
+`
@@ -0,0 +1,207 @@
+package artifactory
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+func pathListRoles(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "roles/?$",
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ListOperation: b.pathRoleList,
+		},
+
+		HelpSynopsis:    pathRolesHelpSyn,
+		HelpDescription: pathRolesHelpDesc,
+	}
+}
+
+func pathRoles(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "roles/" + framework.GenericNameRegex("name"),
+		Fields: map[string]*framework.FieldSchema{
+			"name": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Name of the role",
+			},
+
+			"username": &framework.FieldSchema{
+				Type: framework.TypeString,
+				Description: `Artifactory user of the tokens. If unset, each token has a new
+transient user, whose groups must be given in the scope.`,
+			},
+
+			"scope": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: `Scope of the tokens, e.g. "member-of-groups:ci-readers" or "api:*".`,
+			},
+
+			"audience": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: `Space-separated list of the services accepting the tokens, e.g. "jfrt@*". Defaults to the Artifactory instance.`,
+			},
+
+			"ttl": &framework.FieldSchema{
+				Type:        framework.TypeDurationSecond,
+				Description: "Default validity of the tokens. If unset, the default lease TTL of the mount is used.",
+			},
+
+			"max_ttl": &framework.FieldSchema{
+				Type:        framework.TypeDurationSecond,
+				Description: "Maximum validity of the tokens. If unset, the maximum lease TTL of the mount is used.",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation:   b.pathRolesRead,
+			logical.CreateOperation: b.pathRolesWrite,
+			logical.UpdateOperation: b.pathRolesWrite,
+			logical.DeleteOperation: b.pathRolesDelete,
+		},
+
+		ExistenceCheck: b.rolesExistenceCheck,
+
+		HelpSynopsis:    pathRolesHelpSyn,
+		HelpDescription: pathRolesHelpDesc,
+	}
+}
+
+func (b *backend) rolesExistenceCheck(ctx context.Context, req *logical.Request, d *framework.FieldData) (bool, error) {
+	entry, err := b.Role(ctx, req.Storage, d.Get("name").(string))
+	if err != nil {
+		return false, err
+	}
+	return entry != nil, nil
+}
+
+func (b *backend) Role(ctx context.Context, storage logical.Storage, name string) (*roleConfig, error) {
+	if name == "" {
+		return nil, errors.New("invalid role name")
+	}
+
+	entry, err := storage.Get(ctx, "roles/"+name)
+	if err != nil {
+		return nil, errwrap.Wrapf("error retrieving role: {{err}}", err)
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var result roleConfig
+	if err := entry.DecodeJSON(&result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (b *backend) pathRoleList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	entries, err := req.Storage.List(ctx, "roles/")
+	if err != nil {
+		return nil, err
+	}
+
+	return logical.ListResponse(entries), nil
+}
+
+func (b *backend) pathRolesRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	role, err := b.Role(ctx, req.Storage, d.Get("name").(string))
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return nil, nil
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"username": role.Username,
+			"scope":    role.Scope,
+			"audience": role.Audience,
+			"ttl":      int64(role.TTL.Seconds()),
+			"max_ttl":  int64(role.MaxTTL.Seconds()),
+		},
+	}, nil
+}
+
+func (b *backend) pathRolesWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	role, err := b.Role(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		role = &roleConfig{}
+	}
+
+	if username, ok := d.GetOk("username"); ok {
+		role.Username = username.(string)
+	}
+	if scope, ok := d.GetOk("scope"); ok {
+		role.Scope = scope.(string)
+	}
+	if role.Scope == "" {
+		return logical.ErrorResponse("scope is required"), nil
+	}
+	if audience, ok := d.GetOk("audience"); ok {
+		role.Audience = audience.(string)
+	}
+
+	if ttl, ok := d.GetOk("ttl"); ok {
+		role.TTL = time.Duration(ttl.(int)) * time.Second
+	}
+	if maxTTL, ok := d.GetOk("max_ttl"); ok {
+		role.MaxTTL = time.Duration(maxTTL.(int)) * time.Second
+	}
+	if role.MaxTTL != 0 && role.TTL > role.MaxTTL {
+		return logical.ErrorResponse("ttl cannot be greater than max_ttl"), nil
+	}
+
+	entry, err := logical.StorageEntryJSON("roles/"+name, role)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+func (b *backend) pathRolesDelete(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	if err := req.Storage.Delete(ctx, "roles/"+d.Get("name").(string)); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+type roleConfig struct {
+	Username string        `json:"username"`
+	Scope    string        `json:"scope"`
+	Audience string        `json:"audience"`
+	TTL      time.Duration `json:"ttl"`
+	MaxTTL   time.Duration `json:"max_ttl"`
+}
+
+const pathRolesHelpSyn = `
+Manage the roles used to create Artifactory access tokens.
+`
+
+const pathRolesHelpDesc = `
+A role sets the scope and audience of the access tokens created for it, and
+their user: either the configured username, or a transient user created with
+each token and named after the role. Transient users only exist in the token,
+so their permissions must come from the groups of the scope, e.g.
+"member-of-groups:ci-readers".
+
+The validity of the tokens defaults to ttl, or the default lease TTL of the
+mount, and is capped by max_ttl, or the maximum lease TTL of the mount. Tokens
+are not refreshable, and are revoked when their lease is revoked.
+`
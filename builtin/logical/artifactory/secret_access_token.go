@@ -0,0 +1,46 @@
+package artifactory
+
+import (
+	"context"
+	"errors"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+const secretAccessTokenType = "access_token"
+
+func secretAccessToken(b *backend) *framework.Secret {
+	return &framework.Secret{
+		Type: secretAccessTokenType,
+		Fields: map[string]*framework.FieldSchema{
+			"access_token": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Artifactory access token",
+			},
+		},
+
+		// Tokens can't be renewed, their validity is set when they're created
+		Revoke: b.secretAccessTokenRevoke,
+	}
+}
+
+func (b *backend) secretAccessTokenRevoke(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	tokenID, _ := req.Secret.InternalData["token_id"].(string)
+	token, _ := req.Secret.InternalData["access_token"].(string)
+	if tokenID == "" && token == "" {
+		return nil, errors.New("secret is missing the token ID")
+	}
+
+	c, err := b.client(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.revokeToken(tokenID, token); err != nil {
+		return nil, errwrap.Wrapf("error revoking access token: {{err}}", err)
+	}
+
+	return nil, nil
+}
@@ -31,6 +31,7 @@ func Backend() *backend {
 			},
 			SealWrapStorage: []string{
 				"config/root",
+				staticRoleStoragePrefix,
 			},
 		},
 
@@ -41,6 +42,10 @@ func Backend() *backend {
 			pathRoles(&b),
 			pathListRoles(&b),
 			pathUser(&b),
+			framework.PathRevokeAll("creds", "sts"),
+			pathListStaticRoles(&b),
+			pathStaticRoles(&b),
+			pathStaticCreds(&b),
 		},
 
 		Secrets: []*framework.Secret{
@@ -49,6 +54,7 @@ func Backend() *backend {
 
 		WALRollback:       b.walRollback,
 		WALRollbackMinAge: minAwsUserRollbackAge,
+		PeriodicFunc:      b.periodicFunc,
 		BackendType:       logical.TypeLogical,
 	}
 
@@ -64,6 +70,9 @@ type backend struct {
 	// Mutex to protect access to iam/sts clients and client configs
 	clientMutex sync.RWMutex
 
+	// Mutex to protect the rotation of the access keys of static roles
+	staticRoleMutex sync.Mutex
+
 	// iamClient and stsClient hold configured iam and sts clients for reuse, and
 	// to enable mocking with AWS iface for tests
 	iamClient iamiface.IAMAPI
@@ -78,6 +87,9 @@ are automatically revoked at the end of the lease.
 After mounting this backend, credentials to generate IAM keys must
 be configured with the "root" path and policies must be written using
 the "roles/" endpoints before any access keys can be generated.
+
+The access keys of existing IAM users can also be rotated periodically
+by creating static roles using the "static-roles/" endpoints.
 `
 
 // clientIAM returns the configured IAM client. If nil, it constructs a new one
@@ -136,4 +148,8 @@ func (b *backend) clientSTS(ctx context.Context, s logical.Storage) (stsiface.ST
 	return b.stsClient, nil
 }
 
+func (b *backend) periodicFunc(ctx context.Context, req *logical.Request) error {
+	return b.rotateStaticRoles(ctx, req.Storage)
+}
+
 const minAwsUserRollbackAge = 5 * time.Minute
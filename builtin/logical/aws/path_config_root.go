@@ -2,8 +2,12 @@ package aws
 
 import (
 	"context"
+	"fmt"
+	"strings"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/hashicorp/errwrap"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
 )
@@ -39,6 +43,15 @@ func pathConfigRoot(b *backend) *framework.Path {
 				Default:     aws.UseServiceDefaultRetries,
 				Description: "Maximum number of retries for recoverable exceptions of AWS APIs",
 			},
+			"permissions_boundary_arn": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "ARN of the managed policy to set as the permissions boundary of every IAM user created",
+			},
+			"validate_policy_documents": &framework.FieldSchema{
+				Type: framework.TypeBool,
+				Description: `Validate the policy documents of roles with the IAM policy simulator when
+the roles are written. Requires the iam:SimulateCustomPolicy permission.`,
+			},
 		},
 
 		Callbacks: map[logical.Operation]framework.OperationFunc{
@@ -56,6 +69,14 @@ func (b *backend) pathConfigRootWrite(ctx context.Context, req *logical.Request,
 	stsendpoint := data.Get("sts_endpoint").(string)
 	maxretries := data.Get("max_retries").(int)
 
+	permissionsBoundaryARN := data.Get("permissions_boundary_arn").(string)
+	if permissionsBoundaryARN != "" {
+		parsed, err := arn.Parse(permissionsBoundaryARN)
+		if err != nil || !strings.HasPrefix(parsed.Resource, "policy/") {
+			return logical.ErrorResponse(fmt.Sprintf("permissions_boundary_arn %q is not the ARN of a managed policy", permissionsBoundaryARN)), nil
+		}
+	}
+
 	b.clientMutex.Lock()
 	defer b.clientMutex.Unlock()
 
@@ -66,6 +87,9 @@ func (b *backend) pathConfigRootWrite(ctx context.Context, req *logical.Request,
 		STSEndpoint: stsendpoint,
 		Region:      region,
 		MaxRetries:  maxretries,
+
+		PermissionsBoundaryARN:  permissionsBoundaryARN,
+		ValidatePolicyDocuments: data.Get("validate_policy_documents").(bool),
 	})
 	if err != nil {
 		return nil, err
@@ -90,6 +114,30 @@ type rootConfig struct {
 	STSEndpoint string `json:"sts_endpoint"`
 	Region      string `json:"region"`
 	MaxRetries  int    `json:"max_retries"`
+
+	// PermissionsBoundaryARN is the managed policy set as the permissions
+	// boundary of the IAM users created by the mount
+	PermissionsBoundaryARN string `json:"permissions_boundary_arn,omitempty"`
+
+	// ValidatePolicyDocuments enables the validation of the policy documents
+	// of roles with the IAM policy simulator
+	ValidatePolicyDocuments bool `json:"validate_policy_documents,omitempty"`
+}
+
+// readRootConfig returns the root configuration, nil if it wasn't written
+func readRootConfig(ctx context.Context, s logical.Storage) (*rootConfig, error) {
+	entry, err := s.Get(ctx, "config/root")
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+	var config rootConfig
+	if err := entry.DecodeJSON(&config); err != nil {
+		return nil, errwrap.Wrapf("error reading root configuration: {{err}}", err)
+	}
+	return &config, nil
 }
 
 const pathConfigRootHelpSyn = `
@@ -101,4 +149,8 @@ Before doing anything, the AWS backend needs credentials that are able
 to manage IAM policies, users, access keys, etc. This endpoint is used
 to configure those credentials. They don't necessarily need to be root
 keys as long as they have permission to manage IAM.
+
+A permissions boundary can be set on every IAM user created by the backend,
+whatever the role, and the policy documents of roles can be validated with the
+IAM policy simulator when they are written.
 `
@@ -9,7 +9,10 @@ import (
 	"strings"
 	"time"
 
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/iam"
 	"github.com/hashicorp/vault/helper/consts"
 	"github.com/hashicorp/vault/helper/strutil"
 	"github.com/hashicorp/vault/logical"
@@ -48,6 +51,46 @@ func pathRoles(b *backend) *framework.Path {
 				Description: "ARNs of AWS roles allowed to be assumed. Only valid when credential_type is " + assumedRoleCred,
 			},
 
+			"session_tags": &framework.FieldSchema{
+				Type: framework.TypeKVPairs,
+				Description: `Session tags to set on the credentials, for use in the conditions of AWS
+policies. Values may contain identity templates, e.g.
+{{identity.entity.name}}. Only valid when credential_type is ` + assumedRoleCred,
+			},
+
+			"iam_tags": &framework.FieldSchema{
+				Type: framework.TypeKVPairs,
+				Description: `Tags to set on the IAM users created. Values may contain identity
+templates, e.g. {{identity.entity.name}}. Only valid when credential_type is ` + iamUserCred,
+			},
+
+			"username_template": &framework.FieldSchema{
+				Type: framework.TypeString,
+				Description: `Template of the names of the IAM users created, or of the sessions of
+assumed roles. It may contain {{display_name}}, {{role_name}}, {{unix_time}},
+{{random}} and identity templates, e.g. {{identity.entity.name}}. Only valid
+when credential_type is ` + iamUserCred + " or " + assumedRoleCred,
+			},
+
+			"external_id": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "External ID to pass when assuming the role. Only valid when credential_type is " + assumedRoleCred,
+			},
+
+			"intermediate_role_arn": &framework.FieldSchema{
+				Type: framework.TypeString,
+				Description: `ARN of an AWS role to assume with the root credentials before assuming
+the role, using the credentials of the intermediate role. Only valid when
+credential_type is ` + assumedRoleCred,
+			},
+
+			"use_federation_token": &framework.FieldSchema{
+				Type: framework.TypeBool,
+				Description: `Issue temporary credentials with sts:GetFederationToken, restricted by
+policy_document, instead of creating IAM users. Only valid when
+credential_type is ` + iamUserCred,
+			},
+
 			"policy_arns": &framework.FieldSchema{
 				Type:        framework.TypeCommaStringSlice,
 				Description: "ARNs of AWS policies to attach to IAM users. Only valid when credential_type is " + iamUserCred,
@@ -59,7 +102,8 @@ func pathRoles(b *backend) *framework.Path {
 iam_user, then it will attach the contents of the policy_document to the IAM
 user generated. When credential_type is assumed_role or federation_token, this
 will be passed in as the Policy parameter to the AssumeRole or
-GetFederationToken API call, acting as a filter on permissions available.`,
+GetFederationToken API call, acting as a filter on permissions available. It
+must not exceed 2048 characters once compacted.`,
 			},
 
 			"default_sts_ttl": &framework.FieldSchema{
@@ -177,6 +221,8 @@ func (b *backend) pathRolesWrite(ctx context.Context, req *logical.Request, d *f
 		return nil, err
 	}
 
+	var policyDocumentChanged bool
+
 	if credentialTypeRaw, ok := d.GetOk("credential_type"); ok {
 		if legacyRole != "" {
 			return logical.ErrorResponse("cannot supply deprecated role or policy parameters with an explicit credential_type"), nil
@@ -196,6 +242,75 @@ func (b *backend) pathRolesWrite(ctx context.Context, req *logical.Request, d *f
 		roleEntry.RoleArns = roleArnsRaw.([]string)
 	}
 
+	if sessionTagsRaw, ok := d.GetOk("session_tags"); ok {
+		if legacyRole != "" {
+			return logical.ErrorResponse("cannot supply deprecated role or policy parameters with session_tags"), nil
+		}
+		roleEntry.SessionTags = sessionTagsRaw.(map[string]string)
+		for key := range roleEntry.SessionTags {
+			if key == "" {
+				return logical.ErrorResponse("session tag keys must not be empty"), nil
+			}
+		}
+		if err := validateTemplatedTags("session_tags", roleEntry.SessionTags); err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+	}
+
+	if iamTagsRaw, ok := d.GetOk("iam_tags"); ok {
+		if legacyRole != "" {
+			return logical.ErrorResponse("cannot supply deprecated role or policy parameters with iam_tags"), nil
+		}
+		roleEntry.IAMTags = iamTagsRaw.(map[string]string)
+		for key := range roleEntry.IAMTags {
+			if key == "" {
+				return logical.ErrorResponse("IAM tag keys must not be empty"), nil
+			}
+		}
+		if err := validateTemplatedTags("iam_tags", roleEntry.IAMTags); err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+	}
+
+	if usernameTemplateRaw, ok := d.GetOk("username_template"); ok {
+		if legacyRole != "" {
+			return logical.ErrorResponse("cannot supply deprecated role or policy parameters with username_template"), nil
+		}
+		roleEntry.UsernameTemplate = usernameTemplateRaw.(string)
+		if err := validateUsernameTemplate(roleEntry.UsernameTemplate); err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+	}
+
+	if externalIDRaw, ok := d.GetOk("external_id"); ok {
+		if legacyRole != "" {
+			return logical.ErrorResponse("cannot supply deprecated role or policy parameters with external_id"), nil
+		}
+		roleEntry.ExternalID = externalIDRaw.(string)
+		if len(roleEntry.ExternalID) == 1 {
+			return logical.ErrorResponse("external_id must be at least 2 characters long"), nil
+		}
+	}
+
+	if intermediateRoleArnRaw, ok := d.GetOk("intermediate_role_arn"); ok {
+		if legacyRole != "" {
+			return logical.ErrorResponse("cannot supply deprecated role or policy parameters with intermediate_role_arn"), nil
+		}
+		roleEntry.IntermediateRoleArn = intermediateRoleArnRaw.(string)
+		if roleEntry.IntermediateRoleArn != "" {
+			if _, err := arn.Parse(roleEntry.IntermediateRoleArn); err != nil {
+				return logical.ErrorResponse(fmt.Sprintf("invalid intermediate_role_arn %q: %s", roleEntry.IntermediateRoleArn, err)), nil
+			}
+		}
+	}
+
+	if useFederationTokenRaw, ok := d.GetOk("use_federation_token"); ok {
+		if legacyRole != "" {
+			return logical.ErrorResponse("cannot supply deprecated role or policy parameters with use_federation_token"), nil
+		}
+		roleEntry.UseFederationToken = useFederationTokenRaw.(bool)
+	}
+
 	if policyArnsRaw, ok := d.GetOk("policy_arns"); ok {
 		if legacyRole != "" {
 			return logical.ErrorResponse("cannot supply deprecated role or policy parameters with policy_arns"), nil
@@ -213,16 +328,20 @@ func (b *backend) pathRolesWrite(ctx context.Context, req *logical.Request, d *f
 			if err != nil {
 				return logical.ErrorResponse(fmt.Sprintf("cannot parse policy document: %q", policyDocumentRaw.(string))), nil
 			}
+			if err := validatePolicyDocument(compacted); err != nil {
+				return logical.ErrorResponse(fmt.Sprintf("invalid policy document: %s", err)), nil
+			}
 		}
 		roleEntry.PolicyDocument = compacted
+		policyDocumentChanged = true
 	}
 
 	if defaultSTSTTLRaw, ok := d.GetOk("default_sts_ttl"); ok {
 		if legacyRole != "" {
 			return logical.ErrorResponse("cannot supply deprecated role or policy parameters with default_sts_ttl"), nil
 		}
-		if !strutil.StrListContains(roleEntry.CredentialTypes, assumedRoleCred) && !strutil.StrListContains(roleEntry.CredentialTypes, federationTokenCred) {
-			return logical.ErrorResponse(fmt.Sprintf("default_sts_ttl parameter only valid for %s and %s credential types", assumedRoleCred, federationTokenCred)), nil
+		if !roleEntry.issuesSTSCredentials() {
+			return logical.ErrorResponse(fmt.Sprintf("default_sts_ttl parameter only valid for %s and %s credential types, or %s with use_federation_token", assumedRoleCred, federationTokenCred, iamUserCred)), nil
 		}
 		roleEntry.DefaultSTSTTL = time.Duration(defaultSTSTTLRaw.(int)) * time.Second
 	}
@@ -231,8 +350,8 @@ func (b *backend) pathRolesWrite(ctx context.Context, req *logical.Request, d *f
 		if legacyRole != "" {
 			return logical.ErrorResponse("cannot supply deprecated role or policy parameters with max_sts_ttl"), nil
 		}
-		if !strutil.StrListContains(roleEntry.CredentialTypes, assumedRoleCred) && !strutil.StrListContains(roleEntry.CredentialTypes, federationTokenCred) {
-			return logical.ErrorResponse(fmt.Sprintf("max_sts_ttl parameter only valid for %s and %s credential types", assumedRoleCred, federationTokenCred)), nil
+		if !roleEntry.issuesSTSCredentials() {
+			return logical.ErrorResponse(fmt.Sprintf("max_sts_ttl parameter only valid for %s and %s credential types, or %s with use_federation_token", assumedRoleCred, federationTokenCred, iamUserCred)), nil
 		}
 
 		roleEntry.MaxSTSTTL = time.Duration(maxSTSTTLRaw.(int)) * time.Second
@@ -261,9 +380,38 @@ func (b *backend) pathRolesWrite(ctx context.Context, req *logical.Request, d *f
 	if len(roleEntry.RoleArns) > 0 && !strutil.StrListContains(roleEntry.CredentialTypes, assumedRoleCred) {
 		return logical.ErrorResponse(fmt.Sprintf("cannot supply role_arns when credential_type isn't %s", assumedRoleCred)), nil
 	}
+	if (len(roleEntry.SessionTags) > 0 || roleEntry.ExternalID != "" || roleEntry.IntermediateRoleArn != "") && !strutil.StrListContains(roleEntry.CredentialTypes, assumedRoleCred) {
+		return logical.ErrorResponse(fmt.Sprintf("cannot supply session_tags, external_id or intermediate_role_arn when credential_type isn't %s", assumedRoleCred)), nil
+	}
+	if len(roleEntry.IAMTags) > 0 && !strutil.StrListContains(roleEntry.CredentialTypes, iamUserCred) {
+		return logical.ErrorResponse(fmt.Sprintf("cannot supply iam_tags when credential_type isn't %s", iamUserCred)), nil
+	}
+	if roleEntry.UsernameTemplate != "" && !strutil.StrListContains(roleEntry.CredentialTypes, iamUserCred) && !strutil.StrListContains(roleEntry.CredentialTypes, assumedRoleCred) {
+		return logical.ErrorResponse(fmt.Sprintf("cannot supply username_template when credential_type isn't %s or %s", iamUserCred, assumedRoleCred)), nil
+	}
 	if len(roleEntry.PolicyArns) > 0 && !strutil.StrListContains(roleEntry.CredentialTypes, iamUserCred) {
 		return logical.ErrorResponse(fmt.Sprintf("cannot supply policy_arns when credential_type isn't %s", iamUserCred)), nil
 	}
+	if roleEntry.UseFederationToken {
+		switch {
+		case !strutil.StrListContains(roleEntry.CredentialTypes, iamUserCred):
+			return logical.ErrorResponse(fmt.Sprintf("cannot supply use_federation_token when credential_type isn't %s", iamUserCred)), nil
+		case roleEntry.PolicyDocument == "":
+			return logical.ErrorResponse("policy_document is required with use_federation_token"), nil
+		case len(roleEntry.PolicyArns) > 0 || len(roleEntry.IAMTags) > 0 || roleEntry.UsernameTemplate != "":
+			return logical.ErrorResponse("cannot supply policy_arns, iam_tags or username_template with use_federation_token, as no IAM user is created"), nil
+		}
+	}
+
+	if policyDocumentChanged && roleEntry.PolicyDocument != "" {
+		warning, err := b.simulatePolicyDocument(ctx, req.Storage, roleEntry.PolicyDocument)
+		if err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+		if warning != "" {
+			resp.AddWarning(warning)
+		}
+	}
 
 	err = setAwsRole(ctx, req.Storage, roleName, roleEntry)
 	if err != nil {
@@ -416,15 +564,29 @@ func setAwsRole(ctx context.Context, s logical.Storage, roleName string, roleEnt
 }
 
 type awsRoleEntry struct {
-	CredentialTypes          []string      `json:"credential_types"`                      // Entries must all be in the set of ("iam_user", "assumed_role", "federation_token")
-	PolicyArns               []string      `json:"policy_arns"`                           // ARNs of managed policies to attach to an IAM user
-	RoleArns                 []string      `json:"role_arns"`                             // ARNs of roles to assume for AssumedRole credentials
-	PolicyDocument           string        `json:"policy_document"`                       // JSON-serialized inline policy to attach to IAM users and/or to specify as the Policy parameter in AssumeRole calls
-	InvalidData              string        `json:"invalid_data,omitempty"`                // Invalid role data. Exists to support converting the legacy role data into the new format
-	ProhibitFlexibleCredPath bool          `json:"prohibit_flexible_cred_path,omitempty"` // Disallow accessing STS credentials via the creds path and vice verse
-	Version                  int           `json:"version"`                               // Version number of the role format
-	DefaultSTSTTL            time.Duration `json:"default_sts_ttl"`                       // Default TTL for STS credentials
-	MaxSTSTTL                time.Duration `json:"max_sts_ttl"`                           // Max allowed TTL for STS credentials
+	CredentialTypes          []string          `json:"credential_types"`                      // Entries must all be in the set of ("iam_user", "assumed_role", "federation_token")
+	PolicyArns               []string          `json:"policy_arns"`                           // ARNs of managed policies to attach to an IAM user
+	RoleArns                 []string          `json:"role_arns"`                             // ARNs of roles to assume for AssumedRole credentials
+	PolicyDocument           string            `json:"policy_document"`                       // JSON-serialized inline policy to attach to IAM users and/or to specify as the Policy parameter in AssumeRole calls
+	InvalidData              string            `json:"invalid_data,omitempty"`                // Invalid role data. Exists to support converting the legacy role data into the new format
+	ProhibitFlexibleCredPath bool              `json:"prohibit_flexible_cred_path,omitempty"` // Disallow accessing STS credentials via the creds path and vice verse
+	Version                  int               `json:"version"`                               // Version number of the role format
+	DefaultSTSTTL            time.Duration     `json:"default_sts_ttl"`                       // Default TTL for STS credentials
+	MaxSTSTTL                time.Duration     `json:"max_sts_ttl"`                           // Max allowed TTL for STS credentials
+	SessionTags              map[string]string `json:"session_tags,omitempty"`                // Session tags to set in AssumeRole calls
+	ExternalID               string            `json:"external_id,omitempty"`                 // External ID to pass in AssumeRole calls
+	IntermediateRoleArn      string            `json:"intermediate_role_arn,omitempty"`       // ARN of a role to assume first, whose credentials assume the role
+	IAMTags                  map[string]string `json:"iam_tags,omitempty"`                    // Tags to set on IAM users
+	UsernameTemplate         string            `json:"username_template,omitempty"`           // Template of the names of IAM users and assumed role sessions
+	UseFederationToken       bool              `json:"use_federation_token,omitempty"`        // Issue iam_user credentials with GetFederationToken instead of creating IAM users
+}
+
+// issuesSTSCredentials returns whether the role issues temporary credentials
+// from STS, whose TTL is bounded by default_sts_ttl and max_sts_ttl
+func (r *awsRoleEntry) issuesSTSCredentials() bool {
+	return strutil.StrListContains(r.CredentialTypes, assumedRoleCred) ||
+		strutil.StrListContains(r.CredentialTypes, federationTokenCred) ||
+		r.UseFederationToken
 }
 
 func (r *awsRoleEntry) toResponseData() map[string]interface{} {
@@ -436,18 +598,88 @@ func (r *awsRoleEntry) toResponseData() map[string]interface{} {
 		"default_sts_ttl": int64(r.DefaultSTSTTL.Seconds()),
 		"max_sts_ttl":     int64(r.MaxSTSTTL.Seconds()),
 	}
+	if strutil.StrListContains(r.CredentialTypes, iamUserCred) || strutil.StrListContains(r.CredentialTypes, assumedRoleCred) {
+		respData["username_template"] = r.UsernameTemplate
+	}
+	if strutil.StrListContains(r.CredentialTypes, iamUserCred) {
+		respData["iam_tags"] = r.IAMTags
+		respData["use_federation_token"] = r.UseFederationToken
+	}
+	if strutil.StrListContains(r.CredentialTypes, assumedRoleCred) {
+		respData["session_tags"] = r.SessionTags
+		respData["external_id"] = r.ExternalID
+		respData["intermediate_role_arn"] = r.IntermediateRoleArn
+	}
 	if r.InvalidData != "" {
 		respData["invalid_data"] = r.InvalidData
 	}
 	return respData
 }
 
+// validatePolicyDocument checks the compacted policy document is an IAM policy
+// within the size AWS accepts for inline user policies and session policies
+func validatePolicyDocument(policyDocument string) error {
+	if len(policyDocument) > maxPolicyDocumentSize {
+		return fmt.Errorf("policy document is %d characters long once compacted, more than the maximum of %d", len(policyDocument), maxPolicyDocumentSize)
+	}
+
+	var policy map[string]interface{}
+	if err := json.Unmarshal([]byte(policyDocument), &policy); err != nil {
+		return errors.New("policy document must be a JSON object")
+	}
+	if version, ok := policy["Version"]; ok && version != "2012-10-17" && version != "2008-10-17" {
+		return fmt.Errorf("unsupported policy version %v", version)
+	}
+	switch policy["Statement"].(type) {
+	case map[string]interface{}, []interface{}:
+	case nil:
+		return errors.New("policy document has no Statement")
+	default:
+		return errors.New("Statement must be an object or a list of objects")
+	}
+	return nil
+}
+
+// simulatePolicyDocument checks the syntax of the policy document with the
+// IAM policy simulator, if enabled in the root configuration. Failures to
+// reach the simulator are returned as a warning, so that roles can still be
+// written when the root credentials aren't allowed to simulate policies.
+func (b *backend) simulatePolicyDocument(ctx context.Context, s logical.Storage, policyDocument string) (string, error) {
+	config, err := readRootConfig(ctx, s)
+	if err != nil || config == nil || !config.ValidatePolicyDocuments {
+		return "", nil
+	}
+
+	iamClient, err := b.clientIAM(ctx, s)
+	if err == nil {
+		_, err = iamClient.SimulateCustomPolicy(&iam.SimulateCustomPolicyInput{
+			PolicyInputList: []*string{aws.String(policyDocument)},
+			ActionNames:     []*string{aws.String("sts:GetCallerIdentity")},
+		})
+	}
+	if aerr, ok := err.(awserr.Error); ok {
+		switch aerr.Code() {
+		case iam.ErrCodeMalformedPolicyDocumentException, iam.ErrCodeInvalidInputException:
+			return "", fmt.Errorf("invalid policy document: %s", aerr.Message())
+		}
+	}
+	if err != nil {
+		return fmt.Sprintf("policy document could not be validated with the IAM policy simulator: %s", err), nil
+	}
+	return "", nil
+}
+
 func compactJSON(input string) (string, error) {
 	var compacted bytes.Buffer
 	err := json.Compact(&compacted, []byte(input))
 	return compacted.String(), err
 }
 
+// maxPolicyDocumentSize is the maximum number of characters of a compacted
+// policy document, the size limit of both the inline policies of IAM users and
+// the session policies passed to STS
+const maxPolicyDocumentSize = 2048
+
 const (
 	assumedRoleCred     = "assumed_role"
 	iamUserCred         = "iam_user"
@@ -0,0 +1,68 @@
+package aws
+
+import (
+	"context"
+	"time"
+
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+func pathStaticCreds(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "static-creds/" + framework.GenericNameRegex("name"),
+		Fields: map[string]*framework.FieldSchema{
+			"name": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Name of the static role",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation: b.pathStaticCredsRead,
+		},
+
+		HelpSynopsis:    pathStaticCredsHelpSyn,
+		HelpDescription: pathStaticCredsHelpDesc,
+	}
+}
+
+func (b *backend) pathStaticCredsRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	b.staticRoleMutex.Lock()
+	defer b.staticRoleMutex.Unlock()
+
+	role, err := staticRoleRead(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return logical.ErrorResponse("unknown static role: " + name), nil
+	}
+
+	ttl := time.Until(role.nextRotation())
+	if ttl < 0 {
+		ttl = 0
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"access_key":   role.AccessKeyID,
+			"secret_key":   role.SecretAccessKey,
+			"last_rotated": role.LastRotated,
+			"ttl":          int64(ttl.Seconds()),
+		},
+	}, nil
+}
+
+const pathStaticCredsHelpSyn = `
+Read the current access key of a static role.
+`
+
+const pathStaticCredsHelpDesc = `
+This path returns the current access key of the IAM user of a static role.
+The key isn't leased; it remains valid until the role rotates it, which
+happens once "ttl" seconds have elapsed. Applications should read the key
+again after that.
+`
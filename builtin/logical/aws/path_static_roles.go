@@ -0,0 +1,359 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/iam/iamiface"
+	"github.com/hashicorp/errwrap"
+	multierror "github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+const (
+	staticRoleStoragePrefix = "static-role/"
+
+	// minStaticRoleRotationPeriod is the shortest rotation period allowed, as
+	// keys are rotated by the periodic function of the backend which only
+	// runs once a minute
+	minStaticRoleRotationPeriod = time.Minute
+)
+
+func pathListStaticRoles(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "static-roles/?$",
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ListOperation: b.pathStaticRoleList,
+		},
+
+		HelpSynopsis:    pathListStaticRolesHelpSyn,
+		HelpDescription: pathListStaticRolesHelpDesc,
+	}
+}
+
+func pathStaticRoles(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "static-roles/" + framework.GenericNameRegex("name"),
+		Fields: map[string]*framework.FieldSchema{
+			"name": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Name of the static role",
+			},
+
+			"username": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Name of the existing IAM user whose access keys are managed by this role. Cannot be changed once set.",
+			},
+
+			"rotation_period": &framework.FieldSchema{
+				Type:        framework.TypeDurationSecond,
+				Description: "Period after which the access key of the IAM user is rotated. Must be at least one minute.",
+			},
+		},
+
+		ExistenceCheck: b.pathStaticRoleExistenceCheck,
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.CreateOperation: b.pathStaticRolesWrite,
+			logical.UpdateOperation: b.pathStaticRolesWrite,
+			logical.ReadOperation:   b.pathStaticRolesRead,
+			logical.DeleteOperation: b.pathStaticRolesDelete,
+		},
+
+		HelpSynopsis:    pathStaticRolesHelpSyn,
+		HelpDescription: pathStaticRolesHelpDesc,
+	}
+}
+
+type awsStaticRoleEntry struct {
+	Username        string        `json:"username"`          // Name of the IAM user whose access keys are managed
+	RotationPeriod  time.Duration `json:"rotation_period"`   // Period after which the access key is rotated
+	AccessKeyID     string        `json:"access_key_id"`     // ID of the current access key of the user
+	SecretAccessKey string        `json:"secret_access_key"` // Secret of the current access key of the user
+	LastRotated     time.Time     `json:"last_rotated"`      // Time the current access key was created
+}
+
+// nextRotation returns the time the access key of the role is due to be
+// rotated
+func (r *awsStaticRoleEntry) nextRotation() time.Time {
+	return r.LastRotated.Add(r.RotationPeriod)
+}
+
+func (b *backend) pathStaticRoleExistenceCheck(ctx context.Context, req *logical.Request, d *framework.FieldData) (bool, error) {
+	role, err := staticRoleRead(ctx, req.Storage, d.Get("name").(string))
+	if err != nil {
+		return false, err
+	}
+	return role != nil, nil
+}
+
+func (b *backend) pathStaticRoleList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	entries, err := req.Storage.List(ctx, staticRoleStoragePrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	return logical.ListResponse(entries), nil
+}
+
+func (b *backend) pathStaticRolesRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	role, err := staticRoleRead(ctx, req.Storage, d.Get("name").(string))
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return nil, nil
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"username":        role.Username,
+			"rotation_period": int64(role.RotationPeriod.Seconds()),
+			"access_key":      role.AccessKeyID,
+			"last_rotated":    role.LastRotated,
+			"next_rotation":   role.nextRotation(),
+		},
+	}, nil
+}
+
+func (b *backend) pathStaticRolesDelete(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	b.staticRoleMutex.Lock()
+	defer b.staticRoleMutex.Unlock()
+
+	return nil, req.Storage.Delete(ctx, staticRoleStoragePrefix+d.Get("name").(string))
+}
+
+func (b *backend) pathStaticRolesWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	b.staticRoleMutex.Lock()
+	defer b.staticRoleMutex.Unlock()
+
+	role, err := staticRoleRead(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	create := role == nil
+	if create {
+		role = &awsStaticRoleEntry{}
+	}
+
+	if usernameRaw, ok := d.GetOk("username"); ok {
+		username := usernameRaw.(string)
+		if !create && username != role.Username {
+			return logical.ErrorResponse("username cannot be changed; delete the role and create a new one instead"), nil
+		}
+		role.Username = username
+	}
+	if role.Username == "" {
+		return logical.ErrorResponse("missing username"), nil
+	}
+
+	if rotationPeriodRaw, ok := d.GetOk("rotation_period"); ok {
+		role.RotationPeriod = time.Duration(rotationPeriodRaw.(int)) * time.Second
+	}
+	if role.RotationPeriod < minStaticRoleRotationPeriod {
+		return logical.ErrorResponse(fmt.Sprintf("rotation_period must be at least %s", minStaticRoleRotationPeriod)), nil
+	}
+
+	if !create {
+		return nil, staticRoleWrite(ctx, req.Storage, name, role)
+	}
+
+	// Adopt the user by replacing its access key right away, so that the
+	// role always has valid credentials to return
+	client, err := b.clientIAM(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	_, err = client.GetUser(&iam.GetUserInput{
+		UserName: aws.String(role.Username),
+	})
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == iam.ErrCodeNoSuchEntityException {
+			return logical.ErrorResponse(fmt.Sprintf("IAM user %q does not exist", role.Username)), nil
+		}
+		return nil, errwrap.Wrapf("error calling GetUser: {{err}}", err)
+	}
+
+	if err := b.rotateStaticRole(ctx, req.Storage, name, role); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+// rotateStaticRole replaces the access key of the IAM user of the role with
+// a new one, and deletes the previous key managed by the role. Access keys
+// of the user that weren't created by the role are left untouched. If the
+// user already has as many access keys as IAM allows, the previous key is
+// deleted before the new one is created. The caller must hold
+// staticRoleMutex.
+func (b *backend) rotateStaticRole(ctx context.Context, s logical.Storage, name string, role *awsStaticRoleEntry) error {
+	client, err := b.clientIAM(ctx, s)
+	if err != nil {
+		return err
+	}
+
+	oldAccessKey := role.AccessKeyID
+
+	createAccessKeyInput := &iam.CreateAccessKeyInput{
+		UserName: aws.String(role.Username),
+	}
+	createAccessKeyRes, err := client.CreateAccessKey(createAccessKeyInput)
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == iam.ErrCodeLimitExceededException && oldAccessKey != "" {
+		// The user has another access key besides the one of the role, so
+		// the previous key has to be deleted before the new one is created
+		if err := deleteAccessKey(client, role.Username, oldAccessKey); err != nil {
+			return err
+		}
+		oldAccessKey = ""
+		role.AccessKeyID = ""
+		role.SecretAccessKey = ""
+
+		createAccessKeyRes, err = client.CreateAccessKey(createAccessKeyInput)
+	}
+	if err != nil {
+		return errwrap.Wrapf("error calling CreateAccessKey: {{err}}", err)
+	}
+	if createAccessKeyRes.AccessKey == nil {
+		return fmt.Errorf("nil response from CreateAccessKey")
+	}
+	if createAccessKeyRes.AccessKey.AccessKeyId == nil || createAccessKeyRes.AccessKey.SecretAccessKey == nil {
+		return fmt.Errorf("nil AccessKeyId or SecretAccessKey returned from CreateAccessKey")
+	}
+
+	role.AccessKeyID = *createAccessKeyRes.AccessKey.AccessKeyId
+	role.SecretAccessKey = *createAccessKeyRes.AccessKey.SecretAccessKey
+	role.LastRotated = time.Now()
+
+	if err := staticRoleWrite(ctx, s, name, role); err != nil {
+		// Don't leave a key behind that nothing knows about
+		var mErr *multierror.Error
+		mErr = multierror.Append(mErr, errwrap.Wrapf("error saving static role: {{err}}", err))
+		_, delErr := client.DeleteAccessKey(&iam.DeleteAccessKeyInput{
+			AccessKeyId: createAccessKeyRes.AccessKey.AccessKeyId,
+			UserName:    aws.String(role.Username),
+		})
+		if delErr != nil {
+			mErr = multierror.Append(mErr, errwrap.Wrapf("error deleting new access key: {{err}}", delErr))
+		}
+		return mErr.ErrorOrNil()
+	}
+
+	if oldAccessKey == "" {
+		return nil
+	}
+
+	return deleteAccessKey(client, role.Username, oldAccessKey)
+}
+
+// deleteAccessKey deletes the given access key of the user, if it still
+// exists
+func deleteAccessKey(client iamiface.IAMAPI, username, accessKey string) error {
+	_, err := client.DeleteAccessKey(&iam.DeleteAccessKeyInput{
+		AccessKeyId: aws.String(accessKey),
+		UserName:    aws.String(username),
+	})
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == iam.ErrCodeNoSuchEntityException {
+			return nil
+		}
+		return errwrap.Wrapf("error deleting old access key: {{err}}", err)
+	}
+
+	return nil
+}
+
+// rotateStaticRoles rotates the access keys of the static roles whose
+// rotation period has elapsed. It is called by the periodic function of the
+// backend.
+func (b *backend) rotateStaticRoles(ctx context.Context, s logical.Storage) error {
+	names, err := s.List(ctx, staticRoleStoragePrefix)
+	if err != nil {
+		return err
+	}
+
+	b.staticRoleMutex.Lock()
+	defer b.staticRoleMutex.Unlock()
+
+	var mErr *multierror.Error
+	now := time.Now()
+	for _, name := range names {
+		role, err := staticRoleRead(ctx, s, name)
+		if err != nil {
+			mErr = multierror.Append(mErr, err)
+			continue
+		}
+		if role == nil || now.Before(role.nextRotation()) {
+			continue
+		}
+
+		if err := b.rotateStaticRole(ctx, s, name, role); err != nil {
+			mErr = multierror.Append(mErr, errwrap.Wrapf(fmt.Sprintf("failed to rotate the access key of static role %q: {{err}}", name), err))
+		}
+	}
+
+	return mErr.ErrorOrNil()
+}
+
+func staticRoleRead(ctx context.Context, s logical.Storage, name string) (*awsStaticRoleEntry, error) {
+	if name == "" {
+		return nil, fmt.Errorf("missing static role name")
+	}
+
+	entry, err := s.Get(ctx, staticRoleStoragePrefix+name)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var role awsStaticRoleEntry
+	if err := entry.DecodeJSON(&role); err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+func staticRoleWrite(ctx context.Context, s logical.Storage, name string, role *awsStaticRoleEntry) error {
+	entry, err := logical.StorageEntryJSON(staticRoleStoragePrefix+name, role)
+	if err != nil {
+		return err
+	}
+	return s.Put(ctx, entry)
+}
+
+const pathListStaticRolesHelpSyn = `List the existing static roles in this backend`
+
+const pathListStaticRolesHelpDesc = `Static roles will be listed by the role name.`
+
+const pathStaticRolesHelpSyn = `
+Manage the access keys of existing IAM users.
+`
+
+const pathStaticRolesHelpDesc = `
+This path allows you to read and write static roles, which adopt an existing
+IAM user and rotate its access key every rotation period. This suits
+applications that can't handle short-lived credentials. For example, if the
+backend is mounted at "aws" and you create a static role at
+"aws/static-roles/legacy" then the current access key of the user can be read
+at "aws/static-creds/legacy".
+
+When a static role is created, a new access key is created for the user right
+away. On each rotation a new access key is created and the previous one
+created by the role is deleted; access keys of the user that weren't created
+by the role are left untouched. As IAM users can have at most two access keys,
+the user must have at most one access key when the role is created, and if it
+has another access key besides the one of the role, the previous key is
+deleted before the new one is created on rotation.
+
+Deleting a static role leaves the current access key of the user in place.
+`
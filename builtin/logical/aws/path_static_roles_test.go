@@ -0,0 +1,197 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/iam/iamiface"
+	"github.com/hashicorp/vault/logical"
+)
+
+// mockStaticRoleIAMClient keeps track of the access keys of a set of users
+type mockStaticRoleIAMClient struct {
+	iamiface.IAMAPI
+
+	keys    map[string][]string
+	created int
+}
+
+func (m *mockStaticRoleIAMClient) GetUser(input *iam.GetUserInput) (*iam.GetUserOutput, error) {
+	if _, ok := m.keys[*input.UserName]; !ok {
+		return nil, awserr.New(iam.ErrCodeNoSuchEntityException, "", nil)
+	}
+	return &iam.GetUserOutput{
+		User: &iam.User{UserName: input.UserName},
+	}, nil
+}
+
+func (m *mockStaticRoleIAMClient) CreateAccessKey(input *iam.CreateAccessKeyInput) (*iam.CreateAccessKeyOutput, error) {
+	if len(m.keys[*input.UserName]) >= 2 {
+		return nil, awserr.New(iam.ErrCodeLimitExceededException, "", nil)
+	}
+	m.created++
+	id := fmt.Sprintf("AKIA%d", m.created)
+	m.keys[*input.UserName] = append(m.keys[*input.UserName], id)
+	return &iam.CreateAccessKeyOutput{
+		AccessKey: &iam.AccessKey{
+			AccessKeyId:     aws.String(id),
+			SecretAccessKey: aws.String("secret-" + id),
+			UserName:        input.UserName,
+		},
+	}, nil
+}
+
+func (m *mockStaticRoleIAMClient) DeleteAccessKey(input *iam.DeleteAccessKeyInput) (*iam.DeleteAccessKeyOutput, error) {
+	keys := m.keys[*input.UserName]
+	for i, id := range keys {
+		if id == *input.AccessKeyId {
+			m.keys[*input.UserName] = append(keys[:i], keys[i+1:]...)
+			return &iam.DeleteAccessKeyOutput{}, nil
+		}
+	}
+	return nil, awserr.New(iam.ErrCodeNoSuchEntityException, "", nil)
+}
+
+func TestBackend_StaticRoles(t *testing.T) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+
+	b := Backend()
+	if err := b.Setup(context.Background(), config); err != nil {
+		t.Fatal(err)
+	}
+	client := &mockStaticRoleIAMClient{
+		keys: map[string][]string{
+			"legacy": []string{"AKIAEXISTING"},
+		},
+	}
+	b.iamClient = client
+
+	request := func(op logical.Operation, path string, data map[string]interface{}) (*logical.Response, error) {
+		return b.HandleRequest(context.Background(), &logical.Request{
+			Operation: op,
+			Path:      path,
+			Storage:   config.StorageView,
+			Data:      data,
+		})
+	}
+	readCreds := func() map[string]interface{} {
+		t.Helper()
+		resp, err := request(logical.ReadOperation, "static-creds/legacy", nil)
+		if err != nil || resp == nil || resp.IsError() {
+			t.Fatalf("bad: resp: %#v err: %v", resp, err)
+		}
+		return resp.Data
+	}
+
+	for _, data := range []map[string]interface{}{
+		{"username": "missing", "rotation_period": "1h"},
+		{"username": "legacy", "rotation_period": "10s"},
+		{"rotation_period": "1h"},
+	} {
+		resp, err := request(logical.CreateOperation, "static-roles/legacy", data)
+		if err != nil || resp == nil || !resp.IsError() {
+			t.Fatalf("expected an error creating the role with %v: resp: %#v err: %v", data, resp, err)
+		}
+	}
+
+	resp, err := request(logical.CreateOperation, "static-roles/legacy", map[string]interface{}{
+		"username":        "legacy",
+		"rotation_period": "1h",
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: resp: %#v err: %v", resp, err)
+	}
+
+	// The user is adopted with a new key, leaving its existing one alone
+	creds := readCreds()
+	if creds["access_key"] != "AKIA1" || creds["secret_key"] != "secret-AKIA1" {
+		t.Fatalf("bad creds: %#v", creds)
+	}
+	if ttl := creds["ttl"].(int64); ttl <= 3500 || ttl > 3600 {
+		t.Fatalf("bad ttl: %d", ttl)
+	}
+	if keys := client.keys["legacy"]; !reflect.DeepEqual(keys, []string{"AKIAEXISTING", "AKIA1"}) {
+		t.Fatalf("bad keys: %v", keys)
+	}
+
+	resp, err = request(logical.ReadOperation, "static-roles/legacy", nil)
+	if err != nil || resp == nil {
+		t.Fatalf("bad: resp: %#v err: %v", resp, err)
+	}
+	if resp.Data["username"] != "legacy" || resp.Data["rotation_period"] != int64(3600) || resp.Data["access_key"] != "AKIA1" {
+		t.Fatalf("bad role: %#v", resp.Data)
+	}
+	if _, ok := resp.Data["secret_key"]; ok {
+		t.Fatal("expected the secret key not to be returned with the role")
+	}
+
+	resp, err = request(logical.UpdateOperation, "static-roles/legacy", map[string]interface{}{
+		"username": "other",
+	})
+	if err != nil || resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error changing the username: resp: %#v err: %v", resp, err)
+	}
+
+	// Keys aren't rotated before the end of the rotation period
+	if err := b.periodicFunc(context.Background(), &logical.Request{Storage: config.StorageView}); err != nil {
+		t.Fatal(err)
+	}
+	if creds := readCreds(); creds["access_key"] != "AKIA1" {
+		t.Fatalf("expected the key not to be rotated, got %#v", creds)
+	}
+
+	// Shortening the rotation period makes the key due for rotation
+	role, err := staticRoleRead(context.Background(), config.StorageView, "legacy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	role.LastRotated = time.Now().Add(-2 * time.Minute)
+	if err := staticRoleWrite(context.Background(), config.StorageView, "legacy", role); err != nil {
+		t.Fatal(err)
+	}
+	resp, err = request(logical.UpdateOperation, "static-roles/legacy", map[string]interface{}{
+		"rotation_period": "1m",
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: resp: %#v err: %v", resp, err)
+	}
+	if err := b.periodicFunc(context.Background(), &logical.Request{Storage: config.StorageView}); err != nil {
+		t.Fatal(err)
+	}
+	if creds := readCreds(); creds["access_key"] != "AKIA2" || creds["secret_key"] != "secret-AKIA2" {
+		t.Fatalf("expected the key to be rotated, got %#v", creds)
+	}
+	keys := append([]string(nil), client.keys["legacy"]...)
+	sort.Strings(keys)
+	if !reflect.DeepEqual(keys, []string{"AKIA2", "AKIAEXISTING"}) {
+		t.Fatalf("expected the previous key to be deleted, got %v", keys)
+	}
+
+	resp, err = request(logical.ListOperation, "static-roles/", nil)
+	if err != nil || resp == nil {
+		t.Fatalf("bad: resp: %#v err: %v", resp, err)
+	}
+	if keys := resp.Data["keys"].([]string); !reflect.DeepEqual(keys, []string{"legacy"}) {
+		t.Fatalf("bad static roles: %v", keys)
+	}
+
+	// Deleting the role leaves the current key of the user in place
+	if _, err := request(logical.DeleteOperation, "static-roles/legacy", nil); err != nil {
+		t.Fatal(err)
+	}
+	resp, err = request(logical.ReadOperation, "static-creds/legacy", nil)
+	if err != nil || resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error reading the creds of a deleted role: resp: %#v err: %v", resp, err)
+	}
+	if len(client.keys["legacy"]) != 2 {
+		t.Fatalf("expected the keys of the user to be left alone, got %v", client.keys["legacy"])
+	}
+}
@@ -115,7 +115,10 @@ func (b *backend) pathCredsRead(ctx context.Context, req *logical.Request, d *fr
 
 	switch credentialType {
 	case iamUserCred:
-		return b.secretAccessKeysCreate(ctx, req.Storage, req.DisplayName, roleName, role)
+		if role.UseFederationToken {
+			return b.secretTokenCreate(ctx, req.Storage, req.DisplayName, roleName, role.PolicyDocument, ttl)
+		}
+		return b.secretAccessKeysCreate(ctx, req.Storage, req.DisplayName, req.EntityID, roleName, role)
 	case assumedRoleCred:
 		switch {
 		case roleArn == "":
@@ -126,7 +129,7 @@ func (b *backend) pathCredsRead(ctx context.Context, req *logical.Request, d *fr
 		case !strutil.StrListContains(role.RoleArns, roleArn):
 			return logical.ErrorResponse(fmt.Sprintf("role_arn %q not in allowed role arns for Vault role %q", roleArn, roleName)), nil
 		}
-		return b.assumeRole(ctx, req.Storage, req.DisplayName, roleName, roleArn, role.PolicyDocument, ttl)
+		return b.assumeRole(ctx, req.Storage, req.DisplayName, req.EntityID, roleName, roleArn, role, ttl)
 	case federationTokenCred:
 		return b.secretTokenCreate(ctx, req.Storage, req.DisplayName, roleName, role.PolicyDocument, ttl)
 	default:
@@ -3,11 +3,17 @@ package aws
 import (
 	"context"
 	"fmt"
+	"io/ioutil"
 	"math/rand"
+	"net/url"
 	"regexp"
+	"sort"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/iam"
 	"github.com/aws/aws-sdk-go/service/sts"
 	"github.com/hashicorp/errwrap"
@@ -111,26 +117,66 @@ func (b *backend) secretTokenCreate(ctx context.Context, s logical.Storage,
 }
 
 func (b *backend) assumeRole(ctx context.Context, s logical.Storage,
-	displayName, roleName, roleArn, policy string,
+	displayName, entityID, roleName, roleArn string, role *awsRoleEntry,
 	lifeTimeInSeconds int64) (*logical.Response, error) {
 	stsClient, err := b.clientSTS(ctx, s)
 	if err != nil {
 		return logical.ErrorResponse(err.Error()), nil
 	}
 
-	username, usernameWarning := genUsername(displayName, roleName, "iam_user")
+	var username, usernameWarning string
+	if role.UsernameTemplate != "" {
+		username, usernameWarning, err = renderUsername(role.UsernameTemplate, displayName, roleName, entityID, b.System(), maxRoleSessionNameLength)
+		if err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+	} else {
+		username, usernameWarning = genUsername(displayName, roleName, "iam_user")
+	}
+
+	sessionTags, err := populateTemplatedTags(role.SessionTags, entityID, b.System())
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	// The role is assumed with the credentials of the intermediate role, if
+	// any, rather than the root credentials
+	var creds *credentials.Credentials
+	if role.IntermediateRoleArn != "" {
+		req, intermediateResp := stsClient.AssumeRoleRequest(&sts.AssumeRoleInput{
+			RoleSessionName: aws.String(username),
+			RoleArn:         aws.String(role.IntermediateRoleArn),
+		})
+		if err := req.Send(); err != nil {
+			return logical.ErrorResponse(fmt.Sprintf(
+				"Error assuming intermediate role: %s", err)), awsutil.CheckAWSError(err)
+		}
+		creds = credentials.NewStaticCredentials(
+			*intermediateResp.Credentials.AccessKeyId,
+			*intermediateResp.Credentials.SecretAccessKey,
+			*intermediateResp.Credentials.SessionToken)
+	}
 
 	assumeRoleInput := &sts.AssumeRoleInput{
 		RoleSessionName: aws.String(username),
 		RoleArn:         aws.String(roleArn),
 		DurationSeconds: &lifeTimeInSeconds,
 	}
-	if policy != "" {
-		assumeRoleInput.SetPolicy(policy)
+	if role.PolicyDocument != "" {
+		assumeRoleInput.SetPolicy(role.PolicyDocument)
+	}
+	if role.ExternalID != "" {
+		assumeRoleInput.SetExternalId(role.ExternalID)
+	}
+	req, tokenResp := stsClient.AssumeRoleRequest(assumeRoleInput)
+	if creds != nil {
+		req.Config.Credentials = creds
+	}
+	if len(sessionTags) > 0 {
+		req.Handlers.Build.PushBackNamed(tagsHandler(sessionTags))
 	}
-	tokenResp, err := stsClient.AssumeRole(assumeRoleInput)
 
-	if err != nil {
+	if err := req.Send(); err != nil {
 		return logical.ErrorResponse(fmt.Sprintf(
 			"Error assuming role: %s", err)), awsutil.CheckAWSError(err)
 	}
@@ -158,16 +204,71 @@ func (b *backend) assumeRole(ctx context.Context, s logical.Storage,
 	return resp, nil
 }
 
+// tagsHandler returns a request handler adding the given tags to the
+// parameters of an AssumeRole or CreateUser request, as they aren't modeled
+// by the versions of the STS and IAM APIs in use.
+func tagsHandler(tags map[string]string) request.NamedHandler {
+	return request.NamedHandler{
+		Name: "vault.aws.Tags",
+		Fn: func(r *request.Request) {
+			if r.Error != nil {
+				return
+			}
+
+			body, err := ioutil.ReadAll(r.GetBody())
+			if err != nil {
+				r.Error = awserr.New("SerializationError", "failed reading "+r.Operation.Name+" request", err)
+				return
+			}
+			params, err := url.ParseQuery(string(body))
+			if err != nil {
+				r.Error = awserr.New("SerializationError", "failed parsing "+r.Operation.Name+" request", err)
+				return
+			}
+
+			keys := make([]string, 0, len(tags))
+			for key := range tags {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for i, key := range keys {
+				params.Set(fmt.Sprintf("Tags.member.%d.Key", i+1), key)
+				params.Set(fmt.Sprintf("Tags.member.%d.Value", i+1), tags[key])
+			}
+
+			r.SetBufferBody([]byte(params.Encode()))
+		},
+	}
+}
+
 func (b *backend) secretAccessKeysCreate(
 	ctx context.Context,
 	s logical.Storage,
-	displayName, policyName string, role *awsRoleEntry) (*logical.Response, error) {
+	displayName, entityID, policyName string, role *awsRoleEntry) (*logical.Response, error) {
 	iamClient, err := b.clientIAM(ctx, s)
 	if err != nil {
 		return logical.ErrorResponse(err.Error()), nil
 	}
 
-	username, usernameWarning := genUsername(displayName, policyName, "iam_user")
+	config, err := readRootConfig(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+
+	var username, usernameWarning string
+	if role.UsernameTemplate != "" {
+		username, usernameWarning, err = renderUsername(role.UsernameTemplate, displayName, policyName, entityID, b.System(), maxIAMUsernameLength)
+		if err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+	} else {
+		username, usernameWarning = genUsername(displayName, policyName, "iam_user")
+	}
+
+	iamTags, err := populateTemplatedTags(role.IAMTags, entityID, b.System())
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
 
 	// Write to the WAL that this user will be created. We do this before
 	// the user is created because if switch the order then the WAL put
@@ -181,9 +282,19 @@ func (b *backend) secretAccessKeysCreate(
 	}
 
 	// Create the user
-	_, err = iamClient.CreateUser(&iam.CreateUserInput{
+	createUserInput := &iam.CreateUserInput{
 		UserName: aws.String(username),
-	})
+	}
+	if config != nil && config.PermissionsBoundaryARN != "" {
+		createUserInput.PermissionsBoundary = aws.String(config.PermissionsBoundaryARN)
+	}
+	if len(iamTags) > 0 {
+		req, _ := iamClient.CreateUserRequest(createUserInput)
+		req.Handlers.Build.PushBackNamed(tagsHandler(iamTags))
+		err = req.Send()
+	} else {
+		_, err = iamClient.CreateUser(createUserInput)
+	}
 	if err != nil {
 		if walErr := framework.DeleteWAL(ctx, s, walID); walErr != nil {
 			iamErr := errwrap.Wrapf("error creating IAM user: {{err}}", err)
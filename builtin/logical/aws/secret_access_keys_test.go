@@ -1,7 +1,16 @@
 package aws
 
 import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/logical"
 )
 
 func TestNormalizeDisplayName_NormRequired(t *testing.T) {
@@ -42,3 +51,496 @@ func TestNormalizeDisplayName_NormNotRequired(t *testing.T) {
 		}
 	}
 }
+
+func TestBackend_AssumeRoleChainingAndSessionTags(t *testing.T) {
+	var requests []url.Values
+	var authorizations []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Fatal(err)
+		}
+		requests = append(requests, r.PostForm)
+		authorizations = append(authorizations, r.Header.Get("Authorization"))
+
+		fmt.Fprintf(w, `<AssumeRoleResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <AssumeRoleResult>
+    <Credentials>
+      <AccessKeyId>ASIA%d</AccessKeyId>
+      <SecretAccessKey>secret</SecretAccessKey>
+      <SessionToken>token</SessionToken>
+      <Expiration>%s</Expiration>
+    </Credentials>
+  </AssumeRoleResult>
+</AssumeRoleResponse>`, len(requests), time.Now().Add(time.Hour).UTC().Format(time.RFC3339))
+	}))
+	defer server.Close()
+
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+	b := Backend()
+	if err := b.Setup(context.Background(), config); err != nil {
+		t.Fatal(err)
+	}
+
+	request := func(op logical.Operation, path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation: op,
+			Path:      path,
+			Storage:   config.StorageView,
+			Data:      data,
+		})
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("bad: resp: %#v err: %v", resp, err)
+		}
+		return resp
+	}
+
+	request(logical.UpdateOperation, "config/root", map[string]interface{}{
+		"access_key":   "AKIAROOT",
+		"secret_key":   "secret",
+		"sts_endpoint": server.URL,
+	})
+
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "roles/invalid",
+		Storage:   config.StorageView,
+		Data: map[string]interface{}{
+			"credential_type": iamUserCred,
+			"external_id":     "abcd",
+		},
+	})
+	if err != nil || resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error setting an external ID on an IAM user role: resp: %#v err: %v", resp, err)
+	}
+
+	request(logical.UpdateOperation, "roles/tagged", map[string]interface{}{
+		"credential_type":       assumedRoleCred,
+		"role_arns":             "arn:aws:iam::123456789012:role/target",
+		"intermediate_role_arn": "arn:aws:iam::210987654321:role/intermediate",
+		"external_id":           "abcd",
+		"session_tags":          []string{"team=dev", "project=vault"},
+	})
+	resp = request(logical.ReadOperation, "roles/tagged", nil)
+	if resp.Data["external_id"] != "abcd" || resp.Data["intermediate_role_arn"] != "arn:aws:iam::210987654321:role/intermediate" {
+		t.Fatalf("bad role: %#v", resp.Data)
+	}
+
+	resp = request(logical.ReadOperation, "sts/tagged", nil)
+	if resp.Data["access_key"] != "ASIA2" {
+		t.Fatalf("expected the credentials of the target role, got %#v", resp.Data)
+	}
+	if len(requests) != 2 {
+		t.Fatalf("expected 2 AssumeRole calls, got %d", len(requests))
+	}
+
+	// The intermediate role is assumed with the root credentials
+	intermediate := requests[0]
+	if intermediate.Get("RoleArn") != "arn:aws:iam::210987654321:role/intermediate" || intermediate.Get("ExternalId") != "" || intermediate.Get("Tags.member.1.Key") != "" {
+		t.Fatalf("bad intermediate request: %v", intermediate)
+	}
+	if !strings.Contains(authorizations[0], "Credential=AKIAROOT/") {
+		t.Fatalf("expected the root credentials to sign the intermediate request, got %q", authorizations[0])
+	}
+
+	// and the target role with those of the intermediate role, passing the
+	// external ID and session tags
+	target := requests[1]
+	for k, v := range map[string]string{
+		"RoleArn":             "arn:aws:iam::123456789012:role/target",
+		"ExternalId":          "abcd",
+		"Tags.member.1.Key":   "project",
+		"Tags.member.1.Value": "vault",
+		"Tags.member.2.Key":   "team",
+		"Tags.member.2.Value": "dev",
+	} {
+		if target.Get(k) != v {
+			t.Fatalf("expected %s to be %q in the target request, got %v", k, v, target)
+		}
+	}
+	if !strings.Contains(authorizations[1], "Credential=ASIA1/") {
+		t.Fatalf("expected the intermediate credentials to sign the target request, got %q", authorizations[1])
+	}
+}
+
+func TestBackend_UsernameTemplateAndTags(t *testing.T) {
+	var requests []url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Fatal(err)
+		}
+		requests = append(requests, r.PostForm)
+
+		switch action := r.PostForm.Get("Action"); action {
+		case "CreateUser":
+			fmt.Fprintf(w, `<CreateUserResponse xmlns="https://iam.amazonaws.com/doc/2010-05-08/">
+  <CreateUserResult>
+    <User>
+      <UserName>%s</UserName>
+    </User>
+  </CreateUserResult>
+</CreateUserResponse>`, r.PostForm.Get("UserName"))
+		case "PutUserPolicy":
+			fmt.Fprint(w, `<PutUserPolicyResponse xmlns="https://iam.amazonaws.com/doc/2010-05-08/"></PutUserPolicyResponse>`)
+		case "CreateAccessKey":
+			fmt.Fprintf(w, `<CreateAccessKeyResponse xmlns="https://iam.amazonaws.com/doc/2010-05-08/">
+  <CreateAccessKeyResult>
+    <AccessKey>
+      <UserName>%s</UserName>
+      <AccessKeyId>AKIA1</AccessKeyId>
+      <Status>Active</Status>
+      <SecretAccessKey>secret</SecretAccessKey>
+    </AccessKey>
+  </CreateAccessKeyResult>
+</CreateAccessKeyResponse>`, r.PostForm.Get("UserName"))
+		case "AssumeRole":
+			fmt.Fprintf(w, `<AssumeRoleResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <AssumeRoleResult>
+    <Credentials>
+      <AccessKeyId>ASIA1</AccessKeyId>
+      <SecretAccessKey>secret</SecretAccessKey>
+      <SessionToken>token</SessionToken>
+      <Expiration>%s</Expiration>
+    </Credentials>
+  </AssumeRoleResult>
+</AssumeRoleResponse>`, time.Now().Add(time.Hour).UTC().Format(time.RFC3339))
+		default:
+			t.Errorf("unexpected action %q", action)
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+	sysView := logical.TestSystemView()
+	sysView.EntityVal = &logical.Entity{
+		ID:       "entity-id",
+		Name:     "alice",
+		Metadata: map[string]string{"team": "dev"},
+	}
+	config.System = sysView
+	b := Backend()
+	if err := b.Setup(context.Background(), config); err != nil {
+		t.Fatal(err)
+	}
+
+	request := func(op logical.Operation, path string, data map[string]interface{}) (*logical.Response, error) {
+		return b.HandleRequest(context.Background(), &logical.Request{
+			Operation:   op,
+			Path:        path,
+			Storage:     config.StorageView,
+			Data:        data,
+			DisplayName: "token",
+			EntityID:    "entity-id",
+		})
+	}
+	mustRequest := func(op logical.Operation, path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := request(op, path, data)
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("bad: resp: %#v err: %v", resp, err)
+		}
+		return resp
+	}
+
+	mustRequest(logical.UpdateOperation, "config/root", map[string]interface{}{
+		"access_key":   "AKIAROOT",
+		"secret_key":   "secret",
+		"iam_endpoint": server.URL,
+		"sts_endpoint": server.URL,
+	})
+
+	for _, data := range []map[string]interface{}{
+		{"credential_type": iamUserCred, "username_template": "vault-{{identity.entity.name"},
+		{"credential_type": iamUserCred, "iam_tags": "owner={{identity.entity.name"},
+		{"credential_type": assumedRoleCred, "role_arns": "arn:aws:iam::123456789012:role/target", "iam_tags": "owner=me"},
+		{"credential_type": federationTokenCred, "policy_document": `{}`, "username_template": "vault-{{role_name}}"},
+	} {
+		resp, err := request(logical.UpdateOperation, "roles/invalid", data)
+		if err != nil || resp == nil || !resp.IsError() {
+			t.Fatalf("expected an error creating the role with %v: resp: %#v err: %v", data, resp, err)
+		}
+	}
+
+	mustRequest(logical.UpdateOperation, "roles/user", map[string]interface{}{
+		"credential_type":   iamUserCred,
+		"policy_document":   `{"Version": "2012-10-17", "Statement": []}`,
+		"username_template": "vault-{{identity.entity.name}}-{{role_name}}",
+		"iam_tags":          []string{"owner={{identity.entity.name}}", "team={{identity.entity.metadata.team}}"},
+	})
+	resp := mustRequest(logical.ReadOperation, "roles/user", nil)
+	if resp.Data["username_template"] != "vault-{{identity.entity.name}}-{{role_name}}" {
+		t.Fatalf("bad role: %#v", resp.Data)
+	}
+
+	resp = mustRequest(logical.ReadOperation, "creds/user", nil)
+	if resp.Data["access_key"] != "AKIA1" {
+		t.Fatalf("bad creds: %#v", resp.Data)
+	}
+	for k, v := range map[string]string{
+		"Action":              "CreateUser",
+		"UserName":            "vault-alice-user",
+		"Tags.member.1.Key":   "owner",
+		"Tags.member.1.Value": "alice",
+		"Tags.member.2.Key":   "team",
+		"Tags.member.2.Value": "dev",
+	} {
+		if requests[0].Get(k) != v {
+			t.Fatalf("expected %s to be %q in the CreateUser request, got %v", k, v, requests[0])
+		}
+	}
+	for _, r := range requests[1:] {
+		if r.Get("UserName") != "vault-alice-user" {
+			t.Fatalf("expected requests for the templated user, got %v", r)
+		}
+	}
+
+	requests = nil
+	mustRequest(logical.UpdateOperation, "roles/assumed", map[string]interface{}{
+		"credential_type":   assumedRoleCred,
+		"role_arns":         "arn:aws:iam::123456789012:role/target",
+		"username_template": "{{display_name}}-{{identity.entity.name}}",
+		"session_tags":      "owner={{identity.entity.name}}",
+	})
+	mustRequest(logical.ReadOperation, "sts/assumed", nil)
+	if len(requests) != 1 {
+		t.Fatalf("expected 1 AssumeRole call, got %d", len(requests))
+	}
+	for k, v := range map[string]string{
+		"RoleSessionName":     "token-alice",
+		"Tags.member.1.Key":   "owner",
+		"Tags.member.1.Value": "alice",
+	} {
+		if requests[0].Get(k) != v {
+			t.Fatalf("expected %s to be %q in the AssumeRole request, got %v", k, v, requests[0])
+		}
+	}
+}
+
+func TestBackend_IAMUserFederationToken(t *testing.T) {
+	var requests []url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Fatal(err)
+		}
+		requests = append(requests, r.PostForm)
+
+		fmt.Fprintf(w, `<GetFederationTokenResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <GetFederationTokenResult>
+    <Credentials>
+      <AccessKeyId>ASIA1</AccessKeyId>
+      <SecretAccessKey>secret</SecretAccessKey>
+      <SessionToken>token</SessionToken>
+      <Expiration>%s</Expiration>
+    </Credentials>
+  </GetFederationTokenResult>
+</GetFederationTokenResponse>`, time.Now().Add(30*time.Minute).UTC().Format(time.RFC3339))
+	}))
+	defer server.Close()
+
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+	b := Backend()
+	if err := b.Setup(context.Background(), config); err != nil {
+		t.Fatal(err)
+	}
+
+	request := func(op logical.Operation, path string, data map[string]interface{}) (*logical.Response, error) {
+		return b.HandleRequest(context.Background(), &logical.Request{
+			Operation: op,
+			Path:      path,
+			Storage:   config.StorageView,
+			Data:      data,
+		})
+	}
+
+	if resp, err := request(logical.UpdateOperation, "config/root", map[string]interface{}{
+		"access_key":   "AKIAROOT",
+		"secret_key":   "secret",
+		"sts_endpoint": server.URL,
+	}); err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: resp: %#v err: %v", resp, err)
+	}
+
+	policyDocument := `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":"s3:GetObject","Resource":"*"}]}`
+	for _, data := range []map[string]interface{}{
+		{"credential_type": iamUserCred, "use_federation_token": true},
+		{"credential_type": iamUserCred, "use_federation_token": true, "policy_document": policyDocument, "policy_arns": "arn:aws:iam::aws:policy/ReadOnlyAccess"},
+		{"credential_type": iamUserCred, "use_federation_token": true, "policy_document": policyDocument, "iam_tags": "owner=me"},
+		{"credential_type": assumedRoleCred, "use_federation_token": true, "role_arns": "arn:aws:iam::123456789012:role/target"},
+		{"credential_type": iamUserCred, "policy_document": policyDocument, "default_sts_ttl": "30m"},
+	} {
+		resp, err := request(logical.UpdateOperation, "roles/invalid", data)
+		if err != nil || resp == nil || !resp.IsError() {
+			t.Fatalf("expected an error creating the role with %v: resp: %#v err: %v", data, resp, err)
+		}
+	}
+
+	if resp, err := request(logical.UpdateOperation, "roles/federated", map[string]interface{}{
+		"credential_type":      iamUserCred,
+		"use_federation_token": true,
+		"policy_document":      policyDocument,
+		"default_sts_ttl":      "30m",
+	}); err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: resp: %#v err: %v", resp, err)
+	}
+	resp, err := request(logical.ReadOperation, "roles/federated", nil)
+	if err != nil || resp == nil || resp.Data["use_federation_token"] != true {
+		t.Fatalf("bad: resp: %#v err: %v", resp, err)
+	}
+
+	resp, err = request(logical.ReadOperation, "creds/federated", nil)
+	if err != nil || resp == nil || resp.IsError() {
+		t.Fatalf("bad: resp: %#v err: %v", resp, err)
+	}
+	if resp.Data["access_key"] != "ASIA1" || resp.Data["security_token"] != "token" {
+		t.Fatalf("bad creds: %#v", resp.Data)
+	}
+	if resp.Secret.Renewable || resp.Secret.TTL > 30*time.Minute {
+		t.Fatalf("bad secret: %#v", resp.Secret)
+	}
+	if len(requests) != 1 {
+		t.Fatalf("expected 1 GetFederationToken call, got %d", len(requests))
+	}
+	for k, v := range map[string]string{
+		"Action":          "GetFederationToken",
+		"Policy":          policyDocument,
+		"DurationSeconds": "1800",
+	} {
+		if requests[0].Get(k) != v {
+			t.Fatalf("expected %s to be %q in the GetFederationToken request, got %v", k, v, requests[0])
+		}
+	}
+}
+
+func TestBackend_PolicyDocumentValidationAndPermissionsBoundary(t *testing.T) {
+	const malformed = `{"Version":"2012-10-17","Statement":[{"Effect":"Maybe","Action":"s3:GetObject","Resource":"*"}]}`
+	var requests []url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Fatal(err)
+		}
+		requests = append(requests, r.PostForm)
+
+		switch action := r.PostForm.Get("Action"); action {
+		case "SimulateCustomPolicy":
+			if r.PostForm.Get("PolicyInputList.member.1") == malformed {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, `<ErrorResponse xmlns="https://iam.amazonaws.com/doc/2010-05-08/">
+  <Error>
+    <Type>Sender</Type>
+    <Code>MalformedPolicyDocument</Code>
+    <Message>Syntax errors in policy.</Message>
+  </Error>
+</ErrorResponse>`)
+				return
+			}
+			fmt.Fprint(w, `<SimulateCustomPolicyResponse xmlns="https://iam.amazonaws.com/doc/2010-05-08/">
+  <SimulateCustomPolicyResult>
+    <IsTruncated>false</IsTruncated>
+    <EvaluationResults/>
+  </SimulateCustomPolicyResult>
+</SimulateCustomPolicyResponse>`)
+		case "CreateUser":
+			fmt.Fprintf(w, `<CreateUserResponse xmlns="https://iam.amazonaws.com/doc/2010-05-08/">
+  <CreateUserResult>
+    <User>
+      <UserName>%s</UserName>
+    </User>
+  </CreateUserResult>
+</CreateUserResponse>`, r.PostForm.Get("UserName"))
+		case "PutUserPolicy":
+			fmt.Fprint(w, `<PutUserPolicyResponse xmlns="https://iam.amazonaws.com/doc/2010-05-08/"></PutUserPolicyResponse>`)
+		case "CreateAccessKey":
+			fmt.Fprintf(w, `<CreateAccessKeyResponse xmlns="https://iam.amazonaws.com/doc/2010-05-08/">
+  <CreateAccessKeyResult>
+    <AccessKey>
+      <UserName>%s</UserName>
+      <AccessKeyId>AKIA1</AccessKeyId>
+      <Status>Active</Status>
+      <SecretAccessKey>secret</SecretAccessKey>
+    </AccessKey>
+  </CreateAccessKeyResult>
+</CreateAccessKeyResponse>`, r.PostForm.Get("UserName"))
+		default:
+			t.Errorf("unexpected action %q", action)
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+	b := Backend()
+	if err := b.Setup(context.Background(), config); err != nil {
+		t.Fatal(err)
+	}
+
+	request := func(op logical.Operation, path string, data map[string]interface{}) (*logical.Response, error) {
+		return b.HandleRequest(context.Background(), &logical.Request{
+			Operation: op,
+			Path:      path,
+			Storage:   config.StorageView,
+			Data:      data,
+		})
+	}
+
+	resp, err := request(logical.UpdateOperation, "config/root", map[string]interface{}{
+		"access_key":               "AKIAROOT",
+		"secret_key":               "secret",
+		"permissions_boundary_arn": "arn:aws:iam::123456789012:role/boundary",
+	})
+	if err != nil || resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error setting a role as permissions boundary: resp: %#v err: %v", resp, err)
+	}
+	if resp, err := request(logical.UpdateOperation, "config/root", map[string]interface{}{
+		"access_key":                "AKIAROOT",
+		"secret_key":                "secret",
+		"iam_endpoint":              server.URL,
+		"permissions_boundary_arn":  "arn:aws:iam::123456789012:policy/boundary",
+		"validate_policy_documents": true,
+	}); err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: resp: %#v err: %v", resp, err)
+	}
+
+	for _, policyDocument := range []string{
+		`[]`,
+		`{"Version":"2012-10-17"}`,
+		`{"Version":"2020-01-01","Statement":[]}`,
+		`{"Statement":[],"Padding":"` + strings.Repeat("a", maxPolicyDocumentSize) + `"}`,
+		malformed,
+	} {
+		resp, err := request(logical.UpdateOperation, "roles/invalid", map[string]interface{}{
+			"credential_type": iamUserCred,
+			"policy_document": policyDocument,
+		})
+		if err != nil || resp == nil || !resp.IsError() {
+			t.Fatalf("expected an error creating the role with %s: resp: %#v err: %v", policyDocument, resp, err)
+		}
+	}
+	if len(requests) != 1 {
+		t.Fatalf("expected only the well-formed policy document to be simulated, got %d requests", len(requests))
+	}
+
+	policyDocument := `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":"s3:GetObject","Resource":"*"}]}`
+	if resp, err := request(logical.UpdateOperation, "roles/user", map[string]interface{}{
+		"credential_type": iamUserCred,
+		"policy_document": policyDocument,
+	}); err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: resp: %#v err: %v", resp, err)
+	}
+
+	requests = nil
+	resp, err = request(logical.ReadOperation, "creds/user", nil)
+	if err != nil || resp == nil || resp.IsError() {
+		t.Fatalf("bad: resp: %#v err: %v", resp, err)
+	}
+	if len(requests) == 0 || requests[0].Get("Action") != "CreateUser" {
+		t.Fatalf("expected a CreateUser request, got %v", requests)
+	}
+	if boundary := requests[0].Get("PermissionsBoundary"); boundary != "arn:aws:iam::123456789012:policy/boundary" {
+		t.Fatalf("expected the permissions boundary to be set on the user, got %q", boundary)
+	}
+}
@@ -0,0 +1,100 @@
+package aws
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+const (
+	// maxIAMUsernameLength and maxRoleSessionNameLength are the lengths AWS
+	// allows for the names of IAM users and of assumed role sessions
+	maxIAMUsernameLength     = 64
+	maxRoleSessionNameLength = 64
+)
+
+// usernameTemplateFields returns the replacer of the fields of username
+// templates besides identity directives
+func usernameTemplateFields(displayName, roleName string) *strings.Replacer {
+	return strings.NewReplacer(
+		"{{display_name}}", normalizeDisplayName(displayName),
+		"{{role_name}}", normalizeDisplayName(roleName),
+		"{{unix_time}}", strconv.FormatInt(time.Now().Unix(), 10),
+		"{{random}}", strconv.Itoa(int(rand.Int31n(10000))),
+	)
+}
+
+// validateUsernameTemplate returns an error if the username template is
+// malformed
+func validateUsernameTemplate(tpl string) error {
+	if _, err := framework.ValidateIdentityTemplate(usernameTemplateFields("", "").Replace(tpl)); err != nil {
+		return errwrap.Wrapf("invalid username_template: {{err}}", err)
+	}
+	return nil
+}
+
+// renderUsername renders the username template of a role for the entity of
+// the requester, truncating the result to the given length. It returns a
+// warning if the username had to be truncated.
+func renderUsername(tpl, displayName, roleName, entityID string, sysView logical.SystemView, maxLength int) (username string, warning string, err error) {
+	username, err = populateIdentityTemplate(usernameTemplateFields(displayName, roleName).Replace(tpl), entityID, sysView)
+	if err != nil {
+		return "", "", errwrap.Wrapf("failed to render username_template: {{err}}", err)
+	}
+
+	username = normalizeDisplayName(username)
+	if len(username) > maxLength {
+		username = username[:maxLength]
+		warning = fmt.Sprintf("the username generated from the username_template was truncated to %d characters", maxLength)
+	}
+
+	return username, warning, nil
+}
+
+// validateTemplatedTags returns an error if the value of one of the tags is
+// a malformed identity template
+func validateTemplatedTags(field string, tags map[string]string) error {
+	for key, value := range tags {
+		if _, err := framework.ValidateIdentityTemplate(value); err != nil {
+			return fmt.Errorf("invalid value of the %q tag in %s: %s", key, field, err)
+		}
+	}
+	return nil
+}
+
+// populateTemplatedTags returns the tags with the identity templates in
+// their values populated for the entity of the requester
+func populateTemplatedTags(tags map[string]string, entityID string, sysView logical.SystemView) (map[string]string, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+
+	ret := make(map[string]string, len(tags))
+	for key, value := range tags {
+		populated, err := populateIdentityTemplate(value, entityID, sysView)
+		if err != nil {
+			return nil, errwrap.Wrapf(fmt.Sprintf("failed to populate the value of the %q tag: {{err}}", key), err)
+		}
+		ret[key] = populated
+	}
+
+	return ret, nil
+}
+
+func populateIdentityTemplate(tpl, entityID string, sysView logical.SystemView) (string, error) {
+	hasTemplating, err := framework.ValidateIdentityTemplate(tpl)
+	if err != nil {
+		return "", err
+	}
+	if !hasTemplating {
+		return tpl, nil
+	}
+
+	return framework.PopulateIdentityTemplate(tpl, entityID, sysView)
+}
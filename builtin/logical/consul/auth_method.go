@@ -0,0 +1,113 @@
+package consul
+
+import (
+	"context"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/errwrap"
+)
+
+// loginTokenMinTTL is the validity under which the token obtained from the
+// auth method is replaced by a new one
+const loginTokenMinTTL = time.Minute
+
+// loginRequest is a request to log in to Consul with an auth method, which
+// requires Consul 1.5 or later
+type loginRequest struct {
+	AuthMethod  string
+	BearerToken string
+	Meta        map[string]string `json:",omitempty"`
+}
+
+// loginToken is the token returned by a login to Consul
+type loginToken struct {
+	AccessorID     string
+	SecretID       string
+	ExpirationTime *time.Time `json:",omitempty"`
+}
+
+// valid returns true if the token can still be used
+func (t *loginToken) valid() bool {
+	return t.ExpirationTime == nil || time.Until(*t.ExpirationTime) > loginTokenMinTTL
+}
+
+// bearerToken returns the JWT used to log in with the auth method, reading it
+// from its file if needed so that it can be rotated outside of Vault
+func (c *accessConfig) bearerToken() (string, error) {
+	if c.BearerTokenFile == "" {
+		return c.BearerToken, nil
+	}
+
+	token, err := ioutil.ReadFile(c.BearerTokenFile)
+	if err != nil {
+		return "", errwrap.Wrapf("error reading bearer token file: {{err}}", err)
+	}
+	return strings.TrimSpace(string(token)), nil
+}
+
+// authMethodToken returns a token obtained by logging in to Consul with the
+// configured auth method. The token is reused until it is about to expire,
+// then the backend logs in again and logs the previous token out.
+func (b *backend) authMethodToken(ctx context.Context, conf *accessConfig, consulConf *api.Config) (string, error) {
+	b.loginLock.Lock()
+	defer b.loginLock.Unlock()
+
+	if b.loginToken != nil && b.loginToken.valid() {
+		return b.loginToken.SecretID, nil
+	}
+
+	bearerToken, err := conf.bearerToken()
+	if err != nil {
+		return "", err
+	}
+
+	client, err := api.NewClient(consulConf)
+	if err != nil {
+		return "", err
+	}
+
+	writeOpts := &api.WriteOptions{}
+	writeOpts = writeOpts.WithContext(ctx)
+
+	var token loginToken
+	if _, err := client.Raw().Write("/v1/acl/login", &loginRequest{
+		AuthMethod:  conf.AuthMethod,
+		BearerToken: bearerToken,
+		Meta: map[string]string{
+			"vault_backend": "consul",
+		},
+	}, &token, writeOpts); err != nil {
+		return "", errwrap.Wrapf("error logging in to consul with the auth method: {{err}}", err)
+	}
+
+	if b.loginToken != nil {
+		b.logout(client, b.loginToken)
+	}
+	b.loginToken = &token
+
+	return token.SecretID, nil
+}
+
+// logout deletes a token obtained from the auth method. Failures are only
+// logged since the token expires anyway.
+func (b *backend) logout(client *api.Client, token *loginToken) {
+	if token.ExpirationTime != nil && time.Now().After(*token.ExpirationTime) {
+		return
+	}
+
+	if _, err := client.Raw().Write("/v1/acl/logout", nil, nil, &api.WriteOptions{Token: token.SecretID}); err != nil {
+		b.Logger().Warn("failed to log out the consul token of the auth method", "accessor", token.AccessorID, "error", err)
+	}
+}
+
+// resetLoginToken forgets the token obtained from the auth method, e.g. when
+// the configuration changes
+func (b *backend) resetLoginToken() {
+	b.loginLock.Lock()
+	defer b.loginLock.Unlock()
+
+	b.loginToken = nil
+}
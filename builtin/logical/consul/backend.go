@@ -2,6 +2,7 @@ package consul
 
 import (
 	"context"
+	"sync"
 
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
@@ -26,14 +27,17 @@ func Backend() *backend {
 
 		Paths: []*framework.Path{
 			pathConfigAccess(&b),
+			framework.PathConfigVerify("config/access", b.verifyConfigAccess),
 			pathListRoles(&b),
 			pathRoles(&b),
 			pathToken(&b),
+			framework.PathRevokeAll(),
 		},
 
 		Secrets: []*framework.Secret{
 			secretToken(&b),
 		},
+		Invalidate:  b.invalidate,
 		BackendType: logical.TypeLogical,
 	}
 
@@ -42,4 +46,16 @@ func Backend() *backend {
 
 type backend struct {
 	*framework.Backend
+
+	// loginToken is the token obtained from the auth method, if one is
+	// configured
+	loginToken *loginToken
+	loginLock  sync.Mutex
+}
+
+func (b *backend) invalidate(_ context.Context, key string) {
+	switch key {
+	case "config/access":
+		b.resetLoginToken()
+	}
 }
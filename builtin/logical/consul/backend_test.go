@@ -3,11 +3,16 @@ package consul
 import (
 	"context"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"log"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"reflect"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -162,8 +167,10 @@ func testBackendConfigAccess(t *testing.T, version string) {
 	}
 
 	expected := map[string]interface{}{
-		"address": connData["address"].(string),
-		"scheme":  "http",
+		"address":           connData["address"].(string),
+		"scheme":            "http",
+		"auth_method":       "",
+		"bearer_token_file": "",
 	}
 	if !reflect.DeepEqual(expected, resp.Data) {
 		t.Fatalf("bad: expected:%#v\nactual:%#v\n", expected, resp.Data)
@@ -171,6 +178,122 @@ func testBackendConfigAccess(t *testing.T, version string) {
 	if resp.Data["token"] != nil {
 		t.Fatalf("token should not be set in the response")
 	}
+
+	confReq.Path = "config/access/verify"
+	resp, err = b.HandleRequest(context.Background(), confReq)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("failed to verify configuration: resp:%#v err:%s", resp, err)
+	}
+	if resp.Data["verified"] != true {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+}
+
+func TestBackend_AuthMethod(t *testing.T) {
+	var l sync.Mutex
+	var logins int
+	var loggedOut, usedTokens []string
+	loginTTL := 30 * time.Second
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		l.Lock()
+		defer l.Unlock()
+
+		switch r.URL.Path {
+		case "/v1/acl/login":
+			var req loginRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			if req.AuthMethod != "vault" || req.BearerToken != "test-jwt" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			logins++
+			expiration := time.Now().Add(loginTTL)
+			json.NewEncoder(w).Encode(&loginToken{
+				AccessorID:     fmt.Sprintf("accessor-%d", logins),
+				SecretID:       fmt.Sprintf("login-%d", logins),
+				ExpirationTime: &expiration,
+			})
+		case "/v1/acl/logout":
+			loggedOut = append(loggedOut, r.Header.Get("X-Consul-Token"))
+		case "/v1/acl/token":
+			usedTokens = append(usedTokens, r.Header.Get("X-Consul-Token"))
+			w.Write([]byte(`{"AccessorID": "accessor", "SecretID": "secret"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	tokenFile, err := ioutil.TempFile("", "vault-consul-jwt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(tokenFile.Name())
+	tokenFile.WriteString("test-jwt\n")
+	tokenFile.Close()
+
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+	b, err := Factory(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request := func(op logical.Operation, path string, data map[string]interface{}) *logical.Response {
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation: op,
+			Path:      path,
+			Storage:   config.StorageView,
+			Data:      data,
+		})
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		return resp
+	}
+
+	resp := request(logical.UpdateOperation, "config/access", map[string]interface{}{
+		"address":      srv.URL,
+		"auth_method":  "vault",
+		"token":        "static",
+		"bearer_token": "test-jwt",
+	})
+	if resp == nil || !resp.IsError() {
+		t.Fatal("expected token and auth_method to be mutually exclusive")
+	}
+
+	request(logical.UpdateOperation, "config/access", map[string]interface{}{
+		"address":           strings.TrimPrefix(srv.URL, "http://"),
+		"auth_method":       "vault",
+		"bearer_token_file": tokenFile.Name(),
+	})
+	request(logical.UpdateOperation, "roles/test", map[string]interface{}{
+		"policies": "test",
+	})
+
+	// The first token expires within loginTokenMinTTL, so it is replaced at
+	// the next request
+	for i := 0; i < 3; i++ {
+		if i == 1 {
+			l.Lock()
+			loginTTL = time.Hour
+			l.Unlock()
+		}
+		resp = request(logical.ReadOperation, "creds/test", nil)
+		if resp.IsError() || resp.Data["token"] != "secret" {
+			t.Fatalf("bad: %#v", resp)
+		}
+	}
+
+	l.Lock()
+	defer l.Unlock()
+	if !reflect.DeepEqual(usedTokens, []string{"login-1", "login-2", "login-2"}) {
+		t.Fatalf("bad tokens: %v", usedTokens)
+	}
+	if !reflect.DeepEqual(loggedOut, []string{"login-1"}) {
+		t.Fatalf("bad logouts: %v", loggedOut)
+	}
 }
 
 func TestBackend_Renew_Revoke(t *testing.T) {
@@ -25,6 +25,14 @@ func (b *backend) client(ctx context.Context, s logical.Storage) (*api.Client, e
 	consulConf.Scheme = conf.Scheme
 	consulConf.Token = conf.Token
 
+	if conf.AuthMethod != "" {
+		token, err := b.authMethodToken(ctx, conf, consulConf)
+		if err != nil {
+			return nil, nil, err
+		}
+		consulConf.Token = token
+	}
+
 	client, err := api.NewClient(consulConf)
 	return client, nil, err
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/errwrap"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
@@ -32,6 +33,24 @@ func pathConfigAccess(b *backend) *framework.Path {
 				Type:        framework.TypeString,
 				Description: "Token for API calls",
 			},
+
+			"auth_method": &framework.FieldSchema{
+				Type: framework.TypeString,
+				Description: `Name of a Consul auth method used to obtain tokens for API
+calls instead of a static token. Requires Consul 1.5 or later.`,
+			},
+
+			"bearer_token": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "JWT presented to the auth method",
+			},
+
+			"bearer_token_file": &framework.FieldSchema{
+				Type: framework.TypeString,
+				Description: `Path of a file on the Vault servers containing the JWT presented to
+the auth method, read at each login so that it can be rotated,
+e.g. a projected Kubernetes service account token.`,
+			},
 		},
 
 		Callbacks: map[logical.Operation]framework.OperationFunc{
@@ -58,6 +77,38 @@ func (b *backend) readConfigAccess(ctx context.Context, storage logical.Storage)
 	return conf, nil, nil
 }
 
+// verifyConfigAccess lists ACLs with the configured token to check that Consul
+// can be reached and that the token has the management privileges required to
+// create and revoke tokens.
+func (b *backend) verifyConfigAccess(ctx context.Context, s logical.Storage) error {
+	c, userErr, intErr := b.client(ctx, s)
+	if intErr != nil {
+		return intErr
+	}
+	if userErr != nil {
+		return userErr
+	}
+
+	queryOpts := &api.QueryOptions{}
+	queryOpts = queryOpts.WithContext(ctx)
+
+	// Tokens of auth methods are looked up since they only have the
+	// privileges of their roles, not the management ones
+	conf, _, _ := b.readConfigAccess(ctx, s)
+	if conf != nil && conf.AuthMethod != "" {
+		if _, _, err := c.ACL().TokenReadSelf(queryOpts); err != nil {
+			return errwrap.Wrapf("error looking up the consul token of the auth method: {{err}}", err)
+		}
+		return nil
+	}
+
+	if _, _, err := c.ACL().List(queryOpts); err != nil {
+		return errwrap.Wrapf("error listing consul ACLs: {{err}}", err)
+	}
+
+	return nil
+}
+
 func (b *backend) pathConfigAccessRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
 	conf, userErr, intErr := b.readConfigAccess(ctx, req.Storage)
 	if intErr != nil {
@@ -72,18 +123,34 @@ func (b *backend) pathConfigAccessRead(ctx context.Context, req *logical.Request
 
 	return &logical.Response{
 		Data: map[string]interface{}{
-			"address": conf.Address,
-			"scheme":  conf.Scheme,
+			"address":           conf.Address,
+			"scheme":            conf.Scheme,
+			"auth_method":       conf.AuthMethod,
+			"bearer_token_file": conf.BearerTokenFile,
 		},
 	}, nil
 }
 
 func (b *backend) pathConfigAccessWrite(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
-	entry, err := logical.StorageEntryJSON("config/access", accessConfig{
-		Address: data.Get("address").(string),
-		Scheme:  data.Get("scheme").(string),
-		Token:   data.Get("token").(string),
-	})
+	conf := accessConfig{
+		Address:         data.Get("address").(string),
+		Scheme:          data.Get("scheme").(string),
+		Token:           data.Get("token").(string),
+		AuthMethod:      data.Get("auth_method").(string),
+		BearerToken:     data.Get("bearer_token").(string),
+		BearerTokenFile: data.Get("bearer_token_file").(string),
+	}
+
+	switch {
+	case conf.AuthMethod == "" && (conf.BearerToken != "" || conf.BearerTokenFile != ""):
+		return logical.ErrorResponse("bearer_token and bearer_token_file require auth_method"), nil
+	case conf.AuthMethod != "" && conf.Token != "":
+		return logical.ErrorResponse("token and auth_method are mutually exclusive"), nil
+	case conf.AuthMethod != "" && (conf.BearerToken == "") == (conf.BearerTokenFile == ""):
+		return logical.ErrorResponse("exactly one of bearer_token or bearer_token_file is required with auth_method"), nil
+	}
+
+	entry, err := logical.StorageEntryJSON("config/access", conf)
 	if err != nil {
 		return nil, err
 	}
@@ -92,11 +159,17 @@ func (b *backend) pathConfigAccessWrite(ctx context.Context, req *logical.Reques
 		return nil, err
 	}
 
+	// The token of the previous auth method configuration isn't used anymore
+	b.resetLoginToken()
+
 	return nil, nil
 }
 
 type accessConfig struct {
-	Address string `json:"address"`
-	Scheme  string `json:"scheme"`
-	Token   string `json:"token"`
+	Address         string `json:"address"`
+	Scheme          string `json:"scheme"`
+	Token           string `json:"token"`
+	AuthMethod      string `json:"auth_method"`
+	BearerToken     string `json:"bearer_token"`
+	BearerTokenFile string `json:"bearer_token_file"`
 }
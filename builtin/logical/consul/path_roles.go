@@ -74,12 +74,42 @@ Defaults to 'client'.`,
 
 		Callbacks: map[logical.Operation]framework.OperationFunc{
 			logical.ReadOperation:   b.pathRolesRead,
+			logical.CreateOperation: b.pathRolesWrite,
 			logical.UpdateOperation: b.pathRolesWrite,
 			logical.DeleteOperation: b.pathRolesDelete,
 		},
+
+		ExistenceCheck: b.rolesExistenceCheck,
 	}
 }
 
+// rolesExistenceCheck tells a write creating a role, which requires the create
+// capability, from a write updating it, which leaves the fields it doesn't set
+// unchanged.
+func (b *backend) rolesExistenceCheck(ctx context.Context, req *logical.Request, d *framework.FieldData) (bool, error) {
+	role, err := b.role(ctx, req.Storage, d.Get("name").(string))
+	if err != nil {
+		return false, err
+	}
+	return role != nil, nil
+}
+
+func (b *backend) role(ctx context.Context, storage logical.Storage, name string) (*roleConfig, error) {
+	entry, err := storage.Get(ctx, "policy/"+name)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var result roleConfig
+	if err := entry.DecodeJSON(&result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
 func (b *backend) pathRoleList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
 	entries, err := req.Storage.List(ctx, "policy/")
 	if err != nil {
@@ -92,19 +122,14 @@ func (b *backend) pathRoleList(ctx context.Context, req *logical.Request, d *fra
 func (b *backend) pathRolesRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
 	name := d.Get("name").(string)
 
-	entry, err := req.Storage.Get(ctx, "policy/"+name)
+	result, err := b.role(ctx, req.Storage, name)
 	if err != nil {
 		return nil, err
 	}
-	if entry == nil {
+	if result == nil {
 		return nil, nil
 	}
 
-	var result roleConfig
-	if err := entry.DecodeJSON(&result); err != nil {
-		return nil, err
-	}
-
 	if result.TokenType == "" {
 		result.TokenType = "client"
 	}
@@ -129,16 +154,56 @@ func (b *backend) pathRolesRead(ctx context.Context, req *logical.Request, d *fr
 }
 
 func (b *backend) pathRolesWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
-	tokenType := d.Get("token_type").(string)
-	policy := d.Get("policy").(string)
 	name := d.Get("name").(string)
-	policies := d.Get("policies").([]string)
-	local := d.Get("local").(bool)
 
-	if len(policies) == 0 {
-		switch tokenType {
+	role, err := b.role(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	create := role == nil
+	if create {
+		role = new(roleConfig)
+	}
+	if role.TokenType == "" {
+		role.TokenType = "client"
+	}
+
+	// Updating a role leaves the fields not set by the request unchanged
+	if _, ok := d.GetOk("token_type"); ok || create {
+		role.TokenType = d.Get("token_type").(string)
+	}
+
+	if policy, ok := d.GetOk("policy"); ok {
+		policyRaw, err := base64.StdEncoding.DecodeString(policy.(string))
+		if err != nil {
+			return logical.ErrorResponse(fmt.Sprintf(
+				"Error decoding policy base64: %s", err)), nil
+		}
+		role.Policy = string(policyRaw)
+	}
+
+	if policies, ok := d.GetOk("policies"); ok {
+		role.Policies = policies.([]string)
+	}
+
+	if local, ok := d.GetOk("local"); ok {
+		role.Local = local.(bool)
+	}
+
+	if ttlRaw, ok := d.GetOk("ttl"); ok {
+		role.TTL = time.Second * time.Duration(ttlRaw.(int))
+	} else if leaseParamRaw, ok := d.GetOk("lease"); ok {
+		role.TTL = time.Second * time.Duration(leaseParamRaw.(int))
+	}
+
+	if maxTTLRaw, ok := d.GetOk("max_ttl"); ok {
+		role.MaxTTL = time.Second * time.Duration(maxTTLRaw.(int))
+	}
+
+	if len(role.Policies) == 0 {
+		switch role.TokenType {
 		case "client":
-			if policy == "" {
+			if role.Policy == "" {
 				return logical.ErrorResponse(
 					"Use either a policy document, or a list of policies, depending on your Consul version"), nil
 			}
@@ -149,37 +214,7 @@ func (b *backend) pathRolesWrite(ctx context.Context, req *logical.Request, d *f
 		}
 	}
 
-	policyRaw, err := base64.StdEncoding.DecodeString(policy)
-	if err != nil {
-		return logical.ErrorResponse(fmt.Sprintf(
-			"Error decoding policy base64: %s", err)), nil
-	}
-
-	var ttl time.Duration
-	ttlRaw, ok := d.GetOk("ttl")
-	if ok {
-		ttl = time.Second * time.Duration(ttlRaw.(int))
-	} else {
-		leaseParamRaw, ok := d.GetOk("lease")
-		if ok {
-			ttl = time.Second * time.Duration(leaseParamRaw.(int))
-		}
-	}
-
-	var maxTTL time.Duration
-	maxTTLRaw, ok := d.GetOk("max_ttl")
-	if ok {
-		maxTTL = time.Second * time.Duration(maxTTLRaw.(int))
-	}
-
-	entry, err := logical.StorageEntryJSON("policy/"+name, roleConfig{
-		Policy:    string(policyRaw),
-		Policies:  policies,
-		TokenType: tokenType,
-		TTL:       ttl,
-		MaxTTL:    maxTTL,
-		Local:     local,
-	})
+	entry, err := logical.StorageEntryJSON("policy/"+name, role)
 	if err != nil {
 		return nil, err
 	}
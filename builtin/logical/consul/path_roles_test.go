@@ -0,0 +1,82 @@
+package consul
+
+import (
+	"context"
+	"encoding/base64"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/vault/logical"
+)
+
+func TestBackend_roles_create_update(t *testing.T) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+	b, err := Factory(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exists := func() bool {
+		checkFound, exists, err := b.HandleExistenceCheck(context.Background(), &logical.Request{
+			Operation: logical.CreateOperation,
+			Path:      "roles/test",
+			Storage:   config.StorageView,
+		})
+		if err != nil || !checkFound {
+			t.Fatalf("bad: checkFound: %t, err: %v", checkFound, err)
+		}
+		return exists
+	}
+	request := func(op logical.Operation, data map[string]interface{}) *logical.Response {
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation: op,
+			Path:      "roles/test",
+			Storage:   config.StorageView,
+			Data:      data,
+		})
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		return resp
+	}
+
+	if exists() {
+		t.Fatal("expected the role not to exist")
+	}
+	if resp := request(logical.CreateOperation, map[string]interface{}{
+		"policy": base64.StdEncoding.EncodeToString([]byte(testPolicy)),
+		"ttl":    "1h",
+	}); resp != nil {
+		t.Fatalf("bad: %#v", resp)
+	}
+	if !exists() {
+		t.Fatal("expected the role to exist")
+	}
+
+	// Updating leaves the policy and TTL set at creation
+	if resp := request(logical.UpdateOperation, map[string]interface{}{
+		"max_ttl": "2h",
+	}); resp != nil {
+		t.Fatalf("bad: %#v", resp)
+	}
+	resp := request(logical.ReadOperation, nil)
+	expected := map[string]interface{}{
+		"lease":      int64(3600),
+		"ttl":        int64(3600),
+		"max_ttl":    int64(7200),
+		"token_type": "client",
+		"local":      false,
+		"policy":     base64.StdEncoding.EncodeToString([]byte(testPolicy)),
+	}
+	if !reflect.DeepEqual(resp.Data, expected) {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	if resp := request(logical.UpdateOperation, map[string]interface{}{
+		"token_type": "bogus",
+		"policy":     "",
+	}); resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error, got: %#v", resp)
+	}
+}
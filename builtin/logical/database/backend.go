@@ -63,28 +63,49 @@ func Backend(conf *logical.BackendConfig) *databaseBackend {
 		Paths: []*framework.Path{
 			pathListPluginConnection(&b),
 			pathConfigurePluginConnection(&b),
+			pathConnectionHealth(&b),
 			pathListRoles(&b),
 			pathRoles(&b),
 			pathCredsCreate(&b),
+			framework.PathRevokeAll(),
 			pathResetConnection(&b),
 			pathRotateCredentials(&b),
+			pathRotationHistory(&b),
+			pathRotationHistoryConfig(&b),
 		},
 
 		Secrets: []*framework.Secret{
 			secretCreds(&b),
 		},
-		Clean:       b.closeAllDBs,
-		Invalidate:  b.invalidate,
+		Clean:        b.closeAllDBs,
+		Invalidate:   b.invalidate,
+		PeriodicFunc: b.periodicFunc,
+		ScheduledFuncs: []*framework.ScheduledFunc{
+			{
+				Name:            "health_check",
+				Description:     "Checks the health of the connections not checked within the last five minutes.",
+				DefaultSchedule: "1m",
+				Func:            b.periodicHealthCheck,
+			},
+			{
+				Name:            "prune_rotation_history",
+				Description:     "Removes the rotation records older than the retention.",
+				DefaultSchedule: "1h",
+				Func:            b.periodicPruneRotationHistory,
+			},
+		},
 		BackendType: logical.TypeLogical,
 	}
 
 	b.logger = conf.Logger
 	b.connections = make(map[string]*dbPluginInstance)
+	b.health = newHealthChecker()
 	return &b
 }
 
 type databaseBackend struct {
 	connections map[string]*dbPluginInstance
+	health      *healthChecker
 	logger      log.Logger
 
 	*framework.Backend
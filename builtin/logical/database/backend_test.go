@@ -1377,6 +1377,114 @@ func TestBackend_RotateRootCredentials(t *testing.T) {
 	}
 }
 
+func TestBackend_RotationHistory(t *testing.T) {
+	cluster, sys := getCluster(t)
+	defer cluster.Cleanup()
+
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+	config.System = sys
+
+	b, err := Factory(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Cleanup(context.Background())
+
+	// Configure a connection that cannot be reached so that rotation fails
+	req := &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "config/plugin-test",
+		Storage:   config.StorageView,
+		Data: map[string]interface{}{
+			"connection_url":    "postgres://localhost:1/database?sslmode=disable",
+			"plugin_name":       "postgresql-database-plugin",
+			"verify_connection": false,
+			"allowed_roles":     []string{"*"},
+		},
+	}
+	resp, err := b.HandleRequest(namespace.RootContext(nil), req)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err:%s resp:%#v\n", err, resp)
+	}
+
+	req = &logical.Request{
+		Operation:   logical.UpdateOperation,
+		Path:        "rotate-root/plugin-test",
+		Storage:     config.StorageView,
+		DisplayName: "token-operator",
+		EntityID:    "entity-1",
+	}
+	if _, err := b.HandleRequest(namespace.RootContext(nil), req); err == nil {
+		t.Fatal("expected rotation to fail")
+	}
+
+	readHistory := func(connection string) []map[string]interface{} {
+		t.Helper()
+		req := &logical.Request{
+			Operation: logical.ReadOperation,
+			Path:      "rotations/",
+			Storage:   config.StorageView,
+			Data:      map[string]interface{}{},
+		}
+		if connection != "" {
+			req.Data["connection"] = connection
+		}
+		resp, err := b.HandleRequest(namespace.RootContext(nil), req)
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("err:%s resp:%#v\n", err, resp)
+		}
+		return resp.Data["rotations"].([]map[string]interface{})
+	}
+
+	rotations := readHistory("")
+	if len(rotations) != 1 {
+		t.Fatalf("expected 1 rotation record, got %d", len(rotations))
+	}
+	record := rotations[0]
+	if record["connection"] != "plugin-test" || record["type"] != "root" ||
+		record["requester"] != "token-operator" || record["entity_id"] != "entity-1" ||
+		record["success"] != false || record["error"] == "" {
+		t.Fatalf("bad: %#v", record)
+	}
+
+	if rotations := readHistory("other"); len(rotations) != 0 {
+		t.Fatalf("expected no rotation records, got %d", len(rotations))
+	}
+
+	// Shrinking the retention below the record's age removes it
+	req = &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "rotations/config",
+		Storage:   config.StorageView,
+		Data: map[string]interface{}{
+			"retention": "1s",
+		},
+	}
+	resp, err = b.HandleRequest(namespace.RootContext(nil), req)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err:%s resp:%#v\n", err, resp)
+	}
+
+	time.Sleep(1500 * time.Millisecond)
+	if rotations := readHistory(""); len(rotations) != 0 {
+		t.Fatalf("expected no rotation records, got %d", len(rotations))
+	}
+
+	// Reading the history leaves the removal to the scheduled function
+	keys, err := config.StorageView.List(context.Background(), rotationHistoryPrefix)
+	if err != nil || len(keys) != 1 {
+		t.Fatalf("expected 1 stored rotation record, got %v: %v", keys, err)
+	}
+	if err := b.(*databaseBackend).periodicPruneRotationHistory(context.Background(), &logical.Request{Storage: config.StorageView}); err != nil {
+		t.Fatal(err)
+	}
+	keys, err = config.StorageView.List(context.Background(), rotationHistoryPrefix)
+	if err != nil || len(keys) != 0 {
+		t.Fatalf("expected no stored rotation records, got %v: %v", keys, err)
+	}
+}
+
 func testCredsExist(t *testing.T, resp *logical.Response, connURL string) bool {
 	t.Helper()
 	var d struct {
@@ -1438,3 +1546,92 @@ REVOKE USAGE ON SCHEMA public FROM {{name}};
 
 DROP ROLE IF EXISTS {{name}};
 `
+
+func TestBackend_ConnectionHealth(t *testing.T) {
+	cluster, sys := getCluster(t)
+	defer cluster.Cleanup()
+
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+	config.System = sys
+
+	b, err := Factory(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Cleanup(context.Background())
+
+	// Configure a connection that cannot be reached
+	req := &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "config/plugin-test",
+		Storage:   config.StorageView,
+		Data: map[string]interface{}{
+			"connection_url":    "postgres://localhost:1/database?sslmode=disable",
+			"plugin_name":       "postgresql-database-plugin",
+			"verify_connection": false,
+			"allowed_roles":     []string{"*"},
+		},
+	}
+	resp, err := b.HandleRequest(namespace.RootContext(nil), req)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err:%s resp:%#v\n", err, resp)
+	}
+
+	// The background check records the failure
+	req = &logical.Request{
+		Storage: config.StorageView,
+	}
+	if err := b.(*databaseBackend).periodicHealthCheck(namespace.RootContext(nil), req); err != nil {
+		t.Fatal(err)
+	}
+	status := b.(*databaseBackend).health.status["plugin-test"]
+	if status == nil || status.LastError == "" || status.ConsecutiveFails != 1 {
+		t.Fatalf("bad: %#v", status)
+	}
+
+	req = &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "config/plugin-test/health",
+		Storage:   config.StorageView,
+	}
+	resp, err = b.HandleRequest(namespace.RootContext(nil), req)
+	if err != nil || resp == nil {
+		t.Fatalf("err:%s resp:%#v\n", err, resp)
+	}
+	if resp.Data["healthy"] != false {
+		t.Fatalf("expected connection to be unhealthy: %#v", resp.Data)
+	}
+	if resp.Data["consecutive_fails"] != 2 {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+	if resp.Data["last_error"] == "" || resp.Data["last_error_time"] == nil {
+		t.Fatalf("expected last error to be set: %#v", resp.Data)
+	}
+	if _, ok := resp.Data["last_success"]; ok {
+		t.Fatalf("expected no last success: %#v", resp.Data)
+	}
+
+	// Deleting the connection drops its status
+	req = &logical.Request{
+		Operation: logical.DeleteOperation,
+		Path:      "config/plugin-test",
+		Storage:   config.StorageView,
+	}
+	if _, err := b.HandleRequest(namespace.RootContext(nil), req); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := b.(*databaseBackend).health.status["plugin-test"]; ok {
+		t.Fatal("expected health status to be removed")
+	}
+
+	req = &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "config/plugin-test/health",
+		Storage:   config.StorageView,
+	}
+	resp, err = b.HandleRequest(namespace.RootContext(nil), req)
+	if err != nil || resp != nil {
+		t.Fatalf("err:%s resp:%#v\n", err, resp)
+	}
+}
@@ -0,0 +1,368 @@
+// Package dbmigrate converts the storage of the standalone cassandra, mongodb
+// and mssql secrets engines into that of the database secrets engine.
+package dbmigrate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/logical"
+)
+
+// The parts of the database secrets engine storage written by the migration,
+// which cannot be imported from its package as it depends on the core.
+
+const secretCredsType = "creds"
+
+type databaseConfig struct {
+	PluginName        string                 `json:"plugin_name"`
+	ConnectionDetails map[string]interface{} `json:"connection_details"`
+	AllowedRoles      []string               `json:"allowed_roles"`
+}
+
+type statements struct {
+	Creation   []string `json:"creation,omitempty"`
+	Revocation []string `json:"revocation,omitempty"`
+	Rollback   []string `json:"rollback,omitempty"`
+}
+
+type roleEntry struct {
+	DBName     string        `json:"db_name"`
+	Statements statements    `json:"statements"`
+	DefaultTTL time.Duration `json:"default_ttl"`
+	MaxTTL     time.Duration `json:"max_ttl"`
+}
+
+// legacyPluginNames maps the types of the standalone database secrets
+// engines that can be migrated to the plugin serving them in the database
+// secrets engine
+var legacyPluginNames = map[string]string{
+	"cassandra": "cassandra-database-plugin",
+	"mongodb":   "mongodb-database-plugin",
+	"mssql":     "mssql-database-plugin",
+}
+
+// IsLegacyType returns whether mounts of the given type can be migrated to
+// the database secrets engine with MigrateLegacyStorage
+func IsLegacyType(mountType string) bool {
+	_, ok := legacyPluginNames[mountType]
+	return ok
+}
+
+// LegacyMigration describes the outcome of migrating the storage of a legacy
+// database secrets engine
+type LegacyMigration struct {
+	ConnectionName string
+	Roles          []string
+	Warnings       []string
+
+	legacyType string
+	revocation map[string][]string
+}
+
+// legacy storage formats, as written by the standalone engines
+
+type legacyCassandraConfig struct {
+	Hosts           string `json:"hosts"`
+	Username        string `json:"username"`
+	Password        string `json:"password"`
+	TLS             bool   `json:"tls"`
+	InsecureTLS     bool   `json:"insecure_tls"`
+	Certificate     string `json:"certificate"`
+	PrivateKey      string `json:"private_key"`
+	IssuingCA       string `json:"issuing_ca"`
+	ProtocolVersion int    `json:"protocol_version"`
+	ConnectTimeout  int    `json:"connect_timeout"`
+	TLSMinVersion   string `json:"tls_min_version"`
+}
+
+type legacyCassandraRole struct {
+	CreationCQL string        `json:"creation_cql"`
+	Lease       time.Duration `json:"lease"`
+	RollbackCQL string        `json:"rollback_cql"`
+	Consistency string        `json:"consistency"`
+}
+
+type legacyMongoDBConfig struct {
+	URI string `json:"uri"`
+}
+
+type legacyMongoDBRole struct {
+	DB    string `json:"db"`
+	Roles []struct {
+		Role string `json:"role"`
+		DB   string `json:"db"`
+	} `json:"roles"`
+}
+
+type legacyMSSQLConfig struct {
+	ConnectionString   string `json:"connection_string"`
+	MaxOpenConnections int    `json:"max_open_connections"`
+}
+
+type legacyMSSQLRole struct {
+	SQL string `json:"sql"`
+}
+
+// The lease configuration of the mongodb and mssql engines, which only
+// differ in the name of the maximum TTL field
+type legacyLeaseConfig struct {
+	TTL    time.Duration
+	MaxTTL time.Duration
+	TTLMax time.Duration
+}
+
+// MigrateLegacyStorage converts the storage of a cassandra, mongodb or mssql
+// secrets engine into a connection with the given name and the equivalent
+// roles of the database secrets engine. The legacy entries are removed once read, so the
+// storage must not be used by the legacy backend afterwards.
+func MigrateLegacyStorage(ctx context.Context, s logical.Storage, legacyType, connName string) (*LegacyMigration, error) {
+	pluginName, ok := legacyPluginNames[legacyType]
+	if !ok {
+		return nil, fmt.Errorf("secrets engines of type %q cannot be migrated", legacyType)
+	}
+	if connName == "" {
+		return nil, fmt.Errorf("empty connection name")
+	}
+
+	m := &LegacyMigration{
+		ConnectionName: connName,
+		legacyType:     legacyType,
+		revocation:     make(map[string][]string),
+	}
+
+	// Read everything before writing anything, as both layouts keep their
+	// roles under the same prefix
+	connEntry, err := s.Get(ctx, "config/connection")
+	if err != nil {
+		return nil, err
+	}
+	if connEntry == nil {
+		return nil, fmt.Errorf("the %s secrets engine has no connection configured", legacyType)
+	}
+	var lease legacyLeaseConfig
+	leaseEntry, err := s.Get(ctx, "config/lease")
+	if err != nil {
+		return nil, err
+	}
+	if leaseEntry != nil {
+		if err := leaseEntry.DecodeJSON(&lease); err != nil {
+			return nil, errwrap.Wrapf("error decoding the lease configuration: {{err}}", err)
+		}
+		if lease.MaxTTL == 0 {
+			lease.MaxTTL = lease.TTLMax
+		}
+	}
+	roleNames, err := s.List(ctx, "role/")
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(roleNames)
+	roleEntries := make(map[string]*logical.StorageEntry, len(roleNames))
+	for _, name := range roleNames {
+		entry, err := s.Get(ctx, "role/"+name)
+		if err != nil {
+			return nil, err
+		}
+		if entry != nil {
+			roleEntries[name] = entry
+		}
+	}
+
+	config := &databaseConfig{
+		PluginName:        pluginName,
+		ConnectionDetails: make(map[string]interface{}),
+	}
+	roles := make(map[string]*roleEntry, len(roleEntries))
+	for name := range roleEntries {
+		roles[name] = &roleEntry{
+			DBName:     connName,
+			DefaultTTL: lease.TTL,
+			MaxTTL:     lease.MaxTTL,
+		}
+		m.Roles = append(m.Roles, name)
+	}
+	sort.Strings(m.Roles)
+
+	switch legacyType {
+	case "cassandra":
+		var conn legacyCassandraConfig
+		if err := connEntry.DecodeJSON(&conn); err != nil {
+			return nil, errwrap.Wrapf("error decoding the connection configuration: {{err}}", err)
+		}
+		config.ConnectionDetails["hosts"] = conn.Hosts
+		config.ConnectionDetails["username"] = conn.Username
+		config.ConnectionDetails["password"] = conn.Password
+		config.ConnectionDetails["tls"] = conn.TLS
+		config.ConnectionDetails["insecure_tls"] = conn.InsecureTLS
+		config.ConnectionDetails["protocol_version"] = conn.ProtocolVersion
+		config.ConnectionDetails["connect_timeout"] = conn.ConnectTimeout
+		if conn.TLSMinVersion != "" {
+			config.ConnectionDetails["tls_min_version"] = conn.TLSMinVersion
+		}
+		if conn.Certificate != "" || conn.IssuingCA != "" {
+			var pemBundle []string
+			for _, block := range []string{conn.Certificate, conn.PrivateKey, conn.IssuingCA} {
+				if block != "" {
+					pemBundle = append(pemBundle, strings.TrimSpace(block))
+				}
+			}
+			config.ConnectionDetails["pem_bundle"] = strings.Join(pemBundle, "\n")
+		}
+
+		// Consistency is set per connection rather than per role
+		consistencies := make(map[string]bool)
+		for _, name := range m.Roles {
+			var legacy legacyCassandraRole
+			if err := roleEntries[name].DecodeJSON(&legacy); err != nil {
+				return nil, errwrap.Wrapf(fmt.Sprintf("error decoding role %q: {{err}}", name), err)
+			}
+			role := roles[name]
+			role.Statements.Creation = []string{legacy.CreationCQL}
+			if legacy.RollbackCQL != "" {
+				role.Statements.Rollback = []string{legacy.RollbackCQL}
+			}
+			role.DefaultTTL = legacy.Lease
+			if legacy.Consistency != "" {
+				consistencies[legacy.Consistency] = true
+			}
+		}
+		switch len(consistencies) {
+		case 0:
+		case 1:
+			for consistency := range consistencies {
+				config.ConnectionDetails["consistency"] = consistency
+			}
+		default:
+			m.Warnings = append(m.Warnings, "roles used different consistency levels, which the database secrets engine can only set per connection; the default consistency level will be used")
+		}
+
+	case "mongodb":
+		var conn legacyMongoDBConfig
+		if err := connEntry.DecodeJSON(&conn); err != nil {
+			return nil, errwrap.Wrapf("error decoding the connection configuration: {{err}}", err)
+		}
+		config.ConnectionDetails["connection_url"] = conn.URI
+
+		for _, name := range m.Roles {
+			var legacy legacyMongoDBRole
+			if err := roleEntries[name].DecodeJSON(&legacy); err != nil {
+				return nil, errwrap.Wrapf(fmt.Sprintf("error decoding role %q: {{err}}", name), err)
+			}
+			creation, err := json.Marshal(legacy)
+			if err != nil {
+				return nil, err
+			}
+			revocation, err := json.Marshal(map[string]string{"db": legacy.DB})
+			if err != nil {
+				return nil, err
+			}
+			role := roles[name]
+			role.Statements.Creation = []string{string(creation)}
+			role.Statements.Revocation = []string{string(revocation)}
+		}
+
+	case "mssql":
+		var conn legacyMSSQLConfig
+		if err := connEntry.DecodeJSON(&conn); err != nil {
+			return nil, errwrap.Wrapf("error decoding the connection configuration: {{err}}", err)
+		}
+		config.ConnectionDetails["connection_url"] = conn.ConnectionString
+		if conn.MaxOpenConnections != 0 {
+			config.ConnectionDetails["max_open_connections"] = conn.MaxOpenConnections
+		}
+
+		for _, name := range m.Roles {
+			var legacy legacyMSSQLRole
+			if err := roleEntries[name].DecodeJSON(&legacy); err != nil {
+				return nil, errwrap.Wrapf(fmt.Sprintf("error decoding role %q: {{err}}", name), err)
+			}
+			roles[name].Statements.Creation = []string{legacy.SQL}
+		}
+	}
+
+	config.AllowedRoles = m.Roles
+	if len(config.AllowedRoles) == 0 {
+		config.AllowedRoles = []string{"*"}
+	}
+	for name, role := range roles {
+		m.revocation[name] = role.Statements.Revocation
+	}
+
+	// Remove the legacy entries, then write the new ones
+	for _, key := range []string{"config/connection", "config/lease"} {
+		if err := s.Delete(ctx, key); err != nil {
+			return nil, err
+		}
+	}
+	for _, name := range roleNames {
+		if err := s.Delete(ctx, "role/"+name); err != nil {
+			return nil, err
+		}
+	}
+
+	entry, err := logical.StorageEntryJSON(fmt.Sprintf("config/%s", connName), config)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+	for _, name := range m.Roles {
+		entry, err := logical.StorageEntryJSON("role/"+name, roles[name])
+		if err != nil {
+			return nil, err
+		}
+		if err := s.Put(ctx, entry); err != nil {
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
+
+// MigrateSecret rewrites the internal data of a secret issued by the legacy
+// backend at the given path, relative to the mount, so that it can be renewed
+// and revoked by the database secrets engine. It returns false if the secret was not issued
+// for credentials.
+func (m *LegacyMigration) MigrateSecret(path string, secret *logical.Secret) bool {
+	if secret == nil || secret.InternalData == nil {
+		return false
+	}
+	username, ok := secret.InternalData["username"].(string)
+	if !ok {
+		return false
+	}
+
+	// Only the cassandra engine kept the role in the secret
+	roleName, _ := secret.InternalData["role"].(string)
+	if roleName == "" {
+		if !strings.HasPrefix(path, "creds/") {
+			return false
+		}
+		roleName = strings.TrimPrefix(path, "creds/")
+	}
+
+	revocation := m.revocation[roleName]
+	if db, ok := secret.InternalData["db"].(string); ok && m.legacyType == "mongodb" {
+		// Revoke from the database the user was created in, even if the
+		// role has changed since
+		if statement, err := json.Marshal(map[string]string{"db": db}); err == nil {
+			revocation = []string{string(statement)}
+		}
+	}
+
+	secret.InternalData = map[string]interface{}{
+		"secret_type":           secretCredsType,
+		"username":              username,
+		"role":                  roleName,
+		"db_name":               m.ConnectionName,
+		"revocation_statements": revocation,
+	}
+	return true
+}
@@ -0,0 +1,40 @@
+package dbmigrate
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/vault/logical"
+)
+
+func TestLegacyMigration_MigrateSecret(t *testing.T) {
+	m := &LegacyMigration{
+		ConnectionName: "mssql",
+		legacyType:     "mssql",
+		revocation:     map[string][]string{},
+	}
+	secret := &logical.Secret{
+		InternalData: map[string]interface{}{
+			"secret_type": "creds",
+			"username":    "vault-user",
+		},
+	}
+	if !m.MigrateSecret("creds/web", secret) {
+		t.Fatal("expected the secret to be migrated")
+	}
+	expected := map[string]interface{}{
+		"secret_type":           secretCredsType,
+		"username":              "vault-user",
+		"role":                  "web",
+		"db_name":               "mssql",
+		"revocation_statements": []string(nil),
+	}
+	if !reflect.DeepEqual(secret.InternalData, expected) {
+		t.Fatalf("expected %#v, got %#v", expected, secret.InternalData)
+	}
+
+	// Secrets not issued for credentials are left alone
+	if m.MigrateSecret("creds/web", &logical.Secret{InternalData: map[string]interface{}{}}) {
+		t.Fatal("expected the secret not to be migrated")
+	}
+}
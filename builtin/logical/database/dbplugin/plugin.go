@@ -31,6 +31,43 @@ type Database interface {
 	Initialize(ctx context.Context, config map[string]interface{}, verifyConnection bool) (err error)
 }
 
+// PoolStats is the utilization of the connection pool of a database.
+type PoolStats struct {
+	MaxOpenConnections int
+	OpenConnections    int
+	InUse              int
+	Idle               int
+	WaitCount          int64
+	WaitDuration       time.Duration
+}
+
+// PoolStatsReporter is implemented by the databases which pool their
+// connections. Only builtin plugins run in the Vault process, so the pool of
+// external plugins can't be reported.
+type PoolStatsReporter interface {
+	// PoolStats returns false when no connection was made yet.
+	PoolStats() (PoolStats, bool)
+}
+
+// PoolStatsOf returns the pool utilization of a database built by
+// PluginFactory, looking through the middlewares it is wrapped in.
+func PoolStatsOf(db Database) (PoolStats, bool) {
+	for {
+		switch mw := db.(type) {
+		case *databaseTracingMiddleware:
+			db = mw.next
+		case *databaseMetricsMiddleware:
+			db = mw.next
+		case *DatabaseErrorSanitizerMiddleware:
+			db = mw.next
+		case PoolStatsReporter:
+			return mw.PoolStats()
+		default:
+			return PoolStats{}, false
+		}
+	}
+}
+
 // PluginFactory is used to build plugin database types. It wraps the database
 // object in a logging and metrics middleware.
 func PluginFactory(ctx context.Context, pluginName string, sys pluginutil.LookRunnerUtil, logger log.Logger) (Database, error) {
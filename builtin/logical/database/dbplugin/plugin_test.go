@@ -87,6 +87,28 @@ func (m *mockPlugin) Close() error {
 	return nil
 }
 
+// mockPoolPlugin is a mockPlugin pooling its connections
+type mockPoolPlugin struct {
+	mockPlugin
+}
+
+func (m *mockPoolPlugin) PoolStats() (dbplugin.PoolStats, bool) {
+	return dbplugin.PoolStats{MaxOpenConnections: 4, InUse: 3}, true
+}
+
+func TestPlugin_PoolStatsOf(t *testing.T) {
+	db := dbplugin.NewDatabaseErrorSanitizerMiddleware(&mockPoolPlugin{}, nil)
+	stats, ok := dbplugin.PoolStatsOf(db)
+	if !ok || stats.MaxOpenConnections != 4 || stats.InUse != 3 {
+		t.Fatalf("bad: %#v %t", stats, ok)
+	}
+
+	db = dbplugin.NewDatabaseErrorSanitizerMiddleware(&mockPlugin{}, nil)
+	if _, ok := dbplugin.PoolStatsOf(db); ok {
+		t.Fatal("expected no pool stats")
+	}
+}
+
 func getCluster(t *testing.T) (*vault.TestCluster, logical.SystemView) {
 	cluster := vault.NewTestCluster(t, nil, &vault.TestClusterOptions{
 		HandlerFunc: vaulthttp.Handler,
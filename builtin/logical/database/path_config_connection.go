@@ -224,6 +224,8 @@ func (b *databaseBackend) connectionDeleteHandler() framework.OperationFunc {
 			return nil, err
 		}
 
+		b.health.remove(name)
+
 		return nil, nil
 	}
 }
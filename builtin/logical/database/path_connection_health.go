@@ -0,0 +1,265 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	metrics "github.com/armon/go-metrics"
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/builtin/logical/database/dbplugin"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+const (
+	// healthCheckInterval is the minimum amount of time between two
+	// background health checks of the same connection.
+	healthCheckInterval = 5 * time.Minute
+
+	// healthCheckTimeout bounds a single connection verification.
+	healthCheckTimeout = 30 * time.Second
+)
+
+// connectionHealth is the in-memory result of the latest health checks of a
+// database connection.
+type connectionHealth struct {
+	LastCheck        time.Time
+	LastSuccess      time.Time
+	LastError        string
+	LastErrorTime    time.Time
+	ConsecutiveFails int
+}
+
+// healthChecker tracks the health of the configured connections. Status is
+// kept in memory only, it is rebuilt by the periodic check after a restart.
+type healthChecker struct {
+	sync.Mutex
+	status map[string]*connectionHealth
+}
+
+func newHealthChecker() *healthChecker {
+	return &healthChecker{
+		status: make(map[string]*connectionHealth),
+	}
+}
+
+func (h *healthChecker) record(name string, now time.Time, err error) connectionHealth {
+	h.Lock()
+	defer h.Unlock()
+
+	status, ok := h.status[name]
+	if !ok {
+		status = &connectionHealth{}
+		h.status[name] = status
+	}
+
+	status.LastCheck = now
+	if err != nil {
+		status.LastError = err.Error()
+		status.LastErrorTime = now
+		status.ConsecutiveFails++
+	} else {
+		status.LastSuccess = now
+		status.ConsecutiveFails = 0
+	}
+
+	return *status
+}
+
+// due reports whether the connection has not been checked within the
+// health check interval.
+func (h *healthChecker) due(name string, now time.Time) bool {
+	h.Lock()
+	defer h.Unlock()
+
+	status, ok := h.status[name]
+	return !ok || now.Sub(status.LastCheck) >= healthCheckInterval
+}
+
+func (h *healthChecker) remove(name string) {
+	h.Lock()
+	defer h.Unlock()
+
+	delete(h.status, name)
+}
+
+func pathConnectionHealth(b *databaseBackend) *framework.Path {
+	return &framework.Path{
+		Pattern: fmt.Sprintf("config/%s/health", framework.GenericNameRegex("name")),
+		Fields: map[string]*framework.FieldSchema{
+			"name": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Name of this database connection",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation: b.pathConnectionHealthRead,
+		},
+
+		HelpSynopsis:    pathConnectionHealthHelpSyn,
+		HelpDescription: pathConnectionHealthHelpDesc,
+	}
+}
+
+func (b *databaseBackend) pathConnectionHealthRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	name := data.Get("name").(string)
+	if name == "" {
+		return logical.ErrorResponse(respErrEmptyName), nil
+	}
+
+	entry, err := req.Storage.Get(ctx, fmt.Sprintf("config/%s", name))
+	if err != nil {
+		return nil, errwrap.Wrapf("failed to read connection configuration: {{err}}", err)
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	status, checkErr := b.checkConnectionHealth(ctx, req.Storage, name)
+
+	resp := &logical.Response{
+		Data: map[string]interface{}{
+			"healthy":           checkErr == nil,
+			"last_check":        status.LastCheck.Format(time.RFC3339Nano),
+			"consecutive_fails": status.ConsecutiveFails,
+		},
+	}
+	if !status.LastSuccess.IsZero() {
+		resp.Data["last_success"] = status.LastSuccess.Format(time.RFC3339Nano)
+	}
+	if !status.LastErrorTime.IsZero() {
+		resp.Data["last_error"] = status.LastError
+		resp.Data["last_error_time"] = status.LastErrorTime.Format(time.RFC3339Nano)
+	}
+	if stats, ok := b.poolStats(name); ok {
+		resp.Data["pool"] = map[string]interface{}{
+			"max_open_connections": stats.MaxOpenConnections,
+			"open_connections":     stats.OpenConnections,
+			"in_use":               stats.InUse,
+			"idle":                 stats.Idle,
+			"wait_count":           stats.WaitCount,
+			"wait_duration_ms":     int64(stats.WaitDuration / time.Millisecond),
+		}
+	}
+
+	return resp, nil
+}
+
+// poolStats returns the utilization of the connection pool of the named
+// connection, if it is in use and its plugin reports it.
+func (b *databaseBackend) poolStats(name string) (dbplugin.PoolStats, bool) {
+	b.RLock()
+	db, ok := b.connections[name]
+	b.RUnlock()
+	if !ok {
+		return dbplugin.PoolStats{}, false
+	}
+
+	return dbplugin.PoolStatsOf(db.Database)
+}
+
+// emitPoolMetrics reports the utilization of the connection pools of the
+// connections in use.
+func (b *databaseBackend) emitPoolMetrics() {
+	b.RLock()
+	names := make([]string, 0, len(b.connections))
+	for name := range b.connections {
+		names = append(names, name)
+	}
+	b.RUnlock()
+
+	for _, name := range names {
+		stats, ok := b.poolStats(name)
+		if !ok {
+			continue
+		}
+
+		gauge := func(metric string, val float32) {
+			metrics.SetGauge([]string{"database", "pool", name, metric}, val)
+		}
+		gauge("max_open_connections", float32(stats.MaxOpenConnections))
+		gauge("open_connections", float32(stats.OpenConnections))
+		gauge("in_use", float32(stats.InUse))
+		gauge("idle", float32(stats.Idle))
+		gauge("wait_count", float32(stats.WaitCount))
+		gauge("wait_duration_ms", float32(stats.WaitDuration/time.Millisecond))
+	}
+}
+
+// checkConnectionHealth verifies the named connection with a new plugin
+// instance, so the cached connection used to serve requests is left
+// untouched, and records the outcome.
+func (b *databaseBackend) checkConnectionHealth(ctx context.Context, s logical.Storage, name string) (connectionHealth, error) {
+	err := b.verifyConnection(ctx, s, name)
+	if err != nil {
+		b.logger.Warn("database connection health check failed", "connection", name, "error", err)
+	}
+
+	return b.health.record(name, time.Now(), err), err
+}
+
+func (b *databaseBackend) verifyConnection(ctx context.Context, s logical.Storage, name string) error {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	config, err := b.DatabaseConfig(ctx, s, name)
+	if err != nil {
+		return err
+	}
+
+	dbp, err := dbplugin.PluginFactory(ctx, config.PluginName, b.System(), b.logger)
+	if err != nil {
+		return err
+	}
+	defer dbp.Close()
+
+	if _, err := dbp.Init(ctx, config.ConnectionDetails, true); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// periodicFunc is invoked once a minute by the RollbackManager and reports
+// the utilization of the connection pools.
+func (b *databaseBackend) periodicFunc(ctx context.Context, req *logical.Request) error {
+	b.emitPoolMetrics()
+	return nil
+}
+
+// periodicHealthCheck checks the health of every connection not checked
+// within healthCheckInterval, on the "health_check" schedule, so that broken
+// connections are noticed before credential requests fail.
+func (b *databaseBackend) periodicHealthCheck(ctx context.Context, req *logical.Request) error {
+	names, err := req.Storage.List(ctx, "config/")
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, name := range names {
+		if !b.health.due(name, now) {
+			continue
+		}
+		b.checkConnectionHealth(ctx, req.Storage, name)
+	}
+
+	return nil
+}
+
+const pathConnectionHealthHelpSyn = `
+Check the health of a database connection.
+`
+
+const pathConnectionHealthHelpDesc = `
+Reading this endpoint verifies the named connection by starting a new
+instance of its plugin and connecting to the database with the stored
+configuration. The result of this check is returned together with the time
+of the last successful check and the last error seen, which are also
+updated by a background check that runs every five minutes for each
+connection. When the connection is in use and its plugin pools connections,
+the utilization of the pool is returned as well.
+`
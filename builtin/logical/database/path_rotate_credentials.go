@@ -28,7 +28,7 @@ func pathRotateCredentials(b *databaseBackend) *framework.Path {
 }
 
 func (b *databaseBackend) pathRotateCredentialsUpdate() framework.OperationFunc {
-	return func(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	return func(ctx context.Context, req *logical.Request, data *framework.FieldData) (resp *logical.Response, err error) {
 		name := data.Get("name").(string)
 		if name == "" {
 			return logical.ErrorResponse(respErrEmptyName), nil
@@ -39,6 +39,11 @@ func (b *databaseBackend) pathRotateCredentialsUpdate() framework.OperationFunc
 			return nil, err
 		}
 
+		// Record the outcome of the rotation attempt in the rotation history
+		defer func() {
+			b.recordRotation(ctx, req, rotationTypeRoot, name, "", err)
+		}()
+
 		db, err := b.GetConnection(ctx, req.Storage, name)
 		if err != nil {
 			return nil, err
@@ -0,0 +1,317 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	uuid "github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+const (
+	rotationHistoryPrefix     = "rotation-history/"
+	rotationHistoryConfigPath = "rotation-history-config"
+
+	// defaultRotationHistoryRetention is the amount of time rotation records
+	// are kept when no retention has been configured.
+	defaultRotationHistoryRetention = 90 * 24 * time.Hour
+
+	rotationTypeRoot = "root"
+)
+
+// rotationRecord is a single persisted credential rotation attempt.
+type rotationRecord struct {
+	ID          string    `json:"id"`
+	Time        time.Time `json:"time"`
+	Type        string    `json:"type"`
+	Connection  string    `json:"connection"`
+	Role        string    `json:"role,omitempty"`
+	Requester   string    `json:"requester"`
+	EntityID    string    `json:"entity_id,omitempty"`
+	Success     bool      `json:"success"`
+	ErrorString string    `json:"error,omitempty"`
+}
+
+type rotationHistoryConfig struct {
+	Retention time.Duration `json:"retention"`
+}
+
+func pathRotationHistory(b *databaseBackend) *framework.Path {
+	return &framework.Path{
+		Pattern: "rotations/?$",
+		Fields: map[string]*framework.FieldSchema{
+			"connection": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "If set, only rotations of this database connection are returned.",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation: b.pathRotationHistoryRead,
+		},
+
+		HelpSynopsis:    pathRotationHistoryHelpSyn,
+		HelpDescription: pathRotationHistoryHelpDesc,
+	}
+}
+
+func pathRotationHistoryConfig(b *databaseBackend) *framework.Path {
+	return &framework.Path{
+		Pattern: "rotations/config",
+		Fields: map[string]*framework.FieldSchema{
+			"retention": &framework.FieldSchema{
+				Type: framework.TypeDurationSecond,
+				Description: `Amount of time rotation records are kept before
+				being removed. Defaults to 90 days.`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation:   b.pathRotationHistoryConfigRead,
+			logical.UpdateOperation: b.pathRotationHistoryConfigWrite,
+		},
+
+		HelpSynopsis:    pathRotationHistoryConfigHelpSyn,
+		HelpDescription: pathRotationHistoryConfigHelpDesc,
+	}
+}
+
+func (b *databaseBackend) pathRotationHistoryRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	config, err := b.rotationHistoryConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := b.rotationHistory(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	connection := data.Get("connection").(string)
+
+	// Records are removed periodically, so the ones past the retention but
+	// not removed yet are skipped
+	cutoff := time.Now().Add(-config.Retention)
+
+	rotations := make([]map[string]interface{}, 0, len(records))
+	for _, record := range records {
+		if connection != "" && record.Connection != connection {
+			continue
+		}
+		if record.Time.Before(cutoff) {
+			continue
+		}
+
+		entry := map[string]interface{}{
+			"id":         record.ID,
+			"time":       record.Time.Format(time.RFC3339Nano),
+			"type":       record.Type,
+			"connection": record.Connection,
+			"requester":  record.Requester,
+			"success":    record.Success,
+		}
+		if record.Role != "" {
+			entry["role"] = record.Role
+		}
+		if record.EntityID != "" {
+			entry["entity_id"] = record.EntityID
+		}
+		if record.ErrorString != "" {
+			entry["error"] = record.ErrorString
+		}
+		rotations = append(rotations, entry)
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"rotations": rotations,
+		},
+	}, nil
+}
+
+func (b *databaseBackend) pathRotationHistoryConfigRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	config, err := b.rotationHistoryConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"retention": int64(config.Retention.Seconds()),
+		},
+	}, nil
+}
+
+func (b *databaseBackend) pathRotationHistoryConfigWrite(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	config, err := b.rotationHistoryConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	if retentionRaw, ok := data.GetOk("retention"); ok {
+		config.Retention = time.Duration(retentionRaw.(int)) * time.Second
+	}
+	if config.Retention <= 0 {
+		return logical.ErrorResponse("retention must be greater than zero"), nil
+	}
+
+	entry, err := logical.StorageEntryJSON(rotationHistoryConfigPath, config)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	return nil, b.pruneRotationHistory(ctx, req.Storage)
+}
+
+func (b *databaseBackend) rotationHistoryConfig(ctx context.Context, s logical.Storage) (*rotationHistoryConfig, error) {
+	entry, err := s.Get(ctx, rotationHistoryConfigPath)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &rotationHistoryConfig{
+		Retention: defaultRotationHistoryRetention,
+	}
+	if entry == nil {
+		return config, nil
+	}
+
+	if err := entry.DecodeJSON(config); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// recordRotation persists the outcome of a credential rotation. Failing to
+// record a rotation is logged rather than returned so that it never masks the
+// result of the rotation itself.
+func (b *databaseBackend) recordRotation(ctx context.Context, req *logical.Request, rotationType, connection, role string, rotationErr error) {
+	id, err := uuid.GenerateUUID()
+	if err != nil {
+		b.Logger().Error("failed to generate rotation record ID", "error", err)
+		return
+	}
+
+	now := time.Now().UTC()
+	record := &rotationRecord{
+		ID:         id,
+		Time:       now,
+		Type:       rotationType,
+		Connection: connection,
+		Role:       role,
+		Requester:  req.DisplayName,
+		EntityID:   req.EntityID,
+		Success:    rotationErr == nil,
+	}
+	if rotationErr != nil {
+		record.ErrorString = rotationErr.Error()
+	}
+
+	// Keys are prefixed with the zero-padded timestamp so that listing the
+	// history returns the records in chronological order.
+	key := fmt.Sprintf("%s%020d-%s", rotationHistoryPrefix, now.UnixNano(), id)
+	entry, err := logical.StorageEntryJSON(key, record)
+	if err != nil {
+		b.Logger().Error("failed to encode rotation record", "error", err)
+		return
+	}
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		b.Logger().Error("failed to persist rotation record", "connection", connection, "error", err)
+	}
+}
+
+// rotationHistory returns all stored rotation records, most recent first.
+func (b *databaseBackend) rotationHistory(ctx context.Context, s logical.Storage) ([]*rotationRecord, error) {
+	keys, err := s.List(ctx, rotationHistoryPrefix)
+	if err != nil {
+		return nil, err
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(keys)))
+
+	records := make([]*rotationRecord, 0, len(keys))
+	for _, key := range keys {
+		entry, err := s.Get(ctx, rotationHistoryPrefix+key)
+		if err != nil {
+			return nil, err
+		}
+		if entry == nil {
+			continue
+		}
+
+		var record rotationRecord
+		if err := entry.DecodeJSON(&record); err != nil {
+			return nil, err
+		}
+		records = append(records, &record)
+	}
+
+	return records, nil
+}
+
+// periodicPruneRotationHistory prunes the rotation history on the
+// "prune_rotation_history" schedule.
+func (b *databaseBackend) periodicPruneRotationHistory(ctx context.Context, req *logical.Request) error {
+	return b.pruneRotationHistory(ctx, req.Storage)
+}
+
+// pruneRotationHistory deletes the records older than the configured
+// retention.
+func (b *databaseBackend) pruneRotationHistory(ctx context.Context, s logical.Storage) error {
+	config, err := b.rotationHistoryConfig(ctx, s)
+	if err != nil {
+		return err
+	}
+
+	keys, err := s.List(ctx, rotationHistoryPrefix)
+	if err != nil {
+		return err
+	}
+
+	cutoff := time.Now().Add(-config.Retention).UnixNano()
+	for _, key := range keys {
+		var nanos int64
+		if _, err := fmt.Sscanf(strings.SplitN(key, "-", 2)[0], "%d", &nanos); err != nil {
+			continue
+		}
+		if nanos >= cutoff {
+			continue
+		}
+		if err := s.Delete(ctx, rotationHistoryPrefix+key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+const pathRotationHistoryHelpSyn = `
+Read the history of credential rotations performed by this backend.
+`
+
+const pathRotationHistoryHelpDesc = `
+This path returns the persisted record of every credential rotation attempted
+by this backend, most recent first. Each record contains the time of the
+rotation, the connection and role involved, the display name and entity of the
+requester, and whether the rotation succeeded. Records older than the retention
+configured at "rotations/config" are not returned, and are removed on the
+"prune_rotation_history" schedule of "config/schedule", hourly by default.
+`
+
+const pathRotationHistoryConfigHelpSyn = `
+Configure how long credential rotation records are kept.
+`
+
+const pathRotationHistoryConfigHelpDesc = `
+This path configures the retention of the records returned by the "rotations/"
+endpoint. Records older than the retention period are removed when a new
+retention period is set, and on the "prune_rotation_history" schedule of
+"config/schedule", hourly by default.
+`
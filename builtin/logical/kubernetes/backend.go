@@ -0,0 +1,77 @@
+package kubernetes
+
+import (
+	"context"
+	"strings"
+
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+// Factory returns a Kubernetes backend that satisfies the logical.Backend
+// interface
+func Factory(ctx context.Context, conf *logical.BackendConfig) (logical.Backend, error) {
+	b := Backend()
+	if err := b.Setup(ctx, conf); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Backend returns the Kubernetes backend
+func Backend() *backend {
+	var b backend
+	b.Backend = &framework.Backend{
+		Help: strings.TrimSpace(backendHelp),
+
+		PathsSpecial: &logical.Paths{
+			SealWrapStorage: []string{
+				"config",
+			},
+		},
+
+		Paths: []*framework.Path{
+			pathConfig(&b),
+			framework.PathConfigVerify("config", b.verifyConfig),
+			pathListRoles(&b),
+			pathRoles(&b),
+			pathCreds(&b),
+		},
+
+		Secrets: []*framework.Secret{
+			secretServiceAccountToken(&b),
+		},
+		BackendType: logical.TypeLogical,
+	}
+
+	return &b
+}
+
+type backend struct {
+	*framework.Backend
+}
+
+// client returns a client of the Kubernetes API using the stored
+// configuration
+func (b *backend) client(ctx context.Context, s logical.Storage) (*client, error) {
+	conf, err := b.readConfig(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+	if conf == nil {
+		return nil, errNotConfigured
+	}
+
+	return clientFromConfig(conf)
+}
+
+const backendHelp = `
+The Kubernetes backend mints short-lived Kubernetes service account tokens.
+
+Roles either name an existing service account to mint tokens for, or a
+Kubernetes Role or ClusterRole for which a service account is created and
+bound with every set of credentials, then deleted when their lease is revoked.
+After configuring the connection to the Kubernetes API with the "config" path,
+create roles with the "roles/" endpoints and request tokens with the "creds/"
+endpoints.
+`
@@ -0,0 +1,307 @@
+package kubernetes
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/logical"
+	authv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// testKubernetesAPI is a fake of the parts of the Kubernetes API used by the
+// backend
+type testKubernetesAPI struct {
+	l               sync.Mutex
+	serviceAccounts map[string]bool
+	roleBindings    map[string]*roleBinding
+	tokenRequests   []*authv1.TokenRequest
+}
+
+func (a *testKubernetesAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	a.l.Lock()
+	defer a.l.Unlock()
+
+	if r.Header.Get("Authorization") != "Bearer test-jwt" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	// Paths are /api/v1/namespaces/<ns>/serviceaccounts[/<name>[/token]] and
+	// /apis/rbac.authorization.k8s.io/v1/namespaces/<ns>/rolebindings[/<name>]
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case r.URL.Path == "/version":
+		w.Write([]byte(`{"major": "1", "minor": "12"}`))
+
+	case len(parts) == 5 && parts[4] == "serviceaccounts" && r.Method == http.MethodPost:
+		var sa serviceAccount
+		json.NewDecoder(r.Body).Decode(&sa)
+		a.serviceAccounts[parts[3]+"/"+sa.Name] = true
+		w.WriteHeader(http.StatusCreated)
+
+	case len(parts) == 6 && parts[4] == "serviceaccounts" && r.Method == http.MethodDelete:
+		if !a.serviceAccounts[parts[3]+"/"+parts[5]] {
+			a.notFound(w)
+			return
+		}
+		delete(a.serviceAccounts, parts[3]+"/"+parts[5])
+
+	case len(parts) == 7 && parts[6] == "token" && r.Method == http.MethodPost:
+		if !a.serviceAccounts[parts[3]+"/"+parts[5]] {
+			a.notFound(w)
+			return
+		}
+		var tr authv1.TokenRequest
+		json.NewDecoder(r.Body).Decode(&tr)
+		a.tokenRequests = append(a.tokenRequests, &tr)
+		tr.Status.Token = "token-of-" + parts[5]
+		tr.Status.ExpirationTimestamp = metav1.NewTime(time.Now().Add(time.Duration(*tr.Spec.ExpirationSeconds) * time.Second))
+		json.NewEncoder(w).Encode(&tr)
+
+	case len(parts) == 6 && parts[5] == "rolebindings" && r.Method == http.MethodPost:
+		var rb roleBinding
+		json.NewDecoder(r.Body).Decode(&rb)
+		a.roleBindings[parts[4]+"/"+rb.Name] = &rb
+		w.WriteHeader(http.StatusCreated)
+
+	case len(parts) == 7 && parts[5] == "rolebindings" && r.Method == http.MethodDelete:
+		if a.roleBindings[parts[4]+"/"+parts[6]] == nil {
+			a.notFound(w)
+			return
+		}
+		delete(a.roleBindings, parts[4]+"/"+parts[6])
+
+	default:
+		a.notFound(w)
+	}
+}
+
+func (a *testKubernetesAPI) notFound(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusNotFound)
+	json.NewEncoder(w).Encode(&metav1.Status{
+		TypeMeta: metav1.TypeMeta{Kind: "Status", APIVersion: "v1"},
+		Status:   metav1.StatusFailure,
+		Reason:   metav1.StatusReasonNotFound,
+		Code:     http.StatusNotFound,
+	})
+}
+
+func testBackend(t *testing.T) (*backend, logical.Storage, *testKubernetesAPI, func()) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+	b := Backend()
+	if err := b.Setup(context.Background(), config); err != nil {
+		t.Fatal(err)
+	}
+
+	api := &testKubernetesAPI{
+		serviceAccounts: map[string]bool{"default/existing": true},
+		roleBindings:    map[string]*roleBinding{},
+	}
+	server := httptest.NewServer(api)
+
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "config",
+		Storage:   config.StorageView,
+		Data: map[string]interface{}{
+			"kubernetes_host":     server.URL,
+			"service_account_jwt": "test-jwt",
+		},
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		server.Close()
+		t.Fatalf("err: %v, resp: %#v", err, resp)
+	}
+
+	return b, config.StorageView, api, server.Close
+}
+
+func TestBackend_Config(t *testing.T) {
+	b, storage, _, cleanup := testBackend(t)
+	defer cleanup()
+
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "config",
+		Storage:   storage,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := resp.Data["service_account_jwt"]; ok {
+		t.Fatal("expected the service account JWT not to be returned")
+	}
+
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "config/verify",
+		Storage:   storage,
+	})
+	if err != nil || resp.IsError() {
+		t.Fatalf("err: %v, resp: %#v", err, resp)
+	}
+}
+
+func TestBackend_Roles(t *testing.T) {
+	b, storage, _, cleanup := testBackend(t)
+	defer cleanup()
+
+	for _, data := range []map[string]interface{}{
+		{},
+		{"service_account_name": "existing", "kubernetes_role_name": "edit"},
+		{"kubernetes_role_name": "edit", "kubernetes_role_type": "Group"},
+		{"service_account_name": "existing", "token_default_ttl": "1m"},
+		{"service_account_name": "existing", "token_default_ttl": "2h", "token_max_ttl": "1h"},
+	} {
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation: logical.CreateOperation,
+			Path:      "roles/invalid",
+			Storage:   storage,
+			Data:      data,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp == nil || !resp.IsError() {
+			t.Fatalf("expected an error with %v", data)
+		}
+	}
+
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.CreateOperation,
+		Path:      "roles/edit",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"kubernetes_role_name":    "edit",
+			"kubernetes_role_type":    "ClusterRole",
+			"token_default_audiences": "vault,api",
+			"token_default_ttl":       "1h",
+		},
+	})
+	if err != nil || resp != nil {
+		t.Fatalf("err: %v, resp: %#v", err, resp)
+	}
+
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "roles/edit",
+		Storage:   storage,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Data["kubernetes_namespace"] != "default" || resp.Data["kubernetes_role_type"] != "ClusterRole" || resp.Data["token_default_ttl"] != int64(3600) {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+}
+
+func TestBackend_CredsExistingServiceAccount(t *testing.T) {
+	b, storage, api, cleanup := testBackend(t)
+	defer cleanup()
+
+	if _, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.CreateOperation,
+		Path:      "roles/existing",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"service_account_name":    "existing",
+			"token_default_audiences": "vault",
+			"token_max_ttl":           "1h",
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "creds/existing",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"ttl": "2h",
+		},
+	})
+	if err != nil || resp.IsError() {
+		t.Fatalf("err: %v, resp: %#v", err, resp)
+	}
+	if resp.Data["service_account_token"] != "token-of-existing" || resp.Data["service_account_name"] != "existing" {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+	if resp.Secret.TTL > time.Hour || resp.Secret.Renewable {
+		t.Fatalf("bad secret: %#v", resp.Secret)
+	}
+
+	tr := api.tokenRequests[0]
+	if *tr.Spec.ExpirationSeconds != 3600 || len(tr.Spec.Audiences) != 1 || tr.Spec.Audiences[0] != "vault" {
+		t.Fatalf("bad token request: %#v", tr.Spec)
+	}
+
+	// Revoking leaves the existing service account in place
+	if _, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.RevokeOperation,
+		Storage:   storage,
+		Secret:    resp.Secret,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if !api.serviceAccounts["default/existing"] {
+		t.Fatal("expected the service account to be kept")
+	}
+}
+
+func TestBackend_CredsGeneratedServiceAccount(t *testing.T) {
+	b, storage, api, cleanup := testBackend(t)
+	defer cleanup()
+
+	if _, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.CreateOperation,
+		Path:      "roles/Editors_Role",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"kubernetes_namespace": "apps",
+			"kubernetes_role_name": "edit",
+			"kubernetes_role_type": "ClusterRole",
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "creds/Editors_Role",
+		Storage:   storage,
+	})
+	if err != nil || resp.IsError() {
+		t.Fatalf("err: %v, resp: %#v", err, resp)
+	}
+
+	name := resp.Data["service_account_name"].(string)
+	if !strings.HasPrefix(name, "vault-editors-role-") || resp.Data["service_account_namespace"] != "apps" {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+	if !api.serviceAccounts["apps/"+name] {
+		t.Fatal("expected the service account to be created")
+	}
+	rb := api.roleBindings["apps/"+name]
+	if rb == nil || rb.RoleRef.Kind != "ClusterRole" || rb.RoleRef.Name != "edit" || rb.Subjects[0].Name != name {
+		t.Fatalf("bad role binding: %#v", rb)
+	}
+
+	if _, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.RevokeOperation,
+		Storage:   storage,
+		Secret:    resp.Secret,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if api.serviceAccounts["apps/"+name] || api.roleBindings["apps/"+name] != nil {
+		t.Fatal("expected the service account and its role binding to be deleted")
+	}
+}
@@ -0,0 +1,228 @@
+package kubernetes
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	cleanhttp "github.com/hashicorp/go-cleanhttp"
+	authv1 "k8s.io/api/authentication/v1"
+	kubeerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var errNotConfigured = errors.New("the Kubernetes backend is not configured")
+
+// serviceAccount is the subset of the Kubernetes ServiceAccount object used
+// by the backend
+type serviceAccount struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+}
+
+// roleBinding is the subset of the Kubernetes RoleBinding object used by the
+// backend
+type roleBinding struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Subjects          []roleBindingSubject `json:"subjects"`
+	RoleRef           roleBindingRoleRef   `json:"roleRef"`
+}
+
+type roleBindingSubject struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+type roleBindingRoleRef struct {
+	APIGroup string `json:"apiGroup"`
+	Kind     string `json:"kind"`
+	Name     string `json:"name"`
+}
+
+// client is a minimal client of the Kubernetes API
+type client struct {
+	host       string
+	jwt        string
+	httpClient *http.Client
+}
+
+func clientFromConfig(conf *kubeConfig) (*client, error) {
+	httpClient := cleanhttp.DefaultClient()
+
+	// If we have a CA cert build the TLSConfig
+	if conf.CACert != "" {
+		certPool := x509.NewCertPool()
+		if !certPool.AppendCertsFromPEM([]byte(conf.CACert)) {
+			return nil, errors.New("failed to parse the Kubernetes CA certificate")
+		}
+
+		httpClient.Transport.(*http.Transport).TLSClientConfig = &tls.Config{
+			MinVersion: tls.VersionTLS12,
+			RootCAs:    certPool,
+		}
+	}
+
+	return &client{
+		host:       strings.TrimSuffix(conf.Host, "/"),
+		jwt:        conf.ServiceAccountJWT,
+		httpClient: httpClient,
+	}, nil
+}
+
+// do sends a request to the Kubernetes API, encoding in as the body of the
+// request and decoding the body of the response into out, if not nil
+func (c *client) do(method, path string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		raw, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(raw)
+	}
+
+	req, err := http.NewRequest(method, c.host+path, body)
+	if err != nil {
+		return err
+	}
+	if c.jwt != "" {
+		req.Header.Set("Authorization", "Bearer "+c.jwt)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	// If the request was not a success return the kubernetes error
+	if resp.StatusCode < http.StatusOK || resp.StatusCode > http.StatusPartialContent {
+		errStatus := &metav1.Status{}
+		if err := json.Unmarshal(respBody, errStatus); err == nil && errStatus.Kind == "Status" {
+			return kubeerrors.FromObject(errStatus)
+		}
+		return kubeerrors.NewGenericServerResponse(resp.StatusCode, method, schema.GroupResource{}, "", strings.TrimSpace(string(respBody)), 0, true)
+	}
+
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(respBody, out)
+}
+
+// serverVersion checks that the Kubernetes API can be reached and that the
+// configured token is accepted
+func (c *client) serverVersion() error {
+	return c.do(http.MethodGet, "/version", nil, nil)
+}
+
+// createServiceAccountToken mints a token of the service account with the
+// TokenRequest API
+func (c *client) createServiceAccountToken(namespace, name string, audiences []string, ttl time.Duration) (*authv1.TokenRequest, error) {
+	expirationSeconds := int64(ttl.Seconds())
+	req := &authv1.TokenRequest{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "authentication.k8s.io/v1",
+			Kind:       "TokenRequest",
+		},
+		Spec: authv1.TokenRequestSpec{
+			Audiences:         audiences,
+			ExpirationSeconds: &expirationSeconds,
+		},
+	}
+
+	resp := &authv1.TokenRequest{}
+	path := fmt.Sprintf("/api/v1/namespaces/%s/serviceaccounts/%s/token", namespace, name)
+	if err := c.do(http.MethodPost, path, req, resp); err != nil {
+		return nil, err
+	}
+	if resp.Status.Token == "" {
+		return nil, errors.New("no token returned by the TokenRequest API")
+	}
+
+	return resp, nil
+}
+
+func (c *client) createServiceAccount(namespace, name string, labels map[string]string) error {
+	sa := &serviceAccount{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "ServiceAccount",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    labels,
+		},
+	}
+
+	return c.do(http.MethodPost, fmt.Sprintf("/api/v1/namespaces/%s/serviceaccounts", namespace), sa, nil)
+}
+
+// deleteServiceAccount deletes the service account, which invalidates its
+// tokens. Service accounts already deleted are ignored.
+func (c *client) deleteServiceAccount(namespace, name string) error {
+	err := c.do(http.MethodDelete, fmt.Sprintf("/api/v1/namespaces/%s/serviceaccounts/%s", namespace, name), nil, nil)
+	if kubeerrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// createRoleBinding binds the Role or ClusterRole to the service account in
+// the namespace
+func (c *client) createRoleBinding(namespace, name, roleType, roleName, serviceAccountName string, labels map[string]string) error {
+	rb := &roleBinding{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "rbac.authorization.k8s.io/v1",
+			Kind:       "RoleBinding",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    labels,
+		},
+		Subjects: []roleBindingSubject{
+			{
+				Kind:      "ServiceAccount",
+				Name:      serviceAccountName,
+				Namespace: namespace,
+			},
+		},
+		RoleRef: roleBindingRoleRef{
+			APIGroup: "rbac.authorization.k8s.io",
+			Kind:     roleType,
+			Name:     roleName,
+		},
+	}
+
+	return c.do(http.MethodPost, fmt.Sprintf("/apis/rbac.authorization.k8s.io/v1/namespaces/%s/rolebindings", namespace), rb, nil)
+}
+
+// deleteRoleBinding deletes the role binding. Role bindings already deleted
+// are ignored.
+func (c *client) deleteRoleBinding(namespace, name string) error {
+	err := c.do(http.MethodDelete, fmt.Sprintf("/apis/rbac.authorization.k8s.io/v1/namespaces/%s/rolebindings/%s", namespace, name), nil, nil)
+	if kubeerrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
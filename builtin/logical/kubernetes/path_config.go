@@ -0,0 +1,166 @@
+package kubernetes
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+const configPath = "config"
+
+func pathConfig(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "config$",
+		Fields: map[string]*framework.FieldSchema{
+			"kubernetes_host": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "URL of the base of the Kubernetes API server, e.g. https://192.168.99.100:8443.",
+			},
+
+			"kubernetes_ca_cert": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "PEM encoded CA cert for use by the TLS client used to talk with the Kubernetes API.",
+			},
+
+			"service_account_jwt": &framework.FieldSchema{
+				Type: framework.TypeString,
+				Description: `A service account JWT used to access the Kubernetes API. The
+service account must be allowed to create tokens of the service
+accounts of the roles, and to create and delete service accounts
+and role bindings for roles generating them.`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation:   b.pathConfigRead,
+			logical.UpdateOperation: b.pathConfigWrite,
+			logical.DeleteOperation: b.pathConfigDelete,
+		},
+
+		HelpSynopsis:    pathConfigHelpSyn,
+		HelpDescription: pathConfigHelpDesc,
+	}
+}
+
+func (b *backend) readConfig(ctx context.Context, s logical.Storage) (*kubeConfig, error) {
+	entry, err := s.Get(ctx, configPath)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	conf := &kubeConfig{}
+	if err := entry.DecodeJSON(conf); err != nil {
+		return nil, errwrap.Wrapf("error reading kubernetes configuration: {{err}}", err)
+	}
+
+	return conf, nil
+}
+
+// verifyConfig queries the version of the Kubernetes API server to check
+// that it can be reached and that the configured token is accepted
+func (b *backend) verifyConfig(ctx context.Context, s logical.Storage) error {
+	c, err := b.client(ctx, s)
+	if err != nil {
+		return err
+	}
+
+	if err := c.serverVersion(); err != nil {
+		return errwrap.Wrapf("error querying the kubernetes API: {{err}}", err)
+	}
+
+	return nil
+}
+
+func (b *backend) pathConfigRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	conf, err := b.readConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if conf == nil {
+		return nil, nil
+	}
+
+	// The service account JWT is not returned
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"kubernetes_host":    conf.Host,
+			"kubernetes_ca_cert": conf.CACert,
+		},
+	}, nil
+}
+
+func (b *backend) pathConfigWrite(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	conf, err := b.readConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if conf == nil {
+		conf = &kubeConfig{}
+	}
+
+	if host, ok := data.GetOk("kubernetes_host"); ok {
+		conf.Host = host.(string)
+	}
+	if conf.Host == "" {
+		return logical.ErrorResponse("no host provided"), nil
+	}
+
+	if caCert, ok := data.GetOk("kubernetes_ca_cert"); ok {
+		conf.CACert = caCert.(string)
+	}
+	if conf.CACert != "" {
+		block, _ := pem.Decode([]byte(conf.CACert))
+		if block == nil {
+			return logical.ErrorResponse("kubernetes_ca_cert is not PEM encoded"), nil
+		}
+		if _, err := x509.ParseCertificate(block.Bytes); err != nil {
+			return logical.ErrorResponse("failed to parse kubernetes_ca_cert: " + err.Error()), nil
+		}
+	}
+
+	if jwt, ok := data.GetOk("service_account_jwt"); ok {
+		conf.ServiceAccountJWT = jwt.(string)
+	}
+
+	entry, err := logical.StorageEntryJSON(configPath, conf)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+func (b *backend) pathConfigDelete(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	if err := req.Storage.Delete(ctx, configPath); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+// kubeConfig contains the configuration of the connection to the Kubernetes
+// API
+type kubeConfig struct {
+	Host              string `json:"kubernetes_host"`
+	CACert            string `json:"kubernetes_ca_cert"`
+	ServiceAccountJWT string `json:"service_account_jwt"`
+}
+
+const pathConfigHelpSyn = `
+Configure the connection to the Kubernetes API.
+`
+
+const pathConfigHelpDesc = `
+This path configures the address of the Kubernetes API server, the CA
+certificate used to verify it, and the service account JWT used to access
+it. The JWT is never returned once written.
+`
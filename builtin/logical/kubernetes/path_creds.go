@@ -0,0 +1,204 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	uuid "github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+// invalidNameChars matches the characters not allowed in the names of
+// Kubernetes objects
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+func pathCreds(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "creds/" + framework.GenericNameRegex("name"),
+		Fields: map[string]*framework.FieldSchema{
+			"name": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Name of the role",
+			},
+
+			"ttl": &framework.FieldSchema{
+				Type:        framework.TypeDurationSecond,
+				Description: "Validity of the token. Defaults to the token_default_ttl of the role.",
+			},
+
+			"audiences": &framework.FieldSchema{
+				Type:        framework.TypeCommaStringSlice,
+				Description: "Comma-separated string or list of the audiences of the token. Defaults to the token_default_audiences of the role.",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathCredsCreate,
+		},
+
+		HelpSynopsis:    pathCredsHelpSyn,
+		HelpDescription: pathCredsHelpDesc,
+	}
+}
+
+func (b *backend) pathCredsCreate(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	role, err := b.Role(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return logical.ErrorResponse(fmt.Sprintf("role %q not found", name)), nil
+	}
+
+	ttl := time.Duration(d.Get("ttl").(int)) * time.Second
+	if ttl == 0 {
+		ttl = role.DefaultTTL
+	}
+	if ttl == 0 {
+		ttl = b.System().DefaultLeaseTTL()
+	}
+	maxTTL := b.System().MaxLeaseTTL()
+	if role.MaxTTL != 0 && role.MaxTTL < maxTTL {
+		maxTTL = role.MaxTTL
+	}
+	if ttl > maxTTL {
+		ttl = maxTTL
+	}
+	if ttl < minTokenTTL {
+		return logical.ErrorResponse("the ttl of the token must be at least 10 minutes"), nil
+	}
+
+	audiences := role.DefaultAudiences
+	if audiencesRaw, ok := d.GetOk("audiences"); ok {
+		audiences = audiencesRaw.([]string)
+	}
+
+	c, err := b.client(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	serviceAccountName := role.ServiceAccountName
+	roleBindingName := ""
+	if role.generatesServiceAccounts() {
+		serviceAccountName, err = generateName(name)
+		if err != nil {
+			return nil, err
+		}
+		roleBindingName = serviceAccountName
+
+		if err := b.createServiceAccount(c, role, serviceAccountName, name); err != nil {
+			return nil, err
+		}
+	}
+
+	tokenRequest, err := c.createServiceAccountToken(role.Namespace, serviceAccountName, audiences, ttl)
+	if err != nil {
+		err = errwrap.Wrapf("error creating service account token: {{err}}", err)
+		if role.generatesServiceAccounts() {
+			if cleanupErr := deleteServiceAccount(c, role.Namespace, serviceAccountName, roleBindingName); cleanupErr != nil {
+				b.Logger().Error("failed to clean up the service account", "namespace", role.Namespace, "service_account", serviceAccountName, "error", cleanupErr)
+			}
+		}
+		return nil, err
+	}
+
+	// Kubernetes may issue the token with a different validity
+	expiration := tokenRequest.Status.ExpirationTimestamp.Time
+	if !expiration.IsZero() {
+		ttl = time.Until(expiration)
+	}
+
+	resp := b.Secret(secretServiceAccountTokenType).Response(map[string]interface{}{
+		"service_account_token":     tokenRequest.Status.Token,
+		"service_account_name":      serviceAccountName,
+		"service_account_namespace": role.Namespace,
+	}, map[string]interface{}{
+		"service_account_name":      serviceAccountName,
+		"service_account_namespace": role.Namespace,
+		"role_binding_name":         roleBindingName,
+	})
+	resp.Secret.TTL = ttl
+	resp.Secret.MaxTTL = ttl
+
+	return resp, nil
+}
+
+// createServiceAccount creates a service account for a set of credentials of
+// the role, bound to the Kubernetes role
+func (b *backend) createServiceAccount(c *client, role *roleConfig, serviceAccountName, roleName string) error {
+	labels := map[string]string{
+		"app.kubernetes.io/managed-by": "vault",
+		"vault.hashicorp.com/role":     sanitizeName(roleName, 63),
+	}
+
+	if err := c.createServiceAccount(role.Namespace, serviceAccountName, labels); err != nil {
+		return errwrap.Wrapf("error creating service account: {{err}}", err)
+	}
+
+	if err := c.createRoleBinding(role.Namespace, serviceAccountName, role.KubernetesRoleType, role.KubernetesRoleName, serviceAccountName, labels); err != nil {
+		if cleanupErr := c.deleteServiceAccount(role.Namespace, serviceAccountName); cleanupErr != nil {
+			b.Logger().Error("failed to clean up the service account", "namespace", role.Namespace, "service_account", serviceAccountName, "error", cleanupErr)
+		}
+		return errwrap.Wrapf("error creating role binding: {{err}}", err)
+	}
+
+	return nil
+}
+
+// deleteServiceAccount deletes a service account created for a set of
+// credentials and its role binding
+func deleteServiceAccount(c *client, namespace, serviceAccountName, roleBindingName string) error {
+	if roleBindingName != "" {
+		if err := c.deleteRoleBinding(namespace, roleBindingName); err != nil {
+			return errwrap.Wrapf("error deleting role binding: {{err}}", err)
+		}
+	}
+	if err := c.deleteServiceAccount(namespace, serviceAccountName); err != nil {
+		return errwrap.Wrapf("error deleting service account: {{err}}", err)
+	}
+	return nil
+}
+
+// generateName returns a unique name for the service account of a set of
+// credentials of the role, valid as the name of a Kubernetes object
+func generateName(roleName string) (string, error) {
+	id, err := uuid.GenerateUUID()
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("vault-%s-%s", sanitizeName(roleName, 40), id[:8]), nil
+}
+
+// sanitizeName lowercases the name and replaces the characters not allowed in
+// the names and labels of Kubernetes objects, truncating it to maxLen
+func sanitizeName(name string, maxLen int) string {
+	name = invalidNameChars.ReplaceAllString(strings.ToLower(name), "-")
+	if len(name) > maxLen {
+		name = name[:maxLen]
+	}
+	return strings.Trim(name, "-")
+}
+
+const pathCredsHelpSyn = `
+Request a Kubernetes service account token for a role.
+`
+
+const pathCredsHelpDesc = `
+This path mints a service account token with the Kubernetes TokenRequest API
+for the service account of the named role. For roles binding a Kubernetes Role
+or ClusterRole, a service account is created and bound to it first, and
+deleted along with its binding when the lease is revoked, which invalidates
+the token.
+
+Leases can't be renewed: the validity of the token is set by Kubernetes when
+it is minted.
+`
@@ -0,0 +1,259 @@
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+// minTokenTTL is the shortest validity of the tokens accepted by the
+// TokenRequest API
+const minTokenTTL = 10 * time.Minute
+
+func pathListRoles(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "roles/?$",
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ListOperation: b.pathRoleList,
+		},
+
+		HelpSynopsis:    pathRolesHelpSyn,
+		HelpDescription: pathRolesHelpDesc,
+	}
+}
+
+func pathRoles(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "roles/" + framework.GenericNameRegex("name"),
+		Fields: map[string]*framework.FieldSchema{
+			"name": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Name of the role",
+			},
+
+			"kubernetes_namespace": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Default:     "default",
+				Description: "Kubernetes namespace of the service accounts of the role.",
+			},
+
+			"service_account_name": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Name of an existing service account to mint tokens for. Mutually exclusive with kubernetes_role_name.",
+			},
+
+			"kubernetes_role_name": &framework.FieldSchema{
+				Type: framework.TypeString,
+				Description: `Name of a Kubernetes Role or ClusterRole bound to a service account
+created for each set of credentials, and deleted with it. Mutually
+exclusive with service_account_name.`,
+			},
+
+			"kubernetes_role_type": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Default:     "Role",
+				Description: `Kind of the kubernetes_role_name: "Role" or "ClusterRole".`,
+			},
+
+			"token_default_audiences": &framework.FieldSchema{
+				Type:        framework.TypeCommaStringSlice,
+				Description: "Comma-separated string or list of the audiences of the tokens when the request doesn't set any. If unset, the audience is the one of the Kubernetes API server.",
+			},
+
+			"token_default_ttl": &framework.FieldSchema{
+				Type:        framework.TypeDurationSecond,
+				Description: "Default validity of the tokens. If unset, the default lease TTL of the mount is used. Must be at least 10 minutes.",
+			},
+
+			"token_max_ttl": &framework.FieldSchema{
+				Type:        framework.TypeDurationSecond,
+				Description: "Maximum validity of the tokens. If unset, the maximum lease TTL of the mount is used.",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation:   b.pathRolesRead,
+			logical.CreateOperation: b.pathRolesWrite,
+			logical.UpdateOperation: b.pathRolesWrite,
+			logical.DeleteOperation: b.pathRolesDelete,
+		},
+
+		ExistenceCheck: b.rolesExistenceCheck,
+
+		HelpSynopsis:    pathRolesHelpSyn,
+		HelpDescription: pathRolesHelpDesc,
+	}
+}
+
+func (b *backend) rolesExistenceCheck(ctx context.Context, req *logical.Request, d *framework.FieldData) (bool, error) {
+	entry, err := b.Role(ctx, req.Storage, d.Get("name").(string))
+	if err != nil {
+		return false, err
+	}
+	return entry != nil, nil
+}
+
+func (b *backend) Role(ctx context.Context, storage logical.Storage, name string) (*roleConfig, error) {
+	if name == "" {
+		return nil, errors.New("invalid role name")
+	}
+
+	entry, err := storage.Get(ctx, "roles/"+name)
+	if err != nil {
+		return nil, errwrap.Wrapf("error retrieving role: {{err}}", err)
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var result roleConfig
+	if err := entry.DecodeJSON(&result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (b *backend) pathRoleList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	entries, err := req.Storage.List(ctx, "roles/")
+	if err != nil {
+		return nil, err
+	}
+
+	return logical.ListResponse(entries), nil
+}
+
+func (b *backend) pathRolesRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	role, err := b.Role(ctx, req.Storage, d.Get("name").(string))
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return nil, nil
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"kubernetes_namespace":    role.Namespace,
+			"service_account_name":    role.ServiceAccountName,
+			"kubernetes_role_name":    role.KubernetesRoleName,
+			"kubernetes_role_type":    role.KubernetesRoleType,
+			"token_default_audiences": role.DefaultAudiences,
+			"token_default_ttl":       int64(role.DefaultTTL.Seconds()),
+			"token_max_ttl":           int64(role.MaxTTL.Seconds()),
+		},
+	}, nil
+}
+
+func (b *backend) pathRolesWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	role, err := b.Role(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		role = &roleConfig{}
+	}
+
+	if _, ok := d.GetOk("kubernetes_namespace"); ok || req.Operation == logical.CreateOperation {
+		role.Namespace = d.Get("kubernetes_namespace").(string)
+	}
+	if role.Namespace == "" {
+		return logical.ErrorResponse("kubernetes_namespace is required"), nil
+	}
+
+	if serviceAccountName, ok := d.GetOk("service_account_name"); ok {
+		role.ServiceAccountName = serviceAccountName.(string)
+	}
+	if kubernetesRoleName, ok := d.GetOk("kubernetes_role_name"); ok {
+		role.KubernetesRoleName = kubernetesRoleName.(string)
+	}
+	switch {
+	case role.ServiceAccountName == "" && role.KubernetesRoleName == "":
+		return logical.ErrorResponse("one of service_account_name or kubernetes_role_name is required"), nil
+	case role.ServiceAccountName != "" && role.KubernetesRoleName != "":
+		return logical.ErrorResponse("service_account_name and kubernetes_role_name are mutually exclusive"), nil
+	}
+
+	if _, ok := d.GetOk("kubernetes_role_type"); ok || req.Operation == logical.CreateOperation {
+		role.KubernetesRoleType = d.Get("kubernetes_role_type").(string)
+	}
+	switch role.KubernetesRoleType {
+	case "Role", "ClusterRole":
+	default:
+		return logical.ErrorResponse(`kubernetes_role_type must be "Role" or "ClusterRole"`), nil
+	}
+
+	if audiences, ok := d.GetOk("token_default_audiences"); ok {
+		role.DefaultAudiences = audiences.([]string)
+	}
+	if defaultTTL, ok := d.GetOk("token_default_ttl"); ok {
+		role.DefaultTTL = time.Duration(defaultTTL.(int)) * time.Second
+	}
+	if maxTTL, ok := d.GetOk("token_max_ttl"); ok {
+		role.MaxTTL = time.Duration(maxTTL.(int)) * time.Second
+	}
+	if role.DefaultTTL != 0 && role.DefaultTTL < minTokenTTL {
+		return logical.ErrorResponse("token_default_ttl must be at least 10 minutes"), nil
+	}
+	if role.MaxTTL != 0 && role.MaxTTL < minTokenTTL {
+		return logical.ErrorResponse("token_max_ttl must be at least 10 minutes"), nil
+	}
+	if role.MaxTTL != 0 && role.DefaultTTL > role.MaxTTL {
+		return logical.ErrorResponse("token_default_ttl cannot be greater than token_max_ttl"), nil
+	}
+
+	entry, err := logical.StorageEntryJSON("roles/"+name, role)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+func (b *backend) pathRolesDelete(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	if err := req.Storage.Delete(ctx, "roles/"+d.Get("name").(string)); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+type roleConfig struct {
+	Namespace          string        `json:"kubernetes_namespace"`
+	ServiceAccountName string        `json:"service_account_name"`
+	KubernetesRoleName string        `json:"kubernetes_role_name"`
+	KubernetesRoleType string        `json:"kubernetes_role_type"`
+	DefaultAudiences   []string      `json:"token_default_audiences"`
+	DefaultTTL         time.Duration `json:"token_default_ttl"`
+	MaxTTL             time.Duration `json:"token_max_ttl"`
+}
+
+// generatesServiceAccounts returns true if a service account is created for
+// each set of credentials of the role
+func (r *roleConfig) generatesServiceAccounts() bool {
+	return r.KubernetesRoleName != ""
+}
+
+const pathRolesHelpSyn = `
+Manage the roles used to mint Kubernetes service account tokens.
+`
+
+const pathRolesHelpDesc = `
+A role either names an existing service account of its namespace, whose tokens
+are minted, or a Kubernetes Role or ClusterRole. In the latter case a service
+account is created in the namespace for each set of credentials and bound to
+the Kubernetes role, and deleted along with its binding when the lease of the
+credentials is revoked.
+
+The validity of the tokens defaults to token_default_ttl, or the default lease
+TTL of the mount, and is capped by token_max_ttl, or the maximum lease TTL of
+the mount. Kubernetes doesn't issue tokens valid for less than 10 minutes.
+`
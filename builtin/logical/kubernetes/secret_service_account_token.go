@@ -0,0 +1,48 @@
+package kubernetes
+
+import (
+	"context"
+
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+const secretServiceAccountTokenType = "service_account_token"
+
+func secretServiceAccountToken(b *backend) *framework.Secret {
+	return &framework.Secret{
+		Type: secretServiceAccountTokenType,
+		Fields: map[string]*framework.FieldSchema{
+			"service_account_token": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Kubernetes service account token",
+			},
+		},
+
+		// Tokens can't be renewed, their validity is set when they're minted
+		Revoke: b.secretServiceAccountTokenRevoke,
+	}
+}
+
+// secretServiceAccountTokenRevoke deletes the service account created for the
+// credentials, if any, which invalidates the token. Tokens of existing
+// service accounts stay valid until they expire.
+func (b *backend) secretServiceAccountTokenRevoke(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	roleBindingName, _ := req.Secret.InternalData["role_binding_name"].(string)
+	if roleBindingName == "" {
+		return nil, nil
+	}
+	namespace, _ := req.Secret.InternalData["service_account_namespace"].(string)
+	serviceAccountName, _ := req.Secret.InternalData["service_account_name"].(string)
+
+	c, err := b.client(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := deleteServiceAccount(c, namespace, serviceAccountName, roleBindingName); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
@@ -31,6 +31,13 @@ const (
 	// defaultMaxVersions is the number of versions to keep around unless set by
 	// the config or key configuration.
 	defaultMaxVersions uint32 = 10
+
+	// maxCustomMetadataKeys, maxCustomMetadataKeyLength and
+	// maxCustomMetadataValueLength limit the size of the custom metadata of
+	// a key.
+	maxCustomMetadataKeys        = 64
+	maxCustomMetadataKeyLength   = 128
+	maxCustomMetadataValueLength = 512
 )
 
 // versionedKVBackend implements logical.Backend
@@ -61,6 +68,10 @@ type versionedKVBackend struct {
 	// upgrading its data.
 	upgrading *uint32
 
+	// upgradeProgress tracks the keys upgraded by the last upgrade this
+	// backend ran
+	upgradeProgress *upgradeProgress
+
 	// globalConfig is a cached value for fast lookup
 	globalConfig     *Configuration
 	globalConfigLock *sync.RWMutex
@@ -90,6 +101,7 @@ func Factory(ctx context.Context, conf *logical.BackendConfig) (logical.Backend,
 func VersionedKVFactory(ctx context.Context, conf *logical.BackendConfig) (logical.Backend, error) {
 	b := &versionedKVBackend{
 		upgrading:        new(uint32),
+		upgradeProgress:  &upgradeProgress{},
 		globalConfigLock: new(sync.RWMutex),
 	}
 	if conf.BackendUUID == "" {
@@ -98,8 +110,9 @@ func VersionedKVFactory(ctx context.Context, conf *logical.BackendConfig) (logic
 	b.storagePrefix = conf.BackendUUID
 
 	b.Backend = &framework.Backend{
-		BackendType: logical.TypeLogical,
-		Help:        backendHelp,
+		BackendType:  logical.TypeLogical,
+		Help:         backendHelp,
+		PeriodicFunc: b.periodicFunc,
 
 		PathsSpecial: &logical.Paths{
 			SealWrapStorage: []string{
@@ -117,6 +130,7 @@ func VersionedKVFactory(ctx context.Context, conf *logical.BackendConfig) (logic
 		Paths: framework.PathAppend(
 			[]*framework.Path{
 				pathConfig(b),
+				pathUpgrade(b),
 				pathData(b),
 				pathMetadata(b),
 				pathDestroy(b),
@@ -150,20 +164,31 @@ func VersionedKVFactory(ctx context.Context, conf *logical.BackendConfig) (logic
 }
 
 func (b *versionedKVBackend) upgradeDone(ctx context.Context, s logical.Storage) (bool, error) {
-	upgradeEntry, err := s.Get(ctx, path.Join(b.storagePrefix, "upgrading"))
+	upgradeInfo, err := b.upgradeInfo(ctx, s)
 	if err != nil {
 		return false, err
 	}
 
+	return upgradeInfo.Done, nil
+}
+
+// upgradeInfo returns the persisted state of the upgrade, which is empty if
+// no upgrade has started
+func (b *versionedKVBackend) upgradeInfo(ctx context.Context, s logical.Storage) (*UpgradeInfo, error) {
+	upgradeEntry, err := s.Get(ctx, path.Join(b.storagePrefix, "upgrading"))
+	if err != nil {
+		return nil, err
+	}
+
 	var upgradeInfo UpgradeInfo
 	if upgradeEntry != nil {
 		err := proto.Unmarshal(upgradeEntry.Value, &upgradeInfo)
 		if err != nil {
-			return false, err
+			return nil, err
 		}
 	}
 
-	return upgradeInfo.Done, nil
+	return &upgradeInfo, nil
 }
 
 func pathInvalid(b *versionedKVBackend) []*framework.Path {
@@ -323,6 +348,7 @@ func (b *versionedKVBackend) config(ctx context.Context, s logical.Storage) (*Co
 		return &Configuration{
 			CasRequired: b.globalConfig.CasRequired,
 			MaxVersions: b.globalConfig.MaxVersions,
+			WriteOnce:   b.globalConfig.WriteOnce,
 		}, nil
 	}
 
@@ -335,6 +361,7 @@ func (b *versionedKVBackend) config(ctx context.Context, s logical.Storage) (*Co
 		return &Configuration{
 			CasRequired: b.globalConfig.CasRequired,
 			MaxVersions: b.globalConfig.MaxVersions,
+			WriteOnce:   b.globalConfig.WriteOnce,
 		}, nil
 	}
 
@@ -0,0 +1,127 @@
+package kv
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/hashicorp/vault/helper/consts"
+	"github.com/hashicorp/vault/helper/locksutil"
+	"github.com/hashicorp/vault/logical"
+)
+
+// periodicFunc destroys the versions whose expiration time has passed.
+func (b *versionedKVBackend) periodicFunc(ctx context.Context, req *logical.Request) error {
+	// Storage of the mount is read-only here
+	if !b.System().LocalMount() && b.System().ReplicationState().HasState(consts.ReplicationPerformanceSecondary|consts.ReplicationPerformanceStandby) {
+		return nil
+	}
+
+	// The metadata is rewritten while upgrading
+	if atomic.LoadUint32(b.upgrading) == 1 {
+		return nil
+	}
+
+	wrapper, err := b.getKeyEncryptor(ctx, req.Storage)
+	if err != nil {
+		return err
+	}
+
+	return b.destroyExpiredVersions(ctx, wrapper.Wrap(req.Storage), req.Storage, "", time.Now())
+}
+
+// destroyExpiredVersions walks the tree of keys below prefix and destroys
+// their versions which expired before now.
+func (b *versionedKVBackend) destroyExpiredVersions(ctx context.Context, es logical.Storage, s logical.Storage, prefix string, now time.Time) error {
+	keys, err := es.List(ctx, prefix)
+	if err != nil {
+		return err
+	}
+
+	for _, k := range keys {
+		if strings.HasSuffix(k, "/") {
+			if err := b.destroyExpiredVersions(ctx, es, s, prefix+k, now); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := b.destroyExpiredKeyVersions(ctx, s, prefix+k, now); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (b *versionedKVBackend) destroyExpiredKeyVersions(ctx context.Context, s logical.Storage, key string, now time.Time) error {
+	lock := locksutil.LockForKey(b.locks, key)
+	lock.Lock()
+	defer lock.Unlock()
+
+	meta, err := b.getKeyMetadata(ctx, s, key)
+	if err != nil {
+		return err
+	}
+	if meta == nil {
+		return nil
+	}
+
+	var expired []uint64
+	for verNum, vm := range meta.Versions {
+		if vm.Destroyed {
+			continue
+		}
+
+		ok, err := versionExpired(vm, now)
+		if err != nil {
+			return err
+		}
+		if ok {
+			vm.Destroyed = true
+			expired = append(expired, verNum)
+		}
+	}
+	if len(expired) == 0 {
+		return nil
+	}
+
+	// Write the metadata key before deleting the versions
+	if err := b.writeKeyMetadata(ctx, s, meta); err != nil {
+		return err
+	}
+
+	for _, verNum := range expired {
+		versionKey, err := b.getVersionKey(ctx, key, verNum, s)
+		if err != nil {
+			return err
+		}
+
+		if err := s.Delete(ctx, versionKey); err != nil {
+			return err
+		}
+	}
+
+	if b.Logger().IsDebug() {
+		b.Logger().Debug("destroyed expired versions", "key", key, "versions", expired)
+	}
+
+	return nil
+}
+
+// versionExpired returns true if the version has an expiration time that
+// is not after now.
+func versionExpired(vm *VersionMetadata, now time.Time) (bool, error) {
+	if vm.ExpirationTime == nil {
+		return false, nil
+	}
+
+	expirationTime, err := ptypes.Timestamp(vm.ExpirationTime)
+	if err != nil {
+		return false, err
+	}
+
+	return !expirationTime.After(now), nil
+}
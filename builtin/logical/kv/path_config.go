@@ -23,6 +23,10 @@ func pathConfig(b *versionedKVBackend) *framework.Path {
 				Type:        framework.TypeBool,
 				Description: "If true, the backend will require the cas parameter to be set for each write",
 			},
+			"write_once": {
+				Type:        framework.TypeBool,
+				Description: "If true, keys can only be written once: writes to keys that already exist are refused",
+			},
 		},
 		Operations: map[logical.Operation]framework.OperationHandler{
 			logical.UpdateOperation: &framework.PathOperation{
@@ -55,6 +59,7 @@ func (b *versionedKVBackend) pathConfigRead() framework.OperationFunc {
 			Data: map[string]interface{}{
 				"max_versions": config.MaxVersions,
 				"cas_required": config.CasRequired,
+				"write_once":   config.WriteOnce,
 			},
 		}, nil
 	}
@@ -65,9 +70,10 @@ func (b *versionedKVBackend) pathConfigWrite() framework.OperationFunc {
 	return func(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
 		maxRaw, mOk := data.GetOk("max_versions")
 		casRaw, cOk := data.GetOk("cas_required")
+		writeOnceRaw, wOk := data.GetOk("write_once")
 
 		// Fast path validation
-		if !mOk && !cOk {
+		if !mOk && !cOk && !wOk {
 			return nil, nil
 		}
 
@@ -82,6 +88,9 @@ func (b *versionedKVBackend) pathConfigWrite() framework.OperationFunc {
 		if cOk {
 			config.CasRequired = casRaw.(bool)
 		}
+		if wOk {
+			config.WriteOnce = writeOnceRaw.(bool)
+		}
 
 		bytes, err := proto.Marshal(config)
 		if err != nil {
@@ -115,4 +124,7 @@ key-value store. This parameter accetps:
     
 	* cas_required (bool) - If true, the backend will require the cas parameter
 	  to be set for each write
+
+	* write_once (bool) - If true, keys can only be written once. Writes to
+	  keys that already exist, and deletions of their metadata, are refused
 `
@@ -12,6 +12,7 @@ import (
 	"github.com/golang/protobuf/ptypes"
 	"github.com/golang/protobuf/ptypes/timestamp"
 	"github.com/hashicorp/vault/helper/locksutil"
+	"github.com/hashicorp/vault/helper/parseutil"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
 	"github.com/mitchellh/mapstructure"
@@ -38,7 +39,11 @@ func pathData(b *versionedKVBackend) *framework.Path {
 Set the "cas" value to use a Check-And-Set operation. If not set the write will
 be allowed. If set to 0 a write will only be allowed if the key doesn’t exist.
 If the index is non-zero the write will only be allowed if the key’s current
-version matches the version specified in the cas parameter.`,
+version matches the version specified in the cas parameter.
+
+Set the "delete_version_after" value to a duration to delete the new version
+once that duration has passed, and the "expires_at" value to an RFC 3339
+timestamp to destroy it at that time.`,
 			},
 			"data": {
 				Type:        framework.TypeMap,
@@ -48,7 +53,7 @@ version matches the version specified in the cas parameter.`,
 		Callbacks: map[logical.Operation]framework.OperationFunc{
 			logical.UpdateOperation: b.upgradeCheck(b.pathDataWrite()),
 			logical.CreateOperation: b.upgradeCheck(b.pathDataWrite()),
-			logical.ReadOperation:   b.upgradeCheck(b.pathDataRead()),
+			logical.ReadOperation:   b.upgradeReadCheck(b.pathDataRead()),
 			logical.DeleteOperation: b.upgradeCheck(b.pathDataDelete()),
 		},
 
@@ -105,10 +110,11 @@ func (b *versionedKVBackend) pathDataRead() framework.OperationFunc {
 			Data: map[string]interface{}{
 				"data": nil,
 				"metadata": map[string]interface{}{
-					"version":       verNum,
-					"created_time":  ptypesTimestampToString(vm.CreatedTime),
-					"deletion_time": ptypesTimestampToString(vm.DeletionTime),
-					"destroyed":     vm.Destroyed,
+					"version":         verNum,
+					"created_time":    ptypesTimestampToString(vm.CreatedTime),
+					"deletion_time":   ptypesTimestampToString(vm.DeletionTime),
+					"destroyed":       vm.Destroyed,
+					"expiration_time": ptypesTimestampToString(vm.ExpirationTime),
 				},
 			},
 		}
@@ -132,6 +138,16 @@ func (b *versionedKVBackend) pathDataRead() framework.OperationFunc {
 
 		}
 
+		// If the version has expired but was not destroyed yet return
+		// metadata with a 404
+		expired, err := versionExpired(vm, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		if expired {
+			return logical.RespondWithStatusCode(resp, req, http.StatusNotFound)
+		}
+
 		versionKey, err := b.getVersionKey(ctx, key, verNum, req.Storage)
 		if err != nil {
 			return nil, err
@@ -203,7 +219,14 @@ func (b *versionedKVBackend) pathDataWrite() framework.OperationFunc {
 			}
 		}
 
+		// In write-once mode, a key can't be written again once created
+		if config.WriteOnce && meta.CurrentVersion != 0 {
+			return logical.ErrorResponse("the key already exists and the backend is write-once"), logical.ErrInvalidRequest
+		}
+
 		// Parse options
+		var deleteVersionAfter time.Duration
+		var expirationTime *timestamp.Timestamp
 		{
 			var casRaw interface{}
 			var casOk bool
@@ -213,6 +236,34 @@ func (b *versionedKVBackend) pathDataWrite() framework.OperationFunc {
 
 				// Verify the CAS parameter is valid.
 				casRaw, casOk = options["cas"]
+
+				if deleteVersionAfterRaw, ok := options["delete_version_after"]; ok {
+					deleteVersionAfter, err = parseutil.ParseDurationSecond(deleteVersionAfterRaw)
+					if err != nil {
+						return logical.ErrorResponse("error parsing delete_version_after parameter"), logical.ErrInvalidRequest
+					}
+					if deleteVersionAfter <= 0 {
+						return logical.ErrorResponse("delete_version_after must be a positive duration"), logical.ErrInvalidRequest
+					}
+				}
+
+				if expiresAtRaw, ok := options["expires_at"]; ok {
+					expiresAtStr, ok := expiresAtRaw.(string)
+					if !ok {
+						return logical.ErrorResponse("error parsing expires_at parameter"), logical.ErrInvalidRequest
+					}
+					expiresAt, err := time.Parse(time.RFC3339, expiresAtStr)
+					if err != nil {
+						return logical.ErrorResponse("error parsing expires_at parameter, it must be an RFC 3339 timestamp"), logical.ErrInvalidRequest
+					}
+					if !expiresAt.After(time.Now()) {
+						return logical.ErrorResponse("expires_at must be in the future"), logical.ErrInvalidRequest
+					}
+					expirationTime, err = ptypes.TimestampProto(expiresAt)
+					if err != nil {
+						return nil, err
+					}
+				}
 			}
 
 			switch {
@@ -252,7 +303,20 @@ func (b *versionedKVBackend) pathDataWrite() framework.OperationFunc {
 			return nil, err
 		}
 
-		vm, versionToDelete := meta.AddVersion(version.CreatedTime, nil, config.MaxVersions)
+		var deletionTime *timestamp.Timestamp
+		if deleteVersionAfter > 0 {
+			createdTime, err := ptypes.Timestamp(version.CreatedTime)
+			if err != nil {
+				return nil, err
+			}
+			deletionTime, err = ptypes.TimestampProto(createdTime.Add(deleteVersionAfter))
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		vm, versionToDelete := meta.AddVersion(version.CreatedTime, deletionTime, config.MaxVersions)
+		vm.ExpirationTime = expirationTime
 		err = b.writeKeyMetadata(ctx, req.Storage, meta)
 		if err != nil {
 			return nil, err
@@ -261,10 +325,11 @@ func (b *versionedKVBackend) pathDataWrite() framework.OperationFunc {
 		// We create the response here so we can add warnings to it below.
 		resp := &logical.Response{
 			Data: map[string]interface{}{
-				"version":       meta.CurrentVersion,
-				"created_time":  ptypesTimestampToString(vm.CreatedTime),
-				"deletion_time": ptypesTimestampToString(vm.DeletionTime),
-				"destroyed":     vm.Destroyed,
+				"version":         meta.CurrentVersion,
+				"created_time":    ptypesTimestampToString(vm.CreatedTime),
+				"deletion_time":   ptypesTimestampToString(vm.DeletionTime),
+				"destroyed":       vm.Destroyed,
+				"expiration_time": ptypesTimestampToString(vm.ExpirationTime),
 			},
 		}
 
@@ -428,6 +493,10 @@ data.
 A read operation will return the latest version for a key unless the "version"
 parameter is set, then it returns the version at that number.
 
+The "delete_version_after" option of a write deletes the new version once the
+given duration has passed, and the "expires_at" option destroys it at the given
+time.
+
 Delete operations are a soft delete. They will mark the latest version as
 deleted, but the underlying data will not be fully removed. Delete operations
 can be undone.
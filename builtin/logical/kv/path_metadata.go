@@ -3,6 +3,8 @@ package kv
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/golang/protobuf/ptypes"
 	"github.com/hashicorp/vault/helper/locksutil"
@@ -32,6 +34,19 @@ If false, the backend’s configuration will be used.`,
 The number of versions to keep. If not set, the backend’s configured max
 version is used.`,
 			},
+			"custom_metadata": {
+				Type: framework.TypeKVPairs,
+				Description: `
+User-provided key/value pairs describing the key, e.g. its owner. Replaces
+the existing custom metadata when set.`,
+			},
+			"filter": {
+				Type: framework.TypeKVPairs,
+				Description: `
+When listing, only return the keys under the path whose custom metadata
+matches all of the given key/value pairs, searching the whole tree below
+the path.`,
+			},
 		},
 		Callbacks: map[logical.Operation]framework.OperationFunc{
 			logical.UpdateOperation: b.upgradeCheck(b.pathMetadataWrite()),
@@ -73,12 +88,63 @@ func (b *versionedKVBackend) pathMetadataList() framework.OperationFunc {
 
 		es := wrapper.Wrap(req.Storage)
 
+		filter := data.Get("filter").(map[string]string)
+		if len(filter) > 0 {
+			keys, err := b.filterKeys(ctx, es, req.Storage, key, "", filter)
+			if err != nil {
+				return nil, err
+			}
+			sort.Strings(keys)
+			return logical.ListResponse(keys), nil
+		}
+
 		// Use encrypted key storage to list the keys
 		keys, err := es.List(ctx, key)
 		return logical.ListResponse(keys), err
 	}
 }
 
+// filterKeys walks the tree below prefix+relative and returns the keys,
+// relative to prefix, whose custom metadata matches all the pairs of the
+// filter.
+func (b *versionedKVBackend) filterKeys(ctx context.Context, es logical.Storage, s logical.Storage, prefix, relative string, filter map[string]string) ([]string, error) {
+	keys, err := es.List(ctx, prefix+relative)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []string
+	for _, k := range keys {
+		if strings.HasSuffix(k, "/") {
+			subMatches, err := b.filterKeys(ctx, es, s, prefix, relative+k, filter)
+			if err != nil {
+				return nil, err
+			}
+			matches = append(matches, subMatches...)
+			continue
+		}
+
+		meta, err := b.getKeyMetadata(ctx, s, prefix+relative+k)
+		if err != nil {
+			return nil, err
+		}
+		if meta != nil && customMetadataMatches(meta.CustomMetadata, filter) {
+			matches = append(matches, relative+k)
+		}
+	}
+
+	return matches, nil
+}
+
+func customMetadataMatches(customMetadata, filter map[string]string) bool {
+	for k, v := range filter {
+		if value, ok := customMetadata[k]; !ok || value != v {
+			return false
+		}
+	}
+	return true
+}
+
 func (b *versionedKVBackend) pathMetadataRead() framework.OperationFunc {
 	return func(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
 		key := data.Get("path").(string)
@@ -94,9 +160,10 @@ func (b *versionedKVBackend) pathMetadataRead() framework.OperationFunc {
 		versions := make(map[string]interface{}, len(meta.Versions))
 		for i, v := range meta.Versions {
 			versions[fmt.Sprintf("%d", i)] = map[string]interface{}{
-				"created_time":  ptypesTimestampToString(v.CreatedTime),
-				"deletion_time": ptypesTimestampToString(v.DeletionTime),
-				"destroyed":     v.Destroyed,
+				"created_time":    ptypesTimestampToString(v.CreatedTime),
+				"deletion_time":   ptypesTimestampToString(v.DeletionTime),
+				"destroyed":       v.Destroyed,
+				"expiration_time": ptypesTimestampToString(v.ExpirationTime),
 			}
 		}
 
@@ -109,6 +176,7 @@ func (b *versionedKVBackend) pathMetadataRead() framework.OperationFunc {
 				"updated_time":    ptypesTimestampToString(meta.UpdatedTime),
 				"max_versions":    meta.MaxVersions,
 				"cas_required":    meta.CasRequired,
+				"custom_metadata": meta.CustomMetadata,
 			},
 		}, nil
 	}
@@ -123,12 +191,19 @@ func (b *versionedKVBackend) pathMetadataWrite() framework.OperationFunc {
 
 		maxRaw, mOk := data.GetOk("max_versions")
 		casRaw, cOk := data.GetOk("cas_required")
+		customMetadataRaw, cmOk := data.GetOk("custom_metadata")
 
 		// Fast path validation
-		if !mOk && !cOk {
+		if !mOk && !cOk && !cmOk {
 			return nil, nil
 		}
 
+		if cmOk {
+			if err := validateCustomMetadata(customMetadataRaw.(map[string]string)); err != nil {
+				return logical.ErrorResponse(err.Error()), nil
+			}
+		}
+
 		config, err := b.config(ctx, req.Storage)
 		if err != nil {
 			return nil, err
@@ -164,6 +239,9 @@ func (b *versionedKVBackend) pathMetadataWrite() framework.OperationFunc {
 		if cOk {
 			meta.CasRequired = casRaw.(bool)
 		}
+		if cmOk {
+			meta.CustomMetadata = customMetadataRaw.(map[string]string)
+		}
 
 		err = b.writeKeyMetadata(ctx, req.Storage, meta)
 		return resp, err
@@ -186,6 +264,15 @@ func (b *versionedKVBackend) pathMetadataDelete() framework.OperationFunc {
 			return nil, nil
 		}
 
+		// Deleting the metadata would allow the key to be written again
+		config, err := b.config(ctx, req.Storage)
+		if err != nil {
+			return nil, err
+		}
+		if config.WriteOnce {
+			return logical.ErrorResponse("the metadata of keys can't be deleted when the backend is write-once"), logical.ErrInvalidRequest
+		}
+
 		// Delete each version.
 		for id, _ := range meta.Versions {
 			versionKey, err := b.getVersionKey(ctx, key, id, req.Storage)
@@ -213,8 +300,29 @@ func (b *versionedKVBackend) pathMetadataDelete() framework.OperationFunc {
 	}
 }
 
+// validateCustomMetadata checks the custom metadata of a key against the
+// limits on the number and size of its pairs
+func validateCustomMetadata(customMetadata map[string]string) error {
+	if len(customMetadata) > maxCustomMetadataKeys {
+		return fmt.Errorf("custom_metadata can't have more than %d keys", maxCustomMetadataKeys)
+	}
+	for k, v := range customMetadata {
+		switch {
+		case len(k) > maxCustomMetadataKeyLength:
+			return fmt.Errorf("custom_metadata key %q is longer than %d characters", k, maxCustomMetadataKeyLength)
+		case len(v) > maxCustomMetadataValueLength:
+			return fmt.Errorf("custom_metadata value of key %q is longer than %d characters", k, maxCustomMetadataValueLength)
+		}
+	}
+	return nil
+}
+
 const metadataHelpSyn = `Allows interaction with key metadata and settings in the KV store.`
 const metadataHelpDesc = `
 This endpoint allows for reading, information about a key in the key-value
 store, writing key settings, and permanently deleting a key and all versions. 
+
+Keys can be given custom metadata, e.g. their owner, with the
+"custom_metadata" parameter. Listing with the "filter" parameter returns the
+keys below the path whose custom metadata matches the given pairs.
 `
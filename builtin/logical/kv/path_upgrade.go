@@ -0,0 +1,80 @@
+package kv
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+// pathUpgrade returns the path reporting the progress of the upgrade from
+// non-versioned to versioned data.
+func pathUpgrade(b *versionedKVBackend) *framework.Path {
+	return &framework.Path{
+		Pattern: "upgrade$",
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.ReadOperation: &framework.PathOperation{
+				Callback: b.pathUpgradeRead(),
+				Summary:  "Read the status and progress of the upgrade from a version 1 mount.",
+			},
+		},
+
+		HelpSynopsis:    upgradeHelpSyn,
+		HelpDescription: upgradeHelpDesc,
+	}
+}
+
+// pathUpgradeRead reports the status of the upgrade. The key counts are only
+// known by the node which ran the upgrade.
+func (b *versionedKVBackend) pathUpgradeRead() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+		info, err := b.upgradeInfo(ctx, req.Storage)
+		if err != nil {
+			return nil, err
+		}
+
+		p := b.upgradeProgress
+		p.l.RLock()
+		defer p.l.RUnlock()
+
+		var status string
+		switch {
+		case p.lastError != "":
+			status = "failed"
+		case atomic.LoadUint32(b.upgrading) == 1:
+			status = "in_progress"
+		case info.Done:
+			status = "completed"
+		default:
+			status = "not_started"
+		}
+
+		resp := &logical.Response{
+			Data: map[string]interface{}{
+				"status":        status,
+				"started_time":  ptypesTimestampToString(info.StartedTime),
+				"keys_total":    p.keysTotal,
+				"keys_upgraded": p.keysUpgraded,
+			},
+		}
+		if !p.finishedTime.IsZero() {
+			resp.Data["completed_time"] = p.finishedTime.Format(time.RFC3339Nano)
+		}
+		if p.lastError != "" {
+			resp.Data["error"] = p.lastError
+		}
+		return resp, nil
+	}
+}
+
+const upgradeHelpSyn = `Read the progress of the upgrade from a version 1 mount.`
+const upgradeHelpDesc = `
+Tuning a version 1 mount to version 2 upgrades its keys to versioned data in
+the background. While the upgrade is in progress, reads of the data are served
+and keys which haven't been upgraded yet are returned as their first version,
+while the other operations are rejected. This endpoint reports the status of
+the upgrade ("not_started", "in_progress", "completed" or "failed") along with the number of
+keys upgraded so far.
+`
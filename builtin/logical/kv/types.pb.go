@@ -35,6 +35,7 @@ const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
 type Configuration struct {
 	MaxVersions uint32 `protobuf:"varint,1,opt,name=max_versions,json=maxVersions" json:"max_versions,omitempty"`
 	CasRequired bool   `protobuf:"varint,2,opt,name=cas_required,json=casRequired" json:"cas_required,omitempty"`
+	WriteOnce   bool   `protobuf:"varint,3,opt,name=write_once,json=writeOnce" json:"write_once,omitempty"`
 }
 
 func (m *Configuration) Reset()                    { *m = Configuration{} }
@@ -56,6 +57,13 @@ func (m *Configuration) GetCasRequired() bool {
 	return false
 }
 
+func (m *Configuration) GetWriteOnce() bool {
+	if m != nil {
+		return m.WriteOnce
+	}
+	return false
+}
+
 type VersionMetadata struct {
 	// CreatedTime is when the version was created.
 	CreatedTime *google_protobuf.Timestamp `protobuf:"bytes,1,opt,name=created_time,json=createdTime" json:"created_time,omitempty"`
@@ -66,6 +74,9 @@ type VersionMetadata struct {
 	// Destroyed is used to specify this version is
 	// a has been removed and the underlying data deleted.
 	Destroyed bool `protobuf:"varint,3,opt,name=destroyed" json:"destroyed,omitempty"`
+	// ExpirationTime is the time after which the version is
+	// destroyed by the periodic function of the backend.
+	ExpirationTime *google_protobuf.Timestamp `protobuf:"bytes,4,opt,name=expiration_time,json=expirationTime" json:"expiration_time,omitempty"`
 }
 
 func (m *VersionMetadata) Reset()                    { *m = VersionMetadata{} }
@@ -94,6 +105,13 @@ func (m *VersionMetadata) GetDestroyed() bool {
 	return false
 }
 
+func (m *VersionMetadata) GetExpirationTime() *google_protobuf.Timestamp {
+	if m != nil {
+		return m.ExpirationTime
+	}
+	return nil
+}
+
 type KeyMetadata struct {
 	// Key is the key for this entry
 	Key string `protobuf:"bytes,1,opt,name=key" json:"key,omitempty"`
@@ -116,6 +134,9 @@ type KeyMetadata struct {
 	// CasRequired specifies if the cas parameter is
 	// required for this key
 	CasRequired bool `protobuf:"varint,8,opt,name=cas_required,json=casRequired" json:"cas_required,omitempty"`
+	// CustomMetadata is a map of arbitrary key/value pairs set by
+	// operators on the key, e.g. its owner.
+	CustomMetadata map[string]string `protobuf:"bytes,9,rep,name=custom_metadata,json=customMetadata" json:"custom_metadata,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
 }
 
 func (m *KeyMetadata) Reset()                    { *m = KeyMetadata{} }
@@ -179,6 +200,13 @@ func (m *KeyMetadata) GetCasRequired() bool {
 	return false
 }
 
+func (m *KeyMetadata) GetCustomMetadata() map[string]string {
+	if m != nil {
+		return m.CustomMetadata
+	}
+	return nil
+}
+
 type Version struct {
 	// Data is a JSON object with string keys that
 	// represents the user supplied data.
@@ -255,33 +283,37 @@ func init() {
 func init() { proto.RegisterFile("types.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 436 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x93, 0xdf, 0x8a, 0x13, 0x31,
-	0x18, 0xc5, 0x99, 0x3f, 0xbb, 0xdb, 0xfd, 0x32, 0xb3, 0x2b, 0xf1, 0xa6, 0x14, 0xc5, 0x3a, 0x20,
-	0xd6, 0x9b, 0x59, 0x58, 0x6f, 0x54, 0x58, 0xbc, 0x10, 0x2f, 0x44, 0x04, 0x09, 0xae, 0xb7, 0x35,
-	0xdb, 0x7c, 0x2d, 0x43, 0xdb, 0xc9, 0x98, 0x64, 0xca, 0xce, 0xc3, 0xf8, 0x0c, 0xbe, 0x82, 0x8f,
-	0x26, 0xc9, 0x24, 0xdd, 0xb5, 0x0a, 0xa5, 0x78, 0x17, 0x4e, 0xcf, 0x09, 0xbf, 0x9e, 0x39, 0x01,
-	0x62, 0xba, 0x06, 0x75, 0xd9, 0x28, 0x69, 0x24, 0x8d, 0x97, 0x9b, 0xd1, 0x93, 0x85, 0x94, 0x8b,
-	0x15, 0x5e, 0x38, 0xe5, 0xa6, 0x9d, 0x5f, 0x98, 0x6a, 0x8d, 0xda, 0xf0, 0x75, 0xd3, 0x9b, 0x8a,
-	0x6b, 0xc8, 0xdf, 0xc9, 0x7a, 0x5e, 0x2d, 0x5a, 0xc5, 0x4d, 0x25, 0x6b, 0xfa, 0x14, 0xb2, 0x35,
-	0xbf, 0x9d, 0x6e, 0x50, 0xe9, 0x4a, 0xd6, 0x7a, 0x18, 0x8d, 0xa3, 0x49, 0xce, 0xc8, 0x9a, 0xdf,
-	0x7e, 0xf5, 0x92, 0xb5, 0xcc, 0xb8, 0x9e, 0x2a, 0xfc, 0xde, 0x56, 0x0a, 0xc5, 0x30, 0x1e, 0x47,
-	0x93, 0x01, 0x23, 0x33, 0xae, 0x99, 0x97, 0x8a, 0x9f, 0x11, 0x9c, 0x7b, 0xff, 0x27, 0x34, 0x5c,
-	0x70, 0xc3, 0xe9, 0x15, 0x64, 0x33, 0x85, 0xdc, 0xa0, 0x98, 0x5a, 0x0a, 0x77, 0x33, 0xb9, 0x1c,
-	0x95, 0x3d, 0x62, 0x19, 0x10, 0xcb, 0x2f, 0x01, 0x91, 0x11, 0xef, 0xb7, 0x0a, 0x7d, 0x0b, 0xb9,
-	0xc0, 0x15, 0x5a, 0xc8, 0x3e, 0x1f, 0xef, 0xcd, 0x67, 0x21, 0xe0, 0x2e, 0x78, 0x04, 0xa7, 0x02,
-	0xb5, 0x51, 0xb2, 0x43, 0x31, 0x4c, 0x1c, 0xf3, 0x9d, 0x50, 0xfc, 0x4a, 0x80, 0x7c, 0xc4, 0x6e,
-	0x4b, 0xfb, 0x00, 0x92, 0x25, 0x76, 0x0e, 0xf2, 0x94, 0xd9, 0x23, 0x7d, 0x0d, 0x83, 0x6d, 0x2b,
-	0xf1, 0x38, 0x99, 0x90, 0xcb, 0xc7, 0xe5, 0x72, 0x53, 0xde, 0x0b, 0x95, 0xa1, 0xa2, 0xf7, 0xb5,
-	0x51, 0x1d, 0xdb, 0xda, 0xe9, 0x73, 0x38, 0x9f, 0xb5, 0x4a, 0x61, 0x6d, 0x42, 0xb1, 0x0e, 0x20,
-	0x65, 0x67, 0x5e, 0xf6, 0x41, 0xfa, 0x0c, 0xce, 0xe4, 0xca, 0x42, 0x6d, 0x7d, 0xa9, 0xf3, 0xe5,
-	0xbd, 0x1a, 0x6c, 0xbb, 0x55, 0x1e, 0x1d, 0x56, 0xe5, 0x15, 0x64, 0x6d, 0x23, 0xee, 0xe2, 0xc7,
-	0xfb, 0xe3, 0xde, 0xef, 0xe2, 0xbb, 0x13, 0x39, 0xd9, 0x3f, 0x91, 0xc1, 0x5f, 0x13, 0x19, 0x7d,
-	0x86, 0xfc, 0x8f, 0xba, 0xee, 0x37, 0x9e, 0xf6, 0x8d, 0xbf, 0x80, 0xa3, 0x0d, 0x5f, 0xb5, 0xe1,
-	0x53, 0x3f, 0xb4, 0x75, 0xef, 0xac, 0x8a, 0xf5, 0x8e, 0x37, 0xf1, 0xab, 0xa8, 0xf8, 0x11, 0xc1,
-	0x49, 0x68, 0x88, 0x42, 0x6a, 0x7f, 0x76, 0xb7, 0x65, 0x2c, 0xfd, 0xe7, 0x00, 0xe3, 0xff, 0x1c,
-	0x60, 0x72, 0xd8, 0x00, 0x8b, 0x6f, 0x40, 0xae, 0x9b, 0x85, 0xe2, 0x02, 0x3f, 0xd4, 0x73, 0x69,
-	0x71, 0xb4, 0xe1, 0xea, 0x90, 0xf7, 0xe0, 0xfd, 0x0e, 0xc7, 0xfe, 0x43, 0x59, 0xa3, 0x7f, 0x7d,
-	0xee, 0x7c, 0x73, 0xec, 0x42, 0x2f, 0x7f, 0x07, 0x00, 0x00, 0xff, 0xff, 0xa2, 0xd2, 0xb5, 0x3c,
-	0x08, 0x04, 0x00, 0x00,
+	// 510 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x54, 0xdd, 0x8a, 0xd3, 0x40,
+	0x14, 0x26, 0x69, 0xf6, 0xa7, 0x27, 0x4d, 0x2b, 0xb3, 0x5e, 0x94, 0xe2, 0x62, 0x8d, 0x88, 0xf5,
+	0x26, 0x0b, 0xeb, 0x8d, 0x0a, 0x8b, 0x48, 0xf1, 0x42, 0x54, 0x94, 0xa0, 0xde, 0xc6, 0xd9, 0xe4,
+	0xb4, 0x84, 0x36, 0x99, 0x38, 0x99, 0xd4, 0xe6, 0x61, 0x7c, 0x28, 0xdf, 0xc5, 0x07, 0x90, 0x99,
+	0xcc, 0xa4, 0xdd, 0x5a, 0x28, 0xc5, 0xbb, 0xc9, 0xc7, 0xf7, 0x9d, 0x73, 0xbe, 0xf3, 0x13, 0x70,
+	0x45, 0x5d, 0x60, 0x19, 0x14, 0x9c, 0x09, 0x46, 0xec, 0xc5, 0x6a, 0xf4, 0x70, 0xce, 0xd8, 0x7c,
+	0x89, 0x57, 0x0a, 0xb9, 0xad, 0x66, 0x57, 0x22, 0xcd, 0xb0, 0x14, 0x34, 0x2b, 0x1a, 0x92, 0x2f,
+	0xc0, 0x9b, 0xb2, 0x7c, 0x96, 0xce, 0x2b, 0x4e, 0x45, 0xca, 0x72, 0xf2, 0x08, 0x7a, 0x19, 0x5d,
+	0x47, 0x2b, 0xe4, 0x65, 0xca, 0xf2, 0x72, 0x68, 0x8d, 0xad, 0x89, 0x17, 0xba, 0x19, 0x5d, 0x7f,
+	0xd3, 0x90, 0xa4, 0xc4, 0xb4, 0x8c, 0x38, 0xfe, 0xa8, 0x52, 0x8e, 0xc9, 0xd0, 0x1e, 0x5b, 0x93,
+	0xf3, 0xd0, 0x8d, 0x69, 0x19, 0x6a, 0x88, 0x5c, 0x02, 0xfc, 0xe4, 0xa9, 0xc0, 0x88, 0xe5, 0x31,
+	0x0e, 0x3b, 0x8a, 0xd0, 0x55, 0xc8, 0xa7, 0x3c, 0x46, 0xff, 0x8f, 0x05, 0x03, 0x1d, 0xee, 0x23,
+	0x0a, 0x9a, 0x50, 0x41, 0xc9, 0x0d, 0xf4, 0x62, 0x8e, 0x54, 0x60, 0x12, 0xc9, 0x22, 0x55, 0x62,
+	0xf7, 0x7a, 0x14, 0x34, 0x0e, 0x02, 0xe3, 0x20, 0xf8, 0x62, 0x1c, 0x84, 0xae, 0xe6, 0x4b, 0x84,
+	0xbc, 0x06, 0x2f, 0xc1, 0x25, 0x4a, 0x0f, 0x8d, 0xde, 0x3e, 0xa8, 0xef, 0x19, 0x81, 0x0a, 0xf0,
+	0x00, 0xba, 0x09, 0x96, 0x82, 0xb3, 0x1a, 0x13, 0x53, 0x71, 0x0b, 0x90, 0x29, 0x0c, 0x70, 0x5d,
+	0xa4, 0x9c, 0x6e, 0x12, 0x38, 0x07, 0x13, 0xf4, 0x37, 0x12, 0x09, 0xfa, 0xbf, 0x1d, 0x70, 0xdf,
+	0x63, 0xdd, 0x5a, 0xbe, 0x07, 0x9d, 0x05, 0xd6, 0xca, 0x69, 0x37, 0x94, 0x4f, 0xf2, 0x12, 0xce,
+	0xdb, 0xce, 0xdb, 0xe3, 0xce, 0xc4, 0xbd, 0xbe, 0x0c, 0x16, 0xab, 0x60, 0x4b, 0x14, 0x98, 0x31,
+	0xbc, 0xcd, 0x05, 0xaf, 0xc3, 0x96, 0x4e, 0x9e, 0xc2, 0x20, 0xae, 0x38, 0xc7, 0x5c, 0x98, 0xe1,
+	0x29, 0x17, 0x4e, 0xd8, 0xd7, 0xb0, 0x16, 0x92, 0x27, 0xd0, 0x67, 0x4b, 0xe9, 0xac, 0xe5, 0x39,
+	0x8a, 0xe7, 0x35, 0xa8, 0xa1, 0xed, 0xce, 0xe3, 0xe4, 0xb8, 0x79, 0xdc, 0x40, 0xaf, 0x2a, 0x92,
+	0x8d, 0xfc, 0xf4, 0xb0, 0x5c, 0xf3, 0x95, 0x7c, 0x77, 0x0d, 0xcf, 0x0e, 0xaf, 0xe1, 0xf9, 0xbf,
+	0x6b, 0xf8, 0x41, 0xf6, 0xa4, 0x14, 0x2c, 0x8b, 0x32, 0xdd, 0xbe, 0x61, 0x57, 0x75, 0xf5, 0xf1,
+	0x6e, 0x57, 0xa7, 0x8a, 0x66, 0x3e, 0x9b, 0xde, 0xf6, 0xe3, 0x3b, 0xe0, 0xe8, 0x33, 0x78, 0x77,
+	0x9a, 0xbf, 0x3d, 0x3f, 0xa7, 0x99, 0xdf, 0x33, 0x38, 0x59, 0xd1, 0x65, 0x65, 0xb6, 0xef, 0x42,
+	0xa6, 0xd9, 0x59, 0xf4, 0xb0, 0x61, 0xbc, 0xb2, 0x5f, 0x58, 0xa3, 0x37, 0x70, 0xb1, 0x27, 0xf1,
+	0x9e, 0xbd, 0xb8, 0xbf, 0x1d, 0xb7, 0xbb, 0x15, 0xc2, 0xff, 0x65, 0xc1, 0x99, 0x19, 0x19, 0x01,
+	0x47, 0x79, 0x94, 0xc2, 0x5e, 0xe8, 0xec, 0x3d, 0x2b, 0xfb, 0x3f, 0xcf, 0xaa, 0x73, 0xdc, 0x59,
+	0xf9, 0xdf, 0xc1, 0xfd, 0x5a, 0xcc, 0x39, 0x4d, 0xf0, 0x5d, 0x3e, 0x63, 0xb2, 0x9c, 0x52, 0x50,
+	0x7e, 0xcc, 0x95, 0x6b, 0xbe, 0x2a, 0x47, 0x3a, 0x64, 0x39, 0xea, 0x5f, 0x8e, 0x7a, 0xdf, 0x9e,
+	0x2a, 0xd1, 0xf3, 0xbf, 0x03, 0x00, 0x1e, 0xe9, 0x26, 0xe1, 0xfd, 0x04, 0x00, 0x00,
 }
@@ -6,12 +6,14 @@ import (
 	"fmt"
 	"path"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/golang/protobuf/proto"
 	"github.com/golang/protobuf/ptypes"
 	"github.com/hashicorp/vault/helper/consts"
+	"github.com/hashicorp/vault/helper/jsonutil"
 	"github.com/hashicorp/vault/helper/locksutil"
 	"github.com/hashicorp/vault/helper/pluginutil"
 	"github.com/hashicorp/vault/logical"
@@ -36,6 +38,96 @@ func (b *versionedKVBackend) upgradeCheck(next framework.OperationFunc) framewor
 	}
 }
 
+// upgradeReadCheck lets reads through while the backend is upgrading. The keys
+// which haven't been upgraded yet are read from the unversioned data and
+// returned as their first version.
+func (b *versionedKVBackend) upgradeReadCheck(next framework.OperationFunc) framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+		if atomic.LoadUint32(b.upgrading) == 0 {
+			return next(ctx, req, data)
+		}
+
+		resp, err := next(ctx, req, data)
+		if err != nil || resp != nil {
+			return resp, err
+		}
+		if data.Get("version").(int) > 1 {
+			return nil, nil
+		}
+
+		key := data.Get("path").(string)
+		lock := locksutil.LockForKey(b.locks, key)
+		lock.RLock()
+		raw, err := req.Storage.Get(ctx, key)
+		lock.RUnlock()
+		if err != nil {
+			return nil, err
+		}
+		if raw == nil {
+			// The key may have been upgraded since it was looked up
+			return next(ctx, req, data)
+		}
+
+		vData := map[string]interface{}{}
+		if err := jsonutil.DecodeJSON(raw.Value, &vData); err != nil {
+			return nil, err
+		}
+
+		return &logical.Response{
+			Data: map[string]interface{}{
+				"data": vData,
+				"metadata": map[string]interface{}{
+					"version":         uint64(1),
+					"created_time":    "",
+					"deletion_time":   "",
+					"destroyed":       false,
+					"expiration_time": "",
+				},
+			},
+		}, nil
+	}
+}
+
+// upgradeProgress tracks the progress of an upgrade, reported by the upgrade
+// endpoint. It is only kept in memory: an upgrade interrupted by a restart
+// starts over, skipping the keys already upgraded.
+type upgradeProgress struct {
+	l sync.RWMutex
+
+	keysTotal    int
+	keysUpgraded int
+	finishedTime time.Time
+	lastError    string
+}
+
+func (p *upgradeProgress) start(keysTotal int) {
+	p.l.Lock()
+	defer p.l.Unlock()
+
+	p.keysTotal = keysTotal
+	p.keysUpgraded = 0
+	p.finishedTime = time.Time{}
+	p.lastError = ""
+}
+
+func (p *upgradeProgress) keyUpgraded() {
+	p.l.Lock()
+	defer p.l.Unlock()
+
+	p.keysUpgraded++
+}
+
+func (p *upgradeProgress) finish(err error) {
+	p.l.Lock()
+	defer p.l.Unlock()
+
+	if err != nil {
+		p.lastError = err.Error()
+		return
+	}
+	p.finishedTime = time.Now()
+}
+
 func (b *versionedKVBackend) Upgrade(ctx context.Context, s logical.Storage) error {
 	// Don't run if the plugin is in metadata mode.
 	if pluginutil.InMetadataMode() {
@@ -102,6 +194,9 @@ func (b *versionedKVBackend) Upgrade(ctx context.Context, s logical.Storage) err
 		if err != nil {
 			return err
 		}
+		if data == nil {
+			return nil
+		}
 
 		locksutil.LockForKey(b.locks, key).Lock()
 		defer locksutil.LockForKey(b.locks, key).Unlock()
@@ -177,19 +272,31 @@ func (b *versionedKVBackend) Upgrade(ctx context.Context, s logical.Storage) err
 		keys, err := logical.CollectKeys(ctx, s)
 		if err != nil {
 			b.Logger().Error("upgrading resulted in error", "error", err)
+			b.upgradeProgress.finish(err)
 			return
 		}
 
-		b.Logger().Info("done collecting keys", "num_keys", len(keys))
-		for i, key := range keys {
+		// Only count the unversioned keys left to upgrade
+		var pending []string
+		for _, key := range keys {
+			if !strings.HasPrefix(key, b.storagePrefix) {
+				pending = append(pending, key)
+			}
+		}
+		b.upgradeProgress.start(len(pending))
+
+		b.Logger().Info("done collecting keys", "num_keys", len(pending))
+		for i, key := range pending {
 			if b.Logger().IsDebug() && i%500 == 0 {
-				b.Logger().Debug("upgrading keys", "progress", fmt.Sprintf("%d/%d", i, len(keys)))
+				b.Logger().Debug("upgrading keys", "progress", fmt.Sprintf("%d/%d", i, len(pending)))
 			}
 			err := upgradeKey(key)
 			if err != nil {
-				b.Logger().Error("upgrading resulted in error", "error", err, "progress", fmt.Sprintf("%d/%d", i+1, len(keys)))
+				b.Logger().Error("upgrading resulted in error", "error", err, "progress", fmt.Sprintf("%d/%d", i+1, len(pending)))
+				b.upgradeProgress.finish(err)
 				return
 			}
+			b.upgradeProgress.keyUpgraded()
 		}
 
 		b.Logger().Info("upgrading keys finished")
@@ -209,6 +316,7 @@ func (b *versionedKVBackend) Upgrade(ctx context.Context, s logical.Storage) err
 			b.Logger().Error("writing upgrade done resulted in an error", "error", err)
 		}
 
+		b.upgradeProgress.finish(nil)
 		atomic.StoreUint32(b.upgrading, 0)
 	}()
 
@@ -0,0 +1,75 @@
+package mongodbatlas
+
+import (
+	"context"
+	"strings"
+
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+// Factory returns a MongoDB Atlas backend that satisfies the logical.Backend
+// interface
+func Factory(ctx context.Context, conf *logical.BackendConfig) (logical.Backend, error) {
+	b := Backend()
+	if err := b.Setup(ctx, conf); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Backend returns the MongoDB Atlas backend
+func Backend() *backend {
+	var b backend
+	b.Backend = &framework.Backend{
+		Help: strings.TrimSpace(backendHelp),
+
+		PathsSpecial: &logical.Paths{
+			SealWrapStorage: []string{
+				"config",
+			},
+		},
+
+		Paths: []*framework.Path{
+			pathConfig(&b),
+			framework.PathConfigVerify("config", b.verifyConfig),
+			pathListRoles(&b),
+			pathRoles(&b),
+			pathCreds(&b),
+		},
+
+		Secrets: []*framework.Secret{
+			secretProgrammaticAPIKey(&b),
+		},
+		BackendType: logical.TypeLogical,
+	}
+
+	return &b
+}
+
+type backend struct {
+	*framework.Backend
+}
+
+// client returns a client of the Atlas API using the stored configuration
+func (b *backend) client(ctx context.Context, s logical.Storage) (*client, error) {
+	conf, err := b.readConfig(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+	if conf == nil {
+		return nil, errNotConfigured
+	}
+
+	return clientFromConfig(conf), nil
+}
+
+const backendHelp = `
+The MongoDB Atlas backend issues programmatic API keys of MongoDB Atlas.
+
+Roles create keys either in an organization or in a project, with the given
+Atlas roles and IP access list, and the keys are deleted when their lease is
+revoked. After configuring the API key used by Vault with the "config" path,
+create roles with the "roles/" endpoints and request keys with the "creds/"
+endpoints.
+`
@@ -0,0 +1,309 @@
+package mongodbatlas
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/hashicorp/vault/logical"
+)
+
+// testAtlasAPI is a fake of the parts of the Atlas API used by the backend,
+// checking the digest authentication of the requests
+type testAtlasAPI struct {
+	l          sync.Mutex
+	keys       map[string]*apiKey
+	keyOrgs    map[string]string
+	accessList map[string][]accessListEntry
+	nextID     int
+}
+
+func (a *testAtlasAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	a.l.Lock()
+	defer a.l.Unlock()
+
+	if !a.authenticated(r) {
+		w.Header().Set("WWW-Authenticate", `Digest realm="MMS Public API", domain="", nonce="test-nonce", algorithm=MD5, qop="auth", stale=false`)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	// Paths are /api/atlas/v1.0/orgs[/<org>/apiKeys[/<key>[/accessList]]]
+	// and /api/atlas/v1.0/groups/<project>[/apiKeys]
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/atlas/v1.0/"), "/")
+	switch {
+	case len(parts) == 1 && parts[0] == "orgs":
+		w.Write([]byte(`{"results": []}`))
+
+	case len(parts) == 2 && parts[0] == "groups" && r.Method == http.MethodGet:
+		json.NewEncoder(w).Encode(&project{ID: parts[1], OrgID: "org-of-" + parts[1]})
+
+	case len(parts) == 3 && parts[2] == "apiKeys" && r.Method == http.MethodPost:
+		var req apiKeyCreateRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		a.nextID++
+		key := &apiKey{
+			ID:         fmt.Sprintf("key-%d", a.nextID),
+			Desc:       req.Desc,
+			PublicKey:  fmt.Sprintf("public-%d", a.nextID),
+			PrivateKey: fmt.Sprintf("private-%d", a.nextID),
+		}
+		for _, role := range req.Roles {
+			key.Roles = append(key.Roles, apiKeyRole{RoleName: role})
+		}
+		a.keys[key.ID] = key
+		if parts[0] == "groups" {
+			a.keyOrgs[key.ID] = "org-of-" + parts[1]
+		} else {
+			a.keyOrgs[key.ID] = parts[1]
+		}
+		json.NewEncoder(w).Encode(key)
+
+	case len(parts) == 5 && parts[4] == "accessList" && r.Method == http.MethodPost:
+		if a.keyOrgs[parts[3]] != parts[1] {
+			a.notFound(w)
+			return
+		}
+		var entries []accessListEntry
+		json.NewDecoder(r.Body).Decode(&entries)
+		a.accessList[parts[3]] = append(a.accessList[parts[3]], entries...)
+		w.Write([]byte(`{}`))
+
+	case len(parts) == 4 && parts[2] == "apiKeys" && r.Method == http.MethodDelete:
+		if a.keyOrgs[parts[3]] != parts[1] {
+			a.notFound(w)
+			return
+		}
+		delete(a.keys, parts[3])
+
+	default:
+		a.notFound(w)
+	}
+}
+
+func (a *testAtlasAPI) notFound(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusNotFound)
+	w.Write([]byte(`{"error": 404, "errorCode": "RESOURCE_NOT_FOUND", "detail": "not found"}`))
+}
+
+// authenticated checks the digest response of the request, computed with the
+// public key "public" and the private key "private"
+func (a *testAtlasAPI) authenticated(r *http.Request) bool {
+	authorization := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authorization, "Digest ") {
+		return false
+	}
+	params := parseDigestChallenge(strings.TrimPrefix(authorization, "Digest "))
+	if params["username"] != "public" || params["uri"] != r.URL.Path || params["nonce"] != "test-nonce" {
+		return false
+	}
+
+	ha1 := md5Hex("public:" + params["realm"] + ":private")
+	ha2 := md5Hex(r.Method + ":" + params["uri"])
+	expected := md5Hex(strings.Join([]string{ha1, params["nonce"], params["nc"], params["cnonce"], params["qop"], ha2}, ":"))
+	return params["response"] == expected
+}
+
+func testBackend(t *testing.T) (*backend, logical.Storage, *testAtlasAPI, func()) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+	b := Backend()
+	if err := b.Setup(context.Background(), config); err != nil {
+		t.Fatal(err)
+	}
+
+	api := &testAtlasAPI{
+		keys:       map[string]*apiKey{},
+		keyOrgs:    map[string]string{},
+		accessList: map[string][]accessListEntry{},
+	}
+	server := httptest.NewServer(api)
+
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "config",
+		Storage:   config.StorageView,
+		Data: map[string]interface{}{
+			"public_key":  "public",
+			"private_key": "private",
+			"base_url":    server.URL + "/api/atlas/v1.0",
+		},
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		server.Close()
+		t.Fatalf("err: %v, resp: %#v", err, resp)
+	}
+
+	return b, config.StorageView, api, server.Close
+}
+
+func TestBackend_Config(t *testing.T) {
+	b, storage, _, cleanup := testBackend(t)
+	defer cleanup()
+
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "config",
+		Storage:   storage,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := resp.Data["private_key"]; ok || resp.Data["public_key"] != "public" {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "config/verify",
+		Storage:   storage,
+	})
+	if err != nil || resp.IsError() {
+		t.Fatalf("err: %v, resp: %#v", err, resp)
+	}
+
+	// A wrong private key is rejected
+	if _, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "config",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"private_key": "wrong",
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "config/verify",
+		Storage:   storage,
+	})
+	if err != nil || !resp.IsError() {
+		t.Fatalf("expected verification to fail: err: %v, resp: %#v", err, resp)
+	}
+}
+
+func TestBackend_Roles(t *testing.T) {
+	b, storage, _, cleanup := testBackend(t)
+	defer cleanup()
+
+	for _, data := range []map[string]interface{}{
+		{"roles": "ORG_READ_ONLY"},
+		{"organization_id": "org", "project_id": "project", "roles": "ORG_READ_ONLY"},
+		{"organization_id": "org"},
+		{"organization_id": "org", "roles": "ORG_READ_ONLY", "ip_addresses": "10.0.0"},
+		{"organization_id": "org", "roles": "ORG_READ_ONLY", "cidr_blocks": "10.0.0.1"},
+		{"organization_id": "org", "roles": "ORG_READ_ONLY", "ttl": "2h", "max_ttl": "1h"},
+	} {
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation: logical.CreateOperation,
+			Path:      "roles/invalid",
+			Storage:   storage,
+			Data:      data,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp == nil || !resp.IsError() {
+			t.Fatalf("expected an error with %v", data)
+		}
+	}
+}
+
+func TestBackend_OrganizationCreds(t *testing.T) {
+	b, storage, api, cleanup := testBackend(t)
+	defer cleanup()
+
+	if _, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.CreateOperation,
+		Path:      "roles/org",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"organization_id": "org",
+			"roles":           "ORG_READ_ONLY",
+			"ip_addresses":    "192.0.2.1",
+			"cidr_blocks":     "198.51.100.0/24",
+			"ttl":             "1h",
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "creds/org",
+		Storage:   storage,
+	})
+	if err != nil || resp.IsError() {
+		t.Fatalf("err: %v, resp: %#v", err, resp)
+	}
+	if resp.Data["public_key"] != "public-1" || resp.Data["private_key"] != "private-1" || resp.Secret.TTL.Hours() != 1 {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	key := api.keys["key-1"]
+	if key == nil || key.Roles[0].RoleName != "ORG_READ_ONLY" || !strings.HasPrefix(key.Desc, "vault-org-") {
+		t.Fatalf("bad key: %#v", key)
+	}
+	accessList := api.accessList["key-1"]
+	if len(accessList) != 2 || accessList[0].IPAddress != "192.0.2.1" || accessList[1].CIDRBlock != "198.51.100.0/24" {
+		t.Fatalf("bad access list: %#v", accessList)
+	}
+
+	if _, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.RevokeOperation,
+		Storage:   storage,
+		Secret:    resp.Secret,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if api.keys["key-1"] != nil {
+		t.Fatal("expected the key to be deleted")
+	}
+}
+
+func TestBackend_ProjectCreds(t *testing.T) {
+	b, storage, api, cleanup := testBackend(t)
+	defer cleanup()
+
+	if _, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.CreateOperation,
+		Path:      "roles/project",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"project_id": "project",
+			"roles":      "GROUP_READ_ONLY",
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "creds/project",
+		Storage:   storage,
+	})
+	if err != nil || resp.IsError() {
+		t.Fatalf("err: %v, resp: %#v", err, resp)
+	}
+	if api.keyOrgs["key-1"] != "org-of-project" || resp.Secret.InternalData["organization_id"] != "org-of-project" {
+		t.Fatalf("bad: %#v", resp.Secret.InternalData)
+	}
+
+	// Keys are deleted from the organization of the project
+	if _, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.RevokeOperation,
+		Storage:   storage,
+		Secret:    resp.Secret,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if api.keys["key-1"] != nil {
+		t.Fatal("expected the key to be deleted")
+	}
+}
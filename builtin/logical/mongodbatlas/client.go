@@ -0,0 +1,282 @@
+package mongodbatlas
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	cleanhttp "github.com/hashicorp/go-cleanhttp"
+)
+
+// defaultBaseURL is the URL of the Atlas API
+const defaultBaseURL = "https://cloud.mongodb.com/api/atlas/v1.0"
+
+var errNotConfigured = errors.New("the MongoDB Atlas backend is not configured")
+
+// apiKey is a programmatic API key of Atlas
+type apiKey struct {
+	ID         string       `json:"id,omitempty"`
+	Desc       string       `json:"desc"`
+	Roles      []apiKeyRole `json:"roles,omitempty"`
+	PublicKey  string       `json:"publicKey,omitempty"`
+	PrivateKey string       `json:"privateKey,omitempty"`
+}
+
+type apiKeyRole struct {
+	GroupID  string `json:"groupId,omitempty"`
+	OrgID    string `json:"orgId,omitempty"`
+	RoleName string `json:"roleName"`
+}
+
+// apiKeyCreateRequest is the request creating an API key
+type apiKeyCreateRequest struct {
+	Desc  string   `json:"desc"`
+	Roles []string `json:"roles"`
+}
+
+// accessListEntry is an entry of the IP access list of an API key
+type accessListEntry struct {
+	IPAddress string `json:"ipAddress,omitempty"`
+	CIDRBlock string `json:"cidrBlock,omitempty"`
+}
+
+type project struct {
+	ID    string `json:"id"`
+	OrgID string `json:"orgId"`
+}
+
+// apiError is an error returned by the Atlas API
+type apiError struct {
+	StatusCode int    `json:"error"`
+	ErrorCode  string `json:"errorCode"`
+	Detail     string `json:"detail"`
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("%d (%s): %s", e.StatusCode, e.ErrorCode, e.Detail)
+}
+
+func isNotFound(err error) bool {
+	apiErr, ok := err.(*apiError)
+	return ok && apiErr.StatusCode == http.StatusNotFound
+}
+
+// client is a minimal client of the Atlas API, authenticating with HTTP
+// digest authentication as the API requires
+type client struct {
+	baseURL    string
+	publicKey  string
+	privateKey string
+	httpClient *http.Client
+}
+
+func clientFromConfig(conf *atlasConfig) *client {
+	baseURL := conf.BaseURL
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
+
+	return &client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		publicKey:  conf.PublicKey,
+		privateKey: conf.PrivateKey,
+		httpClient: cleanhttp.DefaultClient(),
+	}
+}
+
+// do sends a request to the Atlas API, encoding in as the body of the request
+// and decoding the body of the response into out, if not nil
+func (c *client) do(method, path string, in, out interface{}) error {
+	var body []byte
+	if in != nil {
+		var err error
+		body, err = json.Marshal(in)
+		if err != nil {
+			return err
+		}
+	}
+
+	resp, err := c.send(method, path, body, "")
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode == http.StatusUnauthorized && strings.HasPrefix(resp.Header.Get("WWW-Authenticate"), "Digest ") {
+		resp.Body.Close()
+
+		authorization, err := c.digestAuthorization(method, path, resp.Header.Get("WWW-Authenticate"))
+		if err != nil {
+			return err
+		}
+		resp, err = c.send(method, path, body, authorization)
+		if err != nil {
+			return err
+		}
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		apiErr := &apiError{}
+		if err := json.Unmarshal(respBody, apiErr); err != nil || apiErr.Detail == "" {
+			apiErr.Detail = string(respBody)
+		}
+		apiErr.StatusCode = resp.StatusCode
+		return apiErr
+	}
+
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(respBody, out)
+}
+
+func (c *client) send(method, path string, body []byte, authorization string) (*http.Response, error) {
+	var reqBody io.Reader
+	if body != nil {
+		reqBody = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequest(method, c.baseURL+path, reqBody)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+
+	return c.httpClient.Do(req)
+}
+
+// digestAuthorization computes the Authorization header answering the digest
+// challenge of the API, as specified by RFC 2617 with the MD5 algorithm and
+// the "auth" quality of protection
+func (c *client) digestAuthorization(method, path, challenge string) (string, error) {
+	params := parseDigestChallenge(strings.TrimPrefix(challenge, "Digest "))
+	if algorithm := params["algorithm"]; algorithm != "" && !strings.EqualFold(algorithm, "MD5") {
+		return "", fmt.Errorf("unsupported digest algorithm %q", algorithm)
+	}
+
+	uri := path
+	if i := strings.Index(c.baseURL, "://"); i >= 0 {
+		if j := strings.Index(c.baseURL[i+3:], "/"); j >= 0 {
+			uri = c.baseURL[i+3+j:] + path
+		}
+	}
+
+	cnonceRaw := make([]byte, 16)
+	if _, err := rand.Read(cnonceRaw); err != nil {
+		return "", err
+	}
+	cnonce := hex.EncodeToString(cnonceRaw)
+	nc := "00000001"
+
+	ha1 := md5Hex(c.publicKey + ":" + params["realm"] + ":" + c.privateKey)
+	ha2 := md5Hex(method + ":" + uri)
+
+	var response string
+	qop := ""
+	if params["qop"] != "" {
+		qop = "auth"
+		response = md5Hex(strings.Join([]string{ha1, params["nonce"], nc, cnonce, qop, ha2}, ":"))
+	} else {
+		response = md5Hex(ha1 + ":" + params["nonce"] + ":" + ha2)
+	}
+
+	authorization := fmt.Sprintf(`Digest username="%s", realm="%s", nonce="%s", uri="%s", response="%s", algorithm=MD5`,
+		c.publicKey, params["realm"], params["nonce"], uri, response)
+	if qop != "" {
+		authorization += fmt.Sprintf(`, qop=%s, nc=%s, cnonce="%s"`, qop, nc, cnonce)
+	}
+	if params["opaque"] != "" {
+		authorization += fmt.Sprintf(`, opaque="%s"`, params["opaque"])
+	}
+
+	return authorization, nil
+}
+
+// parseDigestChallenge parses the comma-separated key="value" parameters of
+// a digest challenge
+func parseDigestChallenge(challenge string) map[string]string {
+	params := map[string]string{}
+	for _, part := range strings.Split(challenge, ",") {
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		params[strings.ToLower(kv[0])] = strings.Trim(kv[1], `"`)
+	}
+	return params
+}
+
+func md5Hex(s string) string {
+	sum := md5.Sum([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+// listOrganizations lists the organizations the configured key can access
+func (c *client) listOrganizations() error {
+	return c.do(http.MethodGet, "/orgs", nil, nil)
+}
+
+// project returns a project, to find its organization
+func (c *client) project(projectID string) (*project, error) {
+	var p project
+	if err := c.do(http.MethodGet, "/groups/"+projectID, nil, &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// createOrganizationAPIKey creates an API key of an organization
+func (c *client) createOrganizationAPIKey(orgID, desc string, roles []string) (*apiKey, error) {
+	var key apiKey
+	if err := c.do(http.MethodPost, "/orgs/"+orgID+"/apiKeys", &apiKeyCreateRequest{
+		Desc:  desc,
+		Roles: roles,
+	}, &key); err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// createProjectAPIKey creates an API key of the organization of a project,
+// assigned to the project
+func (c *client) createProjectAPIKey(projectID, desc string, roles []string) (*apiKey, error) {
+	var key apiKey
+	if err := c.do(http.MethodPost, "/groups/"+projectID+"/apiKeys", &apiKeyCreateRequest{
+		Desc:  desc,
+		Roles: roles,
+	}, &key); err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// addAccessListEntries restricts the IP addresses allowed to use an API key
+func (c *client) addAccessListEntries(orgID, keyID string, entries []accessListEntry) error {
+	return c.do(http.MethodPost, "/orgs/"+orgID+"/apiKeys/"+keyID+"/accessList", entries, nil)
+}
+
+// deleteAPIKey deletes an API key of an organization, which also removes it
+// from its projects. Keys which don't exist are ignored.
+func (c *client) deleteAPIKey(orgID, keyID string) error {
+	err := c.do(http.MethodDelete, "/orgs/"+orgID+"/apiKeys/"+keyID, nil, nil)
+	if isNotFound(err) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,158 @@
+package mongodbatlas
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+const configPath = "config"
+
+func pathConfig(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "config$",
+		Fields: map[string]*framework.FieldSchema{
+			"public_key": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Public key of the programmatic API key used by Vault",
+			},
+
+			"private_key": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Private key of the programmatic API key used by Vault",
+			},
+
+			"base_url": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Default:     defaultBaseURL,
+				Description: "URL of the Atlas API",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation:   b.pathConfigRead,
+			logical.UpdateOperation: b.pathConfigWrite,
+			logical.DeleteOperation: b.pathConfigDelete,
+		},
+
+		HelpSynopsis:    pathConfigHelpSyn,
+		HelpDescription: pathConfigHelpDesc,
+	}
+}
+
+func (b *backend) readConfig(ctx context.Context, s logical.Storage) (*atlasConfig, error) {
+	entry, err := s.Get(ctx, configPath)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	conf := &atlasConfig{}
+	if err := entry.DecodeJSON(conf); err != nil {
+		return nil, errwrap.Wrapf("error reading mongodb atlas configuration: {{err}}", err)
+	}
+
+	return conf, nil
+}
+
+// verifyConfig lists the organizations the configured key can access to
+// check that the Atlas API can be reached and that the key is accepted
+func (b *backend) verifyConfig(ctx context.Context, s logical.Storage) error {
+	c, err := b.client(ctx, s)
+	if err != nil {
+		return err
+	}
+
+	if err := c.listOrganizations(); err != nil {
+		return errwrap.Wrapf("error listing mongodb atlas organizations: {{err}}", err)
+	}
+
+	return nil
+}
+
+func (b *backend) pathConfigRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	conf, err := b.readConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if conf == nil {
+		return nil, nil
+	}
+
+	// The private key is not returned
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"public_key": conf.PublicKey,
+			"base_url":   conf.BaseURL,
+		},
+	}, nil
+}
+
+func (b *backend) pathConfigWrite(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	conf, err := b.readConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if conf == nil {
+		conf = &atlasConfig{
+			BaseURL: defaultBaseURL,
+		}
+	}
+
+	if publicKey, ok := data.GetOk("public_key"); ok {
+		conf.PublicKey = publicKey.(string)
+	}
+	if privateKey, ok := data.GetOk("private_key"); ok {
+		conf.PrivateKey = privateKey.(string)
+	}
+	if conf.PublicKey == "" || conf.PrivateKey == "" {
+		return logical.ErrorResponse("public_key and private_key are required"), nil
+	}
+
+	if baseURL, ok := data.GetOk("base_url"); ok {
+		conf.BaseURL = baseURL.(string)
+	}
+	if u, err := url.Parse(conf.BaseURL); err != nil || u.Scheme == "" || u.Host == "" {
+		return logical.ErrorResponse("base_url must be an absolute URL"), nil
+	}
+
+	entry, err := logical.StorageEntryJSON(configPath, conf)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+func (b *backend) pathConfigDelete(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	if err := req.Storage.Delete(ctx, configPath); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+// atlasConfig contains the programmatic API key used by the backend
+type atlasConfig struct {
+	PublicKey  string `json:"public_key"`
+	PrivateKey string `json:"private_key"`
+	BaseURL    string `json:"base_url"`
+}
+
+const pathConfigHelpSyn = `
+Configure the programmatic API key used to access MongoDB Atlas.
+`
+
+const pathConfigHelpDesc = `
+This path configures the programmatic API key used by Vault to create and
+delete the keys it issues. It must have the Organization Owner role in the
+organizations of the roles, and its own IP access list must allow the Vault
+servers. The private key is never returned once written.
+`
@@ -0,0 +1,109 @@
+package mongodbatlas
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+// maxDescLength is the maximum length of the description of an API key
+const maxDescLength = 250
+
+func pathCreds(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "creds/" + framework.GenericNameRegex("name"),
+		Fields: map[string]*framework.FieldSchema{
+			"name": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Name of the role",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation: b.pathCredsRead,
+		},
+
+		HelpSynopsis:    pathCredsHelpSyn,
+		HelpDescription: pathCredsHelpDesc,
+	}
+}
+
+func (b *backend) pathCredsRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	role, err := b.Role(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return logical.ErrorResponse(fmt.Sprintf("role %q not found", name)), nil
+	}
+
+	c, err := b.client(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	desc := fmt.Sprintf("vault-%s-%s-%d", name, req.DisplayName, time.Now().UnixNano())
+	if len(desc) > maxDescLength {
+		desc = desc[:maxDescLength]
+	}
+
+	// Project keys belong to the organization of the project, where they
+	// are deleted and their access list is managed
+	organizationID := role.OrganizationID
+	var key *apiKey
+	if role.ProjectID != "" {
+		project, err := c.project(role.ProjectID)
+		if err != nil {
+			return nil, errwrap.Wrapf("error looking up project: {{err}}", err)
+		}
+		organizationID = project.OrgID
+
+		key, err = c.createProjectAPIKey(role.ProjectID, desc, role.Roles)
+		if err != nil {
+			return nil, errwrap.Wrapf("error creating project API key: {{err}}", err)
+		}
+	} else {
+		key, err = c.createOrganizationAPIKey(role.OrganizationID, desc, role.Roles)
+		if err != nil {
+			return nil, errwrap.Wrapf("error creating organization API key: {{err}}", err)
+		}
+	}
+
+	if accessList := role.accessList(); len(accessList) != 0 {
+		if err := c.addAccessListEntries(organizationID, key.ID, accessList); err != nil {
+			if deleteErr := c.deleteAPIKey(organizationID, key.ID); deleteErr != nil {
+				b.Logger().Error("failed to delete the API key", "organization_id", organizationID, "api_key_id", key.ID, "error", deleteErr)
+			}
+			return nil, errwrap.Wrapf("error adding access list entries: {{err}}", err)
+		}
+	}
+
+	resp := b.Secret(secretProgrammaticAPIKeyType).Response(map[string]interface{}{
+		"public_key":  key.PublicKey,
+		"private_key": key.PrivateKey,
+	}, map[string]interface{}{
+		"api_key_id":      key.ID,
+		"organization_id": organizationID,
+		"role":            name,
+	})
+	resp.Secret.TTL = role.TTL
+	resp.Secret.MaxTTL = role.MaxTTL
+
+	return resp, nil
+}
+
+const pathCredsHelpSyn = `
+Request a MongoDB Atlas programmatic API key for a role.
+`
+
+const pathCredsHelpDesc = `
+This path creates a programmatic API key with the Atlas roles and IP access
+list of the named role, in its organization or assigned to its project. The
+key is deleted from Atlas when its lease is revoked.
+`
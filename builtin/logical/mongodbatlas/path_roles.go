@@ -0,0 +1,256 @@
+package mongodbatlas
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+func pathListRoles(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "roles/?$",
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ListOperation: b.pathRoleList,
+		},
+
+		HelpSynopsis:    pathRolesHelpSyn,
+		HelpDescription: pathRolesHelpDesc,
+	}
+}
+
+func pathRoles(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "roles/" + framework.GenericNameRegex("name"),
+		Fields: map[string]*framework.FieldSchema{
+			"name": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Name of the role",
+			},
+
+			"organization_id": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "ID of the organization of the keys. Mutually exclusive with project_id.",
+			},
+
+			"project_id": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "ID of the project the keys are assigned to. Mutually exclusive with organization_id.",
+			},
+
+			"roles": &framework.FieldSchema{
+				Type:        framework.TypeCommaStringSlice,
+				Description: `Comma-separated string or list of the Atlas roles of the keys, e.g. "ORG_READ_ONLY" or "GROUP_DATA_ACCESS_READ_ONLY".`,
+			},
+
+			"ip_addresses": &framework.FieldSchema{
+				Type:        framework.TypeCommaStringSlice,
+				Description: "Comma-separated string or list of the IP addresses allowed to use the keys.",
+			},
+
+			"cidr_blocks": &framework.FieldSchema{
+				Type:        framework.TypeCommaStringSlice,
+				Description: "Comma-separated string or list of the CIDR blocks allowed to use the keys.",
+			},
+
+			"ttl": &framework.FieldSchema{
+				Type:        framework.TypeDurationSecond,
+				Description: "Default lease of the keys. If unset, the default lease TTL of the mount is used.",
+			},
+
+			"max_ttl": &framework.FieldSchema{
+				Type:        framework.TypeDurationSecond,
+				Description: "Maximum lease of the keys. If unset, the maximum lease TTL of the mount is used.",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation:   b.pathRolesRead,
+			logical.CreateOperation: b.pathRolesWrite,
+			logical.UpdateOperation: b.pathRolesWrite,
+			logical.DeleteOperation: b.pathRolesDelete,
+		},
+
+		ExistenceCheck: b.rolesExistenceCheck,
+
+		HelpSynopsis:    pathRolesHelpSyn,
+		HelpDescription: pathRolesHelpDesc,
+	}
+}
+
+func (b *backend) rolesExistenceCheck(ctx context.Context, req *logical.Request, d *framework.FieldData) (bool, error) {
+	entry, err := b.Role(ctx, req.Storage, d.Get("name").(string))
+	if err != nil {
+		return false, err
+	}
+	return entry != nil, nil
+}
+
+func (b *backend) Role(ctx context.Context, storage logical.Storage, name string) (*roleConfig, error) {
+	if name == "" {
+		return nil, errors.New("invalid role name")
+	}
+
+	entry, err := storage.Get(ctx, "roles/"+name)
+	if err != nil {
+		return nil, errwrap.Wrapf("error retrieving role: {{err}}", err)
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var result roleConfig
+	if err := entry.DecodeJSON(&result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (b *backend) pathRoleList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	entries, err := req.Storage.List(ctx, "roles/")
+	if err != nil {
+		return nil, err
+	}
+
+	return logical.ListResponse(entries), nil
+}
+
+func (b *backend) pathRolesRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	role, err := b.Role(ctx, req.Storage, d.Get("name").(string))
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return nil, nil
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"organization_id": role.OrganizationID,
+			"project_id":      role.ProjectID,
+			"roles":           role.Roles,
+			"ip_addresses":    role.IPAddresses,
+			"cidr_blocks":     role.CIDRBlocks,
+			"ttl":             int64(role.TTL.Seconds()),
+			"max_ttl":         int64(role.MaxTTL.Seconds()),
+		},
+	}, nil
+}
+
+func (b *backend) pathRolesWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	role, err := b.Role(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		role = &roleConfig{}
+	}
+
+	if organizationID, ok := d.GetOk("organization_id"); ok {
+		role.OrganizationID = organizationID.(string)
+	}
+	if projectID, ok := d.GetOk("project_id"); ok {
+		role.ProjectID = projectID.(string)
+	}
+	switch {
+	case role.OrganizationID == "" && role.ProjectID == "":
+		return logical.ErrorResponse("one of organization_id or project_id is required"), nil
+	case role.OrganizationID != "" && role.ProjectID != "":
+		return logical.ErrorResponse("organization_id and project_id are mutually exclusive"), nil
+	}
+
+	if roles, ok := d.GetOk("roles"); ok {
+		role.Roles = roles.([]string)
+	}
+	if len(role.Roles) == 0 {
+		return logical.ErrorResponse("at least one role is required"), nil
+	}
+
+	if ipAddresses, ok := d.GetOk("ip_addresses"); ok {
+		role.IPAddresses = ipAddresses.([]string)
+	}
+	for _, ip := range role.IPAddresses {
+		if net.ParseIP(ip) == nil {
+			return logical.ErrorResponse(fmt.Sprintf("invalid IP address %q", ip)), nil
+		}
+	}
+	if cidrBlocks, ok := d.GetOk("cidr_blocks"); ok {
+		role.CIDRBlocks = cidrBlocks.([]string)
+	}
+	for _, cidr := range role.CIDRBlocks {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			return logical.ErrorResponse(fmt.Sprintf("invalid CIDR block %q", cidr)), nil
+		}
+	}
+
+	if ttl, ok := d.GetOk("ttl"); ok {
+		role.TTL = time.Duration(ttl.(int)) * time.Second
+	}
+	if maxTTL, ok := d.GetOk("max_ttl"); ok {
+		role.MaxTTL = time.Duration(maxTTL.(int)) * time.Second
+	}
+	if role.MaxTTL != 0 && role.TTL > role.MaxTTL {
+		return logical.ErrorResponse("ttl cannot be greater than max_ttl"), nil
+	}
+
+	entry, err := logical.StorageEntryJSON("roles/"+name, role)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+func (b *backend) pathRolesDelete(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	if err := req.Storage.Delete(ctx, "roles/"+d.Get("name").(string)); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+type roleConfig struct {
+	OrganizationID string        `json:"organization_id"`
+	ProjectID      string        `json:"project_id"`
+	Roles          []string      `json:"roles"`
+	IPAddresses    []string      `json:"ip_addresses"`
+	CIDRBlocks     []string      `json:"cidr_blocks"`
+	TTL            time.Duration `json:"ttl"`
+	MaxTTL         time.Duration `json:"max_ttl"`
+}
+
+// accessList returns the IP access list of the keys of the role
+func (r *roleConfig) accessList() []accessListEntry {
+	var entries []accessListEntry
+	for _, ip := range r.IPAddresses {
+		entries = append(entries, accessListEntry{IPAddress: ip})
+	}
+	for _, cidr := range r.CIDRBlocks {
+		entries = append(entries, accessListEntry{CIDRBlock: cidr})
+	}
+	return entries
+}
+
+const pathRolesHelpSyn = `
+Manage the roles used to issue MongoDB Atlas programmatic API keys.
+`
+
+const pathRolesHelpDesc = `
+A role creates keys either in an organization, with organization_id, or
+assigned to a project, with project_id. The keys have the Atlas roles given in
+"roles", which must be organization roles for organization keys and project
+roles for project keys.
+
+If ip_addresses or cidr_blocks are set, the keys can only be used from these
+addresses. Keys are deleted from Atlas when their lease is revoked.
+`
@@ -0,0 +1,72 @@
+package mongodbatlas
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+const secretProgrammaticAPIKeyType = "programmatic_api_key"
+
+func secretProgrammaticAPIKey(b *backend) *framework.Secret {
+	return &framework.Secret{
+		Type: secretProgrammaticAPIKeyType,
+		Fields: map[string]*framework.FieldSchema{
+			"public_key": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Public key of the programmatic API key",
+			},
+			"private_key": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Private key of the programmatic API key",
+			},
+		},
+
+		Renew:  b.secretProgrammaticAPIKeyRenew,
+		Revoke: b.secretProgrammaticAPIKeyRevoke,
+	}
+}
+
+func (b *backend) secretProgrammaticAPIKeyRenew(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	resp := &logical.Response{Secret: req.Secret}
+	name, _ := req.Secret.InternalData["role"].(string)
+	if name == "" {
+		return resp, nil
+	}
+
+	role, err := b.Role(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return logical.ErrorResponse(fmt.Sprintf("issuing role %q not found", name)), nil
+	}
+
+	resp.Secret.TTL = role.TTL
+	resp.Secret.MaxTTL = role.MaxTTL
+	return resp, nil
+}
+
+// secretProgrammaticAPIKeyRevoke deletes the key from its organization, which
+// also removes it from its project
+func (b *backend) secretProgrammaticAPIKeyRevoke(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	keyID, _ := req.Secret.InternalData["api_key_id"].(string)
+	organizationID, _ := req.Secret.InternalData["organization_id"].(string)
+	if keyID == "" || organizationID == "" {
+		return nil, fmt.Errorf("secret is missing the API key ID or organization ID")
+	}
+
+	c, err := b.client(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.deleteAPIKey(organizationID, keyID); err != nil {
+		return nil, errwrap.Wrapf("error deleting API key: {{err}}", err)
+	}
+
+	return nil, nil
+}
@@ -29,10 +29,13 @@ func Backend() *backend {
 
 		Paths: []*framework.Path{
 			pathConfigAccess(&b),
+			framework.PathConfigVerify("config/access", b.verifyConfigAccess),
 			pathConfigLease(&b),
+			pathConfigIssuance(&b),
 			pathListRoles(&b),
 			pathRoles(&b),
 			pathCredsCreate(&b),
+			framework.PathRevokeAll(),
 		},
 
 		Secrets: []*framework.Secret{
@@ -54,6 +57,10 @@ func (b *backend) client(ctx context.Context, s logical.Storage) (*api.Client, e
 		return nil, err
 	}
 
+	return clientFromConfig(conf)
+}
+
+func clientFromConfig(conf *accessConfig) (*api.Client, error) {
 	nomadConf := api.DefaultConfig()
 	if conf != nil {
 		if conf.Address != "" {
@@ -62,6 +69,9 @@ func (b *backend) client(ctx context.Context, s logical.Storage) (*api.Client, e
 		if conf.Token != "" {
 			nomadConf.SecretID = conf.Token
 		}
+		if conf.Namespace != "" {
+			nomadConf.Namespace = conf.Namespace
+		}
 	}
 
 	client, err := api.NewClient(nomadConf)
@@ -10,48 +10,95 @@ import (
 
 	nomadapi "github.com/hashicorp/nomad/api"
 	"github.com/hashicorp/vault/helper/testhelpers"
+	"github.com/hashicorp/vault/helper/testhelpers/docker"
 	"github.com/hashicorp/vault/logical"
 	"github.com/mitchellh/mapstructure"
-	"github.com/ory/dockertest"
 )
 
-func prepareTestContainer(t *testing.T) (cleanup func(), retAddress string, nomadToken string) {
-	nomadToken = os.Getenv("NOMAD_TOKEN")
+// nomadTestVersions returns the versions of Nomad the tests run against, from
+// the comma-separated list of NOMAD_VERSION, e.g. "0.8.4,1.4.3". They default
+// to the oldest supported version, the first ones with namespaces and with ACL
+// roles and token TTLs, and a current one.
+func nomadTestVersions() []string {
+	return docker.Versions("NOMAD_VERSION", "0.8.4", "1.0.18", "1.4.3", "1.9.7")
+}
 
-	retAddress = os.Getenv("NOMAD_ADDR")
+// nomadTestImage returns the docker image running the given version of
+// Nomad. NOMAD_DOCKER_IMAGE overrides the repository of the image, otherwise
+// the versions before 1.0, which have no official image, use catsby/nomad.
+func nomadTestImage(nomadVersion string) (repo, tag string) {
+	if repo := os.Getenv("NOMAD_DOCKER_IMAGE"); repo != "" {
+		return repo, nomadVersion
+	}
+	if checkNomadFeature(nomadVersion, featureNamespaces) != nil {
+		return "catsby/nomad", nomadVersion
+	}
+	return "hashicorp/nomad", nomadVersion
+}
 
-	if retAddress != "" {
-		return func() {}, retAddress, nomadToken
+// runNomadVersions runs the test against each of the Nomad versions. When
+// NOMAD_ADDR points the tests to a running agent, it is run once against it.
+func runNomadVersions(t *testing.T, f func(t *testing.T, nomadVersion string)) {
+	if os.Getenv("NOMAD_ADDR") != "" {
+		f(t, "")
+		return
 	}
 
-	pool, err := dockertest.NewPool("")
-	if err != nil {
-		t.Fatalf("Failed to connect to docker: %s", err)
+	for _, v := range nomadTestVersions() {
+		t.Run("nomad-"+v, func(t *testing.T) {
+			f(t, v)
+		})
 	}
+}
+
+// detectTestNomadVersion returns the version of the Nomad agent the tests run
+// against
+func detectTestNomadVersion(t *testing.T, address, token string) string {
+	t.Helper()
 
-	dockerOptions := &dockertest.RunOptions{
-		Repository: "catsby/nomad",
-		Tag:        "0.8.4",
-		Cmd:        []string{"agent", "-dev"},
-		Env:        []string{`NOMAD_LOCAL_CONFIG=bind_addr = "0.0.0.0" acl { enabled = true }`},
+	nomadapiConfig := nomadapi.DefaultConfig()
+	nomadapiConfig.Address = address
+	nomadapiConfig.SecretID = token
+	client, err := nomadapi.NewClient(nomadapiConfig)
+	if err != nil {
+		t.Fatal(err)
 	}
-	resource, err := pool.RunWithOptions(dockerOptions)
+	nomadVersion, err := detectNomadVersion(client)
 	if err != nil {
-		t.Fatalf("Could not start local Nomad docker container: %s", err)
+		t.Fatal(err)
 	}
+	return nomadVersion
+}
 
-	cleanup = func() {
-		err := pool.Purge(resource)
-		if err != nil {
-			t.Fatalf("Failed to cleanup local container: %s", err)
-		}
+// skipUnlessNomadFeature skips the test if the Nomad agent doesn't support
+// the feature
+func skipUnlessNomadFeature(t *testing.T, address, token string, f nomadFeature) {
+	t.Helper()
+
+	if err := checkNomadFeature(detectTestNomadVersion(t, address, token), f); err != nil {
+		t.Skip(err)
 	}
+}
 
-	retAddress = fmt.Sprintf("http://localhost:%s/", resource.GetPort("4646/tcp"))
+// prepareTestContainer starts an ACL enabled Nomad agent of the given version,
+// unless NOMAD_ADDR points to a running one
+func prepareTestContainer(t *testing.T, nomadVersion string) (cleanup func(), retAddress string, nomadToken string) {
+	nomadToken = os.Getenv("NOMAD_TOKEN")
+
+	if addr := os.Getenv("NOMAD_ADDR"); addr != "" {
+		return func() {}, addr, nomadToken
+	}
+
+	repo, tag := nomadTestImage(nomadVersion)
+	svc := docker.Start(t, docker.RunOptions{
+		ImageRepo: repo,
+		ImageTag:  tag,
+		// The agent is configured with flags, which all the images support
+		Cmd:   []string{"agent", "-dev", "-bind=0.0.0.0", "-acl-enabled"},
+		Ports: []string{"4646/tcp"},
+	}, func(svc *docker.Service) error {
+		retAddress = fmt.Sprintf("http://%s/", svc.Address("4646/tcp"))
 
-	// exponential backoff-retry
-	if err = pool.Retry(func() error {
-		var err error
 		nomadapiConfig := nomadapi.DefaultConfig()
 		nomadapiConfig.Address = retAddress
 		nomad, err := nomadapi.NewClient(nomadapiConfig)
@@ -90,6 +137,9 @@ func prepareTestContainer(t *testing.T) (cleanup func(), retAddress string, noma
 		nomadAuthConfig.Address = retAddress
 		nomadAuthConfig.SecretID = nomadToken
 		nomadAuth, err := nomadapi.NewClient(nomadAuthConfig)
+		if err != nil {
+			return err
+		}
 		_, err = nomadAuth.ACLPolicies().Upsert(policy, nil)
 		if err != nil {
 			return err
@@ -99,354 +149,574 @@ func prepareTestContainer(t *testing.T) (cleanup func(), retAddress string, noma
 			return err
 		}
 		return nil
-	}); err != nil {
-		cleanup()
-		t.Fatalf("Could not connect to docker: %s", err)
-	}
-	return cleanup, retAddress, nomadToken
+	})
+	return svc.Cleanup, retAddress, nomadToken
 }
 
 func TestBackend_config_access(t *testing.T) {
-	config := logical.TestBackendConfig()
-	config.StorageView = &logical.InmemStorage{}
-	b, err := Factory(context.Background(), config)
-	if err != nil {
-		t.Fatal(err)
-	}
+	runNomadVersions(t, func(t *testing.T, nomadVersion string) {
+		config := logical.TestBackendConfig()
+		config.StorageView = &logical.InmemStorage{}
+		b, err := Factory(context.Background(), config)
+		if err != nil {
+			t.Fatal(err)
+		}
 
-	cleanup, connURL, connToken := prepareTestContainer(t)
-	defer cleanup()
+		cleanup, connURL, connToken := prepareTestContainer(t, nomadVersion)
+		defer cleanup()
 
-	connData := map[string]interface{}{
-		"address": connURL,
-		"token":   connToken,
-	}
+		connData := map[string]interface{}{
+			"address": connURL,
+			"token":   connToken,
+		}
 
-	confReq := &logical.Request{
-		Operation: logical.UpdateOperation,
-		Path:      "config/access",
-		Storage:   config.StorageView,
-		Data:      connData,
-	}
+		confReq := &logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      "config/access",
+			Storage:   config.StorageView,
+			Data:      connData,
+		}
 
-	resp, err := b.HandleRequest(context.Background(), confReq)
-	if err != nil || (resp != nil && resp.IsError()) || resp != nil {
-		t.Fatalf("failed to write configuration: resp:%#v err:%s", resp, err)
-	}
+		resp, err := b.HandleRequest(context.Background(), confReq)
+		if err != nil || (resp != nil && resp.IsError()) || resp != nil {
+			t.Fatalf("failed to write configuration: resp:%#v err:%s", resp, err)
+		}
 
-	confReq.Operation = logical.ReadOperation
-	resp, err = b.HandleRequest(context.Background(), confReq)
-	if err != nil || (resp != nil && resp.IsError()) {
-		t.Fatalf("failed to write configuration: resp:%#v err:%s", resp, err)
-	}
+		confReq.Operation = logical.ReadOperation
+		resp, err = b.HandleRequest(context.Background(), confReq)
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("failed to write configuration: resp:%#v err:%s", resp, err)
+		}
 
-	expected := map[string]interface{}{
-		"address":               connData["address"].(string),
-		"max_token_name_length": 0,
-	}
-	if !reflect.DeepEqual(expected, resp.Data) {
-		t.Fatalf("bad: expected:%#v\nactual:%#v\n", expected, resp.Data)
-	}
-	if resp.Data["token"] != nil {
-		t.Fatalf("token should not be set in the response")
-	}
+		if resp.Data["detected_nomad_version"] == "" {
+			t.Fatalf("expected nomad version to be detected: %#v", resp.Data)
+		}
+		expected := map[string]interface{}{
+			"address":                connData["address"].(string),
+			"max_token_name_length":  0,
+			"namespace":              "",
+			"nomad_version":          "",
+			"detected_nomad_version": resp.Data["detected_nomad_version"],
+		}
+		if !reflect.DeepEqual(expected, resp.Data) {
+			t.Fatalf("bad: expected:%#v\nactual:%#v\n", expected, resp.Data)
+		}
+		if resp.Data["token"] != nil {
+			t.Fatalf("token should not be set in the response")
+		}
+
+		confReq.Path = "config/access/verify"
+		resp, err = b.HandleRequest(context.Background(), confReq)
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("failed to verify configuration: resp:%#v err:%s", resp, err)
+		}
+		if resp.Data["verified"] != true {
+			t.Fatalf("bad: %#v", resp.Data)
+		}
+
+		confReq.Operation = logical.UpdateOperation
+		confReq.Path = "config/access"
+		confReq.Data = map[string]interface{}{
+			"token": "not-a-token",
+		}
+		resp, err = b.HandleRequest(context.Background(), confReq)
+		if err != nil || resp != nil {
+			t.Fatalf("failed to write configuration: resp:%#v err:%s", resp, err)
+		}
+
+		confReq.Operation = logical.ReadOperation
+		confReq.Path = "config/access/verify"
+		confReq.Data = nil
+		resp, err = b.HandleRequest(context.Background(), confReq)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp == nil || !resp.IsError() {
+			t.Fatalf("expected verification to fail with a bad token: resp:%#v", resp)
+		}
+	})
 }
 
 func TestBackend_renew_revoke(t *testing.T) {
-	config := logical.TestBackendConfig()
-	config.StorageView = &logical.InmemStorage{}
-	b, err := Factory(context.Background(), config)
-	if err != nil {
-		t.Fatal(err)
-	}
+	runNomadVersions(t, func(t *testing.T, nomadVersion string) {
+		config := logical.TestBackendConfig()
+		config.StorageView = &logical.InmemStorage{}
+		b, err := Factory(context.Background(), config)
+		if err != nil {
+			t.Fatal(err)
+		}
 
-	cleanup, connURL, connToken := prepareTestContainer(t)
-	defer cleanup()
-	connData := map[string]interface{}{
-		"address": connURL,
-		"token":   connToken,
-	}
+		cleanup, connURL, connToken := prepareTestContainer(t, nomadVersion)
+		defer cleanup()
+		connData := map[string]interface{}{
+			"address": connURL,
+			"token":   connToken,
+		}
 
-	req := &logical.Request{
-		Storage:   config.StorageView,
-		Operation: logical.UpdateOperation,
-		Path:      "config/access",
-		Data:      connData,
-	}
-	resp, err := b.HandleRequest(context.Background(), req)
-	if err != nil {
-		t.Fatal(err)
-	}
+		req := &logical.Request{
+			Storage:   config.StorageView,
+			Operation: logical.UpdateOperation,
+			Path:      "config/access",
+			Data:      connData,
+		}
+		resp, err := b.HandleRequest(context.Background(), req)
+		if err != nil {
+			t.Fatal(err)
+		}
 
-	req.Path = "role/test"
-	req.Data = map[string]interface{}{
-		"policies": []string{"policy"},
-		"lease":    "6h",
-	}
-	resp, err = b.HandleRequest(context.Background(), req)
-	if err != nil {
-		t.Fatal(err)
-	}
+		req.Path = "role/test"
+		req.Data = map[string]interface{}{
+			"policies": []string{"policy"},
+			"lease":    "6h",
+		}
+		resp, err = b.HandleRequest(context.Background(), req)
+		if err != nil {
+			t.Fatal(err)
+		}
 
-	req.Operation = logical.ReadOperation
-	req.Path = "creds/test"
-	resp, err = b.HandleRequest(context.Background(), req)
-	if err != nil {
-		t.Fatal(err)
-	}
-	if resp == nil {
-		t.Fatal("resp nil")
-	}
-	if resp.IsError() {
-		t.Fatalf("resp is error: %v", resp.Error())
-	}
+		req.Operation = logical.ReadOperation
+		req.Path = "creds/test"
+		resp, err = b.HandleRequest(context.Background(), req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp == nil {
+			t.Fatal("resp nil")
+		}
+		if resp.IsError() {
+			t.Fatalf("resp is error: %v", resp.Error())
+		}
 
-	generatedSecret := resp.Secret
-	generatedSecret.TTL = 6 * time.Hour
+		generatedSecret := resp.Secret
+		generatedSecret.TTL = 6 * time.Hour
 
-	var d struct {
-		Token    string `mapstructure:"secret_id"`
-		Accessor string `mapstructure:"accessor_id"`
-	}
-	if err := mapstructure.Decode(resp.Data, &d); err != nil {
-		t.Fatal(err)
-	}
-	t.Logf("[WARN] Generated token: %s with accessor %s", d.Token, d.Accessor)
+		var d struct {
+			Token    string `mapstructure:"secret_id"`
+			Accessor string `mapstructure:"accessor_id"`
+		}
+		if err := mapstructure.Decode(resp.Data, &d); err != nil {
+			t.Fatal(err)
+		}
+		t.Logf("[WARN] Generated token: %s with accessor %s", d.Token, d.Accessor)
 
-	// Build a client and verify that the credentials work
-	nomadapiConfig := nomadapi.DefaultConfig()
-	nomadapiConfig.Address = connData["address"].(string)
-	nomadapiConfig.SecretID = d.Token
-	client, err := nomadapi.NewClient(nomadapiConfig)
-	if err != nil {
-		t.Fatal(err)
-	}
+		// Build a client and verify that the credentials work
+		nomadapiConfig := nomadapi.DefaultConfig()
+		nomadapiConfig.Address = connData["address"].(string)
+		nomadapiConfig.SecretID = d.Token
+		client, err := nomadapi.NewClient(nomadapiConfig)
+		if err != nil {
+			t.Fatal(err)
+		}
 
-	t.Log("[WARN] Verifying that the generated token works...")
-	_, err = client.Agent().Members, nil
-	if err != nil {
-		t.Fatal(err)
-	}
+		t.Log("[WARN] Verifying that the generated token works...")
+		_, err = client.Agent().Members, nil
+		if err != nil {
+			t.Fatal(err)
+		}
 
-	req.Operation = logical.RenewOperation
-	req.Secret = generatedSecret
-	resp, err = b.HandleRequest(context.Background(), req)
-	if err != nil {
-		t.Fatal(err)
-	}
-	if resp == nil {
-		t.Fatal("got nil response from renew")
-	}
+		req.Operation = logical.RenewOperation
+		req.Secret = generatedSecret
+		resp, err = b.HandleRequest(context.Background(), req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp == nil {
+			t.Fatal("got nil response from renew")
+		}
 
-	req.Operation = logical.RevokeOperation
-	resp, err = b.HandleRequest(context.Background(), req)
-	if err != nil {
-		t.Fatal(err)
-	}
+		req.Operation = logical.RevokeOperation
+		resp, err = b.HandleRequest(context.Background(), req)
+		if err != nil {
+			t.Fatal(err)
+		}
 
-	// Build a management client and verify that the token does not exist anymore
-	nomadmgmtConfig := nomadapi.DefaultConfig()
-	nomadmgmtConfig.Address = connData["address"].(string)
-	nomadmgmtConfig.SecretID = connData["token"].(string)
-	mgmtclient, err := nomadapi.NewClient(nomadmgmtConfig)
+		// Build a management client and verify that the token does not exist anymore
+		nomadmgmtConfig := nomadapi.DefaultConfig()
+		nomadmgmtConfig.Address = connData["address"].(string)
+		nomadmgmtConfig.SecretID = connData["token"].(string)
+		mgmtclient, err := nomadapi.NewClient(nomadmgmtConfig)
 
-	q := &nomadapi.QueryOptions{
-		Namespace: "default",
-	}
+		q := &nomadapi.QueryOptions{
+			Namespace: "default",
+		}
 
-	t.Log("[WARN] Verifying that the generated token does not exist...")
-	_, _, err = mgmtclient.ACLTokens().Info(d.Accessor, q)
-	if err == nil {
-		t.Fatal("err: expected error")
-	}
+		t.Log("[WARN] Verifying that the generated token does not exist...")
+		_, _, err = mgmtclient.ACLTokens().Info(d.Accessor, q)
+		if err == nil {
+			t.Fatal("err: expected error")
+		}
+	})
 }
 
 func TestBackend_CredsCreateEnvVar(t *testing.T) {
-	config := logical.TestBackendConfig()
-	config.StorageView = &logical.InmemStorage{}
-	b, err := Factory(context.Background(), config)
-	if err != nil {
-		t.Fatal(err)
-	}
+	runNomadVersions(t, func(t *testing.T, nomadVersion string) {
+		config := logical.TestBackendConfig()
+		config.StorageView = &logical.InmemStorage{}
+		b, err := Factory(context.Background(), config)
+		if err != nil {
+			t.Fatal(err)
+		}
 
-	cleanup, connURL, connToken := prepareTestContainer(t)
-	defer cleanup()
+		cleanup, connURL, connToken := prepareTestContainer(t, nomadVersion)
+		defer cleanup()
 
-	req := logical.TestRequest(t, logical.UpdateOperation, "role/test")
-	req.Data = map[string]interface{}{
-		"policies": []string{"policy"},
-		"lease":    "6h",
-	}
-	resp, err := b.HandleRequest(context.Background(), req)
-	if err != nil {
-		t.Fatal(err)
-	}
+		req := logical.TestRequest(t, logical.UpdateOperation, "role/test")
+		req.Data = map[string]interface{}{
+			"policies": []string{"policy"},
+			"lease":    "6h",
+		}
+		resp, err := b.HandleRequest(context.Background(), req)
+		if err != nil {
+			t.Fatal(err)
+		}
 
-	os.Setenv("NOMAD_TOKEN", connToken)
-	defer os.Unsetenv("NOMAD_TOKEN")
-	os.Setenv("NOMAD_ADDR", connURL)
-	defer os.Unsetenv("NOMAD_ADDR")
+		os.Setenv("NOMAD_TOKEN", connToken)
+		defer os.Unsetenv("NOMAD_TOKEN")
+		os.Setenv("NOMAD_ADDR", connURL)
+		defer os.Unsetenv("NOMAD_ADDR")
 
-	req.Operation = logical.ReadOperation
-	req.Path = "creds/test"
-	resp, err = b.HandleRequest(context.Background(), req)
-	if err != nil {
-		t.Fatal(err)
-	}
-	if resp == nil {
-		t.Fatal("resp nil")
-	}
-	if resp.IsError() {
-		t.Fatalf("resp is error: %v", resp.Error())
-	}
+		req.Operation = logical.ReadOperation
+		req.Path = "creds/test"
+		resp, err = b.HandleRequest(context.Background(), req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp == nil {
+			t.Fatal("resp nil")
+		}
+		if resp.IsError() {
+			t.Fatalf("resp is error: %v", resp.Error())
+		}
+	})
 }
 
 func TestBackend_max_token_name_length(t *testing.T) {
-	config := logical.TestBackendConfig()
-	config.StorageView = &logical.InmemStorage{}
-	b, err := Factory(context.Background(), config)
-	if err != nil {
-		t.Fatal(err)
-	}
+	runNomadVersions(t, func(t *testing.T, nomadVersion string) {
+		config := logical.TestBackendConfig()
+		config.StorageView = &logical.InmemStorage{}
+		b, err := Factory(context.Background(), config)
+		if err != nil {
+			t.Fatal(err)
+		}
 
-	cleanup, connURL, connToken := prepareTestContainer(t)
-	defer cleanup()
-
-	testCases := []struct {
-		title       string
-		roleName    string
-		tokenLength int
-	}{
-		{
-			title: "Default",
-		},
-		{
-			title:       "ConfigOverride",
-			tokenLength: 64,
-		},
-		{
-			title:       "ConfigOverride-LongName",
-			roleName:    "testlongerrolenametoexceed64charsdddddddddddddddddddddddd",
-			tokenLength: 64,
-		},
-		{
-			title:    "Notrim",
-			roleName: "testlongersubrolenametoexceed64charsdddddddddddddddddddddddd",
-		},
-	}
+		cleanup, connURL, connToken := prepareTestContainer(t, nomadVersion)
+		defer cleanup()
+
+		testCases := []struct {
+			title       string
+			roleName    string
+			tokenLength int
+		}{
+			{
+				title: "Default",
+			},
+			{
+				title:       "ConfigOverride",
+				tokenLength: 64,
+			},
+			{
+				title:       "ConfigOverride-LongName",
+				roleName:    "testlongerrolenametoexceed64charsdddddddddddddddddddddddd",
+				tokenLength: 64,
+			},
+			{
+				title:    "Notrim",
+				roleName: "testlongersubrolenametoexceed64charsdddddddddddddddddddddddd",
+			},
+		}
 
-	for _, tc := range testCases {
-		t.Run(tc.title, func(t *testing.T) {
-			// setup config/access
-			connData := map[string]interface{}{
-				"address":               connURL,
-				"token":                 connToken,
-				"max_token_name_length": tc.tokenLength,
-			}
-			expected := map[string]interface{}{
-				"address":               connURL,
-				"max_token_name_length": tc.tokenLength,
-			}
+		for _, tc := range testCases {
+			t.Run(tc.title, func(t *testing.T) {
+				// setup config/access
+				connData := map[string]interface{}{
+					"address":               connURL,
+					"token":                 connToken,
+					"max_token_name_length": tc.tokenLength,
+				}
+				expected := map[string]interface{}{
+					"address":               connURL,
+					"max_token_name_length": tc.tokenLength,
+					"namespace":             "",
+					"nomad_version":         "",
+				}
+
+				expectedMaxTokenNameLength := maxTokenNameLength
+				if tc.tokenLength != 0 {
+					expectedMaxTokenNameLength = tc.tokenLength
+				}
+
+				confReq := logical.Request{
+					Operation: logical.UpdateOperation,
+					Path:      "config/access",
+					Storage:   config.StorageView,
+					Data:      connData,
+				}
+
+				resp, err := b.HandleRequest(context.Background(), &confReq)
+				if err != nil || (resp != nil && resp.IsError()) || resp != nil {
+					t.Fatalf("failed to write configuration: resp:%#v err:%s", resp, err)
+				}
+				confReq.Operation = logical.ReadOperation
+				resp, err = b.HandleRequest(context.Background(), &confReq)
+				if err != nil || (resp != nil && resp.IsError()) {
+					t.Fatalf("failed to write configuration: resp:%#v err:%s", resp, err)
+				}
+
+				// verify token length is returned in the config/access query
+				expected["detected_nomad_version"] = resp.Data["detected_nomad_version"]
+				if !reflect.DeepEqual(expected, resp.Data) {
+					t.Fatalf("bad: expected:%#v\nactual:%#v\n", expected, resp.Data)
+				}
+				// verify token is not returned
+				if resp.Data["token"] != nil {
+					t.Fatalf("token should not be set in the response")
+				}
+
+				// create a role to create nomad credentials with
+				// Seeds random with current timestamp
+
+				if tc.roleName == "" {
+					tc.roleName = "test"
+				}
+				roleTokenName := testhelpers.RandomWithPrefix(tc.roleName)
+
+				confReq.Path = "role/" + roleTokenName
+				confReq.Operation = logical.UpdateOperation
+				confReq.Data = map[string]interface{}{
+					"policies": []string{"policy"},
+					"lease":    "6h",
+				}
+				resp, err = b.HandleRequest(context.Background(), &confReq)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				confReq.Operation = logical.ReadOperation
+				confReq.Path = "creds/" + roleTokenName
+				resp, err = b.HandleRequest(context.Background(), &confReq)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if resp == nil {
+					t.Fatal("resp nil")
+				}
+				if resp.IsError() {
+					t.Fatalf("resp is error: %v", resp.Error())
+				}
+
+				// extract the secret, so we can query nomad directly
+				generatedSecret := resp.Secret
+				generatedSecret.TTL = 6 * time.Hour
+
+				var d struct {
+					Token    string `mapstructure:"secret_id"`
+					Accessor string `mapstructure:"accessor_id"`
+				}
+				if err := mapstructure.Decode(resp.Data, &d); err != nil {
+					t.Fatal(err)
+				}
+
+				// Build a client and verify that the credentials work
+				nomadapiConfig := nomadapi.DefaultConfig()
+				nomadapiConfig.Address = connData["address"].(string)
+				nomadapiConfig.SecretID = d.Token
+				client, err := nomadapi.NewClient(nomadapiConfig)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				// default query options for Nomad queries ... not sure if needed
+				qOpts := &nomadapi.QueryOptions{
+					Namespace: "default",
+				}
+
+				// connect to Nomad and verify the token name does not exceed the
+				// max_token_name_length
+				token, _, err := client.ACLTokens().Self(qOpts)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if len(token.Name) > expectedMaxTokenNameLength {
+					t.Fatalf("token name exceeds max length (%d): %s (%d)", expectedMaxTokenNameLength, token.Name, len(token.Name))
+				}
+			})
+		}
+	})
+}
 
-			expectedMaxTokenNameLength := maxTokenNameLength
-			if tc.tokenLength != 0 {
-				expectedMaxTokenNameLength = tc.tokenLength
-			}
+func TestBackend_expiration_ttl(t *testing.T) {
+	runNomadVersions(t, func(t *testing.T, nomadVersion string) {
+		config := logical.TestBackendConfig()
+		config.StorageView = &logical.InmemStorage{}
+		b, err := Factory(context.Background(), config)
+		if err != nil {
+			t.Fatal(err)
+		}
 
-			confReq := logical.Request{
-				Operation: logical.UpdateOperation,
-				Path:      "config/access",
-				Storage:   config.StorageView,
-				Data:      connData,
-			}
+		cleanup, connURL, connToken := prepareTestContainer(t, nomadVersion)
+		defer cleanup()
+
+		req := &logical.Request{
+			Storage:   config.StorageView,
+			Operation: logical.UpdateOperation,
+			Path:      "config/access",
+			Data: map[string]interface{}{
+				"address": connURL,
+				"token":   connToken,
+			},
+		}
+		resp, err := b.HandleRequest(context.Background(), req)
+		if err != nil || resp != nil {
+			t.Fatalf("failed to write configuration: resp:%#v err:%s", resp, err)
+		}
 
-			resp, err := b.HandleRequest(context.Background(), &confReq)
-			if err != nil || (resp != nil && resp.IsError()) || resp != nil {
-				t.Fatalf("failed to write configuration: resp:%#v err:%s", resp, err)
-			}
-			confReq.Operation = logical.ReadOperation
-			resp, err = b.HandleRequest(context.Background(), &confReq)
-			if err != nil || (resp != nil && resp.IsError()) {
-				t.Fatalf("failed to write configuration: resp:%#v err:%s", resp, err)
+		// Older versions of Nomad must be refused roles with an expiration
+		req.Path = "role/test"
+		req.Data = map[string]interface{}{
+			"policies":       []string{"test"},
+			"expiration_ttl": "1h",
+		}
+		resp, err = b.HandleRequest(context.Background(), req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if checkNomadFeature(detectTestNomadVersion(t, connURL, connToken), featureTokenTTL) != nil {
+			if resp == nil || !resp.IsError() {
+				t.Fatalf("expected the role to be refused: resp:%#v", resp)
 			}
+			return
+		}
+		if resp != nil {
+			t.Fatalf("failed to write role: resp:%#v", resp)
+		}
 
-			// verify token length is returned in the config/access query
-			if !reflect.DeepEqual(expected, resp.Data) {
-				t.Fatalf("bad: expected:%#v\nactual:%#v\n", expected, resp.Data)
-			}
-			// verify token is not returned
-			if resp.Data["token"] != nil {
-				t.Fatalf("token should not be set in the response")
-			}
+		req.Operation = logical.ReadOperation
+		req.Path = "creds/test"
+		req.Data = nil
+		resp, err = b.HandleRequest(context.Background(), req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp == nil || resp.IsError() {
+			t.Fatalf("failed to create credentials: resp:%#v", resp)
+		}
 
-			// create a role to create nomad credentials with
-			// Seeds random with current timestamp
+		var d struct {
+			Token string `mapstructure:"secret_id"`
+		}
+		if err := mapstructure.Decode(resp.Data, &d); err != nil {
+			t.Fatal(err)
+		}
 
-			if tc.roleName == "" {
-				tc.roleName = "test"
-			}
-			roleTokenName := testhelpers.RandomWithPrefix(tc.roleName)
+		// The vendored client doesn't know about the expiration of tokens
+		nomadapiConfig := nomadapi.DefaultConfig()
+		nomadapiConfig.Address = connURL
+		nomadapiConfig.SecretID = d.Token
+		client, err := nomadapi.NewClient(nomadapiConfig)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var token struct {
+			ExpirationTime *time.Time
+		}
+		if _, err := client.Raw().Query("/v1/acl/token/self", &token, nil); err != nil {
+			t.Fatal(err)
+		}
+		if token.ExpirationTime == nil || time.Until(*token.ExpirationTime) > time.Hour {
+			t.Fatalf("expected the token to expire within an hour: %v", token.ExpirationTime)
+		}
+	})
+}
 
-			confReq.Path = "role/" + roleTokenName
-			confReq.Operation = logical.UpdateOperation
-			confReq.Data = map[string]interface{}{
-				"policies": []string{"policy"},
-				"lease":    "6h",
-			}
-			resp, err = b.HandleRequest(context.Background(), &confReq)
-			if err != nil {
-				t.Fatal(err)
-			}
+func TestBackend_nomad_roles(t *testing.T) {
+	runNomadVersions(t, func(t *testing.T, nomadVersion string) {
+		config := logical.TestBackendConfig()
+		config.StorageView = &logical.InmemStorage{}
+		b, err := Factory(context.Background(), config)
+		if err != nil {
+			t.Fatal(err)
+		}
 
-			confReq.Operation = logical.ReadOperation
-			confReq.Path = "creds/" + roleTokenName
-			resp, err = b.HandleRequest(context.Background(), &confReq)
-			if err != nil {
-				t.Fatal(err)
-			}
-			if resp == nil {
-				t.Fatal("resp nil")
-			}
-			if resp.IsError() {
-				t.Fatalf("resp is error: %v", resp.Error())
-			}
+		cleanup, connURL, connToken := prepareTestContainer(t, nomadVersion)
+		defer cleanup()
 
-			// extract the secret, so we can query nomad directly
-			generatedSecret := resp.Secret
-			generatedSecret.TTL = 6 * time.Hour
+		skipUnlessNomadFeature(t, connURL, connToken, featureACLRoles)
 
-			var d struct {
-				Token    string `mapstructure:"secret_id"`
-				Accessor string `mapstructure:"accessor_id"`
-			}
-			if err := mapstructure.Decode(resp.Data, &d); err != nil {
-				t.Fatal(err)
-			}
+		// The vendored client doesn't know about ACL roles
+		nomadmgmtConfig := nomadapi.DefaultConfig()
+		nomadmgmtConfig.Address = connURL
+		nomadmgmtConfig.SecretID = connToken
+		mgmtclient, err := nomadapi.NewClient(nomadmgmtConfig)
+		if err != nil {
+			t.Fatal(err)
+		}
+		aclRole := map[string]interface{}{
+			"Name":     "vault-test",
+			"Policies": []map[string]string{{"Name": "test"}},
+		}
+		if _, err := mgmtclient.Raw().Write("/v1/acl/role", aclRole, nil, nil); err != nil {
+			t.Fatal(err)
+		}
 
-			// Build a client and verify that the credentials work
-			nomadapiConfig := nomadapi.DefaultConfig()
-			nomadapiConfig.Address = connData["address"].(string)
-			nomadapiConfig.SecretID = d.Token
-			client, err := nomadapi.NewClient(nomadapiConfig)
-			if err != nil {
-				t.Fatal(err)
-			}
+		req := &logical.Request{
+			Storage:   config.StorageView,
+			Operation: logical.UpdateOperation,
+			Path:      "config/access",
+			Data: map[string]interface{}{
+				"address": connURL,
+				"token":   connToken,
+			},
+		}
+		resp, err := b.HandleRequest(context.Background(), req)
+		if err != nil || resp != nil {
+			t.Fatalf("failed to write configuration: resp:%#v err:%s", resp, err)
+		}
 
-			// default query options for Nomad queries ... not sure if needed
-			qOpts := &nomadapi.QueryOptions{
-				Namespace: "default",
-			}
+		req.Path = "role/test"
+		req.Data = map[string]interface{}{
+			"nomad_roles": []string{"vault-test"},
+		}
+		resp, err = b.HandleRequest(context.Background(), req)
+		if err != nil || resp != nil {
+			t.Fatalf("failed to write role: resp:%#v err:%s", resp, err)
+		}
 
-			// connect to Nomad and verify the token name does not exceed the
-			// max_token_name_length
-			token, _, err := client.ACLTokens().Self(qOpts)
-			if err != nil {
-				t.Fatal(err)
-			}
+		req.Operation = logical.ReadOperation
+		req.Path = "creds/test"
+		req.Data = nil
+		resp, err = b.HandleRequest(context.Background(), req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp == nil || resp.IsError() {
+			t.Fatalf("failed to create credentials: resp:%#v", resp)
+		}
 
-			if len(token.Name) > expectedMaxTokenNameLength {
-				t.Fatalf("token name exceeds max length (%d): %s (%d)", expectedMaxTokenNameLength, token.Name, len(token.Name))
+		var d struct {
+			Token string `mapstructure:"secret_id"`
+		}
+		if err := mapstructure.Decode(resp.Data, &d); err != nil {
+			t.Fatal(err)
+		}
+
+		nomadapiConfig := nomadapi.DefaultConfig()
+		nomadapiConfig.Address = connURL
+		nomadapiConfig.SecretID = d.Token
+		client, err := nomadapi.NewClient(nomadapiConfig)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var token struct {
+			Roles []struct {
+				Name string
 			}
-		})
-	}
+		}
+		if _, err := client.Raw().Query("/v1/acl/token/self", &token, nil); err != nil {
+			t.Fatal(err)
+		}
+		if len(token.Roles) != 1 || token.Roles[0].Name != "vault-test" {
+			t.Fatalf("expected the token to have the vault-test role: %#v", token.Roles)
+		}
+	})
 }
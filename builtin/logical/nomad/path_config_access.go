@@ -2,8 +2,10 @@ package nomad
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/go-version"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
 )
@@ -28,6 +30,18 @@ func pathConfigAccess(b *backend) *framework.Path {
 				Type:        framework.TypeInt,
 				Description: "Max length for name of generated Nomad tokens",
 			},
+
+			"namespace": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Nomad namespace to send requests to. Requires Nomad 1.0 or later.",
+			},
+
+			"nomad_version": &framework.FieldSchema{
+				Type: framework.TypeString,
+				Description: `Version of Nomad, used to check that the features
+configured are supported. If unset, the version is queried
+from the Nomad agent when the configuration is written.`,
+			},
 		},
 
 		Callbacks: map[logical.Operation]framework.OperationFunc{
@@ -67,6 +81,21 @@ func (b *backend) readConfigAccess(ctx context.Context, storage logical.Storage)
 	return conf, nil
 }
 
+// verifyConfigAccess looks up the configured token to check that the Nomad
+// server can be reached and that the token is accepted.
+func (b *backend) verifyConfigAccess(ctx context.Context, s logical.Storage) error {
+	c, err := b.client(ctx, s)
+	if err != nil {
+		return err
+	}
+
+	if _, _, err := c.ACLTokens().Self(nil); err != nil {
+		return errwrap.Wrapf("error looking up nomad token: {{err}}", err)
+	}
+
+	return nil
+}
+
 func (b *backend) pathConfigAccessRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
 	conf, err := b.readConfigAccess(ctx, req.Storage)
 	if err != nil {
@@ -78,8 +107,11 @@ func (b *backend) pathConfigAccessRead(ctx context.Context, req *logical.Request
 
 	return &logical.Response{
 		Data: map[string]interface{}{
-			"address":               conf.Address,
-			"max_token_name_length": conf.MaxTokenNameLength,
+			"address":                conf.Address,
+			"max_token_name_length":  conf.MaxTokenNameLength,
+			"namespace":              conf.Namespace,
+			"nomad_version":          conf.NomadVersion,
+			"detected_nomad_version": conf.DetectedNomadVersion,
 		},
 	}, nil
 }
@@ -104,6 +136,44 @@ func (b *backend) pathConfigAccessWrite(ctx context.Context, req *logical.Reques
 
 	conf.MaxTokenNameLength = data.Get("max_token_name_length").(int)
 
+	namespace, ok := data.GetOk("namespace")
+	if ok {
+		conf.Namespace = namespace.(string)
+	}
+	nomadVersion, ok := data.GetOk("nomad_version")
+	if ok {
+		conf.NomadVersion = nomadVersion.(string)
+		if conf.NomadVersion != "" {
+			if _, err := version.NewVersion(conf.NomadVersion); err != nil {
+				return logical.ErrorResponse(fmt.Sprintf("invalid nomad_version: %s", err)), nil
+			}
+		}
+	}
+
+	var resp *logical.Response
+	conf.DetectedNomadVersion = ""
+	if conf.NomadVersion == "" {
+		// The namespace doesn't matter for the agent endpoint, and may not
+		// be supported
+		detectConf := *conf
+		detectConf.Namespace = ""
+		c, err := clientFromConfig(&detectConf)
+		if err != nil {
+			return nil, err
+		}
+		conf.DetectedNomadVersion, err = detectNomadVersion(c)
+		if err != nil {
+			resp = &logical.Response{}
+			resp.AddWarning(fmt.Sprintf("Unable to detect the Nomad version, features are not checked against it: %s", err))
+		}
+	}
+
+	if conf.Namespace != "" {
+		if err := checkNomadFeature(conf.nomadVersion(), featureNamespaces); err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+	}
+
 	entry, err := logical.StorageEntryJSON("config/access", conf)
 	if err != nil {
 		return nil, err
@@ -112,7 +182,7 @@ func (b *backend) pathConfigAccessWrite(ctx context.Context, req *logical.Reques
 		return nil, err
 	}
 
-	return nil, nil
+	return resp, nil
 }
 
 func (b *backend) pathConfigAccessDelete(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
@@ -123,7 +193,22 @@ func (b *backend) pathConfigAccessDelete(ctx context.Context, req *logical.Reque
 }
 
 type accessConfig struct {
-	Address            string `json:"address"`
-	Token              string `json:"token"`
-	MaxTokenNameLength int    `json:"max_token_name_length"`
+	Address              string `json:"address"`
+	Token                string `json:"token"`
+	MaxTokenNameLength   int    `json:"max_token_name_length"`
+	Namespace            string `json:"namespace"`
+	NomadVersion         string `json:"nomad_version"`
+	DetectedNomadVersion string `json:"detected_nomad_version"`
+}
+
+// nomadVersion returns the version of Nomad features are checked against,
+// or an empty string if it is unknown
+func (c *accessConfig) nomadVersion() string {
+	if c == nil {
+		return ""
+	}
+	if c.NomadVersion != "" {
+		return c.NomadVersion
+	}
+	return c.DetectedNomadVersion
 }
@@ -0,0 +1,148 @@
+package nomad
+
+import (
+	"context"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+const issuanceConfigKey = "config/issuance"
+
+// defaultIssuancePausedMessage is returned when issuance is paused without a
+// message
+const defaultIssuancePausedMessage = "issuance of Nomad tokens is paused"
+
+func pathConfigIssuance(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "config/issuance",
+		Fields: map[string]*framework.FieldSchema{
+			"paused": &framework.FieldSchema{
+				Type:        framework.TypeBool,
+				Description: "If set, requests for new tokens are rejected. Existing leases can still be renewed and revoked.",
+			},
+			"message": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Message returned to the requests for new tokens while issuance is paused",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation:   b.pathIssuanceRead,
+			logical.UpdateOperation: b.pathIssuanceUpdate,
+			logical.DeleteOperation: b.pathIssuanceDelete,
+		},
+
+		HelpSynopsis:    pathConfigIssuanceHelpSyn,
+		HelpDescription: pathConfigIssuanceHelpDesc,
+	}
+}
+
+// Sets the issuance configuration parameters
+func (b *backend) pathIssuanceUpdate(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	conf, err := b.IssuanceConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if conf == nil {
+		conf = &configIssuance{}
+	}
+
+	if paused, ok := d.GetOk("paused"); ok {
+		if paused.(bool) && !conf.Paused {
+			conf.PausedAt = time.Now().UTC()
+		}
+		conf.Paused = paused.(bool)
+	}
+	if message, ok := d.GetOk("message"); ok {
+		conf.Message = message.(string)
+	}
+	if !conf.Paused {
+		conf.PausedAt = time.Time{}
+	}
+
+	entry, err := logical.StorageEntryJSON(issuanceConfigKey, conf)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+func (b *backend) pathIssuanceDelete(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	if err := req.Storage.Delete(ctx, issuanceConfigKey); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+// Returns the issuance configuration parameters
+func (b *backend) pathIssuanceRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	conf, err := b.IssuanceConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if conf == nil {
+		conf = &configIssuance{}
+	}
+
+	data := map[string]interface{}{
+		"paused":  conf.Paused,
+		"message": conf.Message,
+	}
+	if conf.Paused {
+		data["paused_at"] = conf.PausedAt.Format(time.RFC3339)
+	}
+
+	return &logical.Response{
+		Data: data,
+	}, nil
+}
+
+// IssuanceConfig returns the issuance configuration
+func (b *backend) IssuanceConfig(ctx context.Context, s logical.Storage) (*configIssuance, error) {
+	entry, err := s.Get(ctx, issuanceConfigKey)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var result configIssuance
+	if err := entry.DecodeJSON(&result); err != nil {
+		return nil, errwrap.Wrapf("error reading nomad issuance configuration: {{err}}", err)
+	}
+
+	return &result, nil
+}
+
+// Issuance configuration of the tokens of the backend
+type configIssuance struct {
+	Paused   bool      `json:"paused"`
+	Message  string    `json:"message"`
+	PausedAt time.Time `json:"paused_at"`
+}
+
+// pausedMessage returns the error returned to the requests for new tokens
+// while issuance is paused
+func (c *configIssuance) pausedMessage() string {
+	if c.Message == "" {
+		return defaultIssuancePausedMessage
+	}
+	return defaultIssuancePausedMessage + ": " + c.Message
+}
+
+var pathConfigIssuanceHelpSyn = "Pause or resume the issuance of Nomad tokens"
+
+var pathConfigIssuanceHelpDesc = `
+While paused is set, requests for new tokens on the creds/ path are rejected
+with the configured message, e.g. during maintenance of the Nomad cluster.
+Tokens already issued can still be renewed and revoked.
+`
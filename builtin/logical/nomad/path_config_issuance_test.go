@@ -0,0 +1,66 @@
+package nomad
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/logical"
+)
+
+func TestBackend_config_issuance(t *testing.T) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+	b, err := Factory(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request := func(op logical.Operation, path string, data map[string]interface{}) *logical.Response {
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation: op,
+			Path:      path,
+			Storage:   config.StorageView,
+			Data:      data,
+		})
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		return resp
+	}
+
+	resp := request(logical.ReadOperation, "config/issuance", nil)
+	if resp.Data["paused"] != false {
+		t.Fatalf("expected issuance not to be paused: %#v", resp.Data)
+	}
+
+	request(logical.UpdateOperation, "config/issuance", map[string]interface{}{
+		"paused":  true,
+		"message": "nomad upgrade in progress",
+	})
+
+	resp = request(logical.ReadOperation, "config/issuance", nil)
+	if resp.Data["paused"] != true || resp.Data["message"] != "nomad upgrade in progress" || resp.Data["paused_at"] == nil {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	resp = request(logical.ReadOperation, "creds/test", nil)
+	if !resp.IsError() || !strings.Contains(resp.Data["error"].(string), "nomad upgrade in progress") {
+		t.Fatalf("expected the request to be rejected: %#v", resp)
+	}
+
+	// Resuming keeps the message for the next pause
+	request(logical.UpdateOperation, "config/issuance", map[string]interface{}{
+		"paused": false,
+	})
+
+	resp = request(logical.ReadOperation, "config/issuance", nil)
+	if resp.Data["paused"] != false || resp.Data["message"] != "nomad upgrade in progress" || resp.Data["paused_at"] != nil {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	resp = request(logical.ReadOperation, "creds/test", nil)
+	if !resp.IsError() || !strings.Contains(resp.Data["error"].(string), "not found") {
+		t.Fatalf("expected the request to reach the role lookup: %#v", resp)
+	}
+}
@@ -33,6 +33,17 @@ func pathCredsCreate(b *backend) *framework.Path {
 
 func (b *backend) pathTokenRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
 	name := d.Get("name").(string)
+
+	// Renewals and revocations don't go through this path, and are still
+	// allowed while issuance is paused
+	issuanceConfig, err := b.IssuanceConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if issuanceConfig != nil && issuanceConfig.Paused {
+		return logical.ErrorResponse(issuanceConfig.pausedMessage()), nil
+	}
+
 	conf, _ := b.readConfigAccess(ctx, req.Storage)
 	// establish a default
 	tokenNameLength := maxTokenNameLength
@@ -73,13 +84,23 @@ func (b *backend) pathTokenRead(ctx context.Context, req *logical.Request, d *fr
 		tokenName = tokenName[:tokenNameLength]
 	}
 
+	// The role may have been written before the Nomad version was known
+	if err := role.checkNomadFeatures(conf.nomadVersion()); err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
 	// Create it
-	token, _, err := c.ACLTokens().Create(&api.ACLToken{
-		Name:     tokenName,
-		Type:     role.TokenType,
-		Policies: role.Policies,
-		Global:   role.Global,
-	}, nil)
+	var token *api.ACLToken
+	if len(role.NomadRoles) == 0 && role.ExpirationTTL == 0 {
+		token, _, err = c.ACLTokens().Create(&api.ACLToken{
+			Name:     tokenName,
+			Type:     role.TokenType,
+			Policies: role.Policies,
+			Global:   role.Global,
+		}, nil)
+	} else {
+		token, err = createTokenWithRoles(c, tokenName, role)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -96,3 +117,41 @@ func (b *backend) pathTokenRead(ctx context.Context, req *logical.Request, d *fr
 
 	return resp, nil
 }
+
+// aclTokenRequest is an ACL token creation request using fields which are
+// not part of api.ACLToken
+type aclTokenRequest struct {
+	Name          string
+	Type          string
+	Policies      []string
+	Roles         []aclTokenRoleLink `json:",omitempty"`
+	Global        bool
+	ExpirationTTL string `json:",omitempty"`
+}
+
+type aclTokenRoleLink struct {
+	Name string
+}
+
+// createTokenWithRoles creates a token with ACL roles or an expiration,
+// which require Nomad 1.4 or later
+func createTokenWithRoles(c *api.Client, name string, role *roleConfig) (*api.ACLToken, error) {
+	req := &aclTokenRequest{
+		Name:     name,
+		Type:     role.TokenType,
+		Policies: role.Policies,
+		Global:   role.Global,
+	}
+	for _, r := range role.NomadRoles {
+		req.Roles = append(req.Roles, aclTokenRoleLink{Name: r})
+	}
+	if role.ExpirationTTL > 0 {
+		req.ExpirationTTL = role.ExpirationTTL.String()
+	}
+
+	var token api.ACLToken
+	if _, err := c.Raw().Write("/v1/acl/token", req, &token, nil); err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
@@ -3,6 +3,7 @@ package nomad
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/hashicorp/errwrap"
 	"github.com/hashicorp/vault/logical"
@@ -46,6 +47,16 @@ or 'management'. If a 'management' token,
 the "policies" parameter is not required.
 Defaults to 'client'.`,
 			},
+
+			"nomad_roles": &framework.FieldSchema{
+				Type:        framework.TypeCommaStringSlice,
+				Description: "Comma-separated string or list of Nomad ACL roles to attach to 'client' tokens. Requires Nomad 1.4 or later.",
+			},
+
+			"expiration_ttl": &framework.FieldSchema{
+				Type:        framework.TypeDurationSecond,
+				Description: "Time after which Nomad expires the token on its own, even if Vault can't revoke it. Requires Nomad 1.4 or later.",
+			},
 		},
 
 		Callbacks: map[logical.Operation]framework.OperationFunc{
@@ -113,9 +124,11 @@ func (b *backend) pathRolesRead(ctx context.Context, req *logical.Request, d *fr
 	// Generate the response
 	resp := &logical.Response{
 		Data: map[string]interface{}{
-			"type":     role.TokenType,
-			"global":   role.Global,
-			"policies": role.Policies,
+			"type":           role.TokenType,
+			"global":         role.Global,
+			"policies":       role.Policies,
+			"nomad_roles":    role.NomadRoles,
+			"expiration_ttl": int64(role.ExpirationTTL.Seconds()),
 		},
 	}
 	return resp, nil
@@ -128,7 +141,8 @@ func (b *backend) pathRolesWrite(ctx context.Context, req *logical.Request, d *f
 	if err != nil {
 		return nil, err
 	}
-	if role == nil {
+	create := role == nil
+	if create {
 		role = new(roleConfig)
 	}
 
@@ -137,17 +151,33 @@ func (b *backend) pathRolesWrite(ctx context.Context, req *logical.Request, d *f
 		role.Policies = policies.([]string)
 	}
 
-	role.TokenType = d.Get("type").(string)
+	nomadRoles, ok := d.GetOk("nomad_roles")
+	if ok {
+		role.NomadRoles = nomadRoles.([]string)
+	}
+
+	expirationTTL, ok := d.GetOk("expiration_ttl")
+	if ok {
+		role.ExpirationTTL = time.Duration(expirationTTL.(int)) * time.Second
+	}
+	if role.ExpirationTTL < 0 {
+		return logical.ErrorResponse("expiration_ttl cannot be negative"), nil
+	}
+
+	// Updating a role leaves the fields not set by the request unchanged
+	if _, ok := d.GetOk("type"); ok || create {
+		role.TokenType = d.Get("type").(string)
+	}
 	switch role.TokenType {
 	case "client":
-		if len(role.Policies) == 0 {
+		if len(role.Policies) == 0 && len(role.NomadRoles) == 0 {
 			return logical.ErrorResponse(
-				"policies cannot be empty when using client tokens"), nil
+				"policies or nomad_roles must be set when using client tokens"), nil
 		}
 	case "management":
-		if len(role.Policies) != 0 {
+		if len(role.Policies) != 0 || len(role.NomadRoles) != 0 {
 			return logical.ErrorResponse(
-				"policies should be empty when using management tokens"), nil
+				"policies and nomad_roles should be empty when using management tokens"), nil
 		}
 	default:
 		return logical.ErrorResponse(
@@ -159,6 +189,14 @@ func (b *backend) pathRolesWrite(ctx context.Context, req *logical.Request, d *f
 		role.Global = global.(bool)
 	}
 
+	conf, err := b.readConfigAccess(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if err := role.checkNomadFeatures(conf.nomadVersion()); err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
 	entry, err := logical.StorageEntryJSON("role/"+name, role)
 	if err != nil {
 		return nil, err
@@ -180,7 +218,25 @@ func (b *backend) pathRolesDelete(ctx context.Context, req *logical.Request, d *
 }
 
 type roleConfig struct {
-	Policies  []string `json:"policies"`
-	TokenType string   `json:"type"`
-	Global    bool     `json:"global"`
+	Policies      []string      `json:"policies"`
+	TokenType     string        `json:"type"`
+	Global        bool          `json:"global"`
+	NomadRoles    []string      `json:"nomad_roles"`
+	ExpirationTTL time.Duration `json:"expiration_ttl"`
+}
+
+// checkNomadFeatures returns an error if the role uses features the given
+// version of Nomad doesn't support
+func (r *roleConfig) checkNomadFeatures(nomadVersion string) error {
+	if len(r.NomadRoles) > 0 {
+		if err := checkNomadFeature(nomadVersion, featureACLRoles); err != nil {
+			return err
+		}
+	}
+	if r.ExpirationTTL > 0 {
+		if err := checkNomadFeature(nomadVersion, featureTokenTTL); err != nil {
+			return err
+		}
+	}
+	return nil
 }
@@ -0,0 +1,83 @@
+package nomad
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/vault/logical"
+)
+
+func TestBackend_roles_create_update(t *testing.T) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+	b, err := Factory(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exists := func() bool {
+		checkFound, exists, err := b.HandleExistenceCheck(context.Background(), &logical.Request{
+			Operation: logical.CreateOperation,
+			Path:      "role/test",
+			Storage:   config.StorageView,
+		})
+		if err != nil || !checkFound {
+			t.Fatalf("bad: checkFound: %t, err: %v", checkFound, err)
+		}
+		return exists
+	}
+	request := func(op logical.Operation, data map[string]interface{}) *logical.Response {
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation: op,
+			Path:      "role/test",
+			Storage:   config.StorageView,
+			Data:      data,
+		})
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		return resp
+	}
+
+	if exists() {
+		t.Fatal("expected the role not to exist")
+	}
+	if resp := request(logical.CreateOperation, map[string]interface{}{
+		"type":   "management",
+		"global": true,
+	}); resp != nil {
+		t.Fatalf("bad: %#v", resp)
+	}
+	if !exists() {
+		t.Fatal("expected the role to exist")
+	}
+
+	// Updating leaves the type set at creation
+	if resp := request(logical.UpdateOperation, map[string]interface{}{
+		"global": false,
+	}); resp != nil {
+		t.Fatalf("bad: %#v", resp)
+	}
+	resp := request(logical.ReadOperation, nil)
+	if resp.Data["type"] != "management" || resp.Data["global"] != false {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	// Switching to client tokens requires policies
+	if resp := request(logical.UpdateOperation, map[string]interface{}{
+		"type": "client",
+	}); resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error, got: %#v", resp)
+	}
+	if resp := request(logical.UpdateOperation, map[string]interface{}{
+		"type":     "client",
+		"policies": "readonly",
+	}); resp != nil {
+		t.Fatalf("bad: %#v", resp)
+	}
+	resp = request(logical.ReadOperation, nil)
+	if resp.Data["type"] != "client" || !reflect.DeepEqual(resp.Data["policies"], []string{"readonly"}) {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+}
@@ -0,0 +1,92 @@
+package nomad
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/nomad/api"
+)
+
+// nomadFeature is a Nomad capability only available from a given version
+type nomadFeature struct {
+	name       string
+	minVersion *version.Version
+}
+
+var (
+	featureNamespaces = nomadFeature{
+		name:       "namespace",
+		minVersion: version.Must(version.NewVersion("1.0.0")),
+	}
+	featureACLRoles = nomadFeature{
+		name:       "nomad_roles",
+		minVersion: version.Must(version.NewVersion("1.4.0")),
+	}
+	featureTokenTTL = nomadFeature{
+		name:       "expiration_ttl",
+		minVersion: version.Must(version.NewVersion("1.4.0")),
+	}
+)
+
+// detectNomadVersion asks the agent the client talks to for its version
+func detectNomadVersion(c *api.Client) (string, error) {
+	self, err := c.Agent().Self()
+	if err != nil {
+		return "", errwrap.Wrapf("error querying nomad agent: {{err}}", err)
+	}
+
+	var v string
+	switch raw := self.Config["Version"].(type) {
+	case string:
+		v = raw
+	case map[string]interface{}:
+		// Since Nomad 0.9 the version is an object also holding the
+		// prerelease and revision
+		v, _ = raw["Version"].(string)
+		if pre, _ := raw["VersionPrerelease"].(string); v != "" && pre != "" {
+			v += "-" + pre
+		}
+	}
+	if v == "" {
+		v = self.Member.Tags["build"]
+	}
+	if v == "" {
+		return "", fmt.Errorf("nomad agent did not report its version")
+	}
+
+	// The build tag may carry a revision, e.g. "0.8.4:6a1b2c3"
+	v = strings.SplitN(v, ":", 2)[0]
+	if _, err := version.NewVersion(v); err != nil {
+		return "", errwrap.Wrapf(fmt.Sprintf("nomad agent reported an invalid version %q: {{err}}", v), err)
+	}
+
+	return v, nil
+}
+
+// checkNomadFeature returns an error if the given Nomad version is known not
+// to support the feature. An empty version, as when it could not be
+// detected, is not checked. Prereleases of the minimum version are accepted.
+func checkNomadFeature(nomadVersion string, f nomadFeature) error {
+	if nomadVersion == "" {
+		return nil
+	}
+	v, err := version.NewVersion(nomadVersion)
+	if err != nil {
+		return errwrap.Wrapf(fmt.Sprintf("invalid nomad version %q: {{err}}", nomadVersion), err)
+	}
+
+	segments := v.Segments()
+	minSegments := f.minVersion.Segments()
+	for i := range minSegments {
+		if segments[i] != minSegments[i] {
+			if segments[i] < minSegments[i] {
+				return fmt.Errorf("%s requires Nomad >= %s, but Nomad is version %s", f.name, f.minVersion, nomadVersion)
+			}
+			break
+		}
+	}
+
+	return nil
+}
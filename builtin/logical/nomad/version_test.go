@@ -0,0 +1,134 @@
+package nomad
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/hashicorp/vault/logical"
+)
+
+func TestCheckNomadFeature(t *testing.T) {
+	cases := []struct {
+		version string
+		ok      bool
+	}{
+		{"", true},
+		{"1.4.0", true},
+		{"1.4.0-beta.1", true},
+		{"1.5.2", true},
+		{"2.0.0", true},
+		{"1.3.9", false},
+		{"0.8.4", false},
+	}
+	for _, c := range cases {
+		err := checkNomadFeature(c.version, featureACLRoles)
+		if (err == nil) != c.ok {
+			t.Fatalf("bad: %q: %v", c.version, err)
+		}
+		if err != nil && !strings.Contains(err.Error(), "requires Nomad >= 1.4.0") {
+			t.Fatalf("bad: error: %v", err)
+		}
+	}
+}
+
+func TestDetectNomadVersion(t *testing.T) {
+	cases := map[string]string{
+		`{"config": {"Version": "0.8.4"}, "member": {}}`:                                             "0.8.4",
+		`{"config": {"Version": {"Version": "1.4.3", "VersionPrerelease": ""}}, "member": {}}`:       "1.4.3",
+		`{"config": {"Version": {"Version": "1.5.0", "VersionPrerelease": "beta.1"}}, "member": {}}`: "1.5.0-beta.1",
+		`{"config": {}, "member": {"Tags": {"build": "0.9.1:4b0e9a2"}}}`:                             "0.9.1",
+	}
+	for body, expected := range cases {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/v1/agent/self" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write([]byte(body))
+		}))
+
+		conf := api.DefaultConfig()
+		conf.Address = srv.URL
+		c, err := api.NewClient(conf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual, err := detectNomadVersion(c)
+		srv.Close()
+		if err != nil {
+			t.Fatalf("bad: %s: %v", body, err)
+		}
+		if actual != expected {
+			t.Fatalf("bad: %s: expected %q, got %q", body, expected, actual)
+		}
+	}
+}
+
+func TestBackend_RoleNomadVersionGating(t *testing.T) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+	b, err := Factory(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doReq := func(path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      path,
+			Storage:   config.StorageView,
+			Data:      data,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	resp := doReq("config/access", map[string]interface{}{
+		"address":       "http://127.0.0.1:1",
+		"nomad_version": "0.12.0",
+		"namespace":     "apps",
+	})
+	if resp == nil || !resp.IsError() {
+		t.Fatalf("expected namespace to be rejected: %#v", resp)
+	}
+
+	resp = doReq("config/access", map[string]interface{}{
+		"address":       "http://127.0.0.1:1",
+		"nomad_version": "1.3.2",
+	})
+	if resp != nil && resp.IsError() {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	resp = doReq("role/test", map[string]interface{}{
+		"nomad_roles": "ops",
+	})
+	if resp == nil || !resp.IsError() || !strings.Contains(resp.Error().Error(), "nomad_roles requires Nomad >= 1.4.0") {
+		t.Fatalf("expected nomad_roles to be rejected: %#v", resp)
+	}
+	resp = doReq("role/test", map[string]interface{}{
+		"policies":       "readonly",
+		"expiration_ttl": "1h",
+	})
+	if resp == nil || !resp.IsError() || !strings.Contains(resp.Error().Error(), "expiration_ttl requires Nomad >= 1.4.0") {
+		t.Fatalf("expected expiration_ttl to be rejected: %#v", resp)
+	}
+
+	doReq("config/access", map[string]interface{}{
+		"nomad_version": "1.4.3",
+	})
+	resp = doReq("role/test", map[string]interface{}{
+		"nomad_roles":    "ops",
+		"expiration_ttl": "1h",
+	})
+	if resp != nil && resp.IsError() {
+		t.Fatalf("bad: %#v", resp)
+	}
+}
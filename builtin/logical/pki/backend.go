@@ -6,6 +6,8 @@ import (
 	"sync"
 	"time"
 
+	"github.com/hashicorp/vault/helper/consts"
+	"github.com/hashicorp/vault/helper/errutil"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
 )
@@ -33,6 +35,11 @@ func Backend(conf *logical.BackendConfig) *backend {
 				"ca",
 				"crl/pem",
 				"crl",
+				"crl/delta",
+				"crl/delta/pem",
+				"ocsp",
+				"ocsp/*",
+				"issuer/*",
 			},
 
 			LocalStorage: []string{
@@ -48,6 +55,20 @@ func Backend(conf *logical.BackendConfig) *backend {
 
 			SealWrapStorage: []string{
 				"config/ca_bundle",
+				"issuers/",
+				ocspSignerPath,
+			},
+
+			CachedResponses: []string{
+				"cert/*",
+				"ca/pem",
+				"ca_chain",
+				"ca",
+				"crl/pem",
+				"crl",
+				"crl/delta",
+				"crl/delta/pem",
+				"issuer/*",
 			},
 		},
 
@@ -61,31 +82,51 @@ func Backend(conf *logical.BackendConfig) *backend {
 			pathGenerateIntermediate(&b),
 			pathSetSignedIntermediate(&b),
 			pathConfigCA(&b),
+			pathListIssuers(&b),
+			pathIssuers(&b),
 			pathConfigCRL(&b),
 			pathConfigURLs(&b),
+			pathConfigOCSP(&b),
 			pathSignVerbatim(&b),
 			pathSign(&b),
 			pathIssue(&b),
 			pathRotateCRL(&b),
+			pathRotateDeltaCRL(&b),
 			pathFetchCA(&b),
 			pathFetchCAChain(&b),
 			pathFetchCRL(&b),
 			pathFetchCRLViaCertPath(&b),
 			pathFetchValid(&b),
 			pathFetchListCerts(&b),
+			pathFetchIssuer(&b),
+			pathOCSP(&b),
+			pathOCSPGet(&b),
 			pathRevoke(&b),
 			pathTidy(&b),
+			pathTidyStatus(&b),
+			pathTidyCancel(&b),
 		},
 
 		Secrets: []*framework.Secret{
 			secretCerts(&b),
 		},
 
+		ScheduledFuncs: []*framework.ScheduledFunc{
+			{
+				Name:            "rebuild_crl",
+				Description:     "Rebuilds the CRL ahead of its expiry, and the delta CRL once its rebuild interval has elapsed, when auto_rebuild is enabled.",
+				DefaultSchedule: "1m",
+				Func:            b.periodicRebuildCRL,
+			},
+		},
+
 		BackendType: logical.TypeLogical,
 	}
 
 	b.crlLifetime = time.Hour * 72
 	b.tidyCASGuard = new(uint32)
+	b.tidyCancelCAS = new(uint32)
+	b.tidyStatus = &tidyStatus{state: tidyStatusInactive}
 	b.storage = conf.StorageView
 
 	return &b
@@ -97,7 +138,69 @@ type backend struct {
 	storage           logical.Storage
 	crlLifetime       time.Duration
 	revokeStorageLock sync.RWMutex
+	crlBuildLock      sync.Mutex
 	tidyCASGuard      *uint32
+	tidyCancelCAS     *uint32
+	tidyStatusLock    sync.RWMutex
+	tidyStatus        *tidyStatus
+	ocspSignerLock    sync.Mutex
+}
+
+// periodicRebuildCRL rebuilds the CRL ahead of its expiry and the delta CRL
+// once its rebuild interval has elapsed, when they are rebuilt automatically
+func (b *backend) periodicRebuildCRL(ctx context.Context, req *logical.Request) error {
+	// Performance standbys can't write the CRLs; the active node keeps them
+	// up to date
+	if b.System().ReplicationState().HasState(consts.ReplicationPerformanceStandby) {
+		return nil
+	}
+
+	crlInfo, err := b.CRL(ctx, req.Storage)
+	if err != nil {
+		return err
+	}
+	if crlInfo == nil || !crlInfo.AutoRebuild || crlInfo.Disable {
+		return nil
+	}
+
+	b.revokeStorageLock.RLock()
+	defer b.revokeStorageLock.RUnlock()
+
+	state, err := fetchCRLState(ctx, req.Storage)
+	if err != nil {
+		return err
+	}
+
+	gracePeriod, err := crlInfo.autoRebuildGracePeriod()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if now.After(state.NextUpdate.Add(-gracePeriod)) {
+		err := buildCRL(ctx, b, req, false)
+		if _, ok := err.(errutil.UserError); ok {
+			// No CA yet
+			return nil
+		}
+		return err
+	}
+
+	if !crlInfo.EnableDelta || !state.DeltaDirty {
+		return nil
+	}
+	interval, err := crlInfo.deltaRebuildInterval()
+	if err != nil {
+		return err
+	}
+	if now.Sub(state.LastDeltaBuild) < interval {
+		return nil
+	}
+	err = buildDeltaCRL(ctx, b, req)
+	if _, ok := err.(errutil.UserError); ok {
+		b.Logger().Warn("unable to rebuild the delta CRL", "error", err)
+		return nil
+	}
+	return err
 }
 
 const backendHelp = `
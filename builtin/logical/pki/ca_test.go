@@ -565,6 +565,14 @@ func runSteps(t *testing.T, rootB, intB *backend, client *api.Client, rootName,
 
 			// Check to make sure we still find the cert and see it on the CRL
 			verifyRevocation(t, intSerialNumber, false)
+
+			resp, err = client.Logical().Read(rootName + "tidy-status")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.Data["state"] != "Finished" || resp.Data["revoked_cert_deleted_count"] != json.Number("1") {
+				t.Fatalf("bad tidy status: %#v", resp.Data)
+			}
 		}
 	}
 }
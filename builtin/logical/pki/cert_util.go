@@ -181,11 +181,25 @@ func validateKeyTypeLength(keyType string, keyBits int) *logical.Response {
 // Fetches the CA info. Unlike other certificates, the CA info is stored
 // in the backend as a CertBundle, because we are storing its private key
 func fetchCAInfo(ctx context.Context, req *logical.Request) (*caInfoBundle, error) {
-	bundleEntry, err := req.Storage.Get(ctx, "config/ca_bundle")
+	return fetchIssuerInfo(ctx, req, defaultIssuerName)
+}
+
+// Fetches the CA info of the issuer with the given name; the default issuer
+// is the CA configured for the mount as a whole
+func fetchIssuerInfo(ctx context.Context, req *logical.Request, name string) (*caInfoBundle, error) {
+	path := "config/ca_bundle"
+	if name != "" && name != defaultIssuerName {
+		path = "issuers/" + name
+	}
+
+	bundleEntry, err := req.Storage.Get(ctx, path)
 	if err != nil {
 		return nil, errutil.InternalError{Err: fmt.Sprintf("unable to fetch local CA certificate/key: %v", err)}
 	}
 	if bundleEntry == nil {
+		if path != "config/ca_bundle" {
+			return nil, errutil.UserError{Err: fmt.Sprintf("unknown issuer %q", name)}
+		}
 		return nil, errutil.UserError{Err: "backend must be configured with a CA certificate/key"}
 	}
 
@@ -221,6 +235,53 @@ func fetchCAInfo(ctx context.Context, req *logical.Request) (*caInfoBundle, erro
 	return caInfo, nil
 }
 
+// issuer is one of the CAs of the mount
+type issuer struct {
+	name   string
+	bundle *caInfoBundle
+}
+
+// Fetches the default issuer, if one is configured, followed by the named
+// issuers
+func fetchIssuers(ctx context.Context, req *logical.Request) ([]issuer, error) {
+	var issuers []issuer
+
+	caInfo, err := fetchCAInfo(ctx, req)
+	switch err.(type) {
+	case nil:
+		issuers = append(issuers, issuer{name: defaultIssuerName, bundle: caInfo})
+	case errutil.UserError:
+	default:
+		return nil, err
+	}
+
+	names, err := req.Storage.List(ctx, "issuers/")
+	if err != nil {
+		return nil, errutil.InternalError{Err: fmt.Sprintf("unable to list issuers: %v", err)}
+	}
+	for _, name := range names {
+		caInfo, err := fetchIssuerInfo(ctx, req, name)
+		switch err.(type) {
+		case nil:
+			issuers = append(issuers, issuer{name: name, bundle: caInfo})
+		case errutil.UserError:
+			// Deleted in the meantime
+		default:
+			return nil, err
+		}
+	}
+
+	return issuers, nil
+}
+
+// issuedBy reports whether the certificate was issued by the given CA
+func issuedBy(cert, caCert *x509.Certificate) bool {
+	if len(cert.AuthorityKeyId) > 0 && len(caCert.SubjectKeyId) > 0 {
+		return bytes.Equal(cert.AuthorityKeyId, caCert.SubjectKeyId)
+	}
+	return bytes.Equal(cert.RawIssuer, caCert.RawSubject) && cert.CheckSignatureFrom(caCert) == nil
+}
+
 // Allows fetching certificates from the backend; it handles the slightly
 // separate pathing for CA, CRL, and revoked certificates.
 func fetchCertBySerial(ctx context.Context, req *logical.Request, prefix, serial string) (*logical.StorageEntry, error) {
@@ -241,6 +302,8 @@ func fetchCertBySerial(ctx context.Context, req *logical.Request, prefix, serial
 		path = "ca"
 	case serial == "crl":
 		path = "crl"
+	case serial == "delta-crl":
+		path = "crl/delta"
 	default:
 		legacyPath = "certs/" + colonSerial
 		path = "certs/" + hyphenSerial
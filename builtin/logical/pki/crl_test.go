@@ -1,8 +1,12 @@
 package pki
 
 import (
+	"context"
 	"crypto/x509"
+	"encoding/asn1"
+	"math/big"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/vault/api"
 	vaulthttp "github.com/hashicorp/vault/http"
@@ -122,3 +126,183 @@ func TestBackend_CRL_EnableDisable(t *testing.T) {
 	toggle(false)
 	test(6)
 }
+
+func TestBackend_CRL_AutoRebuildDelta(t *testing.T) {
+	b, storage := createBackendWithStorage(t)
+	ctx := context.Background()
+
+	write := func(path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := b.HandleRequest(ctx, &logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      path,
+			Storage:   storage,
+			Data:      data,
+		})
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("bad: err: %v resp: %#v", err, resp)
+		}
+		return resp
+	}
+	read := func(path string) *logical.Response {
+		t.Helper()
+		resp, err := b.HandleRequest(ctx, &logical.Request{
+			Operation: logical.ReadOperation,
+			Path:      path,
+			Storage:   storage,
+		})
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("bad: err: %v resp: %#v", err, resp)
+		}
+		return resp
+	}
+
+	// Returns the number of the CRL, its base CRL number if it is a delta
+	// CRL, and the number of revoked certificates it lists
+	fetchCRL := func(path string) (int64, int64, int) {
+		t.Helper()
+		resp := read(path)
+		crl, err := x509.ParseRevocationList(resp.Data[logical.HTTPRawBody].([]byte))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var base int64
+		for _, ext := range crl.Extensions {
+			if ext.Id.Equal(oidDeltaCRLIndicator) {
+				var baseNumber *big.Int
+				if _, err := asn1.Unmarshal(ext.Value, &baseNumber); err != nil {
+					t.Fatal(err)
+				}
+				base = baseNumber.Int64()
+			}
+		}
+		return crl.Number.Int64(), base, len(crl.RevokedCertificateEntries)
+	}
+
+	write("root/generate/internal", map[string]interface{}{
+		"ttl":         "40h",
+		"common_name": "myvault.com",
+	})
+	write("roles/test", map[string]interface{}{
+		"allowed_domains":  "foobar.com",
+		"allow_subdomains": true,
+	})
+	var serials []string
+	for i := 0; i < 2; i++ {
+		resp := write("issue/test", map[string]interface{}{
+			"common_name": "test.foobar.com",
+			"ttl":         "1h",
+		})
+		serials = append(serials, resp.Data["serial_number"].(string))
+	}
+
+	// Delta CRLs need the CRL to be rebuilt automatically
+	resp, err := b.HandleRequest(ctx, &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "config/crl",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"enable_delta": true,
+		},
+	})
+	if err != nil || resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error, got err: %v resp: %#v", err, resp)
+	}
+
+	// The default grace period is longer than the CRL expiry
+	resp, err = b.HandleRequest(ctx, &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "config/crl",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"expiry":       "2h",
+			"auto_rebuild": true,
+		},
+	})
+	if err != nil || resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error, got err: %v resp: %#v", err, resp)
+	}
+
+	write("config/crl", map[string]interface{}{
+		"expiry":                    "2h",
+		"auto_rebuild":              true,
+		"auto_rebuild_grace_period": "1h",
+		"enable_delta":              true,
+		"delta_rebuild_interval":    "1ms",
+	})
+	resp = read("config/crl")
+	if resp.Data["auto_rebuild"] != true || resp.Data["enable_delta"] != true || resp.Data["auto_rebuild_grace_period"] != "1h" {
+		t.Fatalf("bad config: %#v", resp.Data)
+	}
+	rb := &logical.Request{
+		Operation: logical.RollbackOperation,
+		Storage:   storage,
+	}
+
+	baseNumber, _, revoked := fetchCRL("crl")
+	if revoked != 0 {
+		t.Fatalf("expected an empty CRL, got %d entries", revoked)
+	}
+	number, base, revoked := fetchCRL("crl/delta")
+	if base != baseNumber || number <= baseNumber || revoked != 0 {
+		t.Fatalf("bad delta CRL: number %d, base %d, %d entries", number, base, revoked)
+	}
+
+	// Revocations only go on the delta CRL, once it is rebuilt
+	write("revoke", map[string]interface{}{
+		"serial_number": serials[0],
+	})
+	if _, _, revoked := fetchCRL("crl"); revoked != 0 {
+		t.Fatalf("expected the CRL not to be rebuilt, got %d entries", revoked)
+	}
+	if err := b.periodicRebuildCRL(ctx, rb); err != nil {
+		t.Fatal(err)
+	}
+	if n, _, revoked := fetchCRL("crl"); n != baseNumber || revoked != 0 {
+		t.Fatalf("expected the CRL not to be rebuilt, got number %d with %d entries", n, revoked)
+	}
+	prevNumber := number
+	number, base, revoked = fetchCRL("crl/delta")
+	if base != baseNumber || number <= prevNumber || revoked != 1 {
+		t.Fatalf("bad delta CRL: number %d, base %d, %d entries", number, base, revoked)
+	}
+
+	write("revoke", map[string]interface{}{
+		"serial_number": serials[1],
+	})
+	read("crl/rotate-delta")
+	if _, _, revoked = fetchCRL("crl/delta"); revoked != 2 {
+		t.Fatalf("expected 2 entries in the delta CRL, got %d", revoked)
+	}
+
+	// Once within the grace period, the complete CRL is rebuilt, and the
+	// delta CRL starts over from it
+	state, err := fetchCRLState(ctx, storage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state.NextUpdate = time.Now().Add(30 * time.Minute)
+	if err := storeCRLState(ctx, storage, state); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.periodicRebuildCRL(ctx, rb); err != nil {
+		t.Fatal(err)
+	}
+	newBaseNumber, _, revoked := fetchCRL("crl")
+	if newBaseNumber <= number || revoked != 2 {
+		t.Fatalf("bad CRL: number %d, %d entries", newBaseNumber, revoked)
+	}
+	number, base, revoked = fetchCRL("crl/delta")
+	if base != newBaseNumber || number <= newBaseNumber || revoked != 0 {
+		t.Fatalf("bad delta CRL: number %d, base %d, %d entries", number, base, revoked)
+	}
+
+	// The delta CRL is dropped when disabled
+	write("config/crl", map[string]interface{}{
+		"enable_delta": false,
+	})
+	resp = read("crl/delta")
+	if len(resp.Data[logical.HTTPRawBody].([]byte)) != 0 {
+		t.Fatal("expected no delta CRL")
+	}
+}
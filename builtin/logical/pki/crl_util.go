@@ -5,8 +5,9 @@ import (
 	"crypto/rand"
 	"crypto/x509"
 	"crypto/x509/pkix"
-	"errors"
+	"encoding/asn1"
 	"fmt"
+	"math/big"
 	"strings"
 	"time"
 
@@ -33,19 +34,18 @@ func revokeCert(ctx context.Context, b *backend, req *logical.Request, serial st
 		return nil, nil
 	}
 
-	signingBundle, caErr := fetchCAInfo(ctx, req)
-	switch caErr.(type) {
-	case errutil.UserError:
-		return logical.ErrorResponse(fmt.Sprintf("could not fetch the CA certificate: %s", caErr)), nil
-	case errutil.InternalError:
-		return nil, fmt.Errorf("error fetching CA certificate: %s", caErr)
+	issuers, err := fetchIssuers(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching CA certificate: %s", err)
 	}
-	if signingBundle == nil {
-		return nil, errors.New("CA info not found")
+	if len(issuers) == 0 {
+		return logical.ErrorResponse("could not fetch the CA certificate: backend must be configured with a CA certificate/key"), nil
 	}
 	colonSerial := strings.Replace(strings.ToLower(serial), "-", ":", -1)
-	if colonSerial == certutil.GetHexFormatted(signingBundle.Certificate.SerialNumber.Bytes(), ":") {
-		return logical.ErrorResponse("adding CA to CRL is not allowed"), nil
+	for _, issuer := range issuers {
+		if colonSerial == certutil.GetHexFormatted(issuer.bundle.Certificate.SerialNumber.Bytes(), ":") {
+			return logical.ErrorResponse("adding CA to CRL is not allowed"), nil
+		}
 	}
 
 	alreadyRevoked := false
@@ -120,7 +120,7 @@ func revokeCert(ctx context.Context, b *backend, req *logical.Request, serial st
 
 	}
 
-	crlErr := buildCRL(ctx, b, req, false)
+	crlErr := updateCRLOnRevocation(ctx, b, req, serial)
 	switch crlErr.(type) {
 	case errutil.UserError:
 		return logical.ErrorResponse(fmt.Sprintf("Error during CRL building: %s", crlErr)), nil
@@ -139,8 +139,97 @@ func revokeCert(ctx context.Context, b *backend, req *logical.Request, serial st
 	return resp, nil
 }
 
-// Builds a CRL by going through the list of revoked certificates and building
-// a new CRL with the stored revocation times and serial numbers.
+// crlState tracks the CRLs built so far, so that delta CRLs can be built
+// from the revocations since the last complete CRL
+type crlState struct {
+	// CRLNumber is the number of the last CRL built, complete or delta;
+	// both share the same sequence
+	CRLNumber int64 `json:"crl_number"`
+
+	// BaseCRLNumber is the number of the current complete CRL
+	BaseCRLNumber int64 `json:"base_crl_number"`
+
+	// NextUpdate is when the current complete CRL expires
+	NextUpdate time.Time `json:"next_update"`
+
+	// DeltaSerials are the certificates revoked since the current complete
+	// CRL was built
+	DeltaSerials []string `json:"delta_serials"`
+
+	// DeltaDirty is set when the delta CRL lacks some of DeltaSerials
+	DeltaDirty bool `json:"delta_dirty"`
+
+	// LastDeltaBuild is when the delta CRL was last built
+	LastDeltaBuild time.Time `json:"last_delta_build"`
+}
+
+// oidDeltaCRLIndicator identifies the extension marking a CRL as a delta
+// CRL, see RFC 5280 section 5.2.4
+var oidDeltaCRLIndicator = asn1.ObjectIdentifier{2, 5, 29, 27}
+
+func fetchCRLState(ctx context.Context, s logical.Storage) (*crlState, error) {
+	entry, err := s.Get(ctx, "crl/state")
+	if err != nil {
+		return nil, errutil.InternalError{Err: fmt.Sprintf("error fetching CRL state: %s", err)}
+	}
+
+	var state crlState
+	if entry != nil {
+		if err := entry.DecodeJSON(&state); err != nil {
+			return nil, errutil.InternalError{Err: fmt.Sprintf("error decoding CRL state: %s", err)}
+		}
+	}
+
+	return &state, nil
+}
+
+func storeCRLState(ctx context.Context, s logical.Storage, state *crlState) error {
+	entry, err := logical.StorageEntryJSON("crl/state", state)
+	if err != nil {
+		return errutil.InternalError{Err: fmt.Sprintf("error encoding CRL state: %s", err)}
+	}
+	if err := s.Put(ctx, entry); err != nil {
+		return errutil.InternalError{Err: fmt.Sprintf("error storing CRL state: %s", err)}
+	}
+	return nil
+}
+
+// updateCRLOnRevocation makes a newly revoked certificate appear on the CRL.
+// Unless the CRL is rebuilt automatically, it is rebuilt right away;
+// otherwise the revocation is recorded for the next delta CRL, and only
+// appears on the complete CRL once it is next rebuilt.
+func updateCRLOnRevocation(ctx context.Context, b *backend, req *logical.Request, serial string) error {
+	crlInfo, err := b.CRL(ctx, req.Storage)
+	if err != nil {
+		return errutil.InternalError{Err: fmt.Sprintf("error fetching CRL config information: %s", err)}
+	}
+	if crlInfo == nil || !crlInfo.AutoRebuild {
+		return buildCRL(ctx, b, req, false)
+	}
+
+	b.crlBuildLock.Lock()
+	defer b.crlBuildLock.Unlock()
+
+	state, err := fetchCRLState(ctx, req.Storage)
+	if err != nil {
+		return err
+	}
+	serial = normalizeSerial(serial)
+	for _, existing := range state.DeltaSerials {
+		if existing == serial {
+			return nil
+		}
+	}
+	state.DeltaSerials = append(state.DeltaSerials, serial)
+	state.DeltaDirty = true
+
+	return storeCRLState(ctx, req.Storage, state)
+}
+
+// Builds the CRL of each issuer by going through the list of revoked
+// certificates and building a new CRL with the stored revocation times and
+// serial numbers of those it issued. The delta CRL of the default issuer, if
+// enabled, is rebuilt empty along with it.
 func buildCRL(ctx context.Context, b *backend, req *logical.Request, forceNew bool) error {
 	crlInfo, err := b.CRL(ctx, req.Storage)
 	if err != nil {
@@ -148,10 +237,17 @@ func buildCRL(ctx context.Context, b *backend, req *logical.Request, forceNew bo
 	}
 
 	crlLifetime := b.crlLifetime
-	var revokedCerts []pkix.RevokedCertificate
-	var revInfo revocationInfo
+	revokedCerts := make(map[string][]pkix.RevokedCertificate)
 	var revokedSerials []string
 
+	issuers, err := fetchIssuers(ctx, req)
+	if err != nil {
+		return errutil.InternalError{Err: fmt.Sprintf("error fetching CA certificate: %s", err)}
+	}
+	if len(issuers) == 0 {
+		return errutil.UserError{Err: "could not fetch the CA certificate: backend must be configured with a CA certificate/key"}
+	}
+
 	if crlInfo != nil {
 		if crlInfo.Expiry != "" {
 			crlDur, err := time.ParseDuration(crlInfo.Expiry)
@@ -175,64 +271,252 @@ func buildCRL(ctx context.Context, b *backend, req *logical.Request, forceNew bo
 	}
 
 	for _, serial := range revokedSerials {
-		revokedEntry, err := req.Storage.Get(ctx, "revoked/"+serial)
+		revokedCert, cert, err := fetchRevokedCertificate(ctx, req, serial)
 		if err != nil {
-			return errutil.InternalError{Err: fmt.Sprintf("unable to fetch revoked cert with serial %s: %s", serial, err)}
+			return err
 		}
-		if revokedEntry == nil {
+		if revokedCert == nil {
 			return errutil.InternalError{Err: fmt.Sprintf("revoked certificate entry for serial %s is nil", serial)}
 		}
-		if revokedEntry.Value == nil || len(revokedEntry.Value) == 0 {
-			// TODO: In this case, remove it and continue? How likely is this to
-			// happen? Alternately, could skip it entirely, or could implement a
-			// delete function so that there is a way to remove these
-			return errutil.InternalError{Err: fmt.Sprintf("found revoked serial but actual certificate is empty")}
+		for _, name := range crlIssuers(cert, issuers) {
+			revokedCerts[name] = append(revokedCerts[name], *revokedCert)
 		}
+	}
 
-		err = revokedEntry.DecodeJSON(&revInfo)
+WRITE:
+	b.crlBuildLock.Lock()
+	defer b.crlBuildLock.Unlock()
+
+	state, err := fetchCRLState(ctx, req.Storage)
+	if err != nil {
+		return err
+	}
+
+	// All CRLs built together share the same number
+	now := time.Now()
+	crlNumber := state.CRLNumber + 1
+	var defaultIssuer *caInfoBundle
+	for _, issuer := range issuers {
+		crlBytes, err := createCRL(issuer.bundle, revokedCerts[issuer.name], crlNumber, 0, now, now.Add(crlLifetime))
 		if err != nil {
-			return errutil.InternalError{Err: fmt.Sprintf("error decoding revocation entry for serial %s: %s", serial, err)}
+			return err
 		}
 
-		revokedCert, err := x509.ParseCertificate(revInfo.CertificateBytes)
+		err = req.Storage.Put(ctx, &logical.StorageEntry{
+			Key:   issuerCRLPath(issuer.name),
+			Value: crlBytes,
+		})
 		if err != nil {
-			return errutil.InternalError{Err: fmt.Sprintf("unable to parse stored revoked certificate with serial %s: %s", serial, err)}
+			return errutil.InternalError{Err: fmt.Sprintf("error storing CRL: %s", err)}
 		}
 
-		// NOTE: We have to change this to UTC time because the CRL standard
-		// mandates it but Go will happily encode the CRL without this.
-		newRevCert := pkix.RevokedCertificate{
-			SerialNumber: revokedCert.SerialNumber,
+		if issuer.name == defaultIssuerName {
+			defaultIssuer = issuer.bundle
 		}
-		if !revInfo.RevocationTimeUTC.IsZero() {
-			newRevCert.RevocationTime = revInfo.RevocationTimeUTC
-		} else {
-			newRevCert.RevocationTime = time.Unix(revInfo.RevocationTime, 0).UTC()
+	}
+
+	state.CRLNumber = crlNumber
+	state.BaseCRLNumber = crlNumber
+	state.NextUpdate = now.Add(crlLifetime)
+	state.DeltaSerials = nil
+	state.DeltaDirty = defaultIssuer != nil && crlInfo != nil && crlInfo.EnableDelta && !crlInfo.Disable
+	if err := storeCRLState(ctx, req.Storage, state); err != nil {
+		return err
+	}
+
+	if !state.DeltaDirty {
+		return nil
+	}
+	return buildDeltaCRLLocked(ctx, b, req, issuers, state)
+}
+
+// crlIssuers returns the names of the issuers whose CRL lists the given
+// revoked certificate. Certificates none of the issuers issued, e.g. those
+// of a previous CA, are listed on the CRL of the default issuer.
+func crlIssuers(cert *x509.Certificate, issuers []issuer) []string {
+	var names []string
+	for _, issuer := range issuers {
+		if issuedBy(cert, issuer.bundle.Certificate) {
+			names = append(names, issuer.name)
 		}
-		revokedCerts = append(revokedCerts, newRevCert)
 	}
+	if len(names) == 0 {
+		names = []string{defaultIssuerName}
+	}
+	return names
+}
 
-WRITE:
-	signingBundle, caErr := fetchCAInfo(ctx, req)
-	switch caErr.(type) {
-	case errutil.UserError:
-		return errutil.UserError{Err: fmt.Sprintf("could not fetch the CA certificate: %s", caErr)}
-	case errutil.InternalError:
-		return errutil.InternalError{Err: fmt.Sprintf("error fetching CA certificate: %s", caErr)}
+// issuerCRLPath returns the storage path of the CRL of the given issuer
+func issuerCRLPath(name string) string {
+	if name == defaultIssuerName {
+		return "crl"
+	}
+	return "crls/" + name
+}
+
+// buildDeltaCRL builds a delta CRL listing the certificates revoked since
+// the current complete CRL was built.
+func buildDeltaCRL(ctx context.Context, b *backend, req *logical.Request) error {
+	crlInfo, err := b.CRL(ctx, req.Storage)
+	if err != nil {
+		return errutil.InternalError{Err: fmt.Sprintf("error fetching CRL config information: %s", err)}
+	}
+	if crlInfo == nil || !crlInfo.EnableDelta || crlInfo.Disable {
+		return errutil.UserError{Err: "delta CRLs are not enabled"}
 	}
 
-	crlBytes, err := signingBundle.Certificate.CreateCRL(rand.Reader, signingBundle.PrivateKey, revokedCerts, time.Now(), time.Now().Add(crlLifetime))
+	issuers, err := fetchIssuers(ctx, req)
 	if err != nil {
-		return errutil.InternalError{Err: fmt.Sprintf("error creating new CRL: %s", err)}
+		return errutil.InternalError{Err: fmt.Sprintf("error fetching CA certificate: %s", err)}
+	}
+	if len(issuers) == 0 || issuers[0].name != defaultIssuerName {
+		return errutil.UserError{Err: "could not fetch the CA certificate: backend must be configured with a CA certificate/key"}
+	}
+
+	b.crlBuildLock.Lock()
+	defer b.crlBuildLock.Unlock()
+
+	state, err := fetchCRLState(ctx, req.Storage)
+	if err != nil {
+		return err
+	}
+	if state.BaseCRLNumber == 0 {
+		// Complete CRLs built before delta CRLs were supported carry no
+		// number a delta CRL could refer to
+		return errutil.UserError{Err: "the complete CRL must be rebuilt before a delta CRL can be built"}
+	}
+
+	return buildDeltaCRLLocked(ctx, b, req, issuers, state)
+}
+
+// buildDeltaCRLLocked builds the delta CRL of the default issuer, which must
+// come first in the given issuers, and updates the state. The caller must
+// hold crlBuildLock.
+func buildDeltaCRLLocked(ctx context.Context, b *backend, req *logical.Request, issuers []issuer, state *crlState) error {
+	var revokedCerts []pkix.RevokedCertificate
+	for _, serial := range state.DeltaSerials {
+		revokedCert, cert, err := fetchRevokedCertificate(ctx, req, serial)
+		if err != nil {
+			return err
+		}
+		// Entries may have been tidied since
+		if revokedCert == nil {
+			continue
+		}
+		for _, name := range crlIssuers(cert, issuers) {
+			if name == defaultIssuerName {
+				revokedCerts = append(revokedCerts, *revokedCert)
+			}
+		}
+	}
+
+	now := time.Now()
+	nextUpdate := state.NextUpdate
+	if nextUpdate.Before(now) {
+		nextUpdate = now
+	}
+	crlNumber := state.CRLNumber + 1
+	crlBytes, err := createCRL(issuers[0].bundle, revokedCerts, crlNumber, state.BaseCRLNumber, now, nextUpdate)
+	if err != nil {
+		return err
 	}
 
 	err = req.Storage.Put(ctx, &logical.StorageEntry{
-		Key:   "crl",
+		Key:   "crl/delta",
 		Value: crlBytes,
 	})
 	if err != nil {
-		return errutil.InternalError{Err: fmt.Sprintf("error storing CRL: %s", err)}
+		return errutil.InternalError{Err: fmt.Sprintf("error storing delta CRL: %s", err)}
 	}
 
-	return nil
+	state.CRLNumber = crlNumber
+	state.DeltaDirty = false
+	state.LastDeltaBuild = now
+	return storeCRLState(ctx, req.Storage, state)
+}
+
+// fetchRevokedCertificate returns the CRL entry of the revoked certificate
+// with the given serial along with the certificate, or nil if it is not
+// revoked.
+func fetchRevokedCertificate(ctx context.Context, req *logical.Request, serial string) (*pkix.RevokedCertificate, *x509.Certificate, error) {
+	revokedEntry, err := req.Storage.Get(ctx, "revoked/"+serial)
+	if err != nil {
+		return nil, nil, errutil.InternalError{Err: fmt.Sprintf("unable to fetch revoked cert with serial %s: %s", serial, err)}
+	}
+	if revokedEntry == nil {
+		return nil, nil, nil
+	}
+	if revokedEntry.Value == nil || len(revokedEntry.Value) == 0 {
+		// TODO: In this case, remove it and continue? How likely is this to
+		// happen? Alternately, could skip it entirely, or could implement a
+		// delete function so that there is a way to remove these
+		return nil, nil, errutil.InternalError{Err: fmt.Sprintf("found revoked serial but actual certificate is empty")}
+	}
+
+	var revInfo revocationInfo
+	err = revokedEntry.DecodeJSON(&revInfo)
+	if err != nil {
+		return nil, nil, errutil.InternalError{Err: fmt.Sprintf("error decoding revocation entry for serial %s: %s", serial, err)}
+	}
+
+	revokedCert, err := x509.ParseCertificate(revInfo.CertificateBytes)
+	if err != nil {
+		return nil, nil, errutil.InternalError{Err: fmt.Sprintf("unable to parse stored revoked certificate with serial %s: %s", serial, err)}
+	}
+
+	// NOTE: We have to change this to UTC time because the CRL standard
+	// mandates it but Go will happily encode the CRL without this.
+	newRevCert := &pkix.RevokedCertificate{
+		SerialNumber: revokedCert.SerialNumber,
+	}
+	if !revInfo.RevocationTimeUTC.IsZero() {
+		newRevCert.RevocationTime = revInfo.RevocationTimeUTC
+	} else {
+		newRevCert.RevocationTime = time.Unix(revInfo.RevocationTime, 0).UTC()
+	}
+	return newRevCert, revokedCert, nil
+}
+
+// createCRL signs a CRL with the given number. If baseCRLNumber is set, it is
+// a delta CRL on top of the complete CRL with that number.
+func createCRL(signingBundle *caInfoBundle, revokedCerts []pkix.RevokedCertificate, crlNumber, baseCRLNumber int64, thisUpdate, nextUpdate time.Time) ([]byte, error) {
+	caCert := signingBundle.Certificate
+	if caCert.KeyUsage&x509.KeyUsageCRLSign == 0 || len(caCert.SubjectKeyId) == 0 {
+		if baseCRLNumber != 0 {
+			return nil, errutil.UserError{Err: "delta CRLs require a CA certificate with the CRL signing key usage and a subject key identifier"}
+		}
+
+		// Such a CA, e.g. imported, can only sign CRLs without a number
+		crlBytes, err := caCert.CreateCRL(rand.Reader, signingBundle.PrivateKey, revokedCerts, thisUpdate, nextUpdate)
+		if err != nil {
+			return nil, errutil.InternalError{Err: fmt.Sprintf("error creating new CRL: %s", err)}
+		}
+		return crlBytes, nil
+	}
+
+	template := &x509.RevocationList{
+		RevokedCertificates: revokedCerts,
+		Number:              big.NewInt(crlNumber),
+		ThisUpdate:          thisUpdate,
+		NextUpdate:          nextUpdate,
+	}
+	if baseCRLNumber != 0 {
+		value, err := asn1.Marshal(big.NewInt(baseCRLNumber))
+		if err != nil {
+			return nil, errutil.InternalError{Err: fmt.Sprintf("error encoding base CRL number: %s", err)}
+		}
+		template.ExtraExtensions = []pkix.Extension{
+			{
+				Id:       oidDeltaCRLIndicator,
+				Critical: true,
+				Value:    value,
+			},
+		}
+	}
+
+	crlBytes, err := x509.CreateRevocationList(rand.Reader, template, caCert, signingBundle.PrivateKey)
+	if err != nil {
+		return nil, errutil.InternalError{Err: fmt.Sprintf("error creating new CRL: %s", err)}
+	}
+	return crlBytes, nil
 }
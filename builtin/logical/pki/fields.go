@@ -230,3 +230,14 @@ func addCAIssueFields(fields map[string]*framework.FieldSchema) map[string]*fram
 
 	return fields
 }
+
+// issuerField returns the field selecting the issuer a certificate is signed
+// by
+func issuerField() *framework.FieldSchema {
+	return &framework.FieldSchema{
+		Type:    framework.TypeString,
+		Default: defaultIssuerName,
+		Description: `The name of the issuer to sign with; defaults to
+the CA of the mount.`,
+	}
+}
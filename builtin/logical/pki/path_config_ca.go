@@ -31,33 +31,9 @@ secret key and certificate.`,
 }
 
 func (b *backend) pathCAWrite(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
-	pemBundle := data.Get("pem_bundle").(string)
-
-	if pemBundle == "" {
-		return logical.ErrorResponse("'pem_bundle' was empty"), nil
-	}
-
-	parsedBundle, err := certutil.ParsePEMBundle(pemBundle)
-	if err != nil {
-		switch err.(type) {
-		case errutil.InternalError:
-			return nil, err
-		default:
-			return logical.ErrorResponse(err.Error()), nil
-		}
-	}
-
-	if parsedBundle.PrivateKey == nil ||
-		parsedBundle.PrivateKeyType == certutil.UnknownPrivateKey {
-		return logical.ErrorResponse("private key not found in the PEM bundle"), nil
-	}
-
-	if parsedBundle.Certificate == nil {
-		return logical.ErrorResponse("no certificate found in the PEM bundle"), nil
-	}
-
-	if !parsedBundle.Certificate.IsCA {
-		return logical.ErrorResponse("the given certificate is not marked for CA use and cannot be used with this backend"), nil
+	parsedBundle, errResp, err := parseCABundle(data.Get("pem_bundle").(string))
+	if errResp != nil || err != nil {
+		return errResp, err
 	}
 
 	cb, err := parsedBundle.ToCertBundle()
@@ -88,6 +64,39 @@ func (b *backend) pathCAWrite(ctx context.Context, req *logical.Request, data *f
 	return nil, err
 }
 
+// parseCABundle parses a PEM bundle holding the certificate and private key
+// of a CA, returning an error response if it is unsuitable
+func parseCABundle(pemBundle string) (*certutil.ParsedCertBundle, *logical.Response, error) {
+	if pemBundle == "" {
+		return nil, logical.ErrorResponse("'pem_bundle' was empty"), nil
+	}
+
+	parsedBundle, err := certutil.ParsePEMBundle(pemBundle)
+	if err != nil {
+		switch err.(type) {
+		case errutil.InternalError:
+			return nil, nil, err
+		default:
+			return nil, logical.ErrorResponse(err.Error()), nil
+		}
+	}
+
+	if parsedBundle.PrivateKey == nil ||
+		parsedBundle.PrivateKeyType == certutil.UnknownPrivateKey {
+		return nil, logical.ErrorResponse("private key not found in the PEM bundle"), nil
+	}
+
+	if parsedBundle.Certificate == nil {
+		return nil, logical.ErrorResponse("no certificate found in the PEM bundle"), nil
+	}
+
+	if !parsedBundle.Certificate.IsCA {
+		return nil, logical.ErrorResponse("the given certificate is not marked for CA use and cannot be used with this backend"), nil
+	}
+
+	return parsedBundle, nil, nil
+}
+
 const pathConfigCAHelpSyn = `
 Set the CA certificate and private key used for generated credentials.
 `
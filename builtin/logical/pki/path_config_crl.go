@@ -13,8 +13,31 @@ import (
 
 // CRLConfig holds basic CRL configuration information
 type crlConfig struct {
-	Expiry  string `json:"expiry" mapstructure:"expiry"`
-	Disable bool   `json:"disable"`
+	Expiry                 string `json:"expiry" mapstructure:"expiry"`
+	Disable                bool   `json:"disable"`
+	AutoRebuild            bool   `json:"auto_rebuild"`
+	AutoRebuildGracePeriod string `json:"auto_rebuild_grace_period"`
+	EnableDelta            bool   `json:"enable_delta"`
+	DeltaRebuildInterval   string `json:"delta_rebuild_interval"`
+}
+
+const (
+	defaultAutoRebuildGracePeriod = "12h"
+	defaultDeltaRebuildInterval   = "15m"
+)
+
+func (c *crlConfig) autoRebuildGracePeriod() (time.Duration, error) {
+	if c.AutoRebuildGracePeriod == "" {
+		return time.ParseDuration(defaultAutoRebuildGracePeriod)
+	}
+	return time.ParseDuration(c.AutoRebuildGracePeriod)
+}
+
+func (c *crlConfig) deltaRebuildInterval() (time.Duration, error) {
+	if c.DeltaRebuildInterval == "" {
+		return time.ParseDuration(defaultDeltaRebuildInterval)
+	}
+	return time.ParseDuration(c.DeltaRebuildInterval)
 }
 
 func pathConfigCRL(b *backend) *framework.Path {
@@ -31,6 +54,29 @@ valid; defaults to 72 hours`,
 				Type:        framework.TypeBool,
 				Description: `If set to true, disables generating the CRL entirely.`,
 			},
+			"auto_rebuild": &framework.FieldSchema{
+				Type: framework.TypeBool,
+				Description: `If set to true, the CRL is rebuilt periodically
+ahead of its expiry rather than on every revocation.`,
+			},
+			"auto_rebuild_grace_period": &framework.FieldSchema{
+				Type: framework.TypeString,
+				Description: `How long before its expiry the CRL is rebuilt
+when auto_rebuild is set; defaults to 12 hours`,
+				Default: defaultAutoRebuildGracePeriod,
+			},
+			"enable_delta": &framework.FieldSchema{
+				Type: framework.TypeBool,
+				Description: `If set to true, a delta CRL listing the
+certificates revoked since the last complete CRL is built. Requires
+auto_rebuild.`,
+			},
+			"delta_rebuild_interval": &framework.FieldSchema{
+				Type: framework.TypeString,
+				Description: `How often the delta CRL is rebuilt when
+certificates were revoked; defaults to 15 minutes`,
+				Default: defaultDeltaRebuildInterval,
+			},
 		},
 
 		Callbacks: map[logical.Operation]framework.OperationFunc{
@@ -69,12 +115,24 @@ func (b *backend) pathCRLRead(ctx context.Context, req *logical.Request, data *f
 		return nil, nil
 	}
 
-	return &logical.Response{
+	resp := &logical.Response{
 		Data: map[string]interface{}{
-			"expiry":  config.Expiry,
-			"disable": config.Disable,
+			"expiry":                    config.Expiry,
+			"disable":                   config.Disable,
+			"auto_rebuild":              config.AutoRebuild,
+			"auto_rebuild_grace_period": config.AutoRebuildGracePeriod,
+			"enable_delta":              config.EnableDelta,
+			"delta_rebuild_interval":    config.DeltaRebuildInterval,
 		},
-	}, nil
+	}
+	if config.AutoRebuildGracePeriod == "" {
+		resp.Data["auto_rebuild_grace_period"] = defaultAutoRebuildGracePeriod
+	}
+	if config.DeltaRebuildInterval == "" {
+		resp.Data["delta_rebuild_interval"] = defaultDeltaRebuildInterval
+	}
+
+	return resp, nil
 }
 
 func (b *backend) pathCRLWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
@@ -101,6 +159,51 @@ func (b *backend) pathCRLWrite(ctx context.Context, req *logical.Request, d *fra
 		config.Disable = disableRaw.(bool)
 	}
 
+	oldAutoRebuild, oldEnableDelta := config.AutoRebuild, config.EnableDelta
+	if autoRebuildRaw, ok := d.GetOk("auto_rebuild"); ok {
+		config.AutoRebuild = autoRebuildRaw.(bool)
+	}
+	if enableDeltaRaw, ok := d.GetOk("enable_delta"); ok {
+		config.EnableDelta = enableDeltaRaw.(bool)
+	}
+	if config.EnableDelta && !config.AutoRebuild {
+		return logical.ErrorResponse("enable_delta requires auto_rebuild"), nil
+	}
+
+	if gracePeriodRaw, ok := d.GetOk("auto_rebuild_grace_period"); ok {
+		gracePeriod := gracePeriodRaw.(string)
+		if _, err := time.ParseDuration(gracePeriod); err != nil {
+			return logical.ErrorResponse(fmt.Sprintf("given auto_rebuild_grace_period could not be decoded: %s", err)), nil
+		}
+		config.AutoRebuildGracePeriod = gracePeriod
+	}
+	if intervalRaw, ok := d.GetOk("delta_rebuild_interval"); ok {
+		interval := intervalRaw.(string)
+		parsed, err := time.ParseDuration(interval)
+		if err != nil {
+			return logical.ErrorResponse(fmt.Sprintf("given delta_rebuild_interval could not be decoded: %s", err)), nil
+		}
+		if parsed <= 0 {
+			return logical.ErrorResponse("delta_rebuild_interval must be positive"), nil
+		}
+		config.DeltaRebuildInterval = interval
+	}
+
+	if config.AutoRebuild {
+		crlLifetime := b.crlLifetime
+		if config.Expiry != "" {
+			// Validated above when given, and when stored before
+			crlLifetime, _ = time.ParseDuration(config.Expiry)
+		}
+		gracePeriod, err := config.autoRebuildGracePeriod()
+		if err != nil {
+			return nil, err
+		}
+		if gracePeriod >= crlLifetime {
+			return logical.ErrorResponse("auto_rebuild_grace_period must be shorter than the CRL expiry"), nil
+		}
+	}
+
 	entry, err := logical.StorageEntryJSON("config/crl", config)
 	if err != nil {
 		return nil, err
@@ -110,8 +213,15 @@ func (b *backend) pathCRLWrite(ctx context.Context, req *logical.Request, d *fra
 		return nil, err
 	}
 
-	if oldDisable != config.Disable {
-		// It wasn't disabled but now it is, rotate
+	if oldEnableDelta && !config.EnableDelta {
+		if err := req.Storage.Delete(ctx, "crl/delta"); err != nil {
+			return nil, err
+		}
+	}
+
+	// Rotate when the CRL gets disabled or enabled, and when revocations only
+	// recorded for the delta CRL must now go on the complete CRL
+	if oldDisable != config.Disable || oldAutoRebuild != config.AutoRebuild || oldEnableDelta != config.EnableDelta {
 		crlErr := buildCRL(ctx, b, req, true)
 		switch crlErr.(type) {
 		case errutil.UserError:
@@ -129,5 +239,7 @@ Configure the CRL expiration.
 `
 
 const pathConfigCRLHelpDesc = `
-This endpoint allows configuration of the CRL lifetime.
+This endpoint allows configuration of the CRL lifetime, and of whether the
+CRL is rebuilt on every revocation or periodically ahead of its expiry, in
+which case a delta CRL can list the certificates revoked in between.
 `
@@ -0,0 +1,170 @@
+package pki
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/helper/errutil"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+// ocspConfig holds the configuration of the OCSP responder
+type ocspConfig struct {
+	NextUpdate         string `json:"next_update"`
+	DelegatedSigner    bool   `json:"delegated_signer"`
+	DelegatedSignerTTL string `json:"delegated_signer_ttl"`
+}
+
+func defaultOCSPConfig() *ocspConfig {
+	return &ocspConfig{
+		NextUpdate:         "12h",
+		DelegatedSignerTTL: "720h",
+	}
+}
+
+func pathConfigOCSP(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "config/ocsp",
+		Fields: map[string]*framework.FieldSchema{
+			"next_update": &framework.FieldSchema{
+				Type: framework.TypeString,
+				Description: `How long OCSP responses may be cached by clients
+before asking again; defaults to 12 hours`,
+				Default: "12h",
+			},
+			"delegated_signer": &framework.FieldSchema{
+				Type: framework.TypeBool,
+				Description: `If set to true, OCSP responses are signed by a
+delegated OCSP signing certificate issued by the CA rather than by the
+CA key itself.`,
+			},
+			"delegated_signer_ttl": &framework.FieldSchema{
+				Type: framework.TypeString,
+				Description: `The validity period of delegated OCSP signing
+certificates; defaults to 720 hours. A new one is issued once half of it
+has elapsed.`,
+				Default: "720h",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation:   b.pathOCSPConfigRead,
+			logical.UpdateOperation: b.pathOCSPConfigWrite,
+		},
+
+		HelpSynopsis:    pathConfigOCSPHelpSyn,
+		HelpDescription: pathConfigOCSPHelpDesc,
+	}
+}
+
+func (b *backend) OCSP(ctx context.Context, s logical.Storage) (*ocspConfig, error) {
+	entry, err := s.Get(ctx, "config/ocsp")
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	result := defaultOCSPConfig()
+	if err := entry.DecodeJSON(result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (b *backend) pathOCSPConfigRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	config, err := b.OCSP(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if config == nil {
+		return nil, nil
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"next_update":          config.NextUpdate,
+			"delegated_signer":     config.DelegatedSigner,
+			"delegated_signer_ttl": config.DelegatedSignerTTL,
+		},
+	}, nil
+}
+
+func (b *backend) pathOCSPConfigWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	config, err := b.OCSP(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if config == nil {
+		config = defaultOCSPConfig()
+	}
+
+	if nextUpdateRaw, ok := d.GetOk("next_update"); ok {
+		nextUpdate := nextUpdateRaw.(string)
+		if _, err := time.ParseDuration(nextUpdate); err != nil {
+			return logical.ErrorResponse(fmt.Sprintf("given next_update could not be decoded: %s", err)), nil
+		}
+		config.NextUpdate = nextUpdate
+	}
+
+	if ttlRaw, ok := d.GetOk("delegated_signer_ttl"); ok {
+		ttl := ttlRaw.(string)
+		parsed, err := time.ParseDuration(ttl)
+		if err != nil {
+			return logical.ErrorResponse(fmt.Sprintf("given delegated_signer_ttl could not be decoded: %s", err)), nil
+		}
+		if parsed <= 0 {
+			return logical.ErrorResponse("delegated_signer_ttl must be positive"), nil
+		}
+		config.DelegatedSignerTTL = ttl
+	}
+
+	if delegatedRaw, ok := d.GetOk("delegated_signer"); ok {
+		config.DelegatedSigner = delegatedRaw.(bool)
+	}
+
+	entry, err := logical.StorageEntryJSON("config/ocsp", config)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	// Issue the delegated signer right away rather than on the first OCSP
+	// request, which may be served by a node unable to write to storage
+	if !config.DelegatedSigner {
+		if err := req.Storage.Delete(ctx, ocspSignerPath); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+
+	b.ocspSignerLock.Lock()
+	defer b.ocspSignerLock.Unlock()
+	_, err = b.rotateOCSPSigner(ctx, req, config, true)
+	switch err.(type) {
+	case nil:
+	case errutil.UserError:
+		return logical.ErrorResponse(fmt.Sprintf("error issuing the delegated OCSP signer: %s", err)), nil
+	default:
+		return nil, errwrap.Wrapf("error issuing the delegated OCSP signer: {{err}}", err)
+	}
+
+	return nil, nil
+}
+
+const pathConfigOCSPHelpSyn = `
+Configure the OCSP responder.
+`
+
+const pathConfigOCSPHelpDesc = `
+This endpoint allows configuration of how long OCSP responses are valid for,
+and whether they are signed by the CA key or by a delegated OCSP signing
+certificate issued by the CA.
+`
@@ -182,6 +182,7 @@ func (b *backend) pathSignVerbatim(ctx context.Context, req *logical.Request, da
 			*entry.GenerateLease = *role.GenerateLease
 		}
 		entry.NoStore = role.NoStore
+		entry.Issuer = role.Issuer
 	}
 
 	return b.pathIssueSignCert(ctx, req, data, entry, true, true)
@@ -195,7 +196,7 @@ func (b *backend) pathIssueSignCert(ctx context.Context, req *logical.Request, d
 	}
 
 	var caErr error
-	signingBundle, caErr := fetchCAInfo(ctx, req)
+	signingBundle, caErr := fetchIssuerInfo(ctx, req, role.Issuer)
 	switch caErr.(type) {
 	case errutil.UserError:
 		return nil, errutil.UserError{Err: fmt.Sprintf(
@@ -205,6 +206,8 @@ func (b *backend) pathIssueSignCert(ctx context.Context, req *logical.Request, d
 			"error fetching CA certificate: %s", caErr)}
 	}
 
+	role = populateRoleTemplates(b, req, role)
+
 	input := &dataBundle{
 		req:           req,
 		apiData:       data,
@@ -331,6 +334,47 @@ func (b *backend) pathIssueSignCert(ctx context.Context, req *logical.Request, d
 	return resp, nil
 }
 
+// populateRoleTemplates returns a copy of the role with the identity templates
+// in its allowed domains and URI SANs, if enabled, populated from the entity
+// of the request. Entries that can't be populated, e.g. because the token has
+// no entity or the entity lacks the metadata, are dropped so they allow
+// nothing.
+func populateRoleTemplates(b *backend, req *logical.Request, role *roleEntry) *roleEntry {
+	if !role.AllowedDomainsTemplate && !role.AllowedURISANsTemplate {
+		return role
+	}
+
+	populate := func(values []string) []string {
+		var populated []string
+		for _, value := range values {
+			isTemplate, err := framework.ValidateIdentityTemplate(value)
+			if err != nil {
+				continue
+			}
+			if isTemplate {
+				if req.EntityID == "" {
+					continue
+				}
+				value, err = framework.PopulateIdentityTemplate(value, req.EntityID, b.System())
+				if err != nil {
+					continue
+				}
+			}
+			populated = append(populated, value)
+		}
+		return populated
+	}
+
+	populated := *role
+	if role.AllowedDomainsTemplate {
+		populated.AllowedDomains = populate(role.AllowedDomains)
+	}
+	if role.AllowedURISANsTemplate {
+		populated.AllowedURISANs = populate(role.AllowedURISANs)
+	}
+	return &populated
+}
+
 const pathIssueHelpSyn = `
 Request a certificate using a certain role with the provided details.
 `
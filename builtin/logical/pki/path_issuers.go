@@ -0,0 +1,261 @@
+package pki
+
+import (
+	"context"
+	"encoding/pem"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/helper/errutil"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+// defaultIssuerName is the name of the CA configured for the mount as a
+// whole through config/ca, root/generate or intermediate/set-signed
+const defaultIssuerName = "default"
+
+var issuerNameRegex = regexp.MustCompile("^" + framework.GenericNameRegex("name") + "$")
+
+func pathListIssuers(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "issuers/?$",
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ListOperation: b.pathIssuerList,
+		},
+
+		HelpSynopsis:    pathListIssuersHelpSyn,
+		HelpDescription: pathListIssuersHelpDesc,
+	}
+}
+
+func pathIssuers(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "issuers/" + framework.GenericNameRegex("name"),
+		Fields: map[string]*framework.FieldSchema{
+			"name": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Name of the issuer",
+			},
+
+			"pem_bundle": &framework.FieldSchema{
+				Type: framework.TypeString,
+				Description: `PEM-format, concatenated unencrypted
+secret key and certificate.`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation:   b.pathIssuerRead,
+			logical.UpdateOperation: b.pathIssuerWrite,
+			logical.DeleteOperation: b.pathIssuerDelete,
+		},
+
+		HelpSynopsis:    pathIssuersHelpSyn,
+		HelpDescription: pathIssuersHelpDesc,
+	}
+}
+
+// Returns the certificate or CRL of an issuer in raw format
+func pathFetchIssuer(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "issuer/" + framework.GenericNameRegex("name") + `(/crl)?(/pem)?$`,
+		Fields: map[string]*framework.FieldSchema{
+			"name": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Name of the issuer",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation: b.pathFetchIssuerRead,
+		},
+
+		HelpSynopsis:    pathFetchIssuerHelpSyn,
+		HelpDescription: pathFetchIssuerHelpDesc,
+	}
+}
+
+func (b *backend) pathIssuerList(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	issuers, err := fetchIssuers(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(issuers))
+	for _, issuer := range issuers {
+		names = append(names, issuer.name)
+	}
+
+	return logical.ListResponse(names), nil
+}
+
+func (b *backend) pathIssuerRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	name := data.Get("name").(string)
+
+	caInfo, err := fetchIssuerInfo(ctx, req, name)
+	switch err.(type) {
+	case nil:
+	case errutil.UserError:
+		return nil, nil
+	default:
+		return nil, err
+	}
+
+	cb, err := caInfo.ToCertBundle()
+	if err != nil {
+		return nil, errwrap.Wrapf("error converting raw cert bundle to cert bundle: {{err}}", err)
+	}
+
+	resp := &logical.Response{
+		Data: map[string]interface{}{
+			"certificate":      cb.Certificate,
+			"serial_number":    cb.SerialNumber,
+			"expiration":       caInfo.Certificate.NotAfter.Unix(),
+			"private_key_type": cb.PrivateKeyType,
+		},
+	}
+	if len(cb.CAChain) > 0 {
+		resp.Data["ca_chain"] = cb.CAChain
+	}
+
+	return resp, nil
+}
+
+func (b *backend) pathIssuerWrite(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	name := data.Get("name").(string)
+	if name == defaultIssuerName {
+		return logical.ErrorResponse(fmt.Sprintf("the %q issuer must be set through the config/ca endpoint", defaultIssuerName)), nil
+	}
+
+	entry, err := req.Storage.Get(ctx, "issuers/"+name)
+	if err != nil {
+		return nil, err
+	}
+	if entry != nil {
+		return logical.ErrorResponse(fmt.Sprintf("issuer %q already exists; delete it first to replace it", name)), nil
+	}
+
+	parsedBundle, errResp, err := parseCABundle(data.Get("pem_bundle").(string))
+	if errResp != nil || err != nil {
+		return errResp, err
+	}
+
+	cb, err := parsedBundle.ToCertBundle()
+	if err != nil {
+		return nil, errwrap.Wrapf("error converting raw values into cert bundle: {{err}}", err)
+	}
+
+	entry, err = logical.StorageEntryJSON("issuers/"+name, cb)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	return nil, buildCRL(ctx, b, req, true)
+}
+
+func (b *backend) pathIssuerDelete(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	name := data.Get("name").(string)
+	if name == defaultIssuerName {
+		return logical.ErrorResponse(fmt.Sprintf("the %q issuer must be deleted through the root endpoint", defaultIssuerName)), nil
+	}
+
+	if err := req.Storage.Delete(ctx, "issuers/"+name); err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Delete(ctx, issuerCRLPath(name)); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+func (b *backend) pathFetchIssuerRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	name := data.Get("name").(string)
+	suffix := strings.TrimPrefix(req.Path, "issuer/"+name)
+
+	var raw []byte
+	contentType := "application/pkix-cert"
+	pemType := "CERTIFICATE"
+	if strings.HasPrefix(suffix, "/crl") {
+		contentType = "application/pkix-crl"
+		pemType = "X509 CRL"
+
+		entry, err := req.Storage.Get(ctx, issuerCRLPath(name))
+		if err != nil {
+			return nil, err
+		}
+		if entry != nil {
+			raw = entry.Value
+		}
+	} else {
+		caInfo, err := fetchIssuerInfo(ctx, req, name)
+		switch err.(type) {
+		case nil:
+			raw = caInfo.CertificateBytes
+		case errutil.UserError:
+		default:
+			return nil, err
+		}
+	}
+
+	if len(raw) > 0 && strings.HasSuffix(suffix, "/pem") {
+		raw = []byte(strings.TrimSpace(string(pem.EncodeToMemory(&pem.Block{
+			Type:  pemType,
+			Bytes: raw,
+		}))))
+	}
+
+	statusCode := 200
+	if len(raw) == 0 {
+		statusCode = 204
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			logical.HTTPContentType: contentType,
+			logical.HTTPRawBody:     raw,
+			logical.HTTPStatusCode:  statusCode,
+		},
+	}, nil
+}
+
+const pathListIssuersHelpSyn = `
+List the issuers of this mount.
+`
+
+const pathListIssuersHelpDesc = `
+This lists the names of the CAs this mount can issue certificates from.
+The CA configured for the mount as a whole is listed as "default".
+`
+
+const pathIssuersHelpSyn = `
+Manage the additional issuers of this mount.
+`
+
+const pathIssuersHelpDesc = `
+This path imports, reads and deletes the named CAs of this mount. Roles
+select the CA certificates are issued from through their "issuer"
+parameter; the "default" issuer is the CA configured for the mount as a
+whole.
+
+Each issuer has its own CRL, listing the revoked certificates it issued,
+which is available at "issuer/<name>/crl".
+
+For security reasons, the secret key cannot be retrieved later.
+`
+
+const pathFetchIssuerHelpSyn = `
+Fetch the CA certificate or CRL of an issuer.
+`
+
+const pathFetchIssuerHelpDesc = `
+This returns the CA certificate of the issuer in DER encoding, or its CRL
+when "/crl" is appended. Add "/pem" to either to get PEM encoding.
+`
@@ -0,0 +1,297 @@
+package pki
+
+import (
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/helper/certutil"
+	vaulthttp "github.com/hashicorp/vault/http"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/vault"
+	"golang.org/x/crypto/ocsp"
+)
+
+func TestBackend_Issuers(t *testing.T) {
+	coreConfig := &vault.CoreConfig{
+		LogicalBackends: map[string]logical.Factory{
+			"pki": Factory,
+		},
+	}
+	cluster := vault.NewTestCluster(t, coreConfig, &vault.TestClusterOptions{
+		HandlerFunc: vaulthttp.Handler,
+	})
+	cluster.Start()
+	defer cluster.Cleanup()
+
+	client := cluster.Cores[0].Client
+	err := client.Sys().Mount("pki", &api.MountInput{
+		Type: "pki",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := client.Logical().Write("pki/root/generate/internal", map[string]interface{}{
+		"ttl":         "40h",
+		"common_name": "old.myvault.com",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldCA := parsePEMCert(t, resp.Data["certificate"].(string))
+
+	// The new root is kept alongside the current one
+	resp, err = client.Logical().Write("pki/root/generate/exported", map[string]interface{}{
+		"ttl":         "80h",
+		"common_name": "new.myvault.com",
+		"key_type":    "ec",
+		"key_bits":    256,
+		"issuer_name": "next",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	newCA := parsePEMCert(t, resp.Data["certificate"].(string))
+	newBundle := fmt.Sprintf("%s\n%s", resp.Data["private_key"], resp.Data["certificate"])
+
+	if _, err := client.Logical().Write("pki/root/generate/internal", map[string]interface{}{
+		"common_name": "new.myvault.com",
+		"issuer_name": "next",
+	}); err == nil {
+		t.Fatal("expected an error generating over an existing issuer")
+	}
+
+	resp, err = client.Logical().Read("pki/cert/ca")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ca := parsePEMCert(t, resp.Data["certificate"].(string)); !ca.Equal(oldCA) {
+		t.Fatal("expected the CA of the mount to be unchanged")
+	}
+
+	resp, err = client.Logical().List("pki/issuers")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keys := resp.Data["keys"]; !reflect.DeepEqual(keys, []interface{}{"default", "next"}) {
+		t.Fatalf("bad issuers: %v", keys)
+	}
+
+	resp, err = client.Logical().Read("pki/issuers/next")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Data["serial_number"] != certutil.GetHexFormatted(newCA.SerialNumber.Bytes(), ":") || resp.Data["private_key_type"] != "ec" {
+		t.Fatalf("bad issuer: %#v", resp.Data)
+	}
+	if _, ok := resp.Data["private_key"]; ok {
+		t.Fatal("expected the private key not to be returned")
+	}
+
+	// Roles select their issuer
+	for name, issuer := range map[string]string{"old": "", "new": "next"} {
+		resp, err = client.Logical().Write("pki/roles/"+name, map[string]interface{}{
+			"allowed_domains":  "foobar.com",
+			"allow_subdomains": true,
+			"issuer":           issuer,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp != nil && len(resp.Warnings) > 0 {
+			t.Fatalf("unexpected warnings: %v", resp.Warnings)
+		}
+	}
+	resp, err = client.Logical().Read("pki/roles/old")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Data["issuer"] != "default" {
+		t.Fatalf("bad role issuer: %v", resp.Data["issuer"])
+	}
+	resp, err = client.Logical().Write("pki/roles/missing", map[string]interface{}{
+		"allow_any_name": true,
+		"issuer":         "missing",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp == nil || len(resp.Warnings) == 0 {
+		t.Fatal("expected a warning about the missing issuer")
+	}
+	if _, err := client.Logical().Write("pki/issue/missing", map[string]interface{}{
+		"common_name": "test.foobar.com",
+	}); err == nil {
+		t.Fatal("expected an error issuing from a missing issuer")
+	}
+
+	issue := func(role string) *x509.Certificate {
+		t.Helper()
+		resp, err := client.Logical().Write("pki/issue/"+role, map[string]interface{}{
+			"common_name": "test.foobar.com",
+			"ttl":         "1h",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return parsePEMCert(t, resp.Data["certificate"].(string))
+	}
+	oldCert := issue("old")
+	if err := oldCert.CheckSignatureFrom(oldCA); err != nil {
+		t.Fatal(err)
+	}
+	newCert := issue("new")
+	if err := newCert.CheckSignatureFrom(newCA); err != nil {
+		t.Fatal(err)
+	}
+
+	// Cross-sign the current root with the new one
+	oldPEM, err := client.Logical().Read("pki/issuers/default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err = client.Logical().Write("pki/root/sign-self-issued", map[string]interface{}{
+		"certificate": oldPEM.Data["certificate"],
+		"issuer":      "next",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cross := parsePEMCert(t, resp.Data["certificate"].(string))
+	if err := cross.CheckSignatureFrom(newCA); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(cross.RawSubject, oldCA.RawSubject) || !reflect.DeepEqual(cross.SubjectKeyId, oldCA.SubjectKeyId) {
+		t.Fatal("expected the cross-signed certificate to keep the subject and key of the current root")
+	}
+
+	// Certificates of the old root chain up to the new one
+	roots := x509.NewCertPool()
+	roots.AddCert(newCA)
+	intermediates := x509.NewCertPool()
+	intermediates.AddCert(cross)
+	if _, err := oldCert.Verify(x509.VerifyOptions{Roots: roots, Intermediates: intermediates}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Issuers can't be revoked
+	_, err = client.Logical().Write("pki/revoke", map[string]interface{}{
+		"serial_number": certutil.GetHexFormatted(newCA.SerialNumber.Bytes(), ":"),
+	})
+	if err == nil {
+		t.Fatal("expected an error revoking an issuer")
+	}
+
+	// Revoked certificates are listed on the CRL of their issuer only
+	_, err = client.Logical().Write("pki/revoke", map[string]interface{}{
+		"serial_number": certutil.GetHexFormatted(newCert.SerialNumber.Bytes(), ":"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fetchCRL := func(path string, issuer *x509.Certificate) []string {
+		t.Helper()
+		r := client.NewRequest("GET", "/v1/pki/"+path)
+		r.ClientToken = ""
+		httpResp, err := client.RawRequest(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer httpResp.Body.Close()
+		body, err := ioutil.ReadAll(httpResp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		crl, err := x509.ParseRevocationList(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := crl.CheckSignatureFrom(issuer); err != nil {
+			t.Fatal(err)
+		}
+		var serials []string
+		for _, entry := range crl.RevokedCertificateEntries {
+			serials = append(serials, entry.SerialNumber.String())
+		}
+		return serials
+	}
+	if serials := fetchCRL("crl", oldCA); len(serials) != 0 {
+		t.Fatalf("expected an empty CRL, got %v", serials)
+	}
+	if serials := fetchCRL("issuer/next/crl", newCA); !reflect.DeepEqual(serials, []string{newCert.SerialNumber.String()}) {
+		t.Fatalf("bad issuer CRL: %v", serials)
+	}
+
+	// OCSP requests are answered for any issuer
+	der, err := ocsp.CreateRequest(newCert, newCA, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := client.NewRequest("POST", "/v1/pki/ocsp")
+	r.Headers = map[string][]string{"Content-Type": []string{"application/ocsp-request"}}
+	r.BodyBytes = der
+	r.ClientToken = ""
+	httpResp, err := client.RawRequest(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := ioutil.ReadAll(httpResp.Body)
+	httpResp.Body.Close()
+	ocspResp, err := ocsp.ParseResponseForCert(body, newCert, newCA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ocspResp.Status != ocsp.Revoked {
+		t.Fatalf("expected revoked status, got %d", ocspResp.Status)
+	}
+
+	// Issuers can be deleted and imported
+	_, err = client.Logical().Delete("pki/issuers/next")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err = client.Logical().Read("pki/issuers/next")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp != nil {
+		t.Fatalf("expected the issuer to be deleted, got %#v", resp.Data)
+	}
+	_, err = client.Logical().Write("pki/issuers/imported", map[string]interface{}{
+		"pem_bundle": newBundle,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Logical().Write("pki/issuers/imported", map[string]interface{}{
+		"pem_bundle": newBundle,
+	}); err == nil {
+		t.Fatal("expected an error importing over an existing issuer")
+	}
+	if _, err := client.Logical().Write("pki/issuers/default", map[string]interface{}{
+		"pem_bundle": newBundle,
+	}); err == nil {
+		t.Fatal("expected an error importing the default issuer")
+	}
+
+	r = client.NewRequest("GET", "/v1/pki/issuer/imported/pem")
+	r.ClientToken = ""
+	httpResp, err = client.RawRequest(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ = ioutil.ReadAll(httpResp.Body)
+	httpResp.Body.Close()
+	if imported := parsePEMCert(t, string(body)); !imported.Equal(newCA) {
+		t.Fatal("expected the imported issuer certificate")
+	}
+
+	// The CRL of the imported issuer lists the certificates it issued
+	if serials := fetchCRL("issuer/imported/crl", newCA); !reflect.DeepEqual(serials, []string{newCert.SerialNumber.String()}) {
+		t.Fatalf("bad issuer CRL: %v", serials)
+	}
+}
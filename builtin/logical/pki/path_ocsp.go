@@ -0,0 +1,415 @@
+package pki
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/helper/certutil"
+	"github.com/hashicorp/vault/helper/consts"
+	"github.com/hashicorp/vault/helper/errutil"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+	"golang.org/x/crypto/ocsp"
+)
+
+const (
+	ocspRequestContentType  = "application/ocsp-request"
+	ocspResponseContentType = "application/ocsp-response"
+
+	// ocspSignerPath holds the delegated OCSP signing certificate and key
+	ocspSignerPath = "ocsp/signer"
+)
+
+// oidOCSPNoCheck marks a delegated OCSP signing certificate as not needing
+// its own revocation to be checked, see RFC 6960 section 4.2.2.2.1
+var oidOCSPNoCheck = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 48, 1, 5}
+
+// Answers OCSP requests POSTed in DER form
+func pathOCSP(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: `ocsp`,
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathOCSPRequest,
+		},
+
+		HelpSynopsis:    pathOCSPHelpSyn,
+		HelpDescription: pathOCSPHelpDesc,
+	}
+}
+
+// Answers OCSP requests sent with GET, base64-encoded in the path
+func pathOCSPGet(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: `ocsp/` + framework.MatchAllRegex("req"),
+		Fields: map[string]*framework.FieldSchema{
+			"req": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: `The base64-encoded DER OCSP request`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation: b.pathOCSPRequest,
+		},
+
+		HelpSynopsis:    pathOCSPHelpSyn,
+		HelpDescription: pathOCSPHelpDesc,
+	}
+}
+
+func (b *backend) pathOCSPRequest(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	der, err := ocspRequestBytes(req, data)
+	if err != nil {
+		return ocspResponse(ocsp.MalformedRequestErrorResponse), nil
+	}
+	ocspReq, err := ocsp.ParseRequest(der)
+	if err != nil || !ocspReq.HashAlgorithm.Available() {
+		return ocspResponse(ocsp.MalformedRequestErrorResponse), nil
+	}
+
+	signed, err := b.answerOCSP(ctx, req, ocspReq)
+	switch err.(type) {
+	case nil:
+		return ocspResponse(signed), nil
+	case errutil.UserError:
+		// Not configured with a CA, or asked about another one
+		return ocspResponse(ocsp.UnauthorizedErrorResponse), nil
+	default:
+		b.Logger().Error("error answering OCSP request", "error", err)
+		return ocspResponse(ocsp.InternalErrorErrorResponse), nil
+	}
+}
+
+// ocspRequestBytes returns the DER OCSP request, either POSTed as the raw
+// body or given base64-encoded in the path
+func ocspRequestBytes(req *logical.Request, data *framework.FieldData) ([]byte, error) {
+	if req.Operation == logical.ReadOperation {
+		return base64.StdEncoding.DecodeString(strings.TrimSpace(data.Get("req").(string)))
+	}
+
+	switch body := data.Raw[logical.HTTPRawBody].(type) {
+	case []byte:
+		return body, nil
+	case string:
+		// The body was JSON-encoded on its way, e.g. to a plugin
+		return base64.StdEncoding.DecodeString(body)
+	default:
+		return nil, fmt.Errorf("no OCSP request given; it must be sent with a Content-Type of %s", ocspRequestContentType)
+	}
+}
+
+func ocspResponse(body []byte) *logical.Response {
+	// OCSP errors are reported in the response body, always with a 200
+	return &logical.Response{
+		Data: map[string]interface{}{
+			logical.HTTPContentType: ocspResponseContentType,
+			logical.HTTPRawBody:     body,
+			logical.HTTPStatusCode:  http.StatusOK,
+		},
+	}
+}
+
+// answerOCSP returns the signed OCSP response giving the revocation status
+// of the requested certificate
+func (b *backend) answerOCSP(ctx context.Context, req *logical.Request, ocspReq *ocsp.Request) ([]byte, error) {
+	issuers, err := fetchIssuers(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if len(issuers) == 0 {
+		return nil, errutil.UserError{Err: "backend must be configured with a CA certificate/key"}
+	}
+
+	var caBundle *caInfoBundle
+	var issuerName string
+	for _, issuer := range issuers {
+		matches, err := ocspIssuerMatches(issuer.bundle.Certificate, ocspReq)
+		if err != nil {
+			return nil, errutil.InternalError{Err: err.Error()}
+		}
+		if matches {
+			caBundle = issuer.bundle
+			issuerName = issuer.name
+			break
+		}
+	}
+	if caBundle == nil {
+		return nil, errutil.UserError{Err: "the OCSP request is for a certificate of another CA"}
+	}
+
+	config, err := b.OCSP(ctx, req.Storage)
+	if err != nil {
+		return nil, errutil.InternalError{Err: fmt.Sprintf("error fetching the OCSP configuration: %v", err)}
+	}
+	if config == nil {
+		config = defaultOCSPConfig()
+	}
+	nextUpdate, err := time.ParseDuration(config.NextUpdate)
+	if err != nil {
+		return nil, errutil.InternalError{Err: fmt.Sprintf("error parsing next_update: %v", err)}
+	}
+
+	now := time.Now().UTC().Truncate(time.Minute)
+	template := ocsp.Response{
+		Status:       ocsp.Unknown,
+		SerialNumber: ocspReq.SerialNumber,
+		ThisUpdate:   now,
+		NextUpdate:   now.Add(nextUpdate),
+		IssuerHash:   ocspReq.HashAlgorithm,
+	}
+
+	serial := certutil.GetHexFormatted(ocspReq.SerialNumber.Bytes(), ":")
+	b.revokeStorageLock.RLock()
+	revokedEntry, err := fetchCertBySerial(ctx, req, "revoked/", serial)
+	if err == nil && revokedEntry == nil {
+		var certEntry *logical.StorageEntry
+		certEntry, err = fetchCertBySerial(ctx, req, "certs/", serial)
+		if certEntry != nil {
+			template.Status = ocsp.Good
+		}
+	}
+	b.revokeStorageLock.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+
+	if revokedEntry != nil {
+		var revInfo revocationInfo
+		if err := revokedEntry.DecodeJSON(&revInfo); err != nil {
+			return nil, errutil.InternalError{Err: fmt.Sprintf("error decoding revocation entry for serial %s: %s", serial, err)}
+		}
+		template.Status = ocsp.Revoked
+		template.RevocationReason = ocsp.Unspecified
+		template.RevokedAt = revInfo.RevocationTimeUTC
+		if template.RevokedAt.IsZero() {
+			template.RevokedAt = time.Unix(revInfo.RevocationTime, 0).UTC()
+		}
+	}
+
+	responder := caBundle.Certificate
+	signer := caBundle.PrivateKey
+	// Only the default issuer has a delegated signer; the others sign with
+	// their own key
+	if config.DelegatedSigner && issuerName == defaultIssuerName {
+		signerBundle, err := b.ocspSigner(ctx, req, config, caBundle)
+		if err != nil {
+			return nil, err
+		}
+		if signerBundle != nil {
+			responder = signerBundle.Certificate
+			signer = signerBundle.PrivateKey
+			template.Certificate = signerBundle.Certificate
+		}
+	}
+
+	signed, err := ocsp.CreateResponse(caBundle.Certificate, responder, template, signer)
+	if err != nil {
+		return nil, errutil.InternalError{Err: fmt.Sprintf("error signing OCSP response: %v", err)}
+	}
+
+	return signed, nil
+}
+
+// ocspIssuerMatches reports whether the OCSP request is about a certificate
+// issued by the given CA
+func ocspIssuerMatches(caCert *x509.Certificate, ocspReq *ocsp.Request) (bool, error) {
+	var spki struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}
+	if _, err := asn1.Unmarshal(caCert.RawSubjectPublicKeyInfo, &spki); err != nil {
+		return false, errwrap.Wrapf("error parsing the CA public key: {{err}}", err)
+	}
+
+	h := ocspReq.HashAlgorithm.New()
+	h.Write(caCert.RawSubject)
+	nameHash := h.Sum(nil)
+
+	h.Reset()
+	h.Write(spki.PublicKey.RightAlign())
+	keyHash := h.Sum(nil)
+
+	return bytes.Equal(nameHash, ocspReq.IssuerNameHash) && bytes.Equal(keyHash, ocspReq.IssuerKeyHash), nil
+}
+
+// ocspSigner returns the delegated OCSP signing certificate and key, issuing
+// a new one if there is none yet, it was issued by a previous CA or half of
+// its validity has elapsed. Nodes which can't write to storage keep using the
+// stored one while it is valid, and return nil otherwise so the CA key is
+// used instead.
+func (b *backend) ocspSigner(ctx context.Context, req *logical.Request, config *ocspConfig, caBundle *caInfoBundle) (*certutil.ParsedCertBundle, error) {
+	current, err := b.storedOCSPSigner(ctx, req.Storage, caBundle)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if current != nil && now.Before(renewOCSPSignerAfter(current.Certificate)) {
+		return current, nil
+	}
+
+	if !b.System().LocalMount() && b.System().ReplicationState().HasState(consts.ReplicationPerformanceSecondary|consts.ReplicationPerformanceStandby) {
+		if current != nil && now.Before(current.Certificate.NotAfter) {
+			return current, nil
+		}
+		b.Logger().Warn("delegated OCSP signer needs to be issued by the active node of the primary cluster, signing with the CA key meanwhile")
+		return nil, nil
+	}
+
+	b.ocspSignerLock.Lock()
+	defer b.ocspSignerLock.Unlock()
+	return b.rotateOCSPSigner(ctx, req, config, false)
+}
+
+// rotateOCSPSigner issues and stores a new delegated OCSP signer. Unless
+// forced, a valid one stored concurrently is returned instead. The caller
+// must hold ocspSignerLock.
+func (b *backend) rotateOCSPSigner(ctx context.Context, req *logical.Request, config *ocspConfig, force bool) (*certutil.ParsedCertBundle, error) {
+	caBundle, err := fetchCAInfo(ctx, req)
+	if err != nil {
+		if _, ok := err.(errutil.UserError); ok && force {
+			// No CA yet; the signer will be issued on first use
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if !force {
+		current, err := b.storedOCSPSigner(ctx, req.Storage, caBundle)
+		if err != nil {
+			return nil, err
+		}
+		if current != nil && time.Now().Before(renewOCSPSignerAfter(current.Certificate)) {
+			return current, nil
+		}
+	}
+
+	ttl, err := time.ParseDuration(config.DelegatedSignerTTL)
+	if err != nil {
+		return nil, errutil.InternalError{Err: fmt.Sprintf("error parsing delegated_signer_ttl: %v", err)}
+	}
+
+	signer := &certutil.ParsedCertBundle{}
+	switch caBundle.PrivateKeyType {
+	case certutil.ECPrivateKey:
+		err = certutil.GeneratePrivateKey("ec", 256, signer)
+	default:
+		err = certutil.GeneratePrivateKey("rsa", 2048, signer)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	serial, err := certutil.GenerateSerialNumber()
+	if err != nil {
+		return nil, err
+	}
+	subjKeyID, err := certutil.GetSubjKeyID(signer.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	notAfter := now.Add(ttl)
+	if notAfter.After(caBundle.Certificate.NotAfter) {
+		notAfter = caBundle.Certificate.NotAfter
+	}
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject: pkix.Name{
+			CommonName: strings.TrimSpace(caBundle.Certificate.Subject.CommonName + " OCSP Responder"),
+		},
+		SubjectKeyId: subjKeyID,
+		NotBefore:    now.Add(-30 * time.Second),
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageOCSPSigning},
+		ExtraExtensions: []pkix.Extension{
+			{
+				Id:    oidOCSPNoCheck,
+				Value: asn1.NullBytes,
+			},
+		},
+	}
+
+	certBytes, err := x509.CreateCertificate(rand.Reader, template, caBundle.Certificate, signer.PrivateKey.Public(), caBundle.PrivateKey)
+	if err != nil {
+		return nil, errutil.InternalError{Err: fmt.Sprintf("unable to create delegated OCSP signer: %v", err)}
+	}
+	signer.CertificateBytes = certBytes
+	signer.Certificate, err = x509.ParseCertificate(certBytes)
+	if err != nil {
+		return nil, errutil.InternalError{Err: fmt.Sprintf("unable to parse created delegated OCSP signer: %v", err)}
+	}
+
+	bundle, err := signer.ToCertBundle()
+	if err != nil {
+		return nil, errutil.InternalError{Err: fmt.Sprintf("error converting delegated OCSP signer: %v", err)}
+	}
+	entry, err := logical.StorageEntryJSON(ocspSignerPath, bundle)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, errutil.InternalError{Err: fmt.Sprintf("unable to store delegated OCSP signer: %v", err)}
+	}
+
+	return signer, nil
+}
+
+// storedOCSPSigner returns the stored delegated OCSP signer, or nil if there
+// is none or it was not issued by the given CA
+func (b *backend) storedOCSPSigner(ctx context.Context, s logical.Storage, caBundle *caInfoBundle) (*certutil.ParsedCertBundle, error) {
+	entry, err := s.Get(ctx, ocspSignerPath)
+	if err != nil {
+		return nil, errutil.InternalError{Err: fmt.Sprintf("unable to fetch delegated OCSP signer: %v", err)}
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var bundle certutil.CertBundle
+	if err := entry.DecodeJSON(&bundle); err != nil {
+		return nil, errutil.InternalError{Err: fmt.Sprintf("unable to decode delegated OCSP signer: %v", err)}
+	}
+	parsed, err := bundle.ToParsedCertBundle()
+	if err != nil {
+		return nil, errutil.InternalError{Err: fmt.Sprintf("unable to parse delegated OCSP signer: %v", err)}
+	}
+
+	if parsed.Certificate.CheckSignatureFrom(caBundle.Certificate) != nil {
+		return nil, nil
+	}
+
+	return parsed, nil
+}
+
+// renewOCSPSignerAfter returns when half of the signer's validity elapses
+func renewOCSPSignerAfter(cert *x509.Certificate) time.Time {
+	return cert.NotBefore.Add(cert.NotAfter.Sub(cert.NotBefore) / 2)
+}
+
+const pathOCSPHelpSyn = `
+Query the revocation status of a certificate using OCSP.
+`
+
+const pathOCSPHelpDesc = `
+This endpoint is an OCSP responder as described in RFC 6960. OCSP requests
+can be POSTed in DER form with a Content-Type of "application/ocsp-request",
+or sent with GET, base64-encoded in the path after "ocsp/".
+
+The response is signed by the CA key, or by a delegated OCSP signing
+certificate when enabled through "config/ocsp". Certificates which are
+unknown to this backend, e.g. because they were tidied, are reported as
+unknown.
+`
@@ -0,0 +1,223 @@
+package pki
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"testing"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/helper/certutil"
+	vaulthttp "github.com/hashicorp/vault/http"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/vault"
+	"golang.org/x/crypto/ocsp"
+)
+
+func TestBackend_OCSP(t *testing.T) {
+	coreConfig := &vault.CoreConfig{
+		LogicalBackends: map[string]logical.Factory{
+			"pki": Factory,
+		},
+	}
+	cluster := vault.NewTestCluster(t, coreConfig, &vault.TestClusterOptions{
+		HandlerFunc: vaulthttp.Handler,
+	})
+	cluster.Start()
+	defer cluster.Cleanup()
+
+	client := cluster.Cores[0].Client
+	err := client.Sys().Mount("pki", &api.MountInput{
+		Type: "pki",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := client.Logical().Write("pki/root/generate/internal", map[string]interface{}{
+		"ttl":         "40h",
+		"common_name": "myvault.com",
+		"key_type":    "ec",
+		"key_bits":    256,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	caCert := parsePEMCert(t, resp.Data["certificate"].(string))
+
+	_, err = client.Logical().Write("pki/roles/test", map[string]interface{}{
+		"allowed_domains":  "foobar.com",
+		"allow_subdomains": true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	issue := func() *x509.Certificate {
+		resp, err := client.Logical().Write("pki/issue/test", map[string]interface{}{
+			"common_name": "test.foobar.com",
+			"ttl":         "1h",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return parsePEMCert(t, resp.Data["certificate"].(string))
+	}
+
+	// Sends the OCSP request with POST unless get is set, and returns the
+	// parsed response, checking its signature against the CA
+	query := func(cert *x509.Certificate, get bool) *ocsp.Response {
+		t.Helper()
+		der, err := ocsp.CreateRequest(cert, caCert, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var r *api.Request
+		if get {
+			r = client.NewRequest("GET", "/v1/pki/ocsp/"+base64.StdEncoding.EncodeToString(der))
+		} else {
+			r = client.NewRequest("POST", "/v1/pki/ocsp")
+			r.Headers = http.Header{"Content-Type": []string{"application/ocsp-request"}}
+			r.BodyBytes = der
+		}
+		r.ClientToken = ""
+		httpResp, err := client.RawRequest(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer httpResp.Body.Close()
+		if ct := httpResp.Header.Get("Content-Type"); ct != "application/ocsp-response" {
+			t.Fatalf("bad content type %q", ct)
+		}
+		body, err := ioutil.ReadAll(httpResp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ocspResp, err := ocsp.ParseResponseForCert(body, cert, caCert)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ocspResp
+	}
+
+	cert := issue()
+	for _, get := range []bool{false, true} {
+		if status := query(cert, get).Status; status != ocsp.Good {
+			t.Fatalf("expected good status with get=%t, got %d", get, status)
+		}
+	}
+
+	// An unknown serial from the same CA
+	unknown := *cert
+	unknown.SerialNumber = big.NewInt(42)
+	if status := query(&unknown, false).Status; status != ocsp.Unknown {
+		t.Fatalf("expected unknown status, got %d", status)
+	}
+
+	_, err = client.Logical().Write("pki/revoke", map[string]interface{}{
+		"serial_number": certutil.GetHexFormatted(cert.SerialNumber.Bytes(), ":"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ocspResp := query(cert, false)
+	if ocspResp.Status != ocsp.Revoked {
+		t.Fatalf("expected revoked status, got %d", ocspResp.Status)
+	}
+	if ocspResp.RevokedAt.IsZero() {
+		t.Fatal("expected a revocation time")
+	}
+	if ocspResp.Certificate != nil {
+		t.Fatal("expected the response to be signed by the CA")
+	}
+
+	// A malformed request is answered with an OCSP error
+	r := client.NewRequest("POST", "/v1/pki/ocsp")
+	r.Headers = http.Header{"Content-Type": []string{"application/ocsp-request"}}
+	r.BodyBytes = []byte("not an OCSP request")
+	httpResp, err := client.RawRequest(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := ioutil.ReadAll(httpResp.Body)
+	httpResp.Body.Close()
+	if _, err := ocsp.ParseResponse(body, nil); err != (ocsp.ResponseError{Status: ocsp.Malformed}) {
+		t.Fatalf("expected a malformed request error, got %v", err)
+	}
+
+	// With a delegated signer
+	_, err = client.Logical().Write("pki/config/ocsp", map[string]interface{}{
+		"delegated_signer": true,
+		"next_update":      "1h",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err = client.Logical().Read("pki/config/ocsp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Data["delegated_signer"] != true || resp.Data["next_update"] != "1h" || resp.Data["delegated_signer_ttl"] != "720h" {
+		t.Fatalf("bad config: %#v", resp.Data)
+	}
+
+	cert = issue()
+	ocspResp = query(cert, false)
+	if ocspResp.Status != ocsp.Good {
+		t.Fatalf("expected good status, got %d", ocspResp.Status)
+	}
+	signer := ocspResp.Certificate
+	if signer == nil {
+		t.Fatal("expected the response to be signed by a delegated signer")
+	}
+	if len(signer.ExtKeyUsage) != 1 || signer.ExtKeyUsage[0] != x509.ExtKeyUsageOCSPSigning {
+		t.Fatalf("bad delegated signer key usage: %v", signer.ExtKeyUsage)
+	}
+	if err := signer.CheckSignatureFrom(caCert); err != nil {
+		t.Fatal(err)
+	}
+	if d := ocspResp.NextUpdate.Sub(ocspResp.ThisUpdate); d.Hours() != 1 {
+		t.Fatalf("bad next update interval %s", d)
+	}
+
+	// The signer is kept until it needs renewal
+	if next := query(cert, true).Certificate; next == nil || !next.Equal(signer) {
+		t.Fatal("expected the delegated signer to be reused")
+	}
+
+	// A new CA gets a new signer
+	_, err = client.Logical().Delete("pki/root")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err = client.Logical().Write("pki/root/generate/internal", map[string]interface{}{
+		"ttl":         "40h",
+		"common_name": "myvault.com",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	caCert = parsePEMCert(t, resp.Data["certificate"].(string))
+	cert = issue()
+	if next := query(cert, false).Certificate; next == nil || next.Equal(signer) {
+		t.Fatal("expected a new delegated signer for the new CA")
+	}
+}
+
+func parsePEMCert(t *testing.T, certPEM string) *x509.Certificate {
+	t.Helper()
+	block, _ := pem.Decode([]byte(certPEM))
+	if block == nil {
+		t.Fatal("no certificate found in PEM")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
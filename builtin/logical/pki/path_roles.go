@@ -65,6 +65,14 @@ information. This parameter accepts a comma-separated
 string or list of domains.`,
 			},
 
+			"allowed_domains_template": &framework.FieldSchema{
+				Type: framework.TypeBool,
+				Description: `If set, domains specified in "allowed_domains"
+can include identity templates, e.g.
+"{{identity.entity.metadata.service}}.svc.example.com",
+which are populated from the entity of the requesting token.`,
+			},
+
 			"allow_bare_domains": &framework.FieldSchema{
 				Type: framework.TypeBool,
 				Description: `If set, clients can request certificates
@@ -115,6 +123,14 @@ Any valid IP is accepted.`,
 Any valid URI is accepted, these values support globbing.`,
 			},
 
+			"allowed_uri_sans_template": &framework.FieldSchema{
+				Type: framework.TypeBool,
+				Description: `If set, URIs specified in "allowed_uri_sans"
+can include identity templates, e.g.
+"spiffe://example.com/{{identity.entity.name}}", which are
+populated from the entity of the requesting token.`,
+			},
+
 			"allowed_other_sans": &framework.FieldSchema{
 				Type:        framework.TypeCommaStringSlice,
 				Description: `If set, an array of allowed other names to put in SANs. These values support globbing and must be in the format <oid>;<type>:<value>. Currently only "utf8" is a valid type. All values, including globbing values, must use this syntax, with the exception being a single "*" which allows any OID and any value (but type must still be utf8).`,
@@ -291,6 +307,12 @@ for "generate_lease".`,
 				Default:     30,
 				Description: `The duration before now the cert needs to be created / signed.`,
 			},
+			"issuer": &framework.FieldSchema{
+				Type:    framework.TypeString,
+				Default: defaultIssuerName,
+				Description: `The name of the issuer certificates are issued from;
+defaults to the CA of the mount.`,
+			},
 		},
 
 		Callbacks: map[logical.Operation]framework.OperationFunc{
@@ -467,6 +489,7 @@ func (b *backend) pathRoleCreate(ctx context.Context, req *logical.Request, data
 		TTL:                           time.Duration(data.Get("ttl").(int)) * time.Second,
 		AllowLocalhost:                data.Get("allow_localhost").(bool),
 		AllowedDomains:                data.Get("allowed_domains").([]string),
+		AllowedDomainsTemplate:        data.Get("allowed_domains_template").(bool),
 		AllowBareDomains:              data.Get("allow_bare_domains").(bool),
 		AllowSubdomains:               data.Get("allow_subdomains").(bool),
 		AllowGlobDomains:              data.Get("allow_glob_domains").(bool),
@@ -474,6 +497,7 @@ func (b *backend) pathRoleCreate(ctx context.Context, req *logical.Request, data
 		EnforceHostnames:              data.Get("enforce_hostnames").(bool),
 		AllowIPSANs:                   data.Get("allow_ip_sans").(bool),
 		AllowedURISANs:                data.Get("allowed_uri_sans").([]string),
+		AllowedURISANsTemplate:        data.Get("allowed_uri_sans_template").(bool),
 		ServerFlag:                    data.Get("server_flag").(bool),
 		ClientFlag:                    data.Get("client_flag").(bool),
 		CodeSigningFlag:               data.Get("code_signing_flag").(bool),
@@ -499,6 +523,7 @@ func (b *backend) pathRoleCreate(ctx context.Context, req *logical.Request, data
 		PolicyIdentifiers:             data.Get("policy_identifiers").([]string),
 		BasicConstraintsValidForNonCA: data.Get("basic_constraints_valid_for_non_ca").(bool),
 		NotBeforeDuration:             time.Duration(data.Get("not_before_duration").(int)) * time.Second,
+		Issuer:                        data.Get("issuer").(string),
 	}
 
 	otherSANs := data.Get("allowed_other_sans").([]string)
@@ -540,6 +565,22 @@ func (b *backend) pathRoleCreate(ctx context.Context, req *logical.Request, data
 		}
 	}
 
+	if entry.AllowedDomainsTemplate {
+		for _, domain := range entry.AllowedDomains {
+			if _, err := framework.ValidateIdentityTemplate(domain); err != nil {
+				return logical.ErrorResponse(fmt.Sprintf("invalid template in allowed_domains %q", domain)), nil
+			}
+		}
+	}
+
+	if entry.AllowedURISANsTemplate {
+		for _, uri := range entry.AllowedURISANs {
+			if _, err := framework.ValidateIdentityTemplate(uri); err != nil {
+				return logical.ErrorResponse(fmt.Sprintf("invalid template in allowed_uri_sans %q", uri)), nil
+			}
+		}
+	}
+
 	if len(entry.PolicyIdentifiers) > 0 {
 		for _, oidstr := range entry.PolicyIdentifiers {
 			_, err := stringToOid(oidstr)
@@ -558,6 +599,19 @@ func (b *backend) pathRoleCreate(ctx context.Context, req *logical.Request, data
 		return nil, err
 	}
 
+	// Named issuers may be imported later, so only warn about them
+	if entry.Issuer != "" && entry.Issuer != defaultIssuerName {
+		issuerEntry, err := req.Storage.Get(ctx, "issuers/"+entry.Issuer)
+		if err != nil {
+			return nil, err
+		}
+		if issuerEntry == nil {
+			resp := &logical.Response{}
+			resp.AddWarning(fmt.Sprintf("issuer %q does not exist; certificates cannot be issued from this role until it is created", entry.Issuer))
+			return resp, nil
+		}
+	}
+
 	return nil, nil
 }
 
@@ -651,6 +705,7 @@ type roleEntry struct {
 	AllowedBaseDomain             string        `json:"allowed_base_domain" mapstructure:"allowed_base_domain"`
 	AllowedDomainsOld             string        `json:"allowed_domains,omit_empty"`
 	AllowedDomains                []string      `json:"allowed_domains_list" mapstructure:"allowed_domains"`
+	AllowedDomainsTemplate        bool          `json:"allowed_domains_template" mapstructure:"allowed_domains_template"`
 	AllowBaseDomain               bool          `json:"allow_base_domain"`
 	AllowBareDomains              bool          `json:"allow_bare_domains" mapstructure:"allow_bare_domains"`
 	AllowTokenDisplayName         bool          `json:"allow_token_displayname" mapstructure:"allow_token_displayname"`
@@ -686,10 +741,12 @@ type roleEntry struct {
 	AllowedOtherSANs              []string      `json:"allowed_other_sans" mapstructure:"allowed_other_sans"`
 	AllowedSerialNumbers          []string      `json:"allowed_serial_numbers" mapstructure:"allowed_serial_numbers"`
 	AllowedURISANs                []string      `json:"allowed_uri_sans" mapstructure:"allowed_uri_sans"`
+	AllowedURISANsTemplate        bool          `json:"allowed_uri_sans_template" mapstructure:"allowed_uri_sans_template"`
 	PolicyIdentifiers             []string      `json:"policy_identifiers" mapstructure:"policy_identifiers"`
 	ExtKeyUsageOIDs               []string      `json:"ext_key_usage_oids" mapstructure:"ext_key_usage_oids"`
 	BasicConstraintsValidForNonCA bool          `json:"basic_constraints_valid_for_non_ca" mapstructure:"basic_constraints_valid_for_non_ca"`
 	NotBeforeDuration             time.Duration `json:"not_before_duration" mapstructure:"not_before_duration"`
+	Issuer                        string        `json:"issuer" mapstructure:"issuer"`
 
 	// Used internally for signing intermediates
 	AllowExpirationPastCA bool
@@ -701,6 +758,7 @@ func (r *roleEntry) ToResponseData() map[string]interface{} {
 		"max_ttl":                            int64(r.MaxTTL.Seconds()),
 		"allow_localhost":                    r.AllowLocalhost,
 		"allowed_domains":                    r.AllowedDomains,
+		"allowed_domains_template":           r.AllowedDomainsTemplate,
 		"allow_bare_domains":                 r.AllowBareDomains,
 		"allow_token_displayname":            r.AllowTokenDisplayName,
 		"allow_subdomains":                   r.AllowSubdomains,
@@ -730,10 +788,15 @@ func (r *roleEntry) ToResponseData() map[string]interface{} {
 		"allowed_other_sans":                 r.AllowedOtherSANs,
 		"allowed_serial_numbers":             r.AllowedSerialNumbers,
 		"allowed_uri_sans":                   r.AllowedURISANs,
+		"allowed_uri_sans_template":          r.AllowedURISANsTemplate,
 		"require_cn":                         r.RequireCN,
 		"policy_identifiers":                 r.PolicyIdentifiers,
 		"basic_constraints_valid_for_non_ca": r.BasicConstraintsValidForNonCA,
 		"not_before_duration":                int64(r.NotBeforeDuration.Seconds()),
+		"issuer":                             r.Issuer,
+	}
+	if r.Issuer == "" {
+		responseData["issuer"] = defaultIssuerName
 	}
 	if r.MaxPathLength != nil {
 		responseData["max_path_length"] = r.MaxPathLength
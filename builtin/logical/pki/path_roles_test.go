@@ -739,3 +739,95 @@ func TestPki_CertsLease(t *testing.T) {
 		t.Fatalf("expected a response that contains a secret")
 	}
 }
+
+func TestPki_RoleTemplates(t *testing.T) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+	sysView := logical.TestSystemView()
+	sysView.EntityVal = &logical.Entity{
+		ID:   "entity-id",
+		Name: "web",
+		Metadata: map[string]string{
+			"service": "web",
+		},
+	}
+	config.System = sysView
+
+	b := Backend(config)
+	if err := b.Setup(context.Background(), config); err != nil {
+		t.Fatal(err)
+	}
+
+	request := func(path, entityID string, data map[string]interface{}) (*logical.Response, error) {
+		return b.HandleRequest(context.Background(), &logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      path,
+			Storage:   config.StorageView,
+			EntityID:  entityID,
+			Data:      data,
+		})
+	}
+
+	resp, err := request("root/generate/internal", "", map[string]interface{}{
+		"common_name": "myvault.com",
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: err: %v resp: %#v", err, resp)
+	}
+
+	resp, err = request("roles/testrole", "", map[string]interface{}{
+		"allowed_domains":           "{{identity.entity.metadata.service}",
+		"allowed_domains_template":  true,
+		"allow_subdomains":          true,
+		"allowed_uri_sans":          "spiffe://example.com/{{identity.entity.name}}",
+		"allowed_uri_sans_template": true,
+	})
+	if err != nil || resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error for a malformed template: err: %v resp: %#v", err, resp)
+	}
+
+	resp, err = request("roles/testrole", "", map[string]interface{}{
+		"allowed_domains":           "{{identity.entity.metadata.service}}.svc.example.com,{{identity.entity.metadata.missing}}.example.com",
+		"allowed_domains_template":  true,
+		"allow_subdomains":          true,
+		"allowed_uri_sans":          "spiffe://example.com/{{identity.entity.name}}",
+		"allowed_uri_sans_template": true,
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: err: %v resp: %#v", err, resp)
+	}
+
+	issue := func(entityID, commonName, uri string) *logical.Response {
+		t.Helper()
+		data := map[string]interface{}{
+			"common_name": commonName,
+			"ttl":         "1h",
+		}
+		if uri != "" {
+			data["uri_sans"] = uri
+		}
+		resp, err := request("issue/testrole", entityID, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	if resp := issue("entity-id", "host.web.svc.example.com", "spiffe://example.com/web"); resp == nil || resp.IsError() {
+		t.Fatalf("expected the templated names to be allowed: %#v", resp)
+	}
+	if resp := issue("entity-id", "host.db.svc.example.com", ""); resp == nil || !resp.IsError() {
+		t.Fatalf("expected another service's name to be rejected: %#v", resp)
+	}
+	if resp := issue("entity-id", "host.web.svc.example.com", "spiffe://example.com/db"); resp == nil || !resp.IsError() {
+		t.Fatalf("expected another entity's URI to be rejected: %#v", resp)
+	}
+	// Templates populated from missing metadata allow nothing
+	if resp := issue("entity-id", "host..example.com", ""); resp == nil || !resp.IsError() {
+		t.Fatalf("expected a name from missing metadata to be rejected: %#v", resp)
+	}
+	// Tokens without an entity can't use templated entries
+	if resp := issue("", "host.web.svc.example.com", ""); resp == nil || !resp.IsError() {
+		t.Fatalf("expected a token without entity to be rejected: %#v", resp)
+	}
+}
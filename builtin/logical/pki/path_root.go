@@ -33,6 +33,12 @@ func pathGenerateRoot(b *backend) *framework.Path {
 	ret.Fields = addCAKeyGenerationFields(ret.Fields)
 	ret.Fields = addCAIssueFields(ret.Fields)
 
+	ret.Fields["issuer_name"] = &framework.FieldSchema{
+		Type: framework.TypeString,
+		Description: `If set, the root is stored as an additional issuer
+with this name instead of as the CA of the mount.`,
+	}
+
 	return ret
 }
 
@@ -86,6 +92,8 @@ certs signed by this path; for instance,
 the non-repudiation flag.`,
 	}
 
+	ret.Fields["issuer"] = issuerField()
+
 	return ret
 }
 
@@ -102,6 +110,7 @@ func pathSignSelfIssued(b *backend) *framework.Path {
 				Type:        framework.TypeString,
 				Description: `PEM-format self-issued certificate to be signed.`,
 			},
+			"issuer": issuerField(),
 		},
 
 		HelpSynopsis:    pathSignSelfIssuedHelpSyn,
@@ -118,11 +127,27 @@ func (b *backend) pathCADeleteRoot(ctx context.Context, req *logical.Request, da
 func (b *backend) pathCAGenerateRoot(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
 	var err error
 
-	entry, err := req.Storage.Get(ctx, "config/ca_bundle")
+	issuerName := data.Get("issuer_name").(string)
+	if issuerName == defaultIssuerName {
+		issuerName = ""
+	}
+	if issuerName != "" && !issuerNameRegex.MatchString(issuerName) {
+		return logical.ErrorResponse(fmt.Sprintf("invalid issuer name %q", issuerName)), nil
+	}
+
+	bundlePath := "config/ca_bundle"
+	if issuerName != "" {
+		bundlePath = "issuers/" + issuerName
+	}
+
+	entry, err := req.Storage.Get(ctx, bundlePath)
 	if err != nil {
 		return nil, err
 	}
 	if entry != nil {
+		if issuerName != "" {
+			return logical.ErrorResponse(fmt.Sprintf("issuer %q already exists", issuerName)), nil
+		}
 		resp := &logical.Response{}
 		resp.AddWarning(fmt.Sprintf("Refusing to generate a root certificate over an existing root certificate. If you really want to destroy the original root certificate, please issue a delete against %sroot.", req.MountPoint))
 		return resp, nil
@@ -203,7 +228,7 @@ func (b *backend) pathCAGenerateRoot(ctx context.Context, req *logical.Request,
 	}
 
 	// Store it as the CA bundle
-	entry, err = logical.StorageEntryJSON("config/ca_bundle", cb)
+	entry, err = logical.StorageEntryJSON(bundlePath, cb)
 	if err != nil {
 		return nil, err
 	}
@@ -224,11 +249,13 @@ func (b *backend) pathCAGenerateRoot(ctx context.Context, req *logical.Request,
 
 	// For ease of later use, also store just the certificate at a known
 	// location
-	entry.Key = "ca"
-	entry.Value = parsedBundle.CertificateBytes
-	err = req.Storage.Put(ctx, entry)
-	if err != nil {
-		return nil, err
+	if issuerName == "" {
+		entry.Key = "ca"
+		entry.Value = parsedBundle.CertificateBytes
+		err = req.Storage.Put(ctx, entry)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	// Build a fresh CRL
@@ -278,7 +305,7 @@ func (b *backend) pathCASignIntermediate(ctx context.Context, req *logical.Reque
 	}
 
 	var caErr error
-	signingBundle, caErr := fetchCAInfo(ctx, req)
+	signingBundle, caErr := fetchIssuerInfo(ctx, req, data.Get("issuer").(string))
 	switch caErr.(type) {
 	case errutil.UserError:
 		return nil, errutil.UserError{Err: fmt.Sprintf(
@@ -405,7 +432,7 @@ func (b *backend) pathCASignSelfIssued(ctx context.Context, req *logical.Request
 	}
 
 	var caErr error
-	signingBundle, caErr := fetchCAInfo(ctx, req)
+	signingBundle, caErr := fetchIssuerInfo(ctx, req, data.Get("issuer").(string))
 	switch caErr.(type) {
 	case errutil.UserError:
 		return nil, errutil.UserError{Err: fmt.Sprintf(
@@ -428,6 +455,10 @@ func (b *backend) pathCASignSelfIssued(ctx context.Context, req *logical.Request
 	cert.CRLDistributionPoints = urls.CRLDistributionPoints
 	cert.OCSPServer = urls.OCSPServers
 
+	// Let the signing key determine the signature algorithm, as the issuer
+	// may use a different key type than the certificate
+	cert.SignatureAlgorithm = x509.UnknownSignatureAlgorithm
+
 	newCert, err := x509.CreateCertificate(rand.Reader, cert, signingBundle.Certificate, cert.PublicKey, signingBundle.PrivateKey)
 	if err != nil {
 		return nil, errwrap.Wrapf("error signing self-issued certificate: {{err}}", err)
@@ -3,6 +3,7 @@ package pki
 import (
 	"context"
 	"crypto/x509"
+	"errors"
 	"fmt"
 	"net/http"
 	"sync/atomic"
@@ -14,6 +15,93 @@ import (
 	"github.com/hashicorp/vault/logical/framework"
 )
 
+var errTidyCancelled = errors.New("tidy operation cancelled")
+
+type tidyStatusState int
+
+const (
+	tidyStatusInactive tidyStatusState = iota
+	tidyStatusStarted
+	tidyStatusFinished
+	tidyStatusError
+	tidyStatusCancelling
+	tidyStatusCancelled
+)
+
+func (s tidyStatusState) String() string {
+	switch s {
+	case tidyStatusStarted:
+		return "Running"
+	case tidyStatusFinished:
+		return "Finished"
+	case tidyStatusError:
+		return "Error"
+	case tidyStatusCancelling:
+		return "Cancelling"
+	case tidyStatusCancelled:
+		return "Cancelled"
+	default:
+		return "Inactive"
+	}
+}
+
+// tidyStatus tracks the progress of the last tidy operation run on this node
+type tidyStatus struct {
+	// Parameters the operation was started with
+	safetyBuffer     int
+	tidyCertStore    bool
+	tidyRevokedCerts bool
+
+	state                   tidyStatusState
+	err                     error
+	timeStarted             time.Time
+	timeFinished            time.Time
+	message                 string
+	certStoreDeletedCount   uint
+	revokedCertDeletedCount uint
+	bytesReclaimed          int
+}
+
+func (s *tidyStatus) responseData() map[string]interface{} {
+	data := map[string]interface{}{
+		"state":                      s.state.String(),
+		"safety_buffer":              nil,
+		"tidy_cert_store":            nil,
+		"tidy_revoked_certs":         nil,
+		"error":                      nil,
+		"time_started":               nil,
+		"time_finished":              nil,
+		"duration":                   nil,
+		"message":                    nil,
+		"cert_store_deleted_count":   s.certStoreDeletedCount,
+		"revoked_cert_deleted_count": s.revokedCertDeletedCount,
+		"bytes_reclaimed":            s.bytesReclaimed,
+	}
+	if s.state == tidyStatusInactive {
+		return data
+	}
+
+	data["safety_buffer"] = s.safetyBuffer
+	data["tidy_cert_store"] = s.tidyCertStore
+	data["tidy_revoked_certs"] = s.tidyRevokedCerts
+	data["time_started"] = s.timeStarted.Format(time.RFC3339Nano)
+	if s.message != "" {
+		data["message"] = s.message
+	}
+	if s.err != nil {
+		data["error"] = s.err.Error()
+	}
+
+	end := time.Now()
+	if !s.timeFinished.IsZero() {
+		data["time_finished"] = s.timeFinished.Format(time.RFC3339Nano)
+		end = s.timeFinished
+	}
+	data["duration"] = end.Sub(s.timeStarted).Round(time.Millisecond).String()
+
+	return data
+}
+
 func pathTidy(b *backend) *framework.Path {
 	return &framework.Path{
 		Pattern: "tidy",
@@ -55,6 +143,32 @@ Defaults to 72 hours.`,
 	}
 }
 
+func pathTidyStatus(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "tidy-status$",
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation: b.pathTidyStatusRead,
+		},
+
+		HelpSynopsis:    pathTidyStatusHelpSyn,
+		HelpDescription: pathTidyStatusHelpDesc,
+	}
+}
+
+func pathTidyCancel(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "tidy-cancel$",
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathTidyCancelWrite,
+		},
+
+		HelpSynopsis:    pathTidyCancelHelpSyn,
+		HelpDescription: pathTidyCancelHelpDesc,
+	}
+}
+
 func (b *backend) pathTidyWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
 	// If we are a performance standby forward the request to the active node
 	if b.System().ReplicationState().HasState(consts.ReplicationPerformanceStandby) {
@@ -84,6 +198,8 @@ func (b *backend) pathTidyWrite(ctx context.Context, req *logical.Request, d *fr
 		Storage: req.Storage,
 	}
 
+	b.tidyStatusStart(safetyBuffer, tidyCertStore, tidyRevokedCerts || tidyRevocationList)
+
 	go func() {
 		defer atomic.StoreUint32(b.tidyCASGuard, 0)
 
@@ -99,7 +215,12 @@ func (b *backend) pathTidyWrite(ctx context.Context, req *logical.Request, d *fr
 					return errwrap.Wrapf("error fetching list of certs: {{err}}", err)
 				}
 
-				for _, serial := range serials {
+				for i, serial := range serials {
+					if atomic.LoadUint32(b.tidyCancelCAS) == 1 {
+						return errTidyCancelled
+					}
+					b.tidyStatusMessage(fmt.Sprintf("Tidying certificate store: checking entry %d of %d", i+1, len(serials)))
+
 					certEntry, err := req.Storage.Get(ctx, "certs/"+serial)
 					if err != nil {
 						return errwrap.Wrapf(fmt.Sprintf("error fetching certificate %q: {{err}}", serial), err)
@@ -110,6 +231,7 @@ func (b *backend) pathTidyWrite(ctx context.Context, req *logical.Request, d *fr
 						if err := req.Storage.Delete(ctx, "certs/"+serial); err != nil {
 							return errwrap.Wrapf(fmt.Sprintf("error deleting nil entry with serial %s: {{err}}", serial), err)
 						}
+						b.tidyStatusIncCertStoreCount(0)
 						continue
 					}
 
@@ -118,6 +240,8 @@ func (b *backend) pathTidyWrite(ctx context.Context, req *logical.Request, d *fr
 						if err := req.Storage.Delete(ctx, "certs/"+serial); err != nil {
 							return errwrap.Wrapf(fmt.Sprintf("error deleting entry with nil value with serial %s: {{err}}", serial), err)
 						}
+						b.tidyStatusIncCertStoreCount(0)
+						continue
 					}
 
 					cert, err := x509.ParseCertificate(certEntry.Value)
@@ -129,6 +253,7 @@ func (b *backend) pathTidyWrite(ctx context.Context, req *logical.Request, d *fr
 						if err := req.Storage.Delete(ctx, "certs/"+serial); err != nil {
 							return errwrap.Wrapf(fmt.Sprintf("error deleting serial %q from storage: {{err}}", serial), err)
 						}
+						b.tidyStatusIncCertStoreCount(len(certEntry.Value))
 					}
 				}
 			}
@@ -144,8 +269,17 @@ func (b *backend) pathTidyWrite(ctx context.Context, req *logical.Request, d *fr
 					return errwrap.Wrapf("error fetching list of revoked certs: {{err}}", err)
 				}
 
+				// The CRL is rebuilt if anything was removed, even when the
+				// operation is cancelled part way through
+				var cancelled bool
 				var revInfo revocationInfo
-				for _, serial := range revokedSerials {
+				for i, serial := range revokedSerials {
+					if atomic.LoadUint32(b.tidyCancelCAS) == 1 {
+						cancelled = true
+						break
+					}
+					b.tidyStatusMessage(fmt.Sprintf("Tidying revoked certificates: checking certificate %d of %d", i+1, len(revokedSerials)))
+
 					revokedEntry, err := req.Storage.Get(ctx, "revoked/"+serial)
 					if err != nil {
 						return errwrap.Wrapf(fmt.Sprintf("unable to fetch revoked cert with serial %q: {{err}}", serial), err)
@@ -156,6 +290,8 @@ func (b *backend) pathTidyWrite(ctx context.Context, req *logical.Request, d *fr
 						if err := req.Storage.Delete(ctx, "revoked/"+serial); err != nil {
 							return errwrap.Wrapf(fmt.Sprintf("error deleting nil revoked entry with serial %s: {{err}}", serial), err)
 						}
+						b.tidyStatusIncRevokedCertCount(0)
+						continue
 					}
 
 					if revokedEntry.Value == nil || len(revokedEntry.Value) == 0 {
@@ -163,6 +299,8 @@ func (b *backend) pathTidyWrite(ctx context.Context, req *logical.Request, d *fr
 						if err := req.Storage.Delete(ctx, "revoked/"+serial); err != nil {
 							return errwrap.Wrapf(fmt.Sprintf("error deleting revoked entry with nil value with serial %s: {{err}}", serial), err)
 						}
+						b.tidyStatusIncRevokedCertCount(0)
+						continue
 					}
 
 					err = revokedEntry.DecodeJSON(&revInfo)
@@ -176,37 +314,147 @@ func (b *backend) pathTidyWrite(ctx context.Context, req *logical.Request, d *fr
 					}
 
 					if time.Now().After(revokedCert.NotAfter.Add(bufferDuration)) {
+						reclaimed := len(revokedEntry.Value)
+						certEntry, err := req.Storage.Get(ctx, "certs/"+serial)
+						if err != nil {
+							return errwrap.Wrapf(fmt.Sprintf("error fetching certificate %q: {{err}}", serial), err)
+						}
+						if certEntry != nil {
+							reclaimed += len(certEntry.Value)
+						}
+
 						if err := req.Storage.Delete(ctx, "revoked/"+serial); err != nil {
 							return errwrap.Wrapf(fmt.Sprintf("error deleting serial %q from revoked list: {{err}}", serial), err)
 						}
 						if err := req.Storage.Delete(ctx, "certs/"+serial); err != nil {
 							return errwrap.Wrapf(fmt.Sprintf("error deleting serial %q from store when tidying revoked: {{err}}", serial), err)
 						}
+						b.tidyStatusIncRevokedCertCount(reclaimed)
 						tidiedRevoked = true
 					}
 				}
 
 				if tidiedRevoked {
+					b.tidyStatusMessage("Rebuilding the CRL")
 					if err := buildCRL(ctx, b, req, false); err != nil {
 						return err
 					}
 				}
+				if cancelled {
+					return errTidyCancelled
+				}
 			}
 
 			return nil
 		}
 
-		if err := doTidy(); err != nil {
+		err := doTidy()
+		switch err {
+		case nil:
+		case errTidyCancelled:
+			logger.Info("tidy operation cancelled")
+		default:
 			logger.Error("error running tidy", "error", err)
-			return
 		}
+		b.tidyStatusStop(err)
 	}()
 
 	resp := &logical.Response{}
-	resp.AddWarning("Tidy operation successfully started. Any information from the operation will be printed to Vault's server logs.")
+	resp.AddWarning("Tidy operation successfully started. Any information from the operation will be printed to Vault's server logs and can be followed with the tidy-status endpoint.")
 	return logical.RespondWithStatusCode(resp, req, http.StatusAccepted)
 }
 
+func (b *backend) pathTidyStatusRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	// The status is only kept in memory on the node running the operation
+	if b.System().ReplicationState().HasState(consts.ReplicationPerformanceStandby) {
+		return nil, logical.ErrReadOnly
+	}
+
+	b.tidyStatusLock.RLock()
+	defer b.tidyStatusLock.RUnlock()
+
+	return &logical.Response{
+		Data: b.tidyStatus.responseData(),
+	}, nil
+}
+
+func (b *backend) pathTidyCancelWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	if b.System().ReplicationState().HasState(consts.ReplicationPerformanceStandby) {
+		return nil, logical.ErrReadOnly
+	}
+
+	if atomic.LoadUint32(b.tidyCASGuard) == 0 {
+		return logical.ErrorResponse("no tidy operation is currently running"), nil
+	}
+
+	b.tidyStatusLock.Lock()
+	defer b.tidyStatusLock.Unlock()
+
+	// The operation may have completed in the meantime
+	if b.tidyStatus.state == tidyStatusStarted {
+		atomic.StoreUint32(b.tidyCancelCAS, 1)
+		b.tidyStatus.state = tidyStatusCancelling
+	}
+
+	return &logical.Response{
+		Data: b.tidyStatus.responseData(),
+	}, nil
+}
+
+func (b *backend) tidyStatusStart(safetyBuffer int, tidyCertStore, tidyRevokedCerts bool) {
+	b.tidyStatusLock.Lock()
+	defer b.tidyStatusLock.Unlock()
+
+	atomic.StoreUint32(b.tidyCancelCAS, 0)
+	b.tidyStatus = &tidyStatus{
+		safetyBuffer:     safetyBuffer,
+		tidyCertStore:    tidyCertStore,
+		tidyRevokedCerts: tidyRevokedCerts,
+		state:            tidyStatusStarted,
+		timeStarted:      time.Now(),
+	}
+}
+
+func (b *backend) tidyStatusStop(err error) {
+	b.tidyStatusLock.Lock()
+	defer b.tidyStatusLock.Unlock()
+
+	b.tidyStatus.timeFinished = time.Now()
+	b.tidyStatus.message = ""
+	switch err {
+	case nil:
+		b.tidyStatus.state = tidyStatusFinished
+	case errTidyCancelled:
+		b.tidyStatus.state = tidyStatusCancelled
+	default:
+		b.tidyStatus.state = tidyStatusError
+		b.tidyStatus.err = err
+	}
+}
+
+func (b *backend) tidyStatusMessage(msg string) {
+	b.tidyStatusLock.Lock()
+	defer b.tidyStatusLock.Unlock()
+
+	b.tidyStatus.message = msg
+}
+
+func (b *backend) tidyStatusIncCertStoreCount(reclaimed int) {
+	b.tidyStatusLock.Lock()
+	defer b.tidyStatusLock.Unlock()
+
+	b.tidyStatus.certStoreDeletedCount++
+	b.tidyStatus.bytesReclaimed += reclaimed
+}
+
+func (b *backend) tidyStatusIncRevokedCertCount(reclaimed int) {
+	b.tidyStatusLock.Lock()
+	defer b.tidyStatusLock.Unlock()
+
+	b.tidyStatus.revokedCertDeletedCount++
+	b.tidyStatus.bytesReclaimed += reclaimed
+}
+
 const pathTidyHelpSyn = `
 Tidy up the backend by removing expired certificates, revocation information,
 or both.
@@ -233,3 +481,29 @@ certificate storage or in revocation information will then be checked. If the
 current time, minus the value of 'safety_buffer', is greater than the
 expiration, it will be removed.
 `
+
+const pathTidyStatusHelpSyn = `
+Returns the status of the tidy operation.
+`
+
+const pathTidyStatusHelpDesc = `
+This is a read only endpoint that returns information about the current tidy
+operation, or the most recent one if none is running: its parameters and
+state, when it started and finished, how many certificates it removed from the
+certificate store and revocation information, and how many bytes of storage
+were reclaimed.
+
+The status is kept in memory on the node running the operation and is reset
+when the backend is reloaded.
+`
+
+const pathTidyCancelHelpSyn = `
+Cancels the running tidy operation.
+`
+
+const pathTidyCancelHelpDesc = `
+This endpoint stops the tidy operation currently running. Certificates already
+removed stay removed, and the CRL is rebuilt if any revocation information was
+removed. The operation stops once it finishes checking the current entry; its
+status is returned and can be followed with the tidy-status endpoint.
+`
@@ -0,0 +1,94 @@
+package pki
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/logical"
+)
+
+func TestBackend_TidyStatusCancel(t *testing.T) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+	b := Backend(config)
+	if err := b.Setup(context.Background(), config); err != nil {
+		t.Fatal(err)
+	}
+
+	request := func(op logical.Operation, path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation: op,
+			Path:      path,
+			Storage:   config.StorageView,
+			Data:      data,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+	waitForState := func(state string) map[string]interface{} {
+		t.Helper()
+		for i := 0; i < 50; i++ {
+			resp := request(logical.ReadOperation, "tidy-status", nil)
+			if resp.Data["state"] == state {
+				return resp.Data
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+		t.Fatalf("tidy did not reach state %q", state)
+		return nil
+	}
+
+	if state := request(logical.ReadOperation, "tidy-status", nil).Data["state"]; state != "Inactive" {
+		t.Fatalf("expected inactive state, got %v", state)
+	}
+	if resp := request(logical.UpdateOperation, "tidy-cancel", nil); resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error cancelling without a running tidy, got %#v", resp)
+	}
+
+	// Entries without a value are always removed
+	for _, key := range []string{"certs/01", "certs/02", "revoked/03"} {
+		if err := config.StorageView.Put(context.Background(), &logical.StorageEntry{Key: key}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	request(logical.UpdateOperation, "tidy", map[string]interface{}{
+		"tidy_cert_store": true,
+	})
+	status := waitForState("Finished")
+	if status["cert_store_deleted_count"] != uint(2) || status["revoked_cert_deleted_count"] != uint(0) {
+		t.Fatalf("bad status: %#v", status)
+	}
+	if status["tidy_cert_store"] != true || status["tidy_revoked_certs"] != false || status["time_finished"] == nil {
+		t.Fatalf("bad status: %#v", status)
+	}
+
+	// Hold the revocation lock so the tidy can't make progress before it is
+	// cancelled
+	b.revokeStorageLock.Lock()
+	request(logical.UpdateOperation, "tidy", map[string]interface{}{
+		"tidy_revoked_certs": true,
+	})
+	resp := request(logical.UpdateOperation, "tidy-cancel", nil)
+	b.revokeStorageLock.Unlock()
+	if resp.Data["state"] != "Cancelling" {
+		t.Fatalf("expected cancelling state, got %#v", resp.Data)
+	}
+	status = waitForState("Cancelled")
+	if status["revoked_cert_deleted_count"] != uint(0) {
+		t.Fatalf("bad status: %#v", status)
+	}
+
+	// The revoked entry is left for the next run
+	entry, err := config.StorageView.Get(context.Background(), "revoked/03")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry == nil {
+		t.Fatal("expected the revoked entry to remain")
+	}
+}
@@ -28,7 +28,7 @@ func pathConfigConnection(b *backend) *framework.Path {
 			"verify_connection": &framework.FieldSchema{
 				Type:        framework.TypeBool,
 				Default:     true,
-				Description: `If set, connection_uri is verified by actually connecting to the RabbitMQ management API`,
+				Description: `If set, connection_uri is verified by actually connecting to the RabbitMQ management API, and the vhosts referenced by roles are checked to exist when they are written`,
 			},
 		},
 
@@ -74,9 +74,10 @@ func (b *backend) pathConnectionUpdate(ctx context.Context, req *logical.Request
 
 	// Store it
 	entry, err := logical.StorageEntryJSON("config/connection", connectionConfig{
-		URI:      uri,
-		Username: username,
-		Password: password,
+		URI:              uri,
+		Username:         username,
+		Password:         password,
+		VerifyConnection: verifyConnection,
 	})
 	if err != nil {
 		return nil, err
@@ -101,6 +102,10 @@ type connectionConfig struct {
 
 	// Password for the Username
 	Password string `json:"password"`
+
+	// VerifyConnection enables checking that the vhosts referenced by roles
+	// exist when they are written
+	VerifyConnection bool `json:"verify_connection"`
 }
 
 const pathConfigConnectionHelpSyn = `
@@ -112,7 +117,7 @@ This path configures the connection properties used to connect to RabbitMQ manag
 The "connection_uri" parameter is a string that is used to connect to the API. The "username"
 and "password" parameters are strings that are used as credentials to the API. The "verify_connection"
 parameter is a boolean that is used to verify whether the provided connection URI, username, and password
-are valid.
+are valid, and whether the vhosts referenced by roles exist when they are written.
 
 The URI looks like:
 "http://localhost:15672"
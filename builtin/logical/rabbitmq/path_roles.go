@@ -3,11 +3,14 @@ package rabbitmq
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"sort"
 
 	"github.com/fatih/structs"
 	"github.com/hashicorp/vault/helper/jsonutil"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
+	rabbithole "github.com/michaelklishin/rabbit-hole"
 )
 
 func pathListRoles(b *backend) *framework.Path {
@@ -127,6 +130,13 @@ func (b *backend) pathRoleUpdate(ctx context.Context, req *logical.Request, d *f
 		}
 	}
 
+	// Catch roles which can't be used now rather than on first use; they are
+	// still stored as the vhosts may be created later
+	warnings, err := b.checkVHosts(ctx, req.Storage, vhosts)
+	if err != nil {
+		return nil, err
+	}
+
 	// Store it
 	entry, err := logical.StorageEntryJSON("role/"+name, &roleEntry{
 		Tags:   tags,
@@ -139,7 +149,58 @@ func (b *backend) pathRoleUpdate(ctx context.Context, req *logical.Request, d *f
 		return nil, err
 	}
 
-	return nil, nil
+	if len(warnings) == 0 {
+		return nil, nil
+	}
+	return &logical.Response{
+		Warnings: warnings,
+	}, nil
+}
+
+// checkVHosts returns warnings for the vhosts which don't exist, unless
+// verify_connection is disabled on the connection configuration.
+func (b *backend) checkVHosts(ctx context.Context, s logical.Storage, vhosts map[string]vhostPermission) ([]string, error) {
+	if len(vhosts) == 0 {
+		return nil, nil
+	}
+
+	entry, err := s.Get(ctx, "config/connection")
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return []string{"the connection is not configured, so the existence of vhosts was not verified"}, nil
+	}
+	var connConfig connectionConfig
+	if err := entry.DecodeJSON(&connConfig); err != nil {
+		return nil, err
+	}
+	if !connConfig.VerifyConnection {
+		return nil, nil
+	}
+
+	client, err := b.Client(ctx, s)
+	if err != nil {
+		return []string{fmt.Sprintf("unable to verify the existence of vhosts: %s", err)}, nil
+	}
+
+	names := make([]string, 0, len(vhosts))
+	for name := range vhosts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var warnings []string
+	for _, name := range names {
+		_, err := client.GetVhost(name)
+		if rmqErr, ok := err.(rabbithole.ErrorResponse); ok && rmqErr.StatusCode == http.StatusNotFound {
+			warnings = append(warnings, fmt.Sprintf("vhost %q does not exist", name))
+		} else if err != nil {
+			warnings = append(warnings, fmt.Sprintf("unable to verify that vhost %q exists: %s", name, err))
+		}
+	}
+
+	return warnings, nil
 }
 
 // Role that defines the capabilities of the credentials issued against it
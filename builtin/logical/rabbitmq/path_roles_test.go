@@ -0,0 +1,97 @@
+package rabbitmq
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/vault/logical"
+)
+
+func TestBackend_roleVHostsCheck(t *testing.T) {
+	var vhostRequests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.EscapedPath() {
+		case "/api/users/":
+			w.Write([]byte(`[]`))
+		case "/api/vhosts/%2F":
+			vhostRequests++
+			w.Write([]byte(`{"name": "/"}`))
+		default:
+			vhostRequests++
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error": "Object Not Found", "reason": "Not Found"}`))
+		}
+	}))
+	defer srv.Close()
+
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+	b, err := Factory(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	write := func(path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      path,
+			Storage:   config.StorageView,
+			Data:      data,
+		})
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("bad: err: %v resp: %#v", err, resp)
+		}
+		return resp
+	}
+	writeRole := func() []string {
+		t.Helper()
+		resp := write("roles/web", map[string]interface{}{
+			"vhosts": `{"/": {"configure": ".*", "write": ".*", "read": ".*"}, "missing": {"read": ".*"}}`,
+		})
+		if resp == nil {
+			return nil
+		}
+		return resp.Warnings
+	}
+
+	write("config/connection", map[string]interface{}{
+		"connection_uri": srv.URL,
+		"username":       "admin",
+		"password":       "secret",
+	})
+	warnings := writeRole()
+	if expected := []string{`vhost "missing" does not exist`}; !reflect.DeepEqual(warnings, expected) {
+		t.Fatalf("expected warnings %q, got %q", expected, warnings)
+	}
+	if vhostRequests != 2 {
+		t.Fatalf("expected 2 vhost requests, got %d", vhostRequests)
+	}
+
+	// The role is stored regardless
+	role, err := b.(*backend).Role(context.Background(), config.StorageView, "web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if role == nil || len(role.VHosts) != 2 {
+		t.Fatalf("bad role: %#v", role)
+	}
+
+	// No check is made when verification is disabled
+	write("config/connection", map[string]interface{}{
+		"connection_uri":    srv.URL,
+		"username":          "admin",
+		"password":          "secret",
+		"verify_connection": false,
+	})
+	if warnings := writeRole(); len(warnings) != 0 {
+		t.Fatalf("expected no warnings, got %q", warnings)
+	}
+	if vhostRequests != 2 {
+		t.Fatalf("expected no more vhost requests, got %d", vhostRequests)
+	}
+}
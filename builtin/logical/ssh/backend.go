@@ -38,6 +38,7 @@ func Backend(conf *logical.BackendConfig) (*backend, error) {
 			Unauthenticated: []string{
 				"verify",
 				"public_key",
+				"public_keys",
 			},
 
 			LocalStorage: []string{
@@ -49,6 +50,11 @@ func Backend(conf *logical.BackendConfig) (*backend, error) {
 				caPrivateKeyStoragePath,
 				"keys/",
 			},
+
+			CachedResponses: []string{
+				"public_key",
+				"public_keys",
+			},
 		},
 
 		Paths: []*framework.Path{
@@ -60,8 +66,10 @@ func Backend(conf *logical.BackendConfig) (*backend, error) {
 			pathLookup(&b),
 			pathVerify(&b),
 			pathConfigCA(&b),
+			pathConfigCARotate(&b),
 			pathSign(&b),
 			pathFetchPublicKey(&b),
+			pathFetchPublicKeys(&b),
 		},
 
 		Secrets: []*framework.Secret{
@@ -696,6 +696,112 @@ func TestBackend_OptionsOverrideDefaults(t *testing.T) {
 	logicaltest.Test(t, testCase)
 }
 
+func TestBackend_TemplatedDefaults(t *testing.T) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+	sysView := logical.TestSystemView()
+	sysView.EntityVal = &logical.Entity{
+		ID:   "entity-id",
+		Name: "alice",
+		Metadata: map[string]string{
+			"team": "ops",
+		},
+	}
+	config.System = sysView
+
+	b, err := Factory(context.Background(), config)
+	if err != nil {
+		t.Fatalf("Cannot create backend: %s", err)
+	}
+
+	request := func(op logical.Operation, path string, data map[string]interface{}) (*logical.Response, error) {
+		return b.HandleRequest(context.Background(), &logical.Request{
+			Operation: op,
+			Path:      path,
+			Storage:   config.StorageView,
+			EntityID:  "entity-id",
+			Data:      data,
+		})
+	}
+
+	resp, err := request(logical.UpdateOperation, "config/ca", map[string]interface{}{
+		"public_key":  publicKey,
+		"private_key": privateKey,
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: err: %v resp: %#v", err, resp)
+	}
+
+	roleData := map[string]interface{}{
+		"key_type":                "ca",
+		"allowed_users":           "tuber",
+		"default_user":            "tuber",
+		"allow_user_certificates": true,
+		"default_critical_options": map[string]interface{}{
+			"force-command": "/usr/local/bin/session {{identity.entity.name",
+		},
+		"default_critical_options_template": true,
+		"default_extensions": map[string]interface{}{
+			"permit-pty": "",
+			"team":       "{{identity.entity.metadata.team}}",
+		},
+		"default_extensions_template": true,
+	}
+	resp, err = request(logical.UpdateOperation, "roles/testing", roleData)
+	if err != nil || resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error for a malformed template: err: %v resp: %#v", err, resp)
+	}
+
+	roleData["default_critical_options"] = map[string]interface{}{
+		"force-command": "/usr/local/bin/session {{identity.entity.name}}",
+	}
+	resp, err = request(logical.UpdateOperation, "roles/testing", roleData)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: err: %v resp: %#v", err, resp)
+	}
+
+	resp, err = request(logical.ReadOperation, "roles/testing", nil)
+	if err != nil || resp == nil {
+		t.Fatalf("bad: err: %v resp: %#v", err, resp)
+	}
+	if resp.Data["default_critical_options_template"] != true || resp.Data["default_extensions_template"] != true {
+		t.Fatalf("bad role: %#v", resp.Data)
+	}
+
+	resp, err = request(logical.UpdateOperation, "sign/testing", map[string]interface{}{
+		"public_key": publicKey2,
+		"ttl":        "2h",
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: err: %v resp: %#v", err, resp)
+	}
+
+	key, _ := base64.StdEncoding.DecodeString(strings.Split(strings.TrimSpace(resp.Data["signed_key"].(string)), " ")[1])
+	parsedKey, err := ssh.ParsePublicKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = validateSSHCertificate(parsedKey.(*ssh.Certificate), "vault-22608f5ef173aabf700797cb95c5641e792698ec6380e8e1eb55523e39aa5e51", ssh.UserCert, []string{"tuber"}, map[string]string{
+		"force-command": "/usr/local/bin/session alice",
+	}, map[string]string{
+		"permit-pty": "",
+		"team":       "ops",
+	}, 2*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Signing fails rather than dropping the restrictions when the templates
+	// can't be populated
+	sysView.EntityVal = nil
+	resp, err = request(logical.UpdateOperation, "sign/testing", map[string]interface{}{
+		"public_key": publicKey2,
+	})
+	if err != nil || resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error without an entity: err: %v resp: %#v", err, resp)
+	}
+}
+
 func TestBackend_AllowedUserKeyLengths(t *testing.T) {
 	config := logical.TestBackendConfig()
 
@@ -26,6 +26,19 @@ const (
 
 type keyStorageEntry struct {
 	Key string `json:"key" structs:"key" mapstructure:"key"`
+
+	// Version is incremented on each rotation of the CA key; keys configured
+	// before rotation was supported are version 1
+	Version int `json:"version,omitempty" structs:"version" mapstructure:"version"`
+}
+
+// version returns the version of the key, taking into account keys stored
+// before versions were recorded
+func (e *keyStorageEntry) version() int {
+	if e.Version == 0 {
+		return 1
+	}
+	return e.Version
 }
 
 func pathConfigCA(b *backend) *framework.Path {
@@ -73,9 +86,25 @@ func (b *backend) pathConfigCARead(ctx context.Context, req *logical.Request, da
 		return logical.ErrorResponse("keys haven't been configured yet"), nil
 	}
 
+	previousKeys, err := previousCAPublicKeys(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	previous := make([]map[string]interface{}, 0, len(previousKeys))
+	for _, key := range previousKeys {
+		previous = append(previous, map[string]interface{}{
+			"version":    key.Version,
+			"public_key": key.PublicKey,
+			"expiration": key.Expiration.Unix(),
+		})
+	}
+
 	response := &logical.Response{
 		Data: map[string]interface{}{
-			"public_key": publicKeyEntry.Key,
+			"public_key":           publicKeyEntry.Key,
+			"version":              publicKeyEntry.version(),
+			"previous_public_keys": previous,
 		},
 	}
 
@@ -89,6 +118,9 @@ func (b *backend) pathConfigCADelete(ctx context.Context, req *logical.Request,
 	if err := req.Storage.Delete(ctx, caPublicKeyStoragePath); err != nil {
 		return nil, err
 	}
+	if err := req.Storage.Delete(ctx, caPreviousPublicKeysStoragePath); err != nil {
+		return nil, err
+	}
 	return nil, nil
 }
 
@@ -145,60 +177,9 @@ func caKey(ctx context.Context, storage logical.Storage, keyType string) (*keySt
 }
 
 func (b *backend) pathConfigCAUpdate(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
-	var err error
-	publicKey := data.Get("public_key").(string)
-	privateKey := data.Get("private_key").(string)
-
-	var generateSigningKey bool
-
-	generateSigningKeyRaw, ok := data.GetOk("generate_signing_key")
-	switch {
-	// explicitly set true
-	case ok && generateSigningKeyRaw.(bool):
-		if publicKey != "" || privateKey != "" {
-			return logical.ErrorResponse("public_key and private_key must not be set when generate_signing_key is set to true"), nil
-		}
-
-		generateSigningKey = true
-
-	// explicitly set to false, or not set and we have both a public and private key
-	case ok, publicKey != "" && privateKey != "":
-		if publicKey == "" {
-			return logical.ErrorResponse("missing public_key"), nil
-		}
-
-		if privateKey == "" {
-			return logical.ErrorResponse("missing private_key"), nil
-		}
-
-		_, err := ssh.ParsePrivateKey([]byte(privateKey))
-		if err != nil {
-			return logical.ErrorResponse(fmt.Sprintf("Unable to parse private_key as an SSH private key: %v", err)), nil
-		}
-
-		_, err = parsePublicSSHKey(publicKey)
-		if err != nil {
-			return logical.ErrorResponse(fmt.Sprintf("Unable to parse public_key as an SSH public key: %v", err)), nil
-		}
-
-	// not set and no public/private key provided so generate
-	case publicKey == "" && privateKey == "":
-		generateSigningKey = true
-
-	// not set, but one or the other supplied
-	default:
-		return logical.ErrorResponse("only one of public_key and private_key set; both must be set to use, or both must be blank to auto-generate"), nil
-	}
-
-	if generateSigningKey {
-		publicKey, privateKey, err = generateSSHKeyPair()
-		if err != nil {
-			return nil, err
-		}
-	}
-
-	if publicKey == "" || privateKey == "" {
-		return nil, fmt.Errorf("failed to generate or parse the keys")
+	publicKey, privateKey, generateSigningKey, errResp, err := caKeyPair(data)
+	if errResp != nil || err != nil {
+		return errResp, err
 	}
 
 	publicKeyEntry, err := caKey(ctx, req.Storage, caPublicKey)
@@ -212,7 +193,7 @@ func (b *backend) pathConfigCAUpdate(ctx context.Context, req *logical.Request,
 	}
 
 	if (publicKeyEntry != nil && publicKeyEntry.Key != "") || (privateKeyEntry != nil && privateKeyEntry.Key != "") {
-		return logical.ErrorResponse("keys are already configured; delete them before reconfiguring"), nil
+		return logical.ErrorResponse("keys are already configured; delete them before reconfiguring or rotate them through config/ca/rotate"), nil
 	}
 
 	entry, err := logical.StorageEntryJSON(caPublicKeyStoragePath, &keyStorageEntry{
@@ -265,6 +246,67 @@ func (b *backend) pathConfigCAUpdate(ctx context.Context, req *logical.Request,
 	return nil, nil
 }
 
+// caKeyPair returns the CA key pair given in the request, or generates one
+// if asked to. generated reports whether the key pair was generated.
+func caKeyPair(data *framework.FieldData) (publicKey, privateKey string, generated bool, errResp *logical.Response, err error) {
+	publicKey = data.Get("public_key").(string)
+	privateKey = data.Get("private_key").(string)
+
+	var generateSigningKey bool
+
+	generateSigningKeyRaw, ok := data.GetOk("generate_signing_key")
+	switch {
+	// explicitly set true
+	case ok && generateSigningKeyRaw.(bool):
+		if publicKey != "" || privateKey != "" {
+			return "", "", false, logical.ErrorResponse("public_key and private_key must not be set when generate_signing_key is set to true"), nil
+		}
+
+		generateSigningKey = true
+
+	// explicitly set to false, or not set and we have both a public and private key
+	case ok, publicKey != "" && privateKey != "":
+		if publicKey == "" {
+			return "", "", false, logical.ErrorResponse("missing public_key"), nil
+		}
+
+		if privateKey == "" {
+			return "", "", false, logical.ErrorResponse("missing private_key"), nil
+		}
+
+		_, err := ssh.ParsePrivateKey([]byte(privateKey))
+		if err != nil {
+			return "", "", false, logical.ErrorResponse(fmt.Sprintf("Unable to parse private_key as an SSH private key: %v", err)), nil
+		}
+
+		_, err = parsePublicSSHKey(publicKey)
+		if err != nil {
+			return "", "", false, logical.ErrorResponse(fmt.Sprintf("Unable to parse public_key as an SSH public key: %v", err)), nil
+		}
+
+	// not set and no public/private key provided so generate
+	case publicKey == "" && privateKey == "":
+		generateSigningKey = true
+
+	// not set, but one or the other supplied
+	default:
+		return "", "", false, logical.ErrorResponse("only one of public_key and private_key set; both must be set to use, or both must be blank to auto-generate"), nil
+	}
+
+	if generateSigningKey {
+		publicKey, privateKey, err = generateSSHKeyPair()
+		if err != nil {
+			return "", "", false, nil, err
+		}
+	}
+
+	if publicKey == "" || privateKey == "" {
+		return "", "", false, nil, fmt.Errorf("failed to generate or parse the keys")
+	}
+
+	return publicKey, privateKey, generateSigningKey, nil, nil
+}
+
 func generateSSHKeyPair() (string, string, error) {
 	privateSeed, err := rsa.GenerateKey(rand.Reader, 4096)
 	if err != nil {
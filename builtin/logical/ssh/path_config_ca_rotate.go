@@ -0,0 +1,181 @@
+package ssh
+
+import (
+	"context"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+const (
+	caPreviousPublicKeysStoragePath = "config/ca_previous_public_keys"
+
+	// defaultCARotationGracePeriod is how long the public key replaced by a
+	// rotation remains trusted if no grace period is given
+	defaultCARotationGracePeriod = 7 * 24 * time.Hour
+)
+
+// previousCAPublicKey is a CA public key replaced by a rotation. It is
+// returned alongside the current one until it expires, so that hosts keep
+// trusting the certificates it signed while the new key is rolled out.
+type previousCAPublicKey struct {
+	Version    int       `json:"version"`
+	PublicKey  string    `json:"public_key"`
+	Expiration time.Time `json:"expiration"`
+}
+
+func pathConfigCARotate(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "config/ca/rotate",
+		Fields: map[string]*framework.FieldSchema{
+			"private_key": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: `Private half of the SSH key that will be used to sign certificates from now on.`,
+			},
+			"public_key": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: `Public half of the SSH key that will be used to sign certificates from now on.`,
+			},
+			"generate_signing_key": &framework.FieldSchema{
+				Type:        framework.TypeBool,
+				Description: `Generate SSH key pair internally rather than use the private_key and public_key fields.`,
+				Default:     true,
+			},
+			"grace_period": &framework.FieldSchema{
+				Type: framework.TypeDurationSecond,
+				Description: `Duration for which the replaced public key is still returned
+alongside the new one. This should be at least the maximum TTL of the
+certificates it signed. Defaults to 168h.`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathConfigCARotateUpdate,
+		},
+
+		HelpSynopsis:    pathConfigCARotateHelpSyn,
+		HelpDescription: pathConfigCARotateHelpDesc,
+	}
+}
+
+func (b *backend) pathConfigCARotateUpdate(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	gracePeriod := defaultCARotationGracePeriod
+	if gracePeriodRaw, ok := data.GetOk("grace_period"); ok {
+		gracePeriod = time.Duration(gracePeriodRaw.(int)) * time.Second
+	}
+	if gracePeriod < 0 {
+		return logical.ErrorResponse("grace_period must not be negative"), nil
+	}
+
+	publicKeyEntry, err := caKey(ctx, req.Storage, caPublicKey)
+	if err != nil {
+		return nil, errwrap.Wrapf("failed to read CA public key: {{err}}", err)
+	}
+	if publicKeyEntry == nil || publicKeyEntry.Key == "" {
+		return logical.ErrorResponse("keys haven't been configured yet"), nil
+	}
+
+	publicKey, privateKey, generated, errResp, err := caKeyPair(data)
+	if errResp != nil || err != nil {
+		return errResp, err
+	}
+
+	// Keep the current public key until the end of the grace period
+	previousKeys, err := previousCAPublicKeys(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	previousKeys = append([]*previousCAPublicKey{
+		&previousCAPublicKey{
+			Version:    publicKeyEntry.version(),
+			PublicKey:  publicKeyEntry.Key,
+			Expiration: time.Now().Add(gracePeriod),
+		},
+	}, previousKeys...)
+
+	entry, err := logical.StorageEntryJSON(caPreviousPublicKeysStoragePath, previousKeys)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	version := publicKeyEntry.version() + 1
+	entry, err = logical.StorageEntryJSON(caPrivateKeyStoragePath, &keyStorageEntry{
+		Key:     privateKey,
+		Version: version,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, errwrap.Wrapf("failed to store CA private key: {{err}}", err)
+	}
+
+	entry, err = logical.StorageEntryJSON(caPublicKeyStoragePath, &keyStorageEntry{
+		Key:     publicKey,
+		Version: version,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, errwrap.Wrapf("failed to store CA public key: {{err}}", err)
+	}
+
+	response := &logical.Response{
+		Data: map[string]interface{}{
+			"version": version,
+		},
+	}
+	if generated {
+		response.Data["public_key"] = publicKey
+	}
+
+	return response, nil
+}
+
+// previousCAPublicKeys returns the public keys replaced by rotations whose
+// grace period hasn't ended, newest first.
+func previousCAPublicKeys(ctx context.Context, storage logical.Storage) ([]*previousCAPublicKey, error) {
+	entry, err := storage.Get(ctx, caPreviousPublicKeysStoragePath)
+	if err != nil {
+		return nil, errwrap.Wrapf("failed to read previous CA public keys: {{err}}", err)
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var keys []*previousCAPublicKey
+	if err := entry.DecodeJSON(&keys); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	active := make([]*previousCAPublicKey, 0, len(keys))
+	for _, key := range keys {
+		if key.Expiration.After(now) {
+			active = append(active, key)
+		}
+	}
+
+	return active, nil
+}
+
+const pathConfigCARotateHelpSyn = `
+Rotate the SSH key used for signing certificates.
+`
+
+const pathConfigCARotateHelpDesc = `
+This replaces the CA key used to sign certificates, either with the given
+key pair or with a generated one. Certificates are signed with the new key
+from then on.
+
+The replaced public key keeps being returned by the "public_keys" endpoint
+and when reading "config/ca" until the end of the grace period, so that
+hosts can trust both keys while the new one is rolled out and certificates
+signed by the old one expire.
+`
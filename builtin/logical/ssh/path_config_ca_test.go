@@ -1,10 +1,14 @@
 package ssh
 
 import (
+	"bytes"
 	"context"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/vault/logical"
+	"golang.org/x/crypto/ssh"
 )
 
 func TestSSH_ConfigCAStorageUpgrade(t *testing.T) {
@@ -168,3 +172,116 @@ func TestSSH_ConfigCAUpdateDelete(t *testing.T) {
 		t.Fatalf("bad: err: %v, resp:%v", err, resp)
 	}
 }
+
+func TestSSH_ConfigCARotate(t *testing.T) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+
+	b, err := Factory(context.Background(), config)
+	if err != nil {
+		t.Fatalf("Cannot create backend: %s", err)
+	}
+
+	request := func(op logical.Operation, path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation: op,
+			Path:      path,
+			Storage:   config.StorageView,
+			Data:      data,
+		})
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("bad: err: %v, resp: %v", err, resp)
+		}
+		return resp
+	}
+
+	// Keys have to be configured before being rotated
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "config/ca/rotate",
+		Storage:   config.StorageView,
+	})
+	if err != nil || resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error rotating unconfigured keys: err: %v, resp: %v", err, resp)
+	}
+
+	request(logical.UpdateOperation, "config/ca", map[string]interface{}{
+		"public_key":  publicKey,
+		"private_key": privateKey,
+	})
+	request(logical.UpdateOperation, "roles/testing", map[string]interface{}{
+		"key_type":                "ca",
+		"allow_user_certificates": true,
+		"allowed_users":           "tuber",
+	})
+
+	resp = request(logical.UpdateOperation, "config/ca/rotate", nil)
+	if resp.Data["version"] != 2 {
+		t.Fatalf("bad version: %#v", resp.Data)
+	}
+	newPublicKey := resp.Data["public_key"].(string)
+
+	resp = request(logical.ReadOperation, "config/ca", nil)
+	if resp.Data["public_key"] != newPublicKey || resp.Data["version"] != 2 {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+	previous := resp.Data["previous_public_keys"].([]map[string]interface{})
+	if len(previous) != 1 || previous[0]["version"] != 1 || previous[0]["public_key"] != publicKey {
+		t.Fatalf("bad previous keys: %#v", previous)
+	}
+	if expiration := time.Unix(previous[0]["expiration"].(int64), 0); expiration.Before(time.Now().Add(defaultCARotationGracePeriod - time.Minute)) {
+		t.Fatalf("bad expiration: %v", expiration)
+	}
+
+	resp = request(logical.ReadOperation, "public_keys", nil)
+	if keys := string(resp.Data[logical.HTTPRawBody].([]byte)); keys != strings.TrimSpace(newPublicKey)+"\n"+strings.TrimSpace(publicKey)+"\n" {
+		t.Fatalf("bad public keys: %q", keys)
+	}
+
+	// Certificates are signed with the new key
+	resp = request(logical.UpdateOperation, "sign/testing", map[string]interface{}{
+		"public_key":       publicKey2,
+		"valid_principals": "tuber",
+	})
+	signedKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(resp.Data["signed_key"].(string)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	newCAKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(newPublicKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(signedKey.(*ssh.Certificate).SignatureKey.Marshal(), newCAKey.Marshal()) {
+		t.Fatal("expected the certificate to be signed with the new key")
+	}
+
+	// Replaced keys are dropped at the end of their grace period
+	resp = request(logical.UpdateOperation, "config/ca/rotate", map[string]interface{}{
+		"public_key":   publicKey,
+		"private_key":  privateKey,
+		"grace_period": "0s",
+	})
+	if resp.Data["version"] != 3 {
+		t.Fatalf("bad version: %#v", resp.Data)
+	}
+	if _, ok := resp.Data["public_key"]; ok {
+		t.Fatal("expected the public key not to be returned when it was given")
+	}
+	resp = request(logical.ReadOperation, "config/ca", nil)
+	previous = resp.Data["previous_public_keys"].([]map[string]interface{})
+	if len(previous) != 1 || previous[0]["version"] != 1 {
+		t.Fatalf("bad previous keys: %#v", previous)
+	}
+
+	// Deleting the keys deletes the previous ones too
+	request(logical.DeleteOperation, "config/ca", nil)
+	request(logical.UpdateOperation, "config/ca", map[string]interface{}{
+		"public_key":  publicKey,
+		"private_key": privateKey,
+	})
+	resp = request(logical.ReadOperation, "config/ca", nil)
+	if previous := resp.Data["previous_public_keys"].([]map[string]interface{}); len(previous) != 0 || resp.Data["version"] != 1 {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+}
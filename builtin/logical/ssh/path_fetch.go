@@ -2,6 +2,7 @@ package ssh
 
 import (
 	"context"
+	"strings"
 
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
@@ -20,6 +21,21 @@ func pathFetchPublicKey(b *backend) *framework.Path {
 	}
 }
 
+func pathFetchPublicKeys(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: `public_keys`,
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation: b.pathFetchPublicKeys,
+		},
+
+		HelpSynopsis: `Retrieve the current and previous public keys.`,
+		HelpDescription: `This allows the public key that this backend currently signs with, followed by
+the public keys replaced by rotations whose grace period hasn't ended, to be
+fetched, one per line.`,
+	}
+}
+
 func (b *backend) pathFetchPublicKey(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
 	publicKeyEntry, err := caKey(ctx, req.Storage, caPublicKey)
 	if err != nil {
@@ -39,3 +55,34 @@ func (b *backend) pathFetchPublicKey(ctx context.Context, req *logical.Request,
 
 	return response, nil
 }
+
+func (b *backend) pathFetchPublicKeys(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	publicKeyEntry, err := caKey(ctx, req.Storage, caPublicKey)
+	if err != nil {
+		return nil, err
+	}
+	if publicKeyEntry == nil || publicKeyEntry.Key == "" {
+		return nil, nil
+	}
+
+	previousKeys, err := previousCAPublicKeys(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys strings.Builder
+	keys.WriteString(strings.TrimSpace(publicKeyEntry.Key) + "\n")
+	for _, key := range previousKeys {
+		keys.WriteString(strings.TrimSpace(key.PublicKey) + "\n")
+	}
+
+	response := &logical.Response{
+		Data: map[string]interface{}{
+			logical.HTTPContentType: "text/plain",
+			logical.HTTPRawBody:     []byte(keys.String()),
+			logical.HTTPStatusCode:  200,
+		},
+	}
+
+	return response, nil
+}
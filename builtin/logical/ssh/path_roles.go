@@ -27,31 +27,33 @@ const (
 // for both OTP and Dynamic roles. Not all the fields are mandatory for both type.
 // Some are applicable for one and not for other. It doesn't matter.
 type sshRole struct {
-	KeyType                string            `mapstructure:"key_type" json:"key_type"`
-	KeyName                string            `mapstructure:"key" json:"key"`
-	KeyBits                int               `mapstructure:"key_bits" json:"key_bits"`
-	AdminUser              string            `mapstructure:"admin_user" json:"admin_user"`
-	DefaultUser            string            `mapstructure:"default_user" json:"default_user"`
-	CIDRList               string            `mapstructure:"cidr_list" json:"cidr_list"`
-	ExcludeCIDRList        string            `mapstructure:"exclude_cidr_list" json:"exclude_cidr_list"`
-	Port                   int               `mapstructure:"port" json:"port"`
-	InstallScript          string            `mapstructure:"install_script" json:"install_script"`
-	AllowedUsers           string            `mapstructure:"allowed_users" json:"allowed_users"`
-	AllowedDomains         string            `mapstructure:"allowed_domains" json:"allowed_domains"`
-	KeyOptionSpecs         string            `mapstructure:"key_option_specs" json:"key_option_specs"`
-	MaxTTL                 string            `mapstructure:"max_ttl" json:"max_ttl"`
-	TTL                    string            `mapstructure:"ttl" json:"ttl"`
-	DefaultCriticalOptions map[string]string `mapstructure:"default_critical_options" json:"default_critical_options"`
-	DefaultExtensions      map[string]string `mapstructure:"default_extensions" json:"default_extensions"`
-	AllowedCriticalOptions string            `mapstructure:"allowed_critical_options" json:"allowed_critical_options"`
-	AllowedExtensions      string            `mapstructure:"allowed_extensions" json:"allowed_extensions"`
-	AllowUserCertificates  bool              `mapstructure:"allow_user_certificates" json:"allow_user_certificates"`
-	AllowHostCertificates  bool              `mapstructure:"allow_host_certificates" json:"allow_host_certificates"`
-	AllowBareDomains       bool              `mapstructure:"allow_bare_domains" json:"allow_bare_domains"`
-	AllowSubdomains        bool              `mapstructure:"allow_subdomains" json:"allow_subdomains"`
-	AllowUserKeyIDs        bool              `mapstructure:"allow_user_key_ids" json:"allow_user_key_ids"`
-	KeyIDFormat            string            `mapstructure:"key_id_format" json:"key_id_format"`
-	AllowedUserKeyLengths  map[string]int    `mapstructure:"allowed_user_key_lengths" json:"allowed_user_key_lengths"`
+	KeyType                        string            `mapstructure:"key_type" json:"key_type"`
+	KeyName                        string            `mapstructure:"key" json:"key"`
+	KeyBits                        int               `mapstructure:"key_bits" json:"key_bits"`
+	AdminUser                      string            `mapstructure:"admin_user" json:"admin_user"`
+	DefaultUser                    string            `mapstructure:"default_user" json:"default_user"`
+	CIDRList                       string            `mapstructure:"cidr_list" json:"cidr_list"`
+	ExcludeCIDRList                string            `mapstructure:"exclude_cidr_list" json:"exclude_cidr_list"`
+	Port                           int               `mapstructure:"port" json:"port"`
+	InstallScript                  string            `mapstructure:"install_script" json:"install_script"`
+	AllowedUsers                   string            `mapstructure:"allowed_users" json:"allowed_users"`
+	AllowedDomains                 string            `mapstructure:"allowed_domains" json:"allowed_domains"`
+	KeyOptionSpecs                 string            `mapstructure:"key_option_specs" json:"key_option_specs"`
+	MaxTTL                         string            `mapstructure:"max_ttl" json:"max_ttl"`
+	TTL                            string            `mapstructure:"ttl" json:"ttl"`
+	DefaultCriticalOptions         map[string]string `mapstructure:"default_critical_options" json:"default_critical_options"`
+	DefaultExtensions              map[string]string `mapstructure:"default_extensions" json:"default_extensions"`
+	DefaultCriticalOptionsTemplate bool              `mapstructure:"default_critical_options_template" json:"default_critical_options_template"`
+	DefaultExtensionsTemplate      bool              `mapstructure:"default_extensions_template" json:"default_extensions_template"`
+	AllowedCriticalOptions         string            `mapstructure:"allowed_critical_options" json:"allowed_critical_options"`
+	AllowedExtensions              string            `mapstructure:"allowed_extensions" json:"allowed_extensions"`
+	AllowUserCertificates          bool              `mapstructure:"allow_user_certificates" json:"allow_user_certificates"`
+	AllowHostCertificates          bool              `mapstructure:"allow_host_certificates" json:"allow_host_certificates"`
+	AllowBareDomains               bool              `mapstructure:"allow_bare_domains" json:"allow_bare_domains"`
+	AllowSubdomains                bool              `mapstructure:"allow_subdomains" json:"allow_subdomains"`
+	AllowUserKeyIDs                bool              `mapstructure:"allow_user_key_ids" json:"allow_user_key_ids"`
+	KeyIDFormat                    string            `mapstructure:"key_id_format" json:"key_id_format"`
+	AllowedUserKeyLengths          map[string]int    `mapstructure:"allowed_user_key_lengths" json:"allowed_user_key_lengths"`
 }
 
 func pathListRoles(b *backend) *framework.Path {
@@ -232,6 +234,26 @@ func pathRoles(b *backend) *framework.Path {
 				"allowed_extensions". Defaults to none.
 				`,
 			},
+			"default_critical_options_template": &framework.FieldSchema{
+				Type: framework.TypeBool,
+				Description: `
+				[Not applicable for Dynamic type] [Not applicable for OTP type]
+				[Optional for CA type] If set, the values of
+				"default_critical_options" can contain identity template
+				policies, such as {{identity.entity.name}}, which are populated
+				from the entity of the requester when signing. Defaults to false.
+				`,
+			},
+			"default_extensions_template": &framework.FieldSchema{
+				Type: framework.TypeBool,
+				Description: `
+				[Not applicable for Dynamic type] [Not applicable for OTP type]
+				[Optional for CA type] If set, the values of "default_extensions"
+				can contain identity template policies, such as
+				{{identity.entity.name}}, which are populated from the entity of
+				the requester when signing. Defaults to false.
+				`,
+			},
 			"allow_user_certificates": &framework.FieldSchema{
 				Type: framework.TypeBool,
 				Description: `
@@ -449,18 +471,20 @@ func (b *backend) createCARole(allowedUsers, defaultUser string, data *framework
 	ttl := time.Duration(data.Get("ttl").(int)) * time.Second
 	maxTTL := time.Duration(data.Get("max_ttl").(int)) * time.Second
 	role := &sshRole{
-		AllowedCriticalOptions: data.Get("allowed_critical_options").(string),
-		AllowedExtensions:      data.Get("allowed_extensions").(string),
-		AllowUserCertificates:  data.Get("allow_user_certificates").(bool),
-		AllowHostCertificates:  data.Get("allow_host_certificates").(bool),
-		AllowedUsers:           allowedUsers,
-		AllowedDomains:         data.Get("allowed_domains").(string),
-		DefaultUser:            defaultUser,
-		AllowBareDomains:       data.Get("allow_bare_domains").(bool),
-		AllowSubdomains:        data.Get("allow_subdomains").(bool),
-		AllowUserKeyIDs:        data.Get("allow_user_key_ids").(bool),
-		KeyIDFormat:            data.Get("key_id_format").(string),
-		KeyType:                KeyTypeCA,
+		AllowedCriticalOptions:         data.Get("allowed_critical_options").(string),
+		AllowedExtensions:              data.Get("allowed_extensions").(string),
+		DefaultCriticalOptionsTemplate: data.Get("default_critical_options_template").(bool),
+		DefaultExtensionsTemplate:      data.Get("default_extensions_template").(bool),
+		AllowUserCertificates:          data.Get("allow_user_certificates").(bool),
+		AllowHostCertificates:          data.Get("allow_host_certificates").(bool),
+		AllowedUsers:                   allowedUsers,
+		AllowedDomains:                 data.Get("allowed_domains").(string),
+		DefaultUser:                    defaultUser,
+		AllowBareDomains:               data.Get("allow_bare_domains").(bool),
+		AllowSubdomains:                data.Get("allow_subdomains").(bool),
+		AllowUserKeyIDs:                data.Get("allow_user_key_ids").(bool),
+		KeyIDFormat:                    data.Get("key_id_format").(string),
+		KeyType:                        KeyTypeCA,
 	}
 
 	if !role.AllowUserCertificates && !role.AllowHostCertificates {
@@ -474,6 +498,17 @@ func (b *backend) createCARole(allowedUsers, defaultUser string, data *framework
 		return nil, logical.ErrorResponse(fmt.Sprintf("error processing allowed_user_key_lengths: %s", err.Error()))
 	}
 
+	if role.DefaultCriticalOptionsTemplate {
+		if err := validateTemplatedValues(defaultCriticalOptions); err != nil {
+			return nil, logical.ErrorResponse(fmt.Sprintf("error processing default_critical_options: %s", err.Error()))
+		}
+	}
+	if role.DefaultExtensionsTemplate {
+		if err := validateTemplatedValues(defaultExtensions); err != nil {
+			return nil, logical.ErrorResponse(fmt.Sprintf("error processing default_extensions: %s", err.Error()))
+		}
+	}
+
 	if ttl != 0 && maxTTL != 0 && ttl > maxTTL {
 		return nil, logical.ErrorResponse(
 			`"ttl" value must be less than "max_ttl" when both are specified`)
@@ -533,24 +568,26 @@ func (b *backend) parseRole(role *sshRole) (map[string]interface{}, error) {
 		}
 
 		result = map[string]interface{}{
-			"allowed_users":            role.AllowedUsers,
-			"allowed_domains":          role.AllowedDomains,
-			"default_user":             role.DefaultUser,
-			"ttl":                      int64(ttl.Seconds()),
-			"max_ttl":                  int64(maxTTL.Seconds()),
-			"allowed_critical_options": role.AllowedCriticalOptions,
-			"allowed_extensions":       role.AllowedExtensions,
-			"allow_user_certificates":  role.AllowUserCertificates,
-			"allow_host_certificates":  role.AllowHostCertificates,
-			"allow_bare_domains":       role.AllowBareDomains,
-			"allow_subdomains":         role.AllowSubdomains,
-			"allow_user_key_ids":       role.AllowUserKeyIDs,
-			"key_id_format":            role.KeyIDFormat,
-			"key_type":                 role.KeyType,
-			"key_bits":                 role.KeyBits,
-			"default_critical_options": role.DefaultCriticalOptions,
-			"default_extensions":       role.DefaultExtensions,
-			"allowed_user_key_lengths": role.AllowedUserKeyLengths,
+			"allowed_users":                     role.AllowedUsers,
+			"allowed_domains":                   role.AllowedDomains,
+			"default_user":                      role.DefaultUser,
+			"ttl":                               int64(ttl.Seconds()),
+			"max_ttl":                           int64(maxTTL.Seconds()),
+			"allowed_critical_options":          role.AllowedCriticalOptions,
+			"allowed_extensions":                role.AllowedExtensions,
+			"allow_user_certificates":           role.AllowUserCertificates,
+			"allow_host_certificates":           role.AllowHostCertificates,
+			"allow_bare_domains":                role.AllowBareDomains,
+			"allow_subdomains":                  role.AllowSubdomains,
+			"allow_user_key_ids":                role.AllowUserKeyIDs,
+			"key_id_format":                     role.KeyIDFormat,
+			"key_type":                          role.KeyType,
+			"key_bits":                          role.KeyBits,
+			"default_critical_options":          role.DefaultCriticalOptions,
+			"default_extensions":                role.DefaultExtensions,
+			"default_critical_options_template": role.DefaultCriticalOptionsTemplate,
+			"default_extensions_template":       role.DefaultExtensionsTemplate,
+			"allowed_user_key_lengths":          role.AllowedUserKeyLengths,
 		}
 	case KeyTypeDynamic:
 		result = map[string]interface{}{
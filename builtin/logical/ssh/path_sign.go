@@ -149,12 +149,12 @@ func (b *backend) pathSignCertificate(ctx context.Context, req *logical.Request,
 		return logical.ErrorResponse(err.Error()), nil
 	}
 
-	criticalOptions, err := b.calculateCriticalOptions(data, role)
+	criticalOptions, err := b.calculateCriticalOptions(data, req, role)
 	if err != nil {
 		return logical.ErrorResponse(err.Error()), nil
 	}
 
-	extensions, err := b.calculateExtensions(data, role)
+	extensions, err := b.calculateExtensions(data, req, role)
 	if err != nil {
 		return logical.ErrorResponse(err.Error()), nil
 	}
@@ -303,9 +303,12 @@ func (b *backend) calculateKeyID(data *framework.FieldData, req *logical.Request
 	return keyID, nil
 }
 
-func (b *backend) calculateCriticalOptions(data *framework.FieldData, role *sshRole) (map[string]string, error) {
+func (b *backend) calculateCriticalOptions(data *framework.FieldData, req *logical.Request, role *sshRole) (map[string]string, error) {
 	unparsedCriticalOptions := data.Get("critical_options").(map[string]interface{})
 	if len(unparsedCriticalOptions) == 0 {
+		if role.DefaultCriticalOptionsTemplate {
+			return populateTemplatedValues(role.DefaultCriticalOptions, req.EntityID, b.System())
+		}
 		return role.DefaultCriticalOptions, nil
 	}
 
@@ -329,9 +332,12 @@ func (b *backend) calculateCriticalOptions(data *framework.FieldData, role *sshR
 	return criticalOptions, nil
 }
 
-func (b *backend) calculateExtensions(data *framework.FieldData, role *sshRole) (map[string]string, error) {
+func (b *backend) calculateExtensions(data *framework.FieldData, req *logical.Request, role *sshRole) (map[string]string, error) {
 	unparsedExtensions := data.Get("extensions").(map[string]interface{})
 	if len(unparsedExtensions) == 0 {
+		if role.DefaultExtensionsTemplate {
+			return populateTemplatedValues(role.DefaultExtensions, req.EntityID, b.System())
+		}
 		return role.DefaultExtensions, nil
 	}
 
@@ -16,6 +16,7 @@ import (
 	"github.com/hashicorp/errwrap"
 	"github.com/hashicorp/vault/helper/parseutil"
 	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
 
 	log "github.com/hashicorp/go-hclog"
 	"golang.org/x/crypto/ssh"
@@ -236,3 +237,37 @@ func substQuery(tpl string, data map[string]string) string {
 
 	return tpl
 }
+
+// validateTemplatedValues checks that the identity templates in the values of
+// the given map are well formed
+func validateTemplatedValues(values map[string]string) error {
+	for key, value := range values {
+		if _, err := framework.ValidateIdentityTemplate(value); err != nil {
+			return fmt.Errorf("invalid template for %q: %s", key, err)
+		}
+	}
+	return nil
+}
+
+// populateTemplatedValues returns a copy of the given map with the identity
+// templates in its values populated from the given entity
+func populateTemplatedValues(values map[string]string, entityID string, sysView logical.SystemView) (map[string]string, error) {
+	result := make(map[string]string, len(values))
+	for key, value := range values {
+		isTemplate, err := framework.ValidateIdentityTemplate(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid template for %q: %s", key, err)
+		}
+		if !isTemplate {
+			result[key] = value
+			continue
+		}
+
+		populated, err := framework.PopulateIdentityTemplate(value, entityID, sysView)
+		if err != nil {
+			return nil, fmt.Errorf("failed to populate template for %q: %s", key, err)
+		}
+		result[key] = populated
+	}
+	return result, nil
+}
@@ -0,0 +1,78 @@
+package terraform
+
+import (
+	"context"
+	"strings"
+
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+// Factory returns a Terraform Cloud backend that satisfies the
+// logical.Backend interface
+func Factory(ctx context.Context, conf *logical.BackendConfig) (logical.Backend, error) {
+	b := Backend()
+	if err := b.Setup(ctx, conf); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Backend returns the Terraform Cloud backend
+func Backend() *backend {
+	var b backend
+	b.Backend = &framework.Backend{
+		Help: strings.TrimSpace(backendHelp),
+
+		PathsSpecial: &logical.Paths{
+			SealWrapStorage: []string{
+				"config/access",
+			},
+		},
+
+		Paths: []*framework.Path{
+			pathConfigAccess(&b),
+			framework.PathConfigVerify("config/access", b.verifyConfigAccess),
+			pathConfigLease(&b),
+			pathListRoles(&b),
+			pathRoles(&b),
+			pathCredsCreate(&b),
+			framework.PathRevokeAll(),
+		},
+
+		Secrets: []*framework.Secret{
+			secretToken(&b),
+		},
+		BackendType: logical.TypeLogical,
+	}
+
+	return &b
+}
+
+type backend struct {
+	*framework.Backend
+}
+
+// client returns a client of the Terraform Cloud API using the stored access
+// configuration
+func (b *backend) client(ctx context.Context, s logical.Storage) (*client, error) {
+	conf, err := b.readConfigAccess(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+	if conf == nil {
+		return nil, errNotConfigured
+	}
+
+	return clientFromConfig(conf), nil
+}
+
+const backendHelp = `
+The Terraform backend issues Terraform Cloud and Terraform Enterprise API
+tokens on demand.
+
+Using a token configured with the "config/access" path, it creates
+organization, team or user tokens for roles, which are deleted when their
+lease is revoked. Create roles with the "role/" endpoints and request tokens
+with the "creds/" endpoints.
+`
@@ -0,0 +1,380 @@
+package terraform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/hashicorp/vault/logical"
+)
+
+// testTerraformAPI is a fake of the parts of the Terraform Cloud API used by
+// the backend, accepting the token "owner"
+type testTerraformAPI struct {
+	l sync.Mutex
+
+	// current holds the ID of the token of each team or organization, by
+	// path of the token
+	current map[string]string
+
+	// userTokens holds the description of each user token, by ID
+	userTokens map[string]string
+
+	nextID int
+}
+
+func (a *testTerraformAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	a.l.Lock()
+	defer a.l.Unlock()
+
+	w.Header().Set("Content-Type", jsonAPIContentType)
+	if r.Header.Get("Authorization") != "Bearer owner" {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"errors": [{"status": "401", "title": "unauthorized"}]}`))
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, apiPrefix)
+	switch {
+	case path == "/account/details" && r.Method == http.MethodGet:
+		w.Write([]byte(`{"data": {"id": "user-owner", "type": "users"}}`))
+
+	case strings.HasSuffix(path, "/authentication-token"):
+		id, ok := a.current[path]
+		switch r.Method {
+		case http.MethodPost:
+			a.nextID++
+			a.current[path] = fmt.Sprintf("at-%d", a.nextID)
+			a.writeToken(w, a.current[path], "")
+		case http.MethodGet:
+			if !ok {
+				a.notFound(w)
+				return
+			}
+			fmt.Fprintf(w, `{"data": {"id": %q, "type": "authentication-tokens", "attributes": {"token": null}}}`, id)
+		case http.MethodDelete:
+			if !ok {
+				a.notFound(w)
+				return
+			}
+			delete(a.current, path)
+			w.WriteHeader(http.StatusNoContent)
+		}
+
+	case strings.HasPrefix(path, "/users/") && strings.HasSuffix(path, "/authentication-tokens") && r.Method == http.MethodPost:
+		var in tokenDocument
+		if err := json.NewDecoder(r.Body).Decode(&in); err != nil || in.Data.Type != "authentication-tokens" {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			return
+		}
+		a.nextID++
+		id := fmt.Sprintf("at-%d", a.nextID)
+		a.userTokens[id] = in.Data.Attributes.Description
+		a.writeToken(w, id, in.Data.Attributes.Description)
+
+	case strings.HasPrefix(path, "/authentication-tokens/") && r.Method == http.MethodDelete:
+		id := strings.TrimPrefix(path, "/authentication-tokens/")
+		if _, ok := a.userTokens[id]; !ok {
+			a.notFound(w)
+			return
+		}
+		delete(a.userTokens, id)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		a.notFound(w)
+	}
+}
+
+func (a *testTerraformAPI) writeToken(w http.ResponseWriter, id, description string) {
+	var out tokenDocument
+	out.Data.ID = id
+	out.Data.Type = "authentication-tokens"
+	out.Data.Attributes.Token = "secret-" + id
+	out.Data.Attributes.Description = description
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(&out)
+}
+
+func (a *testTerraformAPI) notFound(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusNotFound)
+	w.Write([]byte(`{"errors": [{"status": "404", "title": "not found"}]}`))
+}
+
+func testBackend(t *testing.T) (*backend, logical.Storage, *testTerraformAPI, func()) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+	b := Backend()
+	if err := b.Setup(context.Background(), config); err != nil {
+		t.Fatal(err)
+	}
+
+	api := &testTerraformAPI{
+		current:    map[string]string{},
+		userTokens: map[string]string{},
+	}
+	server := httptest.NewServer(api)
+
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "config/access",
+		Storage:   config.StorageView,
+		Data: map[string]interface{}{
+			"address": server.URL,
+			"token":   "owner",
+		},
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		server.Close()
+		t.Fatalf("err: %v, resp: %#v", err, resp)
+	}
+
+	return b, config.StorageView, api, server.Close
+}
+
+func testWriteRole(t *testing.T, b *backend, s logical.Storage, name string, data map[string]interface{}) *logical.Response {
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.CreateOperation,
+		Path:      "role/" + name,
+		Storage:   s,
+		Data:      data,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func testReadCreds(t *testing.T, b *backend, s logical.Storage, name string) *logical.Response {
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation:   logical.ReadOperation,
+		Path:        "creds/" + name,
+		Storage:     s,
+		DisplayName: "test",
+	})
+	if err != nil || resp.IsError() {
+		t.Fatalf("err: %v, resp: %#v", err, resp)
+	}
+	return resp
+}
+
+func testRevoke(t *testing.T, b *backend, s logical.Storage, secret *logical.Secret) {
+	if _, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.RevokeOperation,
+		Storage:   s,
+		Secret:    secret,
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBackend_ConfigAccess(t *testing.T) {
+	b, storage, _, cleanup := testBackend(t)
+	defer cleanup()
+
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "config/access",
+		Storage:   storage,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := resp.Data["token"]; ok || !strings.HasPrefix(resp.Data["address"].(string), "http://127.0.0.1") {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "config/access/verify",
+		Storage:   storage,
+	})
+	if err != nil || resp.IsError() {
+		t.Fatalf("err: %v, resp: %#v", err, resp)
+	}
+
+	// A wrong token is rejected
+	if _, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "config/access",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"token": "wrong",
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "config/access/verify",
+		Storage:   storage,
+	})
+	if err != nil || !resp.IsError() {
+		t.Fatalf("expected verification to fail: err: %v, resp: %#v", err, resp)
+	}
+
+	// The address defaults to Terraform Cloud
+	if _, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.DeleteOperation,
+		Path:      "config/access",
+		Storage:   storage,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.CreateOperation,
+		Path:      "config/access",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"token": "owner",
+		},
+	})
+	if err != nil || resp != nil {
+		t.Fatalf("err: %v, resp: %#v", err, resp)
+	}
+	conf, err := b.readConfigAccess(context.Background(), storage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conf.Address != defaultAddress {
+		t.Fatalf("bad: %#v", conf)
+	}
+}
+
+func TestBackend_Roles(t *testing.T) {
+	b, storage, _, cleanup := testBackend(t)
+	defer cleanup()
+
+	for _, data := range []map[string]interface{}{
+		{},
+		{"team_id": "team-1", "user_id": "user-1"},
+	} {
+		resp := testWriteRole(t, b, storage, "invalid", data)
+		if resp == nil || !resp.IsError() {
+			t.Fatalf("expected an error with %v", data)
+		}
+	}
+
+	if resp := testWriteRole(t, b, storage, "deploy", map[string]interface{}{
+		"organization": "acme",
+		"team_id":      "team-1",
+	}); resp != nil {
+		t.Fatalf("bad: %#v", resp)
+	}
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "role/deploy",
+		Storage:   storage,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Data["token_type"] != tokenTypeTeam || resp.Data["team_id"] != "team-1" {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+}
+
+func TestBackend_TeamCreds(t *testing.T) {
+	b, storage, api, cleanup := testBackend(t)
+	defer cleanup()
+
+	testWriteRole(t, b, storage, "deploy", map[string]interface{}{
+		"team_id": "team-1",
+	})
+	if _, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "config/lease",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"ttl":     "1h",
+			"max_ttl": "4h",
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	first := testReadCreds(t, b, storage, "deploy")
+	if first.Data["token"] != "secret-at-1" || first.Data["token_id"] != "at-1" || first.Data["token_type"] != tokenTypeTeam {
+		t.Fatalf("bad: %#v", first.Data)
+	}
+	if first.Secret.TTL.Hours() != 1 || first.Secret.MaxTTL.Hours() != 4 {
+		t.Fatalf("bad: %#v", first.Secret)
+	}
+
+	// The second token replaces the first one, whose revocation must leave
+	// the second one alone
+	second := testReadCreds(t, b, storage, "deploy")
+	if second.Data["token_id"] != "at-2" {
+		t.Fatalf("bad: %#v", second.Data)
+	}
+	testRevoke(t, b, storage, first.Secret)
+	if api.current["/teams/team-1/authentication-token"] != "at-2" {
+		t.Fatalf("expected the current token to be kept: %v", api.current)
+	}
+
+	testRevoke(t, b, storage, second.Secret)
+	if _, ok := api.current["/teams/team-1/authentication-token"]; ok {
+		t.Fatalf("expected the token to be deleted: %v", api.current)
+	}
+
+	// Tokens which were already deleted are ignored
+	testRevoke(t, b, storage, second.Secret)
+}
+
+func TestBackend_OrganizationCreds(t *testing.T) {
+	b, storage, api, cleanup := testBackend(t)
+	defer cleanup()
+
+	testWriteRole(t, b, storage, "org", map[string]interface{}{
+		"organization": "acme",
+	})
+
+	resp := testReadCreds(t, b, storage, "org")
+	if resp.Data["token_type"] != tokenTypeOrganization {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+	if api.current["/organizations/acme/authentication-token"] != resp.Data["token_id"] {
+		t.Fatalf("bad: %v", api.current)
+	}
+
+	testRevoke(t, b, storage, resp.Secret)
+	if len(api.current) != 0 {
+		t.Fatalf("expected the token to be deleted: %v", api.current)
+	}
+}
+
+func TestBackend_UserCreds(t *testing.T) {
+	b, storage, api, cleanup := testBackend(t)
+	defer cleanup()
+
+	testWriteRole(t, b, storage, "ci", map[string]interface{}{
+		"user_id": "user-ci",
+	})
+
+	first := testReadCreds(t, b, storage, "ci")
+	second := testReadCreds(t, b, storage, "ci")
+	if len(api.userTokens) != 2 {
+		t.Fatalf("bad: %v", api.userTokens)
+	}
+	if description := api.userTokens[first.Data["token_id"].(string)]; !strings.HasPrefix(description, "vault-ci-test-") {
+		t.Fatalf("bad description: %q", description)
+	}
+
+	// User tokens are independent of each other
+	testRevoke(t, b, storage, first.Secret)
+	if _, ok := api.userTokens[second.Data["token_id"].(string)]; !ok || len(api.userTokens) != 1 {
+		t.Fatalf("bad: %v", api.userTokens)
+	}
+
+	testRevoke(t, b, storage, second.Secret)
+	testRevoke(t, b, storage, second.Secret)
+	if len(api.userTokens) != 0 {
+		t.Fatalf("expected the tokens to be deleted: %v", api.userTokens)
+	}
+}
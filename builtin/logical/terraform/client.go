@@ -0,0 +1,229 @@
+package terraform
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	cleanhttp "github.com/hashicorp/go-cleanhttp"
+)
+
+const (
+	// defaultAddress is the address of Terraform Cloud
+	defaultAddress = "https://app.terraform.io"
+
+	apiPrefix = "/api/v2"
+
+	// jsonAPIContentType is the media type of the requests and responses of
+	// the API
+	jsonAPIContentType = "application/vnd.api+json"
+)
+
+var errNotConfigured = errors.New("the Terraform backend is not configured")
+
+// authenticationToken is an API token of Terraform Cloud. The token itself is
+// only returned when it is created.
+type authenticationToken struct {
+	ID          string
+	Token       string
+	Description string
+}
+
+// tokenDocument is the JSON:API document of an authentication token
+type tokenDocument struct {
+	Data struct {
+		ID         string `json:"id,omitempty"`
+		Type       string `json:"type"`
+		Attributes struct {
+			Token       string `json:"token,omitempty"`
+			Description string `json:"description,omitempty"`
+		} `json:"attributes"`
+	} `json:"data"`
+}
+
+// apiError is an error returned by the Terraform Cloud API
+type apiError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("%d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+func isNotFound(err error) bool {
+	apiErr, ok := err.(*apiError)
+	return ok && apiErr.StatusCode == http.StatusNotFound
+}
+
+// client is a minimal client of the Terraform Cloud API, authenticating with
+// the configured token
+type client struct {
+	address    string
+	token      string
+	httpClient *http.Client
+}
+
+func clientFromConfig(conf *accessConfig) *client {
+	address := conf.Address
+	if address == "" {
+		address = defaultAddress
+	}
+
+	return &client{
+		address:    strings.TrimSuffix(address, "/"),
+		token:      conf.Token,
+		httpClient: cleanhttp.DefaultClient(),
+	}
+}
+
+// do sends a request to the Terraform Cloud API, with in as the JSON body of
+// the request if not nil, and decodes the JSON body of the response into out,
+// if not nil
+func (c *client) do(method, path string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		buf, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(buf)
+	}
+
+	req, err := http.NewRequest(method, c.address+apiPrefix+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Accept", jsonAPIContentType)
+	if in != nil {
+		req.Header.Set("Content-Type", jsonAPIContentType)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &apiError{
+			StatusCode: resp.StatusCode,
+			Message:    errorMessage(respBody),
+		}
+	}
+
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(respBody, out)
+}
+
+// errorMessage extracts the message of an error from the body of a response,
+// which holds either JSON:API error objects or plain strings
+func errorMessage(body []byte) string {
+	var objects struct {
+		Errors []struct {
+			Title  string `json:"title"`
+			Detail string `json:"detail"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &objects); err == nil && len(objects.Errors) != 0 {
+		if objects.Errors[0].Detail != "" {
+			return objects.Errors[0].Detail
+		}
+		return objects.Errors[0].Title
+	}
+
+	var strs struct {
+		Errors []string `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &strs); err == nil && len(strs.Errors) != 0 {
+		return strs.Errors[0]
+	}
+
+	return strings.TrimSpace(string(body))
+}
+
+// accountDetails checks that Terraform Cloud can be reached and that the
+// configured token is accepted
+func (c *client) accountDetails() error {
+	return c.do(http.MethodGet, "/account/details", nil, nil)
+}
+
+// tokenPath returns the path of the token of a team or an organization,
+// which have a single token at a time
+func tokenPath(kind, target string) string {
+	switch kind {
+	case tokenTypeTeam:
+		return "/teams/" + url.PathEscape(target) + "/authentication-token"
+	default:
+		return "/organizations/" + url.PathEscape(target) + "/authentication-token"
+	}
+}
+
+// createToken creates a token of the given kind for target, which is the ID
+// of a team or a user, or the name of an organization. Creating the token of
+// a team or an organization replaces its current token.
+func (c *client) createToken(kind, target, description string) (*authenticationToken, error) {
+	var in tokenDocument
+	in.Data.Type = "authentication-tokens"
+
+	path := tokenPath(kind, target)
+	if kind == tokenTypeUser {
+		path = "/users/" + url.PathEscape(target) + "/authentication-tokens"
+		in.Data.Attributes.Description = description
+	}
+
+	var out tokenDocument
+	if err := c.do(http.MethodPost, path, &in, &out); err != nil {
+		return nil, err
+	}
+	if out.Data.Attributes.Token == "" {
+		return nil, errors.New("no token returned by Terraform Cloud")
+	}
+
+	return &authenticationToken{
+		ID:          out.Data.ID,
+		Token:       out.Data.Attributes.Token,
+		Description: out.Data.Attributes.Description,
+	}, nil
+}
+
+// deleteToken deletes the token with the given ID. Tokens of teams and
+// organizations are only deleted if they are still the current token of
+// target, since a newer token may have replaced it. Tokens which don't exist
+// anymore are ignored.
+func (c *client) deleteToken(kind, target, id string) error {
+	var err error
+	switch kind {
+	case tokenTypeUser:
+		err = c.do(http.MethodDelete, "/authentication-tokens/"+url.PathEscape(id), nil, nil)
+
+	default:
+		var current tokenDocument
+		err = c.do(http.MethodGet, tokenPath(kind, target), nil, &current)
+		if err == nil {
+			if current.Data.ID != id {
+				return nil
+			}
+			err = c.do(http.MethodDelete, tokenPath(kind, target), nil, nil)
+		}
+	}
+
+	if isNotFound(err) {
+		return nil
+	}
+	return err
+}
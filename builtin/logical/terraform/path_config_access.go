@@ -0,0 +1,162 @@
+package terraform
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+const configAccessKey = "config/access"
+
+func pathConfigAccess(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "config/access",
+		Fields: map[string]*framework.FieldSchema{
+			"address": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Default:     defaultAddress,
+				Description: "Address of Terraform Cloud or Terraform Enterprise",
+			},
+
+			"token": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Token for API calls, which must be allowed to manage the tokens of the roles",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation:   b.pathConfigAccessRead,
+			logical.CreateOperation: b.pathConfigAccessWrite,
+			logical.UpdateOperation: b.pathConfigAccessWrite,
+			logical.DeleteOperation: b.pathConfigAccessDelete,
+		},
+
+		ExistenceCheck: b.configExistenceCheck,
+
+		HelpSynopsis:    pathConfigAccessHelpSyn,
+		HelpDescription: pathConfigAccessHelpDesc,
+	}
+}
+
+func (b *backend) configExistenceCheck(ctx context.Context, req *logical.Request, data *framework.FieldData) (bool, error) {
+	entry, err := b.readConfigAccess(ctx, req.Storage)
+	if err != nil {
+		return false, err
+	}
+
+	return entry != nil, nil
+}
+
+func (b *backend) readConfigAccess(ctx context.Context, storage logical.Storage) (*accessConfig, error) {
+	entry, err := storage.Get(ctx, configAccessKey)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	conf := &accessConfig{}
+	if err := entry.DecodeJSON(conf); err != nil {
+		return nil, errwrap.Wrapf("error reading terraform access configuration: {{err}}", err)
+	}
+
+	return conf, nil
+}
+
+// verifyConfigAccess reads the details of the account of the configured
+// token to check that Terraform Cloud can be reached and that the token is
+// accepted.
+func (b *backend) verifyConfigAccess(ctx context.Context, s logical.Storage) error {
+	c, err := b.client(ctx, s)
+	if err != nil {
+		return err
+	}
+
+	if err := c.accountDetails(); err != nil {
+		return errwrap.Wrapf("error reading terraform account details: {{err}}", err)
+	}
+
+	return nil
+}
+
+func (b *backend) pathConfigAccessRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	conf, err := b.readConfigAccess(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if conf == nil {
+		return nil, nil
+	}
+
+	// The token is not returned
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"address": conf.Address,
+		},
+	}, nil
+}
+
+func (b *backend) pathConfigAccessWrite(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	conf, err := b.readConfigAccess(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if conf == nil {
+		conf = &accessConfig{
+			Address: data.Get("address").(string),
+		}
+	}
+
+	address, ok := data.GetOk("address")
+	if ok {
+		conf.Address = address.(string)
+	}
+	if u, err := url.Parse(conf.Address); err != nil || u.Scheme == "" || u.Host == "" {
+		return logical.ErrorResponse("address must be an absolute URL"), nil
+	}
+
+	token, ok := data.GetOk("token")
+	if ok {
+		conf.Token = token.(string)
+	}
+	if conf.Token == "" {
+		return logical.ErrorResponse("token is required"), nil
+	}
+
+	entry, err := logical.StorageEntryJSON(configAccessKey, conf)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+func (b *backend) pathConfigAccessDelete(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	if err := req.Storage.Delete(ctx, configAccessKey); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+type accessConfig struct {
+	Address string `json:"address"`
+	Token   string `json:"token"`
+}
+
+const pathConfigAccessHelpSyn = `
+Configure the access to Terraform Cloud or Terraform Enterprise.
+`
+
+const pathConfigAccessHelpDesc = `
+This path configures the address of Terraform Cloud, or of a Terraform
+Enterprise installation, and the token used by Vault to create and delete
+tokens. Issuing team and organization tokens requires an owner token of the
+organization. The token is never returned once written.
+`
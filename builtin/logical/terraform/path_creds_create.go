@@ -0,0 +1,89 @@
+package terraform
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+func pathCredsCreate(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "creds/" + framework.GenericNameRegex("name"),
+		Fields: map[string]*framework.FieldSchema{
+			"name": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Name of the role",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation: b.pathTokenRead,
+		},
+
+		HelpSynopsis:    pathCredsCreateHelpSyn,
+		HelpDescription: pathCredsCreateHelpDesc,
+	}
+}
+
+func (b *backend) pathTokenRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	role, err := b.Role(ctx, req.Storage, name)
+	if err != nil {
+		return nil, errwrap.Wrapf("error retrieving role: {{err}}", err)
+	}
+	if role == nil {
+		return logical.ErrorResponse(fmt.Sprintf("role %q not found", name)), nil
+	}
+
+	// Determine if we have a lease configuration
+	leaseConfig, err := b.LeaseConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if leaseConfig == nil {
+		leaseConfig = &configLease{}
+	}
+
+	c, err := b.client(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	// The description is only kept for user tokens
+	description := fmt.Sprintf("vault-%s-%s-%d", name, req.DisplayName, time.Now().UnixNano())
+
+	tokenType := role.tokenType()
+	token, err := c.createToken(tokenType, role.tokenTarget(), description)
+	if err != nil {
+		return nil, errwrap.Wrapf("error creating terraform token: {{err}}", err)
+	}
+
+	resp := b.Secret(SecretTokenType).Response(map[string]interface{}{
+		"token":      token.Token,
+		"token_id":   token.ID,
+		"token_type": tokenType,
+	}, map[string]interface{}{
+		"token_id":   token.ID,
+		"token_type": tokenType,
+		"target":     role.tokenTarget(),
+	})
+	resp.Secret.TTL = leaseConfig.TTL
+	resp.Secret.MaxTTL = leaseConfig.MaxTTL
+
+	return resp, nil
+}
+
+const pathCredsCreateHelpSyn = `
+Request a Terraform Cloud token for a role.
+`
+
+const pathCredsCreateHelpDesc = `
+This path creates an organization, team or user token, depending on the named
+role, which is deleted when its lease is revoked. Creating a team or
+organization token replaces the previous token of the team or organization.
+`
@@ -0,0 +1,233 @@
+package terraform
+
+import (
+	"context"
+	"errors"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+const (
+	tokenTypeOrganization = "organization"
+	tokenTypeTeam         = "team"
+	tokenTypeUser         = "user"
+)
+
+func pathListRoles(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "role/?$",
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ListOperation: b.pathRoleList,
+		},
+
+		HelpSynopsis:    pathListRolesHelpSyn,
+		HelpDescription: pathListRolesHelpDesc,
+	}
+}
+
+func pathRoles(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "role/" + framework.GenericNameRegex("name"),
+		Fields: map[string]*framework.FieldSchema{
+			"name": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Name of the role",
+			},
+
+			"organization": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Name of the organization to issue tokens of. Required unless team_id or user_id is set.",
+			},
+
+			"team_id": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "ID of the team to issue tokens of, e.g. team-abc123",
+			},
+
+			"user_id": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "ID of the user, or service account, to issue tokens of, e.g. user-abc123",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation:   b.pathRolesRead,
+			logical.CreateOperation: b.pathRolesWrite,
+			logical.UpdateOperation: b.pathRolesWrite,
+			logical.DeleteOperation: b.pathRolesDelete,
+		},
+
+		ExistenceCheck: b.rolesExistenceCheck,
+
+		HelpSynopsis:    pathRolesHelpSyn,
+		HelpDescription: pathRolesHelpDesc,
+	}
+}
+
+// Establishes dichotomy of request operation between CreateOperation and UpdateOperation.
+// Returning 'true' forces an UpdateOperation, CreateOperation otherwise.
+func (b *backend) rolesExistenceCheck(ctx context.Context, req *logical.Request, d *framework.FieldData) (bool, error) {
+	name := d.Get("name").(string)
+	entry, err := b.Role(ctx, req.Storage, name)
+	if err != nil {
+		return false, err
+	}
+	return entry != nil, nil
+}
+
+func (b *backend) Role(ctx context.Context, storage logical.Storage, name string) (*roleConfig, error) {
+	if name == "" {
+		return nil, errors.New("invalid role name")
+	}
+
+	entry, err := storage.Get(ctx, "role/"+name)
+	if err != nil {
+		return nil, errwrap.Wrapf("error retrieving role: {{err}}", err)
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var result roleConfig
+	if err := entry.DecodeJSON(&result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (b *backend) pathRoleList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	entries, err := req.Storage.List(ctx, "role/")
+	if err != nil {
+		return nil, err
+	}
+
+	return logical.ListResponse(entries), nil
+}
+
+func (b *backend) pathRolesRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	role, err := b.Role(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return nil, nil
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"organization": role.Organization,
+			"team_id":      role.TeamID,
+			"user_id":      role.UserID,
+			"token_type":   role.tokenType(),
+		},
+	}, nil
+}
+
+func (b *backend) pathRolesWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	role, err := b.Role(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		role = new(roleConfig)
+	}
+
+	organization, ok := d.GetOk("organization")
+	if ok {
+		role.Organization = organization.(string)
+	}
+	teamID, ok := d.GetOk("team_id")
+	if ok {
+		role.TeamID = teamID.(string)
+	}
+	userID, ok := d.GetOk("user_id")
+	if ok {
+		role.UserID = userID.(string)
+	}
+
+	switch {
+	case role.TeamID != "" && role.UserID != "":
+		return logical.ErrorResponse("team_id and user_id are mutually exclusive"), nil
+	case role.TeamID == "" && role.UserID == "" && role.Organization == "":
+		return logical.ErrorResponse("one of organization, team_id or user_id must be set"), nil
+	}
+
+	entry, err := logical.StorageEntryJSON("role/"+name, role)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+func (b *backend) pathRolesDelete(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+	if err := req.Storage.Delete(ctx, "role/"+name); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+type roleConfig struct {
+	Organization string `json:"organization"`
+	TeamID       string `json:"team_id"`
+	UserID       string `json:"user_id"`
+}
+
+// tokenType returns the type of the tokens of the role
+func (r *roleConfig) tokenType() string {
+	switch {
+	case r.TeamID != "":
+		return tokenTypeTeam
+	case r.UserID != "":
+		return tokenTypeUser
+	default:
+		return tokenTypeOrganization
+	}
+}
+
+// tokenTarget returns the ID of the team or user, or the name of the
+// organization, the tokens of the role are issued for
+func (r *roleConfig) tokenTarget() string {
+	switch r.tokenType() {
+	case tokenTypeTeam:
+		return r.TeamID
+	case tokenTypeUser:
+		return r.UserID
+	default:
+		return r.Organization
+	}
+}
+
+const pathListRolesHelpSyn = `
+List the existing roles in this backend.
+`
+
+const pathListRolesHelpDesc = `
+Roles will be listed by the role name.
+`
+
+const pathRolesHelpSyn = `
+Manage the roles that can be used to issue Terraform Cloud tokens.
+`
+
+const pathRolesHelpDesc = `
+This path lets you manage the roles used to issue tokens. A role issues the
+tokens of a team if "team_id" is set, of a user or service account if
+"user_id" is set, and of the organization otherwise.
+
+Teams and organizations have a single token at a time: issuing a token for a
+role of a team or an organization replaces the token previously issued, even
+if its lease has not expired. Users can have many tokens.
+`
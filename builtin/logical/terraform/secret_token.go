@@ -0,0 +1,63 @@
+package terraform
+
+import (
+	"context"
+	"errors"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+const (
+	SecretTokenType = "token"
+)
+
+func secretToken(b *backend) *framework.Secret {
+	return &framework.Secret{
+		Type: SecretTokenType,
+		Fields: map[string]*framework.FieldSchema{
+			"token": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Terraform Cloud token",
+			},
+		},
+
+		Renew:  b.secretTokenRenew,
+		Revoke: b.secretTokenRevoke,
+	}
+}
+
+func (b *backend) secretTokenRenew(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	lease, err := b.LeaseConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if lease == nil {
+		lease = &configLease{}
+	}
+	resp := &logical.Response{Secret: req.Secret}
+	resp.Secret.TTL = lease.TTL
+	resp.Secret.MaxTTL = lease.MaxTTL
+	return resp, nil
+}
+
+func (b *backend) secretTokenRevoke(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	tokenID, _ := req.Secret.InternalData["token_id"].(string)
+	tokenType, _ := req.Secret.InternalData["token_type"].(string)
+	target, _ := req.Secret.InternalData["target"].(string)
+	if tokenID == "" || tokenType == "" || target == "" {
+		return nil, errors.New("secret is missing the token ID, type or target")
+	}
+
+	c, err := b.client(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.deleteToken(tokenType, target, tokenID); err != nil {
+		return nil, errwrap.Wrapf("error deleting terraform token: {{err}}", err)
+	}
+
+	return nil, nil
+}
@@ -3,6 +3,7 @@ package totp
 import (
 	"context"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/hashicorp/vault/logical"
@@ -33,6 +34,7 @@ func Backend() *backend {
 			pathListKeys(&b),
 			pathKeys(&b),
 			pathCode(&b),
+			pathCodeBatch(&b),
 		},
 
 		Secrets:     []*framework.Secret{},
@@ -40,6 +42,7 @@ func Backend() *backend {
 	}
 
 	b.usedCodes = cache.New(0, 30*time.Second)
+	b.failedValidations = cache.New(0, 30*time.Second)
 
 	return &b
 }
@@ -48,6 +51,11 @@ type backend struct {
 	*framework.Backend
 
 	usedCodes *cache.Cache
+
+	// failedValidations tracks the failed validations of the keys limiting
+	// them, guarded by validationLock
+	failedValidations *cache.Cache
+	validationLock    sync.Mutex
 }
 
 const backendHelp = `
@@ -343,6 +343,150 @@ func TestBackend_keyCrudDefaultValues(t *testing.T) {
 	})
 }
 
+func TestBackend_validationLockout(t *testing.T) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+	b, err := Factory(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, _ := createKey()
+
+	request := func(path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      path,
+			Storage:   config.StorageView,
+			Data:      data,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	request("keys/test", map[string]interface{}{
+		"key":                       key,
+		"max_validation_attempts":   2,
+		"validation_lockout_period": "1h",
+	})
+
+	for _, invalidCode := range []string{"12345678", "23456789"} {
+		resp := request("code/test", map[string]interface{}{"code": invalidCode})
+		if resp.IsError() || resp.Data["valid"].(bool) {
+			t.Fatalf("bad: %#v", resp)
+		}
+	}
+
+	// The key is locked, even for valid codes
+	code, _ := generateCode(key, 30, otplib.DigitsSix, otplib.AlgorithmSHA1)
+	resp := request("code/test", map[string]interface{}{"code": code})
+	if !resp.IsError() {
+		t.Fatalf("expected the key to be locked: %#v", resp)
+	}
+
+	// Writing the key again resets its failed validations
+	request("keys/test", map[string]interface{}{
+		"key":                     key,
+		"max_validation_attempts": 2,
+	})
+	resp = request("code/test", map[string]interface{}{"code": code})
+	if resp.IsError() || !resp.Data["valid"].(bool) {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "keys/test",
+		Storage:   config.StorageView,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Data["max_validation_attempts"].(uint) != 2 || resp.Data["validation_lockout_period"].(int64) != 300 {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	// Invalid limits are rejected
+	resp = request("keys/invalid", map[string]interface{}{
+		"key":                     key,
+		"max_validation_attempts": -1,
+	})
+	if !resp.IsError() {
+		t.Fatalf("expected an error: %#v", resp)
+	}
+}
+
+func TestBackend_batchValidateCode(t *testing.T) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+	b, err := Factory(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, _ := createKey()
+	if _, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "keys/test",
+		Storage:   config.StorageView,
+		Data: map[string]interface{}{
+			"key": key,
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	code, _ := generateCode(key, 30, otplib.DigitsSix, otplib.AlgorithmSHA1)
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "code",
+		Storage:   config.StorageView,
+		Data: map[string]interface{}{
+			"batch_input": []interface{}{
+				map[string]interface{}{"name": "test", "code": code},
+				map[string]interface{}{"name": "test", "code": code},
+				map[string]interface{}{"name": "test", "code": "12345678"},
+				map[string]interface{}{"name": "missing", "code": code},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := resp.Data["batch_results"].([]BatchResponseItem)
+	if len(results) != 4 {
+		t.Fatalf("bad: %#v", results)
+	}
+	if !results[0].Valid || results[0].Error != "" {
+		t.Fatalf("expected a valid code: %#v", results[0])
+	}
+	// The code was used by the first item
+	if results[1].Valid || results[1].Error == "" {
+		t.Fatalf("expected an error: %#v", results[1])
+	}
+	if results[2].Valid || results[2].Error != "" {
+		t.Fatalf("expected an invalid code: %#v", results[2])
+	}
+	if results[3].Valid || results[3].Error == "" || results[3].Name != "missing" {
+		t.Fatalf("expected an error: %#v", results[3])
+	}
+
+	// An empty batch is rejected
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "code",
+		Storage:   config.StorageView,
+		Data:      map[string]interface{}{},
+	})
+	if err != logical.ErrInvalidRequest || !resp.IsError() {
+		t.Fatalf("expected an error: %#v, %v", resp, err)
+	}
+}
+
 func TestBackend_createKeyMissingKeyValue(t *testing.T) {
 	config := logical.TestBackendConfig()
 	config.StorageView = &logical.InmemStorage{}
@@ -8,6 +8,7 @@ import (
 	"github.com/hashicorp/errwrap"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
+	"github.com/mitchellh/mapstructure"
 	otplib "github.com/pquerna/otp"
 	totplib "github.com/pquerna/otp/totp"
 )
@@ -36,6 +37,42 @@ func pathCode(b *backend) *framework.Path {
 	}
 }
 
+func pathCodeBatch(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "code/?$",
+		Fields: map[string]*framework.FieldSchema{
+			"batch_input": &framework.FieldSchema{
+				Type:        framework.TypeSlice,
+				Description: `List of items to validate, each with the "name" of a key and the TOTP "code" to be validated.`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathValidateCodeBatch,
+		},
+
+		HelpSynopsis:    pathCodeBatchHelpSyn,
+		HelpDescription: pathCodeBatchHelpDesc,
+	}
+}
+
+// BatchRequestItem represents a code to be validated in a batch
+type BatchRequestItem struct {
+	Name string `json:"name" mapstructure:"name" structs:"name"`
+	Code string `json:"code" mapstructure:"code" structs:"code"`
+}
+
+// BatchResponseItem represents the result of the validation of a code in a
+// batch
+type BatchResponseItem struct {
+	Name  string `json:"name" mapstructure:"name" structs:"name"`
+	Valid bool   `json:"valid" mapstructure:"valid" structs:"valid"`
+
+	// Error, if set, represents a failure encountered while validating the
+	// code
+	Error string `json:"error,omitempty" mapstructure:"error" structs:"error"`
+}
+
 func (b *backend) pathReadCode(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
 	name := data.Get("name").(string)
 
@@ -70,25 +107,86 @@ func (b *backend) pathValidateCode(ctx context.Context, req *logical.Request, da
 	name := data.Get("name").(string)
 	code := data.Get("code").(string)
 
+	valid, errMsg, err := b.validateCode(ctx, req.Storage, name, code)
+	if err != nil {
+		return nil, err
+	}
+	if errMsg != "" {
+		return logical.ErrorResponse(errMsg), nil
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"valid": valid,
+		},
+	}, nil
+}
+
+func (b *backend) pathValidateCodeBatch(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	batchInputRaw := data.Raw["batch_input"]
+	if batchInputRaw == nil {
+		return logical.ErrorResponse("missing batch input to process"), logical.ErrInvalidRequest
+	}
+
+	var batchInputItems []BatchRequestItem
+	if err := mapstructure.Decode(batchInputRaw, &batchInputItems); err != nil {
+		return nil, errwrap.Wrapf("failed to parse batch input: {{err}}", err)
+	}
+	if len(batchInputItems) == 0 {
+		return logical.ErrorResponse("missing batch input to process"), logical.ErrInvalidRequest
+	}
+
+	batchResponseItems := make([]BatchResponseItem, len(batchInputItems))
+	for i, item := range batchInputItems {
+		valid, errMsg, err := b.validateCode(ctx, req.Storage, item.Name, item.Code)
+		if err != nil {
+			return nil, err
+		}
+
+		batchResponseItems[i] = BatchResponseItem{
+			Name:  item.Name,
+			Valid: valid,
+			Error: errMsg,
+		}
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"batch_results": batchResponseItems,
+		},
+	}, nil
+}
+
+// validateCode validates the code against the named key. Problems with the
+// input, such as an unknown key, a code already used or a locked key, are
+// returned as an error message.
+func (b *backend) validateCode(ctx context.Context, s logical.Storage, name, code string) (bool, string, error) {
 	// Enforce input value requirements
+	if name == "" {
+		return false, "the name value is required", nil
+	}
 	if code == "" {
-		return logical.ErrorResponse("the code value is required"), nil
+		return false, "the code value is required", nil
 	}
 
 	// Get the key's stored values
-	key, err := b.Key(ctx, req.Storage, name)
+	key, err := b.Key(ctx, s, name)
 	if err != nil {
-		return nil, err
+		return false, "", err
 	}
 	if key == nil {
-		return logical.ErrorResponse(fmt.Sprintf("unknown key: %s", name)), nil
+		return false, fmt.Sprintf("unknown key: %s", name), nil
+	}
+
+	if lockedUntil, locked := b.validationLockout(name); locked {
+		return false, fmt.Sprintf("too many failed validation attempts; the key is locked until %s", lockedUntil.Format(time.RFC3339)), nil
 	}
 
 	usedName := fmt.Sprintf("%s_%s", name, code)
 
 	_, ok := b.usedCodes.Get(usedName)
 	if ok {
-		return logical.ErrorResponse("code already used; wait until the next time period"), nil
+		return false, "code already used; wait until the next time period", nil
 	}
 
 	valid, err := totplib.ValidateCustom(code, key.Key, time.Now(), totplib.ValidateOpts{
@@ -98,9 +196,11 @@ func (b *backend) pathValidateCode(ctx context.Context, req *logical.Request, da
 		Algorithm: key.Algorithm,
 	})
 	if err != nil && err != otplib.ErrValidateInputInvalidLength {
-		return logical.ErrorResponse("an error occured while validating the code"), err
+		return false, "", errwrap.Wrapf("an error occured while validating the code: {{err}}", err)
 	}
 
+	b.recordValidation(name, key, valid)
+
 	// Take the key skew, add two for behind and in front, and multiple that by
 	// the period to cover the full possibility of the validity of the key
 	err = b.usedCodes.Add(usedName, nil, time.Duration(
@@ -108,14 +208,71 @@ func (b *backend) pathValidateCode(ctx context.Context, req *logical.Request, da
 			int64(key.Period)*
 			int64((2+key.Skew))))
 	if err != nil {
-		return nil, errwrap.Wrapf("error adding code to used cache: {{err}}", err)
+		return false, "", errwrap.Wrapf("error adding code to used cache: {{err}}", err)
 	}
 
-	return &logical.Response{
-		Data: map[string]interface{}{
-			"valid": valid,
-		},
-	}, nil
+	return valid, "", nil
+}
+
+// validationAttempts tracks the failed validations of a key
+type validationAttempts struct {
+	failures    uint
+	lockedUntil time.Time
+}
+
+// validationLockout returns the time until which the named key is locked
+// after too many failed validations, if it is
+func (b *backend) validationLockout(name string) (time.Time, bool) {
+	b.validationLock.Lock()
+	defer b.validationLock.Unlock()
+
+	raw, ok := b.failedValidations.Get(name)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	lockedUntil := raw.(*validationAttempts).lockedUntil
+	return lockedUntil, time.Now().Before(lockedUntil)
+}
+
+// recordValidation counts the failed validations of the key, locking it for
+// its lockout period once it reaches its maximum number of attempts. A
+// successful validation resets the count.
+func (b *backend) recordValidation(name string, key *keyEntry, valid bool) {
+	if key.MaxValidationAttempts == 0 {
+		return
+	}
+
+	b.validationLock.Lock()
+	defer b.validationLock.Unlock()
+
+	if valid {
+		b.failedValidations.Delete(name)
+		return
+	}
+
+	attempts := &validationAttempts{}
+	if raw, ok := b.failedValidations.Get(name); ok {
+		attempts = raw.(*validationAttempts)
+	}
+
+	attempts.failures++
+	if attempts.failures >= key.MaxValidationAttempts {
+		attempts.failures = 0
+		attempts.lockedUntil = time.Now().Add(key.ValidationLockoutPeriod)
+	}
+
+	// Failures are forgotten once a lockout period has passed without any,
+	// which is also when a lockout ends
+	b.failedValidations.Set(name, attempts, key.ValidationLockoutPeriod)
+}
+
+// resetValidationAttempts forgets the failed validations of the named key
+func (b *backend) resetValidationAttempts(name string) {
+	b.validationLock.Lock()
+	defer b.validationLock.Unlock()
+
+	b.failedValidations.Delete(name)
 }
 
 const pathCodeHelpSyn = `
@@ -124,4 +281,16 @@ Request time-based one-time use password or validate a password for a certain ke
 const pathCodeHelpDesc = `
 This path generates and validates time-based one-time use passwords for a certain key. 
 
+Keys with a max_validation_attempts value are locked for their
+validation_lockout_period once that many validations in a row have failed.
+`
+
+const pathCodeBatchHelpSyn = `
+Validate a batch of time-based one-time use passwords.
+`
+const pathCodeBatchHelpDesc = `
+This path validates time-based one-time use passwords for several keys at
+once. Each item of the batch_input list holds the name of a key and the code to
+validate, and the batch_results list holds the result of each item in the same
+order, with an error message for the items that couldn't be validated.
 `
@@ -10,6 +10,7 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/errwrap"
 	"github.com/hashicorp/vault/logical"
@@ -97,6 +98,18 @@ func pathKeys(b *backend) *framework.Path {
 				Description: `The number of delay periods that are allowed when validating a TOTP token. This value can either be 0 or 1. Only used if generate is true.`,
 			},
 
+			"max_validation_attempts": {
+				Type:        framework.TypeInt,
+				Default:     0,
+				Description: `The number of failed validations in a row after which the key is locked for the validation_lockout_period. If this value is 0, validations are not limited.`,
+			},
+
+			"validation_lockout_period": {
+				Type:        framework.TypeDurationSecond,
+				Default:     300,
+				Description: `The amount of time, in seconds or as a duration string, during which the key can't be validated after max_validation_attempts failed validations.`,
+			},
+
 			"qr_size": {
 				Type:        framework.TypeInt,
 				Default:     200,
@@ -138,11 +151,14 @@ func (b *backend) Key(ctx context.Context, s logical.Storage, n string) (*keyEnt
 }
 
 func (b *backend) pathKeyDelete(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
-	err := req.Storage.Delete(ctx, "key/"+data.Get("name").(string))
+	name := data.Get("name").(string)
+	err := req.Storage.Delete(ctx, "key/"+name)
 	if err != nil {
 		return nil, err
 	}
 
+	b.resetValidationAttempts(name)
+
 	return nil, nil
 }
 
@@ -166,6 +182,9 @@ func (b *backend) pathKeyRead(ctx context.Context, req *logical.Request, data *f
 			"period":       key.Period,
 			"algorithm":    algorithm,
 			"digits":       key.Digits,
+
+			"max_validation_attempts":   key.MaxValidationAttempts,
+			"validation_lockout_period": int64(key.ValidationLockoutPeriod.Seconds()),
 		},
 	}, nil
 }
@@ -190,6 +209,8 @@ func (b *backend) pathKeyCreate(ctx context.Context, req *logical.Request, data
 	algorithm := data.Get("algorithm").(string)
 	digits := data.Get("digits").(int)
 	skew := data.Get("skew").(int)
+	maxValidationAttempts := data.Get("max_validation_attempts").(int)
+	validationLockoutPeriod := data.Get("validation_lockout_period").(int)
 	qrSize := data.Get("qr_size").(int)
 	keySize := data.Get("key_size").(int)
 	inputURL := data.Get("url").(string)
@@ -298,6 +319,15 @@ func (b *backend) pathKeyCreate(ctx context.Context, req *logical.Request, data
 		return logical.ErrorResponse("the skew value must be 0 or 1"), nil
 	}
 
+	// Max validation attempts can be zero to disable the limit
+	if maxValidationAttempts < 0 {
+		return logical.ErrorResponse("the max_validation_attempts value must be greater than or equal to zero"), nil
+	}
+
+	if maxValidationAttempts > 0 && validationLockoutPeriod <= 0 {
+		return logical.ErrorResponse("the validation_lockout_period value must be greater than zero"), nil
+	}
+
 	// QR size can be zero but it shouldn't be negative
 	if qrSize < 0 {
 		return logical.ErrorResponse("the qr_size value must be greater than or equal to zero"), nil
@@ -311,6 +341,7 @@ func (b *backend) pathKeyCreate(ctx context.Context, req *logical.Request, data
 	uintPeriod := uint(period)
 	uintSkew := uint(skew)
 	uintKeySize := uint(keySize)
+	uintMaxValidationAttempts := uint(maxValidationAttempts)
 
 	var response *logical.Response
 
@@ -391,6 +422,9 @@ func (b *backend) pathKeyCreate(ctx context.Context, req *logical.Request, data
 		Algorithm:   keyAlgorithm,
 		Digits:      keyDigits,
 		Skew:        uintSkew,
+
+		MaxValidationAttempts:   uintMaxValidationAttempts,
+		ValidationLockoutPeriod: time.Duration(validationLockoutPeriod) * time.Second,
 	})
 	if err != nil {
 		return nil, err
@@ -399,6 +433,9 @@ func (b *backend) pathKeyCreate(ctx context.Context, req *logical.Request, data
 		return nil, err
 	}
 
+	// Failed validations of a previous key by the same name don't count
+	b.resetValidationAttempts(name)
+
 	return response, nil
 }
 
@@ -410,6 +447,9 @@ type keyEntry struct {
 	Algorithm   otplib.Algorithm `json:"algorithm" mapstructure:"algorithm" structs:"algorithm"`
 	Digits      otplib.Digits    `json:"digits" mapstructure:"digits" structs:"digits"`
 	Skew        uint             `json:"skew" mapstructure:"skew" structs:"skew"`
+
+	MaxValidationAttempts   uint          `json:"max_validation_attempts" mapstructure:"max_validation_attempts" structs:"max_validation_attempts"`
+	ValidationLockoutPeriod time.Duration `json:"validation_lockout_period" mapstructure:"validation_lockout_period" structs:"validation_lockout_period"`
 }
 
 const pathKeyHelpSyn = `
@@ -3,7 +3,9 @@ package transit
 import (
 	"context"
 	"strings"
+	"sync"
 
+	"github.com/hashicorp/vault/helper/consts"
 	"github.com/hashicorp/vault/helper/keysutil"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
@@ -24,6 +26,7 @@ func Backend(conf *logical.BackendConfig) *backend {
 			SealWrapStorage: []string{
 				"archive/",
 				"policy/",
+				"import/",
 			},
 		},
 
@@ -32,6 +35,10 @@ func Backend(conf *logical.BackendConfig) *backend {
 			// as the handler is greedy
 			b.pathConfig(),
 			b.pathRotate(),
+			b.pathImport(),
+			b.pathImportVersion(),
+			b.pathWrappingKey(),
+			b.pathKeyUsage(),
 			b.pathRewrap(),
 			b.pathKeys(),
 			b.pathListKeys(),
@@ -49,12 +56,22 @@ func Backend(conf *logical.BackendConfig) *backend {
 			b.pathTrim(),
 		},
 
-		Secrets:     []*framework.Secret{},
-		Invalidate:  b.invalidate,
+		Secrets:      []*framework.Secret{},
+		Invalidate:   b.invalidate,
+		PeriodicFunc: b.periodicFunc,
+		ScheduledFuncs: []*framework.ScheduledFunc{
+			{
+				Name:            "auto_rotate",
+				Description:     "Rotates the keys whose latest version is older than their auto_rotate_period.",
+				DefaultSchedule: "1m",
+				Func:            b.periodicAutoRotate,
+			},
+		},
 		BackendType: logical.TypeLogical,
 	}
 
 	b.lm = keysutil.NewLockManager(conf.System.CachingDisabled())
+	b.usage = newUsageTracker()
 
 	return &b
 }
@@ -62,6 +79,36 @@ func Backend(conf *logical.BackendConfig) *backend {
 type backend struct {
 	*framework.Backend
 	lm *keysutil.LockManager
+
+	// wrappingKeyLock serializes the generation of the key import wrapping
+	// key
+	wrappingKeyLock sync.Mutex
+
+	// usage holds key usage not yet written to storage
+	usage *usageTracker
+}
+
+// storageReadOnly returns whether the storage of the mount is read-only on
+// this node
+func (b *backend) storageReadOnly() bool {
+	return !b.System().LocalMount() && b.System().ReplicationState().HasState(consts.ReplicationPerformanceSecondary|consts.ReplicationPerformanceStandby)
+}
+
+// periodicFunc writes the key usage to storage
+func (b *backend) periodicFunc(ctx context.Context, req *logical.Request) error {
+	if b.storageReadOnly() {
+		return nil
+	}
+	return b.flushUsage(ctx, req)
+}
+
+// periodicAutoRotate rotates the keys due for rotation, on the "auto_rotate"
+// schedule
+func (b *backend) periodicAutoRotate(ctx context.Context, req *logical.Request) error {
+	if b.storageReadOnly() {
+		return nil
+	}
+	return b.autoRotateKeys(ctx, req)
 }
 
 func (b *backend) invalidate(_ context.Context, key string) {
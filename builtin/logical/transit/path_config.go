@@ -3,7 +3,9 @@ package transit
 import (
 	"context"
 	"fmt"
+	"time"
 
+	"github.com/hashicorp/vault/helper/errutil"
 	"github.com/hashicorp/vault/helper/keysutil"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
@@ -23,6 +25,7 @@ func (b *backend) pathConfig() *framework.Path {
 				Description: `If set, the minimum version of the key allowed
 to be decrypted. For signing keys, the minimum
 version allowed to be used for verification.`,
+				MinValue: framework.IntPtr(0),
 			},
 
 			"min_encryption_version": &framework.FieldSchema{
@@ -31,6 +34,7 @@ version allowed to be used for verification.`,
 to be used for encryption; or for signing keys,
 to be used for signing. If set to zero, only
 the latest version of the key is allowed.`,
+				MinValue: framework.IntPtr(0),
 			},
 
 			"deletion_allowed": &framework.FieldSchema{
@@ -47,6 +51,22 @@ the latest version of the key is allowed.`,
 				Type:        framework.TypeBool,
 				Description: `Enables taking a backup of the named key in plaintext format. Once set, this cannot be disabled.`,
 			},
+
+			"auto_rotate_period": &framework.FieldSchema{
+				Type: framework.TypeDurationSecond,
+				Description: `Amount of time after which a new version of the key
+is generated automatically. Must be at least one hour,
+or zero to disable automatic rotation.`,
+			},
+
+			"convergent_encryption": &framework.FieldSchema{
+				Type: framework.TypeBool,
+				Description: `Enables convergent encryption for new versions of
+a derived key, rotating it. Nonces are then derived from the
+context and plaintext rather than supplied. Existing versions
+are left unchanged so their ciphertexts can still be decrypted.
+Once set, this cannot be disabled.`,
+			},
 		},
 
 		Callbacks: map[logical.Operation]framework.OperationFunc{
@@ -84,6 +104,7 @@ func (b *backend) pathConfigWrite(ctx context.Context, req *logical.Request, d *
 	originalDeletionAllowed := p.DeletionAllowed
 	originalExportable := p.Exportable
 	originalAllowPlaintextBackup := p.AllowPlaintextBackup
+	originalAutoRotatePeriod := p.AutoRotatePeriod
 
 	defer func() {
 		if retErr != nil || (resp != nil && resp.IsError()) {
@@ -92,6 +113,7 @@ func (b *backend) pathConfigWrite(ctx context.Context, req *logical.Request, d *
 			p.DeletionAllowed = originalDeletionAllowed
 			p.Exportable = originalExportable
 			p.AllowPlaintextBackup = originalAllowPlaintextBackup
+			p.AutoRotatePeriod = originalAutoRotatePeriod
 		}
 	}()
 
@@ -103,10 +125,6 @@ func (b *backend) pathConfigWrite(ctx context.Context, req *logical.Request, d *
 	if ok {
 		minDecryptionVersion := minDecryptionVersionRaw.(int)
 
-		if minDecryptionVersion < 0 {
-			return logical.ErrorResponse("min decryption version cannot be negative"), nil
-		}
-
 		if minDecryptionVersion == 0 {
 			minDecryptionVersion = 1
 			resp.AddWarning("since Vault 0.3, transit key numbering starts at 1; forcing minimum to 1")
@@ -126,10 +144,6 @@ func (b *backend) pathConfigWrite(ctx context.Context, req *logical.Request, d *
 	if ok {
 		minEncryptionVersion := minEncryptionVersionRaw.(int)
 
-		if minEncryptionVersion < 0 {
-			return logical.ErrorResponse("min encryption version cannot be negative"), nil
-		}
-
 		if minEncryptionVersion != p.MinEncryptionVersion {
 			if minEncryptionVersion > p.LatestVersion {
 				return logical.ErrorResponse(
@@ -185,7 +199,34 @@ func (b *backend) pathConfigWrite(ctx context.Context, req *logical.Request, d *
 		}
 	}
 
-	if !persistNeeded {
+	autoRotatePeriodRaw, ok := d.GetOk("auto_rotate_period")
+	if ok {
+		autoRotatePeriod := time.Duration(autoRotatePeriodRaw.(int)) * time.Second
+		switch {
+		case autoRotatePeriod < 0:
+			return logical.ErrorResponse("auto_rotate_period cannot be negative"), nil
+		case autoRotatePeriod > 0 && autoRotatePeriod < minAutoRotatePeriod:
+			return logical.ErrorResponse(fmt.Sprintf("auto_rotate_period must be at least %s", minAutoRotatePeriod)), nil
+		case autoRotatePeriod > 0 && p.Imported && !p.AllowImportedKeyRotation:
+			return logical.ErrorResponse("imported key does not allow rotation, automatic rotation cannot be enabled"), nil
+		}
+		if autoRotatePeriod != p.AutoRotatePeriod {
+			p.AutoRotatePeriod = autoRotatePeriod
+			persistNeeded = true
+		}
+	}
+
+	enableConvergent := false
+	convergentRaw, ok := d.GetOk("convergent_encryption")
+	if ok {
+		convergent := convergentRaw.(bool)
+		if !convergent && p.ConvergentEncryption {
+			return logical.ErrorResponse("convergent encryption cannot be disabled"), nil
+		}
+		enableConvergent = convergent
+	}
+
+	if !persistNeeded && !enableConvergent {
 		return nil, nil
 	}
 
@@ -196,11 +237,27 @@ func (b *backend) pathConfigWrite(ctx context.Context, req *logical.Request, d *
 		return logical.ErrorResponse("min decryption version should not be less then min available version"), nil
 	}
 
+	if enableConvergent {
+		// This rotates the key, persisting the other changes as well
+		if err := p.EnableConvergentEncryption(ctx, req.Storage); err != nil {
+			if _, ok := err.(errutil.UserError); ok {
+				return logical.ErrorResponse(err.Error()), nil
+			}
+			return nil, err
+		}
+	}
+
+	if persistNeeded {
+		if err := p.Persist(ctx, req.Storage); err != nil {
+			return nil, err
+		}
+	}
+
 	if len(resp.Warnings) == 0 {
-		return nil, p.Persist(ctx, req.Storage)
+		return nil, nil
 	}
 
-	return resp, p.Persist(ctx, req.Storage)
+	return resp, nil
 }
 
 const pathConfigHelpSyn = `Configure a named encryption key`
@@ -208,5 +265,8 @@ const pathConfigHelpSyn = `Configure a named encryption key`
 const pathConfigHelpDesc = `
 This path is used to configure the named key. Currently, this
 supports adjusting the minimum version of the key allowed to
-be used for decryption via the min_decryption_version parameter.
+be used for decryption via the min_decryption_version parameter,
+automatic rotation of the key via the auto_rotate_period
+parameter, and enabling convergent encryption for new versions
+of the key via the convergent_encryption parameter.
 `
@@ -2,10 +2,13 @@ package transit
 
 import (
 	"context"
+	"encoding/base64"
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/hashicorp/vault/helper/keysutil"
 	"github.com/hashicorp/vault/logical"
 )
 
@@ -215,3 +218,229 @@ func TestTransit_ConfigSettings(t *testing.T) {
 	testHMAC(3, true)
 	testHMAC(2, false)
 }
+
+func TestTransit_AutoRotate(t *testing.T) {
+	b, storage := createBackendWithSysView(t)
+
+	doReq := func(op logical.Operation, path string, data map[string]interface{}) (*logical.Response, error) {
+		return b.HandleRequest(context.Background(), &logical.Request{
+			Storage:   storage,
+			Operation: op,
+			Path:      path,
+			Data:      data,
+		})
+	}
+	// The rollback operation only runs the rotation once a minute
+	rollback := func() {
+		if err := b.periodicAutoRotate(context.Background(), &logical.Request{Storage: storage}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	latestVersion := func() int {
+		resp, err := doReq(logical.ReadOperation, "keys/foo", nil)
+		if err != nil || resp == nil {
+			t.Fatalf("bad: %#v, %v", resp, err)
+		}
+		return resp.Data["latest_version"].(int)
+	}
+
+	resp, err := doReq(logical.ReadOperation, "config/schedule", nil)
+	if err != nil || resp == nil || resp.Data["auto_rotate"] == nil {
+		t.Fatalf("expected the rotation to be scheduled: %#v, %v", resp, err)
+	}
+
+	if _, err := doReq(logical.UpdateOperation, "keys/foo", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err = doReq(logical.UpdateOperation, "keys/foo/config", map[string]interface{}{
+		"auto_rotate_period": "30m",
+	})
+	if err != nil || resp == nil || !resp.IsError() {
+		t.Fatalf("expected period shorter than an hour to be rejected: %#v, %v", resp, err)
+	}
+
+	resp, err = doReq(logical.UpdateOperation, "keys/foo/config", map[string]interface{}{
+		"auto_rotate_period": "1h",
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: %#v, %v", resp, err)
+	}
+	resp, err = doReq(logical.ReadOperation, "keys/foo", nil)
+	if err != nil || resp.Data["auto_rotate_period"] != int64(3600) {
+		t.Fatalf("bad: %#v, %v", resp, err)
+	}
+
+	// Not due yet
+	rollback()
+	if v := latestVersion(); v != 1 {
+		t.Fatalf("expected no rotation, got version %d", v)
+	}
+
+	// Age the latest version past the period
+	p, _, err := b.lm.GetPolicy(context.Background(), keysutil.PolicyRequest{
+		Storage: storage,
+		Name:    "foo",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := p.Keys["1"]
+	entry.CreationTime = time.Now().Add(-2 * time.Hour)
+	p.Keys["1"] = entry
+	if err := p.Persist(context.Background(), storage); err != nil {
+		t.Fatal(err)
+	}
+
+	rollback()
+	if v := latestVersion(); v != 2 {
+		t.Fatalf("expected rotation, got version %d", v)
+	}
+	rollback()
+	if v := latestVersion(); v != 2 {
+		t.Fatalf("expected no further rotation, got version %d", v)
+	}
+
+	// Disabling stops rotation
+	resp, err = doReq(logical.UpdateOperation, "keys/foo/config", map[string]interface{}{
+		"auto_rotate_period": 0,
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: %#v, %v", resp, err)
+	}
+	entry = p.Keys["2"]
+	entry.CreationTime = time.Now().Add(-2 * time.Hour)
+	p.Keys["2"] = entry
+	rollback()
+	if v := latestVersion(); v != 2 {
+		t.Fatalf("expected no rotation, got version %d", v)
+	}
+}
+
+func TestTransit_ConvergentMixedMode(t *testing.T) {
+	b, storage := createBackendWithSysView(t)
+
+	doReq := func(path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Storage:   storage,
+			Operation: logical.UpdateOperation,
+			Path:      path,
+			Data:      data,
+		})
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("bad: %#v, %v", resp, err)
+		}
+		return resp
+	}
+	doErrReq := func(path string, data map[string]interface{}) {
+		t.Helper()
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Storage:   storage,
+			Operation: logical.UpdateOperation,
+			Path:      path,
+			Data:      data,
+		})
+		if err == nil && (resp == nil || !resp.IsError()) {
+			t.Fatalf("expected error, got %#v", resp)
+		}
+	}
+
+	plaintext := base64.StdEncoding.EncodeToString([]byte(testPlaintext))
+	keyContext := base64.StdEncoding.EncodeToString([]byte("context"))
+	encrypt := func(name string, data map[string]interface{}) string {
+		t.Helper()
+		data["plaintext"] = plaintext
+		data["context"] = keyContext
+		return doReq("encrypt/"+name, data).Data["ciphertext"].(string)
+	}
+	decrypt := func(name, ciphertext string, data map[string]interface{}) {
+		t.Helper()
+		data["ciphertext"] = ciphertext
+		data["context"] = keyContext
+		if resp := doReq("decrypt/"+name, data); resp.Data["plaintext"] != plaintext {
+			t.Fatalf("bad plaintext: %#v", resp.Data)
+		}
+	}
+
+	// Convergent encryption requires derivation
+	doReq("keys/plain", nil)
+	doErrReq("keys/plain/config", map[string]interface{}{
+		"convergent_encryption": true,
+	})
+
+	doReq("keys/mixed", map[string]interface{}{
+		"derived": true,
+	})
+	old := encrypt("mixed", map[string]interface{}{})
+	if encrypt("mixed", map[string]interface{}{}) == old {
+		t.Fatal("expected random nonces before enabling convergent encryption")
+	}
+
+	doReq("keys/mixed/config", map[string]interface{}{
+		"convergent_encryption": true,
+	})
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Storage:   storage,
+		Operation: logical.ReadOperation,
+		Path:      "keys/mixed",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Data["latest_version"] != 2 || resp.Data["convergent_encryption"] != true || resp.Data["convergent_encryption_start_version"] != 2 {
+		t.Fatalf("bad key: %#v", resp.Data)
+	}
+
+	// New versions are convergent without requiring a nonce, older ones aren't
+	ciphertext := encrypt("mixed", map[string]interface{}{})
+	if !strings.HasPrefix(ciphertext, "vault:v2:") || encrypt("mixed", map[string]interface{}{}) != ciphertext {
+		t.Fatalf("expected convergent ciphertexts, got %q", ciphertext)
+	}
+	if encrypt("mixed", map[string]interface{}{"key_version": 1}) == encrypt("mixed", map[string]interface{}{"key_version": 1}) {
+		t.Fatal("expected random nonces for versions created before enabling convergent encryption")
+	}
+	decrypt("mixed", old, map[string]interface{}{})
+	decrypt("mixed", ciphertext, map[string]interface{}{})
+
+	// Enabling again is a no-op and disabling isn't allowed
+	doReq("keys/mixed/config", map[string]interface{}{
+		"convergent_encryption": true,
+	})
+	doErrReq("keys/mixed/config", map[string]interface{}{
+		"convergent_encryption": false,
+	})
+
+	// Keys using the first convergent version, which requires nonces, are
+	// upgraded for new versions
+	doReq("keys/legacy", map[string]interface{}{
+		"derived":               true,
+		"convergent_encryption": true,
+	})
+	p, _, err := b.lm.GetPolicy(context.Background(), keysutil.PolicyRequest{
+		Storage: storage,
+		Name:    "legacy",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.ConvergentVersion = 1
+	entry := p.Keys["1"]
+	entry.ConvergentVersion = 0
+	p.Keys["1"] = entry
+	if err := p.Persist(context.Background(), storage); err != nil {
+		t.Fatal(err)
+	}
+
+	nonce := base64.StdEncoding.EncodeToString([]byte("123456789012"))
+	old = encrypt("legacy", map[string]interface{}{"nonce": nonce})
+	doReq("keys/legacy/config", map[string]interface{}{
+		"convergent_encryption": true,
+	})
+	ciphertext = encrypt("legacy", map[string]interface{}{})
+	if !strings.HasPrefix(ciphertext, "vault:v2:") || encrypt("legacy", map[string]interface{}{}) != ciphertext {
+		t.Fatalf("expected convergent ciphertexts, got %q", ciphertext)
+	}
+	decrypt("legacy", old, map[string]interface{}{"nonce": nonce})
+	decrypt("legacy", ciphertext, map[string]interface{}{})
+}
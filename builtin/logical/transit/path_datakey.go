@@ -145,6 +145,7 @@ func (b *backend) pathDatakeyWrite(ctx context.Context, req *logical.Request, d
 	if ciphertext == "" {
 		return nil, fmt.Errorf("empty ciphertext returned")
 	}
+	b.usage.record(p.Name, prefixedVersion(ciphertext), usageEncrypt)
 
 	// Generate the response
 	resp := &logical.Response{
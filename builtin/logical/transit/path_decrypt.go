@@ -143,6 +143,7 @@ func (b *backend) pathDecryptWrite(ctx context.Context, req *logical.Request, d
 			}
 		}
 		batchResponseItems[i].Plaintext = plaintext
+		b.usage.record(p.Name, prefixedVersion(item.Ciphertext), usageDecrypt)
 	}
 
 	resp := &logical.Response{}
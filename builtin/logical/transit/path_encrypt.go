@@ -280,6 +280,7 @@ func (b *backend) pathEncryptWrite(ctx context.Context, req *logical.Request, d
 		}
 
 		batchResponseItems[i].Ciphertext = ciphertext
+		b.usage.record(p.Name, prefixedVersion(ciphertext), usageEncrypt)
 	}
 
 	resp := &logical.Response{}
@@ -135,6 +135,7 @@ func (b *backend) pathHMACWrite(ctx context.Context, req *logical.Request, d *fr
 
 	retStr := base64.StdEncoding.EncodeToString(retBytes)
 	retStr = fmt.Sprintf("vault:v%s:%s", strconv.Itoa(ver), retStr)
+	b.usage.record(p.Name, ver, usageHMAC)
 
 	// Generate the response
 	resp := &logical.Response{
@@ -231,6 +232,7 @@ func (b *backend) pathHMACVerify(ctx context.Context, req *logical.Request, d *f
 	}
 	hf.Write(input)
 	retBytes := hf.Sum(nil)
+	b.usage.record(p.Name, ver, usageHMAC)
 
 	p.Unlock()
 	return &logical.Response{
@@ -0,0 +1,345 @@
+package transit
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"strconv"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/helper/errutil"
+	"github.com/hashicorp/vault/helper/keysutil"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+const (
+	// wrappingKeyName is the name of the RSA key used to wrap the ephemeral
+	// AES keys protecting imported key material.
+	wrappingKeyName = "wrapping-key"
+
+	// wrappingKeyStoragePrefix keeps the wrapping key apart from the keys
+	// managed through the keys/ endpoints.
+	wrappingKeyStoragePrefix = "import/"
+)
+
+func (b *backend) pathWrappingKey() *framework.Path {
+	return &framework.Path{
+		Pattern: "wrapping_key",
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation: b.pathWrappingKeyRead,
+		},
+
+		HelpSynopsis:    pathWrappingKeyHelpSyn,
+		HelpDescription: pathWrappingKeyHelpDesc,
+	}
+}
+
+func (b *backend) pathImport() *framework.Path {
+	return &framework.Path{
+		Pattern: "keys/" + framework.GenericNameRegex("name") + "/import",
+		Fields: map[string]*framework.FieldSchema{
+			"name": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Name of the key",
+			},
+
+			"type": &framework.FieldSchema{
+				Type:    framework.TypeString,
+				Default: "aes256-gcm96",
+				Description: `
+The type of key being imported. Currently, "aes256-gcm96" (symmetric),
+"chacha20-poly1305" (symmetric), "ecdsa-p256" (asymmetric), 'ed25519'
+(asymmetric), 'rsa-2048' (asymmetric), 'rsa-4096' (asymmetric) are supported.
+Defaults to "aes256-gcm96".
+`,
+			},
+
+			"ciphertext": &framework.FieldSchema{
+				Type: framework.TypeString,
+				Description: `Base64 encoded wrapped key material. This is an
+ephemeral AES-256 key encrypted with RSA-OAEP using the
+wrapping key, followed by the key material wrapped with
+the ephemeral key using AES key wrap with padding
+(RFC 5649). Symmetric keys are given as raw bytes and
+asymmetric keys as PKCS#8 DER encoded private keys.`,
+			},
+
+			"hash_function": &framework.FieldSchema{
+				Type:          framework.TypeString,
+				Default:       "sha2-256",
+				AllowedValues: []interface{}{"sha1", "sha2-224", "sha2-256", "sha2-384", "sha2-512"},
+				Description: `The hash function used for RSA-OAEP when wrapping
+the ephemeral AES key. Defaults to "sha2-256".`,
+			},
+
+			"derived": &framework.FieldSchema{
+				Type: framework.TypeBool,
+				Description: `Enables key derivation mode. This
+allows for per-transaction unique
+keys for encryption operations.`,
+			},
+
+			"exportable": &framework.FieldSchema{
+				Type: framework.TypeBool,
+				Description: `Enables keys to be exportable.
+This allows for all the valid keys
+in the key ring to be exported.`,
+			},
+
+			"allow_plaintext_backup": &framework.FieldSchema{
+				Type: framework.TypeBool,
+				Description: `Enables taking a backup of the named
+key in plaintext format. Once set,
+this cannot be disabled.`,
+			},
+
+			"allow_rotation": &framework.FieldSchema{
+				Type: framework.TypeBool,
+				Description: `Allows the imported key to be rotated,
+which adds a version generated by Vault.
+By default only import_version can add
+new versions to an imported key.`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathImportWrite,
+		},
+
+		HelpSynopsis:    pathImportHelpSyn,
+		HelpDescription: pathImportHelpDesc,
+	}
+}
+
+func (b *backend) pathImportVersion() *framework.Path {
+	return &framework.Path{
+		Pattern: "keys/" + framework.GenericNameRegex("name") + "/import_version",
+		Fields: map[string]*framework.FieldSchema{
+			"name": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Name of the key",
+			},
+
+			"ciphertext": &framework.FieldSchema{
+				Type: framework.TypeString,
+				Description: `Base64 encoded wrapped key material, in the
+same format as for the import endpoint.`,
+			},
+
+			"hash_function": &framework.FieldSchema{
+				Type:          framework.TypeString,
+				Default:       "sha2-256",
+				AllowedValues: []interface{}{"sha1", "sha2-224", "sha2-256", "sha2-384", "sha2-512"},
+				Description: `The hash function used for RSA-OAEP when wrapping
+the ephemeral AES key. Defaults to "sha2-256".`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathImportVersionWrite,
+		},
+
+		HelpSynopsis:    pathImportVersionHelpSyn,
+		HelpDescription: pathImportVersionHelpDesc,
+	}
+}
+
+func (b *backend) pathWrappingKeyRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	wrappingKey, err := b.getWrappingKey(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	derBytes, err := x509.MarshalPKIXPublicKey(&wrappingKey.PublicKey)
+	if err != nil {
+		return nil, errwrap.Wrapf("error marshaling wrapping key: {{err}}", err)
+	}
+	pemBytes := pem.EncodeToMemory(&pem.Block{
+		Type:  "PUBLIC KEY",
+		Bytes: derBytes,
+	})
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"public_key": string(pemBytes),
+		},
+	}, nil
+}
+
+func (b *backend) pathImportWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+	keyType := d.Get("type").(string)
+
+	polReq := keysutil.PolicyRequest{
+		Storage:                  req.Storage,
+		Name:                     name,
+		Derived:                  d.Get("derived").(bool),
+		Exportable:               d.Get("exportable").(bool),
+		AllowPlaintextBackup:     d.Get("allow_plaintext_backup").(bool),
+		AllowImportedKeyRotation: d.Get("allow_rotation").(bool),
+	}
+	var ok bool
+	polReq.KeyType, ok = parseKeyType(keyType)
+	if !ok {
+		return logical.ErrorResponse(fmt.Sprintf("unknown key type %v", keyType)), logical.ErrInvalidRequest
+	}
+
+	key, err := b.unwrapImportedKey(ctx, req.Storage, d)
+	if err != nil {
+		if _, ok := err.(errutil.UserError); ok {
+			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+		}
+		return nil, err
+	}
+
+	if err := b.lm.ImportPolicy(ctx, polReq, key); err != nil {
+		if _, ok := err.(errutil.UserError); ok {
+			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+		}
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+func (b *backend) pathImportVersionWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	p, _, err := b.lm.GetPolicy(ctx, keysutil.PolicyRequest{
+		Storage: req.Storage,
+		Name:    name,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return logical.ErrorResponse("key not found"), logical.ErrInvalidRequest
+	}
+	if !b.System().CachingDisabled() {
+		p.Lock(true)
+	}
+	defer p.Unlock()
+
+	if !p.Imported {
+		return logical.ErrorResponse("versions can only be imported into keys that were imported"), logical.ErrInvalidRequest
+	}
+
+	key, err := b.unwrapImportedKey(ctx, req.Storage, d)
+	if err == nil {
+		err = p.Import(ctx, req.Storage, key)
+	}
+	if err != nil {
+		if _, ok := err.(errutil.UserError); ok {
+			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+		}
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+// unwrapImportedKey decrypts the key material in the ciphertext field of the
+// request. The first part of the ciphertext is an ephemeral AES key encrypted
+// with RSA-OAEP using the wrapping key, the rest is the key material wrapped
+// with that AES key.
+func (b *backend) unwrapImportedKey(ctx context.Context, s logical.Storage, d *framework.FieldData) ([]byte, error) {
+	ciphertextB64 := d.Get("ciphertext").(string)
+	if ciphertextB64 == "" {
+		return nil, errutil.UserError{Err: "missing ciphertext"}
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(ciphertextB64)
+	if err != nil {
+		return nil, errutil.UserError{Err: "failed to base64-decode ciphertext"}
+	}
+
+	hashFunc, ok := keysutil.HashFuncMap[keysutil.HashTypeMap[d.Get("hash_function").(string)]]
+	if !ok {
+		return nil, errutil.UserError{Err: "unsupported hash function"}
+	}
+
+	wrappingKey, err := b.getWrappingKey(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+
+	wrappedKeySize := wrappingKey.Size()
+	if len(ciphertext) <= wrappedKeySize {
+		return nil, errutil.UserError{Err: "ciphertext is too short to contain wrapped key material"}
+	}
+
+	ephemeralKey, err := rsa.DecryptOAEP(hashFunc(), rand.Reader, wrappingKey, ciphertext[:wrappedKeySize], nil)
+	if err != nil {
+		return nil, errutil.UserError{Err: "failed to decrypt ephemeral key with the wrapping key"}
+	}
+	if len(ephemeralKey) != 32 {
+		return nil, errutil.UserError{Err: "ephemeral key must be a 256-bit AES key"}
+	}
+
+	key, err := keysutil.UnwrapKeyWithPadding(ephemeralKey, ciphertext[wrappedKeySize:])
+	if err != nil {
+		return nil, errutil.UserError{Err: err.Error()}
+	}
+
+	return key, nil
+}
+
+// getWrappingKey returns the RSA key used to wrap key material for import,
+// generating it on first use.
+func (b *backend) getWrappingKey(ctx context.Context, s logical.Storage) (*rsa.PrivateKey, error) {
+	b.wrappingKeyLock.Lock()
+	defer b.wrappingKeyLock.Unlock()
+
+	p, err := keysutil.LoadPolicy(ctx, s, wrappingKeyStoragePrefix+"policy/"+wrappingKeyName)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		p = keysutil.NewPolicy(keysutil.PolicyConfig{
+			Name:          wrappingKeyName,
+			Type:          keysutil.KeyType_RSA4096,
+			StoragePrefix: wrappingKeyStoragePrefix,
+		})
+		if err := p.Rotate(ctx, s); err != nil {
+			return nil, errwrap.Wrapf("error generating wrapping key: {{err}}", err)
+		}
+	}
+
+	entry, ok := p.Keys[strconv.Itoa(p.LatestVersion)]
+	if !ok || entry.RSAKey == nil {
+		return nil, fmt.Errorf("wrapping key not found")
+	}
+
+	return entry.RSAKey, nil
+}
+
+const pathWrappingKeyHelpSyn = `Returns the public key to use for wrapping imported keys`
+
+const pathWrappingKeyHelpDesc = `
+This path is used to retrieve the RSA-4096 wrapping key for wrapping keys
+that are being imported into transit. The key is generated on first use.
+`
+
+const pathImportHelpSyn = `Imports an externally-generated key into a new transit key`
+
+const pathImportHelpDesc = `
+This path is used to import an externally-generated key into Vault. The key
+material must be wrapped with an ephemeral AES-256 key using AES key wrap with
+padding (RFC 5649), and the ephemeral key must be encrypted with RSA-OAEP
+using the public key returned by the wrapping_key endpoint. The two are
+concatenated, wrapped ephemeral key first, and base64 encoded. This way the
+key material never transits in plaintext.
+`
+
+const pathImportVersionHelpSyn = `Imports an externally-generated key into an existing imported key`
+
+const pathImportVersionHelpDesc = `
+This path is used to import a new version of an externally-generated key
+into an existing imported key. The key material is wrapped in the same way as
+for the import endpoint.
+`
@@ -0,0 +1,193 @@
+package transit
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/helper/keysutil"
+	"github.com/hashicorp/vault/logical"
+)
+
+func TestTransit_Import(t *testing.T) {
+	b, s := createBackendWithStorage(t)
+
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Storage:   s,
+		Operation: logical.ReadOperation,
+		Path:      "wrapping_key",
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: err: %v\nresp: %#v", err, resp)
+	}
+	block, _ := pem.Decode([]byte(resp.Data["public_key"].(string)))
+	if block == nil {
+		t.Fatal("failed to decode wrapping key")
+	}
+	parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wrappingKey := parsed.(*rsa.PublicKey)
+
+	wrap := func(key []byte) string {
+		ephemeralKey := make([]byte, 32)
+		if _, err := rand.Read(ephemeralKey); err != nil {
+			t.Fatal(err)
+		}
+		wrappedEphemeralKey, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, wrappingKey, ephemeralKey, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		wrappedKey, err := keysutil.WrapKeyWithPadding(ephemeralKey, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return base64.StdEncoding.EncodeToString(append(wrappedEphemeralKey, wrappedKey...))
+	}
+
+	doReq := func(path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Storage:   s,
+			Operation: logical.UpdateOperation,
+			Path:      path,
+			Data:      data,
+		})
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("bad: path: %s\nerr: %v\nresp: %#v", path, err, resp)
+		}
+		return resp
+	}
+
+	doErrReq := func(path string, data map[string]interface{}) {
+		t.Helper()
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Storage:   s,
+			Operation: logical.UpdateOperation,
+			Path:      path,
+			Data:      data,
+		})
+		if err == nil && (resp == nil || !resp.IsError()) {
+			t.Fatalf("expected error; path: %s", path)
+		}
+	}
+
+	// Import an AES key and decrypt a transit ciphertext with it locally
+	aesKey := make([]byte, 32)
+	if _, err := rand.Read(aesKey); err != nil {
+		t.Fatal(err)
+	}
+	doReq("keys/aes/import", map[string]interface{}{
+		"ciphertext": wrap(aesKey),
+	})
+
+	plaintext := base64.StdEncoding.EncodeToString([]byte("the quick brown fox"))
+	resp = doReq("encrypt/aes", map[string]interface{}{
+		"plaintext": plaintext,
+	})
+	ciphertext, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(resp.Data["ciphertext"].(string), "vault:v1:"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	aesCipher, err := aes.NewCipher(aesKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gcm, err := cipher.NewGCM(aesCipher)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decrypted, err := gcm.Open(nil, ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():], nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(decrypted) != "the quick brown fox" {
+		t.Fatalf("bad: decrypted plaintext: %q", decrypted)
+	}
+
+	// The key cannot be imported again or rotated
+	doErrReq("keys/aes/import", map[string]interface{}{
+		"ciphertext": wrap(aesKey),
+	})
+	doErrReq("keys/aes/rotate", nil)
+
+	// Wrong key size and tampered ciphertext are rejected
+	doErrReq("keys/short/import", map[string]interface{}{
+		"ciphertext": wrap(aesKey[:16]),
+	})
+	tampered, _ := base64.StdEncoding.DecodeString(wrap(aesKey))
+	tampered[len(tampered)-1] ^= 0x01
+	doErrReq("keys/tampered/import", map[string]interface{}{
+		"ciphertext": base64.StdEncoding.EncodeToString(tampered),
+	})
+	doErrReq("keys/aes/import_version", map[string]interface{}{
+		"ciphertext":    wrap(aesKey),
+		"hash_function": "md5",
+	})
+
+	// Import a new version
+	doReq("keys/aes/import_version", map[string]interface{}{
+		"ciphertext": wrap(aesKey),
+	})
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Storage:   s,
+		Operation: logical.ReadOperation,
+		Path:      "keys/aes",
+	})
+	if err != nil || resp == nil {
+		t.Fatalf("bad: err: %v\nresp: %#v", err, resp)
+	}
+	if resp.Data["latest_version"] != 2 || resp.Data["imported_key"] != true {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	// Versions cannot be imported into keys generated by Vault
+	doReq("keys/generated", nil)
+	doErrReq("keys/generated/import_version", map[string]interface{}{
+		"ciphertext": wrap(aesKey),
+	})
+
+	// Import an ed25519 key and verify a transit signature with it locally
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doReq("keys/ed/import", map[string]interface{}{
+		"type":           "ed25519",
+		"ciphertext":     wrap(der),
+		"allow_rotation": true,
+	})
+	resp = doReq("sign/ed", map[string]interface{}{
+		"input": plaintext,
+	})
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(resp.Data["signature"].(string), "vault:v1:"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ed25519.Verify(pub, []byte("the quick brown fox"), sig) {
+		t.Fatal("signature did not verify with the imported key")
+	}
+
+	// Rotation was allowed on import
+	doReq("keys/ed/rotate", nil)
+
+	// Key material not matching the type is rejected
+	doErrReq("keys/ecdsa/import", map[string]interface{}{
+		"type":       "ecdsa-p256",
+		"ciphertext": wrap(der),
+	})
+}
@@ -21,7 +21,7 @@ func (b *backend) pathRestore() *framework.Path {
 			},
 			"force": &framework.FieldSchema{
 				Type:        framework.TypeBool,
-				Description: "If set and a key by the given name exists, force the restore operation and override the key.",
+				Description: "If set and a key by the given name exists, force the restore operation and override the key. The backup must be of the same key, and at least as recent.",
 				Default:     false,
 			},
 		},
@@ -46,4 +46,7 @@ func (b *backend) pathRestoreUpdate(ctx context.Context, req *logical.Request, d
 }
 
 const pathRestoreHelpSyn = `Restore the named key`
-const pathRestoreHelpDesc = `This path is used to restore the named key.`
+const pathRestoreHelpDesc = `This path is used to restore the named key.
+
+The integrity of the backup is verified before it is restored. Backups taken
+by older versions of Vault have no integrity check and are restored as is.`
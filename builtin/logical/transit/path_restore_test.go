@@ -2,7 +2,10 @@ package transit
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/hashicorp/vault/helper/testhelpers"
@@ -193,3 +196,118 @@ func TestTransit_Restore(t *testing.T) {
 		})
 	}
 }
+
+func TestTransit_Restore_Integrity(t *testing.T) {
+	b, s := createBackendWithStorage(t)
+
+	request := func(op logical.Operation, path string, data map[string]interface{}) (*logical.Response, error) {
+		return b.HandleRequest(context.Background(), &logical.Request{
+			Path:      path,
+			Operation: op,
+			Storage:   s,
+			Data:      data,
+		})
+	}
+	mustRequest := func(op logical.Operation, path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := request(op, path, data)
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("resp: %#v\nerr: %v", resp, err)
+		}
+		return resp
+	}
+	createKey := func(name string) {
+		t.Helper()
+		mustRequest(logical.UpdateOperation, "keys/"+name, map[string]interface{}{
+			"exportable": true,
+		})
+		mustRequest(logical.UpdateOperation, "keys/"+name+"/config", map[string]interface{}{
+			"deletion_allowed":       true,
+			"allow_plaintext_backup": true,
+		})
+	}
+	backup := func(name string) string {
+		t.Helper()
+		return mustRequest(logical.ReadOperation, "backup/"+name, nil).Data["backup"].(string)
+	}
+	restore := func(name, backup string, force bool) error {
+		_, err := request(logical.UpdateOperation, "restore/"+name, map[string]interface{}{
+			"backup": backup,
+			"force":  force,
+		})
+		return err
+	}
+
+	createKey("my-key")
+	oldBackup := backup("my-key")
+
+	mustRequest(logical.UpdateOperation, "keys/my-key/rotate", nil)
+	mustRequest(logical.UpdateOperation, "keys/my-key/rotate", nil)
+	mustRequest(logical.UpdateOperation, "keys/my-key/config", map[string]interface{}{
+		"min_decryption_version": 2,
+		"auto_rotate_period":     "24h",
+	})
+	newBackup := backup("my-key")
+
+	// The configuration of the key is restored
+	if err := restore("restored", newBackup, false); err != nil {
+		t.Fatal(err)
+	}
+	resp := mustRequest(logical.ReadOperation, "keys/restored", nil)
+	if resp.Data["latest_version"] != 3 || resp.Data["min_decryption_version"] != 2 {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+	if resp.Data["auto_rotate_period"] != int64(24*60*60) {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	// Edited backups are rejected
+	envelopeBytes, err := base64.StdEncoding.DecodeString(newBackup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var envelope map[string]json.RawMessage
+	if err := json.Unmarshal(envelopeBytes, &envelope); err != nil {
+		t.Fatal(err)
+	}
+	keyData := string(envelope["key_data"])
+	if !strings.Contains(keyData, `"min_decryption_version":2`) {
+		t.Fatalf("bad: %s", keyData)
+	}
+	envelope["key_data"] = json.RawMessage(strings.Replace(keyData, `"min_decryption_version":2`, `"min_decryption_version":1`, 1))
+	tampered, err := json.Marshal(envelope)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = restore("tampered", base64.StdEncoding.EncodeToString(tampered), false)
+	if err == nil || !strings.Contains(err.Error(), "integrity check failed") {
+		t.Fatalf("expected the backup to be rejected: %v", err)
+	}
+
+	// Backups without an integrity check can still be restored
+	legacy := base64.StdEncoding.EncodeToString(envelope["key_data"])
+	if err := restore("legacy", legacy, false); err != nil {
+		t.Fatal(err)
+	}
+
+	// Forcing a restore can't lose versions of the existing key
+	if err := restore("my-key", oldBackup, true); err == nil {
+		t.Fatal("expected an older backup not to be restored over the key")
+	}
+
+	// nor overwrite a different key
+	createKey("other-key")
+	if err := restore("other-key", newBackup, true); err == nil {
+		t.Fatal("expected a backup of a different key not to be restored over the key")
+	}
+
+	// Restoring a newer backup of the same key is allowed
+	mustRequest(logical.UpdateOperation, "keys/restored/rotate", nil)
+	if err := restore("my-key", backup("restored"), true); err != nil {
+		t.Fatal(err)
+	}
+	resp = mustRequest(logical.ReadOperation, "keys/my-key", nil)
+	if resp.Data["latest_version"] != 4 {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+}
@@ -145,6 +145,7 @@ func (b *backend) pathRewrapWrite(ctx context.Context, req *logical.Request, d *
 				return nil, err
 			}
 		}
+		b.usage.record(p.Name, prefixedVersion(item.Ciphertext), usageDecrypt)
 
 		ciphertext, err := p.Encrypt(item.KeyVersion, item.DecodedContext, item.DecodedNonce, plaintext)
 		if err != nil {
@@ -167,6 +168,7 @@ func (b *backend) pathRewrapWrite(ctx context.Context, req *logical.Request, d *
 		}
 
 		batchResponseItems[i].Ciphertext = ciphertext
+		b.usage.record(p.Name, prefixedVersion(ciphertext), usageEncrypt)
 	}
 
 	resp := &logical.Response{}
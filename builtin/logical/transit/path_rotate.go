@@ -2,7 +2,11 @@ package transit
 
 import (
 	"context"
+	"strconv"
+	"time"
 
+	"github.com/hashicorp/errwrap"
+	multierror "github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/vault/helper/keysutil"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
@@ -45,6 +49,11 @@ func (b *backend) pathRotateWrite(ctx context.Context, req *logical.Request, d *
 		p.Lock(true)
 	}
 
+	if p.Imported && !p.AllowImportedKeyRotation {
+		p.Unlock()
+		return logical.ErrorResponse("imported key does not allow rotation, use import_version to add a new version"), logical.ErrInvalidRequest
+	}
+
 	// Rotate the policy
 	err = p.Rotate(ctx, req.Storage)
 
@@ -52,6 +61,66 @@ func (b *backend) pathRotateWrite(ctx context.Context, req *logical.Request, d *
 	return nil, err
 }
 
+// minAutoRotatePeriod is the smallest auto_rotate_period a key can be
+// configured with
+const minAutoRotatePeriod = time.Hour
+
+// autoRotateKeys rotates every key whose latest version is older than its
+// auto_rotate_period
+func (b *backend) autoRotateKeys(ctx context.Context, req *logical.Request) error {
+	names, err := req.Storage.List(ctx, "policy/")
+	if err != nil {
+		return err
+	}
+
+	var merr *multierror.Error
+	for _, name := range names {
+		if err := b.autoRotateKey(ctx, req.Storage, name); err != nil {
+			merr = multierror.Append(merr, errwrap.Wrapf("error rotating key "+name+": {{err}}", err))
+		}
+	}
+
+	return merr.ErrorOrNil()
+}
+
+func (b *backend) autoRotateKey(ctx context.Context, storage logical.Storage, name string) error {
+	p, _, err := b.lm.GetPolicy(ctx, keysutil.PolicyRequest{
+		Storage: storage,
+		Name:    name,
+	})
+	if err != nil {
+		return err
+	}
+	if p == nil {
+		return nil
+	}
+	if !b.System().CachingDisabled() {
+		p.Lock(true)
+	}
+	defer p.Unlock()
+
+	if p.AutoRotatePeriod <= 0 || (p.Imported && !p.AllowImportedKeyRotation) {
+		return nil
+	}
+
+	latest, ok := p.Keys[strconv.Itoa(p.LatestVersion)]
+	if !ok {
+		return nil
+	}
+	created := latest.CreationTime
+	if created.IsZero() {
+		created = time.Unix(latest.DeprecatedCreationTime, 0)
+	}
+	if time.Since(created) < p.AutoRotatePeriod {
+		return nil
+	}
+
+	if b.Logger().IsDebug() {
+		b.Logger().Debug("automatically rotating key", "name", name)
+	}
+	return p.Rotate(ctx, storage)
+}
+
 const pathRotateHelpSyn = `Rotate named encryption key`
 
 const pathRotateHelpDesc = `
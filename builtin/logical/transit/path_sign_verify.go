@@ -66,7 +66,14 @@ to the min_encryption_version configured on the key.`,
 
 			"prehashed": {
 				Type:        framework.TypeBool,
-				Description: `Set to 'true' when the input is already hashed. If the key type is 'rsa-2048' or 'rsa-4096', then the algorithm used to hash the input should be indicated by the 'algorithm' parameter.`,
+				Description: `Set to 'true' when the input is already hashed. If the key type is 'rsa-2048' or 'rsa-4096', then the algorithm used to hash the input should be indicated by the 'algorithm' parameter. If the key type is 'ed25519', the input must be a SHA-512 digest and the Ed25519ph variant is used.`,
+			},
+
+			"signature_context": {
+				Type: framework.TypeString,
+				Description: `Base64 encoded context bound into the signature (Ed25519ctx, or Ed25519ph when
+'prehashed' is set). Must be at most 255 bytes; only valid for ed25519 keys. This is
+unrelated to the key derivation 'context'.`,
 			},
 
 			"signature_algorithm": {
@@ -75,6 +82,12 @@ to the min_encryption_version configured on the key.`,
 Options are 'pss' or 'pkcs1v15'. Defaults to 'pss'`,
 			},
 
+			"deterministic": {
+				Type: framework.TypeBool,
+				Description: `Set to 'true' to produce deterministic ECDSA signatures as described in RFC 6979
+instead of randomized ones. Only valid for ECDSA P-256 key types.`,
+			},
+
 			"marshaling_algorithm": {
 				Type:        framework.TypeString,
 				Default:     "asn1",
@@ -148,7 +161,14 @@ Defaults to "sha2-256". Not valid for all key types.`,
 
 			"prehashed": {
 				Type:        framework.TypeBool,
-				Description: `Set to 'true' when the input is already hashed. If the key type is 'rsa-2048' or 'rsa-4096', then the algorithm used to hash the input should be indicated by the 'algorithm' parameter.`,
+				Description: `Set to 'true' when the input is already hashed. If the key type is 'rsa-2048' or 'rsa-4096', then the algorithm used to hash the input should be indicated by the 'algorithm' parameter. If the key type is 'ed25519', the input must be a SHA-512 digest and the Ed25519ph variant is used.`,
+			},
+
+			"signature_context": {
+				Type: framework.TypeString,
+				Description: `Base64 encoded context bound into the signature (Ed25519ctx, or Ed25519ph when
+'prehashed' is set). Must be at most 255 bytes; only valid for ed25519 keys. This is
+unrelated to the key derivation 'context'.`,
 			},
 
 			"signature_algorithm": {
@@ -204,6 +224,11 @@ func (b *backend) pathSignWrite(ctx context.Context, req *logical.Request, d *fr
 		return logical.ErrorResponse(fmt.Sprintf("unable to decode input as base64: %s", err)), logical.ErrInvalidRequest
 	}
 
+	sigContext, err := base64.StdEncoding.DecodeString(d.Get("signature_context").(string))
+	if err != nil {
+		return logical.ErrorResponse("failed to base64-decode signature context"), logical.ErrInvalidRequest
+	}
+
 	// Get the policy
 	p, _, err := b.lm.GetPolicy(ctx, keysutil.PolicyRequest{
 		Storage: req.Storage,
@@ -240,9 +265,19 @@ func (b *backend) pathSignWrite(ctx context.Context, req *logical.Request, d *fr
 		input = hf.Sum(nil)
 	}
 
-	sig, err := p.Sign(ver, context, input, hashAlgorithm, sigAlgorithm, marshaling)
+	sig, err := p.SignWithOptions(ver, context, input, &keysutil.SigningOptions{
+		HashAlgorithm: hashAlgorithm,
+		Marshaling:    marshaling,
+		SigAlgorithm:  sigAlgorithm,
+		Prehashed:     prehashed,
+		SigContext:    sigContext,
+		Deterministic: d.Get("deterministic").(bool),
+	})
 	if err != nil {
 		p.Unlock()
+		if _, ok := err.(errutil.UserError); ok {
+			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+		}
 		return nil, err
 	}
 	if sig == nil {
@@ -250,6 +285,8 @@ func (b *backend) pathSignWrite(ctx context.Context, req *logical.Request, d *fr
 		return nil, fmt.Errorf("signature could not be computed")
 	}
 
+	b.usage.record(p.Name, prefixedVersion(sig.Signature), usageSign)
+
 	// Generate the response
 	resp := &logical.Response{
 		Data: map[string]interface{}{
@@ -308,6 +345,11 @@ func (b *backend) pathVerifyWrite(ctx context.Context, req *logical.Request, d *
 		return logical.ErrorResponse(fmt.Sprintf("unable to decode input as base64: %s", err)), logical.ErrInvalidRequest
 	}
 
+	sigContext, err := base64.StdEncoding.DecodeString(d.Get("signature_context").(string))
+	if err != nil {
+		return logical.ErrorResponse("failed to base64-decode signature context"), logical.ErrInvalidRequest
+	}
+
 	// Get the policy
 	p, _, err := b.lm.GetPolicy(ctx, keysutil.PolicyRequest{
 		Storage: req.Storage,
@@ -344,7 +386,13 @@ func (b *backend) pathVerifyWrite(ctx context.Context, req *logical.Request, d *
 		input = hf.Sum(nil)
 	}
 
-	valid, err := p.VerifySignature(context, input, hashAlgorithm, sigAlgorithm, marshaling, sig)
+	valid, err := p.VerifySignatureWithOptions(context, input, sig, &keysutil.SigningOptions{
+		HashAlgorithm: hashAlgorithm,
+		Marshaling:    marshaling,
+		SigAlgorithm:  sigAlgorithm,
+		Prehashed:     prehashed,
+		SigContext:    sigContext,
+	})
 	if err != nil {
 		switch err.(type) {
 		case errutil.UserError:
@@ -359,6 +407,8 @@ func (b *backend) pathVerifyWrite(ctx context.Context, req *logical.Request, d *
 		}
 	}
 
+	b.usage.record(p.Name, prefixedVersion(sig), usageVerify)
+
 	// Generate the response
 	resp := &logical.Response{
 		Data: map[string]interface{}{
@@ -2,13 +2,15 @@ package transit
 
 import (
 	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/sha512"
 	"encoding/base64"
 	"strconv"
 	"strings"
 	"testing"
 
-	"golang.org/x/crypto/ed25519"
-
 	"github.com/hashicorp/vault/helper/keysutil"
 	"github.com/hashicorp/vault/logical"
 	"github.com/mitchellh/mapstructure"
@@ -427,3 +429,203 @@ func TestTransit_SignVerify_ED25519(t *testing.T) {
 	verifyRequest(req, false, "bar", sig)
 	verifyRequest(req, true, "bar", v1sig)
 }
+
+func TestTransit_SignVerify_ED25519_Options(t *testing.T) {
+	b, storage := createBackendWithSysView(t)
+
+	req := &logical.Request{
+		Storage:   storage,
+		Operation: logical.UpdateOperation,
+		Path:      "keys/foo",
+		Data: map[string]interface{}{
+			"type": "ed25519",
+		},
+	}
+	if _, err := b.HandleRequest(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+
+	p, _, err := b.lm.GetPolicy(context.Background(), keysutil.PolicyRequest{
+		Storage: storage,
+		Name:    "foo",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubKey := ed25519.PrivateKey(p.Keys[strconv.Itoa(p.LatestVersion)].Key).Public().(ed25519.PublicKey)
+
+	message := []byte("the quick brown fox")
+	digest := sha512.Sum512(message)
+	sigContext := []byte("vault-test")
+
+	signAndVerify := func(input []byte, data map[string]interface{}, opts *ed25519.Options) {
+		t.Helper()
+		req := &logical.Request{
+			Storage:   storage,
+			Operation: logical.UpdateOperation,
+			Path:      "sign/foo",
+			Data: map[string]interface{}{
+				"input": base64.StdEncoding.EncodeToString(input),
+			},
+		}
+		for k, v := range data {
+			req.Data[k] = v
+		}
+		resp, err := b.HandleRequest(context.Background(), req)
+		if err != nil || resp.IsError() {
+			t.Fatalf("err: %v, resp: %#v", err, resp)
+		}
+
+		sig := resp.Data["signature"].(string)
+		sigBytes, err := base64.StdEncoding.DecodeString(strings.Split(sig, ":")[2])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ed25519.VerifyWithOptions(pubKey, input, sigBytes, opts); err != nil {
+			t.Fatalf("signature did not verify: %v", err)
+		}
+
+		req.Path = "verify/foo"
+		req.Data["signature"] = sig
+		resp, err = b.HandleRequest(context.Background(), req)
+		if err != nil || resp.IsError() {
+			t.Fatalf("err: %v, resp: %#v", err, resp)
+		}
+		if !resp.Data["valid"].(bool) {
+			t.Fatal("expected signature to be valid")
+		}
+
+		// The signature must not verify as plain Ed25519
+		if len(data) > 0 {
+			delete(req.Data, "prehashed")
+			delete(req.Data, "signature_context")
+			resp, err = b.HandleRequest(context.Background(), req)
+			if err != nil || resp.IsError() {
+				t.Fatalf("err: %v, resp: %#v", err, resp)
+			}
+			if resp.Data["valid"].(bool) {
+				t.Fatal("expected signature to be invalid without options")
+			}
+		}
+	}
+
+	// Ed25519ctx
+	signAndVerify(message, map[string]interface{}{
+		"signature_context": base64.StdEncoding.EncodeToString(sigContext),
+	}, &ed25519.Options{Context: string(sigContext)})
+
+	// Ed25519ph
+	signAndVerify(digest[:], map[string]interface{}{
+		"prehashed": true,
+	}, &ed25519.Options{Hash: crypto.SHA512})
+
+	// Ed25519ph with a context
+	signAndVerify(digest[:], map[string]interface{}{
+		"prehashed":         true,
+		"signature_context": base64.StdEncoding.EncodeToString(sigContext),
+	}, &ed25519.Options{Hash: crypto.SHA512, Context: string(sigContext)})
+
+	// Ed25519ph requires a SHA-512 digest
+	req = &logical.Request{
+		Storage:   storage,
+		Operation: logical.UpdateOperation,
+		Path:      "sign/foo",
+		Data: map[string]interface{}{
+			"input":     base64.StdEncoding.EncodeToString(message),
+			"prehashed": true,
+		},
+	}
+	resp, err := b.HandleRequest(context.Background(), req)
+	if err == nil || resp == nil || !resp.IsError() {
+		t.Fatalf("expected error response, got err: %v, resp: %#v", err, resp)
+	}
+}
+
+func TestTransit_SignVerify_P256_Deterministic(t *testing.T) {
+	b, storage := createBackendWithSysView(t)
+
+	req := &logical.Request{
+		Storage:   storage,
+		Operation: logical.UpdateOperation,
+		Path:      "keys/foo",
+		Data: map[string]interface{}{
+			"type": "ecdsa-p256",
+		},
+	}
+	if _, err := b.HandleRequest(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+
+	sign := func(data map[string]interface{}) string {
+		t.Helper()
+		req := &logical.Request{
+			Storage:   storage,
+			Operation: logical.UpdateOperation,
+			Path:      "sign/foo",
+			Data:      data,
+		}
+		resp, err := b.HandleRequest(context.Background(), req)
+		if err != nil || resp.IsError() {
+			t.Fatalf("err: %v, resp: %#v", err, resp)
+		}
+		return resp.Data["signature"].(string)
+	}
+
+	input := base64.StdEncoding.EncodeToString([]byte("the quick brown fox"))
+
+	sig1 := sign(map[string]interface{}{"input": input, "deterministic": true})
+	sig2 := sign(map[string]interface{}{"input": input, "deterministic": true})
+	if sig1 != sig2 {
+		t.Fatalf("expected deterministic signatures to match: %s != %s", sig1, sig2)
+	}
+
+	sig3 := sign(map[string]interface{}{"input": input})
+	if sig1 == sig3 {
+		t.Fatal("expected randomized signature to differ")
+	}
+
+	// Deterministic signatures of prehashed input must use the matching hash
+	digest := sha256.Sum256([]byte("the quick brown fox"))
+	sig4 := sign(map[string]interface{}{
+		"input":         base64.StdEncoding.EncodeToString(digest[:]),
+		"prehashed":     true,
+		"deterministic": true,
+	})
+	if sig4 != sig1 {
+		t.Fatalf("expected prehashed deterministic signature to match: %s != %s", sig4, sig1)
+	}
+
+	for _, sig := range []string{sig1, sig3} {
+		req := &logical.Request{
+			Storage:   storage,
+			Operation: logical.UpdateOperation,
+			Path:      "verify/foo",
+			Data: map[string]interface{}{
+				"input":     input,
+				"signature": sig,
+			},
+		}
+		resp, err := b.HandleRequest(context.Background(), req)
+		if err != nil || resp.IsError() {
+			t.Fatalf("err: %v, resp: %#v", err, resp)
+		}
+		if !resp.Data["valid"].(bool) {
+			t.Fatalf("expected signature %s to be valid", sig)
+		}
+	}
+
+	// A signature context is only valid for ed25519 keys
+	req = &logical.Request{
+		Storage:   storage,
+		Operation: logical.UpdateOperation,
+		Path:      "sign/foo",
+		Data: map[string]interface{}{
+			"input":             input,
+			"signature_context": base64.StdEncoding.EncodeToString([]byte("ctx")),
+		},
+	}
+	resp, err := b.HandleRequest(context.Background(), req)
+	if err == nil || resp == nil || !resp.IsError() {
+		t.Fatalf("expected error response, got err: %v, resp: %#v", err, resp)
+	}
+}
@@ -0,0 +1,323 @@
+package transit
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/helper/keysutil"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+const usageStoragePrefix = "usage/"
+
+type usageOperation int
+
+const (
+	usageEncrypt usageOperation = iota
+	usageDecrypt
+	usageSign
+	usageVerify
+	usageHMAC
+)
+
+// keyUsage holds the usage counters of a single key version
+type keyUsage struct {
+	Encrypt  uint64    `json:"encrypt"`
+	Decrypt  uint64    `json:"decrypt"`
+	Sign     uint64    `json:"sign"`
+	Verify   uint64    `json:"verify"`
+	HMAC     uint64    `json:"hmac"`
+	LastUsed time.Time `json:"last_used"`
+}
+
+func (u *keyUsage) add(other *keyUsage) {
+	u.Encrypt += other.Encrypt
+	u.Decrypt += other.Decrypt
+	u.Sign += other.Sign
+	u.Verify += other.Verify
+	u.HMAC += other.HMAC
+	if other.LastUsed.After(u.LastUsed) {
+		u.LastUsed = other.LastUsed
+	}
+}
+
+// usageTracker accumulates key usage in memory until it is flushed to
+// storage by the periodic function of the backend.
+type usageTracker struct {
+	l       sync.Mutex
+	pending map[string]map[int]*keyUsage
+}
+
+func newUsageTracker() *usageTracker {
+	return &usageTracker{
+		pending: make(map[string]map[int]*keyUsage),
+	}
+}
+
+// record counts one use of the given version of the named key
+func (t *usageTracker) record(name string, version int, op usageOperation) {
+	if version <= 0 {
+		return
+	}
+
+	t.l.Lock()
+	defer t.l.Unlock()
+
+	versions, ok := t.pending[name]
+	if !ok {
+		versions = make(map[int]*keyUsage)
+		t.pending[name] = versions
+	}
+	u, ok := versions[version]
+	if !ok {
+		u = &keyUsage{}
+		versions[version] = u
+	}
+
+	switch op {
+	case usageEncrypt:
+		u.Encrypt++
+	case usageDecrypt:
+		u.Decrypt++
+	case usageSign:
+		u.Sign++
+	case usageVerify:
+		u.Verify++
+	case usageHMAC:
+		u.HMAC++
+	}
+	u.LastUsed = time.Now().UTC()
+}
+
+// take removes and returns the usage recorded since the last flush
+func (t *usageTracker) take() map[string]map[int]*keyUsage {
+	t.l.Lock()
+	defer t.l.Unlock()
+
+	pending := t.pending
+	t.pending = make(map[string]map[int]*keyUsage)
+	return pending
+}
+
+// restore puts back usage that could not be flushed
+func (t *usageTracker) restore(name string, versions map[int]*keyUsage) {
+	t.l.Lock()
+	defer t.l.Unlock()
+
+	current, ok := t.pending[name]
+	if !ok {
+		t.pending[name] = versions
+		return
+	}
+	for version, u := range versions {
+		if existing, ok := current[version]; ok {
+			existing.add(u)
+		} else {
+			current[version] = u
+		}
+	}
+}
+
+// peek returns a copy of the usage of the named key recorded since the last
+// flush
+func (t *usageTracker) peek(name string) map[int]*keyUsage {
+	t.l.Lock()
+	defer t.l.Unlock()
+
+	ret := make(map[int]*keyUsage, len(t.pending[name]))
+	for version, u := range t.pending[name] {
+		c := *u
+		ret[version] = &c
+	}
+	return ret
+}
+
+func (t *usageTracker) forget(name string) {
+	t.l.Lock()
+	defer t.l.Unlock()
+
+	delete(t.pending, name)
+}
+
+// prefixedVersion returns the key version from a "vault:v<version>:" prefixed
+// ciphertext, signature or HMAC, or 0 if it cannot be parsed.
+func prefixedVersion(value string) int {
+	if !strings.HasPrefix(value, "vault:v") {
+		return 0
+	}
+	split := strings.SplitN(strings.TrimPrefix(value, "vault:v"), ":", 2)
+	if len(split) != 2 {
+		return 0
+	}
+	version, err := strconv.Atoi(split[0])
+	if err != nil {
+		return 0
+	}
+	return version
+}
+
+func getStoredUsage(ctx context.Context, s logical.Storage, name string) (map[int]*keyUsage, error) {
+	entry, err := s.Get(ctx, usageStoragePrefix+name)
+	if err != nil {
+		return nil, err
+	}
+	usage := make(map[int]*keyUsage)
+	if entry == nil {
+		return usage, nil
+	}
+	if err := entry.DecodeJSON(&usage); err != nil {
+		return nil, err
+	}
+	return usage, nil
+}
+
+// flushUsage merges the usage recorded in memory into storage. It is not
+// called where storage of the mount is read-only, in which case usage stays
+// in memory and is reported by this node only.
+func (b *backend) flushUsage(ctx context.Context, req *logical.Request) error {
+	for name, versions := range b.usage.take() {
+		if err := b.flushKeyUsage(ctx, req.Storage, name, versions); err != nil {
+			b.usage.restore(name, versions)
+			return errwrap.Wrapf("error storing key usage: {{err}}", err)
+		}
+	}
+
+	return nil
+}
+
+func (b *backend) flushKeyUsage(ctx context.Context, s logical.Storage, name string, versions map[int]*keyUsage) error {
+	// Don't recreate the usage of a key deleted since it was used
+	policyEntry, err := s.Get(ctx, "policy/"+name)
+	if err != nil {
+		return err
+	}
+	if policyEntry == nil {
+		return nil
+	}
+
+	usage, err := getStoredUsage(ctx, s, name)
+	if err != nil {
+		return err
+	}
+	for version, u := range versions {
+		if existing, ok := usage[version]; ok {
+			existing.add(u)
+		} else {
+			usage[version] = u
+		}
+	}
+
+	entry, err := logical.StorageEntryJSON(usageStoragePrefix+name, usage)
+	if err != nil {
+		return err
+	}
+	return s.Put(ctx, entry)
+}
+
+func (b *backend) pathKeyUsage() *framework.Path {
+	return &framework.Path{
+		Pattern: "keys/" + framework.GenericNameRegex("name") + "/usage",
+		Fields: map[string]*framework.FieldSchema{
+			"name": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Name of the key",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation: b.pathKeyUsageRead,
+		},
+
+		HelpSynopsis:    pathKeyUsageHelpSyn,
+		HelpDescription: pathKeyUsageHelpDesc,
+	}
+}
+
+func (b *backend) pathKeyUsageRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	p, _, err := b.lm.GetPolicy(ctx, keysutil.PolicyRequest{
+		Storage: req.Storage,
+		Name:    name,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return nil, nil
+	}
+	if !b.System().CachingDisabled() {
+		p.Lock(false)
+	}
+	defer p.Unlock()
+
+	usage, err := getStoredUsage(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	for version, u := range b.usage.peek(name) {
+		if existing, ok := usage[version]; ok {
+			existing.add(u)
+		} else {
+			usage[version] = u
+		}
+	}
+
+	var lastUsed time.Time
+	versions := make(map[string]interface{}, len(p.Keys))
+	for k := range p.Keys {
+		version, err := strconv.Atoi(k)
+		if err != nil {
+			return nil, errwrap.Wrapf("invalid key version: {{err}}", err)
+		}
+		u, ok := usage[version]
+		if !ok {
+			u = &keyUsage{}
+		}
+		versionData := map[string]interface{}{
+			"encrypt":   u.Encrypt,
+			"decrypt":   u.Decrypt,
+			"sign":      u.Sign,
+			"verify":    u.Verify,
+			"hmac":      u.HMAC,
+			"last_used": "",
+		}
+		if !u.LastUsed.IsZero() {
+			versionData["last_used"] = u.LastUsed.Format(time.RFC3339)
+		}
+		if u.LastUsed.After(lastUsed) {
+			lastUsed = u.LastUsed
+		}
+		versions[k] = versionData
+	}
+
+	resp := &logical.Response{
+		Data: map[string]interface{}{
+			"name":      name,
+			"versions":  versions,
+			"last_used": "",
+		},
+	}
+	if !lastUsed.IsZero() {
+		resp.Data["last_used"] = lastUsed.Format(time.RFC3339)
+	}
+
+	return resp, nil
+}
+
+const pathKeyUsageHelpSyn = `Report how often each version of a named key was used`
+
+const pathKeyUsageHelpDesc = `
+This path reports, for each version of the named key, how many encrypt,
+decrypt, sign, verify and HMAC operations it was used for, and when it was
+last used. A key that has not been used for a long time may be a candidate
+for deletion.
+
+Usage is gathered in memory and written to storage about once a minute, so
+the most recent operations may be lost if the node shuts down.
+`
@@ -0,0 +1,106 @@
+package transit
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+
+	"github.com/hashicorp/vault/logical"
+)
+
+func TestTransit_KeyUsage(t *testing.T) {
+	b, s := createBackendWithStorage(t)
+
+	doReq := func(op logical.Operation, path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Storage:   s,
+			Operation: op,
+			Path:      path,
+			Data:      data,
+		})
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("bad: path: %s, resp: %#v, err: %v", path, resp, err)
+		}
+		return resp
+	}
+
+	checkUsage := func(version string, encrypt, decrypt, hmac uint64) {
+		t.Helper()
+		resp := doReq(logical.ReadOperation, "keys/foo/usage", nil)
+		versions := resp.Data["versions"].(map[string]interface{})
+		usage := versions[version].(map[string]interface{})
+		if usage["encrypt"] != encrypt || usage["decrypt"] != decrypt || usage["hmac"] != hmac {
+			t.Fatalf("bad: version %s: %#v", version, usage)
+		}
+		if (encrypt+decrypt+hmac > 0) != (usage["last_used"] != "") {
+			t.Fatalf("bad: version %s: last_used: %#v", version, usage["last_used"])
+		}
+		if resp.Data["last_used"] == "" {
+			t.Fatal("expected overall last_used to be set")
+		}
+	}
+
+	doReq(logical.UpdateOperation, "keys/foo", nil)
+
+	plaintext := base64.StdEncoding.EncodeToString([]byte(testPlaintext))
+	resp := doReq(logical.UpdateOperation, "encrypt/foo", map[string]interface{}{
+		"plaintext": plaintext,
+	})
+	ciphertext := resp.Data["ciphertext"].(string)
+	doReq(logical.UpdateOperation, "encrypt/foo", map[string]interface{}{
+		"plaintext": plaintext,
+	})
+
+	doReq(logical.UpdateOperation, "keys/foo/rotate", nil)
+	doReq(logical.UpdateOperation, "decrypt/foo", map[string]interface{}{
+		"ciphertext": ciphertext,
+	})
+	doReq(logical.UpdateOperation, "hmac/foo", map[string]interface{}{
+		"input": plaintext,
+	})
+
+	checkUsage("1", 2, 1, 0)
+	checkUsage("2", 0, 0, 1)
+
+	// Flushing to storage doesn't change what is reported
+	if _, err := b.HandleRequest(context.Background(), &logical.Request{
+		Storage:   s,
+		Operation: logical.RollbackOperation,
+	}); err != nil && err != logical.ErrUnsupportedOperation {
+		t.Fatal(err)
+	}
+	if entry, err := s.Get(context.Background(), usageStoragePrefix+"foo"); err != nil || entry == nil {
+		t.Fatalf("expected usage to be stored: %#v, %v", entry, err)
+	}
+	checkUsage("1", 2, 1, 0)
+
+	doReq(logical.UpdateOperation, "decrypt/foo", map[string]interface{}{
+		"ciphertext": ciphertext,
+	})
+	checkUsage("1", 2, 2, 0)
+
+	// Usage is removed along with the key
+	doReq(logical.UpdateOperation, "keys/foo/config", map[string]interface{}{
+		"deletion_allowed": true,
+	})
+	doReq(logical.DeleteOperation, "keys/foo", nil)
+	if entry, err := s.Get(context.Background(), usageStoragePrefix+"foo"); err != nil || entry != nil {
+		t.Fatalf("expected usage to be deleted: %#v, %v", entry, err)
+	}
+}
+
+func TestTransit_PrefixedVersion(t *testing.T) {
+	cases := map[string]int{
+		"vault:v1:abcd":  1,
+		"vault:v12:abcd": 12,
+		"vault:vx:abcd":  0,
+		"vault:v1":       0,
+		"abcd":           0,
+	}
+	for input, expected := range cases {
+		if actual := prefixedVersion(input); actual != expected {
+			t.Fatalf("bad: %q: expected %d, got %d", input, expected, actual)
+		}
+	}
+}
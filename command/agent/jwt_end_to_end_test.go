@@ -9,8 +9,8 @@ import (
 	"time"
 
 	hclog "github.com/hashicorp/go-hclog"
-	vaultjwt "github.com/hashicorp/vault-plugin-auth-jwt"
 	"github.com/hashicorp/vault/api"
+	vaultjwt "github.com/hashicorp/vault/builtin/credential/jwt"
 	"github.com/hashicorp/vault/command/agent/auth"
 	agentjwt "github.com/hashicorp/vault/command/agent/auth/jwt"
 	"github.com/hashicorp/vault/command/agent/sink"
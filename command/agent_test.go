@@ -7,8 +7,8 @@ import (
 	"testing"
 
 	hclog "github.com/hashicorp/go-hclog"
-	vaultjwt "github.com/hashicorp/vault-plugin-auth-jwt"
 	"github.com/hashicorp/vault/api"
+	vaultjwt "github.com/hashicorp/vault/builtin/credential/jwt"
 	"github.com/hashicorp/vault/command/agent"
 	"github.com/hashicorp/vault/helper/logging"
 	vaulthttp "github.com/hashicorp/vault/http"
@@ -21,7 +21,8 @@ func (c *AuditCommand) Help() string {
 Usage: vault audit <subcommand> [options] [args]
 
   This command groups subcommands for interacting with Vault's audit devices.
-  Users can list, enable, and disable audit devices.
+  Users can list, enable, and disable audit devices, and verify which entries
+  of an audit log hold the HMAC of a value.
 
   List all enabled audit devices:
 
@@ -31,6 +32,10 @@ Usage: vault audit <subcommand> [options] [args]
 
        $ vault audit enable file file_path=/var/log/audit.log
 
+  Find the entries of an audit log holding the HMAC of a value:
+
+      $ vault audit verify file/ /var/log/audit.log my-value
+
   Please see the individual subcommand help for detailed usage information.
 `
 
@@ -0,0 +1,267 @@
+package command
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
+)
+
+var _ cli.Command = (*AuditVerifyCommand)(nil)
+var _ cli.CommandAutocomplete = (*AuditVerifyCommand)(nil)
+
+type AuditVerifyCommand struct {
+	*BaseCommand
+
+	flagField string
+
+	testStdin io.Reader // for tests
+}
+
+// auditVerifyMatch is an entry of an audit log holding the HMAC of the value
+type auditVerifyMatch struct {
+	Line      int      `json:"line"`
+	Time      string   `json:"time"`
+	Type      string   `json:"type"`
+	RequestID string   `json:"request_id"`
+	Fields    []string `json:"fields"`
+}
+
+func (c *AuditVerifyCommand) Synopsis() string {
+	return "Finds the audit log entries holding the HMAC of a value"
+}
+
+func (c *AuditVerifyCommand) Help() string {
+	helpText := `
+Usage: vault audit verify [options] PATH LOG_FILE VALUE
+
+  Verifies whether a plaintext value matches the HMACs of an audit log. The
+  value is hashed by the audit device enabled at PATH, using its salt, and the
+  entries of the audit log file holding the resulting HMAC are listed, along
+  with the fields holding it. This requires access to the
+  "sys/audit-hash/PATH" endpoint.
+
+  The log file must be written by the audit device in the JSON format. A
+  prefix before each entry is ignored, as are the entries of the requests to
+  the "sys/audit-hash" endpoint, which hold the HMACs of the values hashed. If
+  the value is "-", it is read from stdin, which keeps it out of the shell
+  history.
+
+  Find the entries of a client token in the log of the "file/" audit device:
+
+      $ vault audit verify file/ /var/log/vault_audit.log s.Kh6hVRF9J7bDJK7n
+
+  Only look for the value in a given field, read from stdin:
+
+      $ vault audit verify -field=request.data.password file/ audit.log -
+
+  The command exits with code 2 if no entries match.
+
+` + c.Flags().Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *AuditVerifyCommand) Flags() *FlagSets {
+	set := c.flagSet(FlagSetHTTP | FlagSetOutputFormat)
+
+	f := set.NewFlagSet("Command Options")
+
+	f.StringVar(&StringVar{
+		Name:    "field",
+		Target:  &c.flagField,
+		Default: "",
+		EnvVar:  "",
+		Usage: "Dot-separated path of the field of the entries to compare with " +
+			"the HMAC of the value. By default, all the fields are compared.",
+	})
+
+	return set
+}
+
+func (c *AuditVerifyCommand) AutocompleteArgs() complete.Predictor {
+	return c.PredictVaultAudits()
+}
+
+func (c *AuditVerifyCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *AuditVerifyCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	args = f.Args()
+	switch {
+	case len(args) < 3:
+		c.UI.Error(fmt.Sprintf("Not enough arguments (expected 3, got %d)", len(args)))
+		return 1
+	case len(args) > 3:
+		c.UI.Error(fmt.Sprintf("Too many arguments (expected 3, got %d)", len(args)))
+		return 1
+	}
+
+	path := ensureTrailingSlash(sanitizePath(args[0]))
+	logPath := args[1]
+	value := args[2]
+
+	if value == "-" {
+		// Pull our fake stdin if needed
+		stdin := (io.Reader)(os.Stdin)
+		if c.testStdin != nil {
+			stdin = c.testStdin
+		}
+
+		raw, err := ioutil.ReadAll(stdin)
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error reading value from stdin: %s", err))
+			return 1
+		}
+		value = strings.TrimSuffix(string(raw), "\n")
+	}
+
+	logFile, err := os.Open(logPath)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error opening audit log: %s", err))
+		return 1
+	}
+	defer logFile.Close()
+
+	client, err := c.Client()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+
+	hash, err := client.Sys().AuditHash(path, value)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error hashing value with audit device %s: %s", path, err))
+		return 2
+	}
+
+	matches, err := auditVerify(logFile, hash, c.flagField)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error reading audit log: %s", err))
+		return 1
+	}
+
+	if len(matches) == 0 {
+		c.UI.Error("No entries of the audit log match the value")
+		return 2
+	}
+
+	switch Format(c.UI) {
+	case "table":
+		out := []string{"Line | Time | Type | Request ID | Fields"}
+		for _, m := range matches {
+			out = append(out, fmt.Sprintf("%d | %s | %s | %s | %s",
+				m.Line, m.Time, m.Type, m.RequestID, strings.Join(m.Fields, ", ")))
+		}
+		c.UI.Output(tableOutput(out, nil))
+		return 0
+	default:
+		return OutputData(c.UI, matches)
+	}
+}
+
+// auditVerify returns the entries of the audit log read from r which hold
+// hash, in the given field if not empty
+func auditVerify(r io.Reader, hash, field string) ([]*auditVerifyMatch, error) {
+	var fieldPath []string
+	if field != "" {
+		fieldPath = strings.Split(field, ".")
+	}
+
+	var matches []*auditVerifyMatch
+	reader := bufio.NewReader(r)
+	for line := 1; ; line++ {
+		raw, err := reader.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+
+		// Skip the prefix of the entry, if any
+		if i := bytes.IndexByte(raw, '{'); i >= 0 {
+			var entry map[string]interface{}
+			if jsonErr := json.Unmarshal(raw[i:], &entry); jsonErr != nil {
+				return nil, fmt.Errorf("line %d is not a JSON entry: %s", line, jsonErr)
+			}
+
+			if m := auditVerifyEntry(entry, hash, fieldPath); m != nil {
+				m.Line = line
+				matches = append(matches, m)
+			}
+		}
+
+		if err == io.EOF {
+			return matches, nil
+		}
+	}
+}
+
+// auditVerifyEntry returns a match if the entry holds hash, or nil
+func auditVerifyEntry(entry map[string]interface{}, hash string, fieldPath []string) *auditVerifyMatch {
+	// The requests hashing values hold the HMACs of the values themselves,
+	// including the ones of this command
+	req, _ := entry["request"].(map[string]interface{})
+	if reqPath, _ := req["path"].(string); strings.HasPrefix(reqPath, "sys/audit-hash/") {
+		return nil
+	}
+
+	var fields []string
+	auditVerifyWalk(entry, nil, fieldPath, func(p string, v string) {
+		if v == hash {
+			fields = append(fields, p)
+		}
+	})
+	if len(fields) == 0 {
+		return nil
+	}
+	sort.Strings(fields)
+
+	m := &auditVerifyMatch{
+		Fields: fields,
+	}
+	m.Time, _ = entry["time"].(string)
+	m.Type, _ = entry["type"].(string)
+	m.RequestID, _ = req["id"].(string)
+	return m
+}
+
+// auditVerifyWalk calls fn with every string value under v, along with its
+// dot-separated path. If filter is not empty, only the values under this
+// path are walked. The elements of lists share the path of the list.
+func auditVerifyWalk(v interface{}, path, filter []string, fn func(string, string)) {
+	switch v := v.(type) {
+	case string:
+		if len(filter) == 0 {
+			fn(strings.Join(path, "."), v)
+		}
+	case []interface{}:
+		for _, e := range v {
+			auditVerifyWalk(e, path, filter, fn)
+		}
+	case map[string]interface{}:
+		if len(filter) != 0 {
+			if e, ok := v[filter[0]]; ok {
+				auditVerifyWalk(e, append(path, filter[0]), filter[1:], fn)
+			}
+			return
+		}
+		for k, e := range v {
+			auditVerifyWalk(e, append(path[:len(path):len(path)], k), nil, fn)
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package command
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/mitchellh/cli"
+)
+
+func testAuditVerifyCommand(tb testing.TB) (*cli.MockUi, *AuditVerifyCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &AuditVerifyCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+	}
+}
+
+func TestAuditVerifyCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		args []string
+		out  string
+		code int
+	}{
+		{
+			"not_enough_args",
+			[]string{"file/", "audit.log"},
+			"Not enough arguments",
+			1,
+		},
+		{
+			"too_many_args",
+			[]string{"file/", "audit.log", "foo", "bar"},
+			"Too many arguments",
+			1,
+		},
+		{
+			"missing_log",
+			[]string{"file/", "/nonexistent/audit.log", "foo"},
+			"Error opening audit log",
+			1,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ui, cmd := testAuditVerifyCommand(t)
+
+			code := cmd.Run(tc.args)
+			if code != tc.code {
+				t.Errorf("expected %d to be %d", code, tc.code)
+			}
+
+			combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+			if !strings.Contains(combined, tc.out) {
+				t.Errorf("expected %q to contain %q", combined, tc.out)
+			}
+		})
+	}
+
+	t.Run("integration", func(t *testing.T) {
+		t.Parallel()
+
+		dir, err := ioutil.TempDir("", "vault-audit-verify")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+		logPath := filepath.Join(dir, "audit.log")
+
+		client, closer := testVaultServer(t)
+		defer closer()
+
+		if err := client.Sys().EnableAuditWithOptions("file", &api.EnableAuditOptions{
+			Type: "file",
+			Options: map[string]string{
+				"file_path": logPath,
+			},
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := client.Logical().Write("secret/foo", map[string]interface{}{
+			"password": "hunter2",
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		ui, cmd := testAuditVerifyCommand(t)
+		cmd.client = client
+
+		code := cmd.Run([]string{
+			"-field=request.data.password", "file", logPath, "hunter2",
+		})
+		if exp := 0; code != exp {
+			t.Fatalf("expected %d to be %d: %s", code, exp, ui.ErrorWriter.String())
+		}
+
+		// The request and its response both hold the password
+		combined := ui.OutputWriter.String()
+		if strings.Count(combined, "request.data.password") != 2 {
+			t.Errorf("expected %q to contain two matches", combined)
+		}
+
+		// The requests hashing the value are not matches, so verifying it
+		// again from stdin finds the same entries
+		ui, cmd = testAuditVerifyCommand(t)
+		cmd.client = client
+		cmd.testStdin = strings.NewReader("hunter2\n")
+
+		code = cmd.Run([]string{
+			"file/", logPath, "-",
+		})
+		if exp := 0; code != exp {
+			t.Fatalf("expected %d to be %d: %s", code, exp, ui.ErrorWriter.String())
+		}
+		combined = ui.OutputWriter.String()
+		if strings.Count(combined, "request.data.password") != 2 || strings.Contains(combined, "request.data.input") {
+			t.Errorf("expected %q to contain two matches", combined)
+		}
+
+		ui, cmd = testAuditVerifyCommand(t)
+		cmd.client = client
+
+		code = cmd.Run([]string{
+			"file/", logPath, "hunter3",
+		})
+		if exp := 2; code != exp {
+			t.Errorf("expected %d to be %d", code, exp)
+		}
+
+		expected := "No entries of the audit log match the value"
+		combined = ui.OutputWriter.String() + ui.ErrorWriter.String()
+		if !strings.Contains(combined, expected) {
+			t.Errorf("expected %q to contain %q", combined, expected)
+		}
+	})
+
+	t.Run("communication_failure", func(t *testing.T) {
+		t.Parallel()
+
+		f, err := ioutil.TempFile("", "vault-audit-verify")
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Close()
+		defer os.Remove(f.Name())
+
+		client, closer := testVaultServerBad(t)
+		defer closer()
+
+		ui, cmd := testAuditVerifyCommand(t)
+		cmd.client = client
+
+		code := cmd.Run([]string{
+			"file/", f.Name(), "foo",
+		})
+		if exp := 2; code != exp {
+			t.Errorf("expected %d to be %d", code, exp)
+		}
+
+		expected := "Error hashing value with audit device file/: "
+		combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+		if !strings.Contains(combined, expected) {
+			t.Errorf("expected %q to contain %q", combined, expected)
+		}
+	})
+
+	t.Run("no_tabs", func(t *testing.T) {
+		t.Parallel()
+
+		_, cmd := testAuditVerifyCommand(t)
+		assertNoTabs(t, cmd)
+	})
+}
+
+func TestAuditVerify(t *testing.T) {
+	t.Parallel()
+
+	log := strings.Join([]string{
+		`{"time":"t1","type":"request","request":{"id":"r1","data":{"password":"hmac-sha256:abc"}}}`,
+		``,
+		`vault-prefix {"time":"t2","type":"response","request":{"id":"r2","policies":["hmac-sha256:abc"]},"auth":{"client_token":"hmac-sha256:abc"}}`,
+		`{"time":"t3","type":"request","request":{"id":"r3","data":{"password":"hmac-sha256:def"}}}`,
+		`{"time":"t4","type":"request","request":{"id":"r4","path":"sys/audit-hash/file","data":{"input":"hmac-sha256:abc"}}}`,
+	}, "\n")
+
+	matches, err := auditVerify(strings.NewReader(log), "hmac-sha256:abc", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 2 || matches[0].Line != 1 || matches[0].RequestID != "r1" || matches[1].Line != 3 || matches[1].Type != "response" {
+		t.Fatalf("bad: %#v", matches)
+	}
+	if fields := strings.Join(matches[1].Fields, ","); fields != "auth.client_token,request.policies" {
+		t.Fatalf("bad fields: %q", fields)
+	}
+
+	matches, err = auditVerify(strings.NewReader(log), "hmac-sha256:abc", "auth.client_token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 1 || matches[0].RequestID != "r2" {
+		t.Fatalf("bad: %#v", matches)
+	}
+
+	if _, err := auditVerify(strings.NewReader("{not json"), "hmac-sha256:abc", ""); err == nil {
+		t.Fatal("expected an error with a malformed entry")
+	}
+}
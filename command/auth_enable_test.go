@@ -173,6 +173,10 @@ func TestAuthEnableCommand_Run(t *testing.T) {
 			}
 		}
 
+		// The jwt plugin is also registered as "oidc", for the OIDC login
+		// method of the CLI
+		backends = append(backends, "oidc")
+
 		// Add 1 to account for the "token" backend, which is visible when you walk the filesystem but
 		// is treated as special and excluded from the registry.
 		expected := len(builtinplugins.Registry.Keys(consts.PluginTypeCredential)) + 1
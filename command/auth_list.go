@@ -143,7 +143,7 @@ func (c *AuthListCommand) detailedMounts(auths map[string]*api.AuthMount) []stri
 		}
 	}
 
-	out := []string{"Path | Plugin | Accessor | Default TTL | Max TTL | Token Type | Replication | Seal Wrap | Options | Description"}
+	out := []string{"Path | Plugin | Accessor | Default TTL | Max TTL | Token Type | Replication | Seal Wrap | Options | Description | Deprecation Status"}
 	for _, path := range paths {
 		mount := auths[path]
 
@@ -160,7 +160,12 @@ func (c *AuthListCommand) detailedMounts(auths map[string]*api.AuthMount) []stri
 			pluginName = mount.Config.PluginName
 		}
 
-		out = append(out, fmt.Sprintf("%s | %s | %s | %s | %s | %s | %s | %t | %v | %s",
+		deprecationStatus := mount.DeprecationStatus
+		if deprecationStatus == "" {
+			deprecationStatus = "n/a"
+		}
+
+		out = append(out, fmt.Sprintf("%s | %s | %s | %s | %s | %s | %s | %t | %v | %s | %s",
 			path,
 			pluginName,
 			mount.Accessor,
@@ -171,6 +176,7 @@ func (c *AuthListCommand) detailedMounts(auths map[string]*api.AuthMount) []stri
 			mount.SealWrap,
 			mount.Options,
 			mount.Description,
+			deprecationStatus,
 		))
 	}
 
@@ -18,14 +18,21 @@ var _ cli.CommandAutocomplete = (*AuthTuneCommand)(nil)
 type AuthTuneCommand struct {
 	*BaseCommand
 
+	flagAllowedAliasMetadataKeys []string
 	flagAuditNonHMACRequestKeys  []string
 	flagAuditNonHMACResponseKeys []string
 	flagDefaultLeaseTTL          time.Duration
 	flagDescription              string
 	flagListingVisibility        string
+	flagLoginRateLimit           int
+	flagLoginRateLimitPeriod     time.Duration
 	flagMaxLeaseTTL              time.Duration
 	flagOptions                  map[string]string
+	flagRequestConcurrencyLimit  int
 	flagTokenType                string
+	flagUserLockoutThreshold     int
+	flagUserLockoutDuration      time.Duration
+	flagUserLockoutCounterReset  time.Duration
 	flagVersion                  int
 }
 
@@ -55,6 +62,13 @@ func (c *AuthTuneCommand) Flags() *FlagSets {
 
 	f := set.NewFlagSet("Command Options")
 
+	f.StringSliceVar(&StringSliceVar{
+		Name:   flagNameAllowedAliasMetadataKeys,
+		Target: &c.flagAllowedAliasMetadataKeys,
+		Usage: "Comma-separated string or list of the entity alias metadata keys " +
+			"the auth method is allowed to set at login.",
+	})
+
 	f.StringSliceVar(&StringSliceVar{
 		Name:   flagNameAuditNonHMACRequestKeys,
 		Target: &c.flagAuditNonHMACRequestKeys,
@@ -94,6 +108,21 @@ func (c *AuthTuneCommand) Flags() *FlagSets {
 			"endpoint.",
 	})
 
+	f.IntVar(&IntVar{
+		Name:   flagNameLoginRateLimit,
+		Target: &c.flagLoginRateLimit,
+		Usage: "Maximum number of login attempts allowed per period, both per " +
+			"login path and per client address. A value of 0 disables the limit.",
+	})
+
+	f.DurationVar(&DurationVar{
+		Name:       flagNameLoginRateLimitPeriod,
+		Target:     &c.flagLoginRateLimitPeriod,
+		Completion: complete.PredictAnything,
+		Usage: "The period over which the login rate limit applies. If " +
+			"unspecified, this defaults to 1 minute.",
+	})
+
 	f.DurationVar(&DurationVar{
 		Name:       "max-lease-ttl",
 		Target:     &c.flagMaxLeaseTTL,
@@ -105,6 +134,13 @@ func (c *AuthTuneCommand) Flags() *FlagSets {
 			"or a previously configured value for the auth method.",
 	})
 
+	f.IntVar(&IntVar{
+		Name:   flagNameRequestConcurrencyLimit,
+		Target: &c.flagRequestConcurrencyLimit,
+		Usage: "Maximum number of requests each token can have in flight against " +
+			"the auth method at once. A value of 0 disables the limit.",
+	})
+
 	f.StringMapVar(&StringMapVar{
 		Name:       "options",
 		Target:     &c.flagOptions,
@@ -119,6 +155,30 @@ func (c *AuthTuneCommand) Flags() *FlagSets {
 		Usage:  "Sets a forced token type for the mount.",
 	})
 
+	f.IntVar(&IntVar{
+		Name:   flagNameUserLockoutThreshold,
+		Target: &c.flagUserLockoutThreshold,
+		Usage: "Number of failed login attempts after which a user is locked " +
+			"out of the auth method. A value of 0 disables the lockout.",
+	})
+
+	f.DurationVar(&DurationVar{
+		Name:       flagNameUserLockoutDuration,
+		Target:     &c.flagUserLockoutDuration,
+		Completion: complete.PredictAnything,
+		Usage: "How long a user stays locked out. If unspecified, this " +
+			"defaults to 15 minutes.",
+	})
+
+	f.DurationVar(&DurationVar{
+		Name:       flagNameUserLockoutCounterReset,
+		Target:     &c.flagUserLockoutCounterReset,
+		Completion: complete.PredictAnything,
+		Usage: "The period without failed login attempts after which the " +
+			"failed attempts of a user are forgotten. If unspecified, this " +
+			"defaults to 15 minutes.",
+	})
+
 	f.IntVar(&IntVar{
 		Name:    "version",
 		Target:  &c.flagVersion,
@@ -176,6 +236,10 @@ func (c *AuthTuneCommand) Run(args []string) int {
 
 	// Set these values only if they are provided in the CLI
 	f.Visit(func(fl *flag.Flag) {
+		if fl.Name == flagNameAllowedAliasMetadataKeys {
+			mountConfigInput.AllowedAliasMetadataKeys = c.flagAllowedAliasMetadataKeys
+		}
+
 		if fl.Name == flagNameAuditNonHMACRequestKeys {
 			mountConfigInput.AuditNonHMACRequestKeys = c.flagAuditNonHMACRequestKeys
 		}
@@ -195,6 +259,30 @@ func (c *AuthTuneCommand) Run(args []string) int {
 		if fl.Name == flagNameTokenType {
 			mountConfigInput.TokenType = c.flagTokenType
 		}
+
+		if fl.Name == flagNameLoginRateLimit {
+			mountConfigInput.LoginRateLimit = &c.flagLoginRateLimit
+		}
+
+		if fl.Name == flagNameLoginRateLimitPeriod {
+			mountConfigInput.LoginRateLimitPeriod = ttlToAPI(c.flagLoginRateLimitPeriod)
+		}
+
+		if fl.Name == flagNameRequestConcurrencyLimit {
+			mountConfigInput.RequestConcurrencyLimit = &c.flagRequestConcurrencyLimit
+		}
+
+		if fl.Name == flagNameUserLockoutThreshold {
+			mountConfigInput.UserLockoutThreshold = &c.flagUserLockoutThreshold
+		}
+
+		if fl.Name == flagNameUserLockoutDuration {
+			mountConfigInput.UserLockoutDuration = ttlToAPI(c.flagUserLockoutDuration)
+		}
+
+		if fl.Name == flagNameUserLockoutCounterReset {
+			mountConfigInput.UserLockoutCounterReset = ttlToAPI(c.flagUserLockoutCounterReset)
+		}
 	})
 
 	// Append /auth (since that's where auths live) and a trailing slash to
@@ -53,6 +53,7 @@ type BaseCommand struct {
 	flagFormat           string
 	flagField            string
 	flagOutputCurlString bool
+	flagOutputPolicy     bool
 
 	flagMFA []string
 
@@ -82,6 +83,9 @@ func (c *BaseCommand) Client() (*api.Client, error) {
 	if c.flagOutputCurlString {
 		config.OutputCurlString = c.flagOutputCurlString
 	}
+	if c.flagOutputPolicy {
+		config.OutputPolicy = c.flagOutputPolicy
+	}
 
 	// If we need custom TLS configuration, then set it
 	if c.flagCACert != "" || c.flagCAPath != "" || c.flagClientCert != "" ||
@@ -339,6 +343,14 @@ func (c *BaseCommand) flagSet(bit FlagSetBit) *FlagSets {
 					"command string and exit.",
 			})
 
+			f.BoolVar(&BoolVar{
+				Name:    "output-policy",
+				Target:  &c.flagOutputPolicy,
+				Default: false,
+				Usage: "Instead of executing the request, print the ACL policy " +
+					"needed to execute it and exit.",
+			})
+
 		}
 
 		if bit&(FlagSetOutputField|FlagSetOutputFormat) != 0 {
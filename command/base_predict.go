@@ -110,6 +110,7 @@ func (b *BaseCommand) PredictVaultAvailableAuths() complete.Predictor {
 		"okta",
 		"plugin",
 		"radius",
+		"saml",
 		"userpass",
 	)
 }
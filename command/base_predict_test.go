@@ -326,6 +326,7 @@ func TestPredict_Plugins(t *testing.T) {
 				"alicloud",
 				"app-id",
 				"approle",
+				"artifactory",
 				"aws",
 				"azure",
 				"cassandra",
@@ -333,6 +334,7 @@ func TestPredict_Plugins(t *testing.T) {
 				"centrify",
 				"cert",
 				"consul",
+				"elasticsearch-database-plugin",
 				"gcp",
 				"gcpkms",
 				"github",
@@ -344,6 +346,7 @@ func TestPredict_Plugins(t *testing.T) {
 				"ldap",
 				"mongodb",
 				"mongodb-database-plugin",
+				"mongodbatlas",
 				"mssql",
 				"mssql-database-plugin",
 				"mysql",
@@ -352,13 +355,17 @@ func TestPredict_Plugins(t *testing.T) {
 				"mysql-legacy-database-plugin",
 				"mysql-rds-database-plugin",
 				"nomad",
+				"oidc",
 				"okta",
 				"pki",
 				"postgresql",
 				"postgresql-database-plugin",
 				"rabbitmq",
 				"radius",
+				"saml",
+				"snowflake-database-plugin",
 				"ssh",
+				"terraform",
 				"totp",
 				"transit",
 				"userpass",
@@ -30,13 +30,15 @@ import (
 	credAws "github.com/hashicorp/vault/builtin/credential/aws"
 	credCert "github.com/hashicorp/vault/builtin/credential/cert"
 	credGitHub "github.com/hashicorp/vault/builtin/credential/github"
+	credOIDC "github.com/hashicorp/vault/builtin/credential/jwt"
 	credLdap "github.com/hashicorp/vault/builtin/credential/ldap"
 	credOkta "github.com/hashicorp/vault/builtin/credential/okta"
+	credSAML "github.com/hashicorp/vault/builtin/credential/saml"
 	credToken "github.com/hashicorp/vault/builtin/credential/token"
 	credUserpass "github.com/hashicorp/vault/builtin/credential/userpass"
 
-	logicalKv "github.com/hashicorp/vault-plugin-secrets-kv"
 	logicalDb "github.com/hashicorp/vault/builtin/logical/database"
+	logicalKv "github.com/hashicorp/vault/builtin/logical/kv"
 
 	physAliCloudOSS "github.com/hashicorp/vault/physical/alicloudoss"
 	physAzure "github.com/hashicorp/vault/physical/azure"
@@ -80,6 +82,20 @@ const (
 	flagNameAllowedResponseHeaders = "allowed-response-headers"
 	// flagNameTokenType is the flag name used to force a specific token type
 	flagNameTokenType = "token-type"
+	// flagNameLoginRateLimit is the flag name used to throttle logins on an auth mount
+	flagNameLoginRateLimit = "login-rate-limit"
+	// flagNameLoginRateLimitPeriod is the flag name used to set the period of the login rate limit
+	flagNameLoginRateLimitPeriod = "login-rate-limit-period"
+	// flagNameAllowedAliasMetadataKeys is the flag name used to set the alias metadata keys an auth method may set at login
+	flagNameAllowedAliasMetadataKeys = "allowed-alias-metadata-keys"
+	// flagNameRequestConcurrencyLimit is the flag name used to cap the requests each token has in flight against a mount
+	flagNameRequestConcurrencyLimit = "request-concurrency-limit"
+	// flagNameUserLockoutThreshold is the flag name used to lock users out of an auth mount after repeated failed logins
+	flagNameUserLockoutThreshold = "user-lockout-threshold"
+	// flagNameUserLockoutDuration is the flag name used to set how long users stay locked out
+	flagNameUserLockoutDuration = "user-lockout-duration"
+	// flagNameUserLockoutCounterReset is the flag name used to set when the failed logins of a user are forgotten
+	flagNameUserLockoutCounterReset = "user-lockout-counter-reset"
 )
 
 var (
@@ -177,10 +193,12 @@ func initCommands(ui, serverCmdUi cli.Ui, runOpts *RunOptions) {
 		"gcp":      &credGcp.CLIHandler{},
 		"github":   &credGitHub.CLIHandler{},
 		"ldap":     &credLdap.CLIHandler{},
+		"oidc":     &credOIDC.CLIHandler{},
 		"okta":     &credOkta.CLIHandler{},
 		"radius": &credUserpass.CLIHandler{
 			DefaultMount: "radius",
 		},
+		"saml":  &credSAML.CLIHandler{},
 		"token": &credToken.CLIHandler{},
 		"userpass": &credUserpass.CLIHandler{
 			DefaultMount: "userpass",
@@ -225,6 +243,11 @@ func initCommands(ui, serverCmdUi cli.Ui, runOpts *RunOptions) {
 				BaseCommand: getBaseCommand(),
 			}, nil
 		},
+		"audit verify": func() (cli.Command, error) {
+			return &AuditVerifyCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
 		"auth tune": func() (cli.Command, error) {
 			return &AuthTuneCommand{
 				BaseCommand: getBaseCommand(),
@@ -313,6 +336,11 @@ func initCommands(ui, serverCmdUi cli.Ui, runOpts *RunOptions) {
 				BaseCommand: getBaseCommand(),
 			}, nil
 		},
+		"namespace patch": func() (cli.Command, error) {
+			return &NamespacePatchCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
 		"operator": func() (cli.Command, error) {
 			return &OperatorCommand{
 				BaseCommand: getBaseCommand(),
@@ -435,6 +463,14 @@ func initCommands(ui, serverCmdUi cli.Ui, runOpts *RunOptions) {
 				BaseCommand: getBaseCommand(),
 			}, nil
 		},
+		"proxy": func() (cli.Command, error) {
+			return &ProxyCommand{
+				BaseCommand: &BaseCommand{
+					UI: serverCmdUi,
+				},
+				ShutdownCh: MakeShutdownCh(),
+			}, nil
+		},
 		"read": func() (cli.Command, error) {
 			return &ReadCommand{
 				BaseCommand: getBaseCommand(),
@@ -460,6 +496,11 @@ func initCommands(ui, serverCmdUi cli.Ui, runOpts *RunOptions) {
 				BaseCommand: getBaseCommand(),
 			}, nil
 		},
+		"secrets migrate": func() (cli.Command, error) {
+			return &SecretsMigrateCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
 		"secrets move": func() (cli.Command, error) {
 			return &SecretsMoveCommand{
 				BaseCommand: getBaseCommand(),
@@ -13,6 +13,8 @@ var _ cli.CommandAutocomplete = (*NamespaceCreateCommand)(nil)
 
 type NamespaceCreateCommand struct {
 	*BaseCommand
+
+	flagCustomMetadata map[string]string
 }
 
 func (c *NamespaceCreateCommand) Synopsis() string {
@@ -35,13 +37,28 @@ Usage: vault namespace create [options] PATH
 
       $ vault namespace create -namespace=ns1 ns2
 
+  Create a child namespace with custom metadata (e.g. ns1/):
+
+      $ vault namespace create -custom-metadata=owner=teamX ns1
+
 ` + c.Flags().Help()
 
 	return strings.TrimSpace(helpText)
 }
 
 func (c *NamespaceCreateCommand) Flags() *FlagSets {
-	return c.flagSet(FlagSetHTTP | FlagSetOutputField | FlagSetOutputFormat)
+	set := c.flagSet(FlagSetHTTP | FlagSetOutputField | FlagSetOutputFormat)
+
+	f := set.NewFlagSet("Command Options")
+	f.StringMapVar(&StringMapVar{
+		Name:    "custom-metadata",
+		Target:  &c.flagCustomMetadata,
+		Default: map[string]string{},
+		Usage: "Specifies arbitrary key=value metadata meant to describe a namespace. " +
+			"This can be specified multiple times to add multiple pieces of metadata.",
+	})
+
+	return set
 }
 
 func (c *NamespaceCreateCommand) AutocompleteArgs() complete.Predictor {
@@ -78,7 +95,11 @@ func (c *NamespaceCreateCommand) Run(args []string) int {
 		return 2
 	}
 
-	secret, err := client.Logical().Write("sys/namespaces/"+namespacePath, nil)
+	data := map[string]interface{}{
+		"custom_metadata": c.flagCustomMetadata,
+	}
+
+	secret, err := client.Logical().Write("sys/namespaces/"+namespacePath, data)
 	if err != nil {
 		c.UI.Error(fmt.Sprintf("Error creating namespace: %s", err))
 		return 2
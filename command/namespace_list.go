@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/helper/consts"
+	"github.com/hashicorp/vault/helper/namespace"
 	"github.com/mitchellh/cli"
 	"github.com/posener/complete"
 )
@@ -13,6 +16,8 @@ var _ cli.CommandAutocomplete = (*NamespaceListCommand)(nil)
 
 type NamespaceListCommand struct {
 	*BaseCommand
+
+	flagRecursive bool
 }
 
 func (c *NamespaceListCommand) Synopsis() string {
@@ -29,13 +34,28 @@ Usage: vault namespaces list [options]
 
       $ vault namespaces list
 
+  List all child namespaces, including the nested ones:
+
+      $ vault namespaces list -recursive
+
 ` + c.Flags().Help()
 
 	return strings.TrimSpace(helpText)
 }
 
 func (c *NamespaceListCommand) Flags() *FlagSets {
-	return c.flagSet(FlagSetHTTP | FlagSetOutputFormat)
+	set := c.flagSet(FlagSetHTTP | FlagSetOutputFormat)
+
+	f := set.NewFlagSet("Command Options")
+	f.BoolVar(&BoolVar{
+		Name:    "recursive",
+		Target:  &c.flagRecursive,
+		Default: false,
+		Usage: "List the child namespaces of the listed namespaces as well, " +
+			"outputting their paths relative to the current namespace.",
+	})
+
+	return set
 }
 
 func (c *NamespaceListCommand) AutocompleteArgs() complete.Predictor {
@@ -66,6 +86,19 @@ func (c *NamespaceListCommand) Run(args []string) int {
 		return 2
 	}
 
+	if c.flagRecursive {
+		paths, err := c.listRecursive(client)
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error listing namespaces: %s", err))
+			return 2
+		}
+		if len(paths) == 0 {
+			c.UI.Error(fmt.Sprintf("No namespaces found"))
+			return 2
+		}
+		return OutputList(c.UI, paths)
+	}
+
 	secret, err := client.Logical().List("sys/namespaces")
 	if err != nil {
 		c.UI.Error(fmt.Sprintf("Error listing namespaces: %s", err))
@@ -92,3 +125,47 @@ func (c *NamespaceListCommand) Run(args []string) int {
 
 	return OutputList(c.UI, secret)
 }
+
+// listRecursive walks the tree of the child namespaces of the current
+// namespace, and returns their paths relative to it
+func (c *NamespaceListCommand) listRecursive(client *api.Client) ([]interface{}, error) {
+	headers := client.Headers()
+	defer client.SetHeaders(headers)
+
+	var current string
+	if headers != nil {
+		current = namespace.Canonicalize(headers.Get(consts.NamespaceHeaderName))
+	}
+
+	var paths []interface{}
+	var walk func(string) error
+	walk = func(parent string) error {
+		client.SetNamespace(current + parent)
+		secret, err := client.Logical().List("sys/namespaces")
+		if err != nil {
+			return err
+		}
+
+		keys, ok := extractListData(secret)
+		if !ok {
+			return nil
+		}
+		for _, key := range keys {
+			child, ok := key.(string)
+			if !ok {
+				continue
+			}
+			path := parent + namespace.Canonicalize(child)
+			paths = append(paths, path)
+			if err := walk(path); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := walk(""); err != nil {
+		return nil, err
+	}
+	return paths, nil
+}
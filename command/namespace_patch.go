@@ -0,0 +1,160 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
+)
+
+var _ cli.Command = (*NamespacePatchCommand)(nil)
+var _ cli.CommandAutocomplete = (*NamespacePatchCommand)(nil)
+
+type NamespacePatchCommand struct {
+	*BaseCommand
+
+	flagCustomMetadata       map[string]string
+	flagRemoveCustomMetadata []string
+}
+
+func (c *NamespacePatchCommand) Synopsis() string {
+	return "Patch an existing namespace"
+}
+
+func (c *NamespacePatchCommand) Help() string {
+	helpText := `
+Usage: vault namespace patch [options] PATH
+
+  Patch an existing namespace. The namespace patched will be relative to the
+  namespace provided in either the VAULT_NAMESPACE environment variable or
+  -namespace CLI flag.
+
+  Patch an existing child namespace by adding and removing custom-metadata
+  (e.g. ns1/):
+
+      $ vault namespace patch ns1 -custom-metadata=foo=abc -remove-custom-metadata=bar
+
+  Patch an existing child namespace from a parent namespace (e.g. ns1/ns2/):
+
+      $ vault namespace patch -namespace=ns1 ns2 -custom-metadata=foo=abc
+
+` + c.Flags().Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *NamespacePatchCommand) Flags() *FlagSets {
+	set := c.flagSet(FlagSetHTTP | FlagSetOutputField | FlagSetOutputFormat)
+
+	f := set.NewFlagSet("Command Options")
+	f.StringMapVar(&StringMapVar{
+		Name:    "custom-metadata",
+		Target:  &c.flagCustomMetadata,
+		Default: map[string]string{},
+		Usage: "Specifies arbitrary key=value metadata meant to describe a namespace. " +
+			"This can be specified multiple times to add multiple pieces of metadata.",
+	})
+
+	f.StringSliceVar(&StringSliceVar{
+		Name:    "remove-custom-metadata",
+		Target:  &c.flagRemoveCustomMetadata,
+		Default: []string{},
+		Usage: "Key to remove from custom metadata. To specify multiple values, " +
+			"specify this flag multiple times.",
+	})
+
+	return set
+}
+
+func (c *NamespacePatchCommand) AutocompleteArgs() complete.Predictor {
+	return c.PredictVaultFolders()
+}
+
+func (c *NamespacePatchCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *NamespacePatchCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	args = f.Args()
+	switch {
+	case len(args) < 1:
+		c.UI.Error(fmt.Sprintf("Not enough arguments (expected 1, got %d)", len(args)))
+		return 1
+	case len(args) > 1:
+		c.UI.Error(fmt.Sprintf("Too many arguments (expected 1, got %d)", len(args)))
+		return 1
+	}
+
+	namespacePath := strings.TrimSpace(args[0])
+
+	client, err := c.Client()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+
+	// Keys set to null are removed from the custom metadata, as per the JSON
+	// merge patch semantics
+	customMetadata := make(map[string]interface{})
+	for key, value := range c.flagCustomMetadata {
+		customMetadata[key] = value
+	}
+	for _, key := range c.flagRemoveCustomMetadata {
+		if _, ok := c.flagCustomMetadata[key]; ok {
+			c.UI.Error(fmt.Sprintf("Custom metadata key %q can't be both set and removed", key))
+			return 1
+		}
+		customMetadata[key] = nil
+	}
+
+	r := client.NewRequest("PATCH", "/v1/sys/namespaces/"+namespacePath)
+	if r.Headers == nil {
+		r.Headers = make(http.Header)
+	}
+	r.Headers.Set("Content-Type", "application/merge-patch+json")
+	if err := r.SetJSONBody(map[string]interface{}{
+		"custom_metadata": customMetadata,
+	}); err != nil {
+		c.UI.Error(fmt.Sprintf("Error patching namespace: %s", err))
+		return 2
+	}
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+	resp, err := client.RawRequestWithContext(ctx, r)
+	if resp != nil {
+		defer resp.Body.Close()
+	}
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error patching namespace: %s", err))
+		return 2
+	}
+
+	secret, err := api.ParseSecret(resp.Body)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error parsing response: %s", err))
+		return 2
+	}
+	if secret == nil {
+		c.UI.Error("Namespace not found")
+		return 2
+	}
+
+	// Handle single field output
+	if c.flagField != "" {
+		return PrintRawField(c.UI, secret, c.flagField)
+	}
+
+	return OutputSecret(c.UI, secret)
+}
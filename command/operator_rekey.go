@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"sort"
 	"strings"
 
 	"github.com/fatih/structs"
@@ -93,6 +94,27 @@ Usage: vault operator rekey [options] [KEY]
 
       $ vault operator rekey -backup-delete
 
+  Initialize a rekey which requires a quorum of the new unseal keys to be
+  provided before they replace the current ones:
+
+      $ vault operator rekey \
+          -init \
+          -key-shares=5 \
+          -key-threshold=3 \
+          -verify
+
+  Provide a new unseal key to verify the rekey, using the verification nonce:
+
+      $ vault operator rekey -verify -nonce="..."
+
+  Print the status of the verification:
+
+      $ vault operator rekey -verify -status
+
+  Restart the verification, discarding the new unseal keys provided so far:
+
+      $ vault operator rekey -verify -cancel
+
 ` + c.Flags().Help()
 	return strings.TrimSpace(helpText)
 }
@@ -174,7 +196,8 @@ func (c *OperatorRekeyCommand) Flags() *FlagSets {
 		Default: false,
 		Usage: "Indicates that the action (-status, -cancel, or providing a key " +
 			"share) will be affecting verification for the current rekey " +
-			"attempt.",
+			"attempt. Along with -init, requires the new unseal keys to be " +
+			"verified before they replace the current ones.",
 	})
 
 	f.VarFlag(&VarFlag{
@@ -197,8 +220,8 @@ func (c *OperatorRekeyCommand) Flags() *FlagSets {
 		Usage: "Store a backup of the current PGP encrypted unseal keys in " +
 			"Vault's core. The encrypted values can be recovered in the event of " +
 			"failure or discarded after success. See the -backup-delete and " +
-			"-backup-retrieve options for more information. This option only " +
-			"applies when the existing unseal keys were PGP encrypted.",
+			"-backup-retrieve options for more information. This option " +
+			"requires -pgp-keys.",
 	})
 
 	f.BoolVar(&BoolVar{
@@ -333,6 +356,15 @@ func (c *OperatorRekeyCommand) Run(args []string) int {
 
 // init starts the rekey process.
 func (c *OperatorRekeyCommand) init(client *api.Client) int {
+	// The backup holds the PGP encrypted unseal keys, so there is nothing to
+	// backup without PGP keys
+	if c.flagBackup && len(c.flagPGPKeys) == 0 {
+		c.UI.Error(wrapAtLength(
+			"The -backup flag requires -pgp-keys: only PGP encrypted unseal keys " +
+				"can be backed up."))
+		return 1
+	}
+
 	// Handle the different API requests
 	var fn func(*api.RekeyInitRequest) (*api.RekeyStatusResponse, error)
 	switch strings.ToLower(strings.TrimSpace(c.flagTarget)) {
@@ -367,21 +399,21 @@ func (c *OperatorRekeyCommand) init(client *api.Client) int {
 					"recovery. Consider canceling this operation and re-initializing " +
 					"with the -pgp-keys flag to protect the returned unseal keys along " +
 					"with -backup to allow recovery of the encrypted keys in case of " +
-					"emergency. You can delete the stored keys later using the -delete " +
-					"flag."))
+					"emergency. You can delete the stored keys later using the " +
+					"-backup-delete flag."))
 			c.UI.Output("")
 		}
 	}
 	if len(c.flagPGPKeys) > 0 && !c.flagBackup {
 		if Format(c.UI) == "table" {
 			c.UI.Warn(wrapAtLength(
-				"WARNING! You are using PGP keys for encrypted the resulting unseal " +
+				"WARNING! You are using PGP keys for encrypting the resulting unseal " +
 					"keys, but you did not enable the option to backup the keys to " +
 					"Vault's core. If you lose the encrypted keys after they are " +
 					"returned, you will not be able to recover them. Consider canceling " +
 					"this operation and re-running with -backup to allow recovery of the " +
 					"encrypted unseal keys in case of emergency. You can delete the " +
-					"stored keys later using the -delete flag."))
+					"stored keys later using the -backup-delete flag."))
 			c.UI.Output("")
 		}
 	}
@@ -515,8 +547,13 @@ func (c *OperatorRekeyCommand) provide(client *api.Client, key string) int {
 	case "": // Prompt using the tty
 		// Nonce value is not required if we are prompting via the terminal
 		w := getWriterFromUI(c.UI)
-		fmt.Fprintf(w, "Rekey operation nonce: %s\n", nonce)
-		fmt.Fprintf(w, "Unseal Key (will be hidden): ")
+		if c.flagVerify {
+			fmt.Fprintf(w, "Rekey verification nonce: %s\n", nonce)
+			fmt.Fprintf(w, "New Unseal Key (will be hidden): ")
+		} else {
+			fmt.Fprintf(w, "Rekey operation nonce: %s\n", nonce)
+			fmt.Fprintf(w, "Unseal Key (will be hidden): ")
+		}
 		key, err = password.Read(os.Stdin)
 		fmt.Fprintf(w, "\n")
 		if err != nil {
@@ -640,11 +677,38 @@ func (c *OperatorRekeyCommand) backupRetrieve(client *api.Client) int {
 		return 2
 	}
 
-	secret := &api.Secret{
-		Data: structs.New(storedKeys).Map(),
+	switch Format(c.UI) {
+	case "table":
+	default:
+		secret := &api.Secret{
+			Data: structs.New(storedKeys).Map(),
+		}
+		return OutputSecret(c.UI, secret)
+	}
+
+	// The backup maps the PGP fingerprints to the keys they encrypt, which
+	// are listed by fingerprint
+	fingerprints := make([]string, 0, len(storedKeys.Keys))
+	for fingerprint := range storedKeys.Keys {
+		fingerprints = append(fingerprints, fingerprint)
 	}
+	sort.Strings(fingerprints)
 
-	return OutputSecret(c.UI, secret)
+	i := 1
+	for _, fingerprint := range fingerprints {
+		keys := storedKeys.Keys[fingerprint]
+		if b64 := storedKeys.KeysB64[fingerprint]; len(b64) == len(keys) {
+			keys = b64
+		}
+		for _, key := range keys {
+			c.UI.Output(fmt.Sprintf("Key %d fingerprint: %s; value: %s", i, fingerprint, key))
+			i++
+		}
+	}
+
+	c.UI.Output("")
+	c.UI.Output(fmt.Sprintf("Operation nonce: %s", storedKeys.Nonce))
+	return 0
 }
 
 // backupDelete deletes the stored backup keys.
@@ -754,12 +818,18 @@ func (c *OperatorRekeyCommand) printUnsealKeys(client *api.Client, status *api.R
 	c.UI.Output(fmt.Sprintf("Operation nonce: %s", resp.Nonce))
 
 	if len(resp.PGPFingerprints) > 0 && resp.Backup {
+		backupPath := "core/unseal-keys-backup"
+		if target := strings.ToLower(strings.TrimSpace(c.flagTarget)); target == "recovery" || target == "hsm" {
+			backupPath = "core/recovery-keys-backup"
+		}
 		c.UI.Output("")
 		c.UI.Output(wrapAtLength(fmt.Sprintf(
-			"The encrypted unseal keys are backed up to \"core/unseal-keys-backup\"" +
-				"in the storage backend. Remove these keys at any time using " +
-				"\"vault operator rekey -backup-delete\". Vault does not automatically " +
+			"The encrypted unseal keys are backed up to %q in the storage "+
+				"backend. Retrieve them using \"vault operator rekey "+
+				"-backup-retrieve\", and remove them at any time using \"vault "+
+				"operator rekey -backup-delete\". Vault does not automatically "+
 				"remove these keys.",
+			backupPath,
 		)))
 	}
 
@@ -63,6 +63,15 @@ func TestOperatorRekeyCommand_Run(t *testing.T) {
 			"incorrect number",
 			2,
 		},
+		{
+			"backup_pgp_less",
+			[]string{
+				"-init",
+				"-backup",
+			},
+			"requires -pgp-keys",
+			1,
+		},
 	}
 
 	t.Run("validations", func(t *testing.T) {
@@ -392,6 +401,90 @@ func TestOperatorRekeyCommand_Run(t *testing.T) {
 		}
 	})
 
+	t.Run("provide_verify", func(t *testing.T) {
+		t.Parallel()
+
+		client, keys, closer := testVaultServerUnseal(t)
+		defer closer()
+
+		ui, cmd := testOperatorRekeyCommand(t)
+		cmd.client = client
+
+		code := cmd.Run([]string{
+			"-init",
+			"-key-shares", "1",
+			"-key-threshold", "1",
+			"-verify",
+		})
+		if exp := 0; code != exp {
+			t.Errorf("expected %d to be %d: %s", code, exp, ui.ErrorWriter.String())
+		}
+
+		status, err := client.Sys().RekeyStatus()
+		if err != nil {
+			t.Fatal(err)
+		}
+		nonce := status.Nonce
+
+		var combined string
+		for _, key := range keys {
+			ui, cmd := testOperatorRekeyCommand(t)
+			cmd.client = client
+
+			code := cmd.Run([]string{
+				"-nonce", nonce,
+				key,
+			})
+			if exp := 0; code != exp {
+				t.Errorf("expected %d to be %d: %s", code, exp, ui.ErrorWriter.String())
+			}
+			combined += ui.OutputWriter.String()
+		}
+
+		// The new key is printed along with the verification status
+		re := regexp.MustCompile(`Key 1: (.+)`)
+		match := re.FindAllStringSubmatch(combined, -1)
+		if len(match) < 1 || len(match[0]) < 2 {
+			t.Fatalf("bad match: %#v", match)
+		}
+		newKey := match[0][1]
+		if !strings.Contains(combined, "Verification Nonce") {
+			t.Errorf("expected %q to contain the verification status", combined)
+		}
+
+		verification, err := client.Sys().RekeyVerificationStatus()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ui, cmd = testOperatorRekeyCommand(t)
+		cmd.client = client
+		code = cmd.Run([]string{
+			"-verify",
+			"-nonce", verification.Nonce,
+			newKey,
+		})
+		if exp := 0; code != exp {
+			t.Errorf("expected %d to be %d: %s", code, exp, ui.ErrorWriter.String())
+		}
+		expected := "Rekey verification successful"
+		if output := ui.OutputWriter.String(); !strings.Contains(output, expected) {
+			t.Errorf("expected %q to contain %q", output, expected)
+		}
+
+		// The new key is now the one unsealing Vault
+		if err := client.Sys().Seal(); err != nil {
+			t.Fatal(err)
+		}
+		sealStatus, err := client.Sys().Unseal(newKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sealStatus.Sealed {
+			t.Errorf("expected vault to be unsealed: %#v", sealStatus)
+		}
+	})
+
 	t.Run("backup", func(t *testing.T) {
 		t.Parallel()
 
@@ -0,0 +1,295 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
+
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/command/proxy"
+	gatedwriter "github.com/hashicorp/vault/helper/gated-writer"
+	"github.com/hashicorp/vault/helper/logging"
+	"github.com/hashicorp/vault/version"
+)
+
+var _ cli.Command = (*ProxyCommand)(nil)
+var _ cli.CommandAutocomplete = (*ProxyCommand)(nil)
+
+type ProxyCommand struct {
+	*BaseCommand
+
+	ShutdownCh chan struct{}
+
+	logWriter io.Writer
+	logGate   *gatedwriter.Writer
+	logger    log.Logger
+
+	startedCh chan (struct{}) // for tests
+
+	flagListenAddress   string
+	flagCacheTTL        time.Duration
+	flagCacheClearToken string
+	flagLogLevel        string
+
+	flagCombineLogs bool
+}
+
+func (c *ProxyCommand) Synopsis() string {
+	return "Start a Vault API proxy"
+}
+
+func (c *ProxyCommand) Help() string {
+	helpText := `
+Usage: vault proxy [options]
+
+  This command starts a stateless Vault API proxy. Requests received by the
+  proxy are forwarded to the Vault server configured via the usual address
+  flags and environment variables, using the token supplied by the caller.
+
+  Reads of static secrets (responses without a lease or auth information) are
+  cached in memory per token for the configured TTL. Cached responses are only
+  returned to tokens that Vault accepted within the last 30 seconds. Writes and
+  deletes passing through the proxy invalidate the affected entries, including
+  the data and metadata of KV version 2 secrets.
+
+  Vault has no event subsystem to notify the proxy of changes made elsewhere,
+  so such changes are picked up when entries expire. External systems may
+  evict entries earlier by POSTing to the /proxy/v1/cache-clear endpoint. A
+  valid Vault token only clears the entries it populated; clearing the entries
+  of every token requires the token set with -cache-clear-token.
+
+  Start a proxy listening locally in front of a remote Vault server:
+
+      $ vault proxy -address=https://vault.example.com:8200 \
+          -listen-address=127.0.0.1:8100
+
+  Start a proxy with caching disabled:
+
+      $ vault proxy -cache-ttl=0
+
+` + c.Flags().Help()
+	return strings.TrimSpace(helpText)
+}
+
+func (c *ProxyCommand) Flags() *FlagSets {
+	set := c.flagSet(FlagSetHTTP)
+
+	f := set.NewFlagSet("Command Options")
+
+	f.StringVar(&StringVar{
+		Name:       "listen-address",
+		Target:     &c.flagListenAddress,
+		Default:    "127.0.0.1:8100",
+		Completion: complete.PredictAnything,
+		Usage:      "Address on which the proxy listens for requests.",
+	})
+
+	f.DurationVar(&DurationVar{
+		Name:       "cache-ttl",
+		Target:     &c.flagCacheTTL,
+		Default:    5 * time.Minute,
+		Completion: complete.PredictAnything,
+		Usage: "Amount of time a cached static secret is served before it is " +
+			"read again from Vault. A value of 0 disables caching.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "cache-clear-token",
+		Target:     &c.flagCacheClearToken,
+		EnvVar:     "VAULT_PROXY_CACHE_CLEAR_TOKEN",
+		Completion: complete.PredictAnything,
+		Usage: "Token allowing the /proxy/v1/cache-clear endpoint to clear the " +
+			"cached entries of every token. Without it, callers only clear the " +
+			"entries populated by their own Vault token.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "log-level",
+		Target:     &c.flagLogLevel,
+		Default:    "info",
+		EnvVar:     "VAULT_LOG_LEVEL",
+		Completion: complete.PredictSet("trace", "debug", "info", "warn", "err"),
+		Usage: "Log verbosity level. Supported values (in order of detail) are " +
+			"\"trace\", \"debug\", \"info\", \"warn\", and \"err\".",
+	})
+
+	// Internal-only flags to follow.
+	f.BoolVar(&BoolVar{
+		Name:    "combine-logs",
+		Target:  &c.flagCombineLogs,
+		Default: false,
+		Hidden:  true,
+	})
+
+	return set
+}
+
+func (c *ProxyCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *ProxyCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *ProxyCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	args = f.Args()
+	if len(args) > 0 {
+		c.UI.Error(fmt.Sprintf("Too many arguments (expected 0, got %d)", len(args)))
+		return 1
+	}
+
+	if c.flagCacheTTL < 0 {
+		c.UI.Error("Cache TTL must not be negative")
+		return 1
+	}
+
+	// Create a logger. We wrap it in a gated writer so that it doesn't
+	// start logging too early.
+	c.logGate = &gatedwriter.Writer{Writer: os.Stderr}
+	c.logWriter = c.logGate
+	if c.flagCombineLogs {
+		c.logWriter = os.Stdout
+	}
+	var level log.Level
+	c.flagLogLevel = strings.ToLower(strings.TrimSpace(c.flagLogLevel))
+	switch c.flagLogLevel {
+	case "trace":
+		level = log.Trace
+	case "debug":
+		level = log.Debug
+	case "notice", "info", "":
+		level = log.Info
+	case "warn", "warning":
+		level = log.Warn
+	case "err", "error":
+		level = log.Error
+	default:
+		c.UI.Error(fmt.Sprintf("Unknown log level: %s", c.flagLogLevel))
+		return 1
+	}
+
+	if c.logger == nil {
+		c.logger = logging.NewVaultLoggerWithWriter(c.logWriter, level)
+	}
+
+	client, err := c.Client()
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error fetching client: %v", err))
+		return 1
+	}
+
+	// The proxy uses the token supplied with each request; it never falls
+	// back to a locally configured token, so only the client's configuration
+	// is carried over.
+	client, err = client.Clone()
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error cloning client: %v", err))
+		return 1
+	}
+
+	ln, err := net.Listen("tcp", c.flagListenAddress)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error starting listener: %v", err))
+		return 1
+	}
+
+	infoKeys := make([]string, 0, 10)
+	info := make(map[string]string)
+	info["log level"] = c.flagLogLevel
+	infoKeys = append(infoKeys, "log level")
+	info["listen address"] = ln.Addr().String()
+	infoKeys = append(infoKeys, "listen address")
+	info["vault address"] = client.Address()
+	infoKeys = append(infoKeys, "vault address")
+	info["cache ttl"] = "disabled"
+	if c.flagCacheTTL > 0 {
+		info["cache ttl"] = c.flagCacheTTL.String()
+	}
+	infoKeys = append(infoKeys, "cache ttl")
+
+	infoKeys = append(infoKeys, "version")
+	verInfo := version.GetVersion()
+	info["version"] = verInfo.FullVersionNumber(false)
+	if verInfo.Revision != "" {
+		info["version sha"] = strings.Trim(verInfo.Revision, "'")
+		infoKeys = append(infoKeys, "version sha")
+	}
+
+	// Proxy configuration output
+	padding := 24
+	sort.Strings(infoKeys)
+	c.UI.Output("==> Vault proxy configuration:\n")
+	for _, k := range infoKeys {
+		c.UI.Output(fmt.Sprintf(
+			"%s%s: %s",
+			strings.Repeat(" ", padding-len(k)),
+			strings.Title(k),
+			info[k]))
+	}
+	c.UI.Output("")
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+
+	var proxier proxy.Proxier = proxy.NewAPIProxy(&proxy.APIProxyConfig{
+		Client: client,
+		Logger: c.logger.Named("proxy.apiproxy"),
+	})
+
+	var cache *proxy.Cache
+	if c.flagCacheTTL > 0 {
+		cache = proxy.NewCache(&proxy.CacheConfig{
+			Proxier:    proxier,
+			Logger:     c.logger.Named("proxy.cache"),
+			TTL:        c.flagCacheTTL,
+			ClearToken: c.flagCacheClearToken,
+		})
+		proxier = cache
+		go cache.Run(ctx)
+	}
+
+	server := &http.Server{
+		Handler:           proxy.Handler(ctx, c.logger.Named("proxy.handler"), proxier, cache),
+		ReadHeaderTimeout: 10 * time.Second,
+		IdleTimeout:       5 * time.Minute,
+		ErrorLog:          c.logger.StandardLogger(nil),
+	}
+	go server.Serve(ln)
+
+	// Output the header that the proxy has started
+	if !c.flagCombineLogs {
+		c.UI.Output("==> Vault proxy started! Log data will stream in below:\n")
+	}
+
+	// Inform any tests that the proxy is ready
+	select {
+	case c.startedCh <- struct{}{}:
+	default:
+	}
+
+	// Release the log gate.
+	c.logGate.Flush()
+
+	<-c.ShutdownCh
+	c.UI.Output("==> Vault proxy shutdown triggered")
+	server.Close()
+
+	return 0
+}
@@ -0,0 +1,459 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	hclog "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/helper/consts"
+	"github.com/hashicorp/vault/helper/jsonutil"
+	"github.com/hashicorp/vault/helper/strutil"
+)
+
+// defaultTokenTTL is how long the proxy relies on a successful lookup of a
+// token before looking it up again.
+const defaultTokenTTL = 30 * time.Second
+
+// kvV2Prefixes are the path segments under which KV v2 exposes a secret. A
+// write to any of them affects the data and metadata of the secret.
+var kvV2Prefixes = []string{"data", "metadata", "delete", "undelete", "destroy"}
+
+// cacheEntry holds a single cached response along with the information needed
+// to expire and invalidate it.
+type cacheEntry struct {
+	path       string
+	tokenHash  string
+	statusCode int
+	header     http.Header
+	body       []byte
+	expiresAt  time.Time
+}
+
+// Cache is a Proxier that caches responses to reads of static secrets, i.e.
+// responses that carry neither a lease nor auth information. Entries are
+// scoped to the token that performed the read, and the token is looked up
+// again at least every TokenTTL, so a token never receives a response it
+// could not have obtained from Vault directly.
+type Cache struct {
+	proxier  Proxier
+	logger   hclog.Logger
+	ttl      time.Duration
+	tokenTTL time.Duration
+
+	// clearToken is the token allowed to clear the entries of every token
+	// through the cache-clear endpoint
+	clearToken string
+
+	l       sync.RWMutex
+	entries map[string]*cacheEntry
+
+	// tokens maps the hashes of the tokens successfully looked up to the
+	// time until which they are considered valid
+	tokens map[string]time.Time
+
+	// nowFunc is used to obtain the current time; it is overridden in tests.
+	nowFunc func() time.Time
+}
+
+type CacheConfig struct {
+	Proxier  Proxier
+	Logger   hclog.Logger
+	TTL      time.Duration
+	TokenTTL time.Duration
+
+	// ClearToken, if set, allows clearing the entries of every token through
+	// the cache-clear endpoint. Other callers only clear their own entries.
+	ClearToken string
+}
+
+func NewCache(config *CacheConfig) *Cache {
+	tokenTTL := config.TokenTTL
+	if tokenTTL == 0 {
+		tokenTTL = defaultTokenTTL
+	}
+
+	return &Cache{
+		proxier:    config.Proxier,
+		logger:     config.Logger,
+		ttl:        config.TTL,
+		tokenTTL:   tokenTTL,
+		clearToken: config.ClearToken,
+		entries:    make(map[string]*cacheEntry),
+		tokens:     make(map[string]time.Time),
+		nowFunc:    time.Now,
+	}
+}
+
+// Send serves the request from the cache if possible and otherwise forwards
+// it to the underlying proxier, caching the response when eligible. Any
+// request that may mutate state invalidates cached entries for its path.
+func (c *Cache) Send(ctx context.Context, req *SendRequest) (*SendResponse, error) {
+	path := req.Request.URL.Path
+
+	if !isCacheableRequest(req.Request) {
+		resp, err := c.proxier.Send(ctx, req)
+		if err == nil && resp != nil && resp.Response != nil && resp.Response.StatusCode < 400 {
+			c.invalidateWrite(path)
+		}
+		return resp, err
+	}
+
+	key := cacheKey(req)
+	if resp := c.get(key); resp != nil {
+		// Cached responses are only returned to tokens that are still
+		// valid; otherwise the request is forwarded for Vault to reject
+		if c.ValidToken(ctx, req) {
+			c.logger.Debug("returning cached response", "path", path)
+			return resp, nil
+		}
+	}
+
+	resp, err := c.proxier.Send(ctx, req)
+	if err != nil {
+		return resp, err
+	}
+
+	if isCacheableResponse(resp) {
+		c.logger.Debug("caching response", "path", path)
+		c.l.Lock()
+		c.entries[key] = &cacheEntry{
+			path:       path,
+			tokenHash:  hashToken(req.Token),
+			statusCode: resp.Response.StatusCode,
+			header:     resp.Response.Header,
+			body:       resp.ResponseBody,
+			expiresAt:  c.nowFunc().Add(c.ttl),
+		}
+		c.l.Unlock()
+	}
+
+	return resp, nil
+}
+
+func (c *Cache) get(key string) *SendResponse {
+	c.l.RLock()
+	entry, ok := c.entries[key]
+	c.l.RUnlock()
+	if !ok {
+		return nil
+	}
+
+	if c.nowFunc().After(entry.expiresAt) {
+		c.l.Lock()
+		delete(c.entries, key)
+		c.l.Unlock()
+		return nil
+	}
+
+	return &SendResponse{
+		Response: &api.Response{
+			Response: &http.Response{
+				StatusCode: entry.statusCode,
+				Header:     entry.header,
+				Body:       ioutil.NopCloser(bytes.NewReader(entry.body)),
+			},
+		},
+		ResponseBody: entry.body,
+	}
+}
+
+// ValidToken returns whether the token of the request is valid, looking it up
+// in Vault unless it was successfully looked up within the last TokenTTL. The
+// cached entries of tokens Vault rejects are removed.
+func (c *Cache) ValidToken(ctx context.Context, req *SendRequest) bool {
+	if req.Token == "" {
+		return false
+	}
+
+	tokenHash := hashToken(req.Token)
+	now := c.nowFunc()
+
+	c.l.RLock()
+	validUntil, ok := c.tokens[tokenHash]
+	c.l.RUnlock()
+	if ok && now.Before(validUntil) {
+		return true
+	}
+
+	ttl, err := c.lookupToken(ctx, req)
+	if err != nil {
+		c.logger.Debug("failed to look up token", "error", err)
+		c.l.Lock()
+		delete(c.tokens, tokenHash)
+		c.l.Unlock()
+		return false
+	}
+	if ttl == 0 {
+		// An invalid token; drop what it read
+		c.InvalidateToken(req.Token)
+		return false
+	}
+
+	validUntil = now.Add(c.tokenTTL)
+	if ttl > 0 && now.Add(ttl).Before(validUntil) {
+		validUntil = now.Add(ttl)
+	}
+	c.l.Lock()
+	c.tokens[tokenHash] = validUntil
+	c.l.Unlock()
+
+	return true
+}
+
+// lookupToken looks up the token of the request in Vault. It returns the
+// remaining TTL of the token, -1 for a token that doesn't expire and 0 for a
+// token Vault rejects.
+func (c *Cache) lookupToken(ctx context.Context, req *SendRequest) (time.Duration, error) {
+	lookupReq, err := http.NewRequest(http.MethodGet, "/v1/auth/token/lookup-self", nil)
+	if err != nil {
+		return 0, err
+	}
+	if ns := req.Request.Header.Get(consts.NamespaceHeaderName); ns != "" {
+		lookupReq.Header.Set(consts.NamespaceHeaderName, ns)
+	}
+
+	resp, err := c.proxier.Send(ctx, &SendRequest{
+		Token:   req.Token,
+		Request: lookupReq,
+	})
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case resp == nil || resp.Response == nil:
+		return 0, fmt.Errorf("no response")
+	case resp.Response.StatusCode == http.StatusForbidden:
+		return 0, nil
+	case resp.Response.StatusCode != http.StatusOK:
+		return 0, fmt.Errorf("unexpected status code %d", resp.Response.StatusCode)
+	}
+
+	secret, err := api.ParseSecret(bytes.NewReader(resp.ResponseBody))
+	if err != nil {
+		return 0, err
+	}
+	ttl, err := secret.TokenTTL()
+	if err != nil {
+		return 0, err
+	}
+	if ttl <= 0 {
+		return -1, nil
+	}
+	return ttl, nil
+}
+
+// InvalidatePath removes all cached entries for the given path, regardless of
+// which token populated them.
+func (c *Cache) InvalidatePath(path string) int {
+	return c.invalidate(func(e *cacheEntry) bool {
+		return e.path == path
+	})
+}
+
+// invalidateWrite removes the entries made stale by a write to the given
+// path: the path itself and any parent path whose listing may have changed.
+// The proxy doesn't know which mounts are KV v2, so a write to any path
+// looking like one of a KV v2 secret also invalidates the data and metadata
+// of that secret.
+func (c *Cache) invalidateWrite(path string) int {
+	paths := append([]string{path}, kvV2Siblings(path)...)
+	return c.invalidate(func(e *cacheEntry) bool {
+		for _, path := range paths {
+			if e.path == path || strings.HasPrefix(path, strings.TrimSuffix(e.path, "/")+"/") {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// kvV2Siblings returns the data and metadata paths of the KV v2 secret the
+// path may refer to, for each segment of the path that is one of
+// kvV2Prefixes.
+func kvV2Siblings(path string) []string {
+	var siblings []string
+
+	segments := strings.Split(strings.TrimPrefix(path, "/v1/"), "/")
+	// The first segment is part of the mount path
+	for i := 1; i < len(segments); i++ {
+		if !strutil.StrListContains(kvV2Prefixes, segments[i]) {
+			continue
+		}
+
+		mount := strings.Join(segments[:i], "/")
+		secret := strings.Join(segments[i+1:], "/")
+		for _, prefix := range []string{"data", "metadata"} {
+			sibling := "/v1/" + mount + "/" + prefix + "/" + secret
+			if sibling != path {
+				siblings = append(siblings, sibling)
+			}
+		}
+	}
+
+	return siblings
+}
+
+// InvalidateTokenPath removes the cached entries for the given path populated
+// by the given token.
+func (c *Cache) InvalidateTokenPath(token, path string) int {
+	tokenHash := hashToken(token)
+	return c.invalidate(func(e *cacheEntry) bool {
+		return e.tokenHash == tokenHash && e.path == path
+	})
+}
+
+// InvalidatePrefix removes all cached entries whose path starts with the
+// given prefix.
+func (c *Cache) InvalidatePrefix(prefix string) int {
+	return c.invalidate(func(e *cacheEntry) bool {
+		return strings.HasPrefix(e.path, prefix)
+	})
+}
+
+// InvalidateTokenPrefix removes the cached entries whose path starts with the
+// given prefix populated by the given token.
+func (c *Cache) InvalidateTokenPrefix(token, prefix string) int {
+	tokenHash := hashToken(token)
+	return c.invalidate(func(e *cacheEntry) bool {
+		return e.tokenHash == tokenHash && strings.HasPrefix(e.path, prefix)
+	})
+}
+
+// InvalidateToken removes all cached entries populated by the given token.
+func (c *Cache) InvalidateToken(token string) int {
+	tokenHash := hashToken(token)
+
+	c.l.Lock()
+	delete(c.tokens, tokenHash)
+	c.l.Unlock()
+
+	return c.invalidate(func(e *cacheEntry) bool {
+		return e.tokenHash == tokenHash
+	})
+}
+
+// InvalidateAll empties the cache.
+func (c *Cache) InvalidateAll() int {
+	return c.invalidate(func(*cacheEntry) bool {
+		return true
+	})
+}
+
+func (c *Cache) invalidate(match func(*cacheEntry) bool) int {
+	c.l.Lock()
+	defer c.l.Unlock()
+
+	count := 0
+	for key, entry := range c.entries {
+		if match(entry) {
+			delete(c.entries, key)
+			count++
+		}
+	}
+
+	return count
+}
+
+// Tidy removes expired entries and token lookups from the cache.
+func (c *Cache) Tidy() int {
+	now := c.nowFunc()
+
+	c.l.Lock()
+	for tokenHash, validUntil := range c.tokens {
+		if !now.Before(validUntil) {
+			delete(c.tokens, tokenHash)
+		}
+	}
+	c.l.Unlock()
+
+	return c.invalidate(func(e *cacheEntry) bool {
+		return now.After(e.expiresAt)
+	})
+}
+
+// Run periodically removes expired entries until the context is canceled.
+func (c *Cache) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.ttl)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if count := c.Tidy(); count > 0 {
+				c.logger.Trace("removed expired cache entries", "count", count)
+			}
+		}
+	}
+}
+
+// Len returns the number of entries currently held in the cache.
+func (c *Cache) Len() int {
+	c.l.RLock()
+	defer c.l.RUnlock()
+	return len(c.entries)
+}
+
+func isCacheableRequest(req *http.Request) bool {
+	if req.Method != http.MethodGet {
+		return false
+	}
+
+	// Response wrapping creates a new single-use token on every request, so
+	// such responses must never be replayed.
+	if req.Header.Get("X-Vault-Wrap-TTL") != "" {
+		return false
+	}
+
+	// Requests for the sys/ paths are operational in nature and are always
+	// forwarded.
+	return !strings.HasPrefix(strings.TrimPrefix(req.URL.Path, "/v1/"), "sys/")
+}
+
+func isCacheableResponse(resp *SendResponse) bool {
+	if resp == nil || resp.Response == nil || resp.Response.StatusCode != http.StatusOK {
+		return false
+	}
+
+	secret := new(api.Secret)
+	if err := jsonutil.DecodeJSON(resp.ResponseBody, secret); err != nil {
+		return false
+	}
+
+	// Only static secrets are cached; anything carrying a lease, auth or a
+	// wrapped response is tied to state held by Vault.
+	return secret.LeaseID == "" && secret.Auth == nil && secret.WrapInfo == nil
+}
+
+// isClearToken returns whether the token is the configured clear token
+func (c *Cache) isClearToken(token string) bool {
+	return c.clearToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(c.clearToken)) == 1
+}
+
+func cacheKey(req *SendRequest) string {
+	h := sha256.New()
+	h.Write([]byte(req.Token))
+	h.Write([]byte{0})
+	h.Write([]byte(req.Request.Header.Get(consts.NamespaceHeaderName)))
+	h.Write([]byte{0})
+	h.Write([]byte(req.Request.URL.Path))
+	h.Write([]byte{0})
+	h.Write([]byte(req.Request.URL.Query().Encode()))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,246 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	hclog "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/helper/logging"
+)
+
+type mockProxier struct {
+	body    string
+	calls   int
+	lookups int
+
+	// revoked holds the tokens Vault rejects
+	revoked map[string]bool
+}
+
+func (p *mockProxier) Send(ctx context.Context, req *SendRequest) (*SendResponse, error) {
+	status, body := http.StatusOK, p.body
+	if req.Request.URL.Path == "/v1/auth/token/lookup-self" {
+		p.lookups++
+		body = `{"data": {"ttl": 3600}}`
+	} else {
+		p.calls++
+	}
+	if p.revoked[req.Token] {
+		status, body = http.StatusForbidden, `{"errors": ["permission denied"]}`
+	}
+
+	return &SendResponse{
+		Response: &api.Response{
+			Response: &http.Response{
+				StatusCode: status,
+				Header:     http.Header{},
+				Body:       ioutil.NopCloser(bytes.NewReader([]byte(body))),
+			},
+		},
+		ResponseBody: []byte(body),
+	}, nil
+}
+
+func testSendRequest(t *testing.T, method, path, token string) *SendRequest {
+	t.Helper()
+
+	return &SendRequest{
+		Token:   token,
+		Request: httptest.NewRequest(method, path, nil),
+	}
+}
+
+func testCache(proxier Proxier) *Cache {
+	return NewCache(&CacheConfig{
+		Proxier: proxier,
+		Logger:  logging.NewVaultLogger(hclog.Trace),
+		TTL:     time.Minute,
+	})
+}
+
+func TestCache_StaticSecret(t *testing.T) {
+	proxier := &mockProxier{body: `{"data": {"foo": "bar"}}`}
+	cache := testCache(proxier)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		resp, err := cache.Send(ctx, testSendRequest(t, "GET", "/v1/secret/foo", "tokenA"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(resp.ResponseBody) != proxier.body {
+			t.Fatalf("bad: %s", resp.ResponseBody)
+		}
+	}
+	if proxier.calls != 1 {
+		t.Fatalf("expected 1 upstream call, got %d", proxier.calls)
+	}
+
+	// A different token must not be served the cached response
+	if _, err := cache.Send(ctx, testSendRequest(t, "GET", "/v1/secret/foo", "tokenB")); err != nil {
+		t.Fatal(err)
+	}
+	if proxier.calls != 2 {
+		t.Fatalf("expected 2 upstream calls, got %d", proxier.calls)
+	}
+
+	// Expired entries are read again
+	cache.nowFunc = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	if _, err := cache.Send(ctx, testSendRequest(t, "GET", "/v1/secret/foo", "tokenA")); err != nil {
+		t.Fatal(err)
+	}
+	if proxier.calls != 3 {
+		t.Fatalf("expected 3 upstream calls, got %d", proxier.calls)
+	}
+}
+
+func TestCache_NotCached(t *testing.T) {
+	cases := map[string]struct {
+		body   string
+		method string
+		path   string
+	}{
+		"lease":  {`{"lease_id": "database/creds/foo/abcd", "data": {}}`, "GET", "/v1/database/creds/foo"},
+		"auth":   {`{"auth": {"client_token": "foo"}}`, "GET", "/v1/auth/token/lookup"},
+		"sys":    {`{"data": {}}`, "GET", "/v1/sys/mounts"},
+		"method": {`{"data": {}}`, "PUT", "/v1/secret/foo"},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			proxier := &mockProxier{body: tc.body}
+			cache := testCache(proxier)
+
+			for i := 0; i < 2; i++ {
+				if _, err := cache.Send(context.Background(), testSendRequest(t, tc.method, tc.path, "token")); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if proxier.calls != 2 {
+				t.Fatalf("expected 2 upstream calls, got %d", proxier.calls)
+			}
+			if cache.Len() != 0 {
+				t.Fatalf("expected empty cache, got %d entries", cache.Len())
+			}
+		})
+	}
+}
+
+func TestCache_Invalidation(t *testing.T) {
+	proxier := &mockProxier{body: `{"data": {"foo": "bar"}}`}
+	cache := testCache(proxier)
+	ctx := context.Background()
+
+	read := func(path, token string) {
+		t.Helper()
+		if _, err := cache.Send(ctx, testSendRequest(t, "GET", path, token)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	read("/v1/secret/foo", "tokenA")
+	read("/v1/secret/foo", "tokenB")
+	read("/v1/secret/", "tokenA")
+	read("/v1/secret/bar", "tokenA")
+	if cache.Len() != 4 {
+		t.Fatalf("expected 4 entries, got %d", cache.Len())
+	}
+
+	// A write removes the path for all tokens along with the parent listing
+	if _, err := cache.Send(ctx, testSendRequest(t, "PUT", "/v1/secret/foo", "tokenA")); err != nil {
+		t.Fatal(err)
+	}
+	if cache.Len() != 1 {
+		t.Fatalf("expected 1 entry, got %d", cache.Len())
+	}
+
+	read("/v1/secret/foo", "tokenB")
+	if count := cache.InvalidateToken("tokenB"); count != 1 {
+		t.Fatalf("expected 1 entry removed, got %d", count)
+	}
+	if count := cache.InvalidatePrefix("/v1/secret/"); count != 1 {
+		t.Fatalf("expected 1 entry removed, got %d", count)
+	}
+	if cache.Len() != 0 {
+		t.Fatalf("expected empty cache, got %d entries", cache.Len())
+	}
+}
+
+func TestCache_TokenRevalidation(t *testing.T) {
+	proxier := &mockProxier{
+		body:    `{"data": {"foo": "bar"}}`,
+		revoked: make(map[string]bool),
+	}
+	cache := testCache(proxier)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if _, err := cache.Send(ctx, testSendRequest(t, "GET", "/v1/secret/foo", "tokenA")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if proxier.calls != 1 || proxier.lookups != 1 {
+		t.Fatalf("expected 1 upstream call and 1 lookup, got %d and %d", proxier.calls, proxier.lookups)
+	}
+
+	// The token is looked up again once the lookup expires, and a revoked
+	// token isn't served the cached response
+	proxier.revoked["tokenA"] = true
+	cache.nowFunc = func() time.Time { return time.Now().Add(defaultTokenTTL + time.Second) }
+	resp, err := cache.Send(ctx, testSendRequest(t, "GET", "/v1/secret/foo", "tokenA"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Response.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected a forbidden response, got %d", resp.Response.StatusCode)
+	}
+	if proxier.calls != 2 || proxier.lookups != 2 {
+		t.Fatalf("expected 2 upstream calls and 2 lookups, got %d and %d", proxier.calls, proxier.lookups)
+	}
+	if cache.Len() != 0 {
+		t.Fatalf("expected empty cache, got %d entries", cache.Len())
+	}
+}
+
+func TestCache_KVv2Invalidation(t *testing.T) {
+	proxier := &mockProxier{body: `{"data": {"data": {"foo": "bar"}}}`}
+	cache := testCache(proxier)
+	ctx := context.Background()
+
+	send := func(method, path string) {
+		t.Helper()
+		if _, err := cache.Send(ctx, testSendRequest(t, method, path, "token")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	readAll := func() {
+		t.Helper()
+		send("GET", "/v1/kv/data/foo")
+		send("GET", "/v1/kv/metadata/foo")
+		send("GET", "/v1/kv/metadata/?list=true")
+		send("GET", "/v1/kv/data/bar")
+	}
+
+	cases := map[string]string{
+		"data":     "/v1/kv/data/foo",
+		"metadata": "/v1/kv/metadata/foo",
+		"delete":   "/v1/kv/delete/foo",
+		"destroy":  "/v1/kv/destroy/foo",
+	}
+	for name, path := range cases {
+		readAll()
+		send("PUT", path)
+
+		// Only the unrelated secret remains
+		if cache.Len() != 1 {
+			t.Fatalf("%s: expected 1 entry, got %d", name, cache.Len())
+		}
+		cache.InvalidateAll()
+	}
+}
@@ -0,0 +1,181 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/hashicorp/errwrap"
+	hclog "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/helper/consts"
+	"github.com/hashicorp/vault/helper/jsonutil"
+)
+
+// CacheClearPath is the path on which the proxy accepts invalidation
+// requests. External systems that observe changes in Vault may call it to
+// evict entries before their TTL elapses. Requests must carry either a valid
+// Vault token, in which case only the entries populated by that token are
+// cleared, or the clear token configured on the cache, which may clear the
+// entries of every token.
+const CacheClearPath = "/proxy/v1/cache-clear"
+
+// cacheClearRequest is the body accepted by the cache-clear endpoint.
+type cacheClearRequest struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// Handler returns an http.Handler that serves requests received by the proxy
+// through the given proxier.
+func Handler(ctx context.Context, logger hclog.Logger, proxier Proxier, cache *Cache) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle(CacheClearPath, handleCacheClear(ctx, logger, cache))
+	mux.Handle("/", handleProxy(ctx, logger, proxier))
+	return mux
+}
+
+func handleProxy(ctx context.Context, logger hclog.Logger, proxier Proxier) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger.Info("received request", "path", r.URL.Path, "method", r.Method)
+
+		reqBody, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, errwrap.Wrapf("failed to read request body: {{err}}", err))
+			return
+		}
+		if r.Body != nil {
+			r.Body.Close()
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(reqBody))
+
+		req := &SendRequest{
+			Token:       r.Header.Get(consts.AuthHeaderName),
+			Request:     r,
+			RequestBody: reqBody,
+		}
+
+		resp, err := proxier.Send(ctx, req)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, errwrap.Wrapf("failed to get the response: {{err}}", err))
+			return
+		}
+
+		copyHeader(w.Header(), resp.Response.Header)
+		w.WriteHeader(resp.Response.StatusCode)
+		io.Copy(w, bytes.NewReader(resp.ResponseBody))
+	})
+}
+
+func handleCacheClear(ctx context.Context, logger hclog.Logger, cache *Cache) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost && r.Method != http.MethodPut {
+			respondError(w, http.StatusMethodNotAllowed, nil)
+			return
+		}
+
+		if cache == nil {
+			respondError(w, http.StatusBadRequest, fmt.Errorf("caching is not enabled"))
+			return
+		}
+
+		token := r.Header.Get(consts.AuthHeaderName)
+		global := cache.isClearToken(token)
+		if !global && !cache.ValidToken(ctx, &SendRequest{
+			Token:   token,
+			Request: r,
+		}) {
+			respondError(w, http.StatusForbidden, fmt.Errorf("permission denied"))
+			return
+		}
+
+		req := new(cacheClearRequest)
+		if err := jsonutil.DecodeJSONFromReader(r.Body, req); err != nil && err != io.EOF {
+			respondError(w, http.StatusBadRequest, errwrap.Wrapf("failed to parse request body: {{err}}", err))
+			return
+		}
+
+		var count int
+		switch req.Type {
+		case "all":
+			if global {
+				count = cache.InvalidateAll()
+			} else {
+				count = cache.InvalidateToken(token)
+			}
+		case "path":
+			if req.Value == "" {
+				respondError(w, http.StatusBadRequest, fmt.Errorf("value is required for type %q", req.Type))
+				return
+			}
+			path := "/v1/" + strings.TrimPrefix(req.Value, "/")
+			if global {
+				count = cache.InvalidatePath(path)
+			} else {
+				count = cache.InvalidateTokenPath(token, path)
+			}
+		case "prefix":
+			if req.Value == "" {
+				respondError(w, http.StatusBadRequest, fmt.Errorf("value is required for type %q", req.Type))
+				return
+			}
+			prefix := "/v1/" + strings.TrimPrefix(req.Value, "/")
+			if global {
+				count = cache.InvalidatePrefix(prefix)
+			} else {
+				count = cache.InvalidateTokenPrefix(token, prefix)
+			}
+		case "token":
+			if req.Value == "" {
+				respondError(w, http.StatusBadRequest, fmt.Errorf("value is required for type %q", req.Type))
+				return
+			}
+			// Only the clear token may clear the entries of another token
+			if !global && subtle.ConstantTimeCompare([]byte(req.Value), []byte(token)) != 1 {
+				respondError(w, http.StatusForbidden, fmt.Errorf("permission denied"))
+				return
+			}
+			count = cache.InvalidateToken(req.Value)
+		default:
+			respondError(w, http.StatusBadRequest, fmt.Errorf("invalid type %q; must be one of all, path, prefix or token", req.Type))
+			return
+		}
+
+		logger.Debug("cleared cache entries", "type", req.Type, "global", global, "count", count)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"cleared": count,
+		})
+	})
+}
+
+func copyHeader(dst, src http.Header) {
+	for k, vv := range src {
+		for _, v := range vv {
+			dst.Add(k, v)
+		}
+	}
+}
+
+func respondError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	resp := struct {
+		Errors []string `json:"errors"`
+	}{
+		Errors: make([]string, 0, 1),
+	}
+	if err != nil {
+		resp.Errors = append(resp.Errors, err.Error())
+	}
+
+	json.NewEncoder(w).Encode(resp)
+}
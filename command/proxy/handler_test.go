@@ -0,0 +1,90 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	hclog "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/helper/consts"
+	"github.com/hashicorp/vault/helper/logging"
+)
+
+func TestHandler_CacheClear(t *testing.T) {
+	proxier := &mockProxier{
+		body:    `{"data": {"foo": "bar"}}`,
+		revoked: map[string]bool{"revoked": true},
+	}
+	cache := NewCache(&CacheConfig{
+		Proxier:    proxier,
+		Logger:     logging.NewVaultLogger(hclog.Trace),
+		TTL:        time.Minute,
+		ClearToken: "clear-token",
+	})
+	handler := handleCacheClear(context.Background(), logging.NewVaultLogger(hclog.Trace), cache)
+	ctx := context.Background()
+
+	fill := func() {
+		t.Helper()
+		cache.InvalidateAll()
+		for _, token := range []string{"tokenA", "tokenB"} {
+			for _, path := range []string{"/v1/secret/foo", "/v1/secret/bar"} {
+				if _, err := cache.Send(ctx, testSendRequest(t, "GET", path, token)); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+	}
+	clear := func(token, body string) (int, int) {
+		t.Helper()
+		req := httptest.NewRequest("POST", CacheClearPath, strings.NewReader(body))
+		req.Header.Set(consts.AuthHeaderName, token)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		var resp struct {
+			Cleared int `json:"cleared"`
+		}
+		if w.Code == http.StatusOK {
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return w.Code, resp.Cleared
+	}
+
+	cases := []struct {
+		name    string
+		token   string
+		body    string
+		code    int
+		cleared int
+	}{
+		{"own_all", "tokenA", `{"type": "all"}`, http.StatusOK, 2},
+		{"own_path", "tokenA", `{"type": "path", "value": "secret/foo"}`, http.StatusOK, 1},
+		{"own_prefix", "tokenA", `{"type": "prefix", "value": "secret/"}`, http.StatusOK, 2},
+		{"own_token", "tokenA", `{"type": "token", "value": "tokenA"}`, http.StatusOK, 2},
+		{"other_token", "tokenA", `{"type": "token", "value": "tokenB"}`, http.StatusForbidden, 0},
+		{"invalid_token", "revoked", `{"type": "all"}`, http.StatusForbidden, 0},
+		{"no_token", "", `{"type": "all"}`, http.StatusForbidden, 0},
+		{"global_all", "clear-token", `{"type": "all"}`, http.StatusOK, 4},
+		{"global_path", "clear-token", `{"type": "path", "value": "secret/foo"}`, http.StatusOK, 2},
+		{"global_prefix", "clear-token", `{"type": "prefix", "value": "secret/"}`, http.StatusOK, 4},
+		{"global_token", "clear-token", `{"type": "token", "value": "tokenB"}`, http.StatusOK, 2},
+	}
+
+	for _, tc := range cases {
+		fill()
+		code, cleared := clear(tc.token, tc.body)
+		if code != tc.code || cleared != tc.cleared {
+			t.Fatalf("%s: expected %d with %d entries cleared, got %d with %d", tc.name, tc.code, tc.cleared, code, cleared)
+		}
+		if remaining := cache.Len(); remaining != 4-tc.cleared {
+			t.Fatalf("%s: expected %d entries left, got %d", tc.name, 4-tc.cleared, remaining)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package command
+
+import (
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/command/proxy"
+	"github.com/mitchellh/cli"
+)
+
+func testProxyCommand(tb testing.TB) (*cli.MockUi, *ProxyCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &ProxyCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+		ShutdownCh: MakeShutdownCh(),
+	}
+}
+
+func TestProxyCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		args []string
+		out  string
+		code int
+	}{
+		{
+			"too_many_args",
+			[]string{"foo"},
+			"Too many arguments",
+			1,
+		},
+		{
+			"negative_ttl",
+			[]string{"-cache-ttl", "-1s"},
+			"Cache TTL must not be negative",
+			1,
+		},
+		{
+			"bad_log_level",
+			[]string{"-log-level", "nope"},
+			"Unknown log level",
+			1,
+		},
+	}
+
+	t.Run("validations", func(t *testing.T) {
+		t.Parallel()
+
+		for _, tc := range cases {
+			tc := tc
+
+			t.Run(tc.name, func(t *testing.T) {
+				t.Parallel()
+
+				ui, cmd := testProxyCommand(t)
+
+				code := cmd.Run(tc.args)
+				if code != tc.code {
+					t.Errorf("expected %d to be %d", code, tc.code)
+				}
+
+				combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+				if !strings.Contains(combined, tc.out) {
+					t.Errorf("expected %q to contain %q", combined, tc.out)
+				}
+			})
+		}
+	})
+
+	t.Run("integration", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer := testVaultServer(t)
+		defer closer()
+
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		addr := ln.Addr().String()
+		ln.Close()
+
+		_, cmd := testProxyCommand(t)
+		cmd.client = client
+		cmd.startedCh = make(chan struct{})
+
+		codeCh := make(chan int)
+		go func() {
+			codeCh <- cmd.Run([]string{"-listen-address", addr, "-cache-clear-token", "clear-token"})
+		}()
+
+		select {
+		case <-cmd.startedCh:
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for proxy to start")
+		}
+
+		proxyClient, err := client.Clone()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := proxyClient.SetAddress("http://" + addr); err != nil {
+			t.Fatal(err)
+		}
+		proxyClient.SetToken(client.Token())
+
+		if _, err := proxyClient.Logical().Write("secret/foo", map[string]interface{}{
+			"value": "bar",
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		secret, err := proxyClient.Logical().Read("secret/foo")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if secret == nil || secret.Data["value"] != "bar" {
+			t.Fatalf("bad: %#v", secret)
+		}
+
+		// A write through the proxy must invalidate the cached read
+		if _, err := proxyClient.Logical().Write("secret/foo", map[string]interface{}{
+			"value": "baz",
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		secret, err = proxyClient.Logical().Read("secret/foo")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if secret == nil || secret.Data["value"] != "baz" {
+			t.Fatalf("bad: %#v", secret)
+		}
+
+		// Clearing the cache requires a valid token
+		req := proxyClient.NewRequest("POST", proxy.CacheClearPath)
+		if err := req.SetJSONBody(map[string]interface{}{"type": "all"}); err != nil {
+			t.Fatal(err)
+		}
+		resp, err := proxyClient.RawRequest(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		// A Vault token may only clear its own entries
+		req = proxyClient.NewRequest("POST", proxy.CacheClearPath)
+		if err := req.SetJSONBody(map[string]interface{}{"type": "token", "value": "other-token"}); err != nil {
+			t.Fatal(err)
+		}
+		resp, err = proxyClient.RawRequest(req)
+		if err == nil || resp == nil || resp.StatusCode != http.StatusForbidden {
+			t.Fatalf("expected a forbidden response, got err: %v", err)
+		}
+
+		// The clear token may clear the entries of every token
+		proxyClient.SetToken("clear-token")
+		req = proxyClient.NewRequest("POST", proxy.CacheClearPath)
+		if err := req.SetJSONBody(map[string]interface{}{"type": "token", "value": "other-token"}); err != nil {
+			t.Fatal(err)
+		}
+		resp, err = proxyClient.RawRequest(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		proxyClient.SetToken("not-a-token")
+		req = proxyClient.NewRequest("POST", proxy.CacheClearPath)
+		if err := req.SetJSONBody(map[string]interface{}{"type": "all"}); err != nil {
+			t.Fatal(err)
+		}
+		resp, err = proxyClient.RawRequest(req)
+		if err == nil || resp == nil || resp.StatusCode != http.StatusForbidden {
+			t.Fatalf("expected a forbidden response, got err: %v", err)
+		}
+
+		// Requests without a valid token are rejected by Vault
+		if _, err := proxyClient.Logical().Read("secret/foo"); err == nil {
+			t.Fatal("expected error")
+		}
+
+		close(cmd.ShutdownCh)
+		if code := <-codeCh; code != 0 {
+			t.Fatalf("expected 0, got %d", code)
+		}
+	})
+
+	t.Run("no_tabs", func(t *testing.T) {
+		t.Parallel()
+
+		_, cmd := testProxyCommand(t)
+		assertNoTabs(t, cmd)
+	})
+}
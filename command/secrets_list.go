@@ -143,7 +143,7 @@ func (c *SecretsListCommand) detailedMounts(mounts map[string]*api.MountOutput)
 		}
 	}
 
-	out := []string{"Path | Plugin | Accessor | Default TTL | Max TTL | Force No Cache | Replication | Seal Wrap | Options | Description"}
+	out := []string{"Path | Plugin | Accessor | Default TTL | Max TTL | Force No Cache | Replication | Seal Wrap | Options | Description | Deprecation Status"}
 	for _, path := range paths {
 		mount := mounts[path]
 
@@ -160,7 +160,12 @@ func (c *SecretsListCommand) detailedMounts(mounts map[string]*api.MountOutput)
 			pluginName = mount.Config.PluginName
 		}
 
-		out = append(out, fmt.Sprintf("%s | %s | %s | %s | %s | %t | %s | %t | %v | %s",
+		deprecationStatus := mount.DeprecationStatus
+		if deprecationStatus == "" {
+			deprecationStatus = "n/a"
+		}
+
+		out = append(out, fmt.Sprintf("%s | %s | %s | %s | %s | %t | %s | %t | %v | %s | %s",
 			path,
 			pluginName,
 			mount.Accessor,
@@ -171,6 +176,7 @@ func (c *SecretsListCommand) detailedMounts(mounts map[string]*api.MountOutput)
 			mount.SealWrap,
 			mount.Options,
 			mount.Description,
+			deprecationStatus,
 		))
 	}
 
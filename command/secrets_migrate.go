@@ -0,0 +1,109 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
+)
+
+var _ cli.Command = (*SecretsMigrateCommand)(nil)
+var _ cli.CommandAutocomplete = (*SecretsMigrateCommand)(nil)
+
+type SecretsMigrateCommand struct {
+	*BaseCommand
+
+	flagConnectionName string
+}
+
+func (c *SecretsMigrateCommand) Synopsis() string {
+	return "Migrate a legacy database secrets engine to the database engine"
+}
+
+func (c *SecretsMigrateCommand) Help() string {
+	helpText := `
+Usage: vault secrets migrate [options] PATH
+
+  Converts the cassandra, mongodb or mssql secrets engine at the given path
+  into a database secrets engine in place. Its connection configuration
+  becomes a database connection, its roles become roles of that connection
+  and the leases it issued can still be renewed and revoked.
+
+  Once migrated, the engine must be used through the database secrets engine
+  API; this cannot be undone.
+
+  Migrate the mssql secrets engine at mssql/:
+
+      $ vault secrets migrate mssql/
+
+  Migrate the cassandra secrets engine at cassandra/, naming the connection:
+
+      $ vault secrets migrate -connection-name=cluster1 cassandra/
+
+` + c.Flags().Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *SecretsMigrateCommand) Flags() *FlagSets {
+	set := c.flagSet(FlagSetHTTP | FlagSetOutputFormat)
+
+	f := set.NewFlagSet("Command Options")
+
+	f.StringVar(&StringVar{
+		Name:       "connection-name",
+		Target:     &c.flagConnectionName,
+		Completion: complete.PredictAnything,
+		Usage: "Name of the database connection created from the engine's " +
+			"configuration. This defaults to the type of the engine.",
+	})
+
+	return set
+}
+
+func (c *SecretsMigrateCommand) AutocompleteArgs() complete.Predictor {
+	return c.PredictVaultMounts()
+}
+
+func (c *SecretsMigrateCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *SecretsMigrateCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	args = f.Args()
+	switch {
+	case len(args) < 1:
+		c.UI.Error(fmt.Sprintf("Not enough arguments (expected 1, got %d)", len(args)))
+		return 1
+	case len(args) > 1:
+		c.UI.Error(fmt.Sprintf("Too many arguments (expected 1, got %d)", len(args)))
+		return 1
+	}
+
+	path := ensureTrailingSlash(sanitizePath(args[0]))
+
+	client, err := c.Client()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+
+	secret, err := client.Sys().MigrateToDatabase(path, c.flagConnectionName)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error migrating secrets engine %s: %s", path, err))
+		return 2
+	}
+
+	if Format(c.UI) == "table" {
+		c.UI.Output(fmt.Sprintf("Success! Migrated secrets engine at %s to the database secrets engine", path))
+	}
+	return OutputSecret(c.UI, secret)
+}
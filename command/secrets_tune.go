@@ -25,6 +25,7 @@ type SecretsTuneCommand struct {
 	flagListingVisibility        string
 	flagMaxLeaseTTL              time.Duration
 	flagOptions                  map[string]string
+	flagRequestConcurrencyLimit  int
 	flagVersion                  int
 }
 
@@ -104,6 +105,13 @@ func (c *SecretsTuneCommand) Flags() *FlagSets {
 			"TTL, or a previously configured value for the secrets engine.",
 	})
 
+	f.IntVar(&IntVar{
+		Name:   flagNameRequestConcurrencyLimit,
+		Target: &c.flagRequestConcurrencyLimit,
+		Usage: "Maximum number of requests each token can have in flight against " +
+			"the secrets engine at once. A value of 0 disables the limit.",
+	})
+
 	f.StringMapVar(&StringMapVar{
 		Name:       "options",
 		Target:     &c.flagOptions,
@@ -187,6 +195,10 @@ func (c *SecretsTuneCommand) Run(args []string) int {
 		if fl.Name == flagNameListingVisibility {
 			mountConfigInput.ListingVisibility = c.flagListingVisibility
 		}
+
+		if fl.Name == flagNameRequestConcurrencyLimit {
+			mountConfigInput.RequestConcurrencyLimit = &c.flagRequestConcurrencyLimit
+		}
 	})
 
 	if err := client.Sys().TuneMount(mountPath, mountConfigInput); err != nil {
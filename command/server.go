@@ -31,6 +31,7 @@ import (
 	"github.com/hashicorp/vault/command/server"
 	serverseal "github.com/hashicorp/vault/command/server/seal"
 	"github.com/hashicorp/vault/helper/builtinplugins"
+	"github.com/hashicorp/vault/helper/consts"
 	gatedwriter "github.com/hashicorp/vault/helper/gated-writer"
 	"github.com/hashicorp/vault/helper/jsonutil"
 	"github.com/hashicorp/vault/helper/logging"
@@ -84,9 +85,13 @@ type ServerCommand struct {
 	// new stuff
 	flagConfigs        []string
 	flagLogLevel       string
+	flagLogFormat      string
 	flagDev            bool
 	flagDevRootTokenID string
 	flagDevListenAddr  string
+	flagDevTLS         bool
+	flagDevTLSCertDir  string
+	flagDevEngines     []string
 
 	flagDevPluginDir     string
 	flagDevPluginInit    bool
@@ -102,6 +107,9 @@ type ServerCommand struct {
 	flagDevAutoSeal      bool
 	flagTestVerifyOnly   bool
 	flagCombineLogs      bool
+
+	// devTLSCAPath is the path of the CA certificate generated for -dev-tls
+	devTLSCAPath string
 }
 
 type ServerListener struct {
@@ -168,6 +176,17 @@ func (c *ServerCommand) Flags() *FlagSets {
 			"\"trace\", \"debug\", \"info\", \"warn\", and \"err\".",
 	})
 
+	f.StringVar(&StringVar{
+		Name:       "log-format",
+		Target:     &c.flagLogFormat,
+		Default:    notSetValue,
+		EnvVar:     "VAULT_LOG_FORMAT",
+		Completion: complete.PredictSet("standard", "json"),
+		Usage: "Log format. Supported values are \"standard\" and \"json\". " +
+			"JSON lines carry the \"@timestamp\", \"@level\", \"@module\" and " +
+			"\"@message\" fields.",
+	})
+
 	f = set.NewFlagSet("Dev Options")
 
 	f.BoolVar(&BoolVar{
@@ -195,6 +214,34 @@ func (c *ServerCommand) Flags() *FlagSets {
 		Usage:   "Address to bind to in \"dev\" mode.",
 	})
 
+	f.BoolVar(&BoolVar{
+		Name:    "dev-tls",
+		Target:  &c.flagDevTLS,
+		Default: false,
+		Usage: "Enable TLS in \"dev\" mode. A throwaway CA and a server " +
+			"certificate signed by it are generated at startup, and the CA " +
+			"certificate must be trusted by clients. This implies \"-dev\".",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "dev-tls-cert-dir",
+		Target:     &c.flagDevTLSCertDir,
+		Default:    "",
+		Completion: complete.PredictDirs("*"),
+		Usage: "Directory where the certificates and key generated by " +
+			"\"-dev-tls\" are written. If not set, a temporary directory is used " +
+			"and removed when the server exits.",
+	})
+
+	f.StringSliceVar(&StringSliceVar{
+		Name:       "dev-enable-engine",
+		Target:     &c.flagDevEngines,
+		Completion: complete.PredictSet("transit", "pki", "database", "totp", "ssh"),
+		Usage: "Type of a secrets engine to enable in \"dev\" mode, at a path of " +
+			"the same name. This can be specified multiple times. This implies " +
+			"\"-dev\".",
+	})
+
 	// Internal-only flags to follow.
 	//
 	// Why hello there little source code reader! Welcome to the Vault source
@@ -353,6 +400,16 @@ func (c *ServerCommand) Run(args []string) int {
 		return 1
 	}
 
+	logFormat := logging.UnspecifiedFormat
+	if c.flagLogFormat != notSetValue {
+		var err error
+		logFormat, err = logging.ParseLogFormat(c.flagLogFormat)
+		if err != nil {
+			c.UI.Error(err.Error())
+			return 1
+		}
+	}
+
 	if c.flagDevThreeNode || c.flagDevFourCluster {
 		c.logger = log.New(&log.LoggerOptions{
 			Mutex:  &sync.Mutex{},
@@ -360,13 +417,17 @@ func (c *ServerCommand) Run(args []string) int {
 			Level:  log.Trace,
 		})
 	} else {
-		c.logger = logging.NewVaultLoggerWithWriter(c.logWriter, level)
+		c.logger = logging.NewVaultLoggerWithFormat(c.logWriter, level, logFormat)
 	}
 
 	allLoggers := []log.Logger{c.logger}
 
+	if c.flagDevTLSCertDir != "" {
+		c.flagDevTLS = true
+	}
+
 	// Automatically enable dev mode if other dev flags are provided.
-	if c.flagDevHA || c.flagDevTransactional || c.flagDevLeasedKV || c.flagDevThreeNode || c.flagDevFourCluster || c.flagDevAutoSeal || c.flagDevKVV1 {
+	if c.flagDevHA || c.flagDevTransactional || c.flagDevLeasedKV || c.flagDevThreeNode || c.flagDevFourCluster || c.flagDevAutoSeal || c.flagDevKVV1 || c.flagDevTLS || len(c.flagDevEngines) > 0 {
 		c.flagDev = true
 	}
 
@@ -391,6 +452,38 @@ func (c *ServerCommand) Run(args []string) int {
 		if c.flagDevListenAddr != "" {
 			config.Listeners[0].Config["address"] = c.flagDevListenAddr
 		}
+
+		for _, engine := range c.flagDevEngines {
+			_, ok := c.LogicalBackends[engine]
+			if !ok && !builtinplugins.Registry.Contains(engine, consts.PluginTypeSecrets) {
+				c.UI.Error(fmt.Sprintf("Unknown secrets engine type %q given to -dev-enable-engine", engine))
+				return 1
+			}
+		}
+
+		if c.flagDevTLS {
+			certDir := c.flagDevTLSCertDir
+			if certDir == "" {
+				var err error
+				certDir, err = ioutil.TempDir("", "vault-dev-tls")
+				if err != nil {
+					c.UI.Error(fmt.Sprintf("Error creating directory for dev TLS certificates: %s", err))
+					return 1
+				}
+				defer os.RemoveAll(certDir)
+			}
+
+			caPath, certPath, keyPath, err := generateDevTLS(certDir, config.Listeners[0].Config["address"].(string))
+			if err != nil {
+				c.UI.Error(fmt.Sprintf("Error generating dev TLS certificates: %s", err))
+				return 1
+			}
+			c.devTLSCAPath = caPath
+
+			delete(config.Listeners[0].Config, "tls_disable")
+			config.Listeners[0].Config["tls_cert_file"] = certPath
+			config.Listeners[0].Config["tls_key_file"] = keyPath
+		}
 	}
 	for _, path := range c.flagConfigs {
 		current, err := server.LoadConfig(path, c.logger)
@@ -419,25 +512,46 @@ func (c *ServerCommand) Run(args []string) int {
 	if config.LogLevel != "" && logLevelWasNotSet {
 		configLogLevel := strings.ToLower(strings.TrimSpace(config.LogLevel))
 		logLevelString = configLogLevel
-		switch configLogLevel {
-		case "trace":
-			c.logger.SetLevel(log.Trace)
-		case "debug":
-			c.logger.SetLevel(log.Debug)
-		case "notice", "info", "":
-			c.logger.SetLevel(log.Info)
-		case "warn", "warning":
-			c.logger.SetLevel(log.Warn)
-		case "err", "error":
-			c.logger.SetLevel(log.Error)
-		default:
+		var err error
+		level, err = logging.ParseLogLevel(configLogLevel)
+		if err != nil {
 			c.UI.Error(fmt.Sprintf("Unknown log level: %s", config.LogLevel))
 			return 1
 		}
+		c.logger.SetLevel(level)
+	}
+
+	// The format given on the command line takes precedence over the one of
+	// the configuration, which is only known once the logger is in use
+	if config.LogFormat != "" && logFormat == logging.UnspecifiedFormat && !c.flagDevThreeNode && !c.flagDevFourCluster {
+		var err error
+		logFormat, err = logging.ParseLogFormat(config.LogFormat)
+		if err != nil {
+			c.UI.Error(err.Error())
+			return 1
+		}
+		c.logger = logging.NewVaultLoggerWithFormat(c.logWriter, logging.Level(c.logger), logFormat)
+		allLoggers = []log.Logger{c.logger}
+	}
+
+	logLevels, err := parseLogLevels(config.LogLevels)
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	// subsystemLogger returns the logger of a subsystem set up before the
+	// core, which applies the levels of the other subsystems
+	subsystemLogger := func(name string) log.Logger {
+		logger := logging.NewSubsystemLogger(c.logger, name)
+		if level, ok := logging.SubsystemLevel(logLevels, name); ok {
+			logger.SetLevel(level)
+		}
+		allLoggers = append(allLoggers, logger)
+		return logger
 	}
 
-	namedGRPCLogFaker := c.logger.Named("grpclogfaker")
-	allLoggers = append(allLoggers, namedGRPCLogFaker)
+	namedGRPCLogFaker := subsystemLogger("grpclogfaker")
 	grpclog.SetLogger(&grpclogFaker{
 		logger: namedGRPCLogFaker,
 		log:    os.Getenv("VAULT_GRPC_LOGGING") != "",
@@ -480,8 +594,7 @@ func (c *ServerCommand) Run(args []string) int {
 		c.UI.Error(fmt.Sprintf("Unknown storage type %s", config.Storage.Type))
 		return 1
 	}
-	namedStorageLogger := c.logger.Named("storage." + config.Storage.Type)
-	allLoggers = append(allLoggers, namedStorageLogger)
+	namedStorageLogger := subsystemLogger("storage." + config.Storage.Type)
 	backend, err := factory(config.Storage.Config, namedStorageLogger)
 	if err != nil {
 		c.UI.Error(fmt.Sprintf("Error initializing storage of type %s: %s", config.Storage.Type, err))
@@ -512,8 +625,7 @@ func (c *ServerCommand) Run(args []string) int {
 	if c.flagDevAutoSeal {
 		seal = vault.NewAutoSeal(vaultseal.NewTestSeal(nil))
 	} else {
-		sealLogger := c.logger.Named(sealType)
-		allLoggers = append(allLoggers, sealLogger)
+		sealLogger := subsystemLogger(sealType)
 		seal, sealConfigError = serverseal.ConfigureSeal(config, &infoKeys, &info, sealLogger, vault.NewDefaultSeal())
 		if sealConfigError != nil {
 			if !errwrap.ContainsType(sealConfigError, new(logical.KeyNotFoundError)) {
@@ -560,7 +672,13 @@ func (c *ServerCommand) Run(args []string) int {
 		DisableSealWrap:           config.DisableSealWrap,
 		DisablePerformanceStandby: config.DisablePerformanceStandby,
 		DisableIndexing:           config.DisableIndexing,
+		RenewRateLimit:            config.RenewRateLimit,
+		RenewRateLimitPeriod:      config.RenewRateLimitPeriod,
+		RenewCoalesceWindow:       config.RenewCoalesceWindow,
+		RequestConcurrencyLimit:   config.RequestConcurrencyLimit,
+		RawConfig:                 config,
 		AllLoggers:                allLoggers,
+		LogLevels:                 logLevels,
 		BuiltinRegistry:           builtinplugins.Registry,
 		DisableKeyEncodingChecks:  config.DisablePrintableCheck,
 	}
@@ -582,6 +700,8 @@ func (c *ServerCommand) Run(args []string) int {
 		}
 	}
 
+	coreConfig.Physical = wrapStorage(coreConfig.Physical, config.Storage, namedStorageLogger)
+
 	if c.flagDevThreeNode {
 		return c.enableThreeNodeDevCluster(coreConfig, info, infoKeys, c.flagDevListenAddr, os.Getenv("VAULT_DEV_TEMP_DIR"))
 	}
@@ -663,7 +783,7 @@ func (c *ServerCommand) Run(args []string) int {
 		}
 	}
 	if coreConfig.RedirectAddr == "" && c.flagDev {
-		coreConfig.RedirectAddr = fmt.Sprintf("http://%s", config.Listeners[0].Config["address"])
+		coreConfig.RedirectAddr = fmt.Sprintf("%s://%s", c.devScheme(), config.Listeners[0].Config["address"])
 	}
 
 	// After the redirect bits are sorted out, if no cluster address was
@@ -678,7 +798,7 @@ func (c *ServerCommand) Run(args []string) int {
 		case coreConfig.ClusterAddr == "" && coreConfig.RedirectAddr != "":
 			addrToUse = coreConfig.RedirectAddr
 		case c.flagDev:
-			addrToUse = fmt.Sprintf("http://%s", config.Listeners[0].Config["address"])
+			addrToUse = fmt.Sprintf("%s://%s", c.devScheme(), config.Listeners[0].Config["address"])
 		default:
 			goto CLUSTER_SYNTHESIS_COMPLETE
 		}
@@ -1038,14 +1158,32 @@ CLUSTER_SYNTHESIS_COMPLETE:
 		c.UI.Warn("You may need to set the following environment variable:")
 		c.UI.Warn("")
 
-		endpointURL := "http://" + config.Listeners[0].Config["address"].(string)
+		endpointURL := c.devScheme() + "://" + config.Listeners[0].Config["address"].(string)
 		if runtime.GOOS == "windows" {
 			c.UI.Warn("PowerShell:")
 			c.UI.Warn(fmt.Sprintf("    $env:VAULT_ADDR=\"%s\"", endpointURL))
+			if c.flagDevTLS {
+				c.UI.Warn(fmt.Sprintf("    $env:VAULT_CACERT=\"%s\"", c.devTLSCAPath))
+			}
 			c.UI.Warn("cmd.exe:")
 			c.UI.Warn(fmt.Sprintf("    set VAULT_ADDR=%s", endpointURL))
+			if c.flagDevTLS {
+				c.UI.Warn(fmt.Sprintf("    set VAULT_CACERT=%s", c.devTLSCAPath))
+			}
 		} else {
 			c.UI.Warn(fmt.Sprintf("    $ export VAULT_ADDR='%s'", endpointURL))
+			if c.flagDevTLS {
+				c.UI.Warn(fmt.Sprintf("    $ export VAULT_CACERT='%s'", c.devTLSCAPath))
+			}
+		}
+
+		if c.flagDevTLS {
+			c.UI.Warn("")
+			c.UI.Warn(wrapAtLength(fmt.Sprintf(
+				"The listener uses a certificate signed by a throwaway CA generated "+
+					"for this server. To connect with other clients, trust the CA "+
+					"certificate at %s, which is only valid for this run of the "+
+					"server.", c.devTLSCAPath)))
 		}
 
 		// Unseal key is not returned if stored shares is supported
@@ -1069,6 +1207,15 @@ CLUSTER_SYNTHESIS_COMPLETE:
 
 		c.UI.Warn(fmt.Sprintf("Root Token: %s", init.RootToken))
 
+		if len(c.flagDevEngines) > 0 {
+			c.UI.Warn("")
+			c.UI.Warn(wrapAtLength(
+				"The following secrets engines are enabled:"))
+			for _, engine := range c.flagDevEngines {
+				c.UI.Warn(fmt.Sprintf("    - %s/ (%s)", engine, engine))
+			}
+		}
+
 		if len(plugins) > 0 {
 			c.UI.Warn("")
 			c.UI.Warn(wrapAtLength(
@@ -1193,7 +1340,6 @@ CLUSTER_SYNTHESIS_COMPLETE:
 
 			// Check for new log level
 			var config *server.Config
-			var level log.Level
 			for _, path := range c.flagConfigs {
 				current, err := server.LoadConfig(path, c.logger)
 				if err != nil {
@@ -1214,26 +1360,23 @@ CLUSTER_SYNTHESIS_COMPLETE:
 				goto RUNRELOADFUNCS
 			}
 
+			core.SetConfig(config)
+
 			if config.LogLevel != "" {
-				configLogLevel := strings.ToLower(strings.TrimSpace(config.LogLevel))
-				switch configLogLevel {
-				case "trace":
-					level = log.Trace
-				case "debug":
-					level = log.Debug
-				case "notice", "info", "":
-					level = log.Info
-				case "warn", "warning":
-					level = log.Warn
-				case "err", "error":
-					level = log.Error
-				default:
+				level, err := logging.ParseLogLevel(config.LogLevel)
+				if err != nil {
 					c.logger.Error("unknown log level found on reload", "level", config.LogLevel)
 					goto RUNRELOADFUNCS
 				}
 				core.SetLogLevel(level)
 			}
 
+			if logLevels, err := parseLogLevels(config.LogLevels); err != nil {
+				c.logger.Error("unknown subsystem log level found on reload", "error", err)
+			} else {
+				core.SetLogLevels(logLevels)
+			}
+
 		RUNRELOADFUNCS:
 			if err := c.Reload(c.reloadFuncsLock, c.reloadFuncs, c.flagConfigs); err != nil {
 				c.UI.Error(fmt.Sprintf("Error(s) were encountered during reload: %s", err))
@@ -1383,9 +1526,36 @@ func (c *ServerCommand) enableDev(core *vault.Core, coreConfig *vault.CoreConfig
 		}
 	}
 
+	// Enable the requested secrets engines
+	for _, engine := range c.flagDevEngines {
+		req := &logical.Request{
+			Operation:   logical.UpdateOperation,
+			ClientToken: init.RootToken,
+			Path:        "sys/mounts/" + engine,
+			Data: map[string]interface{}{
+				"type": engine,
+			},
+		}
+		resp, err := core.HandleRequest(ctx, req)
+		if err != nil {
+			return nil, errwrap.Wrapf(fmt.Sprintf("error enabling %q secrets engine: {{err}}", engine), err)
+		}
+		if resp.IsError() {
+			return nil, errwrap.Wrapf(fmt.Sprintf("failed to enable %q secrets engine: {{err}}", engine), resp.Error())
+		}
+	}
+
 	return init, nil
 }
 
+// devScheme returns the scheme of the dev mode listener.
+func (c *ServerCommand) devScheme() string {
+	if c.flagDevTLS {
+		return "https"
+	}
+	return "http"
+}
+
 func (c *ServerCommand) enableThreeNodeDevCluster(base *vault.CoreConfig, info map[string]string, infoKeys []string, devListenAddress, tempDir string) int {
 	testCluster := vault.NewTestCluster(&testing.RuntimeT{}, base, &vault.TestClusterOptions{
 		HandlerFunc:       vaulthttp.Handler,
@@ -1787,6 +1957,25 @@ func (c *ServerCommand) setupTelemetry(config *server.Config) error {
 	return nil
 }
 
+// parseLogLevels parses the levels of the subsystems given in the
+// configuration
+func parseLogLevels(levels map[string]string) (map[string]log.Level, error) {
+	if len(levels) == 0 {
+		return nil, nil
+	}
+
+	ret := make(map[string]log.Level, len(levels))
+	for name, levelRaw := range levels {
+		level, err := logging.ParseLogLevel(levelRaw)
+		if err != nil {
+			return nil, errwrap.Wrapf(fmt.Sprintf("error parsing the log level of %q: {{err}}", name), err)
+		}
+		ret[name] = level
+	}
+
+	return ret, nil
+}
+
 func (c *ServerCommand) Reload(lock *sync.RWMutex, reloadFuncs *map[string][]reload.ReloadFunc, configPath []string) error {
 	lock.RLock()
 	defer lock.RUnlock()
@@ -1856,6 +2045,31 @@ func (c *ServerCommand) removePidFile(pidPath string) error {
 	return os.Remove(pidPath)
 }
 
+// wrapStorage wraps the storage backend with the request timeout and circuit
+// breaker of its configuration. The circuit breaker is outermost so that
+// requests that time out count as failures.
+func wrapStorage(backend physical.Backend, storage *server.Storage, logger log.Logger) physical.Backend {
+	_, txnOK := backend.(physical.Transactional)
+
+	if storage.RequestTimeout > 0 {
+		if txnOK {
+			backend = physical.NewTransactionalTimeoutBackend(backend, storage.RequestTimeout, logger)
+		} else {
+			backend = physical.NewTimeoutBackend(backend, storage.RequestTimeout, logger)
+		}
+	}
+
+	if storage.CircuitBreakerThreshold > 0 {
+		if txnOK {
+			backend = physical.NewTransactionalCircuitBreaker(backend, storage.CircuitBreakerThreshold, storage.CircuitBreakerResetTimeout, logger)
+		} else {
+			backend = physical.NewCircuitBreaker(backend, storage.CircuitBreakerThreshold, storage.CircuitBreakerResetTimeout, logger)
+		}
+	}
+
+	return backend
+}
+
 // storageMigrationActive checks and warns against in-progress storage migrations.
 // This function will block until storage is available.
 func (c *ServerCommand) storageMigrationActive(backend physical.Backend) bool {
@@ -16,6 +16,7 @@ import (
 	multierror "github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/hcl"
 	"github.com/hashicorp/hcl/hcl/ast"
+	"github.com/hashicorp/vault/helper/logging"
 	"github.com/hashicorp/vault/helper/parseutil"
 )
 
@@ -48,12 +49,22 @@ type Config struct {
 	DefaultMaxRequestDuration    time.Duration `hcl:"-"`
 	DefaultMaxRequestDurationRaw interface{}   `hcl:"default_max_request_duration"`
 
+	RenewRateLimit          int           `hcl:"renew_rate_limit"`
+	RenewRateLimitPeriod    time.Duration `hcl:"-"`
+	RenewRateLimitPeriodRaw interface{}   `hcl:"renew_rate_limit_period"`
+	RenewCoalesceWindow     time.Duration `hcl:"-"`
+	RenewCoalesceWindowRaw  interface{}   `hcl:"renew_coalesce_window"`
+
+	RequestConcurrencyLimit int `hcl:"request_concurrency_limit"`
+
 	ClusterName         string `hcl:"cluster_name"`
 	ClusterCipherSuites string `hcl:"cluster_cipher_suites"`
 
 	PluginDirectory string `hcl:"plugin_directory"`
 
-	LogLevel string `hcl:"log_level"`
+	LogLevel  string            `hcl:"log_level"`
+	LogLevels map[string]string `hcl:"log_levels"`
+	LogFormat string            `hcl:"log_format"`
 
 	PidFile              string      `hcl:"pid_file"`
 	EnableRawEndpoint    bool        `hcl:"-"`
@@ -130,6 +141,15 @@ type Storage struct {
 	ClusterAddr       string
 	DisableClustering bool
 	Config            map[string]string
+
+	// RequestTimeout bounds the duration of the requests to the backend
+	RequestTimeout time.Duration
+
+	// CircuitBreakerThreshold is the number of consecutive failed requests
+	// after which requests to the backend are rejected for
+	// CircuitBreakerResetTimeout. Zero disables the circuit breaker.
+	CircuitBreakerThreshold    int
+	CircuitBreakerResetTimeout time.Duration
 }
 
 func (b *Storage) GoString() string {
@@ -310,11 +330,46 @@ func (c *Config) Merge(c2 *Config) *Config {
 		result.DefaultMaxRequestDuration = c2.DefaultMaxRequestDuration
 	}
 
+	result.RenewRateLimit = c.RenewRateLimit
+	if c2.RenewRateLimit != 0 {
+		result.RenewRateLimit = c2.RenewRateLimit
+	}
+
+	result.RenewRateLimitPeriod = c.RenewRateLimitPeriod
+	if c2.RenewRateLimitPeriod != 0 {
+		result.RenewRateLimitPeriod = c2.RenewRateLimitPeriod
+	}
+
+	result.RenewCoalesceWindow = c.RenewCoalesceWindow
+	if c2.RenewCoalesceWindow != 0 {
+		result.RenewCoalesceWindow = c2.RenewCoalesceWindow
+	}
+
+	result.RequestConcurrencyLimit = c.RequestConcurrencyLimit
+	if c2.RequestConcurrencyLimit != 0 {
+		result.RequestConcurrencyLimit = c2.RequestConcurrencyLimit
+	}
+
 	result.LogLevel = c.LogLevel
 	if c2.LogLevel != "" {
 		result.LogLevel = c2.LogLevel
 	}
 
+	if len(c.LogLevels) > 0 || len(c2.LogLevels) > 0 {
+		result.LogLevels = make(map[string]string, len(c.LogLevels)+len(c2.LogLevels))
+		for k, v := range c.LogLevels {
+			result.LogLevels[k] = v
+		}
+		for k, v := range c2.LogLevels {
+			result.LogLevels[k] = v
+		}
+	}
+
+	result.LogFormat = c.LogFormat
+	if c2.LogFormat != "" {
+		result.LogFormat = c2.LogFormat
+	}
+
 	result.ClusterName = c.ClusterName
 	if c2.ClusterName != "" {
 		result.ClusterName = c2.ClusterName
@@ -406,6 +461,101 @@ func (c *Config) Merge(c2 *Config) *Config {
 	return result
 }
 
+// Sanitized returns the configuration without the values that may hold
+// secrets: the storage and seal stanzas only report their type and the
+// options Vault itself interprets, and the telemetry stanza omits the
+// Circonus API token.
+func (c *Config) Sanitized() map[string]interface{} {
+	result := map[string]interface{}{
+		"cache_size":                   c.CacheSize,
+		"disable_cache":                c.DisableCache,
+		"disable_mlock":                c.DisableMlock,
+		"disable_printable_check":      c.DisablePrintableCheck,
+		"ui":                           c.EnableUI,
+		"max_lease_ttl":                int64(c.MaxLeaseTTL.Seconds()),
+		"default_lease_ttl":            int64(c.DefaultLeaseTTL.Seconds()),
+		"default_max_request_duration": int64(c.DefaultMaxRequestDuration.Seconds()),
+		"renew_rate_limit":             c.RenewRateLimit,
+		"renew_rate_limit_period":      int64(c.RenewRateLimitPeriod.Seconds()),
+		"renew_coalesce_window":        int64(c.RenewCoalesceWindow.Seconds()),
+		"request_concurrency_limit":    c.RequestConcurrencyLimit,
+		"cluster_name":                 c.ClusterName,
+		"cluster_cipher_suites":        c.ClusterCipherSuites,
+		"plugin_directory":             c.PluginDirectory,
+		"log_level":                    c.LogLevel,
+		"log_levels":                   c.LogLevels,
+		"log_format":                   c.LogFormat,
+		"pid_file":                     c.PidFile,
+		"raw_storage_endpoint":         c.EnableRawEndpoint,
+		"api_addr":                     c.APIAddr,
+		"cluster_addr":                 c.ClusterAddr,
+		"disable_clustering":           c.DisableClustering,
+		"disable_performance_standby":  c.DisablePerformanceStandby,
+		"disable_sealwrap":             c.DisableSealWrap,
+		"disable_indexing":             c.DisableIndexing,
+	}
+
+	// Listener options are addresses, file paths and flags, none of which
+	// are secret
+	listeners := make([]interface{}, 0, len(c.Listeners))
+	for _, l := range c.Listeners {
+		listeners = append(listeners, map[string]interface{}{
+			"type":   l.Type,
+			"config": l.Config,
+		})
+	}
+	result["listeners"] = listeners
+
+	sanitizedStorage := func(s *Storage) map[string]interface{} {
+		return map[string]interface{}{
+			"type":                          s.Type,
+			"redirect_addr":                 s.RedirectAddr,
+			"cluster_addr":                  s.ClusterAddr,
+			"disable_clustering":            s.DisableClustering,
+			"request_timeout":               int64(s.RequestTimeout.Seconds()),
+			"circuit_breaker_threshold":     s.CircuitBreakerThreshold,
+			"circuit_breaker_reset_timeout": int64(s.CircuitBreakerResetTimeout.Seconds()),
+		}
+	}
+	if c.Storage != nil {
+		result["storage"] = sanitizedStorage(c.Storage)
+	}
+	if c.HAStorage != nil {
+		result["ha_storage"] = sanitizedStorage(c.HAStorage)
+	}
+
+	if c.Seal != nil {
+		result["seal"] = map[string]interface{}{
+			"type":     c.Seal.Type,
+			"disabled": c.Seal.Disabled,
+		}
+	}
+
+	if t := c.Telemetry; t != nil {
+		result["telemetry"] = map[string]interface{}{
+			"statsite_address":                       t.StatsiteAddr,
+			"statsd_address":                         t.StatsdAddr,
+			"disable_hostname":                       t.DisableHostname,
+			"circonus_api_app":                       t.CirconusAPIApp,
+			"circonus_api_url":                       t.CirconusAPIURL,
+			"circonus_submission_interval":           t.CirconusSubmissionInterval,
+			"circonus_submission_url":                t.CirconusCheckSubmissionURL,
+			"circonus_check_id":                      t.CirconusCheckID,
+			"circonus_check_force_metric_activation": t.CirconusCheckForceMetricActivation,
+			"circonus_check_instance_id":             t.CirconusCheckInstanceID,
+			"circonus_check_search_tag":              t.CirconusCheckSearchTag,
+			"circonus_check_tags":                    t.CirconusCheckTags,
+			"circonus_check_display_name":            t.CirconusCheckDisplayName,
+			"circonus_broker_id":                     t.CirconusBrokerID,
+			"circonus_broker_select_tag":             t.CirconusBrokerSelectTag,
+			"dogstatsd_addr":                         t.DogStatsDAddr,
+			"dogstatsd_tags":                         t.DogStatsDTags,
+		}
+	}
+
+	return result
+}
+
 // LoadConfig loads the configuration at the given path, regardless if
 // its a file or directory.
 func LoadConfig(path string, logger log.Logger) (*Config, error) {
@@ -460,6 +610,24 @@ func ParseConfig(d string, logger log.Logger) (*Config, error) {
 		}
 	}
 
+	if result.RenewRateLimit < 0 {
+		return nil, fmt.Errorf("renew_rate_limit cannot be negative")
+	}
+	if result.RenewRateLimitPeriodRaw != nil {
+		if result.RenewRateLimitPeriod, err = parseutil.ParseDurationSecond(result.RenewRateLimitPeriodRaw); err != nil {
+			return nil, err
+		}
+	}
+	if result.RenewCoalesceWindowRaw != nil {
+		if result.RenewCoalesceWindow, err = parseutil.ParseDurationSecond(result.RenewCoalesceWindowRaw); err != nil {
+			return nil, err
+		}
+	}
+
+	if result.RequestConcurrencyLimit < 0 {
+		return nil, fmt.Errorf("request_concurrency_limit cannot be negative")
+	}
+
 	if result.EnableUIRaw != nil {
 		if result.EnableUI, err = parseutil.ParseBool(result.EnableUIRaw); err != nil {
 			return nil, err
@@ -514,6 +682,16 @@ func ParseConfig(d string, logger log.Logger) (*Config, error) {
 		}
 	}
 
+	if _, err := logging.ParseLogFormat(result.LogFormat); err != nil {
+		return nil, err
+	}
+
+	for name, level := range result.LogLevels {
+		if _, err := logging.ParseLogLevel(level); err != nil {
+			return nil, errwrap.Wrapf(fmt.Sprintf("error parsing the log level of %q: {{err}}", name), err)
+		}
+	}
+
 	list, ok := obj.Node.(*ast.ObjectList)
 	if !ok {
 		return nil, fmt.Errorf("error parsing: file doesn't contain a root object")
@@ -691,6 +869,44 @@ func ParseStorage(result *Config, list *ast.ObjectList, name string) error {
 		delete(m, "disable_clustering")
 	}
 
+	// Pull out the request timeout and circuit breaker settings since they
+	// apply to all backends
+	var requestTimeout time.Duration
+	if v, ok := m["request_timeout"]; ok {
+		requestTimeout, err = parseutil.ParseDurationSecond(v)
+		if err != nil {
+			return multierror.Prefix(err, fmt.Sprintf("%s.%s:", name, key))
+		}
+		if requestTimeout < 0 {
+			return multierror.Prefix(fmt.Errorf("request_timeout cannot be negative"), fmt.Sprintf("%s.%s:", name, key))
+		}
+		delete(m, "request_timeout")
+	}
+
+	var circuitBreakerThreshold int
+	if v, ok := m["circuit_breaker_threshold"]; ok {
+		circuitBreakerThreshold, err = strconv.Atoi(v)
+		if err != nil {
+			return multierror.Prefix(err, fmt.Sprintf("%s.%s:", name, key))
+		}
+		if circuitBreakerThreshold < 0 {
+			return multierror.Prefix(fmt.Errorf("circuit_breaker_threshold cannot be negative"), fmt.Sprintf("%s.%s:", name, key))
+		}
+		delete(m, "circuit_breaker_threshold")
+	}
+
+	var circuitBreakerResetTimeout time.Duration
+	if v, ok := m["circuit_breaker_reset_timeout"]; ok {
+		circuitBreakerResetTimeout, err = parseutil.ParseDurationSecond(v)
+		if err != nil {
+			return multierror.Prefix(err, fmt.Sprintf("%s.%s:", name, key))
+		}
+		if circuitBreakerResetTimeout < 0 {
+			return multierror.Prefix(fmt.Errorf("circuit_breaker_reset_timeout cannot be negative"), fmt.Sprintf("%s.%s:", name, key))
+		}
+		delete(m, "circuit_breaker_reset_timeout")
+	}
+
 	// Override with top-level values if they are set
 	if result.APIAddr != "" {
 		redirectAddr = result.APIAddr
@@ -705,11 +921,14 @@ func ParseStorage(result *Config, list *ast.ObjectList, name string) error {
 	}
 
 	result.Storage = &Storage{
-		RedirectAddr:      redirectAddr,
-		ClusterAddr:       clusterAddr,
-		DisableClustering: disableClustering,
-		Type:              strings.ToLower(key),
-		Config:            m,
+		RedirectAddr:               redirectAddr,
+		ClusterAddr:                clusterAddr,
+		DisableClustering:          disableClustering,
+		Type:                       strings.ToLower(key),
+		Config:                     m,
+		RequestTimeout:             requestTimeout,
+		CircuitBreakerThreshold:    circuitBreakerThreshold,
+		CircuitBreakerResetTimeout: circuitBreakerResetTimeout,
 	}
 	return nil
 }
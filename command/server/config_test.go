@@ -1,6 +1,7 @@
 package server
 
 import (
+	"fmt"
 	"reflect"
 	"strings"
 	"testing"
@@ -401,3 +402,190 @@ listener "tcp" {
 	}
 
 }
+
+func TestParseConfig_logging(t *testing.T) {
+	logger := logging.NewVaultLogger(log.Debug)
+
+	config, err := ParseConfig(strings.TrimSpace(`
+log_level = "info"
+log_format = "json"
+log_levels {
+	expiration = "trace"
+	"storage.consul" = "warn"
+}`), logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.LogLevel != "info" || config.LogFormat != "json" {
+		t.Fatalf("bad config: %#v", config)
+	}
+	expected := map[string]string{
+		"expiration":     "trace",
+		"storage.consul": "warn",
+	}
+	if !reflect.DeepEqual(config.LogLevels, expected) {
+		t.Fatalf("expected log levels %v, got %v", expected, config.LogLevels)
+	}
+
+	config2, err := ParseConfig(`{"log_format": "standard", "log_levels": {"expiration": "debug", "core": "error"}}`, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	merged := config.Merge(config2)
+	if merged.LogFormat != "standard" || merged.LogLevel != "info" {
+		t.Fatalf("bad merged config: %#v", merged)
+	}
+	expected = map[string]string{
+		"core":           "error",
+		"expiration":     "debug",
+		"storage.consul": "warn",
+	}
+	if !reflect.DeepEqual(merged.LogLevels, expected) {
+		t.Fatalf("expected merged log levels %v, got %v", expected, merged.LogLevels)
+	}
+
+	for _, input := range []string{
+		`log_format = "xml"`,
+		`log_levels { core = "verbose" }`,
+	} {
+		if _, err := ParseConfig(input, logger); err == nil {
+			t.Fatalf("expected an error parsing %q", input)
+		}
+	}
+}
+
+func TestParseConfig_renewals(t *testing.T) {
+	logger := logging.NewVaultLogger(log.Debug)
+
+	config, err := ParseConfig(strings.TrimSpace(`
+renew_rate_limit = 60
+renew_rate_limit_period = "10m"
+renew_coalesce_window = "5s"`), logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.RenewRateLimit != 60 || config.RenewRateLimitPeriod != 10*time.Minute || config.RenewCoalesceWindow != 5*time.Second {
+		t.Fatalf("bad config: %#v", config)
+	}
+
+	config2, err := ParseConfig(`{"renew_coalesce_window": 10}`, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	merged := config.Merge(config2)
+	if merged.RenewRateLimit != 60 || merged.RenewCoalesceWindow != 10*time.Second {
+		t.Fatalf("bad merged config: %#v", merged)
+	}
+
+	if _, err := ParseConfig(`renew_rate_limit = -1`, logger); err == nil {
+		t.Fatal("expected an error with a negative renew_rate_limit")
+	}
+}
+
+func TestParseConfig_requestConcurrencyLimit(t *testing.T) {
+	logger := logging.NewVaultLogger(log.Debug)
+
+	config, err := ParseConfig(`request_concurrency_limit = 32`, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.RequestConcurrencyLimit != 32 {
+		t.Fatalf("bad config: %#v", config)
+	}
+
+	merged := config.Merge(&Config{})
+	if merged.RequestConcurrencyLimit != 32 {
+		t.Fatalf("bad merged config: %#v", merged)
+	}
+
+	if _, err := ParseConfig(`request_concurrency_limit = -1`, logger); err == nil {
+		t.Fatal("expected an error with a negative request_concurrency_limit")
+	}
+}
+
+func TestParseConfig_storageTimeouts(t *testing.T) {
+	logger := logging.NewVaultLogger(log.Debug)
+
+	config, err := ParseConfig(strings.TrimSpace(`
+storage "consul" {
+  address                       = "127.0.0.1:8500"
+  request_timeout               = "10s"
+  circuit_breaker_threshold     = 5
+  circuit_breaker_reset_timeout = "1m"
+}`), logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := &Storage{
+		Type: "consul",
+		Config: map[string]string{
+			"address": "127.0.0.1:8500",
+		},
+		RequestTimeout:             10 * time.Second,
+		CircuitBreakerThreshold:    5,
+		CircuitBreakerResetTimeout: time.Minute,
+	}
+	if !reflect.DeepEqual(config.Storage, expected) {
+		t.Fatalf("expected \n\n%#v\n\n to be \n\n%#v\n\n", config.Storage, expected)
+	}
+
+	if _, err := ParseConfig(`storage "consul" { circuit_breaker_threshold = -1 }`, logger); err == nil {
+		t.Fatal("expected an error with a negative circuit_breaker_threshold")
+	}
+	if _, err := ParseConfig(`storage "consul" { request_timeout = "soon" }`, logger); err == nil {
+		t.Fatal("expected an error with an invalid request_timeout")
+	}
+}
+
+func TestConfig_Sanitized(t *testing.T) {
+	logger := logging.NewVaultLogger(log.Debug)
+
+	config, err := ParseConfig(strings.TrimSpace(`
+listener "tcp" {
+  address = "127.0.0.1:8200"
+}
+storage "consul" {
+  address         = "127.0.0.1:8500"
+  token           = "storage-secret"
+  request_timeout = "10s"
+}
+seal "awskms" {
+  kms_key_id = "seal-secret"
+}
+telemetry {
+  statsd_address     = "127.0.0.1:8125"
+  circonus_api_token = "telemetry-secret"
+}
+max_lease_ttl = "10h"
+cluster_name  = "testcluster"`), logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sanitized := config.Sanitized()
+	if dump := fmt.Sprintf("%#v", sanitized); strings.Contains(dump, "secret") {
+		t.Fatalf("expected the secrets to be left out, got: %s", dump)
+	}
+
+	if sanitized["max_lease_ttl"] != int64(36000) || sanitized["cluster_name"] != "testcluster" {
+		t.Fatalf("bad: %#v", sanitized)
+	}
+	listeners := sanitized["listeners"].([]interface{})
+	if len(listeners) != 1 || listeners[0].(map[string]interface{})["type"] != "tcp" {
+		t.Fatalf("bad listeners: %#v", listeners)
+	}
+	storage := sanitized["storage"].(map[string]interface{})
+	if storage["type"] != "consul" || storage["request_timeout"] != int64(10) {
+		t.Fatalf("bad storage: %#v", storage)
+	}
+	if _, ok := sanitized["ha_storage"]; ok {
+		t.Fatalf("expected no HA storage, got: %#v", sanitized["ha_storage"])
+	}
+	if seal := sanitized["seal"].(map[string]interface{}); seal["type"] != "awskms" {
+		t.Fatalf("bad seal: %#v", seal)
+	}
+	if telemetry := sanitized["telemetry"].(map[string]interface{}); telemetry["statsd_address"] != "127.0.0.1:8125" {
+		t.Fatalf("bad telemetry: %#v", telemetry)
+	}
+}
@@ -0,0 +1,118 @@
+package command
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+)
+
+const (
+	devCAFilename   = "vault-ca.pem"
+	devCertFilename = "vault-cert.pem"
+	devKeyFilename  = "vault-key.pem"
+
+	// devTLSValidity is how long the generated dev mode certificates are
+	// valid for.
+	devTLSValidity = 365 * 24 * time.Hour
+)
+
+// generateDevTLS writes a throwaway CA certificate and a server certificate
+// signed by it for the given listen address to dir. It returns the paths of
+// the CA certificate, server certificate and server key.
+func generateDevTLS(dir, listenAddr string) (caPath, certPath, keyPath string, err error) {
+	host, _, err := net.SplitHostPort(listenAddr)
+	if err != nil {
+		return "", "", "", errwrap.Wrapf("error parsing listen address: {{err}}", err)
+	}
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return "", "", "", errwrap.Wrapf("error generating CA key: {{err}}", err)
+	}
+
+	now := time.Now()
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Vault Dev CA"},
+		NotBefore:             now.Add(-30 * time.Second),
+		NotAfter:              now.Add(devTLSValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+	caBytes, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, caKey.Public(), caKey)
+	if err != nil {
+		return "", "", "", errwrap.Wrapf("error generating CA certificate: {{err}}", err)
+	}
+	caCert, err := x509.ParseCertificate(caBytes)
+	if err != nil {
+		return "", "", "", errwrap.Wrapf("error parsing CA certificate: {{err}}", err)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return "", "", "", errwrap.Wrapf("error generating server key: {{err}}", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+		NotBefore:    now.Add(-30 * time.Second),
+		NotAfter:     now.Add(devTLSValidity),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		if !ip.IsLoopback() && !ip.IsUnspecified() {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		}
+	} else if host != "" && host != "localhost" {
+		template.DNSNames = append(template.DNSNames, host)
+	}
+	certBytes, err := x509.CreateCertificate(rand.Reader, template, caCert, key.Public(), caKey)
+	if err != nil {
+		return "", "", "", errwrap.Wrapf("error generating server certificate: {{err}}", err)
+	}
+
+	keyBytes, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return "", "", "", errwrap.Wrapf("error marshaling server key: {{err}}", err)
+	}
+
+	caPath = filepath.Join(dir, devCAFilename)
+	certPath = filepath.Join(dir, devCertFilename)
+	keyPath = filepath.Join(dir, devKeyFilename)
+
+	files := []struct {
+		path      string
+		blockType string
+		bytes     []byte
+		mode      os.FileMode
+	}{
+		{caPath, "CERTIFICATE", caBytes, 0644},
+		{certPath, "CERTIFICATE", certBytes, 0644},
+		{keyPath, "EC PRIVATE KEY", keyBytes, 0600},
+	}
+	for _, f := range files {
+		data := pem.EncodeToMemory(&pem.Block{Type: f.blockType, Bytes: f.bytes})
+		if err := ioutil.WriteFile(f.path, data, f.mode); err != nil {
+			return "", "", "", errwrap.Wrapf("error writing dev TLS file: {{err}}", err)
+		}
+	}
+
+	return caPath, certPath, keyPath, nil
+}
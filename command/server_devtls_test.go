@@ -0,0 +1,59 @@
+package command
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestServer_GenerateDevTLS(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "vault-dev-tls-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	caPath, certPath, keyPath, err := generateDevTLS(dir, "10.0.0.5:8200")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := tls.LoadX509KeyPair(certPath, keyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	caPEM, err := ioutil.ReadFile(caPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		t.Fatal("failed to parse CA certificate")
+	}
+
+	for _, name := range []string{"localhost", "127.0.0.1", "::1", "10.0.0.5"} {
+		if _, err := leaf.Verify(x509.VerifyOptions{
+			DNSName: name,
+			Roots:   pool,
+		}); err != nil {
+			t.Fatalf("certificate not valid for %q: %v", name, err)
+		}
+	}
+
+	info, err := os.Stat(keyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Fatalf("bad: key file mode: %v", info.Mode())
+	}
+}
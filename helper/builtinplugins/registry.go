@@ -9,36 +9,42 @@ import (
 	credAzure "github.com/hashicorp/vault-plugin-auth-azure"
 	credCentrify "github.com/hashicorp/vault-plugin-auth-centrify"
 	credGcp "github.com/hashicorp/vault-plugin-auth-gcp/plugin"
-	credJWT "github.com/hashicorp/vault-plugin-auth-jwt"
-	credKube "github.com/hashicorp/vault-plugin-auth-kubernetes"
 	credAppId "github.com/hashicorp/vault/builtin/credential/app-id"
 	credAppRole "github.com/hashicorp/vault/builtin/credential/approle"
 	credAws "github.com/hashicorp/vault/builtin/credential/aws"
 	credCert "github.com/hashicorp/vault/builtin/credential/cert"
 	credGitHub "github.com/hashicorp/vault/builtin/credential/github"
+	credJWT "github.com/hashicorp/vault/builtin/credential/jwt"
+	credKube "github.com/hashicorp/vault/builtin/credential/kubernetes"
 	credLdap "github.com/hashicorp/vault/builtin/credential/ldap"
 	credOkta "github.com/hashicorp/vault/builtin/credential/okta"
 	credRadius "github.com/hashicorp/vault/builtin/credential/radius"
+	credSAML "github.com/hashicorp/vault/builtin/credential/saml"
 	credUserpass "github.com/hashicorp/vault/builtin/credential/userpass"
 
 	dbCass "github.com/hashicorp/vault/plugins/database/cassandra"
+	dbElastic "github.com/hashicorp/vault/plugins/database/elasticsearch"
 	dbHana "github.com/hashicorp/vault/plugins/database/hana"
 	dbInflux "github.com/hashicorp/vault/plugins/database/influxdb"
 	dbMongo "github.com/hashicorp/vault/plugins/database/mongodb"
 	dbMssql "github.com/hashicorp/vault/plugins/database/mssql"
 	dbMysql "github.com/hashicorp/vault/plugins/database/mysql"
 	dbPostgres "github.com/hashicorp/vault/plugins/database/postgresql"
+	dbSnowflake "github.com/hashicorp/vault/plugins/database/snowflake"
 
 	logicalAd "github.com/hashicorp/vault-plugin-secrets-ad/plugin"
 	logicalAlicloud "github.com/hashicorp/vault-plugin-secrets-alicloud"
 	logicalAzure "github.com/hashicorp/vault-plugin-secrets-azure"
 	logicalGcp "github.com/hashicorp/vault-plugin-secrets-gcp/plugin"
 	logicalGcpKms "github.com/hashicorp/vault-plugin-secrets-gcpkms"
-	logicalKv "github.com/hashicorp/vault-plugin-secrets-kv"
+	logicalArtifactory "github.com/hashicorp/vault/builtin/logical/artifactory"
 	logicalAws "github.com/hashicorp/vault/builtin/logical/aws"
 	logicalCass "github.com/hashicorp/vault/builtin/logical/cassandra"
 	logicalConsul "github.com/hashicorp/vault/builtin/logical/consul"
+	logicalKube "github.com/hashicorp/vault/builtin/logical/kubernetes"
+	logicalKv "github.com/hashicorp/vault/builtin/logical/kv"
 	logicalMongo "github.com/hashicorp/vault/builtin/logical/mongodb"
+	logicalMongoAtlas "github.com/hashicorp/vault/builtin/logical/mongodbatlas"
 	logicalMssql "github.com/hashicorp/vault/builtin/logical/mssql"
 	logicalMysql "github.com/hashicorp/vault/builtin/logical/mysql"
 	logicalNomad "github.com/hashicorp/vault/builtin/logical/nomad"
@@ -46,6 +52,7 @@ import (
 	logicalPostgres "github.com/hashicorp/vault/builtin/logical/postgresql"
 	logicalRabbit "github.com/hashicorp/vault/builtin/logical/rabbitmq"
 	logicalSsh "github.com/hashicorp/vault/builtin/logical/ssh"
+	logicalTerraform "github.com/hashicorp/vault/builtin/logical/terraform"
 	logicalTotp "github.com/hashicorp/vault/builtin/logical/totp"
 	logicalTransit "github.com/hashicorp/vault/builtin/logical/transit"
 )
@@ -73,8 +80,10 @@ func newRegistry() *registry {
 			"jwt":        credJWT.Factory,
 			"kubernetes": credKube.Factory,
 			"ldap":       credLdap.Factory,
+			"oidc":       credJWT.Factory,
 			"okta":       credOkta.Factory,
 			"radius":     credRadius.Factory,
+			"saml":       credSAML.Factory,
 			"userpass":   credUserpass.Factory,
 		},
 		databasePlugins: map[string]BuiltinFactory{
@@ -85,33 +94,53 @@ func newRegistry() *registry {
 			"mysql-rds-database-plugin":    dbMysql.New(credsutil.NoneLength, dbMysql.LegacyMetadataLen, dbMysql.LegacyUsernameLen),
 			"mysql-legacy-database-plugin": dbMysql.New(credsutil.NoneLength, dbMysql.LegacyMetadataLen, dbMysql.LegacyUsernameLen),
 
-			"postgresql-database-plugin": dbPostgres.New,
-			"mssql-database-plugin":      dbMssql.New,
-			"cassandra-database-plugin":  dbCass.New,
-			"mongodb-database-plugin":    dbMongo.New,
-			"hana-database-plugin":       dbHana.New,
-			"influxdb-database-plugin":   dbInflux.New,
+			"postgresql-database-plugin":    dbPostgres.New,
+			"mssql-database-plugin":         dbMssql.New,
+			"cassandra-database-plugin":     dbCass.New,
+			"mongodb-database-plugin":       dbMongo.New,
+			"hana-database-plugin":          dbHana.New,
+			"influxdb-database-plugin":      dbInflux.New,
+			"snowflake-database-plugin":     dbSnowflake.New,
+			"elasticsearch-database-plugin": dbElastic.New,
 		},
 		logicalBackends: map[string]logical.Factory{
-			"ad":         logicalAd.Factory,
-			"alicloud":   logicalAlicloud.Factory,
-			"aws":        logicalAws.Factory,
-			"azure":      logicalAzure.Factory,
-			"cassandra":  logicalCass.Factory,
-			"consul":     logicalConsul.Factory,
-			"gcp":        logicalGcp.Factory,
-			"gcpkms":     logicalGcpKms.Factory,
-			"kv":         logicalKv.Factory,
-			"mongodb":    logicalMongo.Factory,
-			"mssql":      logicalMssql.Factory,
-			"mysql":      logicalMysql.Factory,
-			"nomad":      logicalNomad.Factory,
-			"pki":        logicalPki.Factory,
-			"postgresql": logicalPostgres.Factory,
-			"rabbitmq":   logicalRabbit.Factory,
-			"ssh":        logicalSsh.Factory,
-			"totp":       logicalTotp.Factory,
-			"transit":    logicalTransit.Factory,
+			"ad":           logicalAd.Factory,
+			"alicloud":     logicalAlicloud.Factory,
+			"artifactory":  logicalArtifactory.Factory,
+			"aws":          logicalAws.Factory,
+			"azure":        logicalAzure.Factory,
+			"cassandra":    logicalCass.Factory,
+			"consul":       logicalConsul.Factory,
+			"gcp":          logicalGcp.Factory,
+			"gcpkms":       logicalGcpKms.Factory,
+			"kubernetes":   logicalKube.Factory,
+			"kv":           logicalKv.Factory,
+			"mongodb":      logicalMongo.Factory,
+			"mongodbatlas": logicalMongoAtlas.Factory,
+			"mssql":        logicalMssql.Factory,
+			"mysql":        logicalMysql.Factory,
+			"nomad":        logicalNomad.Factory,
+			"pki":          logicalPki.Factory,
+			"postgresql":   logicalPostgres.Factory,
+			"rabbitmq":     logicalRabbit.Factory,
+			"ssh":          logicalSsh.Factory,
+			"terraform":    logicalTerraform.Factory,
+			"totp":         logicalTotp.Factory,
+			"transit":      logicalTransit.Factory,
+		},
+		deprecationStatus: map[consts.PluginType]map[string]consts.DeprecationStatus{
+			consts.PluginTypeCredential: {
+				// Superseded by approle
+				"app-id": consts.Deprecated,
+			},
+			consts.PluginTypeSecrets: {
+				// Superseded by the database secrets engine
+				"cassandra":  consts.Deprecated,
+				"mongodb":    consts.Deprecated,
+				"mssql":      consts.Deprecated,
+				"mysql":      consts.Deprecated,
+				"postgresql": consts.Deprecated,
+			},
 		},
 	}
 }
@@ -120,6 +149,10 @@ type registry struct {
 	credentialBackends map[string]logical.Factory
 	databasePlugins    map[string]BuiltinFactory
 	logicalBackends    map[string]logical.Factory
+
+	// deprecationStatus holds the status of the builtin plugins which are no
+	// longer supported, by plugin type. Removed plugins are only listed here.
+	deprecationStatus map[consts.PluginType]map[string]consts.DeprecationStatus
 }
 
 // Get returns the BuiltinFactory func for a particular backend plugin
@@ -169,6 +202,18 @@ func (r *registry) Contains(name string, pluginType consts.PluginType) bool {
 	return false
 }
 
+// DeprecationStatus returns the deprecation status of a builtin plugin, and
+// false if there is no builtin plugin with that name.
+func (r *registry) DeprecationStatus(name string, pluginType consts.PluginType) (consts.DeprecationStatus, bool) {
+	if status, ok := r.deprecationStatus[pluginType][name]; ok {
+		return status, true
+	}
+	if r.Contains(name, pluginType) {
+		return consts.Supported, true
+	}
+	return consts.Unknown, false
+}
+
 func toFunc(ifc interface{}) func() (interface{}, error) {
 	return func() (interface{}, error) {
 		return ifc, nil
@@ -0,0 +1,30 @@
+package consts
+
+// DeprecationStatus is the lifecycle status of a builtin plugin.
+type DeprecationStatus uint32
+
+// This is a list of the DeprecationStatuses of builtin plugins. A plugin is
+// first deprecated, mounted with a warning; it's then pending removal, when
+// new mounts are refused by default; and it's finally removed.
+const (
+	Unknown DeprecationStatus = iota
+	Supported
+	Deprecated
+	PendingRemoval
+	Removed
+)
+
+func (s DeprecationStatus) String() string {
+	switch s {
+	case Supported:
+		return "supported"
+	case Deprecated:
+		return "deprecated"
+	case PendingRemoval:
+		return "pending removal"
+	case Removed:
+		return "removed"
+	default:
+		return "unknown"
+	}
+}
@@ -0,0 +1,196 @@
+package keysutil
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+
+	"github.com/hashicorp/vault/helper/jsonutil"
+)
+
+// backupEnvelopeVersion is the version of the backup format wrapping the key
+// data in an envelope with an integrity HMAC
+const backupEnvelopeVersion = 1
+
+// backupEnvelope wraps the key data of a backup along with an HMAC of it. The
+// HMAC is keyed with the HMAC key of the latest version of the key, so that
+// backups can be restored on any mount, and is checked on restore to detect
+// corrupted, truncated or edited backups.
+type backupEnvelope struct {
+	Version int             `json:"envelope_version"`
+	KeyData json.RawMessage `json:"key_data"`
+	HMAC    []byte          `json:"hmac"`
+}
+
+// backupHMAC returns the HMAC of the encoded key data of a backup
+func backupHMAC(keyData *KeyData, encodedKeyData []byte) ([]byte, error) {
+	entry, ok := keyData.Policy.Keys[strconv.Itoa(keyData.Policy.LatestVersion)]
+	if !ok {
+		return nil, fmt.Errorf("latest version %d of the key not found", keyData.Policy.LatestVersion)
+	}
+
+	mac := hmac.New(sha256.New, entry.HMACKey)
+	mac.Write(encodedKeyData)
+	return mac.Sum(nil), nil
+}
+
+// encodeBackup encodes the key data into a backup envelope
+func encodeBackup(keyData *KeyData) (string, error) {
+	// The key data is marshaled compactly, as it would otherwise be compacted
+	// when embedded in the envelope and no longer match its HMAC
+	encodedKeyData, err := json.Marshal(keyData)
+	if err != nil {
+		return "", err
+	}
+
+	sum, err := backupHMAC(keyData, encodedKeyData)
+	if err != nil {
+		return "", err
+	}
+
+	encodedBackup, err := jsonutil.EncodeJSON(&backupEnvelope{
+		Version: backupEnvelopeVersion,
+		KeyData: encodedKeyData,
+		HMAC:    sum,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(encodedBackup), nil
+}
+
+// decodeBackup decodes and verifies a backup. Backups taken before the
+// envelope was introduced only contain the key data, and have no HMAC to
+// verify.
+func decodeBackup(backup string) (*KeyData, error) {
+	backupBytes, err := base64.StdEncoding.DecodeString(backup)
+	if err != nil {
+		return nil, err
+	}
+
+	var envelope backupEnvelope
+	if err := jsonutil.DecodeJSON(backupBytes, &envelope); err != nil {
+		return nil, err
+	}
+
+	encodedKeyData := []byte(envelope.KeyData)
+	if envelope.Version == 0 {
+		encodedKeyData = backupBytes
+	} else if envelope.Version != backupEnvelopeVersion {
+		return nil, fmt.Errorf("unsupported backup version %d", envelope.Version)
+	}
+
+	var keyData KeyData
+	if err := jsonutil.DecodeJSON(encodedKeyData, &keyData); err != nil {
+		return nil, err
+	}
+	if err := keyData.validate(); err != nil {
+		return nil, err
+	}
+
+	if envelope.Version != 0 {
+		sum, err := backupHMAC(&keyData, encodedKeyData)
+		if err != nil {
+			return nil, err
+		}
+		if !hmac.Equal(sum, envelope.HMAC) {
+			return nil, errors.New("backup integrity check failed: the backup is corrupted or has been modified")
+		}
+	}
+
+	return &keyData, nil
+}
+
+// validate checks the consistency of the versions of the key data of a backup
+func (kd *KeyData) validate() error {
+	p := kd.Policy
+	switch {
+	case p == nil:
+		return errors.New("backup does not contain a key")
+	case p.LatestVersion < 1:
+		return fmt.Errorf("invalid latest version %d of the key", p.LatestVersion)
+	case p.MinDecryptionVersion < 0 || p.MinDecryptionVersion > p.LatestVersion:
+		return fmt.Errorf("minimum decryption version %d of the key is out of range", p.MinDecryptionVersion)
+	case p.MinEncryptionVersion < 0 || p.MinEncryptionVersion > p.LatestVersion:
+		return fmt.Errorf("minimum encryption version %d of the key is out of range", p.MinEncryptionVersion)
+	case p.MinEncryptionVersion > 0 && p.MinEncryptionVersion < p.MinDecryptionVersion:
+		return fmt.Errorf("minimum encryption version %d of the key is lower than its minimum decryption version %d", p.MinEncryptionVersion, p.MinDecryptionVersion)
+	}
+
+	if _, ok := p.Keys[strconv.Itoa(p.LatestVersion)]; !ok {
+		return fmt.Errorf("latest version %d of the key not found", p.LatestVersion)
+	}
+
+	return nil
+}
+
+// keyEntry returns the entry of a version of the key of the backup, looking
+// into the archived keys for versions older than the minimum decryption
+// version
+func (kd *KeyData) keyEntry(version int) (KeyEntry, bool) {
+	if entry, ok := kd.Policy.Keys[strconv.Itoa(version)]; ok {
+		return entry, true
+	}
+	if kd.ArchivedKeys != nil && version > 0 && version < len(kd.ArchivedKeys.Keys) {
+		return kd.ArchivedKeys.Keys[version], true
+	}
+	return KeyEntry{}, false
+}
+
+// checkRestoreOverwrite checks that restoring the key data over an existing
+// key can't lose key versions: the backup must be of the same key, and at
+// least as recent.
+func (kd *KeyData) checkRestoreOverwrite(existing *Policy) error {
+	if existing.Type != kd.Policy.Type {
+		return fmt.Errorf("cannot restore a key of type %s over the existing key %q of type %s", kd.Policy.Type, existing.Name, existing.Type)
+	}
+
+	if kd.Policy.LatestVersion < existing.LatestVersion {
+		return fmt.Errorf("cannot restore version %d of the key over the existing key %q at version %d, as newer key versions would be lost", kd.Policy.LatestVersion, existing.Name, existing.LatestVersion)
+	}
+
+	existingEntry, ok := existing.Keys[strconv.Itoa(existing.LatestVersion)]
+	if !ok {
+		return fmt.Errorf("latest version %d of the existing key %q not found", existing.LatestVersion, existing.Name)
+	}
+	entry, ok := kd.keyEntry(existing.LatestVersion)
+	if !ok || !sameKeyMaterial(entry, existingEntry) {
+		return fmt.Errorf("cannot restore over the existing key %q, as the backup is of a different key", existing.Name)
+	}
+
+	return nil
+}
+
+// sameKeyMaterial returns whether two key entries hold the same key
+func sameKeyMaterial(a, b KeyEntry) bool {
+	if !bytes.Equal(a.Key, b.Key) || !bytes.Equal(a.HMACKey, b.HMACKey) || a.FormattedPublicKey != b.FormattedPublicKey {
+		return false
+	}
+
+	if !sameBigInt(a.EC_D, b.EC_D) {
+		return false
+	}
+
+	switch {
+	case a.RSAKey == nil && b.RSAKey == nil:
+		return true
+	case a.RSAKey == nil || b.RSAKey == nil:
+		return false
+	default:
+		return sameBigInt(a.RSAKey.D, b.RSAKey.D)
+	}
+}
+
+func sameBigInt(a, b *big.Int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Cmp(b) == 0
+}
@@ -0,0 +1,119 @@
+package keysutil
+
+import (
+	"crypto/aes"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// kwpMagic is the high half of the alternative initial value of AES key
+// wrap with padding, see RFC 5649 section 3.
+var kwpMagic = []byte{0xa6, 0x59, 0x59, 0xa6}
+
+// WrapKeyWithPadding wraps the given key material with the given AES key
+// encryption key using AES key wrap with padding (RFC 5649).
+func WrapKeyWithPadding(kek, plaintext []byte) ([]byte, error) {
+	if len(plaintext) == 0 {
+		return nil, errors.New("key material to wrap is empty")
+	}
+
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+
+	var aiv [8]byte
+	copy(aiv[:], kwpMagic)
+	binary.BigEndian.PutUint32(aiv[4:], uint32(len(plaintext)))
+
+	n := (len(plaintext) + 7) / 8
+	padded := make([]byte, n*8)
+	copy(padded, plaintext)
+
+	if n == 1 {
+		out := make([]byte, 16)
+		copy(out, aiv[:])
+		copy(out[8:], padded)
+		block.Encrypt(out, out)
+		return out, nil
+	}
+
+	// Wrapping process of RFC 3394 section 2.2.1 with the alternative
+	// initial value
+	a := aiv
+	r := padded
+	var b [16]byte
+	for j := 0; j < 6; j++ {
+		for i := 0; i < n; i++ {
+			copy(b[:8], a[:])
+			copy(b[8:], r[i*8:(i+1)*8])
+			block.Encrypt(b[:], b[:])
+
+			t := uint64(n*j + i + 1)
+			binary.BigEndian.PutUint64(a[:], binary.BigEndian.Uint64(b[:8])^t)
+			copy(r[i*8:(i+1)*8], b[8:])
+		}
+	}
+
+	out := make([]byte, 0, 8+len(r))
+	out = append(out, a[:]...)
+	return append(out, r...), nil
+}
+
+// UnwrapKeyWithPadding unwraps key material wrapped with AES key wrap with
+// padding (RFC 5649) using the given AES key encryption key.
+func UnwrapKeyWithPadding(kek, ciphertext []byte) ([]byte, error) {
+	if len(ciphertext) < 16 || len(ciphertext)%8 != 0 {
+		return nil, fmt.Errorf("invalid wrapped key length %d", len(ciphertext))
+	}
+
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+
+	n := len(ciphertext)/8 - 1
+	var a [8]byte
+	r := make([]byte, n*8)
+
+	if n == 1 {
+		var b [16]byte
+		block.Decrypt(b[:], ciphertext)
+		copy(a[:], b[:8])
+		copy(r, b[8:])
+	} else {
+		// Unwrapping process of RFC 3394 section 2.2.2
+		copy(a[:], ciphertext[:8])
+		copy(r, ciphertext[8:])
+		var b [16]byte
+		for j := 5; j >= 0; j-- {
+			for i := n - 1; i >= 0; i-- {
+				t := uint64(n*j + i + 1)
+				binary.BigEndian.PutUint64(b[:8], binary.BigEndian.Uint64(a[:])^t)
+				copy(b[8:], r[i*8:(i+1)*8])
+				block.Decrypt(b[:], b[:])
+
+				copy(a[:], b[:8])
+				copy(r[i*8:(i+1)*8], b[8:])
+			}
+		}
+	}
+
+	if subtle.ConstantTimeCompare(a[:4], kwpMagic) != 1 {
+		return nil, errors.New("failed to unwrap key: integrity check failed")
+	}
+
+	length := int(binary.BigEndian.Uint32(a[4:]))
+	if length <= 8*(n-1) || length > 8*n {
+		return nil, errors.New("failed to unwrap key: integrity check failed")
+	}
+	for _, c := range r[length:] {
+		if c != 0 {
+			return nil, errors.New("failed to unwrap key: integrity check failed")
+		}
+	}
+
+	return r[:length], nil
+}
@@ -0,0 +1,52 @@
+package keysutil
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func TestKeyWrapWithPadding(t *testing.T) {
+	// Test vectors from RFC 5649 section 6
+	kek, _ := hex.DecodeString("5840df6e29b02af1ab493b705bf16ea1ae8338f4dcc176a8")
+
+	cases := []struct {
+		key     string
+		wrapped string
+	}{
+		{
+			"c37b7e6492584340bed12207808941155068f738",
+			"138bdeaa9b8fa7fc61f97742e72248ee5ae6ae5360d1ae6a5f54f373fa543b6a",
+		},
+		{
+			"466f7250617369",
+			"afbeb0f07dfbf5419200f2ccb50bb24f",
+		},
+	}
+
+	for _, tc := range cases {
+		key, _ := hex.DecodeString(tc.key)
+		expected, _ := hex.DecodeString(tc.wrapped)
+
+		wrapped, err := WrapKeyWithPadding(kek, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(wrapped, expected) {
+			t.Fatalf("bad: wrapped key\nexpected: %x\nactual:   %x", expected, wrapped)
+		}
+
+		unwrapped, err := UnwrapKeyWithPadding(kek, wrapped)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(unwrapped, key) {
+			t.Fatalf("bad: unwrapped key\nexpected: %x\nactual:   %x", key, unwrapped)
+		}
+
+		wrapped[len(wrapped)-1] ^= 0x01
+		if _, err := UnwrapKeyWithPadding(kek, wrapped); err == nil {
+			t.Fatal("expected error unwrapping modified ciphertext")
+		}
+	}
+}
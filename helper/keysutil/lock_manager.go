@@ -2,7 +2,6 @@ package keysutil
 
 import (
 	"context"
-	"encoding/base64"
 	"errors"
 	"fmt"
 	"sync"
@@ -10,7 +9,7 @@ import (
 	"time"
 
 	"github.com/hashicorp/errwrap"
-	"github.com/hashicorp/vault/helper/jsonutil"
+	"github.com/hashicorp/vault/helper/errutil"
 	"github.com/hashicorp/vault/helper/locksutil"
 	"github.com/hashicorp/vault/logical"
 )
@@ -51,6 +50,9 @@ type PolicyRequest struct {
 
 	// Whether to allow plaintext backup
 	AllowPlaintextBackup bool
+
+	// Whether to allow rotation of an imported key
+	AllowImportedKeyRotation bool
 }
 
 type LockManager struct {
@@ -82,15 +84,9 @@ func (lm *LockManager) InvalidatePolicy(name string) {
 // RestorePolicy acquires an exclusive lock on the policy name and restores the
 // given policy along with the archive.
 func (lm *LockManager) RestorePolicy(ctx context.Context, storage logical.Storage, name, backup string, force bool) error {
-	backupBytes, err := base64.StdEncoding.DecodeString(backup)
-	if err != nil {
-		return err
-	}
-
-	var keyData KeyData
-	err = jsonutil.DecodeJSON(backupBytes, &keyData)
+	keyData, err := decodeBackup(backup)
 	if err != nil {
-		return err
+		return errwrap.Wrapf("invalid backup: {{err}}", err)
 	}
 
 	// Set a different name if desired
@@ -99,6 +95,9 @@ func (lm *LockManager) RestorePolicy(ctx context.Context, storage logical.Storag
 	}
 
 	name = keyData.Policy.Name
+	if name == "" {
+		return fmt.Errorf("backup does not contain the name of the key")
+	}
 
 	// Grab the exclusive lock as we'll be modifying disk
 	lock := locksutil.LockForKey(lm.keyLocks, name)
@@ -150,6 +149,11 @@ func (lm *LockManager) RestorePolicy(ctx context.Context, storage logical.Storag
 	if p != nil {
 		p.l.Lock()
 		defer p.l.Unlock()
+
+		// Forcing the restore must not lose versions of the existing key
+		if err := keyData.checkRestoreOverwrite(p); err != nil {
+			return err
+		}
 	}
 
 	// Restore the archived keys
@@ -304,57 +308,12 @@ func (lm *LockManager) GetPolicy(ctx context.Context, req PolicyRequest) (retP *
 		// to the user to let them know that their request can't be satisfied
 		// because we don't know if the parameters match.
 
-		switch req.KeyType {
-		case KeyType_AES256_GCM96, KeyType_ChaCha20_Poly1305:
-			if req.Convergent && !req.Derived {
-				cleanup()
-				return nil, false, fmt.Errorf("convergent encryption requires derivation to be enabled")
-			}
-
-		case KeyType_ECDSA_P256:
-			if req.Derived || req.Convergent {
-				cleanup()
-				return nil, false, fmt.Errorf("key derivation and convergent encryption not supported for keys of type %v", req.KeyType)
-			}
-
-		case KeyType_ED25519:
-			if req.Convergent {
-				cleanup()
-				return nil, false, fmt.Errorf("convergent encryption not supported for keys of type %v", req.KeyType)
-			}
-
-		case KeyType_RSA2048, KeyType_RSA4096:
-			if req.Derived || req.Convergent {
-				cleanup()
-				return nil, false, fmt.Errorf("key derivation and convergent encryption not supported for keys of type %v", req.KeyType)
-			}
-
-		default:
+		if err := checkPolicyRequest(req); err != nil {
 			cleanup()
-			return nil, false, fmt.Errorf("unsupported key type %v", req.KeyType)
-		}
-
-		p = &Policy{
-			l:                    new(sync.RWMutex),
-			Name:                 req.Name,
-			Type:                 req.KeyType,
-			Derived:              req.Derived,
-			Exportable:           req.Exportable,
-			AllowPlaintextBackup: req.AllowPlaintextBackup,
+			return nil, false, err
 		}
 
-		if req.Derived {
-			p.KDF = Kdf_hkdf_sha256
-			if req.Convergent {
-				p.ConvergentEncryption = true
-				// As of version 3 we store the version within each key, so we
-				// set to -1 to indicate that the value in the policy has no
-				// meaning. We still, for backwards compatibility, fall back to
-				// this value if the key doesn't have one, which means it will
-				// only be -1 in the case where every key version is >= 3
-				p.ConvergentVersion = -1
-			}
-		}
+		p = newPolicyFromRequest(req)
 
 		// Performs the actual persist and does setup
 		err = p.Rotate(ctx, req.Storage)
@@ -396,6 +355,106 @@ func (lm *LockManager) GetPolicy(ctx context.Context, req PolicyRequest) (retP *
 	return
 }
 
+// ImportPolicy creates the policy named in the request with the given key
+// material as its first version. It is an error if the policy already exists.
+func (lm *LockManager) ImportPolicy(ctx context.Context, req PolicyRequest, key []byte) error {
+	if err := checkPolicyRequest(req); err != nil {
+		return errutil.UserError{Err: err.Error()}
+	}
+
+	lock := locksutil.LockForKey(lm.keyLocks, req.Name)
+	lock.Lock()
+	defer lock.Unlock()
+
+	var ok bool
+	if lm.useCache {
+		_, ok = lm.cache.Load(req.Name)
+	}
+	if !ok {
+		p, err := lm.getPolicyFromStorage(ctx, req.Storage, req.Name)
+		if err != nil {
+			return err
+		}
+		ok = p != nil
+	}
+	if ok {
+		return errutil.UserError{Err: fmt.Sprintf("key %q already exists", req.Name)}
+	}
+
+	p := newPolicyFromRequest(req)
+	p.Imported = true
+	p.AllowImportedKeyRotation = req.AllowImportedKeyRotation
+
+	if err := p.Import(ctx, req.Storage, key); err != nil {
+		return err
+	}
+
+	if lm.useCache {
+		lm.cache.Store(req.Name, p)
+	}
+
+	return nil
+}
+
+// checkPolicyRequest verifies that the options of a request creating a new
+// policy are supported by its key type.
+func checkPolicyRequest(req PolicyRequest) error {
+	switch req.KeyType {
+	case KeyType_AES256_GCM96, KeyType_ChaCha20_Poly1305:
+		if req.Convergent && !req.Derived {
+			return fmt.Errorf("convergent encryption requires derivation to be enabled")
+		}
+
+	case KeyType_ECDSA_P256:
+		if req.Derived || req.Convergent {
+			return fmt.Errorf("key derivation and convergent encryption not supported for keys of type %v", req.KeyType)
+		}
+
+	case KeyType_ED25519:
+		if req.Convergent {
+			return fmt.Errorf("convergent encryption not supported for keys of type %v", req.KeyType)
+		}
+
+	case KeyType_RSA2048, KeyType_RSA4096:
+		if req.Derived || req.Convergent {
+			return fmt.Errorf("key derivation and convergent encryption not supported for keys of type %v", req.KeyType)
+		}
+
+	default:
+		return fmt.Errorf("unsupported key type %v", req.KeyType)
+	}
+
+	return nil
+}
+
+// newPolicyFromRequest returns a policy without any key versions with the
+// options of the request.
+func newPolicyFromRequest(req PolicyRequest) *Policy {
+	p := &Policy{
+		l:                    new(sync.RWMutex),
+		Name:                 req.Name,
+		Type:                 req.KeyType,
+		Derived:              req.Derived,
+		Exportable:           req.Exportable,
+		AllowPlaintextBackup: req.AllowPlaintextBackup,
+	}
+
+	if req.Derived {
+		p.KDF = Kdf_hkdf_sha256
+		if req.Convergent {
+			p.ConvergentEncryption = true
+			// As of version 3 we store the version within each key, so we
+			// set to -1 to indicate that the value in the policy has no
+			// meaning. We still, for backwards compatibility, fall back to
+			// this value if the key doesn't have one, which means it will
+			// only be -1 in the case where every key version is >= 3
+			p.ConvergentVersion = -1
+		}
+	}
+
+	return p
+}
+
 func (lm *LockManager) DeletePolicy(ctx context.Context, storage logical.Storage, name string) error {
 	var p *Policy
 	var err error
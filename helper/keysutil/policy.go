@@ -7,11 +7,13 @@ import (
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/elliptic"
 	"crypto/hmac"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/sha256"
+	"crypto/sha512"
 	"crypto/x509"
 	"encoding/asn1"
 	"encoding/base64"
@@ -29,7 +31,6 @@ import (
 	"time"
 
 	"golang.org/x/crypto/chacha20poly1305"
-	"golang.org/x/crypto/ed25519"
 	"golang.org/x/crypto/hkdf"
 
 	"github.com/hashicorp/errwrap"
@@ -81,10 +82,47 @@ type SigningResult struct {
 	PublicKey []byte
 }
 
+// SigningOptions holds the parameters of a signing or verification operation
+// in addition to the key version, derivation context and input.
+type SigningOptions struct {
+	HashAlgorithm HashType
+	Marshaling    MarshalingType
+	SigAlgorithm  string
+
+	// Prehashed indicates that the input is already a digest. For ed25519
+	// keys this selects Ed25519ph and the input must be a SHA-512 digest.
+	Prehashed bool
+
+	// SigContext is the context string bound into Ed25519ctx and Ed25519ph
+	// signatures. It is unrelated to the key derivation context.
+	SigContext []byte
+
+	// Deterministic selects RFC 6979 deterministic ECDSA signatures instead
+	// of randomized ones.
+	Deterministic bool
+}
+
 type ecdsaSignature struct {
 	R, S *big.Int
 }
 
+// ed25519Options returns the ed25519 signing options selecting pure Ed25519,
+// Ed25519ctx or Ed25519ph as requested.
+func ed25519Options(options *SigningOptions) (*ed25519.Options, error) {
+	if len(options.SigContext) > 255 {
+		return nil, errutil.UserError{Err: "signature context must be at most 255 bytes"}
+	}
+
+	opts := &ed25519.Options{
+		Context: string(options.SigContext),
+	}
+	if options.Prehashed {
+		opts.Hash = crypto.SHA512
+	}
+
+	return opts, nil
+}
+
 type KeyType int
 
 func (kt KeyType) EncryptionSupported() bool {
@@ -339,6 +377,12 @@ type Policy struct {
 	// The version of the convergent nonce to use
 	ConvergentVersion int `json:"convergent_version"`
 
+	// ConvergentStartVersion is the first key version using the current
+	// convergent version when convergent encryption was enabled on an existing
+	// key. Earlier versions keep using random nonces, or the version of the
+	// convergent nonce above if the key was convergent already.
+	ConvergentStartVersion int `json:"convergent_start_version"`
+
 	// The type of key
 	Type KeyType `json:"type"`
 
@@ -362,6 +406,18 @@ type Policy struct {
 	// policy object.
 	StoragePrefix string `json:"storage_prefix"`
 
+	// Imported indicates that the key material was imported rather than
+	// generated by Vault
+	Imported bool `json:"imported_key"`
+
+	// AllowImportedKeyRotation allows an imported key to be rotated, which
+	// adds a version generated by Vault
+	AllowImportedKeyRotation bool `json:"allow_imported_key_rotation"`
+
+	// AutoRotatePeriod is the interval after which a new version of the key
+	// is generated automatically. Zero disables automatic rotation.
+	AutoRotatePeriod time.Duration `json:"auto_rotate_period"`
+
 	// versionPrefixCache stores caches of version prefix strings and the split
 	// version template.
 	versionPrefixCache sync.Map
@@ -756,6 +812,11 @@ func (p *Policy) convergentVersion(ver int) int {
 		return 0
 	}
 
+	if ver < p.ConvergentStartVersion && p.ConvergentVersion < 0 {
+		// Created before convergent encryption was enabled
+		return 0
+	}
+
 	convergentVersion := p.ConvergentVersion
 	if convergentVersion == 0 {
 		// For some reason, not upgraded yet
@@ -851,8 +912,7 @@ func (p *Policy) Encrypt(ver int, context, nonce []byte, value string) (string,
 			aead = cha
 		}
 
-		if p.ConvergentEncryption {
-			convergentVersion := p.convergentVersion(ver)
+		if convergentVersion := p.convergentVersion(ver); convergentVersion > 0 {
 			switch convergentVersion {
 			case 1:
 				if len(nonce) != aead.NonceSize() {
@@ -881,7 +941,7 @@ func (p *Policy) Encrypt(ver int, context, nonce []byte, value string) (string,
 		ciphertext = aead.Seal(nil, nonce, plaintext, nil)
 
 		// Place the encrypted data after the nonce
-		if !p.ConvergentEncryption || p.convergentVersion(ver) > 1 {
+		if p.convergentVersion(ver) != 1 {
 			ciphertext = append(nonce, ciphertext...)
 		}
 
@@ -1001,7 +1061,7 @@ func (p *Policy) Decrypt(context, nonce []byte, value string) (string, error) {
 
 		// Extract the nonce and ciphertext
 		var ciphertext []byte
-		if p.ConvergentEncryption && convergentVersion == 1 {
+		if convergentVersion == 1 {
 			ciphertext = decoded
 		} else {
 			nonce = decoded[:aead.NonceSize()]
@@ -1044,6 +1104,14 @@ func (p *Policy) HMACKey(version int) ([]byte, error) {
 }
 
 func (p *Policy) Sign(ver int, context, input []byte, hashAlgorithm HashType, sigAlgorithm string, marshaling MarshalingType) (*SigningResult, error) {
+	return p.SignWithOptions(ver, context, input, &SigningOptions{
+		HashAlgorithm: hashAlgorithm,
+		Marshaling:    marshaling,
+		SigAlgorithm:  sigAlgorithm,
+	})
+}
+
+func (p *Policy) SignWithOptions(ver int, context, input []byte, options *SigningOptions) (*SigningResult, error) {
 	if !p.Type.SigningSupported() {
 		return nil, fmt.Errorf("message signing not supported for key type %v", p.Type)
 	}
@@ -1059,6 +1127,17 @@ func (p *Policy) Sign(ver int, context, input []byte, hashAlgorithm HashType, si
 		return nil, errutil.UserError{Err: "requested version for signing is less than the minimum encryption key version"}
 	}
 
+	hashAlgorithm := options.HashAlgorithm
+	marshaling := options.Marshaling
+	sigAlgorithm := options.SigAlgorithm
+
+	if p.Type != KeyType_ED25519 && len(options.SigContext) > 0 {
+		return nil, errutil.UserError{Err: "a signature context is only supported for ed25519 keys"}
+	}
+	if p.Type != KeyType_ECDSA_P256 && options.Deterministic {
+		return nil, errutil.UserError{Err: "deterministic signatures are only supported for ecdsa keys"}
+	}
+
 	var sig []byte
 	var pubKey []byte
 	var err error
@@ -1075,9 +1154,31 @@ func (p *Policy) Sign(ver int, context, input []byte, hashAlgorithm HashType, si
 			D: keyParams.EC_D,
 		}
 
-		r, s, err := ecdsa.Sign(rand.Reader, key, input)
-		if err != nil {
-			return nil, err
+		var r, s *big.Int
+		if options.Deterministic {
+			// Passing a nil random source makes the signature deterministic
+			// per RFC 6979; the input must be a digest of the hash algorithm
+			algo, ok := CryptoHashMap[hashAlgorithm]
+			if !ok {
+				return nil, errutil.InternalError{Err: "unsupported hash algorithm"}
+			}
+			if len(input) != algo.Size() {
+				return nil, errutil.UserError{Err: "input length does not match the hash algorithm"}
+			}
+			asn1Sig, err := key.Sign(nil, input, algo)
+			if err != nil {
+				return nil, err
+			}
+			var ecdsaSig ecdsaSignature
+			if _, err := asn1.Unmarshal(asn1Sig, &ecdsaSig); err != nil {
+				return nil, err
+			}
+			r, s = ecdsaSig.R, ecdsaSig.S
+		} else {
+			r, s, err = ecdsa.Sign(rand.Reader, key, input)
+			if err != nil {
+				return nil, err
+			}
 		}
 
 		switch marshaling {
@@ -1130,9 +1231,17 @@ func (p *Policy) Sign(ver int, context, input []byte, hashAlgorithm HashType, si
 			key = ed25519.PrivateKey(p.Keys[strconv.Itoa(ver)].Key)
 		}
 
-		// Per docs, do not pre-hash ed25519; it does two passes and performs
-		// its own hashing
-		sig, err = key.Sign(rand.Reader, input, crypto.Hash(0))
+		opts, err := ed25519Options(options)
+		if err != nil {
+			return nil, err
+		}
+		if options.Prehashed && len(input) != sha512.Size {
+			return nil, errutil.UserError{Err: "prehashed input for ed25519 keys must be a SHA-512 digest"}
+		}
+
+		// Per docs, do not pre-hash ed25519 unless Ed25519ph was requested;
+		// it does two passes and performs its own hashing
+		sig, err = key.Sign(rand.Reader, input, opts)
 		if err != nil {
 			return nil, err
 		}
@@ -1196,10 +1305,26 @@ func (p *Policy) Sign(ver int, context, input []byte, hashAlgorithm HashType, si
 }
 
 func (p *Policy) VerifySignature(context, input []byte, hashAlgorithm HashType, sigAlgorithm string, marshaling MarshalingType, sig string) (bool, error) {
+	return p.VerifySignatureWithOptions(context, input, sig, &SigningOptions{
+		HashAlgorithm: hashAlgorithm,
+		Marshaling:    marshaling,
+		SigAlgorithm:  sigAlgorithm,
+	})
+}
+
+func (p *Policy) VerifySignatureWithOptions(context, input []byte, sig string, options *SigningOptions) (bool, error) {
 	if !p.Type.SigningSupported() {
 		return false, errutil.UserError{Err: fmt.Sprintf("message verification not supported for key type %v", p.Type)}
 	}
 
+	hashAlgorithm := options.HashAlgorithm
+	marshaling := options.Marshaling
+	sigAlgorithm := options.SigAlgorithm
+
+	if p.Type != KeyType_ED25519 && len(options.SigContext) > 0 {
+		return false, errutil.UserError{Err: "a signature context is only supported for ed25519 keys"}
+	}
+
 	tplParts, err := p.getTemplateParts()
 	if err != nil {
 		return false, err
@@ -1288,7 +1413,12 @@ func (p *Policy) VerifySignature(context, input []byte, hashAlgorithm HashType,
 			key = ed25519.PrivateKey(p.Keys[strconv.Itoa(ver)].Key)
 		}
 
-		return ed25519.Verify(key.Public().(ed25519.PublicKey), input, sigBytes), nil
+		opts, err := ed25519Options(options)
+		if err != nil {
+			return false, err
+		}
+
+		return ed25519.VerifyWithOptions(key.Public().(ed25519.PublicKey), input, sigBytes, opts) == nil, nil
 
 	case KeyType_RSA2048, KeyType_RSA4096:
 		key := p.Keys[strconv.Itoa(ver)].RSAKey
@@ -1329,7 +1459,65 @@ func (p *Policy) VerifySignature(context, input []byte, hashAlgorithm HashType,
 	}
 }
 
-func (p *Policy) Rotate(ctx context.Context, storage logical.Storage) (retErr error) {
+// Rotate adds a new, randomly generated version of the key to the policy.
+func (p *Policy) Rotate(ctx context.Context, storage logical.Storage) error {
+	return p.rotate(ctx, storage, nil)
+}
+
+// Import adds a new version of the key to the policy using the given key
+// material. Symmetric keys are given as raw bytes and asymmetric keys as
+// PKCS#8 DER encoded private keys.
+func (p *Policy) Import(ctx context.Context, storage logical.Storage, key []byte) error {
+	if len(key) == 0 {
+		return errutil.UserError{Err: "key material to import is empty"}
+	}
+	return p.rotate(ctx, storage, key)
+}
+
+// EnableConvergentEncryption enables convergent encryption using the current
+// convergent version, which derives nonces from the plaintext and context
+// rather than requiring them to be supplied, for the key versions created
+// from now on. Existing versions are left as they are so their ciphertexts
+// can still be decrypted, making this a mixed-mode key; the key is rotated so
+// that new encryptions are convergent. This is a no-op if new versions already
+// use the current convergent version.
+func (p *Policy) EnableConvergentEncryption(ctx context.Context, storage logical.Storage) (retErr error) {
+	if p.ConvergentEncryption && (p.ConvergentVersion < 0 || p.ConvergentVersion > 1 || p.ConvergentStartVersion > 0) {
+		return nil
+	}
+
+	switch {
+	case !p.Derived:
+		return errutil.UserError{Err: "convergent encryption requires derivation to be enabled"}
+	case p.Type != KeyType_AES256_GCM96 && p.Type != KeyType_ChaCha20_Poly1305:
+		return errutil.UserError{Err: fmt.Sprintf("convergent encryption not supported for keys of type %v", p.Type)}
+	case p.Imported && !p.AllowImportedKeyRotation:
+		return errutil.UserError{Err: "imported key does not allow rotation, convergent encryption cannot be enabled"}
+	}
+
+	priorConvergentEncryption := p.ConvergentEncryption
+	priorConvergentVersion := p.ConvergentVersion
+	priorConvergentStartVersion := p.ConvergentStartVersion
+	defer func() {
+		if retErr != nil {
+			p.ConvergentEncryption = priorConvergentEncryption
+			p.ConvergentVersion = priorConvergentVersion
+			p.ConvergentStartVersion = priorConvergentStartVersion
+		}
+	}()
+
+	if !p.ConvergentEncryption {
+		// Keys stored before the convergent version was tracked have zero
+		// here, which would be taken for version 1
+		p.ConvergentVersion = -1
+	}
+	p.ConvergentEncryption = true
+	p.ConvergentStartVersion = p.LatestVersion + 1
+
+	return p.Rotate(ctx, storage)
+}
+
+func (p *Policy) rotate(ctx context.Context, storage logical.Storage, key []byte) (retErr error) {
 	priorLatestVersion := p.LatestVersion
 	priorMinDecryptionVersion := p.MinDecryptionVersion
 	var priorKeys keyEntryMap
@@ -1369,6 +1557,36 @@ func (p *Policy) Rotate(ctx context.Context, storage logical.Storage) (retErr er
 	}
 	entry.HMACKey = hmacKey
 
+	if key != nil {
+		err = p.setImportedKey(&entry, key)
+	} else {
+		err = p.generateKey(&entry)
+	}
+	if err != nil {
+		return err
+	}
+
+	if p.ConvergentEncryption {
+		if p.ConvergentVersion == -1 || p.ConvergentVersion > 1 || p.ConvergentStartVersion > 0 {
+			entry.ConvergentVersion = currentConvergentVersion
+		}
+	}
+
+	p.Keys[strconv.Itoa(p.LatestVersion)] = entry
+
+	// This ensures that with new key creations min decryption version is set
+	// to 1 rather than the int default of 0, since keys start at 1 (either
+	// fresh or after migration to the key map)
+	if p.MinDecryptionVersion == 0 {
+		p.MinDecryptionVersion = 1
+	}
+
+	return p.Persist(ctx, storage)
+}
+
+// generateKey fills the entry with new, randomly generated key material of
+// the policy's type.
+func (p *Policy) generateKey(entry *KeyEntry) error {
 	switch p.Type {
 	case KeyType_AES256_GCM96, KeyType_ChaCha20_Poly1305:
 		// Generate a 256bit key
@@ -1386,19 +1604,10 @@ func (p *Policy) Rotate(ctx context.Context, storage logical.Storage) (retErr er
 		entry.EC_D = privKey.D
 		entry.EC_X = privKey.X
 		entry.EC_Y = privKey.Y
-		derBytes, err := x509.MarshalPKIXPublicKey(privKey.Public())
+		entry.FormattedPublicKey, err = formatECDSAPublicKey(privKey)
 		if err != nil {
-			return errwrap.Wrapf("error marshaling public key: {{err}}", err)
-		}
-		pemBlock := &pem.Block{
-			Type:  "PUBLIC KEY",
-			Bytes: derBytes,
-		}
-		pemBytes := pem.EncodeToMemory(pemBlock)
-		if pemBytes == nil || len(pemBytes) == 0 {
-			return fmt.Errorf("error PEM-encoding public key")
+			return err
 		}
-		entry.FormattedPublicKey = string(pemBytes)
 
 	case KeyType_ED25519:
 		pub, pri, err := ed25519.GenerateKey(rand.Reader)
@@ -1414,28 +1623,88 @@ func (p *Policy) Rotate(ctx context.Context, storage logical.Storage) (retErr er
 			bitSize = 4096
 		}
 
-		entry.RSAKey, err = rsa.GenerateKey(rand.Reader, bitSize)
+		privKey, err := rsa.GenerateKey(rand.Reader, bitSize)
 		if err != nil {
 			return err
 		}
+		entry.RSAKey = privKey
 	}
 
-	if p.ConvergentEncryption {
-		if p.ConvergentVersion == -1 || p.ConvergentVersion > 1 {
-			entry.ConvergentVersion = currentConvergentVersion
+	return nil
+}
+
+// setImportedKey fills the entry with the given key material after checking
+// that it matches the policy's type.
+func (p *Policy) setImportedKey(entry *KeyEntry, key []byte) error {
+	switch p.Type {
+	case KeyType_AES256_GCM96, KeyType_ChaCha20_Poly1305:
+		if len(key) != 32 {
+			return errutil.UserError{Err: fmt.Sprintf("invalid key size %d bytes for key type %v, expected 32 bytes", len(key), p.Type)}
 		}
+		entry.Key = key
+		return nil
 	}
 
-	p.Keys[strconv.Itoa(p.LatestVersion)] = entry
+	parsed, err := x509.ParsePKCS8PrivateKey(key)
+	if err != nil {
+		return errutil.UserError{Err: fmt.Sprintf("error parsing PKCS#8 private key: %v", err)}
+	}
 
-	// This ensures that with new key creations min decryption version is set
-	// to 1 rather than the int default of 0, since keys start at 1 (either
-	// fresh or after migration to the key map)
-	if p.MinDecryptionVersion == 0 {
-		p.MinDecryptionVersion = 1
+	switch p.Type {
+	case KeyType_ECDSA_P256:
+		privKey, ok := parsed.(*ecdsa.PrivateKey)
+		if !ok || privKey.Curve != elliptic.P256() {
+			return errutil.UserError{Err: fmt.Sprintf("key is not a valid %v key", p.Type)}
+		}
+		entry.EC_D = privKey.D
+		entry.EC_X = privKey.X
+		entry.EC_Y = privKey.Y
+		entry.FormattedPublicKey, err = formatECDSAPublicKey(privKey)
+		if err != nil {
+			return err
+		}
+
+	case KeyType_ED25519:
+		privKey, ok := parsed.(ed25519.PrivateKey)
+		if !ok {
+			return errutil.UserError{Err: fmt.Sprintf("key is not a valid %v key", p.Type)}
+		}
+		entry.Key = privKey
+		entry.FormattedPublicKey = base64.StdEncoding.EncodeToString(privKey.Public().(ed25519.PublicKey))
+
+	case KeyType_RSA2048, KeyType_RSA4096:
+		bitSize := 2048
+		if p.Type == KeyType_RSA4096 {
+			bitSize = 4096
+		}
+
+		privKey, ok := parsed.(*rsa.PrivateKey)
+		if !ok || privKey.N.BitLen() != bitSize {
+			return errutil.UserError{Err: fmt.Sprintf("key is not a valid %v key", p.Type)}
+		}
+		entry.RSAKey = privKey
+
+	default:
+		return fmt.Errorf("unsupported key type %v", p.Type)
 	}
 
-	return p.Persist(ctx, storage)
+	return nil
+}
+
+func formatECDSAPublicKey(privKey *ecdsa.PrivateKey) (string, error) {
+	derBytes, err := x509.MarshalPKIXPublicKey(privKey.Public())
+	if err != nil {
+		return "", errwrap.Wrapf("error marshaling public key: {{err}}", err)
+	}
+	pemBlock := &pem.Block{
+		Type:  "PUBLIC KEY",
+		Bytes: derBytes,
+	}
+	pemBytes := pem.EncodeToMemory(pemBlock)
+	if pemBytes == nil || len(pemBytes) == 0 {
+		return "", fmt.Errorf("error PEM-encoding public key")
+	}
+	return string(pemBytes), nil
 }
 
 func (p *Policy) MigrateKeyToKeysMap() {
@@ -1490,12 +1759,7 @@ func (p *Policy) Backup(ctx context.Context, storage logical.Storage) (out strin
 		ArchivedKeys: archivedKeys,
 	}
 
-	encodedBackup, err := jsonutil.EncodeJSON(keyData)
-	if err != nil {
-		return "", err
-	}
-
-	return base64.StdEncoding.EncodeToString(encodedBackup), nil
+	return encodeBackup(keyData)
 }
 
 func (p *Policy) getTemplateParts() ([]string, error) {
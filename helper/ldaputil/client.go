@@ -2,8 +2,11 @@ package ldaputil
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"crypto/subtle"
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/base64"
 	"encoding/binary"
 	"fmt"
 	"math"
@@ -17,75 +20,180 @@ import (
 	hclog "github.com/hashicorp/go-hclog"
 	multierror "github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/vault/helper/tlsutil"
+	cache "github.com/patrickmn/go-cache"
 )
 
 type Client struct {
 	Logger hclog.Logger
 	LDAP   LDAP
+
+	// GroupCache caches the parent groups of the groups when resolving
+	// nested groups, if set
+	GroupCache *cache.Cache
 }
 
 func (c *Client) DialLDAP(cfg *ConfigEntry) (Connection, error) {
 	var retErr *multierror.Error
 	var conn Connection
-	urls := strings.Split(cfg.Url, ",")
-	for _, uut := range urls {
-		u, err := url.Parse(uut)
-		if err != nil {
-			retErr = multierror.Append(retErr, errwrap.Wrapf(fmt.Sprintf("error parsing url %q: {{err}}", uut), err))
-			continue
+	for _, uut := range cfg.URLs() {
+		var err error
+		conn, err = c.DialURL(cfg, uut)
+		if err == nil {
+			if retErr != nil {
+				if c.Logger.IsDebug() {
+					c.Logger.Debug("errors connecting to some hosts: %s", retErr.Error())
+				}
+			}
+			retErr = nil
+			break
 		}
-		host, port, err := net.SplitHostPort(u.Host)
+		retErr = multierror.Append(retErr, err)
+	}
+
+	return conn, retErr.ErrorOrNil()
+}
+
+// DialURL connects to the LDAP server at the given url, one of the urls of
+// the configuration.
+func (c *Client) DialURL(cfg *ConfigEntry, uut string) (Connection, error) {
+	u, err := url.Parse(uut)
+	if err != nil {
+		return nil, errwrap.Wrapf(fmt.Sprintf("error parsing url %q: {{err}}", uut), err)
+	}
+	host, port, err := net.SplitHostPort(u.Host)
+	if err != nil {
+		host = u.Host
+	}
+
+	var conn Connection
+	var tlsConfig *tls.Config
+	switch u.Scheme {
+	case "ldap":
+		if port == "" {
+			port = "389"
+		}
+		conn, err = c.LDAP.Dial("tcp", net.JoinHostPort(host, port))
 		if err != nil {
-			host = u.Host
+			break
 		}
-
-		var tlsConfig *tls.Config
-		switch u.Scheme {
-		case "ldap":
-			if port == "" {
-				port = "389"
-			}
-			conn, err = c.LDAP.Dial("tcp", net.JoinHostPort(host, port))
+		if conn == nil {
+			err = fmt.Errorf("empty connection after dialing")
+			break
+		}
+		if cfg.StartTLS {
+			tlsConfig, err = getTLSConfig(cfg, host)
 			if err != nil {
+				conn.Close()
 				break
 			}
-			if conn == nil {
-				err = fmt.Errorf("empty connection after dialing")
-				break
-			}
-			if cfg.StartTLS {
-				tlsConfig, err = getTLSConfig(cfg, host)
-				if err != nil {
-					break
-				}
-				err = conn.StartTLS(tlsConfig)
+			err = conn.StartTLS(tlsConfig)
+			if err == nil && cfg.RequireTLS && !isEncrypted(conn) {
+				err = fmt.Errorf("TLS is required but StartTLS did not encrypt the connection")
 			}
-		case "ldaps":
-			if port == "" {
-				port = "636"
-			}
-			tlsConfig, err = getTLSConfig(cfg, host)
 			if err != nil {
-				break
+				conn.Close()
 			}
-			conn, err = c.LDAP.DialTLS("tcp", net.JoinHostPort(host, port), tlsConfig)
-		default:
-			retErr = multierror.Append(retErr, fmt.Errorf("invalid LDAP scheme in url %q", net.JoinHostPort(host, port)))
-			continue
+		} else if cfg.RequireTLS {
+			conn.Close()
+			err = fmt.Errorf("TLS is required but the url uses neither ldaps nor StartTLS")
 		}
-		if err == nil {
-			if retErr != nil {
-				if c.Logger.IsDebug() {
-					c.Logger.Debug("errors connecting to some hosts: %s", retErr.Error())
-				}
-			}
-			retErr = nil
+	case "ldaps":
+		if port == "" {
+			port = "636"
+		}
+		tlsConfig, err = getTLSConfig(cfg, host)
+		if err != nil {
 			break
 		}
-		retErr = multierror.Append(retErr, errwrap.Wrapf(fmt.Sprintf("error connecting to host %q: {{err}}", uut), err))
+		conn, err = c.LDAP.DialTLS("tcp", net.JoinHostPort(host, port), tlsConfig)
+	default:
+		return nil, fmt.Errorf("invalid LDAP scheme in url %q", net.JoinHostPort(host, port))
+	}
+	if err != nil {
+		return nil, errwrap.Wrapf(fmt.Sprintf("error connecting to host %q: {{err}}", uut), err)
 	}
 
-	return conn, retErr.ErrorOrNil()
+	return conn, nil
+}
+
+// isEncrypted returns whether TLS was negotiated on the connection, for
+// connections that report it
+func isEncrypted(conn Connection) bool {
+	c, ok := conn.(interface {
+		TLSConnectionState() (tls.ConnectionState, bool)
+	})
+	if !ok {
+		return true
+	}
+	_, encrypted := c.TLSConnectionState()
+	return encrypted
+}
+
+// search runs the search on the connection, following the referrals to other
+// servers it returns if the configuration allows it
+func (c *Client) search(cfg *ConfigEntry, conn Connection, req *ldap.SearchRequest) (*ldap.SearchResult, error) {
+	return c.searchFollowingReferrals(cfg, conn, req, 0)
+}
+
+func (c *Client) searchFollowingReferrals(cfg *ConfigEntry, conn Connection, req *ldap.SearchRequest, hops int) (*ldap.SearchResult, error) {
+	result, err := conn.Search(req)
+	if err != nil || !cfg.FollowReferrals || len(result.Referrals) == 0 {
+		return result, err
+	}
+
+	referrals := result.Referrals
+	result.Referrals = nil
+	if hops >= cfg.ReferralHopLimit {
+		c.Logger.Warn("referral hop limit reached, ignoring referrals", "referrals", referrals)
+		return result, nil
+	}
+
+	// Referrals are followed on a best effort basis, as directories commonly
+	// return referrals to partitions that aren't reachable
+	for _, referral := range referrals {
+		entries, err := c.followReferral(cfg, referral, req, hops+1)
+		if err != nil {
+			c.Logger.Warn("failed to follow referral", "referral", referral, "error", err)
+			continue
+		}
+		result.Entries = append(result.Entries, entries...)
+	}
+
+	return result, nil
+}
+
+// followReferral runs the search against the server the referral points to,
+// bound with the bind DN of the configuration. The connection to the server
+// is held to the same TLS requirements as the configured ones.
+func (c *Client) followReferral(cfg *ConfigEntry, referral string, req *ldap.SearchRequest, hops int) ([]*ldap.Entry, error) {
+	u, err := url.Parse(referral)
+	if err != nil {
+		return nil, errwrap.Wrapf("error parsing referral: {{err}}", err)
+	}
+
+	if c.Logger.IsDebug() {
+		c.Logger.Debug("following referral", "referral", referral, "hops", hops)
+	}
+
+	conn, err := c.DialURL(cfg, (&url.URL{Scheme: strings.ToLower(u.Scheme), Host: u.Host}).String())
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if err := resetBind(cfg, conn); err != nil {
+		return nil, errwrap.Wrapf("LDAP bind (service) failed: {{err}}", err)
+	}
+
+	referred := *req
+	if baseDN := strings.TrimPrefix(u.Path, "/"); baseDN != "" {
+		referred.BaseDN = baseDN
+	}
+	result, err := c.searchFollowingReferrals(cfg, conn, &referred, hops)
+	if err != nil {
+		return nil, err
+	}
+	return result.Entries, nil
 }
 
 /*
@@ -117,7 +225,7 @@ func (c *Client) GetUserBindDN(cfg *ConfigEntry, conn Connection, username strin
 		if c.Logger.IsDebug() {
 			c.Logger.Debug("discovering user", "userdn", cfg.UserDN, "filter", filter)
 		}
-		result, err := conn.Search(&ldap.SearchRequest{
+		result, err := c.search(cfg, conn, &ldap.SearchRequest{
 			BaseDN:    cfg.UserDN,
 			Scope:     ldap.ScopeWholeSubtree,
 			Filter:    filter,
@@ -152,7 +260,7 @@ func (c *Client) GetUserDN(cfg *ConfigEntry, conn Connection, bindDN string) (st
 		if c.Logger.IsDebug() {
 			c.Logger.Debug("searching upn", "userdn", cfg.UserDN, "filter", filter)
 		}
-		result, err := conn.Search(&ldap.SearchRequest{
+		result, err := c.search(cfg, conn, &ldap.SearchRequest{
 			BaseDN:    cfg.UserDN,
 			Scope:     ldap.ScopeWholeSubtree,
 			Filter:    filter,
@@ -171,6 +279,50 @@ func (c *Client) GetUserDN(cfg *ConfigEntry, conn Connection, bindDN string) (st
 	return userDN, nil
 }
 
+// GetUserAliasMetadata returns the alias metadata of the user, made of the
+// values of the attributes of its entry given by AttributeMetadata. Multiple
+// values are joined with commas.
+func (c *Client) GetUserAliasMetadata(cfg *ConfigEntry, conn Connection, userDN string) (map[string]string, error) {
+	if len(cfg.AttributeMetadata) == 0 {
+		return nil, nil
+	}
+
+	attributes := make([]string, 0, len(cfg.AttributeMetadata))
+	for attribute := range cfg.AttributeMetadata {
+		attributes = append(attributes, attribute)
+	}
+
+	result, err := c.search(cfg, conn, &ldap.SearchRequest{
+		BaseDN:     userDN,
+		Scope:      ldap.ScopeBaseObject,
+		Filter:     "(objectClass=*)",
+		Attributes: attributes,
+		SizeLimit:  1,
+	})
+	if err != nil {
+		return nil, errwrap.Wrapf("LDAP search for the user attributes failed: {{err}}", err)
+	}
+	if len(result.Entries) == 0 {
+		return nil, nil
+	}
+
+	metadata := make(map[string]string)
+	for _, attr := range result.Entries[0].Attributes {
+		// Attribute names are case insensitive
+		for attribute, key := range cfg.AttributeMetadata {
+			if strings.EqualFold(attr.Name, attribute) && len(attr.Values) > 0 {
+				metadata[key] = strings.Join(attr.Values, ",")
+			}
+		}
+	}
+
+	if c.Logger.IsDebug() {
+		c.Logger.Debug("user alias metadata fetched", "userdn", userDN, "metadata", metadata)
+	}
+
+	return metadata, nil
+}
+
 func (c *Client) performLdapFilterGroupsSearch(cfg *ConfigEntry, conn Connection, userDN string, username string) ([]*ldap.Entry, error) {
 	if cfg.GroupFilter == "" {
 		c.Logger.Warn("groupfilter is empty, will not query server")
@@ -211,7 +363,7 @@ func (c *Client) performLdapFilterGroupsSearch(cfg *ConfigEntry, conn Connection
 		c.Logger.Debug("searching", "groupdn", cfg.GroupDN, "rendered_query", renderedQuery.String())
 	}
 
-	result, err := conn.Search(&ldap.SearchRequest{
+	result, err := c.search(cfg, conn, &ldap.SearchRequest{
 		BaseDN: cfg.GroupDN,
 		Scope:  ldap.ScopeWholeSubtree,
 		Filter: renderedQuery.String(),
@@ -260,7 +412,7 @@ func sidBytesToString(b []byte) (string, error) {
 }
 
 func (c *Client) performLdapTokenGroupsSearch(cfg *ConfigEntry, conn Connection, userDN string) ([]*ldap.Entry, error) {
-	result, err := conn.Search(&ldap.SearchRequest{
+	result, err := c.search(cfg, conn, &ldap.SearchRequest{
 		BaseDN: userDN,
 		Scope:  ldap.ScopeBaseObject,
 		Filter: "(objectClass=*)",
@@ -288,7 +440,7 @@ func (c *Client) performLdapTokenGroupsSearch(cfg *ConfigEntry, conn Connection,
 			continue
 		}
 
-		groupResult, err := conn.Search(&ldap.SearchRequest{
+		groupResult, err := c.search(cfg, conn, &ldap.SearchRequest{
 			BaseDN: fmt.Sprintf("<SID=%s>", sidString),
 			Scope:  ldap.ScopeBaseObject,
 			Filter: "(objectClass=*)",
@@ -347,6 +499,9 @@ func (c *Client) GetLdapGroups(cfg *ConfigEntry, conn Connection, userDN string,
 	// retrieve the groups in a string/bool map as a structure to avoid duplicates inside
 	ldapMap := make(map[string]bool)
 
+	// the DNs of the groups, from which nested groups are resolved
+	var groupDNs []string
+
 	for _, e := range entries {
 		dn, err := ldap.ParseDN(e.DN)
 		if err != nil || len(dn.RDNs) == 0 {
@@ -359,12 +514,32 @@ func (c *Client) GetLdapGroups(cfg *ConfigEntry, conn Connection, userDN string,
 			for _, val := range values {
 				groupCN := getCN(val)
 				ldapMap[groupCN] = true
+				if isDN(val) {
+					groupDNs = append(groupDNs, val)
+				}
 			}
 		} else {
 			// If groupattr didn't resolve, use self (enumerating group objects)
 			groupCN := getCN(e.DN)
 			ldapMap[groupCN] = true
 		}
+
+		// Unless groupattr holds the DNs of the groups, the entries are the
+		// groups themselves
+		if len(values) == 0 || !isDN(values[0]) {
+			groupDNs = append(groupDNs, e.DN)
+		}
+	}
+
+	// tokenGroups already contains the nested groups
+	if cfg.NestedGroupDepth > 0 && !cfg.UseTokenGroups {
+		nestedGroupDNs, err := c.getNestedGroups(cfg, conn, groupDNs)
+		if err != nil {
+			return nil, err
+		}
+		for _, groupDN := range nestedGroupDNs {
+			ldapMap[getCN(groupDN)] = true
+		}
 	}
 
 	ldapGroups := make([]string, 0, len(ldapMap))
@@ -375,6 +550,84 @@ func (c *Client) GetLdapGroups(cfg *ConfigEntry, conn Connection, userDN string,
 	return ldapGroups, nil
 }
 
+// getNestedGroups returns the DNs of the groups the given groups are nested
+// in, following their memberOf attribute up to the nested group depth of the
+// configuration. Groups are only visited once, so that cycles between groups
+// don't matter.
+func (c *Client) getNestedGroups(cfg *ConfigEntry, conn Connection, groupDNs []string) ([]string, error) {
+	visited := make(map[string]bool, len(groupDNs))
+	for _, groupDN := range groupDNs {
+		visited[strings.ToLower(groupDN)] = true
+	}
+
+	var nestedGroupDNs []string
+	level := groupDNs
+	for depth := 0; depth < cfg.NestedGroupDepth && len(level) > 0; depth++ {
+		var nextLevel []string
+		for _, groupDN := range level {
+			parentDNs, err := c.getParentGroups(cfg, conn, groupDN)
+			if err != nil {
+				return nil, err
+			}
+			for _, parentDN := range parentDNs {
+				if visited[strings.ToLower(parentDN)] {
+					continue
+				}
+				visited[strings.ToLower(parentDN)] = true
+				nestedGroupDNs = append(nestedGroupDNs, parentDN)
+				nextLevel = append(nextLevel, parentDN)
+			}
+		}
+		level = nextLevel
+	}
+
+	if c.Logger.IsDebug() {
+		c.Logger.Debug("nested groups resolved", "num_nested_groups", len(nestedGroupDNs), "nested_groups", nestedGroupDNs)
+	}
+
+	return nestedGroupDNs, nil
+}
+
+// getParentGroups returns the DNs of the groups the group is a member of
+func (c *Client) getParentGroups(cfg *ConfigEntry, conn Connection, groupDN string) ([]string, error) {
+	cacheKey := strings.ToLower(groupDN)
+	if c.GroupCache != nil && cfg.NestedGroupCacheTTL > 0 {
+		if parentDNs, ok := c.GroupCache.Get(cacheKey); ok {
+			return parentDNs.([]string), nil
+		}
+	}
+
+	result, err := c.search(cfg, conn, &ldap.SearchRequest{
+		BaseDN: groupDN,
+		Scope:  ldap.ScopeBaseObject,
+		Filter: "(objectClass=*)",
+		Attributes: []string{
+			"memberOf",
+		},
+		SizeLimit: 1,
+	})
+	if err != nil && !ldap.IsErrorWithCode(err, ldap.LDAPResultNoSuchObject) {
+		return nil, errwrap.Wrapf(fmt.Sprintf("LDAP search for the parent groups of %q failed: {{err}}", groupDN), err)
+	}
+
+	var parentDNs []string
+	if err == nil && len(result.Entries) > 0 {
+		parentDNs = result.Entries[0].GetAttributeValues("memberOf")
+	}
+
+	if c.GroupCache != nil && cfg.NestedGroupCacheTTL > 0 {
+		c.GroupCache.Set(cacheKey, parentDNs, cfg.NestedGroupCacheTTL)
+	}
+
+	return parentDNs, nil
+}
+
+// isDN returns whether the value is a distinguished name
+func isDN(value string) bool {
+	dn, err := ldap.ParseDN(value)
+	return err == nil && len(dn.RDNs) > 0
+}
+
 // EscapeLDAPValue is exported because a plugin uses it outside this package.
 func EscapeLDAPValue(input string) string {
 	if input == "" {
@@ -470,5 +723,32 @@ func getTLSConfig(cfg *ConfigEntry, host string) (*tls.Config, error) {
 		}
 		tlsConfig.RootCAs = caPool
 	}
+	if len(cfg.TLSPinnedSPKISHA256) > 0 {
+		pins := cfg.TLSPinnedSPKISHA256
+		tlsConfig.VerifyPeerCertificate = func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			return verifySPKIPin(pins, rawCerts)
+		}
+	}
 	return tlsConfig, nil
 }
+
+// verifySPKIPin checks that the public key of the leaf certificate presented
+// by the server is one of the pinned ones
+func verifySPKIPin(pins []string, rawCerts [][]byte) error {
+	if len(rawCerts) == 0 {
+		return fmt.Errorf("no certificate presented by the LDAP server")
+	}
+	leaf, err := x509.ParseCertificate(rawCerts[0])
+	if err != nil {
+		return errwrap.Wrapf("failed to parse the certificate of the LDAP server: {{err}}", err)
+	}
+
+	hash := sha256.Sum256(leaf.RawSubjectPublicKeyInfo)
+	for _, pin := range pins {
+		expected, err := base64.StdEncoding.DecodeString(pin)
+		if err == nil && subtle.ConstantTimeCompare(hash[:], expected) == 1 {
+			return nil
+		}
+	}
+	return fmt.Errorf("the public key of the LDAP server certificate %q is not pinned", leaf.Subject.String())
+}
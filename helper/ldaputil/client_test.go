@@ -1,7 +1,22 @@
 package ldaputil
 
 import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/go-ldap/ldap"
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/helper/logging"
+	cache "github.com/patrickmn/go-cache"
 )
 
 func TestLDAPEscape(t *testing.T) {
@@ -46,6 +61,58 @@ func TestGetTLSConfigs(t *testing.T) {
 	}
 }
 
+func TestVerifySPKIPin(t *testing.T) {
+	block, _ := pem.Decode([]byte(validCertificate))
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	pin := base64.StdEncoding.EncodeToString(hash[:])
+
+	config := testConfig()
+	config.TLSPinnedSPKISHA256 = []string{"47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=", pin}
+	tlsConfig, err := getTLSConfig(config, "138.91.247.105")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tlsConfig.VerifyPeerCertificate([][]byte{block.Bytes}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	config.TLSPinnedSPKISHA256 = config.TLSPinnedSPKISHA256[:1]
+	tlsConfig, err = getTLSConfig(config, "138.91.247.105")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tlsConfig.VerifyPeerCertificate([][]byte{block.Bytes}, nil); err == nil {
+		t.Fatal("expected a certificate with another public key to be rejected")
+	}
+}
+
+func TestDialURL_RequireTLS(t *testing.T) {
+	client := &Client{
+		Logger: logging.NewVaultLogger(log.Trace),
+		LDAP: &fakeLDAP{
+			down:  map[string]bool{},
+			dials: map[string]int{},
+		},
+	}
+
+	cfg := testConfig()
+	cfg.RequireTLS = true
+	if _, err := client.DialURL(cfg, "ldap://ldap.example.com"); err == nil {
+		t.Fatal("expected an unencrypted connection to be rejected")
+	}
+	if _, err := client.DialURL(cfg, "ldaps://ldap.example.com"); err != nil {
+		t.Fatal(err)
+	}
+	cfg.StartTLS = true
+	if _, err := client.DialURL(cfg, "ldap://ldap.example.com"); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestSIDBytesToString(t *testing.T) {
 	testcases := map[string][]byte{
 		"S-1-5-21-2127521184-1604012920-1887927527-72713": []byte{0x01, 0x05, 0x00, 0x00, 0x00, 0x00, 0x00, 0x05, 0x15, 0x00, 0x00, 0x00, 0xA0, 0x65, 0xCF, 0x7E, 0x78, 0x4B, 0x9B, 0x5F, 0xE7, 0x7C, 0x87, 0x70, 0x09, 0x1C, 0x01, 0x00},
@@ -62,3 +129,255 @@ func TestSIDBytesToString(t *testing.T) {
 		}
 	}
 }
+
+// fakeDirectory is a connection to a directory of groups, nested through
+// their memberOf attribute. If attributes is set, base searches return an
+// entry with those attributes instead.
+type fakeDirectory struct {
+	userEntry  *ldap.Entry
+	memberOf   map[string][]string
+	attributes map[string][]string
+	searches   int
+}
+
+func (d *fakeDirectory) Bind(username, password string) error           { return nil }
+func (d *fakeDirectory) Close()                                         {}
+func (d *fakeDirectory) Modify(modifyRequest *ldap.ModifyRequest) error { return nil }
+func (d *fakeDirectory) StartTLS(config *tls.Config) error              { return nil }
+func (d *fakeDirectory) UnauthenticatedBind(username string) error      { return nil }
+
+func (d *fakeDirectory) Search(searchRequest *ldap.SearchRequest) (*ldap.SearchResult, error) {
+	d.searches++
+	if searchRequest.Scope != ldap.ScopeBaseObject {
+		return &ldap.SearchResult{Entries: []*ldap.Entry{d.userEntry}}, nil
+	}
+
+	if d.attributes != nil {
+		return &ldap.SearchResult{
+			Entries: []*ldap.Entry{
+				ldap.NewEntry(searchRequest.BaseDN, d.attributes),
+			},
+		}, nil
+	}
+
+	memberOf, ok := d.memberOf[searchRequest.BaseDN]
+	if !ok {
+		return nil, ldap.NewError(ldap.LDAPResultNoSuchObject, nil)
+	}
+	return &ldap.SearchResult{
+		Entries: []*ldap.Entry{
+			ldap.NewEntry(searchRequest.BaseDN, map[string][]string{"memberOf": memberOf}),
+		},
+	}, nil
+}
+
+func TestGetLdapGroups_nested(t *testing.T) {
+	directory := &fakeDirectory{
+		userEntry: ldap.NewEntry("CN=dev,OU=Groups,DC=example,DC=com", map[string][]string{
+			"cn": []string{"dev"},
+		}),
+		memberOf: map[string][]string{
+			"CN=dev,OU=Groups,DC=example,DC=com":   []string{"CN=eng,OU=Groups,DC=example,DC=com"},
+			"CN=eng,OU=Groups,DC=example,DC=com":   []string{"CN=staff,OU=Groups,DC=example,DC=com"},
+			"CN=staff,OU=Groups,DC=example,DC=com": []string{"CN=eng,OU=Groups,DC=example,DC=com", "CN=all,OU=Groups,DC=example,DC=com"},
+			"CN=all,OU=Groups,DC=example,DC=com":   []string{"CN=deleted,OU=Groups,DC=example,DC=com"},
+		},
+	}
+	client := &Client{
+		Logger: logging.NewVaultLogger(log.Trace),
+	}
+
+	cfg := testConfig()
+	cfg.GroupDN = "ou=groups,dc=example,dc=com"
+	cfg.GroupFilter = "(member={{.UserDN}})"
+	cfg.GroupAttr = "cn"
+
+	getGroups := func() string {
+		t.Helper()
+		groups, err := client.GetLdapGroups(cfg, directory, "cn=user,dc=example,dc=com", "user")
+		if err != nil {
+			t.Fatal(err)
+		}
+		sort.Strings(groups)
+		return strings.Join(groups, ",")
+	}
+
+	// Nested groups are only resolved up to the depth, and cycles are ignored
+	expected := map[int]string{
+		0: "dev",
+		1: "dev,eng",
+		2: "dev,eng,staff",
+		3: "all,dev,eng,staff",
+		5: "all,deleted,dev,eng,staff",
+	}
+	for depth, groups := range expected {
+		cfg.NestedGroupDepth = depth
+		if actual := getGroups(); actual != groups {
+			t.Fatalf("depth %d: expected %q, got %q", depth, groups, actual)
+		}
+	}
+
+	// The groups can be the values of groupattr rather than the entries
+	directory.userEntry = ldap.NewEntry("cn=user,dc=example,dc=com", map[string][]string{
+		"memberOf": []string{"CN=dev,OU=Groups,DC=example,DC=com"},
+	})
+	cfg.GroupAttr = "memberOf"
+	cfg.NestedGroupDepth = 1
+	if actual := getGroups(); actual != "dev,eng" {
+		t.Fatalf("expected %q, got %q", "dev,eng", actual)
+	}
+
+	// The parent groups are cached
+	client.GroupCache = cache.New(0, time.Minute)
+	cfg.NestedGroupCacheTTL = time.Minute
+	cfg.NestedGroupDepth = 5
+	getGroups()
+	directory.searches = 0
+	if actual := getGroups(); actual != "all,deleted,dev,eng,staff" {
+		t.Fatalf("bad: %q", actual)
+	}
+	if directory.searches != 1 {
+		t.Fatalf("expected only the group search, got %d searches", directory.searches)
+	}
+}
+
+func TestGetUserAliasMetadata(t *testing.T) {
+	directory := &fakeDirectory{
+		memberOf: map[string][]string{},
+	}
+	client := &Client{
+		Logger: logging.NewVaultLogger(log.Trace),
+	}
+	cfg := testConfig()
+
+	// Nothing is searched without attribute metadata
+	metadata, err := client.GetUserAliasMetadata(cfg, directory, "cn=user,dc=example,dc=com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if metadata != nil || directory.searches != 0 {
+		t.Fatalf("expected no metadata and no search, got %v and %d searches", metadata, directory.searches)
+	}
+
+	cfg.AttributeMetadata = map[string]string{
+		"department": "dept",
+		"mail":       "email",
+		"title":      "title",
+	}
+	directory.attributes = map[string][]string{
+		"Department": []string{"engineering"},
+		"mail":       []string{"user@example.com", "u@example.com"},
+	}
+	metadata, err = client.GetUserAliasMetadata(cfg, directory, "cn=user,dc=example,dc=com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{
+		"dept":  "engineering",
+		"email": "user@example.com,u@example.com",
+	}
+	if !reflect.DeepEqual(metadata, expected) {
+		t.Fatalf("expected %v, got %v", expected, metadata)
+	}
+}
+
+// referringLDAP dials connections to directories which return the entries
+// and the referrals of their address
+type referringLDAP struct {
+	entries   map[string][]string
+	referrals map[string][]string
+	searches  []string
+}
+
+func (f *referringLDAP) Dial(network, addr string) (Connection, error) {
+	return &referringDirectory{ldap: f, addr: addr}, nil
+}
+
+func (f *referringLDAP) DialTLS(network, addr string, config *tls.Config) (Connection, error) {
+	return f.Dial(network, addr)
+}
+
+type referringDirectory struct {
+	fakeDirectory
+	ldap *referringLDAP
+	addr string
+}
+
+func (d *referringDirectory) Search(searchRequest *ldap.SearchRequest) (*ldap.SearchResult, error) {
+	d.ldap.searches = append(d.ldap.searches, fmt.Sprintf("%s/%s", d.addr, searchRequest.BaseDN))
+	result := &ldap.SearchResult{
+		Referrals: d.ldap.referrals[d.addr],
+	}
+	for _, dn := range d.ldap.entries[d.addr] {
+		result.Entries = append(result.Entries, ldap.NewEntry(dn, nil))
+	}
+	return result, nil
+}
+
+func TestSearch_Referrals(t *testing.T) {
+	fake := &referringLDAP{
+		entries: map[string][]string{
+			"ldap1.example.com:389": []string{"cn=user1,dc=example,dc=com"},
+			"ldap2.example.com:389": []string{"cn=user2,dc=child,dc=example,dc=com"},
+		},
+		referrals: map[string][]string{
+			"ldap1.example.com:389": []string{"ldap://ldap2.example.com/dc=child,dc=example,dc=com"},
+			"ldap2.example.com:389": []string{"ldap://ldap1.example.com/dc=example,dc=com"},
+		},
+	}
+	client := &Client{
+		Logger: logging.NewVaultLogger(log.Trace),
+		LDAP:   fake,
+	}
+	conn, err := fake.Dial("tcp", "ldap1.example.com:389")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	search := func(cfg *ConfigEntry) []string {
+		t.Helper()
+		fake.searches = nil
+		result, err := client.search(cfg, conn, &ldap.SearchRequest{BaseDN: "dc=example,dc=com"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var dns []string
+		for _, entry := range result.Entries {
+			dns = append(dns, entry.DN)
+		}
+		return dns
+	}
+
+	// Referrals are ignored by default
+	cfg := testConfig()
+	if dns := search(cfg); !reflect.DeepEqual(dns, []string{"cn=user1,dc=example,dc=com"}) {
+		t.Fatalf("bad: %v", dns)
+	}
+
+	// Referrals are followed up to the hop limit, with the base DN they give
+	cfg.FollowReferrals = true
+	cfg.ReferralHopLimit = 2
+	dns := search(cfg)
+	expected := []string{
+		"cn=user1,dc=example,dc=com",
+		"cn=user2,dc=child,dc=example,dc=com",
+		"cn=user1,dc=example,dc=com",
+	}
+	if !reflect.DeepEqual(dns, expected) {
+		t.Fatalf("bad: %v", dns)
+	}
+	expectedSearches := []string{
+		"ldap1.example.com:389/dc=example,dc=com",
+		"ldap2.example.com:389/dc=child,dc=example,dc=com",
+		"ldap1.example.com:389/dc=example,dc=com",
+	}
+	if !reflect.DeepEqual(fake.searches, expectedSearches) {
+		t.Fatalf("bad: %v", fake.searches)
+	}
+
+	// Referrals to servers not meeting the TLS requirement aren't followed
+	cfg.RequireTLS = true
+	if dns := search(cfg); !reflect.DeepEqual(dns, []string{"cn=user1,dc=example,dc=com"}) {
+		t.Fatalf("bad: %v", dns)
+	}
+}
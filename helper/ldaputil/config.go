@@ -1,12 +1,15 @@
 package ldaputil
 
 import (
+	"crypto/sha256"
 	"crypto/x509"
+	"encoding/base64"
 	"encoding/pem"
 	"errors"
 	"fmt"
 	"strings"
 	"text/template"
+	"time"
 
 	"github.com/hashicorp/vault/helper/tlsutil"
 	"github.com/hashicorp/vault/logical/framework"
@@ -21,7 +24,7 @@ func ConfigFields() map[string]*framework.FieldSchema {
 		"url": {
 			Type:        framework.TypeString,
 			Default:     "ldap://127.0.0.1",
-			Description: "LDAP URL to connect to (default: ldap://127.0.0.1). Multiple URLs can be specified by concatenating them with commas; they will be tried in-order.",
+			Description: "LDAP URL to connect to (default: ldap://127.0.0.1). Multiple URLs can be specified by concatenating them with commas; they will be tried in-order, skipping the servers that are failing.",
 		},
 
 		"userdn": {
@@ -93,6 +96,16 @@ Default: cn`,
 			Description: "Issue a StartTLS command after establishing unencrypted connection (optional)",
 		},
 
+		"require_tls": {
+			Type:        framework.TypeBool,
+			Description: "Require the connections to the LDAP servers to be encrypted: ldap:// urls are only allowed with starttls, and connections that fail to negotiate TLS are rejected (optional)",
+		},
+
+		"tls_pinned_spki_sha256": {
+			Type:        framework.TypeCommaStringSlice,
+			Description: "Base64 encoded SHA-256 hashes of the SubjectPublicKeyInfo of the certificates the LDAP servers may present (optional). When set, servers presenting a leaf certificate with another public key are rejected, even with insecure_tls.",
+		},
+
 		"tls_min_version": {
 			Type:        framework.TypeString,
 			Default:     "tls12",
@@ -105,6 +118,18 @@ Default: cn`,
 			Description: "Maximum TLS version to use. Accepted values are 'tls10', 'tls11' or 'tls12'. Defaults to 'tls12'",
 		},
 
+		"follow_referrals": {
+			Type:        framework.TypeBool,
+			Default:     false,
+			Description: "If true, follow the referrals to other servers returned by searches, binding with binddn and bindpass. Defaults to false, which ignores referrals.",
+		},
+
+		"referral_hop_limit": {
+			Type:        framework.TypeInt,
+			Default:     5,
+			Description: "Maximum number of referrals followed in a row when follow_referrals is set. Defaults to 5.",
+		},
+
 		"deny_null_bind": {
 			Type:        framework.TypeBool,
 			Default:     true,
@@ -121,6 +146,35 @@ Default: cn`,
 			Default:     false,
 			Description: "If true, use the Active Directory tokenGroups constructed attribute of the user to find the group memberships. This will find all security groups including nested ones.",
 		},
+
+		"nested_group_depth": {
+			Type:        framework.TypeInt,
+			Default:     0,
+			Description: "Maximum depth of nested groups to resolve by following the memberOf attribute of the user's groups. Defaults to 0, which disables nested group resolution. Not used with use_token_groups, which already includes nested groups.",
+		},
+
+		"nested_group_cache_ttl": {
+			Type:        framework.TypeDurationSecond,
+			Default:     300,
+			Description: "Duration for which the parent groups of a group are cached when resolving nested groups. Defaults to 5 minutes; 0 disables caching.",
+		},
+
+		"max_idle_connections": {
+			Type:        framework.TypeInt,
+			Default:     0,
+			Description: "Maximum number of idle connections to the LDAP servers kept open for reuse. Defaults to 0, which disables connection pooling.",
+		},
+
+		"health_check_interval": {
+			Type:        framework.TypeDurationSecond,
+			Default:     30,
+			Description: "Interval at which LDAP servers that failed are checked again, so that they're used once they recover, and idle connections are verified. Defaults to 30 seconds.",
+		},
+
+		"attribute_metadata": {
+			Type:        framework.TypeKVPairs,
+			Description: "Map of user attributes to the keys of the entity alias metadata their values are copied to at login. Multiple values are joined with commas.",
+		},
 	}
 }
 
@@ -211,6 +265,32 @@ func NewConfigEntry(d *framework.FieldData) (*ConfigEntry, error) {
 		cfg.StartTLS = startTLS
 	}
 
+	requireTLS := d.Get("require_tls").(bool)
+	if requireTLS {
+		cfg.RequireTLS = requireTLS
+	}
+
+	pins := d.Get("tls_pinned_spki_sha256").([]string)
+	for _, pin := range pins {
+		if err := validateSPKIPin(pin); err != nil {
+			return nil, err
+		}
+	}
+	if len(pins) > 0 {
+		cfg.TLSPinnedSPKISHA256 = pins
+	}
+
+	followReferrals := d.Get("follow_referrals").(bool)
+	if followReferrals {
+		cfg.FollowReferrals = followReferrals
+	}
+
+	referralHopLimit := d.Get("referral_hop_limit").(int)
+	if referralHopLimit <= 0 {
+		return nil, fmt.Errorf("'referral_hop_limit' must be positive")
+	}
+	cfg.ReferralHopLimit = referralHopLimit
+
 	bindDN := d.Get("binddn").(string)
 	if bindDN != "" {
 		cfg.BindDN = bindDN
@@ -242,6 +322,44 @@ func NewConfigEntry(d *framework.FieldData) (*ConfigEntry, error) {
 		cfg.UseTokenGroups = useTokenGroups
 	}
 
+	nestedGroupDepth := d.Get("nested_group_depth").(int)
+	if nestedGroupDepth < 0 {
+		return nil, fmt.Errorf("'nested_group_depth' cannot be negative")
+	}
+	cfg.NestedGroupDepth = nestedGroupDepth
+
+	nestedGroupCacheTTL := d.Get("nested_group_cache_ttl").(int)
+	if nestedGroupCacheTTL < 0 {
+		return nil, fmt.Errorf("'nested_group_cache_ttl' cannot be negative")
+	}
+	cfg.NestedGroupCacheTTL = time.Duration(nestedGroupCacheTTL) * time.Second
+
+	maxIdleConnections := d.Get("max_idle_connections").(int)
+	if maxIdleConnections < 0 {
+		return nil, fmt.Errorf("'max_idle_connections' cannot be negative")
+	}
+	cfg.MaxIdleConnections = maxIdleConnections
+
+	healthCheckInterval := d.Get("health_check_interval").(int)
+	if healthCheckInterval <= 0 {
+		return nil, fmt.Errorf("'health_check_interval' must be positive")
+	}
+	cfg.HealthCheckInterval = time.Duration(healthCheckInterval) * time.Second
+
+	attributeMetadata := d.Get("attribute_metadata").(map[string]string)
+	for attribute, key := range attributeMetadata {
+		if attribute == "" || key == "" {
+			return nil, fmt.Errorf("invalid 'attribute_metadata' entry %q=%q", attribute, key)
+		}
+	}
+	if len(attributeMetadata) > 0 {
+		cfg.AttributeMetadata = attributeMetadata
+	}
+
+	if err := cfg.validateTLSRequirement(); err != nil {
+		return nil, err
+	}
+
 	return cfg, nil
 }
 
@@ -264,6 +382,20 @@ type ConfigEntry struct {
 	TLSMaxVersion  string `json:"tls_max_version"`
 	UseTokenGroups bool   `json:"use_token_groups"`
 
+	RequireTLS          bool     `json:"require_tls"`
+	TLSPinnedSPKISHA256 []string `json:"tls_pinned_spki_sha256"`
+
+	FollowReferrals  bool `json:"follow_referrals"`
+	ReferralHopLimit int  `json:"referral_hop_limit"`
+
+	NestedGroupDepth    int           `json:"nested_group_depth"`
+	NestedGroupCacheTTL time.Duration `json:"nested_group_cache_ttl"`
+
+	MaxIdleConnections  int           `json:"max_idle_connections"`
+	HealthCheckInterval time.Duration `json:"health_check_interval"`
+
+	AttributeMetadata map[string]string `json:"attribute_metadata"`
+
 	// This json tag deviates from snake case because there was a past issue
 	// where the tag was being ignored, causing it to be jsonified as "CaseSensitiveNames".
 	// To continue reading in users' previously stored values,
@@ -295,6 +427,20 @@ func (c *ConfigEntry) PasswordlessMap() map[string]interface{} {
 		"tls_min_version":  c.TLSMinVersion,
 		"tls_max_version":  c.TLSMaxVersion,
 		"use_token_groups": c.UseTokenGroups,
+
+		"require_tls":            c.RequireTLS,
+		"tls_pinned_spki_sha256": c.TLSPinnedSPKISHA256,
+
+		"follow_referrals":   c.FollowReferrals,
+		"referral_hop_limit": c.ReferralHopLimit,
+
+		"nested_group_depth":     c.NestedGroupDepth,
+		"nested_group_cache_ttl": int64(c.NestedGroupCacheTTL.Seconds()),
+
+		"max_idle_connections":  c.MaxIdleConnections,
+		"health_check_interval": int64(c.HealthCheckInterval.Seconds()),
+
+		"attribute_metadata": c.AttributeMetadata,
 	}
 	if c.CaseSensitiveNames != nil {
 		m["case_sensitive_names"] = *c.CaseSensitiveNames
@@ -302,6 +448,17 @@ func (c *ConfigEntry) PasswordlessMap() map[string]interface{} {
 	return m
 }
 
+// URLs returns the urls of the LDAP servers, in the order they're tried
+func (c *ConfigEntry) URLs() []string {
+	var urls []string
+	for _, u := range strings.Split(c.Url, ",") {
+		if u = strings.TrimSpace(u); u != "" {
+			urls = append(urls, u)
+		}
+	}
+	return urls
+}
+
 func (c *ConfigEntry) Validate() error {
 	if len(c.Url) == 0 {
 		return errors.New("at least one url must be provided")
@@ -322,6 +479,14 @@ func (c *ConfigEntry) Validate() error {
 	if tlsMaxVersion < tlsMinVersion {
 		return errors.New("'tls_max_version' must be greater than or equal to 'tls_min_version'")
 	}
+	if err := c.validateTLSRequirement(); err != nil {
+		return err
+	}
+	for _, pin := range c.TLSPinnedSPKISHA256 {
+		if err := validateSPKIPin(pin); err != nil {
+			return err
+		}
+	}
 	if c.Certificate != "" {
 		block, _ := pem.Decode([]byte(c.Certificate))
 		if block == nil || block.Type != "CERTIFICATE" {
@@ -334,3 +499,26 @@ func (c *ConfigEntry) Validate() error {
 	}
 	return nil
 }
+
+// validateTLSRequirement checks that the connections to all the urls are
+// encrypted if the configuration requires it
+func (c *ConfigEntry) validateTLSRequirement() error {
+	if !c.RequireTLS || c.StartTLS {
+		return nil
+	}
+	for _, u := range c.URLs() {
+		if strings.HasPrefix(u, "ldap://") {
+			return fmt.Errorf("url %q must use ldaps or starttls must be set when 'require_tls' is set", u)
+		}
+	}
+	return nil
+}
+
+// validateSPKIPin checks that the pin is a base64 encoded SHA-256 hash
+func validateSPKIPin(pin string) error {
+	hash, err := base64.StdEncoding.DecodeString(pin)
+	if err != nil || len(hash) != sha256.Size {
+		return fmt.Errorf("invalid 'tls_pinned_spki_sha256' entry %q, must be a base64 encoded SHA-256 hash", pin)
+	}
+	return nil
+}
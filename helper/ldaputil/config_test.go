@@ -40,6 +40,34 @@ func TestUseTokenGroupsDefault(t *testing.T) {
 	}
 }
 
+func TestTLSRequirementValidation(t *testing.T) {
+	config := testConfig()
+	config.RequireTLS = true
+	if err := config.Validate(); err == nil {
+		t.Fatal("should err due to an ldap url without starttls")
+	}
+
+	config.StartTLS = true
+	if err := config.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	config.StartTLS = false
+	config.Url = "ldaps://138.91.247.105"
+	if err := config.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	config.TLSPinnedSPKISHA256 = []string{"cats"}
+	if err := config.Validate(); err == nil {
+		t.Fatal("should err due to a bad pin")
+	}
+	config.TLSPinnedSPKISHA256 = []string{"47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU="}
+	if err := config.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func testConfig() *ConfigEntry {
 	return &ConfigEntry{
 		Url:           "ldap://138.91.247.105",
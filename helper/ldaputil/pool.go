@@ -0,0 +1,239 @@
+package ldaputil
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-ldap/ldap"
+	"github.com/hashicorp/errwrap"
+	multierror "github.com/hashicorp/go-multierror"
+)
+
+// PooledConnection is a connection handed out by a Pool, along with the url
+// of the server it is connected to.
+type PooledConnection struct {
+	Connection
+	URL string
+}
+
+// Pool manages the connections to the LDAP servers of a configuration. It
+// keeps idle connections open for reuse, and tracks the servers that are
+// failing so that connections are made to the other servers until they have
+// recovered.
+type Pool struct {
+	client *Client
+
+	l    sync.Mutex
+	idle []*PooledConnection
+
+	// failing maps the urls of the failing servers to the last time they
+	// were checked
+	failing map[string]time.Time
+}
+
+func NewPool(client *Client) *Pool {
+	return &Pool{
+		client:  client,
+		failing: make(map[string]time.Time),
+	}
+}
+
+// Get returns an idle connection if there is one, and otherwise connects to
+// the first server of the configuration that isn't failing. Failing servers
+// are only tried when all the others fail too.
+func (p *Pool) Get(cfg *ConfigEntry) (*PooledConnection, error) {
+	p.l.Lock()
+	for len(p.idle) > 0 {
+		conn := p.idle[len(p.idle)-1]
+		p.idle = p.idle[:len(p.idle)-1]
+		if !isClosing(conn) {
+			p.l.Unlock()
+			return conn, nil
+		}
+	}
+
+	var healthy, failing []string
+	for _, u := range cfg.URLs() {
+		if _, ok := p.failing[u]; ok {
+			failing = append(failing, u)
+		} else {
+			healthy = append(healthy, u)
+		}
+	}
+	p.l.Unlock()
+
+	var retErr *multierror.Error
+	for _, u := range append(healthy, failing...) {
+		conn, err := p.client.DialURL(cfg, u)
+		if err != nil {
+			p.markFailing(u)
+			retErr = multierror.Append(retErr, err)
+			continue
+		}
+		if retErr != nil && p.client.Logger.IsDebug() {
+			p.client.Logger.Debug("errors connecting to some hosts", "error", retErr)
+		}
+		p.markHealthy(u)
+		return &PooledConnection{Connection: conn, URL: u}, nil
+	}
+
+	if retErr == nil {
+		return nil, fmt.Errorf("no LDAP url configured")
+	}
+	return nil, retErr
+}
+
+// Put returns a connection to the pool once it is no longer used. The
+// connection is bound back to the bind DN of the configuration, so that it
+// doesn't keep the identity of the last user logging in with it.
+func (p *Pool) Put(cfg *ConfigEntry, conn *PooledConnection) {
+	p.l.Lock()
+	full := len(p.idle) >= cfg.MaxIdleConnections
+	p.l.Unlock()
+	if full {
+		conn.Close()
+		return
+	}
+
+	if err := resetBind(cfg, conn); err != nil {
+		if p.client.Logger.IsDebug() {
+			p.client.Logger.Debug("failed to reset the bind of a connection", "url", conn.URL, "error", err)
+		}
+		p.Fail(conn, err)
+		return
+	}
+
+	p.put(cfg, conn)
+}
+
+func (p *Pool) put(cfg *ConfigEntry, conn *PooledConnection) {
+	p.l.Lock()
+	defer p.l.Unlock()
+
+	if len(p.idle) >= cfg.MaxIdleConnections {
+		conn.Close()
+		return
+	}
+	p.idle = append(p.idle, conn)
+}
+
+// Fail closes a connection that failed. If it failed because of the server,
+// the server is marked as failing and its idle connections are closed.
+func (p *Pool) Fail(conn *PooledConnection, err error) {
+	conn.Close()
+	if IsNetworkError(err) {
+		p.markFailing(conn.URL)
+	}
+}
+
+// HealthCheck verifies the idle connections, closing the ones that fail, and
+// checks the failing servers that weren't checked for the health check
+// interval of the configuration.
+func (p *Pool) HealthCheck(cfg *ConfigEntry) {
+	p.l.Lock()
+	idle := p.idle
+	p.idle = nil
+
+	var due []string
+	for _, u := range cfg.URLs() {
+		if lastCheck, ok := p.failing[u]; ok && time.Since(lastCheck) >= cfg.HealthCheckInterval {
+			due = append(due, u)
+		}
+	}
+	p.l.Unlock()
+
+	for _, conn := range idle {
+		if err := resetBind(cfg, conn); err != nil {
+			p.Fail(conn, err)
+			continue
+		}
+		p.put(cfg, conn)
+	}
+
+	for _, u := range due {
+		conn, err := p.client.DialURL(cfg, u)
+		if err == nil {
+			err = resetBind(cfg, conn)
+			if err != nil {
+				conn.Close()
+			}
+		}
+		if err != nil {
+			if p.client.Logger.IsDebug() {
+				p.client.Logger.Debug("LDAP server is still failing", "url", u, "error", err)
+			}
+			p.markFailing(u)
+			continue
+		}
+
+		p.client.Logger.Info("LDAP server has recovered", "url", u)
+		p.markHealthy(u)
+		p.put(cfg, &PooledConnection{Connection: conn, URL: u})
+	}
+}
+
+// Reset closes the idle connections and forgets the failing servers, for
+// when the configuration changes.
+func (p *Pool) Reset() {
+	p.l.Lock()
+	defer p.l.Unlock()
+
+	for _, conn := range p.idle {
+		conn.Close()
+	}
+	p.idle = nil
+	p.failing = make(map[string]time.Time)
+}
+
+func (p *Pool) markFailing(u string) {
+	p.l.Lock()
+	defer p.l.Unlock()
+
+	if _, ok := p.failing[u]; !ok {
+		p.client.Logger.Warn("LDAP server is failing, failing over to the other servers", "url", u)
+	}
+	p.failing[u] = time.Now()
+
+	idle := p.idle[:0]
+	for _, conn := range p.idle {
+		if conn.URL == u {
+			conn.Close()
+			continue
+		}
+		idle = append(idle, conn)
+	}
+	p.idle = idle
+}
+
+func (p *Pool) markHealthy(u string) {
+	p.l.Lock()
+	defer p.l.Unlock()
+
+	delete(p.failing, u)
+}
+
+// resetBind binds the connection to the bind DN of the configuration, or
+// anonymously if there is none
+func resetBind(cfg *ConfigEntry, conn Connection) error {
+	if cfg.BindPassword != "" {
+		return conn.Bind(cfg.BindDN, cfg.BindPassword)
+	}
+	return conn.UnauthenticatedBind(cfg.BindDN)
+}
+
+// isClosing returns whether the connection was closed, for connections that
+// track it
+func isClosing(conn *PooledConnection) bool {
+	c, ok := conn.Connection.(interface {
+		IsClosing() bool
+	})
+	return ok && c.IsClosing()
+}
+
+// IsNetworkError returns whether the error, or an error it wraps, is an LDAP
+// network error, as opposed to an error returned by the server
+func IsNetworkError(err error) bool {
+	ldapErr, ok := errwrap.GetType(err, &ldap.Error{}).(*ldap.Error)
+	return ok && ldapErr.ResultCode == ldap.ErrorNetwork
+}
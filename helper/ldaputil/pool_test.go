@@ -0,0 +1,190 @@
+package ldaputil
+
+import (
+	"crypto/tls"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-ldap/ldap"
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/helper/logging"
+)
+
+// fakeLDAP dials fake connections, failing for the addresses that are down
+type fakeLDAP struct {
+	l     sync.Mutex
+	down  map[string]bool
+	dials map[string]int
+}
+
+func (f *fakeLDAP) Dial(network, addr string) (Connection, error) {
+	f.l.Lock()
+	defer f.l.Unlock()
+
+	f.dials[addr]++
+	if f.down[addr] {
+		return nil, ldap.NewError(ldap.ErrorNetwork, errors.New("connection refused"))
+	}
+	return &fakeConnection{ldap: f, addr: addr}, nil
+}
+
+func (f *fakeLDAP) DialTLS(network, addr string, config *tls.Config) (Connection, error) {
+	return f.Dial(network, addr)
+}
+
+func (f *fakeLDAP) setDown(addr string, down bool) {
+	f.l.Lock()
+	defer f.l.Unlock()
+	f.down[addr] = down
+}
+
+type fakeConnection struct {
+	ldap   *fakeLDAP
+	addr   string
+	closed bool
+	binds  []string
+}
+
+func (c *fakeConnection) err() error {
+	c.ldap.l.Lock()
+	defer c.ldap.l.Unlock()
+	if c.closed || c.ldap.down[c.addr] {
+		return ldap.NewError(ldap.ErrorNetwork, errors.New("ldap: response channel closed"))
+	}
+	return nil
+}
+
+func (c *fakeConnection) Bind(username, password string) error {
+	c.binds = append(c.binds, username)
+	return c.err()
+}
+
+func (c *fakeConnection) UnauthenticatedBind(username string) error {
+	c.binds = append(c.binds, username)
+	return c.err()
+}
+
+func (c *fakeConnection) Close() {
+	c.closed = true
+}
+
+func (c *fakeConnection) IsClosing() bool {
+	return c.closed
+}
+
+func (c *fakeConnection) Modify(modifyRequest *ldap.ModifyRequest) error {
+	return c.err()
+}
+
+func (c *fakeConnection) Search(searchRequest *ldap.SearchRequest) (*ldap.SearchResult, error) {
+	return &ldap.SearchResult{}, c.err()
+}
+
+func (c *fakeConnection) StartTLS(config *tls.Config) error {
+	return c.err()
+}
+
+func TestPool_Failover(t *testing.T) {
+	fake := &fakeLDAP{
+		down:  map[string]bool{},
+		dials: map[string]int{},
+	}
+	pool := NewPool(&Client{
+		Logger: logging.NewVaultLogger(log.Trace),
+		LDAP:   fake,
+	})
+
+	cfg := testConfig()
+	cfg.Url = "ldap://ldap1.example.com, ldap://ldap2.example.com"
+	cfg.BindDN = "cn=vault,dc=example,dc=com"
+	cfg.BindPassword = "password"
+	cfg.MaxIdleConnections = 1
+	cfg.HealthCheckInterval = time.Hour
+
+	// Connections are made to the first server
+	conn, err := pool.Get(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conn.URL != "ldap://ldap1.example.com" {
+		t.Fatalf("bad: %s", conn.URL)
+	}
+
+	// and reused once bound back to the bind DN
+	conn.Bind("cn=user,dc=example,dc=com", "secret")
+	pool.Put(cfg, conn)
+	binds := conn.Connection.(*fakeConnection).binds
+	if binds[len(binds)-1] != cfg.BindDN {
+		t.Fatalf("expected the connection to be bound to the bind DN: %v", binds)
+	}
+	conn2, err := pool.Get(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conn2 != conn || fake.dials["ldap1.example.com:389"] != 1 {
+		t.Fatal("expected the idle connection to be reused")
+	}
+
+	// When the first server fails, connections are made to the second one
+	fake.setDown("ldap1.example.com:389", true)
+	err = conn2.Bind(cfg.BindDN, cfg.BindPassword)
+	if !IsNetworkError(err) {
+		t.Fatalf("expected a network error: %v", err)
+	}
+	pool.Fail(conn2, err)
+
+	for i := 0; i < 2; i++ {
+		conn, err = pool.Get(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if conn.URL != "ldap://ldap2.example.com" {
+			t.Fatalf("bad: %s", conn.URL)
+		}
+		conn.Close()
+	}
+	if fake.dials["ldap1.example.com:389"] != 1 {
+		t.Fatal("expected the failing server to be skipped")
+	}
+
+	// Failing servers are still tried when all the servers are failing
+	fake.setDown("ldap2.example.com:389", true)
+	if _, err := pool.Get(cfg); err == nil {
+		t.Fatal("expected an error")
+	}
+	if fake.dials["ldap1.example.com:389"] != 2 {
+		t.Fatal("expected the failing server to be tried")
+	}
+
+	// Health checks only happen once the interval has passed
+	fake.setDown("ldap1.example.com:389", false)
+	pool.HealthCheck(cfg)
+	if fake.dials["ldap1.example.com:389"] != 2 {
+		t.Fatal("expected the failing server not to be checked yet")
+	}
+
+	cfg.HealthCheckInterval = time.Nanosecond
+	pool.HealthCheck(cfg)
+	if fake.dials["ldap1.example.com:389"] != 3 {
+		t.Fatal("expected the failing server to be checked")
+	}
+
+	// The recovered server is used again, starting with the connection made
+	// by the health check
+	conn, err = pool.Get(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conn.URL != "ldap://ldap1.example.com" || fake.dials["ldap1.example.com:389"] != 3 {
+		t.Fatalf("bad: %s", conn.URL)
+	}
+
+	// Resetting the pool closes the idle connections
+	pool.Put(cfg, conn)
+	pool.Reset()
+	if !conn.Connection.(*fakeConnection).closed {
+		t.Fatal("expected the idle connection to be closed")
+	}
+}
@@ -10,3 +10,8 @@ const FeatureNone Features = 0
 func (f Features) HasFeature(flag Features) bool {
 	return false
 }
+
+// BuildModules returns the feature modules compiled into this build
+func BuildModules() []string {
+	return nil
+}
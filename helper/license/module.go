@@ -0,0 +1,22 @@
+package license
+
+// The feature modules builds of Vault may include
+const (
+	ModuleReplication        = "replication"
+	ModuleNamespaces         = "namespaces"
+	ModuleHSM                = "hsm"
+	ModuleSentinel           = "sentinel"
+	ModulePerformanceStandby = "performance_standby"
+	ModuleControlGroups      = "control_groups"
+)
+
+// KnownModules are the feature modules reported whether or not they are
+// compiled into the build
+var KnownModules = []string{
+	ModuleReplication,
+	ModuleNamespaces,
+	ModuleHSM,
+	ModuleSentinel,
+	ModulePerformanceStandby,
+	ModuleControlGroups,
+}
@@ -0,0 +1,223 @@
+package logging
+
+import (
+	"bytes"
+	stdlog "log"
+	"strings"
+	"sync/atomic"
+
+	log "github.com/hashicorp/go-hclog"
+)
+
+// levelLogger checks the level of messages itself before handing them to
+// an hclog logger that writes everything, as the loggers created by hclog
+// share their level with the one they were created from.
+type levelLogger struct {
+	logger log.Logger
+	name   string
+	level  *int32
+}
+
+var _ log.Logger = &levelLogger{}
+
+func newLevelLogger(logger log.Logger, level log.Level) *levelLogger {
+	l := int32(level)
+	return &levelLogger{
+		logger: logger,
+		level:  &l,
+	}
+}
+
+// NewSubsystemLogger returns a logger for the named subsystem of the given
+// logger. Its level starts at the level of the given logger but can be
+// changed without affecting it or its other subsystems; loggers created
+// from it share its level. If the given logger wasn't created by this
+// package, the returned logger shares its level.
+func NewSubsystemLogger(logger log.Logger, name string) log.Logger {
+	ll, ok := logger.(*levelLogger)
+	if !ok {
+		return logger.Named(name)
+	}
+
+	ret := ll.Named(name).(*levelLogger)
+	level := atomic.LoadInt32(ll.level)
+	ret.level = &level
+	return ret
+}
+
+// Name returns the name of the logger, or an empty string if the logger
+// wasn't created by this package or wasn't named.
+func Name(logger log.Logger) string {
+	if ll, ok := logger.(*levelLogger); ok {
+		return ll.name
+	}
+	return ""
+}
+
+// Level returns the level of the logger, or NoLevel if it wasn't created
+// by this package.
+func Level(logger log.Logger) log.Level {
+	if ll, ok := logger.(*levelLogger); ok {
+		return log.Level(atomic.LoadInt32(ll.level))
+	}
+	return log.NoLevel
+}
+
+// SubsystemLevel returns the level of the named subsystem among the given
+// levels. The level of a subsystem also applies to the subsystems below it,
+// e.g. the level of "secrets" to "secrets.pki.<accessor>", unless they have
+// their own.
+func SubsystemLevel(levels map[string]log.Level, name string) (log.Level, bool) {
+	for name != "" {
+		if level, ok := levels[name]; ok {
+			return level, true
+		}
+
+		i := strings.LastIndex(name, ".")
+		if i == -1 {
+			break
+		}
+		name = name[:i]
+	}
+
+	return log.NoLevel, false
+}
+
+func (l *levelLogger) enabled(level log.Level) bool {
+	return level >= log.Level(atomic.LoadInt32(l.level))
+}
+
+func (l *levelLogger) Trace(msg string, args ...interface{}) {
+	if l.enabled(log.Trace) {
+		l.logger.Trace(msg, args...)
+	}
+}
+
+func (l *levelLogger) Debug(msg string, args ...interface{}) {
+	if l.enabled(log.Debug) {
+		l.logger.Debug(msg, args...)
+	}
+}
+
+func (l *levelLogger) Info(msg string, args ...interface{}) {
+	if l.enabled(log.Info) {
+		l.logger.Info(msg, args...)
+	}
+}
+
+func (l *levelLogger) Warn(msg string, args ...interface{}) {
+	if l.enabled(log.Warn) {
+		l.logger.Warn(msg, args...)
+	}
+}
+
+func (l *levelLogger) Error(msg string, args ...interface{}) {
+	if l.enabled(log.Error) {
+		l.logger.Error(msg, args...)
+	}
+}
+
+func (l *levelLogger) IsTrace() bool { return l.enabled(log.Trace) }
+func (l *levelLogger) IsDebug() bool { return l.enabled(log.Debug) }
+func (l *levelLogger) IsInfo() bool  { return l.enabled(log.Info) }
+func (l *levelLogger) IsWarn() bool  { return l.enabled(log.Warn) }
+func (l *levelLogger) IsError() bool { return l.enabled(log.Error) }
+
+func (l *levelLogger) With(args ...interface{}) log.Logger {
+	return &levelLogger{
+		logger: l.logger.With(args...),
+		name:   l.name,
+		level:  l.level,
+	}
+}
+
+func (l *levelLogger) Named(name string) log.Logger {
+	fullName := name
+	if l.name != "" {
+		fullName = l.name + "." + name
+	}
+
+	return &levelLogger{
+		logger: l.logger.Named(name),
+		name:   fullName,
+		level:  l.level,
+	}
+}
+
+func (l *levelLogger) ResetNamed(name string) log.Logger {
+	return &levelLogger{
+		logger: l.logger.ResetNamed(name),
+		name:   name,
+		level:  l.level,
+	}
+}
+
+// SetLevel changes the level of the logger and of the loggers created from
+// it, except for subsystem loggers.
+func (l *levelLogger) SetLevel(level log.Level) {
+	atomic.StoreInt32(l.level, int32(level))
+}
+
+func (l *levelLogger) StandardLogger(opts *log.StandardLoggerOptions) *stdlog.Logger {
+	if opts == nil {
+		opts = &log.StandardLoggerOptions{}
+	}
+
+	return stdlog.New(&stdlogAdapter{
+		logger:      l,
+		inferLevels: opts.InferLevels,
+	}, "", 0)
+}
+
+// stdlogAdapter writes the lines of a standard library logger to a Vault
+// logger, so that they are subject to its level.
+type stdlogAdapter struct {
+	logger      log.Logger
+	inferLevels bool
+}
+
+func (s *stdlogAdapter) Write(data []byte) (int, error) {
+	str := string(bytes.TrimRight(data, " \t\n"))
+
+	level := log.Info
+	if s.inferLevels {
+		level, str = pickLevel(str)
+	}
+
+	switch level {
+	case log.Trace:
+		s.logger.Trace(str)
+	case log.Debug:
+		s.logger.Debug(str)
+	case log.Warn:
+		s.logger.Warn(str)
+	case log.Error:
+		s.logger.Error(str)
+	default:
+		s.logger.Info(str)
+	}
+
+	return len(data), nil
+}
+
+// pickLevel detects the level of a line from its prefix, following the same
+// conventions as hclog
+func pickLevel(str string) (log.Level, string) {
+	for _, prefix := range []struct {
+		prefix string
+		level  log.Level
+	}{
+		{"[TRACE]", log.Trace},
+		{"[DEBUG]", log.Debug},
+		{"[INFO]", log.Info},
+		{"[WARN]", log.Warn},
+		{"[ERROR]", log.Error},
+		{"[ERR]", log.Error},
+	} {
+		if strings.HasPrefix(str, prefix.prefix) {
+			return prefix.level, strings.TrimSpace(str[len(prefix.prefix):])
+		}
+	}
+
+	return log.Info, str
+}
@@ -0,0 +1,78 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	log "github.com/hashicorp/go-hclog"
+)
+
+func TestSubsystemLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewVaultLoggerWithFormat(&buf, log.Info, JSONFormat)
+	expiration := NewSubsystemLogger(logger, "expiration")
+	tidy := expiration.Named("tidy")
+
+	if Name(tidy) != "expiration.tidy" {
+		t.Fatalf("bad name: %q", Name(tidy))
+	}
+
+	expiration.SetLevel(log.Debug)
+	if Level(logger) != log.Info || Level(tidy) != log.Debug {
+		t.Fatalf("bad levels: %d %d", Level(logger), Level(tidy))
+	}
+
+	logger.Debug("dropped")
+	tidy.Debug("kept", "key", "value")
+	logger.SetLevel(log.Error)
+	expiration.Info("kept too")
+	logger.StandardLogger(&log.StandardLoggerOptions{InferLevels: true}).Print("[WARN] dropped too")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %q", lines)
+	}
+
+	var line map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &line); err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range map[string]string{
+		"@level":   "debug",
+		"@module":  "expiration.tidy",
+		"@message": "kept",
+		"key":      "value",
+	} {
+		if line[k] != v {
+			t.Fatalf("expected %q to be %q, got %#v", k, v, line)
+		}
+	}
+	if _, ok := line["@timestamp"]; !ok {
+		t.Fatalf("expected a timestamp, got %#v", line)
+	}
+}
+
+func TestSubsystemLevel(t *testing.T) {
+	levels := map[string]log.Level{
+		"secrets":         log.Debug,
+		"secrets.pki.abc": log.Trace,
+		"storage.consul":  log.Warn,
+	}
+
+	for name, expected := range map[string]log.Level{
+		"secrets.kv.def":  log.Debug,
+		"secrets.pki.abc": log.Trace,
+		"storage.consul":  log.Warn,
+		"storage":         log.NoLevel,
+		"storage.cache":   log.NoLevel,
+		"core":            log.NoLevel,
+		"":                log.NoLevel,
+	} {
+		level, ok := SubsystemLevel(levels, name)
+		if ok != (expected != log.NoLevel) || level != expected {
+			t.Fatalf("bad level of %q: %d", name, level)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package logging
 
 import (
+	"fmt"
 	"io"
 	"os"
 	"strings"
@@ -8,6 +9,80 @@ import (
 	log "github.com/hashicorp/go-hclog"
 )
 
+// LogFormat is the format of the lines written by a Vault logger
+type LogFormat int
+
+const (
+	// UnspecifiedFormat falls back to the format given by the
+	// VAULT_LOG_FORMAT environment variable, which defaults to standard
+	UnspecifiedFormat LogFormat = iota
+	StandardFormat
+	JSONFormat
+)
+
+func (f LogFormat) String() string {
+	switch f {
+	case StandardFormat:
+		return "standard"
+	case JSONFormat:
+		return "json"
+	default:
+		return ""
+	}
+}
+
+// ParseLogFormat parses a log format from its name. An empty name gives
+// UnspecifiedFormat.
+func ParseLogFormat(format string) (LogFormat, error) {
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case "":
+		return UnspecifiedFormat, nil
+	case "standard":
+		return StandardFormat, nil
+	case "json", "vault_json", "vault-json", "vaultjson":
+		return JSONFormat, nil
+	default:
+		return UnspecifiedFormat, fmt.Errorf("unknown log format: %s", format)
+	}
+}
+
+// ParseLogLevel parses a log level from its name, accepting the aliases
+// allowed by the server configuration
+func ParseLogLevel(level string) (log.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "trace":
+		return log.Trace, nil
+	case "debug":
+		return log.Debug, nil
+	case "notice", "info", "":
+		return log.Info, nil
+	case "warn", "warning":
+		return log.Warn, nil
+	case "err", "error":
+		return log.Error, nil
+	default:
+		return log.NoLevel, fmt.Errorf("unknown log level: %s", level)
+	}
+}
+
+// LevelName returns the name of a log level, as accepted by ParseLogLevel
+func LevelName(level log.Level) string {
+	switch level {
+	case log.Trace:
+		return "trace"
+	case log.Debug:
+		return "debug"
+	case log.Info:
+		return "info"
+	case log.Warn:
+		return "warn"
+	case log.Error:
+		return "error"
+	default:
+		return ""
+	}
+}
+
 // NewVaultLogger creates a new logger with the specified level and a Vault
 // formatter
 func NewVaultLogger(level log.Level) log.Logger {
@@ -17,12 +92,26 @@ func NewVaultLogger(level log.Level) log.Logger {
 // NewVaultLoggerWithWriter creates a new logger with the specified level and
 // writer and a Vault formatter
 func NewVaultLoggerWithWriter(w io.Writer, level log.Level) log.Logger {
+	return NewVaultLoggerWithFormat(w, level, UnspecifiedFormat)
+}
+
+// NewVaultLoggerWithFormat creates a new logger with the specified level,
+// writer and format. JSON lines carry the "@timestamp", "@level", "@module"
+// and "@message" fields, followed by the key/value pairs of the message.
+func NewVaultLoggerWithFormat(w io.Writer, level log.Level, format LogFormat) log.Logger {
+	jsonFormat := format == JSONFormat
+	if format == UnspecifiedFormat {
+		jsonFormat = useJson()
+	}
+
 	opts := &log.LoggerOptions{
-		Level:      level,
+		// Levels are checked by the returned logger so that subsystems
+		// can have their own
+		Level:      log.Trace,
 		Output:     w,
-		JSONFormat: useJson(),
+		JSONFormat: jsonFormat,
 	}
-	return log.New(opts)
+	return newLevelLogger(log.New(opts), level)
 }
 
 func useJson() bool {
@@ -30,10 +119,6 @@ func useJson() bool {
 	if logFormat == "" {
 		logFormat = os.Getenv("LOGXI_FORMAT")
 	}
-	switch strings.ToLower(logFormat) {
-	case "json", "vault_json", "vault-json", "vaultjson":
-		return true
-	default:
-		return false
-	}
+	format, _ := ParseLogFormat(logFormat)
+	return format == JSONFormat
 }
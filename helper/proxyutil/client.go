@@ -0,0 +1,50 @@
+package proxyutil
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/hashicorp/errwrap"
+	cleanhttp "github.com/hashicorp/go-cleanhttp"
+)
+
+// ParseProxyURL parses the URL of a proxy for outbound requests, e.g. to the
+// APIs of cloud providers. An empty URL gives a nil URL.
+func ParseProxyURL(rawURL string) (*url.URL, error) {
+	if rawURL == "" {
+		return nil, nil
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, errwrap.Wrapf("invalid proxy URL: {{err}}", err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return nil, fmt.Errorf("invalid proxy URL %q: scheme must be one of http, https or socks5", rawURL)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid proxy URL %q: missing host", rawURL)
+	}
+
+	return u, nil
+}
+
+// NewHTTPClient returns an HTTP client that sends requests through the proxy
+// at the given URL. If the URL is empty, the proxy is taken from the
+// HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables.
+func NewHTTPClient(proxyURL string) (*http.Client, error) {
+	u, err := ParseProxyURL(proxyURL)
+	if err != nil {
+		return nil, err
+	}
+
+	client := cleanhttp.DefaultClient()
+	if u != nil {
+		client.Transport.(*http.Transport).Proxy = http.ProxyURL(u)
+	}
+
+	return client, nil
+}
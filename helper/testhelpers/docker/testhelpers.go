@@ -0,0 +1,194 @@
+// Package docker runs services needed by acceptance tests in throwaway Docker
+// containers.
+//
+// A test describes the container it needs with RunOptions and a readiness
+// probe, and gets back the address of a service which is ready to be used:
+//
+//	svc := docker.Start(t, docker.RunOptions{
+//		ImageRepo:     "postgres",
+//		ImageTag:      version,
+//		Env:           []string{"POSTGRES_PASSWORD=secret"},
+//		Ports:         []string{"5432/tcp"},
+//		AddressEnvVar: "PG_ADDR",
+//	}, func(svc *docker.Service) error {
+//		return pingPostgres(svc.Address("5432/tcp"))
+//	})
+//	defer svc.Cleanup()
+//
+// Version matrices are built by looping over Versions and starting one
+// container per subtest.
+package docker
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	testing "github.com/mitchellh/go-testing-interface"
+	"github.com/ory/dockertest"
+)
+
+const (
+	// DefaultReadyTimeout is how long a container is given to become ready
+	// when RunOptions doesn't say otherwise.
+	DefaultReadyTimeout = time.Minute
+
+	// DefaultExpiry is how long a container is allowed to run when
+	// RunOptions doesn't say otherwise. It is stopped after that even if the
+	// test never cleaned it up, e.g. because it panicked.
+	DefaultExpiry = 10 * time.Minute
+
+	// Label is set on every container started by this package, so leftover
+	// containers can be found with "docker ps --filter label=vault-test".
+	Label = "vault-test"
+)
+
+// RunOptions describes the container to start for a service.
+type RunOptions struct {
+	ImageRepo  string
+	ImageTag   string
+	Cmd        []string
+	Entrypoint []string
+	Env        []string
+
+	// Ports lists the container ports to publish, e.g. "5432/tcp". Each one is
+	// published on a random free port of the host.
+	Ports []string
+
+	// AddressEnvVar names an environment variable which, when set, holds the
+	// address of an already running instance of the service. No container is
+	// started in that case, and Service.Address returns that address for
+	// every port.
+	AddressEnvVar string
+
+	// ReadyTimeout bounds how long the readiness probe is retried for.
+	ReadyTimeout time.Duration
+
+	// Expiry bounds how long the container may run for.
+	Expiry time.Duration
+}
+
+// ReadyFunc probes a started service, returning nil once it is ready to be
+// used. It is retried with exponential backoff until it succeeds or the
+// ReadyTimeout elapses.
+type ReadyFunc func(svc *Service) error
+
+// Service is a running service.
+type Service struct {
+	// Container is the container running the service, or nil if an external
+	// instance was given through RunOptions.AddressEnvVar.
+	Container *dockertest.Resource
+
+	// Cleanup stops and removes the container. It is safe to call when no
+	// container was started.
+	Cleanup func()
+
+	externalAddr string
+}
+
+// Address returns the host:port address at which the given container port is
+// reachable from the test.
+func (s *Service) Address(port string) string {
+	if s.externalAddr != "" {
+		return s.externalAddr
+	}
+	return s.Container.GetHostPort(port)
+}
+
+// Start starts a container according to opts and waits for ready to succeed
+// against it. The test is failed if the container can't be started or never
+// becomes ready, in which case the container is removed.
+func Start(t testing.T, opts RunOptions, ready ReadyFunc) *Service {
+	svc, err := StartE(opts, ready)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return svc
+}
+
+// StartE is like Start but returns an error rather than failing a test, for
+// use outside of tests, e.g. from TestMain.
+func StartE(opts RunOptions, ready ReadyFunc) (*Service, error) {
+	if opts.AddressEnvVar != "" {
+		if addr := os.Getenv(opts.AddressEnvVar); addr != "" {
+			svc := &Service{
+				Cleanup:      func() {},
+				externalAddr: addr,
+			}
+			if ready != nil {
+				if err := ready(svc); err != nil {
+					return nil, errwrap.Wrapf(fmt.Sprintf("service at %s=%s is not ready: {{err}}", opts.AddressEnvVar, addr), err)
+				}
+			}
+			return svc, nil
+		}
+	}
+
+	pool, err := dockertest.NewPool("")
+	if err != nil {
+		return nil, errwrap.Wrapf("failed to connect to docker: {{err}}", err)
+	}
+	pool.MaxWait = opts.ReadyTimeout
+	if pool.MaxWait == 0 {
+		pool.MaxWait = DefaultReadyTimeout
+	}
+
+	resource, err := pool.RunWithOptions(&dockertest.RunOptions{
+		Repository:   opts.ImageRepo,
+		Tag:          opts.ImageTag,
+		Cmd:          opts.Cmd,
+		Entrypoint:   opts.Entrypoint,
+		Env:          opts.Env,
+		ExposedPorts: opts.Ports,
+		Labels:       map[string]string{Label: "true"},
+	})
+	if err != nil {
+		return nil, errwrap.Wrapf(fmt.Sprintf("could not start %s:%s container: {{err}}", opts.ImageRepo, opts.ImageTag), err)
+	}
+
+	expiry := opts.Expiry
+	if expiry == 0 {
+		expiry = DefaultExpiry
+	}
+	resource.Expire(uint(expiry.Seconds()))
+
+	svc := &Service{
+		Container: resource,
+		Cleanup: func() {
+			// Best effort; the container stops on its own once it expires
+			pool.Purge(resource)
+		},
+	}
+
+	if ready != nil {
+		if err := pool.Retry(func() error {
+			return ready(svc)
+		}); err != nil {
+			svc.Cleanup()
+			return nil, errwrap.Wrapf(fmt.Sprintf("%s:%s container did not become ready: {{err}}", opts.ImageRepo, opts.ImageTag), err)
+		}
+	}
+
+	return svc, nil
+}
+
+// Versions returns the image tags to run a test against: the comma-separated
+// list in the given environment variable if it is set, defaults otherwise.
+// This lets CI widen or narrow a version matrix without code changes, e.g.
+// VAULT_TEST_POSTGRES_VERSIONS=9.6,10,11.
+func Versions(envVar string, defaults ...string) []string {
+	raw := os.Getenv(envVar)
+	if raw == "" {
+		return defaults
+	}
+
+	var versions []string
+	for _, v := range strings.Split(raw, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			versions = append(versions, v)
+		}
+	}
+	return versions
+}
@@ -0,0 +1,45 @@
+package docker
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestVersions(t *testing.T) {
+	const envVar = "VAULT_TEST_DOCKER_VERSIONS"
+	defer os.Unsetenv(envVar)
+
+	os.Unsetenv(envVar)
+	if v := Versions(envVar, "1", "2"); !reflect.DeepEqual(v, []string{"1", "2"}) {
+		t.Fatalf("bad: %#v", v)
+	}
+
+	os.Setenv(envVar, " 3.1, ,latest ")
+	if v := Versions(envVar, "1", "2"); !reflect.DeepEqual(v, []string{"3.1", "latest"}) {
+		t.Fatalf("bad: %#v", v)
+	}
+}
+
+func TestStartE_External(t *testing.T) {
+	const envVar = "VAULT_TEST_DOCKER_ADDR"
+	os.Setenv(envVar, "127.0.0.1:1234")
+	defer os.Unsetenv(envVar)
+
+	var probed string
+	svc, err := StartE(RunOptions{
+		ImageRepo:     "unused",
+		AddressEnvVar: envVar,
+	}, func(svc *Service) error {
+		probed = svc.Address("80/tcp")
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer svc.Cleanup()
+
+	if svc.Container != nil || probed != "127.0.0.1:1234" {
+		t.Fatalf("bad: %#v, %q", svc, probed)
+	}
+}
@@ -1,11 +1,14 @@
 package http
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"mime"
 	"net"
 	"net/http"
 	"net/textproto"
@@ -58,6 +61,11 @@ const (
 	// provided and the server is fed ever more data until it exhausts memory.
 	// Can be overridden per listener.
 	DefaultMaxRequestSize = 32 * 1024 * 1024
+
+	// requestConcurrencyLimitRetryAfter is the number of seconds clients
+	// rejected by the request concurrency limit are told to wait before
+	// retrying.
+	requestConcurrencyLimitRetryAfter = "1"
 )
 
 var (
@@ -120,6 +128,7 @@ func Handler(props *vault.HandlerProperties) http.Handler {
 	}
 	mux.Handle("/v1/sys/", handleRequestForwarding(core, handleLogical(core)))
 	mux.Handle("/v1/", handleRequestForwarding(core, handleLogical(core)))
+	mux.Handle("/.well-known/", handleWellKnown(core))
 	if core.UIEnabled() == true {
 		if uiBuiltIn {
 			mux.Handle("/ui/", http.StripPrefix("/ui/", gziphandler.GzipHandler(handleUIHeaders(core, handleUI(http.FileServer(&UIAssetWrapper{FileSystem: assetFS()}))))))
@@ -184,6 +193,7 @@ func wrapGenericHandler(core *vault.Core, h http.Handler, maxRequestSize int64,
 			r = newR
 
 		case strings.HasPrefix(r.URL.Path, "/ui"), r.URL.Path == "/robots.txt", r.URL.Path == "/":
+		case strings.HasPrefix(r.URL.Path, "/.well-known/"):
 		default:
 			respondError(w, http.StatusNotFound, nil)
 			cancelFunc()
@@ -466,6 +476,73 @@ func parseRequest(r *http.Request, w http.ResponseWriter, out interface{}) error
 	return err
 }
 
+// rawBodyContentTypes are the media types of request bodies which are not
+// JSON, and are given to backends as is under logical.HTTPRawBody.
+var rawBodyContentTypes = map[string]bool{
+	"application/ocsp-request": true,
+}
+
+// isRawBodyRequest reports whether the request is a write whose body is to be
+// passed as is to the backend.
+func isRawBodyRequest(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && rawBodyContentTypes[mediaType]
+}
+
+// readRawRequest reads the request body, limited to MaxRequestSize like
+// parseRequest.
+func readRawRequest(r *http.Request, w http.ResponseWriter) ([]byte, error) {
+	reader := r.Body
+	if max, ok := r.Context().Value("max_request_size").(int64); ok && max > 0 {
+		reader = http.MaxBytesReader(w, r.Body, max)
+	}
+	body, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, errwrap.Wrapf("failed to read request body: {{err}}", err)
+	}
+	return body, nil
+}
+
+// isFormRequest reports whether the request body is declared as an HTML form,
+// such as the SAML responses posted by browsers.
+func isFormRequest(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && mediaType == "application/x-www-form-urlencoded"
+}
+
+// parseFormRequest parses a form-encoded request body into out, joining the
+// values of repeated fields with commas. Since many clients send JSON with
+// this content type by default, a body that looks like a JSON object is
+// parsed as JSON instead.
+func parseFormRequest(r *http.Request, w http.ResponseWriter, out *map[string]interface{}) error {
+	body, err := readRawRequest(r, w)
+	if err != nil {
+		return err
+	}
+
+	trimmed := bytes.TrimSpace(body)
+	switch {
+	case len(trimmed) == 0:
+		return io.EOF
+	case trimmed[0] == '{':
+		if err := jsonutil.DecodeJSON(trimmed, out); err != nil {
+			return errwrap.Wrapf("failed to parse JSON input: {{err}}", err)
+		}
+		return nil
+	}
+
+	values, err := url.ParseQuery(string(trimmed))
+	if err != nil {
+		return errwrap.Wrapf("failed to parse form input: {{err}}", err)
+	}
+	data := make(map[string]interface{}, len(values))
+	for k, v := range values {
+		data[k] = strings.Join(v, ",")
+	}
+	*out = data
+	return nil
+}
+
 // handleRequestForwarding determines whether to forward a request or not,
 // falling back on the older behavior of redirecting the client
 func handleRequestForwarding(core *vault.Core, handler http.Handler) http.Handler {
@@ -576,6 +653,9 @@ func request(core *vault.Core, w http.ResponseWriter, rawReq *http.Request, r *l
 	if err != nil && errwrap.Contains(err, logical.ErrPerfStandbyPleaseForward.Error()) {
 		return nil, false, true
 	}
+	if err != nil && errwrap.Contains(err, logical.ErrRequestConcurrencyLimited.Error()) {
+		w.Header().Set("Retry-After", requestConcurrencyLimitRetryAfter)
+	}
 
 	if resp != nil && len(resp.Headers) > 0 {
 		// Set this here so it will take effect regardless of any other type of
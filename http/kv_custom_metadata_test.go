@@ -0,0 +1,117 @@
+package http
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/api"
+	kv "github.com/hashicorp/vault/builtin/logical/kv"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/vault"
+)
+
+func TestKV_CustomMetadataFilter(t *testing.T) {
+	coreConfig := &vault.CoreConfig{
+		LogicalBackends: map[string]logical.Factory{
+			"kv": kv.Factory,
+		},
+	}
+	cluster := vault.NewTestCluster(t, coreConfig, &vault.TestClusterOptions{
+		HandlerFunc: Handler,
+	})
+	cluster.Start()
+	defer cluster.Cleanup()
+
+	core := cluster.Cores[0].Core
+	vault.TestWaitActive(t, core)
+	client := cluster.Cores[0].Client
+
+	// Mount a k/v backend, version 2
+	err := client.Sys().Mount("kv", &api.MountInput{
+		Type:    "kv",
+		Options: map[string]string{"version": "2"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for path, owner := range map[string]string{
+		"app/db":      "teamX",
+		"app/cache":   "teamY",
+		"app/x/token": "teamX",
+		"other":       "teamX",
+	} {
+		if _, err := client.Logical().Write("kv/data/"+path, map[string]interface{}{
+			"data": map[string]interface{}{"a": "b"},
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := client.Logical().Write("kv/metadata/"+path, map[string]interface{}{
+			"custom_metadata": map[string]interface{}{
+				"owner": owner,
+			},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	secret, err := client.Logical().Read("kv/metadata/app/db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secret == nil || !reflect.DeepEqual(secret.Data["custom_metadata"], map[string]interface{}{"owner": "teamX"}) {
+		t.Fatalf("bad metadata: %#v", secret)
+	}
+
+	list := func(path string, filter ...string) []interface{} {
+		t.Helper()
+		r := client.NewRequest("LIST", "/v1/"+path)
+		for _, f := range filter {
+			r.Params.Add("filter", f)
+		}
+		resp, err := client.RawRequest(r)
+		if resp != nil {
+			defer resp.Body.Close()
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		secret, err := api.ParseSecret(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return secret.Data["keys"].([]interface{})
+	}
+
+	if keys := list("kv/metadata/app", "owner=teamX"); !reflect.DeepEqual(keys, []interface{}{"db", "x/token"}) {
+		t.Fatalf("bad filtered keys: %v", keys)
+	}
+	if keys := list("kv/metadata", "owner=teamX"); !reflect.DeepEqual(keys, []interface{}{"app/db", "app/x/token", "other"}) {
+		t.Fatalf("bad filtered keys: %v", keys)
+	}
+	if keys := list("kv/metadata/app"); !reflect.DeepEqual(keys, []interface{}{"cache", "db", "x/"}) {
+		t.Fatalf("bad keys: %v", keys)
+	}
+
+	// The filters of a list request given with the list query parameter
+	// apply as well
+	secret, err = client.Logical().ReadWithData("kv/metadata/app", map[string][]string{
+		"list":   []string{"true"},
+		"filter": []string{"owner=teamY"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keys := secret.Data["keys"]; !reflect.DeepEqual(keys, []interface{}{"cache"}) {
+		t.Fatalf("bad filtered keys: %v", keys)
+	}
+
+	if _, err := client.Logical().Write("kv/metadata/app/db", map[string]interface{}{
+		"custom_metadata": map[string]interface{}{
+			strings.Repeat("k", 129): "too long",
+		},
+	}); err == nil {
+		t.Fatal("expected an error with a custom metadata key that is too long")
+	}
+}
@@ -0,0 +1,128 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	log "github.com/hashicorp/go-hclog"
+	kv "github.com/hashicorp/vault/builtin/logical/kv"
+	"github.com/hashicorp/vault/helper/logging"
+	"github.com/hashicorp/vault/logical"
+)
+
+func TestKV_VersionExpiration(t *testing.T) {
+	storage := &logical.InmemStorage{}
+	b, err := kv.Factory(context.Background(), &logical.BackendConfig{
+		Logger:      logging.NewVaultLogger(log.Trace),
+		System:      logical.TestSystemView(),
+		StorageView: storage,
+		BackendUUID: "kv-uuid",
+		Config:      map[string]string{"version": "2"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request := func(op logical.Operation, path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation: op,
+			Path:      path,
+			Storage:   storage,
+			Data:      data,
+		})
+		if err != nil {
+			t.Fatalf("err: %v, resp: %#v", err, resp)
+		}
+		return resp
+	}
+	write := func(path string, options map[string]interface{}) *logical.Response {
+		t.Helper()
+		return request(logical.UpdateOperation, "data/"+path, map[string]interface{}{
+			"data":    map[string]interface{}{"password": "hunter2"},
+			"options": options,
+		})
+	}
+	readStatus := func(path string) int {
+		t.Helper()
+		resp := request(logical.ReadOperation, "data/"+path, nil)
+		if resp == nil {
+			t.Fatalf("no response reading %s", path)
+		}
+		if status, ok := resp.Data[logical.HTTPStatusCode]; ok {
+			return status.(int)
+		}
+		return http.StatusOK
+	}
+
+	// delete_version_after soft deletes the version
+	resp := write("deleted", map[string]interface{}{"delete_version_after": "1s"})
+	if resp.Data["deletion_time"] == "" {
+		t.Fatalf("expected a deletion time: %#v", resp.Data)
+	}
+	if status := readStatus("deleted"); status != http.StatusOK {
+		t.Fatalf("bad status: %d", status)
+	}
+
+	expiresAt := time.Now().Add(2 * time.Second).UTC().Format(time.RFC3339)
+	resp = write("expired", map[string]interface{}{"expires_at": expiresAt})
+	if resp.Data["expiration_time"] == "" {
+		t.Fatalf("expected an expiration time: %#v", resp.Data)
+	}
+	write("kept", nil)
+
+	// Invalid options are rejected
+	for _, options := range []map[string]interface{}{
+		{"delete_version_after": "-1s"},
+		{"delete_version_after": "bogus"},
+		{"expires_at": "tomorrow"},
+		{"expires_at": time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)},
+	} {
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      "data/invalid",
+			Storage:   storage,
+			Data: map[string]interface{}{
+				"data":    map[string]interface{}{"a": "b"},
+				"options": options,
+			},
+		})
+		if err != logical.ErrInvalidRequest || resp == nil || !resp.IsError() {
+			t.Fatalf("expected an error with options %v: %#v, %v", options, resp, err)
+		}
+	}
+
+	time.Sleep(3 * time.Second)
+
+	if status := readStatus("deleted"); status != http.StatusNotFound {
+		t.Fatalf("bad status: %d", status)
+	}
+	if status := readStatus("expired"); status != http.StatusNotFound {
+		t.Fatalf("bad status: %d", status)
+	}
+
+	// The periodic function destroys the expired version. The backend has
+	// no WAL, so the rollback reports an unsupported operation after it.
+	if _, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.RollbackOperation,
+		Storage:   storage,
+	}); err != nil && err != logical.ErrUnsupportedOperation {
+		t.Fatal(err)
+	}
+
+	resp = request(logical.ReadOperation, "metadata/expired", nil)
+	version := resp.Data["versions"].(map[string]interface{})["1"].(map[string]interface{})
+	if !version["destroyed"].(bool) {
+		t.Fatalf("expected the expired version to be destroyed: %#v", version)
+	}
+	resp = request(logical.ReadOperation, "metadata/deleted", nil)
+	version = resp.Data["versions"].(map[string]interface{})["1"].(map[string]interface{})
+	if version["destroyed"].(bool) {
+		t.Fatalf("expected the deleted version not to be destroyed: %#v", version)
+	}
+	if status := readStatus("kept"); status != http.StatusOK {
+		t.Fatalf("bad status: %d", status)
+	}
+}
@@ -0,0 +1,131 @@
+package http
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	log "github.com/hashicorp/go-hclog"
+	kv "github.com/hashicorp/vault/builtin/logical/kv"
+	"github.com/hashicorp/vault/helper/logging"
+	"github.com/hashicorp/vault/logical"
+)
+
+// blockingStorage holds the deletion of a key until released, pausing a KV
+// upgrade midway
+type blockingStorage struct {
+	*logical.InmemStorage
+	key      string
+	blocked  chan struct{}
+	released chan struct{}
+}
+
+func (s *blockingStorage) Delete(ctx context.Context, key string) error {
+	if key == s.key {
+		close(s.blocked)
+		<-s.released
+	}
+	return s.InmemStorage.Delete(ctx, key)
+}
+
+func TestKV_UpgradeProgress(t *testing.T) {
+	storage := &blockingStorage{
+		InmemStorage: &logical.InmemStorage{},
+		key:          "b",
+		blocked:      make(chan struct{}),
+		released:     make(chan struct{}),
+	}
+	for _, key := range []string{"a", "b", "c"} {
+		if err := storage.Put(context.Background(), &logical.StorageEntry{
+			Key:   key,
+			Value: []byte(`{"name":"` + key + `"}`),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	b, err := kv.Factory(context.Background(), &logical.BackendConfig{
+		Logger:      logging.NewVaultLogger(log.Trace),
+		System:      logical.TestSystemView(),
+		StorageView: storage,
+		BackendUUID: "kv-uuid",
+		Config:      map[string]string{"version": "2"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request := func(op logical.Operation, path string, data map[string]interface{}) (*logical.Response, error) {
+		return b.HandleRequest(context.Background(), &logical.Request{
+			Operation: op,
+			Path:      path,
+			Storage:   storage,
+			Data:      data,
+		})
+	}
+	read := func(key string) map[string]interface{} {
+		resp, err := request(logical.ReadOperation, "data/"+key, nil)
+		if err != nil || resp == nil || resp.IsError() {
+			t.Fatalf("err: %v, resp: %#v", err, resp)
+		}
+		return resp.Data
+	}
+
+	select {
+	case <-storage.blocked:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the upgrade to reach the second key")
+	}
+
+	resp, err := request(logical.ReadOperation, "upgrade", nil)
+	if err != nil || resp == nil {
+		t.Fatalf("err: %v, resp: %#v", err, resp)
+	}
+	if resp.Data["status"] != "in_progress" || resp.Data["keys_total"] != 3 || resp.Data["keys_upgraded"] != 1 || resp.Data["started_time"] == "" {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	// Both the upgraded keys and the ones left are readable
+	for _, key := range []string{"a", "c"} {
+		data := read(key)
+		if data["data"].(map[string]interface{})["name"] != key {
+			t.Fatalf("bad: %#v", data)
+		}
+		if data["metadata"].(map[string]interface{})["version"] != uint64(1) {
+			t.Fatalf("bad: %#v", data)
+		}
+	}
+
+	// while writes are rejected
+	resp, err = request(logical.UpdateOperation, "data/d", map[string]interface{}{
+		"data": map[string]interface{}{"name": "d"},
+	})
+	if err != logical.ErrInvalidRequest {
+		t.Fatalf("expected the write to be rejected: err: %v, resp: %#v", err, resp)
+	}
+
+	close(storage.released)
+	for i := 0; ; i++ {
+		resp, err = request(logical.ReadOperation, "upgrade", nil)
+		if err != nil || resp == nil {
+			t.Fatalf("err: %v, resp: %#v", err, resp)
+		}
+		if resp.Data["status"] == "completed" {
+			break
+		}
+		if i == 50 {
+			t.Fatalf("expected the upgrade to complete: %#v", resp.Data)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if resp.Data["keys_upgraded"] != 3 || resp.Data["completed_time"] == nil {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	if data := read("c"); data["metadata"].(map[string]interface{})["created_time"] == "" {
+		t.Fatalf("expected the key to be upgraded: %#v", data)
+	}
+	if entry, err := storage.Get(context.Background(), "c"); err != nil || entry != nil {
+		t.Fatalf("expected the unversioned data to be removed: %#v %v", entry, err)
+	}
+}
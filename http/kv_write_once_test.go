@@ -0,0 +1,110 @@
+package http
+
+import (
+	"context"
+	"testing"
+
+	log "github.com/hashicorp/go-hclog"
+	kv "github.com/hashicorp/vault/builtin/logical/kv"
+	"github.com/hashicorp/vault/helper/logging"
+	"github.com/hashicorp/vault/logical"
+)
+
+func TestKV_WriteOnce(t *testing.T) {
+	storage := &logical.InmemStorage{}
+	b, err := kv.Factory(context.Background(), &logical.BackendConfig{
+		Logger:      logging.NewVaultLogger(log.Trace),
+		System:      logical.TestSystemView(),
+		StorageView: storage,
+		BackendUUID: "kv-uuid",
+		Config:      map[string]string{"version": "2"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request := func(op logical.Operation, path string, data map[string]interface{}) (*logical.Response, error) {
+		return b.HandleRequest(context.Background(), &logical.Request{
+			Operation: op,
+			Path:      path,
+			Storage:   storage,
+			Data:      data,
+		})
+	}
+	write := func(path string, options map[string]interface{}) (*logical.Response, error) {
+		return request(logical.UpdateOperation, "data/"+path, map[string]interface{}{
+			"data":    map[string]interface{}{"sha256": "d2a84f4b8b650937ec8f73cd8be2c74add5a911ba64df27458ed8229da804a26"},
+			"options": options,
+		})
+	}
+
+	resp, err := request(logical.UpdateOperation, "config", map[string]interface{}{
+		"write_once": true,
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err: %v, resp: %#v", err, resp)
+	}
+
+	resp, err = request(logical.ReadOperation, "config", nil)
+	if err != nil || resp == nil || resp.Data["write_once"] != true {
+		t.Fatalf("err: %v, resp: %#v", err, resp)
+	}
+
+	// New keys can be created
+	resp, err = write("release/1.0.0", nil)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err: %v, resp: %#v", err, resp)
+	}
+
+	// but never written again, even with the current version as cas
+	for _, options := range []map[string]interface{}{nil, {"cas": 1}} {
+		resp, err = write("release/1.0.0", options)
+		if err != logical.ErrInvalidRequest || resp == nil || !resp.IsError() {
+			t.Fatalf("expected the write to be refused: err: %v, resp: %#v", err, resp)
+		}
+	}
+
+	// Soft deleting the version doesn't allow writing the key again
+	resp, err = request(logical.DeleteOperation, "data/release/1.0.0", nil)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err: %v, resp: %#v", err, resp)
+	}
+	resp, err = write("release/1.0.0", nil)
+	if err != logical.ErrInvalidRequest {
+		t.Fatalf("expected the write to be refused: err: %v, resp: %#v", err, resp)
+	}
+
+	// and neither can the metadata be deleted
+	resp, err = request(logical.DeleteOperation, "metadata/release/1.0.0", nil)
+	if err != logical.ErrInvalidRequest {
+		t.Fatalf("expected the metadata deletion to be refused: err: %v, resp: %#v", err, resp)
+	}
+
+	// Combined with cas_required, keys must be created with a cas of 0
+	resp, err = request(logical.UpdateOperation, "config", map[string]interface{}{
+		"cas_required": true,
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err: %v, resp: %#v", err, resp)
+	}
+	resp, err = write("release/1.1.0", nil)
+	if err != logical.ErrInvalidRequest {
+		t.Fatalf("expected the write to be refused: err: %v, resp: %#v", err, resp)
+	}
+	resp, err = write("release/1.1.0", map[string]interface{}{"cas": 0})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err: %v, resp: %#v", err, resp)
+	}
+
+	// Once write-once is disabled, keys can be written again
+	resp, err = request(logical.UpdateOperation, "config", map[string]interface{}{
+		"write_once": false,
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err: %v, resp: %#v", err, resp)
+	}
+	resp, err = write("release/1.1.0", map[string]interface{}{"cas": 1})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err: %v, resp: %#v", err, resp)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"math"
 	"net"
 	"net/http"
 	"strconv"
@@ -52,33 +53,32 @@ func buildLogicalRequest(core *vault.Core, w http.ResponseWriter, r *http.Reques
 			}
 		}
 
-		if !list {
-			getData := map[string]interface{}{}
-
-			for k, v := range r.URL.Query() {
-				// Skip the help key as this is a reserved parameter
-				if k == "help" {
-					continue
-				}
-
-				switch {
-				case len(v) == 0:
-				case len(v) == 1:
-					getData[k] = v[0]
-				default:
-					getData[k] = v
-				}
-			}
-
-			if len(getData) > 0 {
-				data = getData
-			}
-		}
+		data = queryData(r, list)
 
 	case "POST", "PUT":
 		op = logical.UpdateOperation
-		// Parse the request if we can
-		if op == logical.UpdateOperation {
+		// Parse the request if we can; newline-delimited JSON bodies are
+		// read as they are handled
+		switch {
+		case isNDJSONRequest(r):
+		case isRawBodyRequest(r):
+			body, err := readRawRequest(r, w)
+			if err != nil {
+				return nil, http.StatusBadRequest, err
+			}
+			data = map[string]interface{}{
+				logical.HTTPRawBody: body,
+			}
+		case isFormRequest(r):
+			err := parseFormRequest(r, w, &data)
+			if err == io.EOF {
+				data = nil
+				err = nil
+			}
+			if err != nil {
+				return nil, http.StatusBadRequest, err
+			}
+		default:
 			err := parseRequest(r, w, &data)
 			if err == io.EOF {
 				data = nil
@@ -94,6 +94,7 @@ func buildLogicalRequest(core *vault.Core, w http.ResponseWriter, r *http.Reques
 		if !strings.HasSuffix(path, "/") {
 			path += "/"
 		}
+		data = queryData(r, true)
 
 	case "OPTIONS":
 	default:
@@ -138,6 +139,33 @@ func buildLogicalRequest(core *vault.Core, w http.ResponseWriter, r *http.Reques
 	return req, 0, nil
 }
 
+// queryData returns the query parameters of a read or list request as the
+// data of the logical request, or nil if there are none. The parameters that
+// are reserved, such as help, or that select the operation are skipped.
+func queryData(r *http.Request, list bool) map[string]interface{} {
+	data := map[string]interface{}{}
+
+	for k, v := range r.URL.Query() {
+		// Skip the help key as this is a reserved parameter
+		if k == "help" || (list && k == "list") {
+			continue
+		}
+
+		switch {
+		case len(v) == 0:
+		case len(v) == 1:
+			data[k] = v[0]
+		default:
+			data[k] = v
+		}
+	}
+
+	if len(data) == 0 {
+		return nil
+	}
+	return data
+}
+
 func handleLogical(core *vault.Core) http.Handler {
 	return handleLogicalInternal(core, false)
 }
@@ -154,12 +182,31 @@ func handleLogicalInternal(core *vault.Core, injectDataIntoTopLevel bool) http.H
 			return
 		}
 
+		// Rate limit quotas are enforced before the request is forwarded or
+		// routed, so that rejected requests cost as little as possible
+		if allowed, retryAfter := core.ApplyRateLimitQuota(r.Context(), req); !allowed {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			respondError(w, http.StatusTooManyRequests, logical.ErrRateLimitQuotaExceeded)
+			return
+		}
+
 		// Always forward requests that are using a limited use count token
 		if core.PerfStandby() && req.ClientTokenRemainingUses > 0 {
 			forwardRequest(core, w, r)
 			return
 		}
 
+		if isNDJSONRequest(r) {
+			// Streamed bodies cannot be replayed, so requests that might
+			// need to be forwarded are forwarded up front
+			if core.PerfStandby() {
+				forwardRequest(core, w, r)
+				return
+			}
+			handleLogicalNDJSON(core, w, r, req)
+			return
+		}
+
 		// req.Path will be relative by this point. The prefix check is first
 		// to fail faster if we're not in this situation since it's a hot path
 		switch {
@@ -0,0 +1,182 @@
+package http
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"reflect"
+
+	"github.com/hashicorp/errwrap"
+	uuid "github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/helper/jsonutil"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/vault"
+)
+
+const (
+	// NDJSONContentType is the media type of newline-delimited JSON request
+	// and response bodies.
+	NDJSONContentType = "application/x-ndjson"
+
+	// ndjsonBatchSize is the number of lines of a newline-delimited JSON
+	// request body handled together as a single batch request. Only one
+	// batch is held in memory at a time, whatever the size of the body.
+	ndjsonBatchSize = 1000
+)
+
+// isNDJSONRequest reports whether the request is a write with a
+// newline-delimited JSON body.
+func isNDJSONRequest(r *http.Request) bool {
+	if r.Method != "POST" && r.Method != "PUT" {
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && mediaType == NDJSONContentType
+}
+
+// handleLogicalNDJSON serves a write to an endpoint supporting batch_input
+// with a newline-delimited JSON body, each line being one batch item. The
+// body is read ndjsonBatchSize lines at a time, each group is handled as a
+// regular batch request, so it is authorized and audited as such, and its
+// batch_results are streamed back one per line before the next group is
+// read. Other parameters of the endpoint are taken from the query string.
+//
+// If a batch fails after results have been written, a final line holding
+// the errors is written and the request is aborted.
+func handleLogicalNDJSON(core *vault.Core, w http.ResponseWriter, r *http.Request, req *logical.Request) {
+	if req.WrapInfo != nil {
+		respondError(w, http.StatusBadRequest, errors.New("response wrapping is not supported with newline-delimited JSON requests"))
+		return
+	}
+
+	params := make(map[string]interface{})
+	for k, v := range r.URL.Query() {
+		if len(v) > 0 {
+			params[k] = v[0]
+		}
+	}
+
+	maxLineSize := DefaultMaxRequestSize
+	if max, ok := r.Context().Value("max_request_size").(int64); ok && max > 0 {
+		maxLineSize = int(max)
+	}
+	scanner := bufio.NewScanner(r.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+
+	var started bool
+	fail := func(statusCode int, err error) {
+		if !started {
+			respondError(w, statusCode, err)
+			return
+		}
+		json.NewEncoder(w).Encode(&ErrorResponse{Errors: []string{err.Error()}})
+	}
+
+	for done := false; !done; {
+		items := make([]interface{}, 0, ndjsonBatchSize)
+		for len(items) < ndjsonBatchSize {
+			if !scanner.Scan() {
+				done = true
+				break
+			}
+			line := scanner.Bytes()
+			if len(line) == 0 {
+				continue
+			}
+			var item map[string]interface{}
+			if err := jsonutil.DecodeJSON(line, &item); err != nil {
+				fail(http.StatusBadRequest, errwrap.Wrapf("failed to parse JSON input: {{err}}", err))
+				return
+			}
+			items = append(items, item)
+		}
+		if err := scanner.Err(); err != nil {
+			fail(http.StatusBadRequest, errwrap.Wrapf("failed to read request body: {{err}}", err))
+			return
+		}
+		if len(items) == 0 {
+			break
+		}
+
+		batchReq, err := ndjsonBatchRequest(req, params, items)
+		if err != nil {
+			fail(http.StatusInternalServerError, err)
+			return
+		}
+
+		resp, err := core.HandleRequest(r.Context(), batchReq)
+		if err != nil || (resp != nil && resp.IsError()) {
+			statusCode, newErr := logical.RespondErrorCommon(batchReq, resp, err)
+			if newErr == nil {
+				newErr = errors.New("batch request failed")
+			}
+			fail(statusCode, newErr)
+			return
+		}
+
+		var results reflect.Value
+		if resp != nil {
+			results = reflect.ValueOf(resp.Data["batch_results"])
+		}
+		if results.Kind() != reflect.Slice {
+			fail(http.StatusBadRequest, fmt.Errorf("path %q does not support batch requests", req.Path))
+			return
+		}
+
+		if !started {
+			w.Header().Set("Content-Type", NDJSONContentType)
+			w.WriteHeader(http.StatusOK)
+			started = true
+		}
+		enc := json.NewEncoder(w)
+		for i := 0; i < results.Len(); i++ {
+			if err := enc.Encode(results.Index(i).Interface()); err != nil {
+				return
+			}
+		}
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+		}
+	}
+
+	if !started {
+		w.Header().Set("Content-Type", NDJSONContentType)
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// ndjsonBatchRequest returns a copy of the request carrying the given batch
+// items and parameters.
+func ndjsonBatchRequest(req *logical.Request, params map[string]interface{}, items []interface{}) (*logical.Request, error) {
+	id, err := uuid.GenerateUUID()
+	if err != nil {
+		return nil, errwrap.Wrapf("failed to generate identifier for the request: {{err}}", err)
+	}
+
+	data := make(map[string]interface{}, len(params)+1)
+	for k, v := range params {
+		data[k] = v
+	}
+	data["batch_input"] = items
+
+	batchReq := &logical.Request{
+		ID:                       id,
+		Operation:                req.Operation,
+		Path:                     req.Path,
+		Data:                     data,
+		Connection:               req.Connection,
+		Headers:                  req.Headers,
+		ClientToken:              req.ClientToken,
+		ClientTokenAccessor:      req.ClientTokenAccessor,
+		ClientTokenRemainingUses: req.ClientTokenRemainingUses,
+		ClientTokenSource:        req.ClientTokenSource,
+		PolicyOverride:           req.PolicyOverride,
+		MFACreds:                 req.MFACreds,
+	}
+	batchReq.SetTokenEntry(req.TokenEntry())
+
+	return batchReq, nil
+}
@@ -0,0 +1,137 @@
+package http
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	cleanhttp "github.com/hashicorp/go-cleanhttp"
+	"github.com/hashicorp/vault/builtin/logical/transit"
+	"github.com/hashicorp/vault/helper/consts"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/vault"
+)
+
+func testHttpNDJSON(t *testing.T, token, addr string, lines []map[string]interface{}) *http.Response {
+	t.Helper()
+
+	body := new(bytes.Buffer)
+	enc := json.NewEncoder(body)
+	for _, line := range lines {
+		if err := enc.Encode(line); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	req, err := http.NewRequest("POST", addr, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", NDJSONContentType)
+	req.Header.Set(consts.AuthHeaderName, token)
+
+	resp, err := cleanhttp.DefaultClient().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func readNDJSON(t *testing.T, resp *http.Response) []map[string]interface{} {
+	t.Helper()
+	defer resp.Body.Close()
+
+	var out []map[string]interface{}
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		var line map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatal(err)
+		}
+		out = append(out, line)
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestLogical_NDJSON(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealedWithConfig(t, &vault.CoreConfig{
+		LogicalBackends: map[string]logical.Factory{
+			"transit": transit.Factory,
+		},
+	})
+	ln, addr := TestServer(t, core)
+	defer ln.Close()
+	TestServerAuth(t, addr, token)
+
+	resp := testHttpPost(t, token, addr+"/v1/sys/mounts/transit", map[string]interface{}{
+		"type": "transit",
+	})
+	testResponseStatus(t, resp, 204)
+	resp = testHttpPost(t, token, addr+"/v1/transit/keys/test", nil)
+	testResponseStatus(t, resp, 204)
+
+	// More items than fit in one batch, so results span several batches
+	count := ndjsonBatchSize + 10
+	var items []map[string]interface{}
+	for i := 0; i < count; i++ {
+		items = append(items, map[string]interface{}{
+			"plaintext": base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("item-%d", i))),
+		})
+	}
+
+	resp = testHttpNDJSON(t, token, addr+"/v1/transit/encrypt/test", items)
+	testResponseStatus(t, resp, 200)
+	if ct := resp.Header.Get("Content-Type"); ct != NDJSONContentType {
+		t.Fatalf("bad: content type: %q", ct)
+	}
+	encrypted := readNDJSON(t, resp)
+	if len(encrypted) != count {
+		t.Fatalf("expected %d results, got %d", count, len(encrypted))
+	}
+
+	var ciphertexts []map[string]interface{}
+	for _, result := range encrypted {
+		if result["error"] != nil && result["error"] != "" {
+			t.Fatalf("bad: %#v", result)
+		}
+		ciphertexts = append(ciphertexts, map[string]interface{}{
+			"ciphertext": result["ciphertext"],
+		})
+	}
+
+	resp = testHttpNDJSON(t, token, addr+"/v1/transit/decrypt/test", ciphertexts)
+	testResponseStatus(t, resp, 200)
+	decrypted := readNDJSON(t, resp)
+	if len(decrypted) != count {
+		t.Fatalf("expected %d results, got %d", count, len(decrypted))
+	}
+	for i, result := range decrypted {
+		if result["plaintext"] != items[i]["plaintext"] {
+			t.Fatalf("bad: result %d: %#v", i, result)
+		}
+	}
+
+	// Malformed input is rejected before anything is written
+	req, err := http.NewRequest("POST", addr+"/v1/transit/encrypt/test", bytes.NewBufferString("{\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", NDJSONContentType)
+	req.Header.Set(consts.AuthHeaderName, token)
+	resp, err = cleanhttp.DefaultClient().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testResponseStatus(t, resp, 400)
+
+	// Endpoints without batch support are rejected
+	resp = testHttpNDJSON(t, token, addr+"/v1/transit/hash", items[:1])
+	testResponseStatus(t, resp, 400)
+}
@@ -2,6 +2,7 @@ package http
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"io"
 	"io/ioutil"
@@ -14,12 +15,14 @@ import (
 	"time"
 
 	"github.com/go-test/deep"
+	cleanhttp "github.com/hashicorp/go-cleanhttp"
 	log "github.com/hashicorp/go-hclog"
 
 	"github.com/hashicorp/vault/helper/consts"
 	"github.com/hashicorp/vault/helper/logging"
 	"github.com/hashicorp/vault/helper/namespace"
 	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
 	"github.com/hashicorp/vault/physical"
 	"github.com/hashicorp/vault/physical/inmem"
 	"github.com/hashicorp/vault/vault"
@@ -165,6 +168,7 @@ func TestLogical_StandbyRedirect(t *testing.T) {
 			"expire_time":      nil,
 			"entity_id":        "",
 			"type":             "service",
+			"mount_path":       "auth/token/",
 		},
 		"warnings":  nilWarnings,
 		"wrap_info": nil,
@@ -176,6 +180,7 @@ func TestLogical_StandbyRedirect(t *testing.T) {
 	actualDataMap := actual["data"].(map[string]interface{})
 	delete(actualDataMap, "creation_time")
 	delete(actualDataMap, "accessor")
+	delete(actualDataMap, "mount_accessor")
 	actual["data"] = actualDataMap
 	expected["request_id"] = actual["request_id"]
 	delete(actual, "lease_id")
@@ -271,6 +276,155 @@ func TestLogical_RequestSizeLimit(t *testing.T) {
 	testResponseStatus(t, resp, 413)
 }
 
+func TestLogical_RateLimitQuota(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealed(t)
+	ln, addr := TestServer(t, core)
+	defer ln.Close()
+	TestServerAuth(t, addr, token)
+
+	resp := testHttpPut(t, token, addr+"/v1/sys/quotas/rate-limit/secret", map[string]interface{}{
+		"path":     "secret",
+		"rate":     1,
+		"interval": "1h",
+	})
+	testResponseStatus(t, resp, 204)
+
+	resp = testHttpPut(t, token, addr+"/v1/secret/foo", map[string]interface{}{
+		"data": "bar",
+	})
+	testResponseStatus(t, resp, 204)
+
+	resp = testHttpGet(t, token, addr+"/v1/secret/foo")
+	testResponseStatus(t, resp, 429)
+	if retryAfter := resp.Header.Get("Retry-After"); retryAfter != "3600" {
+		t.Fatalf("bad: Retry-After: %q", retryAfter)
+	}
+
+	// Other mounts are not limited
+	resp = testHttpGet(t, token, addr+"/v1/sys/mounts")
+	testResponseStatus(t, resp, 200)
+}
+
+func TestLogical_RequestConcurrencyLimit(t *testing.T) {
+	started := make(chan struct{})
+	unblock := make(chan struct{})
+	core, _, token := vault.TestCoreUnsealedWithConfig(t, &vault.CoreConfig{
+		LogicalBackends: map[string]logical.Factory{
+			"blocking": func(ctx context.Context, conf *logical.BackendConfig) (logical.Backend, error) {
+				b := &framework.Backend{
+					BackendType: logical.TypeLogical,
+					Paths: []*framework.Path{
+						{
+							Pattern: "slow",
+							Callbacks: map[logical.Operation]framework.OperationFunc{
+								logical.ReadOperation: func(context.Context, *logical.Request, *framework.FieldData) (*logical.Response, error) {
+									close(started)
+									<-unblock
+									return nil, nil
+								},
+							},
+						},
+					},
+				}
+				if err := b.Setup(ctx, conf); err != nil {
+					return nil, err
+				}
+				return b, nil
+			},
+		},
+	})
+	ln, addr := TestServer(t, core)
+	defer ln.Close()
+	TestServerAuth(t, addr, token)
+
+	resp := testHttpPost(t, token, addr+"/v1/sys/mounts/blocking", map[string]interface{}{
+		"type": "blocking",
+		"config": map[string]interface{}{
+			"request_concurrency_limit": 1,
+		},
+	})
+	testResponseStatus(t, resp, 204)
+
+	// Hold the only slot of the token on the mount with a request in flight
+	done := make(chan error, 1)
+	go func() {
+		req, err := http.NewRequest("GET", addr+"/v1/blocking/slow", nil)
+		if err != nil {
+			done <- err
+			return
+		}
+		req.Header.Set(consts.AuthHeaderName, token)
+		resp, err := cleanhttp.DefaultClient().Do(req)
+		if err == nil {
+			resp.Body.Close()
+		}
+		done <- err
+	}()
+	select {
+	case <-started:
+	case err := <-done:
+		t.Fatalf("the blocking request returned early: %v", err)
+	}
+
+	resp = testHttpGet(t, token, addr+"/v1/blocking/slow")
+	testResponseStatus(t, resp, 429)
+	if retryAfter := resp.Header.Get("Retry-After"); retryAfter != "1" {
+		t.Fatalf("bad: Retry-After: %q", retryAfter)
+	}
+
+	close(unblock)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLogical_FormRequest(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealed(t)
+	ln, addr := TestServer(t, core)
+	defer ln.Close()
+	TestServerAuth(t, addr, token)
+
+	put := func(body string) {
+		req, err := http.NewRequest("PUT", addr+"/v1/secret/foo", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set(consts.AuthHeaderName, token)
+		resp, err := cleanhttp.DefaultClient().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		testResponseStatus(t, resp, 204)
+	}
+	read := func() map[string]interface{} {
+		var actual map[string]interface{}
+		resp := testHttpGet(t, token, addr+"/v1/secret/foo")
+		testResponseStatus(t, resp, 200)
+		testResponseBody(t, resp, &actual)
+		return actual["data"].(map[string]interface{})
+	}
+
+	// Form fields are given as strings, with repeated fields joined
+	put("foo=bar&list=a&list=b")
+	expected := map[string]interface{}{
+		"foo":  "bar",
+		"list": "a,b",
+	}
+	if diff := deep.Equal(read(), expected); diff != nil {
+		t.Fatal(diff)
+	}
+
+	// JSON sent with the default content type of many clients still parses
+	put(`{"foo": "baz"}`)
+	expected = map[string]interface{}{
+		"foo": "baz",
+	}
+	if diff := deep.Equal(read(), expected); diff != nil {
+		t.Fatal(diff)
+	}
+}
+
 func TestLogical_ListSuffix(t *testing.T) {
 	core, _, rootToken := vault.TestCoreUnsealed(t)
 	req, _ := http.NewRequest("GET", "http://127.0.0.1:8200/v1/secret/foo", nil)
@@ -130,3 +130,31 @@ func TestSysAuditHash(t *testing.T) {
 		t.Fatalf("bad: expected:\n%#v\n, got:\n%#v\n", expected, actual)
 	}
 }
+
+func TestSysAuditHash_batch(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealed(t)
+	ln, addr := TestServer(t, core)
+	defer ln.Close()
+	TestServerAuth(t, addr, token)
+
+	resp := testHttpPost(t, token, addr+"/v1/sys/audit/noop", map[string]interface{}{
+		"type": "noop",
+	})
+	testResponseStatus(t, resp, 204)
+
+	resp = testHttpPost(t, token, addr+"/v1/sys/audit-hash/noop", map[string]interface{}{
+		"inputs": []string{"bar", "bar"},
+	})
+
+	var actual map[string]interface{}
+	testResponseStatus(t, resp, 200)
+	testResponseBody(t, resp, &actual)
+
+	expected := []interface{}{
+		"hmac-sha256:f9320baf0249169e73850cd6156ded0106e2bb6ad8cab01b7bbbebe6d1065317",
+		"hmac-sha256:f9320baf0249169e73850cd6156ded0106e2bb6ad8cab01b7bbbebe6d1065317",
+	}
+	if !reflect.DeepEqual(actual["hashes"], expected) {
+		t.Fatalf("bad: expected:\n%#v\n, got:\n%#v\n", expected, actual["hashes"])
+	}
+}
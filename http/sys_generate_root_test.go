@@ -305,6 +305,7 @@ func TestSysGenerateRoot_Update_OTP(t *testing.T) {
 		"expire_time":      nil,
 		"entity_id":        "",
 		"type":             "service",
+		"mount_path":       "auth/token/",
 	}
 
 	resp = testHttpGet(t, newRootToken, addr+"/v1/auth/token/lookup-self")
@@ -313,6 +314,7 @@ func TestSysGenerateRoot_Update_OTP(t *testing.T) {
 
 	expected["creation_time"] = actual["data"].(map[string]interface{})["creation_time"]
 	expected["accessor"] = actual["data"].(map[string]interface{})["accessor"]
+	expected["mount_accessor"] = actual["data"].(map[string]interface{})["mount_accessor"]
 
 	if !reflect.DeepEqual(actual["data"], expected) {
 		t.Fatalf("\nexpected: %#v\nactual: %#v", expected, actual["data"])
@@ -404,6 +406,7 @@ func TestSysGenerateRoot_Update_PGP(t *testing.T) {
 		"expire_time":      nil,
 		"entity_id":        "",
 		"type":             "service",
+		"mount_path":       "auth/token/",
 	}
 
 	resp = testHttpGet(t, newRootToken, addr+"/v1/auth/token/lookup-self")
@@ -412,6 +415,7 @@ func TestSysGenerateRoot_Update_PGP(t *testing.T) {
 
 	expected["creation_time"] = actual["data"].(map[string]interface{})["creation_time"]
 	expected["accessor"] = actual["data"].(map[string]interface{})["accessor"]
+	expected["mount_accessor"] = actual["data"].(map[string]interface{})["mount_accessor"]
 
 	if diff := deep.Equal(actual["data"], expected); diff != nil {
 		t.Fatal(diff)
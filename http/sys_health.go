@@ -77,6 +77,9 @@ func getSysHealth(core *vault.Core, r *http.Request) (int, *HealthResponse, erro
 	_, standbyOK := r.URL.Query()["standbyok"]
 	_, perfStandbyOK := r.URL.Query()["perfstandbyok"]
 
+	// Check if the storage backend should be probed
+	_, detail := r.URL.Query()["detail"]
+
 	uninitCode := http.StatusNotImplemented
 	if code, found, ok := fetchStatusCode(r, "uninitcode"); !ok {
 		return http.StatusBadRequest, nil, nil
@@ -119,6 +122,13 @@ func getSysHealth(core *vault.Core, r *http.Request) (int, *HealthResponse, erro
 		perfStandbyCode = code
 	}
 
+	storageUnhealthyCode := http.StatusServiceUnavailable
+	if code, found, ok := fetchStatusCode(r, "storageunhealthycode"); !ok {
+		return http.StatusBadRequest, nil, nil
+	} else if found {
+		storageUnhealthyCode = code
+	}
+
 	ctx := context.Background()
 
 	// Check system status
@@ -137,6 +147,11 @@ func getSysHealth(core *vault.Core, r *http.Request) (int, *HealthResponse, erro
 		return http.StatusInternalServerError, nil, err
 	}
 
+	var storage *StorageProbeResponse
+	if detail {
+		storage = probeStorage(core)
+	}
+
 	// Determine the status code
 	code := activeCode
 	switch {
@@ -144,6 +159,8 @@ func getSysHealth(core *vault.Core, r *http.Request) (int, *HealthResponse, erro
 		code = uninitCode
 	case sealed:
 		code = sealedCode
+	case storage != nil && !storage.Healthy:
+		code = storageUnhealthyCode
 	case replicationState.HasState(consts.ReplicationDRSecondary):
 		code = drSecondaryCode
 	case !perfStandbyOK && perfStandby:
@@ -178,6 +195,7 @@ func getSysHealth(core *vault.Core, r *http.Request) (int, *HealthResponse, erro
 		Version:                    version.GetVersion().VersionNumber(),
 		ClusterName:                clusterName,
 		ClusterID:                  clusterID,
+		Storage:                    storage,
 	}
 
 	if init && !sealed && !standby {
@@ -199,4 +217,46 @@ type HealthResponse struct {
 	ClusterName                string `json:"cluster_name,omitempty"`
 	ClusterID                  string `json:"cluster_id,omitempty"`
 	LastWAL                    uint64 `json:"last_wal,omitempty"`
+
+	Storage *StorageProbeResponse `json:"storage,omitempty"`
+}
+
+// StorageProbeResponse is the outcome of the storage probes, returned in
+// detail mode. The write probe only runs on the active node.
+type StorageProbeResponse struct {
+	Healthy        bool    `json:"healthy"`
+	ReadLatencyMs  float64 `json:"read_latency_ms"`
+	WriteLatencyMs float64 `json:"write_latency_ms,omitempty"`
+	Error          string  `json:"error,omitempty"`
+}
+
+// probeStorage probes the storage backend. The errors are logged by the core
+// rather than returned, since the endpoints are unauthenticated.
+func probeStorage(core *vault.Core) *StorageProbeResponse {
+	result := core.ProbeStorage(vault.DefaultStorageProbeTimeout)
+
+	resp := &StorageProbeResponse{
+		Healthy:       result.Healthy(),
+		ReadLatencyMs: durationMs(result.ReadLatency),
+	}
+	if result.Write {
+		resp.WriteLatencyMs = durationMs(result.WriteLatency)
+	}
+
+	switch {
+	case result.ReadErr == vault.ErrStorageProbeTimeout:
+		resp.Error = "storage read probe timed out"
+	case result.ReadErr != nil:
+		resp.Error = "storage read probe failed"
+	case result.WriteErr == vault.ErrStorageProbeTimeout:
+		resp.Error = "storage write probe timed out"
+	case result.WriteErr != nil:
+		resp.Error = "storage write probe failed"
+	}
+
+	return resp
+}
+
+func durationMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
 }
@@ -1,14 +1,21 @@
 package http
 
 import (
+	"context"
+	"errors"
 	"io/ioutil"
+	"sync/atomic"
 
 	"net/http"
 	"net/url"
 	"reflect"
 	"testing"
 
+	log "github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/vault/helper/consts"
+	"github.com/hashicorp/vault/helper/logging"
+	"github.com/hashicorp/vault/physical"
+	"github.com/hashicorp/vault/physical/inmem"
 	"github.com/hashicorp/vault/vault"
 )
 
@@ -272,3 +279,111 @@ func TestSysHealth_head(t *testing.T) {
 		}
 	}
 }
+
+// failingBackend is a physical backend whose requests fail once it is set to
+type failingBackend struct {
+	physical.Backend
+	failing uint32
+}
+
+func (b *failingBackend) Get(ctx context.Context, key string) (*physical.Entry, error) {
+	if atomic.LoadUint32(&b.failing) == 1 {
+		return nil, errors.New("storage is failing")
+	}
+	return b.Backend.Get(ctx, key)
+}
+
+func (b *failingBackend) Put(ctx context.Context, entry *physical.Entry) error {
+	if atomic.LoadUint32(&b.failing) == 1 {
+		return errors.New("storage is failing")
+	}
+	return b.Backend.Put(ctx, entry)
+}
+
+func TestSysHealth_storage(t *testing.T) {
+	inm, err := inmem.NewInmem(nil, logging.NewVaultLogger(log.Trace))
+	if err != nil {
+		t.Fatal(err)
+	}
+	backend := &failingBackend{Backend: inm}
+	core, _, _ := vault.TestCoreUnsealedBackend(t, backend)
+	ln, addr := TestServer(t, core)
+	defer ln.Close()
+
+	getStorage := func(path string, code int) map[string]interface{} {
+		t.Helper()
+		resp, err := http.Get(addr + path)
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		var actual map[string]interface{}
+		testResponseStatus(t, resp, code)
+		testResponseBody(t, resp, &actual)
+		storage, _ := actual["storage"].(map[string]interface{})
+		return storage
+	}
+
+	// The storage is only probed in detail mode
+	if storage := getStorage("/v1/sys/health", 200); storage != nil {
+		t.Fatalf("expected no storage probe: %#v", storage)
+	}
+
+	storage := getStorage("/v1/sys/health?detail", 200)
+	if storage["healthy"] != true || storage["read_latency_ms"] == nil || storage["write_latency_ms"] == nil {
+		t.Fatalf("bad: %#v", storage)
+	}
+	entry, err := inm.Get(context.Background(), "core/health-probe")
+	if err != nil || entry == nil {
+		t.Fatalf("expected the write probe to be stored: %v", err)
+	}
+
+	// Probing again within the interval is answered with the last result,
+	// without writing to storage
+	if err := inm.Delete(context.Background(), "core/health-probe"); err != nil {
+		t.Fatal(err)
+	}
+	storage = getStorage("/v1/sys/seal-status?detail", 200)
+	if storage["healthy"] != true {
+		t.Fatalf("bad: %#v", storage)
+	}
+	getStorage("/v1/sys/health?detail", 200)
+	entry, err = inm.Get(context.Background(), "core/health-probe")
+	if err != nil || entry != nil {
+		t.Fatalf("expected the storage not to be probed again: %#v, %v", entry, err)
+	}
+}
+
+func TestSysHealth_storageFailing(t *testing.T) {
+	inm, err := inmem.NewInmem(nil, logging.NewVaultLogger(log.Trace))
+	if err != nil {
+		t.Fatal(err)
+	}
+	backend := &failingBackend{Backend: inm}
+	core, _, _ := vault.TestCoreUnsealedBackend(t, backend)
+	ln, addr := TestServer(t, core)
+	defer ln.Close()
+
+	get := func(path string, code int) map[string]interface{} {
+		t.Helper()
+		resp, err := http.Get(addr + path)
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		var actual map[string]interface{}
+		testResponseStatus(t, resp, code)
+		testResponseBody(t, resp, &actual)
+		storage, _ := actual["storage"].(map[string]interface{})
+		return storage
+	}
+
+	// Failing storage is reported, and changes the status code. The first
+	// request warms up the cache the rest of the health checks read from.
+	get("/v1/sys/health", 200)
+	atomic.StoreUint32(&backend.failing, 1)
+	storage := get("/v1/sys/health?detail", 503)
+	if storage["healthy"] != false || storage["error"] != "storage read probe failed" {
+		t.Fatalf("bad: %#v", storage)
+	}
+	get("/v1/sys/health?detail&storageunhealthycode=299", 299)
+	get("/v1/sys/health", 200)
+}
@@ -5,6 +5,7 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/hashicorp/vault/api"
 	"github.com/hashicorp/vault/vault"
 )
 
@@ -83,3 +84,38 @@ func TestSysInternal_UIMounts(t *testing.T) {
 		t.Fatalf("bad:\nExpected: %#v\nActual:%#v", expected, actual)
 	}
 }
+
+func TestSysInternal_OpenAPI(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealed(t)
+	ln, addr := TestServer(t, core)
+	defer ln.Close()
+
+	config := api.DefaultConfig()
+	config.Address = addr
+
+	client, err := api.NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.SetToken(token)
+
+	doc, err := client.Sys().OpenAPI("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Version == "" {
+		t.Fatalf("expected openapi version to be set: %#v", doc)
+	}
+
+	path, ok := doc.Paths["/sys/mounts"]
+	if !ok {
+		t.Fatalf("expected /sys/mounts to be documented, got: %d paths", len(doc.Paths))
+	}
+	get, ok := path["get"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected get operation on /sys/mounts: %#v", path)
+	}
+	if get["operationId"] != "getSysMounts_test" {
+		t.Fatalf("bad operationId: %#v", get["operationId"])
+	}
+}
@@ -220,6 +220,11 @@ func handleSysSealStatusRaw(core *vault.Core, w http.ResponseWriter, r *http.Req
 
 	progress, nonce := core.SecretProgress()
 
+	var storage *StorageProbeResponse
+	if _, detail := r.URL.Query()["detail"]; detail {
+		storage = probeStorage(core)
+	}
+
 	respondOk(w, &SealStatusResponse{
 		Type:         sealConfig.Type,
 		Initialized:  true,
@@ -233,6 +238,7 @@ func handleSysSealStatusRaw(core *vault.Core, w http.ResponseWriter, r *http.Req
 		ClusterName:  clusterName,
 		ClusterID:    clusterID,
 		RecoverySeal: core.SealAccess().RecoveryKeySupported(),
+		Storage:      storage,
 	})
 }
 
@@ -249,6 +255,8 @@ type SealStatusResponse struct {
 	ClusterName  string `json:"cluster_name,omitempty"`
 	ClusterID    string `json:"cluster_id,omitempty"`
 	RecoverySeal bool   `json:"recovery_seal"`
+
+	Storage *StorageProbeResponse `json:"storage,omitempty"`
 }
 
 // Note: because we didn't provide explicit tagging in the past we can't do it
@@ -3,8 +3,8 @@ package http
 import (
 	"testing"
 
-	kv "github.com/hashicorp/vault-plugin-secrets-kv"
 	"github.com/hashicorp/vault/api"
+	kv "github.com/hashicorp/vault/builtin/logical/kv"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/vault"
 )
@@ -0,0 +1,35 @@
+package http
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/hashicorp/vault/helper/consts"
+	"github.com/hashicorp/vault/vault"
+)
+
+// handleWellKnown redirects requests to the paths below /.well-known/ to the
+// mounts that claimed them
+func handleWellKnown(core *vault.Core) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		dest, err := core.WellKnownRedirect(strings.TrimPrefix(r.URL.Path, "/.well-known/"))
+		switch {
+		case err == consts.ErrStandby:
+			// The claims are only known to the nodes serving requests
+			forwardRequest(core, w, r)
+			return
+		case err != nil:
+			respondError(w, http.StatusInternalServerError, err)
+			return
+		case dest == "":
+			respondError(w, http.StatusNotFound, nil)
+			return
+		}
+
+		// A temporary redirect keeps the method and body of the request
+		redirectURL := *r.URL
+		redirectURL.Path = "/v1/" + dest
+		redirectURL.RawPath = ""
+		http.Redirect(w, r, redirectURL.String(), http.StatusTemporaryRedirect)
+	})
+}
@@ -0,0 +1,111 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	cleanhttp "github.com/hashicorp/go-cleanhttp"
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+	"github.com/hashicorp/vault/vault"
+	"golang.org/x/net/http2"
+)
+
+func TestWellKnown_Redirect(t *testing.T) {
+	coreConfig := &vault.CoreConfig{
+		LogicalBackends: map[string]logical.Factory{
+			"discovery": func(ctx context.Context, conf *logical.BackendConfig) (logical.Backend, error) {
+				b := &framework.Backend{
+					BackendType: logical.TypeLogical,
+					PathsSpecial: &logical.Paths{
+						Unauthenticated: []string{"discovery"},
+						WellKnown: map[string]string{
+							"test-discovery": "discovery",
+						},
+					},
+					Paths: []*framework.Path{
+						{
+							Pattern: "discovery",
+							Callbacks: map[logical.Operation]framework.OperationFunc{
+								logical.ReadOperation: func(context.Context, *logical.Request, *framework.FieldData) (*logical.Response, error) {
+									return &logical.Response{
+										Data: map[string]interface{}{
+											"issuer": "vault",
+										},
+									}, nil
+								},
+							},
+						},
+					},
+				}
+				if err := b.Setup(ctx, conf); err != nil {
+					return nil, err
+				}
+				return b, nil
+			},
+		},
+	}
+	cluster := vault.NewTestCluster(t, coreConfig, &vault.TestClusterOptions{
+		HandlerFunc: Handler,
+	})
+	cluster.Start()
+	defer cluster.Cleanup()
+
+	core := cluster.Cores[0].Core
+	vault.TestWaitActive(t, core)
+	client := cluster.Cores[0].Client
+
+	if err := client.Sys().Mount("discovery", &api.MountInput{
+		Type: "discovery",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	transport := cleanhttp.DefaultTransport()
+	transport.TLSClientConfig = cluster.Cores[0].TLSConfig
+	if err := http2.ConfigureTransport(transport); err != nil {
+		t.Fatal(err)
+	}
+	httpClient := &http.Client{
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	resp, err := httpClient.Get(client.Address() + "/.well-known/test-discovery?x=y")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusTemporaryRedirect {
+		t.Fatalf("bad status: %d", resp.StatusCode)
+	}
+	if location := resp.Header.Get("Location"); location != "/v1/discovery/discovery?x=y" {
+		t.Fatalf("bad location: %s", location)
+	}
+
+	resp, err = httpClient.Get(client.Address() + "/.well-known/unclaimed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("bad status: %d", resp.StatusCode)
+	}
+
+	// Clients following the redirect reach the mount without a token
+	resp, err = (&http.Client{Transport: transport}).Get(client.Address() + "/.well-known/test-discovery")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	secret, err := api.ParseSecret(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secret.Data["issuer"] != "vault" {
+		t.Fatalf("bad response: %#v", secret)
+	}
+}
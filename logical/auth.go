@@ -92,6 +92,12 @@ type Auth struct {
 
 	// TokenType is the type of token being requested
 	TokenType TokenType `json:"token_type"`
+
+	// LoginCommit is set by auth methods whose logins have side effects,
+	// such as changing the password of the user. These are only applied by
+	// the LoginCommitOperation request that core sends on the login path
+	// once the login, including its MFA, succeeded.
+	LoginCommit bool `json:"-"`
 }
 
 func (a *Auth) GoString() string {
@@ -25,6 +25,26 @@ var (
 	// response from an upstream
 	ErrUpstreamRateLimited = errors.New("upstream rate limited")
 
+	// ErrLoginRateLimited is returned when a login request is rejected
+	// because the rate limit tuned on the auth mount has been exceeded
+	ErrLoginRateLimited = errors.New("login rate limit exceeded")
+
+	// ErrRenewRateLimited is returned when a renewal is rejected because the
+	// token making it exceeded the renewal rate limit
+	ErrRenewRateLimited = errors.New("renewal rate limit exceeded")
+
+	// ErrRequestConcurrencyLimited is returned when a request is rejected
+	// because the token making it has too many requests in flight
+	ErrRequestConcurrencyLimited = errors.New("request concurrency limit exceeded")
+
+	// ErrRateLimitQuotaExceeded is returned when a request is rejected
+	// because the client making it exceeded a rate limit quota
+	ErrRateLimitQuotaExceeded = errors.New("rate limit quota exceeded")
+
+	// ErrLeaseCountQuotaExceeded is returned when a request is rejected
+	// because the path it targets reached its lease count quota
+	ErrLeaseCountQuotaExceeded = errors.New("lease count quota exceeded")
+
 	// ErrPerfStandbyForward is returned when Vault is in a state such that a
 	// perf standby cannot satisfy a request
 	ErrPerfStandbyPleaseForward = errors.New("please forward to the active node")
@@ -60,6 +60,11 @@ type Backend struct {
 	// invoked just before the backend is unmounted).
 	PeriodicFunc periodicFunc
 
+	// ScheduledFuncs are run periodically like PeriodicFunc, but on their
+	// own schedules. If set, a "config/schedule" path is added after the
+	// other paths to configure them.
+	ScheduledFuncs []*ScheduledFunc
+
 	// WALRollback is called when a WAL entry (see wal.go) has to be rolled
 	// back. It is called with the data from the entry.
 	//
@@ -88,6 +93,10 @@ type Backend struct {
 	system  logical.SystemView
 	once    sync.Once
 	pathsRe []*regexp.Regexp
+
+	// scheduleRuns holds the last runs of the scheduled functions
+	scheduleLock sync.Mutex
+	scheduleRuns map[string]time.Time
 }
 
 // periodicFunc is the callback called when the RollbackManager's timer ticks.
@@ -302,6 +311,10 @@ func (b *Backend) Secret(k string) *Secret {
 }
 
 func (b *Backend) init() {
+	if len(b.ScheduledFuncs) > 0 {
+		b.Paths = append(b.Paths, b.pathSchedule())
+	}
+
 	b.pathsRe = make([]*regexp.Regexp, len(b.Paths))
 	for i, p := range b.Paths {
 		if len(p.Pattern) == 0 {
@@ -418,7 +431,8 @@ func (b *Backend) handleRevokeRenew(ctx context.Context, req *logical.Request) (
 	}
 }
 
-// handleRollback invokes the PeriodicFunc set on the backend. It also does a WAL rollback operation.
+// handleRollback invokes the PeriodicFunc set on the backend and the
+// ScheduledFuncs which are due. It also does a WAL rollback operation.
 func (b *Backend) handleRollback(ctx context.Context, req *logical.Request) (*logical.Response, error) {
 	// Response is not expected from the periodic operation.
 	if b.PeriodicFunc != nil {
@@ -426,6 +440,9 @@ func (b *Backend) handleRollback(ctx context.Context, req *logical.Request) (*lo
 			return nil, err
 		}
 	}
+	if err := b.runScheduledFuncs(ctx, req); err != nil {
+		return nil, err
+	}
 
 	return b.handleWALRollback(ctx, req)
 }
@@ -507,11 +524,21 @@ type FieldSchema struct {
 	Deprecated  bool
 
 	// AllowedValues is an optional list of permitted values for this field.
-	// This constraint is not (yet) enforced by the framework, but the list is
-	// output as part of OpenAPI generation and may effect documentation and
-	// dynamic UI generation.
+	// Requests with any other value are rejected before the handler is
+	// invoked. For slice types every element must be permitted. The list is
+	// also output as part of OpenAPI generation and may effect documentation
+	// and dynamic UI generation.
 	AllowedValues []interface{}
 
+	// Pattern is an optional regular expression that string values, or each
+	// element of string slices, must match in full.
+	Pattern string
+
+	// MinValue and MaxValue optionally bound the value of TypeInt,
+	// TypeDurationSecond (in seconds) and TypeCommaIntSlice fields.
+	MinValue *int
+	MaxValue *int
+
 	// Display* members are available to provide hints for UI and documentation
 	// generators. They will be included in OpenAPI output if set.
 
@@ -41,10 +41,15 @@ func (d *FieldData) Validate() error {
 		case TypeBool, TypeInt, TypeMap, TypeDurationSecond, TypeString, TypeLowerCaseString,
 			TypeNameString, TypeSlice, TypeStringSlice, TypeCommaStringSlice,
 			TypeKVPairs, TypeCommaIntSlice, TypeHeader:
-			_, _, err := d.getPrimitive(field, schema)
+			result, ok, err := d.getPrimitive(field, schema)
 			if err != nil {
 				return errwrap.Wrapf(fmt.Sprintf("error converting input %v for field %q: {{err}}", value, field), err)
 			}
+			if ok && result != nil {
+				if err := schema.validateValue(field, result); err != nil {
+					return err
+				}
+			}
 		default:
 			return fmt.Errorf("unknown field type %q for field %q", schema.Type, field)
 		}
@@ -0,0 +1,105 @@
+package framework
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sync"
+
+	"github.com/hashicorp/vault/logical"
+)
+
+// patternCache holds compiled FieldSchema patterns, keyed by the pattern, so
+// they aren't compiled on every request.
+var patternCache sync.Map
+
+// IntPtr returns a pointer to n, for use with FieldSchema.MinValue and
+// FieldSchema.MaxValue.
+func IntPtr(n int) *int {
+	return &n
+}
+
+// validateValue checks a converted field value against the AllowedValues,
+// Pattern, MinValue and MaxValue constraints of the schema. Violations are
+// returned as *logical.StatusBadRequest so that they result in a 400.
+func (s *FieldSchema) validateValue(field string, value interface{}) error {
+	if len(s.AllowedValues) == 0 && s.Pattern == "" && s.MinValue == nil && s.MaxValue == nil {
+		return nil
+	}
+
+	var values []interface{}
+	switch v := value.(type) {
+	case []string:
+		for _, e := range v {
+			values = append(values, e)
+		}
+	case []int:
+		for _, e := range v {
+			values = append(values, e)
+		}
+	case []interface{}:
+		values = v
+	default:
+		values = []interface{}{v}
+	}
+
+	for _, v := range values {
+		if err := s.validateElement(field, v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *FieldSchema) validateElement(field string, value interface{}) error {
+	if len(s.AllowedValues) > 0 {
+		var found bool
+		for _, allowed := range s.AllowedValues {
+			if reflect.DeepEqual(allowed, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return &logical.StatusBadRequest{Err: fmt.Sprintf("invalid value %v for field %q: must be one of %v", value, field, s.AllowedValues)}
+		}
+	}
+
+	if s.Pattern != "" {
+		if str, ok := value.(string); ok {
+			re, err := compilePattern(s.Pattern)
+			if err != nil {
+				return fmt.Errorf("invalid pattern for field %q: %s", field, err)
+			}
+			if !re.MatchString(str) {
+				return &logical.StatusBadRequest{Err: fmt.Sprintf("invalid value %q for field %q: must match %q", str, field, s.Pattern)}
+			}
+		}
+	}
+
+	if n, ok := value.(int); ok {
+		if s.MinValue != nil && n < *s.MinValue {
+			return &logical.StatusBadRequest{Err: fmt.Sprintf("invalid value %d for field %q: must be at least %d", n, field, *s.MinValue)}
+		}
+		if s.MaxValue != nil && n > *s.MaxValue {
+			return &logical.StatusBadRequest{Err: fmt.Sprintf("invalid value %d for field %q: must be at most %d", n, field, *s.MaxValue)}
+		}
+	}
+
+	return nil
+}
+
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if re, ok := patternCache.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+
+	re, err := regexp.Compile("^(?:" + pattern + ")$")
+	if err != nil {
+		return nil, err
+	}
+	patternCache.Store(pattern, re)
+
+	return re, nil
+}
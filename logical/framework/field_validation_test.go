@@ -0,0 +1,178 @@
+package framework
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/vault/logical"
+)
+
+func TestFieldDataValidate_Constraints(t *testing.T) {
+	cases := map[string]struct {
+		Schema *FieldSchema
+		Value  interface{}
+		Valid  bool
+	}{
+		"allowed value": {
+			&FieldSchema{Type: TypeString, AllowedValues: []interface{}{"a", "b"}},
+			"b",
+			true,
+		},
+
+		"disallowed value": {
+			&FieldSchema{Type: TypeString, AllowedValues: []interface{}{"a", "b"}},
+			"c",
+			false,
+		},
+
+		"allowed int value": {
+			&FieldSchema{Type: TypeInt, AllowedValues: []interface{}{128, 256}},
+			"256",
+			true,
+		},
+
+		"disallowed slice element": {
+			&FieldSchema{Type: TypeCommaStringSlice, AllowedValues: []interface{}{"a", "b"}},
+			"a,c",
+			false,
+		},
+
+		"allowed slice elements": {
+			&FieldSchema{Type: TypeCommaStringSlice, AllowedValues: []interface{}{"a", "b"}},
+			"a,b",
+			true,
+		},
+
+		"pattern match": {
+			&FieldSchema{Type: TypeString, Pattern: `[a-z]+`},
+			"abc",
+			true,
+		},
+
+		"pattern partial match": {
+			&FieldSchema{Type: TypeString, Pattern: `[a-z]+`},
+			"abc1",
+			false,
+		},
+
+		"pattern slice element": {
+			&FieldSchema{Type: TypeStringSlice, Pattern: `[a-z]+`},
+			[]interface{}{"abc", "AB"},
+			false,
+		},
+
+		"min value": {
+			&FieldSchema{Type: TypeInt, MinValue: IntPtr(1)},
+			1,
+			true,
+		},
+
+		"below min value": {
+			&FieldSchema{Type: TypeInt, MinValue: IntPtr(1)},
+			0,
+			false,
+		},
+
+		"above max value": {
+			&FieldSchema{Type: TypeDurationSecond, MaxValue: IntPtr(60)},
+			"2m",
+			false,
+		},
+
+		"max value": {
+			&FieldSchema{Type: TypeDurationSecond, MaxValue: IntPtr(60)},
+			"1m",
+			true,
+		},
+
+		"int slice out of range": {
+			&FieldSchema{Type: TypeCommaIntSlice, MinValue: IntPtr(0), MaxValue: IntPtr(10)},
+			"1,11",
+			false,
+		},
+	}
+
+	for name, tc := range cases {
+		data := &FieldData{
+			Raw:    map[string]interface{}{"foo": tc.Value},
+			Schema: map[string]*FieldSchema{"foo": tc.Schema},
+		}
+
+		err := data.Validate()
+		switch {
+		case tc.Valid && err != nil:
+			t.Fatalf("bad: %s: %v", name, err)
+		case !tc.Valid && err == nil:
+			t.Fatalf("bad: %s: expected error", name)
+		case !tc.Valid:
+			if _, ok := err.(*logical.StatusBadRequest); !ok {
+				t.Fatalf("bad: %s: expected StatusBadRequest, got %T", name, err)
+			}
+		}
+	}
+}
+
+func TestFieldDataValidate_InvalidPattern(t *testing.T) {
+	data := &FieldData{
+		Raw:    map[string]interface{}{"foo": "bar"},
+		Schema: map[string]*FieldSchema{"foo": &FieldSchema{Type: TypeString, Pattern: `[`}},
+	}
+
+	err := data.Validate()
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if _, ok := err.(*logical.StatusBadRequest); ok {
+		t.Fatal("invalid pattern should not be reported as a bad request")
+	}
+}
+
+func TestBackendHandleRequest_fieldValidation(t *testing.T) {
+	var called bool
+	b := &Backend{
+		Paths: []*Path{
+			&Path{
+				Pattern: "foo",
+				Fields: map[string]*FieldSchema{
+					"mode": &FieldSchema{
+						Type:          TypeString,
+						AllowedValues: []interface{}{"fast", "slow"},
+					},
+				},
+				Callbacks: map[logical.Operation]OperationFunc{
+					logical.UpdateOperation: func(context.Context, *logical.Request, *FieldData) (*logical.Response, error) {
+						called = true
+						return nil, nil
+					},
+				},
+			},
+		},
+	}
+
+	_, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "foo",
+		Data:      map[string]interface{}{"mode": "medium"},
+	})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if code, _ := logical.RespondErrorCommon(&logical.Request{}, nil, err); code != 400 {
+		t.Fatalf("bad: expected status 400, got %d", code)
+	}
+	if called {
+		t.Fatal("handler should not have been invoked")
+	}
+
+	_, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "foo",
+		Data:      map[string]interface{}{"mode": "fast"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !called {
+		t.Fatal("handler should have been invoked")
+	}
+}
@@ -0,0 +1,66 @@
+package framework
+
+import (
+	"errors"
+
+	"github.com/hashicorp/vault/helper/identity"
+	"github.com/hashicorp/vault/logical"
+)
+
+// PopulateIdentityTemplate takes a template string, an entity ID, and an
+// instance of system view. It will query the system view for information
+// about the entity and use the resulting identity information to populate
+// the template string. Only entity directives are supported, as group
+// membership isn't available to backends.
+func PopulateIdentityTemplate(tpl string, entityID string, sysView logical.SystemView) (string, error) {
+	entity, err := sysView.EntityInfo(entityID)
+	if err != nil {
+		return "", err
+	}
+	if entity == nil {
+		return "", errors.New("no entity found")
+	}
+
+	_, out, err := identity.PopulateString(&identity.PopulateStringInput{
+		String: tpl,
+		Entity: identityEntity(entity),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return out, nil
+}
+
+// ValidateIdentityTemplate takes a template string and returns if the string
+// is a template, and an error if the template is malformed.
+func ValidateIdentityTemplate(tpl string) (bool, error) {
+	hasTemplating, _, err := identity.PopulateString(&identity.PopulateStringInput{
+		ValidityCheckOnly: true,
+		String:            tpl,
+	})
+	if err != nil {
+		return false, errors.New("failed to validate policy templating")
+	}
+
+	return hasTemplating, nil
+}
+
+// identityEntity converts the entity information given to backends into the
+// form used for templating
+func identityEntity(entity *logical.Entity) *identity.Entity {
+	ret := &identity.Entity{
+		ID:       entity.ID,
+		Name:     entity.Name,
+		Metadata: entity.Metadata,
+	}
+	for _, alias := range entity.Aliases {
+		ret.Aliases = append(ret.Aliases, &identity.Alias{
+			MountType:     alias.MountType,
+			MountAccessor: alias.MountAccessor,
+			Name:          alias.Name,
+			Metadata:      alias.Metadata,
+		})
+	}
+	return ret
+}
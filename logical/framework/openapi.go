@@ -154,6 +154,8 @@ type OASSchema struct {
 	Items            *OASSchema            `json:"items,omitempty"`
 	Format           string                `json:"format,omitempty"`
 	Pattern          string                `json:"pattern,omitempty"`
+	Minimum          *int                  `json:"minimum,omitempty"`
+	Maximum          *int                  `json:"maximum,omitempty"`
 	Enum             []interface{}         `json:"enum,omitempty"`
 	Example          interface{}           `json:"example,omitempty"`
 	Deprecated       bool                  `json:"deprecated,omitempty"`
@@ -262,6 +264,8 @@ func documentPath(p *Path, specialPaths *logical.Paths, backendType logical.Back
 				Schema: &OASSchema{
 					Type:             t.baseType,
 					Pattern:          t.pattern,
+					Minimum:          field.MinValue,
+					Maximum:          field.MaxValue,
 					Enum:             field.AllowedValues,
 					DisplayName:      field.DisplayName,
 					DisplayValue:     field.DisplayValue,
@@ -270,6 +274,9 @@ func documentPath(p *Path, specialPaths *logical.Paths, backendType logical.Back
 				Required:   required,
 				Deprecated: field.Deprecated,
 			}
+			if field.Pattern != "" {
+				p.Schema.Pattern = field.Pattern
+			}
 			pi.Parameters = append(pi.Parameters, p)
 		}
 
@@ -320,6 +327,8 @@ func documentPath(p *Path, specialPaths *logical.Paths, backendType logical.Back
 						Description:      cleanString(field.Description),
 						Format:           openapiField.format,
 						Pattern:          openapiField.pattern,
+						Minimum:          field.MinValue,
+						Maximum:          field.MaxValue,
 						Enum:             field.AllowedValues,
 						Required:         field.Required,
 						Deprecated:       field.Deprecated,
@@ -329,8 +338,11 @@ func documentPath(p *Path, specialPaths *logical.Paths, backendType logical.Back
 					}
 					if openapiField.baseType == "array" {
 						p.Items = &OASSchema{
-							Type: openapiField.items,
+							Type:    openapiField.items,
+							Pattern: field.Pattern,
 						}
+					} else if field.Pattern != "" {
+						p.Pattern = field.Pattern
 					}
 					s.Properties[name] = &p
 				}
@@ -634,7 +646,8 @@ func cleanResponse(resp *logical.Response) (*cleanedResponse, error) {
 // /sys/tools/random/{urlbytes} -> postSysToolsRandomUrlbytes
 //
 // In the unlikely case of a duplicate ids, a numeric suffix is added:
-//   postSysToolsRandomUrlbytes_2
+//
+//	postSysToolsRandomUrlbytes_2
 //
 // An optional user-provided suffix ("context") may also be appended.
 func (d *OASDocument) CreateOperationIDs(context string) {
@@ -0,0 +1,66 @@
+package framework
+
+import (
+	"context"
+	"strings"
+
+	"github.com/hashicorp/vault/logical"
+)
+
+// PathRevokeAll returns a path at "creds/<name>/revoke-all" that revokes
+// every outstanding lease issued for the named role, for instance after the
+// role was misconfigured. leasePrefixes are the paths the backend issues
+// leases of a role under, e.g. "creds" and "sts"; it defaults to "creds".
+//
+// The role doesn't need to exist anymore, so that the leases of a deleted
+// role can still be revoked.
+func PathRevokeAll(leasePrefixes ...string) *Path {
+	if len(leasePrefixes) == 0 {
+		leasePrefixes = []string{"creds"}
+	}
+
+	return &Path{
+		Pattern: "creds/" + GenericNameRegex("name") + "/revoke-all",
+		Fields: map[string]*FieldSchema{
+			"name": &FieldSchema{
+				Type:        TypeString,
+				Description: "Name of the role",
+			},
+		},
+
+		Callbacks: map[logical.Operation]OperationFunc{
+			logical.UpdateOperation: func(ctx context.Context, req *logical.Request, d *FieldData) (*logical.Response, error) {
+				name := d.Get("name").(string)
+
+				// Vault revokes the leases once the response is returned, the
+				// trailing slash keeps the leases of "foobar" when revoking
+				// the ones of "foo"
+				prefixes := make([]string, 0, len(leasePrefixes))
+				for _, p := range leasePrefixes {
+					prefixes = append(prefixes, strings.TrimSuffix(p, "/")+"/"+name+"/")
+				}
+
+				return &logical.Response{
+					Data: map[string]interface{}{
+						logical.RevokeLeasesPrefixes: prefixes,
+					},
+				}, nil
+			},
+		},
+
+		HelpSynopsis:    strings.TrimSpace(pathRevokeAllHelpSyn),
+		HelpDescription: strings.TrimSpace(pathRevokeAllHelpDesc),
+	}
+}
+
+const pathRevokeAllHelpSyn = `
+Revoke every outstanding lease of a role.
+`
+
+const pathRevokeAllHelpDesc = `
+Writing to this endpoint revokes every lease issued for the named role by this
+mount, and the credentials they hold, for targeted cleanup after a role
+misconfiguration. It works for roles which were since deleted. Revocation is
+performed before the response is returned; an error is returned if any lease
+could not be revoked.
+`
@@ -0,0 +1,28 @@
+package framework
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/vault/logical"
+)
+
+func TestPathRevokeAll(t *testing.T) {
+	storage := new(logical.InmemStorage)
+	var b logical.Backend = &Backend{Paths: []*Path{PathRevokeAll("creds", "sts/")}}
+
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "creds/my-role/revoke-all",
+		Storage:   storage,
+	})
+	if err != nil {
+		t.Fatalf("bad: %#v", err)
+	}
+
+	expected := []string{"creds/my-role/", "sts/my-role/"}
+	if resp == nil || !reflect.DeepEqual(resp.Data[logical.RevokeLeasesPrefixes], expected) {
+		t.Fatalf("bad: %#v", resp)
+	}
+}
@@ -0,0 +1,53 @@
+package framework
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/vault/logical"
+)
+
+// VerifyFunc checks that the external system a backend is configured to talk
+// to can be reached using the configuration held in storage.
+type VerifyFunc func(ctx context.Context, s logical.Storage) error
+
+// PathConfigVerify returns a path at "<configPattern>/verify" that runs the
+// given VerifyFunc against the stored configuration. Backends register it
+// next to their config path so that operators can check connectivity and
+// credentials without having to request a secret, e.g. config/access/verify.
+func PathConfigVerify(configPattern string, verify VerifyFunc) *Path {
+	configPattern = strings.TrimSuffix(configPattern, "$")
+
+	return &Path{
+		Pattern: configPattern + "/verify",
+
+		Callbacks: map[logical.Operation]OperationFunc{
+			logical.ReadOperation: func(ctx context.Context, req *logical.Request, d *FieldData) (*logical.Response, error) {
+				if err := verify(ctx, req.Storage); err != nil {
+					return logical.ErrorResponse(fmt.Sprintf("failed to verify configuration: %s", err)), nil
+				}
+
+				return &logical.Response{
+					Data: map[string]interface{}{
+						"verified": true,
+					},
+				}, nil
+			},
+		},
+
+		HelpSynopsis:    strings.TrimSpace(pathConfigVerifyHelpSyn),
+		HelpDescription: strings.TrimSpace(pathConfigVerifyHelpDesc),
+	}
+}
+
+const pathConfigVerifyHelpSyn = `
+Verify the connection to the configured external system.
+`
+
+const pathConfigVerifyHelpDesc = `
+Reading this endpoint connects to the external system using the stored
+configuration and performs a lightweight authenticated call. It returns
+"verified" on success, or an error describing why the connection or the
+configured credentials could not be verified.
+`
@@ -0,0 +1,49 @@
+package framework
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/hashicorp/vault/logical"
+)
+
+func TestPathConfigVerify(t *testing.T) {
+	var verifyErr error
+	p := PathConfigVerify("config/access", func(ctx context.Context, s logical.Storage) error {
+		return verifyErr
+	})
+
+	storage := new(logical.InmemStorage)
+	var b logical.Backend = &Backend{Paths: []*Path{p}}
+
+	ctx := context.Background()
+
+	resp, err := b.HandleRequest(ctx, &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "config/access/verify",
+		Storage:   storage,
+	})
+	if err != nil {
+		t.Fatalf("bad: %#v", err)
+	}
+	if resp == nil || resp.Data["verified"] != true {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	verifyErr = errors.New("connection refused")
+	resp, err = b.HandleRequest(ctx, &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "config/access/verify",
+		Storage:   storage,
+	})
+	if err != nil {
+		t.Fatalf("bad: %#v", err)
+	}
+	if resp == nil || !resp.IsError() {
+		t.Fatalf("expected error response, got: %#v", resp)
+	}
+	if resp.Data["error"] != "failed to verify configuration: connection refused" {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+}
@@ -0,0 +1,310 @@
+package framework
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gorhill/cronexpr"
+	"github.com/hashicorp/errwrap"
+	multierror "github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/vault/helper/parseutil"
+	"github.com/hashicorp/vault/logical"
+)
+
+const (
+	// ScheduleDisabled is the schedule of the scheduled functions which
+	// never run
+	ScheduleDisabled = "disabled"
+
+	// scheduleConfigPath is the storage path of the configured schedules
+	scheduleConfigPath = "framework/schedule"
+
+	// scheduleTolerance is how early interval schedules may run, so that
+	// the jitter of the ticks of the RollbackManager doesn't make them skip
+	// a tick when their interval is a multiple of its period
+	scheduleTolerance = 5 * time.Second
+)
+
+// ScheduledFunc is a function the backend runs periodically on a schedule
+// which can be changed through its "config/schedule" path, such as a tidy or
+// rotation operation. Schedules are evaluated when the periodic timer of the
+// RollbackManager ticks, once a minute, so functions don't run more often.
+type ScheduledFunc struct {
+	// Name identifies the function in the "config/schedule" path. It must be
+	// a valid field name.
+	Name string
+
+	// Description is shown in the help of the "config/schedule" path.
+	Description string
+
+	// DefaultSchedule is used until another schedule is configured. It is
+	// either an interval, e.g. "1h", or a cron expression, e.g. "0 3 * * *".
+	DefaultSchedule string
+
+	// Func is called with the request of the rollback operation when the
+	// function is due.
+	Func periodicFunc
+}
+
+// schedule is a parsed schedule of a scheduled function
+type schedule struct {
+	interval time.Duration
+	cron     *cronexpr.Expression
+	disabled bool
+}
+
+// parseSchedule parses an interval, a cron expression or ScheduleDisabled
+func parseSchedule(raw string) (*schedule, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == ScheduleDisabled {
+		return &schedule{disabled: true}, nil
+	}
+
+	if interval, err := parseutil.ParseDurationSecond(raw); err == nil {
+		if interval <= 0 {
+			return nil, fmt.Errorf("schedule interval must be positive")
+		}
+		return &schedule{interval: interval}, nil
+	}
+
+	expr, err := cronexpr.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("schedule %q is neither an interval nor a cron expression", raw)
+	}
+	return &schedule{cron: expr}, nil
+}
+
+// next returns the time the schedule is due after the given last run, or the
+// zero time if it is never due
+func (s *schedule) next(lastRun time.Time) time.Time {
+	switch {
+	case s.disabled:
+		return time.Time{}
+	case s.interval > 0:
+		return lastRun.Add(s.interval)
+	default:
+		return s.cron.Next(lastRun)
+	}
+}
+
+// scheduleConfig returns the configured schedules by function name
+func scheduleConfig(ctx context.Context, s logical.Storage) (map[string]string, error) {
+	entry, err := s.Get(ctx, scheduleConfigPath)
+	if err != nil {
+		return nil, err
+	}
+
+	config := make(map[string]string)
+	if entry != nil {
+		if err := entry.DecodeJSON(&config); err != nil {
+			return nil, err
+		}
+	}
+	return config, nil
+}
+
+// scheduledFuncSchedule returns the configured or default schedule of the
+// function
+func scheduledFuncSchedule(f *ScheduledFunc, config map[string]string) string {
+	if raw, ok := config[f.Name]; ok {
+		return raw
+	}
+	return f.DefaultSchedule
+}
+
+// runScheduledFuncs runs the scheduled functions which are due. Last runs are
+// kept in memory, so interval schedules run on the first tick after the
+// backend is loaded, and cron schedules at their next time after it.
+func (b *Backend) runScheduledFuncs(ctx context.Context, req *logical.Request) error {
+	if len(b.ScheduledFuncs) == 0 {
+		return nil
+	}
+
+	config, err := scheduleConfig(ctx, req.Storage)
+	if err != nil {
+		return err
+	}
+
+	var merr *multierror.Error
+	now := time.Now()
+	for _, f := range b.ScheduledFuncs {
+		sched, err := parseSchedule(scheduledFuncSchedule(f, config))
+		if err != nil {
+			merr = multierror.Append(merr, errwrap.Wrapf(fmt.Sprintf("invalid schedule of %q: {{err}}", f.Name), err))
+			continue
+		}
+
+		if !b.scheduledFuncDue(f.Name, sched, now) {
+			continue
+		}
+		if err := f.Func(ctx, req); err != nil {
+			merr = multierror.Append(merr, errwrap.Wrapf(fmt.Sprintf("error running %q: {{err}}", f.Name), err))
+		}
+	}
+
+	return merr.ErrorOrNil()
+}
+
+// scheduledFuncDue returns whether the function is due, recording the run if
+// it is
+func (b *Backend) scheduledFuncDue(name string, sched *schedule, now time.Time) bool {
+	b.scheduleLock.Lock()
+	defer b.scheduleLock.Unlock()
+
+	if b.scheduleRuns == nil {
+		b.scheduleRuns = make(map[string]time.Time)
+	}
+
+	lastRun, ok := b.scheduleRuns[name]
+	if !ok && sched.cron != nil {
+		b.scheduleRuns[name] = now
+		return false
+	}
+
+	next := sched.next(lastRun)
+	if next.IsZero() {
+		return false
+	}
+	if sched.interval > 0 {
+		next = next.Add(-scheduleTolerance)
+	}
+	if now.Before(next) {
+		return false
+	}
+
+	b.scheduleRuns[name] = now
+	return true
+}
+
+// pathSchedule returns the "config/schedule" path, configuring the schedules
+// of the scheduled functions
+func (b *Backend) pathSchedule() *Path {
+	fields := make(map[string]*FieldSchema, len(b.ScheduledFuncs))
+	var desc []string
+	for _, f := range b.ScheduledFuncs {
+		fields[f.Name] = &FieldSchema{
+			Type:        TypeString,
+			Description: fmt.Sprintf("%s Defaults to %q.", f.Description, f.DefaultSchedule),
+		}
+		desc = append(desc, fmt.Sprintf("  %s: %s", f.Name, f.Description))
+	}
+	sort.Strings(desc)
+
+	return &Path{
+		Pattern: "config/schedule",
+		Fields:  fields,
+
+		Callbacks: map[logical.Operation]OperationFunc{
+			logical.ReadOperation:   b.pathScheduleRead,
+			logical.UpdateOperation: b.pathScheduleWrite,
+			logical.DeleteOperation: b.pathScheduleDelete,
+		},
+
+		HelpSynopsis:    strings.TrimSpace(pathScheduleHelpSyn),
+		HelpDescription: strings.TrimSpace(pathScheduleHelpDesc) + "\n\n" + strings.Join(desc, "\n"),
+	}
+}
+
+func (b *Backend) pathScheduleRead(ctx context.Context, req *logical.Request, d *FieldData) (*logical.Response, error) {
+	config, err := scheduleConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	b.scheduleLock.Lock()
+	defer b.scheduleLock.Unlock()
+
+	data := make(map[string]interface{}, len(b.ScheduledFuncs))
+	for _, f := range b.ScheduledFuncs {
+		raw := scheduledFuncSchedule(f, config)
+		info := map[string]interface{}{
+			"schedule":         raw,
+			"default_schedule": f.DefaultSchedule,
+			"last_run":         "",
+			"next_run":         "",
+		}
+
+		// Interval schedules which never ran are due on the next tick
+		var next time.Time
+		sched, err := parseSchedule(raw)
+		lastRun, ok := b.scheduleRuns[f.Name]
+		switch {
+		case err != nil:
+		case ok:
+			info["last_run"] = lastRun.Format(time.RFC3339)
+			next = sched.next(lastRun)
+		case sched.cron != nil:
+			next = sched.next(time.Now())
+		}
+		if !next.IsZero() {
+			info["next_run"] = next.Format(time.RFC3339)
+		}
+
+		data[f.Name] = info
+	}
+
+	return &logical.Response{
+		Data: data,
+	}, nil
+}
+
+func (b *Backend) pathScheduleWrite(ctx context.Context, req *logical.Request, d *FieldData) (*logical.Response, error) {
+	config, err := scheduleConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, f := range b.ScheduledFuncs {
+		raw, ok := d.GetOk(f.Name)
+		if !ok {
+			continue
+		}
+
+		// An empty schedule resets the default one
+		if raw.(string) == "" {
+			delete(config, f.Name)
+			continue
+		}
+		if _, err := parseSchedule(raw.(string)); err != nil {
+			return logical.ErrorResponse(fmt.Sprintf("invalid schedule of %q: %s", f.Name, err)), nil
+		}
+		config[f.Name] = strings.TrimSpace(raw.(string))
+	}
+
+	entry, err := logical.StorageEntryJSON(scheduleConfigPath, config)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+func (b *Backend) pathScheduleDelete(ctx context.Context, req *logical.Request, d *FieldData) (*logical.Response, error) {
+	if err := req.Storage.Delete(ctx, scheduleConfigPath); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+const pathScheduleHelpSyn = `
+Configure the schedules of the periodic operations of the backend.
+`
+
+const pathScheduleHelpDesc = `
+This endpoint configures when the periodic operations of the backend run.
+Each operation is set a schedule which is either an interval, e.g. "1h", a
+cron expression, e.g. "0 3 * * *", or "disabled". An empty schedule resets
+the default one, and deleting the configuration resets all of them.
+
+Schedules are evaluated once a minute, so operations don't run more often.
+Reading the configuration returns the current schedule of each operation,
+along with its last and next runs on this node.
+
+The periodic operations of the backend are:
+`
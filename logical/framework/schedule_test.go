@@ -0,0 +1,147 @@
+package framework
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/logical"
+)
+
+func TestParseSchedule(t *testing.T) {
+	cases := map[string]bool{
+		"1h":          true,
+		"90":          true,
+		"0 3 * * *":   true,
+		"*/5 * * * *": true,
+		"disabled":    true,
+		"":            false,
+		"0s":          false,
+		"-1h":         false,
+		"every day":   false,
+	}
+	for raw, ok := range cases {
+		_, err := parseSchedule(raw)
+		if (err == nil) != ok {
+			t.Fatalf("bad: %q: %v", raw, err)
+		}
+	}
+}
+
+func TestScheduledFuncDue(t *testing.T) {
+	b := &Backend{}
+	now := time.Date(2020, 1, 1, 2, 0, 30, 0, time.UTC)
+
+	// Interval schedules run on the first tick, then once per interval
+	interval, _ := parseSchedule("1m")
+	if !b.scheduledFuncDue("interval", interval, now) {
+		t.Fatal("expected the interval schedule to be due on the first tick")
+	}
+	if b.scheduledFuncDue("interval", interval, now.Add(30*time.Second)) {
+		t.Fatal("expected the interval schedule not to be due")
+	}
+	if !b.scheduledFuncDue("interval", interval, now.Add(58*time.Second)) {
+		t.Fatal("expected the interval schedule to be due despite the jitter of the ticks")
+	}
+
+	// Cron schedules run at their next time
+	cron, _ := parseSchedule("0 3 * * *")
+	if b.scheduledFuncDue("cron", cron, now) {
+		t.Fatal("expected the cron schedule not to be due on the first tick")
+	}
+	if b.scheduledFuncDue("cron", cron, now.Add(59*time.Minute)) {
+		t.Fatal("expected the cron schedule not to be due before its time")
+	}
+	if !b.scheduledFuncDue("cron", cron, now.Add(time.Hour)) {
+		t.Fatal("expected the cron schedule to be due")
+	}
+	if b.scheduledFuncDue("cron", cron, now.Add(time.Hour+time.Minute)) {
+		t.Fatal("expected the cron schedule not to be due again")
+	}
+
+	disabled, _ := parseSchedule(ScheduleDisabled)
+	if b.scheduledFuncDue("disabled", disabled, now) {
+		t.Fatal("expected the disabled schedule never to be due")
+	}
+}
+
+func TestBackend_scheduledFuncs(t *testing.T) {
+	var runs int
+	b := &Backend{
+		ScheduledFuncs: []*ScheduledFunc{
+			{
+				Name:            "tidy",
+				Description:     "Tidies up.",
+				DefaultSchedule: "1m",
+				Func: func(context.Context, *logical.Request) error {
+					runs++
+					return nil
+				},
+			},
+		},
+	}
+	storage := &logical.InmemStorage{}
+	ctx := context.Background()
+
+	request := func(operation logical.Operation, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := b.HandleRequest(ctx, &logical.Request{
+			Operation: operation,
+			Path:      "config/schedule",
+			Storage:   storage,
+			Data:      data,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+	rollback := func() {
+		t.Helper()
+		_, err := b.HandleRequest(ctx, &logical.Request{
+			Operation: logical.RollbackOperation,
+			Storage:   storage,
+		})
+		if err != nil && err != logical.ErrUnsupportedOperation {
+			t.Fatal(err)
+		}
+	}
+
+	resp := request(logical.ReadOperation, nil)
+	tidy := resp.Data["tidy"].(map[string]interface{})
+	if tidy["schedule"] != "1m" || tidy["default_schedule"] != "1m" || tidy["last_run"] != "" {
+		t.Fatalf("bad: %#v", tidy)
+	}
+
+	rollback()
+	if runs != 1 {
+		t.Fatalf("expected the function to run once, ran %d times", runs)
+	}
+
+	resp = request(logical.UpdateOperation, map[string]interface{}{"tidy": "every day"})
+	if resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error, got: %#v", resp)
+	}
+
+	if resp := request(logical.UpdateOperation, map[string]interface{}{"tidy": ScheduleDisabled}); resp != nil {
+		t.Fatalf("bad: %#v", resp)
+	}
+	resp = request(logical.ReadOperation, nil)
+	tidy = resp.Data["tidy"].(map[string]interface{})
+	if tidy["schedule"] != ScheduleDisabled || tidy["last_run"] == "" || tidy["next_run"] != "" {
+		t.Fatalf("bad: %#v", tidy)
+	}
+
+	// Disabled functions don't run, and the default schedule is reset by
+	// deleting the configuration
+	b.scheduleRuns["tidy"] = time.Now().Add(-time.Hour)
+	rollback()
+	if runs != 1 {
+		t.Fatalf("expected the disabled function not to run, ran %d times", runs)
+	}
+	request(logical.DeleteOperation, nil)
+	rollback()
+	if runs != 2 {
+		t.Fatalf("expected the function to run again, ran %d times", runs)
+	}
+}
@@ -123,4 +123,17 @@ type Paths struct {
 	// should be seal wrapped with extra encryption. It is exact matching
 	// unless it ends with '/' in which case it will be treated as a prefix.
 	SealWrapStorage []string
+
+	// CachedResponses are unauthenticated paths whose read responses can be
+	// served from a short-lived cache. The cache of the mount is cleared on
+	// any request that may change its state, so these should only be paths
+	// whose responses depend solely on the storage of the backend.
+	CachedResponses []string
+
+	// WellKnown are the paths below /.well-known/ claimed by the mount,
+	// mapped to the paths of the mount that requests to them are redirected
+	// to. Redirected clients usually hold no token, so the paths redirected
+	// to should also be unauthenticated. A path can only be claimed by one
+	// mount at a time.
+	WellKnown map[string]string
 }
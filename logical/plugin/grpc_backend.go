@@ -6,6 +6,10 @@ import (
 	"google.golang.org/grpc"
 )
 
+// handleRequestChunkSize is the maximum size of the chunks of the replies
+// streamed by the HandleRequestStream rpc
+const handleRequestChunkSize = 1024 * 1024
+
 var largeMsgGRPCCallOpts []grpc.CallOption = []grpc.CallOption{
 	grpc.MaxCallSendMsgSize(math.MaxInt32),
 	grpc.MaxCallRecvMsgSize(math.MaxInt32),
@@ -1,13 +1,18 @@
 package plugin
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"io"
 	"math"
 	"sync/atomic"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
+	"github.com/golang/protobuf/proto"
 	log "github.com/hashicorp/go-hclog"
 	plugin "github.com/hashicorp/go-plugin"
 	"github.com/hashicorp/vault/helper/pluginutil"
@@ -41,6 +46,11 @@ type backendGRPCPluginClient struct {
 	// so it can be cleaned up.
 	clientConn *grpc.ClientConn
 	doneCtx    context.Context
+
+	// streamingUnsupported is set to 1 once the plugin is found not to
+	// implement the HandleRequestStream rpc, so that requests go straight to
+	// the HandleRequest rpc.
+	streamingUnsupported uint32
 }
 
 func (b *backendGRPCPluginClient) HandleRequest(ctx context.Context, req *logical.Request) (*logical.Response, error) {
@@ -58,9 +68,9 @@ func (b *backendGRPCPluginClient) HandleRequest(ctx context.Context, req *logica
 		return nil, err
 	}
 
-	reply, err := b.client.HandleRequest(ctx, &pb.HandleRequestArgs{
+	reply, err := b.handleRequest(ctx, &pb.HandleRequestArgs{
 		Request: protoReq,
-	}, largeMsgGRPCCallOpts...)
+	})
 	if err != nil {
 		if b.doneCtx.Err() != nil {
 			return nil, ErrPluginShutdown
@@ -79,6 +89,55 @@ func (b *backendGRPCPluginClient) HandleRequest(ctx context.Context, req *logica
 	return resp, nil
 }
 
+// handleRequest calls the HandleRequestStream rpc, falling back to the
+// HandleRequest rpc for plugins built against older versions of the SDK which
+// don't implement it.
+func (b *backendGRPCPluginClient) handleRequest(ctx context.Context, args *pb.HandleRequestArgs) (*pb.HandleRequestReply, error) {
+	if atomic.LoadUint32(&b.streamingUnsupported) == 0 {
+		reply, err := b.handleRequestStream(ctx, args)
+		if err == nil {
+			return reply, nil
+		}
+
+		// Fall back to the unary call if not implemented
+		grpcStatus, ok := status.FromError(err)
+		if !ok || grpcStatus.Code() != codes.Unimplemented {
+			return nil, err
+		}
+		atomic.StoreUint32(&b.streamingUnsupported, 1)
+	}
+
+	return b.client.HandleRequest(ctx, args, largeMsgGRPCCallOpts...)
+}
+
+// handleRequestStream calls the HandleRequestStream rpc and reassembles the
+// reply from the streamed chunks.
+func (b *backendGRPCPluginClient) handleRequestStream(ctx context.Context, args *pb.HandleRequestArgs) (*pb.HandleRequestReply, error) {
+	stream, err := b.client.HandleRequestStream(ctx, args, largeMsgGRPCCallOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(chunk.Data)
+	}
+
+	reply := &pb.HandleRequestReply{}
+	if err := proto.Unmarshal(buf.Bytes(), reply); err != nil {
+		return nil, err
+	}
+
+	return reply, nil
+}
+
 func (b *backendGRPCPluginClient) SpecialPaths() *logical.Paths {
 	reply, err := b.client.SpecialPaths(b.doneCtx, &pb.Empty{})
 	if err != nil {
@@ -3,6 +3,7 @@ package plugin
 import (
 	"context"
 
+	"github.com/golang/protobuf/proto"
 	log "github.com/hashicorp/go-hclog"
 	plugin "github.com/hashicorp/go-plugin"
 	"github.com/hashicorp/vault/helper/pluginutil"
@@ -81,6 +82,35 @@ func (b *backendGRPCPluginServer) HandleRequest(ctx context.Context, args *pb.Ha
 	}, nil
 }
 
+// HandleRequestStream handles the request like HandleRequest, streaming the
+// serialized reply in chunks of at most handleRequestChunkSize bytes.
+func (b *backendGRPCPluginServer) HandleRequestStream(args *pb.HandleRequestArgs, stream pb.Backend_HandleRequestStreamServer) error {
+	reply, err := b.HandleRequest(stream.Context(), args)
+	if err != nil {
+		return err
+	}
+
+	buf, err := proto.Marshal(reply)
+	if err != nil {
+		return err
+	}
+
+	for len(buf) > 0 {
+		n := len(buf)
+		if n > handleRequestChunkSize {
+			n = handleRequestChunkSize
+		}
+		if err := stream.Send(&pb.HandleRequestChunk{
+			Data: buf[:n],
+		}); err != nil {
+			return err
+		}
+		buf = buf[n:]
+	}
+
+	return nil
+}
+
 func (b *backendGRPCPluginServer) SpecialPaths(ctx context.Context, args *pb.Empty) (*pb.SpecialPathsReply, error) {
 	paths := b.backend.SpecialPaths()
 	if paths == nil {
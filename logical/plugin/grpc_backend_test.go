@@ -3,6 +3,7 @@ package plugin
 import (
 	"context"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -37,6 +38,35 @@ func TestGRPCBackendPlugin_HandleRequest(t *testing.T) {
 	}
 }
 
+func TestGRPCBackendPlugin_HandleRequestLargeResponse(t *testing.T) {
+	b, cleanup := testGRPCBackend(t)
+	defer cleanup()
+
+	// The response is streamed in several chunks
+	value := strings.Repeat("a", 3*handleRequestChunkSize)
+	_, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.CreateOperation,
+		Path:      "kv/foo",
+		Data: map[string]interface{}{
+			"value": value,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "kv/foo",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Data["value"] != value {
+		t.Fatalf("bad value of length %d", len(resp.Data["value"].(string)))
+	}
+}
+
 func TestGRPCBackendPlugin_SpecialPaths(t *testing.T) {
 	b, cleanup := testGRPCBackend(t)
 	defer cleanup()
@@ -294,9 +294,9 @@ type Request struct {
 	// attached. Useful in some situations where the client token is not made
 	// accessible.
 	Unauthenticated bool `sentinel:"" protobuf:"varint,19,opt,name=unauthenticated,proto3" json:"unauthenticated,omitempty"`
-	// Connection will be non-nil only for credential providers to
-	// inspect the connection information and potentially use it for
-	// authentication/protection.
+	// Connection holds the connection information of the request, including
+	// the TLS client certificates presented, for backends to inspect and
+	// potentially use for authentication/protection.
 	Connection           *Connection `sentinel:"" protobuf:"bytes,20,opt,name=connection,proto3" json:"connection,omitempty"`
 	XXX_NoUnkeyedLiteral struct{}    `json:"-"`
 	XXX_unrecognized     []byte      `json:"-"`
@@ -2620,10 +2620,12 @@ func (m *PluginEnvReply) GetErr() string {
 
 type Connection struct {
 	// RemoteAddr is the network address that sent the request.
-	RemoteAddr           string   `sentinel:"" protobuf:"bytes,1,opt,name=remote_addr,json=remoteAddr,proto3" json:"remote_addr,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+	RemoteAddr string `sentinel:"" protobuf:"bytes,1,opt,name=remote_addr,json=remoteAddr,proto3" json:"remote_addr,omitempty"`
+	// ConnectionState is the TLS connection state if applicable.
+	ConnectionState      *ConnectionState `sentinel:"" protobuf:"bytes,2,opt,name=connection_state,json=connectionState,proto3" json:"connection_state,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}         `json:"-"`
+	XXX_unrecognized     []byte           `json:"-"`
+	XXX_sizecache        int32            `json:"-"`
 }
 
 func (m *Connection) Reset()         { *m = Connection{} }
@@ -2658,6 +2660,259 @@ func (m *Connection) GetRemoteAddr() string {
 	return ""
 }
 
+func (m *Connection) GetConnectionState() *ConnectionState {
+	if m != nil {
+		return m.ConnectionState
+	}
+	return nil
+}
+
+type ConnectionState struct {
+	Version                     uint32              `sentinel:"" protobuf:"varint,1,opt,name=version,proto3" json:"version,omitempty"`
+	HandshakeComplete           bool                `sentinel:"" protobuf:"varint,2,opt,name=handshake_complete,json=handshakeComplete,proto3" json:"handshake_complete,omitempty"`
+	DidResume                   bool                `sentinel:"" protobuf:"varint,3,opt,name=did_resume,json=didResume,proto3" json:"did_resume,omitempty"`
+	CipherSuite                 uint32              `sentinel:"" protobuf:"varint,4,opt,name=cipher_suite,json=cipherSuite,proto3" json:"cipher_suite,omitempty"`
+	NegotiatedProtocol          string              `sentinel:"" protobuf:"bytes,5,opt,name=negotiated_protocol,json=negotiatedProtocol,proto3" json:"negotiated_protocol,omitempty"`
+	NegotiatedProtocolIsMutual  bool                `sentinel:"" protobuf:"varint,6,opt,name=negotiated_protocol_is_mutual,json=negotiatedProtocolIsMutual,proto3" json:"negotiated_protocol_is_mutual,omitempty"`
+	ServerName                  string              `sentinel:"" protobuf:"bytes,7,opt,name=server_name,json=serverName,proto3" json:"server_name,omitempty"`
+	PeerCertificates            *CertificateChain   `sentinel:"" protobuf:"bytes,8,opt,name=peer_certificates,json=peerCertificates,proto3" json:"peer_certificates,omitempty"`
+	VerifiedChains              []*CertificateChain `sentinel:"" protobuf:"bytes,9,rep,name=verified_chains,json=verifiedChains,proto3" json:"verified_chains,omitempty"`
+	SignedCertificateTimestamps [][]byte            `sentinel:"" protobuf:"bytes,10,rep,name=signed_certificate_timestamps,json=signedCertificateTimestamps,proto3" json:"signed_certificate_timestamps,omitempty"`
+	OcspResponse                []byte              `sentinel:"" protobuf:"bytes,11,opt,name=ocsp_response,json=ocspResponse,proto3" json:"ocsp_response,omitempty"`
+	TlsUnique                   []byte              `sentinel:"" protobuf:"bytes,12,opt,name=tls_unique,json=tlsUnique,proto3" json:"tls_unique,omitempty"`
+	XXX_NoUnkeyedLiteral        struct{}            `json:"-"`
+	XXX_unrecognized            []byte              `json:"-"`
+	XXX_sizecache               int32               `json:"-"`
+}
+
+func (m *ConnectionState) Reset()         { *m = ConnectionState{} }
+func (m *ConnectionState) String() string { return proto.CompactTextString(m) }
+func (*ConnectionState) ProtoMessage()    {}
+func (*ConnectionState) Descriptor() ([]byte, []int) {
+	return fileDescriptor_25821d34acc7c5ef, []int{44}
+}
+
+func (m *ConnectionState) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ConnectionState.Unmarshal(m, b)
+}
+func (m *ConnectionState) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ConnectionState.Marshal(b, m, deterministic)
+}
+func (m *ConnectionState) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ConnectionState.Merge(m, src)
+}
+func (m *ConnectionState) XXX_Size() int {
+	return xxx_messageInfo_ConnectionState.Size(m)
+}
+func (m *ConnectionState) XXX_DiscardUnknown() {
+	xxx_messageInfo_ConnectionState.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ConnectionState proto.InternalMessageInfo
+
+func (m *ConnectionState) GetVersion() uint32 {
+	if m != nil {
+		return m.Version
+	}
+	return 0
+}
+
+func (m *ConnectionState) GetHandshakeComplete() bool {
+	if m != nil {
+		return m.HandshakeComplete
+	}
+	return false
+}
+
+func (m *ConnectionState) GetDidResume() bool {
+	if m != nil {
+		return m.DidResume
+	}
+	return false
+}
+
+func (m *ConnectionState) GetCipherSuite() uint32 {
+	if m != nil {
+		return m.CipherSuite
+	}
+	return 0
+}
+
+func (m *ConnectionState) GetNegotiatedProtocol() string {
+	if m != nil {
+		return m.NegotiatedProtocol
+	}
+	return ""
+}
+
+func (m *ConnectionState) GetNegotiatedProtocolIsMutual() bool {
+	if m != nil {
+		return m.NegotiatedProtocolIsMutual
+	}
+	return false
+}
+
+func (m *ConnectionState) GetServerName() string {
+	if m != nil {
+		return m.ServerName
+	}
+	return ""
+}
+
+func (m *ConnectionState) GetPeerCertificates() *CertificateChain {
+	if m != nil {
+		return m.PeerCertificates
+	}
+	return nil
+}
+
+func (m *ConnectionState) GetVerifiedChains() []*CertificateChain {
+	if m != nil {
+		return m.VerifiedChains
+	}
+	return nil
+}
+
+func (m *ConnectionState) GetSignedCertificateTimestamps() [][]byte {
+	if m != nil {
+		return m.SignedCertificateTimestamps
+	}
+	return nil
+}
+
+func (m *ConnectionState) GetOcspResponse() []byte {
+	if m != nil {
+		return m.OcspResponse
+	}
+	return nil
+}
+
+func (m *ConnectionState) GetTlsUnique() []byte {
+	if m != nil {
+		return m.TlsUnique
+	}
+	return nil
+}
+
+type Certificate struct {
+	Asn1Data             []byte   `sentinel:"" protobuf:"bytes,1,opt,name=asn1_data,json=asn1Data,proto3" json:"asn1_data,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Certificate) Reset()         { *m = Certificate{} }
+func (m *Certificate) String() string { return proto.CompactTextString(m) }
+func (*Certificate) ProtoMessage()    {}
+func (*Certificate) Descriptor() ([]byte, []int) {
+	return fileDescriptor_25821d34acc7c5ef, []int{45}
+}
+
+func (m *Certificate) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Certificate.Unmarshal(m, b)
+}
+func (m *Certificate) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Certificate.Marshal(b, m, deterministic)
+}
+func (m *Certificate) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Certificate.Merge(m, src)
+}
+func (m *Certificate) XXX_Size() int {
+	return xxx_messageInfo_Certificate.Size(m)
+}
+func (m *Certificate) XXX_DiscardUnknown() {
+	xxx_messageInfo_Certificate.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Certificate proto.InternalMessageInfo
+
+func (m *Certificate) GetAsn1Data() []byte {
+	if m != nil {
+		return m.Asn1Data
+	}
+	return nil
+}
+
+type CertificateChain struct {
+	Certificates         []*Certificate `sentinel:"" protobuf:"bytes,1,rep,name=certificates,proto3" json:"certificates,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}       `json:"-"`
+	XXX_unrecognized     []byte         `json:"-"`
+	XXX_sizecache        int32          `json:"-"`
+}
+
+func (m *CertificateChain) Reset()         { *m = CertificateChain{} }
+func (m *CertificateChain) String() string { return proto.CompactTextString(m) }
+func (*CertificateChain) ProtoMessage()    {}
+func (*CertificateChain) Descriptor() ([]byte, []int) {
+	return fileDescriptor_25821d34acc7c5ef, []int{46}
+}
+
+func (m *CertificateChain) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CertificateChain.Unmarshal(m, b)
+}
+func (m *CertificateChain) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CertificateChain.Marshal(b, m, deterministic)
+}
+func (m *CertificateChain) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CertificateChain.Merge(m, src)
+}
+func (m *CertificateChain) XXX_Size() int {
+	return xxx_messageInfo_CertificateChain.Size(m)
+}
+func (m *CertificateChain) XXX_DiscardUnknown() {
+	xxx_messageInfo_CertificateChain.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CertificateChain proto.InternalMessageInfo
+
+func (m *CertificateChain) GetCertificates() []*Certificate {
+	if m != nil {
+		return m.Certificates
+	}
+	return nil
+}
+
+// HandleRequestChunk is a chunk of the serialized HandleRequestReply
+// streamed by the HandleRequestStream rpc.
+type HandleRequestChunk struct {
+	Data                 []byte   `sentinel:"" protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *HandleRequestChunk) Reset()         { *m = HandleRequestChunk{} }
+func (m *HandleRequestChunk) String() string { return proto.CompactTextString(m) }
+func (*HandleRequestChunk) ProtoMessage()    {}
+func (*HandleRequestChunk) Descriptor() ([]byte, []int) {
+	return fileDescriptor_25821d34acc7c5ef, []int{47}
+}
+
+func (m *HandleRequestChunk) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_HandleRequestChunk.Unmarshal(m, b)
+}
+func (m *HandleRequestChunk) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_HandleRequestChunk.Marshal(b, m, deterministic)
+}
+func (m *HandleRequestChunk) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_HandleRequestChunk.Merge(m, src)
+}
+func (m *HandleRequestChunk) XXX_Size() int {
+	return xxx_messageInfo_HandleRequestChunk.Size(m)
+}
+func (m *HandleRequestChunk) XXX_DiscardUnknown() {
+	xxx_messageInfo_HandleRequestChunk.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_HandleRequestChunk proto.InternalMessageInfo
+
+func (m *HandleRequestChunk) GetData() []byte {
+	if m != nil {
+		return m.Data
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterType((*Empty)(nil), "pb.Empty")
 	proto.RegisterType((*Header)(nil), "pb.Header")
@@ -2708,168 +2963,194 @@ func init() {
 	proto.RegisterType((*EntityInfoReply)(nil), "pb.EntityInfoReply")
 	proto.RegisterType((*PluginEnvReply)(nil), "pb.PluginEnvReply")
 	proto.RegisterType((*Connection)(nil), "pb.Connection")
+	proto.RegisterType((*ConnectionState)(nil), "pb.ConnectionState")
+	proto.RegisterType((*Certificate)(nil), "pb.Certificate")
+	proto.RegisterType((*CertificateChain)(nil), "pb.CertificateChain")
+	proto.RegisterType((*HandleRequestChunk)(nil), "pb.HandleRequestChunk")
 }
 
 func init() { proto.RegisterFile("logical/plugin/pb/backend.proto", fileDescriptor_25821d34acc7c5ef) }
 
 var fileDescriptor_25821d34acc7c5ef = []byte{
-	// 2495 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x59, 0xdb, 0x72, 0x1b, 0xc7,
-	0xd1, 0x2e, 0x00, 0xc4, 0xa9, 0x71, 0x22, 0x46, 0x34, 0xff, 0x15, 0x2c, 0xff, 0x82, 0xd7, 0x91,
-	0x44, 0x2b, 0x12, 0x68, 0x51, 0x71, 0x2c, 0x27, 0x65, 0xa7, 0x68, 0x8a, 0x96, 0x19, 0x93, 0x36,
-	0x6b, 0x09, 0xc5, 0x39, 0x55, 0xc1, 0x83, 0xdd, 0x21, 0xb8, 0xc5, 0xc5, 0xee, 0x66, 0x76, 0x96,
-	0x22, 0xae, 0xf2, 0x16, 0x79, 0x8d, 0xdc, 0xa6, 0x72, 0x93, 0xbb, 0x94, 0x2b, 0xf7, 0x79, 0x8d,
-	0x3c, 0x43, 0x6a, 0x7a, 0x66, 0x4f, 0x00, 0x68, 0xc9, 0x55, 0xce, 0xdd, 0x4c, 0x77, 0xcf, 0xa9,
-	0xe7, 0xeb, 0xaf, 0x7b, 0x76, 0xe1, 0xae, 0x17, 0xcc, 0x5c, 0x9b, 0x7a, 0xbb, 0xa1, 0x17, 0xcf,
-	0x5c, 0x7f, 0x37, 0x9c, 0xee, 0x4e, 0xa9, 0x7d, 0xc9, 0x7c, 0x67, 0x14, 0xf2, 0x40, 0x04, 0xa4,
-	0x1c, 0x4e, 0x07, 0x77, 0x67, 0x41, 0x30, 0xf3, 0xd8, 0x2e, 0x4a, 0xa6, 0xf1, 0xf9, 0xae, 0x70,
-	0xe7, 0x2c, 0x12, 0x74, 0x1e, 0x2a, 0xa3, 0xc1, 0x76, 0x32, 0x8b, 0xeb, 0x30, 0x5f, 0xb8, 0x62,
-	0xa1, 0xe5, 0x5b, 0xc5, 0xd9, 0x95, 0xd4, 0xac, 0x43, 0xf5, 0x70, 0x1e, 0x8a, 0x85, 0x39, 0x84,
-	0xda, 0x17, 0x8c, 0x3a, 0x8c, 0x93, 0x6d, 0xa8, 0x5d, 0x60, 0xcb, 0x28, 0x0d, 0x2b, 0x3b, 0x4d,
-	0x4b, 0xf7, 0xcc, 0x3f, 0x00, 0x9c, 0xca, 0x31, 0x87, 0x9c, 0x07, 0x9c, 0xdc, 0x86, 0x06, 0xe3,
-	0x7c, 0x22, 0x16, 0x21, 0x33, 0x4a, 0xc3, 0xd2, 0x4e, 0xc7, 0xaa, 0x33, 0xce, 0xc7, 0x8b, 0x90,
-	0x91, 0xff, 0x03, 0xd9, 0x9c, 0xcc, 0xa3, 0x99, 0x51, 0x1e, 0x96, 0xe4, 0x0c, 0x8c, 0xf3, 0x93,
-	0x68, 0x96, 0x8c, 0xb1, 0x03, 0x87, 0x19, 0x95, 0x61, 0x69, 0xa7, 0x82, 0x63, 0x0e, 0x02, 0x87,
-	0x99, 0x7f, 0x29, 0x41, 0xf5, 0x94, 0x8a, 0x8b, 0x88, 0x10, 0xd8, 0xe0, 0x41, 0x20, 0xf4, 0xe2,
-	0xd8, 0x26, 0x3b, 0xd0, 0x8b, 0x7d, 0x1a, 0x8b, 0x0b, 0x79, 0x22, 0x9b, 0x0a, 0xe6, 0x18, 0x65,
-	0x54, 0x2f, 0x8b, 0xc9, 0x7b, 0xd0, 0xf1, 0x02, 0x9b, 0x7a, 0x93, 0x48, 0x04, 0x9c, 0xce, 0xe4,
-	0x3a, 0xd2, 0xae, 0x8d, 0xc2, 0x33, 0x25, 0x23, 0x0f, 0xa1, 0x1f, 0x31, 0xea, 0x4d, 0x5e, 0x71,
-	0x1a, 0xa6, 0x86, 0x1b, 0x6a, 0x42, 0xa9, 0xf8, 0x86, 0xd3, 0x50, 0xdb, 0x9a, 0xff, 0xa8, 0x41,
-	0xdd, 0x62, 0x7f, 0x8a, 0x59, 0x24, 0x48, 0x17, 0xca, 0xae, 0x83, 0xa7, 0x6d, 0x5a, 0x65, 0xd7,
-	0x21, 0x23, 0x20, 0x16, 0x0b, 0x3d, 0xb9, 0xb4, 0x1b, 0xf8, 0x07, 0x5e, 0x1c, 0x09, 0xc6, 0xf5,
-	0x99, 0xd7, 0x68, 0xc8, 0x1d, 0x68, 0x06, 0x21, 0xe3, 0x28, 0x43, 0x07, 0x34, 0xad, 0x4c, 0x20,
-	0x0f, 0x1e, 0x52, 0x71, 0x61, 0x6c, 0xa0, 0x02, 0xdb, 0x52, 0xe6, 0x50, 0x41, 0x8d, 0xaa, 0x92,
-	0xc9, 0x36, 0x31, 0xa1, 0x16, 0x31, 0x9b, 0x33, 0x61, 0xd4, 0x86, 0xa5, 0x9d, 0xd6, 0x1e, 0x8c,
-	0xc2, 0xe9, 0xe8, 0x0c, 0x25, 0x96, 0xd6, 0x90, 0x3b, 0xb0, 0x21, 0xfd, 0x62, 0xd4, 0xd1, 0xa2,
-	0x21, 0x2d, 0xf6, 0x63, 0x71, 0x61, 0xa1, 0x94, 0xec, 0x41, 0x5d, 0xdd, 0x69, 0x64, 0x34, 0x86,
-	0x95, 0x9d, 0xd6, 0x9e, 0x21, 0x0d, 0xf4, 0x29, 0x47, 0x0a, 0x06, 0xd1, 0xa1, 0x2f, 0xf8, 0xc2,
-	0x4a, 0x0c, 0xc9, 0xbb, 0xd0, 0xb6, 0x3d, 0x97, 0xf9, 0x62, 0x22, 0x82, 0x4b, 0xe6, 0x1b, 0x4d,
-	0xdc, 0x51, 0x4b, 0xc9, 0xc6, 0x52, 0x44, 0xf6, 0xe0, 0xad, 0xbc, 0xc9, 0x84, 0xda, 0x36, 0x8b,
-	0xa2, 0x80, 0x1b, 0x80, 0xb6, 0xb7, 0x72, 0xb6, 0xfb, 0x5a, 0x25, 0xa7, 0x75, 0xdc, 0x28, 0xf4,
-	0xe8, 0x62, 0xe2, 0xd3, 0x39, 0x33, 0x5a, 0x6a, 0x5a, 0x2d, 0xfb, 0x8a, 0xce, 0x19, 0xb9, 0x0b,
-	0xad, 0x79, 0x10, 0xfb, 0x62, 0x12, 0x06, 0xae, 0x2f, 0x8c, 0x36, 0x5a, 0x00, 0x8a, 0x4e, 0xa5,
-	0x84, 0xbc, 0x03, 0xaa, 0xa7, 0xc0, 0xd8, 0x51, 0x7e, 0x45, 0x09, 0xc2, 0xf1, 0x1e, 0x74, 0x95,
-	0x3a, 0xdd, 0x4f, 0x17, 0x4d, 0x3a, 0x28, 0x4d, 0x77, 0xf2, 0x01, 0x34, 0x11, 0x0f, 0xae, 0x7f,
-	0x1e, 0x18, 0x3d, 0xf4, 0xdb, 0xad, 0x9c, 0x5b, 0x24, 0x26, 0x8e, 0xfc, 0xf3, 0xc0, 0x6a, 0xbc,
-	0xd2, 0x2d, 0xf2, 0x09, 0xbc, 0x5d, 0x38, 0x2f, 0x67, 0x73, 0xea, 0xfa, 0xae, 0x3f, 0x9b, 0xc4,
-	0x11, 0x8b, 0x8c, 0x4d, 0x44, 0xb8, 0x91, 0x3b, 0xb5, 0x95, 0x18, 0xbc, 0x8c, 0x58, 0x44, 0xde,
-	0x86, 0xa6, 0x0a, 0xd0, 0x89, 0xeb, 0x18, 0x7d, 0xdc, 0x52, 0x43, 0x09, 0x8e, 0x1c, 0xf2, 0x00,
-	0x7a, 0x61, 0xe0, 0xb9, 0xf6, 0x62, 0x12, 0x5c, 0x31, 0xce, 0x5d, 0x87, 0x19, 0x64, 0x58, 0xda,
-	0x69, 0x58, 0x5d, 0x25, 0xfe, 0x5a, 0x4b, 0xd7, 0x85, 0xc6, 0x2d, 0x34, 0x5c, 0x09, 0x8d, 0x11,
-	0x80, 0x1d, 0xf8, 0x3e, 0xb3, 0x11, 0x7e, 0x5b, 0x78, 0xc2, 0xae, 0x3c, 0xe1, 0x41, 0x2a, 0xb5,
-	0x72, 0x16, 0x83, 0xcf, 0xa1, 0x9d, 0x87, 0x02, 0xd9, 0x84, 0xca, 0x25, 0x5b, 0x68, 0xf8, 0xcb,
-	0x26, 0x19, 0x42, 0xf5, 0x8a, 0x7a, 0x31, 0x43, 0xc8, 0x6b, 0x20, 0xaa, 0x21, 0x96, 0x52, 0xfc,
-	0xa2, 0xfc, 0xac, 0x64, 0xfe, 0xbd, 0x0a, 0x1b, 0x12, 0x7c, 0xe4, 0x43, 0xe8, 0x78, 0x8c, 0x46,
-	0x6c, 0x12, 0x84, 0x72, 0x81, 0x08, 0xa7, 0x6a, 0xed, 0x6d, 0xca, 0x61, 0xc7, 0x52, 0xf1, 0xb5,
-	0x92, 0x5b, 0x6d, 0x2f, 0xd7, 0x93, 0x21, 0xed, 0xfa, 0x82, 0x71, 0x9f, 0x7a, 0x13, 0x0c, 0x06,
-	0x15, 0x60, 0xed, 0x44, 0xf8, 0x5c, 0x06, 0xc5, 0x32, 0x8e, 0x2a, 0xab, 0x38, 0x1a, 0x40, 0x03,
-	0x7d, 0xe7, 0xb2, 0x48, 0x07, 0x7b, 0xda, 0x27, 0x7b, 0xd0, 0x98, 0x33, 0x41, 0x75, 0xac, 0xc9,
-	0x90, 0xd8, 0x4e, 0x62, 0x66, 0x74, 0xa2, 0x15, 0x2a, 0x20, 0x52, 0xbb, 0x95, 0x88, 0xa8, 0xad,
-	0x46, 0xc4, 0x00, 0x1a, 0x29, 0xe8, 0xea, 0xea, 0x86, 0x93, 0xbe, 0xa4, 0xd9, 0x90, 0x71, 0x37,
-	0x70, 0x8c, 0x06, 0x02, 0x45, 0xf7, 0x24, 0x49, 0xfa, 0xf1, 0x5c, 0x41, 0xa8, 0xa9, 0x48, 0xd2,
-	0x8f, 0xe7, 0xab, 0x88, 0x81, 0x25, 0xc4, 0xfc, 0x04, 0xaa, 0xd4, 0x73, 0x69, 0x84, 0x21, 0x24,
-	0x6f, 0x56, 0xf3, 0xfd, 0x68, 0x5f, 0x4a, 0x2d, 0xa5, 0x24, 0x4f, 0xa1, 0x33, 0xe3, 0x41, 0x1c,
-	0x4e, 0xb0, 0xcb, 0x22, 0xa3, 0x8d, 0xa7, 0x5d, 0xb6, 0x6e, 0xa3, 0xd1, 0xbe, 0xb2, 0x91, 0x11,
-	0x38, 0x0d, 0x62, 0xdf, 0x99, 0xd8, 0xae, 0xc3, 0x23, 0xa3, 0x83, 0xce, 0x03, 0x14, 0x1d, 0x48,
-	0x89, 0x0c, 0x31, 0x15, 0x02, 0xa9, 0x83, 0xbb, 0x68, 0xd3, 0x41, 0xe9, 0x69, 0xe2, 0xe5, 0x9f,
-	0x42, 0x3f, 0x49, 0x4a, 0x99, 0x65, 0x0f, 0x2d, 0x37, 0x13, 0x45, 0x6a, 0xbc, 0x03, 0x9b, 0xec,
-	0x5a, 0x52, 0xa8, 0x2b, 0x26, 0x73, 0x7a, 0x3d, 0x11, 0xc2, 0xd3, 0x21, 0xd5, 0x4d, 0xe4, 0x27,
-	0xf4, 0x7a, 0x2c, 0x3c, 0x19, 0xff, 0x6a, 0x75, 0x8c, 0xff, 0x3e, 0x26, 0xa3, 0x26, 0x4a, 0x64,
-	0xfc, 0x0f, 0x7e, 0x09, 0x9d, 0xc2, 0x15, 0xae, 0x01, 0xf2, 0x56, 0x1e, 0xc8, 0xcd, 0x3c, 0x78,
-	0xff, 0xb5, 0x01, 0x80, 0x77, 0xa9, 0x86, 0x2e, 0x67, 0x80, 0xfc, 0x05, 0x97, 0xd7, 0x5c, 0x30,
-	0xe5, 0xcc, 0x17, 0x1a, 0x8c, 0xba, 0xf7, 0xbd, 0x38, 0x4c, 0x72, 0x40, 0x35, 0x97, 0x03, 0x1e,
-	0xc1, 0x86, 0xc4, 0x9c, 0x51, 0xcb, 0xa8, 0x3a, 0xdb, 0x11, 0xa2, 0x53, 0x21, 0x13, 0xad, 0x56,
-	0x02, 0xa1, 0xbe, 0x1a, 0x08, 0x79, 0x84, 0x35, 0x8a, 0x08, 0x7b, 0x0f, 0x3a, 0x36, 0x67, 0x98,
-	0x8f, 0x26, 0xb2, 0xb0, 0xd0, 0x08, 0x6c, 0x27, 0xc2, 0xb1, 0x3b, 0x67, 0xd2, 0x7f, 0xf2, 0x32,
-	0x00, 0x55, 0xb2, 0xb9, 0xf6, 0xae, 0x5a, 0x6b, 0xef, 0x0a, 0xb3, 0xbb, 0xc7, 0x34, 0x8b, 0x63,
-	0x3b, 0x17, 0x09, 0x9d, 0x42, 0x24, 0x14, 0xe0, 0xde, 0x5d, 0x82, 0xfb, 0x12, 0x26, 0x7b, 0x2b,
-	0x98, 0x7c, 0x17, 0xda, 0xd2, 0x01, 0x51, 0x48, 0x6d, 0x26, 0x27, 0xd8, 0x54, 0x8e, 0x48, 0x65,
-	0x47, 0x0e, 0x46, 0x70, 0x3c, 0x9d, 0x2e, 0x2e, 0x02, 0x8f, 0x65, 0x24, 0xdc, 0x4a, 0x65, 0x47,
-	0x8e, 0xdc, 0x2f, 0xa2, 0x8a, 0x20, 0xaa, 0xb0, 0x3d, 0xf8, 0x08, 0x9a, 0xa9, 0xd7, 0x7f, 0x10,
-	0x98, 0xfe, 0x5a, 0x82, 0x76, 0x9e, 0xe8, 0xe4, 0xe0, 0xf1, 0xf8, 0x18, 0x07, 0x57, 0x2c, 0xd9,
-	0x94, 0x25, 0x02, 0x67, 0x3e, 0x7b, 0x45, 0xa7, 0x9e, 0x9a, 0xa0, 0x61, 0x65, 0x02, 0xa9, 0x75,
-	0x7d, 0x9b, 0xb3, 0x79, 0x82, 0xaa, 0x8a, 0x95, 0x09, 0xc8, 0xc7, 0x00, 0x6e, 0x14, 0xc5, 0x4c,
-	0xdd, 0xdc, 0x06, 0xd2, 0xc0, 0x60, 0xa4, 0xea, 0xc5, 0x51, 0x52, 0x2f, 0x8e, 0xc6, 0x49, 0xbd,
-	0x68, 0x35, 0xd1, 0x1a, 0xaf, 0x74, 0x1b, 0x6a, 0xf2, 0x82, 0xc6, 0xc7, 0x88, 0xbc, 0x8a, 0xa5,
-	0x7b, 0xe6, 0x9f, 0xa1, 0xa6, 0x2a, 0x8b, 0xff, 0x29, 0x79, 0xdf, 0x86, 0x86, 0x9a, 0xdb, 0x75,
-	0x74, 0xac, 0xd4, 0xb1, 0x7f, 0xe4, 0x98, 0xdf, 0x95, 0xa1, 0x61, 0xb1, 0x28, 0x0c, 0xfc, 0x88,
-	0xe5, 0x2a, 0x9f, 0xd2, 0x6b, 0x2b, 0x9f, 0xf2, 0xda, 0xca, 0x27, 0xa9, 0xa7, 0x2a, 0xb9, 0x7a,
-	0x6a, 0x00, 0x0d, 0xce, 0x1c, 0x97, 0x33, 0x5b, 0xe8, 0xda, 0x2b, 0xed, 0x4b, 0xdd, 0x2b, 0xca,
-	0x65, 0xca, 0x8e, 0x30, 0x2f, 0x34, 0xad, 0xb4, 0x4f, 0x9e, 0xe4, 0x0b, 0x06, 0x55, 0x8a, 0x6d,
-	0xa9, 0x82, 0x41, 0x6d, 0x77, 0x4d, 0xc5, 0xf0, 0x34, 0x2b, 0xbc, 0xea, 0x18, 0xcd, 0xb7, 0xf3,
-	0x03, 0xd6, 0x57, 0x5e, 0x3f, 0x5a, 0x1e, 0xfe, 0xae, 0x0c, 0x9b, 0xcb, 0x7b, 0x5b, 0x83, 0xc0,
-	0x2d, 0xa8, 0xaa, 0x7c, 0xa6, 0xe1, 0x2b, 0x56, 0x32, 0x59, 0x65, 0x89, 0xe8, 0x7e, 0xb5, 0x4c,
-	0x1a, 0xaf, 0x87, 0x5e, 0x91, 0x50, 0xde, 0x87, 0x4d, 0xe9, 0xa2, 0x90, 0x39, 0x59, 0x8d, 0xa6,
-	0x18, 0xb0, 0xa7, 0xe5, 0x69, 0x95, 0xf6, 0x10, 0xfa, 0x89, 0x69, 0xc6, 0x0d, 0xb5, 0x82, 0xed,
-	0x61, 0x42, 0x11, 0xdb, 0x50, 0x3b, 0x0f, 0xf8, 0x9c, 0x0a, 0x4d, 0x82, 0xba, 0x57, 0x20, 0x39,
-	0x64, 0xdb, 0x86, 0xc2, 0x64, 0x22, 0x94, 0xef, 0x10, 0x49, 0x3e, 0xe9, 0x1b, 0x01, 0x59, 0xb0,
-	0x61, 0x35, 0x92, 0xb7, 0x81, 0xf9, 0x5b, 0xe8, 0x2d, 0x95, 0x85, 0x6b, 0x1c, 0x99, 0x2d, 0x5f,
-	0x2e, 0x2c, 0x5f, 0x98, 0xb9, 0xb2, 0x34, 0xf3, 0xef, 0xa0, 0xff, 0x05, 0xf5, 0x1d, 0x8f, 0xe9,
-	0xf9, 0xf7, 0xf9, 0x2c, 0x92, 0x09, 0x4e, 0xbf, 0x52, 0x26, 0x3a, 0xfb, 0x74, 0xac, 0xa6, 0x96,
-	0x1c, 0x39, 0xe4, 0x1e, 0xd4, 0xb9, 0xb2, 0xd6, 0x00, 0x68, 0xe5, 0xea, 0x56, 0x2b, 0xd1, 0x99,
-	0xdf, 0x02, 0x29, 0x4c, 0x2d, 0x1f, 0x28, 0x0b, 0xb2, 0x23, 0xd1, 0xaf, 0x40, 0xa1, 0xa3, 0xaa,
-	0x9d, 0xc7, 0xa4, 0x95, 0x6a, 0xc9, 0x10, 0x2a, 0x8c, 0x73, 0xbd, 0x04, 0x16, 0x8e, 0xd9, 0x73,
-	0xd0, 0x92, 0x2a, 0xf3, 0x67, 0xd0, 0x3f, 0x0b, 0x99, 0xed, 0x52, 0x0f, 0x9f, 0x72, 0x6a, 0x81,
-	0xbb, 0x50, 0x95, 0x4e, 0x4e, 0x08, 0xa3, 0x89, 0x03, 0x51, 0xad, 0xe4, 0xe6, 0xb7, 0x60, 0xa8,
-	0x7d, 0x1d, 0x5e, 0xbb, 0x91, 0x60, 0xbe, 0xcd, 0x0e, 0x2e, 0x98, 0x7d, 0xf9, 0x23, 0x9e, 0xfc,
-	0x0a, 0x6e, 0xaf, 0x5b, 0x21, 0xd9, 0x5f, 0xcb, 0x96, 0xbd, 0xc9, 0xb9, 0xcc, 0x1d, 0xb8, 0x46,
-	0xc3, 0x02, 0x14, 0x7d, 0x2e, 0x25, 0xf2, 0x1e, 0x99, 0x1c, 0x17, 0x69, 0x3e, 0xd6, 0xbd, 0xc4,
-	0x1f, 0x95, 0x9b, 0xfd, 0xf1, 0xb7, 0x12, 0x34, 0xcf, 0x98, 0x88, 0x43, 0x3c, 0xcb, 0xdb, 0xd0,
-	0x9c, 0xf2, 0xe0, 0x92, 0xf1, 0xec, 0x28, 0x0d, 0x25, 0x38, 0x72, 0xc8, 0x13, 0xa8, 0x1d, 0x04,
-	0xfe, 0xb9, 0x3b, 0xc3, 0x87, 0xad, 0x26, 0x86, 0x74, 0xec, 0x48, 0xe9, 0x14, 0x31, 0x68, 0x43,
-	0x32, 0x84, 0x96, 0xfe, 0x3c, 0xf0, 0xf2, 0xe5, 0xd1, 0xf3, 0xa4, 0xe2, 0xcd, 0x89, 0x06, 0x1f,
-	0x43, 0x2b, 0x37, 0xf0, 0x07, 0xa5, 0xaa, 0xff, 0x07, 0xc0, 0xd5, 0x95, 0x8f, 0x36, 0xd5, 0x51,
-	0xf5, 0x48, 0x79, 0xb4, 0xbb, 0xd0, 0x94, 0xc5, 0x95, 0x52, 0x27, 0x49, 0xb2, 0x94, 0x25, 0x49,
-	0xf3, 0x1e, 0xf4, 0x8f, 0xfc, 0x2b, 0xea, 0xb9, 0x0e, 0x15, 0xec, 0x4b, 0xb6, 0x40, 0x17, 0xac,
-	0xec, 0xc0, 0x3c, 0x83, 0xb6, 0x7e, 0x69, 0xbf, 0xd1, 0x1e, 0xdb, 0x7a, 0x8f, 0xdf, 0x1f, 0x44,
-	0xef, 0x43, 0x4f, 0x4f, 0x7a, 0xec, 0xea, 0x10, 0x92, 0x35, 0x06, 0x67, 0xe7, 0xee, 0xb5, 0x9e,
-	0x5a, 0xf7, 0xcc, 0x67, 0xb0, 0x99, 0x33, 0x4d, 0x8f, 0x73, 0xc9, 0x16, 0x51, 0xf2, 0x05, 0x42,
-	0xb6, 0x13, 0x0f, 0x94, 0x33, 0x0f, 0x98, 0xd0, 0xd5, 0x23, 0x5f, 0x30, 0x71, 0xc3, 0xe9, 0xbe,
-	0x4c, 0x37, 0xf2, 0x82, 0xe9, 0xc9, 0xef, 0x43, 0x95, 0xc9, 0x93, 0xe6, 0xf3, 0x67, 0xde, 0x03,
-	0x96, 0x52, 0xaf, 0x59, 0xf0, 0x59, 0xba, 0xe0, 0x69, 0xac, 0x16, 0x7c, 0xc3, 0xb9, 0xcc, 0xf7,
-	0xd2, 0x6d, 0x9c, 0xc6, 0xe2, 0xa6, 0x1b, 0xbd, 0x07, 0x7d, 0x6d, 0xf4, 0x9c, 0x79, 0x4c, 0xb0,
-	0x1b, 0x8e, 0x74, 0x1f, 0x48, 0xc1, 0xec, 0xa6, 0xe9, 0xee, 0x40, 0x63, 0x3c, 0x3e, 0x4e, 0xb5,
-	0x45, 0x6e, 0x34, 0x3f, 0x81, 0xfe, 0x59, 0xec, 0x04, 0xa7, 0xdc, 0xbd, 0x72, 0x3d, 0x36, 0x53,
-	0x8b, 0x25, 0xc5, 0x6f, 0x29, 0x57, 0xfc, 0xae, 0xcd, 0x46, 0xe6, 0x0e, 0x90, 0xc2, 0xf0, 0xf4,
-	0xde, 0xa2, 0xd8, 0x09, 0x74, 0x08, 0x63, 0xdb, 0xdc, 0x81, 0xf6, 0x98, 0xca, 0x62, 0xc3, 0x51,
-	0x36, 0x06, 0xd4, 0x85, 0xea, 0x6b, 0xb3, 0xa4, 0x6b, 0xee, 0xc1, 0xd6, 0x01, 0xb5, 0x2f, 0x5c,
-	0x7f, 0xf6, 0xdc, 0x8d, 0x64, 0xb5, 0xa5, 0x47, 0x0c, 0xa0, 0xe1, 0x68, 0x81, 0x1e, 0x92, 0xf6,
-	0xcd, 0xc7, 0xf0, 0x56, 0xee, 0x33, 0xcf, 0x99, 0xa0, 0x89, 0x3f, 0xb6, 0xa0, 0x1a, 0xc9, 0x1e,
-	0x8e, 0xa8, 0x5a, 0xaa, 0x63, 0x7e, 0x05, 0x5b, 0xf9, 0x04, 0x2c, 0x6b, 0x9f, 0xe4, 0xe0, 0x58,
-	0x95, 0x94, 0x72, 0x55, 0x89, 0xf6, 0x59, 0x39, 0xcb, 0x27, 0x9b, 0x50, 0xf9, 0xf5, 0x37, 0x63,
-	0x0d, 0x76, 0xd9, 0x34, 0xff, 0x28, 0x97, 0x2f, 0xce, 0xa7, 0x96, 0x2f, 0x94, 0x26, 0xa5, 0x37,
-	0x2a, 0x4d, 0x56, 0xf1, 0xf6, 0x18, 0xfa, 0x27, 0x5e, 0x60, 0x5f, 0x1e, 0xfa, 0x39, 0x6f, 0x18,
-	0x50, 0x67, 0x7e, 0xde, 0x19, 0x49, 0xd7, 0x7c, 0x00, 0xbd, 0xe3, 0xc0, 0xa6, 0xde, 0x49, 0x10,
-	0xfb, 0x22, 0xf5, 0x02, 0x7e, 0x77, 0xd3, 0xa6, 0xaa, 0x63, 0x3e, 0x86, 0xae, 0x4e, 0xd1, 0xfe,
-	0x79, 0x90, 0x30, 0x63, 0x96, 0xcc, 0x4b, 0xc5, 0x42, 0xdf, 0x3c, 0x86, 0x5e, 0x66, 0xae, 0xe6,
-	0x7d, 0x00, 0x35, 0xa5, 0xd6, 0x67, 0xeb, 0xa5, 0xaf, 0x57, 0x65, 0x69, 0x69, 0xf5, 0x9a, 0x43,
-	0xcd, 0xa1, 0x7b, 0x8a, 0xdf, 0x3f, 0x0f, 0xfd, 0x2b, 0x35, 0xd9, 0x11, 0x10, 0xf5, 0x45, 0x74,
-	0xc2, 0xfc, 0x2b, 0x97, 0x07, 0x3e, 0x16, 0xd7, 0x25, 0x5d, 0xc2, 0x24, 0x13, 0xa7, 0x83, 0x12,
-	0x0b, 0xab, 0x1f, 0x2e, 0x8b, 0xd6, 0xfa, 0x10, 0xb2, 0xaf, 0x2b, 0x32, 0xd5, 0x70, 0x36, 0x0f,
-	0x04, 0x9b, 0x50, 0xc7, 0x49, 0xa2, 0x05, 0x94, 0x68, 0xdf, 0x71, 0xf8, 0xde, 0x7f, 0xca, 0x50,
-	0xff, 0x4c, 0x11, 0x38, 0xf9, 0x14, 0x3a, 0x85, 0x74, 0x4d, 0xde, 0xc2, 0xb2, 0x6e, 0xb9, 0x38,
-	0x18, 0x6c, 0xaf, 0x88, 0xd5, 0xb9, 0x3e, 0x80, 0x76, 0x3e, 0x19, 0x13, 0x4c, 0xbc, 0xf8, 0xad,
-	0x77, 0x80, 0x33, 0xad, 0x66, 0xea, 0x33, 0xd8, 0x5a, 0x97, 0x26, 0xc9, 0x9d, 0x6c, 0x85, 0xd5,
-	0x14, 0x3d, 0x78, 0xe7, 0x26, 0x6d, 0x92, 0x5e, 0xeb, 0x07, 0x1e, 0xa3, 0x7e, 0x1c, 0xe6, 0x77,
-	0x90, 0x35, 0xc9, 0x13, 0xe8, 0x14, 0x12, 0x85, 0x3a, 0xe7, 0x4a, 0xee, 0xc8, 0x0f, 0xb9, 0x0f,
-	0x55, 0x4c, 0x4e, 0xa4, 0x53, 0xc8, 0x92, 0x83, 0x6e, 0xda, 0x55, 0x6b, 0x0f, 0x61, 0x03, 0xbf,
-	0x00, 0xe6, 0x16, 0xc6, 0x11, 0x69, 0xe6, 0xda, 0xfb, 0x77, 0x09, 0xea, 0xc9, 0x57, 0xe1, 0x27,
-	0xb0, 0x21, 0x73, 0x00, 0xb9, 0x95, 0xa3, 0xd1, 0x24, 0x7f, 0x0c, 0xb6, 0x96, 0x84, 0x6a, 0x81,
-	0x11, 0x54, 0x5e, 0x30, 0x41, 0x48, 0x4e, 0xa9, 0x93, 0xc1, 0xe0, 0x56, 0x51, 0x96, 0xda, 0x9f,
-	0xc6, 0x45, 0x7b, 0xcd, 0xe5, 0x05, 0xfb, 0x94, 0xa5, 0x3f, 0x82, 0x9a, 0x62, 0x59, 0xe5, 0x94,
-	0x15, 0x7e, 0x56, 0x97, 0xbf, 0xca, 0xc7, 0x7b, 0xff, 0xdc, 0x00, 0x38, 0x5b, 0x44, 0x82, 0xcd,
-	0x7f, 0xe3, 0xb2, 0x57, 0xe4, 0x21, 0xf4, 0x9e, 0xb3, 0x73, 0x1a, 0x7b, 0x02, 0x9f, 0x6a, 0x92,
-	0x4d, 0x72, 0x3e, 0xc1, 0x82, 0x2f, 0x25, 0xeb, 0xfb, 0xd0, 0x3a, 0xa1, 0xd7, 0xaf, 0xb7, 0xfb,
-	0x14, 0x3a, 0x05, 0x0e, 0xd6, 0x5b, 0x5c, 0x66, 0x75, 0xbd, 0xc5, 0x55, 0xb6, 0xbe, 0x0f, 0x75,
-	0xcd, 0xcc, 0xf9, 0x35, 0x30, 0x87, 0x15, 0x18, 0xfb, 0xe7, 0xd0, 0x5b, 0xe2, 0xe5, 0xbc, 0x3d,
-	0x7e, 0x0e, 0x59, 0xcb, 0xdb, 0xcf, 0xe4, 0x6b, 0xa7, 0xc8, 0xcd, 0xf9, 0x81, 0xfa, 0xe5, 0xb5,
-	0x8e, 0xbc, 0x5f, 0x14, 0xdf, 0x49, 0xf8, 0x44, 0x35, 0x96, 0xe9, 0x33, 0x21, 0xef, 0xc1, 0xed,
-	0x75, 0x9a, 0x34, 0x04, 0xf3, 0x0c, 0xba, 0x12, 0x82, 0xab, 0xf4, 0xfa, 0x08, 0x20, 0x23, 0xd1,
-	0xbc, 0x3d, 0xc2, 0x63, 0x99, 0x5f, 0x3f, 0x04, 0xc8, 0xa8, 0x51, 0xa1, 0xaa, 0xc8, 0xac, 0x6a,
-	0xd8, 0x32, 0x7d, 0x3e, 0x84, 0x66, 0x4a, 0x67, 0xf9, 0x35, 0x70, 0x82, 0x22, 0x3b, 0x7e, 0x36,
-	0xfa, 0xfd, 0xa3, 0x99, 0x2b, 0x2e, 0xe2, 0xe9, 0xc8, 0x0e, 0xe6, 0xbb, 0x17, 0x34, 0xba, 0x70,
-	0xed, 0x80, 0x87, 0xbb, 0x57, 0x12, 0x4c, 0xbb, 0x2b, 0x3f, 0xac, 0xa6, 0x35, 0x7c, 0xec, 0x3d,
-	0xfd, 0x6f, 0x00, 0x00, 0x00, 0xff, 0xff, 0x84, 0x79, 0x37, 0x3d, 0xcc, 0x1a, 0x00, 0x00,
+	// 2838 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x59, 0xdd, 0x73, 0xdb, 0xc6,
+	0xb5, 0x1f, 0x92, 0x12, 0x3f, 0x0e, 0x49, 0x91, 0x5c, 0xc9, 0xba, 0x30, 0x6d, 0x5f, 0x33, 0xc8,
+	0xb5, 0xa3, 0xf8, 0xc6, 0x54, 0x2c, 0xdf, 0xdc, 0x38, 0xed, 0x24, 0x1d, 0x45, 0x56, 0x1c, 0x35,
+	0x52, 0xa2, 0x81, 0xe4, 0xa6, 0x5f, 0x33, 0x08, 0x04, 0xac, 0xc8, 0x1d, 0x81, 0x00, 0xb2, 0x0b,
+	0xc8, 0xe6, 0x53, 0xff, 0x8b, 0xfe, 0x1b, 0x7d, 0xeb, 0x74, 0xda, 0x87, 0xbe, 0x75, 0x32, 0x7d,
+	0xef, 0xff, 0xd3, 0xd9, 0xb3, 0x8b, 0x2f, 0x92, 0x4a, 0x9c, 0x99, 0xf4, 0x0d, 0xfb, 0x3b, 0x67,
+	0xbf, 0xce, 0x9e, 0x8f, 0xdf, 0x2e, 0xe0, 0xbe, 0x1f, 0x4e, 0x98, 0xeb, 0xf8, 0xbb, 0x91, 0x9f,
+	0x4c, 0x58, 0xb0, 0x1b, 0x5d, 0xec, 0x5e, 0x38, 0xee, 0x15, 0x0d, 0xbc, 0x71, 0xc4, 0xc3, 0x38,
+	0x24, 0xd5, 0xe8, 0x62, 0x78, 0x7f, 0x12, 0x86, 0x13, 0x9f, 0xee, 0x22, 0x72, 0x91, 0x5c, 0xee,
+	0xc6, 0x6c, 0x46, 0x45, 0xec, 0xcc, 0x22, 0xa5, 0x34, 0xdc, 0x4e, 0x47, 0x61, 0x1e, 0x0d, 0x62,
+	0x16, 0xcf, 0x35, 0xbe, 0x55, 0x1e, 0x5d, 0xa1, 0x66, 0x03, 0xd6, 0x0f, 0x67, 0x51, 0x3c, 0x37,
+	0x47, 0x50, 0xff, 0x9c, 0x3a, 0x1e, 0xe5, 0x64, 0x1b, 0xea, 0x53, 0xfc, 0x32, 0x2a, 0xa3, 0xda,
+	0x4e, 0xcb, 0xd2, 0x2d, 0xf3, 0x77, 0x00, 0xa7, 0xb2, 0xcf, 0x21, 0xe7, 0x21, 0x27, 0xb7, 0xa1,
+	0x49, 0x39, 0xb7, 0xe3, 0x79, 0x44, 0x8d, 0xca, 0xa8, 0xb2, 0xd3, 0xb5, 0x1a, 0x94, 0xf3, 0xf3,
+	0x79, 0x44, 0xc9, 0x7f, 0x81, 0xfc, 0xb4, 0x67, 0x62, 0x62, 0x54, 0x47, 0x15, 0x39, 0x02, 0xe5,
+	0xfc, 0x44, 0x4c, 0xd2, 0x3e, 0x6e, 0xe8, 0x51, 0xa3, 0x36, 0xaa, 0xec, 0xd4, 0xb0, 0xcf, 0x41,
+	0xe8, 0x51, 0xf3, 0x8f, 0x15, 0x58, 0x3f, 0x75, 0xe2, 0xa9, 0x20, 0x04, 0xd6, 0x78, 0x18, 0xc6,
+	0x7a, 0x72, 0xfc, 0x26, 0x3b, 0xd0, 0x4b, 0x02, 0x27, 0x89, 0xa7, 0x72, 0x47, 0xae, 0x13, 0x53,
+	0xcf, 0xa8, 0xa2, 0x78, 0x11, 0x26, 0x6f, 0x43, 0xd7, 0x0f, 0x5d, 0xc7, 0xb7, 0x45, 0x1c, 0x72,
+	0x67, 0x22, 0xe7, 0x91, 0x7a, 0x1d, 0x04, 0xcf, 0x14, 0x46, 0x1e, 0xc1, 0x40, 0x50, 0xc7, 0xb7,
+	0x5f, 0x71, 0x27, 0xca, 0x14, 0xd7, 0xd4, 0x80, 0x52, 0xf0, 0x35, 0x77, 0x22, 0xad, 0x6b, 0xfe,
+	0xbd, 0x0e, 0x0d, 0x8b, 0x7e, 0x9b, 0x50, 0x11, 0x93, 0x0d, 0xa8, 0x32, 0x0f, 0x77, 0xdb, 0xb2,
+	0xaa, 0xcc, 0x23, 0x63, 0x20, 0x16, 0x8d, 0x7c, 0x39, 0x35, 0x0b, 0x83, 0x03, 0x3f, 0x11, 0x31,
+	0xe5, 0x7a, 0xcf, 0x2b, 0x24, 0xe4, 0x2e, 0xb4, 0xc2, 0x88, 0x72, 0xc4, 0xd0, 0x00, 0x2d, 0x2b,
+	0x07, 0xe4, 0xc6, 0x23, 0x27, 0x9e, 0x1a, 0x6b, 0x28, 0xc0, 0x6f, 0x89, 0x79, 0x4e, 0xec, 0x18,
+	0xeb, 0x0a, 0x93, 0xdf, 0xc4, 0x84, 0xba, 0xa0, 0x2e, 0xa7, 0xb1, 0x51, 0x1f, 0x55, 0x76, 0xda,
+	0x7b, 0x30, 0x8e, 0x2e, 0xc6, 0x67, 0x88, 0x58, 0x5a, 0x42, 0xee, 0xc2, 0x9a, 0xb4, 0x8b, 0xd1,
+	0x40, 0x8d, 0xa6, 0xd4, 0xd8, 0x4f, 0xe2, 0xa9, 0x85, 0x28, 0xd9, 0x83, 0x86, 0x3a, 0x53, 0x61,
+	0x34, 0x47, 0xb5, 0x9d, 0xf6, 0x9e, 0x21, 0x15, 0xf4, 0x2e, 0xc7, 0xca, 0x0d, 0xc4, 0x61, 0x10,
+	0xf3, 0xb9, 0x95, 0x2a, 0x92, 0xb7, 0xa0, 0xe3, 0xfa, 0x8c, 0x06, 0xb1, 0x1d, 0x87, 0x57, 0x34,
+	0x30, 0x5a, 0xb8, 0xa2, 0xb6, 0xc2, 0xce, 0x25, 0x44, 0xf6, 0xe0, 0x56, 0x51, 0xc5, 0x76, 0x5c,
+	0x97, 0x0a, 0x11, 0x72, 0x03, 0x50, 0x77, 0xb3, 0xa0, 0xbb, 0xaf, 0x45, 0x72, 0x58, 0x8f, 0x89,
+	0xc8, 0x77, 0xe6, 0x76, 0xe0, 0xcc, 0xa8, 0xd1, 0x56, 0xc3, 0x6a, 0xec, 0x4b, 0x67, 0x46, 0xc9,
+	0x7d, 0x68, 0xcf, 0xc2, 0x24, 0x88, 0xed, 0x28, 0x64, 0x41, 0x6c, 0x74, 0x50, 0x03, 0x10, 0x3a,
+	0x95, 0x08, 0xb9, 0x07, 0xaa, 0xa5, 0x9c, 0xb1, 0xab, 0xec, 0x8a, 0x08, 0xba, 0xe3, 0x03, 0xd8,
+	0x50, 0xe2, 0x6c, 0x3d, 0x1b, 0xa8, 0xd2, 0x45, 0x34, 0x5b, 0xc9, 0xfb, 0xd0, 0x42, 0x7f, 0x60,
+	0xc1, 0x65, 0x68, 0xf4, 0xd0, 0x6e, 0x9b, 0x05, 0xb3, 0x48, 0x9f, 0x38, 0x0a, 0x2e, 0x43, 0xab,
+	0xf9, 0x4a, 0x7f, 0x91, 0x8f, 0xe1, 0x4e, 0x69, 0xbf, 0x9c, 0xce, 0x1c, 0x16, 0xb0, 0x60, 0x62,
+	0x27, 0x82, 0x0a, 0xa3, 0x8f, 0x1e, 0x6e, 0x14, 0x76, 0x6d, 0xa5, 0x0a, 0x2f, 0x05, 0x15, 0xe4,
+	0x0e, 0xb4, 0x54, 0x80, 0xda, 0xcc, 0x33, 0x06, 0xb8, 0xa4, 0xa6, 0x02, 0x8e, 0x3c, 0xf2, 0x0e,
+	0xf4, 0xa2, 0xd0, 0x67, 0xee, 0xdc, 0x0e, 0xaf, 0x29, 0xe7, 0xcc, 0xa3, 0x06, 0x19, 0x55, 0x76,
+	0x9a, 0xd6, 0x86, 0x82, 0xbf, 0xd2, 0xe8, 0xaa, 0xd0, 0xd8, 0x44, 0xc5, 0x45, 0x98, 0x8c, 0x01,
+	0xdc, 0x30, 0x08, 0xa8, 0x8b, 0xee, 0xb7, 0x85, 0x3b, 0xdc, 0x90, 0x3b, 0x3c, 0xc8, 0x50, 0xab,
+	0xa0, 0x31, 0xfc, 0x0c, 0x3a, 0x45, 0x57, 0x20, 0x7d, 0xa8, 0x5d, 0xd1, 0xb9, 0x76, 0x7f, 0xf9,
+	0x49, 0x46, 0xb0, 0x7e, 0xed, 0xf8, 0x09, 0x35, 0xaa, 0xb9, 0x23, 0xaa, 0x2e, 0x96, 0x12, 0xfc,
+	0xac, 0xfa, 0xac, 0x62, 0xfe, 0x75, 0x1d, 0xd6, 0xa4, 0xf3, 0x91, 0x0f, 0xa0, 0xeb, 0x53, 0x47,
+	0x50, 0x3b, 0x8c, 0xe4, 0x04, 0x02, 0x87, 0x6a, 0xef, 0xf5, 0x65, 0xb7, 0x63, 0x29, 0xf8, 0x4a,
+	0xe1, 0x56, 0xc7, 0x2f, 0xb4, 0x64, 0x48, 0xb3, 0x20, 0xa6, 0x3c, 0x70, 0x7c, 0x1b, 0x83, 0x41,
+	0x05, 0x58, 0x27, 0x05, 0x9f, 0xcb, 0xa0, 0x58, 0xf4, 0xa3, 0xda, 0xb2, 0x1f, 0x0d, 0xa1, 0x89,
+	0xb6, 0x63, 0x54, 0xe8, 0x60, 0xcf, 0xda, 0x64, 0x0f, 0x9a, 0x33, 0x1a, 0x3b, 0x3a, 0xd6, 0x64,
+	0x48, 0x6c, 0xa7, 0x31, 0x33, 0x3e, 0xd1, 0x02, 0x15, 0x10, 0x99, 0xde, 0x52, 0x44, 0xd4, 0x97,
+	0x23, 0x62, 0x08, 0xcd, 0xcc, 0xe9, 0x1a, 0xea, 0x84, 0xd3, 0xb6, 0x4c, 0xb3, 0x11, 0xe5, 0x2c,
+	0xf4, 0x8c, 0x26, 0x3a, 0x8a, 0x6e, 0xc9, 0x24, 0x19, 0x24, 0x33, 0xe5, 0x42, 0x2d, 0x95, 0x24,
+	0x83, 0x64, 0xb6, 0xec, 0x31, 0xb0, 0xe0, 0x31, 0xff, 0x03, 0xeb, 0x8e, 0xcf, 0x1c, 0x61, 0xb4,
+	0xf5, 0xc9, 0xea, 0x7c, 0x3f, 0xde, 0x97, 0xa8, 0xa5, 0x84, 0xe4, 0x29, 0x74, 0x27, 0x3c, 0x4c,
+	0x22, 0x1b, 0x9b, 0x54, 0x18, 0x9d, 0x51, 0x6d, 0x85, 0x76, 0x07, 0x95, 0xf6, 0x95, 0x8e, 0x8c,
+	0xc0, 0x8b, 0x30, 0x09, 0x3c, 0xdb, 0x65, 0x1e, 0x17, 0x46, 0x17, 0x8d, 0x07, 0x08, 0x1d, 0x48,
+	0x44, 0x86, 0x98, 0x0a, 0x81, 0xcc, 0xc0, 0x1b, 0xa8, 0xd3, 0x45, 0xf4, 0x34, 0xb5, 0xf2, 0xff,
+	0xc2, 0x20, 0x2d, 0x4a, 0xb9, 0x66, 0x0f, 0x35, 0xfb, 0xa9, 0x20, 0x53, 0xde, 0x81, 0x3e, 0x7d,
+	0x2d, 0x53, 0x28, 0x8b, 0xed, 0x99, 0xf3, 0xda, 0x8e, 0x63, 0x5f, 0x87, 0xd4, 0x46, 0x8a, 0x9f,
+	0x38, 0xaf, 0xcf, 0x63, 0x5f, 0xc6, 0xbf, 0x9a, 0x1d, 0xe3, 0x7f, 0x80, 0xc5, 0xa8, 0x85, 0x88,
+	0x8c, 0xff, 0xe1, 0xcf, 0xa1, 0x5b, 0x3a, 0xc2, 0x15, 0x8e, 0xbc, 0x55, 0x74, 0xe4, 0x56, 0xd1,
+	0x79, 0xff, 0xb9, 0x06, 0x80, 0x67, 0xa9, 0xba, 0x2e, 0x56, 0x80, 0xe2, 0x01, 0x57, 0x57, 0x1c,
+	0xb0, 0xc3, 0x69, 0x10, 0x6b, 0x67, 0xd4, 0xad, 0xef, 0xf5, 0xc3, 0xb4, 0x06, 0xac, 0x17, 0x6a,
+	0xc0, 0x7b, 0xb0, 0x26, 0x7d, 0xce, 0xa8, 0xe7, 0xa9, 0x3a, 0x5f, 0x11, 0x7a, 0x27, 0x7e, 0x59,
+	0xa8, 0xb5, 0x14, 0x08, 0x8d, 0xe5, 0x40, 0x28, 0x7a, 0x58, 0xb3, 0xec, 0x61, 0x6f, 0x43, 0xd7,
+	0xe5, 0x14, 0xeb, 0x91, 0x2d, 0x89, 0x85, 0xf6, 0xc0, 0x4e, 0x0a, 0x9e, 0xb3, 0x19, 0x95, 0xf6,
+	0x93, 0x87, 0x01, 0x28, 0x92, 0x9f, 0x2b, 0xcf, 0xaa, 0xbd, 0xf2, 0xac, 0xb0, 0xba, 0xfb, 0x54,
+	0x67, 0x71, 0xfc, 0x2e, 0x44, 0x42, 0xb7, 0x14, 0x09, 0x25, 0x77, 0xdf, 0x58, 0x70, 0xf7, 0x05,
+	0x9f, 0xec, 0x2d, 0xf9, 0xe4, 0x5b, 0xd0, 0x91, 0x06, 0x10, 0x91, 0xe3, 0x52, 0x39, 0x40, 0x5f,
+	0x19, 0x22, 0xc3, 0x8e, 0x3c, 0x8c, 0xe0, 0xe4, 0xe2, 0x62, 0x3e, 0x0d, 0x7d, 0x9a, 0x27, 0xe1,
+	0x76, 0x86, 0x1d, 0x79, 0x72, 0xbd, 0xe8, 0x55, 0x04, 0xbd, 0x0a, 0xbf, 0x87, 0x1f, 0x42, 0x2b,
+	0xb3, 0xfa, 0x8f, 0x72, 0xa6, 0x3f, 0x55, 0xa0, 0x53, 0x4c, 0x74, 0xb2, 0xf3, 0xf9, 0xf9, 0x31,
+	0x76, 0xae, 0x59, 0xf2, 0x53, 0x52, 0x04, 0x4e, 0x03, 0xfa, 0xca, 0xb9, 0xf0, 0xd5, 0x00, 0x4d,
+	0x2b, 0x07, 0xa4, 0x94, 0x05, 0x2e, 0xa7, 0xb3, 0xd4, 0xab, 0x6a, 0x56, 0x0e, 0x90, 0x8f, 0x00,
+	0x98, 0x10, 0x09, 0x55, 0x27, 0xb7, 0x86, 0x69, 0x60, 0x38, 0x56, 0x7c, 0x71, 0x9c, 0xf2, 0xc5,
+	0xf1, 0x79, 0xca, 0x17, 0xad, 0x16, 0x6a, 0xe3, 0x91, 0x6e, 0x43, 0x5d, 0x1e, 0xd0, 0xf9, 0x31,
+	0x7a, 0x5e, 0xcd, 0xd2, 0x2d, 0xf3, 0x0f, 0x50, 0x57, 0xcc, 0xe2, 0x3f, 0x9a, 0xbc, 0x6f, 0x43,
+	0x53, 0x8d, 0xcd, 0x3c, 0x1d, 0x2b, 0x0d, 0x6c, 0x1f, 0x79, 0xe6, 0x77, 0x55, 0x68, 0x5a, 0x54,
+	0x44, 0x61, 0x20, 0x68, 0x81, 0xf9, 0x54, 0x7e, 0x90, 0xf9, 0x54, 0x57, 0x32, 0x9f, 0x94, 0x4f,
+	0xd5, 0x0a, 0x7c, 0x6a, 0x08, 0x4d, 0x4e, 0x3d, 0xc6, 0xa9, 0x1b, 0x6b, 0xee, 0x95, 0xb5, 0xa5,
+	0xec, 0x95, 0xc3, 0x65, 0xc9, 0x16, 0x58, 0x17, 0x5a, 0x56, 0xd6, 0x26, 0x4f, 0x8a, 0x84, 0x41,
+	0x51, 0xb1, 0x2d, 0x45, 0x18, 0xd4, 0x72, 0x57, 0x30, 0x86, 0xa7, 0x39, 0xf1, 0x6a, 0x60, 0x34,
+	0xdf, 0x2e, 0x76, 0x58, 0xcd, 0xbc, 0x7e, 0xb2, 0x3a, 0xfc, 0x5d, 0x15, 0xfa, 0x8b, 0x6b, 0x5b,
+	0xe1, 0x81, 0x5b, 0xb0, 0xae, 0xea, 0x99, 0x76, 0xdf, 0x78, 0xa9, 0x92, 0xd5, 0x16, 0x12, 0xdd,
+	0x2f, 0x16, 0x93, 0xc6, 0x0f, 0xbb, 0x5e, 0x39, 0xa1, 0xbc, 0x0b, 0x7d, 0x69, 0xa2, 0x88, 0x7a,
+	0x39, 0x47, 0x53, 0x19, 0xb0, 0xa7, 0xf1, 0x8c, 0xa5, 0x3d, 0x82, 0x41, 0xaa, 0x9a, 0xe7, 0x86,
+	0x7a, 0x49, 0xf7, 0x30, 0x4d, 0x11, 0xdb, 0x50, 0xbf, 0x0c, 0xf9, 0xcc, 0x89, 0x75, 0x12, 0xd4,
+	0xad, 0x52, 0x92, 0xc3, 0x6c, 0xdb, 0x54, 0x3e, 0x99, 0x82, 0xf2, 0x1e, 0x22, 0x93, 0x4f, 0x76,
+	0x47, 0xc0, 0x2c, 0xd8, 0xb4, 0x9a, 0xe9, 0xdd, 0xc0, 0xfc, 0x35, 0xf4, 0x16, 0x68, 0xe1, 0x0a,
+	0x43, 0xe6, 0xd3, 0x57, 0x4b, 0xd3, 0x97, 0x46, 0xae, 0x2d, 0x8c, 0xfc, 0x1b, 0x18, 0x7c, 0xee,
+	0x04, 0x9e, 0x4f, 0xf5, 0xf8, 0xfb, 0x7c, 0x22, 0x64, 0x81, 0xd3, 0xb7, 0x14, 0x5b, 0x57, 0x9f,
+	0xae, 0xd5, 0xd2, 0xc8, 0x91, 0x47, 0x1e, 0x40, 0x83, 0x2b, 0x6d, 0xed, 0x00, 0xed, 0x02, 0x6f,
+	0xb5, 0x52, 0x99, 0xf9, 0x0d, 0x90, 0xd2, 0xd0, 0xf2, 0x82, 0x32, 0x27, 0x3b, 0xd2, 0xfb, 0x95,
+	0x53, 0xe8, 0xa8, 0xea, 0x14, 0x7d, 0xd2, 0xca, 0xa4, 0x64, 0x04, 0x35, 0xca, 0xb9, 0x51, 0xcd,
+	0x89, 0x63, 0x7e, 0x1d, 0xb4, 0xa4, 0xc8, 0xfc, 0x3f, 0x18, 0x9c, 0x45, 0xd4, 0x65, 0x8e, 0x8f,
+	0x57, 0x39, 0x35, 0xc1, 0x7d, 0x58, 0x97, 0x46, 0x4e, 0x13, 0x46, 0x0b, 0x3b, 0xa2, 0x58, 0xe1,
+	0xe6, 0x37, 0x60, 0xa8, 0x75, 0x1d, 0xbe, 0x66, 0x22, 0xa6, 0x81, 0x4b, 0x0f, 0xa6, 0xd4, 0xbd,
+	0xfa, 0x09, 0x77, 0x7e, 0x0d, 0xb7, 0x57, 0xcd, 0x90, 0xae, 0xaf, 0xed, 0xca, 0x96, 0x7d, 0x29,
+	0x6b, 0x07, 0xce, 0xd1, 0xb4, 0x00, 0xa1, 0xcf, 0x24, 0x22, 0xcf, 0x91, 0xca, 0x7e, 0x42, 0xe7,
+	0x63, 0xdd, 0x4a, 0xed, 0x51, 0xbb, 0xd9, 0x1e, 0x7f, 0xa9, 0x40, 0xeb, 0x8c, 0xc6, 0x49, 0x84,
+	0x7b, 0xb9, 0x03, 0xad, 0x0b, 0x1e, 0x5e, 0x51, 0x9e, 0x6f, 0xa5, 0xa9, 0x80, 0x23, 0x8f, 0x3c,
+	0x81, 0xfa, 0x41, 0x18, 0x5c, 0xb2, 0x89, 0x51, 0xcd, 0x13, 0x43, 0xd6, 0x77, 0xac, 0x64, 0x2a,
+	0x31, 0x68, 0x45, 0x32, 0x82, 0xb6, 0x7e, 0x1e, 0x78, 0xf9, 0xf2, 0xe8, 0x79, 0xca, 0x78, 0x0b,
+	0xd0, 0xf0, 0x23, 0x68, 0x17, 0x3a, 0xfe, 0xa8, 0x52, 0xf5, 0xdf, 0x00, 0x38, 0xbb, 0xb2, 0x51,
+	0x5f, 0x6d, 0x55, 0xf7, 0x94, 0x5b, 0xbb, 0x0f, 0x2d, 0x49, 0xae, 0x94, 0x38, 0x2d, 0x92, 0x95,
+	0xbc, 0x48, 0x9a, 0x0f, 0x60, 0x70, 0x14, 0x5c, 0x3b, 0x3e, 0xf3, 0x9c, 0x98, 0x7e, 0x41, 0xe7,
+	0x68, 0x82, 0xa5, 0x15, 0x98, 0x67, 0xd0, 0xd1, 0x37, 0xed, 0x37, 0x5a, 0x63, 0x47, 0xaf, 0xf1,
+	0xfb, 0x83, 0xe8, 0x5d, 0xe8, 0xe9, 0x41, 0x8f, 0x99, 0x0e, 0x21, 0xc9, 0x31, 0x38, 0xbd, 0x64,
+	0xaf, 0xf5, 0xd0, 0xba, 0x65, 0x3e, 0x83, 0x7e, 0x41, 0x35, 0xdb, 0xce, 0x15, 0x9d, 0x8b, 0xf4,
+	0x05, 0x42, 0x7e, 0xa7, 0x16, 0xa8, 0xe6, 0x16, 0x30, 0x61, 0x43, 0xf7, 0x7c, 0x41, 0xe3, 0x1b,
+	0x76, 0xf7, 0x45, 0xb6, 0x90, 0x17, 0x54, 0x0f, 0xfe, 0x10, 0xd6, 0xa9, 0xdc, 0x69, 0xb1, 0x7e,
+	0x16, 0x2d, 0x60, 0x29, 0xf1, 0x8a, 0x09, 0x9f, 0x65, 0x13, 0x9e, 0x26, 0x6a, 0xc2, 0x37, 0x1c,
+	0xcb, 0x7c, 0x3b, 0x5b, 0xc6, 0x69, 0x12, 0xdf, 0x74, 0xa2, 0x0f, 0x60, 0xa0, 0x95, 0x9e, 0x53,
+	0x9f, 0xc6, 0xf4, 0x86, 0x2d, 0x3d, 0x04, 0x52, 0x52, 0xbb, 0x69, 0xb8, 0xbb, 0xd0, 0x3c, 0x3f,
+	0x3f, 0xce, 0xa4, 0xe5, 0xdc, 0x68, 0x7e, 0x0c, 0x83, 0xb3, 0xc4, 0x0b, 0x4f, 0x39, 0xbb, 0x66,
+	0x3e, 0x9d, 0xa8, 0xc9, 0x52, 0xf2, 0x5b, 0x29, 0x90, 0xdf, 0x95, 0xd5, 0xc8, 0xdc, 0x01, 0x52,
+	0xea, 0x9e, 0x9d, 0x9b, 0x48, 0xbc, 0x50, 0x87, 0x30, 0x7e, 0x9b, 0x3b, 0xd0, 0x39, 0x77, 0x24,
+	0xd9, 0xf0, 0x94, 0x8e, 0x01, 0x8d, 0x58, 0xb5, 0xb5, 0x5a, 0xda, 0x34, 0xf7, 0x60, 0xeb, 0xc0,
+	0x71, 0xa7, 0x2c, 0x98, 0x3c, 0x67, 0x42, 0xb2, 0x2d, 0xdd, 0x63, 0x08, 0x4d, 0x4f, 0x03, 0xba,
+	0x4b, 0xd6, 0x36, 0x1f, 0xc3, 0xad, 0xc2, 0x33, 0xcf, 0x59, 0xec, 0xa4, 0xf6, 0xd8, 0x82, 0x75,
+	0x21, 0x5b, 0xd8, 0x63, 0xdd, 0x52, 0x0d, 0xf3, 0x4b, 0xd8, 0x2a, 0x16, 0x60, 0xc9, 0x7d, 0xd2,
+	0x8d, 0x23, 0x2b, 0xa9, 0x14, 0x58, 0x89, 0xb6, 0x59, 0x35, 0xaf, 0x27, 0x7d, 0xa8, 0xfd, 0xf2,
+	0xeb, 0x73, 0xed, 0xec, 0xf2, 0xd3, 0xfc, 0x3d, 0xdc, 0x5a, 0x1c, 0x4f, 0x4d, 0x5f, 0xa2, 0x26,
+	0x95, 0x37, 0xa2, 0x26, 0xcb, 0xfe, 0xf6, 0x18, 0x06, 0x27, 0x7e, 0xe8, 0x5e, 0x1d, 0x06, 0x05,
+	0x6b, 0x18, 0xd0, 0xa0, 0x41, 0xd1, 0x18, 0x69, 0xd3, 0x7c, 0x07, 0x7a, 0xc7, 0xf2, 0x91, 0xed,
+	0x44, 0xbe, 0xaa, 0x64, 0x56, 0xc0, 0x77, 0x37, 0xad, 0xaa, 0x1a, 0xe6, 0x63, 0xd8, 0xd0, 0x25,
+	0x3a, 0xb8, 0x0c, 0xd3, 0xcc, 0x98, 0x17, 0xf3, 0x4a, 0x99, 0xe8, 0x9b, 0xc7, 0xd0, 0xcb, 0xd5,
+	0xd5, 0xb8, 0xef, 0x40, 0x5d, 0x89, 0xf5, 0xde, 0x7a, 0xd9, 0xed, 0x55, 0x69, 0x5a, 0x5a, 0xbc,
+	0x62, 0x53, 0x33, 0xd8, 0x38, 0xc5, 0xf7, 0xcf, 0xc3, 0xe0, 0x5a, 0x0d, 0x76, 0x04, 0x44, 0xbd,
+	0x88, 0xda, 0x34, 0xb8, 0x66, 0x3c, 0x0c, 0x90, 0x5c, 0x57, 0x34, 0x85, 0x49, 0x07, 0xce, 0x3a,
+	0xa5, 0x1a, 0xd6, 0x20, 0x5a, 0x84, 0x56, 0x4e, 0x07, 0xf9, 0xeb, 0x8a, 0x2c, 0x35, 0x9c, 0xce,
+	0xc2, 0x98, 0xda, 0x8e, 0xe7, 0xa5, 0xd1, 0x02, 0x0a, 0xda, 0xf7, 0x3c, 0x4e, 0x3e, 0x81, 0x7e,
+	0xfe, 0x00, 0x63, 0x2b, 0x0f, 0xaa, 0xe6, 0x4f, 0x51, 0xf9, 0x50, 0xca, 0xd5, 0x7a, 0x6e, 0x19,
+	0x30, 0xff, 0xb6, 0x06, 0xbd, 0x05, 0x25, 0x79, 0x62, 0xd7, 0x94, 0x0b, 0xf9, 0xe6, 0xa3, 0xdf,
+	0x69, 0x75, 0x93, 0x3c, 0x06, 0x32, 0x75, 0x02, 0x4f, 0x4c, 0x9d, 0x2b, 0x6a, 0xbb, 0xe1, 0x2c,
+	0xf2, 0xa9, 0x9e, 0xaf, 0x69, 0x0d, 0x32, 0xc9, 0x81, 0x16, 0xc8, 0x5a, 0xec, 0x31, 0xcf, 0xe6,
+	0x54, 0x24, 0xfa, 0x81, 0xa5, 0x69, 0xb5, 0x3c, 0xe6, 0x59, 0x08, 0xe0, 0x65, 0x8a, 0x45, 0x53,
+	0xca, 0x6d, 0x91, 0xb0, 0x58, 0x91, 0xc0, 0xae, 0xd5, 0x56, 0xd8, 0x99, 0x84, 0xc8, 0x2e, 0x6c,
+	0x06, 0x74, 0x12, 0xc6, 0xcc, 0x89, 0xa9, 0x67, 0x23, 0x2d, 0x74, 0x43, 0x5f, 0x53, 0x3d, 0x92,
+	0x8b, 0x4e, 0xb5, 0x84, 0xec, 0xc3, 0xbd, 0x15, 0x1d, 0x6c, 0x26, 0xec, 0x59, 0x12, 0x27, 0x8e,
+	0x8f, 0xcc, 0xaf, 0x69, 0x0d, 0x97, 0xbb, 0x1e, 0x89, 0x13, 0xd4, 0x90, 0x36, 0x17, 0x94, 0x5f,
+	0x53, 0x5e, 0xbc, 0x0e, 0x83, 0x82, 0xf0, 0x36, 0xbc, 0x0f, 0x83, 0x88, 0x52, 0x6e, 0xbb, 0x94,
+	0xc7, 0xec, 0x12, 0xdf, 0xca, 0xd4, 0xb5, 0x58, 0xc7, 0xcc, 0x41, 0x8e, 0x1f, 0x4c, 0x1d, 0x16,
+	0x58, 0x7d, 0xa9, 0x5e, 0x40, 0x05, 0xf9, 0x18, 0x7a, 0xd7, 0x94, 0xb3, 0x4b, 0x46, 0x3d, 0xdb,
+	0x95, 0x3a, 0xf2, 0xe5, 0xa6, 0x76, 0xe3, 0x00, 0x1b, 0xa9, 0x32, 0x36, 0x05, 0xf9, 0x14, 0xee,
+	0x09, 0x36, 0x09, 0x64, 0xe7, 0x5c, 0xd5, 0xce, 0xde, 0xf5, 0x85, 0x01, 0xa3, 0xda, 0x4e, 0xc7,
+	0xba, 0xa3, 0x94, 0x0a, 0xc3, 0x65, 0x7c, 0x1a, 0xef, 0x59, 0xa1, 0x2b, 0x22, 0x3b, 0xe3, 0x72,
+	0x6d, 0xac, 0x96, 0x1d, 0x09, 0x66, 0xf7, 0x27, 0xf9, 0x50, 0xe2, 0x0b, 0x3b, 0x09, 0xd8, 0xb7,
+	0x89, 0xba, 0x82, 0x77, 0xac, 0x56, 0xec, 0x8b, 0x97, 0x08, 0x98, 0x8f, 0xa0, 0x5d, 0x18, 0x5c,
+	0x46, 0xa5, 0x23, 0x82, 0x27, 0x76, 0x96, 0x9a, 0x3a, 0x56, 0x53, 0x02, 0x32, 0xcd, 0x98, 0x2f,
+	0xa0, 0xbf, 0xb8, 0x2f, 0xf2, 0x14, 0x3a, 0x25, 0x23, 0x56, 0xd0, 0x06, 0xbd, 0x05, 0x1b, 0x58,
+	0x25, 0x25, 0x99, 0xca, 0x4b, 0xac, 0xf4, 0x60, 0x9a, 0x04, 0x57, 0xa5, 0x8c, 0xd8, 0x51, 0x19,
+	0x71, 0xef, 0xcf, 0x35, 0x68, 0x7c, 0xaa, 0xd8, 0x0d, 0xf9, 0x04, 0xba, 0xa5, 0x5e, 0xe4, 0x16,
+	0xde, 0x79, 0x16, 0x99, 0xf3, 0x70, 0x7b, 0x09, 0x56, 0x41, 0xff, 0x3e, 0x74, 0x8a, 0x4c, 0x95,
+	0x20, 0x2b, 0xc5, 0x1f, 0x21, 0x43, 0x1c, 0x69, 0x99, 0xc6, 0x9e, 0xc1, 0xd6, 0x2a, 0x0e, 0x49,
+	0xee, 0xe6, 0x33, 0x2c, 0xf3, 0xd7, 0xe1, 0xbd, 0x9b, 0xa4, 0x29, 0xf7, 0x6c, 0x1c, 0xf8, 0xd4,
+	0x09, 0x92, 0xa8, 0xb8, 0x82, 0xfc, 0x93, 0x3c, 0x81, 0x6e, 0x89, 0x45, 0xa9, 0x7d, 0x2e, 0x11,
+	0xab, 0x62, 0x97, 0x87, 0xb0, 0x8e, 0xcc, 0x8d, 0x74, 0x4b, 0x14, 0x72, 0xb8, 0x91, 0x35, 0xd5,
+	0xdc, 0x23, 0x58, 0xc3, 0xe7, 0xf1, 0xc2, 0xc4, 0xd8, 0x23, 0xa7, 0x75, 0x9f, 0xc1, 0x66, 0xc9,
+	0x74, 0x67, 0x31, 0xa7, 0xce, 0xec, 0xcd, 0x4d, 0x8d, 0x47, 0xf9, 0x7e, 0x65, 0xef, 0x5f, 0x15,
+	0x68, 0xa4, 0xbf, 0x5e, 0x9e, 0xc0, 0x9a, 0x24, 0x5a, 0x64, 0xb3, 0xc0, 0x55, 0x52, 0x92, 0x36,
+	0xdc, 0x5a, 0x00, 0xd5, 0x32, 0xc6, 0x50, 0x7b, 0x41, 0x63, 0x42, 0x0a, 0x42, 0xcd, 0xb8, 0x86,
+	0x9b, 0x65, 0x2c, 0xd3, 0x3f, 0x4d, 0xca, 0xfa, 0xa7, 0xc9, 0xb2, 0x7e, 0x46, 0x85, 0x3e, 0x84,
+	0xba, 0xa2, 0x32, 0xe4, 0x56, 0x41, 0x9c, 0x93, 0xa0, 0xe1, 0xf6, 0x12, 0x8c, 0x1d, 0xf7, 0xfe,
+	0xb1, 0x06, 0x70, 0x36, 0x17, 0x31, 0x9d, 0xfd, 0x8a, 0xd1, 0x57, 0xe4, 0x11, 0xf4, 0x9e, 0xd3,
+	0x4b, 0x27, 0xf1, 0x63, 0x7c, 0x0f, 0x91, 0x25, 0xbb, 0x60, 0x5b, 0xbc, 0x55, 0x65, 0x8c, 0xe8,
+	0x21, 0xb4, 0x4f, 0x9c, 0xd7, 0x3f, 0xac, 0xf7, 0x09, 0x74, 0x4b, 0x44, 0x47, 0x2f, 0x71, 0x91,
+	0x3a, 0x0d, 0xb7, 0x97, 0xe0, 0x74, 0x9e, 0x86, 0xa6, 0x3f, 0xc5, 0x39, 0x90, 0x28, 0x96, 0x68,
+	0xd1, 0xff, 0x43, 0x6f, 0x81, 0xfc, 0x14, 0xf5, 0xf1, 0xcd, 0x71, 0x25, 0x39, 0x7a, 0x06, 0xfd,
+	0x45, 0x02, 0x54, 0xec, 0xa8, 0x9f, 0x37, 0x56, 0x31, 0xa4, 0x17, 0xe5, 0xc7, 0x08, 0x7c, 0x07,
+	0x32, 0x16, 0x39, 0x4a, 0xca, 0x90, 0x86, 0xb7, 0x57, 0x49, 0xb2, 0x50, 0x2e, 0xd2, 0x94, 0xa5,
+	0x50, 0x5e, 0xe6, 0x30, 0xef, 0x01, 0xe4, 0x4c, 0xa5, 0xa8, 0x8f, 0xee, 0xb1, 0x48, 0x62, 0x3e,
+	0x00, 0xc8, 0xf9, 0x87, 0xf2, 0xaa, 0x32, 0x7d, 0x19, 0x6e, 0x96, 0x31, 0xd5, 0xed, 0x11, 0xb4,
+	0x32, 0xce, 0x50, 0x9c, 0x03, 0x07, 0x28, 0x53, 0x90, 0x4f, 0xc7, 0xbf, 0x7d, 0x6f, 0xc2, 0xe2,
+	0x69, 0x72, 0x31, 0x76, 0xc3, 0xd9, 0xee, 0xd4, 0x11, 0x53, 0xe6, 0x86, 0x3c, 0xda, 0xbd, 0x96,
+	0xce, 0xb4, 0xbb, 0xf4, 0x57, 0xf8, 0xa2, 0x8e, 0x95, 0xf0, 0xe9, 0xbf, 0x07, 0x00, 0xa4, 0x28,
+	0x9d, 0xde, 0x31, 0x1e, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -2916,6 +3197,10 @@ type BackendClient interface {
 	Setup(ctx context.Context, in *SetupArgs, opts ...grpc.CallOption) (*SetupReply, error)
 	// Type returns the BackendType for the particular backend
 	Type(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*TypeReply, error)
+	// HandleRequestStream is used to handle a request like HandleRequest,
+	// streaming the serialized HandleRequestReply in chunks so that large
+	// responses aren't bound by the size limits of a single message.
+	HandleRequestStream(ctx context.Context, in *HandleRequestArgs, opts ...grpc.CallOption) (Backend_HandleRequestStreamClient, error)
 }
 
 type backendClient struct {
@@ -2989,6 +3274,38 @@ func (c *backendClient) Type(ctx context.Context, in *Empty, opts ...grpc.CallOp
 	return out, nil
 }
 
+func (c *backendClient) HandleRequestStream(ctx context.Context, in *HandleRequestArgs, opts ...grpc.CallOption) (Backend_HandleRequestStreamClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Backend_serviceDesc.Streams[0], "/pb.Backend/HandleRequestStream", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &backendHandleRequestStreamClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Backend_HandleRequestStreamClient interface {
+	Recv() (*HandleRequestChunk, error)
+	grpc.ClientStream
+}
+
+type backendHandleRequestStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *backendHandleRequestStreamClient) Recv() (*HandleRequestChunk, error) {
+	m := new(HandleRequestChunk)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // BackendServer is the server API for Backend service.
 type BackendServer interface {
 	// HandleRequest is used to handle a request and generate a response.
@@ -3023,6 +3340,10 @@ type BackendServer interface {
 	Setup(context.Context, *SetupArgs) (*SetupReply, error)
 	// Type returns the BackendType for the particular backend
 	Type(context.Context, *Empty) (*TypeReply, error)
+	// HandleRequestStream is used to handle a request like HandleRequest,
+	// streaming the serialized HandleRequestReply in chunks so that large
+	// responses aren't bound by the size limits of a single message.
+	HandleRequestStream(*HandleRequestArgs, Backend_HandleRequestStreamServer) error
 }
 
 func RegisterBackendServer(s *grpc.Server, srv BackendServer) {
@@ -3155,6 +3476,27 @@ func _Backend_Type_Handler(srv interface{}, ctx context.Context, dec func(interf
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Backend_HandleRequestStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(HandleRequestArgs)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(BackendServer).HandleRequestStream(m, &backendHandleRequestStreamServer{stream})
+}
+
+type Backend_HandleRequestStreamServer interface {
+	Send(*HandleRequestChunk) error
+	grpc.ServerStream
+}
+
+type backendHandleRequestStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *backendHandleRequestStreamServer) Send(m *HandleRequestChunk) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 var _Backend_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "pb.Backend",
 	HandlerType: (*BackendServer)(nil),
@@ -3188,7 +3530,13 @@ var _Backend_serviceDesc = grpc.ServiceDesc{
 			Handler:    _Backend_Type_Handler,
 		},
 	},
-	Streams:  []grpc.StreamDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "HandleRequestStream",
+			Handler:       _Backend_HandleRequestStream_Handler,
+			ServerStreams: true,
+		},
+	},
 	Metadata: "logical/plugin/pb/backend.proto",
 }
 
@@ -1,6 +1,8 @@
 package pb
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"errors"
 	"time"
@@ -271,6 +273,11 @@ func ProtoRequestToLogicalRequest(r *Request) (*logical.Request, error) {
 		return nil, err
 	}
 
+	connection, err := ProtoConnectionToLogicalConnection(r.Connection)
+	if err != nil {
+		return nil, err
+	}
+
 	var headers map[string][]string
 	if len(r.Headers) > 0 {
 		headers = make(map[string][]string, len(r.Headers))
@@ -296,7 +303,7 @@ func ProtoRequestToLogicalRequest(r *Request) (*logical.Request, error) {
 		MountAccessor:            r.MountAccessor,
 		WrapInfo:                 ProtoRequestWrapInfoToLogicalRequestWrapInfo(r.WrapInfo),
 		ClientTokenRemainingUses: int(r.ClientTokenRemainingUses),
-		Connection:               ProtoConnectionToLogicalConnection(r.Connection),
+		Connection:               connection,
 		EntityID:                 r.EntityID,
 		PolicyOverride:           r.PolicyOverride,
 		Unauthenticated:          r.Unauthenticated,
@@ -309,18 +316,116 @@ func LogicalConnectionToProtoConnection(c *logical.Connection) *Connection {
 	}
 
 	return &Connection{
-		RemoteAddr: c.RemoteAddr,
+		RemoteAddr:      c.RemoteAddr,
+		ConnectionState: TLSConnectionStateToProtoConnectionState(c.ConnState),
 	}
 }
 
-func ProtoConnectionToLogicalConnection(c *Connection) *logical.Connection {
+func ProtoConnectionToLogicalConnection(c *Connection) (*logical.Connection, error) {
 	if c == nil {
-		return nil
+		return nil, nil
+	}
+
+	connState, err := ProtoConnectionStateToTLSConnectionState(c.ConnectionState)
+	if err != nil {
+		return nil, err
 	}
 
 	return &logical.Connection{
 		RemoteAddr: c.RemoteAddr,
+		ConnState:  connState,
+	}, nil
+}
+
+func TLSConnectionStateToProtoConnectionState(cs *tls.ConnectionState) *ConnectionState {
+	if cs == nil {
+		return nil
+	}
+
+	var verifiedChains []*CertificateChain
+	for _, chain := range cs.VerifiedChains {
+		verifiedChains = append(verifiedChains, CertificatesToProtoCertificateChain(chain))
+	}
+
+	return &ConnectionState{
+		Version:                     uint32(cs.Version),
+		HandshakeComplete:           cs.HandshakeComplete,
+		DidResume:                   cs.DidResume,
+		CipherSuite:                 uint32(cs.CipherSuite),
+		NegotiatedProtocol:          cs.NegotiatedProtocol,
+		NegotiatedProtocolIsMutual:  cs.NegotiatedProtocolIsMutual,
+		ServerName:                  cs.ServerName,
+		PeerCertificates:            CertificatesToProtoCertificateChain(cs.PeerCertificates),
+		VerifiedChains:              verifiedChains,
+		SignedCertificateTimestamps: cs.SignedCertificateTimestamps,
+		OcspResponse:                cs.OCSPResponse,
+		TlsUnique:                   cs.TLSUnique,
+	}
+}
+
+func ProtoConnectionStateToTLSConnectionState(cs *ConnectionState) (*tls.ConnectionState, error) {
+	if cs == nil {
+		return nil, nil
+	}
+
+	peerCertificates, err := ProtoCertificateChainToCertificates(cs.PeerCertificates)
+	if err != nil {
+		return nil, err
+	}
+
+	var verifiedChains [][]*x509.Certificate
+	for _, chain := range cs.VerifiedChains {
+		certs, err := ProtoCertificateChainToCertificates(chain)
+		if err != nil {
+			return nil, err
+		}
+		verifiedChains = append(verifiedChains, certs)
+	}
+
+	return &tls.ConnectionState{
+		Version:                     uint16(cs.Version),
+		HandshakeComplete:           cs.HandshakeComplete,
+		DidResume:                   cs.DidResume,
+		CipherSuite:                 uint16(cs.CipherSuite),
+		NegotiatedProtocol:          cs.NegotiatedProtocol,
+		NegotiatedProtocolIsMutual:  cs.NegotiatedProtocolIsMutual,
+		ServerName:                  cs.ServerName,
+		PeerCertificates:            peerCertificates,
+		VerifiedChains:              verifiedChains,
+		SignedCertificateTimestamps: cs.SignedCertificateTimestamps,
+		OCSPResponse:                cs.OcspResponse,
+		TLSUnique:                   cs.TlsUnique,
+	}, nil
+}
+
+func CertificatesToProtoCertificateChain(certs []*x509.Certificate) *CertificateChain {
+	if certs == nil {
+		return nil
+	}
+
+	chain := &CertificateChain{
+		Certificates: make([]*Certificate, 0, len(certs)),
+	}
+	for _, cert := range certs {
+		chain.Certificates = append(chain.Certificates, &Certificate{Asn1Data: cert.Raw})
+	}
+	return chain
+}
+
+func ProtoCertificateChainToCertificates(chain *CertificateChain) ([]*x509.Certificate, error) {
+	if chain == nil {
+		return nil, nil
+	}
+
+	certs := make([]*x509.Certificate, 0, len(chain.Certificates))
+	for _, cert := range chain.Certificates {
+		c, err := x509.ParseCertificate(cert.Asn1Data)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, c)
 	}
+	return certs, nil
 }
 
 func LogicalRequestWrapInfoToProtoRequestWrapInfo(i *logical.RequestWrapInfo) *RequestWrapInfo {
@@ -1,7 +1,14 @@
 package pb
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"errors"
+	"math/big"
 	"reflect"
 	"testing"
 	"time"
@@ -55,6 +62,8 @@ func TestTranslation_StorageEntry(t *testing.T) {
 }
 
 func TestTranslation_Request(t *testing.T) {
+	cert := testCertificate(t)
+
 	tCases := []*logical.Request{
 		nil,
 		&logical.Request{
@@ -76,6 +85,25 @@ func TestTranslation_Request(t *testing.T) {
 				RemoteAddr: "localhost",
 			},
 		},
+		&logical.Request{
+			ID:        "ID",
+			Operation: logical.UpdateOperation,
+			Path:      "login",
+			Data:      map[string]interface{}{},
+			Connection: &logical.Connection{
+				RemoteAddr: "localhost",
+				ConnState: &tls.ConnectionState{
+					Version:            tls.VersionTLS12,
+					HandshakeComplete:  true,
+					CipherSuite:        tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+					NegotiatedProtocol: "h2",
+					ServerName:         "vault.example.com",
+					PeerCertificates:   []*x509.Certificate{cert},
+					VerifiedChains:     [][]*x509.Certificate{{cert, cert}},
+					TLSUnique:          []byte("unique"),
+				},
+			},
+		},
 		&logical.Request{
 			ID:                 "ID",
 			ReplicationCluster: "RID",
@@ -267,3 +295,27 @@ func TestTranslation_Response(t *testing.T) {
 		}
 	}
 }
+
+func testCertificate(t *testing.T) *x509.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "client"},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
@@ -96,9 +96,10 @@ type Request struct {
 	// headers.
 	Headers map[string][]string `json:"headers" structs:"headers" mapstructure:"headers" sentinel:""`
 
-	// Connection will be non-nil only for credential providers to
-	// inspect the connection information and potentially use it for
-	// authentication/protection.
+	// Connection holds the connection information of the request, including
+	// the TLS client certificates presented, for backends to inspect and
+	// potentially use for authentication/protection. It is nil for requests
+	// that didn't originate from a client connection.
 	Connection *Connection `json:"connection" structs:"connection" mapstructure:"connection"`
 
 	// ClientToken is provided to the core so that the identity
@@ -284,6 +285,8 @@ const (
 	ListOperation                     = "list"
 	HelpOperation                     = "help"
 	AliasLookaheadOperation           = "alias-lookahead"
+	ResolveRoleOperation              = "resolve-role"
+	LoginCommitOperation              = "login-commit"
 
 	// The operations below are called globally, the path is less relevant.
 	RevokeOperation   Operation = "revoke"
@@ -31,6 +31,13 @@ const (
 	// that it has already been unmarshaled. That way we don't need to simply
 	// ignore errors.
 	HTTPRawBodyAlreadyJSONDecoded = "http_raw_body_already_json_decoded"
+
+	// RevokeLeasesPrefixes can be specified in the Data field of a Response
+	// to have Vault revoke every lease of the backend's mount whose ID starts
+	// with one of the given prefixes, relative to the mount, e.g.
+	// "creds/my-role/". The key is removed from the response once the leases
+	// are revoked. The value must be a slice of strings.
+	RevokeLeasesPrefixes = "revoke_leases_prefixes"
 )
 
 // Response is a struct that stores the response of a request.
@@ -104,6 +111,16 @@ func HelpResponse(text string, seeAlso []string, oapiDoc interface{}) *Response
 	}
 }
 
+// ResolveRoleResponse is used to format a response to a resolve role
+// operation, naming the role a login request is made against
+func ResolveRoleResponse(roleName string) (*Response, error) {
+	return &Response{
+		Data: map[string]interface{}{
+			"role": roleName,
+		},
+	}, nil
+}
+
 // ErrorResponse is used to format an error response
 func ErrorResponse(text string, vargs ...interface{}) *Response {
 	if len(vargs) > 0 {
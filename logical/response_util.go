@@ -109,6 +109,16 @@ func RespondErrorCommon(req *Request, resp *Response, err error) (int, error) {
 			statusCode = http.StatusBadRequest
 		case errwrap.Contains(err, ErrUpstreamRateLimited.Error()):
 			statusCode = http.StatusBadGateway
+		case errwrap.Contains(err, ErrLoginRateLimited.Error()):
+			statusCode = http.StatusTooManyRequests
+		case errwrap.Contains(err, ErrRenewRateLimited.Error()):
+			statusCode = http.StatusTooManyRequests
+		case errwrap.Contains(err, ErrRequestConcurrencyLimited.Error()):
+			statusCode = http.StatusTooManyRequests
+		case errwrap.Contains(err, ErrRateLimitQuotaExceeded.Error()):
+			statusCode = http.StatusTooManyRequests
+		case errwrap.Contains(err, ErrLeaseCountQuotaExceeded.Error()):
+			statusCode = http.StatusTooManyRequests
 		}
 	}
 
@@ -0,0 +1,273 @@
+package testing
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/helper/jsonutil"
+	"github.com/hashicorp/vault/logical"
+)
+
+// UpdateGoldenEnvVar can be set to a non-empty value to write the responses
+// of scenarios to their golden files instead of comparing against them.
+const UpdateGoldenEnvVar = "VAULT_UPDATE_GOLDEN"
+
+// redactedValue replaces the values of redacted fields in golden files.
+const redactedValue = "<redacted>"
+
+// DefaultRedactedFields are the response fields which are always redacted
+// since they differ on every run.
+var DefaultRedactedFields = []string{
+	"accessor",
+	"client_token",
+	"entity_id",
+	"lease_id",
+}
+
+// Scenario is a sequence of requests read from a fixture file, whose
+// responses are compared against a golden file.
+//
+// The fixture file is a JSON object holding a list of steps:
+//
+//	{
+//	  "steps": [
+//	    {"operation": "update", "path": "keys/foo", "data": {"type": "rsa-2048"}},
+//	    {"operation": "read", "path": "keys/foo"},
+//	    {"operation": "read", "path": "keys/bar", "error_ok": true}
+//	  ]
+//	}
+//
+// Paths are relative to the mount of the backend under test, as in TestStep.
+type Scenario struct {
+	// Fixture is the path of the fixture file.
+	Fixture string
+
+	// Golden is the path of the golden file. It defaults to the path of the
+	// fixture file with a ".golden" extension.
+	Golden string
+
+	// Redact lists additional response fields whose values differ on every
+	// run, e.g. generated keys or timestamps. Their values are replaced at any
+	// depth of the response before comparing, so only their presence is
+	// checked.
+	Redact []string
+}
+
+// scenarioFixture is the format of fixture files
+type scenarioFixture struct {
+	Steps []scenarioStep `json:"steps"`
+}
+
+type scenarioStep struct {
+	Operation       logical.Operation      `json:"operation"`
+	Path            string                 `json:"path"`
+	Data            map[string]interface{} `json:"data"`
+	ErrorOk         bool                   `json:"error_ok"`
+	Unauthenticated bool                   `json:"unauthenticated"`
+}
+
+// scenarioResponse is what is kept of a response in golden files. It has
+// the shape of an HTTP API response.
+type scenarioResponse struct {
+	LeaseID       string                 `json:"lease_id,omitempty"`
+	LeaseDuration int                    `json:"lease_duration,omitempty"`
+	Renewable     bool                   `json:"renewable,omitempty"`
+	Data          map[string]interface{} `json:"data,omitempty"`
+	Warnings      []string               `json:"warnings,omitempty"`
+	Auth          *scenarioAuth          `json:"auth,omitempty"`
+	Redirect      string                 `json:"redirect,omitempty"`
+}
+
+type scenarioAuth struct {
+	ClientToken   string            `json:"client_token"`
+	Accessor      string            `json:"accessor"`
+	Policies      []string          `json:"policies"`
+	TokenPolicies []string          `json:"token_policies"`
+	Metadata      map[string]string `json:"metadata"`
+	LeaseDuration int               `json:"lease_duration"`
+	Renewable     bool              `json:"renewable"`
+	EntityID      string            `json:"entity_id"`
+}
+
+// TestScenario runs the steps of the scenario's fixture file after the steps
+// of the test case, and fails the test if a response doesn't match the one
+// recorded in the golden file. When UpdateGoldenEnvVar is set, the golden
+// file is written instead.
+func TestScenario(tt TestT, c TestCase, s Scenario) {
+	golden := s.Golden
+	if golden == "" {
+		golden = strings.TrimSuffix(s.Fixture, filepath.Ext(s.Fixture)) + ".golden"
+	}
+	update := os.Getenv(UpdateGoldenEnvVar) != ""
+
+	fixtureBytes, err := ioutil.ReadFile(s.Fixture)
+	if err != nil {
+		tt.Fatal(fmt.Sprintf("error reading fixture: %s", err))
+		return
+	}
+	var fixture scenarioFixture
+	if err := jsonutil.DecodeJSON(fixtureBytes, &fixture); err != nil {
+		tt.Fatal(fmt.Sprintf("error parsing fixture %s: %s", s.Fixture, err))
+		return
+	}
+
+	var expected []interface{}
+	if !update {
+		goldenBytes, err := ioutil.ReadFile(golden)
+		if err != nil {
+			tt.Fatal(fmt.Sprintf("error reading golden file, set %s to create it: %s", UpdateGoldenEnvVar, err))
+			return
+		}
+		if err := json.Unmarshal(goldenBytes, &expected); err != nil {
+			tt.Fatal(fmt.Sprintf("error parsing golden file %s: %s", golden, err))
+			return
+		}
+		if len(expected) != len(fixture.Steps) {
+			tt.Fatal(fmt.Sprintf("golden file %s has %d responses for %d steps, set %s to update it", golden, len(expected), len(fixture.Steps), UpdateGoldenEnvVar))
+			return
+		}
+	}
+
+	redact := make(map[string]bool)
+	for _, field := range DefaultRedactedFields {
+		redact[field] = true
+	}
+	for _, field := range s.Redact {
+		redact[field] = true
+	}
+
+	actual := make([]interface{}, len(fixture.Steps))
+	steps := make([]TestStep, len(c.Steps), len(c.Steps)+len(fixture.Steps))
+	copy(steps, c.Steps)
+	for i, step := range fixture.Steps {
+		i := i
+		steps = append(steps, TestStep{
+			Operation:       step.Operation,
+			Path:            step.Path,
+			Data:            step.Data,
+			ErrorOk:         step.ErrorOk,
+			Unauthenticated: step.Unauthenticated,
+			Check: func(resp *logical.Response) error {
+				normalized, err := normalizeScenarioResponse(resp, redact)
+				if err != nil {
+					return err
+				}
+				actual[i] = normalized
+				if update || reflect.DeepEqual(normalized, expected[i]) {
+					return nil
+				}
+
+				want, _ := json.MarshalIndent(expected[i], "", "  ")
+				got, _ := json.MarshalIndent(normalized, "", "  ")
+				return fmt.Errorf("response to %s %s does not match %s\n\nexpected:\n%s\n\ngot:\n%s", step.Operation, step.Path, golden, want, got)
+			},
+		})
+	}
+	c.Steps = steps
+
+	Test(tt, c)
+
+	if update {
+		out := new(bytes.Buffer)
+		enc := json.NewEncoder(out)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(actual); err != nil {
+			tt.Fatal(fmt.Sprintf("error encoding responses: %s", err))
+			return
+		}
+		if err := ioutil.WriteFile(golden, out.Bytes(), 0644); err != nil {
+			tt.Fatal(fmt.Sprintf("error writing golden file: %s", err))
+		}
+	}
+}
+
+// TestScenarios runs TestScenario as a subtest for each fixture file matching
+// the glob pattern, e.g. "testdata/*.json". Each subtest mounts a new backend
+// from c.LogicalFactory or c.CredentialFactory, so those should be used
+// rather than a backend instance.
+func TestScenarios(t *testing.T, c TestCase, pattern string, redact ...string) {
+	fixtures, err := filepath.Glob(pattern)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fixtures) == 0 {
+		t.Fatalf("no fixture files match %q", pattern)
+	}
+
+	for _, fixture := range fixtures {
+		fixture := fixture
+		name := strings.TrimSuffix(filepath.Base(fixture), filepath.Ext(fixture))
+		t.Run(name, func(t *testing.T) {
+			TestScenario(t, c, Scenario{
+				Fixture: fixture,
+				Redact:  redact,
+			})
+		})
+	}
+}
+
+// normalizeScenarioResponse returns the parts of the response kept in golden
+// files as decoded JSON, with the values of redacted fields replaced.
+func normalizeScenarioResponse(resp *logical.Response, redact map[string]bool) (interface{}, error) {
+	var sr scenarioResponse
+	if resp != nil {
+		sr.Data = resp.Data
+		sr.Warnings = resp.Warnings
+		sr.Redirect = resp.Redirect
+		if resp.Secret != nil {
+			sr.LeaseID = resp.Secret.LeaseID
+			sr.LeaseDuration = int(resp.Secret.TTL.Seconds())
+			sr.Renewable = resp.Secret.Renewable
+		}
+		if resp.Auth != nil {
+			sr.Auth = &scenarioAuth{
+				ClientToken:   resp.Auth.ClientToken,
+				Accessor:      resp.Auth.Accessor,
+				Policies:      resp.Auth.Policies,
+				TokenPolicies: resp.Auth.TokenPolicies,
+				Metadata:      resp.Auth.Metadata,
+				LeaseDuration: int(resp.Auth.TTL.Seconds()),
+				Renewable:     resp.Auth.Renewable,
+				EntityID:      resp.Auth.EntityID,
+			}
+		}
+	}
+
+	encoded, err := json.Marshal(sr)
+	if err != nil {
+		return nil, errwrap.Wrapf("error encoding response: {{err}}", err)
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		return nil, errwrap.Wrapf("error decoding response: {{err}}", err)
+	}
+
+	return redactFields(decoded, redact), nil
+}
+
+func redactFields(v interface{}, redact map[string]bool) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, inner := range v {
+			if redact[k] && inner != nil && inner != "" {
+				v[k] = redactedValue
+				continue
+			}
+			v[k] = redactFields(inner, redact)
+		}
+	case []interface{}:
+		for i, inner := range v {
+			v[i] = redactFields(inner, redact)
+		}
+	}
+	return v
+}
@@ -0,0 +1,134 @@
+package testing
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	uuid "github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+// scenarioTestFactory returns a backend storing values, which returns a
+// random nonce along with them
+func scenarioTestFactory(ctx context.Context, conf *logical.BackendConfig) (logical.Backend, error) {
+	b := &framework.Backend{
+		BackendType: logical.TypeLogical,
+		Paths: []*framework.Path{
+			&framework.Path{
+				Pattern: "values/" + framework.GenericNameRegex("name"),
+				Fields: map[string]*framework.FieldSchema{
+					"name":  &framework.FieldSchema{Type: framework.TypeString},
+					"value": &framework.FieldSchema{Type: framework.TypeString},
+				},
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.UpdateOperation: func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+						return nil, req.Storage.Put(ctx, &logical.StorageEntry{
+							Key:   d.Get("name").(string),
+							Value: []byte(d.Get("value").(string)),
+						})
+					},
+					logical.ReadOperation: func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+						entry, err := req.Storage.Get(ctx, d.Get("name").(string))
+						if err != nil {
+							return nil, err
+						}
+						if entry == nil {
+							return logical.ErrorResponse("value not found"), nil
+						}
+						nonce, err := uuid.GenerateUUID()
+						if err != nil {
+							return nil, err
+						}
+						return &logical.Response{
+							Data: map[string]interface{}{
+								"value": string(entry.Value),
+								"nonce": nonce,
+							},
+						}, nil
+					},
+				},
+			},
+		},
+	}
+	if err := b.Setup(ctx, conf); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func TestTestScenarios(t *testing.T) {
+	TestScenarios(t, TestCase{
+		LogicalFactory: scenarioTestFactory,
+	}, "testdata/*.json", "nonce")
+}
+
+func TestTestScenario_mismatch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "vault-scenario")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	golden, err := ioutil.ReadFile("testdata/values.golden")
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden = []byte(strings.Replace(string(golden), `"bar"`, `"baz"`, 1))
+	goldenPath := filepath.Join(dir, "values.golden")
+	if err := ioutil.WriteFile(goldenPath, golden, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	mt := new(mockT)
+	TestScenario(mt, TestCase{
+		LogicalFactory: scenarioTestFactory,
+	}, Scenario{
+		Fixture: "testdata/values.json",
+		Golden:  goldenPath,
+		Redact:  []string{"nonce"},
+	})
+	if !mt.failed() || !strings.Contains(mt.failMessage(), "does not match") {
+		t.Fatalf("expected mismatch, got: %v", mt.failMessage())
+	}
+}
+
+func TestTestScenario_update(t *testing.T) {
+	dir, err := ioutil.TempDir("", "vault-scenario")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	os.Setenv(UpdateGoldenEnvVar, "1")
+	defer os.Unsetenv(UpdateGoldenEnvVar)
+
+	goldenPath := filepath.Join(dir, "values.golden")
+	mt := new(mockT)
+	TestScenario(mt, TestCase{
+		LogicalFactory: scenarioTestFactory,
+	}, Scenario{
+		Fixture: "testdata/values.json",
+		Golden:  goldenPath,
+		Redact:  []string{"nonce"},
+	})
+	if mt.failed() {
+		t.Fatal(mt.failMessage())
+	}
+
+	actual, err := ioutil.ReadFile(goldenPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := ioutil.ReadFile("testdata/values.golden")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(actual) != string(expected) {
+		t.Fatalf("bad: golden file:\n%s", actual)
+	}
+}
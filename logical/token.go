@@ -107,6 +107,9 @@ type TokenEntry struct {
 	// The set of CIDRs that this token can be used with
 	BoundCIDRs []*sockaddr.SockAddrMarshaler `json:"bound_cidrs"`
 
+	// The time window during which this token can be used
+	BoundTimeWindow *TokenTimeWindow `json:"bound_time_window,omitempty"`
+
 	// NamespaceID is the identifier of the namespace to which this token is
 	// confined to. Do not return this value over the API when the token is
 	// being looked up.
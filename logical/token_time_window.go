@@ -0,0 +1,151 @@
+package logical
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tokenTimeZones caches the locations of the time zones of the windows by
+// name, so that they aren't loaded from the time zone database on every
+// request. Only valid time zones are cached, so it is bounded by the size of
+// the database.
+var tokenTimeZones sync.Map
+
+// loadTokenTimeZone returns the location of the time zone
+func loadTokenTimeZone(name string) (*time.Location, error) {
+	if loc, ok := tokenTimeZones.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	tokenTimeZones.Store(name, loc)
+	return loc, nil
+}
+
+// tokenWeekdays maps the day names accepted by bound_weekdays to days
+var tokenWeekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// TokenTimeWindow is the time of day, and optionally the days of the week,
+// during which the tokens generated using a role can be used
+type TokenTimeWindow struct {
+	// Start and End are the bounds of the window, in minutes since midnight.
+	// The window spans midnight if End is before Start.
+	Start int `json:"start"`
+	End   int `json:"end"`
+
+	// Weekdays are the days on which the window opens, every day if empty
+	Weekdays []time.Weekday `json:"weekdays,omitempty"`
+
+	// TimeZone is the location the window is given in
+	TimeZone string `json:"time_zone"`
+}
+
+// ParseTokenTimeWindow parses a window given as "HH:MM-HH:MM", the names of
+// the days on which it opens and its time zone, UTC if empty.
+func ParseTokenTimeWindow(window string, weekdays []string, timeZone string) (*TokenTimeWindow, error) {
+	bounds := strings.Split(window, "-")
+	if len(bounds) != 2 {
+		return nil, fmt.Errorf("time window %q must be given as HH:MM-HH:MM", window)
+	}
+
+	w := &TokenTimeWindow{
+		TimeZone: timeZone,
+	}
+	for i, bound := range bounds {
+		t, err := time.Parse("15:04", strings.TrimSpace(bound))
+		if err != nil {
+			return nil, fmt.Errorf("time window %q must be given as HH:MM-HH:MM", window)
+		}
+		minutes := t.Hour()*60 + t.Minute()
+		if i == 0 {
+			w.Start = minutes
+		} else {
+			w.End = minutes
+		}
+	}
+	if w.Start == w.End {
+		return nil, fmt.Errorf("time window %q is empty", window)
+	}
+
+	for _, name := range weekdays {
+		day, ok := tokenWeekdays[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			return nil, fmt.Errorf("invalid weekday %q, must be one of sun, mon, tue, wed, thu, fri or sat", name)
+		}
+		w.Weekdays = append(w.Weekdays, day)
+	}
+
+	if w.TimeZone == "" {
+		w.TimeZone = "UTC"
+	}
+	if _, err := loadTokenTimeZone(w.TimeZone); err != nil {
+		return nil, fmt.Errorf("invalid time zone %q: %v", w.TimeZone, err)
+	}
+
+	return w, nil
+}
+
+// String returns the window as "HH:MM-HH:MM"
+func (w *TokenTimeWindow) String() string {
+	return fmt.Sprintf("%02d:%02d-%02d:%02d", w.Start/60, w.Start%60, w.End/60, w.End%60)
+}
+
+// WeekdayNames returns the names of the days on which the window opens
+func (w *TokenTimeWindow) WeekdayNames() []string {
+	names := make([]string, 0, len(w.Weekdays))
+	for _, day := range w.Weekdays {
+		names = append(names, strings.ToLower(day.String()[:3]))
+	}
+	return names
+}
+
+// Contains reports whether the window is open at the given time. A window
+// spanning midnight belongs to the day on which it opened, so a "22:00-06:00"
+// window on fridays is still open early on saturday.
+func (w *TokenTimeWindow) Contains(t time.Time) bool {
+	loc, err := loadTokenTimeZone(w.TimeZone)
+	if err != nil {
+		// The time zone has been validated when the window was parsed, so
+		// this can only fail if the time zone database changed since
+		return false
+	}
+	t = t.In(loc)
+
+	minutes := t.Hour()*60 + t.Minute()
+	day := t.Weekday()
+
+	switch {
+	case w.Start < w.End:
+		if minutes < w.Start || minutes >= w.End {
+			return false
+		}
+	case minutes >= w.Start:
+	case minutes < w.End:
+		day = (day + 6) % 7
+	default:
+		return false
+	}
+
+	if len(w.Weekdays) == 0 {
+		return true
+	}
+	for _, d := range w.Weekdays {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
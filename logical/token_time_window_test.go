@@ -0,0 +1,78 @@
+package logical
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTokenTimeWindow(t *testing.T) {
+	for _, c := range []struct {
+		window, timeZone string
+		weekdays         []string
+		valid            bool
+	}{
+		{"09:00-17:00", "", nil, true},
+		{"9:00-17:30", "America/New_York", []string{"mon", "Fri"}, true},
+		{"22:00-06:00", "", []string{"sat"}, true},
+		{"09:00", "", nil, false},
+		{"09:00-25:00", "", nil, false},
+		{"09:00-09:00", "", nil, false},
+		{"09:00-17:00", "", []string{"monday"}, false},
+		{"09:00-17:00", "Mars/Olympus_Mons", nil, false},
+	} {
+		_, err := ParseTokenTimeWindow(c.window, c.weekdays, c.timeZone)
+		if c.valid != (err == nil) {
+			t.Fatalf("bad: %#v: err: %v", c, err)
+		}
+	}
+
+	// 2019-05-03 was a friday
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2019, time.May, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	w, err := ParseTokenTimeWindow("09:00-17:00", []string{"mon", "tue", "wed", "thu", "fri"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.String() != "09:00-17:00" || w.TimeZone != "UTC" {
+		t.Fatalf("bad: %#v", w)
+	}
+	for tm, expected := range map[time.Time]bool{
+		at(3, 9, 0):   true,
+		at(3, 16, 59): true,
+		at(3, 17, 0):  false,
+		at(3, 8, 59):  false,
+		at(4, 12, 0):  false,
+	} {
+		if w.Contains(tm) != expected {
+			t.Fatalf("bad: %s: expected %t", tm, expected)
+		}
+	}
+
+	// A window spanning midnight belongs to the day it opened
+	w, err = ParseTokenTimeWindow("22:00-06:00", []string{"fri"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for tm, expected := range map[time.Time]bool{
+		at(3, 23, 0): true,
+		at(4, 5, 0):  true,
+		at(3, 5, 0):  false,
+		at(4, 23, 0): false,
+		at(4, 12, 0): false,
+	} {
+		if w.Contains(tm) != expected {
+			t.Fatalf("bad: %s: expected %t", tm, expected)
+		}
+	}
+
+	// The window is evaluated in its time zone
+	w, err = ParseTokenTimeWindow("09:00-17:00", nil, "America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.Contains(at(3, 12, 0)) || !w.Contains(at(3, 14, 0)) {
+		t.Fatal("expected the window to be evaluated in its time zone")
+	}
+}
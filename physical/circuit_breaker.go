@@ -0,0 +1,193 @@
+package physical
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	metrics "github.com/armon/go-metrics"
+	log "github.com/hashicorp/go-hclog"
+)
+
+const (
+	// DefaultCircuitBreakerResetTimeout is the time a circuit breaker stays
+	// open if no reset timeout is specified
+	DefaultCircuitBreakerResetTimeout = 30 * time.Second
+)
+
+// ErrCircuitBreakerOpen is returned for the physical requests rejected while
+// the circuit breaker is open
+var ErrCircuitBreakerOpen = errors.New("physical backend is unavailable: circuit breaker is open")
+
+type circuitBreakerState int
+
+const (
+	circuitBreakerClosed circuitBreakerState = iota
+	circuitBreakerOpen
+	circuitBreakerHalfOpen
+)
+
+// CircuitBreaker is used to stop sending requests to a failing physical
+// backend. Once the number of consecutive failed requests reaches the
+// threshold, the breaker opens and requests fail immediately for the reset
+// timeout. A single trial request is then let through: the breaker closes
+// if it succeeds, and opens again otherwise.
+type CircuitBreaker struct {
+	backend      Backend
+	threshold    int
+	resetTimeout time.Duration
+	logger       log.Logger
+
+	l        sync.Mutex
+	state    circuitBreakerState
+	failures int
+	openedAt time.Time
+}
+
+// TransactionalCircuitBreaker is the transactional version of the circuit
+// breaker
+type TransactionalCircuitBreaker struct {
+	*CircuitBreaker
+	Transactional
+}
+
+// Verify CircuitBreaker satisfies the correct interfaces
+var _ Backend = (*CircuitBreaker)(nil)
+var _ Transactional = (*TransactionalCircuitBreaker)(nil)
+
+// NewCircuitBreaker returns a wrapped physical backend which rejects requests
+// while the underlying backend is failing
+func NewCircuitBreaker(b Backend, threshold int, resetTimeout time.Duration, logger log.Logger) *CircuitBreaker {
+	if threshold < 1 {
+		threshold = 1
+	}
+	if resetTimeout <= 0 {
+		resetTimeout = DefaultCircuitBreakerResetTimeout
+	}
+	logger.Info("creating circuit breaker", "threshold", threshold, "reset_timeout", resetTimeout)
+
+	return &CircuitBreaker{
+		backend:      b,
+		threshold:    threshold,
+		resetTimeout: resetTimeout,
+		logger:       logger,
+	}
+}
+
+// NewTransactionalCircuitBreaker creates a new transactional CircuitBreaker
+func NewTransactionalCircuitBreaker(b Backend, threshold int, resetTimeout time.Duration, logger log.Logger) *TransactionalCircuitBreaker {
+	return &TransactionalCircuitBreaker{
+		CircuitBreaker: NewCircuitBreaker(b, threshold, resetTimeout, logger),
+		Transactional:  b.(Transactional),
+	}
+}
+
+// allow returns whether a request can be sent to the backend
+func (c *CircuitBreaker) allow() bool {
+	c.l.Lock()
+	defer c.l.Unlock()
+
+	switch c.state {
+	case circuitBreakerOpen:
+		if time.Since(c.openedAt) < c.resetTimeout {
+			return false
+		}
+		c.logger.Info("circuit breaker is half-open, sending a trial request")
+		c.state = circuitBreakerHalfOpen
+		return true
+	case circuitBreakerHalfOpen:
+		// Only the trial request is let through
+		return false
+	default:
+		return true
+	}
+}
+
+// record updates the state of the breaker with the outcome of a request
+func (c *CircuitBreaker) record(ctx context.Context, err error) {
+	c.l.Lock()
+	defer c.l.Unlock()
+
+	// Requests canceled by the caller don't tell anything about the health
+	// of the backend. A canceled trial request is retried by the next one.
+	if err != nil && ctx.Err() == context.Canceled {
+		if c.state == circuitBreakerHalfOpen {
+			c.state = circuitBreakerOpen
+		}
+		return
+	}
+
+	if err == nil {
+		if c.state != circuitBreakerClosed {
+			c.logger.Info("circuit breaker closed, physical backend has recovered")
+		}
+		c.state = circuitBreakerClosed
+		c.failures = 0
+		return
+	}
+
+	c.failures++
+	if c.state == circuitBreakerHalfOpen || (c.state == circuitBreakerClosed && c.failures >= c.threshold) {
+		if c.state == circuitBreakerClosed {
+			metrics.IncrCounter([]string{"physical", "circuit_breaker", "open"}, 1)
+		}
+		c.logger.Error("circuit breaker opened, physical backend is failing", "consecutive_failures", c.failures, "error", err)
+		c.state = circuitBreakerOpen
+		c.openedAt = time.Now()
+	}
+}
+
+func (c *CircuitBreaker) do(ctx context.Context, op string, f func() error) error {
+	if !c.allow() {
+		metrics.IncrCounter([]string{"physical", op, "circuit_breaker_rejected"}, 1)
+		return ErrCircuitBreakerOpen
+	}
+
+	err := f()
+	c.record(ctx, err)
+	return err
+}
+
+// Put is a put request going through the circuit breaker
+func (c *CircuitBreaker) Put(ctx context.Context, entry *Entry) error {
+	return c.do(ctx, "put", func() error {
+		return c.backend.Put(ctx, entry)
+	})
+}
+
+// Get is a get request going through the circuit breaker
+func (c *CircuitBreaker) Get(ctx context.Context, key string) (*Entry, error) {
+	var entry *Entry
+	err := c.do(ctx, "get", func() error {
+		var err error
+		entry, err = c.backend.Get(ctx, key)
+		return err
+	})
+	return entry, err
+}
+
+// Delete is a delete request going through the circuit breaker
+func (c *CircuitBreaker) Delete(ctx context.Context, key string) error {
+	return c.do(ctx, "delete", func() error {
+		return c.backend.Delete(ctx, key)
+	})
+}
+
+// List is a list request going through the circuit breaker
+func (c *CircuitBreaker) List(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	err := c.do(ctx, "list", func() error {
+		var err error
+		keys, err = c.backend.List(ctx, prefix)
+		return err
+	})
+	return keys, err
+}
+
+// Transaction is a transaction request going through the circuit breaker
+func (c *TransactionalCircuitBreaker) Transaction(ctx context.Context, txns []*TxnEntry) error {
+	return c.do(ctx, "transaction", func() error {
+		return c.Transactional.Transaction(ctx, txns)
+	})
+}
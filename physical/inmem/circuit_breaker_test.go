@@ -0,0 +1,93 @@
+package inmem
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/helper/logging"
+	"github.com/hashicorp/vault/physical"
+)
+
+func TestTimeoutBackend(t *testing.T) {
+	logger := logging.NewVaultLogger(log.Debug)
+
+	inm, err := NewTransactionalInmem(nil, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	timeout := physical.NewTransactionalTimeoutBackend(inm, time.Second, logger)
+	physical.ExerciseBackend(t, timeout)
+	physical.ExerciseBackend_ListPrefix(t, timeout)
+	physical.ExerciseTransactionalBackend(t, timeout)
+
+	// Requests to a hung backend time out
+	latent := physical.NewLatencyInjector(inm, time.Second, 0, logger)
+	timeout = physical.NewTransactionalTimeoutBackend(physical.NewTransactionalLatencyInjector(inm, time.Second, 0, logger), 10*time.Millisecond, logger)
+	start := time.Now()
+	if _, err := timeout.Get(context.Background(), "foo"); err != physical.ErrRequestTimeout {
+		t.Fatalf("expected a timeout: %v", err)
+	}
+	if time.Since(start) >= time.Second {
+		t.Fatal("expected the request not to wait for the backend")
+	}
+	if err := physical.NewTimeoutBackend(latent, 10*time.Millisecond, logger).Put(context.Background(), &physical.Entry{Key: "foo"}); err != physical.ErrRequestTimeout {
+		t.Fatalf("expected a timeout: %v", err)
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	logger := logging.NewVaultLogger(log.Debug)
+
+	inm, err := NewInmem(nil, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	breaker := physical.NewCircuitBreaker(inm, 3, time.Hour, logger)
+	physical.ExerciseBackend(t, breaker)
+	physical.ExerciseBackend_ListPrefix(t, breaker)
+
+	failing := physical.NewErrorInjector(inm, 100, logger)
+	breaker = physical.NewCircuitBreaker(failing, 3, 50*time.Millisecond, logger)
+	ctx := context.Background()
+
+	// The breaker opens after consecutive failures
+	for i := 0; i < 3; i++ {
+		if _, err := breaker.Get(ctx, "foo"); err == nil || err == physical.ErrCircuitBreakerOpen {
+			t.Fatalf("expected the backend error: %v", err)
+		}
+	}
+	if _, err := breaker.Get(ctx, "foo"); err != physical.ErrCircuitBreakerOpen {
+		t.Fatalf("expected the circuit breaker to be open: %v", err)
+	}
+
+	// A failed trial request opens it again
+	time.Sleep(50 * time.Millisecond)
+	if _, err := breaker.Get(ctx, "foo"); err == nil || err == physical.ErrCircuitBreakerOpen {
+		t.Fatalf("expected the backend error: %v", err)
+	}
+	if _, err := breaker.Get(ctx, "foo"); err != physical.ErrCircuitBreakerOpen {
+		t.Fatalf("expected the circuit breaker to be open: %v", err)
+	}
+
+	// and a successful one closes it
+	failing.SetErrorPercentage(0)
+	time.Sleep(50 * time.Millisecond)
+	for i := 0; i < 2; i++ {
+		if _, err := breaker.Get(ctx, "foo"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Requests canceled by the caller aren't failures
+	failing.SetErrorPercentage(100)
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	for i := 0; i < 3; i++ {
+		breaker.Get(canceled, "foo")
+	}
+	if _, err := breaker.Get(ctx, "foo"); err == physical.ErrCircuitBreakerOpen {
+		t.Fatal("expected the circuit breaker to be closed")
+	}
+}
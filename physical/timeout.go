@@ -0,0 +1,130 @@
+package physical
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	metrics "github.com/armon/go-metrics"
+	log "github.com/hashicorp/go-hclog"
+)
+
+// ErrRequestTimeout is returned when a physical request doesn't complete
+// within the request timeout. The outcome of a write that timed out is
+// unknown: it may still be applied by the underlying backend.
+var ErrRequestTimeout = errors.New("physical request timed out")
+
+// TimeoutBackend is used to bound the duration of the underlying physical
+// requests, so that a hung backend can't block its callers indefinitely
+type TimeoutBackend struct {
+	backend Backend
+	timeout time.Duration
+	logger  log.Logger
+}
+
+// TransactionalTimeoutBackend is the transactional version of the timeout
+// backend
+type TransactionalTimeoutBackend struct {
+	*TimeoutBackend
+	Transactional
+}
+
+// Verify TimeoutBackend satisfies the correct interfaces
+var _ Backend = (*TimeoutBackend)(nil)
+var _ Transactional = (*TransactionalTimeoutBackend)(nil)
+
+// NewTimeoutBackend returns a wrapped physical backend whose requests fail
+// with ErrRequestTimeout after the given timeout
+func NewTimeoutBackend(b Backend, timeout time.Duration, logger log.Logger) *TimeoutBackend {
+	logger.Info("creating request timeout backend", "timeout", timeout)
+
+	return &TimeoutBackend{
+		backend: b,
+		timeout: timeout,
+		logger:  logger,
+	}
+}
+
+// NewTransactionalTimeoutBackend creates a new transactional TimeoutBackend
+func NewTransactionalTimeoutBackend(b Backend, timeout time.Duration, logger log.Logger) *TransactionalTimeoutBackend {
+	return &TransactionalTimeoutBackend{
+		TimeoutBackend: NewTimeoutBackend(b, timeout, logger),
+		Transactional:  b.(Transactional),
+	}
+}
+
+// do runs the request with a context canceled after the timeout. Backends
+// that don't honor the context keep running the request in the background,
+// but the caller no longer waits for it.
+func (t *TimeoutBackend) do(ctx context.Context, op string, f func(context.Context) error) error {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+
+	// Buffered so that a request completing after the timeout doesn't leak
+	// the goroutine
+	doneCh := make(chan error, 1)
+	go func() {
+		doneCh <- f(ctx)
+	}()
+
+	select {
+	case err := <-doneCh:
+		return err
+	case <-ctx.Done():
+		if ctx.Err() != context.DeadlineExceeded {
+			return ctx.Err()
+		}
+		metrics.IncrCounter([]string{"physical", op, "timeout"}, 1)
+		t.logger.Warn("physical request timed out", "operation", op, "timeout", t.timeout)
+		return ErrRequestTimeout
+	}
+}
+
+// Put is a put request bounded by the timeout
+func (t *TimeoutBackend) Put(ctx context.Context, entry *Entry) error {
+	return t.do(ctx, "put", func(ctx context.Context) error {
+		return t.backend.Put(ctx, entry)
+	})
+}
+
+// Get is a get request bounded by the timeout
+func (t *TimeoutBackend) Get(ctx context.Context, key string) (*Entry, error) {
+	var entry *Entry
+	err := t.do(ctx, "get", func(ctx context.Context) error {
+		var err error
+		entry, err = t.backend.Get(ctx, key)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// Delete is a delete request bounded by the timeout
+func (t *TimeoutBackend) Delete(ctx context.Context, key string) error {
+	return t.do(ctx, "delete", func(ctx context.Context) error {
+		return t.backend.Delete(ctx, key)
+	})
+}
+
+// List is a list request bounded by the timeout
+func (t *TimeoutBackend) List(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	err := t.do(ctx, "list", func(ctx context.Context) error {
+		var err error
+		keys, err = t.backend.List(ctx, prefix)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// Transaction is a transaction request bounded by the timeout
+func (t *TransactionalTimeoutBackend) Transaction(ctx context.Context, txns []*TxnEntry) error {
+	return t.do(ctx, "transaction", func(ctx context.Context) error {
+		return t.Transactional.Transaction(ctx, txns)
+	})
+}
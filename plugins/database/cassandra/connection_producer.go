@@ -36,6 +36,9 @@ type cassandraConnectionProducer struct {
 	PemBundle          string      `json:"pem_bundle" structs:"pem_bundle" mapstructure:"pem_bundle"`
 	PemJSON            string      `json:"pem_json" structs:"pem_json" mapstructure:"pem_json"`
 
+	// MaxOpenConnections is the number of connections opened to each host
+	connutil.PoolSettings `mapstructure:",squash"`
+
 	connectTimeout  time.Duration
 	socketKeepAlive time.Duration
 	certificate     string
@@ -81,6 +84,11 @@ func (c *cassandraConnectionProducer) Init(ctx context.Context, conf map[string]
 		return nil, errwrap.Wrapf("invalid socket_keep_alive: {{err}}", err)
 	}
 
+	err = c.ParsePoolSettings(0, connutil.PoolMaxOpenConnections)
+	if err != nil {
+		return nil, err
+	}
+
 	switch {
 	case len(c.Hosts) == 0:
 		return nil, fmt.Errorf("hosts cannot be empty")
@@ -189,6 +197,9 @@ func (c *cassandraConnectionProducer) createSession() (*gocql.Session, error) {
 
 	clusterConfig.Timeout = c.connectTimeout
 	clusterConfig.SocketKeepalive = c.socketKeepAlive
+	if c.MaxOpenConnections > 0 {
+		clusterConfig.NumConns = c.MaxOpenConnections
+	}
 	if c.TLS {
 		var tlsConfig *tls.Config
 		if len(c.certificate) > 0 || len(c.issuingCA) > 0 {
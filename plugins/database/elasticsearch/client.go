@@ -0,0 +1,218 @@
+package elasticsearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+)
+
+// securityAPI is the subset of the security API of the cluster used by the
+// plugin. Elasticsearch exposes it through X-Pack security, and OpenSearch
+// through its security plugin.
+type securityAPI interface {
+	// authenticate checks that the configured credentials are accepted
+	authenticate(ctx context.Context) error
+
+	// createRole creates or replaces a role with the given definition
+	createRole(ctx context.Context, name string, definition json.RawMessage) error
+
+	// deleteRole deletes a role, ignoring roles that don't exist
+	deleteRole(ctx context.Context, name string) error
+
+	// createUser creates a user with the given password and roles
+	createUser(ctx context.Context, name, password string, roles []string) error
+
+	// deleteUser deletes a user, ignoring users that don't exist
+	deleteUser(ctx context.Context, name string) error
+
+	// changePassword changes the password of the configured user
+	changePassword(ctx context.Context, password string) error
+}
+
+// client sends requests to the REST API of the cluster
+type client struct {
+	baseURL    string
+	username   string
+	password   string
+	httpClient *http.Client
+}
+
+// apiError is an error response of the cluster
+type apiError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("%d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+func isNotFound(err error) bool {
+	apiErr, ok := err.(*apiError)
+	return ok && apiErr.StatusCode == http.StatusNotFound
+}
+
+// do sends a request with the given JSON body, and returns an *apiError if
+// the response doesn't have a 2xx status code
+func (c *client) do(ctx context.Context, method, path string, body interface{}) error {
+	var reqBody io.Reader
+	if body != nil {
+		buf, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(buf)
+	}
+
+	req, err := http.NewRequest(method, c.baseURL+path, reqBody)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.SetBasicAuth(c.username, c.password)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &apiError{
+			StatusCode: resp.StatusCode,
+			Message:    errorMessage(respBody),
+		}
+	}
+
+	return nil
+}
+
+// errorMessage extracts the reason of an error from the body of a response,
+// in the formats used by Elasticsearch and by the OpenSearch security plugin
+func errorMessage(body []byte) string {
+	var resp struct {
+		Error   json.RawMessage `json:"error"`
+		Message string          `json:"message"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return string(body)
+	}
+	if resp.Message != "" {
+		return resp.Message
+	}
+
+	var errObj struct {
+		Reason string `json:"reason"`
+	}
+	if err := json.Unmarshal(resp.Error, &errObj); err == nil && errObj.Reason != "" {
+		return errObj.Reason
+	}
+	var errStr string
+	if err := json.Unmarshal(resp.Error, &errStr); err == nil && errStr != "" {
+		return errStr
+	}
+
+	return string(body)
+}
+
+// xpackAPI implements securityAPI with the X-Pack security API of
+// Elasticsearch
+type xpackAPI struct {
+	*client
+}
+
+func (a *xpackAPI) authenticate(ctx context.Context) error {
+	return a.do(ctx, http.MethodGet, "/_security/_authenticate", nil)
+}
+
+func (a *xpackAPI) createRole(ctx context.Context, name string, definition json.RawMessage) error {
+	return a.do(ctx, http.MethodPut, "/_security/role/"+url.PathEscape(name), definition)
+}
+
+func (a *xpackAPI) deleteRole(ctx context.Context, name string) error {
+	err := a.do(ctx, http.MethodDelete, "/_security/role/"+url.PathEscape(name), nil)
+	if isNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+func (a *xpackAPI) createUser(ctx context.Context, name, password string, roles []string) error {
+	return a.do(ctx, http.MethodPut, "/_security/user/"+url.PathEscape(name), map[string]interface{}{
+		"password": password,
+		"roles":    roles,
+	})
+}
+
+func (a *xpackAPI) deleteUser(ctx context.Context, name string) error {
+	err := a.do(ctx, http.MethodDelete, "/_security/user/"+url.PathEscape(name), nil)
+	if isNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+func (a *xpackAPI) changePassword(ctx context.Context, password string) error {
+	return a.do(ctx, http.MethodPost, "/_security/user/"+url.PathEscape(a.username)+"/_password", map[string]interface{}{
+		"password": password,
+	})
+}
+
+// openSearchAPI implements securityAPI with the REST API of the OpenSearch
+// security plugin
+type openSearchAPI struct {
+	*client
+}
+
+func (a *openSearchAPI) authenticate(ctx context.Context) error {
+	return a.do(ctx, http.MethodGet, "/_plugins/_security/authinfo", nil)
+}
+
+func (a *openSearchAPI) createRole(ctx context.Context, name string, definition json.RawMessage) error {
+	return a.do(ctx, http.MethodPut, "/_plugins/_security/api/roles/"+url.PathEscape(name), definition)
+}
+
+func (a *openSearchAPI) deleteRole(ctx context.Context, name string) error {
+	err := a.do(ctx, http.MethodDelete, "/_plugins/_security/api/roles/"+url.PathEscape(name), nil)
+	if isNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+func (a *openSearchAPI) createUser(ctx context.Context, name, password string, roles []string) error {
+	return a.do(ctx, http.MethodPut, "/_plugins/_security/api/internalusers/"+url.PathEscape(name), map[string]interface{}{
+		"password":                  password,
+		"opendistro_security_roles": roles,
+	})
+}
+
+func (a *openSearchAPI) deleteUser(ctx context.Context, name string) error {
+	err := a.do(ctx, http.MethodDelete, "/_plugins/_security/api/internalusers/"+url.PathEscape(name), nil)
+	if isNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// changePassword uses the account API, which changes the password of the
+// authenticated user given its current one
+func (a *openSearchAPI) changePassword(ctx context.Context, password string) error {
+	return a.do(ctx, http.MethodPut, "/_plugins/_security/api/account", map[string]interface{}{
+		"current_password": a.password,
+		"password":         password,
+	})
+}
@@ -0,0 +1,206 @@
+package elasticsearch
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	cleanhttp "github.com/hashicorp/go-cleanhttp"
+	"github.com/hashicorp/vault/helper/parseutil"
+	"github.com/hashicorp/vault/plugins/helper/database/connutil"
+	"github.com/mitchellh/mapstructure"
+)
+
+const (
+	distributionElasticsearch = "elasticsearch"
+	distributionOpenSearch    = "opensearch"
+)
+
+// elasticsearchConnectionProducer implements ConnectionProducer and provides
+// an interface for Elasticsearch and OpenSearch clusters to make connections.
+type elasticsearchConnectionProducer struct {
+	URL               string      `json:"url" structs:"url" mapstructure:"url"`
+	Username          string      `json:"username" structs:"username" mapstructure:"username"`
+	Password          string      `json:"password" structs:"password" mapstructure:"password"`
+	Distribution      string      `json:"distribution" structs:"distribution" mapstructure:"distribution"`
+	CACert            string      `json:"ca_cert" structs:"ca_cert" mapstructure:"ca_cert"`
+	ClientCert        string      `json:"client_cert" structs:"client_cert" mapstructure:"client_cert"`
+	ClientKey         string      `json:"client_key" structs:"client_key" mapstructure:"client_key"`
+	TLSServerName     string      `json:"tls_server_name" structs:"tls_server_name" mapstructure:"tls_server_name"`
+	InsecureTLS       bool        `json:"insecure_tls" structs:"insecure_tls" mapstructure:"insecure_tls"`
+	ConnectTimeoutRaw interface{} `json:"connect_timeout" structs:"connect_timeout" mapstructure:"connect_timeout"`
+
+	// The pool settings limit the connections of the HTTP transport
+	connutil.PoolSettings `mapstructure:",squash"`
+
+	connectTimeout time.Duration
+	rawConfig      map[string]interface{}
+
+	Initialized bool
+	Type        string
+	client      securityAPI
+	sync.Mutex
+}
+
+func (e *elasticsearchConnectionProducer) Initialize(ctx context.Context, conf map[string]interface{}, verifyConnection bool) error {
+	_, err := e.Init(ctx, conf, verifyConnection)
+	return err
+}
+
+func (e *elasticsearchConnectionProducer) Init(ctx context.Context, conf map[string]interface{}, verifyConnection bool) (map[string]interface{}, error) {
+	e.Lock()
+	defer e.Unlock()
+
+	e.rawConfig = conf
+
+	err := mapstructure.WeakDecode(conf, e)
+	if err != nil {
+		return nil, err
+	}
+
+	if e.ConnectTimeoutRaw == nil {
+		e.ConnectTimeoutRaw = "5s"
+	}
+	e.connectTimeout, err = parseutil.ParseDurationSecond(e.ConnectTimeoutRaw)
+	if err != nil {
+		return nil, errwrap.Wrapf("invalid connect_timeout: {{err}}", err)
+	}
+
+	err = e.ParsePoolSettings(0, connutil.PoolMaxOpenConnections, connutil.PoolMaxIdleConnections)
+	if err != nil {
+		return nil, err
+	}
+
+	if e.Distribution == "" {
+		e.Distribution = distributionElasticsearch
+	}
+
+	switch {
+	case len(e.URL) == 0:
+		return nil, fmt.Errorf("url cannot be empty")
+	case len(e.Username) == 0:
+		return nil, fmt.Errorf("username cannot be empty")
+	case len(e.Password) == 0:
+		return nil, fmt.Errorf("password cannot be empty")
+	case e.Distribution != distributionElasticsearch && e.Distribution != distributionOpenSearch:
+		return nil, fmt.Errorf("distribution must be %q or %q", distributionElasticsearch, distributionOpenSearch)
+	case (len(e.ClientCert) == 0) != (len(e.ClientKey) == 0):
+		return nil, fmt.Errorf("client_cert and client_key must be set together")
+	}
+
+	if _, err := url.Parse(e.URL); err != nil {
+		return nil, errwrap.Wrapf("invalid url: {{err}}", err)
+	}
+
+	// Drop the client of a previous configuration
+	e.client = nil
+
+	// Set initialized to true at this point since all fields are set,
+	// and the connection can be established at a later time.
+	e.Initialized = true
+
+	if verifyConnection {
+		client, err := e.Connection(ctx)
+		if err != nil {
+			return nil, errwrap.Wrapf("error verifying connection: {{err}}", err)
+		}
+		if err := client.(securityAPI).authenticate(ctx); err != nil {
+			return nil, errwrap.Wrapf("error verifying connection: {{err}}", err)
+		}
+	}
+
+	return conf, nil
+}
+
+func (e *elasticsearchConnectionProducer) Connection(_ context.Context) (interface{}, error) {
+	if !e.Initialized {
+		return nil, connutil.ErrNotInitialized
+	}
+
+	// If we already have a client, return it
+	if e.client != nil {
+		return e.client, nil
+	}
+
+	httpClient, err := e.createHTTPClient()
+	if err != nil {
+		return nil, err
+	}
+
+	c := &client{
+		baseURL:    strings.TrimRight(e.URL, "/"),
+		username:   e.Username,
+		password:   e.Password,
+		httpClient: httpClient,
+	}
+
+	switch e.Distribution {
+	case distributionOpenSearch:
+		e.client = &openSearchAPI{c}
+	default:
+		e.client = &xpackAPI{c}
+	}
+
+	return e.client, nil
+}
+
+func (e *elasticsearchConnectionProducer) Close() error {
+	// Grab the write lock
+	e.Lock()
+	defer e.Unlock()
+
+	e.client = nil
+
+	return nil
+}
+
+func (e *elasticsearchConnectionProducer) createHTTPClient() (*http.Client, error) {
+	tlsConfig := &tls.Config{
+		ServerName:         e.TLSServerName,
+		InsecureSkipVerify: e.InsecureTLS,
+	}
+
+	if len(e.CACert) != 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(e.CACert)) {
+			return nil, fmt.Errorf("failed to parse ca_cert")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if len(e.ClientCert) != 0 {
+		cert, err := tls.X509KeyPair([]byte(e.ClientCert), []byte(e.ClientKey))
+		if err != nil {
+			return nil, errwrap.Wrapf("failed to parse client_cert and client_key: {{err}}", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	transport := cleanhttp.DefaultPooledTransport()
+	transport.TLSClientConfig = tlsConfig
+	if e.MaxOpenConnections > 0 {
+		transport.MaxConnsPerHost = e.MaxOpenConnections
+	}
+	if e.MaxIdleConnections > 0 {
+		transport.MaxIdleConnsPerHost = e.MaxIdleConnections
+	}
+
+	return &http.Client{
+		Transport: transport,
+		Timeout:   e.connectTimeout,
+	}, nil
+}
+
+func (e *elasticsearchConnectionProducer) secretValues() map[string]interface{} {
+	return map[string]interface{}{
+		e.Password:  "[password]",
+		e.ClientKey: "[client_key]",
+	}
+}
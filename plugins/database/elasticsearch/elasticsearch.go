@@ -0,0 +1,202 @@
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	multierror "github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/builtin/logical/database/dbplugin"
+	"github.com/hashicorp/vault/plugins"
+	"github.com/hashicorp/vault/plugins/helper/database/credsutil"
+	"github.com/hashicorp/vault/plugins/helper/database/dbutil"
+)
+
+const elasticsearchTypeName = "elasticsearch"
+
+var _ dbplugin.Database = &Elasticsearch{}
+
+// Elasticsearch is an implementation of Database interface for Elasticsearch
+// and OpenSearch clusters
+type Elasticsearch struct {
+	*elasticsearchConnectionProducer
+	credsutil.CredentialsProducer
+}
+
+// New returns a new Elasticsearch instance
+func New() (interface{}, error) {
+	db := new()
+	dbType := dbplugin.NewDatabaseErrorSanitizerMiddleware(db, db.secretValues)
+
+	return dbType, nil
+}
+
+func new() *Elasticsearch {
+	connProducer := &elasticsearchConnectionProducer{}
+	connProducer.Type = elasticsearchTypeName
+
+	credsProducer := &credsutil.SQLCredentialsProducer{
+		DisplayNameLen: 15,
+		RoleNameLen:    15,
+		UsernameLen:    100,
+		Separator:      "-",
+	}
+
+	return &Elasticsearch{
+		elasticsearchConnectionProducer: connProducer,
+		CredentialsProducer:             credsProducer,
+	}
+}
+
+// Run instantiates an Elasticsearch object, and runs the RPC server for the
+// plugin
+func Run(apiTLSConfig *api.TLSConfig) error {
+	dbType, err := New()
+	if err != nil {
+		return err
+	}
+
+	plugins.Serve(dbType.(dbplugin.Database), apiTLSConfig)
+
+	return nil
+}
+
+// Type returns the TypeName for this backend
+func (e *Elasticsearch) Type() (string, error) {
+	return elasticsearchTypeName, nil
+}
+
+func (e *Elasticsearch) getConnection(ctx context.Context) (securityAPI, error) {
+	client, err := e.Connection(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.(securityAPI), nil
+}
+
+// creationStatement is the JSON document expected as the creation statement
+// of the roles. The user is given the existing roles listed in Roles and, if
+// RoleDefinition is set, a role of its own created with this definition.
+type creationStatement struct {
+	Roles          []string        `json:"elasticsearch_roles"`
+	RoleDefinition json.RawMessage `json:"elasticsearch_role_definition"`
+}
+
+// CreateUser creates a user with the roles of the creation statement, after
+// creating its own role if the statement has a role definition.
+func (e *Elasticsearch) CreateUser(ctx context.Context, statements dbplugin.Statements, usernameConfig dbplugin.UsernameConfig, expiration time.Time) (username string, password string, err error) {
+	// Grab the lock
+	e.Lock()
+	defer e.Unlock()
+
+	statements = dbutil.StatementCompatibilityHelper(statements)
+
+	if len(statements.Creation) == 0 {
+		return "", "", dbutil.ErrEmptyCreationStatement
+	}
+
+	var stmt creationStatement
+	if err := json.Unmarshal([]byte(statements.Creation[0]), &stmt); err != nil {
+		return "", "", errwrap.Wrapf("error parsing creation statement: {{err}}", err)
+	}
+	if len(stmt.Roles) == 0 && len(stmt.RoleDefinition) == 0 {
+		return "", "", fmt.Errorf("elasticsearch_roles or elasticsearch_role_definition is required in creation statement")
+	}
+
+	client, err := e.getConnection(ctx)
+	if err != nil {
+		return "", "", err
+	}
+
+	username, err = e.GenerateUsername(usernameConfig)
+	if err != nil {
+		return "", "", err
+	}
+	password, err = e.GeneratePassword()
+	if err != nil {
+		return "", "", err
+	}
+
+	// The role of the user is named after it, so that it can be found at
+	// revocation
+	roles := stmt.Roles
+	if len(stmt.RoleDefinition) != 0 {
+		if err := client.createRole(ctx, username, stmt.RoleDefinition); err != nil {
+			return "", "", errwrap.Wrapf("error creating role: {{err}}", err)
+		}
+		roles = append(roles, username)
+	}
+
+	if err := client.createUser(ctx, username, password, roles); err != nil {
+		err = errwrap.Wrapf("error creating user: {{err}}", err)
+		if len(stmt.RoleDefinition) != 0 {
+			if rollbackErr := client.deleteRole(ctx, username); rollbackErr != nil {
+				err = multierror.Append(err, errwrap.Wrapf("error deleting role: {{err}}", rollbackErr))
+			}
+		}
+		return "", "", err
+	}
+
+	return username, password, nil
+}
+
+// RenewUser is not supported on Elasticsearch, so this is a no-op.
+func (e *Elasticsearch) RenewUser(ctx context.Context, statements dbplugin.Statements, username string, expiration time.Time) error {
+	// NOOP
+	return nil
+}
+
+// RevokeUser deletes the user and the role created for it, if any.
+// Revocation statements are not supported.
+func (e *Elasticsearch) RevokeUser(ctx context.Context, statements dbplugin.Statements, username string) error {
+	// Grab the lock
+	e.Lock()
+	defer e.Unlock()
+
+	client, err := e.getConnection(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := client.deleteUser(ctx, username); err != nil {
+		return errwrap.Wrapf("error deleting user: {{err}}", err)
+	}
+	if err := client.deleteRole(ctx, username); err != nil {
+		return errwrap.Wrapf("error deleting role: {{err}}", err)
+	}
+
+	return nil
+}
+
+// RotateRootCredentials changes the password of the configured user.
+// Rotation statements are not supported.
+func (e *Elasticsearch) RotateRootCredentials(ctx context.Context, statements []string) (map[string]interface{}, error) {
+	// Grab the lock
+	e.Lock()
+	defer e.Unlock()
+
+	client, err := e.getConnection(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	password, err := e.GeneratePassword()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := client.changePassword(ctx, password); err != nil {
+		return nil, errwrap.Wrapf("error changing password: {{err}}", err)
+	}
+
+	// The client authenticates with the previous password
+	e.Password = password
+	e.client = nil
+
+	e.rawConfig["password"] = password
+	return e.rawConfig, nil
+}
@@ -0,0 +1,229 @@
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/builtin/logical/database/dbplugin"
+)
+
+// testCluster is a fake of the security APIs of Elasticsearch and of the
+// OpenSearch security plugin
+type testCluster struct {
+	l        sync.Mutex
+	password string
+	users    map[string][]string
+	roles    map[string]json.RawMessage
+}
+
+func (c *testCluster) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.l.Lock()
+	defer c.l.Unlock()
+
+	if username, password, ok := r.BasicAuth(); !ok || username != "admin" || password != c.password {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var body map[string]json.RawMessage
+	json.NewDecoder(r.Body).Decode(&body)
+
+	path := r.URL.Path
+	switch {
+	case path == "/_security/_authenticate" || path == "/_plugins/_security/authinfo":
+
+	case strings.HasPrefix(path, "/_security/user/") && strings.HasSuffix(path, "/_password"):
+		json.Unmarshal(body["password"], &c.password)
+
+	case path == "/_plugins/_security/api/account":
+		var current string
+		json.Unmarshal(body["current_password"], &current)
+		if current != c.password {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		json.Unmarshal(body["password"], &c.password)
+
+	case strings.HasPrefix(path, "/_security/user/"), strings.HasPrefix(path, "/_plugins/_security/api/internalusers/"):
+		name := path[strings.LastIndex(path, "/")+1:]
+		if r.Method == http.MethodDelete {
+			if _, ok := c.users[name]; !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			delete(c.users, name)
+			return
+		}
+		var roles []string
+		if raw, ok := body["roles"]; ok {
+			json.Unmarshal(raw, &roles)
+		} else {
+			json.Unmarshal(body["opendistro_security_roles"], &roles)
+		}
+		for _, role := range roles {
+			if _, ok := c.roles[role]; !ok {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"error": {"reason": "unknown role"}, "status": 400}`))
+				return
+			}
+		}
+		c.users[name] = roles
+
+	case strings.HasPrefix(path, "/_security/role/"), strings.HasPrefix(path, "/_plugins/_security/api/roles/"):
+		name := path[strings.LastIndex(path, "/")+1:]
+		if r.Method == http.MethodDelete {
+			if _, ok := c.roles[name]; !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			delete(c.roles, name)
+			return
+		}
+		c.roles[name], _ = json.Marshal(body)
+
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func testElasticsearch(t *testing.T, distribution string) (*Elasticsearch, *testCluster, func()) {
+	cluster := &testCluster{
+		password: "password",
+		users:    map[string][]string{},
+		roles:    map[string]json.RawMessage{"monitoring": json.RawMessage(`{}`)},
+	}
+	server := httptest.NewServer(cluster)
+
+	db := new()
+	_, err := db.Init(context.Background(), map[string]interface{}{
+		"url":          server.URL,
+		"username":     "admin",
+		"password":     "password",
+		"distribution": distribution,
+	}, true)
+	if err != nil {
+		server.Close()
+		t.Fatalf("err: %s", err)
+	}
+
+	return db, cluster, server.Close
+}
+
+func TestElasticsearch_Initialize(t *testing.T) {
+	db, _, cleanup := testElasticsearch(t, "")
+	defer cleanup()
+
+	if !db.Initialized || db.Distribution != distributionElasticsearch {
+		t.Fatal("Database should be initialized")
+	}
+
+	_, err := db.Init(context.Background(), map[string]interface{}{
+		"url":      db.URL,
+		"username": "admin",
+		"password": "wrong",
+	}, true)
+	if err == nil {
+		t.Fatal("expected an error with invalid credentials")
+	}
+
+	_, err = db.Init(context.Background(), map[string]interface{}{
+		"url":          db.URL,
+		"username":     "admin",
+		"password":     "password",
+		"distribution": "solr",
+	}, false)
+	if err == nil {
+		t.Fatal("expected an error with an invalid distribution")
+	}
+}
+
+func TestElasticsearch_CreateRevokeUser(t *testing.T) {
+	for _, distribution := range []string{distributionElasticsearch, distributionOpenSearch} {
+		db, cluster, cleanup := testElasticsearch(t, distribution)
+		defer cleanup()
+
+		usernameConfig := dbplugin.UsernameConfig{
+			DisplayName: "test",
+			RoleName:    "test",
+		}
+
+		// Test with no configured Creation Statement
+		_, _, err := db.CreateUser(context.Background(), dbplugin.Statements{}, usernameConfig, time.Now().Add(time.Minute))
+		if err == nil {
+			t.Fatal("Expected error when no creation statement is provided")
+		}
+
+		// Existing roles only
+		username, password, err := db.CreateUser(context.Background(), dbplugin.Statements{
+			Creation: []string{`{"elasticsearch_roles": ["monitoring"]}`},
+		}, usernameConfig, time.Now().Add(time.Minute))
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		if password == "" || len(cluster.users[username]) != 1 || cluster.users[username][0] != "monitoring" {
+			t.Fatalf("%s: bad user %q: %v", distribution, username, cluster.users)
+		}
+
+		if err := db.RevokeUser(context.Background(), dbplugin.Statements{}, username); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		if _, ok := cluster.users[username]; ok {
+			t.Fatalf("%s: expected the user to be deleted", distribution)
+		}
+
+		// Role of the user
+		username, _, err = db.CreateUser(context.Background(), dbplugin.Statements{
+			Creation: []string{`{"elasticsearch_roles": ["monitoring"], "elasticsearch_role_definition": {"indices": [{"names": ["vault"], "privileges": ["read"]}]}}`},
+		}, usernameConfig, time.Now().Add(time.Minute))
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		if len(cluster.users[username]) != 2 || cluster.users[username][1] != username || cluster.roles[username] == nil {
+			t.Fatalf("%s: bad user %q: %v", distribution, username, cluster.users)
+		}
+
+		if err := db.RevokeUser(context.Background(), dbplugin.Statements{}, username); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		if _, ok := cluster.roles[username]; ok {
+			t.Fatalf("%s: expected the role to be deleted", distribution)
+		}
+
+		// The role is deleted when the user can't be created
+		_, _, err = db.CreateUser(context.Background(), dbplugin.Statements{
+			Creation: []string{`{"elasticsearch_roles": ["unknown"], "elasticsearch_role_definition": {}}`},
+		}, usernameConfig, time.Now().Add(time.Minute))
+		if err == nil || !strings.Contains(err.Error(), "unknown role") {
+			t.Fatalf("%s: expected an error, got %v", distribution, err)
+		}
+		if len(cluster.roles) != 1 {
+			t.Fatalf("%s: expected the role to be rolled back: %v", distribution, cluster.roles)
+		}
+	}
+}
+
+func TestElasticsearch_RotateRootCredentials(t *testing.T) {
+	for _, distribution := range []string{distributionElasticsearch, distributionOpenSearch} {
+		db, cluster, cleanup := testElasticsearch(t, distribution)
+		defer cleanup()
+
+		config, err := db.RotateRootCredentials(context.Background(), nil)
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		if config["password"] == "password" || config["password"] != cluster.password {
+			t.Fatalf("%s: bad password: %v", distribution, config["password"])
+		}
+
+		// The new password is used afterwards
+		if err := db.RevokeUser(context.Background(), dbplugin.Statements{}, "unknown"); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+	}
+}
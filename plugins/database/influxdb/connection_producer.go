@@ -30,6 +30,10 @@ type influxdbConnectionProducer struct {
 	PemBundle         string      `json:"pem_bundle" structs:"pem_bundle" mapstructure:"pem_bundle"`
 	PemJSON           string      `json:"pem_json" structs:"pem_json" mapstructure:"pem_json"`
 
+	// The InfluxDB client doesn't expose its HTTP transport, so none of the
+	// pool settings can be applied
+	connutil.PoolSettings `mapstructure:",squash"`
+
 	connectTimeout time.Duration
 	certificate    string
 	privateKey     string
@@ -69,6 +73,11 @@ func (i *influxdbConnectionProducer) Init(ctx context.Context, conf map[string]i
 		return nil, errwrap.Wrapf("invalid connect_timeout: {{err}}", err)
 	}
 
+	err = i.ParsePoolSettings(0)
+	if err != nil {
+		return nil, err
+	}
+
 	switch {
 	case len(i.Host) == 0:
 		return nil, fmt.Errorf("host cannot be empty")
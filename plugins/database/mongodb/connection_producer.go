@@ -30,6 +30,9 @@ type mongoDBConnectionProducer struct {
 	Username      string `json:"username" structs:"username" mapstructure:"username"`
 	Password      string `json:"password" structs:"password" mapstructure:"password"`
 
+	// MaxOpenConnections is the limit of the connections opened to each server
+	connutil.PoolSettings `mapstructure:",squash"`
+
 	Initialized bool
 	RawConfig   map[string]interface{}
 	Type        string
@@ -64,6 +67,11 @@ func (c *mongoDBConnectionProducer) Init(ctx context.Context, conf map[string]in
 		"password": c.Password,
 	})
 
+	err = c.ParsePoolSettings(0, connutil.PoolMaxOpenConnections)
+	if err != nil {
+		return nil, err
+	}
+
 	if c.WriteConcern != "" {
 		input := c.WriteConcern
 
@@ -135,6 +143,9 @@ func (c *mongoDBConnectionProducer) Connection(_ context.Context) (interface{},
 
 	c.session.SetSyncTimeout(1 * time.Minute)
 	c.session.SetSocketTimeout(1 * time.Minute)
+	if c.MaxOpenConnections > 0 {
+		c.session.SetPoolLimit(c.MaxOpenConnections)
+	}
 
 	return c.session, nil
 }
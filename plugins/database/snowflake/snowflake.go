@@ -0,0 +1,310 @@
+package snowflake
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/builtin/logical/database/dbplugin"
+	"github.com/hashicorp/vault/helper/dbtxn"
+	"github.com/hashicorp/vault/helper/strutil"
+	"github.com/hashicorp/vault/plugins"
+	"github.com/hashicorp/vault/plugins/helper/database/connutil"
+	"github.com/hashicorp/vault/plugins/helper/database/credsutil"
+	"github.com/hashicorp/vault/plugins/helper/database/dbutil"
+	_ "github.com/snowflakedb/gosnowflake"
+)
+
+const (
+	snowflakeTypeName = "snowflake"
+
+	defaultSnowflakeRevocationSQL = `
+DROP USER IF EXISTS "{{name}}";
+`
+	defaultSnowflakeRotateRootCredentialsSQL = `
+ALTER USER "{{username}}" SET PASSWORD = '{{password}}';
+`
+)
+
+var _ dbplugin.Database = &Snowflake{}
+
+// New implements builtinplugins.BuiltinFactory
+func New() (interface{}, error) {
+	db := new()
+	// Wrap the plugin with middleware to sanitize errors
+	dbType := dbplugin.NewDatabaseErrorSanitizerMiddleware(db, db.SecretValues)
+	return dbType, nil
+}
+
+func new() *Snowflake {
+	connProducer := &connutil.SQLConnectionProducer{}
+	connProducer.Type = snowflakeTypeName
+
+	credsProducer := &credsutil.SQLCredentialsProducer{
+		DisplayNameLen: 32,
+		RoleNameLen:    32,
+		UsernameLen:    255,
+		Separator:      "_",
+	}
+
+	return &Snowflake{
+		SQLConnectionProducer: connProducer,
+		CredentialsProducer:   credsProducer,
+	}
+}
+
+// Run instantiates a Snowflake object, and runs the RPC server for the plugin
+func Run(apiTLSConfig *api.TLSConfig) error {
+	dbType, err := New()
+	if err != nil {
+		return err
+	}
+
+	plugins.Serve(dbType.(dbplugin.Database), apiTLSConfig)
+
+	return nil
+}
+
+type Snowflake struct {
+	*connutil.SQLConnectionProducer
+	credsutil.CredentialsProducer
+}
+
+func (s *Snowflake) Type() (string, error) {
+	return snowflakeTypeName, nil
+}
+
+func (s *Snowflake) getConnection(ctx context.Context) (*sql.DB, error) {
+	db, err := s.Connection(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return db.(*sql.DB), nil
+}
+
+func (s *Snowflake) CreateUser(ctx context.Context, statements dbplugin.Statements, usernameConfig dbplugin.UsernameConfig, expiration time.Time) (username string, password string, err error) {
+	statements = dbutil.StatementCompatibilityHelper(statements)
+
+	if len(statements.Creation) == 0 {
+		return "", "", dbutil.ErrEmptyCreationStatement
+	}
+
+	// Grab the lock
+	s.Lock()
+	defer s.Unlock()
+
+	username, err = s.GenerateUsername(usernameConfig)
+	if err != nil {
+		return "", "", err
+	}
+
+	password, err = s.GeneratePassword()
+	if err != nil {
+		return "", "", err
+	}
+
+	expirationStr, err := s.GenerateExpiration(expiration)
+	if err != nil {
+		return "", "", err
+	}
+
+	// Get the connection
+	db, err := s.getConnection(ctx)
+	if err != nil {
+		return "", "", err
+	}
+
+	// Start a transaction
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", "", err
+	}
+	defer func() {
+		tx.Rollback()
+	}()
+
+	// Execute each query
+	for _, stmt := range statements.Creation {
+		for _, query := range strutil.ParseArbitraryStringSlice(stmt, ";") {
+			query = strings.TrimSpace(query)
+			if len(query) == 0 {
+				continue
+			}
+
+			m := map[string]string{
+				"name":       username,
+				"password":   password,
+				"expiration": expirationStr,
+			}
+			if err := dbtxn.ExecuteTxQuery(ctx, tx, m, query); err != nil {
+				return "", "", err
+			}
+		}
+	}
+
+	// Commit the transaction
+	if err := tx.Commit(); err != nil {
+		return "", "", err
+	}
+
+	return username, password, nil
+}
+
+// RenewUser runs the configured renewal statements, if any. Snowflake users
+// carry no expiration of their own, so without renewal statements the lease
+// alone governs the lifetime of the credentials.
+func (s *Snowflake) RenewUser(ctx context.Context, statements dbplugin.Statements, username string, expiration time.Time) error {
+	statements = dbutil.StatementCompatibilityHelper(statements)
+
+	if len(statements.Renewal) == 0 {
+		return nil
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	db, err := s.getConnection(ctx)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		tx.Rollback()
+	}()
+
+	expirationStr, err := s.GenerateExpiration(expiration)
+	if err != nil {
+		return err
+	}
+
+	for _, stmt := range statements.Renewal {
+		for _, query := range strutil.ParseArbitraryStringSlice(stmt, ";") {
+			query = strings.TrimSpace(query)
+			if len(query) == 0 {
+				continue
+			}
+
+			m := map[string]string{
+				"name":       username,
+				"expiration": expirationStr,
+			}
+			if err := dbtxn.ExecuteTxQuery(ctx, tx, m, query); err != nil {
+				return err
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (s *Snowflake) RevokeUser(ctx context.Context, statements dbplugin.Statements, username string) error {
+	// Grab the lock
+	s.Lock()
+	defer s.Unlock()
+
+	statements = dbutil.StatementCompatibilityHelper(statements)
+
+	revocationStmts := statements.Revocation
+	if len(revocationStmts) == 0 {
+		revocationStmts = []string{defaultSnowflakeRevocationSQL}
+	}
+
+	db, err := s.getConnection(ctx)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		tx.Rollback()
+	}()
+
+	for _, stmt := range revocationStmts {
+		for _, query := range strutil.ParseArbitraryStringSlice(stmt, ";") {
+			query = strings.TrimSpace(query)
+			if len(query) == 0 {
+				continue
+			}
+
+			m := map[string]string{
+				"name": username,
+			}
+			if err := dbtxn.ExecuteTxQuery(ctx, tx, m, query); err != nil {
+				return err
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (s *Snowflake) RotateRootCredentials(ctx context.Context, statements []string) (map[string]interface{}, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	if len(s.Username) == 0 || len(s.Password) == 0 {
+		return nil, errors.New("username and password are required to rotate")
+	}
+
+	rotateStatements := statements
+	if len(rotateStatements) == 0 {
+		rotateStatements = []string{defaultSnowflakeRotateRootCredentialsSQL}
+	}
+
+	db, err := s.getConnection(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		tx.Rollback()
+	}()
+
+	password, err := s.GeneratePassword()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, stmt := range rotateStatements {
+		for _, query := range strutil.ParseArbitraryStringSlice(stmt, ";") {
+			query = strings.TrimSpace(query)
+			if len(query) == 0 {
+				continue
+			}
+			m := map[string]string{
+				"username": s.Username,
+				"password": password,
+			}
+			if err := dbtxn.ExecuteTxQuery(ctx, tx, m, query); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	// Close the database connection to ensure no new connections come in
+	if err := db.Close(); err != nil {
+		return nil, err
+	}
+
+	s.RawConfig["password"] = password
+	return s.RawConfig, nil
+}
@@ -0,0 +1,175 @@
+package snowflake
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/builtin/logical/database/dbplugin"
+)
+
+// The Snowflake tests run against a real account. SNOWFLAKE_URL must be a DSN
+// accepted by the gosnowflake driver whose user is able to create and drop
+// users, e.g. "user:password@account/db/schema?warehouse=wh&role=SYSADMIN".
+func testSnowflakeURL(t *testing.T) string {
+	t.Helper()
+
+	connURL := os.Getenv("SNOWFLAKE_URL")
+	if connURL == "" || os.Getenv("VAULT_ACC") != "1" {
+		t.SkipNow()
+	}
+	return connURL
+}
+
+func TestSnowflake_Initialize(t *testing.T) {
+	connURL := testSnowflakeURL(t)
+
+	connectionDetails := map[string]interface{}{
+		"connection_url": connURL,
+	}
+
+	db := new()
+	_, err := db.Init(context.Background(), connectionDetails, true)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if !db.Initialized {
+		t.Fatal("Database should be initialized")
+	}
+
+	err = db.Close()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+}
+
+func TestSnowflake_CreateUser(t *testing.T) {
+	connURL := testSnowflakeURL(t)
+
+	connectionDetails := map[string]interface{}{
+		"connection_url": connURL,
+	}
+
+	db := new()
+	_, err := db.Init(context.Background(), connectionDetails, true)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer db.Close()
+
+	usernameConfig := dbplugin.UsernameConfig{
+		DisplayName: "test",
+		RoleName:    "test",
+	}
+
+	// Test with no configured Creation Statement
+	_, _, err = db.CreateUser(context.Background(), dbplugin.Statements{}, usernameConfig, time.Now().Add(time.Minute))
+	if err == nil {
+		t.Fatal("Expected error when no creation statement is provided")
+	}
+
+	statements := dbplugin.Statements{
+		Creation: []string{testSnowflakeRole},
+	}
+
+	username, password, err := db.CreateUser(context.Background(), statements, usernameConfig, time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer db.RevokeUser(context.Background(), dbplugin.Statements{}, username)
+
+	if err := testCredsExist(t, connURL, username, password); err != nil {
+		t.Fatalf("Could not connect with new credentials: %s", err)
+	}
+
+	// Renewal without statements is a no-op
+	if err := db.RenewUser(context.Background(), dbplugin.Statements{}, username, time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+}
+
+func TestSnowflake_RevokeUser(t *testing.T) {
+	connURL := testSnowflakeURL(t)
+
+	connectionDetails := map[string]interface{}{
+		"connection_url": connURL,
+	}
+
+	db := new()
+	_, err := db.Init(context.Background(), connectionDetails, true)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer db.Close()
+
+	statements := dbplugin.Statements{
+		Creation: []string{testSnowflakeRole},
+	}
+
+	usernameConfig := dbplugin.UsernameConfig{
+		DisplayName: "test",
+		RoleName:    "test",
+	}
+
+	// Test default revoke statements
+	username, password, err := db.CreateUser(context.Background(), statements, usernameConfig, time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := testCredsExist(t, connURL, username, password); err != nil {
+		t.Fatalf("Could not connect with new credentials: %s", err)
+	}
+
+	if err := db.RevokeUser(context.Background(), statements, username); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if err := testCredsExist(t, connURL, username, password); err == nil {
+		t.Fatal("Credentials were not revoked")
+	}
+
+	// Test custom revoke statements
+	username, password, err = db.CreateUser(context.Background(), statements, usernameConfig, time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := testCredsExist(t, connURL, username, password); err != nil {
+		t.Fatalf("Could not connect with new credentials: %s", err)
+	}
+
+	statements.Revocation = []string{testSnowflakeDrop}
+	if err := db.RevokeUser(context.Background(), statements, username); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if err := testCredsExist(t, connURL, username, password); err == nil {
+		t.Fatal("Credentials were not revoked")
+	}
+}
+
+func testCredsExist(t testing.TB, connURL, username, password string) error {
+	t.Helper()
+
+	// Swap the credentials in the DSN for the generated ones
+	connURL = fmt.Sprintf("%s:%s@%s", username, password, connURL[strings.Index(connURL, "@")+1:])
+
+	db, err := sql.Open("snowflake", connURL)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return db.Ping()
+}
+
+const testSnowflakeRole = `
+CREATE USER "{{name}}" PASSWORD = '{{password}}';
+`
+
+const testSnowflakeDrop = `
+DROP USER "{{name}}";
+`
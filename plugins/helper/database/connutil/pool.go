@@ -0,0 +1,78 @@
+package connutil
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/helper/parseutil"
+	"github.com/hashicorp/vault/helper/strutil"
+)
+
+const (
+	PoolMaxOpenConnections    = "max_open_connections"
+	PoolMaxIdleConnections    = "max_idle_connections"
+	PoolMaxConnectionLifetime = "max_connection_lifetime"
+)
+
+// PoolSettings holds the connection pool settings accepted by the connection
+// configuration of every builtin database plugin. It is embedded in the
+// connection producers, which apply the settings their client supports.
+type PoolSettings struct {
+	MaxOpenConnections       int         `json:"max_open_connections" mapstructure:"max_open_connections" structs:"max_open_connections"`
+	MaxIdleConnections       int         `json:"max_idle_connections" mapstructure:"max_idle_connections" structs:"max_idle_connections"`
+	MaxConnectionLifetimeRaw interface{} `json:"max_connection_lifetime" mapstructure:"max_connection_lifetime" structs:"max_connection_lifetime"`
+
+	maxConnectionLifetime time.Duration
+}
+
+// ParsePoolSettings validates the settings and fills in their defaults: at
+// most defaultMaxOpen open connections, zero leaving it to the client, all of
+// which can be idle and live forever. The settings set but not listed in
+// supported are rejected, so that a pool limit is never silently ignored.
+func (p *PoolSettings) ParsePoolSettings(defaultMaxOpen int, supported ...string) error {
+	var unsupported []string
+	isSupported := func(name string) bool {
+		return strutil.StrListContains(supported, name)
+	}
+	if p.MaxOpenConnections != 0 && !isSupported(PoolMaxOpenConnections) {
+		unsupported = append(unsupported, PoolMaxOpenConnections)
+	}
+	if p.MaxIdleConnections != 0 && !isSupported(PoolMaxIdleConnections) {
+		unsupported = append(unsupported, PoolMaxIdleConnections)
+	}
+	if p.MaxConnectionLifetimeRaw != nil && !isSupported(PoolMaxConnectionLifetime) {
+		unsupported = append(unsupported, PoolMaxConnectionLifetime)
+	}
+	if len(unsupported) > 0 {
+		return fmt.Errorf("unsupported connection pool settings: %s", strings.Join(unsupported, ", "))
+	}
+
+	if p.MaxOpenConnections == 0 {
+		p.MaxOpenConnections = defaultMaxOpen
+	}
+	if p.MaxIdleConnections == 0 && isSupported(PoolMaxIdleConnections) {
+		p.MaxIdleConnections = p.MaxOpenConnections
+	}
+	if p.MaxOpenConnections > 0 && p.MaxIdleConnections > p.MaxOpenConnections {
+		p.MaxIdleConnections = p.MaxOpenConnections
+	}
+
+	p.maxConnectionLifetime = 0
+	if p.MaxConnectionLifetimeRaw != nil {
+		var err error
+		p.maxConnectionLifetime, err = parseutil.ParseDurationSecond(p.MaxConnectionLifetimeRaw)
+		if err != nil {
+			return errwrap.Wrapf("invalid max_connection_lifetime: {{err}}", err)
+		}
+	}
+
+	return nil
+}
+
+// MaxConnectionLifetime returns the parsed max_connection_lifetime, zero
+// meaning connections are reused forever.
+func (p *PoolSettings) MaxConnectionLifetime() time.Duration {
+	return p.maxConnectionLifetime
+}
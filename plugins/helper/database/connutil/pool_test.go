@@ -0,0 +1,69 @@
+package connutil
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+func TestPoolSettings(t *testing.T) {
+	type producer struct {
+		URL          string `mapstructure:"url"`
+		PoolSettings `mapstructure:",squash"`
+	}
+
+	parse := func(conf map[string]interface{}, defaultMaxOpen int, supported ...string) (*producer, error) {
+		p := &producer{}
+		if err := mapstructure.WeakDecode(conf, p); err != nil {
+			t.Fatal(err)
+		}
+		return p, p.ParsePoolSettings(defaultMaxOpen, supported...)
+	}
+	all := []string{PoolMaxOpenConnections, PoolMaxIdleConnections, PoolMaxConnectionLifetime}
+
+	p, err := parse(map[string]interface{}{"url": "db"}, 2, all...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.MaxOpenConnections != 2 || p.MaxIdleConnections != 2 || p.MaxConnectionLifetime() != 0 {
+		t.Fatalf("bad: %#v", p)
+	}
+
+	p, err = parse(map[string]interface{}{
+		"max_open_connections":    "10",
+		"max_idle_connections":    20,
+		"max_connection_lifetime": "5m",
+	}, 2, all...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.MaxOpenConnections != 10 || p.MaxIdleConnections != 10 || p.MaxConnectionLifetime() != 5*time.Minute {
+		t.Fatalf("bad: %#v", p)
+	}
+
+	// Without a limit on the open connections, the idle ones are left alone
+	p, err = parse(map[string]interface{}{"max_idle_connections": 4}, 0, all...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.MaxOpenConnections != 0 || p.MaxIdleConnections != 4 {
+		t.Fatalf("bad: %#v", p)
+	}
+
+	// The settings a plugin can't apply are rejected, but only once set
+	if _, err := parse(map[string]interface{}{"url": "db"}, 0, PoolMaxOpenConnections); err != nil {
+		t.Fatal(err)
+	}
+	_, err = parse(map[string]interface{}{
+		"max_idle_connections":    4,
+		"max_connection_lifetime": "5m",
+	}, 0, PoolMaxOpenConnections)
+	if err == nil || err.Error() != "unsupported connection pool settings: max_idle_connections, max_connection_lifetime" {
+		t.Fatalf("bad: %v", err)
+	}
+
+	if _, err := parse(map[string]interface{}{"max_connection_lifetime": "forever"}, 2, all...); err == nil {
+		t.Fatal("expected an error")
+	}
+}
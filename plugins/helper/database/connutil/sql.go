@@ -6,30 +6,27 @@ import (
 	"fmt"
 	"strings"
 	"sync"
-	"time"
 
 	"github.com/hashicorp/errwrap"
-	"github.com/hashicorp/vault/helper/parseutil"
+	"github.com/hashicorp/vault/builtin/logical/database/dbplugin"
 	"github.com/hashicorp/vault/plugins/helper/database/dbutil"
 	"github.com/mitchellh/mapstructure"
 )
 
 var _ ConnectionProducer = &SQLConnectionProducer{}
+var _ dbplugin.PoolStatsReporter = &SQLConnectionProducer{}
 
 // SQLConnectionProducer implements ConnectionProducer and provides a generic producer for most sql databases
 type SQLConnectionProducer struct {
-	ConnectionURL            string      `json:"connection_url" mapstructure:"connection_url" structs:"connection_url"`
-	MaxOpenConnections       int         `json:"max_open_connections" mapstructure:"max_open_connections" structs:"max_open_connections"`
-	MaxIdleConnections       int         `json:"max_idle_connections" mapstructure:"max_idle_connections" structs:"max_idle_connections"`
-	MaxConnectionLifetimeRaw interface{} `json:"max_connection_lifetime" mapstructure:"max_connection_lifetime" structs:"max_connection_lifetime"`
-	Username                 string      `json:"username" mapstructure:"username" structs:"username"`
-	Password                 string      `json:"password" mapstructure:"password" structs:"password"`
-
-	Type                  string
-	RawConfig             map[string]interface{}
-	maxConnectionLifetime time.Duration
-	Initialized           bool
-	db                    *sql.DB
+	ConnectionURL string `json:"connection_url" mapstructure:"connection_url" structs:"connection_url"`
+	Username      string `json:"username" mapstructure:"username" structs:"username"`
+	Password      string `json:"password" mapstructure:"password" structs:"password"`
+	PoolSettings  `mapstructure:",squash"`
+
+	Type        string
+	RawConfig   map[string]interface{}
+	Initialized bool
+	db          *sql.DB
 	sync.Mutex
 }
 
@@ -58,23 +55,9 @@ func (c *SQLConnectionProducer) Init(ctx context.Context, conf map[string]interf
 		"password": c.Password,
 	})
 
-	if c.MaxOpenConnections == 0 {
-		c.MaxOpenConnections = 2
-	}
-
-	if c.MaxIdleConnections == 0 {
-		c.MaxIdleConnections = c.MaxOpenConnections
-	}
-	if c.MaxIdleConnections > c.MaxOpenConnections {
-		c.MaxIdleConnections = c.MaxOpenConnections
-	}
-	if c.MaxConnectionLifetimeRaw == nil {
-		c.MaxConnectionLifetimeRaw = "0s"
-	}
-
-	c.maxConnectionLifetime, err = parseutil.ParseDurationSecond(c.MaxConnectionLifetimeRaw)
+	err = c.ParsePoolSettings(2, PoolMaxOpenConnections, PoolMaxIdleConnections, PoolMaxConnectionLifetime)
 	if err != nil {
-		return nil, errwrap.Wrapf("invalid max_connection_lifetime: {{err}}", err)
+		return nil, err
 	}
 
 	// Set initialized to true at this point since all fields are set,
@@ -137,11 +120,31 @@ func (c *SQLConnectionProducer) Connection(ctx context.Context) (interface{}, er
 	// since the request rate shouldn't be high.
 	c.db.SetMaxOpenConns(c.MaxOpenConnections)
 	c.db.SetMaxIdleConns(c.MaxIdleConnections)
-	c.db.SetConnMaxLifetime(c.maxConnectionLifetime)
+	c.db.SetConnMaxLifetime(c.MaxConnectionLifetime())
 
 	return c.db, nil
 }
 
+// PoolStats reports the utilization of the connection pool
+func (c *SQLConnectionProducer) PoolStats() (dbplugin.PoolStats, bool) {
+	c.Lock()
+	defer c.Unlock()
+
+	if c.db == nil {
+		return dbplugin.PoolStats{}, false
+	}
+
+	stats := c.db.Stats()
+	return dbplugin.PoolStats{
+		MaxOpenConnections: stats.MaxOpenConnections,
+		OpenConnections:    stats.OpenConnections,
+		InUse:              stats.InUse,
+		Idle:               stats.Idle,
+		WaitCount:          stats.WaitCount,
+		WaitDuration:       stats.WaitDuration,
+	}, true
+}
+
 func (c *SQLConnectionProducer) SecretValues() map[string]interface{} {
 	return map[string]interface{}{
 		c.Password: "[password]",
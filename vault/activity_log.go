@@ -0,0 +1,600 @@
+package vault
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/logical"
+)
+
+const (
+	// activityLogPrefix is the storage prefix of the monthly client activity
+	// segments, relative to the system barrier view
+	activityLogPrefix = "counters/activity/"
+
+	// activityLogMonthFormat is the format of the month a segment covers, as
+	// found in its storage key
+	activityLogMonthFormat = "2006-01"
+
+	// activityLogSegmentClients is the maximum number of clients stored in a
+	// segment, keeping segments well below the 512KB entry limit of Consul
+	activityLogSegmentClients = 8000
+
+	// activityLogPersistInterval is how often the metrics loop writes the
+	// activity of the current month to storage
+	activityLogPersistInterval = 10 * time.Minute
+
+	// activityLogRetentionMonths is the number of months of activity kept in
+	// storage, the current one included
+	activityLogRetentionMonths = 24
+)
+
+// activityMountClients holds the distinct clients seen on an auth mount
+type activityMountClients struct {
+	Entities        map[string]struct{}
+	NonEntityTokens map[string]struct{}
+}
+
+func newActivityMountClients() *activityMountClients {
+	return &activityMountClients{
+		Entities:        make(map[string]struct{}),
+		NonEntityTokens: make(map[string]struct{}),
+	}
+}
+
+func (m *activityMountClients) merge(other *activityMountClients) {
+	for id := range other.Entities {
+		m.Entities[id] = struct{}{}
+	}
+	for id := range other.NonEntityTokens {
+		m.NonEntityTokens[id] = struct{}{}
+	}
+}
+
+// activityMonth holds the distinct clients seen in a month, by the path of
+// the auth mount that issued their tokens
+type activityMonth struct {
+	Start  time.Time
+	Mounts map[string]*activityMountClients
+
+	// dirty is set when clients were seen since the month was last persisted
+	dirty bool
+}
+
+func newActivityMonth(start time.Time) *activityMonth {
+	return &activityMonth{
+		Start:  start,
+		Mounts: make(map[string]*activityMountClients),
+	}
+}
+
+// activityMonthEntry is the storage form of an activityMonth. A month is
+// stored as several segments of at most activityLogSegmentClients clients,
+// under counters/activity/<month>/<index>.
+type activityMonthEntry struct {
+	Start  time.Time                             `json:"start"`
+	Mounts map[string]*activityMountClientsEntry `json:"mounts"`
+}
+
+type activityMountClientsEntry struct {
+	Entities        []string `json:"entities"`
+	NonEntityTokens []string `json:"non_entity_tokens"`
+}
+
+func sortedActivityIDs(ids map[string]struct{}) []string {
+	sorted := make([]string, 0, len(ids))
+	for id := range ids {
+		sorted = append(sorted, id)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+func (m *activityMonth) entry() *activityMonthEntry {
+	entry := &activityMonthEntry{
+		Start:  m.Start,
+		Mounts: make(map[string]*activityMountClientsEntry, len(m.Mounts)),
+	}
+	for path, clients := range m.Mounts {
+		entry.Mounts[path] = &activityMountClientsEntry{
+			Entities:        sortedActivityIDs(clients.Entities),
+			NonEntityTokens: sortedActivityIDs(clients.NonEntityTokens),
+		}
+	}
+	return entry
+}
+
+// segments splits the entry in entries holding at most max clients each. An
+// entry without clients yields a single empty segment.
+func (e *activityMonthEntry) segments(max int) []*activityMonthEntry {
+	paths := make([]string, 0, len(e.Mounts))
+	for path := range e.Mounts {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	segment := &activityMonthEntry{
+		Start:  e.Start,
+		Mounts: make(map[string]*activityMountClientsEntry),
+	}
+	segments := []*activityMonthEntry{segment}
+	clients := 0
+	add := func(path string, entity bool, id string) {
+		if clients == max {
+			segment = &activityMonthEntry{
+				Start:  e.Start,
+				Mounts: make(map[string]*activityMountClientsEntry),
+			}
+			segments = append(segments, segment)
+			clients = 0
+		}
+		mount, ok := segment.Mounts[path]
+		if !ok {
+			mount = &activityMountClientsEntry{
+				Entities:        []string{},
+				NonEntityTokens: []string{},
+			}
+			segment.Mounts[path] = mount
+		}
+		if entity {
+			mount.Entities = append(mount.Entities, id)
+		} else {
+			mount.NonEntityTokens = append(mount.NonEntityTokens, id)
+		}
+		clients++
+	}
+
+	for _, path := range paths {
+		for _, id := range e.Mounts[path].Entities {
+			add(path, true, id)
+		}
+		for _, id := range e.Mounts[path].NonEntityTokens {
+			add(path, false, id)
+		}
+	}
+	return segments
+}
+
+func (e *activityMonthEntry) month() *activityMonth {
+	m := newActivityMonth(e.Start)
+	for path, entry := range e.Mounts {
+		clients := newActivityMountClients()
+		for _, id := range entry.Entities {
+			clients.Entities[id] = struct{}{}
+		}
+		for _, id := range entry.NonEntityTokens {
+			clients.NonEntityTokens[id] = struct{}{}
+		}
+		m.Mounts[path] = clients
+	}
+	return m
+}
+
+// activityMonthStart returns the start of the month, in UTC, the time falls in
+func activityMonthStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// activityLog tracks the distinct clients making requests each month: the
+// entities, and the tokens without an entity, identified by their accessor.
+// Clients are attributed to the auth mount that issued their token. The
+// activity of the current month is kept in memory and periodically persisted,
+// so that it survives restarts and leadership changes.
+type activityLog struct {
+	l sync.Mutex
+
+	// view is the storage the activity is persisted to, nil while sealed
+	view logical.Storage
+
+	// months holds the current month and the past ones not persisted yet
+	months map[time.Time]*activityMonth
+
+	lastPersist time.Time
+
+	// persistLock serializes the writes of the activity to storage, which
+	// happen outside of l
+	persistLock sync.Mutex
+}
+
+func newActivityLog() *activityLog {
+	return &activityLog{
+		months: make(map[time.Time]*activityMonth),
+	}
+}
+
+// record attributes a request made at the given time to its client
+func (a *activityLog) record(entityID, tokenAccessor, mountPath string, now time.Time) {
+	if entityID == "" && tokenAccessor == "" {
+		return
+	}
+
+	a.l.Lock()
+	defer a.l.Unlock()
+
+	if a.view == nil {
+		return
+	}
+
+	start := activityMonthStart(now)
+	month, ok := a.months[start]
+	if !ok {
+		month = newActivityMonth(start)
+		a.months[start] = month
+	}
+	clients, ok := month.Mounts[mountPath]
+	if !ok {
+		clients = newActivityMountClients()
+		month.Mounts[mountPath] = clients
+	}
+
+	if entityID != "" {
+		if _, ok := clients.Entities[entityID]; !ok {
+			clients.Entities[entityID] = struct{}{}
+			month.dirty = true
+		}
+		return
+	}
+	if _, ok := clients.NonEntityTokens[tokenAccessor]; !ok {
+		clients.NonEntityTokens[tokenAccessor] = struct{}{}
+		month.dirty = true
+	}
+}
+
+// load reads the activity of the current month from storage and starts
+// recording
+func (a *activityLog) load(ctx context.Context, view logical.Storage, now time.Time) error {
+	a.l.Lock()
+	defer a.l.Unlock()
+
+	start := activityMonthStart(now)
+	month, err := readActivityMonth(ctx, view, start)
+	if err != nil {
+		return err
+	}
+	if month == nil {
+		month = newActivityMonth(start)
+	}
+
+	a.view = view
+	a.months = map[time.Time]*activityMonth{start: month}
+	a.lastPersist = now
+	return nil
+}
+
+// persistIfDue persists the activity if activityLogPersistInterval elapsed
+// since it was last persisted
+func (a *activityLog) persistIfDue(ctx context.Context, now time.Time) error {
+	a.l.Lock()
+	due := now.Sub(a.lastPersist) >= activityLogPersistInterval
+	a.l.Unlock()
+
+	if !due {
+		return nil
+	}
+	return a.persist(ctx, now)
+}
+
+// persist writes the months with new clients to storage, forgets the past
+// ones and prunes the months beyond the retention period. The months are
+// copied under the lock and written outside of it, so that recording isn't
+// blocked on storage.
+func (a *activityLog) persist(ctx context.Context, now time.Time) error {
+	a.persistLock.Lock()
+	defer a.persistLock.Unlock()
+
+	a.l.Lock()
+	view := a.view
+	if view == nil {
+		a.l.Unlock()
+		return nil
+	}
+	a.lastPersist = now
+
+	current := activityMonthStart(now)
+	entries := make(map[time.Time]*activityMonthEntry)
+	for start, month := range a.months {
+		if month.dirty {
+			entries[start] = month.entry()
+			month.dirty = false
+		} else if start.Before(current) {
+			delete(a.months, start)
+		}
+	}
+	a.l.Unlock()
+
+	for start, entry := range entries {
+		if err := writeActivityMonth(ctx, view, entry); err != nil {
+			// Write the month again on the next attempt
+			a.l.Lock()
+			if month, ok := a.months[start]; ok {
+				month.dirty = true
+			}
+			a.l.Unlock()
+			return err
+		}
+	}
+
+	// Past months are only dropped once written, unless clients were
+	// recorded in the meantime
+	a.l.Lock()
+	for start := range entries {
+		if month, ok := a.months[start]; ok && !month.dirty && start.Before(current) {
+			delete(a.months, start)
+		}
+	}
+	a.l.Unlock()
+
+	keys, err := view.List(ctx, activityLogPrefix)
+	if err != nil {
+		return errwrap.Wrapf("failed to list client activity: {{err}}", err)
+	}
+	oldest := current.AddDate(0, 1-activityLogRetentionMonths, 0)
+	for _, key := range keys {
+		start, err := time.Parse(activityLogMonthFormat, strings.TrimSuffix(key, "/"))
+		if err != nil || !start.Before(oldest) {
+			continue
+		}
+		if err := deleteActivityMonth(ctx, view, start, 0); err != nil {
+			return errwrap.Wrapf("failed to prune client activity: {{err}}", err)
+		}
+	}
+
+	return nil
+}
+
+// reset stops recording and drops the activity kept in memory
+func (a *activityLog) reset() {
+	a.l.Lock()
+	defer a.l.Unlock()
+
+	a.view = nil
+	a.months = make(map[time.Time]*activityMonth)
+}
+
+// activity returns the activity of the months overlapping the given period,
+// sorted by date. The months still in memory take precedence over storage.
+func (a *activityLog) activity(ctx context.Context, start, end time.Time) ([]*activityMonth, error) {
+	a.l.Lock()
+	view := a.view
+	if view == nil {
+		a.l.Unlock()
+		return nil, nil
+	}
+	inMemory := make(map[time.Time]*activityMonth)
+	for month := activityMonthStart(start); !month.After(end); month = month.AddDate(0, 1, 0) {
+		if m, ok := a.months[month]; ok {
+			copied := newActivityMonth(month)
+			for path, clients := range m.Mounts {
+				copied.Mounts[path] = newActivityMountClients()
+				copied.Mounts[path].merge(clients)
+			}
+			inMemory[month] = copied
+		}
+	}
+	a.l.Unlock()
+
+	var months []*activityMonth
+	for month := activityMonthStart(start); !month.After(end); month = month.AddDate(0, 1, 0) {
+		if m, ok := inMemory[month]; ok {
+			months = append(months, m)
+			continue
+		}
+
+		m, err := readActivityMonth(ctx, view, month)
+		if err != nil {
+			return nil, err
+		}
+		if m != nil {
+			months = append(months, m)
+		}
+	}
+	return months, nil
+}
+
+func activityMonthPrefix(start time.Time) string {
+	return activityLogPrefix + start.Format(activityLogMonthFormat) + "/"
+}
+
+// readActivityMonth reads the segments of a month, or returns nil if none
+// were stored
+func readActivityMonth(ctx context.Context, view logical.Storage, start time.Time) (*activityMonth, error) {
+	prefix := activityMonthPrefix(start)
+	keys, err := view.List(ctx, prefix)
+	if err != nil {
+		return nil, errwrap.Wrapf(fmt.Sprintf("failed to list client activity of %s: {{err}}", start.Format(activityLogMonthFormat)), err)
+	}
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	month := newActivityMonth(start)
+	for _, key := range keys {
+		raw, err := view.Get(ctx, prefix+key)
+		if err != nil {
+			return nil, errwrap.Wrapf(fmt.Sprintf("failed to read client activity of %s: {{err}}", start.Format(activityLogMonthFormat)), err)
+		}
+		if raw == nil {
+			continue
+		}
+
+		var entry activityMonthEntry
+		if err := raw.DecodeJSON(&entry); err != nil {
+			return nil, errwrap.Wrapf(fmt.Sprintf("failed to decode client activity of %s: {{err}}", start.Format(activityLogMonthFormat)), err)
+		}
+		for path, clients := range entry.month().Mounts {
+			if _, ok := month.Mounts[path]; !ok {
+				month.Mounts[path] = newActivityMountClients()
+			}
+			month.Mounts[path].merge(clients)
+		}
+	}
+	return month, nil
+}
+
+// writeActivityMonth writes the segments of a month, and deletes the ones left
+// over from a previous write
+func writeActivityMonth(ctx context.Context, view logical.Storage, entry *activityMonthEntry) error {
+	segments := entry.segments(activityLogSegmentClients)
+	for i, segment := range segments {
+		raw, err := logical.StorageEntryJSON(activityMonthPrefix(entry.Start)+strconv.Itoa(i), segment)
+		if err != nil {
+			return err
+		}
+		if err := view.Put(ctx, raw); err != nil {
+			return errwrap.Wrapf("failed to persist client activity: {{err}}", err)
+		}
+	}
+	if err := deleteActivityMonth(ctx, view, entry.Start, len(segments)); err != nil {
+		return errwrap.Wrapf("failed to persist client activity: {{err}}", err)
+	}
+	return nil
+}
+
+// deleteActivityMonth deletes the segments of a month from the given index on
+func deleteActivityMonth(ctx context.Context, view logical.Storage, start time.Time, from int) error {
+	prefix := activityMonthPrefix(start)
+	keys, err := view.List(ctx, prefix)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if i, err := strconv.Atoi(key); err == nil && i < from {
+			continue
+		}
+		if err := view.Delete(ctx, prefix+key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// activityCounts are the client counts of a mount or a set of mounts over a
+// period
+type activityCounts struct {
+	DistinctEntities int
+	NonEntityTokens  int
+}
+
+func (c activityCounts) clients() int {
+	return c.DistinctEntities + c.NonEntityTokens
+}
+
+func (c activityCounts) data() map[string]interface{} {
+	return map[string]interface{}{
+		"distinct_entities": c.DistinctEntities,
+		"non_entity_tokens": c.NonEntityTokens,
+		"clients":           c.clients(),
+	}
+}
+
+// activityMountCounts are the client counts of a mount
+type activityMountCounts struct {
+	Mount string
+	activityCounts
+}
+
+// activityReport counts the distinct clients of a set of months, in total and
+// by mount. An entity using several mounts is counted once in the total.
+type activityReport struct {
+	Total   activityCounts
+	ByMount []activityMountCounts
+}
+
+func newActivityReport(months []*activityMonth) *activityReport {
+	all := newActivityMountClients()
+	byMount := make(map[string]*activityMountClients)
+	for _, month := range months {
+		for path, clients := range month.Mounts {
+			all.merge(clients)
+			if _, ok := byMount[path]; !ok {
+				byMount[path] = newActivityMountClients()
+			}
+			byMount[path].merge(clients)
+		}
+	}
+
+	report := &activityReport{
+		Total: activityCounts{
+			DistinctEntities: len(all.Entities),
+			NonEntityTokens:  len(all.NonEntityTokens),
+		},
+		ByMount: make([]activityMountCounts, 0, len(byMount)),
+	}
+	for path, clients := range byMount {
+		report.ByMount = append(report.ByMount, activityMountCounts{
+			Mount: path,
+			activityCounts: activityCounts{
+				DistinctEntities: len(clients.Entities),
+				NonEntityTokens:  len(clients.NonEntityTokens),
+			},
+		})
+	}
+	sort.Slice(report.ByMount, func(i, j int) bool {
+		if report.ByMount[i].clients() != report.ByMount[j].clients() {
+			return report.ByMount[i].clients() > report.ByMount[j].clients()
+		}
+		return report.ByMount[i].Mount < report.ByMount[j].Mount
+	})
+	return report
+}
+
+// data returns the report in the form of a response
+func (r *activityReport) data() map[string]interface{} {
+	byMount := make([]map[string]interface{}, 0, len(r.ByMount))
+	for _, counts := range r.ByMount {
+		data := counts.data()
+		data["mount"] = counts.Mount
+		byMount = append(byMount, data)
+	}
+	return map[string]interface{}{
+		"total":    r.Total.data(),
+		"by_mount": byMount,
+	}
+}
+
+// activityCSV exports the client counts of each month, in total and by mount,
+// as CSV. The total of a month is reported with an empty mount.
+func activityCSV(months []*activityMonth) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"month", "mount", "distinct_entities", "non_entity_tokens", "clients"})
+	for _, month := range months {
+		report := newActivityReport([]*activityMonth{month})
+		rows := append([]activityMountCounts{{activityCounts: report.Total}}, report.ByMount...)
+		for _, row := range rows {
+			w.Write([]string{
+				month.Start.Format(activityLogMonthFormat),
+				row.Mount,
+				strconv.Itoa(row.DistinctEntities),
+				strconv.Itoa(row.NonEntityTokens),
+				strconv.Itoa(row.clients()),
+			})
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// setupActivityLog starts recording the client activity
+func (c *Core) setupActivityLog(ctx context.Context) error {
+	return c.activityLog.load(ctx, c.systemBarrierView, time.Now())
+}
+
+// teardownActivityLog persists the client activity and stops recording
+func (c *Core) teardownActivityLog(ctx context.Context) error {
+	err := c.activityLog.persist(ctx, time.Now())
+	c.activityLog.reset()
+	return err
+}
@@ -0,0 +1,247 @@
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/logical"
+)
+
+func TestActivityLog_RecordAndPersist(t *testing.T) {
+	ctx := context.Background()
+	view := &logical.InmemStorage{}
+	october := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	a := newActivityLog()
+	a.record("entity1", "accessor1", "auth/userpass/", october)
+	if len(a.months) != 0 {
+		t.Fatal("expected no activity to be recorded before loading")
+	}
+
+	if err := a.load(ctx, view, october); err != nil {
+		t.Fatal(err)
+	}
+	a.record("entity1", "accessor1", "auth/userpass/", october)
+	a.record("entity1", "accessor2", "auth/userpass/", october)
+	a.record("entity1", "accessor3", "auth/approle/", october)
+	a.record("", "accessor4", "auth/token/", october)
+	a.record("", "accessor4", "auth/token/", october)
+	a.record("entity2", "accessor5", "auth/userpass/", october.AddDate(0, 1, 0))
+
+	months, err := a.activity(ctx, october, october.AddDate(0, 1, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(months) != 2 {
+		t.Fatalf("expected 2 months, got %d", len(months))
+	}
+
+	report := newActivityReport(months[:1])
+	if report.Total != (activityCounts{DistinctEntities: 1, NonEntityTokens: 1}) {
+		t.Fatalf("bad: %#v", report.Total)
+	}
+	expected := []activityMountCounts{
+		{Mount: "auth/approle/", activityCounts: activityCounts{DistinctEntities: 1}},
+		{Mount: "auth/token/", activityCounts: activityCounts{NonEntityTokens: 1}},
+		{Mount: "auth/userpass/", activityCounts: activityCounts{DistinctEntities: 1}},
+	}
+	if !reflect.DeepEqual(report.ByMount, expected) {
+		t.Fatalf("bad: %#v", report.ByMount)
+	}
+
+	report = newActivityReport(months)
+	if report.Total != (activityCounts{DistinctEntities: 2, NonEntityTokens: 1}) {
+		t.Fatalf("bad: %#v", report.Total)
+	}
+
+	// The activity survives a restart once persisted, and the past months
+	// are only kept in storage
+	if err := a.persist(ctx, october.AddDate(0, 1, 0)); err != nil {
+		t.Fatal(err)
+	}
+	if len(a.months) != 1 {
+		t.Fatalf("expected only the current month in memory, got %d", len(a.months))
+	}
+	a.reset()
+	if err := a.load(ctx, view, october); err != nil {
+		t.Fatal(err)
+	}
+	reloaded, err := a.activity(ctx, october, october.AddDate(0, 1, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(newActivityReport(reloaded), newActivityReport(months)) {
+		t.Fatalf("bad: %#v", newActivityReport(reloaded))
+	}
+
+	csv, err := activityCSV(reloaded[:1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedCSV := `month,mount,distinct_entities,non_entity_tokens,clients
+2026-10,,1,1,2
+2026-10,auth/approle/,1,0,1
+2026-10,auth/token/,0,1,1
+2026-10,auth/userpass/,1,0,1
+`
+	if string(csv) != expectedCSV {
+		t.Fatalf("bad: %s", csv)
+	}
+
+	// Months beyond the retention period are pruned
+	a.record("entity1", "accessor1", "auth/userpass/", october)
+	if err := a.persist(ctx, october.AddDate(0, activityLogRetentionMonths, 0)); err != nil {
+		t.Fatal(err)
+	}
+	keys, err := view.List(ctx, activityLogPrefix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(keys, []string{"2026-11/"}) {
+		t.Fatalf("bad: %v", keys)
+	}
+}
+
+func TestActivityLog_Segments(t *testing.T) {
+	ctx := context.Background()
+	view := &logical.InmemStorage{}
+	october := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	a := newActivityLog()
+	if err := a.load(ctx, view, october); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < activityLogSegmentClients; i++ {
+		a.record(fmt.Sprintf("entity%d", i), "", "auth/userpass/", october)
+	}
+	a.record("", "accessor1", "auth/token/", october)
+	if err := a.persist(ctx, october); err != nil {
+		t.Fatal(err)
+	}
+
+	// Each segment holds at most activityLogSegmentClients clients
+	keys, err := view.List(ctx, activityMonthPrefix(october))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(keys, []string{"0", "1"}) {
+		t.Fatalf("bad: %v", keys)
+	}
+
+	a.reset()
+	if err := a.load(ctx, view, october); err != nil {
+		t.Fatal(err)
+	}
+	months, err := a.activity(ctx, october, october)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := newActivityReport(months)
+	if report.Total != (activityCounts{DistinctEntities: activityLogSegmentClients, NonEntityTokens: 1}) {
+		t.Fatalf("bad: %#v", report.Total)
+	}
+
+	// Segments left over from a larger write are deleted
+	entry := &activityMonthEntry{Start: october}
+	if err := writeActivityMonth(ctx, view, entry); err != nil {
+		t.Fatal(err)
+	}
+	keys, err = view.List(ctx, activityMonthPrefix(october))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(keys, []string{"0"}) {
+		t.Fatalf("bad: %v", keys)
+	}
+}
+
+func TestSystemBackend_CountersActivity(t *testing.T) {
+	c, b, root := testCoreSystemBackend(t)
+	ctx := namespace.RootContext(nil)
+
+	resp, err := c.HandleRequest(ctx, &logical.Request{
+		Operation:   logical.UpdateOperation,
+		Path:        "auth/token/create",
+		ClientToken: root,
+	})
+	if err != nil || resp.IsError() {
+		t.Fatalf("bad: resp: %#v err: %v", resp, err)
+	}
+	for _, token := range []string{root, resp.Auth.ClientToken, root} {
+		resp, err := c.HandleRequest(ctx, &logical.Request{
+			Operation:   logical.ReadOperation,
+			Path:        "sys/mounts",
+			ClientToken: token,
+		})
+		if err != nil || resp.IsError() {
+			t.Fatalf("bad: resp: %#v err: %v", resp, err)
+		}
+	}
+
+	req := logical.TestRequest(t, logical.ReadOperation, "internal/counters/activity")
+	resp, err = b.HandleRequest(ctx, req)
+	if err != nil || resp == nil {
+		t.Fatalf("bad: resp: %#v err: %v", resp, err)
+	}
+	total := resp.Data["total"].(map[string]interface{})
+	if total["non_entity_tokens"] != 2 || total["distinct_entities"] != 0 || total["clients"] != 2 {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+	byMount := resp.Data["by_mount"].([]map[string]interface{})
+	if len(byMount) != 1 || byMount[0]["mount"] != "auth/token/" {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+	if months := resp.Data["months"].([]map[string]interface{}); len(months) != 1 || months[0]["month"] != time.Now().UTC().Format(activityLogMonthFormat) {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	req = logical.TestRequest(t, logical.ReadOperation, "internal/counters/activity")
+	req.Data["end_time"] = time.Now().AddDate(-1, 0, 0).Format(time.RFC3339)
+	resp, err = b.HandleRequest(ctx, req)
+	if err != nil || resp == nil {
+		t.Fatalf("bad: resp: %#v err: %v", resp, err)
+	}
+	if total := resp.Data["total"].(map[string]interface{}); total["clients"] != 0 {
+		t.Fatalf("expected no activity a year ago, got %#v", resp.Data)
+	}
+
+	req = logical.TestRequest(t, logical.ReadOperation, "internal/counters/activity")
+	req.Data["start_time"] = "yesterday"
+	if resp, err := b.HandleRequest(ctx, req); err != logical.ErrInvalidRequest {
+		t.Fatalf("expected an invalid start time to be rejected: resp: %#v err: %v", resp, err)
+	}
+
+	req = logical.TestRequest(t, logical.ReadOperation, "internal/counters/activity/export")
+	resp, err = b.HandleRequest(ctx, req)
+	if err != nil || resp == nil {
+		t.Fatalf("bad: resp: %#v err: %v", resp, err)
+	}
+	if resp.Data[logical.HTTPContentType] != "application/json" {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+	var exported []map[string]interface{}
+	if err := json.Unmarshal(resp.Data[logical.HTTPRawBody].([]byte), &exported); err != nil {
+		t.Fatal(err)
+	}
+	if len(exported) != 1 {
+		t.Fatalf("bad: %s", resp.Data[logical.HTTPRawBody])
+	}
+
+	req = logical.TestRequest(t, logical.ReadOperation, "internal/counters/activity/export")
+	req.Data["format"] = "csv"
+	resp, err = b.HandleRequest(ctx, req)
+	if err != nil || resp == nil || resp.Data[logical.HTTPContentType] != "text/csv" {
+		t.Fatalf("bad: resp: %#v err: %v", resp, err)
+	}
+
+	req = logical.TestRequest(t, logical.ReadOperation, "internal/counters/activity/export")
+	req.Data["format"] = "xml"
+	if resp, err := b.HandleRequest(ctx, req); err != logical.ErrInvalidRequest {
+		t.Fatalf("expected an invalid format to be rejected: resp: %#v err: %v", resp, err)
+	}
+}
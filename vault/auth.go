@@ -10,6 +10,7 @@ import (
 	"github.com/hashicorp/vault/builtin/plugin"
 	"github.com/hashicorp/vault/helper/consts"
 	"github.com/hashicorp/vault/helper/jsonutil"
+	"github.com/hashicorp/vault/helper/logging"
 	"github.com/hashicorp/vault/helper/namespace"
 	"github.com/hashicorp/vault/helper/strutil"
 	"github.com/hashicorp/vault/logical"
@@ -183,6 +184,7 @@ func (c *Core) enableCredentialInternal(ctx context.Context, entry *MountEntry,
 	if err := c.router.Mount(backend, credentialRoutePrefix+entry.Path, entry, view); err != nil {
 		return err
 	}
+	c.claimWellKnownPaths(entry, backend)
 
 	if c.logger.IsInfo() {
 		c.logger.Info("enabled credential backend", "path", entry.Path, "type", entry.Type)
@@ -286,6 +288,12 @@ func (c *Core) disableCredentialInternal(ctx context.Context, path string, updat
 
 	removePathCheckers(c, entry, viewPath)
 
+	c.loginRateLimiter.remove(entry.Accessor)
+
+	if err := c.removeUserLockouts(ctx, entry.Accessor); err != nil {
+		return err
+	}
+
 	if c.logger.IsInfo() {
 		c.logger.Info("disabled credential backend", "path", path)
 	}
@@ -612,6 +620,7 @@ func (c *Core) setupCredentials(ctx context.Context) error {
 		if backend == nil {
 			return fmt.Errorf("nil backend returned from %q factory", entry.Type)
 		}
+		c.logDeprecatedBuiltin(entry)
 
 		{
 			// Check for the correct backend type
@@ -639,6 +648,7 @@ func (c *Core) setupCredentials(ctx context.Context) error {
 			c.logger.Error("failed to mount auth entry", "path", entry.Path, "error", err)
 			return errLoadAuthFailed
 		}
+		c.claimWellKnownPaths(entry, backend)
 
 		if c.logger.IsInfo() {
 			c.logger.Info("successfully enabled credential backend", "type", entry.Type, "path", entry.Path)
@@ -727,7 +737,7 @@ func (c *Core) newCredentialBackend(ctx context.Context, entry *MountEntry, sysV
 
 	conf["plugin_type"] = consts.PluginTypeCredential.String()
 
-	authLogger := c.baseLogger.Named(fmt.Sprintf("auth.%s.%s", t, entry.Accessor))
+	authLogger := logging.NewSubsystemLogger(c.baseLogger, fmt.Sprintf("auth.%s.%s", t, entry.Accessor))
 	c.AddLogger(authLogger)
 	config := &logical.BackendConfig{
 		StorageView: view,
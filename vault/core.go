@@ -10,7 +10,9 @@ import (
 	"net"
 	"net/http"
 	"net/url"
+	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -25,6 +27,7 @@ import (
 
 	"github.com/hashicorp/errwrap"
 	"github.com/hashicorp/vault/audit"
+	"github.com/hashicorp/vault/command/server"
 	"github.com/hashicorp/vault/helper/consts"
 	"github.com/hashicorp/vault/helper/jsonutil"
 	"github.com/hashicorp/vault/helper/logging"
@@ -152,6 +155,10 @@ type Core struct {
 	// if it's used directly, it results in import cycles.
 	builtinRegistry BuiltinRegistry
 
+	// deprecatedBuiltins overrides whether deprecated builtin plugins can be
+	// mounted, see deprecatedBuiltinsEnv
+	deprecatedBuiltins string
+
 	// N.B.: This is used to populate a dev token down replication, as
 	// otherwise, after replication is started, a dev would have to go through
 	// the generate-root process simply to talk to the new follower cluster.
@@ -251,6 +258,51 @@ type Core struct {
 	// can be output in the audit logs
 	auditedHeaders *AuditedHeadersConfig
 
+	// loginRateLimiter throttles login requests on auth mounts that have
+	// been tuned with a login rate limit
+	loginRateLimiter *loginRateLimiter
+
+	// userLockout locks aliases out of auth mounts that have been tuned with
+	// a user lockout threshold, after repeated failed logins
+	userLockout *userLockout
+
+	// renewRateLimiter throttles the renewals made by each token
+	renewRateLimiter *renewRateLimiter
+
+	// requestConcurrencyLimiter caps the requests each token has in flight
+	requestConcurrencyLimiter *requestConcurrencyLimiter
+
+	// rawConfig is the configuration the server was started or last
+	// reloaded with
+	rawConfig *atomic.Value
+
+	// loginMFA holds the MFA methods and the login enforcements requiring
+	// them
+	loginMFA *loginMFA
+
+	// rateLimitQuotas holds the rate limit quotas requests are held to
+	// before they are routed
+	rateLimitQuotas *rateLimitQuotas
+
+	// leaseCountQuotas holds the lease count quotas capping the active
+	// leases under a path
+	leaseCountQuotas *leaseCountQuotas
+
+	// renewCoalesceWindow is the window within which redundant renewals of
+	// a lease are answered from the lease, zero if coalescing is disabled
+	renewCoalesceWindow time.Duration
+
+	// tokenUsage aggregates request volume by token policy and auth mount
+	tokenUsage *tokenUsageTracker
+
+	// activityLog counts the distinct clients making requests each month
+	activityLog *activityLog
+
+	// storageMigrations are the storage migrations applied at unseal, and
+	// migrationStatus tracks their progress
+	storageMigrations []*storageMigration
+	migrationStatus   *storageMigrationStatus
+
 	// systemBackend is the backend which is used to manage internal operations
 	systemBackend *SystemBackend
 
@@ -392,6 +444,11 @@ type Core struct {
 	// Stores the sealunwrapper for downgrade needs
 	sealUnwrapper physical.Backend
 
+	// storageProbeLock guards storageProbeResult, the outcome of the last run
+	// of the storage probes
+	storageProbeLock   sync.Mutex
+	storageProbeResult *StorageProbeResult
+
 	// unsealwithStoredKeysLock is a mutex that prevents multiple processes from
 	// unsealing with stored keys are the same time.
 	unsealWithStoredKeysLock sync.Mutex
@@ -409,8 +466,11 @@ type Core struct {
 
 	licensingStopCh chan struct{}
 
-	// Stores loggers so we can reset the level
+	// Stores loggers so we can reset the level. logLevel is the level of the
+	// loggers whose subsystem doesn't have one in logLevels.
 	allLoggers     []log.Logger
+	logLevel       log.Level
+	logLevels      map[string]log.Level
 	allLoggersLock sync.RWMutex
 
 	// Can be toggled atomically to cause the core to never try to become
@@ -488,6 +548,28 @@ type CoreConfig struct {
 	DisableKeyEncodingChecks  bool
 
 	AllLoggers []log.Logger
+
+	// LogLevels overrides the level of the loggers of the given subsystems,
+	// e.g. "expiration" or "storage.consul"
+	LogLevels map[string]log.Level
+
+	// RenewRateLimit is the number of renewals each token can make over
+	// RenewRateLimitPeriod, zero for no limit
+	RenewRateLimit       int
+	RenewRateLimitPeriod time.Duration
+
+	// RequestConcurrencyLimit is the number of requests each token can have
+	// in flight at once, zero for no limit
+	RequestConcurrencyLimit int
+
+	// RawConfig is the configuration the server was started with, reported
+	// sanitized by sys/config/state/sanitized
+	RawConfig *server.Config
+
+	// RenewCoalesceWindow is the window within which renewals of a lease
+	// that would barely extend it are answered without renewing it again,
+	// zero to disable coalescing
+	RenewCoalesceWindow time.Duration
 }
 
 func (c *CoreConfig) Clone() *CoreConfig {
@@ -520,6 +602,12 @@ func (c *CoreConfig) Clone() *CoreConfig {
 		DisablePerformanceStandby: c.DisablePerformanceStandby,
 		DisableIndexing:           c.DisableIndexing,
 		AllLoggers:                c.AllLoggers,
+		LogLevels:                 c.LogLevels,
+		RenewRateLimit:            c.RenewRateLimit,
+		RenewRateLimitPeriod:      c.RenewRateLimitPeriod,
+		RequestConcurrencyLimit:   c.RequestConcurrencyLimit,
+		RawConfig:                 c.RawConfig,
+		RenewCoalesceWindow:       c.RenewCoalesceWindow,
 	}
 }
 
@@ -570,7 +658,7 @@ func NewCore(conf *CoreConfig) (*Core, error) {
 		sealed:                           new(uint32),
 		standby:                          true,
 		baseLogger:                       conf.Logger,
-		logger:                           conf.Logger.Named("core"),
+		logger:                           logging.NewSubsystemLogger(conf.Logger, "core"),
 		defaultLeaseTTL:                  conf.DefaultLeaseTTL,
 		maxLeaseTTL:                      conf.MaxLeaseTTL,
 		cachingDisabled:                  conf.DisableCache,
@@ -593,13 +681,32 @@ func NewCore(conf *CoreConfig) (*Core, error) {
 		disablePerfStandby:               true,
 		activeContextCancelFunc:          new(atomic.Value),
 		allLoggers:                       conf.AllLoggers,
+		logLevel:                         logging.Level(conf.Logger),
+		logLevels:                        conf.LogLevels,
 		builtinRegistry:                  conf.BuiltinRegistry,
+		deprecatedBuiltins:               os.Getenv(deprecatedBuiltinsEnv),
 		neverBecomeActive:                new(uint32),
 		clusterLeaderParams:              new(atomic.Value),
+		loginRateLimiter:                 newLoginRateLimiter(),
+		userLockout:                      newUserLockout(),
+		renewRateLimiter:                 newRenewRateLimiter(conf.RenewRateLimit, conf.RenewRateLimitPeriod),
+		requestConcurrencyLimiter:        newRequestConcurrencyLimiter(conf.RequestConcurrencyLimit),
+		rawConfig:                        new(atomic.Value),
+		loginMFA:                         newLoginMFA(),
+		rateLimitQuotas:                  newRateLimitQuotas(),
+		leaseCountQuotas:                 newLeaseCountQuotas(),
+		renewCoalesceWindow:              conf.RenewCoalesceWindow,
+		tokenUsage:                       newTokenUsageTracker(),
+		activityLog:                      newActivityLog(),
+		storageMigrations:                storageMigrations,
+		migrationStatus:                  &storageMigrationStatus{},
 	}
 
 	atomic.StoreUint32(c.sealed, 1)
 	c.allLoggers = append(c.allLoggers, c.logger)
+	for _, logger := range c.allLoggers {
+		c.applyLogLevel(logger)
+	}
 
 	atomic.StoreUint32(c.replicationState, uint32(consts.ReplicationDRDisabled|consts.ReplicationPerformanceDisabled))
 	c.localClusterCert.Store(([]byte)(nil))
@@ -608,6 +715,8 @@ func NewCore(conf *CoreConfig) (*Core, error) {
 
 	c.clusterLeaderParams.Store((*ClusterLeaderParams)(nil))
 
+	c.rawConfig.Store(conf.RawConfig)
+
 	c.activeContextCancelFunc.Store((context.CancelFunc)(nil))
 
 	if conf.ClusterCipherSuites != "" {
@@ -689,7 +798,7 @@ func NewCore(conf *CoreConfig) (*Core, error) {
 
 	logicalBackends["cubbyhole"] = CubbyholeBackendFactory
 	logicalBackends[systemMountType] = func(ctx context.Context, config *logical.BackendConfig) (logical.Backend, error) {
-		sysBackendLogger := conf.Logger.Named("system")
+		sysBackendLogger := logging.NewSubsystemLogger(conf.Logger, "system")
 		c.AddLogger(sysBackendLogger)
 		b := NewSystemBackend(c, sysBackendLogger)
 		if err := b.Setup(ctx, config); err != nil {
@@ -698,7 +807,7 @@ func NewCore(conf *CoreConfig) (*Core, error) {
 		return b, nil
 	}
 	logicalBackends["identity"] = func(ctx context.Context, config *logical.BackendConfig) (logical.Backend, error) {
-		identityLogger := conf.Logger.Named("identity")
+		identityLogger := logging.NewSubsystemLogger(conf.Logger, "identity")
 		c.AddLogger(identityLogger)
 		return NewIdentityStore(ctx, c, config, identityLogger)
 	}
@@ -710,7 +819,7 @@ func NewCore(conf *CoreConfig) (*Core, error) {
 		credentialBackends[k] = f
 	}
 	credentialBackends["token"] = func(ctx context.Context, config *logical.BackendConfig) (logical.Backend, error) {
-		tsLogger := conf.Logger.Named("token")
+		tsLogger := logging.NewSubsystemLogger(conf.Logger, "token")
 		c.AddLogger(tsLogger)
 		return NewTokenStore(ctx, tsLogger, c, config)
 	}
@@ -1402,6 +1511,9 @@ func (s standardUnsealStrategy) unseal(ctx context.Context, logger log.Logger, c
 	}
 
 	if !c.IsDRSecondary() {
+		if err := c.runStorageMigrations(ctx); err != nil {
+			return err
+		}
 		if err := c.ensureWrappingKey(ctx); err != nil {
 			return err
 		}
@@ -1421,6 +1533,15 @@ func (s standardUnsealStrategy) unseal(ctx context.Context, logger log.Logger, c
 	if err := c.loadCORSConfig(ctx); err != nil {
 		return err
 	}
+	if err := c.loadRateLimitQuotas(ctx); err != nil {
+		return err
+	}
+	if err := c.loadLeaseCountQuotas(ctx); err != nil {
+		return err
+	}
+	if err := c.loadLockedUsers(ctx); err != nil {
+		return err
+	}
 	if err := c.loadCredentials(ctx); err != nil {
 		return err
 	}
@@ -1446,6 +1567,9 @@ func (s standardUnsealStrategy) unseal(ctx context.Context, logger log.Logger, c
 		if err := loadMFAConfigs(ctx, c); err != nil {
 			return err
 		}
+		if err := c.setupActivityLog(ctx); err != nil {
+			return err
+		}
 		if err := c.setupAuditedHeadersConfig(ctx); err != nil {
 			return err
 		}
@@ -1537,8 +1661,14 @@ func (c *Core) preSeal() error {
 		close(c.metricsCh)
 		c.metricsCh = nil
 	}
+	c.tokenUsage.reset()
+
 	var result error
 
+	if err := c.teardownActivityLog(context.Background()); err != nil {
+		result = multierror.Append(result, errwrap.Wrapf("error persisting client activity: {{err}}", err))
+	}
+
 	c.clusterParamsLock.Lock()
 	if err := stopReplication(c); err != nil {
 		result = multierror.Append(result, errwrap.Wrapf("error stopping replication: {{err}}", err))
@@ -1601,6 +1731,10 @@ func (c *Core) emitMetrics(stopCh chan struct{}) {
 				c.expiration.emitMetrics()
 			}
 			c.metricsMutex.Unlock()
+			c.tokenUsage.aggregate(time.Now())
+			if err := c.activityLog.persistIfDue(context.Background(), time.Now()); err != nil {
+				c.logger.Error("failed to persist client activity", "error", err)
+			}
 		case <-stopCh:
 			return
 		}
@@ -1728,14 +1862,110 @@ func (c *Core) AddLogger(logger log.Logger) {
 	c.allLoggersLock.Lock()
 	defer c.allLoggersLock.Unlock()
 	c.allLoggers = append(c.allLoggers, logger)
+	c.applyLogLevel(logger)
 }
 
+// SetLogLevel sets the level of all loggers, except those of the subsystems
+// that have their own level.
 func (c *Core) SetLogLevel(level log.Level) {
+	c.allLoggersLock.Lock()
+	defer c.allLoggersLock.Unlock()
+	c.logLevel = level
+	for _, logger := range c.allLoggers {
+		c.applyLogLevel(logger)
+	}
+}
+
+// SetLogLevels replaces the levels of the subsystems that don't log at the
+// level given to SetLogLevel.
+func (c *Core) SetLogLevels(levels map[string]log.Level) {
+	c.allLoggersLock.Lock()
+	defer c.allLoggersLock.Unlock()
+	c.logLevels = levels
+	for _, logger := range c.allLoggers {
+		c.applyLogLevel(logger)
+	}
+}
+
+// LogLevels returns the levels of the loggers of the subsystems, by name.
+func (c *Core) LogLevels() map[string]log.Level {
 	c.allLoggersLock.RLock()
 	defer c.allLoggersLock.RUnlock()
+
+	levels := make(map[string]log.Level, len(c.allLoggers))
 	for _, logger := range c.allLoggers {
+		name := logging.Name(logger)
+		level := logging.Level(logger)
+		if name == "" || level == log.NoLevel {
+			continue
+		}
+		levels[name] = level
+	}
+
+	return levels
+}
+
+// SetSubsystemLogLevel sets the level of the named subsystem and of the
+// subsystems below it, e.g. of all secrets engines for "secrets". It returns
+// false if there is no logger for the subsystem.
+func (c *Core) SetSubsystemLogLevel(name string, level log.Level) bool {
+	c.allLoggersLock.Lock()
+	defer c.allLoggersLock.Unlock()
+
+	found := false
+	for _, logger := range c.allLoggers {
+		loggerName := logging.Name(logger)
+		if loggerName == name || strings.HasPrefix(loggerName, name+".") {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return false
+	}
+
+	levels := make(map[string]log.Level, len(c.logLevels)+1)
+	for k, v := range c.logLevels {
+		if !strings.HasPrefix(k, name+".") {
+			levels[k] = v
+		}
+	}
+	levels[name] = level
+	c.logLevels = levels
+
+	for _, logger := range c.allLoggers {
+		c.applyLogLevel(logger)
+	}
+
+	return true
+}
+
+// applyLogLevel sets the level of the logger to the one of its subsystem.
+// Callers must hold allLoggersLock.
+func (c *Core) applyLogLevel(logger log.Logger) {
+	if level, ok := logging.SubsystemLevel(c.logLevels, logging.Name(logger)); ok {
 		logger.SetLevel(level)
+		return
+	}
+	if c.logLevel != log.NoLevel {
+		logger.SetLevel(c.logLevel)
+	}
+}
+
+// SetConfig replaces the configuration reported by
+// sys/config/state/sanitized, once the server configuration is reloaded.
+func (c *Core) SetConfig(conf *server.Config) {
+	c.rawConfig.Store(conf)
+}
+
+// SanitizedConfig returns the server configuration without the values that
+// may hold secrets, or nil if the core was not given one.
+func (c *Core) SanitizedConfig() map[string]interface{} {
+	conf := c.rawConfig.Load().(*server.Config)
+	if conf == nil {
+		return nil
 	}
+	return conf.Sanitized()
 }
 
 // BuiltinRegistry is an interface that allows the "vault" package to use
@@ -1745,4 +1975,5 @@ type BuiltinRegistry interface {
 	Contains(name string, pluginType consts.PluginType) bool
 	Get(name string, pluginType consts.PluginType) (func() (interface{}, error), bool)
 	Keys(pluginType consts.PluginType) []string
+	DeprecationStatus(name string, pluginType consts.PluginType) (consts.DeprecationStatus, bool)
 }
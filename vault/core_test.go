@@ -3,6 +3,7 @@ package vault
 import (
 	"context"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -2385,3 +2386,53 @@ func TestCore_HandleRequest_Headers_denyList(t *testing.T) {
 		t.Fatalf("did not expect %q to be in the headers map", consts.AuthHeaderName)
 	}
 }
+
+func TestCore_LogLevels(t *testing.T) {
+	c := TestCoreWithSealAndUI(t, &CoreConfig{
+		LogLevels: map[string]log.Level{
+			"expiration": log.Debug,
+			"secrets":    log.Warn,
+		},
+	})
+	c, _, _ = testCoreUnsealed(t, c)
+
+	secretsLevels := func() []log.Level {
+		var levels []log.Level
+		c.allLoggersLock.RLock()
+		defer c.allLoggersLock.RUnlock()
+		for _, logger := range c.allLoggers {
+			if strings.HasPrefix(logging.Name(logger), "secrets.") {
+				levels = append(levels, logging.Level(logger))
+			}
+		}
+		return levels
+	}
+	checkLevels := func(core, expiration, secrets log.Level) {
+		t.Helper()
+		if level := logging.Level(c.logger); level != core {
+			t.Fatalf("expected core level %d, got %d", core, level)
+		}
+		if level := logging.Level(c.expiration.logger); level != expiration {
+			t.Fatalf("expected expiration level %d, got %d", expiration, level)
+		}
+		levels := secretsLevels()
+		if len(levels) == 0 {
+			t.Fatal("expected loggers of secrets engines")
+		}
+		for _, level := range levels {
+			if level != secrets {
+				t.Fatalf("expected secrets level %d, got %d", secrets, level)
+			}
+		}
+	}
+
+	checkLevels(log.Trace, log.Debug, log.Warn)
+
+	c.SetLogLevel(log.Error)
+	checkLevels(log.Error, log.Debug, log.Warn)
+
+	c.SetLogLevels(map[string]log.Level{
+		"core": log.Info,
+	})
+	checkLevels(log.Info, log.Error, log.Error)
+}
@@ -6,6 +6,7 @@ import (
 	"context"
 
 	"github.com/hashicorp/vault/helper/license"
+	"github.com/hashicorp/vault/helper/logging"
 	"github.com/hashicorp/vault/helper/namespace"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/physical"
@@ -18,11 +19,11 @@ type LicensingConfig struct{}
 func coreInit(c *Core, conf *CoreConfig) error {
 	phys := conf.Physical
 	_, txnOK := phys.(physical.Transactional)
-	sealUnwrapperLogger := conf.Logger.Named("storage.sealunwrapper")
+	sealUnwrapperLogger := logging.NewSubsystemLogger(conf.Logger, "storage.sealunwrapper")
 	c.allLoggers = append(c.allLoggers, sealUnwrapperLogger)
 	c.sealUnwrapper = NewSealUnwrapper(phys, sealUnwrapperLogger)
 	// Wrap the physical backend in a cache layer if enabled
-	cacheLogger := c.baseLogger.Named("storage.cache")
+	cacheLogger := logging.NewSubsystemLogger(c.baseLogger, "storage.cache")
 	c.allLoggers = append(c.allLoggers, cacheLogger)
 	if txnOK {
 		c.physical = physical.NewTransactionalCache(c.sealUnwrapper, conf.CacheSize, cacheLogger)
@@ -71,7 +72,7 @@ func postUnsealPhysical(c *Core) error {
 	return nil
 }
 
-func loadMFAConfigs(context.Context, *Core) error { return nil }
+func loadMFAConfigs(ctx context.Context, c *Core) error { return c.loadLoginMFA(ctx) }
 
 func shouldStartClusterListener(*Core) bool { return true }
 
@@ -0,0 +1,97 @@
+package vault
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/vault/helper/consts"
+)
+
+const (
+	// deprecatedBuiltinsEnv is the environment variable overriding whether
+	// deprecated builtin plugins can be mounted. With "allow", plugins
+	// pending removal can be mounted too; with "block", no deprecated plugin
+	// can be mounted. By default, deprecated plugins are mounted with a
+	// warning and plugins pending removal are refused.
+	deprecatedBuiltinsEnv = "VAULT_DEPRECATED_BUILTINS"
+
+	deprecatedBuiltinsAllow = "allow"
+	deprecatedBuiltinsBlock = "block"
+)
+
+// mountEntryPlugin returns the name and the type of the plugin backing a
+// mount entry
+func mountEntryPlugin(entry *MountEntry) (string, consts.PluginType) {
+	name := entry.Type
+	if name == "plugin" {
+		name = entry.Config.PluginName
+	}
+
+	if entry.Table == credentialTableType {
+		if alias, ok := credentialAliases[name]; ok {
+			name = alias
+		}
+		return name, consts.PluginTypeCredential
+	}
+	if alias, ok := mountAliases[name]; ok {
+		name = alias
+	}
+	return name, consts.PluginTypeSecrets
+}
+
+// builtinDeprecationStatus returns the deprecation status of the builtin
+// plugin backing a mount entry, and false if it isn't a builtin plugin
+func (c *Core) builtinDeprecationStatus(entry *MountEntry) (consts.DeprecationStatus, bool) {
+	if c.builtinRegistry == nil {
+		return consts.Unknown, false
+	}
+
+	name, pluginType := mountEntryPlugin(entry)
+	return c.builtinRegistry.DeprecationStatus(name, pluginType)
+}
+
+// checkDeprecatedBuiltin checks whether a new mount of the builtin plugin is
+// allowed. It returns a warning to surface to the client if the plugin is
+// deprecated but can be mounted, and an error if it can't be mounted.
+func (c *Core) checkDeprecatedBuiltin(entry *MountEntry) (string, error) {
+	status, ok := c.builtinDeprecationStatus(entry)
+	if !ok {
+		return "", nil
+	}
+
+	name, pluginType := mountEntryPlugin(entry)
+	kind := "secrets engine"
+	if pluginType == consts.PluginTypeCredential {
+		kind = "auth method"
+	}
+
+	switch status {
+	case consts.Deprecated:
+		if c.deprecatedBuiltins == deprecatedBuiltinsBlock {
+			return "", fmt.Errorf("the builtin %s %q is deprecated, and mounting deprecated builtins is blocked by %s", kind, name, deprecatedBuiltinsEnv)
+		}
+		return fmt.Sprintf("the builtin %s %q is deprecated and will be removed in a future release", kind, name), nil
+
+	case consts.PendingRemoval:
+		if c.deprecatedBuiltins != deprecatedBuiltinsAllow {
+			return "", fmt.Errorf("the builtin %s %q is pending removal and can no longer be mounted, unless %s is set to %q", kind, name, deprecatedBuiltinsEnv, deprecatedBuiltinsAllow)
+		}
+		return fmt.Sprintf("the builtin %s %q is pending removal and will be removed in the next release", kind, name), nil
+
+	case consts.Removed:
+		return "", fmt.Errorf("the builtin %s %q has been removed", kind, name)
+	}
+
+	return "", nil
+}
+
+// logDeprecatedBuiltin logs a warning when a mount entry backed by a
+// deprecated builtin plugin is set up
+func (c *Core) logDeprecatedBuiltin(entry *MountEntry) {
+	status, ok := c.builtinDeprecationStatus(entry)
+	if !ok || status == consts.Supported {
+		return
+	}
+
+	name, _ := mountEntryPlugin(entry)
+	c.logger.Warn("mount is backed by a deprecated builtin plugin", "path", entry.Path, "plugin", name, "status", status.String())
+}
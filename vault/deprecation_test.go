@@ -0,0 +1,121 @@
+package vault
+
+import (
+	"testing"
+
+	"github.com/hashicorp/vault/helper/consts"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/logical"
+)
+
+// deprecatedBuiltinRegistry reports the status of the test backends as
+// given by statuses, keyed by plugin type and name
+type deprecatedBuiltinRegistry struct {
+	*mockBuiltinRegistry
+	statuses map[consts.PluginType]map[string]consts.DeprecationStatus
+}
+
+func (r *deprecatedBuiltinRegistry) DeprecationStatus(name string, pluginType consts.PluginType) (consts.DeprecationStatus, bool) {
+	status, ok := r.statuses[pluginType][name]
+	return status, ok
+}
+
+func testDeprecatedBuiltinCore(t *testing.T, mode string, secretsStatus, authStatus consts.DeprecationStatus) (*Core, logical.Backend) {
+	c, b, _ := testCoreSystemBackend(t)
+	c.builtinRegistry = &deprecatedBuiltinRegistry{
+		mockBuiltinRegistry: NewMockBuiltinRegistry(),
+		statuses: map[consts.PluginType]map[string]consts.DeprecationStatus{
+			consts.PluginTypeSecrets:    {"kv": secretsStatus},
+			consts.PluginTypeCredential: {"noop": authStatus},
+		},
+	}
+	c.deprecatedBuiltins = mode
+	return c, b
+}
+
+func TestSystemBackend_mount_deprecatedBuiltin(t *testing.T) {
+	mount := func(t *testing.T, b logical.Backend, path, typ string) *logical.Response {
+		req := logical.TestRequest(t, logical.UpdateOperation, path)
+		req.Data["type"] = typ
+		resp, err := b.HandleRequest(namespace.RootContext(nil), req)
+		if err != nil && err != logical.ErrInvalidRequest {
+			t.Fatalf("err: %v", err)
+		}
+		return resp
+	}
+
+	t.Run("deprecated", func(t *testing.T) {
+		_, b := testDeprecatedBuiltinCore(t, "", consts.Deprecated, consts.Deprecated)
+
+		resp := mount(t, b, "mounts/prod/secret/", "kv")
+		if resp == nil || resp.IsError() || len(resp.Warnings) != 1 {
+			t.Fatalf("expected a warning: %#v", resp)
+		}
+		resp = mount(t, b, "auth/foo", "noop")
+		if resp == nil || resp.IsError() || len(resp.Warnings) != 1 {
+			t.Fatalf("expected a warning: %#v", resp)
+		}
+
+		resp, err := b.HandleRequest(namespace.RootContext(nil), logical.TestRequest(t, logical.ReadOperation, "mounts"))
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		info := resp.Data["prod/secret/"].(map[string]interface{})
+		if info["deprecation_status"] != "deprecated" {
+			t.Fatalf("bad: %#v", info)
+		}
+		// Mounts of other plugins have no status
+		if _, ok := resp.Data["sys/"].(map[string]interface{})["deprecation_status"]; ok {
+			t.Fatalf("bad: %#v", resp.Data["sys/"])
+		}
+
+		resp, err = b.HandleRequest(namespace.RootContext(nil), logical.TestRequest(t, logical.ReadOperation, "auth"))
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		info = resp.Data["foo/"].(map[string]interface{})
+		if info["deprecation_status"] != "deprecated" {
+			t.Fatalf("bad: %#v", info)
+		}
+	})
+
+	t.Run("deprecated_blocked", func(t *testing.T) {
+		_, b := testDeprecatedBuiltinCore(t, deprecatedBuiltinsBlock, consts.Deprecated, consts.Supported)
+
+		resp := mount(t, b, "mounts/prod/secret/", "kv")
+		if resp == nil || !resp.IsError() {
+			t.Fatalf("expected an error: %#v", resp)
+		}
+		resp = mount(t, b, "auth/foo", "noop")
+		if resp != nil {
+			t.Fatalf("bad: %#v", resp)
+		}
+	})
+
+	t.Run("pending_removal", func(t *testing.T) {
+		_, b := testDeprecatedBuiltinCore(t, "", consts.PendingRemoval, consts.Removed)
+
+		resp := mount(t, b, "mounts/prod/secret/", "kv")
+		if resp == nil || !resp.IsError() {
+			t.Fatalf("expected an error: %#v", resp)
+		}
+		resp = mount(t, b, "auth/foo", "noop")
+		if resp == nil || !resp.IsError() {
+			t.Fatalf("expected an error: %#v", resp)
+		}
+	})
+
+	t.Run("pending_removal_allowed", func(t *testing.T) {
+		_, b := testDeprecatedBuiltinCore(t, deprecatedBuiltinsAllow, consts.PendingRemoval, consts.Removed)
+
+		resp := mount(t, b, "mounts/prod/secret/", "kv")
+		if resp == nil || resp.IsError() || len(resp.Warnings) != 1 {
+			t.Fatalf("expected a warning: %#v", resp)
+		}
+		// Removed plugins can never be mounted
+		resp = mount(t, b, "auth/foo", "noop")
+		if resp == nil || !resp.IsError() {
+			t.Fatalf("expected an error: %#v", resp)
+		}
+	})
+}
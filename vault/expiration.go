@@ -20,6 +20,7 @@ import (
 	"github.com/hashicorp/vault/helper/consts"
 	"github.com/hashicorp/vault/helper/jsonutil"
 	"github.com/hashicorp/vault/helper/locksutil"
+	"github.com/hashicorp/vault/helper/logging"
 	"github.com/hashicorp/vault/helper/namespace"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
@@ -36,12 +37,24 @@ const (
 	// tokenViewPrefix is the prefix used for the token based lookup of leases.
 	tokenViewPrefix = "token/"
 
-	// maxRevokeAttempts limits how many revoke attempts are made
+	// revokeRetryPrefix is the prefix used for the failed revocation attempts
+	// of leases.
+	revokeRetryPrefix = "revoke-retry/"
+
+	// maxRevokeAttempts limits how many revoke attempts are made before a
+	// lease is marked irrevocable
 	maxRevokeAttempts = 6
 
 	// revokeRetryBase is a baseline retry time
 	revokeRetryBase = 10 * time.Second
 
+	// revokeWorkerCount is the number of workers revoking expired leases
+	revokeWorkerCount = 64
+
+	// revokeMountConcurrency limits the number of revocations in flight
+	// against a single mount
+	revokeMountConcurrency = 16
+
 	// maxLeaseDuration is the default maximum lease duration
 	maxLeaseTTL = 32 * 24 * time.Hour
 
@@ -52,6 +65,17 @@ const (
 	maxLeaseThreshold = 256000
 )
 
+// leaseExpiryWindows are the upcoming windows the number of leases expiring
+// within is reported for, so that operators can forecast revocation storms
+var leaseExpiryWindows = []struct {
+	name   string
+	window time.Duration
+}{
+	{"1m", time.Minute},
+	{"10m", 10 * time.Minute},
+	{"1h", time.Hour},
+}
+
 type pendingInfo struct {
 	exportLeaseTimes *leaseEntry
 	timer            *time.Timer
@@ -87,49 +111,26 @@ type ExpirationManager struct {
 
 	logLeaseExpirations bool
 	expireFunc          ExpireLeaseStrategy
-}
 
-type ExpireLeaseStrategy func(context.Context, *ExpirationManager, *leaseEntry)
+	// renewCoalesceWindow is the window within which redundant renewals are
+	// answered from the lease, zero if coalescing is disabled
+	renewCoalesceWindow time.Duration
 
-// revokeIDFunc is invoked when a given ID is expired
-func expireLeaseStrategyRevoke(ctx context.Context, m *ExpirationManager, le *leaseEntry) {
-	for attempt := uint(0); attempt < maxRevokeAttempts; attempt++ {
-		revokeCtx, cancel := context.WithTimeout(ctx, DefaultMaxRequestDuration)
-		revokeCtx = namespace.ContextWithNamespace(revokeCtx, le.namespace)
+	// revokeQueue hands the expired leases out to the revocation workers
+	revokeQueue *revocationQueue
 
-		go func() {
-			select {
-			case <-ctx.Done():
-			case <-m.quitCh:
-				cancel()
-			case <-revokeCtx.Done():
-			}
-		}()
-
-		select {
-		case <-m.quitCh:
-			m.logger.Error("shutting down, not attempting further revocation of lease", "lease_id", le.LeaseID)
-			cancel()
-			return
-		case <-m.quitContext.Done():
-			m.logger.Error("core context canceled, not attempting further revocation of lease", "lease_id", le.LeaseID)
-			cancel()
-			return
-		default:
-		}
+	// revokeRetries holds the failed revocation attempts of the leases not
+	// revoked yet, persisted in revokeRetryView
+	revokeRetryView *BarrierView
+	revokeRetries   map[string]*revokeRetryEntry
+	revokeRetryLock sync.Mutex
+}
 
-		m.coreStateLock.RLock()
-		err := m.Revoke(revokeCtx, le.LeaseID)
-		m.coreStateLock.RUnlock()
-		cancel()
-		if err == nil {
-			return
-		}
+type ExpireLeaseStrategy func(context.Context, *ExpirationManager, *leaseEntry)
 
-		m.logger.Error("failed to revoke lease", "lease_id", le.LeaseID, "error", err)
-		time.Sleep((1 << attempt) * revokeRetryBase)
-	}
-	m.logger.Error("maximum revoke attempts reached", "lease_id", le.LeaseID)
+// expireLeaseStrategyRevoke queues the revocation of an expired lease
+func expireLeaseStrategyRevoke(ctx context.Context, m *ExpirationManager, le *leaseEntry) {
+	m.enqueueRevocation(le)
 }
 
 // NewExpirationManager creates a new ExpirationManager that is backed
@@ -157,9 +158,18 @@ func NewExpirationManager(c *Core, view *BarrierView, e ExpireLeaseStrategy, log
 
 		logLeaseExpirations: os.Getenv("VAULT_SKIP_LOGGING_LEASE_EXPIRATIONS") == "",
 		expireFunc:          e,
+		renewCoalesceWindow: c.renewCoalesceWindow,
+
+		revokeQueue:     newRevocationQueue(revokeMountConcurrency),
+		revokeRetryView: view.SubView(revokeRetryPrefix),
+		revokeRetries:   make(map[string]*revokeRetryEntry),
 	}
 	*exp.restoreMode = 1
 
+	for i := 0; i < revokeWorkerCount; i++ {
+		go exp.revokeWorker()
+	}
+
 	if exp.logger == nil {
 		opts := log.LoggerOptions{Name: "expiration_manager"}
 		exp.logger = log.New(&opts)
@@ -177,7 +187,7 @@ func (c *Core) setupExpiration(e ExpireLeaseStrategy) error {
 	view := c.systemBarrierView.SubView(expirationSubPath)
 
 	// Create the manager
-	expLogger := c.baseLogger.Named("expiration")
+	expLogger := logging.NewSubsystemLogger(c.baseLogger, "expiration")
 	c.AddLogger(expLogger)
 	mgr := NewExpirationManager(c, view, e, expLogger)
 	c.expiration = mgr
@@ -185,6 +195,12 @@ func (c *Core) setupExpiration(e ExpireLeaseStrategy) error {
 	// Link the token store to this
 	c.tokenStore.SetExpirationManager(mgr)
 
+	// Load the failed revocation attempts before the leases are restored, as
+	// the expired ones are queued for revocation right away
+	if err := mgr.loadRevokeRetries(mgr.quitContext); err != nil {
+		return err
+	}
+
 	// Restore the existing state
 	c.logger.Info("restoring leases")
 	errorFunc := func() {
@@ -237,6 +253,7 @@ func (m *ExpirationManager) invalidate(key string) {
 		if pending, ok := m.pending[leaseID]; ok {
 			pending.timer.Stop()
 			delete(m.pending, leaseID)
+			m.core.leaseCountQuotas.leaseRemoved(leaseID)
 		}
 		m.pendingLock.Unlock()
 	}
@@ -255,7 +272,7 @@ func (m *ExpirationManager) Tidy(ctx context.Context) error {
 
 	var tidyErrors *multierror.Error
 
-	logger := m.logger.Named("tidy")
+	logger := logging.NewSubsystemLogger(m.logger, "tidy")
 	m.core.AddLogger(logger)
 
 	if !atomic.CompareAndSwapInt32(m.tidyLock, 0, 1) {
@@ -546,12 +563,14 @@ func (m *ExpirationManager) Stop() error {
 	// Do this before stopping pending timers to avoid potential races with
 	// expiring timers
 	close(m.quitCh)
+	m.revokeQueue.stop()
 
 	m.pendingLock.Lock()
 	for _, pending := range m.pending {
 		pending.timer.Stop()
 	}
 	m.pending = make(map[string]pendingInfo)
+	m.core.leaseCountQuotas.resetCounts()
 	m.pendingLock.Unlock()
 
 	if m.inRestoreMode() {
@@ -634,10 +653,27 @@ func (m *ExpirationManager) revokeCommon(ctx context.Context, leaseID string, fo
 		}
 	}
 
+	if err := m.removeEntry(ctx, le); err != nil {
+		return err
+	}
+
+	if m.logger.IsInfo() && !skipToken && m.logLeaseExpirations {
+		m.logger.Info("revoked lease", "lease_id", leaseID)
+	}
+
+	return nil
+}
+
+// removeEntry deletes a lease entry along with its secondary index, failed
+// revocation attempts and expiration timer
+func (m *ExpirationManager) removeEntry(ctx context.Context, le *leaseEntry) error {
 	// Delete the entry
 	if err := m.deleteEntry(ctx, le); err != nil {
 		return err
 	}
+	if err := m.clearRevokeRetry(ctx, le.LeaseID); err != nil {
+		return err
+	}
 
 	// Delete the secondary index, but only if it's a leased secret (not auth)
 	if le.Secret != nil {
@@ -648,16 +684,13 @@ func (m *ExpirationManager) revokeCommon(ctx context.Context, leaseID string, fo
 
 	// Clear the expiration handler
 	m.pendingLock.Lock()
-	if pending, ok := m.pending[leaseID]; ok {
+	if pending, ok := m.pending[le.LeaseID]; ok {
 		pending.timer.Stop()
-		delete(m.pending, leaseID)
+		delete(m.pending, le.LeaseID)
+		m.core.leaseCountQuotas.leaseRemoved(le.LeaseID)
 	}
 	m.pendingLock.Unlock()
 
-	if m.logger.IsInfo() && !skipToken && m.logLeaseExpirations {
-		m.logger.Info("revoked lease", "lease_id", leaseID)
-	}
-
 	return nil
 }
 
@@ -787,23 +820,61 @@ func (m *ExpirationManager) revokePrefixCommon(ctx context.Context, prefix strin
 	}
 
 	// Revoke all the keys
+	if sync {
+		return m.revokeConcurrently(ctx, prefix, existing, force)
+	}
 	for idx, suffix := range existing {
 		leaseID := prefix + suffix
-		switch {
-		case sync:
-			if err := m.revokeCommon(ctx, leaseID, force, false); err != nil {
-				return errwrap.Wrapf(fmt.Sprintf("failed to revoke %q (%d / %d): {{err}}", leaseID, idx+1, len(existing)), err)
-			}
-		default:
-			if err := m.LazyRevoke(ctx, leaseID); err != nil {
-				return errwrap.Wrapf(fmt.Sprintf("failed to revoke %q (%d / %d): {{err}}", leaseID, idx+1, len(existing)), err)
-			}
+		if err := m.LazyRevoke(ctx, leaseID); err != nil {
+			return errwrap.Wrapf(fmt.Sprintf("failed to revoke %q (%d / %d): {{err}}", leaseID, idx+1, len(existing)), err)
 		}
 	}
 
 	return nil
 }
 
+// rewriteSecretLeases loads the secret leases under the given prefix and
+// persists those the rewrite function reports as modified. It returns the
+// number of rewritten leases.
+func (m *ExpirationManager) rewriteSecretLeases(ctx context.Context, prefix string, rewrite func(le *leaseEntry) bool) (int, error) {
+	if m.inRestoreMode() {
+		m.restoreRequestLock.Lock()
+		defer m.restoreRequestLock.Unlock()
+	}
+
+	if !strings.HasSuffix(prefix, "/") {
+		prefix = prefix + "/"
+	}
+
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+	view := m.leaseView(ns)
+	existing, err := logical.CollectKeys(ctx, view.SubView(prefix))
+	if err != nil {
+		return 0, errwrap.Wrapf("failed to scan for leases: {{err}}", err)
+	}
+
+	var rewritten int
+	for _, suffix := range existing {
+		leaseID := prefix + suffix
+		le, err := m.loadEntry(ctx, leaseID)
+		if err != nil {
+			return rewritten, err
+		}
+		if le == nil || le.Secret == nil || !rewrite(le) {
+			continue
+		}
+		if err := m.persistEntry(ctx, le); err != nil {
+			return rewritten, errwrap.Wrapf(fmt.Sprintf("failed to rewrite %q: {{err}}", leaseID), err)
+		}
+		rewritten++
+	}
+
+	return rewritten, nil
+}
+
 // Renew is used to renew a secret using the given leaseID
 // and a renew interval. The increment may be ignored.
 func (m *ExpirationManager) Renew(ctx context.Context, leaseID string, increment time.Duration) (*logical.Response, error) {
@@ -841,6 +912,18 @@ func (m *ExpirationManager) Renew(ctx context.Context, leaseID string, increment
 		return nil, fmt.Errorf("unable to retrieve system view from router")
 	}
 
+	if m.coalesceRenewal(le, increment) {
+		secret := *le.Secret
+		secret.LeaseID = leaseID
+		secret.TTL = le.ExpireTime.Sub(time.Now()).Round(time.Second)
+
+		// The lease data holds the secret issued with the lease, which a
+		// renewal must not hand out again
+		return &logical.Response{
+			Secret: &secret,
+		}, nil
+	}
+
 	// Attempt to renew the entry
 	resp, err := m.renewEntry(ctx, le, increment)
 	if err != nil {
@@ -961,6 +1044,15 @@ func (m *ExpirationManager) RenewToken(ctx context.Context, req *logical.Request
 		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
 	}
 
+	if m.coalesceRenewal(le, increment) {
+		auth := *le.Auth
+		auth.ClientToken = te.ID
+		auth.TTL = le.ExpireTime.Sub(time.Now()).Round(time.Second)
+		return &logical.Response{
+			Auth: &auth,
+		}, nil
+	}
+
 	// Attempt to renew the auth entry
 	resp, err := m.renewAuthEntry(ctx, req, le, increment)
 	if err != nil {
@@ -1029,6 +1121,49 @@ func (m *ExpirationManager) RenewToken(ctx context.Context, req *logical.Request
 	return retResp, nil
 }
 
+// coalesceRenewal reports whether renewing the lease can be skipped because
+// it was renewed within the coalescing window and renewing it again would
+// move its expiration by less than the window. The current state of the lease
+// is returned instead, so that a client renewing in a tight loop doesn't
+// reach the backend nor rewrite the lease every time.
+func (m *ExpirationManager) coalesceRenewal(le *leaseEntry, increment time.Duration) bool {
+	if m.renewCoalesceWindow <= 0 {
+		return false
+	}
+
+	lastRenewal := le.LastRenewalTime
+	if lastRenewal.IsZero() {
+		lastRenewal = le.IssueTime
+	}
+	now := time.Now()
+	if now.Sub(lastRenewal) >= m.renewCoalesceWindow {
+		return false
+	}
+
+	// Without an increment the lease is renewed for its previous TTL
+	if increment <= 0 {
+		switch {
+		case le.Secret != nil:
+			increment = le.Secret.TTL
+		case le.Auth != nil && le.Auth.Period > 0:
+			increment = le.Auth.Period
+		case le.Auth != nil:
+			increment = le.Auth.TTL
+		}
+	}
+
+	shift := now.Add(increment).Sub(le.ExpireTime)
+	if shift < 0 {
+		shift = -shift
+	}
+	if shift >= m.renewCoalesceWindow {
+		return false
+	}
+
+	metrics.IncrCounter([]string{"expire", "renew", "coalesced"}, 1)
+	return true
+}
+
 // Register is used to take a request and response with an associated
 // lease. The secret gets assigned a LeaseID and the management of
 // of lease is assumed by the expiration manager.
@@ -1311,6 +1446,7 @@ func (m *ExpirationManager) updatePendingInternal(le *leaseEntry, leaseTotal tim
 		if ok {
 			pending.timer.Stop()
 			delete(m.pending, le.LeaseID)
+			m.core.leaseCountQuotas.leaseRemoved(le.LeaseID)
 		}
 		return
 	}
@@ -1325,6 +1461,7 @@ func (m *ExpirationManager) updatePendingInternal(le *leaseEntry, leaseTotal tim
 		pending = pendingInfo{
 			timer: timer,
 		}
+		m.core.leaseCountQuotas.leaseAdded(le.LeaseID)
 	}
 
 	// Extend the timer by the lease total
@@ -1731,6 +1868,11 @@ func (m *ExpirationManager) emitMetrics() {
 	num := len(m.pending)
 	m.pendingLock.RUnlock()
 	metrics.SetGauge([]string{"expire", "num_leases"}, float32(num))
+	metrics.SetGauge([]string{"expire", "revocation", "queued"}, float32(m.revokeQueue.queued()))
+	metrics.SetGauge([]string{"expire", "leases", "irrevocable"}, float32(m.irrevocableCount()))
+	for i, count := range m.upcomingExpirations(time.Now()) {
+		metrics.SetGauge([]string{"expire", "leases", "expiring", leaseExpiryWindows[i].name}, float32(count))
+	}
 	// Check if lease count is greater than the threshold
 	if num > maxLeaseThreshold {
 		if atomic.LoadUint32(m.leaseCheckCounter) > 59 {
@@ -1742,6 +1884,29 @@ func (m *ExpirationManager) emitMetrics() {
 	}
 }
 
+// upcomingExpirations returns the number of pending leases expiring within
+// each of the leaseExpiryWindows from now
+func (m *ExpirationManager) upcomingExpirations(now time.Time) []int {
+	counts := make([]int, len(leaseExpiryWindows))
+
+	m.pendingLock.RLock()
+	defer m.pendingLock.RUnlock()
+
+	for _, pending := range m.pending {
+		le := pending.exportLeaseTimes
+		if le == nil || le.ExpireTime.IsZero() {
+			continue
+		}
+		remaining := le.ExpireTime.Sub(now)
+		for i, w := range leaseExpiryWindows {
+			if remaining <= w.window {
+				counts[i]++
+			}
+		}
+	}
+	return counts
+}
+
 // leaseEntry is used to structure the values the expiration
 // manager stores. This is used to handle renew and revocation.
 type leaseEntry struct {
@@ -0,0 +1,487 @@
+package vault
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/logical"
+)
+
+// revocationJob is the revocation of an expired lease, queued under the mount
+// the lease was issued by
+type revocationJob struct {
+	le    *leaseEntry
+	mount string
+
+	// attempts is the number of failed attempts to revoke the lease so far
+	attempts int
+}
+
+// mountRevocations holds the revocations queued for a mount
+type mountRevocations struct {
+	pending  []*revocationJob
+	inFlight int
+}
+
+// revocationQueue hands the revocations of expired leases out to a bounded
+// pool of workers. Mounts are served in turn, and each can only have a
+// limited number of revocations in flight, so that a mount with many leases
+// expiring at once, or with a slow backend, can't hold up the revocations of
+// the others.
+type revocationQueue struct {
+	l    sync.Mutex
+	cond *sync.Cond
+
+	mountConcurrency int
+
+	mounts map[string]*mountRevocations
+
+	// order holds the mounts with revocations queued or in flight, served
+	// in turn starting at next
+	order []string
+	next  int
+
+	// scheduled holds the leases queued, in flight or waiting to be retried,
+	// so that a lease is only revoked by one worker at a time
+	scheduled map[string]struct{}
+
+	stopped bool
+}
+
+func newRevocationQueue(mountConcurrency int) *revocationQueue {
+	q := &revocationQueue{
+		mountConcurrency: mountConcurrency,
+		mounts:           make(map[string]*mountRevocations),
+		scheduled:        make(map[string]struct{}),
+	}
+	q.cond = sync.NewCond(&q.l)
+	return q
+}
+
+// enqueue queues the revocation, unless the lease is already scheduled for
+// revocation. It reports whether the revocation was queued.
+func (q *revocationQueue) enqueue(job *revocationJob) bool {
+	q.l.Lock()
+	defer q.l.Unlock()
+
+	if q.stopped {
+		return false
+	}
+	if _, ok := q.scheduled[job.le.LeaseID]; ok {
+		return false
+	}
+	q.scheduled[job.le.LeaseID] = struct{}{}
+	q.push(job)
+	return true
+}
+
+// push is used to queue a job; do not call this without q.l held
+func (q *revocationQueue) push(job *revocationJob) {
+	mr, ok := q.mounts[job.mount]
+	if !ok {
+		mr = &mountRevocations{}
+		q.mounts[job.mount] = mr
+		q.order = append(q.order, job.mount)
+	}
+	mr.pending = append(mr.pending, job)
+	q.cond.Signal()
+}
+
+// dequeue waits for a revocation to perform, taking the first one queued for
+// the next mount in turn below its concurrency limit. It returns nil once the
+// queue is stopped.
+func (q *revocationQueue) dequeue() *revocationJob {
+	q.l.Lock()
+	defer q.l.Unlock()
+
+	for {
+		if q.stopped {
+			return nil
+		}
+		for i := 0; i < len(q.order); i++ {
+			idx := (q.next + i) % len(q.order)
+			mr := q.mounts[q.order[idx]]
+			if len(mr.pending) == 0 || mr.inFlight >= q.mountConcurrency {
+				continue
+			}
+
+			job := mr.pending[0]
+			mr.pending[0] = nil
+			mr.pending = mr.pending[1:]
+			mr.inFlight++
+			q.next = idx + 1
+			return job
+		}
+		q.cond.Wait()
+	}
+}
+
+// done releases the slot of a revocation taken from the queue. If retryAfter
+// is positive, the revocation is queued again once it elapsed.
+func (q *revocationQueue) done(job *revocationJob, retryAfter time.Duration) {
+	q.l.Lock()
+	defer q.l.Unlock()
+
+	mr, ok := q.mounts[job.mount]
+	if !ok {
+		// The queue was stopped while the revocation was in flight
+		delete(q.scheduled, job.le.LeaseID)
+		return
+	}
+	mr.inFlight--
+	if len(mr.pending) == 0 && mr.inFlight == 0 {
+		delete(q.mounts, job.mount)
+		for idx, mount := range q.order {
+			if mount != job.mount {
+				continue
+			}
+			q.order = append(q.order[:idx], q.order[idx+1:]...)
+			if idx < q.next {
+				q.next--
+			}
+			break
+		}
+	}
+	q.cond.Signal()
+
+	if retryAfter <= 0 || q.stopped {
+		delete(q.scheduled, job.le.LeaseID)
+		return
+	}
+	time.AfterFunc(retryAfter, func() {
+		q.l.Lock()
+		defer q.l.Unlock()
+		if !q.stopped {
+			q.push(job)
+		}
+	})
+}
+
+// queued returns the number of revocations waiting for a worker
+func (q *revocationQueue) queued() int {
+	q.l.Lock()
+	defer q.l.Unlock()
+
+	var count int
+	for _, mr := range q.mounts {
+		count += len(mr.pending)
+	}
+	return count
+}
+
+// stop drops the queued revocations and makes the workers return
+func (q *revocationQueue) stop() {
+	q.l.Lock()
+	defer q.l.Unlock()
+
+	q.stopped = true
+	q.mounts = make(map[string]*mountRevocations)
+	q.order = nil
+	q.cond.Broadcast()
+}
+
+// revokeRetryEntry is the persisted state of the revocation of a lease which
+// failed. A restart or a leadership change triggers a single attempt to
+// revoke the lease, after which the retries back off from where they were
+// rather than starting over.
+//
+// Once maxRevokeAttempts is reached, the lease is marked irrevocable: it is
+// no longer revoked automatically, and stays until an operator revokes it
+// synchronously or forgets it.
+type revokeRetryEntry struct {
+	LeaseID     string    `json:"lease_id"`
+	Mount       string    `json:"mount"`
+	Attempts    int       `json:"attempts"`
+	LastAttempt time.Time `json:"last_attempt"`
+	LastError   string    `json:"last_error"`
+	Irrevocable bool      `json:"irrevocable"`
+}
+
+// revokeRetryBackoff returns how long to wait before attempting again to
+// revoke a lease after the given number of failed attempts
+func revokeRetryBackoff(attempts int) time.Duration {
+	return (1 << uint(attempts-1)) * revokeRetryBase
+}
+
+// enqueueRevocation queues the revocation of an expired lease, picking up
+// where the previous attempts left off if it failed before
+func (m *ExpirationManager) enqueueRevocation(le *leaseEntry) {
+	ctx := namespace.ContextWithNamespace(m.quitContext, le.namespace)
+	mount := m.router.MatchingMount(ctx, le.Path)
+	if mount == "" {
+		mount = le.Path
+	}
+
+	job := &revocationJob{
+		le:    le,
+		mount: mount,
+	}
+	m.revokeRetryLock.Lock()
+	retry, ok := m.revokeRetries[le.LeaseID]
+	m.revokeRetryLock.Unlock()
+	if ok {
+		if retry.Irrevocable {
+			m.logger.Debug("not revoking irrevocable lease", "lease_id", le.LeaseID)
+			return
+		}
+		job.attempts = retry.Attempts
+	}
+
+	m.revokeQueue.enqueue(job)
+}
+
+// revokeWorker revokes the leases handed out by the revocation queue until it
+// is stopped
+func (m *ExpirationManager) revokeWorker() {
+	for {
+		job := m.revokeQueue.dequeue()
+		if job == nil {
+			return
+		}
+		m.revokeQueue.done(job, m.processRevocation(job))
+	}
+}
+
+// processRevocation attempts to revoke the lease of the job. If it fails, it
+// returns how long to wait before attempting again.
+func (m *ExpirationManager) processRevocation(job *revocationJob) time.Duration {
+	le := job.le
+
+	select {
+	case <-m.quitCh:
+		m.logger.Error("shutting down, not attempting further revocation of lease", "lease_id", le.LeaseID)
+		return 0
+	case <-m.quitContext.Done():
+		m.logger.Error("core context canceled, not attempting further revocation of lease", "lease_id", le.LeaseID)
+		return 0
+	default:
+	}
+
+	revokeCtx, cancel := context.WithTimeout(m.quitContext, DefaultMaxRequestDuration)
+	defer cancel()
+	revokeCtx = namespace.ContextWithNamespace(revokeCtx, le.namespace)
+
+	go func() {
+		select {
+		case <-m.quitCh:
+			cancel()
+		case <-revokeCtx.Done():
+		}
+	}()
+
+	m.coreStateLock.RLock()
+	err := m.Revoke(revokeCtx, le.LeaseID)
+	m.coreStateLock.RUnlock()
+	if err == nil {
+		return 0
+	}
+
+	job.attempts++
+	m.logger.Error("failed to revoke lease", "lease_id", le.LeaseID, "attempts", job.attempts, "error", err)
+
+	retry := &revokeRetryEntry{
+		LeaseID:     le.LeaseID,
+		Mount:       job.mount,
+		Attempts:    job.attempts,
+		LastAttempt: time.Now(),
+		LastError:   err.Error(),
+		Irrevocable: job.attempts >= maxRevokeAttempts,
+	}
+	if retry.Irrevocable {
+		m.logger.Error("maximum revoke attempts reached, marking lease irrevocable", "lease_id", le.LeaseID)
+	}
+	if err := m.persistRevokeRetry(m.quitContext, retry); err != nil {
+		m.logger.Error("failed to persist lease revocation retry", "lease_id", le.LeaseID, "error", err)
+	}
+
+	if retry.Irrevocable {
+		return 0
+	}
+	return revokeRetryBackoff(job.attempts)
+}
+
+// persistRevokeRetry records a failed revocation attempt
+func (m *ExpirationManager) persistRevokeRetry(ctx context.Context, retry *revokeRetryEntry) error {
+	entry, err := logical.StorageEntryJSON(retry.LeaseID, retry)
+	if err != nil {
+		return err
+	}
+	if err := m.revokeRetryView.Put(ctx, entry); err != nil {
+		return err
+	}
+
+	m.revokeRetryLock.Lock()
+	m.revokeRetries[retry.LeaseID] = retry
+	m.revokeRetryLock.Unlock()
+	return nil
+}
+
+// clearRevokeRetry forgets the failed revocation attempts of a lease, once it
+// is revoked
+func (m *ExpirationManager) clearRevokeRetry(ctx context.Context, leaseID string) error {
+	m.revokeRetryLock.Lock()
+	defer m.revokeRetryLock.Unlock()
+
+	if _, ok := m.revokeRetries[leaseID]; !ok {
+		return nil
+	}
+	if err := m.revokeRetryView.Delete(ctx, leaseID); err != nil {
+		return errwrap.Wrapf(fmt.Sprintf("failed to clear revocation retry of %q: {{err}}", leaseID), err)
+	}
+	delete(m.revokeRetries, leaseID)
+	return nil
+}
+
+// loadRevokeRetries loads the failed revocation attempts from storage. The
+// retries of the leases which no longer exist are cleared.
+func (m *ExpirationManager) loadRevokeRetries(ctx context.Context) error {
+	keys, err := logical.CollectKeys(ctx, m.revokeRetryView)
+	if err != nil {
+		return errwrap.Wrapf("failed to scan for lease revocation retries: {{err}}", err)
+	}
+
+	retries := make(map[string]*revokeRetryEntry, len(keys))
+	for _, leaseID := range keys {
+		if strings.HasSuffix(leaseID, "/") {
+			continue
+		}
+
+		lease, err := m.leaseView(namespace.RootNamespace).Get(ctx, leaseID)
+		if err != nil {
+			return errwrap.Wrapf(fmt.Sprintf("failed to read lease entry %s: {{err}}", leaseID), err)
+		}
+		if lease == nil {
+			if err := m.revokeRetryView.Delete(ctx, leaseID); err != nil {
+				return errwrap.Wrapf(fmt.Sprintf("failed to clear revocation retry of %q: {{err}}", leaseID), err)
+			}
+			continue
+		}
+
+		var retry revokeRetryEntry
+		if err := loadJSONEntry(ctx, m.revokeRetryView, leaseID, &retry); err != nil {
+			return errwrap.Wrapf(fmt.Sprintf("failed to load revocation retry of %q: {{err}}", leaseID), err)
+		}
+		retries[leaseID] = &retry
+	}
+
+	m.revokeRetryLock.Lock()
+	m.revokeRetries = retries
+	m.revokeRetryLock.Unlock()
+	return nil
+}
+
+// revokeConcurrently revokes the leases under the prefix, up to
+// revokeMountConcurrency at a time. It stops at the first failure and returns
+// its error once the revocations in flight are done.
+func (m *ExpirationManager) revokeConcurrently(ctx context.Context, prefix string, suffixes []string, force bool) error {
+	var wg sync.WaitGroup
+	var errLock sync.Mutex
+	var revokeErr error
+	sem := make(chan struct{}, revokeMountConcurrency)
+
+	for idx, suffix := range suffixes {
+		errLock.Lock()
+		failed := revokeErr != nil
+		errLock.Unlock()
+		if failed {
+			break
+		}
+
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(idx int, leaseID string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			if err := m.revokeCommon(ctx, leaseID, force, false); err != nil {
+				errLock.Lock()
+				if revokeErr == nil {
+					revokeErr = errwrap.Wrapf(fmt.Sprintf("failed to revoke %q (%d / %d): {{err}}", leaseID, idx+1, len(suffixes)), err)
+				}
+				errLock.Unlock()
+			}
+		}(idx, prefix+suffix)
+	}
+	wg.Wait()
+
+	return revokeErr
+}
+
+// irrevocableLeases returns the leases of the namespace marked irrevocable,
+// sorted by ID
+func (m *ExpirationManager) irrevocableLeases(ns *namespace.Namespace) []*revokeRetryEntry {
+	m.revokeRetryLock.Lock()
+	defer m.revokeRetryLock.Unlock()
+
+	var leases []*revokeRetryEntry
+	for leaseID, retry := range m.revokeRetries {
+		if !retry.Irrevocable {
+			continue
+		}
+		_, nsID := namespace.SplitIDFromString(leaseID)
+		if nsID == "" {
+			nsID = namespace.RootNamespaceID
+		}
+		if nsID != ns.ID {
+			continue
+		}
+		leases = append(leases, retry)
+	}
+	sort.Slice(leases, func(i, j int) bool {
+		return leases[i].LeaseID < leases[j].LeaseID
+	})
+	return leases
+}
+
+// irrevocableCount returns the number of leases marked irrevocable, across
+// namespaces
+func (m *ExpirationManager) irrevocableCount() int {
+	m.revokeRetryLock.Lock()
+	defer m.revokeRetryLock.Unlock()
+
+	var count int
+	for _, retry := range m.revokeRetries {
+		if retry.Irrevocable {
+			count++
+		}
+	}
+	return count
+}
+
+// ForgetIrrevocable removes a lease marked irrevocable without revoking it
+// through its backend. Whatever the lease stands for is left behind, and has
+// to be cleaned up by other means.
+func (m *ExpirationManager) ForgetIrrevocable(ctx context.Context, leaseID string) error {
+	m.revokeRetryLock.Lock()
+	retry, ok := m.revokeRetries[leaseID]
+	m.revokeRetryLock.Unlock()
+	if !ok || !retry.Irrevocable {
+		return fmt.Errorf("lease %q is not irrevocable", leaseID)
+	}
+
+	le, err := m.loadEntry(ctx, leaseID)
+	if err != nil {
+		return err
+	}
+	if le == nil {
+		return m.clearRevokeRetry(ctx, leaseID)
+	}
+	if le.Auth != nil {
+		return fmt.Errorf("lease %q belongs to a token, which must be revoked instead", leaseID)
+	}
+
+	if err := m.removeEntry(ctx, le); err != nil {
+		return err
+	}
+
+	m.logger.Warn("forgot irrevocable lease", "lease_id", leaseID)
+	return nil
+}
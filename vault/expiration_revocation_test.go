@@ -0,0 +1,310 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	uuid "github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/logical"
+)
+
+func TestRevocationQueue_Fairness(t *testing.T) {
+	q := newRevocationQueue(1)
+	job := func(mount, leaseID string) *revocationJob {
+		return &revocationJob{le: &leaseEntry{LeaseID: leaseID}, mount: mount}
+	}
+
+	for _, j := range []*revocationJob{
+		job("db/", "db/creds/1"),
+		job("db/", "db/creds/2"),
+		job("db/", "db/creds/3"),
+		job("aws/", "aws/creds/1"),
+	} {
+		if !q.enqueue(j) {
+			t.Fatalf("expected %s to be queued", j.le.LeaseID)
+		}
+	}
+	if q.enqueue(job("db/", "db/creds/1")) {
+		t.Fatal("expected a lease already scheduled not to be queued again")
+	}
+	if queued := q.queued(); queued != 4 {
+		t.Fatalf("bad: %d queued", queued)
+	}
+
+	// Mounts are served in turn, and each only has one revocation in flight
+	first := q.dequeue()
+	second := q.dequeue()
+	if first.le.LeaseID != "db/creds/1" || second.le.LeaseID != "aws/creds/1" {
+		t.Fatalf("bad: dequeued %s then %s", first.le.LeaseID, second.le.LeaseID)
+	}
+
+	dequeued := make(chan *revocationJob)
+	go func() {
+		dequeued <- q.dequeue()
+	}()
+	select {
+	case j := <-dequeued:
+		t.Fatalf("expected the mount concurrency to be enforced, dequeued %s", j.le.LeaseID)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	q.done(first, 0)
+	select {
+	case j := <-dequeued:
+		if j.le.LeaseID != "db/creds/2" {
+			t.Fatalf("bad: dequeued %s", j.le.LeaseID)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the next revocation of the mount to be dequeued")
+	}
+
+	// A revocation to retry is queued again once the backoff elapsed
+	q.done(second, 10*time.Millisecond)
+	if q.enqueue(job("aws/", "aws/creds/1")) {
+		t.Fatal("expected a lease waiting to be retried not to be queued again")
+	}
+	time.Sleep(50 * time.Millisecond)
+	if queued := q.queued(); queued != 2 {
+		t.Fatalf("bad: %d queued", queued)
+	}
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		q.stop()
+	}()
+	q.dequeue()
+	if j := q.dequeue(); j != nil {
+		t.Fatalf("expected no revocation once stopped, got %s", j.le.LeaseID)
+	}
+}
+
+func TestRevokeRetryBackoff(t *testing.T) {
+	cases := map[int]time.Duration{
+		1: revokeRetryBase,
+		2: 2 * revokeRetryBase,
+		3: 4 * revokeRetryBase,
+	}
+	for attempts, expected := range cases {
+		if backoff := revokeRetryBackoff(attempts); backoff != expected {
+			t.Fatalf("bad: %d attempts: expected %s, got %s", attempts, expected, backoff)
+		}
+	}
+}
+
+func TestExpiration_RevokeRetryPersisted(t *testing.T) {
+	core, _, _ := TestCoreUnsealed(t)
+	exp := core.expiration
+
+	allowed := new(uint32)
+	rejected := new(uint32)
+	noop := &NoopBackend{
+		RequestHandler: func(ctx context.Context, req *logical.Request) (*logical.Response, error) {
+			if req.Operation == logical.RevokeOperation && atomic.LoadUint32(allowed) == 0 {
+				atomic.AddUint32(rejected, 1)
+				return nil, errors.New("nope")
+			}
+			return nil, nil
+		},
+	}
+	_, barrier, _ := mockBarrier(t)
+	view := NewBarrierView(barrier, "logical/")
+	meUUID, err := uuid.GenerateUUID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = exp.router.Mount(noop, "foo/bar/", &MountEntry{Path: "foo/bar/", Type: "noop", UUID: meUUID, Accessor: "noop-accessor", namespace: namespace.RootNamespace}, view)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	le := &leaseEntry{
+		LeaseID: "foo/bar/1234",
+		Path:    "foo/bar",
+		Secret: &logical.Secret{
+			LeaseOptions: logical.LeaseOptions{
+				TTL: time.Minute,
+			},
+		},
+		IssueTime:  time.Now(),
+		ExpireTime: time.Now().Add(time.Minute),
+		namespace:  namespace.RootNamespace,
+	}
+	if err := exp.persistEntry(namespace.RootContext(nil), le); err != nil {
+		t.Fatal(err)
+	}
+	if err := exp.LazyRevoke(namespace.RootContext(nil), le.LeaseID); err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(time.Second)
+	if atomic.LoadUint32(rejected) != 1 {
+		t.Fatalf("bad: %d rejected revocations", atomic.LoadUint32(rejected))
+	}
+
+	var retry revokeRetryEntry
+	if err := loadJSONEntry(context.Background(), exp.revokeRetryView, le.LeaseID, &retry); err != nil {
+		t.Fatal(err)
+	}
+	if retry.Attempts != 1 || retry.LastError == "" {
+		t.Fatalf("bad: %#v", retry)
+	}
+
+	// The failed attempt is picked up after a restart, which triggers a new
+	// attempt right away
+	if err := exp.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if err := core.setupExpiration(expireLeaseStrategyRevoke); err != nil {
+		t.Fatal(err)
+	}
+	exp = core.expiration
+	exp.revokeRetryLock.Lock()
+	loaded := exp.revokeRetries[le.LeaseID]
+	exp.revokeRetryLock.Unlock()
+	if loaded == nil || loaded.Attempts != 1 {
+		t.Fatalf("bad: %#v", loaded)
+	}
+
+	atomic.StoreUint32(allowed, 1)
+	for exp.inRestoreMode() {
+		time.Sleep(100 * time.Millisecond)
+	}
+	time.Sleep(time.Second)
+
+	le, err = exp.FetchLeaseTimes(namespace.RootContext(nil), le.LeaseID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if le != nil {
+		t.Fatal("lease entry not nil")
+	}
+	entry, err := exp.revokeRetryView.Get(context.Background(), "foo/bar/1234")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry != nil {
+		t.Fatal("expected the revocation retry to be cleared")
+	}
+}
+
+func TestSystemBackend_IrrevocableLeases(t *testing.T) {
+	core, b, _ := testCoreSystemBackend(t)
+	exp := core.expiration
+	ctx := namespace.RootContext(nil)
+
+	revocations := new(uint32)
+	noop := &NoopBackend{
+		RequestHandler: func(ctx context.Context, req *logical.Request) (*logical.Response, error) {
+			if req.Operation == logical.RevokeOperation {
+				atomic.AddUint32(revocations, 1)
+				return nil, errors.New("nope")
+			}
+			return nil, nil
+		},
+	}
+	_, barrier, _ := mockBarrier(t)
+	view := NewBarrierView(barrier, "logical/")
+	meUUID, err := uuid.GenerateUUID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = exp.router.Mount(noop, "foo/bar/", &MountEntry{Path: "foo/bar/", Type: "noop", UUID: meUUID, Accessor: "noop-accessor", namespace: namespace.RootNamespace}, view)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	le := &leaseEntry{
+		LeaseID: "foo/bar/creds/1234",
+		Path:    "foo/bar/creds",
+		Secret: &logical.Secret{
+			LeaseOptions: logical.LeaseOptions{
+				TTL: time.Minute,
+			},
+		},
+		IssueTime:  time.Now(),
+		ExpireTime: time.Now().Add(time.Minute),
+		namespace:  namespace.RootNamespace,
+	}
+	if err := exp.persistEntry(ctx, le); err != nil {
+		t.Fatal(err)
+	}
+
+	// The last attempt fails, marking the lease irrevocable
+	if err := exp.persistRevokeRetry(ctx, &revokeRetryEntry{LeaseID: le.LeaseID, Attempts: maxRevokeAttempts - 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := exp.LazyRevoke(ctx, le.LeaseID); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Second)
+
+	var retry revokeRetryEntry
+	if err := loadJSONEntry(ctx, exp.revokeRetryView, le.LeaseID, &retry); err != nil {
+		t.Fatal(err)
+	}
+	if !retry.Irrevocable || retry.Attempts != maxRevokeAttempts || retry.Mount != "foo/bar/" {
+		t.Fatalf("bad: %#v", retry)
+	}
+
+	// Irrevocable leases are no longer revoked automatically
+	if err := exp.LazyRevoke(ctx, le.LeaseID); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if count := atomic.LoadUint32(revocations); count != 1 {
+		t.Fatalf("bad: %d revocation attempts", count)
+	}
+
+	request := func(op logical.Operation, path string, data map[string]interface{}) (*logical.Response, error) {
+		req := logical.TestRequest(t, op, path)
+		req.Data = data
+		return b.HandleRequest(ctx, req)
+	}
+
+	for _, mount := range []string{"", "foo/bar", "secret/"} {
+		resp, err := request(logical.ReadOperation, "leases/irrevocable", map[string]interface{}{"mount": mount})
+		if err != nil || resp == nil {
+			t.Fatalf("bad: resp: %#v err: %v", resp, err)
+		}
+		expected := 1
+		if mount == "secret/" {
+			expected = 0
+		}
+		if resp.Data["lease_count"] != expected {
+			t.Fatalf("bad: mount %q: %#v", mount, resp.Data)
+		}
+		if expected == 0 {
+			continue
+		}
+		if counts := resp.Data["counts_by_mount"].(map[string]int); counts["foo/bar/"] != 1 {
+			t.Fatalf("bad: %#v", counts)
+		}
+		leases := resp.Data["leases"].([]map[string]interface{})
+		if leases[0]["lease_id"] != le.LeaseID || leases[0]["last_error"] == "" {
+			t.Fatalf("bad: %#v", leases)
+		}
+	}
+
+	resp, err := request(logical.UpdateOperation, "leases/irrevocable/forget", map[string]interface{}{"lease_id": le.LeaseID})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: resp: %#v err: %v", resp, err)
+	}
+	if entry, err := exp.loadEntry(ctx, le.LeaseID); err != nil || entry != nil {
+		t.Fatalf("expected the lease to be forgotten: %#v %v", entry, err)
+	}
+	if count := atomic.LoadUint32(revocations); count != 1 {
+		t.Fatalf("expected the lease to be forgotten without revoking it, got %d revocation attempts", count)
+	}
+	if count := exp.irrevocableCount(); count != 0 {
+		t.Fatalf("bad: %d irrevocable leases", count)
+	}
+
+	resp, err = request(logical.UpdateOperation, "leases/irrevocable/forget", map[string]interface{}{"lease_id": le.LeaseID})
+	if err != logical.ErrInvalidRequest || resp == nil || !resp.IsError() {
+		t.Fatalf("expected forgetting a lease which isn't irrevocable to fail: resp: %#v err: %v", resp, err)
+	}
+}
@@ -1205,6 +1205,88 @@ func TestExpiration_Renew(t *testing.T) {
 	}
 }
 
+func TestExpiration_Renew_Coalesced(t *testing.T) {
+	exp := mockExpiration(t)
+	exp.renewCoalesceWindow = time.Minute
+	noop := &NoopBackend{}
+	_, barrier, _ := mockBarrier(t)
+	view := NewBarrierView(barrier, "logical/")
+	meUUID, err := uuid.GenerateUUID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = exp.router.Mount(noop, "prod/aws/", &MountEntry{Path: "prod/aws/", Type: "noop", UUID: meUUID, Accessor: "noop-accessor", namespace: namespace.RootNamespace}, view)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := &logical.Request{
+		Operation:   logical.ReadOperation,
+		Path:        "prod/aws/foo",
+		ClientToken: "foobar",
+	}
+	req.SetTokenEntry(&logical.TokenEntry{ID: "foobar", NamespaceID: "root"})
+	resp := &logical.Response{
+		Secret: &logical.Secret{
+			LeaseOptions: logical.LeaseOptions{
+				TTL:       time.Hour,
+				Renewable: true,
+			},
+		},
+		Data: map[string]interface{}{
+			"access_key": "xyz",
+			"secret_key": "abcd",
+		},
+	}
+
+	id, err := exp.Register(namespace.RootContext(nil), req, resp)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	noop.Response = &logical.Response{
+		Secret: &logical.Secret{
+			LeaseOptions: logical.LeaseOptions{
+				TTL: 3 * time.Hour,
+			},
+		},
+	}
+
+	// Renewing the lease right away for its TTL is answered from the lease
+	for _, increment := range []time.Duration{0, time.Hour} {
+		out, err := exp.Renew(namespace.RootContext(nil), id, increment)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if out.Secret.LeaseID != id || out.Secret.TTL != time.Hour {
+			t.Fatalf("bad: %#v", out)
+		}
+		if len(out.Data) != 0 {
+			t.Fatalf("coalesced renewal returned the lease data: %#v", out.Data)
+		}
+	}
+
+	noop.Lock()
+	if len(noop.Requests) != 0 {
+		t.Fatalf("Bad: %#v", noop.Requests)
+	}
+	noop.Unlock()
+
+	// Extending the lease further renews it
+	out, err := exp.Renew(namespace.RootContext(nil), id, 3*time.Hour)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out.Secret.TTL != 3*time.Hour {
+		t.Fatalf("bad: %#v", out.Secret)
+	}
+
+	noop.Lock()
+	defer noop.Unlock()
+	if len(noop.Requests) != 1 {
+		t.Fatalf("Bad: %#v", noop.Requests)
+	}
+}
 func TestExpiration_Renew_NotRenewable(t *testing.T) {
 	exp := mockExpiration(t)
 	noop := &NoopBackend{}
@@ -1676,6 +1758,31 @@ func TestExpiration_renewAuthEntry(t *testing.T) {
 	}
 }
 
+func TestExpiration_UpcomingExpirations(t *testing.T) {
+	exp := mockExpiration(t)
+	now := time.Now()
+
+	for i, remaining := range []time.Duration{
+		30 * time.Second,
+		5 * time.Minute,
+		30 * time.Minute,
+		2 * time.Hour,
+	} {
+		exp.updatePending(&leaseEntry{
+			LeaseID:    fmt.Sprintf("secret/test/%d", i),
+			ExpireTime: now.Add(remaining),
+		}, 24*time.Hour)
+	}
+
+	// Leases without an expiry time aren't pending
+	exp.updatePending(&leaseEntry{LeaseID: "secret/test/noexpire"}, 0)
+
+	expected := []int{1, 2, 3}
+	if counts := exp.upcomingExpirations(now); !reflect.DeepEqual(counts, expected) {
+		t.Fatalf("expected %v, got %v", expected, counts)
+	}
+}
+
 func TestExpiration_PersistLoadDelete(t *testing.T) {
 	exp := mockExpiration(t)
 	lastTime := time.Now()
@@ -4,18 +4,22 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/golang/protobuf/ptypes"
 	"github.com/hashicorp/errwrap"
 	log "github.com/hashicorp/go-hclog"
 	memdb "github.com/hashicorp/go-memdb"
+	multierror "github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/vault/helper/consts"
 	"github.com/hashicorp/vault/helper/identity"
+	"github.com/hashicorp/vault/helper/logging"
 	"github.com/hashicorp/vault/helper/namespace"
 	"github.com/hashicorp/vault/helper/storagepacker"
 	"github.com/hashicorp/vault/helper/strutil"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
+	cache "github.com/patrickmn/go-cache"
 )
 
 const (
@@ -45,9 +49,10 @@ func (i *IdentityStore) resetDB(ctx context.Context) error {
 
 func NewIdentityStore(ctx context.Context, core *Core, config *logical.BackendConfig, logger log.Logger) (*IdentityStore, error) {
 	iStore := &IdentityStore{
-		view:   config.StorageView,
-		logger: logger,
-		core:   core,
+		view:          config.StorageView,
+		logger:        logger,
+		core:          core,
+		oidcAuthCodes: cache.New(oidcAuthCodeTTL, time.Minute),
 	}
 
 	// Create a memdb instance, which by default, operates on lower cased
@@ -57,9 +62,9 @@ func NewIdentityStore(ctx context.Context, core *Core, config *logical.BackendCo
 		return nil, err
 	}
 
-	entitiesPackerLogger := iStore.logger.Named("storagepacker").Named("entities")
+	entitiesPackerLogger := logging.NewSubsystemLogger(iStore.logger, "storagepacker.entities")
 	core.AddLogger(entitiesPackerLogger)
-	groupsPackerLogger := iStore.logger.Named("storagepacker").Named("groups")
+	groupsPackerLogger := logging.NewSubsystemLogger(iStore.logger, "storagepacker.groups")
 	core.AddLogger(groupsPackerLogger)
 	iStore.entityPacker, err = storagepacker.NewStoragePacker(iStore.view, entitiesPackerLogger, "")
 	if err != nil {
@@ -75,6 +80,22 @@ func NewIdentityStore(ctx context.Context, core *Core, config *logical.BackendCo
 		BackendType: logical.TypeLogical,
 		Paths:       iStore.paths(),
 		Invalidate:  iStore.Invalidate,
+		PathsSpecial: &logical.Paths{
+			Unauthenticated: []string{
+				oidcIDPPathPrefix + "*",
+			},
+		},
+		PeriodicFunc: func(ctx context.Context, req *logical.Request) error {
+			now := time.Now()
+			var retErr *multierror.Error
+			if err := iStore.reapPolicyGrants(ctx, now); err != nil {
+				retErr = multierror.Append(retErr, err)
+			}
+			if err := iStore.rotateOIDCKeys(ctx, now); err != nil {
+				retErr = multierror.Append(retErr, err)
+			}
+			return retErr.ErrorOrNil()
+		},
 	}
 
 	err = iStore.Setup(ctx, config)
@@ -93,6 +114,8 @@ func (i *IdentityStore) paths() []*framework.Path {
 		groupPaths(i),
 		lookupPaths(i),
 		upgradePaths(i),
+		policyGrantPaths(i),
+		oidcProviderPaths(i),
 	)
 }
 
@@ -430,12 +453,18 @@ func (i *IdentityStore) CreateOrFetchEntity(ctx context.Context, alias *logical.
 		return nil, fmt.Errorf("mount accessor %q is not a mount of type %q", alias.MountAccessor, alias.MountType)
 	}
 
+	// Only keep the alias metadata the auth method is allowed to set
+	metadata := alias.Metadata
+	if mountEntry := i.core.router.MatchingMountByAccessor(alias.MountAccessor); mountEntry != nil {
+		metadata = loginAliasMetadata(metadata, mountEntry.Config.AllowedAliasMetadataKeys)
+	}
+
 	// Check if an entity already exists for the given alias
 	entity, err = i.entityByAliasFactors(alias.MountAccessor, alias.Name, false)
 	if err != nil {
 		return nil, err
 	}
-	if entity != nil && changedAliasIndex(entity, alias) == -1 {
+	if entity != nil && changedAliasIndex(entity, alias.Name, metadata) == -1 {
 		return entity, nil
 	}
 
@@ -452,12 +481,12 @@ func (i *IdentityStore) CreateOrFetchEntity(ctx context.Context, alias *logical.
 		return nil, err
 	}
 	if entity != nil {
-		idx := changedAliasIndex(entity, alias)
+		idx := changedAliasIndex(entity, alias.Name, metadata)
 		if idx == -1 {
 			return entity, nil
 		}
 		a := entity.Aliases[idx]
-		a.Metadata = alias.Metadata
+		a.Metadata = metadata
 		a.LastUpdateTime = ptypes.TimestampNow()
 
 		update = true
@@ -475,7 +504,7 @@ func (i *IdentityStore) CreateOrFetchEntity(ctx context.Context, alias *logical.
 			CanonicalID:   entity.ID,
 			Name:          alias.Name,
 			MountAccessor: alias.MountAccessor,
-			Metadata:      alias.Metadata,
+			Metadata:      metadata,
 			MountPath:     mountValidationResp.MountPath,
 			MountType:     mountValidationResp.MountType,
 		}
@@ -508,12 +537,31 @@ func (i *IdentityStore) CreateOrFetchEntity(ctx context.Context, alias *logical.
 //
 // If a match is found, the changed alias's index is returned. If no alias
 // names match or no metadata is different, -1 is returned.
-func changedAliasIndex(entity *identity.Entity, alias *logical.Alias) int {
+func changedAliasIndex(entity *identity.Entity, name string, metadata map[string]string) int {
 	for i, a := range entity.Aliases {
-		if a.Name == alias.Name && !strutil.EqualStringMaps(a.Metadata, alias.Metadata) {
+		if a.Name == name && !strutil.EqualStringMaps(a.Metadata, metadata) {
 			return i
 		}
 	}
 
 	return -1
 }
+
+// loginAliasMetadata returns the alias metadata given by an auth method at
+// login, restricted to the allowed keys of its mount if there are any
+func loginAliasMetadata(metadata map[string]string, allowedKeys []string) map[string]string {
+	if len(allowedKeys) == 0 {
+		return metadata
+	}
+
+	var allowed map[string]string
+	for _, key := range allowedKeys {
+		if value, ok := metadata[key]; ok {
+			if allowed == nil {
+				allowed = make(map[string]string, len(allowedKeys))
+			}
+			allowed[key] = value
+		}
+	}
+	return allowed
+}
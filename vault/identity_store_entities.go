@@ -514,7 +514,7 @@ func (i *IdentityStore) handleEntityDeleteCommon(ctx context.Context, txn *memdb
 		return err
 	}
 
-	return nil
+	return i.deletePolicyGrants(ctx, policyGrantTargetEntity, entity.ID)
 }
 
 func (i *IdentityStore) pathEntityIDList() framework.OperationFunc {
@@ -686,8 +686,13 @@ func (i *IdentityStore) mergeEntity(ctx context.Context, txn *memdb.Txn, toEntit
 			toEntity.Aliases = append(toEntity.Aliases, alias)
 		}
 
-		// If told to, merge policies
+		// If told to, merge policies, along with their expiring grants
 		if mergePolicies {
+			if persist && !isPerfSecondaryOrStandby {
+				if err := i.mergePolicyGrants(ctx, toEntity, fromEntity.ID); err != nil {
+					return nil, errwrap.Wrapf("failed to merge policy grants: {{err}}", err)
+				}
+			}
 			toEntity.Policies = strutil.MergeSlices(toEntity.Policies, fromEntity.Policies)
 		}
 
@@ -448,6 +448,11 @@ func (i *IdentityStore) handleGroupDeleteCommon(ctx context.Context, key string,
 		return nil, err
 	}
 
+	err = i.deletePolicyGrants(ctx, policyGrantTargetGroup, group.ID)
+	if err != nil {
+		return nil, err
+	}
+
 	// Committing the transaction *after* successfully deleting group
 	txn.Commit()
 
@@ -0,0 +1,1892 @@
+package vault
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	uuid "github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/helper/base62"
+	"github.com/hashicorp/vault/helper/identity"
+	"github.com/hashicorp/vault/helper/jsonutil"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/helper/strutil"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+	jose "gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+const (
+	// Storage prefixes of the OIDC provider objects
+	oidcKeyPrefix      = "oidc/key/"
+	oidcScopePrefix    = "oidc/scope/"
+	oidcClientPrefix   = "oidc/client/"
+	oidcProviderPrefix = "oidc/provider/"
+
+	// oidcIDPPathPrefix is the path prefix of the unauthenticated endpoints
+	// of the providers, which are served to the relying parties
+	oidcIDPPathPrefix = "oidc/idp/"
+
+	// oidcOpenIDScope is the scope required in every authentication request
+	oidcOpenIDScope = "openid"
+
+	// oidcAuthCodeTTL is how long an authorization code can be exchanged
+	oidcAuthCodeTTL = 5 * time.Minute
+
+	// oidcAccessTokenType is the type header of the access tokens, which
+	// distinguishes them from ID tokens signed by the same key
+	oidcAccessTokenType = "at+jwt"
+)
+
+// oidcReservedClaims can't be set by scope templates, as they are set by
+// Vault on the ID tokens
+var oidcReservedClaims = []string{
+	"at_hash",
+	"aud",
+	"auth_time",
+	"azp",
+	"c_hash",
+	"exp",
+	"iat",
+	"iss",
+	"jti",
+	"nbf",
+	"nonce",
+	"sub",
+}
+
+// oidcSigningAlgorithms are the algorithms a signing key can use
+var oidcSigningAlgorithms = []string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"}
+
+// oidcKey is a named signing key of ID and access tokens. The key is rotated
+// every rotation period, and the public keys it replaces remain published for
+// the verification TTL.
+type oidcKey struct {
+	NamespaceID     string           `json:"namespace_id"`
+	Algorithm       string           `json:"algorithm"`
+	RotationPeriod  time.Duration    `json:"rotation_period"`
+	VerificationTTL time.Duration    `json:"verification_ttl"`
+	NextRotation    time.Time        `json:"next_rotation"`
+	SigningKey      *jose.JSONWebKey `json:"signing_key"`
+	PublicKeys      []*oidcPublicKey `json:"public_keys"`
+}
+
+// oidcPublicKey is a public key published for verification. The public key of
+// the current signing key doesn't expire.
+type oidcPublicKey struct {
+	Key      *jose.JSONWebKey `json:"key"`
+	ExpireAt time.Time        `json:"expire_at"`
+}
+
+// oidcScope is a scope a relying party can request, adding the claims of its
+// template to the ID tokens and userinfo
+type oidcScope struct {
+	NamespaceID string `json:"namespace_id"`
+	Template    string `json:"template"`
+	Description string `json:"description"`
+}
+
+// oidcClient is a relying party, which the entities assigned to it can
+// authenticate to
+type oidcClient struct {
+	NamespaceID    string        `json:"namespace_id"`
+	ClientID       string        `json:"client_id"`
+	ClientSecret   string        `json:"client_secret"`
+	Key            string        `json:"key"`
+	RedirectURIs   []string      `json:"redirect_uris"`
+	EntityIDs      []string      `json:"entity_ids"`
+	GroupIDs       []string      `json:"group_ids"`
+	IDTokenTTL     time.Duration `json:"id_token_ttl"`
+	AccessTokenTTL time.Duration `json:"access_token_ttl"`
+}
+
+// oidcProvider is an OpenID provider serving a set of clients and scopes
+type oidcProvider struct {
+	NamespaceID      string   `json:"namespace_id"`
+	Issuer           string   `json:"issuer"`
+	AllowedClientIDs []string `json:"allowed_client_ids"`
+	ScopesSupported  []string `json:"scopes_supported"`
+}
+
+// oidcAuthCode is an authorization code issued by a provider, kept in memory
+// until it is exchanged or expires
+type oidcAuthCode struct {
+	NamespaceID         string
+	Provider            string
+	ClientID            string
+	EntityID            string
+	RedirectURI         string
+	Scopes              []string
+	Nonce               string
+	CodeChallenge       string
+	CodeChallengeMethod string
+	AuthTime            time.Time
+}
+
+func (k *oidcKey) namespaceID() string      { return k.NamespaceID }
+func (s *oidcScope) namespaceID() string    { return s.NamespaceID }
+func (c *oidcClient) namespaceID() string   { return c.NamespaceID }
+func (p *oidcProvider) namespaceID() string { return p.NamespaceID }
+
+type oidcObject interface {
+	namespaceID() string
+}
+
+func oidcProviderPaths(i *IdentityStore) []*framework.Path {
+	return []*framework.Path{
+		{
+			Pattern: "oidc/key/" + framework.GenericNameRegex("name") + "$",
+			Fields: map[string]*framework.FieldSchema{
+				"name": {
+					Type:        framework.TypeString,
+					Description: "Name of the key.",
+				},
+				"algorithm": {
+					Type:        framework.TypeString,
+					Description: "Signing algorithm of the key, one of RS256, RS384, RS512, ES256, ES384 and ES512.",
+					Default:     "RS256",
+				},
+				"rotation_period": {
+					Type:        framework.TypeDurationSecond,
+					Description: "How often the key is rotated.",
+					Default:     "24h",
+				},
+				"verification_ttl": {
+					Type:        framework.TypeDurationSecond,
+					Description: "How long the public key of a rotated key remains published for verification.",
+					Default:     "24h",
+				},
+			},
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.UpdateOperation: i.pathOIDCKeyWrite,
+				logical.ReadOperation:   i.pathOIDCKeyRead,
+				logical.DeleteOperation: i.pathOIDCKeyDelete,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(oidcProviderHelp["oidc-key"][0]),
+			HelpDescription: strings.TrimSpace(oidcProviderHelp["oidc-key"][1]),
+		},
+		{
+			Pattern: "oidc/key/" + framework.GenericNameRegex("name") + "/rotate$",
+			Fields: map[string]*framework.FieldSchema{
+				"name": {
+					Type:        framework.TypeString,
+					Description: "Name of the key.",
+				},
+			},
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.UpdateOperation: i.pathOIDCKeyRotate,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(oidcProviderHelp["oidc-key-rotate"][0]),
+			HelpDescription: strings.TrimSpace(oidcProviderHelp["oidc-key-rotate"][1]),
+		},
+		{
+			Pattern: "oidc/key/?$",
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.ListOperation: i.pathOIDCList(oidcKeyPrefix, func() oidcObject { return &oidcKey{} }),
+			},
+
+			HelpSynopsis:    strings.TrimSpace(oidcProviderHelp["oidc-key-list"][0]),
+			HelpDescription: strings.TrimSpace(oidcProviderHelp["oidc-key-list"][1]),
+		},
+		{
+			Pattern: "oidc/scope/" + framework.GenericNameRegex("name") + "$",
+			Fields: map[string]*framework.FieldSchema{
+				"name": {
+					Type:        framework.TypeString,
+					Description: "Name of the scope.",
+				},
+				"template": {
+					Type:        framework.TypeString,
+					Description: "JSON object of the claims added by the scope, whose string values can hold identity templates.",
+				},
+				"description": {
+					Type:        framework.TypeString,
+					Description: "Description of the scope.",
+				},
+			},
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.UpdateOperation: i.pathOIDCScopeWrite,
+				logical.ReadOperation:   i.pathOIDCScopeRead,
+				logical.DeleteOperation: i.pathOIDCScopeDelete,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(oidcProviderHelp["oidc-scope"][0]),
+			HelpDescription: strings.TrimSpace(oidcProviderHelp["oidc-scope"][1]),
+		},
+		{
+			Pattern: "oidc/scope/?$",
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.ListOperation: i.pathOIDCList(oidcScopePrefix, func() oidcObject { return &oidcScope{} }),
+			},
+
+			HelpSynopsis:    strings.TrimSpace(oidcProviderHelp["oidc-scope-list"][0]),
+			HelpDescription: strings.TrimSpace(oidcProviderHelp["oidc-scope-list"][1]),
+		},
+		{
+			Pattern: "oidc/client/" + framework.GenericNameRegex("name") + "$",
+			Fields: map[string]*framework.FieldSchema{
+				"name": {
+					Type:        framework.TypeString,
+					Description: "Name of the client.",
+				},
+				"key": {
+					Type:        framework.TypeString,
+					Description: "Name of the key signing the tokens issued to the client. Required when creating the client.",
+				},
+				"redirect_uris": {
+					Type:        framework.TypeCommaStringSlice,
+					Description: "Redirection URIs the client can use.",
+				},
+				"entity_ids": {
+					Type:        framework.TypeCommaStringSlice,
+					Description: `IDs of the entities which can authenticate to the client, "*" allowing any entity.`,
+				},
+				"group_ids": {
+					Type:        framework.TypeCommaStringSlice,
+					Description: "IDs of the groups whose member entities can authenticate to the client.",
+				},
+				"id_token_ttl": {
+					Type:        framework.TypeDurationSecond,
+					Description: "TTL of the ID tokens issued to the client. It can't exceed the verification_ttl of the key.",
+					Default:     "24h",
+				},
+				"access_token_ttl": {
+					Type:        framework.TypeDurationSecond,
+					Description: "TTL of the access tokens issued to the client.",
+					Default:     "24h",
+				},
+			},
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.UpdateOperation: i.pathOIDCClientWrite,
+				logical.ReadOperation:   i.pathOIDCClientRead,
+				logical.DeleteOperation: i.pathOIDCClientDelete,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(oidcProviderHelp["oidc-client"][0]),
+			HelpDescription: strings.TrimSpace(oidcProviderHelp["oidc-client"][1]),
+		},
+		{
+			Pattern: "oidc/client/?$",
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.ListOperation: i.pathOIDCList(oidcClientPrefix, func() oidcObject { return &oidcClient{} }),
+			},
+
+			HelpSynopsis:    strings.TrimSpace(oidcProviderHelp["oidc-client-list"][0]),
+			HelpDescription: strings.TrimSpace(oidcProviderHelp["oidc-client-list"][1]),
+		},
+		{
+			Pattern: "oidc/provider/" + framework.GenericNameRegex("name") + "$",
+			Fields: map[string]*framework.FieldSchema{
+				"name": {
+					Type:        framework.TypeString,
+					Description: "Name of the provider.",
+				},
+				"issuer": {
+					Type:        framework.TypeString,
+					Description: "Scheme, host and optional port of the issuer URL. Defaults to the api_addr of Vault.",
+				},
+				"allowed_client_ids": {
+					Type:        framework.TypeCommaStringSlice,
+					Description: `Client IDs of the clients which can use the provider, "*" allowing any client.`,
+				},
+				"scopes_supported": {
+					Type:        framework.TypeCommaStringSlice,
+					Description: "Names of the scopes the clients can request, besides openid.",
+				},
+			},
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.UpdateOperation: i.pathOIDCProviderWrite,
+				logical.ReadOperation:   i.pathOIDCProviderRead,
+				logical.DeleteOperation: i.pathOIDCProviderDelete,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(oidcProviderHelp["oidc-provider"][0]),
+			HelpDescription: strings.TrimSpace(oidcProviderHelp["oidc-provider"][1]),
+		},
+		{
+			Pattern: "oidc/provider/?$",
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.ListOperation: i.pathOIDCList(oidcProviderPrefix, func() oidcObject { return &oidcProvider{} }),
+			},
+
+			HelpSynopsis:    strings.TrimSpace(oidcProviderHelp["oidc-provider-list"][0]),
+			HelpDescription: strings.TrimSpace(oidcProviderHelp["oidc-provider-list"][1]),
+		},
+		{
+			Pattern: "oidc/provider/" + framework.GenericNameRegex("name") + "/authorize$",
+			Fields: map[string]*framework.FieldSchema{
+				"name": {
+					Type:        framework.TypeString,
+					Description: "Name of the provider.",
+				},
+				"client_id": {
+					Type:        framework.TypeString,
+					Description: "Client ID of the client.",
+				},
+				"scope": {
+					Type:        framework.TypeString,
+					Description: "Space-separated scopes requested, which must include openid.",
+				},
+				"redirect_uri": {
+					Type:        framework.TypeString,
+					Description: "Redirection URI of the client the response is sent to.",
+				},
+				"response_type": {
+					Type:        framework.TypeString,
+					Description: "Response type of the authentication flow. Only code is supported.",
+				},
+				"state": {
+					Type:        framework.TypeString,
+					Description: "Value returned to the client along with the code.",
+				},
+				"nonce": {
+					Type:        framework.TypeString,
+					Description: "Value set in the nonce claim of the ID token.",
+				},
+				"code_challenge": {
+					Type:        framework.TypeString,
+					Description: "PKCE code challenge.",
+				},
+				"code_challenge_method": {
+					Type:        framework.TypeString,
+					Description: "PKCE code challenge method, S256 or plain.",
+				},
+			},
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.ReadOperation:   i.pathOIDCAuthorize,
+				logical.UpdateOperation: i.pathOIDCAuthorize,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(oidcProviderHelp["oidc-authorize"][0]),
+			HelpDescription: strings.TrimSpace(oidcProviderHelp["oidc-authorize"][1]),
+		},
+		{
+			Pattern: oidcIDPPathPrefix + framework.GenericNameRegex("name") + "/.well-known/openid-configuration$",
+			Fields: map[string]*framework.FieldSchema{
+				"name": {
+					Type:        framework.TypeString,
+					Description: "Name of the provider.",
+				},
+			},
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.ReadOperation: i.pathOIDCDiscovery,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(oidcProviderHelp["oidc-discovery"][0]),
+			HelpDescription: strings.TrimSpace(oidcProviderHelp["oidc-discovery"][1]),
+		},
+		{
+			Pattern: oidcIDPPathPrefix + framework.GenericNameRegex("name") + "/.well-known/keys$",
+			Fields: map[string]*framework.FieldSchema{
+				"name": {
+					Type:        framework.TypeString,
+					Description: "Name of the provider.",
+				},
+			},
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.ReadOperation: i.pathOIDCKeys,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(oidcProviderHelp["oidc-keys"][0]),
+			HelpDescription: strings.TrimSpace(oidcProviderHelp["oidc-keys"][1]),
+		},
+		{
+			Pattern: oidcIDPPathPrefix + framework.GenericNameRegex("name") + "/token$",
+			Fields: map[string]*framework.FieldSchema{
+				"name": {
+					Type:        framework.TypeString,
+					Description: "Name of the provider.",
+				},
+				"grant_type": {
+					Type:        framework.TypeString,
+					Description: "Grant type of the request. Only authorization_code is supported.",
+				},
+				"code": {
+					Type:        framework.TypeString,
+					Description: "Authorization code issued by the provider.",
+				},
+				"redirect_uri": {
+					Type:        framework.TypeString,
+					Description: "Redirection URI of the authorization request.",
+				},
+				"client_id": {
+					Type:        framework.TypeString,
+					Description: "Client ID of the client.",
+				},
+				"client_secret": {
+					Type:        framework.TypeString,
+					Description: "Client secret of the client.",
+				},
+				"code_verifier": {
+					Type:        framework.TypeString,
+					Description: "PKCE code verifier, required if a code challenge was sent.",
+				},
+			},
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.UpdateOperation: i.pathOIDCToken,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(oidcProviderHelp["oidc-token"][0]),
+			HelpDescription: strings.TrimSpace(oidcProviderHelp["oidc-token"][1]),
+		},
+		{
+			Pattern: oidcIDPPathPrefix + framework.GenericNameRegex("name") + "/userinfo$",
+			Fields: map[string]*framework.FieldSchema{
+				"name": {
+					Type:        framework.TypeString,
+					Description: "Name of the provider.",
+				},
+				"access_token": {
+					Type:        framework.TypeString,
+					Description: "Access token, when not sent as a bearer token.",
+				},
+			},
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.ReadOperation:   i.pathOIDCUserInfo,
+				logical.UpdateOperation: i.pathOIDCUserInfo,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(oidcProviderHelp["oidc-userinfo"][0]),
+			HelpDescription: strings.TrimSpace(oidcProviderHelp["oidc-userinfo"][1]),
+		},
+	}
+}
+
+// readOIDCObject decodes the OIDC object stored under key into out, reporting
+// whether it exists in the namespace of the request
+func (i *IdentityStore) readOIDCObject(ctx context.Context, key string, out oidcObject) (bool, error) {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	entry, err := i.view.Get(ctx, key)
+	if err != nil || entry == nil {
+		return false, err
+	}
+	if err := entry.DecodeJSON(out); err != nil {
+		return false, errwrap.Wrapf(fmt.Sprintf("failed to decode %q: {{err}}", key), err)
+	}
+	return out.namespaceID() == ns.ID, nil
+}
+
+func (i *IdentityStore) putOIDCObject(ctx context.Context, key string, value oidcObject) error {
+	entry, err := logical.StorageEntryJSON(key, value)
+	if err != nil {
+		return err
+	}
+	return i.view.Put(ctx, entry)
+}
+
+func (i *IdentityStore) getOIDCKey(ctx context.Context, name string) (*oidcKey, error) {
+	key := &oidcKey{}
+	if ok, err := i.readOIDCObject(ctx, oidcKeyPrefix+name, key); err != nil || !ok {
+		return nil, err
+	}
+	return key, nil
+}
+
+func (i *IdentityStore) getOIDCScope(ctx context.Context, name string) (*oidcScope, error) {
+	scope := &oidcScope{}
+	if ok, err := i.readOIDCObject(ctx, oidcScopePrefix+name, scope); err != nil || !ok {
+		return nil, err
+	}
+	return scope, nil
+}
+
+func (i *IdentityStore) getOIDCClient(ctx context.Context, name string) (*oidcClient, error) {
+	client := &oidcClient{}
+	if ok, err := i.readOIDCObject(ctx, oidcClientPrefix+name, client); err != nil || !ok {
+		return nil, err
+	}
+	return client, nil
+}
+
+func (i *IdentityStore) getOIDCProvider(ctx context.Context, name string) (*oidcProvider, error) {
+	provider := &oidcProvider{}
+	if ok, err := i.readOIDCObject(ctx, oidcProviderPrefix+name, provider); err != nil || !ok {
+		return nil, err
+	}
+	return provider, nil
+}
+
+// oidcNames lists the names of the OIDC objects of the namespace of the
+// request stored under prefix
+func (i *IdentityStore) oidcNames(ctx context.Context, prefix string, newObject func() oidcObject) ([]string, error) {
+	names, err := i.view.List(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []string
+	for _, name := range names {
+		ok, err := i.readOIDCObject(ctx, prefix+name, newObject())
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			ret = append(ret, name)
+		}
+	}
+	return ret, nil
+}
+
+// oidcClients returns the clients of the namespace of the request by name
+func (i *IdentityStore) oidcClients(ctx context.Context) (map[string]*oidcClient, error) {
+	names, err := i.oidcNames(ctx, oidcClientPrefix, func() oidcObject { return &oidcClient{} })
+	if err != nil {
+		return nil, err
+	}
+
+	clients := make(map[string]*oidcClient, len(names))
+	for _, name := range names {
+		client, err := i.getOIDCClient(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		if client != nil {
+			clients[name] = client
+		}
+	}
+	return clients, nil
+}
+
+// oidcClientByID returns the client with the client ID, or nil
+func (i *IdentityStore) oidcClientByID(ctx context.Context, clientID string) (*oidcClient, error) {
+	if clientID == "" {
+		return nil, nil
+	}
+
+	clients, err := i.oidcClients(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, client := range clients {
+		if subtle.ConstantTimeCompare([]byte(client.ClientID), []byte(clientID)) == 1 {
+			return client, nil
+		}
+	}
+	return nil, nil
+}
+
+func (i *IdentityStore) pathOIDCList(prefix string, newObject func() oidcObject) framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		i.oidcLock.RLock()
+		defer i.oidcLock.RUnlock()
+
+		names, err := i.oidcNames(ctx, prefix, newObject)
+		if err != nil {
+			return nil, err
+		}
+		return logical.ListResponse(names), nil
+	}
+}
+
+func generateOIDCSigningKey(algorithm string) (*jose.JSONWebKey, error) {
+	var key interface{}
+	var err error
+	switch algorithm {
+	case "RS256", "RS384", "RS512":
+		key, err = rsa.GenerateKey(rand.Reader, 2048)
+	case "ES256":
+		key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case "ES384":
+		key, err = ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	case "ES512":
+		key, err = ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q", algorithm)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	keyID, err := uuid.GenerateUUID()
+	if err != nil {
+		return nil, err
+	}
+	return &jose.JSONWebKey{
+		Key:       key,
+		KeyID:     keyID,
+		Algorithm: algorithm,
+		Use:       "sig",
+	}, nil
+}
+
+// rotate replaces the signing key. The public key of the replaced signing key
+// expires after the verification TTL.
+func (k *oidcKey) rotate(now time.Time) error {
+	signingKey, err := generateOIDCSigningKey(k.Algorithm)
+	if err != nil {
+		return err
+	}
+
+	for _, publicKey := range k.PublicKeys {
+		if publicKey.ExpireAt.IsZero() {
+			publicKey.ExpireAt = now.Add(k.VerificationTTL)
+		}
+	}
+	public := signingKey.Public()
+	k.SigningKey = signingKey
+	k.PublicKeys = append(k.PublicKeys, &oidcPublicKey{Key: &public})
+	k.NextRotation = now.Add(k.RotationPeriod)
+	return nil
+}
+
+// prune removes the public keys expired by now, reporting whether there were
+// any
+func (k *oidcKey) prune(now time.Time) bool {
+	publicKeys := k.PublicKeys[:0]
+	for _, publicKey := range k.PublicKeys {
+		if publicKey.ExpireAt.IsZero() || now.Before(publicKey.ExpireAt) {
+			publicKeys = append(publicKeys, publicKey)
+		}
+	}
+	pruned := len(publicKeys) != len(k.PublicKeys)
+	k.PublicKeys = publicKeys
+	return pruned
+}
+
+// verificationKey returns the unexpired public key with the key ID, or nil
+func (k *oidcKey) verificationKey(keyID string, now time.Time) *jose.JSONWebKey {
+	for _, publicKey := range k.PublicKeys {
+		if publicKey.Key.KeyID == keyID && (publicKey.ExpireAt.IsZero() || now.Before(publicKey.ExpireAt)) {
+			return publicKey.Key
+		}
+	}
+	return nil
+}
+
+// sign signs the claims as a JWT of the given type
+func (k *oidcKey) sign(claims interface{}, typ string) (string, error) {
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.SignatureAlgorithm(k.Algorithm), Key: k.SigningKey},
+		(&jose.SignerOptions{}).WithType(jose.ContentType(typ)))
+	if err != nil {
+		return "", err
+	}
+	return jwt.Signed(signer).Claims(claims).CompactSerialize()
+}
+
+func (i *IdentityStore) pathOIDCKeyWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	name := d.Get("name").(string)
+
+	i.oidcLock.Lock()
+	defer i.oidcLock.Unlock()
+
+	key, err := i.getOIDCKey(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	create := key == nil
+	if create {
+		key = &oidcKey{NamespaceID: ns.ID}
+	}
+
+	rotate := create
+	if _, ok := d.GetOk("algorithm"); ok || create {
+		algorithm := d.Get("algorithm").(string)
+		if !strutil.StrListContains(oidcSigningAlgorithms, algorithm) {
+			return logical.ErrorResponse(fmt.Sprintf("unsupported algorithm %q", algorithm)), nil
+		}
+		// The key is rotated for a new algorithm to apply right away
+		rotate = rotate || algorithm != key.Algorithm
+		key.Algorithm = algorithm
+	}
+	if _, ok := d.GetOk("rotation_period"); ok || create {
+		key.RotationPeriod = time.Duration(d.Get("rotation_period").(int)) * time.Second
+		if key.RotationPeriod < time.Minute {
+			return logical.ErrorResponse("rotation_period must be at least one minute"), nil
+		}
+		if !create {
+			key.NextRotation = time.Now().Add(key.RotationPeriod)
+		}
+	}
+	if _, ok := d.GetOk("verification_ttl"); ok || create {
+		key.VerificationTTL = time.Duration(d.Get("verification_ttl").(int)) * time.Second
+	}
+
+	// ID tokens must remain verifiable until they expire
+	clients, err := i.oidcClients(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for clientName, client := range clients {
+		if client.Key == name && client.IDTokenTTL > key.VerificationTTL {
+			return logical.ErrorResponse(fmt.Sprintf("verification_ttl can't be shorter than the id_token_ttl of client %q", clientName)), nil
+		}
+	}
+
+	if rotate {
+		if err := key.rotate(time.Now()); err != nil {
+			return nil, err
+		}
+	}
+	if err := i.putOIDCObject(ctx, oidcKeyPrefix+name, key); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+func (i *IdentityStore) pathOIDCKeyRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	i.oidcLock.RLock()
+	defer i.oidcLock.RUnlock()
+
+	key, err := i.getOIDCKey(ctx, d.Get("name").(string))
+	if err != nil || key == nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"algorithm":        key.Algorithm,
+			"rotation_period":  int64(key.RotationPeriod.Seconds()),
+			"verification_ttl": int64(key.VerificationTTL.Seconds()),
+			"next_rotation":    key.NextRotation.Format(time.RFC3339),
+		},
+	}, nil
+}
+
+func (i *IdentityStore) pathOIDCKeyDelete(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	i.oidcLock.Lock()
+	defer i.oidcLock.Unlock()
+
+	key, err := i.getOIDCKey(ctx, name)
+	if err != nil || key == nil {
+		return nil, err
+	}
+
+	clients, err := i.oidcClients(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var users []string
+	for clientName, client := range clients {
+		if client.Key == name {
+			users = append(users, clientName)
+		}
+	}
+	if len(users) > 0 {
+		sort.Strings(users)
+		return logical.ErrorResponse(fmt.Sprintf("key is used by clients: %s", strings.Join(users, ", "))), nil
+	}
+
+	return nil, i.view.Delete(ctx, oidcKeyPrefix+name)
+}
+
+func (i *IdentityStore) pathOIDCKeyRotate(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	i.oidcLock.Lock()
+	defer i.oidcLock.Unlock()
+
+	key, err := i.getOIDCKey(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		return logical.ErrorResponse("key not found"), nil
+	}
+
+	if err := key.rotate(time.Now()); err != nil {
+		return nil, err
+	}
+	if err := i.putOIDCObject(ctx, oidcKeyPrefix+name, key); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+// rotateOIDCKeys rotates the keys due for rotation by now and stops
+// publishing the expired public keys. It is run periodically on the active
+// node.
+func (i *IdentityStore) rotateOIDCKeys(ctx context.Context, now time.Time) error {
+	i.oidcLock.Lock()
+	defer i.oidcLock.Unlock()
+
+	names, err := i.view.List(ctx, oidcKeyPrefix)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		entry, err := i.view.Get(ctx, oidcKeyPrefix+name)
+		if err != nil {
+			return err
+		}
+		if entry == nil {
+			continue
+		}
+		key := &oidcKey{}
+		if err := entry.DecodeJSON(key); err != nil {
+			i.logger.Error("failed to decode OIDC key", "name", name, "error", err)
+			continue
+		}
+
+		changed := key.prune(now)
+		if !now.Before(key.NextRotation) {
+			if err := key.rotate(now); err != nil {
+				i.logger.Error("failed to rotate OIDC key", "name", name, "error", err)
+				continue
+			}
+			changed = true
+		}
+		if changed {
+			if err := i.putOIDCObject(ctx, oidcKeyPrefix+name, key); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// oidcWholeDirective returns the template directive making up the whole
+// string, if any
+func oidcWholeDirective(s string) string {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "{{") || !strings.HasSuffix(s, "}}") {
+		return ""
+	}
+	return strings.TrimSpace(s[2 : len(s)-2])
+}
+
+// oidcTemplater populates the claims of scope templates. Besides the
+// directives of policy templates, a string made of one of
+// identity.entity.groups.names, identity.entity.groups.ids and
+// identity.entity.metadata is replaced with a list or an object. The claims
+// whose values can't be populated are omitted.
+type oidcTemplater struct {
+	entity *identity.Entity
+	groups []*identity.Group
+	ns     *namespace.Namespace
+}
+
+func (t *oidcTemplater) populate(value interface{}) (interface{}, bool) {
+	switch value := value.(type) {
+	case string:
+		return t.populateString(value)
+	case map[string]interface{}:
+		ret := make(map[string]interface{}, len(value))
+		for k, v := range value {
+			if populated, ok := t.populate(v); ok {
+				ret[k] = populated
+			}
+		}
+		return ret, true
+	case []interface{}:
+		ret := make([]interface{}, 0, len(value))
+		for _, v := range value {
+			if populated, ok := t.populate(v); ok {
+				ret = append(ret, populated)
+			}
+		}
+		return ret, true
+	}
+	return value, true
+}
+
+func (t *oidcTemplater) populateString(s string) (interface{}, bool) {
+	switch oidcWholeDirective(s) {
+	case "identity.entity.groups.names":
+		names := []string{}
+		for _, group := range t.groups {
+			if group.NamespaceID == t.ns.ID {
+				names = append(names, group.Name)
+			}
+		}
+		sort.Strings(names)
+		return names, true
+	case "identity.entity.groups.ids":
+		ids := []string{}
+		for _, group := range t.groups {
+			ids = append(ids, group.ID)
+		}
+		sort.Strings(ids)
+		return ids, true
+	case "identity.entity.metadata":
+		metadata := make(map[string]string, len(t.entity.Metadata))
+		for k, v := range t.entity.Metadata {
+			metadata[k] = v
+		}
+		return metadata, true
+	}
+
+	_, populated, err := identity.PopulateString(&identity.PopulateStringInput{
+		String:    s,
+		Entity:    t.entity,
+		Groups:    t.groups,
+		Namespace: t.ns,
+	})
+	if err != nil {
+		return nil, false
+	}
+	return populated, true
+}
+
+// parseOIDCTemplate parses and validates a scope template
+func parseOIDCTemplate(template string) (map[string]interface{}, error) {
+	var claims map[string]interface{}
+	if err := jsonutil.DecodeJSON([]byte(template), &claims); err != nil {
+		return nil, errwrap.Wrapf("template is not a valid JSON object: {{err}}", err)
+	}
+	if claims == nil {
+		return nil, fmt.Errorf("template is not a valid JSON object")
+	}
+
+	for claim := range claims {
+		if strutil.StrListContains(oidcReservedClaims, claim) {
+			return nil, fmt.Errorf("template can't set the reserved claim %q", claim)
+		}
+	}
+
+	var validate func(interface{}) error
+	validate = func(value interface{}) error {
+		switch value := value.(type) {
+		case string:
+			_, _, err := identity.PopulateString(&identity.PopulateStringInput{
+				ValidityCheckOnly: true,
+				String:            value,
+			})
+			return err
+		case map[string]interface{}:
+			for _, v := range value {
+				if err := validate(v); err != nil {
+					return err
+				}
+			}
+		case []interface{}:
+			for _, v := range value {
+				if err := validate(v); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	if err := validate(claims); err != nil {
+		return nil, errwrap.Wrapf("invalid template: {{err}}", err)
+	}
+	return claims, nil
+}
+
+func (i *IdentityStore) pathOIDCScopeWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	name := d.Get("name").(string)
+	if name == oidcOpenIDScope {
+		return logical.ErrorResponse(fmt.Sprintf("the %q scope is reserved", oidcOpenIDScope)), nil
+	}
+
+	i.oidcLock.Lock()
+	defer i.oidcLock.Unlock()
+
+	scope, err := i.getOIDCScope(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if scope == nil {
+		scope = &oidcScope{NamespaceID: ns.ID}
+	}
+
+	if template, ok := d.GetOk("template"); ok {
+		scope.Template = template.(string)
+	}
+	if description, ok := d.GetOk("description"); ok {
+		scope.Description = description.(string)
+	}
+	if scope.Template != "" {
+		if _, err := parseOIDCTemplate(scope.Template); err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+	}
+
+	if err := i.putOIDCObject(ctx, oidcScopePrefix+name, scope); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+func (i *IdentityStore) pathOIDCScopeRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	i.oidcLock.RLock()
+	defer i.oidcLock.RUnlock()
+
+	scope, err := i.getOIDCScope(ctx, d.Get("name").(string))
+	if err != nil || scope == nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"template":    scope.Template,
+			"description": scope.Description,
+		},
+	}, nil
+}
+
+func (i *IdentityStore) pathOIDCScopeDelete(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	i.oidcLock.Lock()
+	defer i.oidcLock.Unlock()
+
+	providers, err := i.oidcNames(ctx, oidcProviderPrefix, func() oidcObject { return &oidcProvider{} })
+	if err != nil {
+		return nil, err
+	}
+	var users []string
+	for _, providerName := range providers {
+		provider, err := i.getOIDCProvider(ctx, providerName)
+		if err != nil {
+			return nil, err
+		}
+		if provider != nil && strutil.StrListContains(provider.ScopesSupported, name) {
+			users = append(users, providerName)
+		}
+	}
+	if len(users) > 0 {
+		return logical.ErrorResponse(fmt.Sprintf("scope is supported by providers: %s", strings.Join(users, ", "))), nil
+	}
+
+	scope, err := i.getOIDCScope(ctx, name)
+	if err != nil || scope == nil {
+		return nil, err
+	}
+	return nil, i.view.Delete(ctx, oidcScopePrefix+name)
+}
+
+func (i *IdentityStore) pathOIDCClientWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	name := d.Get("name").(string)
+
+	i.oidcLock.Lock()
+	defer i.oidcLock.Unlock()
+
+	client, err := i.getOIDCClient(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	create := client == nil
+	if create {
+		client = &oidcClient{NamespaceID: ns.ID}
+		if client.ClientID, err = base62.Random(32); err != nil {
+			return nil, err
+		}
+		if client.ClientSecret, err = base62.Random(64); err != nil {
+			return nil, err
+		}
+	}
+
+	if keyName, ok := d.GetOk("key"); ok {
+		client.Key = keyName.(string)
+	}
+	if client.Key == "" {
+		return logical.ErrorResponse("missing key"), nil
+	}
+	if _, ok := d.GetOk("redirect_uris"); ok || create {
+		client.RedirectURIs = d.Get("redirect_uris").([]string)
+	}
+	for _, redirectURI := range client.RedirectURIs {
+		if u, err := url.Parse(redirectURI); err != nil || !u.IsAbs() || u.Fragment != "" {
+			return logical.ErrorResponse(fmt.Sprintf("invalid redirect URI %q", redirectURI)), nil
+		}
+	}
+	if _, ok := d.GetOk("entity_ids"); ok || create {
+		client.EntityIDs = d.Get("entity_ids").([]string)
+	}
+	if _, ok := d.GetOk("group_ids"); ok || create {
+		client.GroupIDs = d.Get("group_ids").([]string)
+	}
+	if _, ok := d.GetOk("id_token_ttl"); ok || create {
+		client.IDTokenTTL = time.Duration(d.Get("id_token_ttl").(int)) * time.Second
+	}
+	if _, ok := d.GetOk("access_token_ttl"); ok || create {
+		client.AccessTokenTTL = time.Duration(d.Get("access_token_ttl").(int)) * time.Second
+	}
+	if client.IDTokenTTL <= 0 || client.AccessTokenTTL <= 0 {
+		return logical.ErrorResponse("id_token_ttl and access_token_ttl must be positive"), nil
+	}
+
+	key, err := i.getOIDCKey(ctx, client.Key)
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		return logical.ErrorResponse(fmt.Sprintf("key %q not found", client.Key)), nil
+	}
+	if client.IDTokenTTL > key.VerificationTTL {
+		return logical.ErrorResponse(fmt.Sprintf("id_token_ttl can't exceed the verification_ttl of key %q", client.Key)), nil
+	}
+
+	if err := i.putOIDCObject(ctx, oidcClientPrefix+name, client); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+func (i *IdentityStore) pathOIDCClientRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	i.oidcLock.RLock()
+	defer i.oidcLock.RUnlock()
+
+	client, err := i.getOIDCClient(ctx, d.Get("name").(string))
+	if err != nil || client == nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"client_id":        client.ClientID,
+			"client_secret":    client.ClientSecret,
+			"key":              client.Key,
+			"redirect_uris":    client.RedirectURIs,
+			"entity_ids":       client.EntityIDs,
+			"group_ids":        client.GroupIDs,
+			"id_token_ttl":     int64(client.IDTokenTTL.Seconds()),
+			"access_token_ttl": int64(client.AccessTokenTTL.Seconds()),
+		},
+	}, nil
+}
+
+func (i *IdentityStore) pathOIDCClientDelete(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	i.oidcLock.Lock()
+	defer i.oidcLock.Unlock()
+
+	client, err := i.getOIDCClient(ctx, name)
+	if err != nil || client == nil {
+		return nil, err
+	}
+	return nil, i.view.Delete(ctx, oidcClientPrefix+name)
+}
+
+// assigned reports whether the entity, member of the groups, can
+// authenticate to the client
+func (c *oidcClient) assigned(entityID string, groups []*identity.Group) bool {
+	if strutil.StrListContains(c.EntityIDs, "*") || strutil.StrListContains(c.EntityIDs, entityID) {
+		return true
+	}
+	for _, group := range groups {
+		if strutil.StrListContains(c.GroupIDs, group.ID) {
+			return true
+		}
+	}
+	return false
+}
+
+func (i *IdentityStore) pathOIDCProviderWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	name := d.Get("name").(string)
+
+	i.oidcLock.Lock()
+	defer i.oidcLock.Unlock()
+
+	provider, err := i.getOIDCProvider(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if provider == nil {
+		provider = &oidcProvider{NamespaceID: ns.ID}
+	}
+
+	if issuer, ok := d.GetOk("issuer"); ok {
+		provider.Issuer = strings.TrimSuffix(issuer.(string), "/")
+	}
+	if provider.Issuer != "" {
+		u, err := url.Parse(provider.Issuer)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.Path != "" || u.RawQuery != "" || u.Fragment != "" {
+			return logical.ErrorResponse("issuer must be made of a scheme, a host and an optional port"), nil
+		}
+	} else if i.core.redirectAddr == "" {
+		return logical.ErrorResponse("issuer must be set as Vault has no api_addr"), nil
+	}
+
+	if clientIDs, ok := d.GetOk("allowed_client_ids"); ok {
+		provider.AllowedClientIDs = clientIDs.([]string)
+	}
+	if scopes, ok := d.GetOk("scopes_supported"); ok {
+		provider.ScopesSupported = scopes.([]string)
+	}
+	for _, scopeName := range provider.ScopesSupported {
+		if scopeName == oidcOpenIDScope {
+			return logical.ErrorResponse(fmt.Sprintf("the %q scope is always supported", oidcOpenIDScope)), nil
+		}
+		scope, err := i.getOIDCScope(ctx, scopeName)
+		if err != nil {
+			return nil, err
+		}
+		if scope == nil {
+			return logical.ErrorResponse(fmt.Sprintf("scope %q not found", scopeName)), nil
+		}
+	}
+
+	if err := i.putOIDCObject(ctx, oidcProviderPrefix+name, provider); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+func (i *IdentityStore) pathOIDCProviderRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	i.oidcLock.RLock()
+	defer i.oidcLock.RUnlock()
+
+	provider, err := i.getOIDCProvider(ctx, name)
+	if err != nil || provider == nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"issuer":             i.oidcIssuer(provider, name),
+			"allowed_client_ids": provider.AllowedClientIDs,
+			"scopes_supported":   provider.ScopesSupported,
+		},
+	}, nil
+}
+
+func (i *IdentityStore) pathOIDCProviderDelete(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	i.oidcLock.Lock()
+	defer i.oidcLock.Unlock()
+
+	provider, err := i.getOIDCProvider(ctx, name)
+	if err != nil || provider == nil {
+		return nil, err
+	}
+	return nil, i.view.Delete(ctx, oidcProviderPrefix+name)
+}
+
+// oidcIssuer returns the issuer URL of the provider, under which its
+// unauthenticated endpoints are served
+func (i *IdentityStore) oidcIssuer(provider *oidcProvider, name string) string {
+	base := provider.Issuer
+	if base == "" {
+		base = strings.TrimSuffix(i.core.redirectAddr, "/")
+	}
+	return base + "/v1/identity/" + oidcIDPPathPrefix + name
+}
+
+func (p *oidcProvider) allowsClient(clientID string) bool {
+	return strutil.StrListContains(p.AllowedClientIDs, "*") || strutil.StrListContains(p.AllowedClientIDs, clientID)
+}
+
+// oidcEntity returns the entity if it exists in the namespace of the request
+// and is enabled, along with the groups it is a direct or inherited member of
+func (i *IdentityStore) oidcEntity(ctx context.Context, entityID string) (*identity.Entity, []*identity.Group, error) {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	entity, err := i.MemDBEntityByID(entityID, false)
+	if err != nil || entity == nil || entity.Disabled || entity.NamespaceID != ns.ID {
+		return nil, nil, err
+	}
+
+	groups, inheritedGroups, err := i.groupsByEntityID(entity.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return entity, append(groups, inheritedGroups...), nil
+}
+
+// oidcClaims returns the claims of the scopes populated for the entity
+func (i *IdentityStore) oidcClaims(ctx context.Context, scopes []string, entity *identity.Entity, groups []*identity.Group) (map[string]interface{}, error) {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	templater := &oidcTemplater{
+		entity: entity,
+		groups: groups,
+		ns:     ns,
+	}
+	claims := make(map[string]interface{})
+	for _, scopeName := range scopes {
+		if scopeName == oidcOpenIDScope {
+			continue
+		}
+		scope, err := i.getOIDCScope(ctx, scopeName)
+		if err != nil {
+			return nil, err
+		}
+		if scope == nil || scope.Template == "" {
+			continue
+		}
+
+		template, err := parseOIDCTemplate(scope.Template)
+		if err != nil {
+			return nil, err
+		}
+		for claim, value := range template {
+			if populated, ok := templater.populate(value); ok {
+				claims[claim] = populated
+			}
+		}
+	}
+	return claims, nil
+}
+
+// pathOIDCAuthorize authenticates the entity of the token of the request to
+// a client, issuing an authorization code the client exchanges for tokens
+func (i *IdentityStore) pathOIDCAuthorize(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	name := d.Get("name").(string)
+
+	if req.EntityID == "" {
+		return logical.ErrorResponse("the token of the request is not tied to an entity"), logical.ErrPermissionDenied
+	}
+
+	i.oidcLock.RLock()
+	defer i.oidcLock.RUnlock()
+
+	provider, err := i.getOIDCProvider(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if provider == nil {
+		return logical.ErrorResponse("provider not found"), nil
+	}
+
+	if responseType := d.Get("response_type").(string); responseType != "code" {
+		return logical.ErrorResponse(fmt.Sprintf("unsupported response_type %q", responseType)), nil
+	}
+
+	clientID := d.Get("client_id").(string)
+	client, err := i.oidcClientByID(ctx, clientID)
+	if err != nil {
+		return nil, err
+	}
+	if client == nil || !provider.allowsClient(client.ClientID) {
+		return logical.ErrorResponse("client not found"), nil
+	}
+
+	redirectURI := d.Get("redirect_uri").(string)
+	if !strutil.StrListContains(client.RedirectURIs, redirectURI) {
+		return logical.ErrorResponse("redirect_uri is not allowed by the client"), nil
+	}
+
+	state := d.Get("state").(string)
+	if state == "" {
+		return logical.ErrorResponse("missing state"), nil
+	}
+
+	// The scopes not supported by the provider are ignored
+	requested := strings.Fields(d.Get("scope").(string))
+	if !strutil.StrListContains(requested, oidcOpenIDScope) {
+		return logical.ErrorResponse(fmt.Sprintf("scope must include %q", oidcOpenIDScope)), nil
+	}
+	scopes := []string{oidcOpenIDScope}
+	for _, scope := range strutil.RemoveDuplicates(requested, false) {
+		if strutil.StrListContains(provider.ScopesSupported, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	codeChallenge := d.Get("code_challenge").(string)
+	codeChallengeMethod := d.Get("code_challenge_method").(string)
+	switch {
+	case codeChallenge == "" && codeChallengeMethod != "":
+		return logical.ErrorResponse("code_challenge_method requires a code_challenge"), nil
+	case codeChallenge == "":
+	case codeChallengeMethod == "":
+		codeChallengeMethod = "plain"
+	case codeChallengeMethod != "plain" && codeChallengeMethod != "S256":
+		return logical.ErrorResponse(fmt.Sprintf("unsupported code_challenge_method %q", codeChallengeMethod)), nil
+	}
+
+	entity, groups, err := i.oidcEntity(ctx, req.EntityID)
+	if err != nil {
+		return nil, err
+	}
+	if entity == nil {
+		return logical.ErrorResponse("the entity of the token is not found or disabled"), logical.ErrPermissionDenied
+	}
+	if !client.assigned(entity.ID, groups) {
+		return logical.ErrorResponse("the entity of the token is not assigned to the client"), logical.ErrPermissionDenied
+	}
+
+	code, err := base62.Random(32)
+	if err != nil {
+		return nil, err
+	}
+	i.oidcAuthCodes.SetDefault(code, &oidcAuthCode{
+		NamespaceID:         ns.ID,
+		Provider:            name,
+		ClientID:            client.ClientID,
+		EntityID:            entity.ID,
+		RedirectURI:         redirectURI,
+		Scopes:              scopes,
+		Nonce:               d.Get("nonce").(string),
+		CodeChallenge:       codeChallenge,
+		CodeChallengeMethod: codeChallengeMethod,
+		AuthTime:            time.Now(),
+	})
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"code":  code,
+			"state": state,
+		},
+	}, nil
+}
+
+// oidcRawResponse returns the body encoded as JSON as is, as expected by the
+// relying parties
+func oidcRawResponse(status int, body interface{}) (*logical.Response, error) {
+	encoded, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	return &logical.Response{
+		Data: map[string]interface{}{
+			logical.HTTPStatusCode:  status,
+			logical.HTTPContentType: "application/json",
+			logical.HTTPRawBody:     encoded,
+		},
+	}, nil
+}
+
+// oidcErrorResponse returns an OAuth 2.0 error response
+func oidcErrorResponse(status int, code, description string) (*logical.Response, error) {
+	return oidcRawResponse(status, map[string]string{
+		"error":             code,
+		"error_description": description,
+	})
+}
+
+func (i *IdentityStore) pathOIDCDiscovery(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	i.oidcLock.RLock()
+	defer i.oidcLock.RUnlock()
+
+	provider, err := i.getOIDCProvider(ctx, name)
+	if err != nil || provider == nil {
+		return nil, err
+	}
+
+	issuer := i.oidcIssuer(provider, name)
+	base := strings.TrimSuffix(issuer, oidcIDPPathPrefix+name)
+	return oidcRawResponse(http.StatusOK, map[string]interface{}{
+		"issuer":                                issuer,
+		"authorization_endpoint":                base + "oidc/provider/" + name + "/authorize",
+		"token_endpoint":                        issuer + "/token",
+		"userinfo_endpoint":                     issuer + "/userinfo",
+		"jwks_uri":                              issuer + "/.well-known/keys",
+		"scopes_supported":                      append([]string{oidcOpenIDScope}, provider.ScopesSupported...),
+		"response_types_supported":              []string{"code"},
+		"grant_types_supported":                 []string{"authorization_code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": oidcSigningAlgorithms,
+		"token_endpoint_auth_methods_supported": []string{"client_secret_post"},
+		"code_challenge_methods_supported":      []string{"plain", "S256"},
+	})
+}
+
+// pathOIDCKeys serves the public keys of the keys used by the clients of the
+// provider
+func (i *IdentityStore) pathOIDCKeys(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	i.oidcLock.RLock()
+	defer i.oidcLock.RUnlock()
+
+	provider, err := i.getOIDCProvider(ctx, d.Get("name").(string))
+	if err != nil || provider == nil {
+		return nil, err
+	}
+
+	clients, err := i.oidcClients(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var keyNames []string
+	for _, client := range clients {
+		if provider.allowsClient(client.ClientID) && !strutil.StrListContains(keyNames, client.Key) {
+			keyNames = append(keyNames, client.Key)
+		}
+	}
+	sort.Strings(keyNames)
+
+	now := time.Now()
+	jwks := jose.JSONWebKeySet{
+		Keys: []jose.JSONWebKey{},
+	}
+	for _, keyName := range keyNames {
+		key, err := i.getOIDCKey(ctx, keyName)
+		if err != nil {
+			return nil, err
+		}
+		if key == nil {
+			continue
+		}
+		for _, publicKey := range key.PublicKeys {
+			if publicKey.ExpireAt.IsZero() || now.Before(publicKey.ExpireAt) {
+				jwks.Keys = append(jwks.Keys, *publicKey.Key)
+			}
+		}
+	}
+	return oidcRawResponse(http.StatusOK, jwks)
+}
+
+// oidcTokenHash returns the at_hash of a token signed with the algorithm
+func oidcTokenHash(algorithm, token string) string {
+	hash := crypto.SHA256
+	switch {
+	case strings.HasSuffix(algorithm, "384"):
+		hash = crypto.SHA384
+	case strings.HasSuffix(algorithm, "512"):
+		hash = crypto.SHA512
+	}
+	h := hash.New()
+	h.Write([]byte(token))
+	sum := h.Sum(nil)
+	return base64.RawURLEncoding.EncodeToString(sum[:len(sum)/2])
+}
+
+// verifyOIDCCodeChallenge checks the PKCE code verifier against the challenge
+// of the authorization request
+func verifyOIDCCodeChallenge(authCode *oidcAuthCode, verifier string) bool {
+	if authCode.CodeChallenge == "" {
+		return true
+	}
+	if verifier == "" {
+		return false
+	}
+
+	challenge := verifier
+	if authCode.CodeChallengeMethod == "S256" {
+		sum := sha256.Sum256([]byte(verifier))
+		challenge = base64.RawURLEncoding.EncodeToString(sum[:])
+	}
+	return subtle.ConstantTimeCompare([]byte(challenge), []byte(authCode.CodeChallenge)) == 1
+}
+
+// pathOIDCToken exchanges an authorization code for an ID token and an
+// access token
+func (i *IdentityStore) pathOIDCToken(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	name := d.Get("name").(string)
+
+	// The write lock makes the codes single use
+	i.oidcLock.Lock()
+	defer i.oidcLock.Unlock()
+
+	provider, err := i.getOIDCProvider(ctx, name)
+	if err != nil || provider == nil {
+		return nil, err
+	}
+
+	if grantType := d.Get("grant_type").(string); grantType != "authorization_code" {
+		return oidcErrorResponse(http.StatusBadRequest, "unsupported_grant_type", fmt.Sprintf("unsupported grant_type %q", grantType))
+	}
+
+	client, err := i.oidcClientByID(ctx, d.Get("client_id").(string))
+	if err != nil {
+		return nil, err
+	}
+	if client == nil || subtle.ConstantTimeCompare([]byte(client.ClientSecret), []byte(d.Get("client_secret").(string))) != 1 {
+		return oidcErrorResponse(http.StatusUnauthorized, "invalid_client", "client authentication failed")
+	}
+	if !provider.allowsClient(client.ClientID) {
+		return oidcErrorResponse(http.StatusBadRequest, "unauthorized_client", "client is not allowed by the provider")
+	}
+
+	code := d.Get("code").(string)
+	raw, ok := i.oidcAuthCodes.Get(code)
+	if !ok {
+		return oidcErrorResponse(http.StatusBadRequest, "invalid_grant", "invalid or expired code")
+	}
+	i.oidcAuthCodes.Delete(code)
+	authCode := raw.(*oidcAuthCode)
+	switch {
+	case authCode.NamespaceID != ns.ID, authCode.Provider != name, authCode.ClientID != client.ClientID:
+		return oidcErrorResponse(http.StatusBadRequest, "invalid_grant", "invalid or expired code")
+	case authCode.RedirectURI != d.Get("redirect_uri").(string):
+		return oidcErrorResponse(http.StatusBadRequest, "invalid_grant", "redirect_uri doesn't match the authorization request")
+	case !verifyOIDCCodeChallenge(authCode, d.Get("code_verifier").(string)):
+		return oidcErrorResponse(http.StatusBadRequest, "invalid_grant", "invalid code_verifier")
+	}
+
+	entity, groups, err := i.oidcEntity(ctx, authCode.EntityID)
+	if err != nil {
+		return nil, err
+	}
+	if entity == nil || !client.assigned(entity.ID, groups) {
+		return oidcErrorResponse(http.StatusBadRequest, "invalid_grant", "the entity can no longer authenticate to the client")
+	}
+
+	key, err := i.getOIDCKey(ctx, client.Key)
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		return nil, fmt.Errorf("key %q of client not found", client.Key)
+	}
+
+	now := time.Now()
+	issuer := i.oidcIssuer(provider, name)
+	jti, err := uuid.GenerateUUID()
+	if err != nil {
+		return nil, err
+	}
+	accessToken, err := key.sign(map[string]interface{}{
+		"iss":       issuer,
+		"sub":       entity.ID,
+		"aud":       client.ClientID,
+		"client_id": client.ClientID,
+		"scope":     strings.Join(authCode.Scopes, " "),
+		"iat":       now.Unix(),
+		"exp":       now.Add(client.AccessTokenTTL).Unix(),
+		"jti":       jti,
+	}, oidcAccessTokenType)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, err := i.oidcClaims(ctx, authCode.Scopes, entity, groups)
+	if err != nil {
+		return nil, err
+	}
+	claims["iss"] = issuer
+	claims["sub"] = entity.ID
+	claims["aud"] = client.ClientID
+	claims["iat"] = now.Unix()
+	claims["exp"] = now.Add(client.IDTokenTTL).Unix()
+	claims["auth_time"] = authCode.AuthTime.Unix()
+	claims["at_hash"] = oidcTokenHash(key.Algorithm, accessToken)
+	if authCode.Nonce != "" {
+		claims["nonce"] = authCode.Nonce
+	}
+	idToken, err := key.sign(claims, "JWT")
+	if err != nil {
+		return nil, err
+	}
+
+	return oidcRawResponse(http.StatusOK, map[string]interface{}{
+		"access_token": accessToken,
+		"token_type":   "Bearer",
+		"expires_in":   int64(client.AccessTokenTTL.Seconds()),
+		"id_token":     idToken,
+	})
+}
+
+// pathOIDCUserInfo returns the claims of the entity an access token was
+// issued for
+func (i *IdentityStore) pathOIDCUserInfo(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	i.oidcLock.RLock()
+	defer i.oidcLock.RUnlock()
+
+	provider, err := i.getOIDCProvider(ctx, name)
+	if err != nil || provider == nil {
+		return nil, err
+	}
+
+	invalidToken := func(description string) (*logical.Response, error) {
+		return oidcErrorResponse(http.StatusUnauthorized, "invalid_token", description)
+	}
+
+	// The bearer token of the request is passed as is by the router
+	accessToken := req.ClientToken
+	if raw, ok := d.GetOk("access_token"); ok {
+		accessToken = raw.(string)
+	}
+	if accessToken == "" {
+		return invalidToken("missing access token")
+	}
+
+	parsed, err := jwt.ParseSigned(accessToken)
+	if err != nil || len(parsed.Headers) != 1 || parsed.Headers[0].ExtraHeaders[jose.HeaderType] != oidcAccessTokenType {
+		return invalidToken("malformed access token")
+	}
+	var unverified struct {
+		ClientID string `json:"client_id"`
+	}
+	if err := parsed.UnsafeClaimsWithoutVerification(&unverified); err != nil {
+		return invalidToken("malformed access token")
+	}
+
+	client, err := i.oidcClientByID(ctx, unverified.ClientID)
+	if err != nil {
+		return nil, err
+	}
+	if client == nil || !provider.allowsClient(client.ClientID) {
+		return invalidToken("client not found")
+	}
+	key, err := i.getOIDCKey(ctx, client.Key)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	var publicKey *jose.JSONWebKey
+	if key != nil {
+		publicKey = key.verificationKey(parsed.Headers[0].KeyID, now)
+	}
+	if publicKey == nil {
+		return invalidToken("unknown signing key")
+	}
+
+	var claims jwt.Claims
+	var scope struct {
+		Scope string `json:"scope"`
+	}
+	if err := parsed.Claims(publicKey, &claims, &scope); err != nil {
+		return invalidToken("invalid signature")
+	}
+	expected := jwt.Expected{
+		Issuer:   i.oidcIssuer(provider, name),
+		Audience: jwt.Audience{client.ClientID},
+		Time:     now,
+	}
+	if err := claims.ValidateWithLeeway(expected, 0); err != nil {
+		return invalidToken(err.Error())
+	}
+
+	entity, groups, err := i.oidcEntity(ctx, claims.Subject)
+	if err != nil {
+		return nil, err
+	}
+	if entity == nil || !client.assigned(entity.ID, groups) {
+		return invalidToken("the entity can no longer authenticate to the client")
+	}
+
+	userInfo, err := i.oidcClaims(ctx, strings.Fields(scope.Scope), entity, groups)
+	if err != nil {
+		return nil, err
+	}
+	userInfo["sub"] = entity.ID
+	return oidcRawResponse(http.StatusOK, userInfo)
+}
+
+var oidcProviderHelp = map[string][2]string{
+	"oidc-key": {
+		"Create, read, update or delete a key signing the tokens of OIDC clients.",
+		`
+A key is rotated every rotation_period. Once rotated, its previous public key
+remains published for verification_ttl, which can't be shorter than the
+id_token_ttl of the clients using the key. Changing the algorithm rotates the
+key. A key can't be deleted while clients use it.
+`,
+	},
+	"oidc-key-rotate": {
+		"Rotate a key signing the tokens of OIDC clients.",
+		"Rotates the key now. Its previous public key remains published for the verification_ttl of the key.",
+	},
+	"oidc-key-list": {
+		"List the keys signing the tokens of OIDC clients.",
+		"Lists the names of the keys.",
+	},
+	"oidc-scope": {
+		"Create, read, update or delete a scope OIDC clients can request.",
+		`
+The template of a scope is a JSON object of the claims added to the ID tokens
+and userinfo when the scope is requested. Its string values can hold identity
+templates such as {{identity.entity.name}} or
+{{identity.entity.metadata.<key>}}. A value of
+{{identity.entity.groups.names}} or {{identity.entity.groups.ids}} is replaced
+with the list of the groups of the entity, and a value of
+{{identity.entity.metadata}} with the metadata of the entity. Claims which
+can't be populated for an entity are omitted. A scope can't be deleted while
+providers support it.
+`,
+	},
+	"oidc-scope-list": {
+		"List the scopes OIDC clients can request.",
+		"Lists the names of the scopes.",
+	},
+	"oidc-client": {
+		"Create, read, update or delete an OIDC client.",
+		`
+A client is a relying party which the entities assigned to it, directly or
+through groups, can authenticate to. Its client ID and client secret are
+generated when it is created.
+`,
+	},
+	"oidc-client-list": {
+		"List the OIDC clients.",
+		"Lists the names of the clients.",
+	},
+	"oidc-provider": {
+		"Create, read, update or delete an OIDC provider.",
+		`
+A provider serves the clients it allows and the scopes it supports. Its
+unauthenticated endpoints are served under its issuer, at
+identity/oidc/idp/<name>.
+`,
+	},
+	"oidc-provider-list": {
+		"List the OIDC providers.",
+		"Lists the names of the providers.",
+	},
+	"oidc-authorize": {
+		"Authenticate the entity of the token to an OIDC client.",
+		`
+Issues an authorization code for the entity of the token of the request, which
+must be assigned to the client. The client exchanges the code at the token
+endpoint of the provider within five minutes.
+`,
+	},
+	"oidc-discovery": {
+		"Read the OpenID configuration of a provider.",
+		"Returns the OpenID Connect discovery document of the provider. This endpoint is unauthenticated.",
+	},
+	"oidc-keys": {
+		"Read the public keys of a provider.",
+		"Returns the JSON Web Key Set verifying the tokens of the clients of the provider. This endpoint is unauthenticated.",
+	},
+	"oidc-token": {
+		"Exchange an authorization code for tokens.",
+		`
+Exchanges an authorization code for an ID token and an access token. The
+client authenticates with its client_id and client_secret. This endpoint is
+unauthenticated.
+`,
+	},
+	"oidc-userinfo": {
+		"Read the claims of the entity of an access token.",
+		`
+Returns the claims of the scopes of the access token, sent as a bearer token.
+This endpoint is unauthenticated.
+`,
+	},
+}
@@ -0,0 +1,286 @@
+package vault
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/logical"
+	jose "gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+func TestIdentityStore_OIDCProvider(t *testing.T) {
+	c, _, _ := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+	is := c.identityStore
+
+	handle := func(req *logical.Request) *logical.Response {
+		t.Helper()
+		resp, err := is.HandleRequest(ctx, req)
+		if err != nil && err != logical.ErrPermissionDenied {
+			t.Fatalf("%s %s: %v", req.Operation, req.Path, err)
+		}
+		return resp
+	}
+	request := func(op logical.Operation, path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		return handle(&logical.Request{
+			Path:      path,
+			Operation: op,
+			Data:      data,
+		})
+	}
+	mustSucceed := func(resp *logical.Response) {
+		t.Helper()
+		if resp != nil && resp.IsError() {
+			t.Fatalf("bad: %#v", resp)
+		}
+	}
+	mustFail := func(resp *logical.Response) {
+		t.Helper()
+		if resp == nil || !resp.IsError() {
+			t.Fatalf("expected an error, got: %#v", resp)
+		}
+	}
+	raw := func(resp *logical.Response, status int) map[string]interface{} {
+		t.Helper()
+		if resp == nil || resp.Data[logical.HTTPStatusCode] != status {
+			t.Fatalf("expected status %d, got: %#v", status, resp)
+		}
+		var body map[string]interface{}
+		if err := json.Unmarshal(resp.Data[logical.HTTPRawBody].([]byte), &body); err != nil {
+			t.Fatal(err)
+		}
+		return body
+	}
+
+	// Identities
+	resp := request(logical.UpdateOperation, "entity", map[string]interface{}{
+		"name":     "alice",
+		"metadata": "team=eng",
+	})
+	entityID := resp.Data["id"].(string)
+	resp = request(logical.UpdateOperation, "entity", map[string]interface{}{
+		"name": "bob",
+	})
+	otherEntityID := resp.Data["id"].(string)
+	resp = request(logical.UpdateOperation, "group", map[string]interface{}{
+		"name":              "devs",
+		"member_entity_ids": entityID,
+	})
+	groupID := resp.Data["id"].(string)
+
+	// Configuration
+	mustFail(request(logical.UpdateOperation, "oidc/key/signing", map[string]interface{}{"algorithm": "HS256"}))
+	mustSucceed(request(logical.UpdateOperation, "oidc/key/signing", map[string]interface{}{"algorithm": "ES256"}))
+
+	mustFail(request(logical.UpdateOperation, "oidc/scope/openid", map[string]interface{}{"template": `{}`}))
+	mustFail(request(logical.UpdateOperation, "oidc/scope/profile", map[string]interface{}{"template": `{"sub": "me"}`}))
+	mustFail(request(logical.UpdateOperation, "oidc/scope/profile", map[string]interface{}{"template": `["name"]`}))
+	mustFail(request(logical.UpdateOperation, "oidc/scope/profile", map[string]interface{}{"template": `{"name": "{{identity.entity.name"}`}))
+	mustSucceed(request(logical.UpdateOperation, "oidc/scope/profile", map[string]interface{}{
+		"template": `{
+			"name": "{{identity.entity.name}}",
+			"groups": "{{identity.entity.groups.names}}",
+			"org": {"team": "{{identity.entity.metadata.team}}", "site": "{{identity.entity.metadata.site}}"}
+		}`,
+	}))
+
+	mustFail(request(logical.UpdateOperation, "oidc/client/app", map[string]interface{}{"key": "missing"}))
+	mustFail(request(logical.UpdateOperation, "oidc/client/app", map[string]interface{}{"key": "signing", "id_token_ttl": "48h"}))
+	mustSucceed(request(logical.UpdateOperation, "oidc/client/app", map[string]interface{}{
+		"key":           "signing",
+		"redirect_uris": "https://app.example.com/callback",
+		"group_ids":     groupID,
+		"id_token_ttl":  "1h",
+	}))
+	resp = request(logical.ReadOperation, "oidc/client/app", nil)
+	clientID := resp.Data["client_id"].(string)
+	clientSecret := resp.Data["client_secret"].(string)
+	if clientID == "" || clientSecret == "" {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	mustFail(request(logical.UpdateOperation, "oidc/provider/main", map[string]interface{}{"issuer": "https://vault.example.com/path"}))
+	mustFail(request(logical.UpdateOperation, "oidc/provider/main", map[string]interface{}{"issuer": "https://vault.example.com", "scopes_supported": "missing"}))
+	mustSucceed(request(logical.UpdateOperation, "oidc/provider/main", map[string]interface{}{
+		"issuer":             "https://vault.example.com",
+		"allowed_client_ids": clientID,
+		"scopes_supported":   "profile",
+	}))
+	issuer := "https://vault.example.com/v1/identity/oidc/idp/main"
+
+	// Objects in use can't be deleted
+	mustFail(request(logical.DeleteOperation, "oidc/key/signing", nil))
+	mustFail(request(logical.DeleteOperation, "oidc/scope/profile", nil))
+
+	for path, expected := range map[string][]string{
+		"oidc/key/":      {"signing"},
+		"oidc/scope/":    {"profile"},
+		"oidc/client/":   {"app"},
+		"oidc/provider/": {"main"},
+	} {
+		resp = request(logical.ListOperation, path, nil)
+		if keys := resp.Data["keys"].([]string); !reflect.DeepEqual(keys, expected) {
+			t.Fatalf("unexpected %s list: %v", path, keys)
+		}
+	}
+
+	discovery := raw(request(logical.ReadOperation, "oidc/idp/main/.well-known/openid-configuration", nil), http.StatusOK)
+	if discovery["issuer"] != issuer || discovery["token_endpoint"] != issuer+"/token" {
+		t.Fatalf("bad: %#v", discovery)
+	}
+
+	// Authentication
+	authorize := func(entityID string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		params := map[string]interface{}{
+			"client_id":     clientID,
+			"scope":         "openid profile email",
+			"redirect_uri":  "https://app.example.com/callback",
+			"response_type": "code",
+			"state":         "xyz",
+			"nonce":         "abc",
+		}
+		for k, v := range data {
+			params[k] = v
+		}
+		return handle(&logical.Request{
+			Path:      "oidc/provider/main/authorize",
+			Operation: logical.UpdateOperation,
+			Data:      params,
+			EntityID:  entityID,
+		})
+	}
+	mustFail(authorize(otherEntityID, nil))
+	mustFail(authorize(entityID, map[string]interface{}{"scope": "profile"}))
+	mustFail(authorize(entityID, map[string]interface{}{"redirect_uri": "https://evil.example.com/callback"}))
+	mustFail(authorize(entityID, map[string]interface{}{"response_type": "token"}))
+
+	verifier := "a-code-verifier-long-enough-for-the-test"
+	sum := sha256.Sum256([]byte(verifier))
+	challenge := base64.RawURLEncoding.EncodeToString(sum[:])
+	resp = authorize(entityID, map[string]interface{}{
+		"code_challenge":        challenge,
+		"code_challenge_method": "S256",
+	})
+	mustSucceed(resp)
+	code := resp.Data["code"].(string)
+	if resp.Data["state"] != "xyz" {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	exchange := func(data map[string]interface{}) *logical.Response {
+		t.Helper()
+		params := map[string]interface{}{
+			"grant_type":    "authorization_code",
+			"code":          code,
+			"redirect_uri":  "https://app.example.com/callback",
+			"client_id":     clientID,
+			"client_secret": clientSecret,
+			"code_verifier": verifier,
+		}
+		for k, v := range data {
+			params[k] = v
+		}
+		return request(logical.UpdateOperation, "oidc/idp/main/token", params)
+	}
+	if body := raw(exchange(map[string]interface{}{"client_secret": "wrong"}), http.StatusUnauthorized); body["error"] != "invalid_client" {
+		t.Fatalf("bad: %#v", body)
+	}
+	tokens := raw(exchange(nil), http.StatusOK)
+	if body := raw(exchange(nil), http.StatusBadRequest); body["error"] != "invalid_grant" {
+		t.Fatalf("expected the code to be single use, got: %#v", body)
+	}
+
+	// A wrong code verifier consumes the code
+	resp = authorize(entityID, map[string]interface{}{"code_challenge": challenge, "code_challenge_method": "S256"})
+	code = resp.Data["code"].(string)
+	if body := raw(exchange(map[string]interface{}{"code_verifier": "wrong"}), http.StatusBadRequest); body["error"] != "invalid_grant" {
+		t.Fatalf("bad: %#v", body)
+	}
+
+	// The ID token is verified with the published keys
+	jwksBody := request(logical.ReadOperation, "oidc/idp/main/.well-known/keys", nil).Data[logical.HTTPRawBody].([]byte)
+	var jwks jose.JSONWebKeySet
+	if err := json.Unmarshal(jwksBody, &jwks); err != nil {
+		t.Fatal(err)
+	}
+	if len(jwks.Keys) != 1 {
+		t.Fatalf("unexpected keys: %s", jwksBody)
+	}
+	idToken, err := jwt.ParseSigned(tokens["id_token"].(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var claims jwt.Claims
+	var custom map[string]interface{}
+	if err := idToken.Claims(jwks.Key(idToken.Headers[0].KeyID)[0], &claims, &custom); err != nil {
+		t.Fatal(err)
+	}
+	if err := claims.Validate(jwt.Expected{Issuer: issuer, Subject: entityID, Audience: jwt.Audience{clientID}, Time: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	if custom["nonce"] != "abc" || custom["name"] != "alice" || custom["at_hash"] == nil {
+		t.Fatalf("unexpected claims: %#v", custom)
+	}
+	if groups := custom["groups"].([]interface{}); len(groups) != 1 || groups[0] != "devs" {
+		t.Fatalf("unexpected groups: %#v", custom["groups"])
+	}
+	if org := custom["org"].(map[string]interface{}); !reflect.DeepEqual(org, map[string]interface{}{"team": "eng"}) {
+		t.Fatalf("unexpected org: %#v", custom["org"])
+	}
+
+	// The access token gives access to the userinfo. The request goes
+	// through the core, which passes the bearer token as is.
+	userInfo := func(accessToken string) *logical.Response {
+		t.Helper()
+		resp, err := c.HandleRequest(ctx, &logical.Request{
+			Path:        "identity/oidc/idp/main/userinfo",
+			Operation:   logical.ReadOperation,
+			ClientToken: accessToken,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+	accessToken := tokens["access_token"].(string)
+	info := raw(userInfo(accessToken), http.StatusOK)
+	if info["sub"] != entityID || info["name"] != "alice" {
+		t.Fatalf("bad: %#v", info)
+	}
+	if body := raw(userInfo(tokens["id_token"].(string)), http.StatusUnauthorized); body["error"] != "invalid_token" {
+		t.Fatalf("expected ID tokens to be rejected, got: %#v", body)
+	}
+
+	// Rotated keys remain published until the verification TTL expires
+	mustSucceed(request(logical.UpdateOperation, "oidc/key/signing/rotate", nil))
+	jwksBody = request(logical.ReadOperation, "oidc/idp/main/.well-known/keys", nil).Data[logical.HTTPRawBody].([]byte)
+	if err := json.Unmarshal(jwksBody, &jwks); err != nil {
+		t.Fatal(err)
+	}
+	if len(jwks.Keys) != 2 {
+		t.Fatalf("unexpected keys: %s", jwksBody)
+	}
+	raw(userInfo(accessToken), http.StatusOK)
+
+	// Two days later, the key was rotated again and the first one expired
+	if err := is.rotateOIDCKeys(ctx, time.Now().Add(48*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	jwksBody = request(logical.ReadOperation, "oidc/idp/main/.well-known/keys", nil).Data[logical.HTTPRawBody].([]byte)
+	if err := json.Unmarshal(jwksBody, &jwks); err != nil {
+		t.Fatal(err)
+	}
+	if len(jwks.Keys) != 2 || len(jwks.Key(idToken.Headers[0].KeyID)) != 0 {
+		t.Fatalf("unexpected keys: %s", jwksBody)
+	}
+	raw(userInfo(accessToken), http.StatusUnauthorized)
+}
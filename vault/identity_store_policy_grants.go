@@ -0,0 +1,494 @@
+package vault
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/helper/identity"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/helper/strutil"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+const (
+	// policyGrantPrefix is the storage prefix of the expiring policy grants,
+	// which are stored per entity or group under
+	// policy-grant/<target type>/<target ID>
+	policyGrantPrefix = "policy-grant/"
+
+	policyGrantTargetEntity = "entity"
+	policyGrantTargetGroup  = "group"
+)
+
+// policyGrants holds the expiring policy attachments of an entity or group.
+// The policies are attached to the target like any other policy, and removed
+// by reapPolicyGrants once they expire.
+type policyGrants struct {
+	NamespaceID string               `json:"namespace_id"`
+	Grants      map[string]time.Time `json:"grants"`
+}
+
+func policyGrantPaths(i *IdentityStore) []*framework.Path {
+	var paths []*framework.Path
+	for _, targetType := range []string{policyGrantTargetEntity, policyGrantTargetGroup} {
+		paths = append(paths, &framework.Path{
+			Pattern: targetType + "/id/" + framework.GenericNameRegex("id") + "/policy-grant/(?P<policy>.+)",
+			Fields: map[string]*framework.FieldSchema{
+				"id": {
+					Type:        framework.TypeString,
+					Description: fmt.Sprintf("ID of the %s.", targetType),
+				},
+				"policy": {
+					Type:        framework.TypeString,
+					Description: "Name of the granted policy.",
+				},
+				"expiration_time": {
+					Type:        framework.TypeString,
+					Description: "RFC3339 timestamp at which the grant expires. Either this or ttl must be set when granting.",
+				},
+				"ttl": {
+					Type:        framework.TypeDurationSecond,
+					Description: "Duration after which the grant expires. Either this or expiration_time must be set when granting.",
+				},
+			},
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.UpdateOperation: i.pathPolicyGrantUpdate(targetType),
+				logical.ReadOperation:   i.pathPolicyGrantRead(targetType),
+				logical.DeleteOperation: i.pathPolicyGrantDelete(targetType),
+			},
+
+			HelpSynopsis:    strings.TrimSpace(policyGrantHelp["policy-grant"][0]),
+			HelpDescription: strings.TrimSpace(policyGrantHelp["policy-grant"][1]),
+		}, &framework.Path{
+			Pattern: targetType + "/id/" + framework.GenericNameRegex("id") + "/policy-grant/?$",
+			Fields: map[string]*framework.FieldSchema{
+				"id": {
+					Type:        framework.TypeString,
+					Description: fmt.Sprintf("ID of the %s.", targetType),
+				},
+			},
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.ListOperation: i.pathPolicyGrantList(targetType),
+			},
+
+			HelpSynopsis:    strings.TrimSpace(policyGrantHelp["policy-grant-list"][0]),
+			HelpDescription: strings.TrimSpace(policyGrantHelp["policy-grant-list"][1]),
+		})
+	}
+	return paths
+}
+
+// policyGrantTarget is the entity or group a policy grant is attached to
+type policyGrantTarget struct {
+	NamespaceID string
+	Policies    []string
+
+	// save persists the target with the given policies
+	save func([]string) error
+}
+
+// lockPolicyGrantTarget acquires the lock protecting targets of the type and
+// returns the function releasing it
+func (i *IdentityStore) lockPolicyGrantTarget(targetType string) func() {
+	if targetType == policyGrantTargetGroup {
+		i.groupLock.Lock()
+		return i.groupLock.Unlock
+	}
+	i.lock.Lock()
+	return i.lock.Unlock
+}
+
+// policyGrantTarget returns the entity or group with the ID, or nil if it
+// doesn't exist. The lock of the target type must be held.
+func (i *IdentityStore) policyGrantTarget(ctx context.Context, targetType, id string) (*policyGrantTarget, error) {
+	switch targetType {
+	case policyGrantTargetEntity:
+		entity, err := i.MemDBEntityByID(id, true)
+		if err != nil || entity == nil {
+			return nil, err
+		}
+		return &policyGrantTarget{
+			NamespaceID: entity.NamespaceID,
+			Policies:    entity.Policies,
+			save: func(policies []string) error {
+				entity.Policies = policies
+				entity.LastUpdateTime = ptypes.TimestampNow()
+				return i.upsertEntity(ctx, entity, nil, true)
+			},
+		}, nil
+
+	case policyGrantTargetGroup:
+		group, err := i.MemDBGroupByID(id, true)
+		if err != nil || group == nil {
+			return nil, err
+		}
+		return &policyGrantTarget{
+			NamespaceID: group.NamespaceID,
+			Policies:    group.Policies,
+			save: func(policies []string) error {
+				group.Policies = policies
+				group.LastUpdateTime = ptypes.TimestampNow()
+				return i.UpsertGroup(group, true)
+			},
+		}, nil
+	}
+
+	return nil, fmt.Errorf("unknown policy grant target type %q", targetType)
+}
+
+// namespacedPolicyGrantTarget returns the target if it exists in the
+// namespace of the request
+func (i *IdentityStore) namespacedPolicyGrantTarget(ctx context.Context, targetType, id string) (*policyGrantTarget, error) {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	target, err := i.policyGrantTarget(ctx, targetType, id)
+	if err != nil || target == nil {
+		return nil, err
+	}
+	if target.NamespaceID != ns.ID {
+		return nil, nil
+	}
+	return target, nil
+}
+
+func policyGrantsKey(targetType, id string) string {
+	return policyGrantPrefix + targetType + "/" + id
+}
+
+// policyGrants reads the policy grants of a target, returning an empty set if
+// there are none
+func (i *IdentityStore) policyGrants(ctx context.Context, targetType, id string) (*policyGrants, error) {
+	entry, err := i.view.Get(ctx, policyGrantsKey(targetType, id))
+	if err != nil {
+		return nil, err
+	}
+
+	grants := &policyGrants{}
+	if entry != nil {
+		if err := entry.DecodeJSON(grants); err != nil {
+			return nil, errwrap.Wrapf("failed to decode policy grants: {{err}}", err)
+		}
+	}
+	if grants.Grants == nil {
+		grants.Grants = make(map[string]time.Time)
+	}
+	return grants, nil
+}
+
+// putPolicyGrants persists the policy grants of a target, deleting the
+// storage entry once it holds none
+func (i *IdentityStore) putPolicyGrants(ctx context.Context, targetType, id string, grants *policyGrants) error {
+	key := policyGrantsKey(targetType, id)
+	if len(grants.Grants) == 0 {
+		return i.view.Delete(ctx, key)
+	}
+
+	entry, err := logical.StorageEntryJSON(key, grants)
+	if err != nil {
+		return err
+	}
+	return i.view.Put(ctx, entry)
+}
+
+func (i *IdentityStore) pathPolicyGrantUpdate(targetType string) framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		id := d.Get("id").(string)
+		policy := strings.ToLower(strings.TrimSpace(d.Get("policy").(string)))
+		if policy == "" {
+			return logical.ErrorResponse("missing policy"), nil
+		}
+		if policy == "root" {
+			return logical.ErrorResponse("root cannot be granted"), nil
+		}
+
+		now := time.Now()
+		var expiration time.Time
+		expirationRaw, expirationOk := d.GetOk("expiration_time")
+		ttlRaw, ttlOk := d.GetOk("ttl")
+		switch {
+		case expirationOk && ttlOk:
+			return logical.ErrorResponse("only one of expiration_time and ttl can be set"), nil
+		case expirationOk:
+			var err error
+			expiration, err = time.Parse(time.RFC3339, expirationRaw.(string))
+			if err != nil {
+				return logical.ErrorResponse(fmt.Sprintf("invalid expiration_time: %v", err)), nil
+			}
+		case ttlOk:
+			expiration = now.Add(time.Duration(ttlRaw.(int)) * time.Second)
+		default:
+			return logical.ErrorResponse("one of expiration_time and ttl must be set"), nil
+		}
+		if !expiration.After(now) {
+			return logical.ErrorResponse("the grant must expire in the future"), nil
+		}
+
+		unlock := i.lockPolicyGrantTarget(targetType)
+		defer unlock()
+
+		target, err := i.namespacedPolicyGrantTarget(ctx, targetType, id)
+		if err != nil {
+			return nil, err
+		}
+		if target == nil {
+			return logical.ErrorResponse(fmt.Sprintf("%s not found", targetType)), nil
+		}
+
+		grants, err := i.policyGrants(ctx, targetType, id)
+		if err != nil {
+			return nil, err
+		}
+
+		// A grant can't shorten the attachment of a permanent policy
+		_, granted := grants.Grants[policy]
+		attached := strutil.StrListContains(target.Policies, policy)
+		if attached && !granted {
+			return logical.ErrorResponse(fmt.Sprintf("policy %q is already attached to the %s without expiration", policy, targetType)), nil
+		}
+
+		// The grant is stored before the policy is attached, so that the
+		// policy never remains attached without a grant
+		grants.NamespaceID = target.NamespaceID
+		grants.Grants[policy] = expiration.UTC()
+		if err := i.putPolicyGrants(ctx, targetType, id, grants); err != nil {
+			return nil, err
+		}
+
+		if !attached {
+			if err := target.save(append(target.Policies, policy)); err != nil {
+				return nil, err
+			}
+		}
+
+		return &logical.Response{
+			Data: map[string]interface{}{
+				"policy":          policy,
+				"expiration_time": expiration.UTC().Format(time.RFC3339),
+			},
+		}, nil
+	}
+}
+
+func (i *IdentityStore) pathPolicyGrantRead(targetType string) framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		id := d.Get("id").(string)
+		policy := strings.ToLower(strings.TrimSpace(d.Get("policy").(string)))
+
+		target, err := i.namespacedPolicyGrantTarget(ctx, targetType, id)
+		if err != nil || target == nil {
+			return nil, err
+		}
+
+		grants, err := i.policyGrants(ctx, targetType, id)
+		if err != nil {
+			return nil, err
+		}
+		expiration, ok := grants.Grants[policy]
+		if !ok {
+			return nil, nil
+		}
+
+		return &logical.Response{
+			Data: map[string]interface{}{
+				"policy":          policy,
+				"expiration_time": expiration.Format(time.RFC3339),
+			},
+		}, nil
+	}
+}
+
+func (i *IdentityStore) pathPolicyGrantList(targetType string) framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		id := d.Get("id").(string)
+
+		target, err := i.namespacedPolicyGrantTarget(ctx, targetType, id)
+		if err != nil {
+			return nil, err
+		}
+		if target == nil {
+			return logical.ErrorResponse(fmt.Sprintf("%s not found", targetType)), nil
+		}
+
+		grants, err := i.policyGrants(ctx, targetType, id)
+		if err != nil {
+			return nil, err
+		}
+
+		var keys []string
+		keyInfo := make(map[string]interface{}, len(grants.Grants))
+		for policy, expiration := range grants.Grants {
+			keys = append(keys, policy)
+			keyInfo[policy] = map[string]interface{}{
+				"expiration_time": expiration.Format(time.RFC3339),
+			}
+		}
+
+		return logical.ListResponseWithInfo(keys, keyInfo), nil
+	}
+}
+
+// pathPolicyGrantDelete revokes a grant before its expiration, detaching the
+// policy
+func (i *IdentityStore) pathPolicyGrantDelete(targetType string) framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		id := d.Get("id").(string)
+		policy := strings.ToLower(strings.TrimSpace(d.Get("policy").(string)))
+
+		unlock := i.lockPolicyGrantTarget(targetType)
+		defer unlock()
+
+		target, err := i.namespacedPolicyGrantTarget(ctx, targetType, id)
+		if err != nil || target == nil {
+			return nil, err
+		}
+
+		grants, err := i.policyGrants(ctx, targetType, id)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := grants.Grants[policy]; !ok {
+			return nil, nil
+		}
+
+		if err := i.removePolicyGrants(ctx, targetType, id, target, grants, []string{policy}); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+}
+
+// removePolicyGrants detaches the granted policies from the target, if it
+// still exists, and removes their grants. The lock of the target type must
+// be held.
+func (i *IdentityStore) removePolicyGrants(ctx context.Context, targetType, id string, target *policyGrantTarget, grants *policyGrants, policies []string) error {
+	if target != nil {
+		remaining := target.Policies
+		for _, policy := range policies {
+			remaining = strutil.StrListDelete(remaining, policy)
+		}
+		if len(remaining) != len(target.Policies) {
+			if err := target.save(remaining); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, policy := range policies {
+		delete(grants.Grants, policy)
+	}
+	return i.putPolicyGrants(ctx, targetType, id, grants)
+}
+
+// deletePolicyGrants removes the policy grants of a deleted target
+func (i *IdentityStore) deletePolicyGrants(ctx context.Context, targetType, id string) error {
+	return i.view.Delete(ctx, policyGrantsKey(targetType, id))
+}
+
+// mergePolicyGrants moves the policy grants of an entity merged into
+// toEntity, so that its granted policies don't become permanent on toEntity.
+// Policies already attached to toEntity without expiration stay so.
+func (i *IdentityStore) mergePolicyGrants(ctx context.Context, toEntity *identity.Entity, fromEntityID string) error {
+	from, err := i.policyGrants(ctx, policyGrantTargetEntity, fromEntityID)
+	if err != nil {
+		return err
+	}
+	if len(from.Grants) == 0 {
+		return nil
+	}
+
+	to, err := i.policyGrants(ctx, policyGrantTargetEntity, toEntity.ID)
+	if err != nil {
+		return err
+	}
+	for policy, expiration := range from.Grants {
+		current, granted := to.Grants[policy]
+		switch {
+		case !granted && strutil.StrListContains(toEntity.Policies, policy):
+		case !granted || expiration.After(current):
+			to.Grants[policy] = expiration
+		}
+	}
+	to.NamespaceID = toEntity.NamespaceID
+
+	if err := i.putPolicyGrants(ctx, policyGrantTargetEntity, toEntity.ID, to); err != nil {
+		return err
+	}
+	return i.deletePolicyGrants(ctx, policyGrantTargetEntity, fromEntityID)
+}
+
+// reapPolicyGrants detaches the policies whose grants have expired by now. It
+// is run periodically on the active node.
+func (i *IdentityStore) reapPolicyGrants(ctx context.Context, now time.Time) error {
+	for _, targetType := range []string{policyGrantTargetEntity, policyGrantTargetGroup} {
+		ids, err := i.view.List(ctx, policyGrantPrefix+targetType+"/")
+		if err != nil {
+			return err
+		}
+
+		for _, id := range ids {
+			if err := i.reapTargetPolicyGrants(ctx, targetType, id, now); err != nil {
+				i.logger.Error("failed to remove expired policy grants", "target_type", targetType, "id", id, "error", err)
+			}
+		}
+	}
+	return nil
+}
+
+func (i *IdentityStore) reapTargetPolicyGrants(ctx context.Context, targetType, id string, now time.Time) error {
+	unlock := i.lockPolicyGrantTarget(targetType)
+	defer unlock()
+
+	grants, err := i.policyGrants(ctx, targetType, id)
+	if err != nil {
+		return err
+	}
+
+	var expired []string
+	for policy, expiration := range grants.Grants {
+		if !now.Before(expiration) {
+			expired = append(expired, policy)
+		}
+	}
+	if len(expired) == 0 {
+		return nil
+	}
+
+	// The grants of targets which were deleted are simply dropped
+	target, err := i.policyGrantTarget(ctx, targetType, id)
+	if err != nil {
+		return err
+	}
+
+	if err := i.removePolicyGrants(ctx, targetType, id, target, grants, expired); err != nil {
+		return err
+	}
+	if target != nil {
+		i.logger.Info("removed expired policy grants", "target_type", targetType, "id", id, "policies", expired)
+	}
+	return nil
+}
+
+var policyGrantHelp = map[string][2]string{
+	"policy-grant": {
+		"Grant a policy to an entity or group until an expiration time.",
+		`
+Writing attaches the policy to the entity or group until the given
+expiration_time, or for the given ttl. Writing again moves the expiration.
+Once the grant expires, the policy is detached automatically, within about a
+minute. A policy already attached without expiration can't be granted.
+Deleting revokes the grant and detaches the policy immediately.
+`,
+	},
+	"policy-grant-list": {
+		"List the policy grants of an entity or group.",
+		"Lists the granted policies along with their expiration times.",
+	},
+}
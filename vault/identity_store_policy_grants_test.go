@@ -0,0 +1,199 @@
+package vault
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/logical"
+)
+
+func TestIdentityStore_PolicyGrants(t *testing.T) {
+	c, _, _ := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+	is := c.identityStore
+
+	request := func(op logical.Operation, path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := is.HandleRequest(ctx, &logical.Request{
+			Path:      path,
+			Operation: op,
+			Data:      data,
+		})
+		if err != nil {
+			t.Fatalf("%s %s: %v", op, path, err)
+		}
+		return resp
+	}
+
+	resp := request(logical.UpdateOperation, "entity", map[string]interface{}{
+		"name":     "testentity",
+		"policies": "permanent",
+	})
+	entityID := resp.Data["id"].(string)
+	resp = request(logical.UpdateOperation, "group", map[string]interface{}{
+		"name": "testgroup",
+	})
+	groupID := resp.Data["id"].(string)
+
+	entityPolicies := func() []string {
+		entity, err := is.MemDBEntityByID(entityID, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return entity.Policies
+	}
+	groupPolicies := func() []string {
+		group, err := is.MemDBGroupByID(groupID, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return group.Policies
+	}
+
+	// Invalid grants
+	for _, data := range []map[string]interface{}{
+		{},
+		{"ttl": "1h", "expiration_time": time.Now().Add(time.Hour).Format(time.RFC3339)},
+		{"expiration_time": time.Now().Add(-time.Hour).Format(time.RFC3339)},
+		{"expiration_time": "tomorrow"},
+	} {
+		resp = request(logical.UpdateOperation, "entity/id/"+entityID+"/policy-grant/temp", data)
+		if resp == nil || !resp.IsError() {
+			t.Fatalf("expected an error granting with %v, got: %#v", data, resp)
+		}
+	}
+	resp = request(logical.UpdateOperation, "entity/id/"+entityID+"/policy-grant/permanent", map[string]interface{}{"ttl": "1h"})
+	if resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error granting a permanent policy, got: %#v", resp)
+	}
+	resp = request(logical.UpdateOperation, "entity/id/"+entityID+"/policy-grant/root", map[string]interface{}{"ttl": "1h"})
+	if resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error granting root, got: %#v", resp)
+	}
+
+	// Grant policies expiring at different times
+	resp = request(logical.UpdateOperation, "entity/id/"+entityID+"/policy-grant/temp", map[string]interface{}{"ttl": "1h"})
+	if resp == nil || resp.IsError() {
+		t.Fatalf("bad: %#v", resp)
+	}
+	resp = request(logical.UpdateOperation, "entity/id/"+entityID+"/policy-grant/long", map[string]interface{}{
+		"expiration_time": time.Now().Add(48 * time.Hour).Format(time.RFC3339),
+	})
+	if resp == nil || resp.IsError() {
+		t.Fatalf("bad: %#v", resp)
+	}
+	resp = request(logical.UpdateOperation, "group/id/"+groupID+"/policy-grant/temp", map[string]interface{}{"ttl": "1h"})
+	if resp == nil || resp.IsError() {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	if policies := entityPolicies(); !reflect.DeepEqual(policies, []string{"permanent", "temp", "long"}) {
+		t.Fatalf("unexpected entity policies: %v", policies)
+	}
+	if policies := groupPolicies(); !reflect.DeepEqual(policies, []string{"temp"}) {
+		t.Fatalf("unexpected group policies: %v", policies)
+	}
+
+	resp = request(logical.ListOperation, "entity/id/"+entityID+"/policy-grant/", nil)
+	if keys := resp.Data["keys"].([]string); len(keys) != 2 {
+		t.Fatalf("unexpected grants: %v", keys)
+	}
+	resp = request(logical.ReadOperation, "entity/id/"+entityID+"/policy-grant/temp", nil)
+	if resp == nil || resp.Data["expiration_time"] == "" {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	// Nothing is reaped before the expiration
+	if err := is.reapPolicyGrants(ctx, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if policies := entityPolicies(); len(policies) != 3 {
+		t.Fatalf("unexpected entity policies: %v", policies)
+	}
+
+	// Expired grants are reaped, leaving the permanent and later ones
+	if err := is.reapPolicyGrants(ctx, time.Now().Add(2*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if policies := entityPolicies(); !reflect.DeepEqual(policies, []string{"permanent", "long"}) {
+		t.Fatalf("unexpected entity policies: %v", policies)
+	}
+	if policies := groupPolicies(); len(policies) != 0 {
+		t.Fatalf("unexpected group policies: %v", policies)
+	}
+	resp = request(logical.ReadOperation, "entity/id/"+entityID+"/policy-grant/temp", nil)
+	if resp != nil {
+		t.Fatalf("expected the grant to be removed, got: %#v", resp)
+	}
+	if entry, _ := is.view.Get(ctx, policyGrantsKey(policyGrantTargetGroup, groupID)); entry != nil {
+		t.Fatal("expected the group grants to be deleted")
+	}
+
+	// Revoking detaches the policy right away
+	request(logical.DeleteOperation, "entity/id/"+entityID+"/policy-grant/long", nil)
+	if policies := entityPolicies(); !reflect.DeepEqual(policies, []string{"permanent"}) {
+		t.Fatalf("unexpected entity policies: %v", policies)
+	}
+
+	// The grants of deleted entities are removed along with them
+	request(logical.UpdateOperation, "entity/id/"+entityID+"/policy-grant/temp", map[string]interface{}{"ttl": "1h"})
+	request(logical.DeleteOperation, "entity/id/"+entityID, nil)
+	if entry, _ := is.view.Get(ctx, policyGrantsKey(policyGrantTargetEntity, entityID)); entry != nil {
+		t.Fatal("expected the entity grants to be deleted")
+	}
+}
+
+func TestIdentityStore_PolicyGrantsReapedPeriodically(t *testing.T) {
+	c, _, _ := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+	is := c.identityStore
+
+	resp, err := is.HandleRequest(ctx, &logical.Request{
+		Path:      "entity",
+		Operation: logical.UpdateOperation,
+		Data:      map[string]interface{}{"name": "testentity"},
+	})
+	if err != nil || resp == nil || resp.IsError() {
+		t.Fatalf("bad: err: %v\nresp: %#v", err, resp)
+	}
+	entityID := resp.Data["id"].(string)
+
+	// Store a grant which has already expired
+	grants := &policyGrants{
+		NamespaceID: namespace.RootNamespaceID,
+		Grants:      map[string]time.Time{"temp": time.Now().Add(-time.Minute)},
+	}
+	if err := is.putPolicyGrants(ctx, policyGrantTargetEntity, entityID, grants); err != nil {
+		t.Fatal(err)
+	}
+	entity, err := is.MemDBEntityByID(entityID, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entity.Policies = []string{"temp"}
+	if err := is.upsertEntity(ctx, entity, nil, true); err != nil {
+		t.Fatal(err)
+	}
+
+	// The rollback manager invokes the periodic function of the identity store
+	if _, err := is.HandleRequest(ctx, &logical.Request{
+		Operation: logical.RollbackOperation,
+		Storage:   is.view,
+	}); err != nil && err != logical.ErrUnsupportedOperation {
+		t.Fatal(err)
+	}
+
+	entity, err = is.MemDBEntityByID(entityID, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entity.Policies) != 0 {
+		t.Fatalf("expected the expired grant to be reaped, got policies %v", entity.Policies)
+	}
+	if entry, _ := is.view.Get(context.Background(), policyGrantsKey(policyGrantTargetEntity, entityID)); entry != nil {
+		t.Fatal("expected the entity grants to be deleted")
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/hashicorp/vault/helper/storagepacker"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
+	cache "github.com/patrickmn/go-cache"
 )
 
 const (
@@ -74,6 +75,13 @@ type IdentityStore struct {
 	// disableLowerCaseNames indicates whether or not identity artifacts are
 	// operated case insensitively
 	disableLowerCasedNames bool
+
+	// oidcLock protects the objects of the OIDC providers
+	oidcLock sync.RWMutex
+
+	// oidcAuthCodes holds the authorization codes issued by the OIDC
+	// providers until they are exchanged for tokens
+	oidcAuthCodes *cache.Cache
 }
 
 type groupDiff struct {
@@ -291,6 +291,88 @@ func TestIdentityStore_CreateOrFetchEntity(t *testing.T) {
 	}
 }
 
+func TestIdentityStore_CreateOrFetchEntity_AllowedAliasMetadataKeys(t *testing.T) {
+	ctx := namespace.RootContext(nil)
+	is, ghAccessor, c := testIdentityStoreWithGithubAuth(ctx, t)
+
+	tune := func(keys string) *logical.Response {
+		t.Helper()
+		resp, err := c.systemBackend.HandleRequest(ctx, &logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      "auth/github/tune",
+			Data: map[string]interface{}{
+				"allowed_alias_metadata_keys": keys,
+			},
+		})
+		if err != nil && err != logical.ErrInvalidRequest {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	if resp := tune("a:b"); resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error for an invalid key, got: %#v", resp)
+	}
+	if resp := tune("dept,team,dept"); resp != nil && resp.IsError() {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	resp, err := c.systemBackend.HandleRequest(ctx, &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "auth/github/tune",
+	})
+	if err != nil || resp == nil || resp.IsError() {
+		t.Fatalf("err: %v resp: %#v", err, resp)
+	}
+	if diff := deep.Equal(resp.Data["allowed_alias_metadata_keys"], []string{"dept", "team"}); diff != nil {
+		t.Fatal(diff)
+	}
+
+	// Only the allowed keys are stored
+	alias := &logical.Alias{
+		MountType:     "github",
+		MountAccessor: ghAccessor,
+		Name:          "githubuser",
+		Metadata: map[string]string{
+			"dept": "engineering",
+			"role": "admin",
+		},
+	}
+	entity, err := is.CreateOrFetchEntity(ctx, alias)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := deep.Equal(entity.Aliases[0].Metadata, map[string]string{"dept": "engineering"}); diff != nil {
+		t.Fatal(diff)
+	}
+
+	// Keys no longer given at login are removed
+	alias.Metadata = map[string]string{
+		"team": "vault",
+	}
+	entity, err = is.CreateOrFetchEntity(ctx, alias)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := deep.Equal(entity.Aliases[0].Metadata, map[string]string{"team": "vault"}); diff != nil {
+		t.Fatal(diff)
+	}
+
+	// Without allowed keys, all the metadata is stored
+	tune("")
+	alias.Metadata = map[string]string{
+		"dept": "engineering",
+		"role": "admin",
+	}
+	entity, err = is.CreateOrFetchEntity(ctx, alias)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := deep.Equal(entity.Aliases[0].Metadata, alias.Metadata); diff != nil {
+		t.Fatal(diff)
+	}
+}
+
 func TestIdentityStore_EntityByAliasFactors(t *testing.T) {
 	var err error
 	var resp *logical.Response
@@ -1219,6 +1219,21 @@ func validateMetadata(meta map[string]string) error {
 	return nil
 }
 
+// parseAllowedAliasMetadataKeys validates the alias metadata keys an auth
+// mount is allowed to set at login, and removes duplicates
+func parseAllowedAliasMetadataKeys(keys []string) ([]string, error) {
+	keys = strutil.RemoveDuplicates(keys, false)
+	for _, key := range keys {
+		if err := validateMetaPair(key, ""); err != nil {
+			return nil, errwrap.Wrapf(fmt.Sprintf("invalid alias metadata key %q: {{err}}", key), err)
+		}
+	}
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	return keys, nil
+}
+
 // validateMetaPair checks that the given key/value pair is in a valid format
 func validateMetaPair(key, value string) error {
 	if key == "" {
@@ -0,0 +1,273 @@
+package vault
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	metrics "github.com/armon/go-metrics"
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/logical"
+)
+
+// leaseCountQuotaPrefix is the storage prefix of the lease count quotas,
+// relative to the system barrier view
+const leaseCountQuotaPrefix = "quotas/lease-count/"
+
+// leaseCountQuota caps the number of active leases under a path: a mount, or
+// a path within a mount such as the credentials path of a role. Once the cap
+// is reached, the requests which would register new leases under the path are
+// rejected until leases expire or are revoked.
+type leaseCountQuota struct {
+	// count is the number of active leases under Path, maintained by the
+	// expiration manager. It comes first to be 64-bit aligned for atomic
+	// operations.
+	count int64
+
+	Name string `json:"name"`
+
+	// Path is the path the quota applies to, relative to the root namespace
+	// and ending with a slash
+	Path string `json:"path"`
+
+	MaxLeases int `json:"max_leases"`
+}
+
+// appliesTo reports whether the request or lease path falls under the quota
+func (q *leaseCountQuota) appliesTo(path string) bool {
+	return strings.HasPrefix(path+"/", q.Path)
+}
+
+// leaseCountQuotas holds the lease count quotas by name
+type leaseCountQuotas struct {
+	l      sync.RWMutex
+	quotas map[string]*leaseCountQuota
+}
+
+func newLeaseCountQuotas() *leaseCountQuotas {
+	return &leaseCountQuotas{
+		quotas: make(map[string]*leaseCountQuota),
+	}
+}
+
+func (r *leaseCountQuotas) quota(name string) *leaseCountQuota {
+	r.l.RLock()
+	defer r.l.RUnlock()
+	return r.quotas[name]
+}
+
+func (r *leaseCountQuotas) deleteQuota(name string) {
+	r.l.Lock()
+	defer r.l.Unlock()
+	delete(r.quotas, name)
+}
+
+// setQuotas replaces all the quotas, as loaded from storage
+func (r *leaseCountQuotas) setQuotas(quotas map[string]*leaseCountQuota) {
+	r.l.Lock()
+	defer r.l.Unlock()
+	r.quotas = quotas
+}
+
+// conflicting returns the name of the quota other than the named one on the
+// same path, if any
+func (r *leaseCountQuotas) conflicting(name, path string) string {
+	r.l.RLock()
+	defer r.l.RUnlock()
+
+	for _, quota := range r.quotas {
+		if quota.Name != name && quota.Path == path {
+			return quota.Name
+		}
+	}
+	return ""
+}
+
+// reserve atomically takes a slot of the most specific quota applying to the
+// lease path, so that concurrent requests can't overshoot it. It returns the
+// quota if it has reached its cap, and otherwise a function releasing the
+// slot, to be called once the lease is registered, which counts it, or failed
+// to be.
+func (r *leaseCountQuotas) reserve(path string) (func(), *leaseCountQuota) {
+	r.l.RLock()
+	var match *leaseCountQuota
+	for _, quota := range r.quotas {
+		if quota.appliesTo(path) && (match == nil || len(quota.Path) > len(match.Path)) {
+			match = quota
+		}
+	}
+	r.l.RUnlock()
+
+	if match == nil {
+		return func() {}, nil
+	}
+	for {
+		count := atomic.LoadInt64(&match.count)
+		if count >= int64(match.MaxLeases) {
+			return func() {}, match
+		}
+		if atomic.CompareAndSwapInt64(&match.count, count, count+1) {
+			break
+		}
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			atomic.AddInt64(&match.count, -1)
+		})
+	}, nil
+}
+
+// leaseAdded and leaseRemoved update the counts of the quotas the lease falls
+// under. The expiration manager calls them with its pending lock held.
+func (r *leaseCountQuotas) leaseAdded(leaseID string) {
+	r.updateCounts(leaseID, 1)
+}
+
+func (r *leaseCountQuotas) leaseRemoved(leaseID string) {
+	r.updateCounts(leaseID, -1)
+}
+
+func (r *leaseCountQuotas) updateCounts(leaseID string, delta int64) {
+	r.l.RLock()
+	defer r.l.RUnlock()
+
+	for _, quota := range r.quotas {
+		if quota.appliesTo(leaseID) {
+			atomic.AddInt64(&quota.count, delta)
+		}
+	}
+}
+
+// resetCounts zeroes the counts of the quotas, once the expiration manager
+// dropped its pending leases
+func (r *leaseCountQuotas) resetCounts() {
+	r.l.RLock()
+	defer r.l.RUnlock()
+
+	for _, quota := range r.quotas {
+		atomic.StoreInt64(&quota.count, 0)
+	}
+}
+
+// setLeaseCountQuota counts the active leases under the path of the quota and
+// registers it. The pending lock of the expiration manager is held throughout,
+// so that no lease is registered or revoked in between.
+func (c *Core) setLeaseCountQuota(quota *leaseCountQuota) {
+	r := c.leaseCountQuotas
+	m := c.expiration
+	if m != nil {
+		m.pendingLock.RLock()
+		defer m.pendingLock.RUnlock()
+
+		for leaseID := range m.pending {
+			if quota.appliesTo(leaseID) {
+				quota.count++
+			}
+		}
+	}
+
+	r.l.Lock()
+	defer r.l.Unlock()
+	r.quotas[quota.Name] = quota
+}
+
+// loadLeaseCountQuotas loads the lease count quotas from storage. It runs
+// before the expiration manager restores the leases, which are counted as
+// they are restored.
+func (c *Core) loadLeaseCountQuotas(ctx context.Context) error {
+	quotas := make(map[string]*leaseCountQuota)
+
+	names, err := c.systemBarrierView.List(ctx, leaseCountQuotaPrefix)
+	if err != nil {
+		return errwrap.Wrapf("failed to list lease count quotas: {{err}}", err)
+	}
+	for _, name := range names {
+		var quota leaseCountQuota
+		if err := loadJSONEntry(ctx, c.systemBarrierView, leaseCountQuotaPrefix+name, &quota); err != nil {
+			return errwrap.Wrapf(fmt.Sprintf("failed to load lease count quota %q: {{err}}", name), err)
+		}
+		quotas[quota.Name] = &quota
+	}
+
+	c.leaseCountQuotas.setQuotas(quotas)
+	return nil
+}
+
+// reserveLeaseCountQuota reserves a slot of the quota applying to the path
+// before a lease is registered under it, returning the quota if it has reached
+// its cap. It is called right before the lease of a secret or a service token
+// is registered, so that only the requests which issue leases are limited.
+// The returned function releases the slot once the lease is registered or
+// failed to be.
+func (c *Core) reserveLeaseCountQuota(ctx context.Context, path string) (func(), *leaseCountQuota) {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return func() {}, nil
+	}
+	release, quota := c.leaseCountQuotas.reserve(ns.Path + path)
+	if quota != nil {
+		metrics.IncrCounter([]string{"quota", "lease_count", "violation"}, 1)
+	}
+	return release, quota
+}
+
+// leaseCountReservationsKey is the context key of the lease count quota slots
+// held by a request
+type leaseCountReservationsKey struct{}
+
+// leaseCountReservations holds the lease count quota slots reserved by the
+// token store while handling a request, as the lease of the token is only
+// registered once the token store returned
+type leaseCountReservations struct {
+	l        sync.Mutex
+	releases []func()
+}
+
+// withLeaseCountReservations returns a context holding the slots reserved
+// while handling a request, and the function releasing them
+func withLeaseCountReservations(ctx context.Context) (context.Context, func()) {
+	r := &leaseCountReservations{}
+	return context.WithValue(ctx, leaseCountReservationsKey{}, r), func() {
+		r.l.Lock()
+		defer r.l.Unlock()
+		for _, release := range r.releases {
+			release()
+		}
+		r.releases = nil
+	}
+}
+
+// holdLeaseCountReservation holds the slot until the request releases its
+// reservations. The slot is released right away if the context holds none.
+func holdLeaseCountReservation(ctx context.Context, release func()) {
+	r, ok := ctx.Value(leaseCountReservationsKey{}).(*leaseCountReservations)
+	if !ok {
+		release()
+		return
+	}
+
+	r.l.Lock()
+	defer r.l.Unlock()
+	r.releases = append(r.releases, release)
+}
+
+// queueLeaseRevocation registers the lease of a secret which couldn't be
+// revoked right away, and hands it to the expiration manager, which retries
+// revoking it
+func (c *Core) queueLeaseRevocation(ctx context.Context, req *logical.Request, resp *logical.Response) error {
+	leaseID, err := c.expiration.Register(ctx, req, resp)
+	if err != nil {
+		return err
+	}
+	return c.expiration.LazyRevoke(ctx, leaseID)
+}
+
+// leaseCountQuotaResponse is the response to a request rejected by the quota
+func leaseCountQuotaResponse(quota *leaseCountQuota) *logical.Response {
+	return logical.ErrorResponse(fmt.Sprintf("%s: %q allows %d leases", logical.ErrLeaseCountQuotaExceeded, quota.Name, quota.MaxLeases))
+}
@@ -0,0 +1,395 @@
+package vault
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/logical"
+)
+
+func TestLeaseCountQuotas_Exceeded(t *testing.T) {
+	r := newLeaseCountQuotas()
+	exceeded := func(path string) *leaseCountQuota {
+		release, quota := r.reserve(path)
+		release()
+		return quota
+	}
+	r.quotas["mount"] = &leaseCountQuota{Name: "mount", Path: "database/", MaxLeases: 3}
+	r.quotas["role"] = &leaseCountQuota{Name: "role", Path: "database/creds/web/", MaxLeases: 1}
+
+	r.leaseAdded("database/creds/db/1")
+	if q := exceeded("database/creds/web"); q != nil {
+		t.Fatalf("bad: %#v", q)
+	}
+
+	// The most specific quota applies
+	r.leaseAdded("database/creds/web/2")
+	if q := exceeded("database/creds/web"); q == nil || q.Name != "role" {
+		t.Fatalf("expected the role quota to be exceeded, got %#v", q)
+	}
+	if q := exceeded("database/creds/db"); q != nil {
+		t.Fatalf("bad: %#v", q)
+	}
+	if q := exceeded("database/creds/web-admin"); q != nil {
+		t.Fatalf("expected a sibling path not to be limited, got %#v", q)
+	}
+
+	r.leaseAdded("database/creds/db/3")
+	if q := exceeded("database/creds/db"); q == nil || q.Name != "mount" {
+		t.Fatalf("expected the mount quota to be exceeded, got %#v", q)
+	}
+
+	r.leaseRemoved("database/creds/web/2")
+	if q := exceeded("database/creds/web"); q != nil {
+		t.Fatalf("bad: %#v", q)
+	}
+	if q := exceeded("database/creds/db"); q != nil {
+		t.Fatalf("bad: %#v", q)
+	}
+}
+
+func TestLeaseCountQuotas_ReserveConcurrent(t *testing.T) {
+	r := newLeaseCountQuotas()
+	r.quotas["role"] = &leaseCountQuota{Name: "role", Path: "database/creds/web/", MaxLeases: 10}
+
+	// Concurrent requests can't take more slots than the quota allows
+	var wg sync.WaitGroup
+	var l sync.Mutex
+	var releases []func()
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			release, quota := r.reserve("database/creds/web")
+			if quota == nil {
+				l.Lock()
+				releases = append(releases, release)
+				l.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if len(releases) != 10 {
+		t.Fatalf("expected 10 reservations, got %d", len(releases))
+	}
+
+	// A lease registered with a reserved slot is only counted once the slot
+	// is released, and releasing twice has no effect
+	r.leaseAdded("database/creds/web/1")
+	releases[0]()
+	releases[0]()
+	if count := r.quota("role").count; count != 10 {
+		t.Fatalf("bad: count: %d", count)
+	}
+	for _, release := range releases[1:] {
+		release()
+	}
+	if count := r.quota("role").count; count != 1 {
+		t.Fatalf("bad: count: %d", count)
+	}
+}
+
+func TestSystemBackend_LeaseCountQuota(t *testing.T) {
+	c, b, root := testCoreSystemBackend(t)
+	ctx := namespace.RootContext(nil)
+	request := func(op logical.Operation, path string, data map[string]interface{}) (*logical.Response, error) {
+		req := logical.TestRequest(t, op, path)
+		req.Storage = c.systemBarrierView
+		req.Data = data
+		return b.HandleRequest(ctx, req)
+	}
+	createToken := func() (*logical.Response, error) {
+		return c.HandleRequest(ctx, &logical.Request{
+			Operation:   logical.UpdateOperation,
+			Path:        "auth/token/create",
+			ClientToken: root,
+			Data: map[string]interface{}{
+				"ttl": "1h",
+			},
+		})
+	}
+
+	// Leases issued before the quota exists are counted
+	if resp, err := createToken(); err != nil || resp.IsError() {
+		t.Fatalf("bad: resp: %#v err: %v", resp, err)
+	}
+
+	for _, data := range []map[string]interface{}{
+		{"path": "auth/token/create"},
+		{"path": "auth/token/create", "max_leases": -1},
+		{"max_leases": 1},
+		{"path": "nonexistent/creds", "max_leases": 1},
+	} {
+		resp, err := request(logical.UpdateOperation, "quotas/lease-count/invalid", data)
+		if err != logical.ErrInvalidRequest || resp == nil || !resp.IsError() {
+			t.Fatalf("expected %v to be rejected: resp: %#v err: %v", data, resp, err)
+		}
+	}
+
+	resp, err := request(logical.UpdateOperation, "quotas/lease-count/tokens", map[string]interface{}{
+		"path":       "auth/token/create",
+		"max_leases": 2,
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: resp: %#v err: %v", resp, err)
+	}
+
+	resp, err = request(logical.UpdateOperation, "quotas/lease-count/duplicate", map[string]interface{}{
+		"path":       "auth/token/create/",
+		"max_leases": 10,
+	})
+	if err != logical.ErrInvalidRequest {
+		t.Fatalf("expected a second quota on the same path to be rejected: resp: %#v err: %v", resp, err)
+	}
+
+	if resp, err := createToken(); err != nil || resp.IsError() {
+		t.Fatalf("bad: resp: %#v err: %v", resp, err)
+	}
+	resp, err = createToken()
+	if !errwrap.Contains(err, logical.ErrLeaseCountQuotaExceeded.Error()) || resp == nil || !strings.Contains(resp.Error().Error(), `"tokens" allows 2 leases`) {
+		t.Fatalf("expected the quota to be exceeded: resp: %#v err: %v", resp, err)
+	}
+
+	resp, err = request(logical.ReadOperation, "quotas/lease-count/tokens", nil)
+	if err != nil || resp == nil {
+		t.Fatalf("bad: resp: %#v err: %v", resp, err)
+	}
+	expected := map[string]interface{}{
+		"name":       "tokens",
+		"path":       "auth/token/create/",
+		"max_leases": 2,
+		"count":      int64(2),
+	}
+	for k, v := range expected {
+		if resp.Data[k] != v {
+			t.Fatalf("bad: %s: expected %#v, got %#v", k, v, resp.Data[k])
+		}
+	}
+
+	resp, err = request(logical.ListOperation, "quotas/lease-count/", nil)
+	if err != nil || resp == nil {
+		t.Fatalf("bad: resp: %#v err: %v", resp, err)
+	}
+	if keys := resp.Data["keys"].([]string); len(keys) != 1 || keys[0] != "tokens" {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	// Revoking a lease frees up room under the quota
+	var leaseID string
+	c.expiration.pendingLock.RLock()
+	for id := range c.expiration.pending {
+		if strings.HasPrefix(id, "auth/token/create/") {
+			leaseID = id
+			break
+		}
+	}
+	c.expiration.pendingLock.RUnlock()
+	if err := c.expiration.Revoke(ctx, leaseID); err != nil {
+		t.Fatal(err)
+	}
+	if resp, err := createToken(); err != nil || resp.IsError() {
+		t.Fatalf("bad: resp: %#v err: %v", resp, err)
+	}
+
+	if _, err := request(logical.DeleteOperation, "quotas/lease-count/tokens", nil); err != nil {
+		t.Fatal(err)
+	}
+	if resp, err := createToken(); err != nil || resp.IsError() {
+		t.Fatalf("expected leases to be issued once the quota is deleted: resp: %#v err: %v", resp, err)
+	}
+}
+
+func TestLeaseCountQuota_LeaseRequests(t *testing.T) {
+	c, b, root := testCoreSystemBackend(t)
+	ctx := namespace.RootContext(nil)
+	tokenRequest := func(op logical.Operation, path string, data map[string]interface{}) (*logical.Response, error) {
+		return c.HandleRequest(ctx, &logical.Request{
+			Operation:   op,
+			Path:        path,
+			ClientToken: root,
+			Data:        data,
+		})
+	}
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "quotas/lease-count/tokens")
+	req.Storage = c.systemBarrierView
+	req.Data = map[string]interface{}{
+		"path":       "auth/token/",
+		"max_leases": 1,
+	}
+	if resp, err := b.HandleRequest(ctx, req); err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: resp: %#v err: %v", resp, err)
+	}
+
+	service := map[string]interface{}{"ttl": "1h"}
+	if resp, err := tokenRequest(logical.UpdateOperation, "auth/token/create", service); err != nil || resp.IsError() {
+		t.Fatalf("bad: resp: %#v err: %v", resp, err)
+	}
+
+	// No token is created over the quota
+	resp, err := tokenRequest(logical.UpdateOperation, "auth/token/create", service)
+	if !errwrap.Contains(err, logical.ErrLeaseCountQuotaExceeded.Error()) || resp == nil || resp.Auth != nil {
+		t.Fatalf("expected the quota to be exceeded: resp: %#v err: %v", resp, err)
+	}
+
+	// Requests which don't register leases are still allowed
+	resp, err = tokenRequest(logical.ListOperation, "auth/token/accessors/", nil)
+	if err != nil || resp == nil || resp.IsError() {
+		t.Fatalf("bad: resp: %#v err: %v", resp, err)
+	}
+	if keys := resp.Data["keys"].([]string); len(keys) != 2 {
+		t.Fatalf("expected the root token and the token under the quota, got %#v", keys)
+	}
+	if resp, err := tokenRequest(logical.ReadOperation, "auth/token/lookup-self", nil); err != nil || resp.IsError() {
+		t.Fatalf("bad: resp: %#v err: %v", resp, err)
+	}
+	resp, err = tokenRequest(logical.UpdateOperation, "auth/token/create", map[string]interface{}{
+		"type":     "batch",
+		"policies": "default",
+	})
+	if err != nil || resp.IsError() {
+		t.Fatalf("expected batch tokens to be issued: resp: %#v err: %v", resp, err)
+	}
+
+	if count := c.leaseCountQuotas.quota("tokens").count; count != 1 {
+		t.Fatalf("bad: count: %d", count)
+	}
+}
+
+func TestLeaseCountQuota_Secrets(t *testing.T) {
+	c, b, root := testCoreSystemBackend(t)
+	ctx := namespace.RootContext(nil)
+	secretRequest := func(op logical.Operation, path string, data map[string]interface{}) (*logical.Response, error) {
+		return c.HandleRequest(ctx, &logical.Request{
+			Operation:   op,
+			Path:        path,
+			ClientToken: root,
+			Data:        data,
+		})
+	}
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "quotas/lease-count/secrets")
+	req.Storage = c.systemBarrierView
+	req.Data = map[string]interface{}{
+		"path":       "secret/",
+		"max_leases": 1,
+	}
+	if resp, err := b.HandleRequest(ctx, req); err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: resp: %#v err: %v", resp, err)
+	}
+
+	if resp, err := secretRequest(logical.UpdateOperation, "secret/foo", map[string]interface{}{"ttl": "1h", "foo": "bar"}); err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: resp: %#v err: %v", resp, err)
+	}
+	resp, err := secretRequest(logical.ReadOperation, "secret/foo", nil)
+	if err != nil || resp == nil || resp.Secret == nil || resp.Secret.LeaseID == "" {
+		t.Fatalf("bad: resp: %#v err: %v", resp, err)
+	}
+
+	// Reads issuing leases are rejected once the quota is reached
+	resp, err = secretRequest(logical.ReadOperation, "secret/foo", nil)
+	if !errwrap.Contains(err, logical.ErrLeaseCountQuotaExceeded.Error()) || resp == nil || resp.Secret != nil || resp.Data["foo"] != nil {
+		t.Fatalf("expected the quota to be exceeded: resp: %#v err: %v", resp, err)
+	}
+
+	// Writes, lists and deletes issue no lease
+	if resp, err := secretRequest(logical.UpdateOperation, "secret/bar", map[string]interface{}{"foo": "bar"}); err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: resp: %#v err: %v", resp, err)
+	}
+	if resp, err := secretRequest(logical.ListOperation, "secret/", nil); err != nil || resp == nil || resp.IsError() {
+		t.Fatalf("bad: resp: %#v err: %v", resp, err)
+	}
+	if resp, err := secretRequest(logical.DeleteOperation, "secret/bar", nil); err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: resp: %#v err: %v", resp, err)
+	}
+
+	if count := c.leaseCountQuotas.quota("secrets").count; count != 1 {
+		t.Fatalf("bad: count: %d", count)
+	}
+}
+
+func TestLeaseCountQuota_QueueFailedRevocation(t *testing.T) {
+	var revokes int32
+	noop := &NoopBackend{
+		RequestHandler: func(ctx context.Context, req *logical.Request) (*logical.Response, error) {
+			if req.Operation == logical.RevokeOperation {
+				// Only the first revocation fails
+				if atomic.AddInt32(&revokes, 1) == 1 {
+					return nil, fmt.Errorf("revocation failed")
+				}
+				return nil, nil
+			}
+			return &logical.Response{
+				Secret: &logical.Secret{
+					LeaseOptions: logical.LeaseOptions{
+						TTL:       time.Hour,
+						Renewable: true,
+					},
+				},
+				Data: map[string]interface{}{"foo": "bar"},
+			}, nil
+		},
+	}
+
+	c, b, root := testCoreSystemBackend(t)
+	c.logicalBackends["noop"] = func(context.Context, *logical.BackendConfig) (logical.Backend, error) {
+		return noop, nil
+	}
+	ctx := namespace.RootContext(nil)
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "sys/mounts/noop")
+	req.Data["type"] = "noop"
+	req.ClientToken = root
+	if resp, err := c.HandleRequest(ctx, req); err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: resp: %#v err: %v", resp, err)
+	}
+
+	req = logical.TestRequest(t, logical.UpdateOperation, "quotas/lease-count/noop")
+	req.Storage = c.systemBarrierView
+	req.Data = map[string]interface{}{
+		"path":       "noop/",
+		"max_leases": 1,
+	}
+	if resp, err := b.HandleRequest(ctx, req); err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: resp: %#v err: %v", resp, err)
+	}
+
+	readRequest := func() (*logical.Response, error) {
+		return c.HandleRequest(ctx, &logical.Request{
+			Operation:   logical.ReadOperation,
+			Path:        "noop/creds",
+			ClientToken: root,
+		})
+	}
+	if resp, err := readRequest(); err != nil || resp == nil || resp.Secret == nil || resp.Secret.LeaseID == "" {
+		t.Fatalf("bad: resp: %#v err: %v", resp, err)
+	}
+	resp, err := readRequest()
+	if !errwrap.Contains(err, logical.ErrLeaseCountQuotaExceeded.Error()) || resp == nil || resp.Secret != nil {
+		t.Fatalf("expected the quota to be exceeded: resp: %#v err: %v", resp, err)
+	}
+
+	// The secret which failed to be revoked is left to the expiration
+	// manager, which revokes it
+	deadline := time.Now().Add(10 * time.Second)
+	for atomic.LoadInt32(&revokes) < 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("the secret over the quota was never revoked")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	quota := c.leaseCountQuotas.quota("noop")
+	for atomic.LoadInt64(&quota.count) != 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("bad: count: %d", atomic.LoadInt64(&quota.count))
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
@@ -26,12 +26,15 @@ import (
 	"github.com/hashicorp/vault/helper/consts"
 	"github.com/hashicorp/vault/helper/identity"
 	"github.com/hashicorp/vault/helper/jsonutil"
+	"github.com/hashicorp/vault/helper/license"
+	"github.com/hashicorp/vault/helper/logging"
 	"github.com/hashicorp/vault/helper/namespace"
 	"github.com/hashicorp/vault/helper/parseutil"
 	"github.com/hashicorp/vault/helper/strutil"
 	"github.com/hashicorp/vault/helper/wrapping"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
+	"github.com/hashicorp/vault/version"
 	"github.com/mitchellh/mapstructure"
 )
 
@@ -70,8 +73,9 @@ func NewSystemBackend(core *Core, logger log.Logger) *SystemBackend {
 		Core:      core,
 		db:        db,
 		logger:    logger,
-		mfaLogger: core.baseLogger.Named("mfa"),
+		mfaLogger: logging.NewSubsystemLogger(core.baseLogger, "mfa"),
 		mfaLock:   &sync.RWMutex{},
+		quotaLock: &sync.Mutex{},
 	}
 
 	core.AddLogger(b.mfaLogger)
@@ -97,12 +101,19 @@ func NewSystemBackend(core *Core, logger log.Logger) *SystemBackend {
 				"config/cors",
 				"config/auditing/*",
 				"config/ui/headers/*",
+				"config/state/*",
 				"plugins/catalog/*",
 				"revoke-prefix/*",
 				"revoke-force/*",
 				"leases/revoke-prefix/*",
 				"leases/revoke-force/*",
 				"leases/lookup/*",
+				"leases/irrevocable",
+				"leases/irrevocable/*",
+				"entity/*",
+				"usage/tokens",
+				"loggers",
+				"loggers/*",
 			},
 
 			Unauthenticated: []string{
@@ -132,6 +143,7 @@ func NewSystemBackend(core *Core, logger log.Logger) *SystemBackend {
 	b.Backend.Paths = append(b.Backend.Paths, b.configPaths()...)
 	b.Backend.Paths = append(b.Backend.Paths, b.rekeyPaths()...)
 	b.Backend.Paths = append(b.Backend.Paths, b.sealPaths()...)
+	b.Backend.Paths = append(b.Backend.Paths, b.migrationPaths()...)
 	b.Backend.Paths = append(b.Backend.Paths, b.pluginsCatalogListPaths()...)
 	b.Backend.Paths = append(b.Backend.Paths, b.pluginsCatalogCRUDPath())
 	b.Backend.Paths = append(b.Backend.Paths, b.pluginsReloadPath())
@@ -144,6 +156,14 @@ func NewSystemBackend(core *Core, logger log.Logger) *SystemBackend {
 	b.Backend.Paths = append(b.Backend.Paths, b.toolsPaths()...)
 	b.Backend.Paths = append(b.Backend.Paths, b.capabilitiesPaths()...)
 	b.Backend.Paths = append(b.Backend.Paths, b.internalPaths()...)
+	b.Backend.Paths = append(b.Backend.Paths, b.usagePaths()...)
+	b.Backend.Paths = append(b.Backend.Paths, b.licensePaths()...)
+	b.Backend.Paths = append(b.Backend.Paths, b.loggersPaths()...)
+	b.Backend.Paths = append(b.Backend.Paths, b.mfaPaths()...)
+	b.Backend.Paths = append(b.Backend.Paths, b.quotasPaths()...)
+	b.Backend.Paths = append(b.Backend.Paths, b.lockedUsersPaths()...)
+	b.Backend.Paths = append(b.Backend.Paths, b.entityTokensPaths()...)
+	b.Backend.Paths = append(b.Backend.Paths, b.wellKnownPaths()...)
 	b.Backend.Paths = append(b.Backend.Paths, b.remountPath())
 
 	if core.rawEnabled {
@@ -195,6 +215,7 @@ type SystemBackend struct {
 	Core      *Core
 	db        *memdb.MemDB
 	mfaLock   *sync.RWMutex
+	quotaLock *sync.Mutex
 	mfaLogger log.Logger
 	logger    log.Logger
 }
@@ -236,6 +257,19 @@ func (b *SystemBackend) handleCORSDelete(ctx context.Context, req *logical.Reque
 	return nil, b.Core.corsConfig.Disable(ctx)
 }
 
+// handleConfigStateSanitized returns the configuration of the server without
+// the values that may hold secrets
+func (b *SystemBackend) handleConfigStateSanitized(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	config := b.Core.SanitizedConfig()
+	if config == nil {
+		return logical.ErrorResponse("server configuration is not available"), nil
+	}
+
+	return &logical.Response{
+		Data: config,
+	}, nil
+}
+
 func (b *SystemBackend) handleTidyLeases(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
 	ns, err := namespace.FromContext(ctx)
 	if err != nil {
@@ -651,7 +685,7 @@ func (b *SystemBackend) handleRekeyDeleteRecovery(ctx context.Context, req *logi
 	return b.handleRekeyDelete(ctx, req, data, true)
 }
 
-func mountInfo(entry *MountEntry) map[string]interface{} {
+func (b *SystemBackend) mountInfo(entry *MountEntry) map[string]interface{} {
 	info := map[string]interface{}{
 		"type":        entry.Type,
 		"description": entry.Description,
@@ -682,12 +716,35 @@ func mountInfo(entry *MountEntry) map[string]interface{} {
 	if rawVal, ok := entry.synthesizedConfigCache.Load("allowed_response_headers"); ok {
 		entryConfig["allowed_response_headers"] = rawVal.([]string)
 	}
+	if entry.Config.RequestConcurrencyLimit > 0 {
+		entryConfig["request_concurrency_limit"] = entry.Config.RequestConcurrencyLimit
+	}
 	if entry.Table == credentialTableType {
 		entryConfig["token_type"] = entry.Config.TokenType.String()
+		if entry.Config.LoginRateLimit > 0 {
+			period := entry.Config.LoginRateLimitPeriod
+			if period <= 0 {
+				period = defaultLoginRateLimitPeriod
+			}
+			entryConfig["login_rate_limit"] = entry.Config.LoginRateLimit
+			entryConfig["login_rate_limit_period"] = int64(period.Seconds())
+		}
+		if entry.Config.UserLockoutThreshold > 0 {
+			entryConfig["user_lockout_threshold"] = entry.Config.UserLockoutThreshold
+			entryConfig["user_lockout_duration"] = int64(entry.Config.UserLockoutDuration.Seconds())
+			entryConfig["user_lockout_counter_reset"] = int64(entry.Config.UserLockoutCounterReset.Seconds())
+		}
+		if len(entry.Config.AllowedAliasMetadataKeys) > 0 {
+			entryConfig["allowed_alias_metadata_keys"] = entry.Config.AllowedAliasMetadataKeys
+		}
 	}
 
 	info["config"] = entryConfig
 
+	if status, ok := b.Core.builtinDeprecationStatus(entry); ok {
+		info["deprecation_status"] = status.String()
+	}
+
 	return info
 }
 
@@ -720,7 +777,7 @@ func (b *SystemBackend) handleMountTable(ctx context.Context, req *logical.Reque
 		}
 
 		// Populate mount info
-		info := mountInfo(entry)
+		info := b.mountInfo(entry)
 		resp.Data[entry.Path] = info
 	}
 
@@ -797,6 +854,11 @@ func (b *SystemBackend) handleMount(ctx context.Context, req *logical.Request, d
 			logical.ErrInvalidRequest
 	}
 
+	if apiConfig.RequestConcurrencyLimit < 0 {
+		return logical.ErrorResponse("'request_concurrency_limit' cannot be negative"), logical.ErrInvalidRequest
+	}
+	config.RequestConcurrencyLimit = apiConfig.RequestConcurrencyLimit
+
 	switch logicalType {
 	case "":
 		return logical.ErrorResponse(
@@ -878,12 +940,22 @@ func (b *SystemBackend) handleMount(ctx context.Context, req *logical.Request, d
 		Options:     options,
 	}
 
+	warning, err := b.Core.checkDeprecatedBuiltin(me)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
 	// Attempt mount
 	if err := b.Core.mount(ctx, me); err != nil {
 		b.Backend.Logger().Error("mount failed", "path", me.Path, "error", err)
 		return handleError(err)
 	}
 
+	if warning != "" {
+		resp := &logical.Response{}
+		resp.AddWarning(warning)
+		return resp, nil
+	}
 	return nil, nil
 }
 
@@ -987,6 +1059,43 @@ func (b *SystemBackend) handleRemount(ctx context.Context, req *logical.Request,
 	return nil, nil
 }
 
+// handleMigrateToDatabase is used to convert a legacy cassandra, mongodb or
+// mssql mount into a database mount
+func (b *SystemBackend) handleMigrateToDatabase(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	repState := b.Core.ReplicationState()
+
+	path := data.Get("path").(string)
+	if path == "" {
+		return logical.ErrorResponse(
+				"path must be specified as a string"),
+			logical.ErrInvalidRequest
+	}
+
+	entry := b.Core.router.MatchingMountEntry(ctx, sanitizeMountPath(path))
+	if entry != nil && !entry.Local && repState.HasState(consts.ReplicationPerformanceSecondary) {
+		return logical.ErrorResponse("cannot migrate a non-local mount on a replication secondary"), nil
+	}
+
+	migration, err := b.Core.migrateToDatabase(ctx, path, data.Get("connection_name").(string))
+	if err != nil {
+		b.Backend.Logger().Error("migration failed", "path", path, "error", err)
+		return handleError(err)
+	}
+
+	roles := migration.Roles
+	if roles == nil {
+		roles = []string{}
+	}
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"connection_name": migration.ConnectionName,
+			"roles":           roles,
+			"leases":          migration.Leases,
+		},
+		Warnings: migration.Warnings,
+	}, nil
+}
+
 // handleAuthTuneRead is used to get config settings on a auth path
 func (b *SystemBackend) handleAuthTuneRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
 	path := data.Get("path").(string)
@@ -1037,8 +1146,31 @@ func (b *SystemBackend) handleTuneReadCommon(ctx context.Context, path string) (
 		},
 	}
 
+	if mountEntry.Config.RequestConcurrencyLimit > 0 {
+		resp.Data["request_concurrency_limit"] = mountEntry.Config.RequestConcurrencyLimit
+	}
+
 	if mountEntry.Table == credentialTableType {
 		resp.Data["token_type"] = mountEntry.Config.TokenType.String()
+
+		if mountEntry.Config.LoginRateLimit > 0 {
+			period := mountEntry.Config.LoginRateLimitPeriod
+			if period <= 0 {
+				period = defaultLoginRateLimitPeriod
+			}
+			resp.Data["login_rate_limit"] = mountEntry.Config.LoginRateLimit
+			resp.Data["login_rate_limit_period"] = int(period.Seconds())
+		}
+
+		if threshold, duration, counterReset := userLockoutSettings(mountEntry); threshold > 0 {
+			resp.Data["user_lockout_threshold"] = threshold
+			resp.Data["user_lockout_duration"] = int(duration.Seconds())
+			resp.Data["user_lockout_counter_reset"] = int(counterReset.Seconds())
+		}
+
+		if len(mountEntry.Config.AllowedAliasMetadataKeys) > 0 {
+			resp.Data["allowed_alias_metadata_keys"] = mountEntry.Config.AllowedAliasMetadataKeys
+		}
 	}
 
 	if rawVal, ok := mountEntry.synthesizedConfigCache.Load("audit_non_hmac_request_keys"); ok {
@@ -1318,6 +1450,167 @@ func (b *SystemBackend) handleTuneWriteCommon(ctx context.Context, path string,
 		}
 	}
 
+	rawLimit, limitOk := data.GetOk("login_rate_limit")
+	rawPeriod, periodOk := data.GetOk("login_rate_limit_period")
+	if limitOk || periodOk {
+		if !strings.HasPrefix(path, "auth/") {
+			return logical.ErrorResponse("'login_rate_limit' and 'login_rate_limit_period' can only be modified on auth mounts"), logical.ErrInvalidRequest
+		}
+		if mountEntry.Type == "token" || mountEntry.Type == "ns_token" {
+			return logical.ErrorResponse("'login_rate_limit' cannot be set for 'token' or 'ns_token' auth mounts"), logical.ErrInvalidRequest
+		}
+
+		limit := mountEntry.Config.LoginRateLimit
+		if limitOk {
+			limit = rawLimit.(int)
+			if limit < 0 {
+				return logical.ErrorResponse("'login_rate_limit' cannot be negative"), logical.ErrInvalidRequest
+			}
+		}
+
+		period := mountEntry.Config.LoginRateLimitPeriod
+		if periodOk {
+			var err error
+			period, err = parseutil.ParseDurationSecond(rawPeriod.(string))
+			if err != nil {
+				return logical.ErrorResponse(fmt.Sprintf("unable to parse login_rate_limit_period: %s", err)), logical.ErrInvalidRequest
+			}
+			if period < 0 {
+				return logical.ErrorResponse("'login_rate_limit_period' cannot be negative"), logical.ErrInvalidRequest
+			}
+		}
+
+		oldLimit := mountEntry.Config.LoginRateLimit
+		oldPeriod := mountEntry.Config.LoginRateLimitPeriod
+		mountEntry.Config.LoginRateLimit = limit
+		mountEntry.Config.LoginRateLimitPeriod = period
+
+		// Update the mount table
+		if err := b.Core.persistAuth(ctx, b.Core.auth, &mountEntry.Local); err != nil {
+			mountEntry.Config.LoginRateLimit = oldLimit
+			mountEntry.Config.LoginRateLimitPeriod = oldPeriod
+			return handleError(err)
+		}
+
+		if b.Core.logger.IsInfo() {
+			b.Core.logger.Info("mount tuning of login rate limit successful", "path", path, "login_rate_limit", limit, "login_rate_limit_period", period)
+		}
+	}
+
+	rawThreshold, thresholdOk := data.GetOk("user_lockout_threshold")
+	rawDuration, durationOk := data.GetOk("user_lockout_duration")
+	rawCounterReset, counterResetOk := data.GetOk("user_lockout_counter_reset")
+	if thresholdOk || durationOk || counterResetOk {
+		if !strings.HasPrefix(path, "auth/") {
+			return logical.ErrorResponse("'user_lockout_threshold', 'user_lockout_duration' and 'user_lockout_counter_reset' can only be modified on auth mounts"), logical.ErrInvalidRequest
+		}
+		if mountEntry.Type == "token" || mountEntry.Type == "ns_token" {
+			return logical.ErrorResponse("user lockout cannot be configured for 'token' or 'ns_token' auth mounts"), logical.ErrInvalidRequest
+		}
+
+		threshold := mountEntry.Config.UserLockoutThreshold
+		if thresholdOk {
+			threshold = rawThreshold.(int)
+			if threshold < 0 {
+				return logical.ErrorResponse("'user_lockout_threshold' cannot be negative"), logical.ErrInvalidRequest
+			}
+		}
+
+		duration := mountEntry.Config.UserLockoutDuration
+		if durationOk {
+			var err error
+			duration, err = parseutil.ParseDurationSecond(rawDuration.(string))
+			if err != nil {
+				return logical.ErrorResponse(fmt.Sprintf("unable to parse user_lockout_duration: %s", err)), logical.ErrInvalidRequest
+			}
+			if duration < 0 {
+				return logical.ErrorResponse("'user_lockout_duration' cannot be negative"), logical.ErrInvalidRequest
+			}
+		}
+
+		counterReset := mountEntry.Config.UserLockoutCounterReset
+		if counterResetOk {
+			var err error
+			counterReset, err = parseutil.ParseDurationSecond(rawCounterReset.(string))
+			if err != nil {
+				return logical.ErrorResponse(fmt.Sprintf("unable to parse user_lockout_counter_reset: %s", err)), logical.ErrInvalidRequest
+			}
+			if counterReset < 0 {
+				return logical.ErrorResponse("'user_lockout_counter_reset' cannot be negative"), logical.ErrInvalidRequest
+			}
+		}
+
+		oldThreshold := mountEntry.Config.UserLockoutThreshold
+		oldDuration := mountEntry.Config.UserLockoutDuration
+		oldCounterReset := mountEntry.Config.UserLockoutCounterReset
+		mountEntry.Config.UserLockoutThreshold = threshold
+		mountEntry.Config.UserLockoutDuration = duration
+		mountEntry.Config.UserLockoutCounterReset = counterReset
+
+		// Update the mount table
+		if err := b.Core.persistAuth(ctx, b.Core.auth, &mountEntry.Local); err != nil {
+			mountEntry.Config.UserLockoutThreshold = oldThreshold
+			mountEntry.Config.UserLockoutDuration = oldDuration
+			mountEntry.Config.UserLockoutCounterReset = oldCounterReset
+			return handleError(err)
+		}
+
+		if b.Core.logger.IsInfo() {
+			b.Core.logger.Info("mount tuning of user lockout successful", "path", path, "user_lockout_threshold", threshold, "user_lockout_duration", duration, "user_lockout_counter_reset", counterReset)
+		}
+	}
+
+	if rawVal, ok := data.GetOk("allowed_alias_metadata_keys"); ok {
+		if !strings.HasPrefix(path, "auth/") {
+			return logical.ErrorResponse("'allowed_alias_metadata_keys' can only be modified on auth mounts"), logical.ErrInvalidRequest
+		}
+
+		keys, err := parseAllowedAliasMetadataKeys(rawVal.([]string))
+		if err != nil {
+			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+		}
+
+		oldVal := mountEntry.Config.AllowedAliasMetadataKeys
+		mountEntry.Config.AllowedAliasMetadataKeys = keys
+
+		// Update the mount table
+		if err := b.Core.persistAuth(ctx, b.Core.auth, &mountEntry.Local); err != nil {
+			mountEntry.Config.AllowedAliasMetadataKeys = oldVal
+			return handleError(err)
+		}
+
+		if b.Core.logger.IsInfo() {
+			b.Core.logger.Info("mount tuning of allowed_alias_metadata_keys successful", "path", path)
+		}
+	}
+
+	if rawVal, ok := data.GetOk("request_concurrency_limit"); ok {
+		limit := rawVal.(int)
+		if limit < 0 {
+			return logical.ErrorResponse("'request_concurrency_limit' cannot be negative"), logical.ErrInvalidRequest
+		}
+
+		oldVal := mountEntry.Config.RequestConcurrencyLimit
+		mountEntry.Config.RequestConcurrencyLimit = limit
+
+		// Update the mount table
+		var err error
+		switch {
+		case strings.HasPrefix(path, "auth/"):
+			err = b.Core.persistAuth(ctx, b.Core.auth, &mountEntry.Local)
+		default:
+			err = b.Core.persistMounts(ctx, b.Core.mounts, &mountEntry.Local)
+		}
+		if err != nil {
+			mountEntry.Config.RequestConcurrencyLimit = oldVal
+			return handleError(err)
+		}
+
+		if b.Core.logger.IsInfo() {
+			b.Core.logger.Info("mount tuning of request_concurrency_limit successful", "path", path, "request_concurrency_limit", limit)
+		}
+	}
+
 	if rawVal, ok := data.GetOk("passthrough_request_headers"); ok {
 		headers := rawVal.([]string)
 
@@ -1410,7 +1703,7 @@ func (b *SystemBackend) handleTuneWriteCommon(ctx context.Context, path string,
 			if meVersion < optVersion {
 				kvUpgraded = true
 				resp = &logical.Response{}
-				resp.AddWarning(fmt.Sprintf("Upgrading mount from version %d to version %d. This mount will be unavailable for a brief period and will resume service shortly.", meVersion, optVersion))
+				resp.AddWarning(fmt.Sprintf("Upgrading mount from version %d to version %d. Reads of the data remain available while the keys are upgraded in the background, other operations resume once the upgrade completes. Its progress can be read at %supgrade.", meVersion, optVersion, path))
 			}
 		}
 
@@ -1616,6 +1909,67 @@ func (b *SystemBackend) handleRevokePrefixCommon(ctx context.Context,
 	return logical.RespondWithStatusCode(nil, nil, http.StatusAccepted)
 }
 
+// handleIrrevocableLeases lists the leases of the namespace marked
+// irrevocable, along with their counts per mount
+func (b *SystemBackend) handleIrrevocableLeases(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	mount := data.Get("mount").(string)
+	if mount != "" {
+		mount = sanitizeMountPath(mount)
+	}
+
+	leases := make([]map[string]interface{}, 0)
+	counts := make(map[string]int)
+	for _, retry := range b.Core.expiration.irrevocableLeases(ns) {
+		leaseMount := strings.TrimPrefix(retry.Mount, ns.Path)
+		if mount != "" && leaseMount != mount {
+			continue
+		}
+		counts[leaseMount]++
+		leases = append(leases, map[string]interface{}{
+			"lease_id":     retry.LeaseID,
+			"mount":        leaseMount,
+			"attempts":     retry.Attempts,
+			"last_attempt": retry.LastAttempt,
+			"last_error":   retry.LastError,
+		})
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"lease_count":     len(leases),
+			"counts_by_mount": counts,
+			"leases":          leases,
+		},
+	}, nil
+}
+
+// handleForgetIrrevocableLease removes an irrevocable lease without revoking
+// it through its backend
+func (b *SystemBackend) handleForgetIrrevocableLease(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	leaseID := data.Get("lease_id").(string)
+	if leaseID == "" {
+		return logical.ErrorResponse("lease_id must be specified"),
+			logical.ErrInvalidRequest
+	}
+
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	forgetCtx := namespace.ContextWithNamespace(b.Core.activeContext, ns)
+	if err := b.Core.expiration.ForgetIrrevocable(forgetCtx, leaseID); err != nil {
+		b.Backend.Logger().Error("forgetting irrevocable lease failed", "lease_id", leaseID, "error", err)
+		return handleErrorNoReadOnlyForward(err)
+	}
+
+	return nil, nil
+}
+
 // handleAuthTable handles the "auth" endpoint to provide the auth table
 func (b *SystemBackend) handleAuthTable(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
 	ns, err := namespace.FromContext(ctx)
@@ -1644,7 +1998,7 @@ func (b *SystemBackend) handleAuthTable(ctx context.Context, req *logical.Reques
 			continue
 		}
 
-		info := mountInfo(entry)
+		info := b.mountInfo(entry)
 		resp.Data[entry.Path] = info
 	}
 
@@ -1733,6 +2087,68 @@ func (b *SystemBackend) handleEnableAuth(ctx context.Context, req *logical.Reque
 			"invalid value for 'token_type'")), logical.ErrInvalidRequest
 	}
 
+	if apiConfig.LoginRateLimit < 0 {
+		return logical.ErrorResponse("'login_rate_limit' cannot be negative"), logical.ErrInvalidRequest
+	}
+	config.LoginRateLimit = apiConfig.LoginRateLimit
+
+	if apiConfig.LoginRateLimitPeriod != "" {
+		period, err := parseutil.ParseDurationSecond(apiConfig.LoginRateLimitPeriod)
+		if err != nil {
+			return logical.ErrorResponse(fmt.Sprintf(
+					"unable to parse login_rate_limit_period of %s: %s", apiConfig.LoginRateLimitPeriod, err)),
+				logical.ErrInvalidRequest
+		}
+		if period < 0 {
+			return logical.ErrorResponse("'login_rate_limit_period' cannot be negative"), logical.ErrInvalidRequest
+		}
+		config.LoginRateLimitPeriod = period
+	}
+
+	if apiConfig.UserLockoutThreshold < 0 {
+		return logical.ErrorResponse("'user_lockout_threshold' cannot be negative"), logical.ErrInvalidRequest
+	}
+	config.UserLockoutThreshold = apiConfig.UserLockoutThreshold
+
+	if apiConfig.UserLockoutDuration != "" {
+		duration, err := parseutil.ParseDurationSecond(apiConfig.UserLockoutDuration)
+		if err != nil {
+			return logical.ErrorResponse(fmt.Sprintf(
+					"unable to parse user_lockout_duration of %s: %s", apiConfig.UserLockoutDuration, err)),
+				logical.ErrInvalidRequest
+		}
+		if duration < 0 {
+			return logical.ErrorResponse("'user_lockout_duration' cannot be negative"), logical.ErrInvalidRequest
+		}
+		config.UserLockoutDuration = duration
+	}
+
+	if apiConfig.UserLockoutCounterReset != "" {
+		counterReset, err := parseutil.ParseDurationSecond(apiConfig.UserLockoutCounterReset)
+		if err != nil {
+			return logical.ErrorResponse(fmt.Sprintf(
+					"unable to parse user_lockout_counter_reset of %s: %s", apiConfig.UserLockoutCounterReset, err)),
+				logical.ErrInvalidRequest
+		}
+		if counterReset < 0 {
+			return logical.ErrorResponse("'user_lockout_counter_reset' cannot be negative"), logical.ErrInvalidRequest
+		}
+		config.UserLockoutCounterReset = counterReset
+	}
+
+	if len(apiConfig.AllowedAliasMetadataKeys) > 0 {
+		keys, err := parseAllowedAliasMetadataKeys(apiConfig.AllowedAliasMetadataKeys)
+		if err != nil {
+			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+		}
+		config.AllowedAliasMetadataKeys = keys
+	}
+
+	if apiConfig.RequestConcurrencyLimit < 0 {
+		return logical.ErrorResponse("'request_concurrency_limit' cannot be negative"), logical.ErrInvalidRequest
+	}
+	config.RequestConcurrencyLimit = apiConfig.RequestConcurrencyLimit
+
 	switch logicalType {
 	case "":
 		return logical.ErrorResponse(
@@ -1789,11 +2205,22 @@ func (b *SystemBackend) handleEnableAuth(ctx context.Context, req *logical.Reque
 		Options:     options,
 	}
 
+	warning, err := b.Core.checkDeprecatedBuiltin(me)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
 	// Attempt enabling
 	if err := b.Core.enableCredential(ctx, me); err != nil {
 		b.Backend.Logger().Error("enable auth mount failed", "path", me.Path, "error", err)
 		return handleError(err)
 	}
+
+	if warning != "" {
+		resp := &logical.Response{}
+		resp.AddWarning(warning)
+		return resp, nil
+	}
 	return nil, nil
 }
 
@@ -2023,13 +2450,20 @@ func (b *SystemBackend) handleAuditTable(ctx context.Context, req *logical.Reque
 // specified audit backend's salt
 func (b *SystemBackend) handleAuditHash(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
 	path := data.Get("path").(string)
+	path = sanitizeMountPath(path)
+
+	if inputsRaw, ok := data.GetOk("inputs"); ok {
+		if _, ok := data.GetOk("input"); ok {
+			return logical.ErrorResponse("only one of \"input\" or \"inputs\" can be given"), nil
+		}
+		return b.handleAuditHashBatch(ctx, path, inputsRaw.([]string))
+	}
+
 	input := data.Get("input").(string)
 	if input == "" {
 		return logical.ErrorResponse("the \"input\" parameter is empty"), nil
 	}
 
-	path = sanitizeMountPath(path)
-
 	hash, err := b.Core.auditBroker.GetHash(ctx, path, input)
 	if err != nil {
 		return logical.ErrorResponse(err.Error()), nil
@@ -2042,6 +2476,33 @@ func (b *SystemBackend) handleAuditHash(ctx context.Context, req *logical.Reques
 	}, nil
 }
 
+// handleAuditHashBatch returns the hashes of the given inputs, in the same
+// order
+func (b *SystemBackend) handleAuditHashBatch(ctx context.Context, path string, inputs []string) (*logical.Response, error) {
+	if len(inputs) == 0 {
+		return logical.ErrorResponse("the \"inputs\" parameter is empty"), nil
+	}
+
+	hashes := make([]string, 0, len(inputs))
+	for i, input := range inputs {
+		if input == "" {
+			return logical.ErrorResponse(fmt.Sprintf("input %d of the \"inputs\" parameter is empty", i)), nil
+		}
+
+		hash, err := b.Core.auditBroker.GetHash(ctx, path, input)
+		if err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+		hashes = append(hashes, hash)
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"hashes": hashes,
+		},
+	}, nil
+}
+
 // handleEnableAudit is used to enable a new audit backend
 func (b *SystemBackend) handleEnableAudit(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
 	repState := b.Core.ReplicationState()
@@ -2279,6 +2740,14 @@ func (b *SystemBackend) handleKeyStatus(ctx context.Context, req *logical.Reques
 	return resp, nil
 }
 
+// handleMigrationStatus returns the version of the storage schema and the
+// status of the storage migrations
+func (b *SystemBackend) handleMigrationStatus(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	return &logical.Response{
+		Data: b.Core.storageMigrationStatusData(),
+	}, nil
+}
+
 // handleRotate is used to trigger a key rotation
 func (b *SystemBackend) handleRotate(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
 	repState := b.Core.ReplicationState()
@@ -2838,6 +3307,179 @@ func hasMountAccess(ctx context.Context, acl *ACL, path string) bool {
 	return aclCapabilitiesGiven
 }
 
+// handleTokenUsageRead returns the request volume attributed to token
+// policies and to the auth mounts that issued the tokens.
+func (b *SystemBackend) handleTokenUsageRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	report := b.Core.tokenUsage.report()
+
+	policies := make([]map[string]interface{}, 0, len(report.Policies))
+	for _, entry := range report.Policies {
+		policies = append(policies, map[string]interface{}{
+			"policy":   entry.Name,
+			"requests": entry.Requests,
+		})
+	}
+	mounts := make([]map[string]interface{}, 0, len(report.Mounts))
+	for _, entry := range report.Mounts {
+		mounts = append(mounts, map[string]interface{}{
+			"mount":    entry.Name,
+			"requests": entry.Requests,
+		})
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"start_time":     report.Start.Format(time.RFC3339),
+			"interval":       int64(tokenUsageInterval.Seconds()),
+			"total_requests": report.Total,
+			"policies":       policies,
+			"auth_mounts":    mounts,
+		},
+	}, nil
+}
+
+// handleLicenseStatusRead reports the feature modules compiled into this
+// build, so that tooling can detect the capabilities of the server
+func (b *SystemBackend) handleLicenseStatusRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	modules := make(map[string]bool, len(license.KnownModules))
+	for _, module := range license.KnownModules {
+		modules[module] = false
+	}
+	features := []string{}
+	for _, module := range license.BuildModules() {
+		modules[module] = true
+		features = append(features, module)
+	}
+	sort.Strings(features)
+
+	versionInfo := version.GetVersion()
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"version":          versionInfo.VersionNumber(),
+			"version_metadata": versionInfo.VersionMetadata,
+			"features":         features,
+			"modules":          modules,
+		},
+	}, nil
+}
+
+// handleLoggersRead returns the levels of the loggers of all subsystems
+func (b *SystemBackend) handleLoggersRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	levels := b.Core.LogLevels()
+
+	data := make(map[string]interface{}, len(levels))
+	for name, level := range levels {
+		data[name] = logging.LevelName(level)
+	}
+
+	return &logical.Response{
+		Data: data,
+	}, nil
+}
+
+// handleLoggersWrite sets the level of the loggers of all subsystems,
+// including those that had their own
+func (b *SystemBackend) handleLoggersWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	level, errResp := loggersLevel(d)
+	if errResp != nil {
+		return errResp, nil
+	}
+
+	b.Core.SetLogLevels(nil)
+	b.Core.SetLogLevel(level)
+
+	return nil, nil
+}
+
+// handleLoggersByNameRead returns the levels of the loggers of a subsystem
+// and of the subsystems below it
+func (b *SystemBackend) handleLoggersByNameRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	data := make(map[string]interface{})
+	for loggerName, level := range b.Core.LogLevels() {
+		if loggerName == name || strings.HasPrefix(loggerName, name+".") {
+			data[loggerName] = logging.LevelName(level)
+		}
+	}
+	if len(data) == 0 {
+		return logical.ErrorResponse(fmt.Sprintf("no logger found for %q", name)), logical.ErrInvalidRequest
+	}
+
+	return &logical.Response{
+		Data: data,
+	}, nil
+}
+
+// handleLoggersByNameWrite sets the level of the loggers of a subsystem and
+// of the subsystems below it
+func (b *SystemBackend) handleLoggersByNameWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	level, errResp := loggersLevel(d)
+	if errResp != nil {
+		return errResp, nil
+	}
+
+	if !b.Core.SetSubsystemLogLevel(name, level) {
+		return logical.ErrorResponse(fmt.Sprintf("no logger found for %q", name)), logical.ErrInvalidRequest
+	}
+
+	return nil, nil
+}
+
+func loggersLevel(d *framework.FieldData) (log.Level, *logical.Response) {
+	levelRaw := d.Get("level").(string)
+	if levelRaw == "" {
+		return log.NoLevel, logical.ErrorResponse("level is required")
+	}
+
+	level, err := logging.ParseLogLevel(levelRaw)
+	if err != nil {
+		return log.NoLevel, logical.ErrorResponse(err.Error())
+	}
+
+	return level, nil
+}
+
+// handleWellKnownList lists the paths below /.well-known/ claimed by mounts
+func (b *SystemBackend) handleWellKnownList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	claims := b.Core.router.wellKnown.list()
+
+	keys := make([]string, 0, len(claims))
+	keyInfo := make(map[string]interface{}, len(claims))
+	for path, claim := range claims {
+		keys = append(keys, path)
+		keyInfo[path] = wellKnownClaimData(claim)
+	}
+	sort.Strings(keys)
+
+	return logical.ListResponseWithInfo(keys, keyInfo), nil
+}
+
+// handleWellKnownRead returns the claim of a path below /.well-known/
+func (b *SystemBackend) handleWellKnownRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	path := strings.Trim(d.Get("path").(string), "/")
+
+	claim, ok := b.Core.router.wellKnown.list()[path]
+	if !ok {
+		return nil, nil
+	}
+
+	return &logical.Response{
+		Data: wellKnownClaimData(claim),
+	}, nil
+}
+
+func wellKnownClaimData(claim *wellKnownRedirect) map[string]interface{} {
+	return map[string]interface{}{
+		"mount_path":     claim.mountEntry.APIPath(),
+		"mount_accessor": claim.mountEntry.Accessor,
+		"mount_type":     claim.mountEntry.Type,
+		"destination":    claim.destination,
+	}
+}
+
 func (b *SystemBackend) pathInternalUIMountsRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
 	ns, err := namespace.FromContext(ctx)
 	if err != nil {
@@ -2892,7 +3534,7 @@ func (b *SystemBackend) pathInternalUIMountsRead(ctx context.Context, req *logic
 		if ns.ID == entry.NamespaceID && hasAccess(ctx, entry) {
 			if isAuthed {
 				// If this is an authed request return all the mount info
-				secretMounts[entry.Path] = mountInfo(entry)
+				secretMounts[entry.Path] = b.mountInfo(entry)
 			} else {
 				secretMounts[entry.Path] = map[string]interface{}{
 					"type":        entry.Type,
@@ -2909,7 +3551,7 @@ func (b *SystemBackend) pathInternalUIMountsRead(ctx context.Context, req *logic
 		if ns.ID == entry.NamespaceID && hasAccess(ctx, entry) {
 			if isAuthed {
 				// If this is an authed request return all the mount info
-				authMounts[entry.Path] = mountInfo(entry)
+				authMounts[entry.Path] = b.mountInfo(entry)
 			} else {
 				authMounts[entry.Path] = map[string]interface{}{
 					"type":        entry.Type,
@@ -2946,7 +3588,7 @@ func (b *SystemBackend) pathInternalUIMountRead(ctx context.Context, req *logica
 	}
 
 	resp := &logical.Response{
-		Data: mountInfo(me),
+		Data: b.mountInfo(me),
 	}
 	resp.Data["path"] = me.Path
 	if ns.ID != me.Namespace().ID {
@@ -3265,6 +3907,16 @@ This path responds to the following HTTP methods.
         List the headers configured for the UI.
         `,
 	},
+	"config/state/sanitized": {
+		"Returns the configuration the server was started or last reloaded with.",
+		`
+This path responds to the following HTTP methods.
+
+    GET /
+        Returns the server configuration. The values that may hold secrets,
+        such as the options of the storage and seal stanzas, are left out.
+		`,
+	},
 	"init": {
 		"Initializes or returns the initialization status of the Vault.",
 		`
@@ -3426,6 +4078,22 @@ This path responds to the following HTTP methods.
 		`,
 	},
 
+	"migrate_to_database": {
+		"Migrate a legacy database secrets engine to the database secrets engine.",
+		`
+This path responds to the following HTTP methods.
+
+    POST /sys/mounts/<path>/migrate-to-database
+        Converts the cassandra, mongodb or mssql mount at the given path
+        into a database mount, preserving its roles and leases.
+		`,
+	},
+
+	"migrate_connection_name": {
+		`The name of the database connection created from the configuration of
+the mount. Defaults to the type of the mount.`,
+	},
+
 	"auth_tune": {
 		"Tune the configuration parameters for an auth path.",
 		`Read and write the 'default-lease-ttl' and 'max-lease-ttl' values of
@@ -3620,6 +4288,11 @@ or delete a policy.
 		"",
 	},
 
+	"audit-hash-inputs": {
+		`List of strings to hash in one call, instead of "input". The hashes are returned in the same order.`,
+		"",
+	},
+
 	"audit-table": {
 		"List the currently enabled audit backends.",
 		`
@@ -3670,6 +4343,16 @@ Enable a new audit backend or disable an existing backend.
 		`,
 	},
 
+	"migration-status": {
+		"Provides the status of the storage migrations.",
+		`
+Provides the version of the layout of the data in storage, the latest version
+supported by this version of Vault, and the storage migrations applied to
+reach it. Storage migrations are applied by the active node at unseal; Vault
+refuses to unseal storage whose version is newer than the latest it supports.
+		`,
+	},
+
 	"rotate": {
 		"Rotates the backend encryption key used to persist data.",
 		`
@@ -3711,6 +4394,30 @@ necessary, and is only required if upgrade notes or support personnel suggest
 it.`,
 	},
 
+	"irrevocable-leases": {
+		"Lists the leases which could not be revoked.",
+		`
+Leases are marked irrevocable once their automatic revocation failed
+repeatedly. They are no longer revoked automatically: they stay until they are
+revoked synchronously, for instance once the issue with their backend is
+fixed, or forgotten. This endpoint lists them along with their last error,
+and their counts per mount.
+		`,
+	},
+
+	"irrevocable-leases-mount": {
+		"Only list the irrevocable leases of this mount.",
+	},
+
+	"irrevocable-leases-forget": {
+		"Removes an irrevocable lease without revoking it.",
+		`
+The lease is removed from Vault without calling the backend which issued it,
+so whatever the lease stands for is left behind and has to be cleaned up by
+other means. Only secret leases marked irrevocable can be forgotten.
+		`,
+	},
+
 	"wrap": {
 		"Response-wraps an arbitrary JSON object.",
 		`Round trips the given input data into a response-wrapped token.`,
@@ -3866,10 +4573,396 @@ This path responds to the following HTTP methods.
 		"The type of token to issue (service or batch).",
 		"",
 	},
+	"login_rate_limit": {
+		`The maximum number of login attempts allowed per login_rate_limit_period,
+both per alias the login would authenticate and per client address. Auth
+methods which can't look up the alias ahead of the login are only limited per
+client address. Rejected attempts return 429 and are audited at a sampled
+rate. 0 disables the limit.`,
+		"",
+	},
+	"login_rate_limit_period": {
+		"The period over which login_rate_limit applies. Defaults to 1 minute.",
+		"",
+	},
+	"user_lockout_threshold": {
+		`The number of failed login attempts after which an alias is locked out
+of the auth mount. Only supported by the auth methods which can look up the
+alias of a login ahead of it. 0 disables the lockout.`,
+		"",
+	},
+	"user_lockout_duration": {
+		"How long an alias stays locked out. Defaults to 15 minutes.",
+		"",
+	},
+	"user_lockout_counter_reset": {
+		`The period without failed login attempts after which the failed attempts
+of an alias are forgotten. Defaults to 15 minutes.`,
+		"",
+	},
+	"allowed_alias_metadata_keys": {
+		`The entity alias metadata keys the auth method is allowed to set at login.
+If set, the other keys given by the auth method are dropped.`,
+		"",
+	},
+	"request_concurrency_limit": {
+		`The maximum number of requests each token can have in flight against the
+mount at once. Requests over the limit return 429 with a Retry-After header
+and are audited at a sampled rate. 0 disables the limit.`,
+		"",
+	},
 	"raw": {
 		"Write, Read, and Delete data directly in the Storage backend.",
 		"",
 	},
+	"usage-tokens": {
+		"Request volume by token policy and auth mount.",
+		`
+Reports the number of requests made with tokens carrying each policy, and
+with tokens issued by each auth mount, sorted by request volume. Counts are
+aggregated in memory in hourly windows, and the last 24 windows are kept.
+Counts are reset when the node is sealed or steps down.
+		`,
+	},
+
+	"license-status": {
+		"Report the feature modules compiled into this build.",
+		`
+Returns the version of the server, and the feature modules compiled into its
+build, such as replication, namespaces or HSM support. "features" lists the
+compiled modules, and "modules" tells for each known module whether it is
+compiled.
+		`,
+	},
+
+	"loggers": {
+		"Read and set the log levels of the subsystems of this node.",
+		`
+Reading returns the log level of each subsystem, such as "core",
+"expiration", "audit" or "secrets.pki.<accessor>". Writing sets the level of
+all subsystems. Levels set this way only apply to the node handling the
+request and are reverted to the ones of the configuration on SIGHUP.
+		`,
+	},
+	"loggers-by-name": {
+		"Read and set the log level of a subsystem of this node.",
+		`
+Reading returns the log level of the subsystem and of the subsystems below
+it. Writing sets the level of the subsystem and of the subsystems below it,
+e.g. of all secrets engines for "secrets". Levels set this way only apply to
+the node handling the request and are reverted to the ones of the
+configuration on SIGHUP.
+		`,
+	},
+	"well-known": {
+		"List the paths below /.well-known/ claimed by mounts.",
+		`
+Mounts can claim paths below /.well-known/ so that clients following
+standards such as ACME, EST or OpenID Connect discovery find them at their
+canonical URLs. Requests to a claimed path, or to a path below it, are
+redirected to the destination path of the claim within the mount. A path is
+claimed by the first mount asking for it; conflicting claims of later mounts
+are skipped with a warning in the server log.
+		`,
+	},
+	"well-known-by-path": {
+		"Read the claim of a path below /.well-known/.",
+		`
+Returns the mount that claimed the path and the path within the mount that
+requests are redirected to.
+		`,
+	},
+	"well-known-path": {
+		`The path below /.well-known/, e.g. "est".`,
+		"",
+	},
+	"loggers-name": {
+		`The name of the subsystem, e.g. "expiration" or "storage.consul".`,
+		"",
+	},
+	"loggers-level": {
+		`The log level: "trace", "debug", "info", "warn" or "error".`,
+		"",
+	},
+	"mfa-method-list": {
+		"List the MFA methods login enforcements can require.",
+		"",
+	},
+	"mfa-method": {
+		"Configure an MFA method login enforcements can require.",
+		`
+MFA methods are second factors logins have to validate when a login MFA
+enforcement targeting them requires it. A method is either of type "totp",
+validating passcodes generated from secrets the entities enroll, "duo",
+verifying the user with Duo Push or a Duo passcode, or "pingid", verifying
+the user with PingID.
+
+Logins supply the credentials of a method in the X-Vault-MFA header, e.g.
+"my_totp:123456". Duo pushes and PingID only need the name of the method.
+Method names are unique across method types.
+		`,
+	},
+	"mfa-method-type": {
+		`The type of the MFA method: "totp", "duo" or "pingid".`,
+		"",
+	},
+	"mfa-method-name": {
+		"The name of the MFA method.",
+		"",
+	},
+	"mfa-method-mount-accessor": {
+		`The accessor of the auth mount the aliases of which name the Duo and
+PingID users, required for Duo and PingID methods.`,
+		"",
+	},
+	"mfa-method-username-format": {
+		`The format of the Duo and PingID usernames, substituting the
+{{alias.name}}, {{entity.name}}, {{alias.metadata.<key>}} and
+{{entity.metadata.<key>}} directives, e.g. "{{alias.name}}@example.com".
+Defaults to the name of the alias.`,
+		"",
+	},
+	"mfa-method-issuer": {
+		"The name of the issuer of the TOTP secrets, required for TOTP methods.",
+		"",
+	},
+	"mfa-method-period": {
+		"The period of validity of the TOTP passcodes. Defaults to 30 seconds.",
+		"",
+	},
+	"mfa-method-key-size": {
+		"The size in bytes of the generated TOTP secrets. Defaults to 20.",
+		"",
+	},
+	"mfa-method-algorithm": {
+		`The hashing algorithm of the TOTP passcodes: "SHA1", "SHA256" or
+"SHA512". Defaults to "SHA1".`,
+		"",
+	},
+	"mfa-method-digits": {
+		"The number of digits of the TOTP passcodes, 6 or 8. Defaults to 6.",
+		"",
+	},
+	"mfa-method-skew": {
+		`The number of periods before and after the current one TOTP passcodes
+are accepted from, 0 or 1. Defaults to 1.`,
+		"",
+	},
+	"mfa-method-qr-size": {
+		`The size in pixels of the QR code of the generated TOTP secrets, or 0
+not to return one. Defaults to 200.`,
+		"",
+	},
+	"mfa-method-integration-key": {
+		"The integration key of the Duo application, required for Duo methods.",
+		"",
+	},
+	"mfa-method-secret-key": {
+		"The secret key of the Duo application, required for Duo methods.",
+		"",
+	},
+	"mfa-method-api-hostname": {
+		"The API hostname of the Duo application, required for Duo methods.",
+		"",
+	},
+	"mfa-method-push-info": {
+		"Additional information shown in Duo pushes, as a URL encoded string.",
+		"",
+	},
+	"mfa-method-use-passcode": {
+		"Whether Duo methods require a passcode instead of sending a push.",
+		"",
+	},
+	"mfa-method-settings-file-base64": {
+		`The base64 encoded settings file of the PingID integration, required for
+PingID methods.`,
+		"",
+	},
+	"mfa-totp-entity-id": {
+		"The ID of the entity.",
+		"",
+	},
+	"mfa-totp-generate": {
+		"Generate the TOTP secret of the entity of the token.",
+		`
+Generates a TOTP secret for the entity of the token making the request,
+returning its otpauth URL and a QR code to enroll it in an authenticator
+app. An entity which already has a secret must have it destroyed through
+the "admin-destroy" endpoint before generating a new one.
+		`,
+	},
+	"mfa-totp-admin-generate": {
+		"Generate the TOTP secret of an entity.",
+		`
+Generates a TOTP secret for the given entity and returns its otpauth URL
+and a QR code to enroll it in an authenticator app. An entity which already
+has a secret must have it destroyed through the "admin-destroy" endpoint
+before generating a new one.
+		`,
+	},
+	"mfa-totp-admin-destroy": {
+		"Destroy the TOTP secret of an entity.",
+		"",
+	},
+	"mfa-login-enforcement-list": {
+		"List the login MFA enforcements.",
+		"",
+	},
+	"mfa-login-enforcement": {
+		"Configure a login MFA enforcement.",
+		`
+Login MFA enforcements require logins to validate a second factor before a
+token is issued, whatever the auth method. An enforcement targets the logins
+made against the auth mounts of the given accessors or types, and the logins
+resolving to the given entities or to members of the given groups. Each
+enforcement targeting a login requires one of its MFA methods to be
+validated.
+		`,
+	},
+	"mfa-login-enforcement-name": {
+		"The name of the login MFA enforcement.",
+		"",
+	},
+	"mfa-login-enforcement-mfa-method-names": {
+		"The names of the MFA methods, one of which logins have to validate.",
+		"",
+	},
+	"mfa-login-enforcement-auth-method-accessors": {
+		"The accessors of the auth mounts the logins of which are targeted.",
+		"",
+	},
+	"mfa-login-enforcement-auth-method-types": {
+		`The types of the auth mounts the logins of which are targeted, e.g.
+"userpass".`,
+		"",
+	},
+	"mfa-login-enforcement-identity-entity-ids": {
+		"The IDs of the entities the logins of which are targeted.",
+		"",
+	},
+	"mfa-login-enforcement-identity-group-ids": {
+		`The IDs of the groups the logins of the members of which are targeted,
+including the members of their subgroups.`,
+		"",
+	},
+	"rate-limit-quota-list": {
+		"List the rate limit quotas.",
+		"",
+	},
+	"rate-limit-quota": {
+		"Configure a rate limit quota.",
+		`
+Rate limit quotas hold each client, identified by its address, to a token
+bucket rate limit on the requests it makes to a mount, to the mounts of a
+namespace, or to the whole cluster. Quotas on auth mounts can be restricted
+to the logins against a role. Requests are checked against the most specific
+quota applying to them before they are routed, and rejected with a 429 status
+code when the client exceeded it.
+		`,
+	},
+	"rate-limit-quota-name": {
+		"The name of the rate limit quota.",
+		"",
+	},
+	"rate-limit-quota-path": {
+		`The path of the mount or namespace the quota applies to. The quota is
+global if empty.`,
+		"",
+	},
+	"rate-limit-quota-role": {
+		`The role the logins of which the quota applies to. Only valid for quotas
+on auth mounts.`,
+		"",
+	},
+	"rate-limit-quota-rate": {
+		"The number of requests each client can make per interval.",
+		"",
+	},
+	"rate-limit-quota-interval": {
+		"The interval the rate is given over. Defaults to 1 second.",
+		"",
+	},
+	"rate-limit-quota-burst": {
+		`The number of requests each client can make at once. Defaults to the
+rate.`,
+		"",
+	},
+	"lease-count-quota-list": {
+		"List the lease count quotas.",
+		"",
+	},
+	"lease-count-quota": {
+		"Configure a lease count quota.",
+		`
+Lease count quotas cap the number of active leases under a path: a mount, or a
+path within a mount such as the credentials path of a role. Once the cap is
+reached, the read and write requests to the path are rejected with a 429
+status code until leases expire or are revoked, so that runaway clients can't
+overwhelm storage and the expiration manager.
+		`,
+	},
+	"lease-count-quota-name": {
+		"The name of the lease count quota.",
+		"",
+	},
+	"lease-count-quota-path": {
+		"The path the quota applies to, a mount or a path within a mount.",
+		"",
+	},
+	"lease-count-quota-max-leases": {
+		"The maximum number of active leases under the path.",
+		"",
+	},
+	"locked-users": {
+		"List the users locked out after repeated failed logins.",
+		`
+Auth mounts tuned with a user_lockout_threshold lock an alias out once it
+reaches the threshold of failed login attempts. Its logins are then rejected
+with a permission denied error, without checking its credentials, until the
+user_lockout_duration of the mount elapses or it is unlocked. This lists the
+locked aliases of the auth mounts of the namespace.
+		`,
+	},
+	"locked-users-mount-accessor": {
+		"The accessor of the auth mount.",
+		"",
+	},
+	"locked-users-alias-identifier": {
+		"The alias locked out, such as the username of a userpass login.",
+		"",
+	},
+	"locked-users-unlock": {
+		"Unlock a user locked out after repeated failed logins.",
+		`
+Lifts the lockout of the alias and forgets its failed login attempts. Unlocking
+an alias which isn't locked out succeeds.
+		`,
+	},
+	"entity-tokens": {
+		"List the tokens tied to an entity.",
+		`
+Lists the accessors of the tokens of the namespace tied to the entity, along
+with their path, policies and creation time. Batch tokens aren't persisted, so
+they aren't listed. Tokens created before the entity index was introduced are
+only listed once the token store has been tidied.
+		`,
+	},
+	"entity-tokens-entity-id": {
+		"The ID of the entity.",
+		"",
+	},
+	"entity-tokens-revoke": {
+		"Revoke all the tokens tied to an entity.",
+		`
+Revokes the tokens of the namespace tied to the entity, along with their child
+tokens and the leases they created. The entity itself is left as is: disable it
+to prevent it from logging in again. If any token fails to be revoked, the
+others are still revoked and the request can be retried. The revocation isn't
+atomic: tokens obtained by the entity meanwhile may be left, unless the entity
+is disabled first.
+		`,
+	},
+
 	"internal-ui-mounts": {
 		"Information about mounts returned according to their tuned visibility. Internal API; its location, inputs, and outputs may change.",
 		"",
@@ -3884,4 +4977,31 @@ This path responds to the following HTTP methods.
 		"Information about a token's resultant ACL. Internal API; its location, inputs, and outputs may change.",
 		"",
 	},
+	"activity": {
+		"Distinct clients active over a period.",
+		`
+Reports the number of distinct entities, and of tokens without an entity,
+which made requests over a period, in total, by auth mount and by month.
+Clients are attributed to the auth mount that issued their token; an entity
+using tokens of several mounts is counted once in the total. Activity is
+tracked by calendar month, in UTC, and kept for 24 months.
+		`,
+	},
+	"activity-start-time": {
+		"Start of the period to report on, as an RFC 3339 timestamp. Defaults to the start of the month a year before the end of the period.",
+	},
+	"activity-end-time": {
+		"End of the period to report on, as an RFC 3339 timestamp. Defaults to now.",
+	},
+	"activity-export": {
+		"Export the monthly client counts over a period.",
+		`
+Exports the number of distinct entities, and of tokens without an entity, which
+made requests each month of a period, in total and by auth mount, as JSON or
+CSV. In the CSV export, the total of a month has an empty mount.
+		`,
+	},
+	"activity-export-format": {
+		`Format of the export, "json" or "csv".`,
+	},
 }
@@ -0,0 +1,112 @@
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+// activityPeriod returns the period the client activity is requested over
+func activityPeriod(d *framework.FieldData, now time.Time) (time.Time, time.Time, error) {
+	end := now
+	if raw := d.Get("end_time").(string); raw != "" {
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid end_time %q, must be an RFC 3339 timestamp", raw)
+		}
+		end = t
+	}
+
+	start := activityMonthStart(end).AddDate(-1, 0, 0)
+	if raw := d.Get("start_time").(string); raw != "" {
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid start_time %q, must be an RFC 3339 timestamp", raw)
+		}
+		start = t
+	}
+
+	if start.After(end) {
+		return time.Time{}, time.Time{}, fmt.Errorf("start_time must not be after end_time")
+	}
+	return start.UTC(), end.UTC(), nil
+}
+
+// activityMonthsData returns the client counts of each month, in total and by
+// mount
+func activityMonthsData(months []*activityMonth) []map[string]interface{} {
+	byMonth := make([]map[string]interface{}, 0, len(months))
+	for _, month := range months {
+		data := newActivityReport([]*activityMonth{month}).data()
+		data["month"] = month.Start.Format(activityLogMonthFormat)
+		byMonth = append(byMonth, data)
+	}
+	return byMonth
+}
+
+// pathInternalCountersActivityRead reports the distinct clients active over a
+// period, in total, by mount and by month
+func (b *SystemBackend) pathInternalCountersActivityRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	start, end, err := activityPeriod(d, time.Now())
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	months, err := b.Core.activityLog.activity(ctx, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	data := newActivityReport(months).data()
+	data["start_time"] = start.Format(time.RFC3339)
+	data["end_time"] = end.Format(time.RFC3339)
+	data["months"] = activityMonthsData(months)
+
+	return &logical.Response{
+		Data: data,
+	}, nil
+}
+
+// pathInternalCountersActivityExport exports the client counts of each month
+// of a period as JSON or CSV
+func (b *SystemBackend) pathInternalCountersActivityExport(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	format := d.Get("format").(string)
+	if format != "json" && format != "csv" {
+		return logical.ErrorResponse(fmt.Sprintf("invalid format %q, must be \"json\" or \"csv\"", format)), logical.ErrInvalidRequest
+	}
+
+	start, end, err := activityPeriod(d, time.Now())
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	months, err := b.Core.activityLog.activity(ctx, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	var body []byte
+	contentType := "application/json"
+	switch format {
+	case "csv":
+		contentType = "text/csv"
+		body, err = activityCSV(months)
+	default:
+		body, err = json.Marshal(activityMonthsData(months))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			logical.HTTPStatusCode:  200,
+			logical.HTTPRawBody:     body,
+			logical.HTTPContentType: contentType,
+		},
+	}, nil
+}
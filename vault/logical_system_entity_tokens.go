@@ -0,0 +1,101 @@
+package vault
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	multierror "github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+// handleEntityTokensList lists the accessors of the tokens of the request
+// namespace tied to an entity
+func (b *SystemBackend) handleEntityTokensList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	entityID := d.Get("entity_id").(string)
+	if entityID == "" {
+		return logical.ErrorResponse("entity_id must be specified"), logical.ErrInvalidRequest
+	}
+
+	tokens, err := b.Core.tokenStore.tokensByEntity(ctx, entityID)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(tokens))
+	keyInfo := make(map[string]interface{}, len(tokens))
+	for _, te := range tokens {
+		keys = append(keys, te.Accessor)
+		keyInfo[te.Accessor] = map[string]interface{}{
+			"display_name":  te.DisplayName,
+			"path":          te.Path,
+			"policies":      te.Policies,
+			"creation_time": time.Unix(te.CreationTime, 0).Format(time.RFC3339),
+		}
+	}
+
+	return logical.ListResponseWithInfo(keys, keyInfo), nil
+}
+
+// handleEntityTokensRevoke revokes the tokens of the request namespace tied to
+// an entity, along with their child tokens and leases. A failure to revoke a
+// token doesn't stop the others from being revoked; the request can be retried
+// to revoke the tokens left.
+func (b *SystemBackend) handleEntityTokensRevoke(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	entityID := d.Get("entity_id").(string)
+	if entityID == "" {
+		return logical.ErrorResponse("entity_id must be specified"), logical.ErrInvalidRequest
+	}
+
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens, err := b.Core.tokenStore.tokensByEntity(ctx, entityID)
+	if err != nil {
+		return nil, err
+	}
+
+	revokeCtx := namespace.ContextWithNamespace(b.Core.activeContext, ns)
+	var retErr *multierror.Error
+	revoked := make([]string, 0, len(tokens))
+	for _, te := range tokens {
+		// The token may have been revoked along with the tree of a token
+		// revoked earlier
+		current, err := b.Core.tokenStore.lookupInternal(ctx, te.ID, false, false)
+		if err != nil {
+			retErr = multierror.Append(retErr, errwrap.Wrapf(fmt.Sprintf("failed to look up token with accessor %q: {{err}}", te.Accessor), err))
+			continue
+		}
+		if current == nil {
+			continue
+		}
+
+		leaseID, err := b.Core.expiration.CreateOrFetchRevocationLeaseByToken(revokeCtx, current)
+		if err == nil {
+			err = b.Core.expiration.Revoke(revokeCtx, leaseID)
+		}
+		if err != nil {
+			b.Backend.Logger().Error("token revocation failed", "entity_id", entityID, "accessor", te.Accessor, "error", err)
+			retErr = multierror.Append(retErr, errwrap.Wrapf(fmt.Sprintf("failed to revoke token with accessor %q: {{err}}", te.Accessor), err))
+			continue
+		}
+		revoked = append(revoked, te.Accessor)
+	}
+	if retErr != nil {
+		return nil, retErr
+	}
+
+	if b.Core.logger.IsInfo() {
+		b.Core.logger.Info("revoked entity tokens", "entity_id", entityID, "count", len(revoked))
+	}
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"revoked_accessors": revoked,
+		},
+	}, nil
+}
@@ -0,0 +1,96 @@
+package vault
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+// handleLockedUsersRead lists the aliases locked out of the auth mounts of
+// the request namespace, grouped by mount
+func (b *SystemBackend) handleLockedUsersRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	accessors := b.Core.lockedAccessors()
+	if accessor := d.Get("mount_accessor").(string); accessor != "" {
+		accessors = []string{accessor}
+	}
+
+	now := time.Now()
+	total := 0
+	byMount := make([]map[string]interface{}, 0, len(accessors))
+	for _, accessor := range accessors {
+		entry := b.Core.router.MatchingMountByAccessor(accessor)
+		if entry == nil || entry.Table != credentialTableType || entry.NamespaceID != ns.ID {
+			continue
+		}
+		_, duration, _ := userLockoutSettings(entry)
+
+		aliases := make([]map[string]interface{}, 0)
+		for _, locked := range b.Core.lockedUsers(accessor) {
+			unlockAt := locked.LockedAt.Add(duration)
+			if !now.Before(unlockAt) {
+				continue
+			}
+			aliases = append(aliases, map[string]interface{}{
+				"alias":     locked.Alias,
+				"locked_at": locked.LockedAt.Format(time.RFC3339),
+				"unlock_at": unlockAt.Format(time.RFC3339),
+			})
+		}
+		if len(aliases) == 0 {
+			continue
+		}
+
+		total += len(aliases)
+		byMount = append(byMount, map[string]interface{}{
+			"mount_accessor": accessor,
+			"mount_path":     credentialRoutePrefix + entry.Path,
+			"count":          len(aliases),
+			"aliases":        aliases,
+		})
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"total":    total,
+			"by_mount": byMount,
+		},
+	}, nil
+}
+
+// handleUnlockUser lifts the lockout of an alias. Unlocking an alias which
+// isn't locked succeeds, so that the request can be retried.
+func (b *SystemBackend) handleUnlockUser(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	accessor := d.Get("mount_accessor").(string)
+	alias := d.Get("alias_identifier").(string)
+	if accessor == "" || alias == "" {
+		return logical.ErrorResponse("mount_accessor and alias_identifier are required"), logical.ErrInvalidRequest
+	}
+
+	entry := b.Core.router.MatchingMountByAccessor(accessor)
+	if entry == nil || entry.Table != credentialTableType || entry.NamespaceID != ns.ID {
+		return logical.ErrorResponse(fmt.Sprintf("no auth mount with accessor %q", accessor)), logical.ErrInvalidRequest
+	}
+
+	if err := b.Core.unlockUser(ctx, accessor, alias); err != nil {
+		return nil, err
+	}
+
+	if b.Core.logger.IsInfo() {
+		b.Core.logger.Info("user unlocked", "mount_path", entry.Path, "mount_accessor", accessor)
+	}
+	return nil, nil
+}
@@ -0,0 +1,529 @@
+package vault
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"image/png"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/vault/helper/strutil"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+	otplib "github.com/pquerna/otp"
+	totplib "github.com/pquerna/otp/totp"
+)
+
+// handleMFAMethodList lists the MFA methods along with their types
+func (b *SystemBackend) handleMFAMethodList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	m := b.Core.loginMFA
+	m.l.RLock()
+	defer m.l.RUnlock()
+
+	keys := make([]string, 0, len(m.methods))
+	keyInfo := make(map[string]interface{}, len(m.methods))
+	for name, method := range m.methods {
+		keys = append(keys, name)
+		keyInfo[name] = map[string]interface{}{
+			"type": method.Type,
+		}
+	}
+	sort.Strings(keys)
+
+	return logical.ListResponseWithInfo(keys, keyInfo), nil
+}
+
+func (b *SystemBackend) handleMFAMethodRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	method := b.Core.loginMFA.method(d.Get("name").(string))
+	if method == nil || method.Type != d.Get("method_type").(string) {
+		return nil, nil
+	}
+
+	data := map[string]interface{}{
+		"name": method.Name,
+		"type": method.Type,
+	}
+	switch method.Type {
+	case mfaMethodTypeTOTP:
+		data["issuer"] = method.TOTP.Issuer
+		data["period"] = method.TOTP.Period
+		data["key_size"] = method.TOTP.KeySize
+		data["algorithm"] = method.TOTP.Algorithm
+		data["digits"] = method.TOTP.Digits
+		data["skew"] = method.TOTP.Skew
+		data["qr_size"] = method.TOTP.QRSize
+	case mfaMethodTypeDuo:
+		data["mount_accessor"] = method.MountAccessor
+		data["username_format"] = method.UsernameFormat
+		data["integration_key"] = method.Duo.IntegrationKey
+		data["api_hostname"] = method.Duo.APIHostname
+		data["push_info"] = method.Duo.PushInfo
+		data["use_passcode"] = method.Duo.UsePasscode
+	case mfaMethodTypePingID:
+		data["mount_accessor"] = method.MountAccessor
+		data["username_format"] = method.UsernameFormat
+		data["use_signature"] = method.PingID.UseSignature
+		data["idp_url"] = method.PingID.IDPURL
+		data["org_alias"] = method.PingID.OrgAlias
+		data["admin_url"] = method.PingID.AdminURL
+		data["authenticator_url"] = method.PingID.AuthenticatorURL
+	}
+
+	return &logical.Response{
+		Data: data,
+	}, nil
+}
+
+func (b *SystemBackend) handleMFAMethodWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+	methodType := d.Get("method_type").(string)
+
+	b.mfaLock.Lock()
+	defer b.mfaLock.Unlock()
+
+	method := &mfaMethod{
+		Name: name,
+		Type: methodType,
+	}
+	if existing := b.Core.loginMFA.method(name); existing != nil {
+		if existing.Type != methodType {
+			return logical.ErrorResponse(fmt.Sprintf("MFA method %q already exists with type %q", name, existing.Type)), logical.ErrInvalidRequest
+		}
+		method = existing.clone()
+	}
+
+	// The users of Duo and PingID are mapped from the aliases of the entities
+	// on the mount of the method
+	if methodType != mfaMethodTypeTOTP {
+		if raw, ok := d.GetOk("mount_accessor"); ok {
+			entry := b.Core.router.MatchingMountByAccessor(raw.(string))
+			if entry == nil || entry.Table != credentialTableType {
+				return logical.ErrorResponse(fmt.Sprintf("auth method accessor %q not found", raw.(string))), logical.ErrInvalidRequest
+			}
+			method.MountAccessor = raw.(string)
+		}
+		if method.MountAccessor == "" {
+			return logical.ErrorResponse("mount_accessor is required"), logical.ErrInvalidRequest
+		}
+
+		if raw, ok := d.GetOk("username_format"); ok {
+			if _, err := formatMFAUsername(raw.(string), nil, nil); err != nil {
+				return logical.ErrorResponse(fmt.Sprintf("invalid username_format: %s", err)), logical.ErrInvalidRequest
+			}
+			method.UsernameFormat = raw.(string)
+		}
+	}
+
+	var err error
+	switch methodType {
+	case mfaMethodTypeTOTP:
+		err = updateTOTPMFAConfig(method, d)
+	case mfaMethodTypeDuo:
+		err = updateDuoMFAConfig(method, d)
+	case mfaMethodTypePingID:
+		err = updatePingIDMFAConfig(method, d)
+	}
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	entry, err := logical.StorageEntryJSON(mfaMethodPrefix+name, method)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+	b.Core.loginMFA.setMethod(method)
+
+	return nil, nil
+}
+
+func updateTOTPMFAConfig(method *mfaMethod, d *framework.FieldData) error {
+	// New methods take the defaults of the fields which aren't set
+	isNew := method.TOTP == nil
+	if isNew {
+		method.TOTP = &totpMFAConfig{}
+	}
+	config := method.TOTP
+
+	get := func(field string) (interface{}, bool) {
+		raw, ok := d.GetOk(field)
+		if !ok && isNew {
+			return d.Get(field), true
+		}
+		return raw, ok
+	}
+
+	if raw, ok := get("issuer"); ok {
+		config.Issuer = raw.(string)
+	}
+	if config.Issuer == "" {
+		return fmt.Errorf("issuer is required")
+	}
+
+	if raw, ok := get("period"); ok {
+		if raw.(int) <= 0 {
+			return fmt.Errorf("period must be positive")
+		}
+		config.Period = uint(raw.(int))
+	}
+
+	if raw, ok := get("key_size"); ok {
+		if raw.(int) <= 0 {
+			return fmt.Errorf("key_size must be positive")
+		}
+		config.KeySize = uint(raw.(int))
+	}
+
+	if raw, ok := get("algorithm"); ok {
+		if _, err := totpAlgorithm(raw.(string)); err != nil {
+			return fmt.Errorf("algorithm must be SHA1, SHA256 or SHA512")
+		}
+		config.Algorithm = strings.ToUpper(raw.(string))
+	}
+
+	if raw, ok := get("digits"); ok {
+		if raw.(int) != 6 && raw.(int) != 8 {
+			return fmt.Errorf("digits must be 6 or 8")
+		}
+		config.Digits = raw.(int)
+	}
+
+	if raw, ok := get("skew"); ok {
+		if raw.(int) != 0 && raw.(int) != 1 {
+			return fmt.Errorf("skew must be 0 or 1")
+		}
+		config.Skew = uint(raw.(int))
+	}
+
+	if raw, ok := get("qr_size"); ok {
+		if raw.(int) < 0 {
+			return fmt.Errorf("qr_size must not be negative")
+		}
+		config.QRSize = raw.(int)
+	}
+
+	return nil
+}
+
+func updateDuoMFAConfig(method *mfaMethod, d *framework.FieldData) error {
+	config := method.Duo
+	if config == nil {
+		config = &duoMFAConfig{}
+		method.Duo = config
+	}
+
+	if raw, ok := d.GetOk("integration_key"); ok {
+		config.IntegrationKey = raw.(string)
+	}
+	if raw, ok := d.GetOk("secret_key"); ok {
+		config.SecretKey = raw.(string)
+	}
+	if raw, ok := d.GetOk("api_hostname"); ok {
+		config.APIHostname = raw.(string)
+	}
+	if raw, ok := d.GetOk("push_info"); ok {
+		config.PushInfo = raw.(string)
+	}
+	if raw, ok := d.GetOk("use_passcode"); ok {
+		config.UsePasscode = raw.(bool)
+	}
+
+	switch {
+	case config.IntegrationKey == "":
+		return fmt.Errorf("integration_key is required")
+	case config.SecretKey == "":
+		return fmt.Errorf("secret_key is required")
+	case config.APIHostname == "":
+		return fmt.Errorf("api_hostname is required")
+	}
+	return nil
+}
+
+func updatePingIDMFAConfig(method *mfaMethod, d *framework.FieldData) error {
+	raw, ok := d.GetOk("settings_file_base64")
+	if !ok {
+		if method.PingID == nil {
+			return fmt.Errorf("settings_file_base64 is required")
+		}
+		return nil
+	}
+
+	config, err := parsePingIDSettings(raw.(string))
+	if err != nil {
+		return err
+	}
+	method.PingID = config
+	return nil
+}
+
+func (b *SystemBackend) handleMFAMethodDelete(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	b.mfaLock.Lock()
+	defer b.mfaLock.Unlock()
+
+	method := b.Core.loginMFA.method(name)
+	if method == nil || method.Type != d.Get("method_type").(string) {
+		return nil, nil
+	}
+	if enforcements := b.Core.loginMFA.enforcementsUsing(name); len(enforcements) > 0 {
+		return logical.ErrorResponse(fmt.Sprintf("MFA method %q is required by the login MFA enforcements %s", name, strings.Join(enforcements, ", "))), logical.ErrInvalidRequest
+	}
+
+	secrets, err := req.Storage.List(ctx, mfaTOTPSecretPrefix+name+"/")
+	if err != nil {
+		return nil, err
+	}
+	for _, entityID := range secrets {
+		if err := req.Storage.Delete(ctx, totpSecretPath(name, entityID)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := req.Storage.Delete(ctx, mfaMethodPrefix+name); err != nil {
+		return nil, err
+	}
+	b.Core.loginMFA.deleteMethod(name)
+
+	return nil, nil
+}
+
+// handleMFATOTPGenerate generates a TOTP secret for the entity of the token
+// making the request
+func (b *SystemBackend) handleMFATOTPGenerate(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	if req.EntityID == "" {
+		return logical.ErrorResponse("the token isn't associated with an entity"), logical.ErrInvalidRequest
+	}
+
+	return b.generateTOTPSecret(ctx, req, d.Get("name").(string), req.EntityID)
+}
+
+// handleMFATOTPAdminGenerate generates a TOTP secret for the given entity
+func (b *SystemBackend) handleMFATOTPAdminGenerate(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	entityID := d.Get("entity_id").(string)
+	if entityID == "" {
+		return logical.ErrorResponse("missing entity_id"), logical.ErrInvalidRequest
+	}
+
+	return b.generateTOTPSecret(ctx, req, d.Get("name").(string), entityID)
+}
+
+// generateTOTPSecret generates a TOTP secret for the entity, unless it already
+// has one, which has to be destroyed first
+func (b *SystemBackend) generateTOTPSecret(ctx context.Context, req *logical.Request, name, entityID string) (*logical.Response, error) {
+	b.mfaLock.Lock()
+	defer b.mfaLock.Unlock()
+
+	method := b.Core.loginMFA.method(name)
+	if method == nil || method.Type != mfaMethodTypeTOTP {
+		return logical.ErrorResponse(fmt.Sprintf("TOTP method %q not found", name)), logical.ErrInvalidRequest
+	}
+
+	existing, err := req.Storage.Get(ctx, totpSecretPath(name, entityID))
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return logical.ErrorResponse("a TOTP secret was already generated for the entity"), logical.ErrInvalidRequest
+	}
+
+	entity, err := b.Core.identityStore.MemDBEntityByID(entityID, false)
+	if err != nil {
+		return nil, err
+	}
+	if entity == nil {
+		return logical.ErrorResponse(fmt.Sprintf("entity %q not found", entityID)), logical.ErrInvalidRequest
+	}
+
+	config := method.TOTP
+	algorithm, err := totpAlgorithm(config.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	accountName := entity.Name
+	if accountName == "" {
+		accountName = entity.ID
+	}
+	key, err := totplib.Generate(totplib.GenerateOpts{
+		Issuer:      config.Issuer,
+		AccountName: accountName,
+		Period:      config.Period,
+		SecretSize:  config.KeySize,
+		Digits:      otplib.Digits(config.Digits),
+		Algorithm:   algorithm,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	entry, err := logical.StorageEntryJSON(totpSecretPath(name, entityID), &totpSecret{
+		Secret:    key.Secret(),
+		Period:    config.Period,
+		Algorithm: config.Algorithm,
+		Digits:    config.Digits,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	data := map[string]interface{}{
+		"url": key.String(),
+	}
+	if config.QRSize > 0 {
+		image, err := key.Image(config.QRSize, config.QRSize)
+		if err != nil {
+			return nil, err
+		}
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, image); err != nil {
+			return nil, err
+		}
+		data["barcode"] = base64.StdEncoding.EncodeToString(buf.Bytes())
+	}
+
+	return &logical.Response{
+		Data: data,
+	}, nil
+}
+
+// handleMFATOTPAdminDestroy deletes the TOTP secret of the given entity
+func (b *SystemBackend) handleMFATOTPAdminDestroy(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	entityID := d.Get("entity_id").(string)
+	if entityID == "" {
+		return logical.ErrorResponse("missing entity_id"), logical.ErrInvalidRequest
+	}
+
+	if err := req.Storage.Delete(ctx, totpSecretPath(d.Get("name").(string), entityID)); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+func (b *SystemBackend) handleMFALoginEnforcementList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	m := b.Core.loginMFA
+	m.l.RLock()
+	defer m.l.RUnlock()
+
+	keys := make([]string, 0, len(m.enforcements))
+	for name := range m.enforcements {
+		keys = append(keys, name)
+	}
+	sort.Strings(keys)
+
+	return logical.ListResponse(keys), nil
+}
+
+func (b *SystemBackend) handleMFALoginEnforcementRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	enforcement := b.Core.loginMFA.enforcement(d.Get("name").(string))
+	if enforcement == nil {
+		return nil, nil
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"name":                  enforcement.Name,
+			"mfa_method_names":      enforcement.MFAMethodNames,
+			"auth_method_accessors": enforcement.AuthMethodAccessors,
+			"auth_method_types":     enforcement.AuthMethodTypes,
+			"identity_entity_ids":   enforcement.IdentityEntityIDs,
+			"identity_group_ids":    enforcement.IdentityGroupIDs,
+		},
+	}, nil
+}
+
+func (b *SystemBackend) handleMFALoginEnforcementWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	b.mfaLock.Lock()
+	defer b.mfaLock.Unlock()
+
+	enforcement := &mfaLoginEnforcement{
+		Name: name,
+	}
+	if existing := b.Core.loginMFA.enforcement(name); existing != nil {
+		enforcement = existing.clone()
+	}
+
+	for field, value := range map[string]*[]string{
+		"mfa_method_names":      &enforcement.MFAMethodNames,
+		"auth_method_accessors": &enforcement.AuthMethodAccessors,
+		"auth_method_types":     &enforcement.AuthMethodTypes,
+		"identity_entity_ids":   &enforcement.IdentityEntityIDs,
+		"identity_group_ids":    &enforcement.IdentityGroupIDs,
+	} {
+		if raw, ok := d.GetOk(field); ok {
+			*value = strutil.RemoveDuplicates(raw.([]string), false)
+		}
+	}
+
+	if len(enforcement.MFAMethodNames) == 0 {
+		return logical.ErrorResponse("mfa_method_names is required"), logical.ErrInvalidRequest
+	}
+	for _, methodName := range enforcement.MFAMethodNames {
+		if b.Core.loginMFA.method(methodName) == nil {
+			return logical.ErrorResponse(fmt.Sprintf("MFA method %q not found", methodName)), logical.ErrInvalidRequest
+		}
+	}
+
+	if len(enforcement.AuthMethodAccessors) == 0 && len(enforcement.AuthMethodTypes) == 0 &&
+		len(enforcement.IdentityEntityIDs) == 0 && len(enforcement.IdentityGroupIDs) == 0 {
+		return logical.ErrorResponse("one of auth_method_accessors, auth_method_types, identity_entity_ids or identity_group_ids is required"), logical.ErrInvalidRequest
+	}
+	for _, accessor := range enforcement.AuthMethodAccessors {
+		entry := b.Core.router.MatchingMountByAccessor(accessor)
+		if entry == nil || entry.Table != credentialTableType {
+			return logical.ErrorResponse(fmt.Sprintf("auth method accessor %q not found", accessor)), logical.ErrInvalidRequest
+		}
+	}
+	for _, entityID := range enforcement.IdentityEntityIDs {
+		entity, err := b.Core.identityStore.MemDBEntityByID(entityID, false)
+		if err != nil {
+			return nil, err
+		}
+		if entity == nil {
+			return logical.ErrorResponse(fmt.Sprintf("entity %q not found", entityID)), logical.ErrInvalidRequest
+		}
+	}
+	for _, groupID := range enforcement.IdentityGroupIDs {
+		group, err := b.Core.identityStore.MemDBGroupByID(groupID, false)
+		if err != nil {
+			return nil, err
+		}
+		if group == nil {
+			return logical.ErrorResponse(fmt.Sprintf("group %q not found", groupID)), logical.ErrInvalidRequest
+		}
+	}
+
+	entry, err := logical.StorageEntryJSON(mfaEnforcementPrefix+name, enforcement)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+	b.Core.loginMFA.setEnforcement(enforcement)
+
+	return nil, nil
+}
+
+func (b *SystemBackend) handleMFALoginEnforcementDelete(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	b.mfaLock.Lock()
+	defer b.mfaLock.Unlock()
+
+	if err := req.Storage.Delete(ctx, mfaEnforcementPrefix+name); err != nil {
+		return nil, err
+	}
+	b.Core.loginMFA.deleteEnforcement(name)
+
+	return nil, nil
+}
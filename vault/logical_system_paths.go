@@ -95,6 +95,20 @@ func (b *SystemBackend) configPaths() []*framework.Path {
 			HelpSynopsis:    strings.TrimSpace(sysHelp["config/ui/headers"][1]),
 		},
 
+		{
+			Pattern: "config/state/sanitized$",
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleConfigStateSanitized,
+					Summary:  "Return the server configuration, with the values that may hold secrets left out.",
+				},
+			},
+
+			HelpDescription: strings.TrimSpace(sysHelp["config/state/sanitized"][0]),
+			HelpSynopsis:    strings.TrimSpace(sysHelp["config/state/sanitized"][1]),
+		},
+
 		{
 			Pattern: "generate-root(/attempt)?$",
 			Fields: map[string]*framework.FieldSchema{
@@ -419,6 +433,11 @@ func (b *SystemBackend) auditPaths() []*framework.Path {
 				"input": &framework.FieldSchema{
 					Type: framework.TypeString,
 				},
+
+				"inputs": &framework.FieldSchema{
+					Type:        framework.TypeStringSlice,
+					Description: strings.TrimSpace(sysHelp["audit-hash-inputs"][0]),
+				},
 			},
 
 			Callbacks: map[logical.Operation]framework.OperationFunc{
@@ -532,6 +551,21 @@ func (b *SystemBackend) auditPaths() []*framework.Path {
 	}
 }
 
+func (b *SystemBackend) migrationPaths() []*framework.Path {
+	return []*framework.Path{
+		{
+			Pattern: "migration-status$",
+
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.ReadOperation: b.handleMigrationStatus,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["migration-status"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["migration-status"][1]),
+		},
+	}
+}
+
 func (b *SystemBackend) sealPaths() []*framework.Path {
 	return []*framework.Path{
 		{
@@ -824,6 +858,53 @@ func (b *SystemBackend) internalPaths() []*framework.Path {
 			HelpSynopsis:    strings.TrimSpace(sysHelp["internal-ui-resultant-acl"][0]),
 			HelpDescription: strings.TrimSpace(sysHelp["internal-ui-resultant-acl"][1]),
 		},
+		{
+			Pattern: "internal/counters/activity$",
+			Fields: map[string]*framework.FieldSchema{
+				"start_time": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Description: strings.TrimSpace(sysHelp["activity-start-time"][0]),
+				},
+				"end_time": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Description: strings.TrimSpace(sysHelp["activity-end-time"][0]),
+				},
+			},
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.pathInternalCountersActivityRead,
+					Summary:  "Report the distinct clients active over a period.",
+				},
+			},
+			HelpSynopsis:    strings.TrimSpace(sysHelp["activity"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["activity"][1]),
+		},
+		{
+			Pattern: "internal/counters/activity/export$",
+			Fields: map[string]*framework.FieldSchema{
+				"start_time": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Description: strings.TrimSpace(sysHelp["activity-start-time"][0]),
+				},
+				"end_time": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Description: strings.TrimSpace(sysHelp["activity-end-time"][0]),
+				},
+				"format": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Default:     "json",
+					Description: strings.TrimSpace(sysHelp["activity-export-format"][0]),
+				},
+			},
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.pathInternalCountersActivityExport,
+					Summary:  "Export the monthly client counts over a period.",
+				},
+			},
+			HelpSynopsis:    strings.TrimSpace(sysHelp["activity-export"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["activity-export"][1]),
+		},
 	}
 }
 
@@ -1073,6 +1154,49 @@ func (b *SystemBackend) leasePaths() []*framework.Path {
 			HelpSynopsis:    strings.TrimSpace(sysHelp["tidy_leases"][0]),
 			HelpDescription: strings.TrimSpace(sysHelp["tidy_leases"][1]),
 		},
+
+		{
+			Pattern: "leases/irrevocable$",
+
+			Fields: map[string]*framework.FieldSchema{
+				"mount": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Description: strings.TrimSpace(sysHelp["irrevocable-leases-mount"][0]),
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleIrrevocableLeases,
+					Summary:  "Lists the leases which could not be revoked, with their counts per mount.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["irrevocable-leases"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["irrevocable-leases"][1]),
+		},
+
+		{
+			Pattern: "leases/irrevocable/forget$",
+
+			Fields: map[string]*framework.FieldSchema{
+				"lease_id": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Description: strings.TrimSpace(sysHelp["lease_id"][0]),
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback:    b.handleForgetIrrevocableLease,
+					Summary:     "Removes an irrevocable lease without revoking it.",
+					Description: "The backend which issued the lease is not called, so the secret the lease stands for is left behind and has to be cleaned up by other means. Access to this endpoint should be tightly controlled.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["irrevocable-leases-forget"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["irrevocable-leases-forget"][1]),
+		},
 	}
 }
 
@@ -1157,6 +1281,34 @@ func (b *SystemBackend) authPaths() []*framework.Path {
 					Type:        framework.TypeString,
 					Description: strings.TrimSpace(sysHelp["token_type"][0]),
 				},
+				"login_rate_limit": &framework.FieldSchema{
+					Type:        framework.TypeInt,
+					Description: strings.TrimSpace(sysHelp["login_rate_limit"][0]),
+				},
+				"login_rate_limit_period": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Description: strings.TrimSpace(sysHelp["login_rate_limit_period"][0]),
+				},
+				"user_lockout_threshold": &framework.FieldSchema{
+					Type:        framework.TypeInt,
+					Description: strings.TrimSpace(sysHelp["user_lockout_threshold"][0]),
+				},
+				"user_lockout_duration": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Description: strings.TrimSpace(sysHelp["user_lockout_duration"][0]),
+				},
+				"user_lockout_counter_reset": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Description: strings.TrimSpace(sysHelp["user_lockout_counter_reset"][0]),
+				},
+				"allowed_alias_metadata_keys": &framework.FieldSchema{
+					Type:        framework.TypeCommaStringSlice,
+					Description: strings.TrimSpace(sysHelp["allowed_alias_metadata_keys"][0]),
+				},
+				"request_concurrency_limit": &framework.FieldSchema{
+					Type:        framework.TypeInt,
+					Description: strings.TrimSpace(sysHelp["request_concurrency_limit"][0]),
+				},
 			},
 			Operations: map[logical.Operation]framework.OperationHandler{
 				logical.ReadOperation: &framework.PathOperation{
@@ -1451,6 +1603,34 @@ func (b *SystemBackend) mountPaths() []*framework.Path {
 					Type:        framework.TypeString,
 					Description: strings.TrimSpace(sysHelp["token_type"][0]),
 				},
+				"login_rate_limit": &framework.FieldSchema{
+					Type:        framework.TypeInt,
+					Description: strings.TrimSpace(sysHelp["login_rate_limit"][0]),
+				},
+				"login_rate_limit_period": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Description: strings.TrimSpace(sysHelp["login_rate_limit_period"][0]),
+				},
+				"user_lockout_threshold": &framework.FieldSchema{
+					Type:        framework.TypeInt,
+					Description: strings.TrimSpace(sysHelp["user_lockout_threshold"][0]),
+				},
+				"user_lockout_duration": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Description: strings.TrimSpace(sysHelp["user_lockout_duration"][0]),
+				},
+				"user_lockout_counter_reset": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Description: strings.TrimSpace(sysHelp["user_lockout_counter_reset"][0]),
+				},
+				"allowed_alias_metadata_keys": &framework.FieldSchema{
+					Type:        framework.TypeCommaStringSlice,
+					Description: strings.TrimSpace(sysHelp["allowed_alias_metadata_keys"][0]),
+				},
+				"request_concurrency_limit": &framework.FieldSchema{
+					Type:        framework.TypeInt,
+					Description: strings.TrimSpace(sysHelp["request_concurrency_limit"][0]),
+				},
 			},
 
 			Callbacks: map[logical.Operation]framework.OperationFunc{
@@ -1462,6 +1642,28 @@ func (b *SystemBackend) mountPaths() []*framework.Path {
 			HelpDescription: strings.TrimSpace(sysHelp["mount_tune"][1]),
 		},
 
+		{
+			Pattern: "mounts/(?P<path>.+?)/migrate-to-database$",
+
+			Fields: map[string]*framework.FieldSchema{
+				"path": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Description: strings.TrimSpace(sysHelp["mount_path"][0]),
+				},
+				"connection_name": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Description: strings.TrimSpace(sysHelp["migrate_connection_name"][0]),
+				},
+			},
+
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.UpdateOperation: b.handleMigrateToDatabase,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["migrate_to_database"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["migrate_to_database"][1]),
+		},
+
 		{
 			Pattern: "mounts/(?P<path>.+?)",
 
@@ -1528,3 +1730,535 @@ func (b *SystemBackend) mountPaths() []*framework.Path {
 		},
 	}
 }
+
+func (b *SystemBackend) loggersPaths() []*framework.Path {
+	return []*framework.Path{
+		{
+			Pattern: "loggers$",
+
+			Fields: map[string]*framework.FieldSchema{
+				"level": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Description: strings.TrimSpace(sysHelp["loggers-level"][0]),
+				},
+			},
+
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.ReadOperation:   b.handleLoggersRead,
+				logical.UpdateOperation: b.handleLoggersWrite,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["loggers"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["loggers"][1]),
+		},
+		{
+			Pattern: "loggers/" + framework.MatchAllRegex("name"),
+
+			Fields: map[string]*framework.FieldSchema{
+				"name": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Description: strings.TrimSpace(sysHelp["loggers-name"][0]),
+				},
+				"level": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Description: strings.TrimSpace(sysHelp["loggers-level"][0]),
+				},
+			},
+
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.ReadOperation:   b.handleLoggersByNameRead,
+				logical.UpdateOperation: b.handleLoggersByNameWrite,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["loggers-by-name"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["loggers-by-name"][1]),
+		},
+	}
+}
+
+func (b *SystemBackend) usagePaths() []*framework.Path {
+	return []*framework.Path{
+		{
+			Pattern: "usage/tokens$",
+
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.ReadOperation: b.handleTokenUsageRead,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["usage-tokens"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["usage-tokens"][1]),
+		},
+	}
+}
+
+func (b *SystemBackend) licensePaths() []*framework.Path {
+	return []*framework.Path{
+		{
+			Pattern: "license/status$",
+
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.ReadOperation: b.handleLicenseStatusRead,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["license-status"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["license-status"][1]),
+		},
+	}
+}
+
+func (b *SystemBackend) wellKnownPaths() []*framework.Path {
+	return []*framework.Path{
+		{
+			Pattern: "well-known/?$",
+
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.ListOperation: b.handleWellKnownList,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["well-known"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["well-known"][1]),
+		},
+		{
+			Pattern: "well-known/(?P<path>.+)",
+
+			Fields: map[string]*framework.FieldSchema{
+				"path": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Description: strings.TrimSpace(sysHelp["well-known-path"][0]),
+				},
+			},
+
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.ReadOperation: b.handleWellKnownRead,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["well-known-by-path"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["well-known-by-path"][1]),
+		},
+	}
+}
+
+func (b *SystemBackend) mfaPaths() []*framework.Path {
+	methodFields := map[string]*framework.FieldSchema{
+		"method_type": &framework.FieldSchema{
+			Type:        framework.TypeString,
+			Description: strings.TrimSpace(sysHelp["mfa-method-type"][0]),
+		},
+		"name": &framework.FieldSchema{
+			Type:        framework.TypeString,
+			Description: strings.TrimSpace(sysHelp["mfa-method-name"][0]),
+		},
+		"mount_accessor": &framework.FieldSchema{
+			Type:        framework.TypeString,
+			Description: strings.TrimSpace(sysHelp["mfa-method-mount-accessor"][0]),
+		},
+		"username_format": &framework.FieldSchema{
+			Type:        framework.TypeString,
+			Description: strings.TrimSpace(sysHelp["mfa-method-username-format"][0]),
+		},
+		"issuer": &framework.FieldSchema{
+			Type:        framework.TypeString,
+			Description: strings.TrimSpace(sysHelp["mfa-method-issuer"][0]),
+		},
+		"period": &framework.FieldSchema{
+			Type:        framework.TypeDurationSecond,
+			Default:     30,
+			Description: strings.TrimSpace(sysHelp["mfa-method-period"][0]),
+		},
+		"key_size": &framework.FieldSchema{
+			Type:        framework.TypeInt,
+			Default:     20,
+			Description: strings.TrimSpace(sysHelp["mfa-method-key-size"][0]),
+		},
+		"algorithm": &framework.FieldSchema{
+			Type:        framework.TypeString,
+			Default:     "SHA1",
+			Description: strings.TrimSpace(sysHelp["mfa-method-algorithm"][0]),
+		},
+		"digits": &framework.FieldSchema{
+			Type:        framework.TypeInt,
+			Default:     6,
+			Description: strings.TrimSpace(sysHelp["mfa-method-digits"][0]),
+		},
+		"skew": &framework.FieldSchema{
+			Type:        framework.TypeInt,
+			Default:     1,
+			Description: strings.TrimSpace(sysHelp["mfa-method-skew"][0]),
+		},
+		"qr_size": &framework.FieldSchema{
+			Type:        framework.TypeInt,
+			Default:     200,
+			Description: strings.TrimSpace(sysHelp["mfa-method-qr-size"][0]),
+		},
+		"integration_key": &framework.FieldSchema{
+			Type:        framework.TypeString,
+			Description: strings.TrimSpace(sysHelp["mfa-method-integration-key"][0]),
+		},
+		"secret_key": &framework.FieldSchema{
+			Type:        framework.TypeString,
+			Description: strings.TrimSpace(sysHelp["mfa-method-secret-key"][0]),
+		},
+		"api_hostname": &framework.FieldSchema{
+			Type:        framework.TypeString,
+			Description: strings.TrimSpace(sysHelp["mfa-method-api-hostname"][0]),
+		},
+		"push_info": &framework.FieldSchema{
+			Type:        framework.TypeString,
+			Description: strings.TrimSpace(sysHelp["mfa-method-push-info"][0]),
+		},
+		"use_passcode": &framework.FieldSchema{
+			Type:        framework.TypeBool,
+			Description: strings.TrimSpace(sysHelp["mfa-method-use-passcode"][0]),
+		},
+		"settings_file_base64": &framework.FieldSchema{
+			Type:        framework.TypeString,
+			Description: strings.TrimSpace(sysHelp["mfa-method-settings-file-base64"][0]),
+		},
+	}
+
+	totpFields := map[string]*framework.FieldSchema{
+		"name": &framework.FieldSchema{
+			Type:        framework.TypeString,
+			Description: strings.TrimSpace(sysHelp["mfa-method-name"][0]),
+		},
+		"entity_id": &framework.FieldSchema{
+			Type:        framework.TypeString,
+			Description: strings.TrimSpace(sysHelp["mfa-totp-entity-id"][0]),
+		},
+	}
+
+	return []*framework.Path{
+		{
+			Pattern: "mfa/method/?$",
+
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.ListOperation: b.handleMFAMethodList,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["mfa-method-list"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["mfa-method-list"][1]),
+		},
+		{
+			Pattern: "mfa/method/(?P<method_type>totp|duo|pingid)/" + framework.GenericNameRegex("name") + "$",
+
+			Fields: methodFields,
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleMFAMethodRead,
+					Summary:  "Read the configuration of the MFA method.",
+				},
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleMFAMethodWrite,
+					Summary:  "Create or update the MFA method.",
+				},
+				logical.DeleteOperation: &framework.PathOperation{
+					Callback: b.handleMFAMethodDelete,
+					Summary:  "Delete the MFA method.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["mfa-method"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["mfa-method"][1]),
+		},
+		{
+			Pattern: "mfa/method/totp/" + framework.GenericNameRegex("name") + "/generate$",
+
+			Fields: totpFields,
+
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.ReadOperation:   b.handleMFATOTPGenerate,
+				logical.UpdateOperation: b.handleMFATOTPGenerate,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["mfa-totp-generate"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["mfa-totp-generate"][1]),
+		},
+		{
+			Pattern: "mfa/method/totp/" + framework.GenericNameRegex("name") + "/admin-generate$",
+
+			Fields: totpFields,
+
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.UpdateOperation: b.handleMFATOTPAdminGenerate,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["mfa-totp-admin-generate"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["mfa-totp-admin-generate"][1]),
+		},
+		{
+			Pattern: "mfa/method/totp/" + framework.GenericNameRegex("name") + "/admin-destroy$",
+
+			Fields: totpFields,
+
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.UpdateOperation: b.handleMFATOTPAdminDestroy,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["mfa-totp-admin-destroy"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["mfa-totp-admin-destroy"][1]),
+		},
+		{
+			Pattern: "mfa/login-enforcement/?$",
+
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.ListOperation: b.handleMFALoginEnforcementList,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["mfa-login-enforcement-list"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["mfa-login-enforcement-list"][1]),
+		},
+		{
+			Pattern: "mfa/login-enforcement/" + framework.GenericNameRegex("name") + "$",
+
+			Fields: map[string]*framework.FieldSchema{
+				"name": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Description: strings.TrimSpace(sysHelp["mfa-login-enforcement-name"][0]),
+				},
+				"mfa_method_names": &framework.FieldSchema{
+					Type:        framework.TypeCommaStringSlice,
+					Description: strings.TrimSpace(sysHelp["mfa-login-enforcement-mfa-method-names"][0]),
+				},
+				"auth_method_accessors": &framework.FieldSchema{
+					Type:        framework.TypeCommaStringSlice,
+					Description: strings.TrimSpace(sysHelp["mfa-login-enforcement-auth-method-accessors"][0]),
+				},
+				"auth_method_types": &framework.FieldSchema{
+					Type:        framework.TypeCommaStringSlice,
+					Description: strings.TrimSpace(sysHelp["mfa-login-enforcement-auth-method-types"][0]),
+				},
+				"identity_entity_ids": &framework.FieldSchema{
+					Type:        framework.TypeCommaStringSlice,
+					Description: strings.TrimSpace(sysHelp["mfa-login-enforcement-identity-entity-ids"][0]),
+				},
+				"identity_group_ids": &framework.FieldSchema{
+					Type:        framework.TypeCommaStringSlice,
+					Description: strings.TrimSpace(sysHelp["mfa-login-enforcement-identity-group-ids"][0]),
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleMFALoginEnforcementRead,
+					Summary:  "Read the login MFA enforcement.",
+				},
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleMFALoginEnforcementWrite,
+					Summary:  "Create or update the login MFA enforcement.",
+				},
+				logical.DeleteOperation: &framework.PathOperation{
+					Callback: b.handleMFALoginEnforcementDelete,
+					Summary:  "Delete the login MFA enforcement.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["mfa-login-enforcement"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["mfa-login-enforcement"][1]),
+		},
+	}
+}
+
+func (b *SystemBackend) quotasPaths() []*framework.Path {
+	return []*framework.Path{
+		{
+			Pattern: "quotas/rate-limit/?$",
+
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.ListOperation: b.handleRateLimitQuotaList,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["rate-limit-quota-list"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["rate-limit-quota-list"][1]),
+		},
+		{
+			Pattern: "quotas/rate-limit/" + framework.GenericNameRegex("name") + "$",
+
+			Fields: map[string]*framework.FieldSchema{
+				"name": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Description: strings.TrimSpace(sysHelp["rate-limit-quota-name"][0]),
+				},
+				"path": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Description: strings.TrimSpace(sysHelp["rate-limit-quota-path"][0]),
+				},
+				"role": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Description: strings.TrimSpace(sysHelp["rate-limit-quota-role"][0]),
+				},
+				"rate": &framework.FieldSchema{
+					Type:        framework.TypeInt,
+					Description: strings.TrimSpace(sysHelp["rate-limit-quota-rate"][0]),
+				},
+				"interval": &framework.FieldSchema{
+					Type:        framework.TypeDurationSecond,
+					Default:     1,
+					Description: strings.TrimSpace(sysHelp["rate-limit-quota-interval"][0]),
+				},
+				"burst": &framework.FieldSchema{
+					Type:        framework.TypeInt,
+					Description: strings.TrimSpace(sysHelp["rate-limit-quota-burst"][0]),
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleRateLimitQuotaRead,
+					Summary:  "Read the rate limit quota.",
+				},
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleRateLimitQuotaWrite,
+					Summary:  "Create or update the rate limit quota.",
+				},
+				logical.DeleteOperation: &framework.PathOperation{
+					Callback: b.handleRateLimitQuotaDelete,
+					Summary:  "Delete the rate limit quota.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["rate-limit-quota"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["rate-limit-quota"][1]),
+		},
+		{
+			Pattern: "quotas/lease-count/?$",
+
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.ListOperation: b.handleLeaseCountQuotaList,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["lease-count-quota-list"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["lease-count-quota-list"][1]),
+		},
+		{
+			Pattern: "quotas/lease-count/" + framework.GenericNameRegex("name") + "$",
+
+			Fields: map[string]*framework.FieldSchema{
+				"name": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Description: strings.TrimSpace(sysHelp["lease-count-quota-name"][0]),
+				},
+				"path": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Description: strings.TrimSpace(sysHelp["lease-count-quota-path"][0]),
+				},
+				"max_leases": &framework.FieldSchema{
+					Type:        framework.TypeInt,
+					Description: strings.TrimSpace(sysHelp["lease-count-quota-max-leases"][0]),
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleLeaseCountQuotaRead,
+					Summary:  "Read the lease count quota.",
+				},
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleLeaseCountQuotaWrite,
+					Summary:  "Create or update the lease count quota.",
+				},
+				logical.DeleteOperation: &framework.PathOperation{
+					Callback: b.handleLeaseCountQuotaDelete,
+					Summary:  "Delete the lease count quota.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["lease-count-quota"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["lease-count-quota"][1]),
+		},
+	}
+}
+
+func (b *SystemBackend) lockedUsersPaths() []*framework.Path {
+	return []*framework.Path{
+		{
+			Pattern: "locked-users$",
+
+			Fields: map[string]*framework.FieldSchema{
+				"mount_accessor": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Description: strings.TrimSpace(sysHelp["locked-users-mount-accessor"][0]),
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleLockedUsersRead,
+					Summary:  "Lists the users locked out after repeated failed logins.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["locked-users"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["locked-users"][1]),
+		},
+		{
+			Pattern: "locked-users/(?P<mount_accessor>[^/]+)/unlock/(?P<alias_identifier>.+)",
+
+			Fields: map[string]*framework.FieldSchema{
+				"mount_accessor": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Description: strings.TrimSpace(sysHelp["locked-users-mount-accessor"][0]),
+				},
+				"alias_identifier": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Description: strings.TrimSpace(sysHelp["locked-users-alias-identifier"][0]),
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleUnlockUser,
+					Summary:  "Unlocks a user locked out after repeated failed logins.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["locked-users-unlock"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["locked-users-unlock"][1]),
+		},
+	}
+}
+
+func (b *SystemBackend) entityTokensPaths() []*framework.Path {
+	return []*framework.Path{
+		{
+			Pattern: "entity/(?P<entity_id>[^/]+)/tokens/?$",
+
+			Fields: map[string]*framework.FieldSchema{
+				"entity_id": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Description: strings.TrimSpace(sysHelp["entity-tokens-entity-id"][0]),
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ListOperation: &framework.PathOperation{
+					Callback: b.handleEntityTokensList,
+					Summary:  "Lists the tokens tied to an entity.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["entity-tokens"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["entity-tokens"][1]),
+		},
+		{
+			Pattern: "entity/(?P<entity_id>[^/]+)/tokens/revoke$",
+
+			Fields: map[string]*framework.FieldSchema{
+				"entity_id": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Description: strings.TrimSpace(sysHelp["entity-tokens-entity-id"][0]),
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleEntityTokensRevoke,
+					Summary:  "Revokes all the tokens tied to an entity, with their child tokens and leases.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["entity-tokens-revoke"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["entity-tokens-revoke"][1]),
+		},
+	}
+}
@@ -0,0 +1,256 @@
+package vault
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+// handleRateLimitQuotaList lists the rate limit quotas along with their scopes
+func (b *SystemBackend) handleRateLimitQuotaList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	r := b.Core.rateLimitQuotas
+	r.l.RLock()
+	defer r.l.RUnlock()
+
+	keys := make([]string, 0, len(r.quotas))
+	keyInfo := make(map[string]interface{}, len(r.quotas))
+	for name, quota := range r.quotas {
+		keys = append(keys, name)
+		keyInfo[name] = map[string]interface{}{
+			"path": quota.Path,
+			"role": quota.Role,
+		}
+	}
+	sort.Strings(keys)
+
+	return logical.ListResponseWithInfo(keys, keyInfo), nil
+}
+
+func (b *SystemBackend) handleRateLimitQuotaRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	quota := b.Core.rateLimitQuotas.quota(d.Get("name").(string))
+	if quota == nil {
+		return nil, nil
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"name":     quota.Name,
+			"path":     quota.Path,
+			"role":     quota.Role,
+			"rate":     quota.Rate,
+			"interval": int64(quota.Interval.Seconds()),
+			"burst":    quota.burst(),
+		},
+	}, nil
+}
+
+// handleRateLimitQuotaWrite creates or updates a rate limit quota. Updating a
+// quota resets the buckets of the clients it tracks.
+func (b *SystemBackend) handleRateLimitQuotaWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	b.quotaLock.Lock()
+	defer b.quotaLock.Unlock()
+
+	quota := &rateLimitQuota{
+		Name:     name,
+		Interval: time.Second,
+	}
+	if existing := b.Core.rateLimitQuotas.quota(name); existing != nil {
+		quota.Path = existing.Path
+		quota.Role = existing.Role
+		quota.Rate = existing.Rate
+		quota.Interval = existing.Interval
+		quota.Burst = existing.Burst
+	}
+
+	if raw, ok := d.GetOk("path"); ok {
+		path := strings.TrimPrefix(strings.TrimSpace(raw.(string)), "/")
+		if path != "" && !strings.HasSuffix(path, "/") {
+			path += "/"
+		}
+		quota.Path = path
+	}
+	if raw, ok := d.GetOk("role"); ok {
+		quota.Role = raw.(string)
+	}
+	if raw, ok := d.GetOk("rate"); ok {
+		quota.Rate = raw.(int)
+	}
+	if raw, ok := d.GetOk("interval"); ok {
+		quota.Interval = time.Duration(raw.(int)) * time.Second
+	}
+	if raw, ok := d.GetOk("burst"); ok {
+		quota.Burst = raw.(int)
+	}
+
+	switch {
+	case quota.Rate <= 0:
+		return logical.ErrorResponse("rate must be positive"), logical.ErrInvalidRequest
+	case quota.Interval <= 0:
+		return logical.ErrorResponse("interval must be positive"), logical.ErrInvalidRequest
+	case quota.Burst < 0:
+		return logical.ErrorResponse("burst must not be negative"), logical.ErrInvalidRequest
+	}
+
+	// Quota paths are relative to the root namespace, whichever namespace
+	// the request is made in
+	if quota.Path != "" {
+		rootCtx := namespace.ContextWithNamespace(ctx, namespace.RootNamespace)
+		entry := b.Core.router.MatchingMountEntry(rootCtx, quota.Path)
+		isMount := entry != nil && b.Core.router.MatchingMount(rootCtx, quota.Path) == quota.Path
+		if !isMount {
+			if ns := b.Core.namepaceByPath(quota.Path); ns == nil || ns.Path != quota.Path {
+				return logical.ErrorResponse(fmt.Sprintf("path %q is neither a mount nor a namespace", quota.Path)), logical.ErrInvalidRequest
+			}
+		}
+		if quota.Role != "" && (!isMount || entry.Table != credentialTableType) {
+			return logical.ErrorResponse("role can only be set on quotas applying to an auth mount"), logical.ErrInvalidRequest
+		}
+	} else if quota.Role != "" {
+		return logical.ErrorResponse("role can only be set on quotas applying to an auth mount"), logical.ErrInvalidRequest
+	}
+
+	if other := b.Core.rateLimitQuotas.conflicting(name, quota.Path, quota.Role); other != "" {
+		return logical.ErrorResponse(fmt.Sprintf("rate limit quota %q already applies to the same path and role", other)), logical.ErrInvalidRequest
+	}
+
+	entry, err := logical.StorageEntryJSON(rateLimitQuotaPrefix+name, quota)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+	b.Core.rateLimitQuotas.setQuota(quota)
+
+	return nil, nil
+}
+
+func (b *SystemBackend) handleRateLimitQuotaDelete(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	b.quotaLock.Lock()
+	defer b.quotaLock.Unlock()
+
+	if err := req.Storage.Delete(ctx, rateLimitQuotaPrefix+name); err != nil {
+		return nil, err
+	}
+	b.Core.rateLimitQuotas.deleteQuota(name)
+
+	return nil, nil
+}
+
+// handleLeaseCountQuotaList lists the lease count quotas along with their
+// paths
+func (b *SystemBackend) handleLeaseCountQuotaList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	r := b.Core.leaseCountQuotas
+	r.l.RLock()
+	defer r.l.RUnlock()
+
+	keys := make([]string, 0, len(r.quotas))
+	keyInfo := make(map[string]interface{}, len(r.quotas))
+	for name, quota := range r.quotas {
+		keys = append(keys, name)
+		keyInfo[name] = map[string]interface{}{
+			"path": quota.Path,
+		}
+	}
+	sort.Strings(keys)
+
+	return logical.ListResponseWithInfo(keys, keyInfo), nil
+}
+
+func (b *SystemBackend) handleLeaseCountQuotaRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	quota := b.Core.leaseCountQuotas.quota(d.Get("name").(string))
+	if quota == nil {
+		return nil, nil
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"name":       quota.Name,
+			"path":       quota.Path,
+			"max_leases": quota.MaxLeases,
+			"count":      atomic.LoadInt64(&quota.count),
+		},
+	}, nil
+}
+
+// handleLeaseCountQuotaWrite creates or updates a lease count quota. The
+// active leases under its path are counted again.
+func (b *SystemBackend) handleLeaseCountQuotaWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	b.quotaLock.Lock()
+	defer b.quotaLock.Unlock()
+
+	quota := &leaseCountQuota{
+		Name: name,
+	}
+	if existing := b.Core.leaseCountQuotas.quota(name); existing != nil {
+		quota.Path = existing.Path
+		quota.MaxLeases = existing.MaxLeases
+	}
+
+	if raw, ok := d.GetOk("path"); ok {
+		path := strings.TrimPrefix(strings.TrimSpace(raw.(string)), "/")
+		if path != "" && !strings.HasSuffix(path, "/") {
+			path += "/"
+		}
+		quota.Path = path
+	}
+	if raw, ok := d.GetOk("max_leases"); ok {
+		quota.MaxLeases = raw.(int)
+	}
+
+	if quota.MaxLeases <= 0 {
+		return logical.ErrorResponse("max_leases must be positive"), logical.ErrInvalidRequest
+	}
+
+	// Quota paths are relative to the root namespace, whichever namespace
+	// the request is made in
+	if quota.Path == "" {
+		return logical.ErrorResponse("path is required"), logical.ErrInvalidRequest
+	}
+	rootCtx := namespace.ContextWithNamespace(ctx, namespace.RootNamespace)
+	if b.Core.router.MatchingMount(rootCtx, quota.Path) == "" {
+		return logical.ErrorResponse(fmt.Sprintf("path %q is not within a mount", quota.Path)), logical.ErrInvalidRequest
+	}
+
+	if other := b.Core.leaseCountQuotas.conflicting(name, quota.Path); other != "" {
+		return logical.ErrorResponse(fmt.Sprintf("lease count quota %q already applies to the same path", other)), logical.ErrInvalidRequest
+	}
+
+	entry, err := logical.StorageEntryJSON(leaseCountQuotaPrefix+name, quota)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+	b.Core.setLeaseCountQuota(quota)
+
+	return nil, nil
+}
+
+func (b *SystemBackend) handleLeaseCountQuotaDelete(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	b.quotaLock.Lock()
+	defer b.quotaLock.Unlock()
+
+	if err := req.Storage.Delete(ctx, leaseCountQuotaPrefix+name); err != nil {
+		return nil, err
+	}
+	b.Core.leaseCountQuotas.deleteQuota(name)
+
+	return nil, nil
+}
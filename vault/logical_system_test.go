@@ -18,9 +18,11 @@ import (
 	"github.com/go-test/deep"
 	hclog "github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/vault/audit"
+	"github.com/hashicorp/vault/command/server"
 	"github.com/hashicorp/vault/helper/builtinplugins"
 	"github.com/hashicorp/vault/helper/consts"
 	"github.com/hashicorp/vault/helper/jsonutil"
+	"github.com/hashicorp/vault/helper/license"
 	"github.com/hashicorp/vault/helper/namespace"
 	"github.com/hashicorp/vault/helper/salt"
 	"github.com/hashicorp/vault/logical"
@@ -47,12 +49,19 @@ func TestSystemBackend_RootPaths(t *testing.T) {
 		"config/cors",
 		"config/auditing/*",
 		"config/ui/headers/*",
+		"config/state/*",
 		"plugins/catalog/*",
 		"revoke-prefix/*",
 		"revoke-force/*",
 		"leases/revoke-prefix/*",
 		"leases/revoke-force/*",
 		"leases/lookup/*",
+		"leases/irrevocable",
+		"leases/irrevocable/*",
+		"entity/*",
+		"usage/tokens",
+		"loggers",
+		"loggers/*",
 	}
 
 	b := testSystemBackend(t)
@@ -1736,6 +1745,47 @@ func TestSystemBackend_auditHash(t *testing.T) {
 	if hash.(string) != "hmac-sha256:f9320baf0249169e73850cd6156ded0106e2bb6ad8cab01b7bbbebe6d1065317" {
 		t.Fatalf("bad hash back: %s", hash.(string))
 	}
+
+	req = logical.TestRequest(t, logical.UpdateOperation, "audit-hash/foo")
+	req.Data["inputs"] = []string{"bar", "baz"}
+
+	resp, err = b.HandleRequest(namespace.RootContext(nil), req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp == nil || resp.Data == nil {
+		t.Fatalf("response or its data was nil")
+	}
+	hashes, ok := resp.Data["hashes"].([]string)
+	if !ok || len(hashes) != 2 {
+		t.Fatalf("did not get hashes back in response, response was %#v", resp.Data)
+	}
+	if hashes[0] != hash.(string) || hashes[1] == hashes[0] {
+		t.Fatalf("bad hashes back: %v", hashes)
+	}
+
+	req = logical.TestRequest(t, logical.UpdateOperation, "audit-hash/foo")
+	req.Data["input"] = "bar"
+	req.Data["inputs"] = []string{"baz"}
+
+	resp, err = b.HandleRequest(namespace.RootContext(nil), req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Data["error"] != `only one of "input" or "inputs" can be given` {
+		t.Fatalf("bad: %v", resp)
+	}
+
+	req = logical.TestRequest(t, logical.UpdateOperation, "audit-hash/foo")
+	req.Data["inputs"] = []string{"bar", ""}
+
+	resp, err = b.HandleRequest(namespace.RootContext(nil), req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Data["error"] != `input 1 of the "inputs" parameter is empty` {
+		t.Fatalf("bad: %v", resp)
+	}
 }
 
 func TestSystemBackend_enableAudit_invalid(t *testing.T) {
@@ -1966,6 +2016,87 @@ func TestSystemBackend_rotate(t *testing.T) {
 	}
 }
 
+func TestSystemBackend_entityTokens(t *testing.T) {
+	core, b, _ := testCoreSystemBackend(t)
+	ts := core.tokenStore
+
+	alice := &logical.TokenEntry{
+		ID:       "alice",
+		Path:     "auth/userpass/login/alice",
+		Policies: []string{"default"},
+		EntityID: "alice-entity",
+		TTL:      time.Hour,
+	}
+	testMakeTokenDirectly(t, ts, alice)
+	testMakeTokenDirectly(t, ts, &logical.TokenEntry{
+		ID:       "alice-child",
+		Parent:   "alice",
+		Path:     "auth/token/create",
+		Policies: []string{"default"},
+		TTL:      time.Hour,
+	})
+	testMakeTokenDirectly(t, ts, &logical.TokenEntry{
+		ID:       "bob",
+		Path:     "auth/userpass/login/bob",
+		Policies: []string{"default"},
+		EntityID: "bob-entity",
+		TTL:      time.Hour,
+	})
+
+	req := logical.TestRequest(t, logical.ListOperation, "entity/alice-entity/tokens")
+	resp, err := b.HandleRequest(namespace.RootContext(nil), req)
+	if err != nil || resp == nil || resp.IsError() {
+		t.Fatalf("err: %v, resp: %#v", err, resp)
+	}
+	if keys := resp.Data["keys"].([]string); len(keys) != 1 || keys[0] != alice.Accessor {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+	info := resp.Data["key_info"].(map[string]interface{})[alice.Accessor].(map[string]interface{})
+	if info["path"] != "auth/userpass/login/alice" {
+		t.Fatalf("bad: %#v", info)
+	}
+
+	req = logical.TestRequest(t, logical.UpdateOperation, "entity/alice-entity/tokens/revoke")
+	resp, err = b.HandleRequest(namespace.RootContext(nil), req)
+	if err != nil || resp == nil || resp.IsError() {
+		t.Fatalf("err: %v, resp: %#v", err, resp)
+	}
+	if revoked := resp.Data["revoked_accessors"].([]string); len(revoked) != 1 || revoked[0] != alice.Accessor {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	// The child token goes along with the entity token, the other entity's
+	// token is left alone
+	for id, expected := range map[string]bool{
+		"alice":       false,
+		"alice-child": false,
+		"bob":         true,
+	} {
+		te, err := ts.Lookup(namespace.RootContext(nil), id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if (te != nil) != expected {
+			t.Fatalf("expected token %q to exist: %t, got %#v", id, expected, te)
+		}
+	}
+
+	req = logical.TestRequest(t, logical.ListOperation, "entity/alice-entity/tokens")
+	resp, err = b.HandleRequest(namespace.RootContext(nil), req)
+	if err != nil || resp == nil || resp.IsError() {
+		t.Fatalf("err: %v, resp: %#v", err, resp)
+	}
+	if _, ok := resp.Data["keys"]; ok {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	// Revocation clears the entity index
+	keys, err := ts.entityView(namespace.RootNamespace).List(namespace.RootContext(nil), "alice-entity/")
+	if err != nil || len(keys) != 0 {
+		t.Fatalf("expected an empty entity index, got %v: %v", keys, err)
+	}
+}
+
 func testSystemBackend(t *testing.T) logical.Backend {
 	c, _, _ := TestCoreUnsealed(t)
 	return c.systemBackend
@@ -2552,3 +2683,132 @@ func TestSystemBackend_OpenAPI(t *testing.T) {
 		t.Fatalf("expected to find path '/rotate'")
 	}
 }
+
+func TestSystemBackend_ConfigStateSanitized(t *testing.T) {
+	core, b, _ := testCoreSystemBackend(t)
+
+	req := logical.TestRequest(t, logical.ReadOperation, "config/state/sanitized")
+	resp, err := b.HandleRequest(namespace.RootContext(nil), req)
+	if err != nil || resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error without a configuration: resp: %#v err: %v", resp, err)
+	}
+
+	core.SetConfig(&server.Config{
+		Storage: &server.Storage{
+			Type:   "consul",
+			Config: map[string]string{"token": "secret"},
+		},
+		ClusterName: "testcluster",
+	})
+	resp, err = b.HandleRequest(namespace.RootContext(nil), req)
+	if err != nil || resp == nil || resp.IsError() {
+		t.Fatalf("bad: resp: %#v err: %v", resp, err)
+	}
+	if resp.Data["cluster_name"] != "testcluster" {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+	expected := map[string]interface{}{
+		"type":                          "consul",
+		"redirect_addr":                 "",
+		"cluster_addr":                  "",
+		"disable_clustering":            false,
+		"request_timeout":               int64(0),
+		"circuit_breaker_threshold":     0,
+		"circuit_breaker_reset_timeout": int64(0),
+	}
+	if !reflect.DeepEqual(resp.Data["storage"], expected) {
+		t.Fatalf("bad storage: %#v", resp.Data["storage"])
+	}
+}
+
+func TestSystemBackend_Loggers(t *testing.T) {
+	_, b, _ := testCoreSystemBackend(t)
+
+	request := func(op logical.Operation, path string, data map[string]interface{}) (*logical.Response, error) {
+		req := logical.TestRequest(t, op, path)
+		req.Data = data
+		return b.HandleRequest(namespace.RootContext(nil), req)
+	}
+	readLevels := func(path string) map[string]interface{} {
+		t.Helper()
+		resp, err := request(logical.ReadOperation, path, nil)
+		if err != nil || resp == nil || resp.IsError() {
+			t.Fatalf("bad: resp: %#v err: %v", resp, err)
+		}
+		return resp.Data
+	}
+
+	levels := readLevels("loggers")
+	for _, name := range []string{"core", "expiration", "policy", "system"} {
+		if levels[name] != "trace" {
+			t.Fatalf("expected %q to log at trace level, got %#v", name, levels)
+		}
+	}
+
+	// Setting the level of a subsystem applies to the ones below it
+	if resp, err := request(logical.UpdateOperation, "loggers/secrets", map[string]interface{}{"level": "warn"}); err != nil || resp != nil {
+		t.Fatalf("bad: resp: %#v err: %v", resp, err)
+	}
+	secrets := readLevels("loggers/secrets")
+	if len(secrets) == 0 {
+		t.Fatal("expected loggers of secrets engines")
+	}
+	for name, level := range secrets {
+		if !strings.HasPrefix(name, "secrets.") || level != "warn" {
+			t.Fatalf("bad level of %q: %v", name, level)
+		}
+	}
+	if levels := readLevels("loggers/expiration"); !reflect.DeepEqual(levels, map[string]interface{}{"expiration": "trace"}) {
+		t.Fatalf("bad levels: %#v", levels)
+	}
+
+	for path, data := range map[string]map[string]interface{}{
+		"loggers/unknown": {"level": "debug"},
+		"loggers/core":    {"level": "verbose"},
+		"loggers":         {},
+	} {
+		resp, err := request(logical.UpdateOperation, path, data)
+		if resp == nil || !resp.IsError() {
+			t.Fatalf("expected an error writing %q with %v: resp: %#v err: %v", path, data, resp, err)
+		}
+	}
+	if resp, err := request(logical.ReadOperation, "loggers/unknown", nil); err != logical.ErrInvalidRequest {
+		t.Fatalf("expected an error reading an unknown logger: resp: %#v err: %v", resp, err)
+	}
+
+	// Setting the level of all subsystems overrides the ones set before
+	if resp, err := request(logical.UpdateOperation, "loggers", map[string]interface{}{"level": "error"}); err != nil || resp != nil {
+		t.Fatalf("bad: resp: %#v err: %v", resp, err)
+	}
+	for name, level := range readLevels("loggers") {
+		if level != "error" {
+			t.Fatalf("bad level of %q: %v", name, level)
+		}
+	}
+}
+
+func TestSystemBackend_LicenseStatus(t *testing.T) {
+	_, b, _ := testCoreSystemBackend(t)
+
+	req := logical.TestRequest(t, logical.ReadOperation, "license/status")
+	resp, err := b.HandleRequest(namespace.RootContext(nil), req)
+	if err != nil || resp == nil || resp.IsError() {
+		t.Fatalf("bad: resp: %#v err: %v", resp, err)
+	}
+
+	if resp.Data["version"] != version.GetVersion().VersionNumber() {
+		t.Fatalf("unexpected version: %#v", resp.Data["version"])
+	}
+	if features := resp.Data["features"].([]string); len(features) != 0 {
+		t.Fatalf("expected no feature modules, got %v", features)
+	}
+	modules := resp.Data["modules"].(map[string]bool)
+	if len(modules) != len(license.KnownModules) {
+		t.Fatalf("unexpected modules: %v", modules)
+	}
+	for _, module := range []string{license.ModuleReplication, license.ModuleNamespaces, license.ModuleHSM} {
+		if enabled, ok := modules[module]; !ok || enabled {
+			t.Fatalf("expected module %q to be reported as not compiled, got %v", module, modules)
+		}
+	}
+}
@@ -0,0 +1,752 @@
+package vault
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	duoapi "github.com/duosecurity/duo_api_golang"
+	"github.com/duosecurity/duo_api_golang/authapi"
+	"github.com/hashicorp/errwrap"
+	cleanhttp "github.com/hashicorp/go-cleanhttp"
+	"github.com/hashicorp/vault/helper/identity"
+	"github.com/hashicorp/vault/helper/mfa/duo"
+	"github.com/hashicorp/vault/helper/strutil"
+	"github.com/hashicorp/vault/helper/useragent"
+	"github.com/hashicorp/vault/logical"
+	cache "github.com/patrickmn/go-cache"
+	otplib "github.com/pquerna/otp"
+	totplib "github.com/pquerna/otp/totp"
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+const (
+	mfaMethodTypeTOTP   = "totp"
+	mfaMethodTypeDuo    = "duo"
+	mfaMethodTypePingID = "pingid"
+
+	// mfaMethodPrefix, mfaEnforcementPrefix and mfaTOTPSecretPrefix are the
+	// storage prefixes of the MFA methods, the login enforcements and the
+	// TOTP secrets of the entities, relative to the system barrier view
+	mfaMethodPrefix      = "mfa/method/"
+	mfaEnforcementPrefix = "mfa/login-enforcement/"
+	mfaTOTPSecretPrefix  = "mfa/totp-secret/"
+
+	// pingIDRequestTimeout bounds how long a login waits for the user to
+	// approve the PingID request
+	pingIDRequestTimeout = 2 * time.Minute
+)
+
+// mfaMethod is a second factor login enforcements can require. Exactly one of
+// the type specific configurations is set, according to Type.
+type mfaMethod struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+
+	// MountAccessor is the auth mount the aliases of which name the Duo and
+	// PingID users, unless UsernameFormat maps them otherwise
+	MountAccessor  string `json:"mount_accessor,omitempty"`
+	UsernameFormat string `json:"username_format,omitempty"`
+
+	TOTP   *totpMFAConfig   `json:"totp,omitempty"`
+	Duo    *duoMFAConfig    `json:"duo,omitempty"`
+	PingID *pingIDMFAConfig `json:"pingid,omitempty"`
+}
+
+// clone returns a copy of the method, so that updates don't modify the
+// method logins are validated against before they are persisted
+func (m *mfaMethod) clone() *mfaMethod {
+	cloned := *m
+	if m.TOTP != nil {
+		totp := *m.TOTP
+		cloned.TOTP = &totp
+	}
+	if m.Duo != nil {
+		duo := *m.Duo
+		cloned.Duo = &duo
+	}
+	if m.PingID != nil {
+		pingID := *m.PingID
+		cloned.PingID = &pingID
+	}
+	return &cloned
+}
+
+type totpMFAConfig struct {
+	Issuer    string `json:"issuer"`
+	Period    uint   `json:"period"`
+	KeySize   uint   `json:"key_size"`
+	Algorithm string `json:"algorithm"`
+	Digits    int    `json:"digits"`
+	Skew      uint   `json:"skew"`
+	QRSize    int    `json:"qr_size"`
+}
+
+type duoMFAConfig struct {
+	IntegrationKey string `json:"integration_key"`
+	SecretKey      string `json:"secret_key"`
+	APIHostname    string `json:"api_hostname"`
+	PushInfo       string `json:"push_info"`
+	UsePasscode    bool   `json:"use_passcode"`
+}
+
+type pingIDMFAConfig struct {
+	UseBase64Key     string `json:"use_base64_key"`
+	UseSignature     bool   `json:"use_signature"`
+	Token            string `json:"token"`
+	IDPURL           string `json:"idp_url"`
+	OrgAlias         string `json:"org_alias"`
+	AdminURL         string `json:"admin_url"`
+	AuthenticatorURL string `json:"authenticator_url"`
+}
+
+// mfaLoginEnforcement requires the logins it targets to validate one of its
+// MFA methods. Logins are targeted by the accessor or type of the auth mount
+// they are made against, or by the entity they resolve to, directly or
+// through one of its groups.
+type mfaLoginEnforcement struct {
+	Name                string   `json:"name"`
+	MFAMethodNames      []string `json:"mfa_method_names"`
+	AuthMethodAccessors []string `json:"auth_method_accessors"`
+	AuthMethodTypes     []string `json:"auth_method_types"`
+	IdentityEntityIDs   []string `json:"identity_entity_ids"`
+	IdentityGroupIDs    []string `json:"identity_group_ids"`
+}
+
+func (e *mfaLoginEnforcement) clone() *mfaLoginEnforcement {
+	cloned := *e
+	return &cloned
+}
+
+// totpSecret is the TOTP secret generated for an entity. The parameters of
+// the method it was generated with are kept along with it, so that changing
+// them doesn't invalidate the secrets the entities already enrolled.
+type totpSecret struct {
+	Secret    string `json:"secret"`
+	Period    uint   `json:"period"`
+	Algorithm string `json:"algorithm"`
+	Digits    int    `json:"digits"`
+}
+
+// loginMFA holds the MFA methods and login enforcements, which are loaded in
+// memory so that logins don't read them from storage
+type loginMFA struct {
+	l            sync.RWMutex
+	methods      map[string]*mfaMethod
+	enforcements map[string]*mfaLoginEnforcement
+
+	// usedCodes holds the TOTP passcodes validated within their validity
+	// window, so that they can't be replayed
+	usedCodes *cache.Cache
+}
+
+func newLoginMFA() *loginMFA {
+	return &loginMFA{
+		methods:      make(map[string]*mfaMethod),
+		enforcements: make(map[string]*mfaLoginEnforcement),
+		usedCodes:    cache.New(5*time.Minute, time.Minute),
+	}
+}
+
+func (m *loginMFA) method(name string) *mfaMethod {
+	m.l.RLock()
+	defer m.l.RUnlock()
+	return m.methods[name]
+}
+
+func (m *loginMFA) setMethod(method *mfaMethod) {
+	m.l.Lock()
+	defer m.l.Unlock()
+	m.methods[method.Name] = method
+}
+
+func (m *loginMFA) deleteMethod(name string) {
+	m.l.Lock()
+	defer m.l.Unlock()
+	delete(m.methods, name)
+}
+
+func (m *loginMFA) enforcement(name string) *mfaLoginEnforcement {
+	m.l.RLock()
+	defer m.l.RUnlock()
+	return m.enforcements[name]
+}
+
+func (m *loginMFA) setEnforcement(enforcement *mfaLoginEnforcement) {
+	m.l.Lock()
+	defer m.l.Unlock()
+	m.enforcements[enforcement.Name] = enforcement
+}
+
+func (m *loginMFA) deleteEnforcement(name string) {
+	m.l.Lock()
+	defer m.l.Unlock()
+	delete(m.enforcements, name)
+}
+
+// enforcementsUsing returns the names of the login enforcements requiring
+// the MFA method
+func (m *loginMFA) enforcementsUsing(methodName string) []string {
+	m.l.RLock()
+	defer m.l.RUnlock()
+
+	var names []string
+	for _, enforcement := range m.enforcements {
+		if strutil.StrListContains(enforcement.MFAMethodNames, methodName) {
+			names = append(names, enforcement.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// loadLoginMFA loads the MFA methods and login enforcements from storage
+func (c *Core) loadLoginMFA(ctx context.Context) error {
+	m := newLoginMFA()
+
+	methods, err := c.systemBarrierView.List(ctx, mfaMethodPrefix)
+	if err != nil {
+		return errwrap.Wrapf("failed to list MFA methods: {{err}}", err)
+	}
+	for _, name := range methods {
+		var method mfaMethod
+		if err := loadJSONEntry(ctx, c.systemBarrierView, mfaMethodPrefix+name, &method); err != nil {
+			return errwrap.Wrapf(fmt.Sprintf("failed to load MFA method %q: {{err}}", name), err)
+		}
+		m.methods[method.Name] = &method
+	}
+
+	enforcements, err := c.systemBarrierView.List(ctx, mfaEnforcementPrefix)
+	if err != nil {
+		return errwrap.Wrapf("failed to list login MFA enforcements: {{err}}", err)
+	}
+	for _, name := range enforcements {
+		var enforcement mfaLoginEnforcement
+		if err := loadJSONEntry(ctx, c.systemBarrierView, mfaEnforcementPrefix+name, &enforcement); err != nil {
+			return errwrap.Wrapf(fmt.Sprintf("failed to load login MFA enforcement %q: {{err}}", name), err)
+		}
+		m.enforcements[enforcement.Name] = &enforcement
+	}
+
+	c.loginMFA = m
+	return nil
+}
+
+func loadJSONEntry(ctx context.Context, s logical.Storage, key string, out interface{}) error {
+	entry, err := s.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	if entry == nil {
+		return fmt.Errorf("missing entry")
+	}
+	return entry.DecodeJSON(out)
+}
+
+// enforceLoginMFA validates the MFA credentials of a login request, given in
+// the X-Vault-MFA header, against each login enforcement targeting the login.
+// Every enforcement requires one of its methods to be validated; a method
+// validated for an enforcement satisfies the other ones requiring it too.
+func (c *Core) enforceLoginMFA(ctx context.Context, req *logical.Request, entity *identity.Entity) (userErr error, intErr error) {
+	m := c.loginMFA
+	if m == nil {
+		return nil, nil
+	}
+
+	m.l.RLock()
+	enforcements := make([]*mfaLoginEnforcement, 0, len(m.enforcements))
+	for _, enforcement := range m.enforcements {
+		enforcements = append(enforcements, enforcement)
+	}
+	m.l.RUnlock()
+	if len(enforcements) == 0 {
+		return nil, nil
+	}
+	sort.Slice(enforcements, func(i, j int) bool {
+		return enforcements[i].Name < enforcements[j].Name
+	})
+
+	var groups []*identity.Group
+	if entity != nil && c.identityStore != nil {
+		directGroups, inheritedGroups, err := c.identityStore.groupsByEntityID(entity.ID)
+		if err != nil {
+			return nil, err
+		}
+		groups = append(directGroups, inheritedGroups...)
+	}
+
+	validated := make(map[string]error)
+	for _, enforcement := range enforcements {
+		if !enforcement.targets(req, entity, groups) {
+			continue
+		}
+
+		var errs []string
+		satisfied := false
+		for _, name := range enforcement.MFAMethodNames {
+			creds, ok := req.MFACreds[name]
+			if !ok {
+				continue
+			}
+
+			err, done := validated[name]
+			if !done {
+				method := m.method(name)
+				if method == nil {
+					continue
+				}
+				err, intErr = c.validateMFAMethod(ctx, req, method, entity, creds)
+				if intErr != nil {
+					return nil, intErr
+				}
+				validated[name] = err
+			}
+			if err == nil {
+				satisfied = true
+				break
+			}
+			errs = append(errs, fmt.Sprintf("%s: %s", name, err))
+		}
+
+		switch {
+		case satisfied:
+		case len(errs) == 0:
+			return fmt.Errorf("login MFA enforcement %q requires validating one of the MFA methods %s", enforcement.Name, strings.Join(enforcement.MFAMethodNames, ", ")), nil
+		default:
+			return fmt.Errorf("MFA validation failed for login MFA enforcement %q: %s", enforcement.Name, strings.Join(errs, "; ")), nil
+		}
+	}
+
+	return nil, nil
+}
+
+// targets returns whether the enforcement applies to the login
+func (e *mfaLoginEnforcement) targets(req *logical.Request, entity *identity.Entity, groups []*identity.Group) bool {
+	if strutil.StrListContains(e.AuthMethodAccessors, req.MountAccessor) ||
+		strutil.StrListContains(e.AuthMethodTypes, req.MountType) {
+		return true
+	}
+	if entity == nil {
+		return false
+	}
+	if strutil.StrListContains(e.IdentityEntityIDs, entity.ID) {
+		return true
+	}
+	for _, group := range groups {
+		if strutil.StrListContains(e.IdentityGroupIDs, group.ID) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateMFAMethod validates the credentials supplied for the method
+func (c *Core) validateMFAMethod(ctx context.Context, req *logical.Request, method *mfaMethod, entity *identity.Entity, creds []string) (userErr error, intErr error) {
+	var passcode string
+	if len(creds) > 0 {
+		passcode = creds[0]
+	}
+
+	switch method.Type {
+	case mfaMethodTypeTOTP:
+		if entity == nil {
+			return fmt.Errorf("TOTP requires the login to resolve to an entity"), nil
+		}
+		return c.validateTOTP(ctx, method, entity.ID, passcode)
+
+	case mfaMethodTypeDuo, mfaMethodTypePingID:
+		username, err := mfaUsername(method, entity)
+		if err != nil {
+			return err, nil
+		}
+
+		var remoteAddr string
+		if req.Connection != nil {
+			remoteAddr = req.Connection.RemoteAddr
+		}
+		if method.Type == mfaMethodTypeDuo {
+			return validateDuo(method.Duo, username, passcode, remoteAddr), nil
+		}
+		return validatePingID(method.PingID, username, remoteAddr), nil
+
+	default:
+		return nil, fmt.Errorf("unknown MFA method type %q", method.Type)
+	}
+}
+
+// mfaUsername returns the username the method verifies the entity as. The
+// username is the name of the alias of the entity on the mount of the method,
+// unless the username format of the method maps it from the alias and the
+// entity otherwise.
+func mfaUsername(method *mfaMethod, entity *identity.Entity) (string, error) {
+	if entity == nil {
+		return "", fmt.Errorf("the login must resolve to an entity")
+	}
+
+	var alias *identity.Alias
+	for _, a := range entity.Aliases {
+		if a.MountAccessor == method.MountAccessor {
+			alias = a
+			break
+		}
+	}
+	if alias == nil {
+		return "", fmt.Errorf("the entity has no alias on the mount of the MFA method")
+	}
+
+	if method.UsernameFormat == "" {
+		return alias.Name, nil
+	}
+	return formatMFAUsername(method.UsernameFormat, alias, entity)
+}
+
+// formatMFAUsername substitutes the {{alias.name}}, {{entity.name}},
+// {{alias.metadata.<key>}} and {{entity.metadata.<key>}} directives of the
+// format. With a nil alias and entity, it only checks the format is valid.
+func formatMFAUsername(format string, alias *identity.Alias, entity *identity.Entity) (string, error) {
+	var b strings.Builder
+	rest := format
+	for {
+		start := strings.Index(rest, "{{")
+		if start == -1 {
+			if strings.Contains(rest, "}}") {
+				return "", fmt.Errorf("unbalanced templating characters")
+			}
+			b.WriteString(rest)
+			break
+		}
+		end := strings.Index(rest[start:], "}}")
+		if end == -1 || strings.Contains(rest[:start], "}}") {
+			return "", fmt.Errorf("unbalanced templating characters")
+		}
+		b.WriteString(rest[:start])
+		directive := strings.TrimSpace(rest[start+2 : start+end])
+		rest = rest[start+end+2:]
+
+		var value string
+		var found bool
+		switch {
+		case directive == "alias.name":
+			found = true
+			if alias != nil {
+				value = alias.Name
+			}
+		case directive == "entity.name":
+			found = true
+			if entity != nil {
+				value = entity.Name
+			}
+		case strings.HasPrefix(directive, "alias.metadata.") && len(directive) > len("alias.metadata."):
+			found = true
+			if alias != nil {
+				value, found = alias.Metadata[strings.TrimPrefix(directive, "alias.metadata.")]
+			}
+		case strings.HasPrefix(directive, "entity.metadata.") && len(directive) > len("entity.metadata."):
+			found = true
+			if entity != nil {
+				value, found = entity.Metadata[strings.TrimPrefix(directive, "entity.metadata.")]
+			}
+		default:
+			return "", fmt.Errorf("unknown directive %q", directive)
+		}
+		if !found {
+			return "", fmt.Errorf("no value found for the directive %q", directive)
+		}
+		b.WriteString(value)
+	}
+	return b.String(), nil
+}
+
+func totpAlgorithm(name string) (otplib.Algorithm, error) {
+	switch strings.ToUpper(name) {
+	case "SHA1":
+		return otplib.AlgorithmSHA1, nil
+	case "SHA256":
+		return otplib.AlgorithmSHA256, nil
+	case "SHA512":
+		return otplib.AlgorithmSHA512, nil
+	default:
+		return 0, fmt.Errorf("unknown algorithm %q", name)
+	}
+}
+
+func totpSecretPath(methodName, entityID string) string {
+	return mfaTOTPSecretPrefix + methodName + "/" + entityID
+}
+
+// validateTOTP validates the passcode against the TOTP secret of the entity.
+// Validated passcodes are remembered until they expire, so that they can't
+// be replayed.
+func (c *Core) validateTOTP(ctx context.Context, method *mfaMethod, entityID, passcode string) (userErr error, intErr error) {
+	if passcode == "" {
+		return fmt.Errorf("missing TOTP passcode"), nil
+	}
+
+	entry, err := c.systemBarrierView.Get(ctx, totpSecretPath(method.Name, entityID))
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return fmt.Errorf("no TOTP secret was generated for the entity"), nil
+	}
+	var secret totpSecret
+	if err := entry.DecodeJSON(&secret); err != nil {
+		return nil, err
+	}
+
+	algorithm, err := totpAlgorithm(secret.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	usedKey := method.Name + "/" + entityID + "/" + passcode
+	if _, ok := c.loginMFA.usedCodes.Get(usedKey); ok {
+		return fmt.Errorf("TOTP passcode already used"), nil
+	}
+
+	valid, err := totplib.ValidateCustom(passcode, secret.Secret, time.Now(), totplib.ValidateOpts{
+		Period:    secret.Period,
+		Skew:      method.TOTP.Skew,
+		Digits:    otplib.Digits(secret.Digits),
+		Algorithm: algorithm,
+	})
+	if err != nil && err != otplib.ErrValidateInputInvalidLength {
+		return nil, err
+	}
+	if !valid {
+		return fmt.Errorf("invalid TOTP passcode"), nil
+	}
+
+	// A passcode is valid during its period and the skew on either side of it
+	validity := time.Duration(secret.Period*(2*method.TOTP.Skew+1)) * time.Second
+	c.loginMFA.usedCodes.Set(usedKey, struct{}{}, validity)
+	return nil, nil
+}
+
+// newDuoAuthClient returns the client of the Duo Auth API of a method. It is
+// a variable so that tests can mock Duo.
+var newDuoAuthClient = func(config *duoMFAConfig) duo.AuthClient {
+	client := duoapi.NewDuoApi(config.IntegrationKey, config.SecretKey, config.APIHostname, useragent.String())
+	return authapi.NewAuthApi(*client)
+}
+
+// validateDuo verifies the user with Duo, either through the passcode or, if
+// none is given and the method allows it, through a push to their device
+func validateDuo(config *duoMFAConfig, username, passcode, remoteAddr string) error {
+	if passcode == "" && config.UsePasscode {
+		return fmt.Errorf("missing Duo passcode")
+	}
+
+	client := newDuoAuthClient(config)
+
+	preauth, err := client.Preauth(
+		authapi.PreauthUsername(username),
+		authapi.PreauthIpAddr(remoteAddr),
+	)
+	if err != nil || preauth == nil {
+		return fmt.Errorf("failed to call Duo preauth")
+	}
+	if preauth.StatResult.Stat != "OK" {
+		return duoStatError("failed to look up the Duo user", preauth.StatResult)
+	}
+
+	switch preauth.Response.Result {
+	case "allow":
+		return nil
+	case "deny":
+		return errors.New(preauth.Response.Status_Msg)
+	case "enroll":
+		return fmt.Errorf("%s (%s)", preauth.Response.Status_Msg, preauth.Response.Enroll_Portal_Url)
+	case "auth":
+	default:
+		return fmt.Errorf("invalid Duo preauth response %q", preauth.Response.Result)
+	}
+
+	factor := "push"
+	options := []func(*url.Values){authapi.AuthUsername(username)}
+	if passcode != "" {
+		factor = "passcode"
+		options = append(options, authapi.AuthPasscode(passcode))
+	} else {
+		options = append(options, authapi.AuthDevice("auto"))
+		if config.PushInfo != "" {
+			options = append(options, authapi.AuthPushinfo(config.PushInfo))
+		}
+	}
+
+	result, err := client.Auth(factor, options...)
+	if err != nil || result == nil {
+		return fmt.Errorf("failed to call Duo auth")
+	}
+	if result.StatResult.Stat != "OK" {
+		return duoStatError("failed to authenticate the Duo user", result.StatResult)
+	}
+	if result.Response.Result != "allow" {
+		return errors.New(result.Response.Status_Msg)
+	}
+	return nil
+}
+
+func duoStatError(msg string, stat authapi.StatResult) error {
+	if stat.Message != nil {
+		msg = msg + ": " + *stat.Message
+	}
+	if stat.Message_Detail != nil {
+		msg = msg + " (" + *stat.Message_Detail + ")"
+	}
+	return errors.New(msg)
+}
+
+// parsePingIDSettings parses the properties file PingID provides for
+// integrations, base64 encoded
+func parsePingIDSettings(settingsFileBase64 string) (*pingIDMFAConfig, error) {
+	settings, err := base64.StdEncoding.DecodeString(settingsFileBase64)
+	if err != nil {
+		return nil, errwrap.Wrapf("failed to decode the settings file: {{err}}", err)
+	}
+
+	config := &pingIDMFAConfig{}
+	scanner := bufio.NewScanner(bytes.NewReader(settings))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid line %q in the settings file", line)
+		}
+		key := strings.TrimSpace(kv[0])
+		value := strings.Replace(strings.TrimSpace(kv[1]), `\:`, ":", -1)
+
+		switch key {
+		case "use_base64_key":
+			config.UseBase64Key = value
+		case "use_signature":
+			config.UseSignature, err = strconv.ParseBool(value)
+			if err != nil {
+				return nil, errwrap.Wrapf("invalid use_signature in the settings file: {{err}}", err)
+			}
+		case "token":
+			config.Token = value
+		case "idp_url":
+			config.IDPURL = value
+		case "org_alias":
+			config.OrgAlias = value
+		case "admin_url":
+			config.AdminURL = value
+		case "authenticator_url":
+			config.AuthenticatorURL = value
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case config.UseBase64Key == "":
+		return nil, fmt.Errorf("use_base64_key is missing from the settings file")
+	case config.Token == "":
+		return nil, fmt.Errorf("token is missing from the settings file")
+	case config.IDPURL == "":
+		return nil, fmt.Errorf("idp_url is missing from the settings file")
+	case config.OrgAlias == "":
+		return nil, fmt.Errorf("org_alias is missing from the settings file")
+	}
+	if _, err := base64.StdEncoding.DecodeString(config.UseBase64Key); err != nil {
+		return nil, errwrap.Wrapf("invalid use_base64_key in the settings file: {{err}}", err)
+	}
+	return config, nil
+}
+
+// validatePingID asks PingID to authenticate the user online, which returns
+// once the user approved or denied the request on their device
+func validatePingID(config *pingIDMFAConfig, username, remoteAddr string) error {
+	key, err := base64.StdEncoding.DecodeString(config.UseBase64Key)
+	if err != nil {
+		return err
+	}
+
+	request := map[string]interface{}{
+		"reqHeader": map[string]interface{}{
+			"locale":    "en",
+			"orgAlias":  config.OrgAlias,
+			"secretKey": config.Token,
+			"timestamp": time.Now().UTC().Format("2006-01-02 15:04:05.000"),
+			"version":   "4.9",
+		},
+		"reqBody": map[string]interface{}{
+			"spAlias":  "web",
+			"userName": username,
+			"clientData": []map[string]string{
+				{"name": "ipAddress", "value": remoteAddr},
+			},
+		},
+	}
+	payload, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.HS256, Key: key}, (&jose.SignerOptions{}).
+		WithHeader("org_alias", config.OrgAlias).
+		WithHeader("token", config.Token))
+	if err != nil {
+		return err
+	}
+	signed, err := signer.Sign(payload)
+	if err != nil {
+		return err
+	}
+	body, err := signed.CompactSerialize()
+	if err != nil {
+		return err
+	}
+
+	client := cleanhttp.DefaultClient()
+	client.Timeout = pingIDRequestTimeout
+	resp, err := client.Post(strings.TrimSuffix(config.IDPURL, "/")+"/rest/4/authonline/do", "application/json", strings.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to call PingID")
+	}
+	defer resp.Body.Close()
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read the PingID response")
+	}
+
+	parsed, err := jose.ParseSigned(string(respBody))
+	if err != nil {
+		return fmt.Errorf("failed to parse the PingID response (status %d)", resp.StatusCode)
+	}
+	respPayload, err := parsed.Verify(key)
+	if err != nil {
+		return fmt.Errorf("failed to verify the signature of the PingID response")
+	}
+
+	var result struct {
+		ResponseBody struct {
+			ErrorID  int    `json:"errorId"`
+			ErrorMsg string `json:"errorMsg"`
+		} `json:"responseBody"`
+	}
+	if err := json.Unmarshal(respPayload, &result); err != nil {
+		return fmt.Errorf("failed to decode the PingID response")
+	}
+	if resp.StatusCode != http.StatusOK || result.ResponseBody.ErrorID != 200 {
+		return fmt.Errorf("PingID authentication failed: %s", result.ResponseBody.ErrorMsg)
+	}
+	return nil
+}
@@ -0,0 +1,444 @@
+package vault
+
+import (
+	"encoding/base64"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/duosecurity/duo_api_golang/authapi"
+	credUserpass "github.com/hashicorp/vault/builtin/credential/userpass"
+	"github.com/hashicorp/vault/helper/identity"
+	"github.com/hashicorp/vault/helper/mfa/duo"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/logical"
+	otplib "github.com/pquerna/otp"
+	totplib "github.com/pquerna/otp/totp"
+)
+
+func TestLoginMFA_TOTP(t *testing.T) {
+	core, _, root := TestCoreUnsealed(t)
+	core.credentialBackends["userpass"] = credUserpass.Factory
+
+	request := func(operation logical.Operation, path, token string, data map[string]interface{}, mfaCreds logical.MFACreds) (*logical.Response, error) {
+		t.Helper()
+		return core.HandleRequest(namespace.RootContext(nil), &logical.Request{
+			Operation:   operation,
+			Path:        path,
+			ClientToken: token,
+			Data:        data,
+			MFACreds:    mfaCreds,
+			Connection:  &logical.Connection{},
+		})
+	}
+	mustRequest := func(operation logical.Operation, path, token string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := request(operation, path, token, data, nil)
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("bad: %s: resp: %#v, err: %v", path, resp, err)
+		}
+		return resp
+	}
+	login := func(mfaCreds logical.MFACreds) (*logical.Response, error) {
+		t.Helper()
+		return request(logical.UpdateOperation, "auth/userpass/login/test", "", map[string]interface{}{
+			"password": "foo",
+		}, mfaCreds)
+	}
+
+	mustRequest(logical.UpdateOperation, "sys/auth/userpass", root, map[string]interface{}{"type": "userpass"})
+	mustRequest(logical.UpdateOperation, "auth/userpass/users/test", root, map[string]interface{}{"password": "foo"})
+
+	// The first login creates the entity of the user
+	resp, err := login(nil)
+	if err != nil || resp == nil || resp.Auth == nil {
+		t.Fatalf("bad: resp: %#v, err: %v", resp, err)
+	}
+	entityID := resp.Auth.EntityID
+
+	mustRequest(logical.UpdateOperation, "sys/mfa/method/totp/my_totp", root, map[string]interface{}{
+		"issuer": "Vault",
+	})
+	resp = mustRequest(logical.ReadOperation, "sys/mfa/method/totp/my_totp", root, nil)
+	if resp.Data["period"] != uint(30) || resp.Data["digits"] != 6 || resp.Data["algorithm"] != "SHA1" {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	// Method names are unique across types
+	resp, _ = request(logical.UpdateOperation, "sys/mfa/method/duo/my_totp", root, map[string]interface{}{
+		"mount_accessor":  core.router.MatchingMountEntry(namespace.RootContext(nil), "auth/userpass/").Accessor,
+		"integration_key": "ikey",
+		"secret_key":      "skey",
+		"api_hostname":    "api.duosecurity.com",
+	}, nil)
+	if resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error, got: %#v", resp)
+	}
+
+	resp = mustRequest(logical.UpdateOperation, "sys/mfa/method/totp/my_totp/admin-generate", root, map[string]interface{}{
+		"entity_id": entityID,
+	})
+	if resp.Data["barcode"] == "" {
+		t.Fatalf("expected a barcode, got: %#v", resp.Data)
+	}
+	key, err := otplib.NewKeyFromURL(resp.Data["url"].(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mustRequest(logical.UpdateOperation, "sys/mfa/login-enforcement/userpass", root, map[string]interface{}{
+		"mfa_method_names":  "my_totp",
+		"auth_method_types": "userpass",
+	})
+
+	// Logins have to supply a valid passcode, which can't be replayed
+	resp, err = login(nil)
+	if err != logical.ErrPermissionDenied || resp == nil || !strings.Contains(resp.Error().Error(), "requires validating one of the MFA methods my_totp") {
+		t.Fatalf("bad: resp: %#v, err: %v", resp, err)
+	}
+	resp, err = login(logical.MFACreds{"my_totp": []string{"000000"}})
+	if err != logical.ErrPermissionDenied || !strings.Contains(resp.Error().Error(), "invalid TOTP passcode") {
+		t.Fatalf("bad: resp: %#v, err: %v", resp, err)
+	}
+
+	code, err := totplib.GenerateCode(key.Secret(), time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err = login(logical.MFACreds{"my_totp": []string{code}})
+	if err != nil || resp == nil || resp.Auth == nil {
+		t.Fatalf("bad: resp: %#v, err: %v", resp, err)
+	}
+	resp, err = login(logical.MFACreds{"my_totp": []string{code}})
+	if err != logical.ErrPermissionDenied || !strings.Contains(resp.Error().Error(), "already used") {
+		t.Fatalf("bad: resp: %#v, err: %v", resp, err)
+	}
+
+	// Methods required by enforcements can't be deleted
+	resp, _ = request(logical.DeleteOperation, "sys/mfa/method/totp/my_totp", root, nil, nil)
+	if resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error, got: %#v", resp)
+	}
+
+	// Enforcements which don't target the login don't apply
+	mustRequest(logical.UpdateOperation, "sys/mfa/login-enforcement/userpass", root, map[string]interface{}{
+		"auth_method_types": "ldap",
+	})
+	if resp, err := login(nil); err != nil || resp == nil || resp.Auth == nil {
+		t.Fatalf("bad: resp: %#v, err: %v", resp, err)
+	}
+	mustRequest(logical.UpdateOperation, "sys/mfa/login-enforcement/userpass", root, map[string]interface{}{
+		"identity_entity_ids": entityID,
+	})
+	if _, err := login(nil); err != logical.ErrPermissionDenied {
+		t.Fatalf("expected the enforcement to apply to the entity, got: %v", err)
+	}
+
+	// The configuration is loaded again when the core is unsealed
+	if err := core.loadLoginMFA(namespace.RootContext(nil)); err != nil {
+		t.Fatal(err)
+	}
+	resp = mustRequest(logical.ListOperation, "sys/mfa/login-enforcement", root, nil)
+	if keys := resp.Data["keys"].([]string); len(keys) != 1 || keys[0] != "userpass" {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+	resp = mustRequest(logical.ReadOperation, "sys/mfa/login-enforcement/userpass", root, nil)
+	if methods := resp.Data["mfa_method_names"].([]string); len(methods) != 1 || methods[0] != "my_totp" {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	mustRequest(logical.DeleteOperation, "sys/mfa/login-enforcement/userpass", root, nil)
+	mustRequest(logical.DeleteOperation, "sys/mfa/method/totp/my_totp", root, nil)
+	if resp, err := login(nil); err != nil || resp == nil || resp.Auth == nil {
+		t.Fatalf("bad: resp: %#v, err: %v", resp, err)
+	}
+	resp = mustRequest(logical.ListOperation, "sys/mfa/method", root, nil)
+	if resp.Data["keys"] != nil {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+}
+
+func TestLoginMFA_UserLockout(t *testing.T) {
+	core, _, root := TestCoreUnsealed(t)
+	core.credentialBackends["userpass"] = credUserpass.Factory
+
+	request := func(operation logical.Operation, path, token string, data map[string]interface{}, mfaCreds logical.MFACreds) (*logical.Response, error) {
+		t.Helper()
+		return core.HandleRequest(namespace.RootContext(nil), &logical.Request{
+			Operation:   operation,
+			Path:        path,
+			ClientToken: token,
+			Data:        data,
+			MFACreds:    mfaCreds,
+			Connection:  &logical.Connection{},
+		})
+	}
+	mustRequest := func(operation logical.Operation, path, token string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := request(operation, path, token, data, nil)
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("bad: %s: resp: %#v, err: %v", path, resp, err)
+		}
+		return resp
+	}
+	login := func(password string, mfaCreds logical.MFACreds) (*logical.Response, error) {
+		t.Helper()
+		return request(logical.UpdateOperation, "auth/userpass/login/test", "", map[string]interface{}{
+			"password": password,
+		}, mfaCreds)
+	}
+
+	mustRequest(logical.UpdateOperation, "sys/auth/userpass", root, map[string]interface{}{"type": "userpass"})
+	mustRequest(logical.UpdateOperation, "sys/auth/userpass/tune", root, map[string]interface{}{
+		"user_lockout_threshold": 3,
+	})
+	mustRequest(logical.UpdateOperation, "auth/userpass/users/test", root, map[string]interface{}{"password": "foo"})
+
+	resp, err := login("foo", nil)
+	if err != nil || resp == nil || resp.Auth == nil {
+		t.Fatalf("bad: resp: %#v, err: %v", resp, err)
+	}
+	entityID := resp.Auth.EntityID
+
+	mustRequest(logical.UpdateOperation, "sys/mfa/method/totp/my_totp", root, map[string]interface{}{
+		"issuer": "Vault",
+	})
+	resp = mustRequest(logical.UpdateOperation, "sys/mfa/method/totp/my_totp/admin-generate", root, map[string]interface{}{
+		"entity_id": entityID,
+	})
+	key, err := otplib.NewKeyFromURL(resp.Data["url"].(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mustRequest(logical.UpdateOperation, "sys/mfa/login-enforcement/userpass", root, map[string]interface{}{
+		"mfa_method_names":  "my_totp",
+		"auth_method_types": "userpass",
+	})
+
+	// A right password with a wrong passcode is a failed attempt, and
+	// doesn't forget the previous ones
+	if resp, _ := login("bar", nil); resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error, got: %#v", resp)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := login("foo", logical.MFACreds{"my_totp": []string{"000000"}}); err != logical.ErrPermissionDenied {
+			t.Fatalf("expected permission denied, got: %v", err)
+		}
+	}
+
+	// The user is locked out, even with a valid passcode
+	code, err := totplib.GenerateCode(key.Secret(), time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp, err := login("foo", logical.MFACreds{"my_totp": []string{code}}); err == nil || (resp != nil && resp.Auth != nil) {
+		t.Fatalf("expected the user to be locked out, resp: %#v", resp)
+	}
+	accessor := core.router.MatchingMountEntry(namespace.RootContext(nil), "auth/userpass/").Accessor
+	if users := core.lockedUsers(accessor); len(users) != 1 || users[0].Alias != "test" {
+		t.Fatalf("bad: %#v", users)
+	}
+}
+
+func TestLoginMFA_PasswordChange(t *testing.T) {
+	core, _, root := TestCoreUnsealed(t)
+	core.credentialBackends["userpass"] = credUserpass.Factory
+
+	request := func(operation logical.Operation, path, token string, data map[string]interface{}, mfaCreds logical.MFACreds) (*logical.Response, error) {
+		t.Helper()
+		return core.HandleRequest(namespace.RootContext(nil), &logical.Request{
+			Operation:   operation,
+			Path:        path,
+			ClientToken: token,
+			Data:        data,
+			MFACreds:    mfaCreds,
+			Connection:  &logical.Connection{},
+		})
+	}
+	mustRequest := func(operation logical.Operation, path, token string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := request(operation, path, token, data, nil)
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("bad: %s: resp: %#v, err: %v", path, resp, err)
+		}
+		return resp
+	}
+	login := func(data map[string]interface{}, mfaCreds logical.MFACreds) (*logical.Response, error) {
+		t.Helper()
+		return request(logical.UpdateOperation, "auth/userpass/login/test", "", data, mfaCreds)
+	}
+
+	mustRequest(logical.UpdateOperation, "sys/auth/userpass", root, map[string]interface{}{"type": "userpass"})
+	mustRequest(logical.UpdateOperation, "auth/userpass/users/test", root, map[string]interface{}{"password": "foo"})
+
+	resp, err := login(map[string]interface{}{"password": "foo"}, nil)
+	if err != nil || resp == nil || resp.Auth == nil {
+		t.Fatalf("bad: resp: %#v, err: %v", resp, err)
+	}
+	entityID := resp.Auth.EntityID
+
+	mustRequest(logical.UpdateOperation, "sys/mfa/method/totp/my_totp", root, map[string]interface{}{
+		"issuer": "Vault",
+	})
+	resp = mustRequest(logical.UpdateOperation, "sys/mfa/method/totp/my_totp/admin-generate", root, map[string]interface{}{
+		"entity_id": entityID,
+	})
+	key, err := otplib.NewKeyFromURL(resp.Data["url"].(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mustRequest(logical.UpdateOperation, "sys/mfa/login-enforcement/userpass", root, map[string]interface{}{
+		"mfa_method_names":  "my_totp",
+		"auth_method_types": "userpass",
+	})
+	mustRequest(logical.UpdateOperation, "auth/userpass/users/test", root, map[string]interface{}{
+		"force_password_change": true,
+	})
+
+	// The password isn't changed by a login rejected by MFA
+	data := map[string]interface{}{
+		"password":     "foo",
+		"new_password": "bar",
+	}
+	if _, err := login(data, logical.MFACreds{"my_totp": []string{"000000"}}); err != logical.ErrPermissionDenied {
+		t.Fatalf("expected permission denied, got: %v", err)
+	}
+	resp = mustRequest(logical.ReadOperation, "auth/userpass/users/test", root, nil)
+	if resp.Data["force_password_change"] != true {
+		t.Fatalf("expected the password change to still be required: %#v", resp.Data)
+	}
+
+	// It is changed once the MFA is validated
+	code, err := totplib.GenerateCode(key.Secret(), time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp, err := login(data, logical.MFACreds{"my_totp": []string{code}}); err != nil || resp == nil || resp.Auth == nil {
+		t.Fatalf("bad: resp: %#v, err: %v", resp, err)
+	}
+	resp = mustRequest(logical.ReadOperation, "auth/userpass/users/test", root, nil)
+	if resp.Data["force_password_change"] != false {
+		t.Fatalf("expected the password to be changed: %#v", resp.Data)
+	}
+}
+
+type mockDuoAuthClient struct {
+	preauthResult string
+	passcode      string
+}
+
+func (c *mockDuoAuthClient) Preauth(options ...func(*url.Values)) (*authapi.PreauthResult, error) {
+	result := &authapi.PreauthResult{}
+	result.StatResult.Stat = "OK"
+	result.Response.Result = c.preauthResult
+	return result, nil
+}
+
+func (c *mockDuoAuthClient) Auth(factor string, options ...func(*url.Values)) (*authapi.AuthResult, error) {
+	values := url.Values{}
+	for _, option := range options {
+		option(&values)
+	}
+
+	result := &authapi.AuthResult{}
+	result.StatResult.Stat = "OK"
+	result.Response.Result = "deny"
+	result.Response.Status_Msg = "Login denied."
+	if factor == "push" || values.Get("passcode") == c.passcode {
+		result.Response.Result = "allow"
+	}
+	return result, nil
+}
+
+func TestLoginMFA_validateDuo(t *testing.T) {
+	client := &mockDuoAuthClient{preauthResult: "auth", passcode: "123456"}
+	defer func(f func(*duoMFAConfig) duo.AuthClient) {
+		newDuoAuthClient = f
+	}(newDuoAuthClient)
+	newDuoAuthClient = func(*duoMFAConfig) duo.AuthClient {
+		return client
+	}
+
+	config := &duoMFAConfig{}
+	if err := validateDuo(config, "test", "", ""); err != nil {
+		t.Fatalf("expected the push to be allowed, got: %v", err)
+	}
+	if err := validateDuo(config, "test", "123456", ""); err != nil {
+		t.Fatalf("expected the passcode to be allowed, got: %v", err)
+	}
+	if err := validateDuo(config, "test", "000000", ""); err == nil {
+		t.Fatal("expected an invalid passcode to be denied")
+	}
+
+	config.UsePasscode = true
+	if err := validateDuo(config, "test", "", ""); err == nil {
+		t.Fatal("expected a passcode to be required")
+	}
+
+	client.preauthResult = "deny"
+	if err := validateDuo(config, "test", "123456", ""); err == nil {
+		t.Fatal("expected the user to be denied by preauth")
+	}
+}
+
+func TestLoginMFA_mfaUsername(t *testing.T) {
+	entity := &identity.Entity{
+		Name:     "entity",
+		Metadata: map[string]string{"domain": "example.com"},
+		Aliases: []*identity.Alias{
+			{MountAccessor: "auth_ldap_1234", Name: "ldap-user"},
+			{MountAccessor: "auth_userpass_1234", Name: "user", Metadata: map[string]string{"team": "dev"}},
+		},
+	}
+	method := &mfaMethod{MountAccessor: "auth_userpass_1234"}
+
+	for format, expected := range map[string]string{
+		"":                                       "user",
+		"{{alias.name}}@example.com":             "user@example.com",
+		"{{ entity.name }}":                      "entity",
+		"{{alias.metadata.team}}-{{alias.name}}": "dev-user",
+		"{{alias.name}}@{{entity.metadata.domain}}": "user@example.com",
+	} {
+		method.UsernameFormat = format
+		username, err := mfaUsername(method, entity)
+		if err != nil || username != expected {
+			t.Fatalf("bad: %q: expected %q, got %q, %v", format, expected, username, err)
+		}
+	}
+
+	for _, format := range []string{"{{alias.name", "alias.name}}", "{{alias.id}}", "{{entity.metadata.missing}}"} {
+		method.UsernameFormat = format
+		if _, err := mfaUsername(method, entity); err == nil {
+			t.Fatalf("expected an error for %q", format)
+		}
+	}
+
+	method.MountAccessor = "auth_okta_1234"
+	method.UsernameFormat = ""
+	if _, err := mfaUsername(method, entity); err == nil {
+		t.Fatal("expected an error for an entity without an alias on the mount")
+	}
+}
+
+func TestLoginMFA_parsePingIDSettings(t *testing.T) {
+	settings := `#Auto-Generated from PingOne
+use_base64_key=aGVsbG8=
+use_signature=true
+token=token1234
+idp_url=https\://idpxnyl3m.pingidentity.com/pingid
+org_alias=org1234
+`
+	config, err := parsePingIDSettings(base64.StdEncoding.EncodeToString([]byte(settings)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.IDPURL != "https://idpxnyl3m.pingidentity.com/pingid" || config.Token != "token1234" || !config.UseSignature {
+		t.Fatalf("bad: %#v", config)
+	}
+
+	settings = strings.Replace(settings, "org_alias=org1234\n", "", 1)
+	if _, err := parsePingIDSettings(base64.StdEncoding.EncodeToString([]byte(settings))); err == nil {
+		t.Fatal("expected an error for settings missing the org alias")
+	}
+}
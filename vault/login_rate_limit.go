@@ -0,0 +1,120 @@
+package vault
+
+import (
+	"sync"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// defaultLoginRateLimitPeriod is the period over which login_rate_limit
+	// attempts are allowed if no period has been tuned on the mount.
+	defaultLoginRateLimitPeriod = time.Minute
+
+	// loginRateLimitCacheSize bounds the number of aliases and remote
+	// addresses tracked per auth mount.
+	loginRateLimitCacheSize = 16384
+
+	// loginRateLimitAuditSampleRate controls how often rejected login
+	// attempts are sent to the audit broker. The first rejection is always
+	// audited and after that one of every loginRateLimitAuditSampleRate
+	// rejections is, so that a guessing attack cannot flood the audit log.
+	loginRateLimitAuditSampleRate = 10
+)
+
+// loginRateLimiter throttles login requests per auth mount. Attempts are
+// tracked both per alias, as resolved by the alias lookahead of the auth
+// method, and per remote address, and either one exceeding the mount's limit
+// rejects the request. Auth methods which can't resolve the alias ahead of the
+// login are only throttled per remote address, since keying on anything
+// shared by every user of the mount would let one client lock all of them
+// out.
+type loginRateLimiter struct {
+	l      sync.Mutex
+	mounts map[string]*mountLoginRateLimiter
+}
+
+type mountLoginRateLimiter struct {
+	limit    int
+	period   time.Duration
+	limiters *lru.Cache
+	rejected uint64
+}
+
+func newLoginRateLimiter() *loginRateLimiter {
+	return &loginRateLimiter{
+		mounts: make(map[string]*mountLoginRateLimiter),
+	}
+}
+
+// allow reports whether a login attempt against the given auth mount may
+// proceed. An empty alias is only checked against the remote address. If the
+// attempt is rejected, audit reports whether the rejection has been sampled
+// for auditing.
+func (l *loginRateLimiter) allow(entry *MountEntry, alias, remoteAddr string) (allowed bool, audit bool) {
+	if entry == nil || entry.Config.LoginRateLimit <= 0 {
+		return true, false
+	}
+
+	limit := entry.Config.LoginRateLimit
+	period := entry.Config.LoginRateLimitPeriod
+	if period <= 0 {
+		period = defaultLoginRateLimitPeriod
+	}
+
+	l.l.Lock()
+	defer l.l.Unlock()
+
+	m, ok := l.mounts[entry.Accessor]
+	if !ok || m.limit != limit || m.period != period {
+		// The mount was tuned since the limiters were created, start over
+		limiters, err := lru.New(loginRateLimitCacheSize)
+		if err != nil {
+			return true, false
+		}
+		m = &mountLoginRateLimiter{
+			limit:    limit,
+			period:   period,
+			limiters: limiters,
+		}
+		l.mounts[entry.Accessor] = m
+	}
+
+	keys := []string{"addr:" + remoteAddr}
+	if alias != "" {
+		keys = append(keys, "alias:"+alias)
+	}
+
+	allowed = true
+	for _, key := range keys {
+		if !m.limiter(key).Allow() {
+			allowed = false
+		}
+	}
+	if allowed {
+		return true, false
+	}
+
+	m.rejected++
+	return false, (m.rejected-1)%loginRateLimitAuditSampleRate == 0
+}
+
+// remove drops all tracked state for the auth mount with the given accessor.
+func (l *loginRateLimiter) remove(accessor string) {
+	l.l.Lock()
+	defer l.l.Unlock()
+
+	delete(l.mounts, accessor)
+}
+
+func (m *mountLoginRateLimiter) limiter(key string) *rate.Limiter {
+	if raw, ok := m.limiters.Get(key); ok {
+		return raw.(*rate.Limiter)
+	}
+
+	limiter := rate.NewLimiter(rate.Every(m.period/time.Duration(m.limit)), m.limit)
+	m.limiters.Add(key, limiter)
+	return limiter
+}
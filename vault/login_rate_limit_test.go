@@ -0,0 +1,109 @@
+package vault
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	credUserpass "github.com/hashicorp/vault/builtin/credential/userpass"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/logical"
+)
+
+func TestLoginRateLimiter_AuditSampling(t *testing.T) {
+	l := newLoginRateLimiter()
+	entry := &MountEntry{
+		Accessor: "auth_userpass_1234",
+		Config: MountConfig{
+			LoginRateLimit:       1,
+			LoginRateLimitPeriod: time.Hour,
+		},
+	}
+
+	if allowed, _ := l.allow(entry, "test", "127.0.0.1"); !allowed {
+		t.Fatal("expected first attempt to be allowed")
+	}
+
+	var audited int
+	for i := 0; i < 2*loginRateLimitAuditSampleRate; i++ {
+		allowed, sampled := l.allow(entry, "test", "127.0.0.1")
+		if allowed {
+			t.Fatalf("expected attempt %d to be rejected", i)
+		}
+		if i == 0 && !sampled {
+			t.Fatal("expected the first rejection to be audited")
+		}
+		if sampled {
+			audited++
+		}
+	}
+	if audited != 2 {
+		t.Fatalf("expected 2 audited rejections, got %d", audited)
+	}
+
+	// Tuning the mount resets the limiters
+	entry.Config.LoginRateLimit = 2
+	if allowed, _ := l.allow(entry, "test", "127.0.0.1"); !allowed {
+		t.Fatal("expected attempt to be allowed after tuning")
+	}
+
+	l.remove(entry.Accessor)
+	if _, ok := l.mounts[entry.Accessor]; ok {
+		t.Fatal("expected mount state to be removed")
+	}
+}
+
+// lookaheadCountingBackend counts the alias lookaheads handled by a backend
+type lookaheadCountingBackend struct {
+	logical.Backend
+	lookaheads int
+}
+
+func (b *lookaheadCountingBackend) HandleRequest(ctx context.Context, req *logical.Request) (*logical.Response, error) {
+	if req.Operation == logical.AliasLookaheadOperation {
+		b.lookaheads++
+	}
+	return b.Backend.HandleRequest(ctx, req)
+}
+
+func TestHandleLoginRequest_SingleAliasLookahead(t *testing.T) {
+	core, _, root := TestCoreUnsealed(t)
+	backend := &lookaheadCountingBackend{}
+	core.credentialBackends["userpass"] = func(ctx context.Context, config *logical.BackendConfig) (logical.Backend, error) {
+		b, err := credUserpass.Factory(ctx, config)
+		if err != nil {
+			return nil, err
+		}
+		backend.Backend = b
+		return backend, nil
+	}
+
+	request := func(path, token string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := core.HandleRequest(namespace.RootContext(nil), &logical.Request{
+			Operation:   logical.UpdateOperation,
+			Path:        path,
+			ClientToken: token,
+			Data:        data,
+			Connection:  &logical.Connection{RemoteAddr: "127.0.0.1"},
+		})
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("bad: %s: resp: %#v, err: %v", path, resp, err)
+		}
+		return resp
+	}
+
+	request("sys/auth/userpass", root, map[string]interface{}{"type": "userpass"})
+	request("sys/auth/userpass/tune", root, map[string]interface{}{
+		"login_rate_limit":       10,
+		"user_lockout_threshold": 3,
+	})
+	request("auth/userpass/users/test", root, map[string]interface{}{"password": "foo"})
+
+	// The rate limit and the user lockout share the alias of the login
+	backend.lookaheads = 0
+	request("auth/userpass/login/test", "", map[string]interface{}{"password": "foo"})
+	if backend.lookaheads != 1 {
+		t.Fatalf("expected 1 alias lookahead, got %d", backend.lookaheads)
+	}
+}
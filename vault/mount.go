@@ -14,6 +14,7 @@ import (
 	"github.com/hashicorp/vault/builtin/plugin"
 	"github.com/hashicorp/vault/helper/consts"
 	"github.com/hashicorp/vault/helper/jsonutil"
+	"github.com/hashicorp/vault/helper/logging"
 	"github.com/hashicorp/vault/helper/namespace"
 	"github.com/hashicorp/vault/helper/strutil"
 	"github.com/hashicorp/vault/logical"
@@ -229,6 +230,13 @@ type MountConfig struct {
 	PassthroughRequestHeaders []string              `json:"passthrough_request_headers,omitempty" structs:"passthrough_request_headers" mapstructure:"passthrough_request_headers"`
 	AllowedResponseHeaders    []string              `json:"allowed_response_headers,omitempty" structs:"allowed_response_headers" mapstructure:"allowed_response_headers"`
 	TokenType                 logical.TokenType     `json:"token_type" structs:"token_type" mapstructure:"token_type"`
+	LoginRateLimit            int                   `json:"login_rate_limit,omitempty" structs:"login_rate_limit" mapstructure:"login_rate_limit"`
+	LoginRateLimitPeriod      time.Duration         `json:"login_rate_limit_period,omitempty" structs:"login_rate_limit_period" mapstructure:"login_rate_limit_period"`
+	UserLockoutThreshold      int                   `json:"user_lockout_threshold,omitempty" structs:"user_lockout_threshold" mapstructure:"user_lockout_threshold"`
+	UserLockoutDuration       time.Duration         `json:"user_lockout_duration,omitempty" structs:"user_lockout_duration" mapstructure:"user_lockout_duration"`
+	UserLockoutCounterReset   time.Duration         `json:"user_lockout_counter_reset,omitempty" structs:"user_lockout_counter_reset" mapstructure:"user_lockout_counter_reset"`
+	AllowedAliasMetadataKeys  []string              `json:"allowed_alias_metadata_keys,omitempty" structs:"allowed_alias_metadata_keys" mapstructure:"allowed_alias_metadata_keys"`
+	RequestConcurrencyLimit   int                   `json:"request_concurrency_limit,omitempty" structs:"request_concurrency_limit" mapstructure:"request_concurrency_limit"`
 
 	// PluginName is the name of the plugin registered in the catalog.
 	//
@@ -247,6 +255,13 @@ type APIMountConfig struct {
 	PassthroughRequestHeaders []string              `json:"passthrough_request_headers,omitempty" structs:"passthrough_request_headers" mapstructure:"passthrough_request_headers"`
 	AllowedResponseHeaders    []string              `json:"allowed_response_headers,omitempty" structs:"allowed_response_headers" mapstructure:"allowed_response_headers"`
 	TokenType                 string                `json:"token_type" structs:"token_type" mapstructure:"token_type"`
+	LoginRateLimit            int                   `json:"login_rate_limit,omitempty" structs:"login_rate_limit" mapstructure:"login_rate_limit"`
+	LoginRateLimitPeriod      string                `json:"login_rate_limit_period,omitempty" structs:"login_rate_limit_period" mapstructure:"login_rate_limit_period"`
+	UserLockoutThreshold      int                   `json:"user_lockout_threshold,omitempty" structs:"user_lockout_threshold" mapstructure:"user_lockout_threshold"`
+	UserLockoutDuration       string                `json:"user_lockout_duration,omitempty" structs:"user_lockout_duration" mapstructure:"user_lockout_duration"`
+	UserLockoutCounterReset   string                `json:"user_lockout_counter_reset,omitempty" structs:"user_lockout_counter_reset" mapstructure:"user_lockout_counter_reset"`
+	AllowedAliasMetadataKeys  []string              `json:"allowed_alias_metadata_keys,omitempty" structs:"allowed_alias_metadata_keys" mapstructure:"allowed_alias_metadata_keys"`
+	RequestConcurrencyLimit   int                   `json:"request_concurrency_limit,omitempty" structs:"request_concurrency_limit" mapstructure:"request_concurrency_limit"`
 
 	// PluginName is the name of the plugin registered in the catalog.
 	//
@@ -481,6 +496,7 @@ func (c *Core) mountInternal(ctx context.Context, entry *MountEntry, updateStora
 	if err := c.router.Mount(backend, entry.Path, entry, view); err != nil {
 		return err
 	}
+	c.claimWellKnownPaths(entry, backend)
 
 	if c.logger.IsInfo() {
 		c.logger.Info("successful mount", "namespace", entry.Namespace().Path, "path", entry.Path, "type", entry.Type)
@@ -1059,6 +1075,7 @@ func (c *Core) setupMounts(ctx context.Context) error {
 		if backend == nil {
 			return fmt.Errorf("created mount entry of type %q is nil", entry.Type)
 		}
+		c.logDeprecatedBuiltin(entry)
 
 		{
 			// Check for the correct backend type
@@ -1090,6 +1107,7 @@ func (c *Core) setupMounts(ctx context.Context) error {
 			c.logger.Error("failed to mount entry", "path", entry.Path, "error", err)
 			return errLoadMountsFailed
 		}
+		c.claimWellKnownPaths(entry, backend)
 
 		if c.logger.IsInfo() {
 			c.logger.Info("successfully mounted backend", "type", entry.Type, "path", entry.Path)
@@ -1158,7 +1176,7 @@ func (c *Core) newLogicalBackend(ctx context.Context, entry *MountEntry, sysView
 
 	conf["plugin_type"] = consts.PluginTypeSecrets.String()
 
-	backendLogger := c.baseLogger.Named(fmt.Sprintf("secrets.%s.%s", t, entry.Accessor))
+	backendLogger := logging.NewSubsystemLogger(c.baseLogger, fmt.Sprintf("secrets.%s.%s", t, entry.Accessor))
 	c.AddLogger(backendLogger)
 	config := &logical.BackendConfig{
 		StorageView: view,
@@ -0,0 +1,103 @@
+package vault
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/builtin/logical/database/dbmigrate"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/logical"
+)
+
+// databaseMigration is the result of migrating a legacy database mount
+type databaseMigration struct {
+	*dbmigrate.LegacyMigration
+	Leases int
+}
+
+// migrateToDatabase converts the cassandra, mongodb or mssql mount at the
+// given path into a database mount in place: its configuration and roles are
+// rewritten into a database connection with the given name and the roles of
+// that connection, and the leases it issued are updated so that the new
+// backend can renew and revoke them.
+func (c *Core) migrateToDatabase(ctx context.Context, path, connName string) (*databaseMigration, error) {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	path = sanitizeMountPath(path)
+	for _, p := range protectedMounts {
+		if strings.HasPrefix(path, p) {
+			return nil, fmt.Errorf("cannot migrate %q", path)
+		}
+	}
+
+	c.mountsLock.Lock()
+	defer c.mountsLock.Unlock()
+
+	entry := c.router.MatchingMountEntry(ctx, path)
+	if entry == nil || entry.Path != path {
+		return nil, fmt.Errorf("no matching mount at %q", path)
+	}
+	if entry.NamespaceID != ns.ID {
+		return nil, fmt.Errorf("mount in a different namespace than request")
+	}
+	if !dbmigrate.IsLegacyType(entry.Type) {
+		return nil, fmt.Errorf("mounts of type %q cannot be migrated to the database secrets engine", entry.Type)
+	}
+	if connName == "" {
+		connName = entry.Type
+	}
+
+	view := c.router.MatchingStorageByAPIPath(ctx, path)
+	if view == nil {
+		return nil, fmt.Errorf("no storage found for mount at %q", path)
+	}
+
+	// Stop routing to the legacy backend while its storage is rewritten
+	if err := c.router.Taint(ctx, path); err != nil {
+		return nil, err
+	}
+	defer c.router.Untaint(ctx, path)
+
+	legacy, err := dbmigrate.MigrateLegacyStorage(ctx, view, entry.Type, connName)
+	if err != nil {
+		return nil, err
+	}
+	migration := &databaseMigration{
+		LegacyMigration: legacy,
+	}
+
+	migration.Leases, err = c.expiration.rewriteSecretLeases(ctx, path, func(le *leaseEntry) bool {
+		return legacy.MigrateSecret(strings.TrimPrefix(le.Path, path), le.Secret)
+	})
+	if err != nil {
+		return nil, errwrap.Wrapf("failed to migrate leases: {{err}}", err)
+	}
+
+	// Update the mount table
+	oldType := entry.Type
+	entry.Type = "database"
+	if err := c.persistMounts(ctx, c.mounts, &entry.Local); err != nil {
+		entry.Type = oldType
+		if err == logical.ErrReadOnly && c.perfStandby {
+			return nil, err
+		}
+
+		c.logger.Error("failed to update mounts table", "error", err)
+		return nil, logical.CodedError(500, "failed to update mounts table")
+	}
+
+	// Replace the legacy backend
+	if err := c.reloadBackendCommon(ctx, entry, false); err != nil {
+		return nil, errwrap.Wrapf("failed to load the database backend: {{err}}", err)
+	}
+
+	if c.logger.IsInfo() {
+		c.logger.Info("successful migration to the database secrets engine", "path", path, "type", oldType, "leases", migration.Leases)
+	}
+	return migration, nil
+}
@@ -186,8 +186,14 @@ func (c *Core) reloadBackendCommon(ctx context.Context, entry *MountEntry, isAut
 		if paths != nil {
 			re.rootPaths.Store(pathsToRadix(paths.Root))
 			re.loginPaths.Store(pathsToRadix(paths.Unauthenticated))
+			re.cachedPaths.Store(pathsToRadix(paths.CachedResponses))
 		}
 	}
+	re.responseCache.purge()
+
+	// The claims of the reloaded backend replace those of the previous one
+	c.router.wellKnown.deregisterMount(entry.UUID)
+	c.claimWellKnownPaths(entry, backend)
 
 	return nil
 }
@@ -0,0 +1,270 @@
+package vault
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	metrics "github.com/armon/go-metrics"
+	"github.com/hashicorp/errwrap"
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/logical"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// rateLimitQuotaPrefix is the storage prefix of the rate limit quotas,
+	// relative to the system barrier view
+	rateLimitQuotaPrefix = "quotas/rate-limit/"
+
+	// rateLimitQuotaClientCacheSize bounds the number of clients a quota
+	// tracks; the least recently seen ones start over with a full bucket
+	rateLimitQuotaClientCacheSize = 16384
+)
+
+// rateLimitQuotaExemptPaths are the path prefixes rate limit quotas never
+// apply to, so that operators can always check on, unseal and reconfigure a
+// cluster flooded with requests
+var rateLimitQuotaExemptPaths = []string{
+	"sys/health",
+	"sys/seal-status",
+	"sys/unseal",
+	"sys/generate-root/attempt",
+	"sys/generate-root/update",
+	"sys/quotas/",
+}
+
+// rateLimitQuota is a token bucket rate limit each client, identified by its
+// address, is held to when making requests within the quota's scope
+type rateLimitQuota struct {
+	Name string `json:"name"`
+
+	// Path is the namespace or the mount the quota applies to, relative to
+	// the root namespace, or empty for a global quota
+	Path string `json:"path"`
+
+	// Role restricts a quota on an auth mount to the logins against the role
+	Role string `json:"role,omitempty"`
+
+	// Rate is the number of requests a client can make per Interval, and
+	// Burst the number it can make at once, Rate if zero
+	Rate     int           `json:"rate"`
+	Interval time.Duration `json:"interval"`
+	Burst    int           `json:"burst"`
+
+	l        sync.Mutex
+	limiters *lru.Cache
+}
+
+func (q *rateLimitQuota) burst() int {
+	if q.Burst == 0 {
+		return q.Rate
+	}
+	return q.Burst
+}
+
+// allow reports whether a request of the client may proceed and, if not, how
+// long the client should wait before retrying
+func (q *rateLimitQuota) allow(clientAddr string, now time.Time) (bool, time.Duration) {
+	q.l.Lock()
+	if q.limiters == nil {
+		q.limiters, _ = lru.New(rateLimitQuotaClientCacheSize)
+	}
+	var limiter *rate.Limiter
+	if raw, ok := q.limiters.Get(clientAddr); ok {
+		limiter = raw.(*rate.Limiter)
+	} else {
+		limiter = rate.NewLimiter(rate.Every(q.Interval/time.Duration(q.Rate)), q.burst())
+		q.limiters.Add(clientAddr, limiter)
+	}
+	q.l.Unlock()
+
+	reservation := limiter.ReserveN(now, 1)
+	delay := reservation.DelayFrom(now)
+	if delay == 0 {
+		return true, 0
+	}
+	reservation.CancelAt(now)
+	return false, delay
+}
+
+// rateLimitQuotas holds the rate limit quotas by name
+type rateLimitQuotas struct {
+	l      sync.RWMutex
+	quotas map[string]*rateLimitQuota
+}
+
+func newRateLimitQuotas() *rateLimitQuotas {
+	return &rateLimitQuotas{
+		quotas: make(map[string]*rateLimitQuota),
+	}
+}
+
+func (r *rateLimitQuotas) quota(name string) *rateLimitQuota {
+	r.l.RLock()
+	defer r.l.RUnlock()
+	return r.quotas[name]
+}
+
+func (r *rateLimitQuotas) setQuota(quota *rateLimitQuota) {
+	r.l.Lock()
+	defer r.l.Unlock()
+	r.quotas[quota.Name] = quota
+}
+
+func (r *rateLimitQuotas) deleteQuota(name string) {
+	r.l.Lock()
+	defer r.l.Unlock()
+	delete(r.quotas, name)
+}
+
+// setQuotas replaces all the quotas, as loaded from storage
+func (r *rateLimitQuotas) setQuotas(quotas map[string]*rateLimitQuota) {
+	r.l.Lock()
+	defer r.l.Unlock()
+	r.quotas = quotas
+}
+
+// hasRoleQuotas reports whether any quota is restricted to the logins against
+// a role of the mount
+func (r *rateLimitQuotas) hasRoleQuotas(mountPath string) bool {
+	r.l.RLock()
+	defer r.l.RUnlock()
+
+	for _, quota := range r.quotas {
+		if quota.Path == mountPath && quota.Role != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// conflicting returns the name of the quota other than the named one with the
+// same scope, if any
+func (r *rateLimitQuotas) conflicting(name, path, role string) string {
+	r.l.RLock()
+	defer r.l.RUnlock()
+
+	for _, quota := range r.quotas {
+		if quota.Name != name && quota.Path == path && quota.Role == role {
+			return quota.Name
+		}
+	}
+	return ""
+}
+
+// match returns the most specific quota applying to a request: the quota on
+// its mount and login role, then the one on its mount, then the one on its
+// namespace, then the global one.
+func (r *rateLimitQuotas) match(nsPath, mountPath, role string) *rateLimitQuota {
+	r.l.RLock()
+	defer r.l.RUnlock()
+
+	var mountRole, mount, ns, global *rateLimitQuota
+	for _, quota := range r.quotas {
+		switch {
+		case quota.Path == "":
+			global = quota
+		case quota.Path == mountPath && quota.Role == "":
+			mount = quota
+		case quota.Path == mountPath && role != "" && quota.Role == role:
+			mountRole = quota
+		case quota.Path == nsPath:
+			ns = quota
+		}
+	}
+
+	switch {
+	case mountRole != nil:
+		return mountRole
+	case mount != nil:
+		return mount
+	case ns != nil:
+		return ns
+	}
+	return global
+}
+
+// loadRateLimitQuotas loads the rate limit quotas from storage
+func (c *Core) loadRateLimitQuotas(ctx context.Context) error {
+	quotas := make(map[string]*rateLimitQuota)
+
+	names, err := c.systemBarrierView.List(ctx, rateLimitQuotaPrefix)
+	if err != nil {
+		return errwrap.Wrapf("failed to list rate limit quotas: {{err}}", err)
+	}
+	for _, name := range names {
+		var quota rateLimitQuota
+		if err := loadJSONEntry(ctx, c.systemBarrierView, rateLimitQuotaPrefix+name, &quota); err != nil {
+			return errwrap.Wrapf(fmt.Sprintf("failed to load rate limit quota %q: {{err}}", name), err)
+		}
+		quotas[quota.Name] = &quota
+	}
+
+	c.rateLimitQuotas.setQuotas(quotas)
+	return nil
+}
+
+// loginResolveRole asks the auth mount for the role a login request is made
+// against, without checking its credentials. An empty role is returned if the
+// auth method has no roles or doesn't support the resolution.
+func (c *Core) loginResolveRole(ctx context.Context, req *logical.Request) string {
+	resp, err := c.router.Route(ctx, &logical.Request{
+		MountAccessor: req.MountAccessor,
+		Path:          req.Path,
+		Operation:     logical.ResolveRoleOperation,
+		Data:          req.Data,
+		Connection:    req.Connection,
+		Headers:       req.Headers,
+	})
+	if err != nil || resp == nil || resp.IsError() {
+		return ""
+	}
+	role, _ := resp.Data["role"].(string)
+	return role
+}
+
+// ApplyRateLimitQuota checks the request against the most specific rate limit
+// quota applying to it. If the client exceeded the quota, the request must be
+// rejected and the client should wait for the returned duration before
+// retrying.
+func (c *Core) ApplyRateLimitQuota(ctx context.Context, req *logical.Request) (bool, time.Duration) {
+	r := c.rateLimitQuotas
+	for _, prefix := range rateLimitQuotaExemptPaths {
+		if strings.HasPrefix(req.Path, prefix) {
+			return true, 0
+		}
+	}
+
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		ns = namespace.RootNamespace
+		ctx = namespace.ContextWithNamespace(ctx, ns)
+	}
+
+	mountPath := c.router.MatchingMount(ctx, req.Path)
+	// Resolving the role takes a request to the auth method, so it is only
+	// done if a quota could match it
+	var role string
+	if strings.HasPrefix(strings.TrimPrefix(mountPath, ns.Path), credentialRoutePrefix) && c.router.LoginPath(ctx, req.Path) && r.hasRoleQuotas(mountPath) {
+		role = c.loginResolveRole(ctx, req)
+	}
+
+	quota := r.match(ns.Path, mountPath, role)
+	if quota == nil {
+		return true, 0
+	}
+
+	var clientAddr string
+	if req.Connection != nil {
+		clientAddr = req.Connection.RemoteAddr
+	}
+	allowed, retryAfter := quota.allow(clientAddr, time.Now())
+	if !allowed {
+		metrics.IncrCounter([]string{"quota", "rate_limit", "violation"}, 1)
+	}
+	return allowed, retryAfter
+}
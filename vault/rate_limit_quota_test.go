@@ -0,0 +1,234 @@
+package vault
+
+import (
+	"testing"
+	"time"
+
+	credAppRole "github.com/hashicorp/vault/builtin/credential/approle"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/logical"
+)
+
+func TestRateLimitQuota_Allow(t *testing.T) {
+	q := &rateLimitQuota{Rate: 2, Interval: time.Second}
+	now := time.Now()
+
+	for i := 0; i < 2; i++ {
+		if allowed, _ := q.allow("127.0.0.1", now); !allowed {
+			t.Fatalf("expected request %d to be allowed", i)
+		}
+	}
+	allowed, retryAfter := q.allow("127.0.0.1", now)
+	if allowed {
+		t.Fatal("expected the burst to be exhausted")
+	}
+	if retryAfter <= 0 || retryAfter > 500*time.Millisecond {
+		t.Fatalf("bad: retry after %s", retryAfter)
+	}
+
+	// Clients have their own bucket
+	if allowed, _ := q.allow("127.0.0.2", now); !allowed {
+		t.Fatal("expected another client to be allowed")
+	}
+
+	// Rejected requests don't consume tokens
+	if allowed, _ := q.allow("127.0.0.1", now.Add(retryAfter)); !allowed {
+		t.Fatal("expected the client to be allowed once the bucket refilled")
+	}
+}
+
+func TestRateLimitQuotas_Match(t *testing.T) {
+	r := newRateLimitQuotas()
+	if q := r.match("", "secret/", ""); q != nil {
+		t.Fatalf("bad: %#v", q)
+	}
+
+	r.setQuota(&rateLimitQuota{Name: "global"})
+	r.setQuota(&rateLimitQuota{Name: "ns", Path: "ns1/"})
+	r.setQuota(&rateLimitQuota{Name: "mount", Path: "auth/approle/"})
+	r.setQuota(&rateLimitQuota{Name: "role", Path: "auth/approle/", Role: "web"})
+
+	cases := []struct {
+		nsPath, mountPath, role string
+		expected                string
+	}{
+		{"", "secret/", "", "global"},
+		{"ns1/", "ns1/secret/", "", "ns"},
+		{"", "auth/approle/", "", "mount"},
+		{"", "auth/approle/", "db", "mount"},
+		{"", "auth/approle/", "web", "role"},
+	}
+	for _, tc := range cases {
+		q := r.match(tc.nsPath, tc.mountPath, tc.role)
+		if q == nil || q.Name != tc.expected {
+			t.Fatalf("expected %q to match %s%s, got %#v", tc.expected, tc.mountPath, tc.role, q)
+		}
+	}
+}
+
+func TestSystemBackend_RateLimitQuota(t *testing.T) {
+	c, b, _ := testCoreSystemBackend(t)
+	ctx := namespace.RootContext(nil)
+	request := func(op logical.Operation, path string, data map[string]interface{}) (*logical.Response, error) {
+		req := logical.TestRequest(t, op, path)
+		req.Storage = c.systemBarrierView
+		req.Data = data
+		return b.HandleRequest(ctx, req)
+	}
+
+	for _, data := range []map[string]interface{}{
+		{"rate": 0},
+		{"rate": 1, "burst": -1},
+		{"rate": 1, "path": "nonexistent"},
+		{"rate": 1, "path": "secret", "role": "web"},
+		{"rate": 1, "role": "web"},
+	} {
+		resp, err := request(logical.UpdateOperation, "quotas/rate-limit/invalid", data)
+		if err != logical.ErrInvalidRequest || resp == nil || !resp.IsError() {
+			t.Fatalf("expected %v to be rejected: resp: %#v err: %v", data, resp, err)
+		}
+	}
+
+	resp, err := request(logical.UpdateOperation, "quotas/rate-limit/secret", map[string]interface{}{
+		"path": "secret",
+		"rate": 1,
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: resp: %#v err: %v", resp, err)
+	}
+
+	resp, err = request(logical.UpdateOperation, "quotas/rate-limit/duplicate", map[string]interface{}{
+		"path": "secret/",
+		"rate": 10,
+	})
+	if err != logical.ErrInvalidRequest {
+		t.Fatalf("expected a second quota on the same path to be rejected: resp: %#v err: %v", resp, err)
+	}
+
+	resp, err = request(logical.ReadOperation, "quotas/rate-limit/secret", nil)
+	if err != nil || resp == nil {
+		t.Fatalf("bad: resp: %#v err: %v", resp, err)
+	}
+	expected := map[string]interface{}{
+		"name":     "secret",
+		"path":     "secret/",
+		"role":     "",
+		"rate":     1,
+		"interval": int64(1),
+		"burst":    1,
+	}
+	for k, v := range expected {
+		if resp.Data[k] != v {
+			t.Fatalf("bad: %s: expected %#v, got %#v", k, v, resp.Data[k])
+		}
+	}
+
+	resp, err = request(logical.ListOperation, "quotas/rate-limit/", nil)
+	if err != nil || resp == nil {
+		t.Fatalf("bad: resp: %#v err: %v", resp, err)
+	}
+	if keys := resp.Data["keys"].([]string); len(keys) != 1 || keys[0] != "secret" {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	// The quota applies to the mount only, and survives a reload
+	if err := c.loadRateLimitQuotas(ctx); err != nil {
+		t.Fatal(err)
+	}
+	secretReq := &logical.Request{
+		Path:       "secret/foo",
+		Connection: &logical.Connection{RemoteAddr: "127.0.0.1"},
+	}
+	if allowed, _ := c.ApplyRateLimitQuota(ctx, secretReq); !allowed {
+		t.Fatal("expected the first request to be allowed")
+	}
+	if allowed, retryAfter := c.ApplyRateLimitQuota(ctx, secretReq); allowed || retryAfter <= 0 {
+		t.Fatalf("expected the second request to be rejected, retry after %s", retryAfter)
+	}
+	for _, path := range []string{"sys/mounts", "sys/health", "sys/quotas/rate-limit/secret"} {
+		if allowed, _ := c.ApplyRateLimitQuota(ctx, &logical.Request{Path: path}); !allowed {
+			t.Fatalf("expected a request to %s to be allowed", path)
+		}
+	}
+
+	if _, err := request(logical.DeleteOperation, "quotas/rate-limit/secret", nil); err != nil {
+		t.Fatal(err)
+	}
+	if allowed, _ := c.ApplyRateLimitQuota(ctx, secretReq); !allowed {
+		t.Fatal("expected requests to be allowed once the quota is deleted")
+	}
+}
+
+func TestRateLimitQuota_LoginRole(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+	c.credentialBackends["approle"] = credAppRole.Factory
+
+	request := func(path string, data map[string]interface{}) {
+		t.Helper()
+		resp, err := c.HandleRequest(ctx, &logical.Request{
+			Path:        path,
+			ClientToken: root,
+			Operation:   logical.UpdateOperation,
+			Data:        data,
+			Connection:  &logical.Connection{},
+		})
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("bad: resp: %#v, err: %v", resp, err)
+		}
+	}
+	request("sys/auth/approle", map[string]interface{}{"type": "approle"})
+	request("auth/approle/role/web", map[string]interface{}{"role_id": "web-id"})
+	request("auth/approle/role/db", map[string]interface{}{"role_id": "db-id"})
+	request("sys/quotas/rate-limit/web", map[string]interface{}{
+		"path": "auth/approle/",
+		"role": "web",
+		"rate": 1,
+	})
+
+	// AppRole logins name their role by its role ID, which the auth method
+	// resolves to the role
+	login := func(data map[string]interface{}) bool {
+		allowed, _ := c.ApplyRateLimitQuota(ctx, &logical.Request{
+			Path:       "auth/approle/login",
+			Operation:  logical.UpdateOperation,
+			Data:       data,
+			Connection: &logical.Connection{RemoteAddr: "127.0.0.1"},
+		})
+		return allowed
+	}
+	if !login(map[string]interface{}{"role_id": "web-id"}) {
+		t.Fatal("expected the first login to be allowed")
+	}
+	if login(map[string]interface{}{"role_id": "web-id"}) {
+		t.Fatal("expected the second login to be rejected")
+	}
+
+	// The role can't be named by the client
+	for i := 0; i < 2; i++ {
+		if !login(map[string]interface{}{"role_id": "db-id", "role": "web"}) {
+			t.Fatal("expected logins against another role to be allowed")
+		}
+	}
+}
+
+func TestRateLimitQuotas_Reload(t *testing.T) {
+	c, _, _ := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+
+	// Quotas are reloaded on unseal while requests are being checked
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			if err := c.loadRateLimitQuotas(ctx); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		c.ApplyRateLimitQuota(ctx, &logical.Request{Path: "secret/foo"})
+	}
+	<-done
+}
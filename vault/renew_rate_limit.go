@@ -0,0 +1,93 @@
+package vault
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/hashicorp/vault/logical"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// defaultRenewRateLimitPeriod is the period over which renew_rate_limit
+	// renewals are allowed per token if no period has been configured.
+	defaultRenewRateLimitPeriod = time.Minute
+
+	// renewRateLimitCacheSize bounds the number of tokens tracked.
+	renewRateLimitCacheSize = 16384
+
+	// renewRateLimitAuditSampleRate controls how often rejected renewals are
+	// sent to the audit broker, like loginRateLimitAuditSampleRate.
+	renewRateLimitAuditSampleRate = 10
+)
+
+// renewRateLimiter throttles the lease and token renewals made by each
+// client token, so that one client renewing in a tight loop cannot starve
+// the expiration manager.
+type renewRateLimiter struct {
+	l        sync.Mutex
+	limit    int
+	period   time.Duration
+	limiters *lru.Cache
+	rejected uint64
+}
+
+// newRenewRateLimiter returns a limiter allowing limit renewals per token
+// over period. A limit of zero or less disables the limiter.
+func newRenewRateLimiter(limit int, period time.Duration) *renewRateLimiter {
+	if period <= 0 {
+		period = defaultRenewRateLimitPeriod
+	}
+
+	r := &renewRateLimiter{
+		limit:  limit,
+		period: period,
+	}
+	if limit > 0 {
+		// The size is valid, so this can't fail
+		r.limiters, _ = lru.New(renewRateLimitCacheSize)
+	}
+	return r
+}
+
+// isRenewPath reports whether the request path renews a lease or a token.
+func isRenewPath(path string) bool {
+	return strings.HasPrefix(path, "sys/renew") ||
+		strings.HasPrefix(path, "sys/leases/renew") ||
+		strings.HasPrefix(path, "auth/token/renew")
+}
+
+// allow reports whether a renewal made with the given token may proceed. If
+// the renewal is rejected, audit reports whether the rejection has been
+// sampled for auditing.
+func (r *renewRateLimiter) allow(te *logical.TokenEntry) (allowed bool, audit bool) {
+	if r == nil || r.limiters == nil || te == nil {
+		return true, false
+	}
+
+	// Batch tokens have no accessor
+	key := te.Accessor
+	if key == "" {
+		key = te.ID
+	}
+
+	r.l.Lock()
+	defer r.l.Unlock()
+
+	var limiter *rate.Limiter
+	if raw, ok := r.limiters.Get(key); ok {
+		limiter = raw.(*rate.Limiter)
+	} else {
+		limiter = rate.NewLimiter(rate.Every(r.period/time.Duration(r.limit)), r.limit)
+		r.limiters.Add(key, limiter)
+	}
+
+	if limiter.Allow() {
+		return true, false
+	}
+
+	r.rejected++
+	return false, (r.rejected-1)%renewRateLimitAuditSampleRate == 0
+}
@@ -0,0 +1,47 @@
+package vault
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/logical"
+)
+
+func TestRenewRateLimiter_AuditSampling(t *testing.T) {
+	r := newRenewRateLimiter(1, time.Hour)
+	te := &logical.TokenEntry{ID: "token", Accessor: "accessor"}
+
+	if allowed, _ := r.allow(te); !allowed {
+		t.Fatal("expected first renewal to be allowed")
+	}
+
+	var audited int
+	for i := 0; i < 2*renewRateLimitAuditSampleRate; i++ {
+		allowed, sampled := r.allow(te)
+		if allowed {
+			t.Fatalf("expected renewal %d to be rejected", i)
+		}
+		if i == 0 && !sampled {
+			t.Fatal("expected the first rejection to be audited")
+		}
+		if sampled {
+			audited++
+		}
+	}
+	if audited != 2 {
+		t.Fatalf("expected 2 audited rejections, got %d", audited)
+	}
+
+	// Other tokens are limited separately, batch tokens by their ID
+	if allowed, _ := r.allow(&logical.TokenEntry{ID: "batch"}); !allowed {
+		t.Fatal("expected renewal of another token to be allowed")
+	}
+
+	// A zero limit disables the limiter
+	r = newRenewRateLimiter(0, 0)
+	for i := 0; i < 10; i++ {
+		if allowed, _ := r.allow(te); !allowed {
+			t.Fatal("expected renewals to be allowed without a limit")
+		}
+	}
+}
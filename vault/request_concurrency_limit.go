@@ -0,0 +1,91 @@
+package vault
+
+import (
+	"sync"
+
+	"github.com/hashicorp/vault/logical"
+)
+
+const (
+	// requestConcurrencyLimitAuditSampleRate controls how often rejected
+	// requests are sent to the audit broker, like
+	// renewRateLimitAuditSampleRate.
+	requestConcurrencyLimitAuditSampleRate = 10
+)
+
+// requestConcurrencyLimiter caps the number of requests each client token can
+// have in flight at once, in total and against each mount, so that one client
+// issuing requests in parallel cannot tie up every request handler.
+type requestConcurrencyLimiter struct {
+	l        sync.Mutex
+	limit    int
+	inFlight map[string]int
+	rejected uint64
+}
+
+// newRequestConcurrencyLimiter returns a limiter allowing limit requests in
+// flight per token. A limit of zero or less only enforces the limits tuned on
+// mounts.
+func newRequestConcurrencyLimiter(limit int) *requestConcurrencyLimiter {
+	return &requestConcurrencyLimiter{
+		limit:    limit,
+		inFlight: make(map[string]int),
+	}
+}
+
+// acquire reserves a slot for a request made with the given token against the
+// given mount. If the request is allowed, release must be called once it has
+// been handled. If the request is rejected, audit reports whether the
+// rejection has been sampled for auditing.
+func (r *requestConcurrencyLimiter) acquire(te *logical.TokenEntry, entry *MountEntry) (release func(), allowed bool, audit bool) {
+	var mountLimit int
+	if entry != nil {
+		mountLimit = entry.Config.RequestConcurrencyLimit
+	}
+	if r == nil || te == nil || (r.limit <= 0 && mountLimit <= 0) {
+		return func() {}, true, false
+	}
+
+	// Batch tokens have no accessor
+	tokenKey := te.Accessor
+	if tokenKey == "" {
+		tokenKey = te.ID
+	}
+
+	var keys []string
+	if r.limit > 0 {
+		keys = append(keys, tokenKey)
+	}
+	if mountLimit > 0 {
+		keys = append(keys, tokenKey+"/"+entry.Accessor)
+	}
+
+	r.l.Lock()
+	defer r.l.Unlock()
+
+	if (r.limit > 0 && r.inFlight[tokenKey] >= r.limit) ||
+		(mountLimit > 0 && r.inFlight[keys[len(keys)-1]] >= mountLimit) {
+		r.rejected++
+		return nil, false, (r.rejected-1)%requestConcurrencyLimitAuditSampleRate == 0
+	}
+
+	for _, key := range keys {
+		r.inFlight[key]++
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			r.l.Lock()
+			defer r.l.Unlock()
+
+			for _, key := range keys {
+				if r.inFlight[key] <= 1 {
+					delete(r.inFlight, key)
+					continue
+				}
+				r.inFlight[key]--
+			}
+		})
+	}, true, false
+}
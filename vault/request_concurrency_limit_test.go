@@ -0,0 +1,70 @@
+package vault
+
+import (
+	"testing"
+
+	"github.com/hashicorp/vault/logical"
+)
+
+func TestRequestConcurrencyLimiter(t *testing.T) {
+	r := newRequestConcurrencyLimiter(2)
+	te := &logical.TokenEntry{ID: "token", Accessor: "accessor"}
+	kv := &MountEntry{Accessor: "kv_1234"}
+	pki := &MountEntry{Accessor: "pki_1234", Config: MountConfig{RequestConcurrencyLimit: 1}}
+
+	release1, allowed, _ := r.acquire(te, kv)
+	if !allowed {
+		t.Fatal("expected the first request to be allowed")
+	}
+
+	// The mount limit applies before the global limit is reached
+	release2, allowed, _ := r.acquire(te, pki)
+	if !allowed {
+		t.Fatal("expected the second request to be allowed")
+	}
+	if _, allowed, sampled := r.acquire(te, kv); allowed || !sampled {
+		t.Fatal("expected the global limit to be enforced and the first rejection audited")
+	}
+
+	release1()
+	release1()
+	if _, allowed, _ := r.acquire(te, pki); allowed {
+		t.Fatal("expected the mount limit to be enforced")
+	}
+	release3, allowed, _ := r.acquire(te, kv)
+	if !allowed {
+		t.Fatal("expected a request to be allowed once another one completed")
+	}
+
+	var audited int
+	for i := 0; i < 2*requestConcurrencyLimitAuditSampleRate; i++ {
+		if _, _, sampled := r.acquire(te, kv); sampled {
+			audited++
+		}
+	}
+	if audited != 2 {
+		t.Fatalf("expected 2 audited rejections, got %d", audited)
+	}
+
+	// Other tokens are limited separately, batch tokens by their ID
+	if _, allowed, _ := r.acquire(&logical.TokenEntry{ID: "batch"}, pki); !allowed {
+		t.Fatal("expected a request of another token to be allowed")
+	}
+
+	release2()
+	release3()
+	if len(r.inFlight) != 2 || r.inFlight["accessor"] != 0 {
+		t.Fatalf("expected only the requests of the batch token to be tracked, got: %#v", r.inFlight)
+	}
+
+	// A zero limit only enforces the mount limits
+	r = newRequestConcurrencyLimiter(0)
+	for i := 0; i < 10; i++ {
+		if _, allowed, _ := r.acquire(te, kv); !allowed {
+			t.Fatal("expected requests to be allowed without a limit")
+		}
+	}
+	if len(r.inFlight) != 0 {
+		t.Fatalf("expected no requests to be tracked, got: %#v", r.inFlight)
+	}
+}
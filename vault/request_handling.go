@@ -168,6 +168,11 @@ func (c *Core) fetchACLTokenEntryAndEntity(ctx context.Context, req *logical.Req
 		}
 	}
 
+	// Time window checks bind all tokens except non-expiring root tokens
+	if te.TTL != 0 && te.BoundTimeWindow != nil && !te.BoundTimeWindow.Contains(time.Now()) {
+		return nil, nil, nil, nil, logical.ErrPermissionDenied
+	}
+
 	policies := make(map[string][]string)
 	// Add tokens policies
 	policies[te.NamespaceID] = append(policies[te.NamespaceID], te.Policies...)
@@ -644,9 +649,61 @@ func (c *Core) handleRequest(ctx context.Context, req *logical.Request) (retResp
 		return logical.ErrorResponse(ctErr.Error()), auth, retErr
 	}
 
+	// Throttle the renewals made by the token. Rejections are sampled for
+	// auditing so that a client renewing in a loop cannot flood the audit log.
+	if isRenewPath(req.Path) {
+		if allowed, sampled := c.renewRateLimiter.allow(te); !allowed {
+			metrics.IncrCounter([]string{"core", "renew_rate_limited"}, 1)
+			if sampled && !isControlGroupRun(req) {
+				logInput := &audit.LogInput{
+					Auth:               auth,
+					Request:            req,
+					OuterErr:           logical.ErrRenewRateLimited,
+					NonHMACReqDataKeys: nonHMACReqDataKeys,
+				}
+				if err := c.auditBroker.LogRequest(ctx, logInput, c.auditedHeaders); err != nil {
+					c.logger.Error("failed to audit request", "path", req.Path, "error", err)
+					retErr = multierror.Append(retErr, ErrInternalError)
+					return nil, auth, retErr
+				}
+			}
+			return logical.ErrorResponse(logical.ErrRenewRateLimited.Error()), auth, logical.ErrRenewRateLimited
+		}
+	}
+
+	// Cap the requests the token has in flight, in total and against the
+	// mount. Rejections are sampled for auditing like throttled renewals.
+	release, allowed, sampled := c.requestConcurrencyLimiter.acquire(te, entry)
+	if !allowed {
+		metrics.IncrCounter([]string{"core", "request_concurrency_limited"}, 1)
+		if sampled && !isControlGroupRun(req) {
+			logInput := &audit.LogInput{
+				Auth:               auth,
+				Request:            req,
+				OuterErr:           logical.ErrRequestConcurrencyLimited,
+				NonHMACReqDataKeys: nonHMACReqDataKeys,
+			}
+			if err := c.auditBroker.LogRequest(ctx, logInput, c.auditedHeaders); err != nil {
+				c.logger.Error("failed to audit request", "path", req.Path, "error", err)
+				retErr = multierror.Append(retErr, ErrInternalError)
+				return nil, auth, retErr
+			}
+		}
+		return logical.ErrorResponse(logical.ErrRequestConcurrencyLimited.Error()), auth, logical.ErrRequestConcurrencyLimited
+	}
+	defer release()
+
 	// Attach the display name
 	req.DisplayName = auth.DisplayName
 
+	// Attribute the request to the token's policies, issuing auth mount and
+	// client
+	if te != nil {
+		mountPath := c.tokenMountPath(ctx, te, entry)
+		c.tokenUsage.record(auth.Policies, mountPath)
+		c.activityLog.record(te.EntityID, te.Accessor, mountPath, time.Now())
+	}
+
 	// Create an audit trail of the request
 	if !isControlGroupRun(req) {
 		logInput := &audit.LogInput{
@@ -661,12 +718,22 @@ func (c *Core) handleRequest(ctx context.Context, req *logical.Request) (retResp
 		}
 	}
 
+	// Hold the lease count quota slots reserved by the token store until the
+	// lease of the token is registered
+	ctx, releaseLeaseCountReservations := withLeaseCountReservations(ctx)
+	defer releaseLeaseCountReservations()
+
 	// Route the request
 	resp, routeErr := c.router.Route(ctx, req)
 	// If we're replicating and we get a read-only error from a backend, need to forward to primary
 	if routeErr != nil {
 		resp, routeErr = possiblyForward(ctx, c, req, resp, routeErr)
 	}
+	// Revoke the leases the backend asked for, e.g. from a revoke-all path
+	if routeErr == nil && resp != nil && resp.Data[logical.RevokeLeasesPrefixes] != nil {
+		revokeCtx := namespace.ContextWithNamespace(c.activeContext, ns)
+		resp, routeErr = c.revokeResponseLeases(revokeCtx, entry, resp)
+	}
 	if resp != nil {
 		// If wrapping is used, use the shortest between the request and response
 		var wrapTTL time.Duration
@@ -771,6 +838,23 @@ func (c *Core) handleRequest(ctx context.Context, req *logical.Request) (retResp
 			}
 			resp.Secret.TTL = ttl
 
+			// The secret can't be tracked by a lease if the path reached its
+			// lease count quota, so it is revoked right away. If that fails,
+			// it is registered anyway for the expiration manager to retry
+			// revoking it, so that it isn't left behind.
+			release, quota := c.reserveLeaseCountQuota(ctx, req.Path)
+			defer release()
+			if quota != nil {
+				resp.Secret.IssueTime = time.Now()
+				if revokeResp, err := c.router.Route(ctx, logical.RevokeRequest(req.Path, resp.Secret, resp.Data)); err != nil || (revokeResp != nil && revokeResp.IsError()) {
+					c.logger.Error("failed to revoke secret over lease count quota, queueing its revocation", "request_path", req.Path, "error", err)
+					if err := c.queueLeaseRevocation(ctx, req, resp); err != nil {
+						c.logger.Error("failed to queue revocation of secret over lease count quota", "request_path", req.Path, "error", err)
+					}
+				}
+				return leaseCountQuotaResponse(quota), auth, logical.ErrLeaseCountQuotaExceeded
+			}
+
 			registerFunc, funcGetErr := getLeaseRegisterFunc(c)
 			if funcGetErr != nil {
 				retErr = multierror.Append(retErr, funcGetErr)
@@ -869,6 +953,65 @@ func (c *Core) handleRequest(ctx context.Context, req *logical.Request) (retResp
 	return resp, auth, retErr
 }
 
+// revokeResponseLeases revokes the leases of the mount of the request under
+// the prefixes the backend returned in the RevokeLeasesPrefixes key of the
+// response, and removes the key from the response. Prefixes are relative to
+// the mount, so that backends can't revoke the leases of other mounts.
+func (c *Core) revokeResponseLeases(ctx context.Context, entry *MountEntry, resp *logical.Response) (*logical.Response, error) {
+	if entry == nil {
+		return nil, errors.New("no mount found for revocation of leases")
+	}
+
+	var prefixes []string
+	switch raw := resp.Data[logical.RevokeLeasesPrefixes].(type) {
+	case []string:
+		prefixes = raw
+	case []interface{}:
+		// Responses of plugins are JSON decoded
+		for _, p := range raw {
+			prefix, ok := p.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid lease prefix %v", p)
+			}
+			prefixes = append(prefixes, prefix)
+		}
+	default:
+		return nil, fmt.Errorf("invalid lease prefixes %v", raw)
+	}
+	delete(resp.Data, logical.RevokeLeasesPrefixes)
+
+	for _, prefix := range prefixes {
+		if prefix == "" || !strings.HasSuffix(prefix, "/") || strings.Contains(prefix, "..") {
+			return nil, fmt.Errorf("invalid lease prefix %q", prefix)
+		}
+
+		if err := c.expiration.RevokePrefix(ctx, entry.Path+prefix, true); err != nil {
+			c.logger.Error("failed to revoke leases", "prefix", entry.Path+prefix, "error", err)
+			return nil, err
+		}
+	}
+
+	if len(resp.Data) == 0 && len(resp.Warnings) == 0 {
+		return nil, nil
+	}
+	return resp, nil
+}
+
+// tokenMountPath returns the path of the auth mount that issued the token.
+// Mounts can't be nested, so the entry of the request mount is reused when the
+// token was issued by the same mount.
+func (c *Core) tokenMountPath(ctx context.Context, te *logical.TokenEntry, entry *MountEntry) string {
+	if entry != nil {
+		if ns, err := namespace.FromContext(ctx); err == nil {
+			mountPath := entry.APIPath()
+			if strings.HasPrefix(ns.Path+te.Path, mountPath) {
+				return mountPath
+			}
+		}
+	}
+	return c.router.MatchingMount(ctx, te.Path)
+}
+
 // handleLoginRequest is used to handle a login request, which is an
 // unauthenticated request to the backend.
 func (c *Core) handleLoginRequest(ctx context.Context, req *logical.Request) (retResp *logical.Response, retAuth *logical.Auth, retErr error) {
@@ -924,6 +1067,80 @@ func (c *Core) handleLoginRequest(ctx context.Context, req *logical.Request) (re
 		return logical.ErrorResponse(ctErr.Error()), auth, retErr
 	}
 
+	// Resolve the alias the login would authenticate once, for both the
+	// login rate limit and the user lockout of the auth mount
+	var loginAlias string
+	loginEntry := c.router.MatchingMountEntry(ctx, req.Path)
+	if loginEntry != nil && (loginEntry.Config.LoginRateLimit > 0 || loginEntry.Config.UserLockoutThreshold > 0) {
+		loginAlias = c.loginAliasLookahead(ctx, req)
+	}
+
+	// Throttle the login if the auth mount has a login rate limit. Attempts
+	// are counted against the alias the auth method resolves, and rejections
+	// are sampled for auditing so that a guessing attack cannot flood the
+	// audit log.
+	if entry := loginEntry; entry != nil && entry.Config.LoginRateLimit > 0 {
+		var remoteAddr string
+		if req.Connection != nil {
+			remoteAddr = req.Connection.RemoteAddr
+		}
+		allowed, sampled := c.loginRateLimiter.allow(entry, loginAlias, remoteAddr)
+		if !allowed {
+			metrics.IncrCounter([]string{"core", "login_rate_limited"}, 1)
+			if sampled {
+				var nonHMACReqDataKeys []string
+				if rawVals, ok := entry.synthesizedConfigCache.Load("audit_non_hmac_request_keys"); ok {
+					nonHMACReqDataKeys = rawVals.([]string)
+				}
+				logInput := &audit.LogInput{
+					Auth:               auth,
+					Request:            req,
+					OuterErr:           logical.ErrLoginRateLimited,
+					NonHMACReqDataKeys: nonHMACReqDataKeys,
+				}
+				if err := c.auditBroker.LogRequest(ctx, logInput, c.auditedHeaders); err != nil {
+					c.logger.Error("failed to audit request", "path", req.Path, "error", err)
+					return nil, nil, ErrInternalError
+				}
+			}
+			return logical.ErrorResponse(logical.ErrLoginRateLimited.Error()), nil, logical.ErrLoginRateLimited
+		}
+	}
+
+	// Reject the login if the alias it would authenticate is locked out of
+	// the auth mount after repeated failed attempts. The credentials are not
+	// checked, and the rejection is indistinguishable from a failed login.
+	var lockoutEntry *MountEntry
+	var lockoutAlias string
+	if entry := loginEntry; entry != nil && entry.Config.UserLockoutThreshold > 0 {
+		if alias := loginAlias; alias != "" {
+			lockoutEntry, lockoutAlias = entry, alias
+			locked, err := c.isUserLocked(ctx, entry, alias)
+			if err != nil {
+				c.logger.Error("failed to check user lockout", "path", req.Path, "error", err)
+				return nil, nil, ErrInternalError
+			}
+			if locked {
+				metrics.IncrCounter([]string{"core", "login_locked_out"}, 1)
+				var nonHMACReqDataKeys []string
+				if rawVals, ok := entry.synthesizedConfigCache.Load("audit_non_hmac_request_keys"); ok {
+					nonHMACReqDataKeys = rawVals.([]string)
+				}
+				logInput := &audit.LogInput{
+					Auth:               auth,
+					Request:            req,
+					OuterErr:           logical.ErrPermissionDenied,
+					NonHMACReqDataKeys: nonHMACReqDataKeys,
+				}
+				if err := c.auditBroker.LogRequest(ctx, logInput, c.auditedHeaders); err != nil {
+					c.logger.Error("failed to audit request", "path", req.Path, "error", err)
+					return nil, nil, ErrInternalError
+				}
+				return logical.ErrorResponse(logical.ErrPermissionDenied.Error()), nil, logical.ErrPermissionDenied
+			}
+		}
+	}
+
 	// Create an audit trail of the request. Attach auth if it was returned,
 	// e.g. if a token was provided.
 	logInput := &audit.LogInput{
@@ -948,6 +1165,26 @@ func (c *Core) handleLoginRequest(ctx context.Context, req *logical.Request) (re
 	if routeErr != nil {
 		resp, routeErr = possiblyForward(ctx, c, req, resp, routeErr)
 	}
+
+	// Track the outcome of the login for the user lockout. Only rejected
+	// credentials count as failed attempts, not internal errors. Logins
+	// accepted by the auth method are only successful once their MFA is
+	// validated below.
+	recordLockoutAttempt := func(failed bool) {
+		if lockoutAlias == "" {
+			return
+		}
+		if err := c.recordLoginAttempt(ctx, lockoutEntry, lockoutAlias, failed); err != nil {
+			c.logger.Error("failed to record login attempt for user lockout", "path", req.Path, "error", err)
+		}
+	}
+	if resp == nil || resp.Auth == nil {
+		if (routeErr == nil && resp != nil && resp.IsError()) ||
+			errwrap.Contains(routeErr, logical.ErrPermissionDenied.Error()) ||
+			errwrap.Contains(routeErr, logical.ErrInvalidRequest.Error()) {
+			recordLockoutAttempt(true)
+		}
+	}
 	if resp != nil {
 		// If wrapping is used, use the shortest between the request and response
 		var wrapTTL time.Duration
@@ -1045,6 +1282,19 @@ func (c *Core) handleLoginRequest(ctx context.Context, req *logical.Request) (re
 			}
 		}
 
+		// Validate the second factors of the login MFA enforcements targeting
+		// the login before issuing a token
+		userErr, err := c.enforceLoginMFA(ctx, req, entity)
+		if err != nil {
+			c.logger.Error("failed to enforce login MFA", "request_path", req.Path, "error", err)
+			return nil, nil, ErrInternalError
+		}
+		if userErr != nil {
+			recordLockoutAttempt(true)
+			return logical.ErrorResponse(userErr.Error()), nil, logical.ErrPermissionDenied
+		}
+		recordLockoutAttempt(false)
+
 		// Determine the source of the login
 		source := c.router.MatchingMount(ctx, req.Path)
 		source = strings.TrimPrefix(source, credentialRoutePrefix)
@@ -1091,6 +1341,16 @@ func (c *Core) handleLoginRequest(ctx context.Context, req *logical.Request) (re
 			}
 		}
 
+		// Batch tokens have no lease, so only service tokens are held to the
+		// lease count quotas
+		if auth.TokenType != logical.TokenTypeBatch {
+			release, quota := c.reserveLeaseCountQuota(ctx, req.Path)
+			defer release()
+			if quota != nil {
+				return leaseCountQuotaResponse(quota), nil, logical.ErrLeaseCountQuotaExceeded
+			}
+		}
+
 		var registerFunc RegisterAuthFunc
 		var funcGetErr error
 		// Batch tokens should not be forwarded to perf standby
@@ -1104,6 +1364,26 @@ func (c *Core) handleLoginRequest(ctx context.Context, req *logical.Request) (re
 			return nil, auth, retErr
 		}
 
+		// Let the auth method apply the side effects of the login, now that
+		// it was accepted, including its MFA
+		if auth.LoginCommit {
+			commitResp, err := c.router.Route(ctx, &logical.Request{
+				MountAccessor: req.MountAccessor,
+				Path:          req.Path,
+				Operation:     logical.LoginCommitOperation,
+				Data:          req.Data,
+				Connection:    req.Connection,
+				Headers:       req.Headers,
+			})
+			if err != nil {
+				c.logger.Error("failed to commit login", "request_path", req.Path, "error", err)
+				return nil, nil, ErrInternalError
+			}
+			if commitResp != nil && commitResp.IsError() {
+				return commitResp, nil, logical.ErrInvalidRequest
+			}
+		}
+
 		err = registerFunc(ctx, tokenTTL, req.Path, auth)
 		switch {
 		case err == nil:
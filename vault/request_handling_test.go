@@ -1,13 +1,18 @@
 package vault
 
 import (
+	"context"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/hashicorp/errwrap"
 	uuid "github.com/hashicorp/go-uuid"
+	credAppRole "github.com/hashicorp/vault/builtin/credential/approle"
 	credUserpass "github.com/hashicorp/vault/builtin/credential/userpass"
 	"github.com/hashicorp/vault/helper/namespace"
 	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
 )
 
 func TestRequestHandling_Wrapping(t *testing.T) {
@@ -63,6 +68,54 @@ func TestRequestHandling_Wrapping(t *testing.T) {
 	}
 }
 
+func TestRequestHandling_WrappingTTLPolicy(t *testing.T) {
+	core, _, root := TestCoreUnsealed(t)
+
+	policy, err := ParseACLPolicy(namespace.RootNamespace, `
+path "secret/wrapped" {
+	capabilities = ["read"]
+	min_wrapping_ttl = "10s"
+	max_wrapping_ttl = "60s"
+}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	policy.Name = "wrapped"
+	if err := core.policyStore.SetPolicy(namespace.RootContext(nil), policy); err != nil {
+		t.Fatal(err)
+	}
+	testMakeServiceTokenViaCore(t, core, root, "wrappedClient", "", []string{"wrapped"})
+
+	cases := []struct {
+		wrapTTL time.Duration
+		allowed bool
+	}{
+		{0, false},
+		{5 * time.Second, false},
+		{90 * time.Second, false},
+		{30 * time.Second, true},
+	}
+	for _, tc := range cases {
+		req := &logical.Request{
+			Path:        "secret/wrapped",
+			ClientToken: "wrappedClient",
+			Operation:   logical.ReadOperation,
+		}
+		if tc.wrapTTL > 0 {
+			req.WrapInfo = &logical.RequestWrapInfo{
+				TTL: tc.wrapTTL,
+			}
+		}
+		_, err := core.HandleRequest(namespace.RootContext(nil), req)
+		if tc.allowed && err != nil {
+			t.Fatalf("expected a wrapping TTL of %s to be allowed: %v", tc.wrapTTL, err)
+		}
+		if !tc.allowed && !errwrap.Contains(err, logical.ErrPermissionDenied.Error()) {
+			t.Fatalf("expected a wrapping TTL of %s to be denied, got %v", tc.wrapTTL, err)
+		}
+	}
+}
+
 func TestRequestHandling_LoginWrapping(t *testing.T) {
 	core, _, root := TestCoreUnsealed(t)
 
@@ -144,3 +197,496 @@ func TestRequestHandling_LoginWrapping(t *testing.T) {
 		t.Fatalf("bad: %#v", resp)
 	}
 }
+
+func TestRequestHandling_LoginRateLimit(t *testing.T) {
+	core, _, root := TestCoreUnsealed(t)
+
+	if err := core.loadMounts(namespace.RootContext(nil)); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	core.credentialBackends["userpass"] = credUserpass.Factory
+
+	req := &logical.Request{
+		Path:        "sys/auth/userpass",
+		ClientToken: root,
+		Operation:   logical.UpdateOperation,
+		Data: map[string]interface{}{
+			"type": "userpass",
+		},
+		Connection: &logical.Connection{},
+	}
+	resp, err := core.HandleRequest(namespace.RootContext(nil), req)
+	if err != nil || resp != nil {
+		t.Fatalf("bad: resp: %#v, err: %v", resp, err)
+	}
+
+	for _, user := range []string{"test", "other"} {
+		req.Path = "auth/userpass/users/" + user
+		req.Data = map[string]interface{}{
+			"password": "foo",
+			"policies": "default",
+		}
+		resp, err = core.HandleRequest(namespace.RootContext(nil), req)
+		if err != nil || resp != nil {
+			t.Fatalf("bad: resp: %#v, err: %v", resp, err)
+		}
+	}
+
+	req.Path = "sys/auth/userpass/tune"
+	req.Data = map[string]interface{}{
+		"login_rate_limit":        2,
+		"login_rate_limit_period": "1h",
+	}
+	resp, err = core.HandleRequest(namespace.RootContext(nil), req)
+	if err != nil || resp != nil {
+		t.Fatalf("bad: resp: %#v, err: %v", resp, err)
+	}
+
+	req.Operation = logical.ReadOperation
+	req.Data = nil
+	resp, err = core.HandleRequest(namespace.RootContext(nil), req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Data["login_rate_limit"] != 2 || resp.Data["login_rate_limit_period"] != 3600 {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	login := func(user, addr string) error {
+		_, err := core.HandleRequest(namespace.RootContext(nil), &logical.Request{
+			Path:      "auth/userpass/login/" + user,
+			Operation: logical.UpdateOperation,
+			Data: map[string]interface{}{
+				"password": "foo",
+			},
+			Connection: &logical.Connection{
+				RemoteAddr: addr,
+			},
+		})
+		return err
+	}
+
+	// Two attempts are allowed, the third is rejected
+	for i := 0; i < 2; i++ {
+		if err := login("test", "127.0.0.1"); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+	if err := login("test", "127.0.0.1"); err != logical.ErrLoginRateLimited {
+		t.Fatalf("expected rate limit error, got: %v", err)
+	}
+
+	// The alias is limited regardless of the client address
+	if err := login("test", "127.0.0.2"); err != logical.ErrLoginRateLimited {
+		t.Fatalf("expected rate limit error, got: %v", err)
+	}
+
+	// The client address is limited regardless of the alias
+	if err := login("other", "127.0.0.1"); err != logical.ErrLoginRateLimited {
+		t.Fatalf("expected rate limit error, got: %v", err)
+	}
+
+	// A different alias from a different address is allowed
+	if err := login("other", "127.0.0.3"); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Disabling the limit allows logins again
+	req.Operation = logical.UpdateOperation
+	req.Data = map[string]interface{}{
+		"login_rate_limit": 0,
+	}
+	resp, err = core.HandleRequest(namespace.RootContext(nil), req)
+	if err != nil || resp != nil {
+		t.Fatalf("bad: resp: %#v, err: %v", resp, err)
+	}
+	if err := login("test", "127.0.0.1"); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+}
+
+func TestRequestHandling_LoginRateLimit_Alias(t *testing.T) {
+	core, _, root := TestCoreUnsealed(t)
+
+	core.credentialBackends["approle"] = credAppRole.Factory
+
+	req := &logical.Request{
+		Path:        "sys/auth/approle",
+		ClientToken: root,
+		Operation:   logical.UpdateOperation,
+		Data: map[string]interface{}{
+			"type": "approle",
+		},
+		Connection: &logical.Connection{},
+	}
+	resp, err := core.HandleRequest(namespace.RootContext(nil), req)
+	if err != nil || resp != nil {
+		t.Fatalf("bad: resp: %#v, err: %v", resp, err)
+	}
+
+	req.Path = "sys/auth/approle/tune"
+	req.Data = map[string]interface{}{
+		"login_rate_limit": 1,
+	}
+	resp, err = core.HandleRequest(namespace.RootContext(nil), req)
+	if err != nil || resp != nil {
+		t.Fatalf("bad: resp: %#v, err: %v", resp, err)
+	}
+
+	// The mount listing reports the default period like the tune endpoint
+	resp, err = core.HandleRequest(namespace.RootContext(nil), &logical.Request{
+		Path:        "sys/auth",
+		ClientToken: root,
+		Operation:   logical.ReadOperation,
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	config := resp.Data["approle/"].(map[string]interface{})["config"].(map[string]interface{})
+	if config["login_rate_limit_period"] != int64(defaultLoginRateLimitPeriod.Seconds()) {
+		t.Fatalf("bad: %#v", config)
+	}
+
+	// Every AppRole login goes to the same path, so attempts must be counted
+	// against the role ID rather than the path
+	login := func(roleID, addr string) error {
+		_, err := core.HandleRequest(namespace.RootContext(nil), &logical.Request{
+			Path:      "auth/approle/login",
+			Operation: logical.UpdateOperation,
+			Data: map[string]interface{}{
+				"role_id":   roleID,
+				"secret_id": "bogus",
+			},
+			Connection: &logical.Connection{
+				RemoteAddr: addr,
+			},
+		})
+		return err
+	}
+
+	if err := login("role1", "127.0.0.1"); err == logical.ErrLoginRateLimited {
+		t.Fatal("expected the first attempt not to be rate limited")
+	}
+	if err := login("role1", "127.0.0.2"); err != logical.ErrLoginRateLimited {
+		t.Fatalf("expected rate limit error, got: %v", err)
+	}
+	if err := login("role2", "127.0.0.3"); err == logical.ErrLoginRateLimited {
+		t.Fatal("expected another role not to be rate limited")
+	}
+}
+
+func TestRequestHandling_UserLockout(t *testing.T) {
+	core, _, root := TestCoreUnsealed(t)
+
+	if err := core.loadMounts(namespace.RootContext(nil)); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	core.credentialBackends["userpass"] = credUserpass.Factory
+
+	request := func(op logical.Operation, path string, data map[string]interface{}) (*logical.Response, error) {
+		return core.HandleRequest(namespace.RootContext(nil), &logical.Request{
+			Path:        path,
+			ClientToken: root,
+			Operation:   op,
+			Data:        data,
+			Connection:  &logical.Connection{},
+		})
+	}
+
+	resp, err := request(logical.UpdateOperation, "sys/auth/userpass", map[string]interface{}{
+		"type": "userpass",
+	})
+	if err != nil || resp != nil {
+		t.Fatalf("bad: resp: %#v, err: %v", resp, err)
+	}
+	resp, err = request(logical.UpdateOperation, "auth/userpass/users/test", map[string]interface{}{
+		"password": "foo",
+		"policies": "default",
+	})
+	if err != nil || resp != nil {
+		t.Fatalf("bad: resp: %#v, err: %v", resp, err)
+	}
+
+	resp, err = request(logical.UpdateOperation, "sys/auth/userpass/tune", map[string]interface{}{
+		"user_lockout_threshold": 2,
+		"user_lockout_duration":  "1h",
+	})
+	if err != nil || resp != nil {
+		t.Fatalf("bad: resp: %#v, err: %v", resp, err)
+	}
+	resp, err = request(logical.ReadOperation, "sys/auth/userpass/tune", nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Data["user_lockout_threshold"] != 2 || resp.Data["user_lockout_duration"] != 3600 || resp.Data["user_lockout_counter_reset"] != 900 {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	resp, err = request(logical.UpdateOperation, "sys/auth/token/tune", map[string]interface{}{
+		"user_lockout_threshold": 2,
+	})
+	if !errwrap.Contains(err, logical.ErrInvalidRequest.Error()) {
+		t.Fatalf("expected user lockout to be rejected on the token mount: resp: %#v, err: %v", resp, err)
+	}
+
+	login := func(password string) (*logical.Response, error) {
+		return core.HandleRequest(namespace.RootContext(nil), &logical.Request{
+			Path:      "auth/userpass/login/test",
+			Operation: logical.UpdateOperation,
+			Data: map[string]interface{}{
+				"password": password,
+			},
+			Connection: &logical.Connection{},
+		})
+	}
+
+	for i := 0; i < 2; i++ {
+		if resp, _ := login("bar"); resp == nil || !resp.IsError() {
+			t.Fatalf("expected the login to fail: %#v", resp)
+		}
+	}
+
+	// The valid password is rejected once the user is locked out
+	if _, err := login("foo"); !errwrap.Contains(err, logical.ErrPermissionDenied.Error()) {
+		t.Fatalf("expected permission denied, got: %v", err)
+	}
+
+	resp, err = request(logical.ReadOperation, "sys/locked-users", nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Data["total"] != 1 {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+	byMount := resp.Data["by_mount"].([]map[string]interface{})
+	if byMount[0]["mount_path"] != "auth/userpass/" {
+		t.Fatalf("bad: %#v", byMount)
+	}
+	aliases := byMount[0]["aliases"].([]map[string]interface{})
+	if len(aliases) != 1 || aliases[0]["alias"] != "test" {
+		t.Fatalf("bad: %#v", aliases)
+	}
+	accessor := byMount[0]["mount_accessor"].(string)
+
+	resp, err = request(logical.UpdateOperation, "sys/locked-users/"+accessor+"/unlock/test", nil)
+	if err != nil || resp != nil {
+		t.Fatalf("bad: resp: %#v, err: %v", resp, err)
+	}
+	if resp, err := login("foo"); err != nil || resp == nil || resp.Auth == nil {
+		t.Fatalf("bad: resp: %#v, err: %v", resp, err)
+	}
+
+	// Unlocking is idempotent
+	resp, err = request(logical.UpdateOperation, "sys/locked-users/"+accessor+"/unlock/test", nil)
+	if err != nil || resp != nil {
+		t.Fatalf("bad: resp: %#v, err: %v", resp, err)
+	}
+	resp, err = request(logical.ReadOperation, "sys/locked-users", nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Data["total"] != 0 {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+}
+
+func TestRequestHandling_RevokeAll(t *testing.T) {
+	core, _, root := TestCoreUnsealed(t)
+
+	var l sync.Mutex
+	var revoked []string
+	core.logicalBackends["revoketest"] = func(ctx context.Context, conf *logical.BackendConfig) (logical.Backend, error) {
+		b := &framework.Backend{
+			Paths: []*framework.Path{
+				&framework.Path{
+					Pattern: "creds/" + framework.GenericNameRegex("name"),
+					Fields: map[string]*framework.FieldSchema{
+						"name": &framework.FieldSchema{Type: framework.TypeString},
+					},
+					Callbacks: map[logical.Operation]framework.OperationFunc{
+						logical.ReadOperation: func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+							resp := &logical.Response{
+								Secret: &logical.Secret{
+									InternalData: map[string]interface{}{
+										"secret_type": "test",
+										"name":        d.Get("name").(string),
+									},
+								},
+							}
+							resp.Secret.TTL = time.Hour
+							return resp, nil
+						},
+					},
+				},
+				framework.PathRevokeAll(),
+			},
+			Secrets: []*framework.Secret{
+				&framework.Secret{
+					Type: "test",
+					Revoke: func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+						l.Lock()
+						defer l.Unlock()
+						revoked = append(revoked, req.Secret.InternalData["name"].(string))
+						return nil, nil
+					},
+				},
+			},
+			BackendType: logical.TypeLogical,
+		}
+		if err := b.Setup(ctx, conf); err != nil {
+			return nil, err
+		}
+		return b, nil
+	}
+
+	meUUID, _ := uuid.GenerateUUID()
+	err := core.mount(namespace.RootContext(nil), &MountEntry{
+		Table: mountTableType,
+		UUID:  meUUID,
+		Path:  "revoketest",
+		Type:  "revoketest",
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	var leaseIDs []string
+	for _, name := range []string{"foo", "foo", "foobar"} {
+		resp, err := core.HandleRequest(namespace.RootContext(nil), &logical.Request{
+			Path:        "revoketest/creds/" + name,
+			ClientToken: root,
+			Operation:   logical.ReadOperation,
+		})
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if resp == nil || resp.Secret == nil || resp.Secret.LeaseID == "" {
+			t.Fatalf("bad: %#v", resp)
+		}
+		leaseIDs = append(leaseIDs, resp.Secret.LeaseID)
+	}
+
+	resp, err := core.HandleRequest(namespace.RootContext(nil), &logical.Request{
+		Path:        "revoketest/creds/foo/revoke-all",
+		ClientToken: root,
+		Operation:   logical.UpdateOperation,
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp != nil {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	l.Lock()
+	defer l.Unlock()
+	if len(revoked) != 2 || revoked[0] != "foo" || revoked[1] != "foo" {
+		t.Fatalf("bad: %v", revoked)
+	}
+
+	// The leases of other roles are kept
+	for i, leaseID := range leaseIDs {
+		le, err := core.expiration.loadEntry(namespace.RootContext(nil), leaseID)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if (le != nil) != (i == 2) {
+			t.Fatalf("bad lease %q: %#v", leaseID, le)
+		}
+	}
+}
+
+func TestRequestHandling_RenewRateLimit(t *testing.T) {
+	core, _, root := TestCoreUnsealed(t)
+	core.renewRateLimiter = newRenewRateLimiter(2, time.Hour)
+
+	createToken := func() string {
+		resp, err := core.HandleRequest(namespace.RootContext(nil), &logical.Request{
+			Path:        "auth/token/create",
+			ClientToken: root,
+			Operation:   logical.UpdateOperation,
+			Data: map[string]interface{}{
+				"ttl": "1h",
+			},
+		})
+		if err != nil || resp == nil || resp.Auth == nil {
+			t.Fatalf("bad: resp: %#v, err: %v", resp, err)
+		}
+		return resp.Auth.ClientToken
+	}
+
+	renew := func(token string) error {
+		_, err := core.HandleRequest(namespace.RootContext(nil), &logical.Request{
+			Path:        "auth/token/renew-self",
+			ClientToken: token,
+			Operation:   logical.UpdateOperation,
+		})
+		return err
+	}
+
+	// Two renewals are allowed, the third is rejected
+	token := createToken()
+	for i := 0; i < 2; i++ {
+		if err := renew(token); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+	if err := renew(token); err != logical.ErrRenewRateLimited {
+		t.Fatalf("expected renewal to be rate limited, got: %v", err)
+	}
+
+	// Other tokens aren't affected
+	if err := renew(createToken()); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+}
+
+func TestRequestHandling_RequestConcurrencyLimit(t *testing.T) {
+	core, _, root := TestCoreUnsealed(t)
+
+	request := func(path string, operation logical.Operation, data map[string]interface{}) (*logical.Response, error) {
+		return core.HandleRequest(namespace.RootContext(nil), &logical.Request{
+			Path:        path,
+			ClientToken: root,
+			Operation:   operation,
+			Data:        data,
+		})
+	}
+
+	resp, err := request("sys/mounts/secret/tune", logical.UpdateOperation, map[string]interface{}{
+		"request_concurrency_limit": 1,
+	})
+	if err != nil || resp != nil {
+		t.Fatalf("bad: resp: %#v, err: %v", resp, err)
+	}
+	resp, err = request("sys/mounts/secret/tune", logical.ReadOperation, nil)
+	if err != nil || resp.Data["request_concurrency_limit"] != 1 {
+		t.Fatalf("bad: resp: %#v, err: %v", resp, err)
+	}
+
+	// Hold the only slot of the token on the mount, as a request in flight
+	te, err := core.tokenStore.Lookup(namespace.RootContext(nil), root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	release, allowed, _ := core.requestConcurrencyLimiter.acquire(te, core.router.MatchingMountEntry(namespace.RootContext(nil), "secret/"))
+	if !allowed {
+		t.Fatal("expected the first request to be allowed")
+	}
+
+	if _, err := request("secret/foo", logical.ReadOperation, nil); err != logical.ErrRequestConcurrencyLimited {
+		t.Fatalf("expected the request to be limited, got: %v", err)
+	}
+
+	// Requests against other mounts aren't affected
+	if _, err := request("sys/mounts", logical.ReadOperation, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	release()
+	if _, err := request("secret/foo", logical.ReadOperation, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+}
@@ -0,0 +1,121 @@
+package vault
+
+import (
+	"sync"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/hashicorp/vault/logical"
+)
+
+const (
+	// responseCacheTTL is how long a cached response is served before the
+	// backend is asked again. It bounds the staleness of responses changed
+	// without a request to the mount, e.g. by replication.
+	responseCacheTTL = 5 * time.Second
+
+	// responseCacheSize bounds the number of responses cached per mount.
+	responseCacheSize = 1024
+)
+
+// responseCache holds the responses to unauthenticated reads of the paths a
+// backend marks as cacheable through its CachedResponses special paths.
+type responseCache struct {
+	l       sync.Mutex
+	entries *lru.Cache
+
+	// generation is incremented on each purge, so that a read that raced
+	// with a write doesn't cache what it read before the write
+	generation uint64
+}
+
+type responseCacheEntry struct {
+	resp    *logical.Response
+	expires time.Time
+}
+
+func newResponseCache() (*responseCache, error) {
+	entries, err := lru.New(responseCacheSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &responseCache{
+		entries: entries,
+	}, nil
+}
+
+// get returns the cached response for the given path within the mount, if
+// any and it hasn't expired, along with the current generation of the cache
+// to pass to put when the response has to be fetched from the backend.
+func (c *responseCache) get(path string) (*logical.Response, uint64) {
+	c.l.Lock()
+	defer c.l.Unlock()
+
+	raw, ok := c.entries.Get(path)
+	if !ok {
+		return nil, c.generation
+	}
+
+	entry := raw.(*responseCacheEntry)
+	if time.Now().After(entry.expires) {
+		c.entries.Remove(path)
+		return nil, c.generation
+	}
+
+	return copyCachedResponse(entry.resp), c.generation
+}
+
+// put caches the response for the given path within the mount, unless the
+// cache was purged since the given generation. Only plain successful
+// responses are cached; anything carrying a secret, auth, or wrapping
+// information is always returned by the backend.
+func (c *responseCache) put(path string, generation uint64, resp *logical.Response) {
+	if resp == nil || resp.IsError() || resp.Secret != nil || resp.Auth != nil || resp.WrapInfo != nil || resp.Redirect != "" {
+		return
+	}
+
+	c.l.Lock()
+	defer c.l.Unlock()
+
+	if c.generation != generation {
+		return
+	}
+
+	c.entries.Add(path, &responseCacheEntry{
+		resp:    copyCachedResponse(resp),
+		expires: time.Now().Add(responseCacheTTL),
+	})
+}
+
+// purge drops all cached responses of the mount.
+func (c *responseCache) purge() {
+	c.l.Lock()
+	defer c.l.Unlock()
+
+	c.generation++
+	c.entries.Purge()
+}
+
+// copyCachedResponse returns a copy of the response that can be modified
+// by the caller without affecting the cached one. Values in the data map
+// are shared, as they aren't modified once the backend has returned them.
+func copyCachedResponse(resp *logical.Response) *logical.Response {
+	ret := &logical.Response{
+		Warnings: append([]string(nil), resp.Warnings...),
+	}
+	if resp.Data != nil {
+		ret.Data = make(map[string]interface{}, len(resp.Data))
+		for k, v := range resp.Data {
+			ret.Data[k] = v
+		}
+	}
+	if resp.Headers != nil {
+		ret.Headers = make(map[string][]string, len(resp.Headers))
+		for k, v := range resp.Headers {
+			ret.Headers[k] = append([]string(nil), v...)
+		}
+	}
+
+	return ret
+}
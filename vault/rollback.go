@@ -10,6 +10,7 @@ import (
 	metrics "github.com/armon/go-metrics"
 	log "github.com/hashicorp/go-hclog"
 
+	"github.com/hashicorp/vault/helper/logging"
 	"github.com/hashicorp/vault/helper/namespace"
 	"github.com/hashicorp/vault/logical"
 )
@@ -265,7 +266,7 @@ func (c *Core) startRollback() error {
 		}
 		return ret
 	}
-	rollbackLogger := c.baseLogger.Named("rollback")
+	rollbackLogger := logging.NewSubsystemLogger(c.baseLogger, "rollback")
 	c.AddLogger(rollbackLogger)
 	c.rollback = NewRollbackManager(c.activeContext, rollbackLogger, backendsFunc, c.router, c)
 	c.rollback.Start()
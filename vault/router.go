@@ -34,6 +34,9 @@ type Router struct {
 	// to the backend. This is used to map a key back into the backend that owns it.
 	// For example, logical/uuid1/foobar -> secrets/ (kv backend) + foobar
 	storagePrefix *radix.Tree
+
+	// wellKnown holds the paths below /.well-known/ claimed by the mounts
+	wellKnown *wellKnownRedirects
 }
 
 // NewRouter returns a new router
@@ -43,6 +46,7 @@ func NewRouter() *Router {
 		storagePrefix:      radix.New(),
 		mountUUIDCache:     radix.New(),
 		mountAccessorCache: radix.New(),
+		wellKnown:          newWellKnownRedirects(),
 	}
 	return r
 }
@@ -56,6 +60,8 @@ type routeEntry struct {
 	storagePrefix string
 	rootPaths     atomic.Value
 	loginPaths    atomic.Value
+	cachedPaths   atomic.Value
+	responseCache *responseCache
 	l             sync.RWMutex
 }
 
@@ -119,6 +125,11 @@ func (r *Router) Mount(backend logical.Backend, prefix string, mountEntry *Mount
 		}
 	}
 
+	responseCache, err := newResponseCache()
+	if err != nil {
+		return err
+	}
+
 	// Create a mount entry
 	re := &routeEntry{
 		tainted:       false,
@@ -126,9 +137,11 @@ func (r *Router) Mount(backend logical.Backend, prefix string, mountEntry *Mount
 		mountEntry:    mountEntry,
 		storagePrefix: storageView.Prefix(),
 		storageView:   storageView,
+		responseCache: responseCache,
 	}
 	re.rootPaths.Store(pathsToRadix(paths.Root))
 	re.loginPaths.Store(pathsToRadix(paths.Unauthenticated))
+	re.cachedPaths.Store(pathsToRadix(paths.CachedResponses))
 
 	switch {
 	case prefix == "":
@@ -177,6 +190,7 @@ func (r *Router) Unmount(ctx context.Context, prefix string) error {
 	r.storagePrefix.Delete(re.storagePrefix)
 	r.mountUUIDCache.Delete(re.mountEntry.UUID)
 	r.mountAccessorCache.Delete(re.mountEntry.Accessor)
+	r.wellKnown.deregisterMount(re.mountEntry.UUID)
 
 	return nil
 }
@@ -539,6 +553,9 @@ func (r *Router) routeCommon(ctx context.Context, req *logical.Request, existenc
 	switch {
 	case strings.HasPrefix(originalPath, "auth/token/"):
 	case strings.HasPrefix(originalPath, "sys/"):
+	case strings.HasPrefix(originalPath, "identity/"+oidcIDPPathPrefix):
+		// The unauthenticated OIDC provider endpoints verify the OIDC access
+		// token sent as the bearer token themselves
 	case strings.HasPrefix(originalPath, cubbyholeMountPath):
 		if req.Operation == logical.RollbackOperation {
 			// Backend doesn't support this and it can't properly look up a
@@ -656,7 +673,32 @@ func (r *Router) routeCommon(ctx context.Context, req *logical.Request, existenc
 		ok, exists, err := re.backend.HandleExistenceCheck(ctx, req)
 		return nil, ok, exists, err
 	} else {
+		// Unauthenticated reads of the paths the backend marks as cacheable
+		// are served from the response cache of the mount
+		cacheable := req.Operation == logical.ReadOperation &&
+			req.Unauthenticated &&
+			len(req.Data) == 0 &&
+			len(req.Headers) == 0 &&
+			wrapInfo == nil &&
+			re.cachedPath(req.Path)
+		var cacheGeneration uint64
+		if cacheable {
+			var cached *logical.Response
+			cached, cacheGeneration = re.responseCache.get(req.Path)
+			if cached != nil {
+				return cached, false, false, nil
+			}
+		}
+
 		resp, err := re.backend.HandleRequest(ctx, req)
+
+		switch req.Operation {
+		case logical.ReadOperation, logical.ListOperation, logical.HelpOperation:
+		default:
+			// Anything else may change what the cached paths return
+			re.responseCache.purge()
+		}
+
 		if resp != nil {
 			if len(allowedResponseHeaders) > 0 {
 				resp.Headers = filteredHeaders(resp.Headers, allowedResponseHeaders, nil)
@@ -664,6 +706,10 @@ func (r *Router) routeCommon(ctx context.Context, req *logical.Request, existenc
 				resp.Headers = nil
 			}
 
+			if cacheable && err == nil {
+				re.responseCache.put(req.Path, cacheGeneration, resp)
+			}
+
 			if resp.Auth != nil {
 				// When a token gets renewed, the request hits this path and
 				// reaches token store. Token store delegates the renewal to the
@@ -784,6 +830,21 @@ func (r *Router) LoginPath(ctx context.Context, path string) bool {
 	return match == remain
 }
 
+// cachedPath checks if the given path within the mount is one the backend
+// allows responses to be cached for
+func (re *routeEntry) cachedPath(path string) bool {
+	cachedPaths := re.cachedPaths.Load().(*radix.Tree)
+	match, raw, ok := cachedPaths.LongestPrefix(path)
+	if !ok {
+		return false
+	}
+
+	if raw.(bool) {
+		return strings.HasPrefix(path, match)
+	}
+	return match == path
+}
+
 // pathsToRadix converts a the mapping of special paths to a mapping
 // of special paths to radix trees.
 func pathsToRadix(paths []string) *radix.Tree {
@@ -22,6 +22,8 @@ type NoopBackend struct {
 
 	Root            []string
 	Login           []string
+	Cached          []string
+	WellKnown       map[string]string
 	Paths           []string
 	Requests        []*logical.Request
 	Response        *logical.Response
@@ -64,6 +66,8 @@ func (n *NoopBackend) SpecialPaths() *logical.Paths {
 	return &logical.Paths{
 		Root:            n.Root,
 		Unauthenticated: n.Login,
+		CachedResponses: n.Cached,
+		WellKnown:       n.WellKnown,
 	}
 }
 
@@ -476,6 +480,99 @@ func TestRouter_LoginPath(t *testing.T) {
 	}
 }
 
+func TestRouter_ResponseCache(t *testing.T) {
+	r := NewRouter()
+	_, barrier, _ := mockBarrier(t)
+	view := NewBarrierView(barrier, "logical/")
+
+	meUUID, err := uuid.GenerateUUID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var value string
+	n := &NoopBackend{
+		Login:  []string{"ca", "crl", "cert/*"},
+		Cached: []string{"ca", "cert/*"},
+		RequestHandler: func(ctx context.Context, req *logical.Request) (*logical.Response, error) {
+			return &logical.Response{
+				Data: map[string]interface{}{
+					"value": value,
+				},
+			}, nil
+		},
+	}
+	err = r.Mount(n, "pki/", &MountEntry{UUID: meUUID, Accessor: "pkiaccessor", NamespaceID: namespace.RootNamespaceID, namespace: namespace.RootNamespace}, view)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	read := func(path string, unauthenticated bool) string {
+		t.Helper()
+		resp, err := r.Route(namespace.RootContext(nil), &logical.Request{
+			Operation:       logical.ReadOperation,
+			Path:            path,
+			Unauthenticated: unauthenticated,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.Data["value"].(string)
+	}
+
+	value = "first"
+	if v := read("pki/ca", true); v != "first" {
+		t.Fatalf("bad: %s", v)
+	}
+	if v := read("pki/cert/01", true); v != "first" {
+		t.Fatalf("bad: %s", v)
+	}
+
+	// Cached paths are served from the cache, others always reach the
+	// backend
+	value = "second"
+	if v := read("pki/ca", true); v != "first" {
+		t.Fatalf("expected a cached response, got %s", v)
+	}
+	if v := read("pki/cert/01", true); v != "first" {
+		t.Fatalf("expected a cached response, got %s", v)
+	}
+	if v := read("pki/crl", true); v != "second" {
+		t.Fatalf("expected the response of the backend, got %s", v)
+	}
+	if v := read("pki/ca", false); v != "second" {
+		t.Fatalf("expected the response of the backend, got %s", v)
+	}
+
+	// Responses returned from the cache can't modify it
+	resp, err := r.Route(namespace.RootContext(nil), &logical.Request{
+		Operation:       logical.ReadOperation,
+		Path:            "pki/ca",
+		Unauthenticated: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Data["value"] = "modified"
+	if v := read("pki/ca", true); v != "first" {
+		t.Fatalf("expected a cached response, got %s", v)
+	}
+
+	// Writes to the mount invalidate the cache
+	_, err = r.Route(namespace.RootContext(nil), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "pki/config/ca",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := read("pki/ca", true); v != "second" {
+		t.Fatalf("expected the cache to be invalidated, got %s", v)
+	}
+	if v := read("pki/cert/01", true); v != "second" {
+		t.Fatalf("expected the cache to be invalidated, got %s", v)
+	}
+}
+
 func TestRouter_Taint(t *testing.T) {
 	r := NewRouter()
 	_, barrier, _ := mockBarrier(t)
@@ -0,0 +1,220 @@
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/version"
+)
+
+const (
+	// coreStorageSchemaPath is the path used to record the version of the
+	// layout of the data in storage and the migrations applied to reach it
+	coreStorageSchemaPath = "core/storage-schema"
+)
+
+// storageMigration is an upgrade of the layout of the data in storage.
+// Migrations are applied in the order of their versions, once, by the active
+// node at unseal, before the mounts are loaded. A migration may be run on
+// storage from before the schema was recorded, including a fresh one, so it
+// must cope with data which is already in the new layout or missing.
+type storageMigration struct {
+	version     int
+	description string
+	migrate     func(context.Context, *Core) error
+}
+
+// storageMigrations are the storage migrations known to this version of Vault,
+// ordered by version. Versions must be strictly increasing and must never be
+// reused or removed once released.
+var storageMigrations = []*storageMigration{}
+
+// storageSchema is the stored record of the layout of the data in storage
+type storageSchema struct {
+	Version int                        `json:"version"`
+	Applied []*appliedStorageMigration `json:"applied"`
+}
+
+// appliedStorageMigration is the record of a migration applied to storage
+type appliedStorageMigration struct {
+	Version      int           `json:"version"`
+	Description  string        `json:"description"`
+	VaultVersion string        `json:"vault_version"`
+	StartTime    time.Time     `json:"start_time"`
+	Duration     time.Duration `json:"duration"`
+}
+
+// storageMigrationStatus tracks the storage migrations of the core
+type storageMigrationStatus struct {
+	l sync.RWMutex
+
+	schema *storageSchema
+
+	// current is the migration being applied, if any
+	current *storageMigration
+}
+
+// latestStorageSchemaVersion returns the version of the last storage migration
+// known to this version of Vault
+func (c *Core) latestStorageSchemaVersion() int {
+	if len(c.storageMigrations) == 0 {
+		return 0
+	}
+	return c.storageMigrations[len(c.storageMigrations)-1].version
+}
+
+// loadStorageSchema reads the record of the storage schema, returning an empty
+// one for storage which has none
+func (c *Core) loadStorageSchema(ctx context.Context) (*storageSchema, error) {
+	entry, err := c.barrier.Get(ctx, coreStorageSchemaPath)
+	if err != nil {
+		return nil, errwrap.Wrapf("failed to read storage schema: {{err}}", err)
+	}
+
+	schema := &storageSchema{}
+	if entry == nil {
+		return schema, nil
+	}
+	if err := json.Unmarshal(entry.Value, schema); err != nil {
+		return nil, errwrap.Wrapf("failed to decode storage schema: {{err}}", err)
+	}
+
+	return schema, nil
+}
+
+func (c *Core) persistStorageSchema(ctx context.Context, schema *storageSchema) error {
+	raw, err := json.Marshal(schema)
+	if err != nil {
+		return errwrap.Wrapf("failed to encode storage schema: {{err}}", err)
+	}
+
+	if err := c.barrier.Put(ctx, &logical.StorageEntry{
+		Key:   coreStorageSchemaPath,
+		Value: raw,
+	}); err != nil {
+		return errwrap.Wrapf("failed to persist storage schema: {{err}}", err)
+	}
+
+	return nil
+}
+
+// runStorageMigrations applies the storage migrations newer than the recorded
+// storage schema, recording each as soon as it is applied so that an
+// interrupted run resumes from the first migration not recorded. Storage with
+// a schema newer than the ones known to this version of Vault is refused, so
+// that older versions don't run against data they don't understand.
+func (c *Core) runStorageMigrations(ctx context.Context) error {
+	schema, err := c.loadStorageSchema(ctx)
+	if err != nil {
+		return err
+	}
+
+	latest := c.latestStorageSchemaVersion()
+	if schema.Version > latest {
+		return fmt.Errorf("storage schema version %d is newer than version %d, the latest supported by this version of Vault; upgrade Vault to unseal it", schema.Version, latest)
+	}
+
+	c.migrationStatus.l.Lock()
+	c.migrationStatus.schema = schema
+	c.migrationStatus.l.Unlock()
+
+	prev := 0
+	for _, migration := range c.storageMigrations {
+		if migration.version <= prev {
+			return fmt.Errorf("storage migration version %d is out of order", migration.version)
+		}
+		prev = migration.version
+
+		if migration.version <= schema.Version {
+			continue
+		}
+
+		c.logger.Info("applying storage migration", "version", migration.version, "description", migration.description)
+
+		c.migrationStatus.l.Lock()
+		c.migrationStatus.current = migration
+		c.migrationStatus.l.Unlock()
+
+		start := time.Now()
+		err := migration.migrate(ctx, c)
+
+		c.migrationStatus.l.Lock()
+		c.migrationStatus.current = nil
+		c.migrationStatus.l.Unlock()
+
+		if err != nil {
+			return errwrap.Wrapf(fmt.Sprintf("failed to apply storage migration %d: {{err}}", migration.version), err)
+		}
+
+		applied := &storageSchema{
+			Version: migration.version,
+			Applied: append(schema.Applied, &appliedStorageMigration{
+				Version:      migration.version,
+				Description:  migration.description,
+				VaultVersion: version.GetVersion().VersionNumber(),
+				StartTime:    start.UTC(),
+				Duration:     time.Since(start),
+			}),
+		}
+		if err := c.persistStorageSchema(ctx, applied); err != nil {
+			return err
+		}
+		schema = applied
+
+		c.migrationStatus.l.Lock()
+		c.migrationStatus.schema = schema
+		c.migrationStatus.l.Unlock()
+
+		c.logger.Info("applied storage migration", "version", migration.version, "duration", time.Since(start))
+	}
+
+	return nil
+}
+
+// storageMigrationStatusData returns the status of the storage migrations
+// reported by sys/migration-status
+func (c *Core) storageMigrationStatusData() map[string]interface{} {
+	c.migrationStatus.l.RLock()
+	defer c.migrationStatus.l.RUnlock()
+
+	schema := c.migrationStatus.schema
+	if schema == nil {
+		schema = &storageSchema{}
+	}
+
+	applied := make([]map[string]interface{}, 0, len(schema.Applied))
+	for _, migration := range schema.Applied {
+		applied = append(applied, map[string]interface{}{
+			"version":       migration.Version,
+			"description":   migration.Description,
+			"vault_version": migration.VaultVersion,
+			"start_time":    migration.StartTime.Format(time.RFC3339Nano),
+			"duration":      migration.Duration.String(),
+		})
+	}
+
+	pending := []int{}
+	for _, migration := range c.storageMigrations {
+		if migration.version > schema.Version {
+			pending = append(pending, migration.version)
+		}
+	}
+
+	data := map[string]interface{}{
+		"schema_version": schema.Version,
+		"latest_version": c.latestStorageSchemaVersion(),
+		"applied":        applied,
+		"pending":        pending,
+		"in_progress":    c.migrationStatus.current != nil,
+	}
+	if c.migrationStatus.current != nil {
+		data["current_version"] = c.migrationStatus.current.version
+	}
+
+	return data
+}
@@ -0,0 +1,99 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/logical"
+)
+
+func TestCore_StorageMigrations(t *testing.T) {
+	c, keys, root := TestCoreUnsealed(t)
+
+	runs := map[int]int{}
+	migration := func(version int, err error) *storageMigration {
+		return &storageMigration{
+			version:     version,
+			description: "test migration",
+			migrate: func(ctx context.Context, c *Core) error {
+				runs[version]++
+				if err != nil {
+					return err
+				}
+				return c.barrier.Put(ctx, &logical.StorageEntry{
+					Key:   "test/migration",
+					Value: []byte{byte(version)},
+				})
+			},
+		}
+	}
+
+	reseal := func() error {
+		t.Helper()
+		if err := c.Seal(root); err != nil {
+			t.Fatal(err)
+		}
+		for _, key := range keys {
+			if _, err := TestCoreUnseal(c, TestKeyCopy(key)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	c.storageMigrations = []*storageMigration{migration(1, nil), migration(2, nil)}
+	if err := reseal(); err != nil {
+		t.Fatal(err)
+	}
+	entry, err := c.barrier.Get(context.Background(), "test/migration")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry == nil || entry.Value[0] != 2 {
+		t.Fatalf("expected the migrations to be applied in order: %#v", entry)
+	}
+
+	req := logical.TestRequest(t, logical.ReadOperation, "sys/migration-status")
+	req.ClientToken = root
+	resp, err := c.HandleRequest(namespace.RootContext(nil), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Data["schema_version"] != 2 || resp.Data["latest_version"] != 2 {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+	if applied := resp.Data["applied"].([]map[string]interface{}); len(applied) != 2 || applied[1]["version"] != 2 {
+		t.Fatalf("bad applied migrations: %#v", applied)
+	}
+	if pending := resp.Data["pending"].([]int); len(pending) != 0 {
+		t.Fatalf("bad pending migrations: %#v", pending)
+	}
+
+	// Applied migrations aren't run again, and a failed migration fails the
+	// unseal without being recorded
+	c.storageMigrations = append(c.storageMigrations, migration(3, errors.New("failed")))
+	if err := reseal(); err == nil {
+		t.Fatal("expected the failed migration to fail the unseal")
+	}
+	if !reflect.DeepEqual(runs, map[int]int{1: 1, 2: 1, 3: 1}) {
+		t.Fatalf("bad runs: %v", runs)
+	}
+	c.storageMigrations = c.storageMigrations[:2]
+	for _, key := range keys {
+		if _, err := TestCoreUnseal(c, TestKeyCopy(key)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Versions of Vault which don't know the latest migration are refused
+	c.storageMigrations = c.storageMigrations[:1]
+	if err := reseal(); err == nil {
+		t.Fatal("expected storage with a newer schema to be refused")
+	}
+	if !c.Sealed() {
+		t.Fatal("expected the core to be sealed")
+	}
+}
@@ -0,0 +1,134 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	metrics "github.com/armon/go-metrics"
+	"github.com/hashicorp/vault/physical"
+)
+
+const (
+	// storageProbePath is the path written by the storage write probe
+	storageProbePath = "core/health-probe"
+
+	// DefaultStorageProbeTimeout is the time after which a storage probe is
+	// considered failed
+	DefaultStorageProbeTimeout = 5 * time.Second
+
+	// storageProbeInterval is the minimum time between two runs of the
+	// storage probes. The endpoints reporting them are unauthenticated, so
+	// callers within the interval are served the last result rather than
+	// making the active node write to storage at will.
+	storageProbeInterval = 10 * time.Second
+)
+
+// ErrStorageProbeTimeout is returned when a storage probe doesn't complete
+// within the probe timeout
+var ErrStorageProbeTimeout = errors.New("storage probe timed out")
+
+// StorageProbeResult is the outcome of probing the storage backend
+type StorageProbeResult struct {
+	ReadLatency time.Duration
+	ReadErr     error
+
+	// Write is whether the write probe was run, which only happens on the
+	// active node
+	Write        bool
+	WriteLatency time.Duration
+	WriteErr     error
+
+	probedAt time.Time
+}
+
+// Healthy returns whether all the probes succeeded
+func (r *StorageProbeResult) Healthy() bool {
+	return r.ReadErr == nil && r.WriteErr == nil
+}
+
+// ProbeStorage measures the latency of a read from the storage backend and,
+// on the active node, of a write to it. The probes bypass the physical cache
+// so that they reach the storage backend. They run at most once per
+// storageProbeInterval, and the result of the last run is returned in
+// between; it is shared between callers and must not be modified.
+func (c *Core) ProbeStorage(timeout time.Duration) *StorageProbeResult {
+	c.storageProbeLock.Lock()
+	defer c.storageProbeLock.Unlock()
+
+	if last := c.storageProbeResult; last != nil && time.Since(last.probedAt) < storageProbeInterval {
+		return last
+	}
+
+	c.storageProbeResult = c.probeStorage(timeout)
+	return c.storageProbeResult
+}
+
+func (c *Core) probeStorage(timeout time.Duration) *StorageProbeResult {
+	if timeout <= 0 {
+		timeout = DefaultStorageProbeTimeout
+	}
+
+	// The result is shared by every caller until the next run, so the probes
+	// must not be cut short by the request which happened to trigger them
+	ctx := context.Background()
+
+	result := &StorageProbeResult{
+		probedAt: time.Now(),
+	}
+	result.ReadLatency, result.ReadErr = c.storageProbe(ctx, "read", timeout, func(ctx context.Context) error {
+		_, err := c.sealUnwrapper.Get(ctx, barrierSealConfigPath)
+		return err
+	})
+	if result.ReadErr != nil {
+		c.logger.Warn("storage read probe failed", "error", result.ReadErr)
+	}
+
+	// Standbys don't write to storage
+	if c.Sealed() || c.PerfStandby() {
+		return result
+	}
+	if standby, _ := c.Standby(); standby {
+		return result
+	}
+
+	result.Write = true
+	result.WriteLatency, result.WriteErr = c.storageProbe(ctx, "write", timeout, func(ctx context.Context) error {
+		return c.sealUnwrapper.Put(ctx, &physical.Entry{
+			Key:   storageProbePath,
+			Value: []byte(time.Now().UTC().Format(time.RFC3339Nano)),
+		})
+	})
+	if result.WriteErr != nil {
+		c.logger.Warn("storage write probe failed", "error", result.WriteErr)
+	}
+
+	return result
+}
+
+// storageProbe runs a probe, failing it once the timeout has passed even if
+// the storage backend doesn't honor the context
+func (c *Core) storageProbe(ctx context.Context, op string, timeout time.Duration, f func(context.Context) error) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	defer metrics.MeasureSince([]string{"core", "storage_probe", op}, start)
+
+	// Buffered so that a probe completing after the timeout doesn't leak the
+	// goroutine
+	doneCh := make(chan error, 1)
+	go func() {
+		doneCh <- f(ctx)
+	}()
+
+	select {
+	case err := <-doneCh:
+		return time.Since(start), err
+	case <-ctx.Done():
+		if ctx.Err() == context.DeadlineExceeded {
+			return time.Since(start), ErrStorageProbeTimeout
+		}
+		return time.Since(start), ctx.Err()
+	}
+}
@@ -149,6 +149,7 @@ func TestCoreWithSealAndUI(t testing.T, opts *CoreConfig) *Core {
 	conf.Seal = opts.Seal
 	conf.LicensingConfig = opts.LicensingConfig
 	conf.DisableKeyEncodingChecks = opts.DisableKeyEncodingChecks
+	conf.LogLevels = opts.LogLevels
 
 	for k, v := range opts.LogicalBackends {
 		conf.LogicalBackends[k] = v
@@ -1557,9 +1558,15 @@ func (m *mockBuiltinRegistry) Keys(pluginType consts.PluginType) []string {
 		"mongodb-database-plugin",
 		"hana-database-plugin",
 		"influxdb-database-plugin",
+		"snowflake-database-plugin",
+		"elasticsearch-database-plugin",
 	}
 }
 
 func (m *mockBuiltinRegistry) Contains(name string, pluginType consts.PluginType) bool {
 	return false
 }
+
+func (m *mockBuiltinRegistry) DeprecationStatus(name string, pluginType consts.PluginType) (consts.DeprecationStatus, bool) {
+	return consts.Unknown, false
+}
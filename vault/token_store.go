@@ -50,6 +50,10 @@ const (
 	// secondar parent based index
 	parentPrefix = "parent/"
 
+	// entityIndexPrefix is the prefix used to store the index from entity ID
+	// to the tokens tied to the entity
+	entityIndexPrefix = "entity/"
+
 	// tokenSubPath is the sub-path used for the token store
 	// view. This is nested under the system view.
 	tokenSubPath = "token/"
@@ -179,6 +183,21 @@ func (ts *TokenStore) paths() []*framework.Path {
 					Description: `Comma separated string or JSON list of CIDR blocks. If set, specifies the blocks of IP addresses which are allowed to use the generated token.`,
 				},
 
+				"bound_time_window": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Description: `Time of day, as HH:MM-HH:MM, during which the generated tokens can be used. The window may span midnight, e.g. 22:00-06:00. If empty, the tokens can be used at any time.`,
+				},
+
+				"bound_weekdays": &framework.FieldSchema{
+					Type:        framework.TypeCommaStringSlice,
+					Description: `Comma separated string or JSON list of the days, among sun, mon, tue, wed, thu, fri and sat, on which bound_time_window opens. If empty, it opens every day.`,
+				},
+
+				"bound_time_zone": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Description: `The time zone bound_time_window is given in, e.g. America/New_York. Defaults to UTC.`,
+				},
+
 				"token_type": &framework.FieldSchema{
 					Type:        framework.TypeString,
 					Default:     "service",
@@ -456,6 +475,7 @@ type TokenStore struct {
 	idBarrierView       *BarrierView
 	accessorBarrierView *BarrierView
 	parentBarrierView   *BarrierView
+	entityBarrierView   *BarrierView
 	rolesBarrierView    *BarrierView
 
 	expiration *ExpirationManager
@@ -499,6 +519,7 @@ func NewTokenStore(ctx context.Context, logger log.Logger, core *Core, config *l
 		idBarrierView:         view.SubView(idPrefix),
 		accessorBarrierView:   view.SubView(accessorPrefix),
 		parentBarrierView:     view.SubView(parentPrefix),
+		entityBarrierView:     view.SubView(entityIndexPrefix),
 		rolesBarrierView:      view.SubView(rolesPrefix),
 		cubbyholeDestroyer:    destroyCubbyhole,
 		logger:                logger,
@@ -526,6 +547,7 @@ func NewTokenStore(ctx context.Context, logger log.Logger, core *Core, config *l
 				idPrefix,
 				accessorPrefix,
 				parentPrefix,
+				entityIndexPrefix,
 				salt.DefaultLocation,
 			},
 		},
@@ -612,6 +634,10 @@ type tsRoleEntry struct {
 	// The set of CIDRs that tokens generated using this role will be bound to
 	BoundCIDRs []*sockaddr.SockAddrMarshaler `json:"bound_cidrs"`
 
+	// The time window during which tokens generated using this role can be
+	// used. Like the CIDRs it is copied to the tokens.
+	BoundTimeWindow *logical.TokenTimeWindow `json:"bound_time_window,omitempty"`
+
 	// The type of token this role should issue
 	TokenType logical.TokenType `json:"token_type" mapstructure:"token_type"`
 }
@@ -708,6 +734,53 @@ func (ts *TokenStore) tokenStoreAccessorList(ctx context.Context, req *logical.R
 	return resp, nil
 }
 
+// entityIndexKey returns the key of a token in the index of the tokens tied
+// to an entity
+func entityIndexKey(entityID, saltedID string, tokenNS *namespace.Namespace) string {
+	key := entityID + "/" + saltedID
+	if tokenNS.ID != namespace.RootNamespaceID {
+		key = fmt.Sprintf("%s.%s", key, tokenNS.ID)
+	}
+	return key
+}
+
+// tokensByEntity returns the tokens of the namespace in the context which are
+// tied to the given entity, using the entity index. Batch tokens aren't
+// persisted, so they can't be found this way.
+func (ts *TokenStore) tokensByEntity(ctx context.Context, entityID string) ([]*logical.TokenEntry, error) {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := ts.entityView(ns).List(ctx, entityID+"/")
+	if err != nil {
+		return nil, errwrap.Wrapf("failed to scan the entity index: {{err}}", err)
+	}
+
+	var ret []*logical.TokenEntry
+	for _, key := range keys {
+		saltedID, saltedNSID := namespace.SplitIDFromString(key)
+		if saltedNSID == "" {
+			saltedNSID = namespace.RootNamespaceID
+		}
+		if saltedNSID != ns.ID {
+			continue
+		}
+
+		te, err := ts.lookupInternal(ctx, saltedID, true, false)
+		if err != nil {
+			return nil, errwrap.Wrapf("failed to look up token using entity index: {{err}}", err)
+		}
+		if te == nil || te.EntityID != entityID {
+			continue
+		}
+		ret = append(ret, te)
+	}
+
+	return ret, nil
+}
+
 // createAccessor is used to create an identifier for the token ID.
 // A storage index, mapping the accessor to the token ID is also created.
 func (ts *TokenStore) createAccessor(ctx context.Context, entry *logical.TokenEntry) error {
@@ -947,6 +1020,13 @@ func (ts *TokenStore) storeCommon(ctx context.Context, entry *logical.TokenEntry
 				return errwrap.Wrapf("failed to persist entry: {{err}}", err)
 			}
 		}
+
+		if entry.EntityID != "" {
+			le := &logical.StorageEntry{Key: entityIndexKey(entry.EntityID, saltedID, tokenNS)}
+			if err := ts.entityView(tokenNS).Put(ctx, le); err != nil {
+				return errwrap.Wrapf("failed to persist entry: {{err}}", err)
+			}
+		}
 	}
 
 	// Write the primary ID
@@ -1416,6 +1496,13 @@ func (ts *TokenStore) revokeInternal(ctx context.Context, saltedID string, skipO
 		}
 	}
 
+	// Clear the entity index if any
+	if entry.EntityID != "" {
+		if err = ts.entityView(tokenNS).Delete(ctx, entityIndexKey(entry.EntityID, saltedID, tokenNS)); err != nil {
+			return errwrap.Wrapf("failed to delete entry: {{err}}", err)
+		}
+	}
+
 	// Clear the accessor index if any
 	if entry.Accessor != "" {
 		accessorSaltedID, err := ts.SaltID(revokeCtx, entry.Accessor)
@@ -1821,6 +1908,35 @@ func (ts *TokenStore) handleTidy(ctx context.Context, req *logical.Request, data
 				}
 			}
 
+			// Remove the entity index entries of tokens which no longer exist
+			var countEntityList, deletedCountEntityList int64
+			entityList, err := ts.entityView(ns).List(quitCtx, "")
+			if err != nil {
+				return errwrap.Wrapf("failed to fetch entity index entries: {{err}}", err)
+			}
+			for _, entity := range entityList {
+				tokens, err := ts.entityView(ns).List(quitCtx, entity)
+				if err != nil {
+					tidyErrors = multierror.Append(tidyErrors, errwrap.Wrapf("failed to read entity index: {{err}}", err))
+					continue
+				}
+				for _, token := range tokens {
+					countEntityList++
+					saltedID, _ := namespace.SplitIDFromString(token)
+					if te, _ := ts.lookupInternal(quitCtx, saltedID, true, true); te != nil {
+						continue
+					}
+
+					index := entity + token
+					ts.logger.Debug("deleting invalid entity index", "index", index)
+					if err := ts.entityView(ns).Delete(quitCtx, index); err != nil {
+						tidyErrors = multierror.Append(tidyErrors, errwrap.Wrapf("failed to delete entity index: {{err}}", err))
+						continue
+					}
+					deletedCountEntityList++
+				}
+			}
+
 			var countAccessorList,
 				countCubbyholeKeys,
 				deletedCountAccessorEmptyToken,
@@ -1909,6 +2025,25 @@ func (ts *TokenStore) handleTidy(ctx context.Context, req *logical.Request, data
 					}
 					deletedCountAccessorInvalidToken++
 				default:
+					// Index the tokens created before the entity index
+					if te.EntityID != "" {
+						tokenNS, err := NamespaceByID(quitCtx, te.NamespaceID, ts.core)
+						if err != nil || tokenNS == nil {
+							tidyErrors = multierror.Append(tidyErrors, fmt.Errorf("failed to find the namespace of a valid token"))
+							continue
+						}
+						saltedID, err := ts.SaltID(namespace.ContextWithNamespace(quitCtx, tokenNS), te.ID)
+						if err != nil {
+							tidyErrors = multierror.Append(tidyErrors, errwrap.Wrapf("failed to create salted token id: {{err}}", err))
+							continue
+						}
+						le := &logical.StorageEntry{Key: entityIndexKey(te.EntityID, saltedID, tokenNS)}
+						if err := ts.entityView(tokenNS).Put(quitCtx, le); err != nil {
+							tidyErrors = multierror.Append(tidyErrors, errwrap.Wrapf("failed to persist entity index: {{err}}", err))
+							continue
+						}
+					}
+
 					// Cache the cubbyhole storage key when the token is valid
 					switch {
 					case te.NamespaceID == namespace.RootNamespaceID && !strings.HasPrefix(te.ID, "s."):
@@ -1950,6 +2085,8 @@ func (ts *TokenStore) handleTidy(ctx context.Context, req *logical.Request, data
 			ts.logger.Info("number of entries deleted in parent prefix", "count", deletedCountParentEntries)
 			ts.logger.Info("number of tokens scanned in parent index list", "count", countParentList)
 			ts.logger.Info("number of tokens revoked in parent index list", "count", deletedCountParentList)
+			ts.logger.Info("number of tokens scanned in entity index list", "count", countEntityList)
+			ts.logger.Info("number of tokens deleted in entity index list", "count", deletedCountEntityList)
 			ts.logger.Info("number of accessors scanned", "count", countAccessorList)
 			ts.logger.Info("number of deleted accessors which had empty tokens", "count", deletedCountAccessorEmptyToken)
 			ts.logger.Info("number of revoked tokens which were invalid but present in accessors", "count", deletedCountInvalidTokenInAccessor)
@@ -2409,6 +2546,7 @@ func (ts *TokenStore) handleCreateCommon(ctx context.Context, req *logical.Reque
 		if len(role.BoundCIDRs) > 0 {
 			te.BoundCIDRs = role.BoundCIDRs
 		}
+		te.BoundTimeWindow = role.BoundTimeWindow
 
 	case data.NoParent:
 		// Only allow an orphan token if the client has sudo policy
@@ -2432,12 +2570,13 @@ func (ts *TokenStore) handleCreateCommon(ctx context.Context, req *logical.Reque
 	if te.Parent != "" {
 		te.EntityID = parent.EntityID
 
-		// If the parent has bound CIDRs, copy those into the child. We don't
-		// do this if role is not nil because then we always use the role's
-		// bound CIDRs; roles allow escalation of privilege in proper
-		// circumstances.
+		// If the parent has bound CIDRs or a time window, copy those into
+		// the child. We don't do this if role is not nil because then we
+		// always use the role's bound CIDRs and time window; roles allow
+		// escalation of privilege in proper circumstances.
 		if role == nil {
 			te.BoundCIDRs = parent.BoundCIDRs
+			te.BoundTimeWindow = parent.BoundTimeWindow
 		}
 	}
 
@@ -2545,19 +2684,37 @@ func (ts *TokenStore) handleCreateCommon(ctx context.Context, req *logical.Reque
 	}
 
 	// Don't advertise non-expiring root tokens as renewable, as attempts to
-	// renew them are denied. Don't CIDR-restrict these either.
+	// renew them are denied. Don't CIDR or time restrict these either.
 	if te.TTL == 0 {
 		if parent.TTL != 0 {
 			return logical.ErrorResponse("expiring root tokens cannot create non-expiring root tokens"), logical.ErrInvalidRequest
 		}
 		renewable = false
 		te.BoundCIDRs = nil
+		te.BoundTimeWindow = nil
+	}
+
+	// Batch tokens are encoded without a time window, so they can't be
+	// issued by roles or parents bound to one
+	if te.Type == logical.TokenTypeBatch && te.BoundTimeWindow != nil {
+		return logical.ErrorResponse("batch tokens cannot be bound to a time window"), logical.ErrInvalidRequest
 	}
 
 	if te.ID != "" {
 		resp.AddWarning("Supplying a custom ID for the token uses the weaker SHA1 hashing instead of the more secure SHA2-256 HMAC for token obfuscation. SHA1 hashed tokens on the wire leads to less secure lookups.")
 	}
 
+	// Service tokens are registered with the expiration manager, so they are
+	// held to the lease count quotas of their path. The slot is held until
+	// the request registered the lease of the token.
+	if te.Type != logical.TokenTypeBatch {
+		release, quota := ts.core.reserveLeaseCountQuota(ctx, te.Path)
+		if quota != nil {
+			return leaseCountQuotaResponse(quota), logical.ErrLeaseCountQuotaExceeded
+		}
+		holdLeaseCountReservation(ctx, release)
+	}
+
 	// Create the token
 	if err := ts.create(ctx, &te); err != nil {
 		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
@@ -2760,6 +2917,10 @@ func (ts *TokenStore) handleLookup(ctx context.Context, req *logical.Request, da
 		resp.Data["bound_cidrs"] = out.BoundCIDRs
 	}
 
+	if out.BoundTimeWindow != nil {
+		resp.Data["bound_time_window"] = out.BoundTimeWindow.String()
+	}
+
 	tokenNS, err := NamespaceByID(ctx, out.NamespaceID, ts.core)
 	if err != nil {
 		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
@@ -2772,6 +2933,15 @@ func (ts *TokenStore) handleLookup(ctx context.Context, req *logical.Request, da
 		resp.Data["namespace_path"] = tokenNS.Path
 	}
 
+	// Report the auth mount the token was issued by, relative to the
+	// namespace of the token like its path
+	resp.Data["mount_path"] = ""
+	resp.Data["mount_accessor"] = ""
+	if mountEntry := ts.core.router.MatchingMountEntry(namespace.ContextWithNamespace(ctx, tokenNS), out.Path); mountEntry != nil {
+		resp.Data["mount_path"] = strings.TrimPrefix(mountEntry.APIPath(), tokenNS.Path)
+		resp.Data["mount_accessor"] = mountEntry.Accessor
+	}
+
 	// Fetch the last renewal time
 	leaseTimes, err := ts.expiration.FetchLeaseTimesByToken(ctx, out)
 	if err != nil {
@@ -2792,10 +2962,13 @@ func (ts *TokenStore) handleLookup(ctx context.Context, req *logical.Request, da
 	}
 
 	if out.EntityID != "" {
-		_, identityPolicies, err := ts.core.fetchEntityAndDerivedPolicies(ctx, tokenNS, out.EntityID)
+		entity, identityPolicies, err := ts.core.fetchEntityAndDerivedPolicies(ctx, tokenNS, out.EntityID)
 		if err != nil {
 			return nil, err
 		}
+		if entity != nil {
+			resp.Data["entity_name"] = entity.Name
+		}
 		if len(identityPolicies) != 0 {
 			resp.Data["identity_policies"] = identityPolicies[out.NamespaceID]
 			delete(identityPolicies, out.NamespaceID)
@@ -2962,6 +3135,12 @@ func (ts *TokenStore) tokenStoreRoleRead(ctx context.Context, req *logical.Reque
 		resp.Data["bound_cidrs"] = role.BoundCIDRs
 	}
 
+	if role.BoundTimeWindow != nil {
+		resp.Data["bound_time_window"] = role.BoundTimeWindow.String()
+		resp.Data["bound_weekdays"] = role.BoundTimeWindow.WeekdayNames()
+		resp.Data["bound_time_zone"] = role.BoundTimeWindow.TimeZone
+	}
+
 	return resp, nil
 }
 
@@ -3037,6 +3216,41 @@ func (ts *TokenStore) tokenStoreRoleCreateUpdate(ctx context.Context, req *logic
 		}
 	}
 
+	windowRaw, windowOk := data.GetOk("bound_time_window")
+	weekdaysRaw, weekdaysOk := data.GetOk("bound_weekdays")
+	timeZoneRaw, timeZoneOk := data.GetOk("bound_time_zone")
+	if windowOk || weekdaysOk || timeZoneOk {
+		var window, timeZone string
+		var weekdays []string
+		if entry.BoundTimeWindow != nil {
+			window = entry.BoundTimeWindow.String()
+			weekdays = entry.BoundTimeWindow.WeekdayNames()
+			timeZone = entry.BoundTimeWindow.TimeZone
+		}
+		if windowOk {
+			window = windowRaw.(string)
+		}
+		if weekdaysOk {
+			weekdays = weekdaysRaw.([]string)
+		}
+		if timeZoneOk {
+			timeZone = timeZoneRaw.(string)
+		}
+
+		switch {
+		case window != "":
+			boundTimeWindow, err := logical.ParseTokenTimeWindow(window, weekdays, timeZone)
+			if err != nil {
+				return logical.ErrorResponse(err.Error()), nil
+			}
+			entry.BoundTimeWindow = boundTimeWindow
+		case weekdaysOk || timeZoneOk:
+			return logical.ErrorResponse("bound_weekdays and bound_time_zone require bound_time_window to be set"), nil
+		default:
+			entry.BoundTimeWindow = nil
+		}
+	}
+
 	var resp *logical.Response
 
 	explicitMaxTTLInt, ok := data.GetOk("explicit_max_ttl")
@@ -3129,6 +3343,9 @@ func (ts *TokenStore) tokenStoreRoleCreateUpdate(ctx context.Context, req *logic
 		if entry.ExplicitMaxTTL != 0 {
 			return logical.ErrorResponse("'token_type' cannot be 'batch' when role is set to generate tokens with an explicit max TTL"), nil
 		}
+		if entry.BoundTimeWindow != nil {
+			return logical.ErrorResponse("'token_type' cannot be 'batch' when role is set to generate tokens bound to a time window"), nil
+		}
 	}
 
 	ns, err := namespace.FromContext(ctx)
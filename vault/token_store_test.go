@@ -2302,6 +2302,7 @@ func TestTokenStore_HandleRequest_Lookup(t *testing.T) {
 func testTokenStore_HandleRequest_Lookup(t *testing.T, batch bool) {
 	c, _, root := TestCoreUnsealed(t)
 	ts := c.tokenStore
+	tokenMountAccessor := c.router.MatchingMountEntry(namespace.RootContext(nil), "auth/token/").Accessor
 	req := logical.TestRequest(t, logical.UpdateOperation, "lookup")
 	req.Data = map[string]interface{}{
 		"token": root,
@@ -2328,6 +2329,8 @@ func testTokenStore_HandleRequest_Lookup(t *testing.T, batch bool) {
 		"explicit_max_ttl": int64(0),
 		"expire_time":      nil,
 		"entity_id":        "",
+		"mount_path":       "auth/token/",
+		"mount_accessor":   tokenMountAccessor,
 		"type":             "service",
 	}
 
@@ -2377,6 +2380,8 @@ func testTokenStore_HandleRequest_Lookup(t *testing.T, batch bool) {
 		"explicit_max_ttl": int64(0),
 		"renewable":        !batch,
 		"entity_id":        "",
+		"mount_path":       "auth/token/",
+		"mount_accessor":   tokenMountAccessor,
 		"type":             tokenType,
 	}
 
@@ -2429,6 +2434,8 @@ func testTokenStore_HandleRequest_Lookup(t *testing.T, batch bool) {
 		"explicit_max_ttl": int64(0),
 		"renewable":        !batch,
 		"entity_id":        "",
+		"mount_path":       "auth/token/",
+		"mount_accessor":   tokenMountAccessor,
 		"type":             tokenType,
 	}
 
@@ -2491,6 +2498,7 @@ func testTokenStore_HandleRequest_Lookup(t *testing.T, batch bool) {
 func TestTokenStore_HandleRequest_LookupSelf(t *testing.T) {
 	c, _, root := TestCoreUnsealed(t)
 	ts := c.tokenStore
+	tokenMountAccessor := c.router.MatchingMountEntry(namespace.RootContext(nil), "auth/token/").Accessor
 	testMakeServiceTokenViaCore(t, c, root, "client", "3600s", []string{"foo"})
 
 	req := logical.TestRequest(t, logical.ReadOperation, "lookup-self")
@@ -2517,6 +2525,8 @@ func TestTokenStore_HandleRequest_LookupSelf(t *testing.T) {
 		"ttl":              int64(3600),
 		"explicit_max_ttl": int64(0),
 		"entity_id":        "",
+		"mount_path":       "auth/token/",
+		"mount_accessor":   tokenMountAccessor,
 		"type":             "service",
 	}
 
@@ -2543,6 +2553,67 @@ func TestTokenStore_HandleRequest_LookupSelf(t *testing.T) {
 	}
 }
 
+func TestTokenStore_HandleRequest_LookupIssuer(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	ts := c.tokenStore
+	ctx := namespace.RootContext(nil)
+
+	c.credentialBackends["noop"] = func(context.Context, *logical.BackendConfig) (logical.Backend, error) {
+		return &NoopBackend{
+			BackendType: logical.TypeCredential,
+		}, nil
+	}
+	me := &MountEntry{
+		Table: credentialTableType,
+		Path:  "userpass/",
+		Type:  "noop",
+	}
+	if err := c.enableCredential(ctx, me); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := c.identityStore.HandleRequest(ctx, &logical.Request{
+		Path:      "entity",
+		Operation: logical.UpdateOperation,
+		Data: map[string]interface{}{
+			"name": "alice",
+		},
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: err:%v\nresp: %#v", err, resp)
+	}
+	entityID := resp.Data["id"].(string)
+
+	testMakeTokenDirectly(t, ts, &logical.TokenEntry{
+		ID:       "issued",
+		Path:     "auth/userpass/login/alice",
+		Policies: []string{"default"},
+		EntityID: entityID,
+		TTL:      time.Hour,
+	})
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "lookup")
+	req.ClientToken = root
+	req.Data = map[string]interface{}{
+		"token": "issued",
+	}
+	resp, err = ts.HandleRequest(ctx, req)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err: %v\nresp: %#v", err, resp)
+	}
+
+	for k, v := range map[string]interface{}{
+		"mount_path":     "auth/userpass/",
+		"mount_accessor": me.Accessor,
+		"entity_id":      entityID,
+		"entity_name":    "alice",
+	} {
+		if resp.Data[k] != v {
+			t.Fatalf("expected %s to be %q, got %#v", k, v, resp.Data[k])
+		}
+	}
+}
+
 func TestTokenStore_HandleRequest_Renew(t *testing.T) {
 	exp := mockExpiration(t)
 	ts := exp.tokenStore
@@ -3145,6 +3216,163 @@ func TestTokenStore_RolePathSuffix(t *testing.T) {
 	}
 }
 
+func TestTokenStore_RoleTimeWindow(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+
+	request := func(path, token string, data map[string]interface{}) (*logical.Response, error) {
+		return c.HandleRequest(namespace.RootContext(nil), &logical.Request{
+			Path:        path,
+			ClientToken: token,
+			Operation:   logical.UpdateOperation,
+			Data:        data,
+		})
+	}
+	// window returns a window of an hour opening the given number of hours
+	// from now
+	window := func(hours int) string {
+		start := time.Now().UTC().Add(time.Duration(hours) * time.Hour)
+		return start.Format("15:04") + "-" + start.Add(time.Hour).Format("15:04")
+	}
+
+	resp, err := request("auth/token/roles/test", root, map[string]interface{}{
+		"bound_time_window": window(-1) + "x",
+	})
+	if resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error with an invalid window: resp: %#v err: %v", resp, err)
+	}
+	resp, err = request("auth/token/roles/test", root, map[string]interface{}{
+		"bound_weekdays": "mon",
+	})
+	if resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error with weekdays but no window: resp: %#v err: %v", resp, err)
+	}
+
+	resp, err = request("auth/token/roles/test", root, map[string]interface{}{
+		"bound_time_window": window(2),
+		"bound_time_zone":   "UTC",
+	})
+	if err != nil || resp != nil {
+		t.Fatalf("bad: resp: %#v err: %v", resp, err)
+	}
+	resp, err = c.HandleRequest(namespace.RootContext(nil), &logical.Request{
+		Path:        "auth/token/roles/test",
+		ClientToken: root,
+		Operation:   logical.ReadOperation,
+	})
+	if err != nil || resp.Data["bound_time_window"] != window(2) || resp.Data["bound_time_zone"] != "UTC" {
+		t.Fatalf("bad: resp: %#v err: %v", resp, err)
+	}
+
+	resp, err = request("auth/token/create/test", root, map[string]interface{}{
+		"ttl": "1h",
+	})
+	if err != nil || resp == nil || resp.Auth == nil {
+		t.Fatalf("bad: resp: %#v err: %v", resp, err)
+	}
+	token := resp.Auth.ClientToken
+
+	// The token can't be used outside of the window
+	if _, err := request("auth/token/lookup-self", token, nil); err == nil || !strings.Contains(err.Error(), logical.ErrPermissionDenied.Error()) {
+		t.Fatalf("expected permission denied, got: %v", err)
+	}
+
+	// The window is copied to the token, so changing the role only applies
+	// to the tokens generated afterwards, and deleting it doesn't lift the
+	// restriction
+	now := time.Now().UTC()
+	resp, err = request("auth/token/roles/test", root, map[string]interface{}{
+		"bound_time_window": now.Add(-time.Hour).Format("15:04") + "-" + now.Add(time.Hour).Format("15:04"),
+	})
+	if err != nil || resp != nil {
+		t.Fatalf("bad: resp: %#v err: %v", resp, err)
+	}
+	if _, err := request("auth/token/lookup-self", token, nil); err == nil || !strings.Contains(err.Error(), logical.ErrPermissionDenied.Error()) {
+		t.Fatalf("expected permission denied, got: %v", err)
+	}
+	resp, err = request("auth/token/create/test", root, map[string]interface{}{
+		"ttl": "1h",
+	})
+	if err != nil || resp == nil || resp.Auth == nil {
+		t.Fatalf("bad: resp: %#v err: %v", resp, err)
+	}
+	if _, err := request("auth/token/lookup-self", resp.Auth.ClientToken, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	resp, err = c.HandleRequest(namespace.RootContext(nil), &logical.Request{
+		Path:        "auth/token/roles/test",
+		ClientToken: root,
+		Operation:   logical.DeleteOperation,
+	})
+	if err != nil || resp != nil {
+		t.Fatalf("bad: resp: %#v err: %v", resp, err)
+	}
+	if _, err := request("auth/token/lookup-self", token, nil); err == nil || !strings.Contains(err.Error(), logical.ErrPermissionDenied.Error()) {
+		t.Fatalf("expected permission denied, got: %v", err)
+	}
+}
+
+func TestTokenStore_TimeWindowBatchTokens(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+
+	request := func(path, token string, data map[string]interface{}) (*logical.Response, error) {
+		return c.HandleRequest(namespace.RootContext(nil), &logical.Request{
+			Path:        path,
+			ClientToken: token,
+			Operation:   logical.UpdateOperation,
+			Data:        data,
+		})
+	}
+	now := time.Now().UTC()
+	window := now.Add(-time.Hour).Format("15:04") + "-" + now.Add(time.Hour).Format("15:04")
+
+	// Batch tokens are encoded without their time window
+	resp, err := request("auth/token/roles/batch", root, map[string]interface{}{
+		"token_type":        "batch",
+		"orphan":            true,
+		"renewable":         false,
+		"bound_time_window": window,
+	})
+	if resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error for a batch role with a window: resp: %#v err: %v", resp, err)
+	}
+
+	resp, err = request("auth/token/roles/test", root, map[string]interface{}{
+		"bound_time_window": window,
+	})
+	if err != nil || resp != nil {
+		t.Fatalf("bad: resp: %#v err: %v", resp, err)
+	}
+	resp, err = request("sys/policy/creator", root, map[string]interface{}{
+		"policy": `path "auth/token/create" { capabilities = ["update"] }`,
+	})
+	if err != nil || resp != nil {
+		t.Fatalf("bad: resp: %#v err: %v", resp, err)
+	}
+	resp, err = request("auth/token/create/test", root, map[string]interface{}{
+		"type":     "batch",
+		"policies": "creator",
+	})
+	if resp == nil || !resp.IsError() || !strings.Contains(resp.Error().Error(), "time window") {
+		t.Fatalf("expected an error for a batch token of a role with a window: resp: %#v err: %v", resp, err)
+	}
+
+	// Nor can tokens bound to a window escape it through batch children
+	resp, err = request("auth/token/create/test", root, map[string]interface{}{
+		"ttl":      "1h",
+		"policies": "creator",
+	})
+	if err != nil || resp == nil || resp.Auth == nil {
+		t.Fatalf("bad: resp: %#v err: %v", resp, err)
+	}
+	resp, err = request("auth/token/create", resp.Auth.ClientToken, map[string]interface{}{
+		"type": "batch",
+	})
+	if resp == nil || !resp.IsError() || !strings.Contains(resp.Error().Error(), "time window") {
+		t.Fatalf("expected an error for a batch child of a token with a window: resp: %#v err: %v", resp, err)
+	}
+}
+
 func TestTokenStore_RolePeriod(t *testing.T) {
 	core, _, root := TestCoreUnsealed(t)
 
@@ -4231,6 +4459,56 @@ func TestTokenStore_RevokeUseCountToken(t *testing.T) {
 	}
 }
 
+func TestTokenStore_HandleTidy_EntityIndex(t *testing.T) {
+	c, _, _ := TestCoreUnsealed(t)
+	ts := c.tokenStore
+	ctx := namespace.RootContext(nil)
+
+	te := &logical.TokenEntry{
+		ID:       "alice",
+		Path:     "auth/userpass/login/alice",
+		Policies: []string{"default"},
+		EntityID: "alice-entity",
+		TTL:      time.Hour,
+	}
+	testMakeTokenDirectly(t, ts, te)
+
+	view := ts.entityView(namespace.RootNamespace)
+	keys, err := view.List(ctx, "alice-entity/")
+	if err != nil || len(keys) != 1 {
+		t.Fatalf("expected the token to be indexed, got %v: %v", keys, err)
+	}
+
+	// Tidy indexes the tokens missing from the entity index, and removes the
+	// entries of tokens which no longer exist
+	if err := view.Delete(ctx, "alice-entity/"+keys[0]); err != nil {
+		t.Fatal(err)
+	}
+	if err := view.Put(ctx, &logical.StorageEntry{Key: "bob-entity/dangling"}); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := ts.HandleRequest(ctx, &logical.Request{
+		Path:      "tidy",
+		Operation: logical.UpdateOperation,
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: resp: %#v\nerr: %v", resp, err)
+	}
+
+	// Wait for tidy operation to complete
+	time.Sleep(2 * time.Second)
+
+	tokens, err := ts.tokensByEntity(ctx, "alice-entity")
+	if err != nil || len(tokens) != 1 || tokens[0].ID != "alice" {
+		t.Fatalf("bad: %#v, %v", tokens, err)
+	}
+	keys, err = view.List(ctx, "bob-entity/")
+	if err != nil || len(keys) != 0 {
+		t.Fatalf("expected the dangling entry to be removed, got %v: %v", keys, err)
+	}
+}
+
 // Create a token, delete the token entry while leaking accessors, invoke tidy
 // and check if the dangling accessor entry is getting removed
 func TestTokenStore_HandleTidyCase1(t *testing.T) {
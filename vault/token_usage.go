@@ -0,0 +1,181 @@
+package vault
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// tokenUsageInterval is the length of a token usage aggregation window.
+	tokenUsageInterval = time.Hour
+
+	// tokenUsageRetainedIntervals is the number of completed aggregation
+	// windows kept in memory, so reports cover at most a day of traffic.
+	tokenUsageRetainedIntervals = 24
+)
+
+// tokenUsageCounts holds request counts attributed to token policies and to
+// the auth mounts that issued the tokens. Counts are updated atomically, so
+// that recording requests doesn't contend on a lock.
+type tokenUsageCounts struct {
+	// Total is first to be 64-bit aligned for atomic operations
+	Total uint64
+	Start time.Time
+
+	// Policies and Mounts map names to *uint64 counters
+	Policies sync.Map
+	Mounts   sync.Map
+}
+
+func newTokenUsageCounts(start time.Time) *tokenUsageCounts {
+	return &tokenUsageCounts{
+		Start: start,
+	}
+}
+
+// incrTokenUsage increments the counter of the name
+func incrTokenUsage(counts *sync.Map, name string) {
+	counter, ok := counts.Load(name)
+	if !ok {
+		counter, _ = counts.LoadOrStore(name, new(uint64))
+	}
+	atomic.AddUint64(counter.(*uint64), 1)
+}
+
+// sumTokenUsage adds the counters to the sums by name
+func sumTokenUsage(sums map[string]uint64, counts *sync.Map) {
+	counts.Range(func(name, counter interface{}) bool {
+		sums[name.(string)] += atomic.LoadUint64(counter.(*uint64))
+		return true
+	})
+}
+
+// tokenUsageTracker aggregates request volume by token policy and auth
+// mount. Counts are kept in memory on the node serving requests and are
+// rolled into a new window every tokenUsageInterval by the metrics loop.
+type tokenUsageTracker struct {
+	// current holds the *tokenUsageCounts of the current window
+	current atomic.Value
+
+	// l guards the rotation of the windows
+	l         sync.Mutex
+	intervals []*tokenUsageCounts
+}
+
+func newTokenUsageTracker() *tokenUsageTracker {
+	t := &tokenUsageTracker{}
+	t.current.Store(newTokenUsageCounts(time.Now()))
+	return t
+}
+
+// window returns the counts of the current window
+func (t *tokenUsageTracker) window() *tokenUsageCounts {
+	return t.current.Load().(*tokenUsageCounts)
+}
+
+// record attributes a single request to the given policies and auth mount.
+// A request recorded while the window is rolled may be counted in the
+// previous window.
+func (t *tokenUsageTracker) record(policies []string, mount string) {
+	counts := t.window()
+
+	atomic.AddUint64(&counts.Total, 1)
+POLICIES:
+	for i, policy := range policies {
+		for _, seen := range policies[:i] {
+			if seen == policy {
+				continue POLICIES
+			}
+		}
+		incrTokenUsage(&counts.Policies, policy)
+	}
+	if mount != "" {
+		incrTokenUsage(&counts.Mounts, mount)
+	}
+}
+
+// aggregate closes the current window if it is older than
+// tokenUsageInterval, dropping windows beyond tokenUsageRetainedIntervals.
+func (t *tokenUsageTracker) aggregate(now time.Time) {
+	t.l.Lock()
+	defer t.l.Unlock()
+
+	current := t.window()
+	if now.Sub(current.Start) < tokenUsageInterval {
+		return
+	}
+
+	t.intervals = append(t.intervals, current)
+	if len(t.intervals) > tokenUsageRetainedIntervals {
+		t.intervals = t.intervals[len(t.intervals)-tokenUsageRetainedIntervals:]
+	}
+	t.current.Store(newTokenUsageCounts(now))
+}
+
+// reset drops all collected counts.
+func (t *tokenUsageTracker) reset() {
+	t.l.Lock()
+	defer t.l.Unlock()
+
+	t.current.Store(newTokenUsageCounts(time.Now()))
+	t.intervals = nil
+}
+
+// tokenUsageEntry is a single line of a token usage report.
+type tokenUsageEntry struct {
+	Name     string
+	Requests uint64
+}
+
+// tokenUsageReport sums the counts of the retained windows and the current
+// one.
+type tokenUsageReport struct {
+	Start    time.Time
+	Total    uint64
+	Policies []tokenUsageEntry
+	Mounts   []tokenUsageEntry
+}
+
+// report returns the usage summed over all retained windows, with policies
+// and mounts sorted by descending request count.
+func (t *tokenUsageTracker) report() *tokenUsageReport {
+	t.l.Lock()
+	defer t.l.Unlock()
+
+	current := t.window()
+	start := current.Start
+	var total uint64
+	policies := make(map[string]uint64)
+	mounts := make(map[string]uint64)
+	for _, counts := range append(t.intervals, current) {
+		if counts.Start.Before(start) {
+			start = counts.Start
+		}
+		total += atomic.LoadUint64(&counts.Total)
+		sumTokenUsage(policies, &counts.Policies)
+		sumTokenUsage(mounts, &counts.Mounts)
+	}
+
+	return &tokenUsageReport{
+		Start:    start,
+		Total:    total,
+		Policies: sortedTokenUsageEntries(policies),
+		Mounts:   sortedTokenUsageEntries(mounts),
+	}
+}
+
+func sortedTokenUsageEntries(counts map[string]uint64) []tokenUsageEntry {
+	entries := make([]tokenUsageEntry, 0, len(counts))
+	for name, n := range counts {
+		entries = append(entries, tokenUsageEntry{Name: name, Requests: n})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Requests != entries[j].Requests {
+			return entries[i].Requests > entries[j].Requests
+		}
+		return entries[i].Name < entries[j].Name
+	})
+	return entries
+}
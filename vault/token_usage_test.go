@@ -0,0 +1,147 @@
+package vault
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/logical"
+)
+
+func TestTokenUsageTracker(t *testing.T) {
+	tracker := newTokenUsageTracker()
+	start := tracker.window().Start
+
+	tracker.record([]string{"default", "ops", "default"}, "auth/userpass/")
+	tracker.record([]string{"default"}, "auth/token/")
+	tracker.record([]string{"ops"}, "auth/userpass/")
+
+	// Not yet due
+	tracker.aggregate(start.Add(time.Minute))
+	if len(tracker.intervals) != 0 {
+		t.Fatalf("expected no completed windows, got %d", len(tracker.intervals))
+	}
+
+	tracker.aggregate(start.Add(tokenUsageInterval))
+	if len(tracker.intervals) != 1 {
+		t.Fatalf("expected 1 completed window, got %d", len(tracker.intervals))
+	}
+	tracker.record([]string{"ops"}, "")
+
+	report := tracker.report()
+	if report.Total != 4 {
+		t.Fatalf("bad: total: %d", report.Total)
+	}
+	if !report.Start.Equal(start) {
+		t.Fatalf("bad: start: %v", report.Start)
+	}
+	expectedPolicies := []tokenUsageEntry{{"ops", 3}, {"default", 2}}
+	if len(report.Policies) != 2 || report.Policies[0] != expectedPolicies[0] || report.Policies[1] != expectedPolicies[1] {
+		t.Fatalf("bad: policies: %#v", report.Policies)
+	}
+	expectedMounts := []tokenUsageEntry{{"auth/userpass/", 2}, {"auth/token/", 1}}
+	if len(report.Mounts) != 2 || report.Mounts[0] != expectedMounts[0] || report.Mounts[1] != expectedMounts[1] {
+		t.Fatalf("bad: mounts: %#v", report.Mounts)
+	}
+
+	// Only the last windows are retained
+	now := start.Add(tokenUsageInterval)
+	for i := 0; i < tokenUsageRetainedIntervals; i++ {
+		now = now.Add(tokenUsageInterval)
+		tracker.aggregate(now)
+	}
+	if len(tracker.intervals) != tokenUsageRetainedIntervals {
+		t.Fatalf("expected %d windows, got %d", tokenUsageRetainedIntervals, len(tracker.intervals))
+	}
+	if report := tracker.report(); report.Total != 1 {
+		t.Fatalf("bad: total after expiry: %d", report.Total)
+	}
+}
+
+func TestTokenUsageTracker_Concurrent(t *testing.T) {
+	tracker := newTokenUsageTracker()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				tracker.record([]string{"default"}, "auth/token/")
+			}
+		}()
+	}
+	wg.Wait()
+
+	report := tracker.report()
+	if report.Total != 800 || len(report.Policies) != 1 || report.Policies[0].Requests != 800 {
+		t.Fatalf("bad: %#v", report)
+	}
+}
+
+func TestSystemBackend_TokenUsage(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+
+	resp, err := c.HandleRequest(ctx, &logical.Request{
+		Operation:   logical.UpdateOperation,
+		Path:        "auth/token/create",
+		ClientToken: root,
+		Data: map[string]interface{}{
+			"policies": "usage-test",
+		},
+	})
+	if err != nil || resp == nil || resp.IsError() {
+		t.Fatalf("bad: resp: %#v, err: %v", resp, err)
+	}
+	token := resp.Auth.ClientToken
+
+	for i := 0; i < 3; i++ {
+		resp, err = c.HandleRequest(ctx, &logical.Request{
+			Operation:   logical.ReadOperation,
+			Path:        "auth/token/lookup-self",
+			ClientToken: token,
+		})
+		if err != nil || resp == nil || resp.IsError() {
+			t.Fatalf("bad: resp: %#v, err: %v", resp, err)
+		}
+	}
+
+	resp, err = c.HandleRequest(ctx, &logical.Request{
+		Operation:   logical.ReadOperation,
+		Path:        "sys/usage/tokens",
+		ClientToken: root,
+	})
+	if err != nil || resp == nil || resp.IsError() {
+		t.Fatalf("bad: resp: %#v, err: %v", resp, err)
+	}
+
+	var found bool
+	for _, entry := range resp.Data["policies"].([]map[string]interface{}) {
+		if entry["policy"] == "usage-test" {
+			found = true
+			if entry["requests"] != uint64(3) {
+				t.Fatalf("bad: requests for policy: %v", entry["requests"])
+			}
+		}
+	}
+	if !found {
+		t.Fatalf("policy not found in report: %#v", resp.Data["policies"])
+	}
+
+	mounts := resp.Data["auth_mounts"].([]map[string]interface{})
+	if len(mounts) != 1 || mounts[0]["mount"] != "auth/token/" {
+		t.Fatalf("bad: auth mounts: %#v", mounts)
+	}
+
+	// The report is only available to root tokens
+	resp, err = c.HandleRequest(ctx, &logical.Request{
+		Operation:   logical.ReadOperation,
+		Path:        "sys/usage/tokens",
+		ClientToken: token,
+	})
+	if err == nil {
+		t.Fatalf("expected permission denied, got: %#v", resp)
+	}
+}
@@ -0,0 +1,290 @@
+package vault
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/hashicorp/vault/logical"
+)
+
+const (
+	// defaultUserLockoutDuration is how long an alias stays locked out if no
+	// duration has been tuned on the mount
+	defaultUserLockoutDuration = 15 * time.Minute
+
+	// defaultUserLockoutCounterReset is the period without failed attempts
+	// after which the failed attempts of an alias are forgotten, if no
+	// period has been tuned on the mount
+	defaultUserLockoutCounterReset = 15 * time.Minute
+
+	// userLockoutCacheSize bounds the number of aliases whose failed login
+	// attempts are tracked per auth mount
+	userLockoutCacheSize = 16384
+
+	// lockedUsersPrefix is the storage prefix of the locked users, relative
+	// to the system barrier view
+	lockedUsersPrefix = "login/locked-users/"
+)
+
+// lockedUser is the persisted record of an alias locked out of an auth mount
+type lockedUser struct {
+	MountAccessor string    `json:"mount_accessor"`
+	Alias         string    `json:"alias"`
+	LockedAt      time.Time `json:"locked_at"`
+}
+
+// failedLogins counts the failed login attempts of an alias
+type failedLogins struct {
+	count       int
+	lastAttempt time.Time
+}
+
+// userLockout locks aliases out of an auth mount after repeated failed
+// logins. Failed attempts are only tracked in memory, while the locked
+// aliases are persisted so that a lockout survives a restart or failover.
+type userLockout struct {
+	l sync.Mutex
+
+	// failures holds the failed attempts of the aliases by mount accessor
+	failures map[string]*lru.Cache
+
+	// locked holds the locked aliases by mount accessor
+	locked map[string]map[string]*lockedUser
+}
+
+func newUserLockout() *userLockout {
+	return &userLockout{
+		failures: make(map[string]*lru.Cache),
+		locked:   make(map[string]map[string]*lockedUser),
+	}
+}
+
+// userLockoutSettings returns the lockout settings of the auth mount, with
+// the defaults applied. A zero threshold means lockout is disabled.
+func userLockoutSettings(entry *MountEntry) (threshold int, duration, counterReset time.Duration) {
+	threshold = entry.Config.UserLockoutThreshold
+	duration = entry.Config.UserLockoutDuration
+	if duration <= 0 {
+		duration = defaultUserLockoutDuration
+	}
+	counterReset = entry.Config.UserLockoutCounterReset
+	if counterReset <= 0 {
+		counterReset = defaultUserLockoutCounterReset
+	}
+	return threshold, duration, counterReset
+}
+
+// lockedUserPath returns the storage path of a locked alias. Aliases are
+// encoded since they can hold any character, such as the slashes of a DN.
+func lockedUserPath(accessor, alias string) string {
+	return lockedUsersPrefix + accessor + "/" + base64.RawURLEncoding.EncodeToString([]byte(alias))
+}
+
+// loginAliasLookahead asks the auth mount for the alias a login request
+// would authenticate, without checking its credentials. An empty alias is
+// returned if the auth method doesn't support the lookahead.
+func (c *Core) loginAliasLookahead(ctx context.Context, req *logical.Request) string {
+	resp, err := c.router.Route(ctx, &logical.Request{
+		MountAccessor: req.MountAccessor,
+		Path:          req.Path,
+		Operation:     logical.AliasLookaheadOperation,
+		Data:          req.Data,
+		Connection:    req.Connection,
+		Headers:       req.Headers,
+	})
+	if err != nil || resp == nil || resp.Auth == nil || resp.Auth.Alias == nil {
+		return ""
+	}
+	return resp.Auth.Alias.Name
+}
+
+// isUserLocked reports whether the alias is locked out of the auth mount. A
+// lockout which has expired is lifted.
+func (c *Core) isUserLocked(ctx context.Context, entry *MountEntry, alias string) (bool, error) {
+	_, duration, _ := userLockoutSettings(entry)
+
+	u := c.userLockout
+	u.l.Lock()
+	defer u.l.Unlock()
+
+	locked := u.locked[entry.Accessor][alias]
+	if locked == nil {
+		return false, nil
+	}
+	if time.Now().Before(locked.LockedAt.Add(duration)) {
+		return true, nil
+	}
+
+	if err := c.systemBarrierView.Delete(ctx, lockedUserPath(entry.Accessor, alias)); err != nil {
+		return false, errwrap.Wrapf("failed to lift expired lockout: {{err}}", err)
+	}
+	delete(u.locked[entry.Accessor], alias)
+	return false, nil
+}
+
+// recordLoginAttempt updates the failed attempts of the alias after a login
+// attempt. A successful login forgets them, while a failed one locks the
+// alias out once the threshold of the mount is reached.
+func (c *Core) recordLoginAttempt(ctx context.Context, entry *MountEntry, alias string, failed bool) error {
+	threshold, _, counterReset := userLockoutSettings(entry)
+
+	u := c.userLockout
+	u.l.Lock()
+	defer u.l.Unlock()
+
+	failures, ok := u.failures[entry.Accessor]
+	if !ok {
+		if !failed {
+			return nil
+		}
+		var err error
+		failures, err = lru.New(userLockoutCacheSize)
+		if err != nil {
+			return err
+		}
+		u.failures[entry.Accessor] = failures
+	}
+
+	if !failed {
+		failures.Remove(alias)
+		return nil
+	}
+
+	now := time.Now()
+	attempts := &failedLogins{}
+	if raw, ok := failures.Get(alias); ok && now.Sub(raw.(*failedLogins).lastAttempt) < counterReset {
+		attempts = raw.(*failedLogins)
+	}
+	attempts.count++
+	attempts.lastAttempt = now
+
+	if attempts.count < threshold {
+		failures.Add(alias, attempts)
+		return nil
+	}
+
+	locked := &lockedUser{
+		MountAccessor: entry.Accessor,
+		Alias:         alias,
+		LockedAt:      now,
+	}
+	storageEntry, err := logical.StorageEntryJSON(lockedUserPath(entry.Accessor, alias), locked)
+	if err != nil {
+		return err
+	}
+	if err := c.systemBarrierView.Put(ctx, storageEntry); err != nil {
+		return errwrap.Wrapf("failed to persist locked user: {{err}}", err)
+	}
+	failures.Remove(alias)
+	if u.locked[entry.Accessor] == nil {
+		u.locked[entry.Accessor] = make(map[string]*lockedUser)
+	}
+	u.locked[entry.Accessor][alias] = locked
+
+	c.logger.Warn("user locked out after repeated failed logins", "mount_path", entry.Path, "mount_accessor", entry.Accessor, "failed_attempts", attempts.count)
+	return nil
+}
+
+// unlockUser lifts the lockout of the alias and forgets its failed attempts.
+// Unlocking an alias which isn't locked is not an error.
+func (c *Core) unlockUser(ctx context.Context, accessor, alias string) error {
+	u := c.userLockout
+	u.l.Lock()
+	defer u.l.Unlock()
+
+	if err := c.systemBarrierView.Delete(ctx, lockedUserPath(accessor, alias)); err != nil {
+		return errwrap.Wrapf("failed to unlock user: {{err}}", err)
+	}
+	delete(u.locked[accessor], alias)
+	if failures, ok := u.failures[accessor]; ok {
+		failures.Remove(alias)
+	}
+	return nil
+}
+
+// lockedUsers returns the aliases currently locked out of the auth mount
+// with the given accessor, sorted by alias
+func (c *Core) lockedUsers(accessor string) []*lockedUser {
+	u := c.userLockout
+	u.l.Lock()
+	defer u.l.Unlock()
+
+	users := make([]*lockedUser, 0, len(u.locked[accessor]))
+	for _, locked := range u.locked[accessor] {
+		users = append(users, locked)
+	}
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].Alias < users[j].Alias
+	})
+	return users
+}
+
+// lockedAccessors returns the accessors of the auth mounts with locked
+// aliases
+func (c *Core) lockedAccessors() []string {
+	u := c.userLockout
+	u.l.Lock()
+	defer u.l.Unlock()
+
+	accessors := make([]string, 0, len(u.locked))
+	for accessor, users := range u.locked {
+		if len(users) > 0 {
+			accessors = append(accessors, accessor)
+		}
+	}
+	sort.Strings(accessors)
+	return accessors
+}
+
+// removeUserLockouts drops the failed attempts and locked aliases of the auth
+// mount with the given accessor, once it has been disabled
+func (c *Core) removeUserLockouts(ctx context.Context, accessor string) error {
+	u := c.userLockout
+	u.l.Lock()
+	defer u.l.Unlock()
+
+	delete(u.failures, accessor)
+	delete(u.locked, accessor)
+	return logical.ClearView(ctx, c.systemBarrierView.SubView(lockedUsersPrefix+accessor+"/"))
+}
+
+// loadLockedUsers loads the locked aliases from storage. The failed attempts
+// are not persisted and start over.
+func (c *Core) loadLockedUsers(ctx context.Context) error {
+	lockedByAccessor := make(map[string]map[string]*lockedUser)
+
+	accessors, err := c.systemBarrierView.List(ctx, lockedUsersPrefix)
+	if err != nil {
+		return errwrap.Wrapf("failed to list locked users: {{err}}", err)
+	}
+	for _, accessor := range accessors {
+		keys, err := c.systemBarrierView.List(ctx, lockedUsersPrefix+accessor)
+		if err != nil {
+			return errwrap.Wrapf("failed to list locked users: {{err}}", err)
+		}
+		for _, key := range keys {
+			var locked lockedUser
+			if err := loadJSONEntry(ctx, c.systemBarrierView, lockedUsersPrefix+accessor+key, &locked); err != nil {
+				return errwrap.Wrapf(fmt.Sprintf("failed to load locked user %q: {{err}}", key), err)
+			}
+			if lockedByAccessor[locked.MountAccessor] == nil {
+				lockedByAccessor[locked.MountAccessor] = make(map[string]*lockedUser)
+			}
+			lockedByAccessor[locked.MountAccessor][locked.Alias] = &locked
+		}
+	}
+
+	u := c.userLockout
+	u.l.Lock()
+	defer u.l.Unlock()
+
+	u.failures = make(map[string]*lru.Cache)
+	u.locked = lockedByAccessor
+	return nil
+}
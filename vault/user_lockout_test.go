@@ -0,0 +1,71 @@
+package vault
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+)
+
+func TestUserLockout_Lifecycle(t *testing.T) {
+	core, _, _ := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+	entry := &MountEntry{
+		Path:     "userpass/",
+		Accessor: "auth_userpass_1234",
+		Config: MountConfig{
+			UserLockoutThreshold:    2,
+			UserLockoutCounterReset: time.Hour,
+		},
+	}
+
+	locked := func() bool {
+		locked, err := core.isUserLocked(ctx, entry, "test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return locked
+	}
+
+	// A successful login forgets the failed attempts
+	for _, failed := range []bool{true, false, true} {
+		if err := core.recordLoginAttempt(ctx, entry, "test", failed); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if locked() {
+		t.Fatal("expected the user not to be locked")
+	}
+
+	if err := core.recordLoginAttempt(ctx, entry, "test", true); err != nil {
+		t.Fatal(err)
+	}
+	if !locked() {
+		t.Fatal("expected the user to be locked")
+	}
+
+	// The lockout survives a restart
+	if err := core.loadLockedUsers(ctx); err != nil {
+		t.Fatal(err)
+	}
+	users := core.lockedUsers(entry.Accessor)
+	if len(users) != 1 || users[0].Alias != "test" {
+		t.Fatalf("bad: %#v", users)
+	}
+
+	// The lockout is lifted once its duration elapsed
+	entry.Config.UserLockoutDuration = time.Nanosecond
+	if locked() {
+		t.Fatal("expected the lockout to expire")
+	}
+	if users := core.lockedUsers(entry.Accessor); len(users) != 0 {
+		t.Fatalf("bad: %#v", users)
+	}
+	keys, err := core.systemBarrierView.List(ctx, lockedUsersPrefix+entry.Accessor+"/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 0 {
+		t.Fatalf("expected the lockout to be removed from storage: %v", keys)
+	}
+}
@@ -0,0 +1,186 @@
+package vault
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	radix "github.com/armon/go-radix"
+	"github.com/hashicorp/vault/helper/consts"
+	"github.com/hashicorp/vault/logical"
+)
+
+// wellKnownRedirect is the claim of a mount on a path below /.well-known/
+type wellKnownRedirect struct {
+	mountEntry  *MountEntry
+	destination string
+}
+
+// apiPath returns the API path, without the /v1/ prefix, that a request to
+// the claimed path followed by remaining is redirected to
+func (w *wellKnownRedirect) apiPath(remaining string) string {
+	dest := w.destination
+	if remaining != "" {
+		dest = strings.TrimSuffix(dest, "/") + "/" + remaining
+	}
+	return w.mountEntry.APIPath() + dest
+}
+
+// wellKnownRedirects is the registry of the paths below /.well-known/
+// claimed by mounts. It belongs to the router so that it follows the
+// lifetime of the mounts.
+type wellKnownRedirects struct {
+	l     sync.RWMutex
+	paths *radix.Tree
+}
+
+func newWellKnownRedirects() *wellKnownRedirects {
+	return &wellKnownRedirects{
+		paths: radix.New(),
+	}
+}
+
+// tryRegister claims the path for the mount, redirecting it to the given
+// destination within the mount. A path claimed by another mount, or which
+// is nested in or contains such a path, can't be claimed.
+func (w *wellKnownRedirects) tryRegister(entry *MountEntry, src, dest string) error {
+	src = strings.Trim(src, "/")
+	if src == "" {
+		return fmt.Errorf("invalid well-known path %q", src)
+	}
+
+	w.l.Lock()
+	defer w.l.Unlock()
+
+	var conflict *wellKnownRedirect
+	conflictPath := ""
+	check := func(path string, raw interface{}) bool {
+		redirect := raw.(*wellKnownRedirect)
+		if redirect.mountEntry.UUID == entry.UUID {
+			return false
+		}
+		if !pathSegmentPrefix(path, src) && !pathSegmentPrefix(src, path) {
+			return false
+		}
+		conflict = redirect
+		conflictPath = path
+		return true
+	}
+	w.paths.WalkPath(src, check)
+	if conflict == nil {
+		w.paths.WalkPrefix(src, check)
+	}
+	if conflict != nil {
+		return fmt.Errorf("well-known path %q conflicts with %q claimed by the mount at %q", src, conflictPath, conflict.mountEntry.APIPath())
+	}
+
+	w.paths.Insert(src, &wellKnownRedirect{
+		mountEntry:  entry,
+		destination: strings.TrimPrefix(dest, "/"),
+	})
+	return nil
+}
+
+// deregisterMount releases the paths claimed by the mount with the given
+// UUID
+func (w *wellKnownRedirects) deregisterMount(uuid string) {
+	w.l.Lock()
+	defer w.l.Unlock()
+
+	var paths []string
+	w.paths.Walk(func(path string, raw interface{}) bool {
+		if raw.(*wellKnownRedirect).mountEntry.UUID == uuid {
+			paths = append(paths, path)
+		}
+		return false
+	})
+	for _, path := range paths {
+		w.paths.Delete(path)
+	}
+}
+
+// find returns the claim of the given path below /.well-known/, and the
+// remainder of the path below the claimed one
+func (w *wellKnownRedirects) find(path string) (*wellKnownRedirect, string) {
+	path = strings.TrimPrefix(path, "/")
+
+	w.l.RLock()
+	defer w.l.RUnlock()
+
+	var ret *wellKnownRedirect
+	remaining := ""
+	w.paths.WalkPath(path, func(claimed string, raw interface{}) bool {
+		if pathSegmentPrefix(claimed, path) {
+			ret = raw.(*wellKnownRedirect)
+			remaining = strings.TrimPrefix(strings.TrimPrefix(path, claimed), "/")
+		}
+		return false
+	})
+
+	return ret, remaining
+}
+
+// list returns the claimed paths and their claims
+func (w *wellKnownRedirects) list() map[string]*wellKnownRedirect {
+	w.l.RLock()
+	defer w.l.RUnlock()
+
+	ret := make(map[string]*wellKnownRedirect, w.paths.Len())
+	w.paths.Walk(func(path string, raw interface{}) bool {
+		ret[path] = raw.(*wellKnownRedirect)
+		return false
+	})
+	return ret
+}
+
+// pathSegmentPrefix returns true if prefix is path or one of its parents
+func pathSegmentPrefix(prefix, path string) bool {
+	return path == prefix || strings.HasPrefix(path, prefix+"/")
+}
+
+// claimWellKnownPaths registers the paths below /.well-known/ claimed by the
+// backend of the mount. Claims conflicting with those of other mounts are
+// skipped, leaving the existing claims in place.
+func (c *Core) claimWellKnownPaths(entry *MountEntry, backend logical.Backend) {
+	if backend == nil {
+		return
+	}
+	paths := backend.SpecialPaths()
+	if paths == nil || len(paths.WellKnown) == 0 {
+		return
+	}
+
+	srcs := make([]string, 0, len(paths.WellKnown))
+	for src := range paths.WellKnown {
+		srcs = append(srcs, src)
+	}
+	sort.Strings(srcs)
+
+	for _, src := range srcs {
+		if err := c.router.wellKnown.tryRegister(entry, src, paths.WellKnown[src]); err != nil {
+			c.logger.Warn("skipping claim of well-known path", "path", entry.APIPath(), "error", err)
+		}
+	}
+}
+
+// WellKnownRedirect returns the API path, without the /v1/ prefix, that a
+// request to the given path below /.well-known/ is redirected to, or an
+// empty string if no mount claims the path.
+func (c *Core) WellKnownRedirect(path string) (string, error) {
+	c.stateLock.RLock()
+	defer c.stateLock.RUnlock()
+	if c.Sealed() {
+		return "", consts.ErrSealed
+	}
+	if c.standby && !c.perfStandby {
+		return "", consts.ErrStandby
+	}
+
+	redirect, remaining := c.router.wellKnown.find(path)
+	if redirect == nil {
+		return "", nil
+	}
+
+	return redirect.apiPath(remaining), nil
+}
@@ -0,0 +1,127 @@
+package vault
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/logical"
+)
+
+func TestWellKnownRedirects(t *testing.T) {
+	pki := &MountEntry{UUID: "pki-uuid", Table: mountTableType, Path: "pki/", namespace: namespace.RootNamespace}
+	oidc := &MountEntry{UUID: "oidc-uuid", Table: credentialTableType, Path: "oidc/", namespace: namespace.RootNamespace}
+
+	w := newWellKnownRedirects()
+	if err := w.tryRegister(pki, "est", "est"); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.tryRegister(pki, "/acme/", "acme/directory"); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.tryRegister(oidc, "openid-configuration", "/.well-known/openid-configuration"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Paths claimed by, nested in or containing those of another mount
+	// conflict
+	for _, src := range []string{"est", "est/", "est/simpleenroll", "acme"} {
+		if err := w.tryRegister(oidc, src, "x"); err == nil {
+			t.Fatalf("expected a conflict claiming %q", src)
+		}
+	}
+	// Paths sharing a prefix don't
+	if err := w.tryRegister(oidc, "estimate", "x"); err != nil {
+		t.Fatal(err)
+	}
+
+	for path, expected := range map[string]string{
+		"est":                  "pki/est",
+		"est/simpleenroll":     "pki/est/simpleenroll",
+		"acme":                 "pki/acme/directory",
+		"openid-configuration": "auth/oidc/.well-known/openid-configuration",
+		"estimate":             "auth/oidc/x",
+		"esta":                 "",
+		"unclaimed":            "",
+	} {
+		actual := ""
+		if redirect, remaining := w.find(path); redirect != nil {
+			actual = redirect.apiPath(remaining)
+		}
+		if actual != expected {
+			t.Fatalf("bad redirect of %q: expected %q, got %q", path, expected, actual)
+		}
+	}
+
+	w.deregisterMount(pki.UUID)
+	if redirect, _ := w.find("est"); redirect != nil {
+		t.Fatal("expected the claims of the mount to be released")
+	}
+	if err := w.tryRegister(oidc, "est", "x"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCore_WellKnownClaims(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	c.logicalBackends["noop"] = func(context.Context, *logical.BackendConfig) (logical.Backend, error) {
+		return &NoopBackend{
+			WellKnown: map[string]string{"est": "est"},
+		}, nil
+	}
+
+	for _, path := range []string{"first", "second"} {
+		req := logical.TestRequest(t, logical.UpdateOperation, "sys/mounts/"+path)
+		req.Data["type"] = "noop"
+		req.ClientToken = root
+		if _, err := c.HandleRequest(namespace.RootContext(nil), req); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The first mount keeps its claim
+	dest, err := c.WellKnownRedirect("est/cacerts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dest != "first/est/cacerts" {
+		t.Fatalf("bad: %q", dest)
+	}
+
+	req := logical.TestRequest(t, logical.ListOperation, "sys/well-known")
+	req.ClientToken = root
+	resp, err := c.HandleRequest(namespace.RootContext(nil), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keys := resp.Data["keys"]; !reflect.DeepEqual(keys, []string{"est"}) {
+		t.Fatalf("bad keys: %v", keys)
+	}
+	info := resp.Data["key_info"].(map[string]interface{})["est"].(map[string]interface{})
+	if info["mount_path"] != "first/" || info["mount_type"] != "noop" || info["destination"] != "est" {
+		t.Fatalf("bad key info: %#v", info)
+	}
+
+	// Unmounting releases the claim
+	if err := c.unmount(namespace.RootContext(nil), "first"); err != nil {
+		t.Fatal(err)
+	}
+	dest, err = c.WellKnownRedirect("est")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dest != "" {
+		t.Fatalf("expected no redirect, got %q", dest)
+	}
+
+	req = logical.TestRequest(t, logical.ReadOperation, "sys/well-known/est")
+	req.ClientToken = root
+	resp, err = c.HandleRequest(namespace.RootContext(nil), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp != nil {
+		t.Fatalf("expected no claim, got %#v", resp)
+	}
+}